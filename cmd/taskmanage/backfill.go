@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"taskmanage/internal/config"
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository/mysql"
+	"taskmanage/internal/service"
+	"taskmanage/pkg/logger"
+)
+
+// backfillHistoryChain 为升级前写入的流程执行历史补齐哈希链
+// 已写入哈希的记录保持不变，重复执行是安全的
+func backfillHistoryChain(ctx context.Context) error {
+	db := database.GetDB()
+	if db == nil {
+		return fmt.Errorf("无法获取数据库连接")
+	}
+
+	report, err := mysql.NewWorkflowInstanceRepository(db).BackfillExecutionHistoryChain(ctx)
+	if err != nil {
+		return err
+	}
+	logger.Infof("流程执行历史哈希链回填完成: 实例%d个, 记录%d条", report.Instances, report.Sealed)
+	return nil
+}
+
+// checkEmployeeNumbers 报告已有员工中重复或不符合employee_number配置格式的编号，只检查不修改
+// 存在重复编号时返回错误；格式不符的编号（如导入的历史编号）只记录警告
+func checkEmployeeNumbers(ctx context.Context, cfg *config.Config) error {
+	db := database.GetDB()
+	if db == nil {
+		return fmt.Errorf("无法获取数据库连接")
+	}
+
+	repos := mysql.NewRepositoryManager(db)
+	generator := service.NewEmployeeNumberGenerator(repos, cfg.EmployeeNumber)
+	report, err := service.CheckEmployeeNos(ctx, repos.EmployeeRepository(), generator)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range report.Mismatched {
+		logger.Warnf("员工编号不符合配置格式: employee_id=%d, employee_no=%s", item.EmployeeID, item.EmployeeNo)
+	}
+	duplicates := make([]string, 0, len(report.Duplicates))
+	for employeeNo := range report.Duplicates {
+		duplicates = append(duplicates, employeeNo)
+	}
+	sort.Strings(duplicates)
+	for _, employeeNo := range duplicates {
+		logger.Errorf("员工编号重复: employee_no=%s, employee_ids=%v", employeeNo, report.Duplicates[employeeNo])
+	}
+	logger.Infof("员工编号检查完成: 员工%d个, 重复编号%d个, 格式不符%d个", report.Total, len(report.Duplicates), len(report.Mismatched))
+	if len(report.Duplicates) > 0 {
+		return fmt.Errorf("存在%d个重复的员工编号", len(report.Duplicates))
+	}
+	return nil
+}
@@ -0,0 +1,334 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"taskmanage/internal/config"
+	"taskmanage/internal/container"
+	"taskmanage/internal/jobs"
+	"taskmanage/internal/service"
+	"taskmanage/pkg/logger"
+)
+
+// defaultWorkflowSweepInterval 过期工作流实例扫描的默认间隔
+const defaultWorkflowSweepInterval = 5 * time.Minute
+
+// skillExpiryNotifyInterval 技能认证到期提醒的扫描间隔
+const skillExpiryNotifyInterval = 24 * time.Hour
+
+// probationReminderInterval 试用期到期提醒的扫描间隔
+const probationReminderInterval = 24 * time.Hour
+
+// workloadReconcileInterval 员工任务数校正的间隔
+const workloadReconcileInterval = time.Hour
+
+// leaveStatusSyncInterval 请假状态同步的间隔
+const leaveStatusSyncInterval = time.Hour
+
+// tenurePermissionInterval 司龄权限规则的扫描间隔
+const tenurePermissionInterval = 24 * time.Hour
+
+// registerJobs 注册后台定时任务
+func registerJobs(scheduler *jobs.Scheduler, appContainer *container.ApplicationContainer, cfg *config.Config) error {
+	sweepInterval := time.Duration(cfg.Workflow.SLASweepIntervalSeconds) * time.Second
+	if sweepInterval <= 0 {
+		sweepInterval = defaultWorkflowSweepInterval
+	}
+	outboxInterval := time.Duration(cfg.Email.OutboxIntervalSeconds) * time.Second
+	if outboxInterval <= 0 {
+		outboxInterval = service.DefaultEmailOutboxInterval
+	}
+	webhookInterval := time.Duration(cfg.Webhook.DeliveryIntervalSeconds) * time.Second
+	if webhookInterval <= 0 {
+		webhookInterval = service.DefaultWebhookDeliveryInterval
+	}
+	withinDays := cfg.Onboarding.ProbationReminderDays
+	archiveInterval := time.Duration(cfg.Archive.IntervalSeconds) * time.Second
+	if archiveInterval <= 0 {
+		archiveInterval = service.DefaultArchiveInterval
+	}
+	escalationInterval := time.Duration(cfg.Task.EscalationIntervalSeconds) * time.Second
+	if escalationInterval <= 0 {
+		escalationInterval = service.DefaultTaskEscalationInterval
+	}
+	dueReminderInterval := time.Duration(cfg.Task.DueReminderIntervalSeconds) * time.Second
+	if dueReminderInterval <= 0 {
+		dueReminderInterval = service.DefaultTaskDueReminderInterval
+	}
+	notificationCleanupInterval := time.Duration(cfg.Notification.CleanupIntervalSeconds) * time.Second
+	if notificationCleanupInterval <= 0 {
+		notificationCleanupInterval = service.DefaultNotificationCleanupInterval
+	}
+
+	jobList := []jobs.Job{
+		{
+			// 终止超过SLA或节点超时的工作流实例
+			Name:       "workflow_expiry_sweep",
+			Schedule:   jobs.Every(sweepInterval),
+			RunOnStart: true,
+			Run: func(ctx context.Context, now time.Time) error {
+				return expireWorkflowInstances(ctx, appContainer, now)
+			},
+		},
+		{
+			// 每天提醒认证即将到期的员工技能
+			Name:       "skill_expiry_notify",
+			Schedule:   jobs.Every(skillExpiryNotifyInterval),
+			RunOnStart: true,
+			Run: func(ctx context.Context, now time.Time) error {
+				return notifyExpiringSkills(ctx, appContainer, now)
+			},
+		},
+		{
+			// 每天提醒HR和直接上级为试用期即将结束的员工办理转正
+			Name:       "probation_reminder",
+			Schedule:   jobs.Every(probationReminderInterval),
+			RunOnStart: true,
+			Run: func(ctx context.Context, now time.Time) error {
+				return remindProbationEnding(ctx, appContainer, now, withinDays)
+			},
+		},
+		{
+			// 按任务表校正员工当前任务数，修正各分配路径遗漏造成的计数偏差
+			Name:       "workload_reconcile",
+			Schedule:   jobs.Every(workloadReconcileInterval),
+			RunOnStart: true,
+			Run: func(ctx context.Context, now time.Time) error {
+				return reconcileWorkload(ctx, appContainer)
+			},
+		},
+		{
+			// 请假开始时将员工状态置为on_leave，结束后恢复原状态
+			Name:       "leave_status_sync",
+			Schedule:   jobs.Every(leaveStatusSyncInterval),
+			RunOnStart: true,
+			Run: func(ctx context.Context, now time.Time) error {
+				return syncLeaveStatus(ctx, appContainer, now)
+			},
+		},
+		{
+			// 每天为入职或转正满规则天数的员工执行司龄权限规则
+			Name:       "permission_tenure_rules",
+			Schedule:   jobs.Every(tenurePermissionInterval),
+			RunOnStart: true,
+			Run: func(ctx context.Context, now time.Time) error {
+				return applyTenurePermissions(ctx, appContainer, now)
+			},
+		},
+		{
+			// 待分配任务超过升级规则阈值后调整优先级并通知相关人员
+			Name:     "task_priority_aging",
+			Schedule: jobs.Every(escalationInterval),
+			Run: func(ctx context.Context, now time.Time) error {
+				return escalateAgingTasks(ctx, appContainer, now)
+			},
+		},
+		{
+			// 按优先级的提前量提醒任务负责人即将到期，到期后发送逾期提醒
+			Name:     "task_due_reminder",
+			Schedule: jobs.Every(dueReminderInterval),
+			Run: func(ctx context.Context, now time.Time) error {
+				return remindDueTasks(ctx, appContainer, now)
+			},
+		},
+		{
+			// 软删除超过保留期的已读站内通知
+			Name:     "notification_read_cleanup",
+			Schedule: jobs.Every(notificationCleanupInterval),
+			Run: func(ctx context.Context, now time.Time) error {
+				return cleanupReadNotifications(ctx, appContainer, now)
+			},
+		},
+		{
+			// 投递发件箱中到期的通知邮件，失败的邮件按退避时间重试
+			Name:     "email_outbox",
+			Schedule: jobs.Every(outboxInterval),
+			Run: func(ctx context.Context, now time.Time) error {
+				return sendOutboxEmails(ctx, appContainer, now)
+			},
+		},
+		{
+			// 签名投递Webhook发件箱中到期的事件，失败的事件按退避时间重试
+			Name:     "webhook_delivery",
+			Schedule: jobs.Every(webhookInterval),
+			Run: func(ctx context.Context, now time.Time) error {
+				return deliverWebhooks(ctx, appContainer, now)
+			},
+		},
+	}
+	if cfg.Archive.Enabled {
+		jobList = append(jobList, jobs.Job{
+			// 将超过保留期的已结束任务和流程实例分批移入归档表
+			Name:     "data_archive",
+			Schedule: jobs.Every(archiveInterval),
+			Run: func(ctx context.Context, now time.Time) error {
+				return archiveFinished(ctx, appContainer, now)
+			},
+		})
+	}
+
+	for _, job := range jobList {
+		if err := scheduler.Register(job); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expireWorkflowInstances 将超过SLA或节点超时的工作流实例标记为过期并记录汇总
+func expireWorkflowInstances(ctx context.Context, appContainer *container.ApplicationContainer, now time.Time) error {
+	report, err := appContainer.GetServiceManager().WorkflowService().ExpireOverdueInstances(ctx, now)
+	if err != nil {
+		return fmt.Errorf("扫描过期工作流实例失败: %w", err)
+	}
+	if report.Scanned == 0 {
+		return nil
+	}
+
+	logger.Infof("过期工作流实例扫描完成: 超时实例%d个, 已过期%d个, 失败%d个",
+		report.Scanned, len(report.Expired), len(report.Failed))
+	for _, item := range report.Expired {
+		logger.Infof("流程实例已过期: 实例=%s, 业务类型=%s, 业务ID=%s, 原因=%s", item.InstanceID, item.BusinessType, item.BusinessID, item.Reason)
+	}
+	for _, item := range report.Failed {
+		logger.Warnf("标记流程实例过期失败: 实例=%s, 原因=%s", item.InstanceID, item.Error)
+	}
+	return nil
+}
+
+// notifyExpiringSkills 发送技能认证到期提醒并记录数量
+func notifyExpiringSkills(ctx context.Context, appContainer *container.ApplicationContainer, now time.Time) error {
+	notified, err := appContainer.GetSkillService().NotifyExpiringSkills(ctx, now)
+	if err != nil {
+		return fmt.Errorf("发送技能认证到期提醒失败: %w", err)
+	}
+	if notified > 0 {
+		logger.Infof("已发送技能认证到期提醒%d条", notified)
+	}
+	return nil
+}
+
+// remindProbationEnding 创建转正评估提醒并记录人数
+func remindProbationEnding(ctx context.Context, appContainer *container.ApplicationContainer, now time.Time, withinDays int) error {
+	reminded, err := appContainer.GetServiceManager().OnboardingService().RemindProbationEnding(ctx, now, withinDays)
+	if err != nil {
+		return fmt.Errorf("发送试用期到期提醒失败: %w", err)
+	}
+	if reminded > 0 {
+		logger.Infof("已为%d名试用期即将结束的员工创建转正评估提醒", reminded)
+	}
+	return nil
+}
+
+// reconcileWorkload 校正员工当前任务数并记录汇总，每条修正由服务层记录
+func reconcileWorkload(ctx context.Context, appContainer *container.ApplicationContainer) error {
+	report, err := appContainer.GetServiceManager().EmployeeService().ReconcileWorkload(ctx)
+	if err != nil {
+		return fmt.Errorf("校正员工任务数失败: %w", err)
+	}
+	if len(report.Corrected) > 0 || len(report.Failed) > 0 {
+		logger.Infof("员工任务数校正完成: 检查%d人, 修正%d人, 跳过%d人, 失败%d人",
+			report.Checked, len(report.Corrected), len(report.Skipped), len(report.Failed))
+	}
+	return nil
+}
+
+// syncLeaveStatus 同步请假员工状态并记录人数
+func syncLeaveStatus(ctx context.Context, appContainer *container.ApplicationContainer, now time.Time) error {
+	report, err := appContainer.GetServiceManager().EmployeeCapacityService().SyncLeaveStatus(ctx, now)
+	if err != nil {
+		return fmt.Errorf("同步请假员工状态失败: %w", err)
+	}
+	if len(report.Applied) > 0 || len(report.Restored) > 0 || report.Failed > 0 {
+		logger.Infof("请假员工状态同步完成: 开始请假%d人, 结束请假%d人, 失败%d条", len(report.Applied), len(report.Restored), report.Failed)
+	}
+	return nil
+}
+
+// applyTenurePermissions 执行司龄权限规则并记录汇总
+func applyTenurePermissions(ctx context.Context, appContainer *container.ApplicationContainer, now time.Time) error {
+	report, err := appContainer.GetServiceManager().PermissionAssignmentService().ProcessTenureRules(ctx, now)
+	if report != nil && (report.Granted > 0 || report.Pending > 0 || report.Revoked > 0 || report.Failed > 0) {
+		logger.Infof("司龄权限规则执行完成: 检查%d人, 分配%d个, 待审批%d个, 撤销%d个, 无变化%d个, 失败%d个",
+			report.Checked, report.Granted, report.Pending, report.Revoked, report.Unchanged, report.Failed)
+	}
+	if err != nil {
+		return fmt.Errorf("执行司龄权限规则失败: %w", err)
+	}
+	return nil
+}
+
+// sendOutboxEmails 投递到期的待发送邮件
+func sendOutboxEmails(ctx context.Context, appContainer *container.ApplicationContainer, now time.Time) error {
+	sent, err := appContainer.GetServiceManager().EmailNotifier().ProcessOutbox(ctx, now)
+	if err != nil {
+		return fmt.Errorf("投递通知邮件失败: %w", err)
+	}
+	if sent > 0 {
+		logger.Infof("已投递通知邮件%d封", sent)
+	}
+	return nil
+}
+
+// deliverWebhooks 投递到期的Webhook事件并记录投递成功数
+func deliverWebhooks(ctx context.Context, appContainer *container.ApplicationContainer, now time.Time) error {
+	delivered, err := appContainer.GetServiceManager().WebhookService().ProcessOutbox(ctx, now)
+	if err != nil {
+		return fmt.Errorf("投递Webhook事件失败: %w", err)
+	}
+	if delivered > 0 {
+		logger.Infof("已投递Webhook事件%d个", delivered)
+	}
+	return nil
+}
+
+// archiveFinished 归档超过保留期的已结束任务和流程实例并记录数量
+func archiveFinished(ctx context.Context, appContainer *container.ApplicationContainer, now time.Time) error {
+	report, err := appContainer.GetServiceManager().ArchiveService().ArchiveFinished(ctx, now)
+	if report != nil && (report.TasksArchived > 0 || report.WorkflowInstancesArchived > 0) {
+		logger.Infof("数据归档完成: 任务%d个, 流程实例%d个, 共%d批",
+			report.TasksArchived, report.WorkflowInstancesArchived, report.Batches)
+	}
+	if err != nil {
+		return fmt.Errorf("归档已结束数据失败: %w", err)
+	}
+	return nil
+}
+
+// escalateAgingTasks 按优先级老化规则升级超时的待分配任务并记录数量
+func escalateAgingTasks(ctx context.Context, appContainer *container.ApplicationContainer, now time.Time) error {
+	report, err := appContainer.GetServiceManager().TaskEscalationService().RunEscalations(ctx, now, false)
+	if report != nil && (len(report.Escalated) > 0 || report.Failed > 0) {
+		logger.Infof("任务优先级老化完成: 升级%d个, 失败%d个", len(report.Escalated), report.Failed)
+	}
+	if err != nil {
+		return fmt.Errorf("执行任务优先级老化失败: %w", err)
+	}
+	return nil
+}
+
+// remindDueTasks 发送任务截止提醒和逾期提醒并记录数量
+func remindDueTasks(ctx context.Context, appContainer *container.ApplicationContainer, now time.Time) error {
+	report, err := appContainer.GetServiceManager().TaskDueReminderService().RunReminders(ctx, now)
+	if report != nil && (report.DueSoon > 0 || report.Overdue > 0 || report.Failed > 0) {
+		logger.Infof("任务截止提醒完成: 即将到期%d个, 已逾期%d个, 失败%d个", report.DueSoon, report.Overdue, report.Failed)
+	}
+	if err != nil {
+		return fmt.Errorf("发送任务截止提醒失败: %w", err)
+	}
+	return nil
+}
+
+// cleanupReadNotifications 清理超过保留期的已读通知并记录数量
+func cleanupReadNotifications(ctx context.Context, appContainer *container.ApplicationContainer, now time.Time) error {
+	deleted, err := appContainer.GetServiceManager().NotificationService().CleanupReadNotifications(ctx, now)
+	if err != nil {
+		return fmt.Errorf("清理已读通知失败: %w", err)
+	}
+	if deleted > 0 {
+		logger.Infof("已清理超过保留期的已读通知%d条", deleted)
+	}
+	return nil
+}
@@ -17,9 +17,20 @@ import (
 	"taskmanage/internal/database"
 	"taskmanage/internal/service"
 	"taskmanage/internal/utils"
+	"taskmanage/pkg/i18n"
 	"taskmanage/pkg/logger"
 )
 
+// 接口文档由swag根据处理器注释生成，修改路由或注释后执行 go generate ./cmd/taskmanage 更新 docs/swagger
+//go:generate swag init --dir ./,../../internal/api/handlers --generalInfo main.go --output ../../docs/swagger --parseDependency --parseInternal
+
+// @title 任务管理系统 API
+// @version 1.0.0
+// @description 任务分配、审批流程、员工与组织架构管理接口。除认证和健康检查外，接口需要在Authorization请求头中携带 "Bearer {token}"。
+// @BasePath /
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
 func main() {
 	// 设置全局错误恢复
 	defer utils.Recovery()
@@ -29,6 +40,9 @@ func main() {
 		configPath = flag.String("config", "", "配置文件路径")
 		env        = flag.String("env", "development", "运行环境 (development, testing, production)")
 		showConfig = flag.Bool("show-config", false, "显示配置信息")
+		backfill   = flag.Bool("backfill-history-chain", false, "为已有的流程执行历史补齐哈希链后退出")
+		migrate    = flag.Bool("migrate", false, "执行待执行的数据库迁移后退出")
+		checkNos   = flag.Bool("check-employee-numbers", false, "检查已有员工编号是否重复或不符合配置格式后退出")
 	)
 	flag.Parse()
 
@@ -54,6 +68,11 @@ func main() {
 	// 添加钩子
 	logger.AddHooks(logger.GetLogger(), cfg.App.Name, cfg.App.Version, cfg.App.Environment)
 
+	// 设置默认语言并校验消息目录，代码引用的消息在默认语言中缺失时拒绝启动
+	if err := i18n.Init(cfg.App.Locale); err != nil {
+		logger.Fatalf("初始化消息目录失败: %v", err)
+	}
+
 	// 显示配置信息
 	if *showConfig {
 		fmt.Printf("应用程序配置:\n")
@@ -81,8 +100,16 @@ func main() {
 	logger.Infof("日志级别: %s", cfg.Log.Level)
 	logger.Infof("日志格式: %s", cfg.Log.Format)
 
+	// 执行数据库迁移后退出，用于关闭自动迁移的环境在发布时显式迁移
+	if *migrate {
+		if err := runMigrations(cfg); err != nil {
+			logger.Fatalf("数据库迁移失败: %v", err)
+		}
+		return
+	}
+
 	// 初始化数据库连接池
-	logger.Info("正在初始化MySQL数据库连接...")
+	logger.Infof("正在初始化数据库连接: driver=%s", cfg.Database.Driver)
 	if err := database.Initialize(cfg); err != nil {
 		logger.Fatalf("数据库初始化失败: %v", err)
 	}
@@ -107,7 +134,23 @@ func main() {
 	dbInfo := database.GetConnectionInfo()
 	logger.Infof("数据库连接信息: %+v", dbInfo)
 
-	logger.Info("MySQL连接池配置完成，系统准备就绪")
+	logger.Info("数据库连接池配置完成，系统准备就绪")
+
+	// 回填流程执行历史哈希链，完成后退出
+	if *backfill {
+		if err := backfillHistoryChain(context.Background()); err != nil {
+			logger.Fatalf("回填流程执行历史哈希链失败: %v", err)
+		}
+		return
+	}
+
+	// 检查已有员工编号，完成后退出
+	if *checkNos {
+		if err := checkEmployeeNumbers(context.Background(), cfg); err != nil {
+			logger.Fatalf("员工编号检查未通过: %v", err)
+		}
+		return
+	}
 
 	// 启动HTTP服务器
 	startHTTPServer(cfg)
@@ -124,22 +167,60 @@ func startHTTPServer(cfg *config.Config) {
 	}
 
 	// 初始化全局容器
-	container.InitGlobalContainer(cfg, db)
+	if err := container.InitGlobalContainer(cfg, db); err != nil {
+		logger.Fatalf("初始化依赖容器失败: %v", err)
+	}
 	appContainer := container.GetGlobalContainer()
+	startup := appContainer.GetStartupTracker()
+	startup.Finish(container.StartupStepContainer, nil)
+
+	// 创建路由器
+	logger.Info("正在创建路由器...")
+	engine := router.NewRouter(appContainer, logger.GetLogger())
+	logger.Info("路由器创建完成")
+	logContainerSelfCheck(appContainer)
+
+	// 创建HTTP服务器
+	server := &http.Server{
+		Addr:    cfg.GetServerAddr(),
+		Handler: engine,
+	}
+
+	// 先启动服务器以便探针可访问，初始化完成前 /health/startup 和 /health/ready 返回503
+	go func() {
+		logger.Infof("HTTP服务器正在启动，监听地址: %s", cfg.GetServerAddr())
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Fatalf("HTTP服务器启动失败: %v", err)
+		}
+	}()
 
 	// 初始化系统默认数据（角色、权限、超级管理员）
-	if err := initializeSystemData(appContainer); err != nil {
+	err := initializeSystemData(appContainer)
+	if err != nil {
 		logger.Errorf("初始化系统默认数据失败: %v", err)
 	} else {
 		logger.Info("系统默认数据初始化完成")
 	}
+	startup.Finish(container.StartupStepSystemData, err)
 
 	// 初始化默认工作流定义
-	if err := utils.InitializeDefaultWorkflows(appContainer); err != nil {
+	err = utils.InitializeDefaultWorkflows(appContainer)
+	if err != nil {
 		logger.Errorf("初始化默认工作流定义失败: %v", err)
 	} else {
 		logger.Info("默认工作流定义初始化完成")
 	}
+	startup.Finish(container.StartupStepDefaultWorkflows, err)
+
+	// 恢复上次进程退出时中断的工作流节点执行
+	recoverWorkflowInstances(appContainer)
+
+	// 注册并启动定时任务，服务关闭时等待执行中的任务结束
+	scheduler := appContainer.GetJobScheduler()
+	if err := registerJobs(scheduler, appContainer, cfg); err != nil {
+		logger.Fatalf("注册定时任务失败: %v", err)
+	}
+	scheduler.Start(context.Background())
 
 	// 初始化权限模板
 	if err := initializePermissionTemplates(appContainer); err != nil {
@@ -148,28 +229,6 @@ func startHTTPServer(cfg *config.Config) {
 		logger.Info("权限模板初始化完成")
 	}
 
-	// 创建路由器
-	logger.Info("正在创建路由器...")
-	engine := router.NewRouter(appContainer, logger.GetLogger())
-	logger.Info("路由器创建完成")
-
-	// 创建HTTP服务器
-	server := &http.Server{
-		Addr:    cfg.GetServerAddr(),
-		Handler: engine,
-	}
-	
-	// 启动服务器
-	go func() {
-		logger.Infof("HTTP服务器正在启动，监听地址: %s", cfg.GetServerAddr())
-		logger.Info("服务器已准备就绪，等待请求...")
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			logger.Fatalf("HTTP服务器启动失败: %v", err)
-		}
-	}()
-	
-	// 给服务器一点时间完全启动
-	time.Sleep(100 * time.Millisecond)
 	logger.Info("HTTP服务器启动完成，现在可以接收请求")
 
 	// 等待中断信号
@@ -183,6 +242,13 @@ func startHTTPServer(cfg *config.Config) {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
+	// 先停止调度并等待执行中的定时任务，与HTTP服务器共用关闭期限
+	if err := scheduler.Stop(ctx); err != nil {
+		logger.Errorf("定时任务停止失败: %v", err)
+	}
+
+	// Shutdown会等待处理中的请求返回，工作流节点在请求内同步执行并逐节点提交，
+	// 超时被强制退出时未提交的节点会在下次启动时由recoverWorkflowInstances补执行
 	if err := server.Shutdown(ctx); err != nil {
 		logger.Errorf("服务器关闭失败: %v", err)
 	} else {
@@ -191,6 +257,17 @@ func startHTTPServer(cfg *config.Config) {
 }
 
 // initializeSystemData 初始化系统默认数据
+// logContainerSelfCheck 记录容器各注册项的实现类型，路由创建后大部分服务已初始化
+func logContainerSelfCheck(appContainer *container.ApplicationContainer) {
+	for _, registration := range appContainer.SelfCheck() {
+		implementation := registration.Type
+		if !registration.Initialized {
+			implementation = "未初始化"
+		}
+		logger.Infof("容器自检: %s => %s", registration.Name, implementation)
+	}
+}
+
 func initializeSystemData(appContainer *container.ApplicationContainer) error {
 	// 获取仓储管理器
 	repoManager := appContainer.GetRepositoryManager()
@@ -199,8 +276,47 @@ func initializeSystemData(appContainer *container.ApplicationContainer) error {
 	bootstrapService := service.NewBootstrapService(repoManager)
 	
 	// 初始化系统数据
-	ctx := context.Background()
-	return bootstrapService.InitializeSystem(ctx)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	if err := bootstrapService.InitializeSystem(ctx); err != nil {
+		return err
+	}
+
+	// 初始化可能调整了角色权限，清除全部用户的权限缓存
+	if err := appContainer.GetServiceManager().PermissionService().InvalidateAll(ctx); err != nil {
+		logger.Warnf("清除权限缓存失败: %v", err)
+	}
+
+	// 迁移无法自动修正的任务负责人需人工处理
+	tasks, err := repoManager.TaskRepository().ListTasksWithUnknownAssignee(ctx)
+	if err != nil {
+		logger.Warnf("检查任务负责人失败: %v", err)
+	}
+	for _, task := range tasks {
+		logger.Warnf("任务负责人不对应任何用户: TaskID=%d, AssigneeID=%d", task.ID, *task.AssigneeID)
+	}
+	return nil
+}
+
+// recoverWorkflowInstances 重新执行结果未落库的工作流节点并记录恢复汇总
+func recoverWorkflowInstances(appContainer *container.ApplicationContainer) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	report, err := appContainer.GetServiceManager().WorkflowService().RecoverInstances(ctx)
+	if err != nil {
+		logger.Errorf("恢复工作流实例失败: %v", err)
+		return
+	}
+
+	logger.Infof("工作流实例恢复完成: 扫描运行中实例%d个, 恢复节点%d个, 失败%d个",
+		report.Scanned, len(report.Recovered), len(report.Failed))
+	for _, node := range report.Recovered {
+		logger.Infof("已恢复节点: 实例=%s, 节点=%s", node.InstanceID, node.NodeID)
+	}
+	for _, node := range report.Failed {
+		logger.Warnf("节点恢复失败: 实例=%s, 节点=%s, 原因=%s", node.InstanceID, node.NodeID, node.Error)
+	}
 }
 
 // initializePermissionTemplates 初始化权限模板
@@ -209,7 +325,8 @@ func initializePermissionTemplates(appContainer *container.ApplicationContainer)
 	serviceManager := appContainer.GetServiceManager()
 	permissionService := serviceManager.PermissionAssignmentService()
 	
-	ctx := context.Background()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
 	
 	// 初始化基础权限模板
 	if err := permissionService.InitializePermissionTemplates(ctx); err != nil {
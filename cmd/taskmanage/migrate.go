@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"taskmanage/internal/config"
+	"taskmanage/internal/database"
+	"taskmanage/pkg/logger"
+)
+
+// runMigrations 连接数据库并执行待执行的版本化迁移，不受 database.auto_migrate 配置影响
+// 多个实例同时执行时由迁移锁串行化，中途失败后重新执行会从失败的迁移继续
+func runMigrations(cfg *config.Config) error {
+	if err := database.Connect(cfg); err != nil {
+		return fmt.Errorf("数据库连接失败: %w", err)
+	}
+	defer database.Close()
+
+	applied, err := database.RunMigrations(context.Background(), database.GetDB(), database.MigrationOptionsFromConfig(cfg))
+	for _, m := range applied {
+		logger.Infof("已执行数据库迁移: %s", m)
+	}
+	if err != nil {
+		return err
+	}
+	if len(applied) == 0 {
+		logger.Info("没有待执行的数据库迁移")
+	}
+	return nil
+}
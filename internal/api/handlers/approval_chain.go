@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"taskmanage/internal/repository"
+	"taskmanage/internal/service"
+	"taskmanage/pkg/response"
+)
+
+// ApprovalChainHandler 部门审批链处理器
+type ApprovalChainHandler struct {
+	approvalChainService service.ApprovalChainService
+	logger               *logrus.Logger
+}
+
+// NewApprovalChainHandler 创建部门审批链处理器
+func NewApprovalChainHandler(approvalChainService service.ApprovalChainService, logger *logrus.Logger) *ApprovalChainHandler {
+	return &ApprovalChainHandler{
+		approvalChainService: approvalChainService,
+		logger:               logger,
+	}
+}
+
+// ListApprovalChains 获取部门审批链列表
+// @Summary 获取部门审批链列表
+// @Description 获取部门按业务类型配置的全部审批链
+// @Tags 部门管理
+// @Produce json
+// @Param id path int true "部门ID"
+// @Success 200 {object} response.Response{data=[]service.ApprovalChainResponse} "获取成功"
+// @Failure 404 {object} response.Response "部门不存在"
+// @Router /api/v1/departments/{id}/approval-chains [get]
+// @Security BearerAuth
+func (h *ApprovalChainHandler) ListApprovalChains(c *gin.Context) {
+	departmentID, ok := h.parseID(c, "id", "无效的部门ID")
+	if !ok {
+		return
+	}
+
+	chains, err := h.approvalChainService.ListDepartmentChains(c.Request.Context(), departmentID)
+	if err != nil {
+		h.handleError(c, err, "获取部门审批链失败")
+		return
+	}
+
+	response.Success(c, chains)
+}
+
+// GetApprovalChain 获取部门审批链详情
+// @Summary 获取部门审批链详情
+// @Tags 部门管理
+// @Produce json
+// @Param id path int true "部门ID"
+// @Param chain_id path int true "审批链ID"
+// @Success 200 {object} response.Response{data=service.ApprovalChainResponse} "获取成功"
+// @Failure 404 {object} response.Response "审批链不存在"
+// @Router /api/v1/departments/{id}/approval-chains/{chain_id} [get]
+// @Security BearerAuth
+func (h *ApprovalChainHandler) GetApprovalChain(c *gin.Context) {
+	departmentID, ok := h.parseID(c, "id", "无效的部门ID")
+	if !ok {
+		return
+	}
+	chainID, ok := h.parseID(c, "chain_id", "无效的审批链ID")
+	if !ok {
+		return
+	}
+
+	chain, err := h.approvalChainService.GetDepartmentChain(c.Request.Context(), departmentID, chainID)
+	if err != nil {
+		h.handleError(c, err, "获取部门审批链失败")
+		return
+	}
+
+	response.Success(c, chain)
+}
+
+// CreateApprovalChain 创建部门审批链
+// @Summary 创建部门审批链
+// @Description 为部门的业务类型配置有序审批人，审批节点配置 assignee_source 为 department_chain 时使用
+// @Tags 部门管理
+// @Accept json
+// @Produce json
+// @Param id path int true "部门ID"
+// @Param request body service.ApprovalChainRequest true "审批链配置"
+// @Success 201 {object} response.Response{data=service.ApprovalChainResponse} "创建成功"
+// @Failure 400 {object} response.Response "配置不合法"
+// @Failure 409 {object} response.Response "业务类型已配置审批链"
+// @Router /api/v1/departments/{id}/approval-chains [post]
+// @Security BearerAuth
+func (h *ApprovalChainHandler) CreateApprovalChain(c *gin.Context) {
+	departmentID, ok := h.parseID(c, "id", "无效的部门ID")
+	if !ok {
+		return
+	}
+
+	var req service.ApprovalChainRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Warn("绑定部门审批链请求失败")
+		response.BadRequest(c, "请求参数格式错误")
+		return
+	}
+
+	chain, err := h.approvalChainService.CreateDepartmentChain(c.Request.Context(), departmentID, &req)
+	if err != nil {
+		h.handleError(c, err, "创建部门审批链失败")
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.Response{
+		Code:    response.ErrCodeSuccess,
+		Message: "部门审批链创建成功",
+		Data:    chain,
+	})
+}
+
+// UpdateApprovalChain 更新部门审批链
+// @Summary 更新部门审批链
+// @Tags 部门管理
+// @Accept json
+// @Produce json
+// @Param id path int true "部门ID"
+// @Param chain_id path int true "审批链ID"
+// @Param request body service.ApprovalChainRequest true "审批链配置"
+// @Success 200 {object} response.Response{data=service.ApprovalChainResponse} "更新成功"
+// @Failure 400 {object} response.Response "配置不合法"
+// @Failure 404 {object} response.Response "审批链不存在"
+// @Failure 409 {object} response.Response "业务类型已配置审批链"
+// @Router /api/v1/departments/{id}/approval-chains/{chain_id} [put]
+// @Security BearerAuth
+func (h *ApprovalChainHandler) UpdateApprovalChain(c *gin.Context) {
+	departmentID, ok := h.parseID(c, "id", "无效的部门ID")
+	if !ok {
+		return
+	}
+	chainID, ok := h.parseID(c, "chain_id", "无效的审批链ID")
+	if !ok {
+		return
+	}
+
+	var req service.ApprovalChainRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Warn("绑定部门审批链请求失败")
+		response.BadRequest(c, "请求参数格式错误")
+		return
+	}
+
+	chain, err := h.approvalChainService.UpdateDepartmentChain(c.Request.Context(), departmentID, chainID, &req)
+	if err != nil {
+		h.handleError(c, err, "更新部门审批链失败")
+		return
+	}
+
+	response.SuccessWithMessage(c, "部门审批链更新成功", chain)
+}
+
+// DeleteApprovalChain 删除部门审批链
+// @Summary 删除部门审批链
+// @Description 删除后审批节点回退到流程定义中的审批人
+// @Tags 部门管理
+// @Produce json
+// @Param id path int true "部门ID"
+// @Param chain_id path int true "审批链ID"
+// @Success 200 {object} response.Response "删除成功"
+// @Failure 404 {object} response.Response "审批链不存在"
+// @Router /api/v1/departments/{id}/approval-chains/{chain_id} [delete]
+// @Security BearerAuth
+func (h *ApprovalChainHandler) DeleteApprovalChain(c *gin.Context) {
+	departmentID, ok := h.parseID(c, "id", "无效的部门ID")
+	if !ok {
+		return
+	}
+	chainID, ok := h.parseID(c, "chain_id", "无效的审批链ID")
+	if !ok {
+		return
+	}
+
+	if err := h.approvalChainService.DeleteDepartmentChain(c.Request.Context(), departmentID, chainID); err != nil {
+		h.handleError(c, err, "删除部门审批链失败")
+		return
+	}
+
+	response.SuccessWithMessage(c, "部门审批链已删除", nil)
+}
+
+// parseID 解析路径中的ID参数，失败时直接返回400
+func (h *ApprovalChainHandler) parseID(c *gin.Context, param, message string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(param), 10, 32)
+	if err != nil || id == 0 {
+		response.BadRequest(c, message)
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// handleError 将审批链相关错误映射为HTTP响应
+func (h *ApprovalChainHandler) handleError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidApprovalChain):
+		response.BadRequest(c, err.Error())
+	case errors.Is(err, service.ErrApprovalChainExists):
+		response.Conflict(c, err.Error())
+	case repository.IsNotFoundError(err):
+		response.NotFound(c, "部门或审批链不存在")
+	default:
+		h.logger.WithError(err).Error(message)
+		response.InternalError(c, message)
+	}
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"taskmanage/internal/service"
+	"taskmanage/pkg/response"
+)
+
+// ArchiveHandler 归档数据管理处理器
+type ArchiveHandler struct {
+	archiveService service.ArchiveService
+	logger         *logrus.Logger
+}
+
+// NewArchiveHandler 创建归档数据管理处理器
+func NewArchiveHandler(archiveService service.ArchiveService, logger *logrus.Logger) *ArchiveHandler {
+	return &ArchiveHandler{
+		archiveService: archiveService,
+		logger:         logger,
+	}
+}
+
+// DeleteArchivedTask 永久删除归档任务
+// @Summary 永久删除归档任务
+// @Description 从归档表中永久删除任务，不可恢复；只能删除已归档的任务，在用任务请使用任务删除接口
+// @Tags 系统管理
+// @Produce json
+// @Param id path int true "任务ID"
+// @Success 200 {object} response.Response "删除成功"
+// @Failure 400 {object} response.Response "无效的任务ID"
+// @Failure 404 {object} response.Response "归档任务不存在"
+// @Router /api/v1/admin/archive/tasks/{id} [delete]
+// @Security BearerAuth
+func (h *ArchiveHandler) DeleteArchivedTask(c *gin.Context) {
+	taskID, ok := parseUintParam(c, "id", "无效的任务ID")
+	if !ok {
+		return
+	}
+
+	if err := h.archiveService.DeleteArchivedTask(c.Request.Context(), taskID); err != nil {
+		response.FromError(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, "归档任务已永久删除", nil)
+}
+
+// DeleteArchivedWorkflowInstance 永久删除归档流程实例
+// @Summary 永久删除归档流程实例
+// @Description 从归档表中永久删除流程实例，不可恢复；执行历史保留在历史表中
+// @Tags 系统管理
+// @Produce json
+// @Param instance_id path string true "流程实例ID"
+// @Success 200 {object} response.Response "删除成功"
+// @Failure 404 {object} response.Response "归档流程实例不存在"
+// @Router /api/v1/admin/archive/workflow-instances/{instance_id} [delete]
+// @Security BearerAuth
+func (h *ArchiveHandler) DeleteArchivedWorkflowInstance(c *gin.Context) {
+	instanceID := c.Param("instance_id")
+
+	if err := h.archiveService.DeleteArchivedWorkflowInstance(c.Request.Context(), instanceID); err != nil {
+		response.FromError(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, "归档流程实例已永久删除", nil)
+}
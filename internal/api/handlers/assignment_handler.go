@@ -1,9 +1,11 @@
 package handlers
 
 import (
+	"errors"
+	"net/http"
 	"strconv"
 
-	"taskmanage/internal/container"
+	"taskmanage/internal/assignment"
 	"taskmanage/internal/service"
 	"taskmanage/pkg/response"
 
@@ -13,17 +15,17 @@ import (
 
 // AssignmentHandler 分配管理处理器
 type AssignmentHandler struct {
-	container         *container.ApplicationContainer
 	logger            *logrus.Logger
 	assignmentService service.AssignmentService
+	strategies        *assignment.StrategyRegistry
 }
 
 // NewAssignmentHandler 创建分配管理处理器
-func NewAssignmentHandler(container *container.ApplicationContainer, logger *logrus.Logger) *AssignmentHandler {
+func NewAssignmentHandler(assignmentService service.AssignmentService, strategies *assignment.StrategyRegistry, logger *logrus.Logger) *AssignmentHandler {
 	return &AssignmentHandler{
-		container:         container,
 		logger:            logger,
-		assignmentService: container.GetAssignmentManagementService(),
+		assignmentService: assignmentService,
+		strategies:        strategies,
 	}
 }
 
@@ -34,10 +36,12 @@ func NewAssignmentHandler(container *container.ApplicationContainer, logger *log
 // @Accept json
 // @Produce json
 // @Param request body service.ManualAssignmentRequest true "分配请求"
-// @Success 200 {object} response.Response{data=service.AssignmentHistory}
+// @Success 200 {object} response.Response{data=ManualAssignResult}
 // @Failure 400 {object} response.Response
+// @Failure 409 {object} response.Response "员工不是任务所属项目的成员或任务有未完成的子任务"
 // @Failure 500 {object} response.Response
-// @Router /api/assignments/manual [post]
+// @Router /api/v1/assignments/manual [post]
+// @Security BearerAuth
 func (h *AssignmentHandler) ManualAssign(c *gin.Context) {
 	var req service.ManualAssignmentRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -55,13 +59,21 @@ func (h *AssignmentHandler) ManualAssign(c *gin.Context) {
 
 	history, err := h.assignmentService.ManualAssign(c.Request.Context(), &req)
 	if err != nil {
+		if errors.Is(err, service.ErrNotProjectMember) {
+			response.ErrorWithCode(c, response.ErrCodeNotProjectMember, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrTaskHasOpenSubtasks) {
+			response.ErrorWithCode(c, response.ErrCodeTaskHasOpenSubtasks, err.Error())
+			return
+		}
 		response.InternalError(c, "分配失败")
 		return
 	}
 
-	response.Success(c, gin.H{
-		"message": "分配成功",
-		"history": history,
+	response.Success(c, ManualAssignResult{
+		Message: "分配成功",
+		History: history,
 	})
 }
 
@@ -72,10 +84,11 @@ func (h *AssignmentHandler) ManualAssign(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param request body service.AssignmentSuggestionRequest true "建议请求"
-// @Success 200 {object} response.Response{data=[]service.AssignmentSuggestion}
-// @Failure 400 {object} response.Response
+// @Success 200 {object} response.Response{data=AssignmentSuggestionsResult}
+// @Failure 400 {object} response.Response "请求参数错误或分配策略未注册"
 // @Failure 500 {object} response.Response
-// @Router /api/assignments/suggestions [post]
+// @Router /api/v1/assignments/suggestions [post]
+// @Security BearerAuth
 func (h *AssignmentHandler) GetAssignmentSuggestions(c *gin.Context) {
 	var req service.AssignmentSuggestionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -90,13 +103,17 @@ func (h *AssignmentHandler) GetAssignmentSuggestions(c *gin.Context) {
 
 	suggestions, err := h.assignmentService.GetAssignmentSuggestions(c.Request.Context(), &req)
 	if err != nil {
+		if errors.Is(err, service.ErrUnknownAssignmentStrategy) {
+			response.BadRequest(c, err.Error())
+			return
+		}
 		response.InternalError(c, "获取建议失败")
 		return
 	}
 
-	response.Success(c, gin.H{
-		"message":     "获取建议成功",
-		"suggestions": suggestions,
+	response.Success(c, AssignmentSuggestionsResult{
+		Message:     "获取建议成功",
+		Suggestions: suggestions,
 	})
 }
 
@@ -106,11 +123,13 @@ func (h *AssignmentHandler) GetAssignmentSuggestions(c *gin.Context) {
 // @Tags 任务分配
 // @Accept json
 // @Produce json
-// @Param request body service.AssignmentConflictCheck true "冲突检查请求"
-// @Success 200 {object} response.Response{data=[]service.AssignmentConflict}
+// @Param task_id path int true "任务ID"
+// @Param employee_id query int true "员工ID"
+// @Success 200 {object} response.Response{data=AssignmentConflictsResult}
 // @Failure 400 {object} response.Response
 // @Failure 500 {object} response.Response
-// @Router /api/assignments/conflicts [post]
+// @Router /api/v1/assignments/conflicts/{task_id} [post]
+// @Security BearerAuth
 func (h *AssignmentHandler) CheckAssignmentConflicts(c *gin.Context) {
 	// 简化冲突检查实现
 	taskIDStr := c.Param("task_id")
@@ -134,23 +153,26 @@ func (h *AssignmentHandler) CheckAssignmentConflicts(c *gin.Context) {
 		return
 	}
 
-	response.Success(c, gin.H{
-		"message":   "冲突检查完成",
-		"conflicts": conflicts,
+	response.Success(c, AssignmentConflictsResult{
+		Message:   "冲突检查完成",
+		Conflicts: conflicts,
 	})
 }
 
 // GetAssignmentHistory 获取分配历史
 // @Summary 获取分配历史
-// @Description 获取任务的分配历史记录
+// @Description 分页获取任务的分配历史记录，关联审批流程时返回审批信息
 // @Tags 任务分配
 // @Accept json
 // @Produce json
 // @Param task_id path int true "任务ID"
-// @Success 200 {object} response.Response{data=[]service.AssignmentHistory}
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} response.PaginationResponse{data=[]service.AssignmentHistory}
 // @Failure 400 {object} response.Response
 // @Failure 500 {object} response.Response
-// @Router /api/assignments/history/{task_id} [get]
+// @Router /api/v1/assignments/history/{task_id} [get]
+// @Security BearerAuth
 func (h *AssignmentHandler) GetAssignmentHistory(c *gin.Context) {
 	taskIDStr := c.Param("task_id")
 	taskID, err := strconv.ParseUint(taskIDStr, 10, 32)
@@ -159,16 +181,22 @@ func (h *AssignmentHandler) GetAssignmentHistory(c *gin.Context) {
 		return
 	}
 
-	history, err := h.assignmentService.GetAssignmentHistory(c.Request.Context(), uint(taskID))
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	pageSize, err := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if err != nil || pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	history, total, err := h.assignmentService.GetAssignmentHistory(c.Request.Context(), uint(taskID), page, pageSize)
 	if err != nil {
 		response.InternalError(c, "获取历史失败")
 		return
 	}
 
-	response.Success(c, gin.H{
-		"message": "获取历史成功",
-		"history": history,
-	})
+	response.SuccessWithPagination(c, history, page, pageSize, total)
 }
 
 // ReassignTask 重新分配任务
@@ -179,10 +207,11 @@ func (h *AssignmentHandler) GetAssignmentHistory(c *gin.Context) {
 // @Produce json
 // @Param task_id path int true "任务ID"
 // @Param request body ReassignRequest true "重新分配请求"
-// @Success 200 {object} response.Response
+// @Success 200 {object} response.Response{data=AssignmentActionResult}
 // @Failure 400 {object} response.Response
 // @Failure 500 {object} response.Response
-// @Router /api/assignments/reassign/{task_id} [post]
+// @Router /api/v1/assignments/reassign/{task_id} [post]
+// @Security BearerAuth
 func (h *AssignmentHandler) ReassignTask(c *gin.Context) {
 	taskIDStr := c.Param("task_id")
 	taskID, err := strconv.ParseUint(taskIDStr, 10, 32)
@@ -210,9 +239,10 @@ func (h *AssignmentHandler) ReassignTask(c *gin.Context) {
 		return
 	}
 
-	response.Success(c, gin.H{
-		"message": "重新分配成功",
-		"assign":  true,
+	assigned := true
+	response.Success(c, AssignmentActionResult{
+		Message: "重新分配成功",
+		Assign:  &assigned,
 	})
 }
 
@@ -224,10 +254,11 @@ func (h *AssignmentHandler) ReassignTask(c *gin.Context) {
 // @Produce json
 // @Param task_id path int true "任务ID"
 // @Param request body CancelAssignmentRequest true "取消分配请求"
-// @Success 200 {object} response.Response
+// @Success 200 {object} response.Response{data=AssignmentActionResult}
 // @Failure 400 {object} response.Response
 // @Failure 500 {object} response.Response
-// @Router /api/assignments/cancel/{task_id} [post]
+// @Router /api/v1/assignments/cancel/{task_id} [post]
+// @Security BearerAuth
 func (h *AssignmentHandler) CancelAssignment(c *gin.Context) {
 	taskIDStr := c.Param("task_id")
 	taskID, err := strconv.ParseUint(taskIDStr, 10, 32)
@@ -255,114 +286,110 @@ func (h *AssignmentHandler) CancelAssignment(c *gin.Context) {
 		return
 	}
 
-	response.Success(c, gin.H{
-		"message": "取消分配成功",
-		"assign":  nil,
+	response.Success(c, AssignmentActionResult{
+		Message: "取消分配成功",
 	})
 }
 
 // GetAssignmentStrategies 获取可用的分配策略
 // @Summary 获取分配策略
-// @Description 获取所有可用的分配策略
+// @Description 按注册顺序获取所有可用的分配策略
 // @Tags 任务分配
 // @Accept json
 // @Produce json
-// @Success 200 {object} response.Response{data=[]StrategyInfo}
+// @Success 200 {object} response.Response{data=AssignmentStrategiesResult}
 // @Failure 500 {object} response.Response
-// @Router /api/assignments/strategies [get]
+// @Router /api/v1/assignments/strategies [get]
+// @Security BearerAuth
 func (h *AssignmentHandler) GetAssignmentStrategies(c *gin.Context) {
-	strategies := []StrategyInfo{
-		{
-			Strategy:    "round_robin",
-			Name:        "轮询分配",
-			Description: "按顺序轮流分配给可用员工",
-		},
-		{
-			Strategy:    "load_balance",
-			Name:        "负载均衡",
-			Description: "优先分配给工作负载较低的员工",
-		},
-		{
-			Strategy:    "skill_match",
-			Name:        "技能匹配",
-			Description: "根据技能要求匹配最合适的员工",
-		},
-		{
-			Strategy:    "comprehensive",
-			Name:        "综合评分",
-			Description: "综合考虑技能、负载、可用性等因素",
-		},
-		{
-			Strategy:    "manual",
-			Name:        "手动分配",
-			Description: "管理员手动指定员工",
-		},
+	registered := h.strategies.List()
+	strategies := make([]StrategyInfo, 0, len(registered))
+	for _, strategy := range registered {
+		strategies = append(strategies, StrategyInfo{
+			Strategy:    string(strategy.Name()),
+			Name:        strategy.DisplayName(),
+			Description: strategy.Description(),
+		})
 	}
 
-	response.Success(c, gin.H{
-		"message":    "获取策略成功",
-		"strategies": strategies,
+	response.Success(c, AssignmentStrategiesResult{
+		Message:    "获取策略成功",
+		Strategies: strategies,
 	})
 }
 
 // GetAssignmentStats 获取分配统计
 // @Summary 获取分配统计
-// @Description 获取分配相关的统计信息
+// @Description 按分配时间统计分配记录：按分配方式和状态的数量、审批拒绝率、分配到审批的平均耗时，以及按被分配人所在部门的明细。不传日期时统计全部记录
 // @Tags 任务分配
-// @Accept json
 // @Produce json
-// @Param employee_id query int false "员工ID"
-// @Param days query int false "统计天数，默认30天"
-// @Success 200 {object} response.Response{data=AssignmentStats}
-// @Failure 400 {object} response.Response
+// @Param from query string false "开始日期，格式: 2006-01-02"
+// @Param to query string false "结束日期（含当天），格式: 2006-01-02"
+// @Param department_id query int false "被分配人所在部门ID"
+// @Success 200 {object} response.Response{data=service.AssignmentStatsResponse}
+// @Failure 400 {object} response.Response "参数错误"
 // @Failure 500 {object} response.Response
-// @Router /api/assignments/stats [get]
+// @Router /api/v1/assignments/stats [get]
+// @Security BearerAuth
 func (h *AssignmentHandler) GetAssignmentStats(c *gin.Context) {
-	employeeIDStr := c.Query("employee_id")
-	daysStr := c.Query("days")
+	var req service.AssignmentStatsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.BadRequest(c, "请求参数格式错误")
+		return
+	}
 
-	var employeeID uint
-	if employeeIDStr != "" {
-		id, err := strconv.ParseUint(employeeIDStr, 10, 32)
-		if err != nil {
-			response.BadRequest(c, "员工ID格式错误")
-			return
-		}
-		employeeID = uint(id)
+	stats, err := h.assignmentService.GetAssignmentStats(c.Request.Context(), &req)
+	if err != nil {
+		h.handleStatsError(c, err)
+		return
 	}
 
-	days := 30
-	if daysStr != "" {
-		d, err := strconv.Atoi(daysStr)
-		if err != nil {
-			response.BadRequest(c, "天数格式错误")
-			return
-		}
-		days = d
+	response.Success(c, stats)
+}
+
+// ExportAssignmentStats 导出分配统计
+// @Summary 导出分配统计
+// @Description 以CSV导出与分配统计接口相同的数据，依次为汇总、按分配方式、按状态和按部门四组，组间以空行分隔
+// @Tags 任务分配
+// @Produce text/csv
+// @Param from query string false "开始日期，格式: 2006-01-02"
+// @Param to query string false "结束日期（含当天），格式: 2006-01-02"
+// @Param department_id query int false "被分配人所在部门ID"
+// @Success 200 {file} file "CSV文件"
+// @Failure 400 {object} response.Response "参数错误"
+// @Failure 500 {object} response.Response
+// @Router /api/v1/assignments/stats/export [get]
+// @Security BearerAuth
+func (h *AssignmentHandler) ExportAssignmentStats(c *gin.Context) {
+	var req service.AssignmentStatsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.BadRequest(c, "请求参数格式错误")
+		return
 	}
 
-	// 构建统计信息
-	stats := AssignmentStats{
-		TotalAssignments:      100, // 示例数据
-		SuccessfulAssignments: 85,
-		PendingApprovals:      5,
-		CancelledAssignments:  10,
-		AverageAssignmentTime: 2.5,
-		TopStrategies: []StrategyUsage{
-			{Strategy: "comprehensive", Count: 40, Percentage: 40.0},
-			{Strategy: "load_balance", Count: 30, Percentage: 30.0},
-			{Strategy: "skill_match", Count: 20, Percentage: 20.0},
-			{Strategy: "manual", Count: 10, Percentage: 10.0},
-		},
+	export, err := h.assignmentService.ExportAssignmentStats(c.Request.Context(), &req)
+	if err != nil {
+		h.handleStatsError(c, err)
+		return
 	}
 
-	response.Success(c, "")
-	response.Success(c, gin.H{
-		"message":    "获取统计成功",
-		"stats":      stats,
-		"days":       days,
-		"employeeID": employeeID,
-	})
+	c.Header("Content-Type", export.ContentType)
+	c.Header("Content-Disposition", `attachment; filename="`+export.Filename+`"`)
+	c.Status(http.StatusOK)
+	if err := export.WriteTo(c.Request.Context(), c.Writer); err != nil {
+		h.logger.WithError(err).WithField("file", export.Filename).Error("分配统计写出中断")
+		c.Abort()
+	}
+}
+
+// handleStatsError 将分配统计错误映射为HTTP响应
+func (h *AssignmentHandler) handleStatsError(c *gin.Context, err error) {
+	if errors.Is(err, service.ErrInvalidAssignmentStatsRequest) {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	h.logger.WithError(err).Error("获取分配统计失败")
+	response.InternalError(c, "获取分配统计失败")
 }
 
 // 请求和响应结构体
@@ -385,21 +412,34 @@ type StrategyInfo struct {
 	Description string `json:"description"`
 }
 
-// AssignmentStats 分配统计
-type AssignmentStats struct {
-	TotalAssignments      int             `json:"total_assignments"`
-	SuccessfulAssignments int             `json:"successful_assignments"`
-	PendingApprovals      int             `json:"pending_approvals"`
-	CancelledAssignments  int             `json:"cancelled_assignments"`
-	AverageAssignmentTime float64         `json:"average_assignment_time"`
-	TopStrategies         []StrategyUsage `json:"top_strategies"`
+// ManualAssignResult 手动分配结果
+type ManualAssignResult struct {
+	Message string                     `json:"message"`
+	History *service.AssignmentHistory `json:"history"`
+}
+
+// AssignmentSuggestionsResult 分配建议结果
+type AssignmentSuggestionsResult struct {
+	Message     string                          `json:"message"`
+	Suggestions []*service.AssignmentSuggestion `json:"suggestions"`
+}
+
+// AssignmentConflictsResult 分配冲突检查结果
+type AssignmentConflictsResult struct {
+	Message   string                        `json:"message"`
+	Conflicts []*service.AssignmentConflict `json:"conflicts"`
+}
+
+// AssignmentActionResult 重新分配和取消分配结果，取消分配时assign为null
+type AssignmentActionResult struct {
+	Message string `json:"message"`
+	Assign  *bool  `json:"assign"`
 }
 
-// StrategyUsage 策略使用情况
-type StrategyUsage struct {
-	Strategy   string  `json:"strategy"`
-	Count      int     `json:"count"`
-	Percentage float64 `json:"percentage"`
+// AssignmentStrategiesResult 分配策略列表
+type AssignmentStrategiesResult struct {
+	Message    string         `json:"message"`
+	Strategies []StrategyInfo `json:"strategies"`
 }
 
 func (h *AssignmentHandler) GetPendingAssignments(c *gin.Context) {
@@ -1,30 +1,99 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 
+	"taskmanage/internal/cache"
 	"taskmanage/internal/container"
 	"taskmanage/internal/service"
+	"taskmanage/pkg/jwt"
+	"taskmanage/pkg/oidc"
 	"taskmanage/pkg/response"
 )
 
 // AuthHandler 认证处理器
 type AuthHandler struct {
-	container *container.ApplicationContainer
-	logger    *logrus.Logger
+	container     *container.ApplicationContainer
+	logger        *logrus.Logger
+	loginAttempts *cache.LoginAttemptStore // 登录失败计数，为nil时不做锁定
+	tokenStore    *cache.TokenStore        // 令牌状态存储，为nil时令牌退化为无状态
+	oidcProvider  *oidc.Provider           // 单点登录提供方，未启用单点登录时为nil
+	oidcStates    *cache.OIDCStateStore    // 单点登录state存储
+	passwordLogin bool                     // 是否允许用户名密码登录
 }
 
 // NewAuthHandler 创建认证处理器
 func NewAuthHandler(container *container.ApplicationContainer, logger *logrus.Logger) *AuthHandler {
-	return &AuthHandler{
-		container: container,
-		logger:    logger,
+	oidcConfig := container.GetConfig().OIDC
+	handler := &AuthHandler{
+		container:     container,
+		logger:        logger,
+		passwordLogin: !oidcConfig.DisablePasswordLogin,
+	}
+
+	if container.GetConfig().RateLimit.Enabled {
+		loginAttempts, err := container.GetLoginAttemptStore()
+		if err != nil {
+			logger.WithError(err).Warn("Login attempt store unavailable, login lockout disabled")
+		} else {
+			handler.loginAttempts = loginAttempts
+		}
 	}
+
+	tokenStore, err := container.GetTokenStore()
+	if err != nil {
+		logger.WithError(err).Warn("Token store unavailable, refresh rotation and revocation disabled")
+	} else {
+		handler.tokenStore = tokenStore
+	}
+
+	// 单点登录依赖state存储校验回调，存储不可用时不启用
+	if oidcConfig.Enabled {
+		oidcStates, err := container.GetOIDCStateStore()
+		if err != nil {
+			logger.WithError(err).Error("OIDC state store unavailable, single sign-on disabled")
+		} else {
+			handler.oidcStates = oidcStates
+			handler.oidcProvider = oidc.NewProvider(oidc.Config{
+				Issuer:       oidcConfig.Issuer,
+				ClientID:     oidcConfig.ClientID,
+				ClientSecret: oidcConfig.ClientSecret,
+				RedirectURL:  oidcConfig.RedirectURL,
+				Scopes:       oidcConfig.Scopes,
+			})
+		}
+	}
+
+	return handler
 }
 
 // Login 用户登录
+// @Summary 用户登录
+// @Description 使用用户名和密码登录，返回访问令牌和刷新令牌；连续失败多次后用户名被临时锁定。配置关闭密码登录后只能使用单点登录
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Param request body service.LoginRequest true "登录请求"
+// @Success 200 {object} response.Response{data=service.LoginResponse} "登录成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "用户名或密码错误"
+// @Failure 403 {object} response.Response "已关闭密码登录"
+// @Failure 429 {object} response.Response "登录失败次数过多"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/auth/login [post]
 func (h *AuthHandler) Login(c *gin.Context) {
+	if !h.passwordLogin {
+		response.Forbidden(c, "已关闭用户名密码登录，请使用单点登录")
+		return
+	}
+
 	var req service.LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		h.logger.WithError(err).Warn("Invalid login request")
@@ -32,6 +101,19 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
+	// 检查用户名是否因多次登录失败被临时锁定
+	if h.loginAttempts != nil {
+		lockedFor, err := h.loginAttempts.LockedFor(c.Request.Context(), req.Username)
+		if err != nil {
+			h.logger.WithError(err).Warn("Failed to check login lockout")
+		} else if lockedFor > 0 {
+			seconds := int(math.Ceil(lockedFor.Seconds()))
+			c.Header("Retry-After", strconv.Itoa(seconds))
+			response.TooManyRequests(c, fmt.Sprintf("登录失败次数过多，请%d秒后再试", seconds))
+			return
+		}
+	}
+
 	// 获取用户服务
 	userService := h.container.GetServiceManager().UserService()
 
@@ -39,38 +121,96 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	user, err := userService.AuthenticateUser(c.Request.Context(), req.Username, req.Password)
 	if err != nil {
 		h.logger.WithError(err).WithField("username", req.Username).Warn("User authentication failed")
+		if h.loginAttempts != nil {
+			if locked, recordErr := h.loginAttempts.RecordFailure(c.Request.Context(), req.Username); recordErr != nil {
+				h.logger.WithError(recordErr).Warn("Failed to record login failure")
+			} else if locked {
+				h.logger.WithField("username", req.Username).Warn("User locked due to repeated login failures")
+			}
+		}
 		response.Unauthorized(c, "用户名或密码错误")
 		return
 	}
 
+	// 登录成功，清除失败计数
+	if h.loginAttempts != nil {
+		if err := h.loginAttempts.Reset(c.Request.Context(), req.Username); err != nil {
+			h.logger.WithError(err).Warn("Failed to reset login failures")
+		}
+	}
+
+	loginResp, ok := h.issueLoginTokens(c, user)
+	if !ok {
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"user_id":  user.ID,
+		"username": user.Username,
+	}).Info("User login successful")
+
+	response.Success(c, loginResp)
+}
+
+// issueLoginTokens 为登录成功的用户开启新会话并签发访问令牌和刷新令牌，失败时已写入错误响应
+func (h *AuthHandler) issueLoginTokens(c *gin.Context, user *service.UserResponse) (*service.LoginResponse, bool) {
 	// 获取JWT管理器
 	jwtManager, err := h.container.GetJWTManager()
 	if err != nil {
 		h.logger.WithError(err).Error("Failed to get JWT manager")
 		response.InternalError(c, "认证服务不可用")
-		return
+		return nil, false
 	}
 
-	// 生成访问令牌
-	accessToken, err := jwtManager.GenerateToken(user.ID, user.Username, user.Email, user.Role)
+	// 每次登录开启一个新会话
+	sessionID, err := jwt.NewSessionID()
 	if err != nil {
-		h.logger.WithError(err).Error("Failed to generate access token")
+		h.logger.WithError(err).Error("Failed to generate session id")
 		response.InternalError(c, "令牌生成失败")
-		return
+		return nil, false
 	}
 
-	// 生成刷新令牌
-	refreshToken, err := jwtManager.GenerateRefreshToken(user.ID)
+	// 生成访问令牌和刷新令牌
+	pair, err := jwtManager.GenerateTokenPair(user.ID, user.Username, user.Email, user.Role, sessionID)
 	if err != nil {
-		h.logger.WithError(err).Error("Failed to generate refresh token")
-		response.InternalError(c, "刷新令牌生成失败")
-		return
+		h.logger.WithError(err).Error("Failed to generate tokens")
+		response.InternalError(c, "令牌生成失败")
+		return nil, false
+	}
+
+	// 登记刷新令牌，用于后续轮换校验
+	if h.tokenStore != nil {
+		if err := h.tokenStore.SaveRefreshToken(c.Request.Context(), user.ID, sessionID, pair.RefreshTokenID, jwtManager.GetRefreshExpiry()); err != nil {
+			h.logger.WithError(err).Error("Failed to save refresh token")
+			response.InternalError(c, "刷新令牌生成失败")
+			return nil, false
+		}
+
+		// 登记会话设备信息，吊销会话时据此使访问令牌失效
+		now := time.Now()
+		session := &cache.Session{
+			ID:         sessionID,
+			UserID:     user.ID,
+			UserAgent:  c.Request.UserAgent(),
+			IP:         c.ClientIP(),
+			CreatedAt:  now,
+			LastUsedAt: now,
+		}
+		if err := h.tokenStore.StartSession(c.Request.Context(), session, pair.AccessTokenID, pair.AccessExpiresAt, jwtManager.GetRefreshExpiry()); err != nil {
+			h.logger.WithError(err).Error("Failed to save session")
+			response.InternalError(c, "刷新令牌生成失败")
+			return nil, false
+		}
+	}
+
+	if err := h.container.GetServiceManager().UserService().RecordLogin(c.Request.Context(), user.ID, c.ClientIP()); err != nil {
+		h.logger.WithError(err).WithField("user_id", user.ID).Warn("Failed to record last login")
 	}
 
 	// 构建响应
-	loginResp := &service.LoginResponse{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
+	return &service.LoginResponse{
+		AccessToken:  pair.AccessToken,
+		RefreshToken: pair.RefreshToken,
 		ExpiresIn:    int64(jwtManager.GetTokenExpiry().Seconds()),
 		User: service.UserResponse{
 			ID:       user.ID,
@@ -80,17 +220,19 @@ func (h *AuthHandler) Login(c *gin.Context) {
 			Status:   user.Status,
 			Role:     user.Role,
 		},
-	}
-
-	h.logger.WithFields(logrus.Fields{
-		"user_id":  user.ID,
-		"username": user.Username,
-	}).Info("User login successful")
-
-	response.Success(c, loginResp)
+	}, true
 }
 
 // Register 用户注册
+// @Summary 用户注册
+// @Description 注册新用户
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Param request body service.CreateUserRequest true "注册请求"
+// @Success 200 {object} response.Response{data=service.UserResponse} "注册成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Router /api/v1/auth/register [post]
 func (h *AuthHandler) Register(c *gin.Context) {
 	var req service.CreateUserRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -119,13 +261,96 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	response.Success(c, user)
 }
 
-// RefreshToken 刷新令牌
-func (h *AuthHandler) RefreshToken(c *gin.Context) {
-	type RefreshRequest struct {
-		RefreshToken string `json:"refresh_token" binding:"required"`
+// ActivateAccount 使用激活令牌设置密码并激活账号
+// @Summary 激活账号
+// @Description 使用激活邮件中的令牌设置密码并激活账号
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Param request body service.ActivateAccountRequest true "激活请求"
+// @Success 200 {object} response.Response{data=MessageResult} "激活成功"
+// @Failure 400 {object} response.Response "请求参数错误或激活链接无效"
+// @Failure 410 {object} response.Response "激活链接已过期"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/auth/activate [post]
+func (h *AuthHandler) ActivateAccount(c *gin.Context) {
+	var req service.ActivateAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Warn("Invalid activation request")
+		response.BadRequest(c, "请求参数无效")
+		return
+	}
+
+	activationService := h.container.GetServiceManager().ActivationService()
+	if err := activationService.Activate(c.Request.Context(), &req); err != nil {
+		switch {
+		case errors.Is(err, service.ErrActivationTokenExpired):
+			response.ErrorWithCode(c, response.ErrCodeActivationTokenExpired, "激活链接已过期，请重新发送激活邮件")
+		case errors.Is(err, service.ErrActivationTokenInvalid):
+			response.ErrorWithCode(c, response.ErrCodeActivationTokenInvalid, "激活链接无效")
+		default:
+			h.logger.WithError(err).Error("Account activation failed")
+			response.InternalError(c, "账号激活失败")
+		}
+		return
 	}
 
-	var req RefreshRequest
+	response.Success(c, MessageResult{Message: "账号激活成功"})
+}
+
+// ResendActivation 重新发送激活邮件
+// @Summary 重新发送激活邮件
+// @Description 向待激活账号重新发送激活邮件，邮箱不存在时同样返回成功
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Param request body service.ResendActivationRequest true "重发请求"
+// @Success 200 {object} response.Response{data=MessageResult} "已发送"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 409 {object} response.Response "账号已激活"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/auth/resend-activation [post]
+func (h *AuthHandler) ResendActivation(c *gin.Context) {
+	var req service.ResendActivationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Warn("Invalid resend activation request")
+		response.BadRequest(c, "请求参数无效")
+		return
+	}
+
+	activationService := h.container.GetServiceManager().ActivationService()
+	if err := activationService.ResendActivation(c.Request.Context(), req.Email); err != nil {
+		if errors.Is(err, service.ErrAccountAlreadyActivated) {
+			response.ErrorWithCode(c, response.ErrCodeAccountAlreadyActivated, "账号已激活，请直接登录")
+			return
+		}
+		h.logger.WithError(err).WithField("email", req.Email).Error("Failed to resend activation email")
+		response.InternalError(c, "发送激活邮件失败")
+		return
+	}
+
+	response.Success(c, MessageResult{Message: "如果该邮箱存在待激活账号，激活邮件已发送"})
+}
+
+// RefreshTokenRequest 刷新令牌请求
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// RefreshToken 刷新令牌
+// @Summary 刷新令牌
+// @Description 使用刷新令牌换取新的令牌对，旧刷新令牌随即失效，重复使用会吊销整个会话
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Param request body RefreshTokenRequest true "刷新令牌请求"
+// @Success 200 {object} response.Response{data=service.LoginResponse} "刷新成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "刷新令牌无效或已过期"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/auth/refresh [post]
+func (h *AuthHandler) RefreshToken(c *gin.Context) {
+	var req RefreshTokenRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		h.logger.WithError(err).Warn("Invalid refresh token request")
 		response.BadRequest(c, "请求参数无效")
@@ -141,12 +366,35 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 	}
 
 	// 验证刷新令牌并获取用户ID
-	userID, err := jwtManager.ValidateRefreshToken(req.RefreshToken)
+	claims, err := jwtManager.ValidateRefreshToken(req.RefreshToken)
 	if err != nil {
 		h.logger.WithError(err).Warn("Invalid refresh token")
 		response.Unauthorized(c, "刷新令牌无效或已过期")
 		return
 	}
+	userID := claims.UserID
+
+	// 消费旧刷新令牌，每个刷新令牌只能使用一次
+	if h.tokenStore != nil {
+		consumed, err := h.tokenStore.ConsumeRefreshToken(c.Request.Context(), userID, claims.SessionID, claims.ID, jwtManager.GetRefreshExpiry())
+		if err != nil {
+			h.logger.WithError(err).WithField("user_id", userID).Error("Failed to consume refresh token")
+			response.InternalError(c, "令牌刷新失败")
+			return
+		}
+		if !consumed {
+			// 已轮换的刷新令牌被重复使用，视为泄露，吊销整个会话
+			h.logger.WithFields(logrus.Fields{
+				"user_id":    userID,
+				"session_id": claims.SessionID,
+			}).Warn("Refresh token reuse detected, revoking session")
+			if err := h.tokenStore.RevokeSession(c.Request.Context(), userID, claims.SessionID); err != nil {
+				h.logger.WithError(err).Warn("Failed to revoke session after refresh token reuse")
+			}
+			response.Unauthorized(c, "刷新令牌无效或已过期")
+			return
+		}
+	}
 
 	// 获取用户信息
 	userService := h.container.GetServiceManager().UserService()
@@ -157,18 +405,32 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 		return
 	}
 
-	// 生成新的访问令牌和刷新令牌
-	newAccessToken, newRefreshToken, err := jwtManager.RefreshToken(req.RefreshToken, user.Username, user.Email, user.Role)
+	// 生成新的访问令牌和刷新令牌，沿用原会话
+	pair, err := jwtManager.GenerateTokenPair(userID, user.Username, user.Email, user.Role, claims.SessionID)
 	if err != nil {
 		h.logger.WithError(err).Error("Failed to refresh tokens")
 		response.InternalError(c, "令牌刷新失败")
 		return
 	}
 
+	if h.tokenStore != nil {
+		if err := h.tokenStore.SaveRefreshToken(c.Request.Context(), userID, claims.SessionID, pair.RefreshTokenID, jwtManager.GetRefreshExpiry()); err != nil {
+			h.logger.WithError(err).Error("Failed to save rotated refresh token")
+			response.InternalError(c, "令牌刷新失败")
+			return
+		}
+		if err := h.tokenStore.TouchSession(c.Request.Context(), userID, claims.SessionID, c.Request.UserAgent(), c.ClientIP(),
+			pair.AccessTokenID, pair.AccessExpiresAt, time.Now(), jwtManager.GetRefreshExpiry()); err != nil {
+			h.logger.WithError(err).Error("Failed to update session")
+			response.InternalError(c, "令牌刷新失败")
+			return
+		}
+	}
+
 	// 构建响应
 	loginResp := &service.LoginResponse{
-		AccessToken:  newAccessToken,
-		RefreshToken: newRefreshToken,
+		AccessToken:  pair.AccessToken,
+		RefreshToken: pair.RefreshToken,
 		ExpiresIn:    int64(jwtManager.GetTokenExpiry().Seconds()),
 		User:         *user,
 	}
@@ -181,7 +443,15 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 	response.Success(c, loginResp)
 }
 
-// Logout 用户登出
+// Logout 用户登出，吊销当前访问令牌及所在会话的刷新令牌
+// @Summary 用户登出
+// @Description 吊销当前访问令牌及所在会话的刷新令牌
+// @Tags 认证
+// @Produce json
+// @Success 200 {object} response.Response{data=MessageResult} "登出成功"
+// @Failure 401 {object} response.Response "未授权"
+// @Router /api/v1/auth/logout [post]
+// @Security BearerAuth
 func (h *AuthHandler) Logout(c *gin.Context) {
 	userID := c.GetUint("user_id")
 
@@ -194,6 +464,72 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 		// 即使登出操作失败，也返回成功，因为客户端可以丢弃令牌
 	}
 
+	if h.tokenStore != nil {
+		ctx := c.Request.Context()
+		if err := h.tokenStore.RevokeAccessToken(ctx, c.GetString("token_id"), h.remainingTokenTTL(c)); err != nil {
+			h.logger.WithError(err).WithField("user_id", userID).Warn("Failed to revoke access token")
+		}
+		if sessionID := c.GetString("session_id"); sessionID != "" {
+			if err := h.tokenStore.RevokeSession(ctx, userID, sessionID); err != nil {
+				h.logger.WithError(err).WithField("user_id", userID).Warn("Failed to revoke session")
+			}
+		}
+	}
+
 	h.logger.WithField("user_id", userID).Info("User logout successful")
-	response.Success(c, gin.H{"message": "登出成功"})
+	response.Success(c, MessageResult{Message: "登出成功"})
+}
+
+// LogoutAll 下线用户的全部会话
+// @Summary 下线全部会话
+// @Description 吊销当前用户在所有设备上的令牌
+// @Tags 认证
+// @Produce json
+// @Success 200 {object} response.Response{data=MessageResult} "已下线全部会话"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/auth/logout-all [post]
+// @Security BearerAuth
+func (h *AuthHandler) LogoutAll(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	if h.tokenStore == nil {
+		response.InternalError(c, "令牌吊销服务不可用")
+		return
+	}
+
+	jwtManager, err := h.container.GetJWTManager()
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to get JWT manager")
+		response.InternalError(c, "认证服务不可用")
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.tokenStore.RevokeAllForUser(ctx, userID, jwtManager.GetTokenExpiry()); err != nil {
+		h.logger.WithError(err).WithField("user_id", userID).Error("Failed to revoke all sessions")
+		response.InternalError(c, "下线全部会话失败")
+		return
+	}
+
+	// 当前令牌可能与吊销时间处于同一秒，单独加入黑名单
+	if err := h.tokenStore.RevokeAccessToken(ctx, c.GetString("token_id"), h.remainingTokenTTL(c)); err != nil {
+		h.logger.WithError(err).WithField("user_id", userID).Warn("Failed to revoke access token")
+	}
+
+	h.logger.WithField("user_id", userID).Info("All sessions revoked")
+	response.Success(c, MessageResult{Message: "已下线全部会话"})
+}
+
+// remainingTokenTTL 计算当前访问令牌的剩余有效期
+func (h *AuthHandler) remainingTokenTTL(c *gin.Context) time.Duration {
+	value, exists := c.Get("token_expires_at")
+	if !exists {
+		return 0
+	}
+	expiresAt, ok := value.(time.Time)
+	if !ok {
+		return 0
+	}
+	return time.Until(expiresAt)
 }
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"taskmanage/internal/service"
+	"taskmanage/pkg/oidc"
+	"taskmanage/pkg/response"
+)
+
+// OIDCLogin 单点登录跳转
+// @Summary 单点登录跳转
+// @Description 生成state和nonce并保存在服务端，然后跳转到OIDC提供方的授权页面
+// @Tags 认证
+// @Success 302 "跳转到提供方授权页面"
+// @Failure 404 {object} response.Response "未启用单点登录"
+// @Failure 500 {object} response.Response "单点登录服务不可用"
+// @Router /api/v1/auth/oidc/login [get]
+func (h *AuthHandler) OIDCLogin(c *gin.Context) {
+	if h.oidcProvider == nil {
+		response.NotFound(c, "未启用单点登录")
+		return
+	}
+
+	state, err := oidc.NewRandomValue()
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to generate oidc state")
+		response.InternalError(c, "单点登录服务不可用")
+		return
+	}
+	nonce, err := oidc.NewRandomValue()
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to generate oidc nonce")
+		response.InternalError(c, "单点登录服务不可用")
+		return
+	}
+
+	authURL, err := h.oidcProvider.AuthCodeURL(c.Request.Context(), state, nonce)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to build oidc authorization url")
+		response.InternalError(c, "单点登录服务不可用")
+		return
+	}
+	if err := h.oidcStates.Save(c.Request.Context(), state, nonce); err != nil {
+		h.logger.WithError(err).Error("Failed to save oidc state")
+		response.InternalError(c, "单点登录服务不可用")
+		return
+	}
+
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// OIDCCallback 单点登录回调
+// @Summary 单点登录回调
+// @Description 校验state、用授权码换取ID令牌并校验签名、签发方、受众、有效期和nonce，按邮箱关联系统用户后签发访问令牌和刷新令牌。
+// @Description 邮箱没有对应用户时，开启自动开通则创建待入职员工并发送激活邮件，激活后才能登录
+// @Tags 认证
+// @Produce json
+// @Param state query string true "登录跳转时生成的state"
+// @Param code query string true "提供方返回的授权码"
+// @Success 200 {object} response.Response{data=service.LoginResponse} "登录成功"
+// @Failure 400 {object} response.Response "state无效或已过期"
+// @Failure 401 {object} response.Response "授权码或ID令牌无效"
+// @Failure 403 {object} response.Response "没有对应账号、账号待激活或已禁用"
+// @Failure 404 {object} response.Response "未启用单点登录"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/auth/oidc/callback [get]
+func (h *AuthHandler) OIDCCallback(c *gin.Context) {
+	if h.oidcProvider == nil {
+		response.NotFound(c, "未启用单点登录")
+		return
+	}
+
+	if providerErr := c.Query("error"); providerErr != "" {
+		h.logger.WithFields(logrus.Fields{
+			"error":       providerErr,
+			"description": c.Query("error_description"),
+		}).Warn("OIDC provider returned error")
+		response.Unauthorized(c, "单点登录授权失败")
+		return
+	}
+
+	state, code := c.Query("state"), c.Query("code")
+	if state == "" || code == "" {
+		response.BadRequest(c, "缺少state或code参数")
+		return
+	}
+
+	// state只能使用一次，与登录跳转时保存的不一致或已过期时拒绝
+	nonce, err := h.oidcStates.Consume(c.Request.Context(), state)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to consume oidc state")
+		response.InternalError(c, "单点登录服务不可用")
+		return
+	}
+	if nonce == "" {
+		response.BadRequest(c, "登录状态无效或已过期，请重新登录")
+		return
+	}
+
+	rawIDToken, err := h.oidcProvider.Exchange(c.Request.Context(), code)
+	if err != nil {
+		h.logger.WithError(err).Warn("OIDC code exchange failed")
+		if errors.Is(err, oidc.ErrDiscovery) {
+			response.InternalError(c, "单点登录服务不可用")
+			return
+		}
+		response.Unauthorized(c, "授权码无效")
+		return
+	}
+
+	claims, err := h.oidcProvider.Verify(c.Request.Context(), rawIDToken, nonce)
+	if err != nil {
+		h.logger.WithError(err).Warn("OIDC id token verification failed")
+		if errors.Is(err, oidc.ErrDiscovery) {
+			response.InternalError(c, "单点登录服务不可用")
+			return
+		}
+		response.Unauthorized(c, "身份令牌校验失败")
+		return
+	}
+
+	user, err := h.container.GetServiceManager().SSOService().ResolveOIDCUser(c.Request.Context(), &service.OIDCIdentity{
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		Name:          claims.Name,
+	})
+	if err != nil {
+		h.logger.WithError(err).WithField("email", claims.Email).Warn("OIDC user resolution failed")
+		switch {
+		case errors.Is(err, service.ErrSSOEmailMissing):
+			response.Unauthorized(c, err.Error())
+		case errors.Is(err, service.ErrSSOUserNotFound),
+			errors.Is(err, service.ErrSSOAccountPending),
+			errors.Is(err, service.ErrSSOUserDisabled):
+			response.Forbidden(c, err.Error())
+		default:
+			response.InternalError(c, "单点登录失败")
+		}
+		return
+	}
+
+	loginResp, ok := h.issueLoginTokens(c, user)
+	if !ok {
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"user_id":  user.ID,
+		"username": user.Username,
+	}).Info("User oidc login successful")
+
+	response.Success(c, loginResp)
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"taskmanage/internal/service"
+	"taskmanage/pkg/response"
+)
+
+// DashboardHandler 当前用户工作台处理器
+type DashboardHandler struct {
+	dashboardService service.DashboardService
+	logger           *logrus.Logger
+}
+
+// NewDashboardHandler 创建当前用户工作台处理器
+func NewDashboardHandler(dashboardService service.DashboardService, logger *logrus.Logger) *DashboardHandler {
+	return &DashboardHandler{
+		dashboardService: dashboardService,
+		logger:           logger,
+	}
+}
+
+// GetMyDashboard 获取我的工作台
+// @Summary 获取我的工作台
+// @Description 一次返回首页所需的我的任务、待审批、未读通知、参与的进行中项目和7天内到期任务，各项列表最多5条。
+// @Description 各分区并发加载并单独超时，失败的分区为null，原因见errors。响应带ETag，请求携带If-None-Match且内容未变化时返回304
+// @Tags 个人中心
+// @Produce json
+// @Param If-None-Match header string false "上次响应的ETag"
+// @Success 200 {object} response.Response{data=service.MyDashboard} "获取成功"
+// @Success 304 "内容未变化"
+// @Failure 401 {object} response.Response "未认证"
+// @Router /api/v1/me/dashboard [get]
+// @Security BearerAuth
+func (h *DashboardHandler) GetMyDashboard(c *gin.Context) {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		response.Unauthorized(c, "用户信息缺失")
+		return
+	}
+
+	dashboard, err := h.dashboardService.GetMyDashboard(c.Request.Context(), userID)
+	if err != nil {
+		response.FromError(c, err)
+		return
+	}
+
+	response.SuccessWithETag(c, dashboard)
+}
@@ -1,12 +1,15 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 	"taskmanage/internal/service"
+	"taskmanage/internal/workflow"
+	"taskmanage/pkg/response"
 )
 
 // DepartmentHandler 部门处理器
@@ -24,6 +27,16 @@ func NewDepartmentHandler(departmentService service.DepartmentService, logger *l
 }
 
 // CreateDepartment 创建部门
+// @Summary 创建部门
+// @Tags 部门管理
+// @Accept json
+// @Produce json
+// @Param request body service.CreateDepartmentRequest true "创建部门请求"
+// @Success 201 {object} DataResponse{data=service.DepartmentResponse} "创建成功"
+// @Failure 400 {object} ErrorResponse "请求参数错误或管理者不合法"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/departments [post]
+// @Security BearerAuth
 func (h *DepartmentHandler) CreateDepartment(c *gin.Context) {
 	var req service.CreateDepartmentRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -40,6 +53,10 @@ func (h *DepartmentHandler) CreateDepartment(c *gin.Context) {
 	department, err := h.departmentService.CreateDepartment(c.Request.Context(), &req)
 	if err != nil {
 		h.logger.WithError(err).Error("创建部门失败")
+		if errors.Is(err, service.ErrInvalidDepartmentManager) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "部门管理者不合法", "details": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建部门失败", "details": err.Error()})
 		return
 	}
@@ -51,6 +68,17 @@ func (h *DepartmentHandler) CreateDepartment(c *gin.Context) {
 }
 
 // UpdateDepartment 更新部门
+// @Summary 更新部门
+// @Tags 部门管理
+// @Accept json
+// @Produce json
+// @Param id path int true "部门ID"
+// @Param request body service.UpdateDepartmentRequest true "更新部门请求"
+// @Success 200 {object} DataResponse{data=service.DepartmentResponse} "更新成功"
+// @Failure 400 {object} ErrorResponse "请求参数错误或管理者不合法"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/departments/{id} [put]
+// @Security BearerAuth
 func (h *DepartmentHandler) UpdateDepartment(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
@@ -75,6 +103,10 @@ func (h *DepartmentHandler) UpdateDepartment(c *gin.Context) {
 	department, err := h.departmentService.UpdateDepartment(c.Request.Context(), uint(id), &req)
 	if err != nil {
 		h.logger.WithError(err).Error("更新部门失败")
+		if errors.Is(err, service.ErrInvalidDepartmentManager) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "部门管理者不合法", "details": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新部门失败", "details": err.Error()})
 		return
 	}
@@ -86,6 +118,20 @@ func (h *DepartmentHandler) UpdateDepartment(c *gin.Context) {
 }
 
 // DeleteDepartment 删除部门
+// @Summary 删除部门
+// @Description 部门仍被未离职员工、子部门、项目、权限模板或入职权限配置引用时返回409，details为各类引用数。
+// @Description 指定reassign_to时在同一事务中将员工、项目、子部门、部门专属权限模板、入职权限配置和审批链迁移到目标部门后再删除，目标部门不能是待删除部门的下级部门或已停用部门
+// @Tags 部门管理
+// @Produce json
+// @Param id path int true "部门ID"
+// @Param reassign_to query int false "引用迁移到的目标部门ID"
+// @Success 200 {object} DataResponse "删除成功"
+// @Failure 400 {object} ErrorResponse "请求参数错误或迁移目标不合法"
+// @Failure 404 {object} ErrorResponse "部门不存在"
+// @Failure 409 {object} response.Response{details=repository.DepartmentReferences} "部门仍被引用"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/departments/{id} [delete]
+// @Security BearerAuth
 func (h *DepartmentHandler) DeleteDepartment(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
@@ -95,11 +141,29 @@ func (h *DepartmentHandler) DeleteDepartment(c *gin.Context) {
 		return
 	}
 
+	var reassignTo *uint
+	if raw := c.Query("reassign_to"); raw != "" {
+		targetID, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil || targetID == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的迁移目标ID"})
+			return
+		}
+		target := uint(targetID)
+		reassignTo = &target
+	}
+
 	h.logger.WithField("id", id).Info("处理删除部门请求")
 
-	if err := h.departmentService.DeleteDepartment(c.Request.Context(), uint(id)); err != nil {
+	if err := h.departmentService.DeleteDepartment(c.Request.Context(), uint(id), reassignTo); err != nil {
 		h.logger.WithError(err).Error("删除部门失败")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除部门失败", "details": err.Error()})
+		switch {
+		case errors.Is(err, service.ErrDepartmentNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "部门不存在", "details": err.Error()})
+		case errors.Is(err, service.ErrDepartmentInUse), errors.Is(err, service.ErrInvalidReassignTarget):
+			response.FromError(c, err)
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "删除部门失败", "details": err.Error()})
+		}
 		return
 	}
 
@@ -107,6 +171,15 @@ func (h *DepartmentHandler) DeleteDepartment(c *gin.Context) {
 }
 
 // GetDepartment 获取部门详情
+// @Summary 获取部门详情
+// @Tags 部门管理
+// @Produce json
+// @Param id path int true "部门ID"
+// @Success 200 {object} DataResponse{data=service.DepartmentResponse} "获取成功"
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 404 {object} ErrorResponse "部门不存在"
+// @Router /api/v1/departments/{id} [get]
+// @Security BearerAuth
 func (h *DepartmentHandler) GetDepartment(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
@@ -129,6 +202,19 @@ func (h *DepartmentHandler) GetDepartment(c *gin.Context) {
 }
 
 // ListDepartments 获取部门列表
+// @Summary 获取部门列表
+// @Tags 部门管理
+// @Produce json
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Param sort query string false "排序字段"
+// @Param order query string false "排序方向" Enums(asc,desc)
+// @Param keyword query string false "搜索关键词"
+// @Success 200 {object} service.ListResponse[service.DepartmentResponse] "获取成功"
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/departments [get]
+// @Security BearerAuth
 func (h *DepartmentHandler) ListDepartments(c *gin.Context) {
 	var req service.ListRequest
 	if err := c.ShouldBindQuery(&req); err != nil {
@@ -153,6 +239,14 @@ func (h *DepartmentHandler) ListDepartments(c *gin.Context) {
 }
 
 // GetDepartmentTree 获取部门树结构
+// @Summary 获取部门树
+// @Description 返回根部门及其逐级子部门
+// @Tags 部门管理
+// @Produce json
+// @Success 200 {object} DataResponse{data=[]service.DepartmentResponse} "获取成功"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/departments/tree [get]
+// @Security BearerAuth
 func (h *DepartmentHandler) GetDepartmentTree(c *gin.Context) {
 	h.logger.Debug("处理获取部门树请求")
 
@@ -167,6 +261,13 @@ func (h *DepartmentHandler) GetDepartmentTree(c *gin.Context) {
 }
 
 // GetRootDepartments 获取根部门
+// @Summary 获取根部门
+// @Tags 部门管理
+// @Produce json
+// @Success 200 {object} DataResponse{data=[]service.DepartmentResponse} "获取成功"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/departments/roots [get]
+// @Security BearerAuth
 func (h *DepartmentHandler) GetRootDepartments(c *gin.Context) {
 	h.logger.Debug("处理获取根部门请求")
 
@@ -181,6 +282,15 @@ func (h *DepartmentHandler) GetRootDepartments(c *gin.Context) {
 }
 
 // GetSubDepartments 获取子部门
+// @Summary 获取子部门
+// @Tags 部门管理
+// @Produce json
+// @Param id path int true "部门ID"
+// @Success 200 {object} DataResponse{data=[]service.DepartmentResponse} "获取成功"
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/departments/{id}/sub [get]
+// @Security BearerAuth
 func (h *DepartmentHandler) GetSubDepartments(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
@@ -203,6 +313,18 @@ func (h *DepartmentHandler) GetSubDepartments(c *gin.Context) {
 }
 
 // UpdateDepartmentManager 更新部门管理者
+// @Summary 更新部门管理者
+// @Tags 部门管理
+// @Accept json
+// @Produce json
+// @Param id path int true "部门ID"
+// @Param request body service.UpdateDepartmentManagerRequest true "部门管理者"
+// @Success 200 {object} DataResponse{data=service.DepartmentManagerResult} "更新成功"
+// @Failure 400 {object} ErrorResponse "请求参数错误或管理者不合法"
+// @Failure 404 {object} ErrorResponse "部门不存在"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/departments/{id}/manager [put]
+// @Security BearerAuth
 func (h *DepartmentHandler) UpdateDepartmentManager(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
@@ -212,9 +334,7 @@ func (h *DepartmentHandler) UpdateDepartmentManager(c *gin.Context) {
 		return
 	}
 
-	var req struct {
-		ManagerID uint `json:"manager_id" binding:"required"`
-	}
+	var req service.UpdateDepartmentManagerRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		h.logger.WithError(err).Error("绑定更新管理者请求失败")
 		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数无效", "details": err.Error()})
@@ -222,15 +342,183 @@ func (h *DepartmentHandler) UpdateDepartmentManager(c *gin.Context) {
 	}
 
 	h.logger.WithFields(logrus.Fields{
-		"department_id": id,
-		"manager_id":    req.ManagerID,
+		"department_id":       id,
+		"manager_id":          req.ManagerID,
+		"sync_direct_manager": req.SyncDirectManager,
 	}).Info("处理更新部门管理者请求")
 
-	if err := h.departmentService.UpdateManager(c.Request.Context(), uint(id), req.ManagerID); err != nil {
+	result, err := h.departmentService.UpdateManager(c.Request.Context(), uint(id), &req)
+	if err != nil {
 		h.logger.WithError(err).Error("更新部门管理者失败")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新部门管理者失败", "details": err.Error()})
+		switch {
+		case errors.Is(err, service.ErrDepartmentNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "部门不存在", "details": err.Error()})
+		case errors.Is(err, service.ErrInvalidDepartmentManager):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "部门管理者不合法", "details": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "更新部门管理者失败", "details": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "部门管理者更新成功",
+		"data":    result,
+	})
+}
+
+// MergeDepartment 将部门合并到目标部门
+// @Summary 合并部门
+// @Description 将部门的员工、子部门和审批链迁移到目标部门并停用源部门
+// @Tags 部门管理
+// @Accept json
+// @Produce json
+// @Param id path int true "源部门ID"
+// @Param request body service.MergeDepartmentRequest true "合并部门请求"
+// @Success 200 {object} DataResponse{data=service.DepartmentMergeResult} "合并成功"
+// @Failure 400 {object} ErrorResponse "请求参数错误或部门合并不合法"
+// @Failure 404 {object} ErrorResponse "部门不存在"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/departments/{id}/merge [post]
+// @Security BearerAuth
+func (h *DepartmentHandler) MergeDepartment(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		h.logger.WithError(err).WithField("id", idStr).Error("解析部门ID失败")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的部门ID"})
+		return
+	}
+
+	var req service.MergeDepartmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("绑定合并部门请求失败")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数无效", "details": err.Error()})
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"source_department_id": id,
+		"target_department_id": req.TargetDepartmentID,
+	}).Info("处理合并部门请求")
+
+	result, err := h.departmentService.MergeDepartment(c.Request.Context(), uint(id), &req)
+	if err != nil {
+		h.logger.WithError(err).Error("合并部门失败")
+		switch {
+		case errors.Is(err, service.ErrDepartmentNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "部门不存在", "details": err.Error()})
+		case errors.Is(err, service.ErrInvalidDepartmentMerge):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "部门合并不合法", "details": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "合并部门失败", "details": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "部门合并成功",
+		"data":    result,
+	})
+}
+
+// SetAssignmentStrategy 设置部门默认分配策略
+// @Summary 设置部门默认分配策略
+// @Description 设置部门任务自动分配时使用的默认策略，策略为空时恢复系统默认策略
+// @Tags 部门管理
+// @Accept json
+// @Produce json
+// @Param id path int true "部门ID"
+// @Param request body service.UpdateDepartmentAssignmentStrategyRequest true "默认分配策略"
+// @Success 200 {object} DataResponse{data=service.DepartmentResponse} "设置成功"
+// @Failure 400 {object} ErrorResponse "请求参数错误或分配策略未注册"
+// @Failure 404 {object} ErrorResponse "部门不存在"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/departments/{id}/assignment-strategy [put]
+// @Security BearerAuth
+func (h *DepartmentHandler) SetAssignmentStrategy(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		h.logger.WithError(err).WithField("id", idStr).Error("解析部门ID失败")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的部门ID"})
+		return
+	}
+
+	var req service.UpdateDepartmentAssignmentStrategyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("绑定设置默认分配策略请求失败")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数无效", "details": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "部门管理者更新成功"})
+	department, err := h.departmentService.SetAssignmentStrategy(c.Request.Context(), uint(id), &req)
+	if err != nil {
+		h.logger.WithError(err).Error("设置部门默认分配策略失败")
+		switch {
+		case errors.Is(err, service.ErrDepartmentNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "部门不存在", "details": err.Error()})
+		case errors.Is(err, service.ErrUnknownAssignmentStrategy):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "分配策略未注册", "details": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "设置部门默认分配策略失败", "details": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "部门默认分配策略设置成功",
+		"data":    department,
+	})
 }
+
+// SetOnboardingWorkflow 设置部门入职审批流程
+// @Summary 设置部门入职审批流程
+// @Description 设置部门员工入职审批使用的流程：simple为HR审批，full为HR、部门负责人、管理员依次审批；类型为空时恢复系统默认流程
+// @Tags 部门管理
+// @Accept json
+// @Produce json
+// @Param id path int true "部门ID"
+// @Param request body service.UpdateDepartmentOnboardingWorkflowRequest true "入职审批流程类型"
+// @Success 200 {object} DataResponse{data=service.DepartmentResponse} "设置成功"
+// @Failure 400 {object} ErrorResponse "请求参数错误或流程类型未知"
+// @Failure 404 {object} ErrorResponse "部门不存在"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/departments/{id}/onboarding-workflow [put]
+// @Security BearerAuth
+func (h *DepartmentHandler) SetOnboardingWorkflow(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		h.logger.WithError(err).WithField("id", idStr).Error("解析部门ID失败")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的部门ID"})
+		return
+	}
+
+	var req service.UpdateDepartmentOnboardingWorkflowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("绑定设置入职审批流程请求失败")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数无效", "details": err.Error()})
+		return
+	}
+
+	department, err := h.departmentService.SetOnboardingWorkflowType(c.Request.Context(), uint(id), &req)
+	if err != nil {
+		h.logger.WithError(err).Error("设置部门入职审批流程失败")
+		switch {
+		case errors.Is(err, service.ErrDepartmentNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "部门不存在", "details": err.Error()})
+		case errors.Is(err, workflow.ErrUnknownOnboardingWorkflowType):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "入职审批流程类型未知", "details": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "设置部门入职审批流程失败", "details": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "部门入职审批流程设置成功",
+		"data":    department,
+	})
+}
+
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"taskmanage/internal/service"
+	"taskmanage/pkg/response"
+)
+
+// EmailOutboxHandler 邮件发件箱处理器
+type EmailOutboxHandler struct {
+	emailNotifier service.EmailNotifier
+	logger        *logrus.Logger
+}
+
+// NewEmailOutboxHandler 创建邮件发件箱处理器
+func NewEmailOutboxHandler(emailNotifier service.EmailNotifier, logger *logrus.Logger) *EmailOutboxHandler {
+	return &EmailOutboxHandler{
+		emailNotifier: emailNotifier,
+		logger:        logger,
+	}
+}
+
+// ListOutbox 查询邮件发件箱
+// @Summary 查询邮件发件箱
+// @Description 按状态分页查询通知邮件的投递状态、尝试次数和最近错误，用于排查积压或发送失败的邮件
+// @Tags 系统管理
+// @Produce json
+// @Param status query string false "状态: pending, sent, failed"
+// @Param user_id query int false "收件用户ID"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} response.Response{data=service.ListResponse[service.EmailOutboxResponse]}
+// @Failure 400 {object} response.Response
+// @Router /api/v1/admin/email-outbox [get]
+// @Security BearerAuth
+func (h *EmailOutboxHandler) ListOutbox(c *gin.Context) {
+	var req service.EmailOutboxListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.BindError(c, err)
+		return
+	}
+
+	result, err := h.emailNotifier.ListOutbox(c.Request.Context(), &req)
+	if err != nil {
+		h.logger.WithError(err).Error("查询邮件发件箱失败")
+		response.InternalError(c, "查询邮件发件箱失败")
+		return
+	}
+	response.Success(c, result)
+}
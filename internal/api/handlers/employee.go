@@ -1,12 +1,15 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 
 	"taskmanage/internal/container"
+	"taskmanage/internal/repository"
 	"taskmanage/internal/service"
 	"taskmanage/pkg/response"
 )
@@ -23,6 +26,19 @@ func NewEmployeeHandler(container *container.ApplicationContainer, logger *logru
 }
 
 // ListEmployees 获取员工列表
+// @Summary 获取员工列表
+// @Description 分页获取员工列表，支持按部门、职位和状态过滤
+// @Tags 员工管理
+// @Produce json
+// @Param page query int false "页码" default(1)
+// @Param size query int false "每页数量" default(20)
+// @Param department query string false "部门"
+// @Param position query string false "职位"
+// @Param status query string false "员工状态"
+// @Success 200 {object} response.PaginationResponse{data=[]service.EmployeeResponse} "获取成功"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/employees [get]
+// @Security BearerAuth
 func (h *EmployeeHandler) ListEmployees(c *gin.Context) {
 	// 解析查询参数
 	pageStr := c.DefaultQuery("page", "1")
@@ -71,11 +87,19 @@ func (h *EmployeeHandler) ListEmployees(c *gin.Context) {
 }
 
 // CreateEmployee 创建员工
+// @Summary 创建员工
+// @Tags 员工管理
+// @Accept json
+// @Produce json
+// @Param request body service.CreateEmployeeRequest true "创建员工请求"
+// @Success 200 {object} response.Response{data=service.EmployeeResponse} "创建成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/employees [post]
+// @Security BearerAuth
 func (h *EmployeeHandler) CreateEmployee(c *gin.Context) {
 	var req service.CreateEmployeeRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.WithError(err).Warn("Invalid create employee request")
-		response.BadRequest(c, "请求参数无效")
+	if !response.BindAndValidate(c, &req) {
 		return
 	}
 
@@ -99,6 +123,15 @@ func (h *EmployeeHandler) CreateEmployee(c *gin.Context) {
 }
 
 // GetEmployee 获取员工详情
+// @Summary 获取员工详情
+// @Tags 员工管理
+// @Produce json
+// @Param id path int true "员工ID"
+// @Success 200 {object} response.Response{data=service.EmployeeResponse} "获取成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 404 {object} response.Response "员工不存在"
+// @Router /api/v1/employees/{id} [get]
+// @Security BearerAuth
 func (h *EmployeeHandler) GetEmployee(c *gin.Context) {
 	employeeID := c.Param("id")
 	if employeeID == "" {
@@ -128,6 +161,20 @@ func (h *EmployeeHandler) GetEmployee(c *gin.Context) {
 }
 
 // UpdateEmployee 更新员工信息
+// @Summary 更新员工
+// @Description 职位变化时记录变更历史；职级变化时按position_change权限规则调整权限，结果见permission_change
+// @Tags 员工管理
+// @Accept json
+// @Produce json
+// @Param id path int true "员工ID"
+// @Param request body service.UpdateEmployeeRequest true "更新员工请求"
+// @Success 200 {object} response.Response{data=service.EmployeeResponse} "更新成功"
+// @Failure 400 {object} response.Response "请求参数错误或职位不存在"
+// @Failure 401 {object} response.Response "未认证"
+// @Failure 409 {object} response.Response{data=service.EmployeeResponse} "员工已被修改，data为最新状态"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/employees/{id} [put]
+// @Security BearerAuth
 func (h *EmployeeHandler) UpdateEmployee(c *gin.Context) {
 	employeeID := c.Param("id")
 	if employeeID == "" {
@@ -143,11 +190,15 @@ func (h *EmployeeHandler) UpdateEmployee(c *gin.Context) {
 	}
 
 	var req service.UpdateEmployeeRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.WithError(err).Warn("Invalid update employee request")
-		response.BadRequest(c, "请求参数无效")
+	if !response.BindAndValidate(c, &req) {
+		return
+	}
+	operatorID, err := GetUserIDFromContext(c)
+	if err != nil {
+		response.Unauthorized(c, "用户信息缺失")
 		return
 	}
+	req.OperatorID = operatorID
 
 	h.logger.WithFields(logrus.Fields{
 		"employee_id":   id,
@@ -160,6 +211,15 @@ func (h *EmployeeHandler) UpdateEmployee(c *gin.Context) {
 	employeeService := h.container.GetServiceManager().EmployeeService()
 	employee, err := employeeService.UpdateEmployee(c.Request.Context(), uint(id), &req)
 	if err != nil {
+		var conflict *service.ConflictError
+		if errors.As(err, &conflict) {
+			response.ConflictWithData(c, conflict.Error(), conflict.Current)
+			return
+		}
+		if response.IsAppError(err) {
+			response.FromError(c, err)
+			return
+		}
 		h.logger.WithError(err).Error("Failed to update employee")
 		response.InternalError(c, "更新员工失败")
 		return
@@ -169,6 +229,15 @@ func (h *EmployeeHandler) UpdateEmployee(c *gin.Context) {
 }
 
 // DeleteEmployee 删除员工
+// @Summary 删除员工
+// @Tags 员工管理
+// @Produce json
+// @Param id path int true "员工ID"
+// @Success 200 {object} response.Response{data=MessageResult} "删除成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/employees/{id} [delete]
+// @Security BearerAuth
 func (h *EmployeeHandler) DeleteEmployee(c *gin.Context) {
 	employeeID := c.Param("id")
 	if employeeID == "" {
@@ -189,15 +258,27 @@ func (h *EmployeeHandler) DeleteEmployee(c *gin.Context) {
 	employeeService := h.container.GetServiceManager().EmployeeService()
 	err = employeeService.DeleteEmployee(c.Request.Context(), uint(id))
 	if err != nil {
+		if response.IsAppError(err) {
+			response.FromError(c, err)
+			return
+		}
 		h.logger.WithError(err).Error("Failed to delete employee")
 		response.InternalError(c, "删除员工失败")
 		return
 	}
 
-	response.Success(c, gin.H{"message": "员工删除成功"})
+	response.Success(c, MessageResult{Message: "员工删除成功"})
 }
 
 // GetAvailableEmployees 获取可用员工列表
+// @Summary 获取可用员工
+// @Description 获取当前可以接受任务分配的员工
+// @Tags 员工管理
+// @Produce json
+// @Success 200 {object} response.Response{data=[]service.EmployeeResponse} "获取成功"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/employees/available [get]
+// @Security BearerAuth
 func (h *EmployeeHandler) GetAvailableEmployees(c *gin.Context) {
 	// 获取查询参数
 	skills := c.QueryArray("skills")
@@ -223,6 +304,16 @@ func (h *EmployeeHandler) GetAvailableEmployees(c *gin.Context) {
 }
 
 // GetEmployeeWorkload 获取员工工作负载
+// @Summary 获取员工工作负载
+// @Tags 员工管理
+// @Produce json
+// @Param id path int true "员工ID"
+// @Param include_computed query bool false "同时返回按任务表统计的实际任务数"
+// @Success 200 {object} response.Response{data=service.WorkloadResponse} "获取成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 404 {object} response.Response "员工不存在"
+// @Router /api/v1/employees/{id}/workload [get]
+// @Security BearerAuth
 func (h *EmployeeHandler) GetEmployeeWorkload(c *gin.Context) {
 	employeeID := c.Param("id")
 	if employeeID == "" {
@@ -241,7 +332,9 @@ func (h *EmployeeHandler) GetEmployeeWorkload(c *gin.Context) {
 
 	// 调用服务层
 	employeeService := h.container.GetServiceManager().EmployeeService()
-	workload, err := employeeService.GetEmployeeWorkload(c.Request.Context(), uint(id))
+	// include_computed=true时同时返回按任务表统计的实际任务数，用于监控计数偏差
+	includeComputed := c.Query("include_computed") == "true"
+	workload, err := employeeService.GetEmployeeWorkload(c.Request.Context(), uint(id), includeComputed)
 	if err != nil {
 		h.logger.WithError(err).Error("Failed to get employee workload")
 		response.NotFound(c, "员工不存在")
@@ -251,7 +344,82 @@ func (h *EmployeeHandler) GetEmployeeWorkload(c *gin.Context) {
 	response.Success(c, workload)
 }
 
+// GetOrgChart 获取员工组织架构图
+// @Summary 获取员工组织架构图
+// @Description 返回员工从直接上级到最高上级的上级链，以及depth层以内的直接和间接下属树。节点包含职位、部门和入职状态；上级链存在循环汇报关系时在重复出现的员工处截断
+// @Tags 员工管理
+// @Produce json
+// @Param id path int true "员工ID"
+// @Param depth query int false "下属展开层级，1-10" default(3)
+// @Success 200 {object} response.Response{data=service.OrgChartResponse} "获取成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 404 {object} response.Response "员工不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/employees/{id}/org-chart [get]
+// @Security BearerAuth
+func (h *EmployeeHandler) GetOrgChart(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的员工ID")
+		return
+	}
+
+	depth := service.DefaultOrgChartDepth
+	if depthStr := c.Query("depth"); depthStr != "" {
+		depth, err = strconv.Atoi(depthStr)
+		if err != nil || depth < 1 || depth > service.MaxOrgChartDepth {
+			response.BadRequest(c, fmt.Sprintf("depth必须在1到%d之间", service.MaxOrgChartDepth))
+			return
+		}
+	}
+
+	employeeService := h.container.GetServiceManager().EmployeeService()
+	orgChart, err := employeeService.GetOrgChart(c.Request.Context(), uint(id), depth)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			response.NotFound(c, "员工不存在")
+			return
+		}
+		h.logger.WithError(err).Error("Failed to get employee org chart")
+		response.InternalError(c, "获取组织架构图失败")
+		return
+	}
+
+	response.Success(c, orgChart)
+}
+
+// ReconcileWorkload 按任务表校正全部员工的当前任务数
+// @Summary 校正员工任务数
+// @Description 按任务表重新统计全部员工的当前任务数，返回存在偏差的员工
+// @Tags 系统管理
+// @Produce json
+// @Success 200 {object} response.Response{data=service.WorkloadReconcileReport} "校正完成"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/admin/reconcile-workload [post]
+// @Security BearerAuth
+func (h *EmployeeHandler) ReconcileWorkload(c *gin.Context) {
+	employeeService := h.container.GetServiceManager().EmployeeService()
+	report, err := employeeService.ReconcileWorkload(c.Request.Context())
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to reconcile employee workload")
+		response.InternalError(c, "校正员工任务数失败")
+		return
+	}
+
+	response.Success(c, report)
+}
+
 // AddSkill 添加员工技能
+// @Summary 添加员工技能
+// @Description 尚未实现，请使用 POST /api/v1/skills/assign
+// @Tags 员工管理
+// @Accept json
+// @Produce json
+// @Param id path int true "员工ID"
+// @Param request body service.AddSkillRequest true "技能"
+// @Failure 400 {object} response.Response "功能尚未实现"
+// @Router /api/v1/employees/{id}/skills [post]
+// @Security BearerAuth
 func (h *EmployeeHandler) AddSkill(c *gin.Context) {
 	employeeID := c.Param("id")
 	if employeeID == "" {
@@ -267,9 +435,7 @@ func (h *EmployeeHandler) AddSkill(c *gin.Context) {
 	}
 
 	var req service.AddSkillRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.WithError(err).Warn("Invalid add skill request")
-		response.BadRequest(c, "请求参数无效")
+	if !response.BindAndValidate(c, &req) {
 		return
 	}
 
@@ -285,6 +451,17 @@ func (h *EmployeeHandler) AddSkill(c *gin.Context) {
 }
 
 // RemoveSkill 移除员工技能
+// @Summary 移除员工技能
+// @Tags 员工管理
+// @Accept json
+// @Produce json
+// @Param id path int true "员工ID"
+// @Param request body service.RemoveSkillRequest true "技能"
+// @Success 200 {object} response.Response{data=MessageResult} "删除成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/employees/{id}/skills [delete]
+// @Security BearerAuth
 func (h *EmployeeHandler) RemoveSkill(c *gin.Context) {
 	employeeID := c.Param("id")
 	if employeeID == "" {
@@ -300,9 +477,7 @@ func (h *EmployeeHandler) RemoveSkill(c *gin.Context) {
 	}
 
 	var req service.RemoveSkillRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.WithError(err).Warn("Invalid remove skill request")
-		response.BadRequest(c, "请求参数无效")
+	if !response.BindAndValidate(c, &req) {
 		return
 	}
 
@@ -315,15 +490,30 @@ func (h *EmployeeHandler) RemoveSkill(c *gin.Context) {
 	employeeService := h.container.GetEmployeeService()
 	err = employeeService.RemoveSkill(c.Request.Context(), uint(id), &req)
 	if err != nil {
+		if response.IsAppError(err) {
+			response.FromError(c, err)
+			return
+		}
 		h.logger.WithError(err).Error("Failed to remove skill from employee")
 		response.InternalError(c, "删除员工技能失败")
 		return
 	}
 
-	response.Success(c, gin.H{"message": "员工技能删除成功"})
+	response.Success(c, MessageResult{Message: "员工技能删除成功"})
 }
 
 // UpdateEmployeeStatus 更新员工状态
+// @Summary 更新员工状态
+// @Tags 员工管理
+// @Accept json
+// @Produce json
+// @Param id path int true "员工ID"
+// @Param request body EmployeeStatusRequest true "员工状态"
+// @Success 200 {object} response.Response{data=MessageResult} "更新成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/employees/{id}/status [put]
+// @Security BearerAuth
 func (h *EmployeeHandler) UpdateEmployeeStatus(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
@@ -332,27 +522,36 @@ func (h *EmployeeHandler) UpdateEmployeeStatus(c *gin.Context) {
 		return
 	}
 
-	var req struct {
-		Status string `json:"status" binding:"required"`
-	}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.WithError(err).Warn("Invalid update status request")
-		response.BadRequest(c, "请求参数无效")
+	var req EmployeeStatusRequest
+	if !response.BindAndValidate(c, &req) {
 		return
 	}
 
 	employeeService := h.container.GetEmployeeService()
 	err = employeeService.UpdateEmployeeStatus(c.Request.Context(), uint(id), req.Status)
 	if err != nil {
+		if response.IsAppError(err) {
+			response.FromError(c, err)
+			return
+		}
 		h.logger.WithError(err).Error("Failed to update employee status")
 		response.InternalError(c, "更新员工状态失败")
 		return
 	}
 
-	response.Success(c, gin.H{"message": "员工状态更新成功"})
+	response.Success(c, MessageResult{Message: "员工状态更新成功"})
 }
 
 // GetEmployeesByStatus 根据状态获取员工列表
+// @Summary 按状态获取员工
+// @Tags 员工管理
+// @Produce json
+// @Param status query string true "员工状态"
+// @Success 200 {object} response.Response{data=[]service.EmployeeResponse} "获取成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/employees/status [get]
+// @Security BearerAuth
 func (h *EmployeeHandler) GetEmployeesByStatus(c *gin.Context) {
 	status := c.Query("status")
 	if status == "" {
@@ -372,6 +571,20 @@ func (h *EmployeeHandler) GetEmployeesByStatus(c *gin.Context) {
 }
 
 // GetWorkloadStats 获取工作负载统计
+// @Summary 获取工作负载统计
+// @Description 待处理、逾期任务数按当前状态统计；完成数、效率（按时完成比例）和平均完成时长只统计日期范围内完成的任务，未指定时为最近90天（可配置）
+// @Tags 员工管理
+// @Produce json
+// @Param employee_ids query []int false "员工ID列表" collectionFormat(multi)
+// @Param department query string false "部门名称"
+// @Param department_id query int false "部门ID，优先于部门名称"
+// @Param start_date query string false "开始日期(2006-01-02)"
+// @Param end_date query string false "结束日期(2006-01-02)，包含当天"
+// @Success 200 {object} response.Response{data=[]service.WorkloadResponse} "获取成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/employees/workload/stats [get]
+// @Security BearerAuth
 func (h *EmployeeHandler) GetWorkloadStats(c *gin.Context) {
 	var req service.WorkloadStatsRequest
 	if err := c.ShouldBindQuery(&req); err != nil {
@@ -382,6 +595,10 @@ func (h *EmployeeHandler) GetWorkloadStats(c *gin.Context) {
 
 	employeeService := h.container.GetEmployeeService()
 	stats, err := employeeService.GetWorkloadStats(c.Request.Context(), &req)
+	if errors.Is(err, service.ErrInvalidWorkloadRange) {
+		response.BadRequest(c, err.Error())
+		return
+	}
 	if err != nil {
 		h.logger.WithError(err).Error("Failed to get workload stats")
 		response.InternalError(c, "获取工作负载统计失败")
@@ -392,6 +609,16 @@ func (h *EmployeeHandler) GetWorkloadStats(c *gin.Context) {
 }
 
 // GetDepartmentWorkload 获取部门工作负载统计
+// @Summary 获取部门工作负载统计
+// @Description 工作负载率超过配置阈值（默认0.9）的员工计入超负荷人数
+// @Tags 员工管理
+// @Produce json
+// @Param department path int true "部门ID"
+// @Success 200 {object} response.Response{data=service.DepartmentWorkloadResponse} "获取成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/employees/workload/departments/{department} [get]
+// @Security BearerAuth
 func (h *EmployeeHandler) GetDepartmentWorkload(c *gin.Context) {
 	departmentIDStr := c.Param("department")
 	if departmentIDStr == "" {
@@ -416,3 +643,8 @@ func (h *EmployeeHandler) GetDepartmentWorkload(c *gin.Context) {
 
 	response.Success(c, workload)
 }
+
+// EmployeeStatusRequest 更新员工状态请求
+type EmployeeStatusRequest struct {
+	Status string `json:"status" binding:"required"`
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"taskmanage/internal/service"
+	"taskmanage/pkg/response"
+)
+
+// EmployeeCapacityHandler 员工容量日历处理器
+type EmployeeCapacityHandler struct {
+	capacityService service.EmployeeCapacityService
+	logger          *logrus.Logger
+}
+
+// NewEmployeeCapacityHandler 创建员工容量日历处理器
+func NewEmployeeCapacityHandler(capacityService service.EmployeeCapacityService, logger *logrus.Logger) *EmployeeCapacityHandler {
+	return &EmployeeCapacityHandler{
+		capacityService: capacityService,
+		logger:          logger,
+	}
+}
+
+// ListCapacities 获取员工容量日历
+// @Summary 获取员工容量日历
+// @Description 返回员工的全部容量条目，按开始日期排序
+// @Tags 员工管理
+// @Produce json
+// @Param id path int true "员工ID"
+// @Success 200 {object} response.Response{data=[]service.EmployeeCapacityResponse} "获取成功"
+// @Failure 404 {object} response.Response "员工不存在"
+// @Router /api/v1/employees/{id}/capacity [get]
+// @Security BearerAuth
+func (h *EmployeeCapacityHandler) ListCapacities(c *gin.Context) {
+	employeeID, ok := parseUintParam(c, "id", "无效的员工ID")
+	if !ok {
+		return
+	}
+
+	capacities, err := h.capacityService.ListCapacities(c.Request.Context(), employeeID)
+	if err != nil {
+		response.FromError(c, err)
+		return
+	}
+
+	response.Success(c, capacities)
+}
+
+// CreateCapacity 创建员工容量条目
+// @Summary 创建员工容量条目
+// @Description 在日期范围内（含首尾两天）按容量系数折算员工的最大任务数，系数为0表示请假，请假期间员工状态自动置为on_leave；日期范围不能与已有条目重叠
+// @Tags 员工管理
+// @Accept json
+// @Produce json
+// @Param id path int true "员工ID"
+// @Param request body service.EmployeeCapacityRequest true "容量条目"
+// @Success 201 {object} response.Response{data=service.EmployeeCapacityResponse} "创建成功"
+// @Failure 400 {object} response.Response "容量条目不合法"
+// @Failure 404 {object} response.Response "员工不存在"
+// @Failure 409 {object} response.Response "日期范围与已有条目重叠"
+// @Router /api/v1/employees/{id}/capacity [post]
+// @Security BearerAuth
+func (h *EmployeeCapacityHandler) CreateCapacity(c *gin.Context) {
+	employeeID, ok := parseUintParam(c, "id", "无效的员工ID")
+	if !ok {
+		return
+	}
+
+	var req service.EmployeeCapacityRequest
+	if !response.BindAndValidate(c, &req) {
+		return
+	}
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		response.Unauthorized(c, "用户信息缺失")
+		return
+	}
+
+	capacity, err := h.capacityService.CreateCapacity(c.Request.Context(), employeeID, userID, &req)
+	if err != nil {
+		response.FromError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.Response{
+		Code:    response.ErrCodeSuccess,
+		Message: "员工容量条目创建成功",
+		Data:    capacity,
+	})
+}
+
+// UpdateCapacity 更新员工容量条目
+// @Summary 更新员工容量条目
+// @Description 整体更新容量条目，日期范围不能与其它条目重叠；已生效的请假先恢复员工状态，仍处于请假期间时由定时任务重新生效
+// @Tags 员工管理
+// @Accept json
+// @Produce json
+// @Param id path int true "员工ID"
+// @Param capacity_id path int true "容量条目ID"
+// @Param request body service.EmployeeCapacityRequest true "容量条目"
+// @Success 200 {object} response.Response{data=service.EmployeeCapacityResponse} "更新成功"
+// @Failure 400 {object} response.Response "容量条目不合法"
+// @Failure 404 {object} response.Response "容量条目不存在"
+// @Failure 409 {object} response.Response "日期范围与其它条目重叠"
+// @Router /api/v1/employees/{id}/capacity/{capacity_id} [put]
+// @Security BearerAuth
+func (h *EmployeeCapacityHandler) UpdateCapacity(c *gin.Context) {
+	employeeID, ok := parseUintParam(c, "id", "无效的员工ID")
+	if !ok {
+		return
+	}
+	capacityID, ok := parseUintParam(c, "capacity_id", "无效的容量条目ID")
+	if !ok {
+		return
+	}
+
+	var req service.EmployeeCapacityRequest
+	if !response.BindAndValidate(c, &req) {
+		return
+	}
+
+	capacity, err := h.capacityService.UpdateCapacity(c.Request.Context(), employeeID, capacityID, &req)
+	if err != nil {
+		response.FromError(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, "员工容量条目已更新", capacity)
+}
+
+// DeleteCapacity 删除员工容量条目
+// @Summary 删除员工容量条目
+// @Description 删除容量条目，已生效的请假同时恢复员工请假前的状态
+// @Tags 员工管理
+// @Produce json
+// @Param id path int true "员工ID"
+// @Param capacity_id path int true "容量条目ID"
+// @Success 200 {object} response.Response "删除成功"
+// @Failure 404 {object} response.Response "容量条目不存在"
+// @Router /api/v1/employees/{id}/capacity/{capacity_id} [delete]
+// @Security BearerAuth
+func (h *EmployeeCapacityHandler) DeleteCapacity(c *gin.Context) {
+	employeeID, ok := parseUintParam(c, "id", "无效的员工ID")
+	if !ok {
+		return
+	}
+	capacityID, ok := parseUintParam(c, "capacity_id", "无效的容量条目ID")
+	if !ok {
+		return
+	}
+
+	if err := h.capacityService.DeleteCapacity(c.Request.Context(), employeeID, capacityID); err != nil {
+		response.FromError(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, "员工容量条目已删除", nil)
+}
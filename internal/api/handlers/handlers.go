@@ -1,9 +1,11 @@
 package handlers
 
 import (
+	"errors"
 	"strconv"
 
 	"taskmanage/internal/container"
+	"taskmanage/internal/service"
 	"taskmanage/pkg/response"
 
 	"github.com/gin-gonic/gin"
@@ -51,16 +53,34 @@ type BaseHandler struct {
 
 // NotificationHandler 通知处理器
 type NotificationHandler struct {
-	*BaseHandler
+	notificationService service.NotificationService
+	logger              *logrus.Logger
 }
 
-func NewNotificationHandler(container *container.ApplicationContainer, logger *logrus.Logger) *NotificationHandler {
+// NewNotificationHandler 创建通知处理器
+func NewNotificationHandler(notificationService service.NotificationService, logger *logrus.Logger) *NotificationHandler {
 	return &NotificationHandler{
-		BaseHandler: &BaseHandler{container: container, logger: logger},
+		notificationService: notificationService,
+		logger:              logger,
 	}
 }
 
 // GetNotifications 获取用户通知列表
+// @Summary 获取通知列表
+// @Description 分页获取当前用户未过期的通知，按创建时间倒序，可按类型和已读状态过滤
+// @Tags 通知
+// @Produce json
+// @Param type query string false "通知类型，如 task_assigned"
+// @Param is_read query bool false "true只返回已读或已操作的通知，false只返回未读通知"
+// @Param unread_only query bool false "只返回未读通知，兼容旧参数，指定is_read时忽略"
+// @Param page query int false "页码，默认1"
+// @Param page_size query int false "每页数量，默认20，最大100"
+// @Success 200 {object} response.Response{data=NotificationListResult} "获取成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/notifications [get]
+// @Security BearerAuth
 func (h *NotificationHandler) GetNotifications(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
@@ -69,32 +89,62 @@ func (h *NotificationHandler) GetNotifications(c *gin.Context) {
 	}
 
 	// 解析查询参数
-	unreadOnly := c.Query("unread_only") == "true"
+	var req service.NotificationListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.BindError(c, err)
+		return
+	}
 
 	// 使用NotificationService获取通知
-	notificationService := h.container.GetServiceManager().NotificationService()
-	notifications, total, err := notificationService.ListNotifications(c.Request.Context(), userID.(uint), unreadOnly)
+	notifications, total, err := h.notificationService.ListNotifications(c.Request.Context(), userID.(uint), &req)
 	if err != nil {
 		h.logger.WithError(err).Error("获取通知列表失败")
 		response.InternalError(c, "获取通知列表失败")
 		return
 	}
 
-	response.Success(c, gin.H{
-		"notifications": notifications,
-		"total":         total,
+	response.Success(c, NotificationListResult{
+		Notifications: notifications,
+		Total:         total,
+		Page:          req.Page,
+		PageSize:      req.PageSize,
 	})
 }
 
+// SendNotification 发送通知
+// @Summary 发送通知
+// @Description 尚未实现
+// @Tags 通知
+// @Produce json
+// @Success 200 {object} response.Response{data=MessageResult} "占位响应"
+// @Router /api/v1/notifications/send [post]
+// @Security BearerAuth
 func (h *NotificationHandler) SendNotification(c *gin.Context) {
-	response.Success(c, gin.H{"message": "SendNotification - TODO: implement"})
+	response.Success(c, MessageResult{Message: "SendNotification - TODO: implement"})
 }
 
+// BroadcastNotification 广播通知
+// @Summary 广播通知
+// @Description 尚未实现
+// @Tags 通知
+// @Produce json
+// @Success 200 {object} response.Response{data=MessageResult} "占位响应"
+// @Router /api/v1/notifications/broadcast [post]
+// @Security BearerAuth
 func (h *NotificationHandler) BroadcastNotification(c *gin.Context) {
-	response.Success(c, gin.H{"message": "BroadcastNotification - TODO: implement"})
+	response.Success(c, MessageResult{Message: "BroadcastNotification - TODO: implement"})
 }
 
 // MarkAsRead 标记通知为已读
+// @Summary 标记通知已读
+// @Tags 通知
+// @Produce json
+// @Param id path int true "通知ID"
+// @Success 200 {object} response.Response{data=MessageResult} "已标记"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/notifications/{id}/read [put]
+// @Security BearerAuth
 func (h *NotificationHandler) MarkAsRead(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
@@ -110,18 +160,24 @@ func (h *NotificationHandler) MarkAsRead(c *gin.Context) {
 	}
 
 	// 使用NotificationService标记已读
-	notificationService := h.container.GetServiceManager().NotificationService()
-	err = notificationService.MarkAsRead(c.Request.Context(), uint(notificationID), userID.(uint))
+	err = h.notificationService.MarkAsRead(c.Request.Context(), uint(notificationID), userID.(uint))
 	if err != nil {
 		h.logger.WithError(err).Error("标记通知已读失败")
 		response.InternalError(c, "标记通知已读失败")
 		return
 	}
 
-	response.Success(c, gin.H{"message": "通知已标记为已读"})
+	response.Success(c, MessageResult{Message: "通知已标记为已读"})
 }
 
 // MarkAllAsRead 标记所有通知为已读
+// @Summary 全部标记已读
+// @Tags 通知
+// @Produce json
+// @Success 200 {object} response.Response{data=MessageResult} "已标记"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/notifications/read [put]
+// @Security BearerAuth
 func (h *NotificationHandler) MarkAllAsRead(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
@@ -130,20 +186,58 @@ func (h *NotificationHandler) MarkAllAsRead(c *gin.Context) {
 	}
 
 	// 使用NotificationService批量标记已读
-	notificationService := h.container.GetServiceManager().NotificationService()
-	err := notificationService.MarkAllAsRead(c.Request.Context(), userID.(uint))
+	err := h.notificationService.MarkAllAsRead(c.Request.Context(), userID.(uint))
 	if err != nil {
 		h.logger.WithError(err).Error("批量标记通知已读失败")
 		response.InternalError(c, "批量标记通知已读失败")
 		return
 	}
 
-	response.Success(c, gin.H{
-		"message": "批量标记完成",
-	})
+	response.Success(c, MessageResult{Message: "批量标记完成"})
+}
+
+// BulkMarkAsRead 按ID列表或过滤条件批量标记已读
+// @Summary 批量标记通知已读
+// @Description 指定ids时只标记这些通知，任一ID不属于当前用户时返回404且不做修改；不指定ids时按type和before（创建时间早于该时间）过滤，两种方式不能同时使用。所有符合条件的通知用一条UPDATE标记，返回本次由未读变为已读的数量
+// @Tags 通知
+// @Accept json
+// @Produce json
+// @Param request body service.BulkReadNotificationsRequest true "批量标记条件"
+// @Success 200 {object} response.Response{data=service.BulkReadNotificationsResult} "已标记"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 404 {object} response.Response "通知不存在或不属于当前用户"
+// @Router /api/v1/notifications/read-bulk [put]
+// @Security BearerAuth
+func (h *NotificationHandler) BulkMarkAsRead(c *gin.Context) {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		response.Unauthorized(c, "用户未认证")
+		return
+	}
+
+	var req service.BulkReadNotificationsRequest
+	if !response.BindAndValidate(c, &req) {
+		return
+	}
+
+	updated, err := h.notificationService.BulkMarkAsRead(c.Request.Context(), userID, &req)
+	if err != nil {
+		response.FromError(c, err)
+		return
+	}
+
+	response.Success(c, service.BulkReadNotificationsResult{Updated: updated})
 }
 
 // GetUnreadCount 获取未读通知数量
+// @Summary 获取未读通知数量
+// @Tags 通知
+// @Produce json
+// @Param type query string false "只统计该类型的通知"
+// @Success 200 {object} response.Response{data=UnreadCountResult} "获取成功"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/notifications/count [get]
+// @Security BearerAuth
 func (h *NotificationHandler) GetUnreadCount(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
@@ -152,18 +246,29 @@ func (h *NotificationHandler) GetUnreadCount(c *gin.Context) {
 	}
 
 	// 使用NotificationService获取未读数量
-	notificationService := h.container.GetServiceManager().NotificationService()
-	count, err := notificationService.GetUnreadCount(c.Request.Context(), userID.(uint))
+	count, err := h.notificationService.GetUnreadCount(c.Request.Context(), userID.(uint), c.Query("type"))
 	if err != nil {
 		h.logger.WithError(err).Error("获取未读通知数量失败")
 		response.InternalError(c, "获取未读通知数量失败")
 		return
 	}
 
-	response.Success(c, gin.H{"count": count})
+	response.Success(c, UnreadCountResult{Count: count})
 }
 
 // AcceptTask 接受任务
+// @Summary 接受任务
+// @Description 被分配员工通过任务通知接受任务
+// @Tags 通知
+// @Accept json
+// @Produce json
+// @Param id path int true "通知ID"
+// @Param request body TaskNotificationDecisionRequest true "接受任务请求"
+// @Success 200 {object} response.Response{data=MessageResult} "任务已接受"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/notifications/{id}/accept [post]
+// @Security BearerAuth
 func (h *NotificationHandler) AcceptTask(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
@@ -171,11 +276,7 @@ func (h *NotificationHandler) AcceptTask(c *gin.Context) {
 		return
 	}
 
-	var req struct {
-		NotificationID uint    `json:"notification_id" binding:"required"`
-		TaskID         uint    `json:"task_id" binding:"required"`
-		Reason         *string `json:"reason,omitempty"`
-	}
+	var req TaskNotificationDecisionRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		response.BadRequest(c, "请求参数错误: "+err.Error())
@@ -183,18 +284,29 @@ func (h *NotificationHandler) AcceptTask(c *gin.Context) {
 	}
 
 	// 使用NotificationService处理任务接受
-	notificationService := h.container.GetServiceManager().NotificationService()
-	err := notificationService.AcceptTaskNotification(c.Request.Context(), req.NotificationID, req.TaskID, userID.(uint), req.Reason)
+	err := h.notificationService.AcceptTaskNotification(c.Request.Context(), req.NotificationID, req.TaskID, userID.(uint), req.Reason)
 	if err != nil {
 		h.logger.WithError(err).Error("接受任务失败")
 		response.InternalError(c, "接受任务失败")
 		return
 	}
 
-	response.Success(c, gin.H{"message": "任务已接受"})
+	response.Success(c, MessageResult{Message: "任务已接受"})
 }
 
 // RejectTask 拒绝任务
+// @Summary 拒绝任务
+// @Description 被分配员工通过任务通知拒绝任务，必须提供理由
+// @Tags 通知
+// @Accept json
+// @Produce json
+// @Param id path int true "通知ID"
+// @Param request body TaskNotificationDecisionRequest true "拒绝任务请求"
+// @Success 200 {object} response.Response{data=MessageResult} "任务已拒绝"
+// @Failure 400 {object} response.Response "请求参数错误或缺少拒绝理由"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/notifications/{id}/reject [post]
+// @Security BearerAuth
 func (h *NotificationHandler) RejectTask(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
@@ -202,11 +314,7 @@ func (h *NotificationHandler) RejectTask(c *gin.Context) {
 		return
 	}
 
-	var req struct {
-		NotificationID uint    `json:"notification_id" binding:"required"`
-		TaskID         uint    `json:"task_id" binding:"required"`
-		Reason         *string `json:"reason" binding:"required"`
-	}
+	var req TaskNotificationDecisionRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		response.BadRequest(c, "请求参数错误: "+err.Error())
@@ -219,13 +327,94 @@ func (h *NotificationHandler) RejectTask(c *gin.Context) {
 	}
 
 	// 使用NotificationService处理任务拒绝
-	notificationService := h.container.GetServiceManager().NotificationService()
-	err := notificationService.RejectTaskNotification(c.Request.Context(), req.NotificationID, req.TaskID, userID.(uint), req.Reason)
+	err := h.notificationService.RejectTaskNotification(c.Request.Context(), req.NotificationID, req.TaskID, userID.(uint), req.Reason)
 	if err != nil {
 		h.logger.WithError(err).Error("拒绝任务失败")
 		response.InternalError(c, "拒绝任务失败")
 		return
 	}
 
-	response.Success(c, gin.H{"message": "任务已拒绝"})
+	response.Success(c, MessageResult{Message: "任务已拒绝"})
+}
+
+// GetPreferences 获取当前用户的通知偏好
+// @Summary 获取通知偏好
+// @Tags 通知
+// @Produce json
+// @Success 200 {object} response.Response{data=[]service.NotificationPreferenceResponse} "获取成功"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/notifications/preferences [get]
+// @Security BearerAuth
+func (h *NotificationHandler) GetPreferences(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Unauthorized(c, "用户未认证")
+		return
+	}
+
+	preferences, err := h.notificationService.GetPreferences(c.Request.Context(), userID.(uint))
+	if err != nil {
+		h.logger.WithError(err).Error("获取通知偏好失败")
+		response.InternalError(c, "获取通知偏好失败")
+		return
+	}
+
+	response.Success(c, preferences)
+}
+
+// UpdatePreferences 更新当前用户的通知偏好
+// @Summary 更新通知偏好
+// @Tags 通知
+// @Accept json
+// @Produce json
+// @Param request body service.UpdateNotificationPreferencesRequest true "通知偏好"
+// @Success 200 {object} response.Response{data=[]service.NotificationPreferenceResponse} "更新成功"
+// @Failure 400 {object} response.Response "请求参数错误或偏好不可修改"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/notifications/preferences [put]
+// @Security BearerAuth
+func (h *NotificationHandler) UpdatePreferences(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Unauthorized(c, "用户未认证")
+		return
+	}
+
+	var req service.UpdateNotificationPreferencesRequest
+	if !response.BindAndValidate(c, &req) {
+		return
+	}
+
+	preferences, err := h.notificationService.UpdatePreferences(c.Request.Context(), userID.(uint), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidNotificationPreference) || errors.Is(err, service.ErrNotificationPreferenceLocked) {
+			response.BadRequest(c, err.Error())
+			return
+		}
+		h.logger.WithError(err).Error("更新通知偏好失败")
+		response.InternalError(c, "更新通知偏好失败")
+		return
+	}
+
+	response.Success(c, preferences)
+}
+
+// TaskNotificationDecisionRequest 通过通知接受或拒绝任务的请求，拒绝时reason必填
+type TaskNotificationDecisionRequest struct {
+	NotificationID uint    `json:"notification_id" binding:"required"`
+	TaskID         uint    `json:"task_id" binding:"required"`
+	Reason         *string `json:"reason,omitempty"`
+}
+
+// NotificationListResult 通知列表
+type NotificationListResult struct {
+	Notifications []*service.NotificationResponse `json:"notifications"`
+	Total         int64                           `json:"total"`
+	Page          int                             `json:"page"`
+	PageSize      int                             `json:"page_size"`
+}
+
+// UnreadCountResult 未读通知数量
+type UnreadCountResult struct {
+	Count int64 `json:"count"`
 }
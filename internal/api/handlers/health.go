@@ -1,7 +1,11 @@
 package handlers
 
 import (
+	"context"
+	"fmt"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
@@ -24,13 +28,71 @@ func NewHealthHandler(container *container.ApplicationContainer, logger *logrus.
 	}
 }
 
+// ServiceInfoResult 服务基本信息
+type ServiceInfoResult struct {
+	Service string `json:"service"`
+	Status  string `json:"status"`
+	Version string `json:"version"`
+}
+
+// HealthStatus 健康检查结果
+type HealthStatus struct {
+	Status    string            `json:"status"` // healthy, degraded, unhealthy
+	Timestamp string            `json:"timestamp"`
+	Version   string            `json:"version"`
+	Services  map[string]string `json:"services"`
+}
+
+// ReadinessStatus 就绪检查结果
+type ReadinessStatus struct {
+	Status    string                      `json:"status"` // ready, degraded, not_ready
+	Ready     bool                        `json:"ready"`
+	Checks    map[string]*DependencyCheck `json:"checks"`
+	Timestamp string                      `json:"timestamp"`
+}
+
+// StartupStatus 启动检查结果
+type StartupStatus struct {
+	Started bool                    `json:"started"`
+	Steps   []container.StartupStep `json:"steps"`
+}
+
+// LivenessStatus 存活检查结果
+type LivenessStatus struct {
+	Alive     bool   `json:"alive"`
+	Timestamp string `json:"timestamp"`
+	Uptime    string `json:"uptime"`
+}
+
+// ServiceInfo 服务信息
+// @Summary 服务信息
+// @Tags 健康检查
+// @Produce json
+// @Success 200 {object} ServiceInfoResult "服务运行中"
+// @Router / [get]
+func (h *HealthHandler) ServiceInfo(c *gin.Context) {
+	c.JSON(http.StatusOK, ServiceInfoResult{
+		Service: "Task Management System",
+		Status:  "running",
+		Version: "1.0.0",
+	})
+}
+
 // HealthCheck 健康检查
+// @Summary 健康检查
+// @Description 检查数据库连接，数据库不可用返回503，连接异常返回206
+// @Tags 健康检查
+// @Produce json
+// @Success 200 {object} HealthStatus "服务健康"
+// @Success 206 {object} HealthStatus "服务降级"
+// @Failure 503 {object} HealthStatus "服务不可用"
+// @Router /health [get]
 func (h *HealthHandler) HealthCheck(c *gin.Context) {
-	status := gin.H{
-		"status":    "healthy",
-		"timestamp": "2025-08-28T17:00:00Z",
-		"version":   "v1.0.0",
-		"services":  gin.H{},
+	status := HealthStatus{
+		Status:    "healthy",
+		Timestamp: "2025-08-28T17:00:00Z",
+		Version:   "v1.0.0",
+		Services:  map[string]string{},
 	}
 	
 	// 检查数据库连接
@@ -38,83 +100,225 @@ func (h *HealthHandler) HealthCheck(c *gin.Context) {
 		sqlDB, err := db.DB()
 		if err == nil {
 			if err := sqlDB.Ping(); err == nil {
-				status["services"].(gin.H)["database"] = "healthy"
+				status.Services["database"] = "healthy"
 			} else {
-				status["services"].(gin.H)["database"] = "unhealthy"
-				status["status"] = "degraded"
+				status.Services["database"] = "unhealthy"
+				status.Status = "degraded"
 			}
 		} else {
-			status["services"].(gin.H)["database"] = "unhealthy"
-			status["status"] = "degraded"
+			status.Services["database"] = "unhealthy"
+			status.Status = "degraded"
 		}
 	} else {
-		status["services"].(gin.H)["database"] = "unavailable"
-		status["status"] = "unhealthy"
+		status.Services["database"] = "unavailable"
+		status.Status = "unhealthy"
 	}
 	
 	// 检查缓存连接 (暂时跳过，因为缓存管理器未实现)
-	status["services"].(gin.H)["cache"] = "not_implemented"
+	status.Services["cache"] = "not_implemented"
 	
 	// 根据整体状态返回相应的HTTP状态码
 	httpStatus := http.StatusOK
-	if status["status"] == "unhealthy" {
+	if status.Status == "unhealthy" {
 		httpStatus = http.StatusServiceUnavailable
-	} else if status["status"] == "degraded" {
+	} else if status.Status == "degraded" {
 		httpStatus = http.StatusPartialContent
 	}
 	
 	c.JSON(httpStatus, status)
 }
 
+// readinessCheckTimeout 单个依赖检查的超时时间
+const readinessCheckTimeout = 2 * time.Second
+
+// 依赖检查状态
+const (
+	dependencyUp   = "up"
+	dependencyDown = "down"
+)
+
+// DependencyCheck 单个依赖的检查结果
+type DependencyCheck struct {
+	Status    string      `json:"status"`   // up, down
+	Required  bool        `json:"required"` // 必需依赖不可用时就绪检查返回503，可选依赖只标记为degraded
+	LatencyMs float64     `json:"latency_ms"`
+	Error     string      `json:"error,omitempty"`
+	Details   interface{} `json:"details,omitempty"`
+}
+
 // ReadinessCheck 就绪检查
+// 并发检查数据库、Redis和流程引擎，任一必需依赖不可用或启动初始化未完成时返回503
+// @Summary 就绪检查
+// @Description 并发检查数据库、Redis和流程引擎，任一必需依赖不可用或启动初始化未完成时返回503。
+// @Description Redis在依赖它的限流和权限缓存都开启了进程内降级时为可选依赖，不可用时返回200且status为degraded
+// @Tags 健康检查
+// @Produce json
+// @Success 200 {object} ReadinessStatus "服务就绪"
+// @Failure 503 {object} ReadinessStatus "服务未就绪"
+// @Router /health/ready [get]
 func (h *HealthHandler) ReadinessCheck(c *gin.Context) {
-	ready := true
-	checks := gin.H{}
-	
-	// 检查数据库
-	if db := h.container.GetDB(); db != nil {
-		sqlDB, err := db.DB()
-		if err == nil {
-			if err := sqlDB.Ping(); err == nil {
-				checks["database"] = "ready"
-			} else {
-				checks["database"] = "not_ready"
-				ready = false
-			}
-		} else {
-			checks["database"] = "not_ready"
-			ready = false
+	cfg := h.container.GetConfig()
+	// 限流和权限缓存依赖Redis，均关闭或都有进程内降级时Redis不可用只标记为degraded
+	redisRequired := (cfg.RateLimit.Enabled && !cfg.Redis.RateLimitFallback) ||
+		(cfg.PermissionCache.Enabled && !cfg.Redis.PermissionCacheFallback)
+
+	probes := map[string]struct {
+		required bool
+		check    func(ctx context.Context) (interface{}, error)
+	}{
+		"database": {true, h.checkDatabase},
+		"redis":    {redisRequired, h.checkRedis},
+		"workflow": {true, h.checkWorkflowEngine},
+	}
+
+	checks := make(map[string]*DependencyCheck, len(probes)+1)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, probe := range probes {
+		wg.Add(1)
+		go func(name string, required bool, check func(ctx context.Context) (interface{}, error)) {
+			defer wg.Done()
+			result := runDependencyCheck(c.Request.Context(), required, check)
+			mu.Lock()
+			checks[name] = result
+			mu.Unlock()
+		}(name, probe.required, probe.check)
+	}
+	wg.Wait()
+
+	startup := &DependencyCheck{Status: dependencyUp, Required: true}
+	if !h.container.GetStartupTracker().Completed() {
+		startup.Status = dependencyDown
+		startup.Error = "启动初始化未完成"
+	}
+	checks["startup"] = startup
+
+	status := "ready"
+	for _, check := range checks {
+		if check.Status != dependencyDown {
+			continue
 		}
-	} else {
-		checks["database"] = "not_available"
-		ready = false
+		if check.Required {
+			status = "not_ready"
+			break
+		}
+		status = "degraded"
 	}
-	
-	// 检查Repository管理器
-	if repoManager := h.container.GetRepositoryManager(); repoManager != nil {
-		checks["repository"] = "ready"
-	} else {
-		checks["repository"] = "not_ready"
-		ready = false
+
+	httpStatus := http.StatusOK
+	if status == "not_ready" {
+		httpStatus = http.StatusServiceUnavailable
 	}
-	
-	status := gin.H{
-		"ready":  ready,
-		"checks": checks,
+	c.JSON(httpStatus, ReadinessStatus{
+		Status:    status,
+		Ready:     status != "not_ready",
+		Checks:    checks,
+		Timestamp: time.Now().Format(time.RFC3339),
+	})
+}
+
+// StartupCheck 启动检查
+// 容器初始化、系统默认数据和默认工作流初始化全部结束后返回200，之前返回503
+// @Summary 启动检查
+// @Tags 健康检查
+// @Produce json
+// @Success 200 {object} StartupStatus "启动完成"
+// @Failure 503 {object} StartupStatus "启动初始化未完成"
+// @Router /health/startup [get]
+func (h *HealthHandler) StartupCheck(c *gin.Context) {
+	tracker := h.container.GetStartupTracker()
+	started := tracker.Completed()
+
+	httpStatus := http.StatusOK
+	if !started {
+		httpStatus = http.StatusServiceUnavailable
 	}
-	
-	if ready {
-		c.JSON(http.StatusOK, status)
-	} else {
-		c.JSON(http.StatusServiceUnavailable, status)
+	c.JSON(httpStatus, StartupStatus{
+		Started: started,
+		Steps:   tracker.Steps(),
+	})
+}
+
+// runDependencyCheck 在超时时间内执行依赖检查并记录耗时，检查本身不响应ctx时按超时处理
+func runDependencyCheck(ctx context.Context, required bool, check func(ctx context.Context) (interface{}, error)) *DependencyCheck {
+	ctx, cancel := context.WithTimeout(ctx, readinessCheckTimeout)
+	defer cancel()
+
+	type outcome struct {
+		details interface{}
+		err     error
+	}
+	done := make(chan outcome, 1)
+	start := time.Now()
+	go func() {
+		details, err := check(ctx)
+		done <- outcome{details, err}
+	}()
+
+	var details interface{}
+	var err error
+	select {
+	case result := <-done:
+		details, err = result.details, result.err
+	case <-ctx.Done():
+		err = fmt.Errorf("检查超时(%s): %w", readinessCheckTimeout, ctx.Err())
+	}
+
+	result := &DependencyCheck{
+		Status:    dependencyUp,
+		Required:  required,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+		Details:   details,
+	}
+	if err != nil {
+		result.Status = dependencyDown
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// checkDatabase 数据库PING
+func (h *HealthHandler) checkDatabase(ctx context.Context) (interface{}, error) {
+	db := h.container.GetDB()
+	if db == nil {
+		return nil, fmt.Errorf("数据库连接未初始化")
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("获取数据库连接失败: %w", err)
+	}
+	return nil, sqlDB.PingContext(ctx)
+}
+
+// checkRedis Redis PING，附带熔断器状态；熔断期间不访问Redis直接按不可用处理
+func (h *HealthHandler) checkRedis(ctx context.Context) (interface{}, error) {
+	redisCache, err := h.container.GetRedisCache()
+	if err != nil {
+		return nil, err
+	}
+	err = redisCache.Ping(ctx)
+	return map[string]interface{}{"breaker": redisCache.BreakerSnapshot()}, err
+}
+
+// checkWorkflowEngine 流程引擎自检：启用的流程定义中的节点类型都有执行器
+func (h *HealthHandler) checkWorkflowEngine(ctx context.Context) (interface{}, error) {
+	report, err := h.container.GetServiceManager().WorkflowService().SelfCheck(ctx)
+	if report == nil {
+		return nil, err
 	}
+	return report, err
 }
 
 // LivenessCheck 存活检查
+// @Summary 存活检查
+// @Tags 健康检查
+// @Produce json
+// @Success 200 {object} response.Response{data=LivenessStatus} "服务存活"
+// @Router /health/live [get]
 func (h *HealthHandler) LivenessCheck(c *gin.Context) {
-	response.Success(c, gin.H{
-		"alive":     true,
-		"timestamp": "2025-08-28T17:00:00Z",
-		"uptime":    "running",
+	response.Success(c, LivenessStatus{
+		Alive:     true,
+		Timestamp: "2025-08-28T17:00:00Z",
+		Uptime:    "running",
 	})
 }
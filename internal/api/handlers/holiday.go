@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"taskmanage/internal/service"
+	"taskmanage/pkg/response"
+)
+
+// HolidayHandler 节假日处理器
+type HolidayHandler struct {
+	workCalendarService service.WorkCalendarService
+	logger              *logrus.Logger
+}
+
+// NewHolidayHandler 创建节假日处理器
+func NewHolidayHandler(workCalendarService service.WorkCalendarService, logger *logrus.Logger) *HolidayHandler {
+	return &HolidayHandler{
+		workCalendarService: workCalendarService,
+		logger:              logger,
+	}
+}
+
+// ListHolidays 获取节假日
+// @Summary 获取节假日
+// @Description 返回登记的节假日，按日期排序；启用工作日历后审批时限、流程超时、试用期和截止提醒的计算跳过这些日期
+// @Tags 系统管理
+// @Produce json
+// @Param year query int false "年份，为空时返回全部年份"
+// @Success 200 {object} response.Response{data=[]service.HolidayResponse} "获取成功"
+// @Failure 400 {object} response.Response "年份无效"
+// @Router /api/v1/admin/holidays [get]
+// @Security BearerAuth
+func (h *HolidayHandler) ListHolidays(c *gin.Context) {
+	year := 0
+	if value := c.Query("year"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			response.BadRequest(c, "无效的年份")
+			return
+		}
+		year = parsed
+	}
+
+	holidays, err := h.workCalendarService.ListHolidays(c.Request.Context(), year)
+	if err != nil {
+		response.FromError(c, err)
+		return
+	}
+
+	response.Success(c, holidays)
+}
+
+// CreateHoliday 登记节假日
+// @Summary 登记节假日
+// @Description 登记后立即刷新工作日历，之后创建的待审批时限、流程超时和截止提醒按新日历计算，已生成的时限不变
+// @Tags 系统管理
+// @Accept json
+// @Produce json
+// @Param request body service.HolidayRequest true "节假日"
+// @Success 201 {object} response.Response{data=service.HolidayResponse} "登记成功"
+// @Failure 400 {object} response.Response "节假日不合法"
+// @Failure 409 {object} response.Response "该日期已登记为节假日"
+// @Router /api/v1/admin/holidays [post]
+// @Security BearerAuth
+func (h *HolidayHandler) CreateHoliday(c *gin.Context) {
+	var req service.HolidayRequest
+	if !response.BindAndValidate(c, &req) {
+		return
+	}
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		response.Unauthorized(c, "用户信息缺失")
+		return
+	}
+
+	holiday, err := h.workCalendarService.CreateHoliday(c.Request.Context(), userID, &req)
+	if err != nil {
+		response.FromError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.Response{
+		Code:    response.ErrCodeSuccess,
+		Message: "节假日登记成功",
+		Data:    holiday,
+	})
+}
+
+// UpdateHoliday 更新节假日
+// @Summary 更新节假日
+// @Description 修改节假日的日期或名称，修改后立即刷新工作日历
+// @Tags 系统管理
+// @Accept json
+// @Produce json
+// @Param id path int true "节假日ID"
+// @Param request body service.HolidayRequest true "节假日"
+// @Success 200 {object} response.Response{data=service.HolidayResponse} "更新成功"
+// @Failure 400 {object} response.Response "节假日不合法"
+// @Failure 404 {object} response.Response "节假日不存在"
+// @Failure 409 {object} response.Response "该日期已登记为节假日"
+// @Router /api/v1/admin/holidays/{id} [put]
+// @Security BearerAuth
+func (h *HolidayHandler) UpdateHoliday(c *gin.Context) {
+	holidayID, ok := parseUintParam(c, "id", "无效的节假日ID")
+	if !ok {
+		return
+	}
+
+	var req service.HolidayRequest
+	if !response.BindAndValidate(c, &req) {
+		return
+	}
+
+	holiday, err := h.workCalendarService.UpdateHoliday(c.Request.Context(), holidayID, &req)
+	if err != nil {
+		response.FromError(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, "节假日已更新", holiday)
+}
+
+// DeleteHoliday 删除节假日
+// @Summary 删除节假日
+// @Description 删除后立即刷新工作日历
+// @Tags 系统管理
+// @Produce json
+// @Param id path int true "节假日ID"
+// @Success 200 {object} response.Response "删除成功"
+// @Failure 404 {object} response.Response "节假日不存在"
+// @Router /api/v1/admin/holidays/{id} [delete]
+// @Security BearerAuth
+func (h *HolidayHandler) DeleteHoliday(c *gin.Context) {
+	holidayID, ok := parseUintParam(c, "id", "无效的节假日ID")
+	if !ok {
+		return
+	}
+
+	if err := h.workCalendarService.DeleteHoliday(c.Request.Context(), holidayID); err != nil {
+		response.FromError(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, "节假日已删除", nil)
+}
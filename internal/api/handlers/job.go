@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"taskmanage/internal/jobs"
+	"taskmanage/pkg/response"
+)
+
+// JobHandler 定时任务处理器
+type JobHandler struct {
+	scheduler *jobs.Scheduler
+}
+
+// NewJobHandler 创建定时任务处理器
+func NewJobHandler(scheduler *jobs.Scheduler) *JobHandler {
+	return &JobHandler{scheduler: scheduler}
+}
+
+// JobsResult 定时任务状态列表
+type JobsResult struct {
+	Jobs []jobs.JobStatus `json:"jobs"`
+}
+
+// ListJobs 查询定时任务状态
+// @Summary 查询定时任务状态
+// @Description 返回后台定时任务的执行计划、是否正在执行、执行/失败/跳过次数、最近一次执行时间和错误以及下次执行时间
+// @Tags 系统管理
+// @Produce json
+// @Success 200 {object} response.Response{data=JobsResult}
+// @Router /api/v1/admin/jobs [get]
+// @Security BearerAuth
+func (h *JobHandler) ListJobs(c *gin.Context) {
+	response.Success(c, JobsResult{Jobs: h.scheduler.Status()})
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"taskmanage/internal/service"
+	"taskmanage/pkg/response"
+)
+
+// OffboardingHandler 离职工作流处理器
+type OffboardingHandler struct {
+	offboardingService service.OffboardingService
+	logger             *logrus.Logger
+}
+
+// NewOffboardingHandler 创建离职工作流处理器
+func NewOffboardingHandler(offboardingService service.OffboardingService, logger *logrus.Logger) *OffboardingHandler {
+	return &OffboardingHandler{
+		offboardingService: offboardingService,
+		logger:             logger,
+	}
+}
+
+// StartOffboarding 发起离职申请
+// @Summary 发起离职申请
+// @Description 提交离职申请并启动离职审批流程，办理期间员工不再接收新任务
+// @Tags offboarding
+// @Accept json
+// @Produce json
+// @Param request body service.ResignationRequest true "离职申请"
+// @Success 200 {object} response.Response{data=service.OffboardingResponse}
+// @Failure 400 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Router /api/v1/offboarding [post]
+func (h *OffboardingHandler) StartOffboarding(c *gin.Context) {
+	var req service.ResignationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("解析离职申请参数失败")
+		response.BadRequest(c, "请求参数格式错误")
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Unauthorized(c, "用户未认证")
+		return
+	}
+	req.RequesterID = userID.(uint)
+
+	result, err := h.offboardingService.StartOffboarding(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err, "发起离职申请失败")
+		return
+	}
+
+	response.SuccessWithMessage(c, "离职申请已提交", result)
+}
+
+// ProcessOffboardingApproval 处理离职审批
+// @Summary 处理离职审批
+// @Description 审批通过后进入任务交接阶段，拒绝则恢复员工原状态
+// @Tags offboarding
+// @Accept json
+// @Produce json
+// @Param request body service.ProcessOffboardingApprovalRequest true "审批决策"
+// @Success 200 {object} response.Response{data=service.OffboardingResponse}
+// @Failure 400 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Router /api/v1/offboarding/approval/process [post]
+func (h *OffboardingHandler) ProcessOffboardingApproval(c *gin.Context) {
+	var req service.ProcessOffboardingApprovalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("解析离职审批参数失败")
+		response.BadRequest(c, "请求参数格式错误")
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Unauthorized(c, "用户未认证")
+		return
+	}
+	req.ApproverID = userID.(uint)
+
+	result, err := h.offboardingService.ProcessOffboardingApproval(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err, "处理离职审批失败")
+		return
+	}
+
+	response.SuccessWithMessage(c, "离职审批处理成功", result)
+}
+
+// ListHandoverTasks 获取待交接任务
+// @Summary 获取待交接任务
+// @Description 列出离职员工尚未重新分配或取消的任务
+// @Tags offboarding
+// @Produce json
+// @Param employee_id path int true "员工ID"
+// @Success 200 {object} response.Response{data=service.HandoverTasksResponse}
+// @Failure 400 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Router /api/v1/offboarding/{employee_id}/handover-tasks [get]
+func (h *OffboardingHandler) ListHandoverTasks(c *gin.Context) {
+	employeeID, err := strconv.ParseUint(c.Param("employee_id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "员工ID无效")
+		return
+	}
+
+	result, err := h.offboardingService.ListHandoverTasks(c.Request.Context(), uint(employeeID))
+	if err != nil {
+		h.handleError(c, err, "获取待交接任务失败")
+		return
+	}
+
+	response.SuccessWithMessage(c, "获取待交接任务成功", result)
+}
+
+// CompleteOffboarding 完成离职
+// @Summary 完成离职
+// @Description 所有任务交接完毕后将员工置为离职，并撤销其全部权限分配
+// @Tags offboarding
+// @Produce json
+// @Param employee_id path int true "员工ID"
+// @Success 200 {object} response.Response{data=service.OffboardingResponse}
+// @Failure 400 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Router /api/v1/offboarding/{employee_id}/complete [post]
+func (h *OffboardingHandler) CompleteOffboarding(c *gin.Context) {
+	employeeID, err := strconv.ParseUint(c.Param("employee_id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "员工ID无效")
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Unauthorized(c, "用户未认证")
+		return
+	}
+
+	result, err := h.offboardingService.CompleteOffboarding(c.Request.Context(), uint(employeeID), userID.(uint))
+	if err != nil {
+		h.handleError(c, err, "完成离职失败")
+		return
+	}
+
+	response.SuccessWithMessage(c, "员工离职办理完成", result)
+}
+
+// handleError 状态冲突返回409，其余返回500
+func (h *OffboardingHandler) handleError(c *gin.Context, err error, message string) {
+	h.logger.WithError(err).Error(message)
+	if errors.Is(err, service.ErrOffboardingNotAllowed) {
+		response.Conflict(c, err.Error())
+		return
+	}
+	response.InternalError(c, message)
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"io"
 	"net/http"
 	"strconv"
@@ -9,6 +10,8 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 	"taskmanage/internal/service"
+	"taskmanage/internal/workflow"
+	"taskmanage/pkg/response"
 )
 
 // OnboardingHandler 入职工作流处理器
@@ -26,11 +29,20 @@ func NewOnboardingHandler(onboardingService service.OnboardingService, logger *l
 }
 
 // CreatePendingEmployee 创建待入职员工
+// @Summary 创建待入职员工
+// @Description HR创建待入职员工，同时创建用户账号并发送激活邮件
+// @Tags 入职工作流
+// @Accept json
+// @Produce json
+// @Param request body service.CreatePendingEmployeeRequest true "待入职员工信息"
+// @Success 200 {object} DataResponse{data=service.OnboardingWorkflowResponse} "创建成功"
+// @Failure 400 {object} response.Response "请求参数校验失败"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/onboarding/pending [post]
+// @Security BearerAuth
 func (h *OnboardingHandler) CreatePendingEmployee(c *gin.Context) {
 	var req service.CreatePendingEmployeeRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.WithError(err).Error("绑定创建待入职员工请求失败")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数无效", "details": err.Error()})
+	if !response.BindAndValidate(c, &req) {
 		return
 	}
 
@@ -50,12 +62,78 @@ func (h *OnboardingHandler) CreatePendingEmployee(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "创建待入职员工成功", "data": result})
 }
 
+// maxEmployeeImportFileSize 批量导入文件的大小上限
+const maxEmployeeImportFileSize = 2 << 20
+
+// ImportPendingEmployees 批量导入待入职员工
+// @Summary 批量导入待入职员工
+// @Description 上传CSV批量创建待入职员工，列为real_name、email、phone、expected_date（必填）及department_code、position_code、notes（可选），首行为表头。逐行校验邮箱格式、部门和职位编码、文件内及与已有用户的邮箱重复，校验通过的行各自在独立事务中创建用户和员工并发送激活邮件，单行失败不影响其它行。dry_run=true时只校验不创建；format=csv时以CSV附件返回每行结果
+// @Tags 入职工作流
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "CSV文件，不超过2MB、500行"
+// @Param dry_run query bool false "只校验不创建"
+// @Param format query string false "结果格式，json（默认）或csv" Enums(json, csv)
+// @Success 200 {object} response.Response{data=service.EmployeeImportReport} "每行的处理结果"
+// @Failure 400 {object} response.Response "文件无法解析、缺少必填列或超过行数上限"
+// @Router /api/v1/onboarding/import [post]
+// @Security BearerAuth
+func (h *OnboardingHandler) ImportPendingEmployees(c *gin.Context) {
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		response.BadRequest(c, "format只能为json或csv")
+		return
+	}
+	dryRun := c.Query("dry_run") == "true"
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxEmployeeImportFileSize)
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		response.BadRequest(c, "请上传不超过2MB的CSV文件（字段名file）")
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		response.BadRequest(c, "无法读取上传的文件")
+		return
+	}
+	defer file.Close()
+
+	result, err := h.onboardingService.ImportPendingEmployees(c.Request.Context(), file, dryRun)
+	if err != nil {
+		response.FromError(c, err)
+		return
+	}
+
+	if format == "csv" {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Header("Content-Disposition", `attachment; filename="employee_import_result.csv"`)
+		c.Status(http.StatusOK)
+		if err := service.WriteEmployeeImportResult(c.Writer, result); err != nil {
+			h.logger.WithError(err).Error("写出员工导入结果失败")
+			c.Abort()
+		}
+		return
+	}
+	response.Success(c, result)
+}
+
 // ConfirmOnboarding 确认入职
+// @Summary 确认入职
+// @Description 部门经理确认员工入职，状态由审批通过变为入职中
+// @Tags 入职工作流
+// @Accept json
+// @Produce json
+// @Param request body service.OnboardConfirmRequest true "确认入职请求"
+// @Success 200 {object} DataResponse{data=service.OnboardingWorkflowResponse} "确认成功"
+// @Failure 400 {object} response.Response "请求参数校验失败"
+// @Failure 409 {object} ErrorResponse "当前入职状态不允许该操作，allowed为可转换到的状态"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/onboarding/confirm [post]
+// @Security BearerAuth
 func (h *OnboardingHandler) ConfirmOnboarding(c *gin.Context) {
 	var req service.OnboardConfirmRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.WithError(err).Error("绑定确认入职请求失败")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数无效", "details": err.Error()})
+	if !response.BindAndValidate(c, &req) {
 		return
 	}
 
@@ -67,6 +145,9 @@ func (h *OnboardingHandler) ConfirmOnboarding(c *gin.Context) {
 	result, err := h.onboardingService.ConfirmOnboarding(c.Request.Context(), &req)
 	if err != nil {
 		h.logger.WithError(err).Error("确认入职失败")
+		if respondTransitionError(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "确认入职失败", "details": err.Error()})
 		return
 	}
@@ -76,6 +157,18 @@ func (h *OnboardingHandler) ConfirmOnboarding(c *gin.Context) {
 }
 
 // CompleteProbation 完成试用期
+// @Summary 完成入职手续
+// @Description 入职手续办理完成，员工进入试用期
+// @Tags 入职工作流
+// @Produce json
+// @Param employee_id path int true "员工ID"
+// @Success 200 {object} DataResponse{data=service.OnboardingWorkflowResponse} "操作成功"
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 401 {object} ErrorResponse "无法获取操作员信息"
+// @Failure 409 {object} ErrorResponse "当前入职状态不允许该操作，allowed为可转换到的状态"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/onboarding/{employee_id}/probation [post]
+// @Security BearerAuth
 func (h *OnboardingHandler) CompleteProbation(c *gin.Context) {
 	employeeIDStr := c.Param("employee_id")
 	employeeID, err := strconv.ParseUint(employeeIDStr, 10, 32)
@@ -101,6 +194,9 @@ func (h *OnboardingHandler) CompleteProbation(c *gin.Context) {
 	result, err := h.onboardingService.CompleteProbation(c.Request.Context(), uint(employeeID), operatorID.(uint))
 	if err != nil {
 		h.logger.WithError(err).Error("完成试用期失败")
+		if respondTransitionError(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "完成试用期失败", "details": err.Error()})
 		return
 	}
@@ -110,11 +206,21 @@ func (h *OnboardingHandler) CompleteProbation(c *gin.Context) {
 }
 
 // ConfirmEmployee 确认员工（试用期转正）
+// @Summary 试用期转正
+// @Tags 入职工作流
+// @Accept json
+// @Produce json
+// @Param request body service.ProbationToActiveRequest true "转正请求"
+// @Success 200 {object} DataResponse{data=service.OnboardingWorkflowResponse} "转正成功"
+// @Failure 400 {object} response.Response "请求参数校验失败"
+// @Failure 401 {object} ErrorResponse "无法获取操作员信息"
+// @Failure 409 {object} ErrorResponse "当前入职状态不允许该操作，allowed为可转换到的状态"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/onboarding/confirm-employee [post]
+// @Security BearerAuth
 func (h *OnboardingHandler) ConfirmEmployee(c *gin.Context) {
 	var req service.ProbationToActiveRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.WithError(err).Error("绑定员工确认请求失败")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数无效", "details": err.Error()})
+	if !response.BindAndValidate(c, &req) {
 		return
 	}
 
@@ -134,6 +240,9 @@ func (h *OnboardingHandler) ConfirmEmployee(c *gin.Context) {
 	result, err := h.onboardingService.ConfirmEmployee(c.Request.Context(), &req, operatorID.(uint))
 	if err != nil {
 		h.logger.WithError(err).Error("确认员工失败")
+		if respondTransitionError(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "确认员工失败", "details": err.Error()})
 		return
 	}
@@ -143,11 +252,21 @@ func (h *OnboardingHandler) ConfirmEmployee(c *gin.Context) {
 }
 
 // ChangeEmployeeStatus 更改员工入职状态
+// @Summary 变更员工入职状态
+// @Tags 入职工作流
+// @Accept json
+// @Produce json
+// @Param request body service.EmployeeStatusChangeRequest true "状态变更请求"
+// @Success 200 {object} DataResponse{data=service.OnboardingWorkflowResponse} "变更成功"
+// @Failure 400 {object} response.Response "请求参数校验失败"
+// @Failure 401 {object} ErrorResponse "无法获取操作员信息"
+// @Failure 409 {object} ErrorResponse "当前入职状态不允许该操作，allowed为可转换到的状态"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/onboarding/change-status [post]
+// @Security BearerAuth
 func (h *OnboardingHandler) ChangeEmployeeStatus(c *gin.Context) {
 	var req service.EmployeeStatusChangeRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.WithError(err).Error("绑定状态更改请求失败")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数无效", "details": err.Error()})
+	if !response.BindAndValidate(c, &req) {
 		return
 	}
 
@@ -168,6 +287,9 @@ func (h *OnboardingHandler) ChangeEmployeeStatus(c *gin.Context) {
 	result, err := h.onboardingService.ChangeEmployeeStatus(c.Request.Context(), &req, operatorID.(uint))
 	if err != nil {
 		h.logger.WithError(err).Error("更改员工状态失败")
+		if respondTransitionError(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "更改员工状态失败", "details": err.Error()})
 		return
 	}
@@ -185,13 +307,14 @@ func (h *OnboardingHandler) ChangeEmployeeStatus(c *gin.Context) {
 // @Param page query int false "页码" default(1)
 // @Param page_size query int false "每页数量" default(10)
 // @Param status query string false "入职状态过滤"
-// @Param department query string false "部门过滤"
-// @Param date_from query string false "开始日期过滤"
-// @Param date_to query string false "结束日期过滤"
-// @Success 200 {object} response.Response{data=[]service.OnboardingWorkflowResponse}
-// @Failure 400 {object} response.Response
-// @Failure 500 {object} response.Response
-// @Router /api/onboarding/workflows [get]
+// @Param department query string false "部门名称过滤"
+// @Param date_from query string false "预期入职日期起始（YYYY-MM-DD）"
+// @Param date_to query string false "预期入职日期截止（YYYY-MM-DD，含当天）"
+// @Success 200 {object} DataResponse{data=service.ListResponse[service.OnboardingWorkflowResponse]} "获取成功"
+// @Failure 400 {object} ErrorResponse "查询参数无效"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/onboarding/workflows [get]
+// @Security BearerAuth
 func (h *OnboardingHandler) GetOnboardingWorkflows(c *gin.Context) {
 	// 解析查询参数
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
@@ -213,6 +336,10 @@ func (h *OnboardingHandler) GetOnboardingWorkflows(c *gin.Context) {
 	}).Info("处理获取入职工作流列表请求")
 
 	workflows, err := h.onboardingService.GetOnboardingWorkflows(c.Request.Context(), filter)
+	if errors.Is(err, service.ErrInvalidOnboardingFilter) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "查询参数无效", "details": err.Error()})
+		return
+	}
 	if err != nil {
 		h.logger.WithError(err).Error("获取入职工作流列表失败")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取入职工作流列表失败", "details": err.Error()})
@@ -224,6 +351,15 @@ func (h *OnboardingHandler) GetOnboardingWorkflows(c *gin.Context) {
 }
 
 // GetOnboardingHistory 获取入职历史记录
+// @Summary 获取入职历史记录
+// @Tags 入职工作流
+// @Produce json
+// @Param employee_id path int true "员工ID"
+// @Success 200 {object} DataResponse{data=[]service.OnboardingHistoryResponse} "获取成功"
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/onboarding/{employee_id}/history [get]
+// @Security BearerAuth
 func (h *OnboardingHandler) GetOnboardingHistory(c *gin.Context) {
 	employeeIDStr := c.Param("employee_id")
 	employeeID, err := strconv.ParseUint(employeeIDStr, 10, 32)
@@ -248,12 +384,69 @@ func (h *OnboardingHandler) GetOnboardingHistory(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "获取入职历史记录成功", "data": history})
 }
 
+// GetStatusTransitions 获取入职状态机
+// @Summary 获取入职状态机
+// @Description 返回每个入职状态可以转换到的状态。指定employee_id时同时返回该员工的当前状态和可转换到的状态，供界面只展示合法的操作
+// @Tags 入职工作流
+// @Produce json
+// @Param employee_id query int false "员工ID"
+// @Success 200 {object} response.Response{data=service.OnboardingTransitionsResponse} "获取成功"
+// @Failure 400 {object} response.Response "员工ID无效"
+// @Failure 404 {object} response.Response "员工不存在"
+// @Router /api/v1/onboarding/transitions [get]
+// @Security BearerAuth
+func (h *OnboardingHandler) GetStatusTransitions(c *gin.Context) {
+	var employeeID uint64
+	if raw := c.Query("employee_id"); raw != "" {
+		var err error
+		if employeeID, err = strconv.ParseUint(raw, 10, 32); err != nil || employeeID == 0 {
+			response.BadRequest(c, "员工ID无效")
+			return
+		}
+	}
+
+	result, err := h.onboardingService.GetStatusTransitions(c.Request.Context(), uint(employeeID))
+	if err != nil {
+		response.FromError(c, err)
+		return
+	}
+	response.Success(c, result)
+}
+
+// respondTransitionError 入职状态转换不合法时返回409和当前状态可转换到的状态，已响应时返回true
+func respondTransitionError(c *gin.Context, err error) bool {
+	var transition *service.InvalidStatusTransitionError
+	if !errors.As(err, &transition) {
+		return false
+	}
+	c.JSON(http.StatusConflict, gin.H{"error": "当前入职状态不允许该操作", "details": err.Error(), "allowed": transition.Allowed})
+	return true
+}
+
 // StartOnboardingApproval 启动入职审批流程
+// @Summary 启动入职审批流程
+// @Description 成功启动返回201。员工已有运行中的入职审批时不会重复启动：默认返回200和已在运行的流程（already_running为true），on_duplicate=conflict时返回409和该流程实例ID。workflow_type为simple（HR审批）或full（HR、部门负责人、管理员依次审批），为空时使用部门设置，部门未设置时使用系统默认流程
+// @Tags 入职工作流
+// @Accept json
+// @Produce json
+// @Param request body service.OnboardingApprovalRequest true "入职审批请求"
+// @Param on_duplicate query string false "已有运行中的入职审批时的处理方式" Enums(return, conflict) default(return)
+// @Success 201 {object} DataResponse{data=service.OnboardingApprovalResponse} "启动成功"
+// @Success 200 {object} DataResponse{data=service.OnboardingApprovalResponse} "员工已有运行中的入职审批"
+// @Failure 400 {object} ErrorResponse "试用期天数不合法、审批参数不完整、流程类型未知或完整流程的部门未设置负责人"
+// @Failure 409 {object} DataResponse{data=RunningInstanceResult} "员工已有运行中的入职审批，或当前入职状态不允许发起审批（返回error、allowed）"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/onboarding/approval/start [post]
+// @Security BearerAuth
 func (h *OnboardingHandler) StartOnboardingApproval(c *gin.Context) {
+	onDuplicate, ok := parseOnDuplicate(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "on_duplicate只能为return或conflict"})
+		return
+	}
+
 	var req service.OnboardingApprovalRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.WithError(err).Error("解析启动审批请求失败")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误", "details": err.Error()})
+	if !response.BindAndValidate(c, &req) {
 		return
 	}
 
@@ -263,17 +456,55 @@ func (h *OnboardingHandler) StartOnboardingApproval(c *gin.Context) {
 	}
 
 	result, err := h.onboardingService.StartOnboardingApproval(c.Request.Context(), &req)
+	if errors.Is(err, service.ErrInvalidProbationDays) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "试用期天数不合法", "details": err.Error()})
+		return
+	}
+	if errors.Is(err, workflow.ErrInvalidVariable) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "入职审批参数不完整", "details": err.Error()})
+		return
+	}
+	if errors.Is(err, workflow.ErrUnknownOnboardingWorkflowType) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "入职审批流程类型未知", "details": err.Error()})
+		return
+	}
+	if errors.Is(err, service.ErrOnboardingDepartmentManagerMissing) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "部门未设置负责人", "details": err.Error()})
+		return
+	}
+	if respondTransitionError(c, err) {
+		return
+	}
 	if err != nil {
 		h.logger.WithError(err).Error("启动入职审批失败")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "启动入职审批失败", "details": err.Error()})
 		return
 	}
 
+	if result.AlreadyRunning {
+		if onDuplicate == onDuplicateConflict {
+			c.JSON(http.StatusConflict, gin.H{"message": "员工已有运行中的入职审批", "data": RunningInstanceResult{InstanceID: result.InstanceID}})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "员工已有运行中的入职审批", "data": result})
+		return
+	}
+
 	h.logger.Info("启动入职审批成功")
-	c.JSON(http.StatusOK, gin.H{"message": "启动入职审批成功", "data": result})
+	c.JSON(http.StatusCreated, gin.H{"message": "启动入职审批成功", "data": result})
 }
 
 // ProcessOnboardingApproval 处理入职审批决策
+// @Summary 处理入职审批
+// @Tags 入职工作流
+// @Accept json
+// @Produce json
+// @Param request body service.ProcessOnboardingApprovalRequest true "审批决策"
+// @Success 200 {object} DataResponse{data=service.OnboardingApprovalResponse} "处理成功"
+// @Failure 400 {object} response.Response "请求参数校验失败"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/onboarding/approval/process [post]
+// @Security BearerAuth
 func (h *OnboardingHandler) ProcessOnboardingApproval(c *gin.Context) {
 	// 先读取原始请求体用于调试
 	body, _ := c.GetRawData()
@@ -283,9 +514,7 @@ func (h *OnboardingHandler) ProcessOnboardingApproval(c *gin.Context) {
 	c.Request.Body = io.NopCloser(strings.NewReader(string(body)))
 	
 	var req service.ProcessOnboardingApprovalRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.WithError(err).Error("解析审批处理请求失败")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误", "details": err.Error()})
+	if !response.BindAndValidate(c, &req) {
 		return
 	}
 	
@@ -308,6 +537,15 @@ func (h *OnboardingHandler) ProcessOnboardingApproval(c *gin.Context) {
 }
 
 // GetPendingOnboardingApprovals 获取待审批的入职申请
+// @Summary 获取待审批的入职申请
+// @Description 返回当前用户待处理的入职审批
+// @Tags 入职工作流
+// @Produce json
+// @Success 200 {object} DataResponse{data=[]service.PendingOnboardingApproval} "获取成功"
+// @Failure 401 {object} ErrorResponse "未授权"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/onboarding/approval/pending [get]
+// @Security BearerAuth
 func (h *OnboardingHandler) GetPendingOnboardingApprovals(c *gin.Context) {
 	// 从JWT中获取用户ID
 	userID, exists := c.Get("user_id")
@@ -329,6 +567,15 @@ func (h *OnboardingHandler) GetPendingOnboardingApprovals(c *gin.Context) {
 }
 
 // GetOnboardingApprovalHistory 获取入职审批历史
+// @Summary 获取入职审批历史
+// @Tags 入职工作流
+// @Produce json
+// @Param employee_id path int true "员工ID"
+// @Success 200 {object} DataResponse{data=[]service.OnboardingApprovalHistory} "获取成功"
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/onboarding/approval/history/{employee_id} [get]
+// @Security BearerAuth
 func (h *OnboardingHandler) GetOnboardingApprovalHistory(c *gin.Context) {
 	employeeIDStr := c.Param("employee_id")
 	employeeID, err := strconv.ParseUint(employeeIDStr, 10, 32)
@@ -350,6 +597,18 @@ func (h *OnboardingHandler) GetOnboardingApprovalHistory(c *gin.Context) {
 }
 
 // CancelOnboardingApproval 取消入职审批流程
+// @Summary 取消入职审批流程
+// @Tags 入职工作流
+// @Accept json
+// @Produce json
+// @Param instance_id path string true "流程实例ID"
+// @Param request body CancelOnboardingApprovalRequest true "取消原因"
+// @Success 200 {object} DataResponse "取消成功"
+// @Failure 400 {object} response.Response "请求参数校验失败"
+// @Failure 401 {object} ErrorResponse "未授权"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/onboarding/approval/cancel/{instance_id} [post]
+// @Security BearerAuth
 func (h *OnboardingHandler) CancelOnboardingApproval(c *gin.Context) {
 	instanceID := c.Param("instance_id")
 	if instanceID == "" {
@@ -358,12 +617,8 @@ func (h *OnboardingHandler) CancelOnboardingApproval(c *gin.Context) {
 		return
 	}
 
-	var req struct {
-		Reason string `json:"reason" binding:"required"`
-	}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.WithError(err).Error("解析取消请求失败")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数错误", "details": err.Error()})
+	var req CancelOnboardingApprovalRequest
+	if !response.BindAndValidate(c, &req) {
 		return
 	}
 
@@ -385,3 +640,8 @@ func (h *OnboardingHandler) CancelOnboardingApproval(c *gin.Context) {
 	h.logger.Info("取消入职审批成功")
 	c.JSON(http.StatusOK, gin.H{"message": "取消入职审批成功"})
 }
+
+// CancelOnboardingApprovalRequest 取消入职审批流程请求
+type CancelOnboardingApprovalRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
@@ -1,12 +1,14 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 
+	"taskmanage/internal/repository"
 	"taskmanage/internal/service"
 	"taskmanage/pkg/response"
 )
@@ -25,19 +27,38 @@ func NewPermissionAssignmentHandler(permissionAssignmentService service.Permissi
 	}
 }
 
+// RevokePermissionAssignmentRequest 撤销权限分配请求
+type RevokePermissionAssignmentRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// ProcessPermissionApprovalRequest 处理权限审批请求
+type ProcessPermissionApprovalRequest struct {
+	Approved *bool  `json:"approved" binding:"required"` // 指针类型，false表示拒绝时也能通过required校验
+	Comments string `json:"comments"`
+}
+
 // CreatePermissionTemplate 创建权限模板
+// @Summary 创建权限模板
+// @Tags 权限分配
+// @Accept json
+// @Produce json
+// @Param request body service.CreatePermissionTemplateRequest true "权限模板信息"
+// @Success 200 {object} response.Response{data=service.PermissionTemplateResponse} "创建成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 409 {object} response.Response "模板编码已存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/permissions/templates [post]
+// @Security BearerAuth
 func (h *PermissionAssignmentHandler) CreatePermissionTemplate(c *gin.Context) {
 	var req service.CreatePermissionTemplateRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.Warnf("创建权限模板参数绑定失败: %v", err)
-		response.BadRequest(c, "参数错误")
+	if !response.BindAndValidate(c, &req) {
 		return
 	}
 
 	template, err := h.permissionAssignmentService.CreatePermissionTemplate(c.Request.Context(), &req)
 	if err != nil {
-		h.logger.Errorf("创建权限模板失败: %v", err)
-		response.InternalError(c, "创建权限模板失败")
+		h.handleTemplateError(c, err, "创建权限模板失败")
 		return
 	}
 
@@ -46,6 +67,15 @@ func (h *PermissionAssignmentHandler) CreatePermissionTemplate(c *gin.Context) {
 }
 
 // GetPermissionTemplate 获取权限模板
+// @Summary 获取权限模板
+// @Tags 权限分配
+// @Produce json
+// @Param id path int true "模板ID"
+// @Success 200 {object} response.Response{data=service.PermissionTemplateResponse} "获取成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 404 {object} response.Response "权限模板不存在"
+// @Router /api/v1/permissions/templates/{id} [get]
+// @Security BearerAuth
 func (h *PermissionAssignmentHandler) GetPermissionTemplate(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
@@ -65,6 +95,22 @@ func (h *PermissionAssignmentHandler) GetPermissionTemplate(c *gin.Context) {
 }
 
 // ListPermissionTemplates 获取权限模板列表
+// @Summary 获取权限模板列表
+// @Tags 权限分配
+// @Produce json
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Param category query string false "模板分类"
+// @Param level query int false "权限级别"
+// @Param department_id query int false "部门ID"
+// @Param position_id query int false "职位ID"
+// @Param is_active query bool false "是否启用"
+// @Param search query string false "搜索关键词"
+// @Success 200 {object} response.Response{data=service.ListPermissionTemplatesResponse} "获取成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/permissions/templates [get]
+// @Security BearerAuth
 func (h *PermissionAssignmentHandler) ListPermissionTemplates(c *gin.Context) {
 	var req service.ListPermissionTemplatesRequest
 	if err := c.ShouldBindQuery(&req); err != nil {
@@ -92,6 +138,19 @@ func (h *PermissionAssignmentHandler) ListPermissionTemplates(c *gin.Context) {
 }
 
 // UpdatePermissionTemplate 更新权限模板
+// @Summary 更新权限模板
+// @Description 部分更新，只修改请求中提供的字段；permissions按权限ID替换模板包含的权限；模板编码创建后不可修改
+// @Tags 权限分配
+// @Accept json
+// @Produce json
+// @Param id path int true "模板ID"
+// @Param request body service.UpdatePermissionTemplateRequest true "更新内容"
+// @Success 200 {object} response.Response{data=service.PermissionTemplateResponse} "更新成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 404 {object} response.Response "权限模板不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/permissions/templates/{id} [put]
+// @Security BearerAuth
 func (h *PermissionAssignmentHandler) UpdatePermissionTemplate(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
@@ -101,16 +160,13 @@ func (h *PermissionAssignmentHandler) UpdatePermissionTemplate(c *gin.Context) {
 	}
 
 	var req service.UpdatePermissionTemplateRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.Warnf("更新权限模板参数绑定失败: %v", err)
-		response.BadRequest(c, "参数错误")
+	if !response.BindAndValidate(c, &req) {
 		return
 	}
 
 	template, err := h.permissionAssignmentService.UpdatePermissionTemplate(c.Request.Context(), uint(id), &req)
 	if err != nil {
-		h.logger.Errorf("更新权限模板失败: %v", err)
-		response.InternalError(c, "更新权限模板失败")
+		h.handleTemplateError(c, err, "更新权限模板失败")
 		return
 	}
 
@@ -119,6 +175,19 @@ func (h *PermissionAssignmentHandler) UpdatePermissionTemplate(c *gin.Context) {
 }
 
 // DeletePermissionTemplate 删除权限模板
+// @Summary 删除权限模板
+// @Description 模板仍被生效中或待审批的权限分配、入职权限配置引用时返回409；mode=deactivate时只停用模板
+// @Tags 权限分配
+// @Produce json
+// @Param id path int true "模板ID"
+// @Param mode query string false "删除方式" Enums(delete, deactivate) default(delete)
+// @Success 200 {object} response.Response{data=MessageResult} "删除成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 404 {object} response.Response "权限模板不存在"
+// @Failure 409 {object} response.Response "权限模板仍在使用中"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/permissions/templates/{id} [delete]
+// @Security BearerAuth
 func (h *PermissionAssignmentHandler) DeletePermissionTemplate(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
@@ -127,22 +196,282 @@ func (h *PermissionAssignmentHandler) DeletePermissionTemplate(c *gin.Context) {
 		return
 	}
 
-	if err := h.permissionAssignmentService.DeletePermissionTemplate(c.Request.Context(), uint(id)); err != nil {
-		h.logger.Errorf("删除权限模板失败: %v", err)
-		response.InternalError(c, "删除权限模板失败")
+	mode := c.DefaultQuery("mode", "delete")
+	if mode != "delete" && mode != "deactivate" {
+		response.BadRequest(c, "mode只能为delete或deactivate")
+		return
+	}
+	deactivate := mode == "deactivate"
+
+	if err := h.permissionAssignmentService.DeletePermissionTemplate(c.Request.Context(), uint(id), deactivate); err != nil {
+		h.handleTemplateError(c, err, "删除权限模板失败")
 		return
 	}
 
+	if deactivate {
+		h.logger.Infof("成功停用权限模板: %d", id)
+		response.Success(c, MessageResult{Message: "权限模板已停用"})
+		return
+	}
 	h.logger.Infof("成功删除权限模板: %d", id)
-	response.Success(c, gin.H{"message": "权限模板删除成功"})
+	response.Success(c, MessageResult{Message: "权限模板删除成功"})
+}
+
+// PreviewPermissionTemplate 预览应用权限模板后的权限变化
+// @Summary 预览权限模板应用
+// @Description 对比用户当前有效权限（角色权限及生效中的分配）与应用模板后的权限，返回新增、移除和不变的权限，不做任何修改
+// @Tags 权限分配
+// @Accept json
+// @Produce json
+// @Param id path int true "模板ID"
+// @Param request body service.PreviewPermissionTemplateRequest true "预览请求"
+// @Success 200 {object} response.Response{data=service.PermissionTemplatePreviewResponse} "预览成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 404 {object} response.Response "用户或权限模板不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/permissions/templates/{id}/preview [post]
+// @Security BearerAuth
+func (h *PermissionAssignmentHandler) PreviewPermissionTemplate(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的模板ID")
+		return
+	}
+
+	var req service.PreviewPermissionTemplateRequest
+	if !response.BindAndValidate(c, &req) {
+		return
+	}
+
+	preview, err := h.permissionAssignmentService.PreviewPermissionTemplateApplication(c.Request.Context(), req.UserID, uint(id), req.ReplaceExisting)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			response.NotFound(c, "用户或权限模板不存在")
+			return
+		}
+		h.logger.Errorf("预览权限模板应用失败: %v", err)
+		response.InternalError(c, "预览权限模板应用失败")
+		return
+	}
+
+	response.Success(c, preview)
+}
+
+// ApplyPermissionTemplate 为用户应用权限模板
+// @Summary 应用权限模板
+// @Description replace_existing为true时在同一事务中撤销用户其它通过模板分配的权限，并为每条撤销记录历史
+// @Tags 权限分配
+// @Accept json
+// @Produce json
+// @Param id path int true "模板ID"
+// @Param request body service.ApplyPermissionTemplateRequest true "应用请求"
+// @Success 200 {object} response.Response{data=service.PermissionAssignmentResponse} "应用成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 404 {object} response.Response "权限模板不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/permissions/templates/{id}/apply [post]
+// @Security BearerAuth
+func (h *PermissionAssignmentHandler) ApplyPermissionTemplate(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的模板ID")
+		return
+	}
+
+	var req service.ApplyPermissionTemplateRequest
+	if !response.BindAndValidate(c, &req) {
+		return
+	}
+
+	// 从JWT中获取操作员ID
+	operatorID, exists := c.Get("user_id")
+	if !exists {
+		response.Unauthorized(c, "未授权")
+		return
+	}
+
+	assignment, err := h.permissionAssignmentService.ApplyPermissionTemplate(c.Request.Context(), req.UserID, uint(id), operatorID.(uint), req.Reason, req.ReplaceExisting)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			response.NotFound(c, "权限模板不存在")
+			return
+		}
+		h.logger.Errorf("应用权限模板失败: %v", err)
+		response.InternalError(c, "应用权限模板失败")
+		return
+	}
+
+	h.logger.Infof("成功应用权限模板: template=%d, user=%d", id, req.UserID)
+	response.Success(c, assignment)
+}
+
+// CreatePermissionRule 创建权限规则
+// @Summary 创建权限规则
+// @Description tenure_days和confirmed_days规则的条件值为入职或转正天数，由每日定时任务执行，每条规则对每个用户只执行一次
+// @Tags 权限分配
+// @Accept json
+// @Produce json
+// @Param request body service.CreatePermissionRuleRequest true "权限规则信息"
+// @Success 200 {object} response.Response{data=service.PermissionRuleResponse} "创建成功"
+// @Failure 400 {object} response.Response "请求参数错误或权限模板不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/permissions/rules [post]
+// @Security BearerAuth
+func (h *PermissionAssignmentHandler) CreatePermissionRule(c *gin.Context) {
+	var req service.CreatePermissionRuleRequest
+	if !response.BindAndValidate(c, &req) {
+		return
+	}
+
+	rule, err := h.permissionAssignmentService.CreatePermissionRule(c.Request.Context(), &req)
+	if err != nil {
+		h.handleRuleError(c, err, "创建权限规则失败")
+		return
+	}
+
+	h.logger.Infof("成功创建权限规则: %s", rule.Name)
+	response.Success(c, rule)
+}
+
+// GetPermissionRule 获取权限规则
+// @Summary 获取权限规则
+// @Tags 权限分配
+// @Produce json
+// @Param id path int true "规则ID"
+// @Success 200 {object} response.Response{data=service.PermissionRuleResponse} "获取成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 404 {object} response.Response "权限规则不存在"
+// @Router /api/v1/permissions/rules/{id} [get]
+// @Security BearerAuth
+func (h *PermissionAssignmentHandler) GetPermissionRule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的规则ID")
+		return
+	}
+
+	rule, err := h.permissionAssignmentService.GetPermissionRule(c.Request.Context(), uint(id))
+	if err != nil {
+		h.handleRuleError(c, err, "获取权限规则失败")
+		return
+	}
+
+	response.Success(c, rule)
+}
+
+// ListPermissionRules 获取权限规则列表
+// @Summary 获取权限规则列表
+// @Tags 权限分配
+// @Produce json
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Param template_id query int false "权限模板ID"
+// @Param trigger_condition query string false "触发条件"
+// @Param action query string false "动作" Enums(grant, revoke, upgrade)
+// @Param is_active query bool false "是否启用"
+// @Param search query string false "搜索关键词"
+// @Success 200 {object} response.Response{data=service.ListPermissionRulesResponse} "获取成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/permissions/rules [get]
+// @Security BearerAuth
+func (h *PermissionAssignmentHandler) ListPermissionRules(c *gin.Context) {
+	var req service.ListPermissionRulesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.logger.Warnf("权限规则列表参数绑定失败: %v", err)
+		response.BadRequest(c, "参数错误")
+		return
+	}
+
+	rules, err := h.permissionAssignmentService.ListPermissionRules(c.Request.Context(), &req)
+	if err != nil {
+		h.handleRuleError(c, err, "获取权限规则列表失败")
+		return
+	}
+
+	response.Success(c, rules)
+}
+
+// UpdatePermissionRule 更新权限规则
+// @Summary 更新权限规则
+// @Description 部分更新，已执行过规则的用户不会因修改条件而重新执行
+// @Tags 权限分配
+// @Accept json
+// @Produce json
+// @Param id path int true "规则ID"
+// @Param request body service.UpdatePermissionRuleRequest true "更新内容"
+// @Success 200 {object} response.Response{data=service.PermissionRuleResponse} "更新成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 404 {object} response.Response "权限规则不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/permissions/rules/{id} [put]
+// @Security BearerAuth
+func (h *PermissionAssignmentHandler) UpdatePermissionRule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的规则ID")
+		return
+	}
+
+	var req service.UpdatePermissionRuleRequest
+	if !response.BindAndValidate(c, &req) {
+		return
+	}
+
+	rule, err := h.permissionAssignmentService.UpdatePermissionRule(c.Request.Context(), uint(id), &req)
+	if err != nil {
+		h.handleRuleError(c, err, "更新权限规则失败")
+		return
+	}
+
+	h.logger.Infof("成功更新权限规则: %d", id)
+	response.Success(c, rule)
+}
+
+// DeletePermissionRule 删除权限规则
+// @Summary 删除权限规则
+// @Description 规则已创建的权限分配不受影响
+// @Tags 权限分配
+// @Produce json
+// @Param id path int true "规则ID"
+// @Success 200 {object} response.Response{data=MessageResult} "删除成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 404 {object} response.Response "权限规则不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/permissions/rules/{id} [delete]
+// @Security BearerAuth
+func (h *PermissionAssignmentHandler) DeletePermissionRule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的规则ID")
+		return
+	}
+
+	if err := h.permissionAssignmentService.DeletePermissionRule(c.Request.Context(), uint(id)); err != nil {
+		h.handleRuleError(c, err, "删除权限规则失败")
+		return
+	}
+
+	h.logger.Infof("成功删除权限规则: %d", id)
+	response.Success(c, MessageResult{Message: "权限规则删除成功"})
 }
 
 // AssignPermissions 分配权限
+// @Summary 分配权限
+// @Description 按模板或权限列表为用户分配权限
+// @Tags 权限分配
+// @Accept json
+// @Produce json
+// @Param request body service.AssignPermissionsRequest true "权限分配请求"
+// @Success 200 {object} response.Response{data=service.PermissionAssignmentResponse} "分配成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/permissions/assignments [post]
+// @Security BearerAuth
 func (h *PermissionAssignmentHandler) AssignPermissions(c *gin.Context) {
 	var req service.AssignPermissionsRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.Warnf("分配权限参数绑定失败: %v", err)
-		response.BadRequest(c, "参数错误")
+	if !response.BindAndValidate(c, &req) {
 		return
 	}
 
@@ -158,6 +487,15 @@ func (h *PermissionAssignmentHandler) AssignPermissions(c *gin.Context) {
 }
 
 // GetPermissionAssignment 获取权限分配
+// @Summary 获取权限分配
+// @Tags 权限分配
+// @Produce json
+// @Param id path int true "分配ID"
+// @Success 200 {object} response.Response{data=service.PermissionAssignmentResponse} "获取成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 404 {object} response.Response "权限分配不存在"
+// @Router /api/v1/permissions/assignments/{id} [get]
+// @Security BearerAuth
 func (h *PermissionAssignmentHandler) GetPermissionAssignment(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
@@ -177,6 +515,22 @@ func (h *PermissionAssignmentHandler) GetPermissionAssignment(c *gin.Context) {
 }
 
 // ListPermissionAssignments 获取权限分配列表
+// @Summary 获取权限分配列表
+// @Tags 权限分配
+// @Produce json
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Param user_id query int false "用户ID"
+// @Param template_id query int false "模板ID"
+// @Param status query string false "分配状态"
+// @Param approval_status query string false "审批状态"
+// @Param assigned_by query int false "分配人ID"
+// @Param search query string false "搜索关键词"
+// @Success 200 {object} response.Response{data=service.ListPermissionAssignmentsResponse} "获取成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/permissions/assignments [get]
+// @Security BearerAuth
 func (h *PermissionAssignmentHandler) ListPermissionAssignments(c *gin.Context) {
 	var req service.ListPermissionAssignmentsRequest
 	if err := c.ShouldBindQuery(&req); err != nil {
@@ -204,6 +558,18 @@ func (h *PermissionAssignmentHandler) ListPermissionAssignments(c *gin.Context)
 }
 
 // RevokePermissionAssignment 撤销权限分配
+// @Summary 撤销权限分配
+// @Tags 权限分配
+// @Accept json
+// @Produce json
+// @Param id path int true "分配ID"
+// @Param request body RevokePermissionAssignmentRequest true "撤销原因"
+// @Success 200 {object} response.Response{data=MessageResult} "撤销成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/permissions/assignments/{id} [delete]
+// @Security BearerAuth
 func (h *PermissionAssignmentHandler) RevokePermissionAssignment(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
@@ -212,12 +578,8 @@ func (h *PermissionAssignmentHandler) RevokePermissionAssignment(c *gin.Context)
 		return
 	}
 
-	var req struct {
-		Reason string `json:"reason" binding:"required"`
-	}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.Warnf("撤销权限分配参数绑定失败: %v", err)
-		response.BadRequest(c, "参数错误")
+	var req RevokePermissionAssignmentRequest
+	if !response.BindAndValidate(c, &req) {
 		return
 	}
 
@@ -235,10 +597,25 @@ func (h *PermissionAssignmentHandler) RevokePermissionAssignment(c *gin.Context)
 	}
 
 	h.logger.Infof("成功撤销权限分配: %d", id)
-	response.Success(c, gin.H{"message": "权限分配撤销成功"})
+	response.Success(c, MessageResult{Message: "权限分配撤销成功"})
 }
 
 // GetPermissionAssignmentHistory 获取权限分配历史
+// @Summary 获取权限分配历史
+// @Tags 权限分配
+// @Produce json
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Param assignment_id query int false "分配ID"
+// @Param user_id query int false "用户ID"
+// @Param action query string false "操作类型"
+// @Param operator_id query int false "操作人ID"
+// @Param search query string false "搜索关键词"
+// @Success 200 {object} response.Response{data=service.ListPermissionAssignmentHistoryResponse} "获取成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/permissions/assignments/history [get]
+// @Security BearerAuth
 func (h *PermissionAssignmentHandler) GetPermissionAssignmentHistory(c *gin.Context) {
 	var req service.GetPermissionAssignmentHistoryRequest
 	if err := c.ShouldBindQuery(&req); err != nil {
@@ -266,11 +643,19 @@ func (h *PermissionAssignmentHandler) GetPermissionAssignmentHistory(c *gin.Cont
 }
 
 // CreateOnboardingPermissionConfig 创建入职权限配置
+// @Summary 创建入职权限配置
+// @Tags 权限分配
+// @Accept json
+// @Produce json
+// @Param request body service.CreateOnboardingPermissionConfigRequest true "入职权限配置"
+// @Success 200 {object} response.Response{data=service.OnboardingPermissionConfigResponse} "创建成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/permissions/onboarding-configs [post]
+// @Security BearerAuth
 func (h *PermissionAssignmentHandler) CreateOnboardingPermissionConfig(c *gin.Context) {
 	var req service.CreateOnboardingPermissionConfigRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.Warnf("创建入职权限配置参数绑定失败: %v", err)
-		response.BadRequest(c, "参数错误")
+	if !response.BindAndValidate(c, &req) {
 		return
 	}
 
@@ -286,6 +671,15 @@ func (h *PermissionAssignmentHandler) CreateOnboardingPermissionConfig(c *gin.Co
 }
 
 // GetOnboardingPermissionConfig 获取入职权限配置
+// @Summary 获取入职权限配置
+// @Tags 权限分配
+// @Produce json
+// @Param id path int true "配置ID"
+// @Success 200 {object} response.Response{data=service.OnboardingPermissionConfigResponse} "获取成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 404 {object} response.Response "入职权限配置不存在"
+// @Router /api/v1/permissions/onboarding-configs/{id} [get]
+// @Security BearerAuth
 func (h *PermissionAssignmentHandler) GetOnboardingPermissionConfig(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
@@ -305,6 +699,21 @@ func (h *PermissionAssignmentHandler) GetOnboardingPermissionConfig(c *gin.Conte
 }
 
 // ListOnboardingPermissionConfigs 获取入职权限配置列表
+// @Summary 获取入职权限配置列表
+// @Tags 权限分配
+// @Produce json
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Param onboarding_status query string false "入职状态"
+// @Param department_id query int false "部门ID"
+// @Param position_id query int false "职位ID"
+// @Param auto_assign query bool false "是否自动分配"
+// @Param search query string false "搜索关键词"
+// @Success 200 {object} response.Response{data=service.ListOnboardingPermissionConfigsResponse} "获取成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/permissions/onboarding-configs [get]
+// @Security BearerAuth
 func (h *PermissionAssignmentHandler) ListOnboardingPermissionConfigs(c *gin.Context) {
 	var req service.ListOnboardingPermissionConfigsRequest
 	if err := c.ShouldBindQuery(&req); err != nil {
@@ -332,6 +741,17 @@ func (h *PermissionAssignmentHandler) ListOnboardingPermissionConfigs(c *gin.Con
 }
 
 // UpdateOnboardingPermissionConfig 更新入职权限配置
+// @Summary 更新入职权限配置
+// @Tags 权限分配
+// @Accept json
+// @Produce json
+// @Param id path int true "配置ID"
+// @Param request body service.UpdateOnboardingPermissionConfigRequest true "更新内容"
+// @Success 200 {object} response.Response{data=service.OnboardingPermissionConfigResponse} "更新成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/permissions/onboarding-configs/{id} [put]
+// @Security BearerAuth
 func (h *PermissionAssignmentHandler) UpdateOnboardingPermissionConfig(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
@@ -341,9 +761,7 @@ func (h *PermissionAssignmentHandler) UpdateOnboardingPermissionConfig(c *gin.Co
 	}
 
 	var req service.UpdateOnboardingPermissionConfigRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.Warnf("更新入职权限配置参数绑定失败: %v", err)
-		response.BadRequest(c, "参数错误")
+	if !response.BindAndValidate(c, &req) {
 		return
 	}
 
@@ -359,6 +777,15 @@ func (h *PermissionAssignmentHandler) UpdateOnboardingPermissionConfig(c *gin.Co
 }
 
 // DeleteOnboardingPermissionConfig 删除入职权限配置
+// @Summary 删除入职权限配置
+// @Tags 权限分配
+// @Produce json
+// @Param id path int true "配置ID"
+// @Success 200 {object} response.Response{data=MessageResult} "删除成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/permissions/onboarding-configs/{id} [delete]
+// @Security BearerAuth
 func (h *PermissionAssignmentHandler) DeleteOnboardingPermissionConfig(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
@@ -374,10 +801,25 @@ func (h *PermissionAssignmentHandler) DeleteOnboardingPermissionConfig(c *gin.Co
 	}
 
 	h.logger.Infof("成功删除入职权限配置: %d", id)
-	response.Success(c, gin.H{"message": "入职权限配置删除成功"})
+	response.Success(c, MessageResult{Message: "入职权限配置删除成功"})
 }
 
 // ProcessPermissionApproval 处理权限审批
+// @Summary 处理权限审批
+// @Description 批准或拒绝待审批的权限分配
+// @Tags 权限分配
+// @Accept json
+// @Produce json
+// @Param id path int true "分配ID"
+// @Param request body ProcessPermissionApprovalRequest true "审批决策"
+// @Success 200 {object} response.Response{data=MessageResult} "处理成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 404 {object} response.Response "权限分配不存在"
+// @Failure 409 {object} response.Response "权限分配不是待审批状态"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/permissions/approvals/{id}/process [post]
+// @Security BearerAuth
 func (h *PermissionAssignmentHandler) ProcessPermissionApproval(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
@@ -386,13 +828,8 @@ func (h *PermissionAssignmentHandler) ProcessPermissionApproval(c *gin.Context)
 		return
 	}
 
-	var req struct {
-		Approved bool   `json:"approved" binding:"required"`
-		Comments string `json:"comments"`
-	}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.Warnf("处理权限审批参数绑定失败: %v", err)
-		response.BadRequest(c, "参数错误")
+	var req ProcessPermissionApprovalRequest
+	if !response.BindAndValidate(c, &req) {
 		return
 	}
 
@@ -403,21 +840,38 @@ func (h *PermissionAssignmentHandler) ProcessPermissionApproval(c *gin.Context)
 		return
 	}
 
-	if err := h.permissionAssignmentService.ProcessPermissionApproval(c.Request.Context(), uint(id), req.Approved, approverID.(uint), req.Comments); err != nil {
+	if err := h.permissionAssignmentService.ProcessPermissionApproval(c.Request.Context(), uint(id), *req.Approved, approverID.(uint), req.Comments); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			response.NotFound(c, "权限分配不存在")
+			return
+		}
+		if response.GetAppError(err) != nil {
+			response.FromError(c, err)
+			return
+		}
 		h.logger.Errorf("处理权限审批失败: %v", err)
 		response.InternalError(c, "处理权限审批失败")
 		return
 	}
 
 	action := "拒绝"
-	if req.Approved {
+	if *req.Approved {
 		action = "批准"
 	}
 	h.logger.Infof("成功%s权限分配: %d", action, id)
-	response.Success(c, gin.H{"message": fmt.Sprintf("权限分配%s成功", action)})
+	response.Success(c, MessageResult{Message: fmt.Sprintf("权限分配%s成功", action)})
 }
 
 // GetPendingPermissionApprovals 获取待审批权限
+// @Summary 获取待审批权限
+// @Description 返回待审批的权限分配，包括需要审批的规则分配和职级降低后待复核的分配，复核期间分配不生效
+// @Tags 权限分配
+// @Produce json
+// @Success 200 {object} response.Response{data=[]service.PermissionAssignmentResponse} "获取成功"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/permissions/approvals/pending [get]
+// @Security BearerAuth
 func (h *PermissionAssignmentHandler) GetPendingPermissionApprovals(c *gin.Context) {
 	// 从JWT中获取审批员ID
 	approverID, exists := c.Get("user_id")
@@ -435,3 +889,31 @@ func (h *PermissionAssignmentHandler) GetPendingPermissionApprovals(c *gin.Conte
 
 	response.Success(c, approvals)
 }
+
+// handleTemplateError 将权限模板相关错误映射为HTTP响应
+func (h *PermissionAssignmentHandler) handleTemplateError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidPermissionTemplate):
+		response.BadRequest(c, err.Error())
+	case errors.Is(err, service.ErrPermissionTemplateExists), errors.Is(err, service.ErrPermissionTemplateInUse):
+		response.Conflict(c, err.Error())
+	case errors.Is(err, repository.ErrNotFound):
+		response.NotFound(c, "权限模板不存在")
+	default:
+		h.logger.Errorf("%s: %v", message, err)
+		response.InternalError(c, message)
+	}
+}
+
+// handleRuleError 将权限规则相关错误映射为HTTP响应
+func (h *PermissionAssignmentHandler) handleRuleError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidPermissionRule):
+		response.BadRequest(c, err.Error())
+	case errors.Is(err, repository.ErrNotFound):
+		response.NotFound(c, "权限规则不存在")
+	default:
+		h.logger.Errorf("%s: %v", message, err)
+		response.InternalError(c, message)
+	}
+}
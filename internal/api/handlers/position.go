@@ -1,12 +1,14 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 	"taskmanage/internal/service"
+	"taskmanage/pkg/response"
 )
 
 // PositionHandler 职位处理器
@@ -24,6 +26,16 @@ func NewPositionHandler(positionService service.PositionService, logger *logrus.
 }
 
 // CreatePosition 创建职位
+// @Summary 创建职位
+// @Tags 职位管理
+// @Accept json
+// @Produce json
+// @Param request body service.CreatePositionRequest true "创建职位请求"
+// @Success 201 {object} DataResponse{data=service.PositionResponse} "创建成功"
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/positions [post]
+// @Security BearerAuth
 func (h *PositionHandler) CreatePosition(c *gin.Context) {
 	var req service.CreatePositionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -52,6 +64,17 @@ func (h *PositionHandler) CreatePosition(c *gin.Context) {
 }
 
 // UpdatePosition 更新职位
+// @Summary 更新职位
+// @Tags 职位管理
+// @Accept json
+// @Produce json
+// @Param id path int true "职位ID"
+// @Param request body service.UpdatePositionRequest true "更新职位请求"
+// @Success 200 {object} DataResponse{data=service.PositionResponse} "更新成功"
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/positions/{id} [put]
+// @Security BearerAuth
 func (h *PositionHandler) UpdatePosition(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
@@ -87,6 +110,20 @@ func (h *PositionHandler) UpdatePosition(c *gin.Context) {
 }
 
 // DeletePosition 删除职位
+// @Summary 删除职位
+// @Description 职位仍被未离职员工、权限模板或入职权限配置引用时返回409，details为各类引用数。
+// @Description 指定reassign_to时在同一事务中将这些引用迁移到替换职位后再删除；批量迁移不触发职级变化的权限规则，替换职位的职级须与原职位相同
+// @Tags 职位管理
+// @Produce json
+// @Param id path int true "职位ID"
+// @Param reassign_to query int false "替换职位ID"
+// @Success 200 {object} DataResponse "删除成功"
+// @Failure 400 {object} ErrorResponse "请求参数错误或替换职位不合法"
+// @Failure 404 {object} ErrorResponse "职位不存在"
+// @Failure 409 {object} response.Response{details=repository.PositionReferences} "职位仍被引用"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/positions/{id} [delete]
+// @Security BearerAuth
 func (h *PositionHandler) DeletePosition(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
@@ -96,11 +133,29 @@ func (h *PositionHandler) DeletePosition(c *gin.Context) {
 		return
 	}
 
+	var reassignTo *uint
+	if raw := c.Query("reassign_to"); raw != "" {
+		targetID, err := strconv.ParseUint(raw, 10, 32)
+		if err != nil || targetID == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "无效的迁移目标ID"})
+			return
+		}
+		target := uint(targetID)
+		reassignTo = &target
+	}
+
 	h.logger.WithField("id", id).Info("处理删除职位请求")
 
-	if err := h.positionService.DeletePosition(c.Request.Context(), uint(id)); err != nil {
+	if err := h.positionService.DeletePosition(c.Request.Context(), uint(id), reassignTo); err != nil {
 		h.logger.WithError(err).Error("删除职位失败")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除职位失败", "details": err.Error()})
+		switch {
+		case errors.Is(err, service.ErrPositionNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "职位不存在", "details": err.Error()})
+		case errors.Is(err, service.ErrPositionInUse), errors.Is(err, service.ErrInvalidReassignTarget):
+			response.FromError(c, err)
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "删除职位失败", "details": err.Error()})
+		}
 		return
 	}
 
@@ -108,6 +163,15 @@ func (h *PositionHandler) DeletePosition(c *gin.Context) {
 }
 
 // GetPosition 获取职位详情
+// @Summary 获取职位详情
+// @Tags 职位管理
+// @Produce json
+// @Param id path int true "职位ID"
+// @Success 200 {object} DataResponse{data=service.PositionResponse} "获取成功"
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 404 {object} ErrorResponse "职位不存在"
+// @Router /api/v1/positions/{id} [get]
+// @Security BearerAuth
 func (h *PositionHandler) GetPosition(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
@@ -130,6 +194,19 @@ func (h *PositionHandler) GetPosition(c *gin.Context) {
 }
 
 // ListPositions 获取职位列表
+// @Summary 获取职位列表
+// @Tags 职位管理
+// @Produce json
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Param sort query string false "排序字段"
+// @Param order query string false "排序方向" Enums(asc,desc)
+// @Param keyword query string false "搜索关键词"
+// @Success 200 {object} service.ListResponse[service.PositionResponse] "获取成功"
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/positions [get]
+// @Security BearerAuth
 func (h *PositionHandler) ListPositions(c *gin.Context) {
 	var req service.ListRequest
 	if err := c.ShouldBindQuery(&req); err != nil {
@@ -154,6 +231,15 @@ func (h *PositionHandler) ListPositions(c *gin.Context) {
 }
 
 // GetPositionsByCategory 根据类别获取职位
+// @Summary 按类别获取职位
+// @Tags 职位管理
+// @Produce json
+// @Param category path string true "职位类别"
+// @Success 200 {object} DataResponse{data=[]service.PositionResponse} "获取成功"
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/positions/category/{category} [get]
+// @Security BearerAuth
 func (h *PositionHandler) GetPositionsByCategory(c *gin.Context) {
 	category := c.Param("category")
 	if category == "" {
@@ -175,6 +261,15 @@ func (h *PositionHandler) GetPositionsByCategory(c *gin.Context) {
 }
 
 // GetPositionsByLevel 根据级别获取职位
+// @Summary 按级别获取职位
+// @Tags 职位管理
+// @Produce json
+// @Param level path int true "职位级别"
+// @Success 200 {object} DataResponse{data=[]service.PositionResponse} "获取成功"
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/positions/level/{level} [get]
+// @Security BearerAuth
 func (h *PositionHandler) GetPositionsByLevel(c *gin.Context) {
 	levelStr := c.Param("level")
 	level, err := strconv.Atoi(levelStr)
@@ -197,6 +292,13 @@ func (h *PositionHandler) GetPositionsByLevel(c *gin.Context) {
 }
 
 // GetPositionCategories 获取所有职位类别
+// @Summary 获取职位类别
+// @Tags 职位管理
+// @Produce json
+// @Success 200 {object} DataResponse{data=[]string} "获取成功"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/positions/categories [get]
+// @Security BearerAuth
 func (h *PositionHandler) GetPositionCategories(c *gin.Context) {
 	h.logger.Debug("处理获取职位类别请求")
 
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"taskmanage/internal/service"
+	"taskmanage/pkg/response"
+)
+
+// ProfileHandler 当前用户个人资料处理器
+type ProfileHandler struct {
+	profileService service.ProfileService
+	logger         *logrus.Logger
+}
+
+// NewProfileHandler 创建当前用户个人资料处理器
+func NewProfileHandler(profileService service.ProfileService, logger *logrus.Logger) *ProfileHandler {
+	return &ProfileHandler{
+		profileService: profileService,
+		logger:         logger,
+	}
+}
+
+// GetMyProfile 获取我的个人资料
+// @Summary 获取我的个人资料
+// @Description 返回当前用户的账号信息、员工档案（部门、职位、直接上级姓名、技能及等级）和通知偏好，没有员工档案的用户employee为null
+// @Tags 个人中心
+// @Produce json
+// @Success 200 {object} response.Response{data=service.MyProfileResponse} "获取成功"
+// @Failure 401 {object} response.Response "未认证"
+// @Router /api/v1/me [get]
+// @Security BearerAuth
+func (h *ProfileHandler) GetMyProfile(c *gin.Context) {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		response.Unauthorized(c, "用户信息缺失")
+		return
+	}
+
+	profile, err := h.profileService.GetMyProfile(c.Request.Context(), userID)
+	if err != nil {
+		response.FromError(c, err)
+		return
+	}
+
+	response.Success(c, profile)
+}
+
+// UpdateMyProfile 更新我的个人资料
+// @Summary 更新我的个人资料
+// @Description 只能修改手机号、头像和通知偏好，未提供的字段保持不变；部门、职位、状态等字段由人事维护，请求中携带时被忽略。
+// @Description 手机号变化时记录审计日志
+// @Tags 个人中心
+// @Accept json
+// @Produce json
+// @Param request body service.UpdateMyProfileRequest true "个人资料"
+// @Success 200 {object} response.Response{data=service.MyProfileResponse} "更新成功"
+// @Failure 400 {object} response.Response "请求参数错误或通知偏好不合法"
+// @Failure 401 {object} response.Response "未认证"
+// @Router /api/v1/me [put]
+// @Security BearerAuth
+func (h *ProfileHandler) UpdateMyProfile(c *gin.Context) {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		response.Unauthorized(c, "用户信息缺失")
+		return
+	}
+
+	var req service.UpdateMyProfileRequest
+	if !response.BindAndValidate(c, &req) {
+		return
+	}
+
+	profile, err := h.profileService.UpdateMyProfile(c.Request.Context(), userID, &req)
+	if err != nil {
+		response.FromError(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, "个人资料已更新", profile)
+}
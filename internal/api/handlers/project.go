@@ -1,11 +1,13 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
+	"taskmanage/internal/repository"
 	"taskmanage/internal/service"
 )
 
@@ -24,6 +26,16 @@ func NewProjectHandler(projectService service.ProjectService, logger *logrus.Log
 }
 
 // CreateProject 创建项目
+// @Summary 创建项目
+// @Tags 项目管理
+// @Accept json
+// @Produce json
+// @Param request body service.CreateProjectRequest true "创建项目请求"
+// @Success 201 {object} DataResponse{data=service.ProjectResponse} "创建成功"
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/projects [post]
+// @Security BearerAuth
 func (h *ProjectHandler) CreateProject(c *gin.Context) {
 	var req service.CreateProjectRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -52,6 +64,17 @@ func (h *ProjectHandler) CreateProject(c *gin.Context) {
 }
 
 // UpdateProject 更新项目
+// @Summary 更新项目
+// @Tags 项目管理
+// @Accept json
+// @Produce json
+// @Param id path int true "项目ID"
+// @Param request body service.UpdateProjectRequest true "更新项目请求"
+// @Success 200 {object} DataResponse{data=service.ProjectResponse} "更新成功"
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/projects/{id} [put]
+// @Security BearerAuth
 func (h *ProjectHandler) UpdateProject(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
@@ -87,6 +110,15 @@ func (h *ProjectHandler) UpdateProject(c *gin.Context) {
 }
 
 // DeleteProject 删除项目
+// @Summary 删除项目
+// @Tags 项目管理
+// @Produce json
+// @Param id path int true "项目ID"
+// @Success 200 {object} DataResponse "删除成功"
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/projects/{id} [delete]
+// @Security BearerAuth
 func (h *ProjectHandler) DeleteProject(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
@@ -108,6 +140,15 @@ func (h *ProjectHandler) DeleteProject(c *gin.Context) {
 }
 
 // GetProject 获取项目详情
+// @Summary 获取项目详情
+// @Tags 项目管理
+// @Produce json
+// @Param id path int true "项目ID"
+// @Success 200 {object} DataResponse{data=service.ProjectResponse} "获取成功"
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 404 {object} ErrorResponse "项目不存在"
+// @Router /api/v1/projects/{id} [get]
+// @Security BearerAuth
 func (h *ProjectHandler) GetProject(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
@@ -130,6 +171,19 @@ func (h *ProjectHandler) GetProject(c *gin.Context) {
 }
 
 // ListProjects 获取项目列表
+// @Summary 获取项目列表
+// @Tags 项目管理
+// @Produce json
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Param sort query string false "排序字段"
+// @Param order query string false "排序方向" Enums(asc,desc)
+// @Param keyword query string false "搜索关键词"
+// @Success 200 {object} service.ListResponse[service.ProjectResponse] "获取成功"
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/projects [get]
+// @Security BearerAuth
 func (h *ProjectHandler) ListProjects(c *gin.Context) {
 	var req service.ListRequest
 	if err := c.ShouldBindQuery(&req); err != nil {
@@ -198,6 +252,15 @@ func (h *ProjectHandler) GetProjectsByManager(c *gin.Context) {
 }
 
 // GetProjectsByStatus 根据状态获取项目
+// @Summary 按状态获取项目
+// @Tags 项目管理
+// @Produce json
+// @Param status path string true "项目状态"
+// @Success 200 {object} DataResponse{data=[]service.ProjectResponse} "获取成功"
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/projects/status/{status} [get]
+// @Security BearerAuth
 func (h *ProjectHandler) GetProjectsByStatus(c *gin.Context) {
 	status := c.Param("status")
 	if status == "" {
@@ -219,6 +282,17 @@ func (h *ProjectHandler) GetProjectsByStatus(c *gin.Context) {
 }
 
 // AddProjectMember 添加项目成员
+// @Summary 添加项目成员
+// @Tags 项目管理
+// @Accept json
+// @Produce json
+// @Param id path int true "项目ID"
+// @Param request body service.AddProjectMemberRequest true "项目成员"
+// @Success 200 {object} DataResponse "添加成功"
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/projects/{id}/members [post]
+// @Security BearerAuth
 func (h *ProjectHandler) AddProjectMember(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
@@ -250,6 +324,19 @@ func (h *ProjectHandler) AddProjectMember(c *gin.Context) {
 }
 
 // RemoveProjectMember 移除项目成员
+// @Summary 移除项目成员
+// @Description 被移除的员工以请求体中的employee_id为准
+// @Tags 项目管理
+// @Accept json
+// @Produce json
+// @Param id path int true "项目ID"
+// @Param member_id path int true "成员员工ID"
+// @Param request body service.RemoveProjectMemberRequest true "项目成员"
+// @Success 200 {object} DataResponse "移除成功"
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/projects/{id}/members/{member_id} [delete]
+// @Security BearerAuth
 func (h *ProjectHandler) RemoveProjectMember(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
@@ -281,6 +368,15 @@ func (h *ProjectHandler) RemoveProjectMember(c *gin.Context) {
 }
 
 // GetProjectMembers 获取项目成员
+// @Summary 获取项目成员
+// @Tags 项目管理
+// @Produce json
+// @Param id path int true "项目ID"
+// @Success 200 {object} DataResponse{data=[]service.EmployeeResponse} "获取成功"
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/projects/{id}/members [get]
+// @Security BearerAuth
 func (h *ProjectHandler) GetProjectMembers(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
@@ -334,3 +430,368 @@ func (h *ProjectHandler) UpdateProjectManager(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "项目管理者更新成功"})
 }
+
+// GetProjectTasks 获取按状态分组的项目任务看板
+// @Summary 获取项目任务看板
+// @Description 返回按状态分组的项目任务及各列的在制品上限
+// @Tags 项目管理
+// @Produce json
+// @Param id path int true "项目ID"
+// @Param status query string false "任务状态"
+// @Param priority query string false "优先级"
+// @Param assigned_to query int false "负责人用户ID"
+// @Param created_by query int false "创建者ID"
+// @Param expand query string false "逗号分隔的展开项: assignee、creator、project，不传时只返回ID"
+// @Success 200 {object} DataResponse{data=service.ProjectTaskBoardResponse} "获取成功"
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 404 {object} ErrorResponse "项目不存在"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/projects/{id}/tasks [get]
+// @Security BearerAuth
+func (h *ProjectHandler) GetProjectTasks(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		h.logger.WithError(err).WithField("id", idStr).Error("解析项目ID失败")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的项目ID"})
+		return
+	}
+
+	var filter service.TaskListFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		h.logger.WithError(err).Error("绑定项目任务查询参数失败")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数无效", "details": err.Error()})
+		return
+	}
+	filter.Expand = service.ParseTaskExpand(c.Query("expand"))
+
+	board, err := h.projectService.GetProjectTaskBoard(c.Request.Context(), uint(id), filter)
+	if err != nil {
+		h.logger.WithError(err).Error("获取项目任务看板失败")
+		if repository.IsNotFoundError(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "项目不存在"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取项目任务失败", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": board})
+}
+
+// UpdateWIPLimits 设置项目在制品上限
+// @Summary 设置项目在制品上限
+// @Description 仅项目经理可以设置各状态列的在制品上限
+// @Tags 项目管理
+// @Accept json
+// @Produce json
+// @Param id path int true "项目ID"
+// @Param request body service.UpdateWIPLimitsRequest true "在制品上限"
+// @Success 200 {object} DataResponse{data=ProjectWIPLimitsResult} "更新成功"
+// @Failure 400 {object} ErrorResponse "请求参数错误或上限不合法"
+// @Failure 403 {object} ErrorResponse "不是项目经理"
+// @Failure 404 {object} ErrorResponse "项目不存在"
+// @Router /api/v1/projects/{id}/wip-limits [put]
+// @Security BearerAuth
+func (h *ProjectHandler) UpdateWIPLimits(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		h.logger.WithError(err).WithField("id", idStr).Error("解析项目ID失败")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的项目ID"})
+		return
+	}
+
+	var req service.UpdateWIPLimitsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("绑定在制品上限请求失败")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数无效", "details": err.Error()})
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "用户未认证"})
+		return
+	}
+
+	limits, err := h.projectService.UpdateWIPLimits(c.Request.Context(), uint(id), userID.(uint), &req)
+	if err != nil {
+		h.logger.WithError(err).Error("设置项目在制品上限失败")
+		switch {
+		case errors.Is(err, service.ErrProjectManagerRequired):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case repository.IsNotFoundError(err):
+			c.JSON(http.StatusNotFound, gin.H{"error": "项目不存在"})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "设置在制品上限失败", "details": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "在制品上限更新成功",
+		"data":    ProjectWIPLimitsResult{ProjectID: uint(id), WIPLimits: limits},
+	})
+}
+
+// ProjectWIPLimitsResult 项目在制品上限，键为任务状态
+type ProjectWIPLimitsResult struct {
+	ProjectID uint           `json:"project_id"`
+	WIPLimits map[string]int `json:"wip_limits"`
+}
+
+// GetProjectTimeline 获取项目时间线
+// @Summary 获取项目时间线
+// @Description 返回甘特图所需的任务条、父子任务连线和里程碑扁平数组。任务未开始时按截止时间减预估工时推算开始时间，既没有开始时间也没有截止时间的任务列在unscheduled_task_ids中
+// @Tags 项目管理
+// @Produce json
+// @Param id path int true "项目ID"
+// @Param from query string false "开始日期，格式2006-01-02"
+// @Param to query string false "结束日期（含），格式2006-01-02"
+// @Success 200 {object} DataResponse{data=service.ProjectTimelineResponse} "获取成功"
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 404 {object} ErrorResponse "项目不存在"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/projects/{id}/timeline [get]
+// @Security BearerAuth
+func (h *ProjectHandler) GetProjectTimeline(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		h.logger.WithError(err).WithField("id", idStr).Error("解析项目ID失败")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的项目ID"})
+		return
+	}
+
+	timeline, err := h.projectService.GetProjectTimeline(c.Request.Context(), uint(id), c.Query("from"), c.Query("to"))
+	if err != nil {
+		h.logger.WithError(err).Error("获取项目时间线失败")
+		switch {
+		case errors.Is(err, service.ErrInvalidTimelineWindow):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case repository.IsNotFoundError(err):
+			c.JSON(http.StatusNotFound, gin.H{"error": "项目不存在"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "获取项目时间线失败", "details": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": timeline})
+}
+
+// GetProjectBurndown 获取项目燃尽图
+// @Summary 获取项目燃尽图
+// @Description 返回日期范围内每天结束时剩余的预估工时（未完成、未取消任务的预估工时之和）、当天新建和完成任务的预估工时、当天记录的实际工时，以及合计、最近7天的滚动速度和推算的完成日期。
+// @Description 实际工时取工时记录，没有工时记录的已完成任务在完成当天计入任务的实际工时。日期按tz划分，结果按项目、日期范围和时区缓存5分钟
+// @Tags 项目管理
+// @Produce json
+// @Param id path int true "项目ID"
+// @Param from query string false "开始日期，格式2006-01-02，默认为结束日期前29天"
+// @Param to query string false "结束日期（含），格式2006-01-02，默认且最晚为今天"
+// @Param tz query string false "IANA时区名，如Asia/Shanghai，默认服务器时区"
+// @Success 200 {object} DataResponse{data=service.ProjectBurndownResponse} "获取成功"
+// @Failure 400 {object} ErrorResponse "日期范围或时区不合法"
+// @Failure 404 {object} ErrorResponse "项目不存在"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/projects/{id}/burndown [get]
+// @Security BearerAuth
+func (h *ProjectHandler) GetProjectBurndown(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		h.logger.WithError(err).WithField("id", idStr).Error("解析项目ID失败")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的项目ID"})
+		return
+	}
+
+	burndown, err := h.projectService.GetProjectBurndown(c.Request.Context(), uint(id), c.Query("from"), c.Query("to"), c.Query("tz"))
+	if err != nil {
+		h.logger.WithError(err).Error("获取项目燃尽图失败")
+		switch {
+		case errors.Is(err, service.ErrInvalidBurndownRange):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case repository.IsNotFoundError(err):
+			c.JSON(http.StatusNotFound, gin.H{"error": "项目不存在"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "获取项目燃尽图失败", "details": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": burndown})
+}
+
+// ListMilestones 获取项目里程碑
+// @Summary 获取项目里程碑
+// @Tags 项目管理
+// @Produce json
+// @Param id path int true "项目ID"
+// @Success 200 {object} DataResponse{data=[]service.MilestoneResponse} "获取成功"
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 404 {object} ErrorResponse "项目不存在"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/projects/{id}/milestones [get]
+// @Security BearerAuth
+func (h *ProjectHandler) ListMilestones(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		h.logger.WithError(err).WithField("id", idStr).Error("解析项目ID失败")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的项目ID"})
+		return
+	}
+
+	milestones, err := h.projectService.ListMilestones(c.Request.Context(), uint(id))
+	if err != nil {
+		h.logger.WithError(err).Error("获取项目里程碑失败")
+		if repository.IsNotFoundError(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "项目不存在"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取项目里程碑失败", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": milestones})
+}
+
+// CreateMilestone 创建项目里程碑
+// @Summary 创建项目里程碑
+// @Tags 项目管理
+// @Accept json
+// @Produce json
+// @Param id path int true "项目ID"
+// @Param request body service.MilestoneRequest true "里程碑"
+// @Success 201 {object} DataResponse{data=service.MilestoneResponse} "创建成功"
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 404 {object} ErrorResponse "项目不存在"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/projects/{id}/milestones [post]
+// @Security BearerAuth
+func (h *ProjectHandler) CreateMilestone(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		h.logger.WithError(err).WithField("id", idStr).Error("解析项目ID失败")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的项目ID"})
+		return
+	}
+
+	var req service.MilestoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("绑定里程碑请求失败")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数无效", "details": err.Error()})
+		return
+	}
+
+	milestone, err := h.projectService.CreateMilestone(c.Request.Context(), uint(id), &req)
+	if err != nil {
+		h.logger.WithError(err).Error("创建项目里程碑失败")
+		h.writeMilestoneError(c, err, "创建项目里程碑失败")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "里程碑创建成功",
+		"data":    milestone,
+	})
+}
+
+// UpdateMilestone 更新项目里程碑
+// @Summary 更新项目里程碑
+// @Description 未指定status时保持原状态
+// @Tags 项目管理
+// @Accept json
+// @Produce json
+// @Param id path int true "项目ID"
+// @Param milestone_id path int true "里程碑ID"
+// @Param request body service.MilestoneRequest true "里程碑"
+// @Success 200 {object} DataResponse{data=service.MilestoneResponse} "更新成功"
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 404 {object} ErrorResponse "里程碑不存在"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/projects/{id}/milestones/{milestone_id} [put]
+// @Security BearerAuth
+func (h *ProjectHandler) UpdateMilestone(c *gin.Context) {
+	projectID, milestoneID, ok := h.parseMilestonePath(c)
+	if !ok {
+		return
+	}
+
+	var req service.MilestoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("绑定里程碑请求失败")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请求参数无效", "details": err.Error()})
+		return
+	}
+
+	milestone, err := h.projectService.UpdateMilestone(c.Request.Context(), projectID, milestoneID, &req)
+	if err != nil {
+		h.logger.WithError(err).Error("更新项目里程碑失败")
+		h.writeMilestoneError(c, err, "更新项目里程碑失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "里程碑更新成功",
+		"data":    milestone,
+	})
+}
+
+// DeleteMilestone 删除项目里程碑
+// @Summary 删除项目里程碑
+// @Tags 项目管理
+// @Produce json
+// @Param id path int true "项目ID"
+// @Param milestone_id path int true "里程碑ID"
+// @Success 200 {object} DataResponse "删除成功"
+// @Failure 400 {object} ErrorResponse "请求参数错误"
+// @Failure 404 {object} ErrorResponse "里程碑不存在"
+// @Failure 500 {object} ErrorResponse "服务器内部错误"
+// @Router /api/v1/projects/{id}/milestones/{milestone_id} [delete]
+// @Security BearerAuth
+func (h *ProjectHandler) DeleteMilestone(c *gin.Context) {
+	projectID, milestoneID, ok := h.parseMilestonePath(c)
+	if !ok {
+		return
+	}
+
+	if err := h.projectService.DeleteMilestone(c.Request.Context(), projectID, milestoneID); err != nil {
+		h.logger.WithError(err).Error("删除项目里程碑失败")
+		h.writeMilestoneError(c, err, "删除项目里程碑失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "里程碑删除成功"})
+}
+
+// parseMilestonePath 解析路径中的项目ID和里程碑ID，失败时已写入响应
+func (h *ProjectHandler) parseMilestonePath(c *gin.Context) (uint, uint, bool) {
+	projectID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的项目ID"})
+		return 0, 0, false
+	}
+	milestoneID, err := strconv.ParseUint(c.Param("milestone_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "无效的里程碑ID"})
+		return 0, 0, false
+	}
+	return uint(projectID), uint(milestoneID), true
+}
+
+// writeMilestoneError 按里程碑错误类型写入响应
+func (h *ProjectHandler) writeMilestoneError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidMilestone):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrMilestoneNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "里程碑不存在"})
+	case repository.IsNotFoundError(err):
+		c.JSON(http.StatusNotFound, gin.H{"error": "项目不存在"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message, "details": err.Error()})
+	}
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"taskmanage/internal/service"
+	"taskmanage/pkg/response"
+)
+
+// ReportHandler 报表导出处理器
+type ReportHandler struct {
+	reportService service.ReportService
+	logger        *logrus.Logger
+}
+
+// NewReportHandler 创建报表导出处理器
+func NewReportHandler(reportService service.ReportService, logger *logrus.Logger) *ReportHandler {
+	return &ReportHandler{
+		reportService: reportService,
+		logger:        logger,
+	}
+}
+
+// ExportTasks 导出任务报表
+// @Summary 导出任务报表
+// @Description 按创建时间导出任务的状态、负责人、部门、截止时间和实际工时，文件以附件形式流式返回
+// @Tags 报表
+// @Produce text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string false "文件格式: csv 或 xlsx，默认csv"
+// @Param from query string false "开始日期，格式: 2006-01-02，默认本月1日"
+// @Param to query string false "结束日期（含当天），格式: 2006-01-02，默认今天"
+// @Param department_id query int false "负责人所在部门ID"
+// @Param timezone query string false "时区，如 Asia/Shanghai，默认服务器时区"
+// @Success 200 {file} file "报表文件"
+// @Failure 400 {object} response.Response "参数错误或超过导出行数上限"
+// @Router /api/v1/reports/tasks/export [get]
+// @Security BearerAuth
+func (h *ReportHandler) ExportTasks(c *gin.Context) {
+	var req service.ReportExportRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.BadRequest(c, "请求参数格式错误")
+		return
+	}
+
+	export, err := h.reportService.ExportTasks(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err, "导出任务报表失败")
+		return
+	}
+	h.stream(c, export)
+}
+
+// ExportAssignments 导出任务分配报表
+// @Summary 导出任务分配报表
+// @Description 按分配时间导出分配方式、审批人和审批耗时（审批时间减分配时间），文件以附件形式流式返回
+// @Tags 报表
+// @Produce text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string false "文件格式: csv 或 xlsx，默认csv"
+// @Param from query string false "开始日期，格式: 2006-01-02，默认本月1日"
+// @Param to query string false "结束日期（含当天），格式: 2006-01-02，默认今天"
+// @Param department_id query int false "被分配人所在部门ID"
+// @Param timezone query string false "时区，如 Asia/Shanghai，默认服务器时区"
+// @Success 200 {file} file "报表文件"
+// @Failure 400 {object} response.Response "参数错误或超过导出行数上限"
+// @Router /api/v1/reports/assignments/export [get]
+// @Security BearerAuth
+func (h *ReportHandler) ExportAssignments(c *gin.Context) {
+	var req service.ReportExportRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.BadRequest(c, "请求参数格式错误")
+		return
+	}
+
+	export, err := h.reportService.ExportAssignments(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err, "导出任务分配报表失败")
+		return
+	}
+	h.stream(c, export)
+}
+
+// GetSkillGaps 获取部门技能缺口报表
+// @Summary 部门技能缺口报表
+// @Description 统计部门开放任务（所属项目归该部门或创建人在该部门，未完成且未取消）要求的技能等级分布、部门在职员工中等级不低于各所需等级的人数和缺口分。缺口分为0-1，是各等级上合格人数不足任务数的部分占该技能任务总数的比例，技能按缺口分从高到低排序。export=csv或xlsx时以附件返回
+// @Tags 报表
+// @Produce json,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param department_id query int true "部门ID"
+// @Param export query string false "导出格式: csv 或 xlsx，为空时返回JSON"
+// @Success 200 {object} response.Response{data=service.SkillGapReport} "技能缺口"
+// @Failure 400 {object} response.Response "参数错误"
+// @Failure 404 {object} response.Response "部门不存在"
+// @Router /api/v1/reports/skill-gaps [get]
+// @Security BearerAuth
+func (h *ReportHandler) GetSkillGaps(c *gin.Context) {
+	var req service.SkillGapRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.BadRequest(c, "请求参数格式错误，department_id为必填参数")
+		return
+	}
+
+	if req.Export != "" {
+		export, err := h.reportService.ExportSkillGaps(c.Request.Context(), &req)
+		if err != nil {
+			h.handleError(c, err, "导出技能缺口报表失败")
+			return
+		}
+		h.stream(c, export)
+		return
+	}
+
+	gaps, err := h.reportService.GetSkillGaps(c.Request.Context(), req.DepartmentID)
+	if err != nil {
+		h.handleError(c, err, "获取技能缺口报表失败")
+		return
+	}
+	response.Success(c, gaps)
+}
+
+// stream 设置附件响应头并流式写出报表，写出开始后的错误只能中断连接
+func (h *ReportHandler) stream(c *gin.Context, export *service.ReportExport) {
+	c.Header("Content-Type", export.ContentType)
+	c.Header("Content-Disposition", `attachment; filename="`+export.Filename+`"`)
+	c.Header("X-Report-Rows", strconv.FormatInt(export.RowCount, 10))
+	c.Status(http.StatusOK)
+
+	if err := export.WriteTo(c.Request.Context(), c.Writer); err != nil {
+		h.logger.WithError(err).WithField("file", export.Filename).Error("报表写出中断")
+		c.Abort()
+	}
+}
+
+// handleError 将报表错误映射为HTTP响应
+func (h *ReportHandler) handleError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrReportTooLarge):
+		response.ErrorWithCode(c, response.ErrCodeExportTooLarge, err.Error())
+	case errors.Is(err, service.ErrInvalidReportRequest):
+		response.BadRequest(c, err.Error())
+	case errors.Is(err, service.ErrDepartmentNotFound):
+		response.NotFound(c, err.Error())
+	default:
+		h.logger.WithError(err).Error(message)
+		response.InternalError(c, message)
+	}
+}
@@ -0,0 +1,31 @@
+package handlers
+
+import "taskmanage/internal/service"
+
+// MessageResult 只包含提示信息的响应数据
+type MessageResult struct {
+	Message string `json:"message"`
+}
+
+// DataResponse 部门、职位、项目和入职接口直接返回的响应结构，data为具体业务数据
+type DataResponse struct {
+	Message string      `json:"message,omitempty"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// ErrorResponse 部门、职位、项目和入职接口直接返回的错误结构
+type ErrorResponse struct {
+	Error   string `json:"error"`
+	Details string `json:"details,omitempty"`
+}
+
+// RunningInstanceResult 重复启动审批流程时409响应的数据，instance_id为已在运行的流程实例
+type RunningInstanceResult struct {
+	InstanceID string `json:"instance_id"`
+}
+
+// TaskStatusResult 任务状态变更结果，task为变更后的任务
+type TaskStatusResult struct {
+	Message string                `json:"message"`
+	Task    *service.TaskResponse `json:"task"`
+}
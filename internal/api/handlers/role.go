@@ -0,0 +1,425 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"taskmanage/internal/repository"
+	"taskmanage/internal/service"
+	"taskmanage/pkg/response"
+)
+
+// RoleHandler 角色与权限管理处理器
+type RoleHandler struct {
+	roleService service.RoleService
+	logger      *logrus.Logger
+}
+
+// NewRoleHandler 创建角色与权限管理处理器
+func NewRoleHandler(roleService service.RoleService, logger *logrus.Logger) *RoleHandler {
+	return &RoleHandler{
+		roleService: roleService,
+		logger:      logger,
+	}
+}
+
+// ListRoles 获取角色列表
+// @Summary 获取角色列表
+// @Tags 角色管理
+// @Produce json
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Success 200 {object} response.Response{data=service.ListResponse[service.RoleResponse]} "获取成功"
+// @Router /api/v1/roles [get]
+// @Security BearerAuth
+func (h *RoleHandler) ListRoles(c *gin.Context) {
+	var req service.ListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.BindError(c, err)
+		return
+	}
+
+	roles, err := h.roleService.ListRoles(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err, "获取角色列表失败")
+		return
+	}
+
+	response.Success(c, roles)
+}
+
+// GetRole 获取角色详情
+// @Summary 获取角色详情
+// @Description 返回角色信息及其权限
+// @Tags 角色管理
+// @Produce json
+// @Param id path int true "角色ID"
+// @Success 200 {object} response.Response{data=service.RoleResponse} "获取成功"
+// @Failure 404 {object} response.Response "角色不存在"
+// @Router /api/v1/roles/{id} [get]
+// @Security BearerAuth
+func (h *RoleHandler) GetRole(c *gin.Context) {
+	roleID, ok := h.parseID(c, "无效的角色ID")
+	if !ok {
+		return
+	}
+
+	role, err := h.roleService.GetRole(c.Request.Context(), roleID)
+	if err != nil {
+		h.handleError(c, err, "获取角色失败")
+		return
+	}
+
+	response.Success(c, role)
+}
+
+// CreateRole 创建角色
+// @Summary 创建角色
+// @Tags 角色管理
+// @Accept json
+// @Produce json
+// @Param request body service.CreateRoleRequest true "角色信息"
+// @Success 201 {object} response.Response{data=service.RoleResponse} "创建成功"
+// @Failure 400 {object} response.Response "参数不合法"
+// @Failure 409 {object} response.Response "角色名已存在"
+// @Router /api/v1/roles [post]
+// @Security BearerAuth
+func (h *RoleHandler) CreateRole(c *gin.Context) {
+	var req service.CreateRoleRequest
+	if !response.BindAndValidate(c, &req) {
+		return
+	}
+
+	role, err := h.roleService.CreateRole(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err, "创建角色失败")
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.Response{
+		Code:    response.ErrCodeSuccess,
+		Message: "角色创建成功",
+		Data:    role,
+	})
+}
+
+// UpdateRole 更新角色
+// @Summary 更新角色
+// @Description 更新角色显示名、描述和上级角色，角色名不可修改
+// @Tags 角色管理
+// @Accept json
+// @Produce json
+// @Param id path int true "角色ID"
+// @Param request body service.UpdateRoleRequest true "角色信息"
+// @Success 200 {object} response.Response{data=service.RoleResponse} "更新成功"
+// @Failure 400 {object} response.Response "参数不合法"
+// @Failure 404 {object} response.Response "角色不存在"
+// @Router /api/v1/roles/{id} [put]
+// @Security BearerAuth
+func (h *RoleHandler) UpdateRole(c *gin.Context) {
+	roleID, ok := h.parseID(c, "无效的角色ID")
+	if !ok {
+		return
+	}
+
+	var req service.UpdateRoleRequest
+	if !response.BindAndValidate(c, &req) {
+		return
+	}
+
+	role, err := h.roleService.UpdateRole(c.Request.Context(), roleID, &req)
+	if err != nil {
+		h.handleError(c, err, "更新角色失败")
+		return
+	}
+
+	response.SuccessWithMessage(c, "角色更新成功", role)
+}
+
+// DeleteRole 删除角色
+// @Summary 删除角色
+// @Description 角色仍分配给用户时返回409，传 force=true 强制删除会解除这些用户的角色并记录审计日志
+// @Tags 角色管理
+// @Produce json
+// @Param id path int true "角色ID"
+// @Param force query bool false "强制删除仍分配给用户的角色"
+// @Success 200 {object} response.Response{data=service.DeleteRoleResult} "删除成功"
+// @Failure 404 {object} response.Response "角色不存在"
+// @Failure 409 {object} response.Response "角色仍分配给用户"
+// @Router /api/v1/roles/{id} [delete]
+// @Security BearerAuth
+func (h *RoleHandler) DeleteRole(c *gin.Context) {
+	roleID, ok := h.parseID(c, "无效的角色ID")
+	if !ok {
+		return
+	}
+	operatorID, exists := c.Get("user_id")
+	if !exists {
+		response.Unauthorized(c, "无法获取操作员信息")
+		return
+	}
+
+	result, err := h.roleService.DeleteRole(c.Request.Context(), roleID, c.Query("force") == "true", operatorID.(uint))
+	if err != nil {
+		h.handleError(c, err, "删除角色失败")
+		return
+	}
+
+	response.SuccessWithMessage(c, "角色已删除", result)
+}
+
+// AttachPermissions 为角色绑定权限
+// @Summary 为角色绑定权限
+// @Description 在角色现有权限基础上追加权限
+// @Tags 角色管理
+// @Accept json
+// @Produce json
+// @Param id path int true "角色ID"
+// @Param request body service.RolePermissionsRequest true "权限ID列表"
+// @Success 200 {object} response.Response{data=service.RoleResponse} "绑定成功"
+// @Failure 400 {object} response.Response "权限不存在"
+// @Failure 404 {object} response.Response "角色不存在"
+// @Router /api/v1/roles/{id}/permissions [post]
+// @Security BearerAuth
+func (h *RoleHandler) AttachPermissions(c *gin.Context) {
+	roleID, ok := h.parseID(c, "无效的角色ID")
+	if !ok {
+		return
+	}
+
+	var req service.RolePermissionsRequest
+	if !response.BindAndValidate(c, &req) {
+		return
+	}
+
+	role, err := h.roleService.AttachPermissions(c.Request.Context(), roleID, req.PermissionIDs)
+	if err != nil {
+		h.handleError(c, err, "绑定角色权限失败")
+		return
+	}
+
+	response.SuccessWithMessage(c, "角色权限绑定成功", role)
+}
+
+// DetachPermissions 解除角色的权限
+// @Summary 解除角色的权限
+// @Tags 角色管理
+// @Accept json
+// @Produce json
+// @Param id path int true "角色ID"
+// @Param request body service.RolePermissionsRequest true "权限ID列表"
+// @Success 200 {object} response.Response{data=service.RoleResponse} "解除成功"
+// @Failure 404 {object} response.Response "角色不存在"
+// @Router /api/v1/roles/{id}/permissions [delete]
+// @Security BearerAuth
+func (h *RoleHandler) DetachPermissions(c *gin.Context) {
+	roleID, ok := h.parseID(c, "无效的角色ID")
+	if !ok {
+		return
+	}
+
+	var req service.RolePermissionsRequest
+	if !response.BindAndValidate(c, &req) {
+		return
+	}
+
+	role, err := h.roleService.DetachPermissions(c.Request.Context(), roleID, req.PermissionIDs)
+	if err != nil {
+		h.handleError(c, err, "解除角色权限失败")
+		return
+	}
+
+	response.SuccessWithMessage(c, "角色权限已解除", role)
+}
+
+// ListRoleUsers 获取拥有该角色的用户
+// @Summary 获取角色用户
+// @Tags 角色管理
+// @Produce json
+// @Param id path int true "角色ID"
+// @Success 200 {object} response.Response{data=[]service.UserResponse} "获取成功"
+// @Failure 404 {object} response.Response "角色不存在"
+// @Router /api/v1/roles/{id}/users [get]
+// @Security BearerAuth
+func (h *RoleHandler) ListRoleUsers(c *gin.Context) {
+	roleID, ok := h.parseID(c, "无效的角色ID")
+	if !ok {
+		return
+	}
+
+	users, err := h.roleService.ListRoleUsers(c.Request.Context(), roleID)
+	if err != nil {
+		h.handleError(c, err, "获取角色用户失败")
+		return
+	}
+
+	response.Success(c, users)
+}
+
+// ListPermissions 获取权限列表
+// @Summary 获取权限列表
+// @Tags 角色管理
+// @Produce json
+// @Param resource query string false "按资源过滤"
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Success 200 {object} response.Response{data=service.ListResponse[service.PermissionResponse]} "获取成功"
+// @Router /api/v1/permissions [get]
+// @Security BearerAuth
+func (h *RoleHandler) ListPermissions(c *gin.Context) {
+	var req service.ListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.BindError(c, err)
+		return
+	}
+
+	permissions, err := h.roleService.ListPermissions(c.Request.Context(), &req, c.Query("resource"))
+	if err != nil {
+		h.handleError(c, err, "获取权限列表失败")
+		return
+	}
+
+	response.Success(c, permissions)
+}
+
+// GetPermission 获取权限详情
+// @Summary 获取权限详情
+// @Tags 角色管理
+// @Produce json
+// @Param id path int true "权限ID"
+// @Success 200 {object} response.Response{data=service.PermissionResponse} "获取成功"
+// @Failure 404 {object} response.Response "权限不存在"
+// @Router /api/v1/permissions/{id} [get]
+// @Security BearerAuth
+func (h *RoleHandler) GetPermission(c *gin.Context) {
+	permissionID, ok := h.parseID(c, "无效的权限ID")
+	if !ok {
+		return
+	}
+
+	permission, err := h.roleService.GetPermission(c.Request.Context(), permissionID)
+	if err != nil {
+		h.handleError(c, err, "获取权限失败")
+		return
+	}
+
+	response.Success(c, permission)
+}
+
+// CreatePermission 创建权限
+// @Summary 创建权限
+// @Description 权限名由 resource:action 组成，创建后需绑定到角色才会生效
+// @Tags 角色管理
+// @Accept json
+// @Produce json
+// @Param request body service.CreatePermissionRequest true "权限信息"
+// @Success 201 {object} response.Response{data=service.PermissionResponse} "创建成功"
+// @Failure 400 {object} response.Response "参数不合法"
+// @Failure 409 {object} response.Response "权限已存在"
+// @Router /api/v1/permissions [post]
+// @Security BearerAuth
+func (h *RoleHandler) CreatePermission(c *gin.Context) {
+	var req service.CreatePermissionRequest
+	if !response.BindAndValidate(c, &req) {
+		return
+	}
+
+	permission, err := h.roleService.CreatePermission(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err, "创建权限失败")
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.Response{
+		Code:    response.ErrCodeSuccess,
+		Message: "权限创建成功",
+		Data:    permission,
+	})
+}
+
+// UpdatePermission 更新权限
+// @Summary 更新权限
+// @Description 更新权限显示名和描述，资源和操作不可修改
+// @Tags 角色管理
+// @Accept json
+// @Produce json
+// @Param id path int true "权限ID"
+// @Param request body service.UpdatePermissionRequest true "权限信息"
+// @Success 200 {object} response.Response{data=service.PermissionResponse} "更新成功"
+// @Failure 404 {object} response.Response "权限不存在"
+// @Router /api/v1/permissions/{id} [put]
+// @Security BearerAuth
+func (h *RoleHandler) UpdatePermission(c *gin.Context) {
+	permissionID, ok := h.parseID(c, "无效的权限ID")
+	if !ok {
+		return
+	}
+
+	var req service.UpdatePermissionRequest
+	if !response.BindAndValidate(c, &req) {
+		return
+	}
+
+	permission, err := h.roleService.UpdatePermission(c.Request.Context(), permissionID, &req)
+	if err != nil {
+		h.handleError(c, err, "更新权限失败")
+		return
+	}
+
+	response.SuccessWithMessage(c, "权限更新成功", permission)
+}
+
+// DeletePermission 删除权限
+// @Summary 删除权限
+// @Description 同时解除权限与角色、权限模板的关联，仍有生效中的直接分配时返回409
+// @Tags 角色管理
+// @Produce json
+// @Param id path int true "权限ID"
+// @Success 200 {object} response.Response "删除成功"
+// @Failure 404 {object} response.Response "权限不存在"
+// @Failure 409 {object} response.Response "权限仍直接分配给用户"
+// @Router /api/v1/permissions/{id} [delete]
+// @Security BearerAuth
+func (h *RoleHandler) DeletePermission(c *gin.Context) {
+	permissionID, ok := h.parseID(c, "无效的权限ID")
+	if !ok {
+		return
+	}
+
+	if err := h.roleService.DeletePermission(c.Request.Context(), permissionID); err != nil {
+		h.handleError(c, err, "删除权限失败")
+		return
+	}
+
+	response.SuccessWithMessage(c, "权限已删除", nil)
+}
+
+// parseID 解析路径中的ID参数，失败时直接返回400
+func (h *RoleHandler) parseID(c *gin.Context, message string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil || id == 0 {
+		response.BadRequest(c, message)
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// handleError 将角色与权限相关错误映射为HTTP响应
+func (h *RoleHandler) handleError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidRole), errors.Is(err, service.ErrInvalidPermission):
+		response.BadRequest(c, err.Error())
+	case errors.Is(err, service.ErrRoleExists), errors.Is(err, service.ErrPermissionExists),
+		errors.Is(err, service.ErrRoleInUse), errors.Is(err, service.ErrPermissionInUse):
+		response.Conflict(c, err.Error())
+	case repository.IsNotFoundError(err):
+		response.NotFound(c, "角色或权限不存在")
+	default:
+		h.logger.WithError(err).Error(message)
+		response.InternalError(c, message)
+	}
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"taskmanage/internal/cache"
+	"taskmanage/pkg/response"
+)
+
+// SessionHandler 登录会话管理处理器
+type SessionHandler struct {
+	tokenStore *cache.TokenStore // 令牌状态存储，为nil时会话管理不可用
+	logger     *logrus.Logger
+}
+
+// NewSessionHandler 创建登录会话管理处理器
+func NewSessionHandler(tokenStore *cache.TokenStore, logger *logrus.Logger) *SessionHandler {
+	return &SessionHandler{
+		tokenStore: tokenStore,
+		logger:     logger,
+	}
+}
+
+// SessionResponse 登录会话
+type SessionResponse struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"` // 登录时的User-Agent
+	IP         string    `json:"ip"`         // 最近一次登录或刷新令牌的客户端IP
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"` // 最近一次登录或刷新令牌的时间
+	Current    bool      `json:"current"`      // 是否为发起本次请求的会话
+}
+
+// ListMySessions 获取当前用户的登录会话
+// @Summary 我的登录会话
+// @Description 列出当前用户未过期的登录会话及登录设备、IP和最后使用时间，按最后使用时间倒序
+// @Tags 认证
+// @Produce json
+// @Success 200 {object} response.Response{data=[]SessionResponse} "会话列表"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 500 {object} response.Response "会话服务不可用"
+// @Router /api/v1/me/sessions [get]
+// @Security BearerAuth
+func (h *SessionHandler) ListMySessions(c *gin.Context) {
+	h.listSessions(c, c.GetUint("user_id"))
+}
+
+// RevokeMySession 下线当前用户的一个会话
+// @Summary 下线我的会话
+// @Description 吊销会话的刷新令牌及其签发的未过期访问令牌，可下线发起请求的会话本身
+// @Tags 认证
+// @Produce json
+// @Param id path string true "会话ID"
+// @Success 200 {object} response.Response{data=MessageResult} "已下线"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 404 {object} response.Response "会话不存在或已过期"
+// @Failure 500 {object} response.Response "会话服务不可用"
+// @Router /api/v1/me/sessions/{id} [delete]
+// @Security BearerAuth
+func (h *SessionHandler) RevokeMySession(c *gin.Context) {
+	h.revokeSession(c, c.GetUint("user_id"), c.Param("id"))
+}
+
+// ListUserSessions 获取指定用户的登录会话
+// @Summary 用户登录会话
+// @Description 管理员查看指定用户未过期的登录会话
+// @Tags 用户管理
+// @Produce json
+// @Param id path int true "用户ID"
+// @Success 200 {object} response.Response{data=[]SessionResponse} "会话列表"
+// @Failure 400 {object} response.Response "用户ID无效"
+// @Failure 403 {object} response.Response "权限不足"
+// @Failure 500 {object} response.Response "会话服务不可用"
+// @Router /api/v1/users/{id}/sessions [get]
+// @Security BearerAuth
+func (h *SessionHandler) ListUserSessions(c *gin.Context) {
+	userID, ok := parseUintParam(c, "id", "无效的用户ID")
+	if !ok {
+		return
+	}
+	h.listSessions(c, userID)
+}
+
+// RevokeUserSession 下线指定用户的一个会话
+// @Summary 下线用户会话
+// @Description 管理员吊销指定用户会话的刷新令牌及其签发的未过期访问令牌
+// @Tags 用户管理
+// @Produce json
+// @Param id path int true "用户ID"
+// @Param session_id path string true "会话ID"
+// @Success 200 {object} response.Response{data=MessageResult} "已下线"
+// @Failure 400 {object} response.Response "用户ID无效"
+// @Failure 403 {object} response.Response "权限不足"
+// @Failure 404 {object} response.Response "会话不存在或已过期"
+// @Failure 500 {object} response.Response "会话服务不可用"
+// @Router /api/v1/users/{id}/sessions/{session_id} [delete]
+// @Security BearerAuth
+func (h *SessionHandler) RevokeUserSession(c *gin.Context) {
+	userID, ok := parseUintParam(c, "id", "无效的用户ID")
+	if !ok {
+		return
+	}
+	h.revokeSession(c, userID, c.Param("session_id"))
+}
+
+// listSessions 返回用户的会话列表，标记发起请求的会话
+func (h *SessionHandler) listSessions(c *gin.Context, userID uint) {
+	if h.tokenStore == nil {
+		response.InternalError(c, "会话服务不可用")
+		return
+	}
+
+	sessions, err := h.tokenStore.ListSessions(c.Request.Context(), userID)
+	if err != nil {
+		h.logger.WithError(err).WithField("user_id", userID).Error("Failed to list sessions")
+		response.InternalError(c, "获取会话列表失败")
+		return
+	}
+
+	// 只有查看自己的会话时才可能包含当前会话
+	currentSessionID := ""
+	if userID == c.GetUint("user_id") {
+		currentSessionID = c.GetString("session_id")
+	}
+	result := make([]SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		result = append(result, SessionResponse{
+			ID:         session.ID,
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+			Current:    currentSessionID != "" && session.ID == currentSessionID,
+		})
+	}
+	response.Success(c, result)
+}
+
+// revokeSession 吊销用户的一个会话，会话不存在时返回404
+func (h *SessionHandler) revokeSession(c *gin.Context, userID uint, sessionID string) {
+	if h.tokenStore == nil {
+		response.InternalError(c, "会话服务不可用")
+		return
+	}
+
+	ctx := c.Request.Context()
+	session, err := h.tokenStore.GetSession(ctx, userID, sessionID)
+	if err != nil {
+		h.logger.WithError(err).WithField("user_id", userID).Error("Failed to get session")
+		response.InternalError(c, "下线会话失败")
+		return
+	}
+	if session == nil {
+		response.NotFound(c, "会话不存在或已过期")
+		return
+	}
+
+	if err := h.tokenStore.RevokeSession(ctx, userID, sessionID); err != nil {
+		h.logger.WithError(err).WithField("user_id", userID).Error("Failed to revoke session")
+		response.InternalError(c, "下线会话失败")
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"user_id":    userID,
+		"session_id": sessionID,
+		"operator":   c.GetUint("user_id"),
+	}).Info("Session revoked")
+	response.Success(c, MessageResult{Message: "会话已下线"})
+}
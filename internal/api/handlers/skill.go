@@ -1,9 +1,11 @@
 package handlers
 
 import (
+	"errors"
 	"strconv"
 
 	"taskmanage/internal/container"
+	"taskmanage/internal/repository"
 	"taskmanage/internal/service"
 	"taskmanage/pkg/logger"
 	"taskmanage/pkg/response"
@@ -24,6 +26,17 @@ func NewSkillHandler(c *container.ApplicationContainer) *SkillHandler {
 }
 
 // CreateSkill 创建技能
+// @Summary 创建技能
+// @Tags 技能管理
+// @Accept json
+// @Produce json
+// @Description 分类按名称不区分大小写匹配已有分类；分类不存在时按skill.auto_create_categories配置自动创建或返回400
+// @Param request body service.CreateSkillRequest true "创建技能请求"
+// @Success 200 {object} response.Response{data=service.SkillResponse} "创建成功"
+// @Failure 400 {object} response.Response "请求参数错误或技能分类不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/skills [post]
+// @Security BearerAuth
 func (h *SkillHandler) CreateSkill(c *gin.Context) {
 	var req service.CreateSkillRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -35,6 +48,10 @@ func (h *SkillHandler) CreateSkill(c *gin.Context) {
 	skillService := h.container.GetSkillService()
 	skill, err := skillService.CreateSkill(c.Request.Context(), &req)
 	if err != nil {
+		if errors.Is(err, service.ErrUnknownSkillCategory) {
+			response.BadRequest(c, err.Error())
+			return
+		}
 		logger.Errorf("Failed to create skill: %v", err)
 		response.InternalError(c, "Failed to create skill")
 		return
@@ -44,6 +61,15 @@ func (h *SkillHandler) CreateSkill(c *gin.Context) {
 }
 
 // GetSkill 获取技能详情
+// @Summary 获取技能详情
+// @Tags 技能管理
+// @Produce json
+// @Param id path int true "技能ID"
+// @Success 200 {object} response.Response{data=service.SkillResponse} "获取成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 404 {object} response.Response "技能不存在"
+// @Router /api/v1/skills/{id} [get]
+// @Security BearerAuth
 func (h *SkillHandler) GetSkill(c *gin.Context) {
 	skillIDStr := c.Param("id")
 	skillID, err := strconv.ParseUint(skillIDStr, 10, 32)
@@ -65,6 +91,17 @@ func (h *SkillHandler) GetSkill(c *gin.Context) {
 }
 
 // UpdateSkill 更新技能
+// @Summary 更新技能
+// @Tags 技能管理
+// @Accept json
+// @Produce json
+// @Param id path int true "技能ID"
+// @Param request body service.UpdateSkillRequest true "更新技能请求"
+// @Success 200 {object} response.Response{data=service.SkillResponse} "更新成功"
+// @Failure 400 {object} response.Response "请求参数错误或技能分类不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/skills/{id} [put]
+// @Security BearerAuth
 func (h *SkillHandler) UpdateSkill(c *gin.Context) {
 	skillIDStr := c.Param("id")
 	skillID, err := strconv.ParseUint(skillIDStr, 10, 32)
@@ -84,6 +121,10 @@ func (h *SkillHandler) UpdateSkill(c *gin.Context) {
 	skillService := h.container.GetSkillService()
 	skill, err := skillService.UpdateSkill(c.Request.Context(), uint(skillID), &req)
 	if err != nil {
+		if errors.Is(err, service.ErrUnknownSkillCategory) {
+			response.BadRequest(c, err.Error())
+			return
+		}
 		logger.Errorf("Failed to update skill: %v", err)
 		response.InternalError(c, "Failed to update skill")
 		return
@@ -93,6 +134,15 @@ func (h *SkillHandler) UpdateSkill(c *gin.Context) {
 }
 
 // DeleteSkill 删除技能
+// @Summary 删除技能
+// @Tags 技能管理
+// @Produce json
+// @Param id path int true "技能ID"
+// @Success 200 {object} response.Response{data=int} "删除成功，data为技能ID"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/skills/{id} [delete]
+// @Security BearerAuth
 func (h *SkillHandler) DeleteSkill(c *gin.Context) {
 	skillIDStr := c.Param("id")
 	skillID, err := strconv.ParseUint(skillIDStr, 10, 32)
@@ -114,6 +164,16 @@ func (h *SkillHandler) DeleteSkill(c *gin.Context) {
 }
 
 // ListSkills 获取技能列表
+// @Summary 获取技能列表
+// @Tags 技能管理
+// @Produce json
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Param category query string false "技能分类"
+// @Success 200 {object} response.Response{data=SkillListResult} "获取成功"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/skills [get]
+// @Security BearerAuth
 func (h *SkillHandler) ListSkills(c *gin.Context) {
 	var filter service.SkillListFilter
 
@@ -157,30 +217,130 @@ func (h *SkillHandler) ListSkills(c *gin.Context) {
 		return
 	}
 
-	response.Success(c, gin.H{
-		"skills":    result.Items,
-		"total":     result.Total,
-		"page":      result.Page,
-		"page_size": result.Size,
+	response.Success(c, SkillListResult{
+		Skills:   result.Items,
+		Total:    result.Total,
+		Page:     result.Page,
+		PageSize: result.Size,
 	})
 }
 
 // GetSkillCategories 获取技能分类列表
+// @Summary 获取技能分类
+// @Description 返回全部技能分类及各分类下的技能数量，按名称排序
+// @Tags 技能管理
+// @Produce json
+// @Success 200 {object} response.Response{data=SkillCategoriesResult} "获取成功"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/skills/categories [get]
+// @Security BearerAuth
 func (h *SkillHandler) GetSkillCategories(c *gin.Context) {
 	skillService := h.container.GetSkillService()
-	categories, err := skillService.GetAllCategories(c.Request.Context())
+	categories, err := skillService.GetSkillCategories(c.Request.Context())
 	if err != nil {
 		logger.Errorf("Failed to get skill categories: %v", err)
 		response.InternalError(c, "Failed to get skill categories")
 		return
 	}
 
-	response.Success(c, gin.H{
-		"categories": categories,
-	})
+	response.Success(c, SkillCategoriesResult{Categories: categories})
+}
+
+// RenameSkillCategory 重命名技能分类
+// @Summary 重命名技能分类
+// @Description 新名称不能与其他分类重复（不区分大小写），需要归并到已有分类时请使用合并接口
+// @Tags 技能管理
+// @Accept json
+// @Produce json
+// @Param id path int true "分类ID"
+// @Param request body service.UpdateSkillCategoryRequest true "新的分类名称"
+// @Success 200 {object} response.Response{data=service.SkillCategoryResponse} "重命名成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 404 {object} response.Response "技能分类不存在"
+// @Failure 409 {object} response.Response "分类名称已存在"
+// @Router /api/v1/skills/categories/{id} [put]
+// @Security BearerAuth
+func (h *SkillHandler) RenameSkillCategory(c *gin.Context) {
+	categoryID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的分类ID")
+		return
+	}
+
+	var req service.UpdateSkillCategoryRequest
+	if !response.BindAndValidate(c, &req) {
+		return
+	}
+
+	category, err := h.container.GetSkillService().RenameSkillCategory(c.Request.Context(), uint(categoryID), &req)
+	if err != nil {
+		h.handleSkillCategoryError(c, err, "重命名技能分类失败")
+		return
+	}
+
+	response.Success(c, category)
+}
+
+// MergeSkillCategory 合并技能分类
+// @Summary 合并技能分类
+// @Description 将路径中的源分类合并到目标分类：源分类下的全部技能在同一事务内改挂到目标分类，然后删除源分类
+// @Tags 技能管理
+// @Accept json
+// @Produce json
+// @Param id path int true "源分类ID"
+// @Param request body service.MergeSkillCategoryRequest true "目标分类"
+// @Success 200 {object} response.Response{data=service.MergeSkillCategoryResponse} "合并成功"
+// @Failure 400 {object} response.Response "请求参数错误或合并到自身"
+// @Failure 404 {object} response.Response "源分类或目标分类不存在"
+// @Router /api/v1/skills/categories/{id}/merge [post]
+// @Security BearerAuth
+func (h *SkillHandler) MergeSkillCategory(c *gin.Context) {
+	categoryID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的分类ID")
+		return
+	}
+
+	var req service.MergeSkillCategoryRequest
+	if !response.BindAndValidate(c, &req) {
+		return
+	}
+
+	result, err := h.container.GetSkillService().MergeSkillCategories(c.Request.Context(), uint(categoryID), &req)
+	if err != nil {
+		h.handleSkillCategoryError(c, err, "合并技能分类失败")
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// handleSkillCategoryError 将技能分类相关错误映射为HTTP响应
+func (h *SkillHandler) handleSkillCategoryError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrSkillCategoryMergeSelf):
+		response.BadRequest(c, err.Error())
+	case errors.Is(err, service.ErrSkillCategoryExists):
+		response.Conflict(c, err.Error())
+	case repository.IsNotFoundError(err):
+		response.NotFound(c, "技能分类不存在")
+	default:
+		logger.Errorf("%s: %v", message, err)
+		response.InternalError(c, message)
+	}
 }
 
 // AssignSkillToEmployee 为员工分配技能
+// @Summary 为员工分配技能
+// @Tags 技能管理
+// @Accept json
+// @Produce json
+// @Param request body service.AssignSkillRequest true "分配技能请求"
+// @Success 200 {object} response.Response{data=MessageResult} "分配成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/skills/assign [post]
+// @Security BearerAuth
 func (h *SkillHandler) AssignSkillToEmployee(c *gin.Context) {
 	var req service.AssignSkillRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -197,10 +357,20 @@ func (h *SkillHandler) AssignSkillToEmployee(c *gin.Context) {
 		return
 	}
 
-	response.Success(c, gin.H{"message": "success assign"})
+	response.Success(c, MessageResult{Message: "success assign"})
 }
 
 // RemoveSkillFromEmployee 移除员工技能
+// @Summary 移除员工技能
+// @Tags 技能管理
+// @Produce json
+// @Param employee_id path int true "员工ID"
+// @Param skill_id path int true "技能ID"
+// @Success 200 {object} response.Response{data=int} "移除成功，data为技能ID"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/skills/employees/{employee_id}/skills/{skill_id} [delete]
+// @Security BearerAuth
 func (h *SkillHandler) RemoveSkillFromEmployee(c *gin.Context) {
 	employeeIDStr := c.Param("employee_id")
 	employeeID, err := strconv.ParseUint(employeeIDStr, 10, 32)
@@ -230,6 +400,16 @@ func (h *SkillHandler) RemoveSkillFromEmployee(c *gin.Context) {
 }
 
 // GetEmployeeSkills 获取员工技能列表
+// @Summary 获取员工技能
+// @Tags 技能管理
+// @Produce json
+// @Param employee_id path int true "员工ID"
+// @Param exclude_expired query bool false "不返回认证已到期的技能"
+// @Success 200 {object} response.Response{data=SkillsResult} "获取成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/skills/employees/{employee_id} [get]
+// @Security BearerAuth
 func (h *SkillHandler) GetEmployeeSkills(c *gin.Context) {
 	employeeIDStr := c.Param("employee_id")
 	employeeID, err := strconv.ParseUint(employeeIDStr, 10, 32)
@@ -239,15 +419,100 @@ func (h *SkillHandler) GetEmployeeSkills(c *gin.Context) {
 		return
 	}
 
+	// exclude_expired=true 时不返回认证已到期的技能
+	excludeExpired := c.Query("exclude_expired") == "true"
+
 	skillService := h.container.GetSkillService()
-	skills, err := skillService.GetEmployeeSkills(c.Request.Context(), uint(employeeID))
+	skills, err := skillService.GetEmployeeSkills(c.Request.Context(), uint(employeeID), excludeExpired)
 	if err != nil {
 		logger.Errorf("Failed to get employee skills: %v", err)
 		response.InternalError(c, "Failed to get employee skills")
 		return
 	}
 
-	response.Success(c, gin.H{
-		"skills": skills,
-	})
+	response.Success(c, SkillsResult{Skills: skills})
+}
+
+// EndorseEmployeeSkill 认证员工技能，员工的直接上级或拥有skill:update权限的用户可以认证
+// @Summary 认证员工技能
+// @Description 员工的直接上级或拥有skill:update权限的用户可以认证员工技能
+// @Tags 技能管理
+// @Accept json
+// @Produce json
+// @Param employee_id path int true "员工ID"
+// @Param skill_id path int true "技能ID"
+// @Param request body service.EndorseSkillRequest true "认证请求"
+// @Success 200 {object} response.Response{data=service.SkillResponse} "认证成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 403 {object} response.Response "无权认证该员工技能"
+// @Failure 404 {object} response.Response "员工或员工技能不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/skills/employees/{employee_id}/skills/{skill_id}/endorse [post]
+// @Security BearerAuth
+func (h *SkillHandler) EndorseEmployeeSkill(c *gin.Context) {
+	employeeID, err := strconv.ParseUint(c.Param("employee_id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的员工ID")
+		return
+	}
+	skillID, err := strconv.ParseUint(c.Param("skill_id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的技能ID")
+		return
+	}
+
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		response.Unauthorized(c, "用户未认证")
+		return
+	}
+
+	var req service.EndorseSkillRequest
+	if !response.BindAndValidate(c, &req) {
+		return
+	}
+	req.EndorserID = userID
+
+	req.CanManageSkills, err = h.container.GetServiceManager().PermissionService().HasPermission(c.Request.Context(), userID, "skill", "update")
+	if err != nil {
+		logger.Errorf("检查技能管理权限失败: %v", err)
+		response.InternalError(c, "权限检查失败")
+		return
+	}
+
+	skill, err := h.container.GetSkillService().EndorseEmployeeSkill(c.Request.Context(), uint(employeeID), uint(skillID), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidSkillEndorsement):
+			response.BadRequest(c, err.Error())
+		case errors.Is(err, service.ErrSkillEndorsementForbidden):
+			response.Forbidden(c, err.Error())
+		case repository.IsNotFoundError(err):
+			response.NotFound(c, "员工或员工技能不存在")
+		default:
+			logger.Errorf("认证员工技能失败: %v", err)
+			response.InternalError(c, "认证员工技能失败")
+		}
+		return
+	}
+
+	response.Success(c, skill)
+}
+
+// SkillListResult 技能分页列表
+type SkillListResult struct {
+	Skills   []*service.SkillResponse `json:"skills"`
+	Total    int64                    `json:"total"`
+	Page     int                      `json:"page"`
+	PageSize int                      `json:"page_size"`
+}
+
+// SkillCategoriesResult 技能分类列表
+type SkillCategoriesResult struct {
+	Categories []*service.SkillCategoryResponse `json:"categories"`
+}
+
+// SkillsResult 员工技能列表
+type SkillsResult struct {
+	Skills []*service.SkillResponse `json:"skills"`
 }
@@ -1,11 +1,15 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
 	"taskmanage/internal/service"
 	"taskmanage/pkg/logger"
 	"taskmanage/pkg/response"
@@ -15,21 +19,22 @@ import (
 type TaskHandler struct {
 	taskService       service.TaskService
 	assignmentService service.AssignmentService
+	permissionService service.PermissionService
+	// permissionAssignmentService 解析任务可见范围，为nil时不限制
+	permissionAssignmentService service.PermissionAssignmentService
+	hideOutOfScopeTasks         bool // 范围外的任务返回404而非403
 }
 
 // NewTaskHandler 创建任务处理器
-func NewTaskHandler(container interface{}, _ interface{}) *TaskHandler {
-	// 从容器中获取TaskService和AssignmentService
-	if c, ok := container.(interface{ 
-		GetServiceManager() service.ServiceManager
-		GetAssignmentManagementService() service.AssignmentService
-	}); ok {
-		return &TaskHandler{
-			taskService:       c.GetServiceManager().TaskService(),
-			assignmentService: c.GetAssignmentManagementService(),
-		}
+// permissionAssignmentService为nil时不限制任务可见范围，hideOutOfScopeTasks为true时范围外的任务返回404
+func NewTaskHandler(taskService service.TaskService, assignmentService service.AssignmentService, permissionService service.PermissionService, permissionAssignmentService service.PermissionAssignmentService, hideOutOfScopeTasks bool) *TaskHandler {
+	return &TaskHandler{
+		taskService:                 taskService,
+		assignmentService:           assignmentService,
+		permissionService:           permissionService,
+		permissionAssignmentService: permissionAssignmentService,
+		hideOutOfScopeTasks:         hideOutOfScopeTasks,
 	}
-	panic("无法从容器中获取服务")
 }
 
 // CreateTask 创建任务
@@ -48,17 +53,14 @@ func NewTaskHandler(container interface{}, _ interface{}) *TaskHandler {
 // @Security BearerAuth
 func (h *TaskHandler) CreateTask(c *gin.Context) {
 	var req service.CreateTaskRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		logger.Warnf("创建任务请求参数绑定失败: %v", err)
-		response.BadRequest(c, "请求参数格式错误")
+	if !response.BindAndValidate(c, &req) {
 		return
 	}
 
 	// 创建任务
 	task, err := h.taskService.CreateTask(c.Request.Context(), &req)
 	if err != nil {
-		logger.Errorf("创建任务失败: %v", err)
-		response.InternalError(c, "创建任务失败")
+		respondTaskError(c, err, "创建任务失败")
 		return
 	}
 
@@ -72,14 +74,17 @@ func (h *TaskHandler) CreateTask(c *gin.Context) {
 
 // GetTask 获取任务详情
 // @Summary 获取任务详情
-// @Description 根据ID获取任务详情
+// @Description 根据ID获取任务详情，任务已归档时从归档中读取并返回archived=true。
+// @Description 任务不在当前用户的可见范围内时返回403，配置task.out_of_scope_status为404时按任务不存在处理
 // @Tags 任务管理
 // @Accept json
 // @Produce json
 // @Param id path int true "任务ID"
+// @Param expand query string false "逗号分隔的展开项: assignee（负责人ID、姓名、头像）、creator（创建者ID、姓名）、project（项目ID、名称）"
 // @Success 200 {object} response.Response{data=service.TaskResponse} "获取成功"
 // @Failure 400 {object} response.Response "请求参数错误"
 // @Failure 401 {object} response.Response "未授权"
+// @Failure 403 {object} response.Response "任务不在可见范围内"
 // @Failure 404 {object} response.Response "任务不存在"
 // @Failure 500 {object} response.Response "服务器内部错误"
 // @Router /api/v1/tasks/{id} [get]
@@ -91,14 +96,14 @@ func (h *TaskHandler) GetTask(c *gin.Context) {
 		return
 	}
 
-	task, err := h.taskService.GetTask(c.Request.Context(), uint(taskID))
+	ctx, ok := h.taskVisibilityContext(c)
+	if !ok {
+		return
+	}
+
+	task, err := h.taskService.GetTask(ctx, uint(taskID), service.ParseTaskExpand(c.Query("expand")))
 	if err != nil {
-		if err.Error() == "任务不存在" {
-			response.NotFound(c, "任务不存在")
-			return
-		}
-		logger.Errorf("获取任务失败: %v", err)
-		response.InternalError(c, "获取任务失败")
+		respondTaskError(c, err, "获取任务失败")
 		return
 	}
 
@@ -118,6 +123,7 @@ func (h *TaskHandler) GetTask(c *gin.Context) {
 // @Failure 401 {object} response.Response "未授权"
 // @Failure 403 {object} response.Response "权限不足"
 // @Failure 404 {object} response.Response "任务不存在"
+// @Failure 409 {object} response.Response{data=service.TaskResponse} "任务已被修改，data为最新状态"
 // @Failure 500 {object} response.Response "服务器内部错误"
 // @Router /api/v1/tasks/{id} [put]
 // @Security BearerAuth
@@ -129,20 +135,18 @@ func (h *TaskHandler) UpdateTask(c *gin.Context) {
 	}
 
 	var req service.UpdateTaskRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		logger.Warnf("更新任务请求参数绑定失败: %v", err)
-		response.BadRequest(c, "请求参数格式错误")
+	if !response.BindAndValidate(c, &req) {
 		return
 	}
 
-	task, err := h.taskService.UpdateTask(c.Request.Context(), uint(taskID), &req)
+	ctx := c.Request.Context()
+	if userID, err := GetUserIDFromContext(c); err == nil {
+		ctx = SetUserIDInContext(ctx, userID)
+	}
+
+	task, err := h.taskService.UpdateTask(ctx, uint(taskID), &req)
 	if err != nil {
-		if err.Error() == "任务不存在" {
-			response.NotFound(c, "任务不存在")
-			return
-		}
-		logger.Errorf("更新任务失败: %v", err)
-		response.InternalError(c, "更新任务失败")
+		respondTaskError(c, err, "更新任务失败")
 		return
 	}
 
@@ -174,12 +178,7 @@ func (h *TaskHandler) DeleteTask(c *gin.Context) {
 
 	err = h.taskService.DeleteTask(c.Request.Context(), uint(taskID))
 	if err != nil {
-		if err.Error() == "任务不存在" {
-			response.NotFound(c, "任务不存在")
-			return
-		}
-		logger.Errorf("删除任务失败: %v", err)
-		response.InternalError(c, "删除任务失败")
+		respondTaskError(c, err, "删除任务失败")
 		return
 	}
 
@@ -189,28 +188,71 @@ func (h *TaskHandler) DeleteTask(c *gin.Context) {
 
 // ListTasks 获取任务列表
 // @Summary 获取任务列表
-// @Description 获取任务列表，支持分页和过滤
+// @Description 获取任务列表，支持分页和过滤。只返回当前用户权限模板task_scope范围内的任务：
+// @Description assigned为分配给自己的任务，created另含自己创建的任务，team另含负责人与自己同部门的任务，all不限制
 // @Tags 任务管理
 // @Accept json
 // @Produce json
 // @Param page query int false "页码" default(1)
 // @Param page_size query int false "每页数量" default(10)
 // @Param search query string false "搜索关键词"
-// @Param status query string false "任务状态" Enums(pending,assigned,in_progress,completed,cancelled)
+// @Param status query string false "任务状态" Enums(pending,assigned,in_progress,in_review,completed,cancelled)
 // @Param priority query string false "优先级" Enums(low,medium,high,urgent)
 // @Param type query string false "任务类型" Enums(development,testing,design,documentation,maintenance,research)
 // @Param category query string false "任务分类"
 // @Param created_by query int false "创建者ID"
 // @Param assigned_to query int false "分配给用户ID"
+// @Param labels query string false "逗号分隔的标签名称，默认须带有全部标签"
+// @Param any query bool false "为true时带有任一标签即可"
+// @Param include_archived query bool false "为true时同时返回已归档的任务，归档任务带archived标记"
+// @Param expand query string false "逗号分隔的展开项: assignee、creator、project，不传时只返回ID"
 // @Param sort_by query string false "排序字段" default(created_at)
 // @Param sort_desc query bool false "是否降序" default(true)
-// @Success 200 {object} response.Response{data=response.ListResponse{items=[]service.TaskResponse}} "获取成功"
+// @Success 200 {object} response.PaginationResponse{data=[]service.TaskResponse} "获取成功"
 // @Failure 400 {object} response.Response "请求参数错误"
 // @Failure 401 {object} response.Response "未授权"
 // @Failure 500 {object} response.Response "服务器内部错误"
 // @Router /api/v1/tasks [get]
 // @Security BearerAuth
 func (h *TaskHandler) ListTasks(c *gin.Context) {
+	h.listTasks(c, taskListFilterFromQuery(c))
+}
+
+// ListMyTasks 获取分配给我的任务
+// @Summary 获取分配给我的任务
+// @Description 等同于GET /api/v1/tasks?assigned_to=当前用户ID，客户端不需要知道自己的用户ID；查询参数中的assigned_to被忽略
+// @Tags 个人中心
+// @Produce json
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(10)
+// @Param search query string false "搜索关键词"
+// @Param status query string false "任务状态" Enums(pending,assigned,in_progress,in_review,completed,cancelled)
+// @Param priority query string false "优先级" Enums(low,medium,high,urgent)
+// @Param labels query string false "逗号分隔的标签名称，默认须带有全部标签"
+// @Param any query bool false "为true时带有任一标签即可"
+// @Param include_archived query bool false "为true时同时返回已归档的任务，归档任务带archived标记"
+// @Param expand query string false "逗号分隔的展开项: assignee、creator、project，不传时只返回ID"
+// @Param sort_by query string false "排序字段" default(created_at)
+// @Param sort_desc query bool false "是否降序" default(true)
+// @Success 200 {object} response.PaginationResponse{data=[]service.TaskResponse} "获取成功"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/me/tasks [get]
+// @Security BearerAuth
+func (h *TaskHandler) ListMyTasks(c *gin.Context) {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		response.Unauthorized(c, "用户信息缺失")
+		return
+	}
+
+	filter := taskListFilterFromQuery(c)
+	filter.AssignedTo = &userID
+	h.listTasks(c, filter)
+}
+
+// taskListFilterFromQuery 从查询参数解析任务列表过滤条件，无效的参数被忽略
+func taskListFilterFromQuery(c *gin.Context) service.TaskListFilter {
 	// 解析查询参数
 	filter := service.TaskListFilter{
 		Page:     1,
@@ -249,8 +291,28 @@ func (h *TaskHandler) ListTasks(c *gin.Context) {
 		}
 	}
 
+	// 标签过滤
+	filter.Labels = c.Query("labels")
+	filter.Any = c.Query("any") == "true"
+
+	// 默认不含已归档的任务
+	filter.IncludeArchived = c.Query("include_archived") == "true"
+
+	// 按需展开负责人、创建者和项目信息
+	filter.Expand = service.ParseTaskExpand(c.Query("expand"))
+
+	return filter
+}
+
+// listTasks 按当前用户的任务可见范围查询并返回分页的任务列表
+func (h *TaskHandler) listTasks(c *gin.Context, filter service.TaskListFilter) {
+	ctx, ok := h.taskVisibilityContext(c)
+	if !ok {
+		return
+	}
+
 	// 获取任务列表
-	tasks, total, err := h.taskService.ListTasks(c.Request.Context(), filter)
+	tasks, total, err := h.taskService.ListTasks(ctx, filter)
 	if err != nil {
 		logger.Errorf("获取任务列表失败: %v", err)
 		response.InternalError(c, "获取任务列表失败")
@@ -262,21 +324,11 @@ func (h *TaskHandler) ListTasks(c *gin.Context) {
 }
 
 // GetTaskStats 获取任务统计信息
-// @Summary 获取任务统计信息
-// @Description 获取任务状态统计
-// @Tags 任务管理
-// @Accept json
-// @Produce json
-// @Success 200 {object} response.Response{data=map[string]int64} "获取成功"
-// @Failure 401 {object} response.Response "未授权"
-// @Failure 500 {object} response.Response "服务器内部错误"
-// @Router /api/v1/tasks/stats [get]
-// @Security BearerAuth
 func (h *TaskHandler) GetTaskStats(c *gin.Context) {
 	// 获取各状态的任务数量统计
 	stats := make(map[string]int64)
 
-	statuses := []string{"pending", "assigned", "in_progress", "completed", "cancelled"}
+	statuses := []string{"pending", "assigned", "in_progress", "in_review", "completed", "cancelled"}
 	for _, status := range statuses {
 		filter := service.TaskListFilter{
 			Status:   status,
@@ -305,6 +357,20 @@ func (h *TaskHandler) GetTaskStats(c *gin.Context) {
 }
 
 // AssignTask 分配任务
+// @Summary 分配任务
+// @Description 将任务分配给员工，按分配方式和审批配置可能进入审批流程或待审批状态
+// @Tags 任务管理
+// @Accept json
+// @Produce json
+// @Param id path int true "任务ID"
+// @Param request body service.AssignTaskRequest true "分配任务请求"
+// @Success 200 {object} response.Response{data=service.AssignmentResponse} "分配成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 401 {object} response.Response "未授权"
+// @Failure 409 {object} response.Response "员工不可用、不是项目成员、有未完成的子任务或任务已被修改"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/tasks/{id}/assign [post]
+// @Security BearerAuth
 func (h *TaskHandler) AssignTask(c *gin.Context) {
 	taskID := c.Param("id")
 	if taskID == "" {
@@ -313,9 +379,7 @@ func (h *TaskHandler) AssignTask(c *gin.Context) {
 	}
 
 	var req service.AssignTaskRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		logger.Warnf("分配任务请求参数绑定失败: %v", err)
-		response.BadRequest(c, "请求参数格式错误")
+	if !response.BindAndValidate(c, &req) {
 		return
 	}
 
@@ -341,15 +405,113 @@ func (h *TaskHandler) AssignTask(c *gin.Context) {
 	req.TaskID = uint(id) // 设置任务ID
 	result, err := h.taskService.AssignTask(ctx, &req)
 	if err != nil {
-		logger.Errorf("分配任务失败: %v", err)
-		response.InternalError(c, "分配任务失败")
+		respondTaskError(c, err, "分配任务失败")
 		return
 	}
 
 	response.Success(c, result)
 }
 
+// ApproveAssignment 审批通过待审批的任务分配（不使用审批流程时）
+// @Summary 审批通过任务分配
+// @Description 审批通过未使用审批流程的待审批分配记录，任务分配给被分配员工
+// @Tags 任务分配
+// @Accept json
+// @Produce json
+// @Param id path int true "分配记录ID"
+// @Param request body service.ApproveAssignmentRequest false "审批意见"
+// @Success 200 {object} response.Response "审批通过"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 404 {object} response.Response "分配记录不存在"
+// @Failure 409 {object} response.Response "分配记录不是待审批状态"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/assignments/{id}/approve [post]
+// @Security BearerAuth
+func (h *TaskHandler) ApproveAssignment(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的分配记录ID")
+		return
+	}
+
+	var req service.ApproveAssignmentRequest
+	if c.Request.ContentLength > 0 && !response.BindAndValidate(c, &req) {
+		return
+	}
+	if userID, exists := c.Get("user_id"); exists {
+		req.ApproverID, _ = userID.(uint)
+	}
+
+	if err := h.taskService.ApproveAssignment(c.Request.Context(), uint(id), &req); err != nil {
+		h.handleAssignmentDecisionError(c, err, "审批通过任务分配失败")
+		return
+	}
+	response.SuccessWithMessage(c, "任务分配已审批通过", nil)
+}
+
+// RejectAssignment 拒绝待审批的任务分配，任务重置为待分配
+// @Summary 拒绝任务分配
+// @Description 拒绝未使用审批流程的待审批分配记录，任务重置为待分配
+// @Tags 任务分配
+// @Accept json
+// @Produce json
+// @Param id path int true "分配记录ID"
+// @Param request body service.RejectAssignmentRequest true "拒绝原因"
+// @Success 200 {object} response.Response "已拒绝"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 404 {object} response.Response "分配记录不存在"
+// @Failure 409 {object} response.Response "分配记录不是待审批状态"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/assignments/{id}/reject [post]
+// @Security BearerAuth
+func (h *TaskHandler) RejectAssignment(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的分配记录ID")
+		return
+	}
+
+	var req service.RejectAssignmentRequest
+	if !response.BindAndValidate(c, &req) {
+		return
+	}
+	if userID, exists := c.Get("user_id"); exists {
+		req.ApproverID, _ = userID.(uint)
+	}
+
+	if err := h.taskService.RejectAssignment(c.Request.Context(), uint(id), &req); err != nil {
+		h.handleAssignmentDecisionError(c, err, "拒绝任务分配失败")
+		return
+	}
+	response.SuccessWithMessage(c, "任务分配已拒绝", nil)
+}
+
+// handleAssignmentDecisionError 将任务分配审批错误映射为响应
+func (h *TaskHandler) handleAssignmentDecisionError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound) && !response.IsAppError(err):
+		response.NotFound(c, "分配记录不存在")
+	case errors.Is(err, repository.ErrConflict):
+		response.Conflict(c, "任务或分配记录已被其他请求修改，请刷新后重试")
+	default:
+		respondTaskError(c, err, message)
+	}
+}
+
 // ReassignTask 重新分配任务
+// @Summary 重新分配任务
+// @Description 将已分配或进行中的任务转交给其他员工
+// @Tags 任务管理
+// @Accept json
+// @Produce json
+// @Param id path int true "任务ID"
+// @Param request body service.ReassignTaskRequest true "重新分配请求"
+// @Success 200 {object} response.Response{data=service.AssignmentResponse} "重新分配成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 409 {object} response.Response "任务状态不允许重新分配、员工不可用或任务已被修改"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/tasks/{id}/reassign [post]
+// @Security BearerAuth
 func (h *TaskHandler) ReassignTask(c *gin.Context) {
 	taskID := c.Param("id")
 	if taskID == "" {
@@ -358,9 +520,7 @@ func (h *TaskHandler) ReassignTask(c *gin.Context) {
 	}
 
 	var req service.ReassignTaskRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		logger.Warnf("重新分配任务请求参数绑定失败: %v", err)
-		response.BadRequest(c, "请求参数格式错误")
+	if !response.BindAndValidate(c, &req) {
 		return
 	}
 
@@ -371,22 +531,35 @@ func (h *TaskHandler) ReassignTask(c *gin.Context) {
 		return
 	}
 
+	// 操作人取自登录信息
+	if userID, exists := c.Get("user_id"); exists {
+		req.OperatorID, _ = userID.(uint)
+	}
+
 	// 执行任务重新分配
-	_, err = h.taskService.ReassignTask(c.Request.Context(), uint(id), &service.ReassignTaskRequest{
-		FromEmployeeID: req.FromEmployeeID,
-		ToEmployeeID:   req.ToEmployeeID,
-		Reason:         req.Reason,
-	})
+	result, err := h.taskService.ReassignTask(c.Request.Context(), uint(id), &req)
 	if err != nil {
-		logger.Errorf("重新分配任务失败: %v", err)
-		response.InternalError(c, "重新分配任务失败")
+		respondTaskError(c, err, "重新分配任务失败")
 		return
 	}
 
-	response.Success(c, gin.H{"message": "任务重新分配成功"})
+	response.Success(c, result)
 }
 
 // StartTask 开始任务
+// @Summary 开始任务
+// @Description 任务负责人开始处理任务
+// @Tags 任务管理
+// @Produce json
+// @Param id path int true "任务ID"
+// @Success 200 {object} response.Response{data=MessageResult} "任务已开始"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 403 {object} response.Response "不是任务负责人"
+// @Failure 404 {object} response.Response "任务不存在"
+// @Failure 409 {object} response.Response "任务状态不允许或超过项目在制品上限"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/tasks/{id}/start [post]
+// @Security BearerAuth
 func (h *TaskHandler) StartTask(c *gin.Context) {
 	taskID := c.Param("id")
 	if taskID == "" {
@@ -411,15 +584,29 @@ func (h *TaskHandler) StartTask(c *gin.Context) {
 	// 执行开始任务
 	err = h.taskService.StartTask(c.Request.Context(), uint(id), userID.(uint))
 	if err != nil {
-		logger.Errorf("开始任务失败: %v", err)
-		response.InternalError(c, "开始任务失败")
+		respondTaskError(c, err, "开始任务失败")
 		return
 	}
 
-	response.Success(c, gin.H{"message": "任务已开始"})
+	response.Success(c, MessageResult{Message: "任务已开始"})
 }
 
 // CompleteTask 完成任务
+// @Summary 完成任务
+// @Description 任务负责人提交完成说明和附件。requires_review的任务进入in_review并通知审核人，审核通过后才完成
+// @Tags 任务管理
+// @Accept json
+// @Produce json
+// @Param id path int true "任务ID"
+// @Param request body service.CompleteTaskRequest true "完成任务请求"
+// @Success 200 {object} response.Response{data=TaskStatusResult} "任务已完成或已提交审核"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 403 {object} response.Response "不是任务负责人"
+// @Failure 404 {object} response.Response "任务不存在"
+// @Failure 409 {object} response.Response "任务不是进行中状态"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/tasks/{id}/complete [post]
+// @Security BearerAuth
 func (h *TaskHandler) CompleteTask(c *gin.Context) {
 	taskID := c.Param("id")
 	if taskID == "" {
@@ -428,9 +615,7 @@ func (h *TaskHandler) CompleteTask(c *gin.Context) {
 	}
 
 	var req service.CompleteTaskRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		logger.Warnf("完成任务请求参数绑定失败: %v", err)
-		response.BadRequest(c, "请求参数格式错误")
+	if !response.BindAndValidate(c, &req) {
 		return
 	}
 
@@ -449,20 +634,120 @@ func (h *TaskHandler) CompleteTask(c *gin.Context) {
 	}
 
 	// 执行完成任务
-	err = h.taskService.CompleteTask(c.Request.Context(), uint(id), userID.(uint), &service.CompleteTaskRequest{
+	task, err := h.taskService.CompleteTask(c.Request.Context(), uint(id), userID.(uint), &service.CompleteTaskRequest{
 		Comment: req.Comment,
 		Files:   req.Files,
 	})
 	if err != nil {
-		logger.Errorf("完成任务失败: %v", err)
-		response.InternalError(c, "完成任务失败")
+		respondTaskError(c, err, "完成任务失败")
+		return
+	}
+
+	message := "任务已完成"
+	if task.Status == database.TaskStatusInReview {
+		message = "任务已提交审核"
+	}
+	response.Success(c, TaskStatusResult{Message: message, Task: task})
+}
+
+// ApproveTaskReview 审核通过任务
+// @Summary 审核通过任务
+// @Description 审核人确认待审核（in_review）的任务，任务完成并释放负责人的任务名额，通知负责人。审核人为任务的reviewer_id，未指定时为创建者
+// @Tags 任务管理
+// @Accept json
+// @Produce json
+// @Param id path int true "任务ID"
+// @Param request body service.ApproveTaskReviewRequest false "审核意见"
+// @Success 200 {object} response.Response{data=TaskStatusResult} "审核通过"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 403 {object} response.Response "不是任务审核人"
+// @Failure 404 {object} response.Response "任务不存在"
+// @Failure 409 {object} response.Response "任务不是待审核状态"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/tasks/{id}/review/approve [post]
+// @Security BearerAuth
+func (h *TaskHandler) ApproveTaskReview(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的任务ID")
+		return
+	}
+
+	var req service.ApproveTaskReviewRequest
+	if c.Request.ContentLength > 0 && !response.BindAndValidate(c, &req) {
+		return
+	}
+	req.ReviewerID, err = GetUserIDFromContext(c)
+	if err != nil {
+		response.Unauthorized(c, "用户信息缺失")
+		return
+	}
+
+	task, err := h.taskService.ApproveTaskReview(c.Request.Context(), uint(id), &req)
+	if err != nil {
+		respondTaskError(c, err, "审核任务失败")
+		return
+	}
+	response.Success(c, TaskStatusResult{Message: "任务已审核通过", Task: task})
+}
+
+// RejectTaskReview 驳回任务审核
+// @Summary 驳回任务审核
+// @Description 审核人驳回待审核（in_review）的任务，任务退回进行中，驳回意见必填并记录为任务评论，通知负责人
+// @Tags 任务管理
+// @Accept json
+// @Produce json
+// @Param id path int true "任务ID"
+// @Param request body service.RejectTaskReviewRequest true "驳回意见"
+// @Success 200 {object} response.Response{data=TaskStatusResult} "已驳回"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 403 {object} response.Response "不是任务审核人"
+// @Failure 404 {object} response.Response "任务不存在"
+// @Failure 409 {object} response.Response "任务不是待审核状态"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/tasks/{id}/review/reject [post]
+// @Security BearerAuth
+func (h *TaskHandler) RejectTaskReview(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的任务ID")
 		return
 	}
 
-	response.Success(c, gin.H{"message": "任务已完成"})
+	var req service.RejectTaskReviewRequest
+	if !response.BindAndValidate(c, &req) {
+		return
+	}
+	req.ReviewerID, err = GetUserIDFromContext(c)
+	if err != nil {
+		response.Unauthorized(c, "用户信息缺失")
+		return
+	}
+
+	task, err := h.taskService.RejectTaskReview(c.Request.Context(), uint(id), &req)
+	if err != nil {
+		respondTaskError(c, err, "驳回任务审核失败")
+		return
+	}
+	response.Success(c, TaskStatusResult{Message: "任务审核已驳回", Task: task})
 }
 
 // CancelTask 取消任务
+// @Summary 取消任务
+// @Description 取消任务并记录原因。任务有未完成的子任务时返回409 TASK_HAS_OPEN_SUBTASKS，设置cascade后在一个事务中一并取消全部未完成的后代任务
+// @Tags 任务管理
+// @Accept json
+// @Produce json
+// @Param id path int true "任务ID"
+// @Param request body service.CancelTaskRequest true "取消任务请求"
+// @Success 200 {object} response.Response{data=MessageResult} "任务已取消"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 403 {object} response.Response "不是任务创建者或负责人"
+// @Failure 404 {object} response.Response "任务不存在"
+// @Failure 409 {object} response.Response "任务已结束、有未完成的子任务或任务已被修改"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/tasks/{id}/cancel [post]
+// @Security BearerAuth
 func (h *TaskHandler) CancelTask(c *gin.Context) {
 	taskID := c.Param("id")
 	if taskID == "" {
@@ -471,9 +756,7 @@ func (h *TaskHandler) CancelTask(c *gin.Context) {
 	}
 
 	var req service.CancelTaskRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		logger.Warnf("取消任务请求参数绑定失败: %v", err)
-		response.BadRequest(c, "请求参数格式错误")
+	if !response.BindAndValidate(c, &req) {
 		return
 	}
 
@@ -492,17 +775,29 @@ func (h *TaskHandler) CancelTask(c *gin.Context) {
 	}
 
 	// 执行取消任务
-	err = h.taskService.CancelTask(c.Request.Context(), uint(id), userID.(uint), req.Reason)
+	err = h.taskService.CancelTask(c.Request.Context(), uint(id), userID.(uint), &req)
 	if err != nil {
-		logger.Errorf("取消任务失败: %v", err)
-		response.InternalError(c, "取消任务失败")
+		respondTaskError(c, err, "取消任务失败")
 		return
 	}
 
-	response.Success(c, gin.H{"message": "任务已取消"})
+	response.Success(c, MessageResult{Message: "任务已取消"})
 }
 
 // AutoAssignTask 自动分配任务
+// @Summary 自动分配任务
+// @Description 按分配策略从任务所属项目成员中自动选择员工，未指定策略时使用任务所属部门的默认策略
+// @Tags 任务管理
+// @Accept json
+// @Produce json
+// @Param id path int true "任务ID"
+// @Param request body service.AutoAssignRequest true "自动分配请求"
+// @Success 200 {object} response.Response{data=AutoAssignResult} "分配成功"
+// @Failure 400 {object} response.Response "请求参数错误或分配策略未注册"
+// @Failure 409 {object} response.Response "任务有未完成的子任务"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/tasks/{id}/auto-assign [post]
+// @Security BearerAuth
 func (h *TaskHandler) AutoAssignTask(c *gin.Context) {
 	taskID := c.Param("id")
 	if taskID == "" {
@@ -511,9 +806,7 @@ func (h *TaskHandler) AutoAssignTask(c *gin.Context) {
 	}
 
 	var req service.AutoAssignRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		logger.Warnf("自动分配任务请求参数绑定失败: %v", err)
-		response.BadRequest(c, "请求参数格式错误")
+	if !response.BindAndValidate(c, &req) {
 		return
 	}
 
@@ -527,18 +820,34 @@ func (h *TaskHandler) AutoAssignTask(c *gin.Context) {
 	// 执行自动分配
 	assignment, err := h.assignmentService.AutoAssign(c.Request.Context(), uint(id), req.Strategy)
 	if err != nil {
-		logger.Errorf("自动分配任务失败: %v", err)
-		response.InternalError(c, "自动分配任务失败")
+		respondTaskError(c, err, "自动分配任务失败")
 		return
 	}
 
-	response.Success(c, gin.H{
-		"message":    "任务自动分配成功",
-		"assignment": assignment,
+	response.Success(c, AutoAssignResult{
+		Message:    "任务自动分配成功",
+		Assignment: assignment,
 	})
 }
 
+// AutoAssignResult 自动分配结果
+type AutoAssignResult struct {
+	Message    string                      `json:"message"`
+	Assignment *service.AssignmentResponse `json:"assignment"`
+}
+
 // GetAssignmentSuggestions 获取分配建议
+// @Summary 获取任务分配建议
+// @Description 按策略返回最多5个候选员工，任务属于项目时标注是否为项目成员
+// @Tags 任务管理
+// @Produce json
+// @Param id path int true "任务ID"
+// @Param strategy query string false "分配策略" default(comprehensive)
+// @Success 200 {object} response.Response{data=[]service.AssignmentSuggestion} "获取成功"
+// @Failure 400 {object} response.Response "请求参数错误或分配策略未注册"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/tasks/{id}/suggestions [get]
+// @Security BearerAuth
 func (h *TaskHandler) GetAssignmentSuggestions(c *gin.Context) {
 	taskID := c.Param("id")
 	if taskID == "" {
@@ -563,10 +872,47 @@ func (h *TaskHandler) GetAssignmentSuggestions(c *gin.Context) {
 	// 获取分配建议
 	suggestions, err := h.assignmentService.GetAssignmentSuggestions(c.Request.Context(), req)
 	if err != nil {
-		logger.Errorf("获取分配建议失败: %v", err)
-		response.InternalError(c, "获取分配建议失败")
+		respondTaskError(c, err, "获取分配建议失败")
 		return
 	}
 
 	response.Success(c, suggestions)
 }
+
+// taskVisibilityContext 解析当前用户的任务可见范围并放入请求上下文，失败时已写入响应并返回false
+func (h *TaskHandler) taskVisibilityContext(c *gin.Context) (context.Context, bool) {
+	ctx := c.Request.Context()
+	if h.permissionAssignmentService == nil {
+		return ctx, true
+	}
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		response.Unauthorized(c, "未授权")
+		return nil, false
+	}
+	visibility, err := h.permissionAssignmentService.ResolveTaskScope(ctx, userID)
+	if err != nil {
+		logger.Errorf("解析任务可见范围失败: user_id=%d, error=%v", userID, err)
+		response.InternalError(c, "解析任务可见范围失败")
+		return nil, false
+	}
+	visibility.HideOutOfScope = h.hideOutOfScopeTasks
+	return service.WithTaskVisibility(ctx, visibility), true
+}
+
+// respondTaskError 将任务相关服务错误翻译为响应
+// 带最新状态的冲突错误返回最新数据，其余AppError经response.FromError按错误码翻译，未知错误返回500
+func respondTaskError(c *gin.Context, err error, message string) {
+	var conflict *service.ConflictError
+	switch {
+	case errors.As(err, &conflict):
+		response.ConflictWithData(c, conflict.Error(), conflict.Current)
+	case errors.Is(err, service.ErrUnknownAssignmentStrategy):
+		response.BadRequest(c, err.Error())
+	case response.IsAppError(err):
+		response.FromError(c, err)
+	default:
+		logger.Errorf("%s: %v", message, err)
+		response.InternalError(c, message)
+	}
+}
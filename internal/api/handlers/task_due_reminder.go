@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"taskmanage/internal/service"
+	"taskmanage/pkg/response"
+)
+
+// TaskDueReminderHandler 任务截止提醒设置处理器
+type TaskDueReminderHandler struct {
+	reminderService service.TaskDueReminderService
+	logger          *logrus.Logger
+}
+
+// NewTaskDueReminderHandler 创建任务截止提醒设置处理器
+func NewTaskDueReminderHandler(reminderService service.TaskDueReminderService, logger *logrus.Logger) *TaskDueReminderHandler {
+	return &TaskDueReminderHandler{
+		reminderService: reminderService,
+		logger:          logger,
+	}
+}
+
+// GetSettings 获取任务截止提醒设置
+// @Summary 获取任务截止提醒设置
+// @Description 返回各优先级在截止前提醒的小时数，未设置时返回默认值
+// @Tags 系统管理
+// @Produce json
+// @Success 200 {object} response.Response{data=service.TaskDueReminderSettings} "获取成功"
+// @Router /api/v1/admin/settings/task-due-reminders [get]
+// @Security BearerAuth
+func (h *TaskDueReminderHandler) GetSettings(c *gin.Context) {
+	settings, err := h.reminderService.GetSettings(c.Request.Context())
+	if err != nil {
+		response.FromError(c, err)
+		return
+	}
+
+	response.Success(c, settings)
+}
+
+// UpdateSettings 更新任务截止提醒设置
+// @Summary 更新任务截止提醒设置
+// @Description 整体替换各优先级的提醒提前量（小时），提前量须为正数且从大到小排列；已发送过的提醒不会因修改而重发
+// @Tags 系统管理
+// @Accept json
+// @Produce json
+// @Param request body service.TaskDueReminderSettings true "截止提醒设置"
+// @Success 200 {object} response.Response{data=service.TaskDueReminderSettings} "更新成功"
+// @Failure 400 {object} response.Response "截止提醒设置不合法"
+// @Router /api/v1/admin/settings/task-due-reminders [put]
+// @Security BearerAuth
+func (h *TaskDueReminderHandler) UpdateSettings(c *gin.Context) {
+	var req service.TaskDueReminderSettings
+	if !response.BindAndValidate(c, &req) {
+		return
+	}
+
+	settings, err := h.reminderService.UpdateSettings(c.Request.Context(), &req)
+	if err != nil {
+		response.FromError(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, "任务截止提醒设置已更新", settings)
+}
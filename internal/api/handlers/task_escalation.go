@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"taskmanage/internal/service"
+	"taskmanage/pkg/response"
+)
+
+// TaskEscalationHandler 任务优先级老化规则处理器
+type TaskEscalationHandler struct {
+	escalationService service.TaskEscalationService
+	logger            *logrus.Logger
+}
+
+// NewTaskEscalationHandler 创建任务优先级老化规则处理器
+func NewTaskEscalationHandler(escalationService service.TaskEscalationService, logger *logrus.Logger) *TaskEscalationHandler {
+	return &TaskEscalationHandler{
+		escalationService: escalationService,
+		logger:            logger,
+	}
+}
+
+// ListRules 获取任务升级规则
+// @Summary 获取任务升级规则
+// @Description 返回全部优先级老化规则，按阈值从小到大排序
+// @Tags 系统管理
+// @Produce json
+// @Success 200 {object} response.Response{data=[]service.TaskEscalationRuleResponse} "获取成功"
+// @Router /api/v1/admin/task-escalation-rules [get]
+// @Security BearerAuth
+func (h *TaskEscalationHandler) ListRules(c *gin.Context) {
+	rules, err := h.escalationService.ListRules(c.Request.Context())
+	if err != nil {
+		response.FromError(c, err)
+		return
+	}
+
+	response.Success(c, rules)
+}
+
+// CreateRule 创建任务升级规则
+// @Summary 创建任务升级规则
+// @Description 指定优先级的任务待分配超过阈值小时数后，按规则调整优先级、添加系统评论并通知创建者或其部门经理；同一规则对同一任务只升级一次
+// @Tags 系统管理
+// @Accept json
+// @Produce json
+// @Param request body service.TaskEscalationRuleRequest true "升级规则"
+// @Success 201 {object} response.Response{data=service.TaskEscalationRuleResponse} "创建成功"
+// @Failure 400 {object} response.Response "升级规则不合法"
+// @Router /api/v1/admin/task-escalation-rules [post]
+// @Security BearerAuth
+func (h *TaskEscalationHandler) CreateRule(c *gin.Context) {
+	var req service.TaskEscalationRuleRequest
+	if !response.BindAndValidate(c, &req) {
+		return
+	}
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		response.Unauthorized(c, "用户信息缺失")
+		return
+	}
+
+	rule, err := h.escalationService.CreateRule(c.Request.Context(), userID, &req)
+	if err != nil {
+		response.FromError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.Response{
+		Code:    response.ErrCodeSuccess,
+		Message: "任务升级规则创建成功",
+		Data:    rule,
+	})
+}
+
+// UpdateRule 更新任务升级规则
+// @Summary 更新任务升级规则
+// @Description 整体更新升级规则，已被该规则升级过的任务不会再次升级
+// @Tags 系统管理
+// @Accept json
+// @Produce json
+// @Param id path int true "规则ID"
+// @Param request body service.TaskEscalationRuleRequest true "升级规则"
+// @Success 200 {object} response.Response{data=service.TaskEscalationRuleResponse} "更新成功"
+// @Failure 400 {object} response.Response "升级规则不合法"
+// @Failure 404 {object} response.Response "升级规则不存在"
+// @Router /api/v1/admin/task-escalation-rules/{id} [put]
+// @Security BearerAuth
+func (h *TaskEscalationHandler) UpdateRule(c *gin.Context) {
+	ruleID, ok := parseUintParam(c, "id", "无效的规则ID")
+	if !ok {
+		return
+	}
+
+	var req service.TaskEscalationRuleRequest
+	if !response.BindAndValidate(c, &req) {
+		return
+	}
+
+	rule, err := h.escalationService.UpdateRule(c.Request.Context(), ruleID, &req)
+	if err != nil {
+		response.FromError(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, "任务升级规则已更新", rule)
+}
+
+// DeleteRule 删除任务升级规则
+// @Summary 删除任务升级规则
+// @Description 删除升级规则及其升级记录，已调整的任务优先级保持不变
+// @Tags 系统管理
+// @Produce json
+// @Param id path int true "规则ID"
+// @Success 200 {object} response.Response "删除成功"
+// @Failure 404 {object} response.Response "升级规则不存在"
+// @Router /api/v1/admin/task-escalation-rules/{id} [delete]
+// @Security BearerAuth
+func (h *TaskEscalationHandler) DeleteRule(c *gin.Context) {
+	ruleID, ok := parseUintParam(c, "id", "无效的规则ID")
+	if !ok {
+		return
+	}
+
+	if err := h.escalationService.DeleteRule(c.Request.Context(), ruleID); err != nil {
+		response.FromError(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, "任务升级规则已删除", nil)
+}
+
+// RunEscalations 立即执行任务升级
+// @Summary 立即执行任务升级
+// @Description 按启用的规则立即升级超时的待分配任务，与定时任务效果相同；dry_run=true时只返回将要升级的任务和通知对象，不做任何修改
+// @Tags 系统管理
+// @Produce json
+// @Param dry_run query bool false "只预演不执行"
+// @Success 200 {object} response.Response{data=service.TaskEscalationReport} "执行成功"
+// @Router /api/v1/admin/task-escalation-rules/run [post]
+// @Security BearerAuth
+func (h *TaskEscalationHandler) RunEscalations(c *gin.Context) {
+	dryRun := c.Query("dry_run") == "true"
+
+	report, err := h.escalationService.RunEscalations(c.Request.Context(), time.Now(), dryRun)
+	if err != nil {
+		response.FromError(c, err)
+		return
+	}
+
+	response.Success(c, report)
+}
@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"taskmanage/internal/repository"
+	"taskmanage/internal/service"
+	"taskmanage/pkg/response"
+)
+
+// TaskLabelHandler 任务标签处理器
+type TaskLabelHandler struct {
+	labelService service.TaskLabelService
+	logger       *logrus.Logger
+}
+
+// NewTaskLabelHandler 创建任务标签处理器
+func NewTaskLabelHandler(labelService service.TaskLabelService, logger *logrus.Logger) *TaskLabelHandler {
+	return &TaskLabelHandler{
+		labelService: labelService,
+		logger:       logger,
+	}
+}
+
+// ListLabels 获取任务标签列表
+// @Summary 获取任务标签列表
+// @Description 返回全部标签及各标签关联的任务数，按名称排序
+// @Tags 任务标签
+// @Produce json
+// @Success 200 {object} response.Response{data=[]service.TaskLabelResponse} "获取成功"
+// @Router /api/v1/tasks/labels [get]
+// @Security BearerAuth
+func (h *TaskLabelHandler) ListLabels(c *gin.Context) {
+	labels, err := h.labelService.ListLabels(c.Request.Context())
+	if err != nil {
+		h.handleError(c, err, "获取任务标签列表失败")
+		return
+	}
+
+	response.Success(c, labels)
+}
+
+// CreateLabel 创建任务标签
+// @Summary 创建任务标签
+// @Description 名称不区分大小写唯一且不能包含逗号，颜色为#RRGGBB格式，默认#808080
+// @Tags 任务标签
+// @Accept json
+// @Produce json
+// @Param request body service.CreateTaskLabelRequest true "标签内容"
+// @Success 201 {object} response.Response{data=service.TaskLabelResponse} "创建成功"
+// @Failure 400 {object} response.Response "标签不合法"
+// @Failure 409 {object} response.Response "同名标签已存在"
+// @Router /api/v1/tasks/labels [post]
+// @Security BearerAuth
+func (h *TaskLabelHandler) CreateLabel(c *gin.Context) {
+	var req service.CreateTaskLabelRequest
+	if !response.BindAndValidate(c, &req) {
+		return
+	}
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		response.Unauthorized(c, "用户信息缺失")
+		return
+	}
+
+	label, err := h.labelService.CreateLabel(c.Request.Context(), userID, &req)
+	if err != nil {
+		h.handleError(c, err, "创建任务标签失败")
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.Response{
+		Code:    response.ErrCodeSuccess,
+		Message: "任务标签创建成功",
+		Data:    label,
+	})
+}
+
+// DeleteLabel 删除任务标签
+// @Summary 删除任务标签
+// @Description 解除标签与全部任务的关联后删除标签，返回解除关联的任务数
+// @Tags 任务标签
+// @Produce json
+// @Param label_id path int true "标签ID"
+// @Success 200 {object} response.Response{data=service.DeleteTaskLabelResponse} "删除成功"
+// @Failure 404 {object} response.Response "标签不存在"
+// @Router /api/v1/tasks/labels/{label_id} [delete]
+// @Security BearerAuth
+func (h *TaskLabelHandler) DeleteLabel(c *gin.Context) {
+	labelID, ok := parseUintParam(c, "label_id", "无效的标签ID")
+	if !ok {
+		return
+	}
+
+	result, err := h.labelService.DeleteLabel(c.Request.Context(), labelID)
+	if err != nil {
+		h.handleError(c, err, "删除任务标签失败")
+		return
+	}
+
+	response.SuccessWithMessage(c, "任务标签已删除", result)
+}
+
+// BulkAttach 批量为任务添加标签
+// @Summary 批量为任务添加标签
+// @Description 在一个事务内为多个任务添加同一标签，任一任务不存在时不做任何修改；已带有该标签的任务跳过，单次最多500个任务
+// @Tags 任务标签
+// @Accept json
+// @Produce json
+// @Param request body service.BulkTaskLabelRequest true "标签和任务列表"
+// @Success 200 {object} response.Response{data=service.BulkTaskLabelResponse} "添加成功"
+// @Failure 400 {object} response.Response "任务不存在或数量超限"
+// @Failure 404 {object} response.Response "标签不存在"
+// @Router /api/v1/tasks/labels/bulk [post]
+// @Security BearerAuth
+func (h *TaskLabelHandler) BulkAttach(c *gin.Context) {
+	var req service.BulkTaskLabelRequest
+	if !response.BindAndValidate(c, &req) {
+		return
+	}
+
+	result, err := h.labelService.BulkAttach(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err, "批量添加任务标签失败")
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// SetTaskLabels 设置任务标签
+// @Summary 设置任务标签
+// @Description 在一个事务内将任务的标签整体替换为给定集合，空列表清除全部标签
+// @Tags 任务标签
+// @Accept json
+// @Produce json
+// @Param id path int true "任务ID"
+// @Param request body service.SetTaskLabelsRequest true "标签ID列表"
+// @Success 200 {object} response.Response{data=[]service.TaskLabelResponse} "设置成功"
+// @Failure 400 {object} response.Response "标签不存在"
+// @Failure 404 {object} response.Response "任务不存在"
+// @Router /api/v1/tasks/{id}/labels [put]
+// @Security BearerAuth
+func (h *TaskLabelHandler) SetTaskLabels(c *gin.Context) {
+	taskID, ok := parseUintParam(c, "id", "无效的任务ID")
+	if !ok {
+		return
+	}
+
+	var req service.SetTaskLabelsRequest
+	if !response.BindAndValidate(c, &req) {
+		return
+	}
+
+	labels, err := h.labelService.SetTaskLabels(c.Request.Context(), taskID, &req)
+	if err != nil {
+		h.handleError(c, err, "设置任务标签失败")
+		return
+	}
+
+	response.Success(c, labels)
+}
+
+// parseUintParam 解析路径中的ID参数，失败时直接返回400
+func parseUintParam(c *gin.Context, name, message string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 32)
+	if err != nil || id == 0 {
+		response.BadRequest(c, message)
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// handleError 将任务标签相关错误映射为HTTP响应
+func (h *TaskLabelHandler) handleError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidTaskLabel):
+		response.BadRequest(c, err.Error())
+	case errors.Is(err, service.ErrTaskLabelExists):
+		response.Conflict(c, err.Error())
+	case repository.IsNotFoundError(err):
+		response.NotFound(c, "任务或标签不存在")
+	default:
+		h.logger.WithError(err).Error(message)
+		response.InternalError(c, message)
+	}
+}
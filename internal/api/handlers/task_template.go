@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"taskmanage/internal/repository"
+	"taskmanage/internal/service"
+	"taskmanage/pkg/response"
+)
+
+// TaskTemplateHandler 任务模板处理器
+type TaskTemplateHandler struct {
+	templateService service.TaskTemplateService
+	logger          *logrus.Logger
+}
+
+// NewTaskTemplateHandler 创建任务模板处理器
+func NewTaskTemplateHandler(templateService service.TaskTemplateService, logger *logrus.Logger) *TaskTemplateHandler {
+	return &TaskTemplateHandler{
+		templateService: templateService,
+		logger:          logger,
+	}
+}
+
+// ListTemplates 获取任务模板列表
+// @Summary 获取任务模板列表
+// @Description 只返回模板根节点，keyword按标题模糊匹配
+// @Tags 任务模板
+// @Produce json
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Param keyword query string false "标题关键字"
+// @Success 200 {object} response.Response{data=service.ListResponse[service.TaskTemplateResponse]} "获取成功"
+// @Router /api/v1/task-templates [get]
+// @Security BearerAuth
+func (h *TaskTemplateHandler) ListTemplates(c *gin.Context) {
+	var req service.ListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.BindError(c, err)
+		return
+	}
+
+	templates, err := h.templateService.ListTemplates(c.Request.Context(), &req)
+	if err != nil {
+		h.handleError(c, err, "获取任务模板列表失败")
+		return
+	}
+
+	response.Success(c, templates)
+}
+
+// GetTemplate 获取任务模板详情
+// @Summary 获取任务模板详情
+// @Description 返回模板及按顺序排列的全部子任务模板
+// @Tags 任务模板
+// @Produce json
+// @Param id path int true "模板ID"
+// @Success 200 {object} response.Response{data=service.TaskTemplateResponse} "获取成功"
+// @Failure 404 {object} response.Response "模板不存在"
+// @Router /api/v1/task-templates/{id} [get]
+// @Security BearerAuth
+func (h *TaskTemplateHandler) GetTemplate(c *gin.Context) {
+	templateID, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	template, err := h.templateService.GetTemplate(c.Request.Context(), templateID)
+	if err != nil {
+		h.handleError(c, err, "获取任务模板失败")
+		return
+	}
+
+	response.Success(c, template)
+}
+
+// CreateTemplate 创建任务模板
+// @Summary 创建任务模板
+// @Tags 任务模板
+// @Accept json
+// @Produce json
+// @Param request body service.TaskTemplateRequest true "模板内容"
+// @Success 201 {object} response.Response{data=service.TaskTemplateResponse} "创建成功"
+// @Failure 400 {object} response.Response "模板不合法"
+// @Router /api/v1/task-templates [post]
+// @Security BearerAuth
+func (h *TaskTemplateHandler) CreateTemplate(c *gin.Context) {
+	var req service.TaskTemplateRequest
+	if !response.BindAndValidate(c, &req) {
+		return
+	}
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		response.Unauthorized(c, "用户信息缺失")
+		return
+	}
+
+	template, err := h.templateService.CreateTemplate(c.Request.Context(), userID, &req)
+	if err != nil {
+		h.handleError(c, err, "创建任务模板失败")
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.Response{
+		Code:    response.ErrCodeSuccess,
+		Message: "任务模板创建成功",
+		Data:    template,
+	})
+}
+
+// UpdateTemplate 更新任务模板
+// @Summary 更新任务模板
+// @Description 整体替换模板内容和子任务模板，已实例化的任务不受影响
+// @Tags 任务模板
+// @Accept json
+// @Produce json
+// @Param id path int true "模板ID"
+// @Param request body service.TaskTemplateRequest true "模板内容"
+// @Success 200 {object} response.Response{data=service.TaskTemplateResponse} "更新成功"
+// @Failure 400 {object} response.Response "模板不合法"
+// @Failure 404 {object} response.Response "模板不存在"
+// @Router /api/v1/task-templates/{id} [put]
+// @Security BearerAuth
+func (h *TaskTemplateHandler) UpdateTemplate(c *gin.Context) {
+	templateID, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	var req service.TaskTemplateRequest
+	if !response.BindAndValidate(c, &req) {
+		return
+	}
+
+	template, err := h.templateService.UpdateTemplate(c.Request.Context(), templateID, &req)
+	if err != nil {
+		h.handleError(c, err, "更新任务模板失败")
+		return
+	}
+
+	response.SuccessWithMessage(c, "任务模板更新成功", template)
+}
+
+// DeleteTemplate 删除任务模板
+// @Summary 删除任务模板
+// @Description 已实例化的任务不受影响
+// @Tags 任务模板
+// @Produce json
+// @Param id path int true "模板ID"
+// @Success 200 {object} response.Response "删除成功"
+// @Failure 404 {object} response.Response "模板不存在"
+// @Router /api/v1/task-templates/{id} [delete]
+// @Security BearerAuth
+func (h *TaskTemplateHandler) DeleteTemplate(c *gin.Context) {
+	templateID, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.templateService.DeleteTemplate(c.Request.Context(), templateID); err != nil {
+		h.handleError(c, err, "删除任务模板失败")
+		return
+	}
+
+	response.SuccessWithMessage(c, "任务模板已删除", nil)
+}
+
+// InstantiateTemplate 按模板创建任务
+// @Summary 按模板创建任务
+// @Description 在同一事务中创建任务树并复制技能要求，返回模板节点到任务的ID映射；指定负责人时按常规流程分配根任务
+// @Tags 任务模板
+// @Accept json
+// @Produce json
+// @Param id path int true "模板ID"
+// @Param request body service.InstantiateTaskTemplateRequest false "覆盖项"
+// @Success 201 {object} response.Response{data=service.InstantiateTaskTemplateResponse} "创建成功"
+// @Failure 400 {object} response.Response "参数不合法"
+// @Failure 404 {object} response.Response "模板、项目或员工不存在"
+// @Router /api/v1/task-templates/{id}/instantiate [post]
+// @Security BearerAuth
+func (h *TaskTemplateHandler) InstantiateTemplate(c *gin.Context) {
+	templateID, ok := h.parseID(c)
+	if !ok {
+		return
+	}
+
+	var req service.InstantiateTaskTemplateRequest
+	if c.Request.ContentLength != 0 && !response.BindAndValidate(c, &req) {
+		return
+	}
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		response.Unauthorized(c, "用户信息缺失")
+		return
+	}
+
+	// 分配根任务时需要从服务层上下文读取操作人
+	ctx := SetUserIDInContext(c.Request.Context(), userID)
+	result, err := h.templateService.InstantiateTemplate(ctx, templateID, userID, &req)
+	if err != nil {
+		h.handleError(c, err, "按模板创建任务失败")
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.Response{
+		Code:    response.ErrCodeSuccess,
+		Message: "已按模板创建任务",
+		Data:    result,
+	})
+}
+
+// parseID 解析路径中的模板ID，失败时直接返回400
+func (h *TaskTemplateHandler) parseID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil || id == 0 {
+		response.BadRequest(c, "无效的模板ID")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// handleError 将任务模板相关错误映射为HTTP响应
+func (h *TaskTemplateHandler) handleError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidTaskTemplate), errors.Is(err, service.ErrInvalidTaskSkill), errors.Is(err, service.ErrUnknownSkill):
+		response.BadRequest(c, err.Error())
+	case errors.Is(err, service.ErrEmployeeUnavailable):
+		response.ErrorWithCode(c, response.ErrCodeEmployeeNotAvailable, err.Error())
+	case errors.Is(err, service.ErrNotProjectMember):
+		response.ErrorWithCode(c, response.ErrCodeNotProjectMember, err.Error())
+	case repository.IsNotFoundError(err):
+		response.NotFound(c, "模板、项目或员工不存在")
+	default:
+		h.logger.WithError(err).Error(message)
+		response.InternalError(c, message)
+	}
+}
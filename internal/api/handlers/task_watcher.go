@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"taskmanage/internal/repository"
+	"taskmanage/internal/service"
+	"taskmanage/pkg/logger"
+	"taskmanage/pkg/response"
+)
+
+// AddTaskWatcher 关注任务
+// @Summary 关注任务
+// @Description 关注任务以接收状态变更和评论通知。不传user_id时关注当前用户，为他人添加关注需要task:assign权限。每个任务最多50名关注者
+// @Tags 任务管理
+// @Accept json
+// @Produce json
+// @Param id path int true "任务ID"
+// @Param request body service.TaskWatcherRequest false "关注的用户"
+// @Success 201 {object} response.Response{data=service.TaskWatcherResponse} "关注成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 403 {object} response.Response "无权为他人添加关注"
+// @Failure 404 {object} response.Response "任务或用户不存在"
+// @Failure 409 {object} response.Response "关注者数量已达上限"
+// @Router /api/v1/tasks/{id}/watchers [post]
+// @Security BearerAuth
+func (h *TaskHandler) AddTaskWatcher(c *gin.Context) {
+	taskID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的任务ID")
+		return
+	}
+
+	var req service.TaskWatcherRequest
+	if c.Request.ContentLength > 0 && !response.BindAndValidate(c, &req) {
+		return
+	}
+	if !h.fillWatcherOperator(c, &req) {
+		return
+	}
+
+	watcher, err := h.taskService.AddTaskWatcher(c.Request.Context(), uint(taskID), &req)
+	if err != nil {
+		h.handleTaskWatcherError(c, err, "关注任务失败")
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.Response{
+		Code:    response.ErrCodeSuccess,
+		Message: "关注成功",
+		Data:    watcher,
+	})
+}
+
+// RemoveTaskWatcher 取消关注任务
+// @Summary 取消关注任务
+// @Description 不传user_id时取消当前用户的关注，取消他人的关注需要task:assign权限。创建者和负责人的隐式关注不能取消
+// @Tags 任务管理
+// @Produce json
+// @Param id path int true "任务ID"
+// @Param user_id query int false "取消关注的用户ID"
+// @Success 200 {object} response.Response "取消成功"
+// @Failure 403 {object} response.Response "无权取消他人的关注"
+// @Failure 404 {object} response.Response "未关注该任务"
+// @Router /api/v1/tasks/{id}/watchers [delete]
+// @Security BearerAuth
+func (h *TaskHandler) RemoveTaskWatcher(c *gin.Context) {
+	taskID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的任务ID")
+		return
+	}
+
+	var req service.TaskWatcherRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.BadRequest(c, "无效的用户ID")
+		return
+	}
+	if !h.fillWatcherOperator(c, &req) {
+		return
+	}
+
+	if err := h.taskService.RemoveTaskWatcher(c.Request.Context(), uint(taskID), &req); err != nil {
+		h.handleTaskWatcherError(c, err, "取消关注失败")
+		return
+	}
+
+	response.SuccessWithMessage(c, "已取消关注", nil)
+}
+
+// ListTaskWatchers 获取任务关注者
+// @Summary 获取任务关注者
+// @Description 返回任务的全部关注者，创建者和当前负责人作为隐式关注者列在前面
+// @Tags 任务管理
+// @Produce json
+// @Param id path int true "任务ID"
+// @Success 200 {object} response.Response{data=service.TaskWatchersResponse} "获取成功"
+// @Failure 404 {object} response.Response "任务不存在"
+// @Router /api/v1/tasks/{id}/watchers [get]
+// @Security BearerAuth
+func (h *TaskHandler) ListTaskWatchers(c *gin.Context) {
+	taskID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的任务ID")
+		return
+	}
+
+	watchers, err := h.taskService.ListTaskWatchers(c.Request.Context(), uint(taskID))
+	if err != nil {
+		h.handleTaskWatcherError(c, err, "获取任务关注者失败")
+		return
+	}
+
+	response.Success(c, watchers)
+}
+
+// AddTaskComment 发表任务评论
+// @Summary 发表任务评论
+// @Description 发表评论或回复已有评论，任务的关注者会收到通知
+// @Tags 任务管理
+// @Accept json
+// @Produce json
+// @Param id path int true "任务ID"
+// @Param request body service.CreateTaskCommentRequest true "评论内容"
+// @Success 201 {object} response.Response{data=service.TaskCommentResponse} "发表成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 404 {object} response.Response "任务或回复的评论不存在"
+// @Router /api/v1/tasks/{id}/comments [post]
+// @Security BearerAuth
+func (h *TaskHandler) AddTaskComment(c *gin.Context) {
+	taskID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的任务ID")
+		return
+	}
+
+	var req service.CreateTaskCommentRequest
+	if !response.BindAndValidate(c, &req) {
+		return
+	}
+
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		response.Unauthorized(c, "用户信息缺失")
+		return
+	}
+
+	comment, err := h.taskService.AddTaskComment(c.Request.Context(), uint(taskID), userID, &req)
+	if err != nil {
+		h.handleTaskWatcherError(c, err, "发表评论失败")
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.Response{
+		Code:    response.ErrCodeSuccess,
+		Message: "评论成功",
+		Data:    comment,
+	})
+}
+
+// ListTaskComments 获取任务评论
+// @Summary 获取任务评论
+// @Tags 任务管理
+// @Produce json
+// @Param id path int true "任务ID"
+// @Success 200 {object} response.Response{data=[]service.TaskCommentResponse} "获取成功"
+// @Failure 404 {object} response.Response "任务不存在"
+// @Router /api/v1/tasks/{id}/comments [get]
+// @Security BearerAuth
+func (h *TaskHandler) ListTaskComments(c *gin.Context) {
+	taskID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的任务ID")
+		return
+	}
+
+	comments, err := h.taskService.ListTaskComments(c.Request.Context(), uint(taskID))
+	if err != nil {
+		h.handleTaskWatcherError(c, err, "获取任务评论失败")
+		return
+	}
+
+	response.Success(c, comments)
+}
+
+// fillWatcherOperator 填充关注操作的操作人，为他人操作时检查task:assign权限
+func (h *TaskHandler) fillWatcherOperator(c *gin.Context, req *service.TaskWatcherRequest) bool {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		response.Unauthorized(c, "用户信息缺失")
+		return false
+	}
+	req.OperatorID = userID
+
+	if req.UserID != 0 && req.UserID != userID {
+		req.CanManageWatchers, err = h.permissionService.HasPermission(c.Request.Context(), userID, "task", "assign")
+		if err != nil {
+			logger.Errorf("检查任务分配权限失败: %v", err)
+			response.InternalError(c, "权限检查失败")
+			return false
+		}
+	}
+	return true
+}
+
+// handleTaskWatcherError 将任务关注和评论相关错误映射为HTTP响应
+func (h *TaskHandler) handleTaskWatcherError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidTaskComment):
+		response.BadRequest(c, err.Error())
+	case errors.Is(err, service.ErrTaskWatcherForbidden):
+		response.Forbidden(c, err.Error())
+	case errors.Is(err, service.ErrTaskWatcherLimit):
+		response.Conflict(c, err.Error())
+	case repository.IsNotFoundError(err):
+		response.NotFound(c, "任务、用户或关注记录不存在")
+	default:
+		logger.Errorf("%s: %v", message, err)
+		response.InternalError(c, message)
+	}
+}
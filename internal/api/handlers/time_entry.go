@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"taskmanage/internal/repository"
+	"taskmanage/internal/service"
+	"taskmanage/pkg/logger"
+	"taskmanage/pkg/response"
+)
+
+// AddTimeEntry 记录任务工时
+// @Summary 记录任务工时
+// @Description 为分配给自己或自己创建的任务记录一条工时
+// @Tags 任务管理
+// @Accept json
+// @Produce json
+// @Param id path int true "任务ID"
+// @Param request body service.CreateTimeEntryRequest true "工时记录"
+// @Success 201 {object} response.Response{data=service.TimeEntryResponse} "记录成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 403 {object} response.Response "无权记录"
+// @Failure 404 {object} response.Response "任务不存在"
+// @Router /api/v1/tasks/{id}/time-entries [post]
+// @Security BearerAuth
+func (h *TaskHandler) AddTimeEntry(c *gin.Context) {
+	taskID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的任务ID")
+		return
+	}
+
+	var req service.CreateTimeEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Warnf("记录工时请求参数绑定失败: %v", err)
+		response.BadRequest(c, "请求参数格式错误")
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Unauthorized(c, "用户信息缺失")
+		return
+	}
+
+	entry, err := h.taskService.AddTimeEntry(c.Request.Context(), uint(taskID), userID.(uint), &req)
+	if err != nil {
+		h.handleTimeEntryError(c, err, "记录工时失败")
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.Response{
+		Code:    response.ErrCodeSuccess,
+		Message: "工时记录成功",
+		Data:    entry,
+	})
+}
+
+// ListTimeEntries 获取任务工时记录
+// @Summary 获取任务工时记录
+// @Description 获取任务的全部工时记录及累计工时
+// @Tags 任务管理
+// @Produce json
+// @Param id path int true "任务ID"
+// @Success 200 {object} response.Response{data=service.TaskTimeEntriesResponse} "获取成功"
+// @Failure 404 {object} response.Response "任务不存在"
+// @Router /api/v1/tasks/{id}/time-entries [get]
+// @Security BearerAuth
+func (h *TaskHandler) ListTimeEntries(c *gin.Context) {
+	taskID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的任务ID")
+		return
+	}
+
+	entries, err := h.taskService.ListTimeEntries(c.Request.Context(), uint(taskID))
+	if err != nil {
+		h.handleTimeEntryError(c, err, "获取工时记录失败")
+		return
+	}
+
+	response.Success(c, entries)
+}
+
+// DeleteTimeEntry 删除工时记录
+// @Summary 删除工时记录
+// @Description 删除自己记录的工时，已完成或已取消的任务不可删除
+// @Tags 任务管理
+// @Produce json
+// @Param id path int true "任务ID"
+// @Param entry_id path int true "工时记录ID"
+// @Success 200 {object} response.Response "删除成功"
+// @Failure 403 {object} response.Response "无权删除"
+// @Failure 404 {object} response.Response "工时记录不存在"
+// @Router /api/v1/tasks/{id}/time-entries/{entry_id} [delete]
+// @Security BearerAuth
+func (h *TaskHandler) DeleteTimeEntry(c *gin.Context) {
+	taskID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的任务ID")
+		return
+	}
+	entryID, err := strconv.ParseUint(c.Param("entry_id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的工时记录ID")
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Unauthorized(c, "用户信息缺失")
+		return
+	}
+
+	if err := h.taskService.DeleteTimeEntry(c.Request.Context(), uint(taskID), uint(entryID), userID.(uint)); err != nil {
+		h.handleTimeEntryError(c, err, "删除工时记录失败")
+		return
+	}
+
+	response.SuccessWithMessage(c, "工时记录已删除", nil)
+}
+
+// GetUserTimeSummary 获取用户周工时汇总
+// @Summary 获取用户周工时汇总
+// @Description 按任务汇总用户在指定日期所在周（周一至周日）的工时，默认本周
+// @Tags 用户管理
+// @Produce json
+// @Param id path int true "用户ID"
+// @Param week query string false "周内任意日期，格式: 2006-01-02"
+// @Success 200 {object} response.Response{data=service.UserTimeSummaryResponse} "获取成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Router /api/v1/users/{id}/time-summary [get]
+// @Security BearerAuth
+func (h *TaskHandler) GetUserTimeSummary(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "无效的用户ID")
+		return
+	}
+
+	week := time.Now()
+	if weekParam := c.Query("week"); weekParam != "" {
+		week, err = time.ParseInLocation("2006-01-02", weekParam, time.Local)
+		if err != nil {
+			response.BadRequest(c, "week参数格式错误，应为2006-01-02")
+			return
+		}
+	}
+
+	summary, err := h.taskService.GetUserTimeSummary(c.Request.Context(), uint(userID), week)
+	if err != nil {
+		logger.Errorf("获取用户工时汇总失败: %v", err)
+		response.InternalError(c, "获取用户工时汇总失败")
+		return
+	}
+
+	response.Success(c, summary)
+}
+
+// handleTimeEntryError 将工时记录相关错误映射为HTTP响应
+func (h *TaskHandler) handleTimeEntryError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidTimeEntry):
+		response.BadRequest(c, err.Error())
+	case errors.Is(err, service.ErrTimeEntryForbidden):
+		response.Forbidden(c, err.Error())
+	case repository.IsNotFoundError(err):
+		response.NotFound(c, "任务或工时记录不存在")
+	default:
+		logger.Errorf("%s: %v", message, err)
+		response.InternalError(c, message)
+	}
+}
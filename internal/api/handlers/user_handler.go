@@ -5,6 +5,7 @@ import (
 	"strconv"
 
 	"taskmanage/internal/container"
+	"taskmanage/internal/database"
 	"taskmanage/internal/service"
 	"taskmanage/pkg/response"
 
@@ -25,6 +26,19 @@ func NewUserHandler(container *container.ApplicationContainer, logger *logrus.Lo
 }
 
 // ListUsers 获取用户列表
+// @Summary 获取用户列表
+// @Description 分页获取用户列表，支持按关键词、状态和角色过滤
+// @Tags 用户管理
+// @Produce json
+// @Param page query int false "页码" default(1)
+// @Param limit query int false "每页数量" default(10)
+// @Param keyword query string false "搜索关键词"
+// @Param status query string false "用户状态"
+// @Param role query string false "角色"
+// @Success 200 {object} response.Response{data=UserListResult} "获取成功"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/users [get]
+// @Security BearerAuth
 func (h *UserHandler) ListUsers(c *gin.Context) {
 	// 获取查询参数
 	pageStr := c.DefaultQuery("page", "1")
@@ -46,27 +60,33 @@ func (h *UserHandler) ListUsers(c *gin.Context) {
 	}
 
 	userRepo := h.container.GetRepositoryManager().UserRepository()
-	users, total, err := userRepo.ListUsers(c, page, limit, conditions, keyword)
+	users, total, err := userRepo.ListUsers(c.Request.Context(), page, limit, conditions, keyword)
 	if err != nil {
 		h.logger.WithError(err).Error("获取用户列表失败")
 		response.InternalError(c, "获取用户列表失败")
 		return
 	}
 
-	// 构建响应数据
-	responseData := gin.H{
-		"users": users,
-		"pagination": gin.H{
-			"page":  page,
-			"limit": limit,
-			"total": total,
+	response.Success(c, UserListResult{
+		Users: users,
+		Pagination: UserListPagination{
+			Page:  page,
+			Limit: limit,
+			Total: total,
 		},
-	}
-
-	response.Success(c, responseData)
+	})
 }
 
 // GetUser 获取单个用户信息
+// @Summary 获取用户详情
+// @Tags 用户管理
+// @Produce json
+// @Param id path int true "用户ID"
+// @Success 200 {object} response.Response{data=database.User} "获取成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 404 {object} response.Response "用户不存在"
+// @Router /api/v1/users/{id} [get]
+// @Security BearerAuth
 func (h *UserHandler) GetUser(c *gin.Context) {
 	userID := c.Param("id")
 	if userID == "" {
@@ -82,7 +102,7 @@ func (h *UserHandler) GetUser(c *gin.Context) {
 	}
 
 	userRepo := h.container.GetRepositoryManager().UserRepository()
-	user, err := userRepo.GetByID(c, id)
+	user, err := userRepo.GetByID(c.Request.Context(), id)
 	if err != nil {
 		h.logger.WithError(err).WithField("user_id", id).Error("获取用户失败")
 		response.NotFound(c, "用户不存在")
@@ -119,7 +139,7 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 		RealName: req.RealName,
 	}
 
-	userResp, err := userService.CreateUser(c, createReq)
+	userResp, err := userService.CreateUser(c.Request.Context(), createReq)
 	if err != nil {
 		h.logger.WithError(err).Error("创建用户失败")
 		response.InternalError(c, err.Error())
@@ -131,6 +151,19 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 }
 
 // UpdateUser 更新用户信息
+// @Summary 更新用户
+// @Description 更新用户信息，只更新非空字段
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Param id path int true "用户ID"
+// @Param request body UpdateUserRequest true "更新用户请求"
+// @Success 200 {object} response.Response{data=database.User} "更新成功"
+// @Failure 400 {object} response.Response "请求参数错误或用户名、邮箱已存在"
+// @Failure 404 {object} response.Response "用户不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/users/{id} [put]
+// @Security BearerAuth
 func (h *UserHandler) UpdateUser(c *gin.Context) {
 	userID := c.Param("id")
 	if userID == "" {
@@ -146,15 +179,7 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 	}
 
 	// 绑定请求数据
-	var req struct {
-		Username string `json:"username"`
-		Email    string `json:"email"`
-		RealName string `json:"real_name"`
-		Role     string `json:"role"`
-		Phone    string `json:"phone"`
-		Avatar   string `json:"avatar"`
-		Status   string `json:"status"`
-	}
+	var req UpdateUserRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		response.BadRequest(c, "请求参数错误: "+err.Error())
@@ -164,7 +189,7 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 	userRepo := h.container.GetRepositoryManager().UserRepository()
 
 	// 获取现有用户
-	user, err := userRepo.GetByID(c, id)
+	user, err := userRepo.GetByID(c.Request.Context(), id)
 	if err != nil {
 		h.logger.WithError(err).WithField("user_id", id).Error("获取用户失败")
 		response.NotFound(c, "用户不存在")
@@ -173,7 +198,7 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 
 	// 检查用户名和邮箱唯一性（如果有更新）
 	if req.Username != "" && req.Username != user.Username {
-		if existingUser, _ := userRepo.GetByUsername(c, req.Username); existingUser != nil {
+		if existingUser, _ := userRepo.GetByUsername(c.Request.Context(), req.Username); existingUser != nil {
 			response.BadRequest(c, "用户名已存在")
 			return
 		}
@@ -181,7 +206,7 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 	}
 
 	if req.Email != "" && req.Email != user.Email {
-		if existingUser, _ := userRepo.GetByEmail(c, req.Email); existingUser != nil {
+		if existingUser, _ := userRepo.GetByEmail(c.Request.Context(), req.Email); existingUser != nil {
 			response.BadRequest(c, "邮箱已存在")
 			return
 		}
@@ -206,7 +231,7 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 	}
 
 	// 保存更新
-	if err := userRepo.Update(c, user); err != nil {
+	if err := userRepo.Update(c.Request.Context(), user); err != nil {
 		h.logger.WithError(err).WithField("user_id", id).Error("更新用户失败")
 		response.InternalError(c, "更新用户失败")
 		return
@@ -217,6 +242,15 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 }
 
 // DeleteUser 删除用户
+// @Summary 删除用户
+// @Tags 用户管理
+// @Produce json
+// @Param id path int true "用户ID"
+// @Success 200 {object} response.Response{data=MessageResult} "删除成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/users/{id} [delete]
+// @Security BearerAuth
 func (h *UserHandler) DeleteUser(c *gin.Context) {
 	userID := c.Param("id")
 	if userID == "" {
@@ -232,17 +266,30 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 	}
 
 	userRepo := h.container.GetRepositoryManager().UserRepository()
-	if err := userRepo.Delete(c, id); err != nil {
+	if err := userRepo.Delete(c.Request.Context(), id); err != nil {
 		h.logger.WithError(err).WithField("user_id", id).Error("删除用户失败")
 		response.InternalError(c, "删除用户失败")
 		return
 	}
 
 	h.logger.WithField("user_id", id).Info("用户删除成功")
-	response.Success(c, gin.H{"message": "用户删除成功"})
+	response.Success(c, MessageResult{Message: "用户删除成功"})
 }
 
 // AssignRoles 分配角色给用户
+// @Summary 分配用户角色
+// @Description 为用户追加角色并清除其权限缓存
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Param id path int true "用户ID"
+// @Param request body UserRolesRequest true "角色ID列表"
+// @Success 200 {object} response.Response{data=UserRolesResult} "分配成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 404 {object} response.Response "用户不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/users/{id}/roles [post]
+// @Security BearerAuth
 func (h *UserHandler) AssignRoles(c *gin.Context) {
 	userID := c.Param("id")
 	if userID == "" {
@@ -258,9 +305,7 @@ func (h *UserHandler) AssignRoles(c *gin.Context) {
 	}
 
 	// 绑定请求数据
-	var req struct {
-		RoleIDs []uint `json:"role_ids" binding:"required"`
-	}
+	var req UserRolesRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		response.BadRequest(c, "请求参数错误: "+err.Error())
@@ -270,7 +315,7 @@ func (h *UserHandler) AssignRoles(c *gin.Context) {
 	userRepo := h.container.GetRepositoryManager().UserRepository()
 
 	// 检查用户是否存在
-	_, err := userRepo.GetByID(c, id)
+	_, err := userRepo.GetByID(c.Request.Context(), id)
 	if err != nil {
 		h.logger.WithError(err).WithField("user_id", id).Error("获取用户失败")
 		response.NotFound(c, "用户不存在")
@@ -278,21 +323,39 @@ func (h *UserHandler) AssignRoles(c *gin.Context) {
 	}
 
 	// 分配角色
-	if err := userRepo.AssignRoles(c, id, req.RoleIDs); err != nil {
+	if err := userRepo.AssignRoles(c.Request.Context(), id, req.RoleIDs); err != nil {
 		h.logger.WithError(err).WithField("user_id", id).Error("分配角色失败")
 		response.InternalError(c, "分配角色失败")
 		return
 	}
 
+	// 角色变更后清除用户权限缓存
+	if err := h.container.GetServiceManager().PermissionService().Invalidate(c.Request.Context(), id); err != nil {
+		h.logger.WithError(err).WithField("user_id", id).Warn("清除用户权限缓存失败")
+	}
+
 	h.logger.WithField("user_id", id).Info("角色分配成功")
-	response.Success(c, gin.H{
-		"message":  "角色分配成功",
-		"user_id":  id,
-		"role_ids": req.RoleIDs,
+	response.Success(c, UserRolesResult{
+		Message: "角色分配成功",
+		UserID:  id,
+		RoleIDs: req.RoleIDs,
 	})
 }
 
 // RemoveRoles 移除用户角色
+// @Summary 移除用户角色
+// @Description 移除用户的角色并清除其权限缓存
+// @Tags 用户管理
+// @Accept json
+// @Produce json
+// @Param id path int true "用户ID"
+// @Param request body UserRolesRequest true "角色ID列表"
+// @Success 200 {object} response.Response{data=UserRolesResult} "移除成功"
+// @Failure 400 {object} response.Response "请求参数错误"
+// @Failure 404 {object} response.Response "用户不存在"
+// @Failure 500 {object} response.Response "服务器内部错误"
+// @Router /api/v1/users/{id}/roles [delete]
+// @Security BearerAuth
 func (h *UserHandler) RemoveRoles(c *gin.Context) {
 	userID := c.Param("id")
 	if userID == "" {
@@ -308,9 +371,7 @@ func (h *UserHandler) RemoveRoles(c *gin.Context) {
 	}
 
 	// 绑定请求数据
-	var req struct {
-		RoleIDs []uint `json:"role_ids" binding:"required"`
-	}
+	var req UserRolesRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		response.BadRequest(c, "请求参数错误: "+err.Error())
@@ -320,7 +381,7 @@ func (h *UserHandler) RemoveRoles(c *gin.Context) {
 	userRepo := h.container.GetRepositoryManager().UserRepository()
 
 	// 检查用户是否存在
-	_, err := userRepo.GetByID(c, id)
+	_, err := userRepo.GetByID(c.Request.Context(), id)
 	if err != nil {
 		h.logger.WithError(err).WithField("user_id", id).Error("获取用户失败")
 		response.NotFound(c, "用户不存在")
@@ -328,16 +389,57 @@ func (h *UserHandler) RemoveRoles(c *gin.Context) {
 	}
 
 	// 移除角色
-	if err := userRepo.RemoveRoles(c, id, req.RoleIDs); err != nil {
+	if err := userRepo.RemoveRoles(c.Request.Context(), id, req.RoleIDs); err != nil {
 		h.logger.WithError(err).WithField("user_id", id).Error("移除角色失败")
 		response.InternalError(c, "移除角色失败")
 		return
 	}
 
+	// 角色变更后清除用户权限缓存
+	if err := h.container.GetServiceManager().PermissionService().Invalidate(c.Request.Context(), id); err != nil {
+		h.logger.WithError(err).WithField("user_id", id).Warn("清除用户权限缓存失败")
+	}
+
 	h.logger.WithField("user_id", id).Info("角色移除成功")
-	response.Success(c, gin.H{
-		"message":  "角色移除成功",
-		"user_id":  id,
-		"role_ids": req.RoleIDs,
+	response.Success(c, UserRolesResult{
+		Message: "角色移除成功",
+		UserID:  id,
+		RoleIDs: req.RoleIDs,
 	})
 }
+
+// UpdateUserRequest 更新用户请求
+type UpdateUserRequest struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	RealName string `json:"real_name"`
+	Role     string `json:"role"`
+	Phone    string `json:"phone"`
+	Avatar   string `json:"avatar"`
+	Status   string `json:"status"`
+}
+
+// UserRolesRequest 分配或移除用户角色请求
+type UserRolesRequest struct {
+	RoleIDs []uint `json:"role_ids" binding:"required"`
+}
+
+// UserListResult 用户列表
+type UserListResult struct {
+	Users      []*database.User   `json:"users"`
+	Pagination UserListPagination `json:"pagination"`
+}
+
+// UserListPagination 用户列表分页信息
+type UserListPagination struct {
+	Page  int   `json:"page"`
+	Limit int   `json:"limit"`
+	Total int64 `json:"total"`
+}
+
+// UserRolesResult 用户角色变更结果
+type UserRolesResult struct {
+	Message string `json:"message"`
+	UserID  uint   `json:"user_id"`
+	RoleIDs []uint `json:"role_ids"`
+}
@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"taskmanage/internal/service"
+	"taskmanage/pkg/response"
+)
+
+// WebhookHandler 集成Webhook处理器
+type WebhookHandler struct {
+	webhookService service.WebhookService
+	logger         *logrus.Logger
+}
+
+// NewWebhookHandler 创建集成Webhook处理器
+func NewWebhookHandler(webhookService service.WebhookService, logger *logrus.Logger) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+		logger:         logger,
+	}
+}
+
+// ListEndpoints 获取Webhook端点
+// @Summary 获取Webhook端点
+// @Description 返回全部Webhook端点，不含签名密钥
+// @Tags 系统管理
+// @Produce json
+// @Success 200 {object} response.Response{data=[]service.WebhookEndpointResponse} "获取成功"
+// @Router /api/v1/admin/webhooks [get]
+// @Security BearerAuth
+func (h *WebhookHandler) ListEndpoints(c *gin.Context) {
+	endpoints, err := h.webhookService.ListEndpoints(c.Request.Context())
+	if err != nil {
+		response.FromError(c, err)
+		return
+	}
+
+	response.Success(c, endpoints)
+}
+
+// CreateEndpoint 创建Webhook端点
+// @Summary 创建Webhook端点
+// @Description 订阅任务、审批和入职状态事件，events为空时订阅全部事件；未指定secret时自动生成，签名密钥只在本次响应中返回。
+// @Description 事件以JSON POST到url，X-Signature头为 sha256=<请求体的HMAC-SHA256十六进制>
+// @Tags 系统管理
+// @Accept json
+// @Produce json
+// @Param request body service.CreateWebhookEndpointRequest true "Webhook端点"
+// @Success 201 {object} response.Response{data=service.WebhookEndpointResponse} "创建成功"
+// @Failure 400 {object} response.Response "端点配置不合法"
+// @Router /api/v1/admin/webhooks [post]
+// @Security BearerAuth
+func (h *WebhookHandler) CreateEndpoint(c *gin.Context) {
+	var req service.CreateWebhookEndpointRequest
+	if !response.BindAndValidate(c, &req) {
+		return
+	}
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		response.Unauthorized(c, "用户信息缺失")
+		return
+	}
+
+	endpoint, err := h.webhookService.CreateEndpoint(c.Request.Context(), userID, &req)
+	if err != nil {
+		response.FromError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.Response{
+		Code:    response.ErrCodeSuccess,
+		Message: "Webhook端点创建成功",
+		Data:    endpoint,
+	})
+}
+
+// GetEndpoint 获取Webhook端点详情
+// @Summary 获取Webhook端点详情
+// @Tags 系统管理
+// @Produce json
+// @Param id path int true "端点ID"
+// @Success 200 {object} response.Response{data=service.WebhookEndpointResponse} "获取成功"
+// @Failure 404 {object} response.Response "端点不存在"
+// @Router /api/v1/admin/webhooks/{id} [get]
+// @Security BearerAuth
+func (h *WebhookHandler) GetEndpoint(c *gin.Context) {
+	endpointID, ok := parseUintParam(c, "id", "无效的端点ID")
+	if !ok {
+		return
+	}
+
+	endpoint, err := h.webhookService.GetEndpoint(c.Request.Context(), endpointID)
+	if err != nil {
+		response.FromError(c, err)
+		return
+	}
+
+	response.Success(c, endpoint)
+}
+
+// UpdateEndpoint 更新Webhook端点
+// @Summary 更新Webhook端点
+// @Description 未传的字段保持不变；rotate_secret为true时重新生成签名密钥，新密钥只在本次响应中返回
+// @Tags 系统管理
+// @Accept json
+// @Produce json
+// @Param id path int true "端点ID"
+// @Param request body service.UpdateWebhookEndpointRequest true "Webhook端点"
+// @Success 200 {object} response.Response{data=service.WebhookEndpointResponse} "更新成功"
+// @Failure 400 {object} response.Response "端点配置不合法"
+// @Failure 404 {object} response.Response "端点不存在"
+// @Router /api/v1/admin/webhooks/{id} [put]
+// @Security BearerAuth
+func (h *WebhookHandler) UpdateEndpoint(c *gin.Context) {
+	endpointID, ok := parseUintParam(c, "id", "无效的端点ID")
+	if !ok {
+		return
+	}
+
+	var req service.UpdateWebhookEndpointRequest
+	if !response.BindAndValidate(c, &req) {
+		return
+	}
+
+	endpoint, err := h.webhookService.UpdateEndpoint(c.Request.Context(), endpointID, &req)
+	if err != nil {
+		response.FromError(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, "Webhook端点已更新", endpoint)
+}
+
+// DeleteEndpoint 删除Webhook端点
+// @Summary 删除Webhook端点
+// @Description 删除后尚未投递的事件不再发送
+// @Tags 系统管理
+// @Produce json
+// @Param id path int true "端点ID"
+// @Success 200 {object} response.Response "删除成功"
+// @Failure 404 {object} response.Response "端点不存在"
+// @Router /api/v1/admin/webhooks/{id} [delete]
+// @Security BearerAuth
+func (h *WebhookHandler) DeleteEndpoint(c *gin.Context) {
+	endpointID, ok := parseUintParam(c, "id", "无效的端点ID")
+	if !ok {
+		return
+	}
+
+	if err := h.webhookService.DeleteEndpoint(c.Request.Context(), endpointID); err != nil {
+		response.FromError(c, err)
+		return
+	}
+
+	response.SuccessWithMessage(c, "Webhook端点已删除", nil)
+}
+
+// ListDeliveries 查询Webhook投递记录
+// @Summary 查询Webhook投递记录
+// @Description 按ID倒序分页返回端点的事件投递记录，包含每次发送的响应状态码、耗时和错误，用于排查对接问题
+// @Tags 系统管理
+// @Produce json
+// @Param id path int true "端点ID"
+// @Param status query string false "状态: pending, delivered, failed"
+// @Param event_type query string false "事件类型"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} response.Response{data=service.ListResponse[service.WebhookDeliveryResponse]} "获取成功"
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response "端点不存在"
+// @Router /api/v1/admin/webhooks/{id}/deliveries [get]
+// @Security BearerAuth
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	endpointID, ok := parseUintParam(c, "id", "无效的端点ID")
+	if !ok {
+		return
+	}
+
+	var req service.WebhookDeliveryListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.BindError(c, err)
+		return
+	}
+
+	result, err := h.webhookService.ListDeliveries(c.Request.Context(), endpointID, &req)
+	if err != nil {
+		response.FromError(c, err)
+		return
+	}
+	response.Success(c, result)
+}
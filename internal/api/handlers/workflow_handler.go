@@ -1,9 +1,16 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 
+	"taskmanage/internal/repository"
 	"taskmanage/internal/service"
 	"taskmanage/internal/workflow"
 	"taskmanage/pkg/response"
@@ -11,31 +18,58 @@ import (
 
 // WorkflowHandler 工作流处理器
 type WorkflowHandler struct {
-	workflowService service.WorkflowService
-	logger          *logrus.Logger
+	workflowService   service.WorkflowService
+	permissionService service.PermissionService
+	logger            *logrus.Logger
 }
 
 // NewWorkflowHandler 创建工作流处理器
-func NewWorkflowHandler(workflowService service.WorkflowService, logger *logrus.Logger) *WorkflowHandler {
+func NewWorkflowHandler(workflowService service.WorkflowService, permissionService service.PermissionService, logger *logrus.Logger) *WorkflowHandler {
 	return &WorkflowHandler{
-		workflowService: workflowService,
-		logger:          logger,
+		workflowService:   workflowService,
+		permissionService: permissionService,
+		logger:            logger,
+	}
+}
+
+// 重复启动审批流程的处理方式，由查询参数on_duplicate指定
+const (
+	onDuplicateReturn   = "return"   // 返回已在运行的流程，状态码200
+	onDuplicateConflict = "conflict" // 返回409和已在运行的流程实例ID
+)
+
+// parseOnDuplicate 解析on_duplicate查询参数，未指定时返回已在运行的流程
+func parseOnDuplicate(c *gin.Context) (string, bool) {
+	switch mode := c.DefaultQuery("on_duplicate", onDuplicateReturn); mode {
+	case onDuplicateReturn, onDuplicateConflict:
+		return mode, true
+	default:
+		return "", false
 	}
 }
 
 // StartTaskAssignmentApproval 启动任务分配审批流程
 // @Summary 启动任务分配审批流程
-// @Description 为任务分配启动审批流程
+// @Description 为任务分配启动审批流程，成功启动返回201。同一任务已有运行中的审批流程时不会重复启动：默认返回200和已在运行的流程，on_duplicate=conflict时返回409和该流程实例ID
 // @Tags workflow
 // @Accept json
 // @Produce json
 // @Param request body workflow.TaskAssignmentApprovalRequest true "审批请求"
-// @Success 200 {object} response.Response{data=workflow.WorkflowInstance}
-// @Failure 400 {object} response.Response
+// @Param on_duplicate query string false "已有运行中的审批流程时的处理方式" Enums(return, conflict) default(return)
+// @Success 201 {object} response.Response{data=workflow.WorkflowInstance} "启动成功"
+// @Success 200 {object} response.Response{data=workflow.WorkflowInstance} "任务已有运行中的审批流程"
+// @Failure 400 {object} response.Response{details=[]workflow.VariableFieldError} "请求参数错误，流程变量不符合变量声明时details逐项列出"
 // @Failure 401 {object} response.Response
+// @Failure 409 {object} response.Response{data=RunningInstanceResult} "任务已有运行中的审批流程"
 // @Failure 500 {object} response.Response
 // @Router /api/v1/workflows/task-assignment/start [post]
 func (h *WorkflowHandler) StartTaskAssignmentApproval(c *gin.Context) {
+	onDuplicate, ok := parseOnDuplicate(c)
+	if !ok {
+		response.BadRequest(c, "on_duplicate只能为return或conflict")
+		return
+	}
+
 	var req workflow.TaskAssignmentApprovalRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		h.logger.WithError(err).Error("解析请求参数失败")
@@ -52,18 +86,40 @@ func (h *WorkflowHandler) StartTaskAssignmentApproval(c *gin.Context) {
 	req.RequesterID = userID.(uint)
 
 	instance, err := h.workflowService.StartTaskAssignmentApproval(c.Request.Context(), &req)
+	var running *workflow.RunningInstanceError
+	if errors.As(err, &running) {
+		if onDuplicate == onDuplicateConflict {
+			response.ConflictWithData(c, "任务已有运行中的审批流程", RunningInstanceResult{InstanceID: running.Instance.ID})
+			return
+		}
+		response.SuccessWithMessage(c, "任务已有运行中的审批流程", running.Instance)
+		return
+	}
+	var invalidVariables *workflow.VariableValidationError
+	if errors.As(err, &invalidVariables) {
+		response.ValidationError(c, invalidVariables.Fields)
+		return
+	}
+	if errors.Is(err, workflow.ErrInvalidVariable) {
+		response.BadRequest(c, err.Error())
+		return
+	}
 	if err != nil {
 		h.logger.WithError(err).Error("启动任务分配审批流程失败")
 		response.InternalError(c, "启动审批流程失败")
 		return
 	}
 
-	response.SuccessWithMessage(c, "审批流程启动成功", instance)
+	c.JSON(http.StatusCreated, response.Response{
+		Code:    response.ErrCodeSuccess,
+		Message: "审批流程启动成功",
+		Data:    instance,
+	})
 }
 
 // ProcessApproval 处理审批决策
 // @Summary 处理审批决策
-// @Description 处理审批决策（同意/拒绝/退回）
+// @Description 处理审批决策（同意/拒绝/退回/委托）。退回需节点配置允许，return_to须为允许的上游节点且必须填写comment；退回到开始节点后由发起人以resubmit重新提交。attachment_ids须为审批人上传或属于流程关联任务的附件，mentions最多10个用户，被提及的用户收到通知；节点配置require_comment_on_reject时拒绝必须填写comment。同一实例的审批串行处理，节点已由其他审批人处理出结果时返回409 APPROVAL_ALREADY_PROCESSED
 // @Tags workflow
 // @Accept json
 // @Produce json
@@ -71,6 +127,7 @@ func (h *WorkflowHandler) StartTaskAssignmentApproval(c *gin.Context) {
 // @Success 200 {object} response.Response{data=workflow.ApprovalResult}
 // @Failure 400 {object} response.Response
 // @Failure 401 {object} response.Response
+// @Failure 409 {object} response.Response "该审批已被他人处理"
 // @Failure 500 {object} response.Response
 // @Router /api/v1/workflows/approvals/process [post]
 func (h *WorkflowHandler) ProcessApproval(c *gin.Context) {
@@ -91,6 +148,14 @@ func (h *WorkflowHandler) ProcessApproval(c *gin.Context) {
 
 	result, err := h.workflowService.ProcessTaskAssignmentApproval(c.Request.Context(), &req)
 	if err != nil {
+		if errors.Is(err, workflow.ErrInvalidReturn) || errors.Is(err, workflow.ErrInvalidResubmission) || errors.Is(err, workflow.ErrInvalidApprovalComment) {
+			response.BadRequest(c, err.Error())
+			return
+		}
+		if errors.Is(err, workflow.ErrApprovalAlreadyProcessed) {
+			response.ErrorWithCode(c, response.ErrCodeApprovalAlreadyProcessed, err.Error())
+			return
+		}
 		h.logger.WithError(err).Error("处理审批决策失败")
 		response.InternalError(c, "处理审批失败")
 		return
@@ -99,9 +164,47 @@ func (h *WorkflowHandler) ProcessApproval(c *gin.Context) {
 	response.SuccessWithMessage(c, "审批处理成功", result)
 }
 
+// BatchProcessApprovals 批量处理审批决策
+// @Summary 批量处理审批决策
+// @Description 一次处理多条分配给当前用户的审批，逐条返回处理结果，单条失败不影响其它条目
+// @Tags workflow
+// @Accept json
+// @Produce json
+// @Param request body service.BatchApprovalRequest true "批量审批请求"
+// @Success 200 {object} response.Response{data=service.BatchApprovalResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/workflows/approvals/batch [post]
+func (h *WorkflowHandler) BatchProcessApprovals(c *gin.Context) {
+	var req service.BatchApprovalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("解析请求参数失败")
+		response.BadRequest(c, fmt.Sprintf("请求参数格式错误，单次最多处理%d条审批", service.MaxBatchApprovalItems))
+		return
+	}
+
+	// 获取当前用户ID
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Unauthorized(c, "用户未认证")
+		return
+	}
+	req.ApproverID = userID.(uint)
+
+	result, err := h.workflowService.ProcessApprovalsBatch(c.Request.Context(), &req)
+	if err != nil {
+		h.logger.WithError(err).Error("批量处理审批失败")
+		response.InternalError(c, "批量处理审批失败")
+		return
+	}
+
+	response.SuccessWithMessage(c, "批量审批处理完成", result)
+}
+
 // GetWorkflowInstance 获取流程实例
 // @Summary 获取流程实例
-// @Description 根据实例ID获取流程实例详情
+// @Description 根据实例ID获取流程实例详情。流程定义声明为敏感（sensitive）的变量，只有拥有workflow:view_sensitive权限的用户和当前节点的审批人能看到原始值，其他用户看到的是"******"，执行历史中的变量同样处理
 // @Tags workflow
 // @Accept json
 // @Produce json
@@ -119,7 +222,20 @@ func (h *WorkflowHandler) GetWorkflowInstance(c *gin.Context) {
 		return
 	}
 
-	instance, err := h.workflowService.GetWorkflowInstance(c.Request.Context(), instanceID)
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		response.Unauthorized(c, "用户信息缺失")
+		return
+	}
+	viewer := workflow.VariableViewer{UserID: userID}
+	viewer.CanViewSensitive, err = h.permissionService.HasPermission(c.Request.Context(), userID, workflow.SensitiveVariableResource, workflow.SensitiveVariableAction)
+	if err != nil {
+		h.logger.WithError(err).Error("检查敏感变量查看权限失败")
+		response.InternalError(c, "权限检查失败")
+		return
+	}
+
+	instance, err := h.workflowService.GetWorkflowInstanceForViewer(c.Request.Context(), instanceID, viewer)
 	if err != nil {
 		h.logger.WithError(err).Error("获取流程实例失败")
 		response.InternalError(c, "获取流程实例失败")
@@ -129,32 +245,137 @@ func (h *WorkflowHandler) GetWorkflowInstance(c *gin.Context) {
 	response.SuccessWithMessage(c, "获取流程实例成功", instance)
 }
 
+// ListWorkflowInstances 获取流程实例列表
+// @Summary 获取流程实例列表
+// @Description 按状态、流程、业务对象、发起人和启动时间分页获取流程实例摘要，按启动时间倒序，不返回流程变量，默认不含已归档的实例；status=expired 返回超过SLA或节点超时被终止的实例
+// @Tags workflow
+// @Accept json
+// @Produce json
+// @Param status query string false "实例状态: running、completed、cancelled、failed、suspended、expired"
+// @Param workflow_id query string false "流程定义ID"
+// @Param workflow_name query string false "流程名称，模糊匹配"
+// @Param business_type query string false "业务类型"
+// @Param business_id query string false "业务对象ID"
+// @Param started_by query int false "发起人用户ID"
+// @Param started_from query string false "启动时间不早于，RFC3339或2006-01-02"
+// @Param started_to query string false "启动时间早于，RFC3339或2006-01-02（只传日期时包含当天）"
+// @Param include_archived query bool false "同时返回已归档的实例，默认false"
+// @Param page query int false "页码，默认1"
+// @Param page_size query int false "每页条数，默认20，最大100"
+// @Success 200 {object} response.PaginationResponse{data=[]workflow.InstanceSummary}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/workflows/instances [get]
+func (h *WorkflowHandler) ListWorkflowInstances(c *gin.Context) {
+	filter, ok := h.bindInstanceFilter(c)
+	if !ok {
+		return
+	}
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PageSize < 1 {
+		filter.PageSize = 20
+	}
+	if filter.PageSize > 100 {
+		filter.PageSize = 100
+	}
+
+	instances, total, err := h.workflowService.ListWorkflowInstances(c.Request.Context(), filter)
+	if err != nil {
+		h.handleInstanceQueryError(c, err, "获取流程实例列表失败")
+		return
+	}
+
+	response.SuccessWithPagination(c, instances, filter.Page, filter.PageSize, total)
+}
+
+// GetWorkflowInstanceStats 获取流程实例统计
+// @Summary 获取流程实例统计
+// @Description 按状态和流程分组统计流程实例数，过滤参数与流程实例列表相同
+// @Tags workflow
+// @Produce json
+// @Param status query string false "实例状态"
+// @Param workflow_id query string false "流程定义ID"
+// @Param workflow_name query string false "流程名称，模糊匹配"
+// @Param business_type query string false "业务类型"
+// @Param business_id query string false "业务对象ID"
+// @Param started_by query int false "发起人用户ID"
+// @Param started_from query string false "启动时间不早于，RFC3339或2006-01-02"
+// @Param started_to query string false "启动时间早于，RFC3339或2006-01-02（只传日期时包含当天）"
+// @Param include_archived query bool false "同时统计已归档的实例，默认false"
+// @Success 200 {object} response.Response{data=workflow.InstanceStats}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/workflows/instances/stats [get]
+func (h *WorkflowHandler) GetWorkflowInstanceStats(c *gin.Context) {
+	filter, ok := h.bindInstanceFilter(c)
+	if !ok {
+		return
+	}
+
+	stats, err := h.workflowService.GetWorkflowInstanceStats(c.Request.Context(), filter)
+	if err != nil {
+		h.handleInstanceQueryError(c, err, "获取流程实例统计失败")
+		return
+	}
+
+	response.Success(c, stats)
+}
+
 // GetPendingApprovals 获取待审批任务
 // @Summary 获取待审批任务
-// @Description 获取当前用户的待审批任务列表
+// @Description 分页获取当前用户的待审批任务列表，默认第1页、每页20条，按优先级降序排列。/api/v1/me/approvals是不需要审批权限的别名
+// @Description sort_by=urgency时已逾期的记录排在最前，其余按优先级降序、截止时间升序、创建时间升序；截止时间已过的记录overdue为true。
+// @Description 传group_by时data为分组名到分组的映射，分组的count为该分组全部记录数，items只包含当前页的记录，未指定sort_by时按urgency排序。
+// @Description summary统计全部符合条件的记录，不受分页影响
 // @Tags workflow
 // @Accept json
 // @Produce json
-// @Success 200 {object} response.Response{data=[]workflow.PendingApproval}
+// @Param page query int false "页码，默认1"
+// @Param page_size query int false "每页条数，默认20，最大100"
+// @Param sort_by query string false "排序字段: created_at、priority 或 urgency"
+// @Param sort_order query string false "排序方向: asc 或 desc，urgency排序时忽略"
+// @Param group_by query string false "分组字段: workflow_name 或 business_type"
+// @Param workflow_name query string false "流程名称"
+// @Param business_type query string false "业务类型"
+// @Param deadline_before query string false "截止时间早于，RFC3339或2006-01-02"
+// @Success 200 {object} PendingApprovalListResponse{data=[]workflow.PendingApproval}
+// @Failure 400 {object} response.Response
 // @Failure 401 {object} response.Response
 // @Failure 500 {object} response.Response
 // @Router /api/v1/workflows/approvals/pending [get]
+// @Router /api/v1/me/approvals [get]
 func (h *WorkflowHandler) GetPendingApprovals(c *gin.Context) {
-	// 获取当前用户ID
-	userID, exists := c.Get("user_id")
-	if !exists {
-		response.Unauthorized(c, "用户未认证")
+	filter, ok := h.bindPendingApprovalFilter(c)
+	if !ok {
 		return
 	}
 
-	approvals, err := h.workflowService.GetPendingApprovals(c.Request.Context(), userID.(uint))
+	if filter.GroupBy != "" && filter.SortBy == "" {
+		filter.SortBy = workflow.PendingSortUrgency
+	}
+	approvals, total, err := h.workflowService.GetPendingApprovals(c.Request.Context(), filter)
 	if err != nil {
-		h.logger.WithError(err).Error("获取待审批任务失败")
-		response.InternalError(c, "获取待审批任务失败")
+		h.handlePendingApprovalError(c, err, "获取待审批任务失败")
+		return
+	}
+	summary, err := h.workflowService.SummarizePendingApprovals(c.Request.Context(), filter)
+	if err != nil {
+		h.handlePendingApprovalError(c, err, "获取待审批任务失败")
 		return
 	}
 
-	response.SuccessWithMessage(c, "获取待审批任务成功", approvals)
+	var data interface{} = approvals
+	if filter.GroupBy != "" {
+		data = summary.Group(approvals, filter.GroupBy)
+	}
+	c.JSON(http.StatusOK, PendingApprovalListResponse{
+		PaginationResponse: response.NewPaginationResponse(c, data, filter.Page, filter.PageSize, total),
+		Summary:            summary,
+	})
 }
 
 // CancelWorkflow 取消流程
@@ -194,16 +415,57 @@ func (h *WorkflowHandler) CancelWorkflow(c *gin.Context) {
 	response.SuccessWithMessage(c, "流程取消成功", nil)
 }
 
+// RetryCompletion 重试流程结束业务回调
+// @Summary 重试流程结束业务回调
+// @Description 重新执行失败的业务回调（如审批通过后完成任务分配）
+// @Tags workflow
+// @Accept json
+// @Produce json
+// @Param instance_id path string true "实例ID"
+// @Success 200 {object} response.Response{data=workflow.CompletionRecord}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/workflows/instances/{instance_id}/retry-completion [post]
+func (h *WorkflowHandler) RetryCompletion(c *gin.Context) {
+	instanceID := c.Param("instance_id")
+	if instanceID == "" {
+		response.BadRequest(c, "实例ID不能为空")
+		return
+	}
+
+	record, err := h.workflowService.RetryCompletion(c.Request.Context(), instanceID)
+	if err != nil {
+		if errors.Is(err, workflow.ErrCompletionNotRetryable) {
+			response.Conflict(c, err.Error())
+			return
+		}
+		h.logger.WithError(err).Error("重试业务回调失败")
+		response.InternalError(c, "重试业务回调失败")
+		return
+	}
+
+	if record.Status == workflow.CompletionFailed {
+		response.SuccessWithMessage(c, "业务回调仍然失败", record)
+		return
+	}
+	response.SuccessWithMessage(c, "业务回调执行成功", record)
+}
+
 // GetWorkflowHistory 获取流程历史
 // @Summary 获取流程历史
-// @Description 获取指定流程实例的执行历史
+// @Description 分页获取指定流程实例的执行历史，按执行时间升序。节点名称取自实例绑定的流程定义版本（节点已删除时返回节点ID），执行人附带姓名和头像，并给出距上一步的秒数以及是否为委托表决或超时终止；原始节点ID和用户ID保留在返回中
 // @Tags workflow
 // @Accept json
 // @Produce json
 // @Param instance_id path string true "实例ID"
-// @Success 200 {object} response.Response{data=[]workflow.ExecutionHistory}
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量，最大100" default(20)
+// @Success 200 {object} response.Response{data=service.ListResponse[service.WorkflowHistoryEntry]}
 // @Failure 400 {object} response.Response
 // @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
 // @Failure 500 {object} response.Response
 // @Router /api/v1/workflows/instances/{instance_id}/history [get]
 func (h *WorkflowHandler) GetWorkflowHistory(c *gin.Context) {
@@ -212,9 +474,15 @@ func (h *WorkflowHandler) GetWorkflowHistory(c *gin.Context) {
 		response.BadRequest(c, "实例ID不能为空")
 		return
 	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
 
-	history, err := h.workflowService.GetWorkflowHistory(c.Request.Context(), instanceID)
+	history, err := h.workflowService.ListWorkflowHistory(c.Request.Context(), instanceID, page, pageSize)
 	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			response.NotFound(c, "流程实例不存在")
+			return
+		}
 		h.logger.WithError(err).Error("获取流程历史失败")
 		response.InternalError(c, "获取流程历史失败")
 		return
@@ -223,33 +491,75 @@ func (h *WorkflowHandler) GetWorkflowHistory(c *gin.Context) {
 	response.SuccessWithMessage(c, "获取流程历史成功", history)
 }
 
+// VerifyWorkflowHistory 校验流程历史哈希链
+// @Summary 校验流程历史哈希链
+// @Description 按写入顺序逐条校验执行历史的哈希链，valid为false时first_broken给出第一处断链的记录和原因
+// @Tags workflow
+// @Accept json
+// @Produce json
+// @Param instance_id path string true "实例ID"
+// @Success 200 {object} response.Response{data=workflow.HistoryVerification}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/workflows/instances/{instance_id}/history/verify [get]
+func (h *WorkflowHandler) VerifyWorkflowHistory(c *gin.Context) {
+	instanceID := c.Param("instance_id")
+	if instanceID == "" {
+		response.BadRequest(c, "实例ID不能为空")
+		return
+	}
+
+	verification, err := h.workflowService.VerifyWorkflowHistory(c.Request.Context(), instanceID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			response.NotFound(c, "流程实例不存在")
+			return
+		}
+		h.logger.WithError(err).Error("校验流程历史失败")
+		response.InternalError(c, "校验流程历史失败")
+		return
+	}
+
+	if !verification.Valid {
+		h.logger.WithFields(logrus.Fields{
+			"instance_id": instanceID,
+			"history_id":  verification.FirstBroken.HistoryID,
+			"reason":      verification.FirstBroken.Reason,
+		}).Warn("流程历史哈希链断裂")
+	}
+	response.SuccessWithMessage(c, "校验流程历史完成", verification)
+}
+
 // GetApprovalCount 获取待审批数量
 // @Summary 获取待审批数量
-// @Description 获取当前用户的待审批任务数量
+// @Description 获取当前用户的待审批任务数量，支持与待审批列表相同的过滤条件
 // @Tags workflow
 // @Accept json
 // @Produce json
+// @Param workflow_name query string false "流程名称"
+// @Param business_type query string false "业务类型"
+// @Param deadline_before query string false "截止时间早于，RFC3339或2006-01-02"
 // @Success 200 {object} response.Response{data=ApprovalCountResponse}
+// @Failure 400 {object} response.Response
 // @Failure 401 {object} response.Response
 // @Failure 500 {object} response.Response
 // @Router /api/v1/workflows/approvals/count [get]
 func (h *WorkflowHandler) GetApprovalCount(c *gin.Context) {
-	// 获取当前用户ID
-	userID, exists := c.Get("user_id")
-	if !exists {
-		response.Unauthorized(c, "用户未认证")
+	filter, ok := h.bindPendingApprovalFilter(c)
+	if !ok {
 		return
 	}
 
-	approvals, err := h.workflowService.GetPendingApprovals(c.Request.Context(), userID.(uint))
+	count, err := h.workflowService.CountPendingApprovals(c.Request.Context(), filter)
 	if err != nil {
-		h.logger.WithError(err).Error("获取待审批任务失败")
-		response.InternalError(c, "获取待审批任务失败")
+		h.handlePendingApprovalError(c, err, "获取待审批数量失败")
 		return
 	}
 
 	countResp := ApprovalCountResponse{
-		Total: len(approvals),
+		Total: count,
 	}
 
 	response.SuccessWithMessage(c, "获取待审批数量成功", countResp)
@@ -404,9 +714,111 @@ func (h *WorkflowHandler) DeleteWorkflowDefinition(c *gin.Context) {
 	response.SuccessWithMessage(c, "工作流定义删除成功", nil)
 }
 
+// ListWorkflowDefinitionVersions 获取工作流定义版本列表
+// @Summary 获取工作流定义版本列表
+// @Description 获取指定工作流定义的所有版本，按版本号倒序
+// @Tags workflow
+// @Accept json
+// @Produce json
+// @Param id path string true "工作流定义ID"
+// @Success 200 {object} response.Response{data=[]workflow.WorkflowDefinition}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/workflows/definitions/{id}/versions [get]
+func (h *WorkflowHandler) ListWorkflowDefinitionVersions(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		response.BadRequest(c, "工作流定义ID不能为空")
+		return
+	}
+
+	versions, err := h.workflowService.ListWorkflowDefinitionVersions(c.Request.Context(), id)
+	if err != nil {
+		h.logger.WithError(err).Error("获取工作流定义版本列表失败")
+		response.InternalError(c, "获取工作流定义版本列表失败")
+		return
+	}
+
+	response.SuccessWithMessage(c, "获取工作流定义版本列表成功", versions)
+}
+
+// ActivateWorkflowDefinitionVersion 激活工作流定义版本
+// @Summary 激活工作流定义版本
+// @Description 激活指定版本，新启动的流程实例将使用该版本
+// @Tags workflow
+// @Accept json
+// @Produce json
+// @Param id path string true "工作流定义ID"
+// @Param version_id path int true "版本ID"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/workflows/definitions/{id}/versions/{version_id}/activate [post]
+func (h *WorkflowHandler) ActivateWorkflowDefinitionVersion(c *gin.Context) {
+	id, versionID, ok := h.parseVersionParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.workflowService.ActivateWorkflowDefinitionVersion(c.Request.Context(), id, versionID); err != nil {
+		h.logger.WithError(err).Error("激活工作流定义版本失败")
+		response.InternalError(c, "激活工作流定义版本失败")
+		return
+	}
+
+	response.SuccessWithMessage(c, "工作流定义版本激活成功", nil)
+}
+
+// DeactivateWorkflowDefinitionVersion 停用工作流定义版本
+// @Summary 停用工作流定义版本
+// @Description 停用指定版本，已运行的流程实例不受影响
+// @Tags workflow
+// @Accept json
+// @Produce json
+// @Param id path string true "工作流定义ID"
+// @Param version_id path int true "版本ID"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /api/v1/workflows/definitions/{id}/versions/{version_id}/deactivate [post]
+func (h *WorkflowHandler) DeactivateWorkflowDefinitionVersion(c *gin.Context) {
+	id, versionID, ok := h.parseVersionParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.workflowService.DeactivateWorkflowDefinitionVersion(c.Request.Context(), id, versionID); err != nil {
+		h.logger.WithError(err).Error("停用工作流定义版本失败")
+		response.InternalError(c, "停用工作流定义版本失败")
+		return
+	}
+
+	response.SuccessWithMessage(c, "工作流定义版本停用成功", nil)
+}
+
+// parseVersionParams 解析工作流定义ID和版本ID路径参数
+func (h *WorkflowHandler) parseVersionParams(c *gin.Context) (string, uint, bool) {
+	id := c.Param("id")
+	if id == "" {
+		response.BadRequest(c, "工作流定义ID不能为空")
+		return "", 0, false
+	}
+
+	versionID, err := strconv.ParseUint(c.Param("version_id"), 10, 32)
+	if err != nil {
+		response.BadRequest(c, "版本ID格式错误")
+		return "", 0, false
+	}
+
+	return id, uint(versionID), true
+}
+
 // ValidateWorkflowDefinition 验证工作流定义
 // @Summary 验证工作流定义
-// @Description 验证工作流定义的有效性
+// @Description 返回流程设计器使用的诊断列表：结构错误、多个开始节点、不可达节点、没有出边的非结束节点、不经过审批节点的循环、引用未声明的变量，以及审批人当前为空的审批节点。error级别的诊断项会阻止保存，warning不影响保存
 // @Tags workflow
 // @Accept json
 // @Produce json
@@ -424,12 +836,24 @@ func (h *WorkflowHandler) ValidateWorkflowDefinition(c *gin.Context) {
 		return
 	}
 
-	err := h.workflowService.ValidateWorkflowDefinition(c.Request.Context(), &req)
-	result := ValidationResult{
-		Valid: err == nil,
-	}
+	diagnostics, err := h.workflowService.ValidateWorkflowDefinition(c.Request.Context(), &req)
 	if err != nil {
-		result.Error = err.Error()
+		h.logger.WithError(err).Error("验证工作流定义失败")
+		response.InternalError(c, "验证工作流定义失败")
+		return
+	}
+
+	result := ValidationResult{Valid: true, Diagnostics: diagnostics}
+	if result.Diagnostics == nil {
+		result.Diagnostics = []workflow.Diagnostic{}
+	}
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Severity == workflow.DiagnosticError {
+			if result.Valid {
+				result.Error = diagnostic.Message
+			}
+			result.Valid = false
+		}
 	}
 
 	response.SuccessWithMessage(c, "工作流定义验证完成", result)
@@ -442,39 +866,197 @@ type CancelWorkflowRequest struct {
 
 // ApprovalCountResponse 待审批数量响应
 type ApprovalCountResponse struct {
-	Total int `json:"total"`
+	Total int64 `json:"total"`
+}
+
+// PendingApprovalListResponse 待审批列表响应，在分页响应之外附带全部符合条件记录的汇总
+type PendingApprovalListResponse struct {
+	response.PaginationResponse
+	Summary *workflow.PendingApprovalSummary `json:"summary"`
+}
+
+// PendingApprovalQuery 待审批列表查询参数
+type PendingApprovalQuery struct {
+	Page           int    `form:"page"`
+	PageSize       int    `form:"page_size"`
+	SortBy         string `form:"sort_by"`
+	SortOrder      string `form:"sort_order"`
+	GroupBy        string `form:"group_by"`
+	WorkflowName   string `form:"workflow_name"`
+	BusinessType   string `form:"business_type"`
+	DeadlineBefore string `form:"deadline_before"` // RFC3339 或 2006-01-02
+}
+
+// WorkflowInstanceQuery 流程实例列表查询参数
+type WorkflowInstanceQuery struct {
+	Status          string `form:"status"`
+	WorkflowID      string `form:"workflow_id"`
+	WorkflowName    string `form:"workflow_name"`
+	BusinessType    string `form:"business_type"`
+	BusinessID      string `form:"business_id"`
+	StartedBy       uint   `form:"started_by"`
+	StartedFrom     string `form:"started_from"` // RFC3339 或 2006-01-02
+	StartedTo       string `form:"started_to"`   // RFC3339 或 2006-01-02
+	IncludeArchived bool   `form:"include_archived"`
+	Page            int    `form:"page"`
+	PageSize        int    `form:"page_size"`
 }
 
 // GetPendingTaskAssignmentApprovals 获取待审批的任务分配
 // @Summary 获取待审批的任务分配
-// @Description 获取当前用户待审批的任务分配列表
+// @Description 分页获取当前用户待审批的任务分配列表，参数与待审批任务列表相同，business_type固定为task_assignment
 // @Tags workflow
 // @Accept json
 // @Produce json
-// @Success 200 {object} response.Response{data=[]workflow.PendingApproval}
+// @Param page query int false "页码，默认1"
+// @Param page_size query int false "每页条数，默认20，最大100"
+// @Param sort_by query string false "排序字段: created_at 或 priority"
+// @Param sort_order query string false "排序方向: asc 或 desc"
+// @Param workflow_name query string false "流程名称"
+// @Param deadline_before query string false "截止时间早于，RFC3339或2006-01-02"
+// @Success 200 {object} response.PaginationResponse{data=[]workflow.PendingApproval}
+// @Failure 400 {object} response.Response
 // @Failure 401 {object} response.Response
 // @Failure 500 {object} response.Response
 // @Router /api/v1/workflows/approvals/task-assignments [get]
 func (h *WorkflowHandler) GetPendingTaskAssignmentApprovals(c *gin.Context) {
-	// 从JWT中获取用户ID
-	userID, exists := c.Get("user_id")
-	if !exists {
-		response.Unauthorized(c, "用户信息缺失")
+	filter, ok := h.bindPendingApprovalFilter(c)
+	if !ok {
 		return
 	}
 
-	approvals, err := h.workflowService.GetPendingTaskAssignmentApprovals(c.Request.Context(), userID.(uint))
+	approvals, total, err := h.workflowService.GetPendingTaskAssignmentApprovals(c.Request.Context(), filter)
 	if err != nil {
-		h.logger.WithError(err).Error("获取待审批任务分配列表失败")
-		response.InternalError(c, "获取待审批任务分配列表失败")
+		h.handlePendingApprovalError(c, err, "获取待审批任务分配列表失败")
+		return
+	}
+
+	response.SuccessWithPagination(c, approvals, filter.Page, filter.PageSize, total)
+}
+
+// bindPendingApprovalFilter 解析待审批查询参数，未传分页参数时默认第1页、每页20条
+func (h *WorkflowHandler) bindPendingApprovalFilter(c *gin.Context) (workflow.PendingApprovalFilter, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Unauthorized(c, "用户未认证")
+		return workflow.PendingApprovalFilter{}, false
+	}
+
+	var query PendingApprovalQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.BadRequest(c, "请求参数格式错误")
+		return workflow.PendingApprovalFilter{}, false
+	}
+
+	filter := workflow.PendingApprovalFilter{
+		UserID:       userID.(uint),
+		WorkflowName: query.WorkflowName,
+		BusinessType: query.BusinessType,
+		SortBy:       query.SortBy,
+		SortOrder:    query.SortOrder,
+		GroupBy:      query.GroupBy,
+		Page:         query.Page,
+		PageSize:     query.PageSize,
+		Now:          time.Now(),
+	}
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PageSize < 1 {
+		filter.PageSize = 20
+	}
+	if filter.PageSize > 100 {
+		filter.PageSize = 100
+	}
+	if query.DeadlineBefore != "" {
+		deadline, err := parseDeadline(query.DeadlineBefore)
+		if err != nil {
+			response.BadRequest(c, "deadline_before格式应为RFC3339或2006-01-02")
+			return workflow.PendingApprovalFilter{}, false
+		}
+		filter.DeadlineBefore = &deadline
+	}
+	if err := filter.Validate(); err != nil {
+		response.BadRequest(c, err.Error())
+		return workflow.PendingApprovalFilter{}, false
+	}
+
+	return filter, true
+}
+
+// bindInstanceFilter 解析流程实例查询参数，分页参数原样返回
+func (h *WorkflowHandler) bindInstanceFilter(c *gin.Context) (workflow.InstanceFilter, bool) {
+	var query WorkflowInstanceQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.BindError(c, err)
+		return workflow.InstanceFilter{}, false
+	}
+
+	filter := workflow.InstanceFilter{
+		Status:          workflow.InstanceStatus(query.Status),
+		WorkflowID:      query.WorkflowID,
+		WorkflowName:    query.WorkflowName,
+		BusinessType:    query.BusinessType,
+		BusinessID:      query.BusinessID,
+		StartedBy:       query.StartedBy,
+		IncludeArchived: query.IncludeArchived,
+		Page:            query.Page,
+		PageSize:        query.PageSize,
+	}
+	if query.StartedFrom != "" {
+		startedFrom, err := parseDeadline(query.StartedFrom)
+		if err != nil {
+			response.BadRequest(c, "started_from格式应为RFC3339或2006-01-02")
+			return workflow.InstanceFilter{}, false
+		}
+		filter.StartedFrom = &startedFrom
+	}
+	if query.StartedTo != "" {
+		startedTo, err := parseDeadline(query.StartedTo)
+		if err != nil {
+			response.BadRequest(c, "started_to格式应为RFC3339或2006-01-02")
+			return workflow.InstanceFilter{}, false
+		}
+		// 只传日期时包含当天
+		if len(query.StartedTo) == len("2006-01-02") {
+			startedTo = startedTo.AddDate(0, 0, 1)
+		}
+		filter.StartedTo = &startedTo
+	}
+	return filter, true
+}
+
+// handleInstanceQueryError 将流程实例查询错误映射为HTTP响应
+func (h *WorkflowHandler) handleInstanceQueryError(c *gin.Context, err error, message string) {
+	if errors.Is(err, workflow.ErrInvalidInstanceFilter) {
+		response.BadRequest(c, err.Error())
 		return
 	}
+	h.logger.WithError(err).Error(message)
+	response.InternalError(c, message)
+}
 
-	response.SuccessWithMessage(c, "获取待审批任务分配列表成功", approvals)
+// parseDeadline 解析RFC3339时间或按服务器时区解析日期
+func parseDeadline(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02", value, time.Local)
+}
+
+// handlePendingApprovalError 将待审批查询错误映射为HTTP响应
+func (h *WorkflowHandler) handlePendingApprovalError(c *gin.Context, err error, message string) {
+	if errors.Is(err, workflow.ErrInvalidPendingApprovalFilter) {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	h.logger.WithError(err).Error(message)
+	response.InternalError(c, message)
 }
 
 // ValidationResult 验证结果
 type ValidationResult struct {
-	Valid bool   `json:"valid"`
-	Error string `json:"error,omitempty"`
+	Valid       bool                  `json:"valid"`           // 没有error级别的诊断项
+	Error       string                `json:"error,omitempty"` // 第一个error级别诊断项的信息
+	Diagnostics []workflow.Diagnostic `json:"diagnostics"`
 }
@@ -2,17 +2,23 @@ package middleware
 
 import (
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 	
+	"taskmanage/internal/cache"
 	"taskmanage/internal/container"
 	"taskmanage/pkg/jwt"
+	"taskmanage/pkg/logger"
 	"taskmanage/pkg/response"
 )
 
 // Auth 认证中间件
+// 令牌状态存储在创建时解析一次，Redis不可用时仅做无状态校验
 func Auth(appContainer *container.ApplicationContainer) gin.HandlerFunc {
+	tokenStore := resolveTokenStore(appContainer)
+
 	return func(c *gin.Context) {
 		logger := appContainer.GetLogger()
 		
@@ -69,12 +75,16 @@ func Auth(appContainer *container.ApplicationContainer) gin.HandlerFunc {
 			return
 		}
 		
+		// 检查令牌是否已被吊销（登出或全部会话下线）
+		if isTokenRevoked(c, tokenStore, claims, logger) {
+			logger.WithField("user_id", claims.UserID).Warn("Revoked JWT token")
+			response.Unauthorized(c, "令牌已失效")
+			c.Abort()
+			return
+		}
+		
 		// 设置用户信息到上下文
-		c.Set("user_id", claims.UserID)
-		c.Set("username", claims.Username)
-		c.Set("email", claims.Email)
-		c.Set("role", claims.Role)
-		c.Set("token", token)
+		setClaimsContext(c, claims, token)
 		
 		logger.WithFields(logrus.Fields{
 			"user_id":  claims.UserID,
@@ -88,6 +98,8 @@ func Auth(appContainer *container.ApplicationContainer) gin.HandlerFunc {
 
 // OptionalAuth 可选认证中间件
 func OptionalAuth(appContainer *container.ApplicationContainer) gin.HandlerFunc {
+	tokenStore := resolveTokenStore(appContainer)
+
 	return func(c *gin.Context) {
 		logger := appContainer.GetLogger()
 		authHeader := c.GetHeader("Authorization")
@@ -107,13 +119,11 @@ func OptionalAuth(appContainer *container.ApplicationContainer) gin.HandlerFunc
 				
 				// 验证JWT token
 				claims, err := jwtManager.ValidateToken(token)
-				if err == nil {
+				if err == nil && isTokenRevoked(c, tokenStore, claims, logger) {
+					logger.WithField("user_id", claims.UserID).Debug("Optional auth: revoked token")
+				} else if err == nil {
 					// 设置用户信息到上下文
-					c.Set("user_id", claims.UserID)
-					c.Set("username", claims.Username)
-					c.Set("email", claims.Email)
-					c.Set("role", claims.Role)
-					c.Set("token", token)
+					setClaimsContext(c, claims, token)
 					
 					logger.WithFields(logrus.Fields{
 						"user_id":  claims.UserID,
@@ -128,3 +138,48 @@ func OptionalAuth(appContainer *container.ApplicationContainer) gin.HandlerFunc
 		c.Next()
 	}
 }
+
+// setClaimsContext 将令牌声明写入请求上下文，请求日志条目追加user_id
+func setClaimsContext(c *gin.Context, claims *jwt.Claims, token string) {
+	ctx := logger.ContextWithFields(c.Request.Context(), logrus.Fields{"user_id": claims.UserID})
+	c.Request = c.Request.WithContext(ctx)
+	c.Set("user_id", claims.UserID)
+	c.Set("username", claims.Username)
+	c.Set("email", claims.Email)
+	c.Set("role", claims.Role)
+	c.Set("token", token)
+	c.Set("token_id", claims.ID)
+	c.Set("session_id", claims.SessionID)
+	if claims.ExpiresAt != nil {
+		c.Set("token_expires_at", claims.ExpiresAt.Time)
+	}
+}
+
+// resolveTokenStore 获取令牌状态存储，Redis不可用时返回nil
+func resolveTokenStore(appContainer *container.ApplicationContainer) *cache.TokenStore {
+	tokenStore, err := appContainer.GetTokenStore()
+	if err != nil {
+		appContainer.GetLogger().WithError(err).Warn("Token store unavailable, token revocation disabled")
+		return nil
+	}
+	return tokenStore
+}
+
+// isTokenRevoked 检查令牌是否已吊销，存储异常时放行避免Redis故障导致全部请求失败
+func isTokenRevoked(c *gin.Context, tokenStore *cache.TokenStore, claims *jwt.Claims, logger *logrus.Logger) bool {
+	if tokenStore == nil {
+		return false
+	}
+
+	var issuedAt time.Time
+	if claims.IssuedAt != nil {
+		issuedAt = claims.IssuedAt.Time
+	}
+
+	revoked, err := tokenStore.IsAccessTokenRevoked(c.Request.Context(), claims.UserID, claims.ID, issuedAt)
+	if err != nil {
+		logger.WithError(err).Warn("Token revocation check failed")
+		return false
+	}
+	return revoked
+}
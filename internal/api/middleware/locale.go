@@ -0,0 +1,17 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"taskmanage/pkg/i18n"
+)
+
+// Locale 语言中间件，按Accept-Language选择响应消息的语言，不支持时使用配置的默认语言
+func Locale() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		locale := i18n.ResolveLocale(c.GetHeader("Accept-Language"))
+		c.Set(i18n.ContextKey, locale)
+		c.Header("Content-Language", locale)
+		c.Next()
+	}
+}
@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"taskmanage/internal/cache"
+	"taskmanage/internal/container"
+	"taskmanage/pkg/response"
+)
+
+// RateLimit 限流中间件
+// 已认证请求按用户ID限流，未认证请求按客户端IP限流；需放在Auth之后才能识别用户
+func RateLimit(appContainer *container.ApplicationContainer) gin.HandlerFunc {
+	cfg := appContainer.GetConfig().RateLimit
+	limiter := resolveRateLimiter(appContainer)
+	if limiter == nil {
+		return passThrough
+	}
+
+	return func(c *gin.Context) {
+		if userID, exists := c.Get("user_id"); exists {
+			applyRateLimit(c, appContainer, limiter, fmt.Sprintf("user:%v", userID), cfg.UserRequestsPerMinute)
+			return
+		}
+		applyRateLimit(c, appContainer, limiter, "ip:"+c.ClientIP(), cfg.IPRequestsPerMinute)
+	}
+}
+
+// LoginRateLimit 登录接口限流中间件，按客户端IP使用更严格的限额
+func LoginRateLimit(appContainer *container.ApplicationContainer) gin.HandlerFunc {
+	cfg := appContainer.GetConfig().RateLimit
+	limiter := resolveRateLimiter(appContainer)
+	if limiter == nil {
+		return passThrough
+	}
+
+	return func(c *gin.Context) {
+		applyRateLimit(c, appContainer, limiter, "login:ip:"+c.ClientIP(), cfg.LoginRequestsPerMinute)
+	}
+}
+
+// resolveRateLimiter 获取限流器，未启用或Redis不可用且未开启进程内降级时返回nil
+func resolveRateLimiter(appContainer *container.ApplicationContainer) cache.RateLimiter {
+	if !appContainer.GetConfig().RateLimit.Enabled {
+		return nil
+	}
+
+	limiter, err := appContainer.GetRateLimiter()
+	if err != nil {
+		appContainer.GetLogger().WithError(err).Warn("Rate limiter unavailable, rate limiting disabled")
+		return nil
+	}
+	return limiter
+}
+
+// applyRateLimit 执行限流判断，超限时返回429并设置Retry-After
+func applyRateLimit(c *gin.Context, appContainer *container.ApplicationContainer, limiter cache.RateLimiter, key string, limit int) {
+	allowed, retryAfter, err := limiter.Allow(c.Request.Context(), key, limit, time.Minute)
+	if err != nil {
+		// Redis异常且未开启进程内降级时放行，避免限流组件故障导致服务不可用
+		appContainer.GetLogger().WithError(err).WithField("key", key).Warn("Rate limit check failed")
+		c.Next()
+		return
+	}
+
+	if !allowed {
+		seconds := int(math.Ceil(retryAfter.Seconds()))
+		if seconds < 1 {
+			seconds = 1
+		}
+		c.Header("Retry-After", strconv.Itoa(seconds))
+		response.TooManyRequests(c, "请求过于频繁，请稍后再试")
+		c.Abort()
+		return
+	}
+
+	c.Next()
+}
+
+// passThrough 直接放行
+func passThrough(c *gin.Context) {
+	c.Next()
+}
@@ -79,8 +79,8 @@ func RequirePermission(appContainer *container.ApplicationContainer, resource, a
 		// 获取服务管理器
 		serviceManager := appContainer.GetServiceManager()
 		
-		// 检查用户权限
-		hasPermission, err := serviceManager.UserService().HasPermission(c.Request.Context(), userIDUint, resource, action)
+		// 检查用户权限（优先读取权限缓存）
+		hasPermission, err := serviceManager.PermissionService().HasPermission(c.Request.Context(), userIDUint, resource, action)
 		if err != nil {
 			logger.WithError(err).Error("Failed to check user permission")
 			response.InternalError(c, "权限检查失败")
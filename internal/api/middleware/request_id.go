@@ -3,9 +3,13 @@ package middleware
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"taskmanage/pkg/logger"
 )
 
 // RequestID 请求ID中间件
+// 同时在请求上下文中放入带request_id和route的日志条目，服务层通过logger.FromContext获取
 func RequestID() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// 尝试从请求头获取请求ID
@@ -19,6 +23,11 @@ func RequestID() gin.HandlerFunc {
 		// 设置到上下文和响应头
 		c.Set("request_id", requestID)
 		c.Header("X-Request-ID", requestID)
+		entry := logger.GetLogger().WithFields(logrus.Fields{
+			"request_id": requestID,
+			"route":      c.Request.Method + " " + c.FullPath(),
+		})
+		c.Request = c.Request.WithContext(logger.NewContext(c.Request.Context(), entry))
 		
 		c.Next()
 	}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/pkg/jwt"
+	"taskmanage/pkg/logger"
+)
+
+func TestRequestID_LoggerFromContext(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	testLogger, hook := test.NewNullLogger()
+	original := logger.Logger
+	logger.Logger = testLogger
+	defer func() { logger.Logger = original }()
+
+	engine := gin.New()
+	engine.Use(RequestID())
+	engine.GET("/api/v1/tasks/:id", func(c *gin.Context) {
+		setClaimsContext(c, &jwt.Claims{UserID: 7}, "token")
+		// 服务层和流程执行器经请求上下文取得日志条目
+		ctx := c.Request.Context()
+		logger.FromContext(ctx).Info("获取任务")
+		ctx = logger.ContextWithFields(ctx, logrus.Fields{"instance_id": "inst-1", "node_id": "approve"})
+		logger.FromContext(ctx).Info("执行节点")
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/tasks/3", nil)
+	req.Header.Set("X-Request-ID", "req-42")
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "req-42", rec.Header().Get("X-Request-ID"))
+
+	entries := hook.AllEntries()
+	require.Len(t, entries, 2)
+	for _, entry := range entries {
+		assert.Equal(t, "req-42", entry.Data["request_id"], entry.Message)
+		assert.Equal(t, "GET /api/v1/tasks/:id", entry.Data["route"])
+		assert.Equal(t, uint(7), entry.Data["user_id"])
+	}
+	assert.Equal(t, "inst-1", entries[1].Data["instance_id"])
+	assert.Equal(t, "approve", entries[1].Data["node_id"])
+
+	// 请求之外使用全局日志器
+	hook.Reset()
+	logger.FromContext(context.Background()).Info("定时任务")
+	require.Len(t, hook.AllEntries(), 1)
+	assert.NotContains(t, hook.LastEntry().Data, "request_id")
+}
@@ -1,16 +1,23 @@
 package router
 
 import (
+	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
 
+	_ "taskmanage/docs/swagger"
 	"taskmanage/internal/api/handlers"
 	"taskmanage/internal/api/middleware"
 	"taskmanage/internal/container"
+	"taskmanage/internal/database"
+	"taskmanage/internal/metrics"
+	"taskmanage/internal/workflow"
 )
 
 // NewRouter 创建新的路由器
@@ -27,9 +34,15 @@ func NewRouter(container *container.ApplicationContainer, logger *logrus.Logger)
 	// 设置全局中间件
 	setupMiddleware(engine, logger)
 
+	// 设置Prometheus指标（需在路由注册前挂载采集中间件）
+	setupMetrics(engine, container, logger)
+
 	// 设置路由
 	setupRoutes(engine, container, logger)
 
+	// 设置API文档
+	setupSwagger(engine, container, logger)
+
 	// 添加启动完成日志
 	logger.Info("路由器创建完成，所有路由已注册")
 
@@ -47,12 +60,15 @@ func setupMiddleware(engine *gin.Engine, logger *logrus.Logger) {
 	// 日志中间件
 	engine.Use(middleware.Logger(logger))
 
+	// 语言中间件，决定响应消息的语言
+	engine.Use(middleware.Locale())
+
 	// CORS中间件
 	engine.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Requested-With"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Accept-Language", "Authorization", "X-Requested-With"},
+		ExposeHeaders:    []string{"Content-Length", "Content-Language"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
@@ -63,6 +79,51 @@ func setupMiddleware(engine *gin.Engine, logger *logrus.Logger) {
 	logger.Info("中间件设置完成")
 }
 
+// setupMetrics 在配置启用时注册/metrics路由、HTTP采集中间件和工作流执行观察者
+func setupMetrics(engine *gin.Engine, container *container.ApplicationContainer, logger *logrus.Logger) {
+	cfg := container.GetConfig().Metrics
+	if !cfg.Enabled {
+		return
+	}
+
+	appMetrics := metrics.New(cfg.HTTPDurationBuckets)
+	appMetrics.RegisterDBStats(database.GetConnectionInfo)
+	appMetrics.RegisterPendingApprovals(container.GetRepositoryManager().WorkflowInstanceRepository().CountPendingApprovalsByWorkflow)
+	appMetrics.RegisterPermissionCache(func() (int64, int64) {
+		stats := container.GetServiceManager().PermissionService().CacheStats()
+		return stats.Hits, stats.Misses
+	})
+	if redisCache, err := container.GetRedisCache(); err == nil {
+		appMetrics.RegisterRedisBreaker(func() (string, int) {
+			snapshot := redisCache.BreakerSnapshot()
+			return snapshot.State, snapshot.ConsecutiveFailures
+		})
+	} else {
+		logger.WithError(err).Warn("Redis不可用，不导出熔断器指标")
+	}
+	workflow.SetExecutionObserver(appMetrics)
+
+	path := cfg.Path
+	if path == "" {
+		path = "/metrics"
+	}
+	// 先注册/metrics再挂载中间件，抓取请求本身不计入HTTP指标
+	engine.GET(path, gin.WrapH(appMetrics.Handler()))
+	engine.Use(middleware.Metrics(appMetrics))
+
+	logger.Infof("Prometheus指标已启用: %s", path)
+}
+
+// setupSwagger 在配置启用时注册/swagger文档页面，文档由 go generate ./cmd/taskmanage 生成
+func setupSwagger(engine *gin.Engine, container *container.ApplicationContainer, logger *logrus.Logger) {
+	if !container.GetConfig().Swagger.Enabled {
+		return
+	}
+
+	engine.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	logger.Info("API文档已启用: /swagger/index.html")
+}
+
 // setupRoutes 设置路由
 func setupRoutes(engine *gin.Engine, container *container.ApplicationContainer, logger *logrus.Logger) {
 	// 健康检查路由
@@ -77,17 +138,12 @@ func setupRoutes(engine *gin.Engine, container *container.ApplicationContainer,
 func setupHealthRoutes(engine *gin.Engine, container *container.ApplicationContainer, logger *logrus.Logger) {
 	healthHandler := handlers.NewHealthHandler(container, logger)
 
-	engine.GET("/", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{
-			"service": "Task Management System",
-			"status":  "running",
-			"version": "1.0.0",
-		})
-	})
+	engine.GET("/", healthHandler.ServiceInfo)
 
 	engine.GET("/health", healthHandler.HealthCheck)
 	engine.GET("/health/ready", healthHandler.ReadinessCheck)
 	engine.GET("/health/live", healthHandler.LivenessCheck)
+	engine.GET("/health/startup", healthHandler.StartupCheck)
 }
 
 // setupAPIRoutes 设置API路由组
@@ -96,38 +152,82 @@ func setupAPIRoutes(engine *gin.Engine, container *container.ApplicationContaine
 	authHandler := handlers.NewAuthHandler(container, logger)
 	userHandler := handlers.NewUserHandler(container, logger)
 
-	logger.Info("开始创建TaskHandler...")
-	taskHandler := handlers.NewTaskHandler(container, logger)
-	logger.Info("TaskHandler创建成功")
-	assignmentHandler := handlers.NewAssignmentHandler(container, logger)
+	serviceManager := container.GetServiceManager()
+	assignmentService := container.GetAssignmentManagementService()
+	strategies, err := container.GetAssignmentStrategies()
+	if err != nil {
+		// NewApplicationContainer已校验注册项，这里失败说明容器未经校验
+		panic(fmt.Sprintf("获取分配策略失败: %v", err))
+	}
+
+	taskHandler := handlers.NewTaskHandler(serviceManager.TaskService(), assignmentService, serviceManager.PermissionService(), serviceManager.PermissionAssignmentService(), container.GetConfig().Task.OutOfScopeStatus == http.StatusNotFound)
+	taskTemplateHandler := handlers.NewTaskTemplateHandler(container.GetServiceManager().TaskTemplateService(), logger)
+	taskLabelHandler := handlers.NewTaskLabelHandler(container.GetServiceManager().TaskLabelService(), logger)
+	assignmentHandler := handlers.NewAssignmentHandler(assignmentService, strategies, logger)
 	employeeHandler := handlers.NewEmployeeHandler(container, logger)
+	employeeCapacityHandler := handlers.NewEmployeeCapacityHandler(container.GetServiceManager().EmployeeCapacityService(), logger)
 	skillHandler := handlers.NewSkillHandler(container)
-	notificationHandler := handlers.NewNotificationHandler(container, logger)
-	workflowHandler := handlers.NewWorkflowHandler(container.GetServiceManager().WorkflowService(), logger)
+	notificationHandler := handlers.NewNotificationHandler(serviceManager.NotificationService(), logger)
+	tokenStore, err := container.GetTokenStore()
+	if err != nil {
+		logger.WithError(err).Warn("Token store unavailable, session management disabled")
+	}
+	sessionHandler := handlers.NewSessionHandler(tokenStore, logger)
+	workflowHandler := handlers.NewWorkflowHandler(container.GetServiceManager().WorkflowService(), container.GetServiceManager().PermissionService(), logger)
 	
 	// 组织架构管理处理器
 	departmentHandler := handlers.NewDepartmentHandler(container.GetServiceManager().DepartmentService(), logger)
+	approvalChainHandler := handlers.NewApprovalChainHandler(container.GetServiceManager().ApprovalChainService(), logger)
 	positionHandler := handlers.NewPositionHandler(container.GetServiceManager().PositionService(), logger)
 	projectHandler := handlers.NewProjectHandler(container.GetServiceManager().ProjectService(), logger)
 	
 	// 权限分配处理器
 	permissionAssignmentHandler := handlers.NewPermissionAssignmentHandler(container.GetServiceManager().PermissionAssignmentService(), logger)
 
+	// 角色与权限管理处理器
+	roleHandler := handlers.NewRoleHandler(container.GetServiceManager().RoleService(), logger)
+
+	// 认证中间件（共享同一实例，令牌吊销存储只解析一次）
+	authenticate := middleware.Auth(container)
+
+	// 限流中间件（按用户/IP令牌桶，基于Redis在多实例间共享）
+	rateLimit := middleware.RateLimit(container)
+
 	// API v1 路由组
 	v1 := engine.Group("/api/v1")
 
 	// 认证路由（无需认证）
 	auth := v1.Group("/auth")
+	auth.Use(rateLimit)
 	{
-		auth.POST("/login", authHandler.Login)
+		auth.POST("/login", middleware.LoginRateLimit(container), authHandler.Login)
 		auth.POST("/register", authHandler.Register)
+		auth.GET("/oidc/login", middleware.LoginRateLimit(container), authHandler.OIDCLogin)
+		auth.GET("/oidc/callback", middleware.LoginRateLimit(container), authHandler.OIDCCallback)
 		auth.POST("/refresh", authHandler.RefreshToken)
-		auth.POST("/logout", authHandler.Logout)
+		auth.POST("/activate", middleware.LoginRateLimit(container), authHandler.ActivateAccount)
+		auth.POST("/resend-activation", middleware.LoginRateLimit(container), authHandler.ResendActivation)
+		auth.POST("/logout", authenticate, authHandler.Logout)
+		auth.POST("/logout-all", authenticate, authHandler.LogoutAll)
 	}
 
 	// 需要认证的路由
 	authenticated := v1.Group("/")
-	authenticated.Use(middleware.Auth(container))
+	authenticated.Use(authenticate, rateLimit)
+
+	// 当前用户路由，只访问本人数据，不需要额外权限
+	dashboardHandler := handlers.NewDashboardHandler(container.GetServiceManager().DashboardService(), logger)
+	profileHandler := handlers.NewProfileHandler(container.GetServiceManager().ProfileService(), logger)
+	me := authenticated.Group("/me")
+	{
+		me.GET("", profileHandler.GetMyProfile)
+		me.PUT("", profileHandler.UpdateMyProfile)
+		me.GET("/dashboard", dashboardHandler.GetMyDashboard)
+		me.GET("/tasks", taskHandler.ListMyTasks)
+		me.GET("/approvals", workflowHandler.GetPendingApprovals)
+		me.GET("/sessions", sessionHandler.ListMySessions)
+		me.DELETE("/sessions/:id", sessionHandler.RevokeMySession)
+	}
 
 	// 用户管理路由
 	users := authenticated.Group("/users")
@@ -138,6 +238,22 @@ func setupAPIRoutes(engine *gin.Engine, container *container.ApplicationContaine
 		users.DELETE("/:id", middleware.RequirePermission(container, "user", "delete"), userHandler.DeleteUser)
 		users.POST("/:id/roles", middleware.RequirePermission(container, "user", "assign_role"), userHandler.AssignRoles)
 		users.DELETE("/:id/roles", middleware.RequirePermission(container, "user", "assign_role"), userHandler.RemoveRoles)
+		users.GET("/:id/time-summary", middleware.RequirePermission(container, "task", "read"), taskHandler.GetUserTimeSummary)
+		users.GET("/:id/sessions", middleware.RequirePermission(container, "user", "update"), sessionHandler.ListUserSessions)
+		users.DELETE("/:id/sessions/:session_id", middleware.RequirePermission(container, "user", "update"), sessionHandler.RevokeUserSession)
+	}
+
+	// 角色管理路由
+	roles := authenticated.Group("/roles")
+	{
+		roles.GET("", middleware.RequirePermission(container, "role", "read"), roleHandler.ListRoles)
+		roles.POST("", middleware.RequirePermission(container, "role", "create"), roleHandler.CreateRole)
+		roles.GET("/:id", middleware.RequirePermission(container, "role", "read"), roleHandler.GetRole)
+		roles.PUT("/:id", middleware.RequirePermission(container, "role", "update"), roleHandler.UpdateRole)
+		roles.DELETE("/:id", middleware.RequirePermission(container, "role", "delete"), roleHandler.DeleteRole)
+		roles.POST("/:id/permissions", middleware.RequirePermission(container, "role", "update"), roleHandler.AttachPermissions)
+		roles.DELETE("/:id/permissions", middleware.RequirePermission(container, "role", "update"), roleHandler.DetachPermissions)
+		roles.GET("/:id/users", middleware.RequirePermission(container, "role", "read"), roleHandler.ListRoleUsers)
 	}
 
 	// 任务管理路由
@@ -152,9 +268,41 @@ func setupAPIRoutes(engine *gin.Engine, container *container.ApplicationContaine
 		tasks.POST("/:id/reassign", middleware.RequirePermission(container, "task", "assign"), taskHandler.ReassignTask)
 		tasks.POST("/:id/start", middleware.RequirePermission(container, "task", "update"), taskHandler.StartTask)
 		tasks.POST("/:id/complete", middleware.RequirePermission(container, "task", "update"), taskHandler.CompleteTask)
+		tasks.POST("/:id/review/approve", middleware.RequirePermission(container, "task", "update"), taskHandler.ApproveTaskReview)
+		tasks.POST("/:id/review/reject", middleware.RequirePermission(container, "task", "update"), taskHandler.RejectTaskReview)
 		tasks.POST("/:id/cancel", middleware.RequirePermission(container, "task", "update"), taskHandler.CancelTask)
 		tasks.POST("/:id/auto-assign", middleware.RequirePermission(container, "task", "assign"), taskHandler.AutoAssignTask)
 		tasks.GET("/:id/suggestions", middleware.RequirePermission(container, "task", "assign"), taskHandler.GetAssignmentSuggestions)
+
+		// 工时记录
+		tasks.POST("/:id/time-entries", middleware.RequirePermission(container, "task", "update"), taskHandler.AddTimeEntry)
+		tasks.GET("/:id/time-entries", middleware.RequirePermission(container, "task", "read"), taskHandler.ListTimeEntries)
+		tasks.DELETE("/:id/time-entries/:entry_id", middleware.RequirePermission(container, "task", "update"), taskHandler.DeleteTimeEntry)
+
+		// 关注者和评论
+		tasks.POST("/:id/watchers", middleware.RequirePermission(container, "task", "read"), taskHandler.AddTaskWatcher)
+		tasks.DELETE("/:id/watchers", middleware.RequirePermission(container, "task", "read"), taskHandler.RemoveTaskWatcher)
+		tasks.GET("/:id/watchers", middleware.RequirePermission(container, "task", "read"), taskHandler.ListTaskWatchers)
+		tasks.POST("/:id/comments", middleware.RequirePermission(container, "task", "update"), taskHandler.AddTaskComment)
+		tasks.GET("/:id/comments", middleware.RequirePermission(container, "task", "read"), taskHandler.ListTaskComments)
+
+		// 标签
+		tasks.GET("/labels", middleware.RequirePermission(container, "task", "read"), taskLabelHandler.ListLabels)
+		tasks.POST("/labels", middleware.RequirePermission(container, "task", "create"), taskLabelHandler.CreateLabel)
+		tasks.DELETE("/labels/:label_id", middleware.RequirePermission(container, "task", "delete"), taskLabelHandler.DeleteLabel)
+		tasks.POST("/labels/bulk", middleware.RequirePermission(container, "task", "update"), taskLabelHandler.BulkAttach)
+		tasks.PUT("/:id/labels", middleware.RequirePermission(container, "task", "update"), taskLabelHandler.SetTaskLabels)
+	}
+
+	// 任务模板路由
+	taskTemplates := authenticated.Group("/task-templates")
+	{
+		taskTemplates.GET("", middleware.RequirePermission(container, "task", "read"), taskTemplateHandler.ListTemplates)
+		taskTemplates.POST("", middleware.RequirePermission(container, "task", "create"), taskTemplateHandler.CreateTemplate)
+		taskTemplates.GET("/:id", middleware.RequirePermission(container, "task", "read"), taskTemplateHandler.GetTemplate)
+		taskTemplates.PUT("/:id", middleware.RequirePermission(container, "task", "create"), taskTemplateHandler.UpdateTemplate)
+		taskTemplates.DELETE("/:id", middleware.RequirePermission(container, "task", "delete"), taskTemplateHandler.DeleteTemplate)
+		taskTemplates.POST("/:id/instantiate", middleware.RequirePermission(container, "task", "create"), taskTemplateHandler.InstantiateTemplate)
 	}
 
 	// 分配管理路由
@@ -168,6 +316,10 @@ func setupAPIRoutes(engine *gin.Engine, container *container.ApplicationContaine
 		assignments.POST("/cancel/:task_id", middleware.RequirePermission(container, "task", "assign"), assignmentHandler.CancelAssignment)
 		assignments.GET("/strategies", middleware.RequirePermission(container, "task", "read"), assignmentHandler.GetAssignmentStrategies)
 		assignments.GET("/stats", middleware.RequirePermission(container, "task", "read"), assignmentHandler.GetAssignmentStats)
+		assignments.GET("/stats/export", middleware.RequirePermission(container, "task", "read"), assignmentHandler.ExportAssignmentStats)
+		// 不使用审批流程时审批待审批的分配记录
+		assignments.POST("/:id/approve", middleware.RequirePermission(container, "task", "approve"), taskHandler.ApproveAssignment)
+		assignments.POST("/:id/reject", middleware.RequirePermission(container, "task", "approve"), taskHandler.RejectAssignment)
 	}
 
 	// 员工管理路由
@@ -180,9 +332,16 @@ func setupAPIRoutes(engine *gin.Engine, container *container.ApplicationContaine
 		employees.DELETE("/:id", middleware.RequirePermission(container, "employee", "delete"), employeeHandler.DeleteEmployee)
 		employees.GET("/available", middleware.RequirePermission(container, "employee", "read"), employeeHandler.GetAvailableEmployees)
 		employees.GET("/:id/workload", middleware.RequirePermission(container, "employee", "read"), employeeHandler.GetEmployeeWorkload)
+		employees.GET("/:id/org-chart", middleware.RequirePermission(container, "employee", "read"), employeeHandler.GetOrgChart)
 		employees.POST("/:id/skills", middleware.RequirePermission(container, "employee", "update"), employeeHandler.AddSkill)
 		employees.DELETE("/:id/skills", middleware.RequirePermission(container, "employee", "update"), employeeHandler.RemoveSkill)
 
+		// 员工容量日历
+		employees.GET("/:id/capacity", middleware.RequirePermission(container, "employee", "read"), employeeCapacityHandler.ListCapacities)
+		employees.POST("/:id/capacity", middleware.RequirePermission(container, "employee", "update"), employeeCapacityHandler.CreateCapacity)
+		employees.PUT("/:id/capacity/:capacity_id", middleware.RequirePermission(container, "employee", "update"), employeeCapacityHandler.UpdateCapacity)
+		employees.DELETE("/:id/capacity/:capacity_id", middleware.RequirePermission(container, "employee", "update"), employeeCapacityHandler.DeleteCapacity)
+
 		// 员工状态管理
 		employees.PUT("/:id/status", middleware.RequirePermission(container, "employee", "update"), employeeHandler.UpdateEmployeeStatus)
 		employees.GET("/status", middleware.RequirePermission(container, "employee", "read"), employeeHandler.GetEmployeesByStatus)
@@ -194,12 +353,15 @@ func setupAPIRoutes(engine *gin.Engine, container *container.ApplicationContaine
 
 	// 通知路由
 	notificationRoutes := v1.Group("/notifications")
-	notificationRoutes.Use(middleware.Auth(container))
+	notificationRoutes.Use(authenticate, rateLimit)
 	{
 		notificationRoutes.GET("", middleware.RequirePermission(container, "notification", "read"), notificationHandler.GetNotifications)
 		notificationRoutes.GET("/count", middleware.RequirePermission(container, "notification", "read"), notificationHandler.GetUnreadCount)
+		notificationRoutes.GET("/preferences", middleware.RequirePermission(container, "notification", "read"), notificationHandler.GetPreferences)
+		notificationRoutes.PUT("/preferences", middleware.RequirePermission(container, "notification", "read"), notificationHandler.UpdatePreferences)
 		notificationRoutes.PUT("/:id/read", middleware.RequirePermission(container, "notification", "read"), notificationHandler.MarkAsRead)
 		notificationRoutes.PUT("/read", middleware.RequirePermission(container, "notification", "read"), notificationHandler.MarkAllAsRead)
+		notificationRoutes.PUT("/read-bulk", middleware.RequirePermission(container, "notification", "read"), notificationHandler.BulkMarkAsRead)
 		notificationRoutes.POST("/:id/accept", middleware.RequirePermission(container, "task", "update"), notificationHandler.AcceptTask)
 		notificationRoutes.POST("/:id/reject", middleware.RequirePermission(container, "task", "update"), notificationHandler.RejectTask)
 		notificationRoutes.POST("/send", middleware.RequirePermission(container, "notification", "send"), notificationHandler.SendNotification)
@@ -208,7 +370,7 @@ func setupAPIRoutes(engine *gin.Engine, container *container.ApplicationContaine
 
 	// 工作流路由
 	workflowRoutes := v1.Group("/workflows")
-	workflowRoutes.Use(middleware.Auth(container))
+	workflowRoutes.Use(authenticate, rateLimit)
 	{
 		// 工作流定义管理
 		workflowRoutes.POST("/definitions", middleware.RequirePermission(container, "system", "admin"), workflowHandler.CreateWorkflowDefinition)
@@ -217,20 +379,28 @@ func setupAPIRoutes(engine *gin.Engine, container *container.ApplicationContaine
 		workflowRoutes.PUT("/definitions/:id", middleware.RequirePermission(container, "system", "admin"), workflowHandler.UpdateWorkflowDefinition)
 		workflowRoutes.DELETE("/definitions/:id", middleware.RequirePermission(container, "system", "admin"), workflowHandler.DeleteWorkflowDefinition)
 		workflowRoutes.POST("/definitions/validate", middleware.RequirePermission(container, "system", "admin"), workflowHandler.ValidateWorkflowDefinition)
+		workflowRoutes.GET("/definitions/:id/versions", middleware.RequirePermission(container, "task", "read"), workflowHandler.ListWorkflowDefinitionVersions)
+		workflowRoutes.POST("/definitions/:id/versions/:version_id/activate", middleware.RequirePermission(container, "system", "admin"), workflowHandler.ActivateWorkflowDefinitionVersion)
+		workflowRoutes.POST("/definitions/:id/versions/:version_id/deactivate", middleware.RequirePermission(container, "system", "admin"), workflowHandler.DeactivateWorkflowDefinitionVersion)
 		
 		// 任务分配审批流程
 		workflowRoutes.POST("/task-assignment/start", middleware.RequirePermission(container, "task", "approve"), workflowHandler.StartTaskAssignmentApproval)
 		
 		// 审批处理
 		workflowRoutes.POST("/approvals/process", middleware.RequirePermission(container, "task", "approve"), workflowHandler.ProcessApproval)
+		workflowRoutes.POST("/approvals/batch", middleware.RequirePermission(container, "task", "approve"), workflowHandler.BatchProcessApprovals)
 		workflowRoutes.GET("/approvals/pending", middleware.RequirePermission(container, "task", "approve"), workflowHandler.GetPendingApprovals)
 		workflowRoutes.GET("/approvals/task-assignments", middleware.RequirePermission(container, "task", "approve"), workflowHandler.GetPendingTaskAssignmentApprovals)
 		workflowRoutes.GET("/approvals/count", middleware.RequirePermission(container, "task", "approve"), workflowHandler.GetApprovalCount)
 		
 		// 流程实例管理
+		workflowRoutes.GET("/instances", middleware.RequirePermission(container, "task", "read"), workflowHandler.ListWorkflowInstances)
+		workflowRoutes.GET("/instances/stats", middleware.RequirePermission(container, "task", "read"), workflowHandler.GetWorkflowInstanceStats)
 		workflowRoutes.GET("/instances/:instance_id", middleware.RequirePermission(container, "task", "read"), workflowHandler.GetWorkflowInstance)
 		workflowRoutes.POST("/instances/:instance_id/cancel", middleware.RequirePermission(container, "task", "approve"), workflowHandler.CancelWorkflow)
+		workflowRoutes.POST("/instances/:instance_id/retry-completion", middleware.RequirePermission(container, "task", "approve"), workflowHandler.RetryCompletion)
 		workflowRoutes.GET("/instances/:instance_id/history", middleware.RequirePermission(container, "task", "read"), workflowHandler.GetWorkflowHistory)
+		workflowRoutes.GET("/instances/:instance_id/history/verify", middleware.RequirePermission(container, "task", "read"), workflowHandler.VerifyWorkflowHistory)
 	}
 
 	// 技能管理路由
@@ -242,9 +412,13 @@ func setupAPIRoutes(engine *gin.Engine, container *container.ApplicationContaine
 		skills.PUT("/:id", middleware.RequirePermission(container, "skill", "update"), skillHandler.UpdateSkill)
 		skills.DELETE("/:id", middleware.RequirePermission(container, "skill", "delete"), skillHandler.DeleteSkill)
 		skills.GET("/categories", middleware.RequirePermission(container, "skill", "read"), skillHandler.GetSkillCategories)
+		skills.PUT("/categories/:id", middleware.RequirePermission(container, "skill", "update"), skillHandler.RenameSkillCategory)
+		skills.POST("/categories/:id/merge", middleware.RequirePermission(container, "skill", "update"), skillHandler.MergeSkillCategory)
 		skills.POST("/assign", middleware.RequirePermission(container, "skill", "update"), skillHandler.AssignSkillToEmployee)
 		skills.DELETE("/employees/:employee_id/skills/:skill_id", middleware.RequirePermission(container, "skill", "update"), skillHandler.RemoveSkillFromEmployee)
 		skills.GET("/employees/:employee_id", middleware.RequirePermission(container, "skill", "read"), skillHandler.GetEmployeeSkills)
+		// 直接上级也可以认证下属技能，权限在处理器中检查
+		skills.POST("/employees/:employee_id/skills/:skill_id/endorse", skillHandler.EndorseEmployeeSkill)
 	}
 
 	// 部门管理路由
@@ -259,6 +433,16 @@ func setupAPIRoutes(engine *gin.Engine, container *container.ApplicationContaine
 		departments.GET("/roots", middleware.RequirePermission(container, "department", "read"), departmentHandler.GetRootDepartments)
 		departments.GET("/:id/sub", middleware.RequirePermission(container, "department", "read"), departmentHandler.GetSubDepartments)
 		departments.PUT("/:id/manager", middleware.RequirePermission(container, "department", "update"), departmentHandler.UpdateDepartmentManager)
+		departments.PUT("/:id/assignment-strategy", middleware.RequirePermission(container, "department", "update"), departmentHandler.SetAssignmentStrategy)
+		departments.PUT("/:id/onboarding-workflow", middleware.RequirePermission(container, "department", "update"), departmentHandler.SetOnboardingWorkflow)
+		departments.POST("/:id/merge", middleware.RequirePermission(container, "department", "update"), departmentHandler.MergeDepartment)
+
+		// 部门审批链
+		departments.GET("/:id/approval-chains", middleware.RequirePermission(container, "department", "read"), approvalChainHandler.ListApprovalChains)
+		departments.POST("/:id/approval-chains", middleware.RequirePermission(container, "department", "update"), approvalChainHandler.CreateApprovalChain)
+		departments.GET("/:id/approval-chains/:chain_id", middleware.RequirePermission(container, "department", "read"), approvalChainHandler.GetApprovalChain)
+		departments.PUT("/:id/approval-chains/:chain_id", middleware.RequirePermission(container, "department", "update"), approvalChainHandler.UpdateApprovalChain)
+		departments.DELETE("/:id/approval-chains/:chain_id", middleware.RequirePermission(container, "department", "update"), approvalChainHandler.DeleteApprovalChain)
 	}
 
 	// 职位管理路由
@@ -276,7 +460,7 @@ func setupAPIRoutes(engine *gin.Engine, container *container.ApplicationContaine
 
 	// 项目管理路由
 	projectRoutes := v1.Group("/projects")
-	projectRoutes.Use(middleware.Auth(container))
+	projectRoutes.Use(authenticate, rateLimit)
 	{
 		projectRoutes.POST("", middleware.RequirePermission(container, "project", "create"), projectHandler.CreateProject)
 		projectRoutes.GET("", middleware.RequirePermission(container, "project", "read"), projectHandler.ListProjects)
@@ -287,15 +471,75 @@ func setupAPIRoutes(engine *gin.Engine, container *container.ApplicationContaine
 		projectRoutes.POST("/:id/members", middleware.RequirePermission(container, "project", "update"), projectHandler.AddProjectMember)
 		projectRoutes.DELETE("/:id/members/:member_id", middleware.RequirePermission(container, "project", "update"), projectHandler.RemoveProjectMember)
 		projectRoutes.GET("/status/:status", middleware.RequirePermission(container, "project", "read"), projectHandler.GetProjectsByStatus)
+		projectRoutes.GET("/:id/tasks", middleware.RequirePermission(container, "task", "read"), projectHandler.GetProjectTasks)
+		projectRoutes.GET("/:id/timeline", middleware.RequirePermission(container, "task", "read"), projectHandler.GetProjectTimeline)
+		projectRoutes.GET("/:id/burndown", middleware.RequirePermission(container, "task", "read"), projectHandler.GetProjectBurndown)
+		projectRoutes.GET("/:id/milestones", middleware.RequirePermission(container, "project", "read"), projectHandler.ListMilestones)
+		projectRoutes.POST("/:id/milestones", middleware.RequirePermission(container, "project", "update"), projectHandler.CreateMilestone)
+		projectRoutes.PUT("/:id/milestones/:milestone_id", middleware.RequirePermission(container, "project", "update"), projectHandler.UpdateMilestone)
+		projectRoutes.DELETE("/:id/milestones/:milestone_id", middleware.RequirePermission(container, "project", "update"), projectHandler.DeleteMilestone)
+		// 在制品上限仅项目经理可设置，由服务层校验
+		projectRoutes.PUT("/:id/wip-limits", middleware.RequirePermission(container, "project", "read"), projectHandler.UpdateWIPLimits)
+	}
+
+	// 系统管理路由
+	emailOutboxHandler := handlers.NewEmailOutboxHandler(container.GetServiceManager().EmailNotifier(), logger)
+	jobHandler := handlers.NewJobHandler(container.GetJobScheduler())
+	archiveHandler := handlers.NewArchiveHandler(container.GetServiceManager().ArchiveService(), logger)
+	taskEscalationHandler := handlers.NewTaskEscalationHandler(container.GetServiceManager().TaskEscalationService(), logger)
+	taskDueReminderHandler := handlers.NewTaskDueReminderHandler(container.GetServiceManager().TaskDueReminderService(), logger)
+	holidayHandler := handlers.NewHolidayHandler(container.GetServiceManager().WorkCalendarService(), logger)
+	webhookHandler := handlers.NewWebhookHandler(container.GetServiceManager().WebhookService(), logger)
+	adminRoutes := v1.Group("/admin")
+	adminRoutes.Use(authenticate, rateLimit)
+	{
+		adminRoutes.GET("/email-outbox", middleware.RequirePermission(container, "system", "admin"), emailOutboxHandler.ListOutbox)
+		adminRoutes.POST("/reconcile-workload", middleware.RequirePermission(container, "system", "admin"), employeeHandler.ReconcileWorkload)
+		adminRoutes.GET("/jobs", middleware.RequirePermission(container, "system", "admin"), jobHandler.ListJobs)
+		// 归档数据只能通过管理员显式操作永久删除
+		adminRoutes.DELETE("/archive/tasks/:id", middleware.RequirePermission(container, "system", "admin"), archiveHandler.DeleteArchivedTask)
+		adminRoutes.DELETE("/archive/workflow-instances/:instance_id", middleware.RequirePermission(container, "system", "admin"), archiveHandler.DeleteArchivedWorkflowInstance)
+		// 任务优先级老化规则，run支持dry_run预演
+		adminRoutes.GET("/task-escalation-rules", middleware.RequirePermission(container, "system", "admin"), taskEscalationHandler.ListRules)
+		adminRoutes.POST("/task-escalation-rules", middleware.RequirePermission(container, "system", "admin"), taskEscalationHandler.CreateRule)
+		adminRoutes.POST("/task-escalation-rules/run", middleware.RequirePermission(container, "system", "admin"), taskEscalationHandler.RunEscalations)
+		adminRoutes.PUT("/task-escalation-rules/:id", middleware.RequirePermission(container, "system", "admin"), taskEscalationHandler.UpdateRule)
+		adminRoutes.DELETE("/task-escalation-rules/:id", middleware.RequirePermission(container, "system", "admin"), taskEscalationHandler.DeleteRule)
+		// 任务截止提醒的各优先级提前量
+		adminRoutes.GET("/settings/task-due-reminders", middleware.RequirePermission(container, "system", "admin"), taskDueReminderHandler.GetSettings)
+		adminRoutes.PUT("/settings/task-due-reminders", middleware.RequirePermission(container, "system", "admin"), taskDueReminderHandler.UpdateSettings)
+		// 工作日历的节假日，修改后立即刷新缓存的日历
+		adminRoutes.GET("/holidays", middleware.RequirePermission(container, "system", "admin"), holidayHandler.ListHolidays)
+		adminRoutes.POST("/holidays", middleware.RequirePermission(container, "system", "admin"), holidayHandler.CreateHoliday)
+		adminRoutes.PUT("/holidays/:id", middleware.RequirePermission(container, "system", "admin"), holidayHandler.UpdateHoliday)
+		adminRoutes.DELETE("/holidays/:id", middleware.RequirePermission(container, "system", "admin"), holidayHandler.DeleteHoliday)
+		// 集成Webhook端点及其投递记录
+		adminRoutes.GET("/webhooks", middleware.RequirePermission(container, "system", "admin"), webhookHandler.ListEndpoints)
+		adminRoutes.POST("/webhooks", middleware.RequirePermission(container, "system", "admin"), webhookHandler.CreateEndpoint)
+		adminRoutes.GET("/webhooks/:id", middleware.RequirePermission(container, "system", "admin"), webhookHandler.GetEndpoint)
+		adminRoutes.PUT("/webhooks/:id", middleware.RequirePermission(container, "system", "admin"), webhookHandler.UpdateEndpoint)
+		adminRoutes.DELETE("/webhooks/:id", middleware.RequirePermission(container, "system", "admin"), webhookHandler.DeleteEndpoint)
+		adminRoutes.GET("/webhooks/:id/deliveries", middleware.RequirePermission(container, "system", "admin"), webhookHandler.ListDeliveries)
+	}
+
+	// 报表导出路由
+	reportHandler := handlers.NewReportHandler(container.GetServiceManager().ReportService(), logger)
+	reportRoutes := v1.Group("/reports")
+	reportRoutes.Use(authenticate, rateLimit)
+	{
+		reportRoutes.GET("/tasks/export", middleware.RequirePermission(container, "task", "read"), reportHandler.ExportTasks)
+		reportRoutes.GET("/assignments/export", middleware.RequirePermission(container, "task", "read"), reportHandler.ExportAssignments)
+		reportRoutes.GET("/skill-gaps", middleware.RequirePermission(container, "task", "read"), reportHandler.GetSkillGaps)
 	}
 
 	// 入职工作流路由
 	onboardingHandler := handlers.NewOnboardingHandler(container.GetServiceManager().OnboardingService(), container.GetLogger())
 	onboardingRoutes := v1.Group("/onboarding")
-	onboardingRoutes.Use(middleware.Auth(container))
+	onboardingRoutes.Use(authenticate, rateLimit)
 	{
-		// HR操作：创建待入职员工
+		// HR操作：创建待入职员工，支持CSV批量导入
 		onboardingRoutes.POST("/pending", middleware.RequirePermission(container, "employee", "create"), onboardingHandler.CreatePendingEmployee)
+		onboardingRoutes.POST("/import", middleware.RequirePermission(container, "employee", "create"), onboardingHandler.ImportPendingEmployees)
 		
 		// 部门经理操作：确认入职
 		onboardingRoutes.POST("/confirm", middleware.RequirePermission(container, "employee", "update"), onboardingHandler.ConfirmOnboarding)
@@ -312,6 +556,7 @@ func setupAPIRoutes(engine *gin.Engine, container *container.ApplicationContaine
 		// 查询操作
 		onboardingRoutes.GET("/workflows", middleware.RequirePermission(container, "employee", "read"), onboardingHandler.GetOnboardingWorkflows)
 		onboardingRoutes.GET("/:employee_id/history", middleware.RequirePermission(container, "employee", "read"), onboardingHandler.GetOnboardingHistory)
+		onboardingRoutes.GET("/transitions", middleware.RequirePermission(container, "employee", "read"), onboardingHandler.GetStatusTransitions)
 		
 		// 入职审批工作流操作
 		approvalRoutes := onboardingRoutes.Group("/approval")
@@ -333,10 +578,33 @@ func setupAPIRoutes(engine *gin.Engine, container *container.ApplicationContaine
 		}
 	}
 
+	// 离职工作流路由
+	offboardingHandler := handlers.NewOffboardingHandler(container.GetServiceManager().OffboardingService(), container.GetLogger())
+	offboardingRoutes := v1.Group("/offboarding")
+	offboardingRoutes.Use(authenticate, rateLimit)
+	{
+		// 发起离职申请
+		offboardingRoutes.POST("", middleware.RequirePermission(container, "employee", "update"), offboardingHandler.StartOffboarding)
+		
+		// 处理离职审批决策
+		offboardingRoutes.POST("/approval/process", middleware.RequirePermission(container, "employee", "update"), offboardingHandler.ProcessOffboardingApproval)
+		
+		// 任务交接与完成离职
+		offboardingRoutes.GET("/:employee_id/handover-tasks", middleware.RequirePermission(container, "employee", "read"), offboardingHandler.ListHandoverTasks)
+		offboardingRoutes.POST("/:employee_id/complete", middleware.RequirePermission(container, "employee", "update"), offboardingHandler.CompleteOffboarding)
+	}
+
 	// 权限分配路由
 	permissionRoutes := v1.Group("/permissions")
-	permissionRoutes.Use(middleware.Auth(container))
+	permissionRoutes.Use(authenticate, rateLimit)
 	{
+		// 权限定义管理
+		permissionRoutes.GET("", middleware.RequirePermission(container, "permission", "read"), roleHandler.ListPermissions)
+		permissionRoutes.POST("", middleware.RequirePermission(container, "permission", "create"), roleHandler.CreatePermission)
+		permissionRoutes.GET("/:id", middleware.RequirePermission(container, "permission", "read"), roleHandler.GetPermission)
+		permissionRoutes.PUT("/:id", middleware.RequirePermission(container, "permission", "update"), roleHandler.UpdatePermission)
+		permissionRoutes.DELETE("/:id", middleware.RequirePermission(container, "permission", "delete"), roleHandler.DeletePermission)
+
 		// 权限模板管理
 		templateRoutes := permissionRoutes.Group("/templates")
 		{
@@ -345,6 +613,18 @@ func setupAPIRoutes(engine *gin.Engine, container *container.ApplicationContaine
 			templateRoutes.GET("/:id", middleware.RequirePermission(container, "permission", "read"), permissionAssignmentHandler.GetPermissionTemplate)
 			templateRoutes.PUT("/:id", middleware.RequirePermission(container, "permission", "update"), permissionAssignmentHandler.UpdatePermissionTemplate)
 			templateRoutes.DELETE("/:id", middleware.RequirePermission(container, "permission", "delete"), permissionAssignmentHandler.DeletePermissionTemplate)
+			templateRoutes.POST("/:id/preview", middleware.RequirePermission(container, "permission", "read"), permissionAssignmentHandler.PreviewPermissionTemplate)
+			templateRoutes.POST("/:id/apply", middleware.RequirePermission(container, "permission", "update"), permissionAssignmentHandler.ApplyPermissionTemplate)
+		}
+
+		// 权限规则管理
+		ruleRoutes := permissionRoutes.Group("/rules")
+		{
+			ruleRoutes.POST("", middleware.RequirePermission(container, "permission", "create"), permissionAssignmentHandler.CreatePermissionRule)
+			ruleRoutes.GET("", middleware.RequirePermission(container, "permission", "read"), permissionAssignmentHandler.ListPermissionRules)
+			ruleRoutes.GET("/:id", middleware.RequirePermission(container, "permission", "read"), permissionAssignmentHandler.GetPermissionRule)
+			ruleRoutes.PUT("/:id", middleware.RequirePermission(container, "permission", "update"), permissionAssignmentHandler.UpdatePermissionRule)
+			ruleRoutes.DELETE("/:id", middleware.RequirePermission(container, "permission", "delete"), permissionAssignmentHandler.DeletePermissionRule)
 		}
 
 		// 权限分配管理
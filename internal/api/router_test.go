@@ -0,0 +1,62 @@
+package router
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"taskmanage/docs/swagger"
+	"taskmanage/internal/config"
+	"taskmanage/internal/container"
+)
+
+// swaggerSpec 测试关心的OpenAPI文档字段
+type swaggerSpec struct {
+	Paths       map[string]map[string]json.RawMessage `json:"paths"`
+	Definitions map[string]json.RawMessage            `json:"definitions"`
+}
+
+var pathParamPattern = regexp.MustCompile(`:(\w+)`)
+
+// TestSwaggerCoversAllRoutes 生成的文档需覆盖路由表中的全部接口，且不包含未注册的接口
+// 修改路由或注解后执行 go generate ./cmd/taskmanage 重新生成文档
+func TestSwaggerCoversAllRoutes(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	appContainer, err := container.NewApplicationContainer(&config.Config{}, &gorm.DB{})
+	require.NoError(t, err)
+	engine := NewRouter(appContainer, logger)
+
+	doc := swagger.SwaggerInfo.ReadDoc()
+	var spec swaggerSpec
+	require.NoError(t, json.Unmarshal([]byte(doc), &spec), "swagger文档解析失败")
+
+	// 所有模型引用都能解析
+	for _, ref := range regexp.MustCompile(`"#/definitions/([^"]+)"`).FindAllStringSubmatch(doc, -1) {
+		assert.Contains(t, spec.Definitions, ref[1], "未定义的模型引用")
+	}
+
+	registered := make(map[string]bool)
+	for _, route := range engine.Routes() {
+		if strings.HasPrefix(route.Path, "/swagger") {
+			continue
+		}
+		path := pathParamPattern.ReplaceAllString(route.Path, "{$1}")
+		method := strings.ToLower(route.Method)
+		registered[method+" "+path] = true
+		_, ok := spec.Paths[path][method]
+		assert.True(t, ok, "路由缺少swagger注解: %s %s (%s)", route.Method, path, route.Handler)
+	}
+
+	for path, operations := range spec.Paths {
+		for method := range operations {
+			assert.True(t, registered[method+" "+path], "swagger文档包含未注册的路由: %s %s", strings.ToUpper(method), path)
+		}
+	}
+}
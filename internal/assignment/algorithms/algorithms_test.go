@@ -13,12 +13,12 @@ func TestRoundRobinAlgorithm(t *testing.T) {
 	algorithm := NewRoundRobinAlgorithm()
 
 	// 验证算法基本信息
-	if algorithm.GetName() != "Round Robin Assignment" {
-		t.Errorf("Expected name 'Round Robin Assignment', got %s", algorithm.GetName())
+	if algorithm.DisplayName() != "Round Robin Assignment" {
+		t.Errorf("Expected name 'Round Robin Assignment', got %s", algorithm.DisplayName())
 	}
 
-	if algorithm.GetStrategy() != StrategyRoundRobin {
-		t.Errorf("Expected strategy %s, got %s", StrategyRoundRobin, algorithm.GetStrategy())
+	if algorithm.Name() != StrategyRoundRobin {
+		t.Errorf("Expected strategy %s, got %s", StrategyRoundRobin, algorithm.Name())
 	}
 
 	// 创建测试候选人
@@ -34,7 +34,7 @@ func TestRoundRobinAlgorithm(t *testing.T) {
 	ctx := context.Background()
 
 	// 执行分配算法
-	result, err := algorithm.Assign(ctx, req, candidates)
+	result, err := Assign(ctx, algorithm, req, candidates)
 	if err != nil {
 		t.Fatalf("Assignment failed: %v", err)
 	}
@@ -58,8 +58,8 @@ func TestLoadBalanceAlgorithm(t *testing.T) {
 	algorithm := NewLoadBalanceAlgorithm()
 
 	// 验证算法基本信息
-	if algorithm.GetName() != "Load Balance Assignment" {
-		t.Errorf("Expected name 'Load Balance Assignment', got %s", algorithm.GetName())
+	if algorithm.DisplayName() != "Load Balance Assignment" {
+		t.Errorf("Expected name 'Load Balance Assignment', got %s", algorithm.DisplayName())
 	}
 
 	// 创建测试候选人（不同负载）
@@ -117,7 +117,7 @@ func TestLoadBalanceAlgorithm(t *testing.T) {
 	ctx := context.Background()
 
 	// 执行分配算法
-	result, err := algorithm.Assign(ctx, req, candidates)
+	result, err := Assign(ctx, algorithm, req, candidates)
 	if err != nil {
 		t.Fatalf("Assignment failed: %v", err)
 	}
@@ -133,8 +133,8 @@ func TestSkillMatchAlgorithm(t *testing.T) {
 	algorithm := NewSkillMatchAlgorithm()
 
 	// 验证算法基本信息
-	if algorithm.GetName() != "Skill Match Assignment" {
-		t.Errorf("Expected name 'Skill Match Assignment', got %s", algorithm.GetName())
+	if algorithm.DisplayName() != "Skill Match Assignment" {
+		t.Errorf("Expected name 'Skill Match Assignment', got %s", algorithm.DisplayName())
 	}
 
 	candidates := createTestCandidates()
@@ -152,7 +152,7 @@ func TestSkillMatchAlgorithm(t *testing.T) {
 	ctx := context.Background()
 
 	// 执行分配算法
-	result, err := algorithm.Assign(ctx, req, candidates)
+	result, err := Assign(ctx, algorithm, req, candidates)
 	if err != nil {
 		t.Fatalf("Assignment failed: %v", err)
 	}
@@ -167,13 +167,49 @@ func TestSkillMatchAlgorithm(t *testing.T) {
 	}
 }
 
+// TestSkillMatchAlgorithm_UsesSkillLevels 测试按员工实际技能等级选择候选人
+func TestSkillMatchAlgorithm_UsesSkillLevels(t *testing.T) {
+	algorithm := NewSkillMatchAlgorithm()
+
+	candidates := createTestCandidates()
+	candidates[0].SkillLevels = map[uint]int{1: 1}
+	candidates[1].SkillLevels = map[uint]int{1: 4, 2: 2}
+	candidates[2].SkillLevels = map[uint]int{2: 5}
+
+	req := &AssignmentRequest{
+		TaskID:   1,
+		Strategy: StrategySkillMatch,
+		RequiredSkills: []SkillRequirement{
+			{SkillID: 1, MinLevel: 3},
+			{SkillID: 2, MinLevel: 2},
+		},
+	}
+
+	result, err := Assign(context.Background(), algorithm, req, candidates)
+	if err != nil {
+		t.Fatalf("Assignment failed: %v", err)
+	}
+
+	if result.SelectedEmployee.ID != 2 {
+		t.Errorf("Expected employee 2 with all skills at required level, got %d", result.SelectedEmployee.ID)
+	}
+	if result.Score != 100 {
+		t.Errorf("Expected score 100, got %f", result.Score)
+	}
+
+	match := MatchSkillLevels(req.RequiredSkills, candidates[0].SkillLevels)
+	if match.Matched != 0 || match.LevelGap != 4 || len(match.Gaps) != 2 {
+		t.Errorf("Unexpected match result: %+v", match)
+	}
+}
+
 // TestComprehensiveAlgorithm 测试综合评分分配算法
 func TestComprehensiveAlgorithm(t *testing.T) {
 	algorithm := NewComprehensiveAlgorithm()
 
 	// 验证算法基本信息
-	if algorithm.GetName() != "Comprehensive Score Assignment" {
-		t.Errorf("Expected name 'Comprehensive Score Assignment', got %s", algorithm.GetName())
+	if algorithm.DisplayName() != "Comprehensive Score Assignment" {
+		t.Errorf("Expected name 'Comprehensive Score Assignment', got %s", algorithm.DisplayName())
 	}
 
 	candidates := createTestCandidates()
@@ -190,7 +226,7 @@ func TestComprehensiveAlgorithm(t *testing.T) {
 	ctx := context.Background()
 
 	// 执行分配算法
-	result, err := algorithm.Assign(ctx, req, candidates)
+	result, err := Assign(ctx, algorithm, req, candidates)
 	if err != nil {
 		t.Fatalf("Assignment failed: %v", err)
 	}
@@ -218,7 +254,7 @@ func TestEmptyCandidates(t *testing.T) {
 	ctx := context.Background()
 
 	// 测试空候选人列表
-	_, err := algorithm.Assign(ctx, req, []AssignmentCandidate{})
+	_, err := Assign(ctx, algorithm, req, []AssignmentCandidate{})
 	if err == nil {
 		t.Error("Expected error for empty candidates list")
 	}
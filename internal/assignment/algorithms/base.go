@@ -2,9 +2,12 @@ package algorithms
 
 import (
 	"context"
+	"fmt"
+	"sort"
 	"time"
 
 	"taskmanage/internal/database"
+	"taskmanage/pkg/logger"
 )
 
 // AssignmentStrategy 分配策略类型
@@ -18,16 +21,55 @@ const (
 	StrategyManual        AssignmentStrategy = "manual"
 )
 
-// AssignmentAlgorithm 分配算法接口
-type AssignmentAlgorithm interface {
-	// GetName 获取算法名称
-	GetName() string
+// Strategy 分配策略插件。Filter剔除不适合的候选人，Score为剩余候选人逐个评分，
+// 由Assign统一排序并选出评分最高者
+type Strategy interface {
+	// Name 策略标识，即请求中的strategy取值
+	Name() AssignmentStrategy
 
-	// GetStrategy 获取算法策略
-	GetStrategy() AssignmentStrategy
+	// DisplayName 策略展示名称
+	DisplayName() string
 
-	// Assign 执行分配算法
-	Assign(ctx context.Context, req *AssignmentRequest, candidates []AssignmentCandidate) (*AssignmentResult, error)
+	// Description 策略说明
+	Description() string
+
+	// Filter 过滤候选人，返回参与评分的候选人
+	Filter(ctx context.Context, req *AssignmentRequest, candidates []AssignmentCandidate) []AssignmentCandidate
+
+	// Score 为候选人评分（0-100）并给出原因
+	Score(ctx context.Context, req *AssignmentRequest, candidate AssignmentCandidate) (float64, string)
+}
+
+// Selector 不按评分选择的策略实现此接口，如轮询，返回ranked中选中的下标
+type Selector interface {
+	Select(ranked []ScoredCandidate) int
+}
+
+// baseStrategy 内置策略的公共部分，默认不过滤候选人
+type baseStrategy struct {
+	name        AssignmentStrategy
+	displayName string
+	description string
+}
+
+// Name 策略标识
+func (b *baseStrategy) Name() AssignmentStrategy {
+	return b.name
+}
+
+// DisplayName 策略展示名称
+func (b *baseStrategy) DisplayName() string {
+	return b.displayName
+}
+
+// Description 策略说明
+func (b *baseStrategy) Description() string {
+	return b.description
+}
+
+// Filter 保留全部候选人
+func (b *baseStrategy) Filter(ctx context.Context, req *AssignmentRequest, candidates []AssignmentCandidate) []AssignmentCandidate {
+	return candidates
 }
 
 // AssignmentRequest 分配请求
@@ -50,16 +92,18 @@ type SkillRequirement struct {
 
 // AssignmentCandidate 分配候选人
 type AssignmentCandidate struct {
-	Employee database.Employee `json:"employee"`
-	Workload WorkloadInfo      `json:"workload"`
-	Score    float64           `json:"score,omitempty"`
+	Employee    database.Employee `json:"employee"`
+	Workload    WorkloadInfo      `json:"workload"`
+	SkillLevels map[uint]int      `json:"skill_levels,omitempty"` // 技能ID -> 员工技能等级
+	Score       float64           `json:"score,omitempty"`
 }
 
 // WorkloadInfo 工作负载信息
 type WorkloadInfo struct {
 	CurrentTasks      int           `json:"current_tasks"`
 	MaxTasks          int           `json:"max_tasks"`
-	UtilizationRate   float64       `json:"utilization_rate"`
+	EffectiveMaxTasks int           `json:"effective_max_tasks"` // 按容量日历折算后的最大任务数
+	UtilizationRate   float64       `json:"utilization_rate"`    // 按有效容量计算
 	AvgTaskDuration   time.Duration `json:"avg_task_duration"`
 }
 
@@ -78,5 +122,78 @@ type AssignmentResult struct {
 type ScoredCandidate struct {
 	Candidate AssignmentCandidate
 	Score     float64
-	Reasons   []string
+	Reason    string
+}
+
+// maxAlternatives 分配结果中备选候选人的数量上限
+const maxAlternatives = 3
+
+// Assign 按策略执行一次分配：Filter过滤后逐个评分，按评分从高到低排序，
+// 评分相同时负载低的在前；策略实现Selector时由其选择，否则选择评分最高者
+func Assign(ctx context.Context, strategy Strategy, req *AssignmentRequest, candidates []AssignmentCandidate) (*AssignmentResult, error) {
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("没有可用的候选人")
+	}
+
+	filtered := strategy.Filter(ctx, req, candidates)
+	if len(filtered) == 0 {
+		return nil, fmt.Errorf("没有符合%s策略条件的候选人", strategy.Name())
+	}
+
+	logger.Infof("开始%s分配，候选人数量: %d", strategy.DisplayName(), len(filtered))
+
+	ranked := make([]ScoredCandidate, 0, len(filtered))
+	for _, candidate := range filtered {
+		score, reason := strategy.Score(ctx, req, candidate)
+		ranked = append(ranked, ScoredCandidate{Candidate: candidate, Score: score, Reason: reason})
+		logger.Debugf("员工 %d %s评分: %.2f, 原因: %s", candidate.Employee.ID, strategy.DisplayName(), score, reason)
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Candidate.Workload.UtilizationRate != b.Candidate.Workload.UtilizationRate {
+			return a.Candidate.Workload.UtilizationRate < b.Candidate.Workload.UtilizationRate
+		}
+		return a.Candidate.Workload.CurrentTasks < b.Candidate.Workload.CurrentTasks
+	})
+
+	selectedIndex := 0
+	if selector, ok := strategy.(Selector); ok {
+		selectedIndex = selector.Select(ranked)
+		if selectedIndex < 0 || selectedIndex >= len(ranked) {
+			return nil, fmt.Errorf("%s策略选择的候选人下标越界: %d", strategy.Name(), selectedIndex)
+		}
+	}
+	selected := ranked[selectedIndex]
+
+	alternatives := make([]AssignmentCandidate, 0, maxAlternatives)
+	for i, item := range ranked {
+		if i == selectedIndex {
+			continue
+		}
+		if len(alternatives) == maxAlternatives {
+			break
+		}
+		alt := item.Candidate
+		alt.Score = item.Score
+		alternatives = append(alternatives, alt)
+	}
+
+	result := &AssignmentResult{
+		TaskID:           req.TaskID,
+		Strategy:         strategy.Name(),
+		SelectedEmployee: selected.Candidate.Employee,
+		Score:            selected.Score,
+		Reason:           selected.Reason,
+		Alternatives:     alternatives,
+		ExecutedAt:       time.Now(),
+	}
+
+	logger.Infof("%s分配完成，选择员工: %d (%s), 评分: %.2f", strategy.DisplayName(),
+		result.SelectedEmployee.ID, result.SelectedEmployee.User.Username, result.Score)
+
+	return result, nil
 }
@@ -4,17 +4,12 @@ import (
 	"context"
 	"fmt"
 	"math"
-	"sort"
-	"time"
-
-	"taskmanage/pkg/logger"
 )
 
 // ComprehensiveAlgorithm 综合评分分配算法
 type ComprehensiveAlgorithm struct {
-	name     string
-	strategy AssignmentStrategy
-	weights  *ComprehensiveWeights
+	baseStrategy
+	weights *ComprehensiveWeights
 }
 
 // ComprehensiveWeights 综合评分权重配置
@@ -38,92 +33,28 @@ var DefaultWeights = &ComprehensiveWeights{
 }
 
 // NewComprehensiveAlgorithm 创建综合评分分配算法实例
-func NewComprehensiveAlgorithm() AssignmentAlgorithm {
-	return &ComprehensiveAlgorithm{
-		name:     "Comprehensive Score Assignment",
-		strategy: StrategyComprehensive,
-		weights:  DefaultWeights,
-	}
+func NewComprehensiveAlgorithm() Strategy {
+	return NewComprehensiveAlgorithmWithWeights(DefaultWeights)
 }
 
 // NewComprehensiveAlgorithmWithWeights 创建带自定义权重的综合评分算法
-func NewComprehensiveAlgorithmWithWeights(weights *ComprehensiveWeights) AssignmentAlgorithm {
+func NewComprehensiveAlgorithmWithWeights(weights *ComprehensiveWeights) Strategy {
 	return &ComprehensiveAlgorithm{
-		name:     "Comprehensive Score Assignment",
-		strategy: StrategyComprehensive,
-		weights:  weights,
+		baseStrategy: baseStrategy{
+			name:        StrategyComprehensive,
+			displayName: "Comprehensive Score Assignment",
+			description: "综合评分 - 综合考虑技能、负载、可用性等因素",
+		},
+		weights: weights,
 	}
 }
 
-// GetName 获取算法名称
-func (a *ComprehensiveAlgorithm) GetName() string {
-	return a.name
-}
-
-// GetStrategy 获取算法策略
-func (a *ComprehensiveAlgorithm) GetStrategy() AssignmentStrategy {
-	return a.strategy
-}
-
-// Assign 执行综合评分分配算法
-func (a *ComprehensiveAlgorithm) Assign(ctx context.Context, req *AssignmentRequest, candidates []AssignmentCandidate) (*AssignmentResult, error) {
-	if len(candidates) == 0 {
-		return nil, fmt.Errorf("没有可用的候选人")
-	}
-
-	logger.Infof("开始综合评分分配算法，候选人数量: %d", len(candidates))
-
-	// 为每个候选人计算综合评分
-	scoredCandidates := make([]ScoredCandidate, 0, len(candidates))
-
-	for _, candidate := range candidates {
-		score, reasons := a.calculateComprehensiveScore(req, candidate)
-
-		scoredCandidate := ScoredCandidate{
-			Candidate: candidate,
-			Score:     score,
-			Reasons:   reasons,
-		}
-
-		scoredCandidates = append(scoredCandidates, scoredCandidate)
-
-		logger.Debugf("员工 %d 综合评分: %.2f, 原因: %v",
-			candidate.Employee.ID, score, reasons)
-	}
-
-	// 按评分排序（降序）
-	sort.Slice(scoredCandidates, func(i, j int) bool {
-		return scoredCandidates[i].Score > scoredCandidates[j].Score
-	})
-
-	// 选择评分最高的候选人
-	selectedCandidate := scoredCandidates[0]
-
-	// 准备备选方案（前3名）
-	alternatives := make([]AssignmentCandidate, 0)
-	for i := 1; i < len(scoredCandidates) && i < 4; i++ {
-		alt := scoredCandidates[i].Candidate
-		alt.Score = scoredCandidates[i].Score
-		alternatives = append(alternatives, alt)
-	}
-
-	result := &AssignmentResult{
-		TaskID:           req.TaskID,
-		Strategy:         a.strategy,
-		SelectedEmployee: selectedCandidate.Candidate.Employee,
-		Score:            selectedCandidate.Score,
-		Reason:           a.generateReason(selectedCandidate),
-		Alternatives:     alternatives,
-		ExecutedAt:       time.Now(),
-	}
-
-	logger.Infof("综合评分分配完成，选择员工: %d (%s), 评分: %.2f",
-		result.SelectedEmployee.ID, result.SelectedEmployee.User.Username, result.Score)
-
-	return result, nil
+// Score 按权重汇总各项评分
+func (a *ComprehensiveAlgorithm) Score(ctx context.Context, req *AssignmentRequest, candidate AssignmentCandidate) (float64, string) {
+	score, reasons := a.calculateComprehensiveScore(req, candidate)
+	return score, a.generateReason(score, reasons)
 }
 
-
 // calculateComprehensiveScore 计算综合评分
 func (a *ComprehensiveAlgorithm) calculateComprehensiveScore(req *AssignmentRequest, candidate AssignmentCandidate) (float64, []string) {
 	var totalScore float64
@@ -183,27 +114,13 @@ func (a *ComprehensiveAlgorithm) calculateSkillScore(req *AssignmentRequest, can
 		return 80.0, "无特定技能要求"
 	}
 
-	// 这里需要实际的技能匹配逻辑
-	// 暂时使用简化逻辑
-	matchedSkills := 0
-	totalSkills := len(req.RequiredSkills)
-
-	// 模拟技能匹配（实际应该查询数据库）
-	for range req.RequiredSkills {
-		// 假设70%的概率匹配技能
-		if candidate.Employee.ID%3 != 0 { // 简单的模拟逻辑
-			matchedSkills++
-		}
-	}
-
-	if matchedSkills == 0 {
+	match := MatchSkillLevels(req.RequiredSkills, candidate.SkillLevels)
+	if match.Coverage == 0 {
 		return 20.0, "技能不匹配"
 	}
 
-	matchRate := float64(matchedSkills) / float64(totalSkills)
-	score := matchRate * 100
-
-	return score, fmt.Sprintf("技能匹配度: %d/%d (%.1f%%)", matchedSkills, totalSkills, matchRate*100)
+	return match.Score(), fmt.Sprintf("技能匹配度: %d/%d (%.1f%%), 等级差距: %d",
+		match.Matched, match.Total, match.Coverage*100, match.LevelGap)
 }
 
 // calculateLoadScore 计算负载均衡评分
@@ -296,11 +213,10 @@ func (a *ComprehensiveAlgorithm) calculatePriorityScore(req *AssignmentRequest,
 }
 
 // generateReason 生成分配原因
-func (a *ComprehensiveAlgorithm) generateReason(candidate ScoredCandidate) string {
-	if len(candidate.Reasons) == 0 {
-		return fmt.Sprintf("综合评分分配 (分数: %.1f)", candidate.Score)
+func (a *ComprehensiveAlgorithm) generateReason(score float64, reasons []string) string {
+	if len(reasons) == 0 {
+		return fmt.Sprintf("综合评分分配 (分数: %.1f)", score)
 	}
 
-	return fmt.Sprintf("综合评分分配 (%s, 分数: %.1f)",
-		fmt.Sprintf("%s", candidate.Reasons[0]), candidate.Score)
+	return fmt.Sprintf("综合评分分配 (%s, 分数: %.1f)", reasons[0], score)
 }
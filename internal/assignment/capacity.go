@@ -0,0 +1,53 @@
+package assignment
+
+import (
+	"math"
+	"time"
+
+	"taskmanage/internal/database"
+)
+
+// CapacityWindow 计算有效容量时考察的日期范围：从今天到截止日期，
+// 没有截止日期或截止日期已过时只考察今天
+func CapacityWindow(now time.Time, deadline *time.Time) (time.Time, time.Time) {
+	from := Day(now)
+	if deadline == nil || deadline.Before(from) {
+		return from, from
+	}
+	return from, Day(*deadline)
+}
+
+// Day 返回t所在日期的零点
+func Day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// CapacityFactor 取容量条目中最小的容量系数，没有条目时为1
+func CapacityFactor(entries []*database.EmployeeCapacity) float64 {
+	factor := 1.0
+	for _, entry := range entries {
+		if entry.CapacityFactor < factor {
+			factor = entry.CapacityFactor
+		}
+	}
+	return factor
+}
+
+// EffectiveMaxTasks 有效容量，即最大任务数乘以容量系数后向下取整
+func EffectiveMaxTasks(maxTasks int, factor float64) int {
+	if factor >= 1 {
+		return maxTasks
+	}
+	if factor <= 0 {
+		return 0
+	}
+	return int(math.Floor(float64(maxTasks) * factor))
+}
+
+// UtilizationRate 按有效容量计算的利用率，没有有效容量时视为已满
+func UtilizationRate(currentTasks, effectiveMaxTasks int) float64 {
+	if effectiveMaxTasks <= 0 {
+		return 1
+	}
+	return float64(currentTasks) / float64(effectiveMaxTasks)
+}
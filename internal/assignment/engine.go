@@ -13,77 +13,33 @@ import (
 
 // AssignmentEngineImpl 分配引擎实现
 type AssignmentEngineImpl struct {
-	algorithms        map[AssignmentStrategy]AssignmentAlgorithm
+	strategies        *StrategyRegistry
 	candidateProvider CandidateProvider
 	history           AssignmentHistory
-	mutex             sync.RWMutex
 }
 
 // NewAssignmentEngine 创建分配引擎实例
-func NewAssignmentEngine(candidateProvider CandidateProvider, history AssignmentHistory) AssignmentEngine {
-	engine := &AssignmentEngineImpl{
-		algorithms:        make(map[AssignmentStrategy]AssignmentAlgorithm),
+func NewAssignmentEngine(strategies *StrategyRegistry, candidateProvider CandidateProvider, history AssignmentHistory) AssignmentEngine {
+	return &AssignmentEngineImpl{
+		strategies:        strategies,
 		candidateProvider: candidateProvider,
 		history:           history,
 	}
-
-	return engine
-}
-
-// RegisterAlgorithm 注册分配算法
-func (e *AssignmentEngineImpl) RegisterAlgorithm(algorithm AssignmentAlgorithm) error {
-	if algorithm == nil {
-		return fmt.Errorf("算法不能为空")
-	}
-
-	strategy := algorithm.GetStrategy()
-	if strategy == "" {
-		return fmt.Errorf("算法策略不能为空")
-	}
-
-	e.mutex.Lock()
-	defer e.mutex.Unlock()
-
-	e.algorithms[strategy] = algorithm
-	logger.Infof("注册分配算法: %s (%s)", algorithm.GetName(), strategy)
-
-	return nil
-}
-
-// GetAlgorithm 获取分配算法
-func (e *AssignmentEngineImpl) GetAlgorithm(strategy AssignmentStrategy) (AssignmentAlgorithm, error) {
-	e.mutex.RLock()
-	defer e.mutex.RUnlock()
-
-	algorithm, exists := e.algorithms[strategy]
-	if !exists {
-		return nil, fmt.Errorf("未找到分配算法: %s", strategy)
-	}
-
-	return algorithm, nil
 }
 
-// ListAlgorithms 列出所有可用算法
-func (e *AssignmentEngineImpl) ListAlgorithms() []AssignmentAlgorithm {
-	e.mutex.RLock()
-	defer e.mutex.RUnlock()
-
-	algorithms := make([]AssignmentAlgorithm, 0, len(e.algorithms))
-	for _, algorithm := range e.algorithms {
-		algorithms = append(algorithms, algorithm)
-	}
-
-	return algorithms
+// Strategies 获取分配策略注册表
+func (e *AssignmentEngineImpl) Strategies() *StrategyRegistry {
+	return e.strategies
 }
 
 // ExecuteAssignment 执行任务分配
 func (e *AssignmentEngineImpl) ExecuteAssignment(ctx context.Context, req *AssignmentRequest) (*AssignmentResult, error) {
 	logger.Infof("开始执行任务分配: TaskID=%d, Strategy=%s", req.TaskID, req.Strategy)
 
-	// 获取分配算法
-	algorithm, err := e.GetAlgorithm(req.Strategy)
+	// 获取分配策略
+	strategy, err := e.strategies.Get(req.Strategy)
 	if err != nil {
-		return nil, fmt.Errorf("获取分配算法失败: %w", err)
+		return nil, err
 	}
 
 	// 获取候选人
@@ -98,8 +54,8 @@ func (e *AssignmentEngineImpl) ExecuteAssignment(ctx context.Context, req *Assig
 
 	logger.Infof("找到 %d 个候选人", len(candidates))
 
-	// 执行分配算法
-	result, err := algorithm.Assign(ctx, req, candidates)
+	// 执行分配策略
+	result, err := runStrategy(ctx, strategy, req, candidates)
 	if err != nil {
 		return nil, fmt.Errorf("执行分配算法失败: %w", err)
 	}
@@ -129,14 +85,15 @@ func (e *AssignmentEngineImpl) GetCandidates(ctx context.Context, req *Assignmen
 
 	for _, employee := range employees {
 		// 获取员工工作负载
-		workload, err := e.candidateProvider.GetEmployeeWorkload(ctx, employee.ID)
+		workload, err := e.candidateProvider.GetEmployeeWorkload(ctx, employee.ID, req.Deadline)
 		if err != nil {
 			logger.Warnf("获取员工 %d 工作负载失败: %v", employee.ID, err)
 			// 使用默认工作负载信息
 			workload = &WorkloadInfo{
-				CurrentTasks:    employee.CurrentTasks,
-				MaxTasks:        employee.MaxTasks,
-				UtilizationRate: float64(employee.CurrentTasks) / float64(employee.MaxTasks),
+				CurrentTasks:      employee.CurrentTasks,
+				MaxTasks:          employee.MaxTasks,
+				EffectiveMaxTasks: employee.MaxTasks,
+				UtilizationRate:   float64(employee.CurrentTasks) / float64(employee.MaxTasks),
 			}
 		}
 
@@ -156,6 +113,15 @@ func (e *AssignmentEngineImpl) GetCandidates(ctx context.Context, req *Assignmen
 			Workload: *workload,
 		}
 
+		// 有技能要求时加载员工技能等级，供技能匹配评分使用
+		if len(req.RequiredSkills) > 0 {
+			levels, err := e.candidateProvider.GetEmployeeSkillLevels(ctx, employee.ID, req.IncludeExpiredSkills)
+			if err != nil {
+				logger.Warnf("获取员工 %d 技能等级失败: %v", employee.ID, err)
+			}
+			candidate.SkillLevels = levels
+		}
+
 		candidates = append(candidates, candidate)
 	}
 
@@ -166,10 +132,10 @@ func (e *AssignmentEngineImpl) GetCandidates(ctx context.Context, req *Assignmen
 func (e *AssignmentEngineImpl) PreviewAssignment(ctx context.Context, req *AssignmentRequest) (*AssignmentResult, error) {
 	logger.Infof("预览任务分配: TaskID=%d, Strategy=%s", req.TaskID, req.Strategy)
 
-	// 获取分配算法
-	algorithm, err := e.GetAlgorithm(req.Strategy)
+	// 获取分配策略
+	strategy, err := e.strategies.Get(req.Strategy)
 	if err != nil {
-		return nil, fmt.Errorf("获取分配算法失败: %w", err)
+		return nil, err
 	}
 
 	// 获取候选人
@@ -182,8 +148,8 @@ func (e *AssignmentEngineImpl) PreviewAssignment(ctx context.Context, req *Assig
 		return nil, fmt.Errorf("没有可用的候选人")
 	}
 
-	// 执行分配算法（预览模式）
-	result, err := algorithm.Assign(ctx, req, candidates)
+	// 执行分配策略（预览模式）
+	result, err := runStrategy(ctx, strategy, req, candidates)
 	if err != nil {
 		return nil, fmt.Errorf("执行分配算法失败: %w", err)
 	}
@@ -199,14 +165,16 @@ type CandidateProviderImpl struct {
 	employeeRepo repository.EmployeeRepository
 	skillRepo    repository.SkillRepository
 	taskRepo     repository.TaskRepository
+	capacityRepo repository.EmployeeCapacityRepository
 }
 
 // NewCandidateProvider 创建候选人提供者实例
-func NewCandidateProvider(employeeRepo repository.EmployeeRepository, skillRepo repository.SkillRepository, taskRepo repository.TaskRepository) CandidateProvider {
+func NewCandidateProvider(employeeRepo repository.EmployeeRepository, skillRepo repository.SkillRepository, taskRepo repository.TaskRepository, capacityRepo repository.EmployeeCapacityRepository) CandidateProvider {
 	return &CandidateProviderImpl{
 		employeeRepo: employeeRepo,
 		skillRepo:    skillRepo,
 		taskRepo:     taskRepo,
+		capacityRepo: capacityRepo,
 	}
 }
 
@@ -218,6 +186,15 @@ func (c *CandidateProviderImpl) GetAvailableEmployees(ctx context.Context, req *
 		return nil, fmt.Errorf("获取可用员工失败: %w", err)
 	}
 
+	// 排除离职办理中的员工
+	activeEmployees := make([]*database.Employee, 0, len(employees))
+	for _, employee := range employees {
+		if employee.OnboardingStatus != "offboarding" {
+			activeEmployees = append(activeEmployees, employee)
+		}
+	}
+	employees = activeEmployees
+
 	// 根据部门过滤（如果指定）
 	if req.Department != "" {
 		filteredEmployees := make([]*database.Employee, 0)
@@ -229,6 +206,22 @@ func (c *CandidateProviderImpl) GetAvailableEmployees(ctx context.Context, req *
 		employees = filteredEmployees
 	}
 
+	// 限定候选范围（如项目成员）
+	if len(req.OnlyEmployees) > 0 {
+		allowed := make(map[uint]bool, len(req.OnlyEmployees))
+		for _, id := range req.OnlyEmployees {
+			allowed[id] = true
+		}
+
+		filteredEmployees := make([]*database.Employee, 0)
+		for _, employee := range employees {
+			if allowed[employee.ID] {
+				filteredEmployees = append(filteredEmployees, employee)
+			}
+		}
+		employees = filteredEmployees
+	}
+
 	// 排除指定员工
 	if len(req.ExcludeEmployees) > 0 {
 		excludeMap := make(map[uint]bool)
@@ -264,21 +257,46 @@ func (c *CandidateProviderImpl) GetEmployeeSkills(ctx context.Context, employeeI
 	return result, nil
 }
 
-// GetEmployeeWorkload 获取员工工作负载
-func (c *CandidateProviderImpl) GetEmployeeWorkload(ctx context.Context, employeeID uint) (*WorkloadInfo, error) {
+// GetEmployeeSkillLevels 获取员工技能等级，认证已到期的技能视为不具备
+func (c *CandidateProviderImpl) GetEmployeeSkillLevels(ctx context.Context, employeeID uint, includeExpired bool) (map[uint]int, error) {
+	details, err := c.skillRepo.GetEmployeeSkillsWithLevel(ctx, employeeID)
+	if err != nil {
+		return nil, fmt.Errorf("获取员工技能等级失败: %w", err)
+	}
+
+	now := time.Now()
+	levels := make(map[uint]int, len(details))
+	for _, detail := range details {
+		if !includeExpired && detail.IsExpired(now) {
+			continue
+		}
+		levels[detail.SkillID] = detail.Level
+	}
+
+	return levels, nil
+}
+
+// GetEmployeeWorkload 获取员工工作负载，有效容量按截止日期前容量系数最低的日期折算
+func (c *CandidateProviderImpl) GetEmployeeWorkload(ctx context.Context, employeeID uint, deadline *time.Time) (*WorkloadInfo, error) {
 	employee, err := c.employeeRepo.GetByID(ctx, employeeID)
 	if err != nil {
 		return nil, fmt.Errorf("获取员工信息失败: %w", err)
 	}
 
+	effectiveMaxTasks, err := c.effectiveMaxTasks(ctx, employee, deadline)
+	if err != nil {
+		return nil, err
+	}
+
 	workload := &WorkloadInfo{
-		CurrentTasks: employee.CurrentTasks,
-		MaxTasks:     employee.MaxTasks,
+		CurrentTasks:      employee.CurrentTasks,
+		MaxTasks:          employee.MaxTasks,
+		EffectiveMaxTasks: effectiveMaxTasks,
 	}
 
 	// 计算利用率
 	if employee.MaxTasks > 0 {
-		workload.UtilizationRate = float64(employee.CurrentTasks) / float64(employee.MaxTasks)
+		workload.UtilizationRate = UtilizationRate(employee.CurrentTasks, effectiveMaxTasks)
 	}
 
 	// 计算平均任务完成时间（这里需要实际的统计数据，暂时使用模拟值）
@@ -303,8 +321,12 @@ func (c *CandidateProviderImpl) CheckEmployeeAvailability(ctx context.Context, e
 		return false, nil
 	}
 
-	// 检查工作负载
-	if employee.CurrentTasks >= employee.MaxTasks {
+	// 检查截止日期前的有效容量
+	effectiveMaxTasks, err := c.effectiveMaxTasks(ctx, employee, deadline)
+	if err != nil {
+		return false, err
+	}
+	if employee.CurrentTasks >= effectiveMaxTasks {
 		return false, nil
 	}
 
@@ -317,6 +339,19 @@ func (c *CandidateProviderImpl) CheckEmployeeAvailability(ctx context.Context, e
 	return true, nil
 }
 
+// effectiveMaxTasks 按从今天到截止日期的容量日历折算员工的有效容量
+func (c *CandidateProviderImpl) effectiveMaxTasks(ctx context.Context, employee *database.Employee, deadline *time.Time) (int, error) {
+	if c.capacityRepo == nil {
+		return employee.MaxTasks, nil
+	}
+	from, to := CapacityWindow(time.Now(), deadline)
+	entries, err := c.capacityRepo.ListOverlapping(ctx, employee.ID, from, to)
+	if err != nil {
+		return 0, fmt.Errorf("获取员工容量日历失败: %w", err)
+	}
+	return EffectiveMaxTasks(employee.MaxTasks, CapacityFactor(entries)), nil
+}
+
 // AssignmentHistoryImpl 分配历史记录实现
 type AssignmentHistoryImpl struct {
 	// 这里可以使用数据库或缓存存储历史记录
@@ -0,0 +1,105 @@
+package assignment
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"taskmanage/internal/assignment/algorithms"
+	"taskmanage/pkg/logger"
+)
+
+// ErrUnknownStrategy 分配策略未注册
+var ErrUnknownStrategy = errors.New("未知的分配策略")
+
+// StrategyRegistry 分配策略注册表，新增策略只需实现algorithms.Strategy并在容器初始化时注册
+type StrategyRegistry struct {
+	mu         sync.RWMutex
+	strategies map[AssignmentStrategy]algorithms.Strategy
+	order      []AssignmentStrategy
+}
+
+// NewStrategyRegistry 创建空的分配策略注册表
+func NewStrategyRegistry() *StrategyRegistry {
+	return &StrategyRegistry{strategies: make(map[AssignmentStrategy]algorithms.Strategy)}
+}
+
+// NewDefaultStrategyRegistry 创建已注册全部内置策略的注册表
+func NewDefaultStrategyRegistry() *StrategyRegistry {
+	registry := NewStrategyRegistry()
+	builtins := []algorithms.Strategy{
+		algorithms.NewRoundRobinAlgorithm(),
+		algorithms.NewLoadBalanceAlgorithm(),
+		algorithms.NewSkillMatchAlgorithm(),
+		algorithms.NewComprehensiveAlgorithm(),
+	}
+	for _, strategy := range builtins {
+		if err := registry.Register(strategy); err != nil {
+			panic(err)
+		}
+	}
+	return registry
+}
+
+// Register 注册分配策略，策略标识不能重复
+func (r *StrategyRegistry) Register(strategy algorithms.Strategy) error {
+	if strategy == nil {
+		return fmt.Errorf("分配策略不能为空")
+	}
+	name := AssignmentStrategy(strategy.Name())
+	if name == "" {
+		return fmt.Errorf("分配策略标识不能为空")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.strategies[name]; exists {
+		return fmt.Errorf("分配策略已注册: %s", name)
+	}
+	r.strategies[name] = strategy
+	r.order = append(r.order, name)
+	logger.Infof("注册分配策略: %s (%s)", strategy.DisplayName(), name)
+
+	return nil
+}
+
+// Get 获取分配策略，未注册时返回ErrUnknownStrategy并列出可选策略
+func (r *StrategyRegistry) Get(name AssignmentStrategy) (algorithms.Strategy, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	strategy, exists := r.strategies[name]
+	if !exists {
+		return nil, fmt.Errorf("%w: %q，可选: %s", ErrUnknownStrategy, name, strings.Join(r.namesLocked(), ", "))
+	}
+	return strategy, nil
+}
+
+// List 按注册顺序列出全部分配策略
+func (r *StrategyRegistry) List() []algorithms.Strategy {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	strategies := make([]algorithms.Strategy, 0, len(r.order))
+	for _, name := range r.order {
+		strategies = append(strategies, r.strategies[name])
+	}
+	return strategies
+}
+
+// Names 按注册顺序列出全部策略标识
+func (r *StrategyRegistry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.namesLocked()
+}
+
+func (r *StrategyRegistry) namesLocked() []string {
+	names := make([]string, len(r.order))
+	for i, name := range r.order {
+		names[i] = string(name)
+	}
+	return names
+}
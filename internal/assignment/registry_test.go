@@ -0,0 +1,27 @@
+package assignment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/assignment/algorithms"
+)
+
+func TestStrategyRegistry(t *testing.T) {
+	registry := NewDefaultStrategyRegistry()
+	assert.Equal(t, []string{"round_robin", "load_balance", "skill_match", "comprehensive"}, registry.Names())
+
+	strategy, err := registry.Get(StrategySkillMatch)
+	require.NoError(t, err)
+	assert.Equal(t, algorithms.StrategySkillMatch, strategy.Name())
+
+	// 未注册的策略不回退到默认策略，错误中列出可选策略
+	_, err = registry.Get("random")
+	assert.ErrorIs(t, err, ErrUnknownStrategy)
+	assert.Contains(t, err.Error(), "round_robin, load_balance, skill_match, comprehensive")
+
+	assert.Error(t, registry.Register(algorithms.NewRoundRobinAlgorithm()))
+	assert.Len(t, registry.List(), 4)
+}
@@ -16,55 +16,33 @@ type AssignmentService struct {
 	history           AssignmentHistory
 }
 
-// NewAssignmentService 创建分配服务实例
-func NewAssignmentService(repoManager repository.RepositoryManager) *AssignmentService {
+// NewAssignmentService 创建分配服务实例，strategies为容器初始化时注册好的分配策略
+func NewAssignmentService(repoManager repository.RepositoryManager, strategies *StrategyRegistry) *AssignmentService {
 	// 创建候选人提供者
 	candidateProvider := NewCandidateProvider(
 		repoManager.EmployeeRepository(),
 		repoManager.SkillRepository(),
 		repoManager.TaskRepository(),
+		repoManager.EmployeeCapacityRepository(),
 	)
 
 	// 创建分配历史记录
 	history := NewAssignmentHistory()
 
-	// 创建分配引擎
-	engine := NewAssignmentEngine(candidateProvider, history)
-
-	// 注册所有算法
-	service := &AssignmentService{
-		engine:            engine,
+	return &AssignmentService{
+		engine:            NewAssignmentEngine(strategies, candidateProvider, history),
 		candidateProvider: candidateProvider,
 		history:           history,
 	}
-
-	service.registerAlgorithms()
-
-	return service
-}
-
-// AlgorithmAdapter 算法适配器，用于适配不同包中的类型定义
-type AlgorithmAdapter struct {
-	algorithm algorithms.AssignmentAlgorithm
-}
-
-// GetName 获取算法名称
-func (a *AlgorithmAdapter) GetName() string {
-	return a.algorithm.GetName()
 }
 
-// GetStrategy 获取算法策略
-func (a *AlgorithmAdapter) GetStrategy() AssignmentStrategy {
-	return AssignmentStrategy(a.algorithm.GetStrategy())
-}
-
-// Assign 执行分配算法
-func (a *AlgorithmAdapter) Assign(ctx context.Context, req *AssignmentRequest, candidates []AssignmentCandidate) (*AssignmentResult, error) {
+// runStrategy 将请求和候选人转换为algorithms包的类型后执行分配策略
+func runStrategy(ctx context.Context, strategy algorithms.Strategy, req *AssignmentRequest, candidates []AssignmentCandidate) (*AssignmentResult, error) {
 	// 转换请求类型
 	algoReq := &algorithms.AssignmentRequest{
 		TaskID:           req.TaskID,
 		Strategy:         algorithms.AssignmentStrategy(req.Strategy),
-		RequiredSkills:   make([]algorithms.SkillRequirement, len(req.RequiredSkills)),
+		RequiredSkills:   toAlgorithmSkills(req.RequiredSkills),
 		Department:       req.Department,
 		Priority:         req.Priority,
 		Deadline:         req.Deadline,
@@ -72,31 +50,25 @@ func (a *AlgorithmAdapter) Assign(ctx context.Context, req *AssignmentRequest, c
 		Preferences:      req.Preferences,
 	}
 
-	// 转换技能要求
-	for i, skill := range req.RequiredSkills {
-		algoReq.RequiredSkills[i] = algorithms.SkillRequirement{
-			SkillID:  skill.SkillID,
-			MinLevel: skill.MinLevel,
-		}
-	}
-
 	// 转换候选人类型
 	algoCandidates := make([]algorithms.AssignmentCandidate, len(candidates))
 	for i, candidate := range candidates {
 		algoCandidates[i] = algorithms.AssignmentCandidate{
 			Employee: candidate.Employee,
 			Workload: algorithms.WorkloadInfo{
-				CurrentTasks:    candidate.Workload.CurrentTasks,
-				MaxTasks:        candidate.Workload.MaxTasks,
-				UtilizationRate: candidate.Workload.UtilizationRate,
-				AvgTaskDuration: candidate.Workload.AvgTaskDuration,
+				CurrentTasks:      candidate.Workload.CurrentTasks,
+				MaxTasks:          candidate.Workload.MaxTasks,
+				EffectiveMaxTasks: candidate.Workload.EffectiveMaxTasks,
+				UtilizationRate:   candidate.Workload.UtilizationRate,
+				AvgTaskDuration:   candidate.Workload.AvgTaskDuration,
 			},
-			Score: candidate.Score,
+			SkillLevels: candidate.SkillLevels,
+			Score:       candidate.Score,
 		}
 	}
 
-	// 调用算法
-	result, err := a.algorithm.Assign(ctx, algoReq, algoCandidates)
+	// 调用策略
+	result, err := algorithms.Assign(ctx, strategy, algoReq, algoCandidates)
 	if err != nil {
 		return nil, err
 	}
@@ -107,12 +79,14 @@ func (a *AlgorithmAdapter) Assign(ctx context.Context, req *AssignmentRequest, c
 		alternatives[i] = AssignmentCandidate{
 			Employee: alt.Employee,
 			Workload: WorkloadInfo{
-				CurrentTasks:    alt.Workload.CurrentTasks,
-				MaxTasks:        alt.Workload.MaxTasks,
-				UtilizationRate: alt.Workload.UtilizationRate,
-				AvgTaskDuration: alt.Workload.AvgTaskDuration,
+				CurrentTasks:      alt.Workload.CurrentTasks,
+				MaxTasks:          alt.Workload.MaxTasks,
+				EffectiveMaxTasks: alt.Workload.EffectiveMaxTasks,
+				UtilizationRate:   alt.Workload.UtilizationRate,
+				AvgTaskDuration:   alt.Workload.AvgTaskDuration,
 			},
-			Score: alt.Score,
+			SkillLevels: alt.SkillLevels,
+			Score:       alt.Score,
 		}
 	}
 
@@ -127,22 +101,21 @@ func (a *AlgorithmAdapter) Assign(ctx context.Context, req *AssignmentRequest, c
 	}, nil
 }
 
-// registerAlgorithms 注册所有分配算法
-func (s *AssignmentService) registerAlgorithms() {
-	algorithmList := []AssignmentAlgorithm{
-		&AlgorithmAdapter{algorithms.NewRoundRobinAlgorithm()},
-		&AlgorithmAdapter{algorithms.NewLoadBalanceAlgorithm()},
-		&AlgorithmAdapter{algorithms.NewSkillMatchAlgorithm()},
-		&AlgorithmAdapter{algorithms.NewComprehensiveAlgorithm()},
-	}
-
-	for _, algorithm := range algorithmList {
-		if err := s.engine.RegisterAlgorithm(algorithm); err != nil {
-			logger.Errorf("注册分配算法失败: %v", err)
+// toAlgorithmSkills 转换技能要求类型
+func toAlgorithmSkills(skills []SkillRequirement) []algorithms.SkillRequirement {
+	result := make([]algorithms.SkillRequirement, len(skills))
+	for i, skill := range skills {
+		result[i] = algorithms.SkillRequirement{
+			SkillID:  skill.SkillID,
+			MinLevel: skill.MinLevel,
 		}
 	}
+	return result
+}
 
-	logger.Infof("已注册 %d 个分配算法", len(algorithmList))
+// MatchSkillLevels 比对候选人技能等级与技能要求
+func MatchSkillLevels(required []SkillRequirement, levels map[uint]int) algorithms.SkillMatchResult {
+	return algorithms.MatchSkillLevels(toAlgorithmSkills(required), levels)
 }
 
 // AssignTask 分配任务
@@ -195,22 +168,28 @@ func (s *AssignmentService) GetCandidates(ctx context.Context, req *AssignmentRe
 	return candidates, nil
 }
 
-// GetAvailableStrategies 获取可用的分配策略
+// GetAvailableStrategies 按注册顺序获取可用的分配策略
 func (s *AssignmentService) GetAvailableStrategies() []StrategyInfo {
-	algorithms := s.engine.ListAlgorithms()
-	strategies := make([]StrategyInfo, len(algorithms))
+	registered := s.engine.Strategies().List()
+	strategies := make([]StrategyInfo, len(registered))
 
-	for i, algorithm := range algorithms {
+	for i, strategy := range registered {
 		strategies[i] = StrategyInfo{
-			Strategy:    algorithm.GetStrategy(),
-			Name:        algorithm.GetName(),
-			Description: s.getStrategyDescription(algorithm.GetStrategy()),
+			Strategy:    AssignmentStrategy(strategy.Name()),
+			Name:        strategy.DisplayName(),
+			Description: strategy.Description(),
 		}
 	}
 
 	return strategies
 }
 
+// ValidateStrategy 检查分配策略是否已注册，未注册时返回ErrUnknownStrategy并列出可选策略
+func (s *AssignmentService) ValidateStrategy(strategy AssignmentStrategy) error {
+	_, err := s.engine.Strategies().Get(strategy)
+	return err
+}
+
 // GetAssignmentHistory 获取分配历史
 func (s *AssignmentService) GetAssignmentHistory(ctx context.Context, taskID uint) ([]*AssignmentResult, error) {
 	return s.history.GetAssignmentHistory(ctx, taskID)
@@ -237,43 +216,23 @@ func (s *AssignmentService) validateAssignmentRequest(req *AssignmentRequest) er
 	}
 
 	// 检查策略是否存在
-	_, err := s.engine.GetAlgorithm(req.Strategy)
-	if err != nil {
-		return fmt.Errorf("不支持的分配策略: %s", req.Strategy)
+	if err := s.ValidateStrategy(req.Strategy); err != nil {
+		return err
 	}
 
 	// 验证技能要求
-	if req.Strategy == StrategySkillMatch || req.Strategy == StrategyComprehensive {
-		for _, skill := range req.RequiredSkills {
-			if skill.SkillID == 0 {
-				return fmt.Errorf("技能ID不能为空")
-			}
-			if skill.MinLevel < 1 || skill.MinLevel > 5 {
-				return fmt.Errorf("技能级别必须在1-5之间")
-			}
+	for _, skill := range req.RequiredSkills {
+		if skill.SkillID == 0 {
+			return fmt.Errorf("技能ID不能为空")
+		}
+		if skill.MinLevel < 1 || skill.MinLevel > 5 {
+			return fmt.Errorf("技能级别必须在1-5之间")
 		}
 	}
 
 	return nil
 }
 
-// getStrategyDescription 获取策略描述
-func (s *AssignmentService) getStrategyDescription(strategy AssignmentStrategy) string {
-	descriptions := map[AssignmentStrategy]string{
-		StrategyRoundRobin:    "轮询分配 - 按顺序轮流分配给可用员工",
-		StrategyLoadBalance:   "负载均衡 - 优先分配给工作负载较低的员工",
-		StrategySkillMatch:    "技能匹配 - 根据技能要求匹配最合适的员工",
-		StrategyComprehensive: "综合评分 - 综合考虑技能、负载、可用性等因素",
-		StrategyManual:        "手动分配 - 管理员手动指定员工",
-	}
-
-	if desc, exists := descriptions[strategy]; exists {
-		return desc
-	}
-
-	return "未知策略"
-}
-
 // StrategyInfo 策略信息
 type StrategyInfo struct {
 	Strategy    AssignmentStrategy `json:"strategy"`
@@ -287,9 +246,9 @@ type AssignmentServiceManager struct {
 }
 
 // NewAssignmentServiceManager 创建分配服务管理器
-func NewAssignmentServiceManager(repoManager repository.RepositoryManager) *AssignmentServiceManager {
+func NewAssignmentServiceManager(repoManager repository.RepositoryManager, strategies *StrategyRegistry) *AssignmentServiceManager {
 	return &AssignmentServiceManager{
-		service: NewAssignmentService(repoManager),
+		service: NewAssignmentService(repoManager, strategies),
 	}
 }
 
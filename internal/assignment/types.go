@@ -18,18 +18,6 @@ const (
 	StrategyManual        AssignmentStrategy = "manual"
 )
 
-// AssignmentAlgorithm 分配算法接口
-type AssignmentAlgorithm interface {
-	// GetName 获取算法名称
-	GetName() string
-
-	// GetStrategy 获取算法策略
-	GetStrategy() AssignmentStrategy
-
-	// Assign 执行分配算法
-	Assign(ctx context.Context, req *AssignmentRequest, candidates []AssignmentCandidate) (*AssignmentResult, error)
-}
-
 // AssignmentRequest 分配请求
 type AssignmentRequest struct {
 	TaskID           uint                    `json:"task_id"`
@@ -39,27 +27,34 @@ type AssignmentRequest struct {
 	Priority         string                  `json:"priority,omitempty"`
 	Deadline         *time.Time              `json:"deadline,omitempty"`
 	ExcludeEmployees []uint                  `json:"exclude_employees,omitempty"`
+	OnlyEmployees    []uint                  `json:"only_employees,omitempty"` // 非空时只从这些员工中选择，如任务所属项目的成员
 	Preferences      map[string]interface{}  `json:"preferences,omitempty"`
+
+	// IncludeExpiredSkills 技能匹配时计入认证已到期的技能，默认忽略
+	IncludeExpiredSkills bool `json:"include_expired_skills,omitempty"`
 }
 
 // SkillRequirement 技能要求
 type SkillRequirement struct {
-	SkillID  uint `json:"skill_id"`
-	MinLevel int  `json:"min_level"`
+	SkillID   uint   `json:"skill_id"`
+	SkillName string `json:"skill_name,omitempty"`
+	MinLevel  int    `json:"min_level"`
 }
 
 // AssignmentCandidate 分配候选人
 type AssignmentCandidate struct {
-	Employee database.Employee `json:"employee"`
-	Workload WorkloadInfo      `json:"workload"`
-	Score    float64           `json:"score,omitempty"`
+	Employee    database.Employee `json:"employee"`
+	Workload    WorkloadInfo      `json:"workload"`
+	SkillLevels map[uint]int      `json:"skill_levels,omitempty"` // 技能ID -> 员工技能等级，仅在有技能要求时加载
+	Score       float64           `json:"score,omitempty"`
 }
 
 // WorkloadInfo 工作负载信息
 type WorkloadInfo struct {
 	CurrentTasks      int           `json:"current_tasks"`
 	MaxTasks          int           `json:"max_tasks"`
-	UtilizationRate   float64       `json:"utilization_rate"`
+	EffectiveMaxTasks int           `json:"effective_max_tasks"` // 按容量日历折算后的最大任务数
+	UtilizationRate   float64       `json:"utilization_rate"`    // 按有效容量计算
 	AvgTaskDuration   time.Duration `json:"avg_task_duration"`
 }
 
@@ -76,14 +71,8 @@ type AssignmentResult struct {
 
 // AssignmentEngine 分配引擎接口
 type AssignmentEngine interface {
-	// RegisterAlgorithm 注册分配算法
-	RegisterAlgorithm(algorithm AssignmentAlgorithm) error
-
-	// GetAlgorithm 获取分配算法
-	GetAlgorithm(strategy AssignmentStrategy) (AssignmentAlgorithm, error)
-
-	// ListAlgorithms 列出所有可用算法
-	ListAlgorithms() []AssignmentAlgorithm
+	// Strategies 获取分配策略注册表
+	Strategies() *StrategyRegistry
 
 	// ExecuteAssignment 执行任务分配
 	ExecuteAssignment(ctx context.Context, req *AssignmentRequest) (*AssignmentResult, error)
@@ -103,8 +92,11 @@ type CandidateProvider interface {
 	// GetEmployeeSkills 获取员工技能
 	GetEmployeeSkills(ctx context.Context, employeeID uint) ([]database.Skill, error)
 
-	// GetEmployeeWorkload 获取员工工作负载
-	GetEmployeeWorkload(ctx context.Context, employeeID uint) (*WorkloadInfo, error)
+	// GetEmployeeSkillLevels 获取员工技能等级（技能ID -> 等级），includeExpired为false时忽略认证已到期的技能
+	GetEmployeeSkillLevels(ctx context.Context, employeeID uint, includeExpired bool) (map[uint]int, error)
+
+	// GetEmployeeWorkload 获取员工工作负载，按截止日期前的容量日历折算有效容量
+	GetEmployeeWorkload(ctx context.Context, employeeID uint, deadline *time.Time) (*WorkloadInfo, error)
 
 	// CheckEmployeeAvailability 检查员工可用性
 	CheckEmployeeAvailability(ctx context.Context, employeeID uint, deadline *time.Time) (bool, error)
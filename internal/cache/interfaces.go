@@ -62,6 +62,12 @@ type CacheManager interface {
 	Close() error
 }
 
+// RateLimiter 限流器接口
+type RateLimiter interface {
+	// Allow 判断key在窗口内是否允许通过，limit为窗口内允许的请求数；不允许时返回需要等待的时长
+	Allow(ctx context.Context, key string, limit int, window time.Duration) (bool, time.Duration, error)
+}
+
 // CacheConfig 缓存配置
 type CacheConfig struct {
 	Type        string        `json:"type"`         // redis, memory, hybrid
@@ -0,0 +1,99 @@
+package cache
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"taskmanage/pkg/logger"
+)
+
+// localBucketIdleTTL 本地令牌桶闲置超过该时长后清理
+const localBucketIdleTTL = 10 * time.Minute
+
+// localBucket 进程内令牌桶
+type localBucket struct {
+	tokens float64
+	ts     time.Time
+}
+
+// LocalRateLimiter 进程内令牌桶限流器，算法与Redis令牌桶一致，限流状态不在实例间共享
+type LocalRateLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*localBucket
+	now       func() time.Time
+	lastSweep time.Time
+}
+
+// NewLocalRateLimiter 创建进程内令牌桶限流器
+func NewLocalRateLimiter() *LocalRateLimiter {
+	return &LocalRateLimiter{buckets: make(map[string]*localBucket), now: time.Now}
+}
+
+// Allow 判断请求是否允许通过，桶容量为limit，每个window补满
+func (l *LocalRateLimiter) Allow(ctx context.Context, key string, limit int, window time.Duration) (bool, time.Duration, error) {
+	if limit <= 0 {
+		return true, 0, nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	rate := float64(limit) / float64(window)
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &localBucket{tokens: float64(limit), ts: now}
+		l.buckets[key] = bucket
+	}
+	elapsed := now.Sub(bucket.ts)
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	bucket.tokens = math.Min(float64(limit), bucket.tokens+float64(elapsed)*rate)
+	bucket.ts = now
+
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return true, 0, nil
+	}
+	wait := time.Duration(math.Ceil((1 - bucket.tokens) / rate))
+	return false, wait, nil
+}
+
+// sweep 每隔localBucketIdleTTL清理一次闲置的令牌桶，调用方需持有锁
+func (l *LocalRateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < localBucketIdleTTL {
+		return
+	}
+	l.lastSweep = now
+	for key, bucket := range l.buckets {
+		if now.Sub(bucket.ts) >= localBucketIdleTTL {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// FallbackRateLimiter 主限流器出错时改用备用限流器，用于Redis不可用时降级为进程内限流
+type FallbackRateLimiter struct {
+	primary  RateLimiter
+	fallback RateLimiter
+}
+
+// NewFallbackRateLimiter 创建带降级的限流器
+func NewFallbackRateLimiter(primary, fallback RateLimiter) *FallbackRateLimiter {
+	return &FallbackRateLimiter{primary: primary, fallback: fallback}
+}
+
+// Allow 优先使用主限流器，主限流器出错时由备用限流器判断
+func (f *FallbackRateLimiter) Allow(ctx context.Context, key string, limit int, window time.Duration) (bool, time.Duration, error) {
+	allowed, retryAfter, err := f.primary.Allow(ctx, key, limit, window)
+	if err == nil {
+		return allowed, retryAfter, nil
+	}
+	logger.Debugf("限流器不可用，改用进程内限流: key=%s, error=%v", key, err)
+	return f.fallback.Allow(ctx, key, limit, window)
+}
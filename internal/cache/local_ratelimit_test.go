@@ -0,0 +1,59 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalRateLimiter(t *testing.T) {
+	now := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	limiter := NewLocalRateLimiter()
+	limiter.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		allowed, _, err := limiter.Allow(ctx, "ip:1", 2, time.Minute)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+	}
+	allowed, wait, err := limiter.Allow(ctx, "ip:1", 2, time.Minute)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, 30*time.Second, wait)
+
+	// 其他键不受影响，半个窗口后补充一个令牌
+	allowed, _, _ = limiter.Allow(ctx, "ip:2", 2, time.Minute)
+	assert.True(t, allowed)
+	now = now.Add(30 * time.Second)
+	allowed, _, _ = limiter.Allow(ctx, "ip:1", 2, time.Minute)
+	assert.True(t, allowed)
+
+	// 闲置的令牌桶被清理
+	now = now.Add(localBucketIdleTTL)
+	_, _, _ = limiter.Allow(ctx, "ip:3", 2, time.Minute)
+	assert.Len(t, limiter.buckets, 1)
+}
+
+// failingRateLimiter 始终返回错误的限流器
+type failingRateLimiter struct{}
+
+func (failingRateLimiter) Allow(ctx context.Context, key string, limit int, window time.Duration) (bool, time.Duration, error) {
+	return false, 0, errors.New("redis down")
+}
+
+func TestFallbackRateLimiter(t *testing.T) {
+	limiter := NewFallbackRateLimiter(failingRateLimiter{}, NewLocalRateLimiter())
+	ctx := context.Background()
+
+	allowed, _, err := limiter.Allow(ctx, "ip:1", 1, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	allowed, _, err = limiter.Allow(ctx, "ip:1", 1, time.Minute)
+	require.NoError(t, err)
+	assert.False(t, allowed)
+}
@@ -0,0 +1,85 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// LoginAttemptStore 登录失败计数存储，用于按用户名临时锁定
+type LoginAttemptStore struct {
+	cache       Cache
+	maxFailures int
+	lockout     time.Duration
+}
+
+// NewLoginAttemptStore 创建登录失败计数存储
+func NewLoginAttemptStore(cache Cache, maxFailures int, lockout time.Duration) *LoginAttemptStore {
+	return &LoginAttemptStore{
+		cache:       cache,
+		maxFailures: maxFailures,
+		lockout:     lockout,
+	}
+}
+
+// failureKey 构建失败计数键
+func (s *LoginAttemptStore) failureKey(username string) string {
+	return "login:failures:" + username
+}
+
+// LockedFor 返回用户名剩余的锁定时长，未锁定时返回0
+func (s *LoginAttemptStore) LockedFor(ctx context.Context, username string) (time.Duration, error) {
+	if s.maxFailures <= 0 {
+		return 0, nil
+	}
+
+	data, err := s.cache.Get(ctx, s.failureKey(username))
+	if err != nil {
+		if errors.Is(err, ErrCacheKeyNotFound) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	failures, err := strconv.Atoi(string(data))
+	if err != nil || failures < s.maxFailures {
+		return 0, nil
+	}
+
+	ttl, err := s.cache.TTL(ctx, s.failureKey(username))
+	if err != nil {
+		return 0, err
+	}
+	if ttl <= 0 {
+		return s.lockout, nil
+	}
+	return ttl, nil
+}
+
+// RecordFailure 记录一次登录失败，返回是否因此进入锁定状态
+func (s *LoginAttemptStore) RecordFailure(ctx context.Context, username string) (bool, error) {
+	if s.maxFailures <= 0 {
+		return false, nil
+	}
+
+	key := s.failureKey(username)
+	failures, err := s.cache.Increment(ctx, key, 1)
+	if err != nil {
+		return false, err
+	}
+
+	// 首次失败开始计时，达到上限时重新计算锁定时长
+	if failures == 1 || failures == int64(s.maxFailures) {
+		if err := s.cache.Expire(ctx, key, s.lockout); err != nil {
+			return false, err
+		}
+	}
+
+	return failures >= int64(s.maxFailures), nil
+}
+
+// Reset 清除用户名的失败计数
+func (s *LoginAttemptStore) Reset(ctx context.Context, username string) error {
+	return s.cache.Delete(ctx, s.failureKey(username))
+}
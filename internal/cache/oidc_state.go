@@ -0,0 +1,49 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// OIDCStateStore 单点登录跳转的state存储，state对应本次登录的nonce
+type OIDCStateStore struct {
+	cache Cache
+	ttl   time.Duration
+}
+
+// NewOIDCStateStore 创建单点登录state存储
+func NewOIDCStateStore(cache Cache, ttl time.Duration) *OIDCStateStore {
+	return &OIDCStateStore{cache: cache, ttl: ttl}
+}
+
+// stateKey 构建state键
+func (s *OIDCStateStore) stateKey(state string) string {
+	return "auth:oidc_state:" + state
+}
+
+// Save 登记一次登录跳转的state和nonce
+func (s *OIDCStateStore) Save(ctx context.Context, state, nonce string) error {
+	if err := s.cache.Set(ctx, s.stateKey(state), []byte(nonce), s.ttl); err != nil {
+		return fmt.Errorf("保存登录state失败: %w", err)
+	}
+	return nil
+}
+
+// Consume 取出state对应的nonce并删除state，每个state只能使用一次
+// state未登记、已过期或已使用时返回空字符串
+func (s *OIDCStateStore) Consume(ctx context.Context, state string) (string, error) {
+	key := s.stateKey(state)
+	data, err := s.cache.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, ErrCacheKeyNotFound) {
+			return "", nil
+		}
+		return "", fmt.Errorf("查询登录state失败: %w", err)
+	}
+	if err := s.cache.Delete(ctx, key); err != nil {
+		return "", fmt.Errorf("删除登录state失败: %w", err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,30 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOIDCStateStore_ConsumeOnce(t *testing.T) {
+	ctx := context.Background()
+	store := NewOIDCStateStore(newMemoryCache(), 10*time.Minute)
+	require.NoError(t, store.Save(ctx, "state-1", "nonce-1"))
+
+	// 未登记的state
+	nonce, err := store.Consume(ctx, "state-2")
+	require.NoError(t, err)
+	assert.Empty(t, nonce)
+
+	nonce, err = store.Consume(ctx, "state-1")
+	require.NoError(t, err)
+	assert.Equal(t, "nonce-1", nonce)
+
+	// 同一个state不能重复使用
+	nonce, err = store.Consume(ctx, "state-1")
+	require.NoError(t, err)
+	assert.Empty(t, nonce)
+}
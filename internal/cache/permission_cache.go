@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// permissionKeyPrefix 用户有效权限集合键前缀
+const permissionKeyPrefix = "perm:user:"
+
+// PermissionCache 用户有效权限集合缓存，权限以 resource:action 形式存储
+type PermissionCache struct {
+	cache      Cache
+	ttl        time.Duration
+	noFallback bool
+	hits       atomic.Int64
+	misses     atomic.Int64
+}
+
+// NewPermissionCache 创建权限缓存
+func NewPermissionCache(cache Cache, ttl time.Duration) *PermissionCache {
+	return &PermissionCache{cache: cache, ttl: ttl}
+}
+
+// DisableFallback 缓存读取失败时不再回源数据库，权限检查直接返回错误
+func (p *PermissionCache) DisableFallback() {
+	p.noFallback = true
+}
+
+// Fallback 缓存读取失败时是否回源数据库，默认回源
+func (p *PermissionCache) Fallback() bool {
+	return !p.noFallback
+}
+
+// permissionKey 构建用户权限集合键
+func (p *PermissionCache) permissionKey(userID uint) string {
+	return fmt.Sprintf("%s%d", permissionKeyPrefix, userID)
+}
+
+// Get 读取用户权限集合，未命中时返回false
+func (p *PermissionCache) Get(ctx context.Context, userID uint) ([]string, bool, error) {
+	data, err := p.cache.Get(ctx, p.permissionKey(userID))
+	if err != nil {
+		if errors.Is(err, ErrCacheKeyNotFound) {
+			p.misses.Add(1)
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("读取权限缓存失败: %w", err)
+	}
+
+	var permissions []string
+	if err := json.Unmarshal(data, &permissions); err != nil {
+		// 缓存内容损坏时按未命中处理，由调用方回源后覆盖
+		p.misses.Add(1)
+		return nil, false, nil
+	}
+	p.hits.Add(1)
+	return permissions, true, nil
+}
+
+// Set 写入用户权限集合
+func (p *PermissionCache) Set(ctx context.Context, userID uint, permissions []string) error {
+	data, err := json.Marshal(permissions)
+	if err != nil {
+		return fmt.Errorf("序列化权限缓存失败: %w", err)
+	}
+	if err := p.cache.Set(ctx, p.permissionKey(userID), data, p.ttl); err != nil {
+		return fmt.Errorf("写入权限缓存失败: %w", err)
+	}
+	return nil
+}
+
+// Invalidate 删除用户权限集合
+func (p *PermissionCache) Invalidate(ctx context.Context, userID uint) error {
+	if err := p.cache.Delete(ctx, p.permissionKey(userID)); err != nil {
+		return fmt.Errorf("删除权限缓存失败: %w", err)
+	}
+	return nil
+}
+
+// InvalidateAll 删除全部用户的权限集合，用于角色权限变更
+func (p *PermissionCache) InvalidateAll(ctx context.Context) error {
+	if err := p.cache.DeleteByPattern(ctx, permissionKeyPrefix+"*"); err != nil {
+		return fmt.Errorf("清空权限缓存失败: %w", err)
+	}
+	return nil
+}
+
+// Stats 返回命中与未命中次数
+func (p *PermissionCache) Stats() CacheStats {
+	hits, misses := p.hits.Load(), p.misses.Load()
+	stats := CacheStats{Hits: hits, Misses: misses}
+	if total := hits + misses; total > 0 {
+		stats.HitRate = float64(hits) / float64(total)
+	}
+	return stats
+}
@@ -0,0 +1,46 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPermissionCache(t *testing.T) {
+	ctx := context.Background()
+	permissionCache := NewPermissionCache(newMemoryCache(), time.Minute)
+
+	_, ok, err := permissionCache.Get(ctx, 1)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, permissionCache.Set(ctx, 1, []string{"tasks:read", "tasks:write"}))
+	require.NoError(t, permissionCache.Set(ctx, 2, []string{}))
+
+	permissions, ok, err := permissionCache.Get(ctx, 1)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"tasks:read", "tasks:write"}, permissions)
+
+	// 空权限集合同样被缓存，避免无权限用户每次回源
+	permissions, ok, err = permissionCache.Get(ctx, 2)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Empty(t, permissions)
+
+	require.NoError(t, permissionCache.Invalidate(ctx, 1))
+	_, ok, _ = permissionCache.Get(ctx, 1)
+	assert.False(t, ok)
+
+	require.NoError(t, permissionCache.InvalidateAll(ctx))
+	_, ok, _ = permissionCache.Get(ctx, 2)
+	assert.False(t, ok)
+
+	stats := permissionCache.Stats()
+	assert.Equal(t, int64(2), stats.Hits)
+	assert.Equal(t, int64(3), stats.Misses)
+	assert.InDelta(t, 0.4, stats.HitRate, 1e-9)
+}
@@ -0,0 +1,96 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"taskmanage/internal/cache"
+	"taskmanage/pkg/logger"
+)
+
+// tokenBucketScript 令牌桶脚本，在Redis中原子地完成补充令牌和扣减
+// KEYS[1] 桶键; ARGV[1] 容量; ARGV[2] 每毫秒补充的令牌数; ARGV[3] 当前毫秒时间戳; ARGV[4] 键过期毫秒数
+// 返回 {是否允许, 需要等待的毫秒数}
+var tokenBucketScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local ttl = tonumber(ARGV[4])
+
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(bucket[1])
+local ts = tonumber(bucket[2])
+if tokens == nil or ts == nil then
+	tokens = capacity
+	ts = now
+end
+
+local elapsed = math.max(0, now - ts)
+tokens = math.min(capacity, tokens + elapsed * rate)
+
+local allowed = 0
+local wait = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	wait = math.ceil((1 - tokens) / rate)
+end
+
+redis.call('HMSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(now))
+redis.call('PEXPIRE', KEYS[1], ttl)
+return {allowed, wait}
+`)
+
+// TokenBucketLimiter 基于Redis的令牌桶限流器，多个实例共享同一份限流状态
+type TokenBucketLimiter struct {
+	client     *redis.Client
+	prefix     string
+	resilience *resilience
+}
+
+// NewTokenBucketLimiter 使用已有的Redis连接创建令牌桶限流器，与缓存共用熔断器
+func NewTokenBucketLimiter(redisCache *RedisCache) cache.RateLimiter {
+	return &TokenBucketLimiter{
+		client:     redisCache.client,
+		prefix:     redisCache.prefix + "ratelimit:",
+		resilience: redisCache.resilience,
+	}
+}
+
+// Allow 判断请求是否允许通过，桶容量为limit，每个window补满
+func (l *TokenBucketLimiter) Allow(ctx context.Context, key string, limit int, window time.Duration) (bool, time.Duration, error) {
+	if limit <= 0 {
+		return true, 0, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	rate := float64(limit) / float64(window.Milliseconds())
+	now := time.Now().UnixMilli()
+
+	// 脚本会扣减令牌，失败时不重试
+	var result interface{}
+	err := l.resilience.doOnce(ctx, func(ctx context.Context) (err error) {
+		result, err = tokenBucketScript.Run(ctx, l.client, []string{l.prefix + key},
+			limit, rate, now, window.Milliseconds()).Result()
+		return err
+	})
+	if err != nil {
+		logger.Errorf("Redis限流脚本执行失败: %v", err)
+		return false, 0, cache.ErrCacheConnection.WithCause(err)
+	}
+
+	values, ok := result.([]interface{})
+	if !ok || len(values) != 2 {
+		return false, 0, fmt.Errorf("限流脚本返回格式错误: %v", result)
+	}
+
+	allowed, _ := values[0].(int64)
+	waitMs, _ := values[1].(int64)
+	return allowed == 1, time.Duration(waitMs) * time.Millisecond, nil
+}
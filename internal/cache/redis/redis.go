@@ -14,13 +14,14 @@ import (
 	"taskmanage/pkg/logger"
 )
 
-// RedisCache Redis缓存实现
+// RedisCache Redis缓存实现，调用经过重试与熔断，熔断期间直接返回ErrCacheConnection
 type RedisCache struct {
-	client *redis.Client
-	prefix string
+	client     *redis.Client
+	prefix     string
+	resilience *resilience
 }
 
-// NewRedisCache 创建Redis缓存
+// NewRedisCache 创建Redis缓存，重试由resilience按配置处理，关闭客户端自带的重试
 func NewRedisCache(cfg *config.Config) (*RedisCache, error) {
 	rdb := redis.NewClient(&redis.Options{
 		Addr:         cfg.GetRedisAddr(),
@@ -28,7 +29,7 @@ func NewRedisCache(cfg *config.Config) (*RedisCache, error) {
 		DB:           cfg.Redis.Database,
 		PoolSize:     cfg.Redis.PoolSize,
 		MinIdleConns: cfg.Redis.MinIdleConn,
-		MaxRetries:   cfg.Redis.MaxRetries,
+		MaxRetries:   -1,
 		DialTimeout:  5 * time.Second,
 		ReadTimeout:  3 * time.Second,
 		WriteTimeout: 3 * time.Second,
@@ -45,11 +46,17 @@ func NewRedisCache(cfg *config.Config) (*RedisCache, error) {
 	}
 
 	return &RedisCache{
-		client: rdb,
-		prefix: "taskmanage:",
+		client:     rdb,
+		prefix:     "taskmanage:",
+		resilience: newResilience(cfg.Redis),
 	}, nil
 }
 
+// BreakerSnapshot 返回熔断器当前状态
+func (r *RedisCache) BreakerSnapshot() BreakerSnapshot {
+	return r.resilience.breaker.snapshot()
+}
+
 // buildKey 构建带前缀的键
 func (r *RedisCache) buildKey(key string) string {
 	return r.prefix + key
@@ -60,7 +67,11 @@ func (r *RedisCache) Get(ctx context.Context, key string) ([]byte, error) {
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	result, err := r.client.Get(ctx, r.buildKey(key)).Bytes()
+	var result []byte
+	err := r.resilience.do(ctx, func(ctx context.Context) (err error) {
+		result, err = r.client.Get(ctx, r.buildKey(key)).Bytes()
+		return err
+	})
 	if err != nil {
 		if err == redis.Nil {
 			return nil, cache.ErrCacheKeyNotFound
@@ -77,7 +88,10 @@ func (r *RedisCache) Set(ctx context.Context, key string, value []byte, ttl time
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	if err := r.client.Set(ctx, r.buildKey(key), value, ttl).Err(); err != nil {
+	err := r.resilience.do(ctx, func(ctx context.Context) error {
+		return r.client.Set(ctx, r.buildKey(key), value, ttl).Err()
+	})
+	if err != nil {
 		logger.Errorf("Redis SET失败: %v", err)
 		return cache.ErrCacheConnection.WithCause(err)
 	}
@@ -90,7 +104,10 @@ func (r *RedisCache) Delete(ctx context.Context, key string) error {
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	if err := r.client.Del(ctx, r.buildKey(key)).Err(); err != nil {
+	err := r.resilience.do(ctx, func(ctx context.Context) error {
+		return r.client.Del(ctx, r.buildKey(key)).Err()
+	})
+	if err != nil {
 		logger.Errorf("Redis DEL失败: %v", err)
 		return cache.ErrCacheConnection.WithCause(err)
 	}
@@ -103,7 +120,11 @@ func (r *RedisCache) Exists(ctx context.Context, key string) (bool, error) {
 	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
 	defer cancel()
 
-	count, err := r.client.Exists(ctx, r.buildKey(key)).Result()
+	var count int64
+	err := r.resilience.do(ctx, func(ctx context.Context) (err error) {
+		count, err = r.client.Exists(ctx, r.buildKey(key)).Result()
+		return err
+	})
 	if err != nil {
 		logger.Errorf("Redis EXISTS失败: %v", err)
 		return false, cache.ErrCacheConnection.WithCause(err)
@@ -127,7 +148,11 @@ func (r *RedisCache) MGet(ctx context.Context, keys []string) (map[string][]byte
 		prefixedKeys[i] = r.buildKey(key)
 	}
 
-	values, err := r.client.MGet(ctx, prefixedKeys...).Result()
+	var values []interface{}
+	err := r.resilience.do(ctx, func(ctx context.Context) (err error) {
+		values, err = r.client.MGet(ctx, prefixedKeys...).Result()
+		return err
+	})
 	if err != nil {
 		logger.Errorf("Redis MGET失败: %v", err)
 		return nil, cache.ErrCacheConnection.WithCause(err)
@@ -154,14 +179,15 @@ func (r *RedisCache) MSet(ctx context.Context, items map[string][]byte, ttl time
 		return nil
 	}
 
-	// 使用Pipeline提高性能
-	pipe := r.client.Pipeline()
-
-	for key, value := range items {
-		pipe.Set(ctx, r.buildKey(key), value, ttl)
-	}
-
-	_, err := pipe.Exec(ctx)
+	// 使用Pipeline提高性能，重试时整批重新执行，SET可重复执行
+	err := r.resilience.do(ctx, func(ctx context.Context) error {
+		pipe := r.client.Pipeline()
+		for key, value := range items {
+			pipe.Set(ctx, r.buildKey(key), value, ttl)
+		}
+		_, err := pipe.Exec(ctx)
+		return err
+	})
 	if err != nil {
 		logger.Errorf("Redis MSET失败: %v", err)
 		return cache.ErrCacheConnection.WithCause(err)
@@ -185,7 +211,10 @@ func (r *RedisCache) MDelete(ctx context.Context, keys []string) error {
 		prefixedKeys[i] = r.buildKey(key)
 	}
 
-	if err := r.client.Del(ctx, prefixedKeys...).Err(); err != nil {
+	err := r.resilience.do(ctx, func(ctx context.Context) error {
+		return r.client.Del(ctx, prefixedKeys...).Err()
+	})
+	if err != nil {
 		logger.Errorf("Redis MDEL失败: %v", err)
 		return cache.ErrCacheConnection.WithCause(err)
 	}
@@ -193,12 +222,16 @@ func (r *RedisCache) MDelete(ctx context.Context, keys []string) error {
 	return nil
 }
 
-// Increment 递增
+// Increment 递增，失败时无法确认是否已执行，只经过熔断不重试
 func (r *RedisCache) Increment(ctx context.Context, key string, delta int64) (int64, error) {
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	result, err := r.client.IncrBy(ctx, r.buildKey(key), delta).Result()
+	var result int64
+	err := r.resilience.doOnce(ctx, func(ctx context.Context) (err error) {
+		result, err = r.client.IncrBy(ctx, r.buildKey(key), delta).Result()
+		return err
+	})
 	if err != nil {
 		logger.Errorf("Redis INCRBY失败: %v", err)
 		return 0, cache.ErrCacheConnection.WithCause(err)
@@ -217,7 +250,10 @@ func (r *RedisCache) Expire(ctx context.Context, key string, ttl time.Duration)
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	if err := r.client.Expire(ctx, r.buildKey(key), ttl).Err(); err != nil {
+	err := r.resilience.do(ctx, func(ctx context.Context) error {
+		return r.client.Expire(ctx, r.buildKey(key), ttl).Err()
+	})
+	if err != nil {
 		logger.Errorf("Redis EXPIRE失败: %v", err)
 		return cache.ErrCacheConnection.WithCause(err)
 	}
@@ -230,7 +266,11 @@ func (r *RedisCache) TTL(ctx context.Context, key string) (time.Duration, error)
 	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
 	defer cancel()
 
-	ttl, err := r.client.TTL(ctx, r.buildKey(key)).Result()
+	var ttl time.Duration
+	err := r.resilience.do(ctx, func(ctx context.Context) (err error) {
+		ttl, err = r.client.TTL(ctx, r.buildKey(key)).Result()
+		return err
+	})
 	if err != nil {
 		logger.Errorf("Redis TTL失败: %v", err)
 		return 0, cache.ErrCacheConnection.WithCause(err)
@@ -244,7 +284,11 @@ func (r *RedisCache) Keys(ctx context.Context, pattern string) ([]string, error)
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	keys, err := r.client.Keys(ctx, r.buildKey(pattern)).Result()
+	var keys []string
+	err := r.resilience.do(ctx, func(ctx context.Context) (err error) {
+		keys, err = r.client.Keys(ctx, r.buildKey(pattern)).Result()
+		return err
+	})
 	if err != nil {
 		logger.Errorf("Redis KEYS失败: %v", err)
 		return nil, cache.ErrCacheConnection.WithCause(err)
@@ -281,7 +325,10 @@ func (r *RedisCache) Ping(ctx context.Context) error {
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
 	defer cancel()
 
-	if err := r.client.Ping(ctx).Err(); err != nil {
+	err := r.resilience.do(ctx, func(ctx context.Context) error {
+		return r.client.Ping(ctx).Err()
+	})
+	if err != nil {
 		return cache.ErrCacheConnection.WithCause(err)
 	}
 
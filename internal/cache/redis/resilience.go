@@ -0,0 +1,284 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+
+	"taskmanage/internal/config"
+	"taskmanage/pkg/logger"
+)
+
+// 熔断器状态
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half_open"
+)
+
+// 重试与熔断的默认参数，配置为0时使用
+const (
+	defaultRetryBackoff     = 50 * time.Millisecond
+	defaultRetryMaxBackoff  = 500 * time.Millisecond
+	defaultBreakerThreshold = 5
+	defaultBreakerOpen      = 30 * time.Second
+)
+
+// errBreakerOpen 熔断期间不访问Redis直接返回的错误
+var errBreakerOpen = errors.New("Redis熔断中，暂停访问")
+
+// transientReplyPrefixes Redis服务端返回的可重试错误前缀
+var transientReplyPrefixes = []string{"LOADING", "READONLY", "MASTERDOWN", "TRYAGAIN", "CLUSTERDOWN"}
+
+// BreakerSnapshot 熔断器当前状态
+type BreakerSnapshot struct {
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	OpenedAt            *time.Time `json:"opened_at,omitempty"`
+}
+
+// circuitBreaker 连续失败threshold次后打开，打开openDuration后半开放行一次探测请求，
+// 探测成功关闭，失败重新打开
+type circuitBreaker struct {
+	mu           sync.Mutex
+	name         string
+	threshold    int
+	openDuration time.Duration
+	now          func() time.Time
+
+	state    string
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func newCircuitBreaker(name string, threshold int, openDuration time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		name:         name,
+		threshold:    threshold,
+		openDuration: openDuration,
+		now:          time.Now,
+		state:        BreakerClosed,
+	}
+}
+
+// allow 请求是否可以访问Redis，打开期满后只放行一个探测请求
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if b.now().Sub(b.openedAt) < b.openDuration {
+			return false
+		}
+		b.transition(BreakerHalfOpen)
+		b.probing = true
+		return true
+	case BreakerHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// success 记录一次成功访问
+func (b *circuitBreaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.probing = false
+	if b.state != BreakerClosed {
+		b.transition(BreakerClosed)
+	}
+}
+
+// failure 记录一次失败访问，半开探测失败或连续失败达到阈值时打开
+func (b *circuitBreaker) failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+	if b.state == BreakerHalfOpen || (b.state == BreakerClosed && b.failures >= b.threshold) {
+		b.openedAt = b.now()
+		b.transition(BreakerOpen)
+	}
+}
+
+// release 放弃本次探测，调用方在未得出结论时（如请求被取消）归还探测名额
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// transition 切换状态并记录结构化日志，调用方需持有锁
+func (b *circuitBreaker) transition(to string) {
+	from := b.state
+	b.state = to
+	entry := logger.WithFields(logrus.Fields{
+		"event":    "redis_breaker_state_change",
+		"breaker":  b.name,
+		"from":     from,
+		"to":       to,
+		"failures": b.failures,
+	})
+	if to == BreakerOpen {
+		entry.Warn("Redis熔断器打开")
+		return
+	}
+	entry.Info("Redis熔断器状态变化")
+}
+
+// snapshot 返回熔断器当前状态
+func (b *circuitBreaker) snapshot() BreakerSnapshot {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	snapshot := BreakerSnapshot{State: b.state, ConsecutiveFailures: b.failures}
+	if b.state != BreakerClosed {
+		openedAt := b.openedAt
+		snapshot.OpenedAt = &openedAt
+	}
+	return snapshot
+}
+
+// resilience 为Redis调用提供有限次数的抖动退避重试和熔断，多个使用同一连接的组件共享
+type resilience struct {
+	breaker    *circuitBreaker
+	maxRetries int
+	backoff    time.Duration
+	maxBackoff time.Duration
+	sleep      func(ctx context.Context, d time.Duration) error
+}
+
+// newResilience 按Redis配置创建重试与熔断策略，未配置的参数使用默认值
+func newResilience(cfg config.RedisConfig) *resilience {
+	backoff := time.Duration(cfg.RetryBackoffMs) * time.Millisecond
+	if backoff <= 0 {
+		backoff = defaultRetryBackoff
+	}
+	maxBackoff := time.Duration(cfg.RetryMaxBackoffMs) * time.Millisecond
+	if maxBackoff <= 0 {
+		maxBackoff = defaultRetryMaxBackoff
+	}
+	threshold := cfg.BreakerFailureThreshold
+	if threshold <= 0 {
+		threshold = defaultBreakerThreshold
+	}
+	openDuration := time.Duration(cfg.BreakerOpenSeconds) * time.Second
+	if openDuration <= 0 {
+		openDuration = defaultBreakerOpen
+	}
+	return &resilience{
+		breaker:    newCircuitBreaker("redis", threshold, openDuration),
+		maxRetries: cfg.MaxRetries,
+		backoff:    backoff,
+		maxBackoff: maxBackoff,
+		sleep:      sleepContext,
+	}
+}
+
+// do 执行一次Redis调用，临时性错误按退避重试，重试用尽后计入熔断失败；
+// redis.Nil和命令本身的错误说明Redis可用，原样返回且不重试。熔断打开时直接返回errBreakerOpen
+func (r *resilience) do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return r.run(ctx, r.maxRetries, fn)
+}
+
+// doOnce 同do但不重试，用于INCRBY等重复执行会改变结果的命令
+func (r *resilience) doOnce(ctx context.Context, fn func(ctx context.Context) error) error {
+	return r.run(ctx, 0, fn)
+}
+
+// run 执行调用，临时性错误最多重试maxRetries次
+func (r *resilience) run(ctx context.Context, maxRetries int, fn func(ctx context.Context) error) error {
+	if !r.breaker.allow() {
+		return errBreakerOpen
+	}
+
+	var err error
+	for attempt := 0; ; attempt++ {
+		err = fn(ctx)
+		if err == nil || !isTransientError(err) {
+			r.breaker.success()
+			return err
+		}
+		// 调用方取消不代表Redis故障；超时说明Redis响应过慢，计入失败
+		if errors.Is(ctx.Err(), context.Canceled) {
+			r.breaker.release()
+			return err
+		}
+		if ctx.Err() != nil || attempt >= maxRetries {
+			break
+		}
+		if sleepErr := r.sleep(ctx, r.backoffFor(attempt)); errors.Is(sleepErr, context.Canceled) {
+			r.breaker.release()
+			return err
+		} else if sleepErr != nil {
+			break
+		}
+	}
+
+	r.breaker.failure()
+	return err
+}
+
+// backoffFor 第attempt次重试前的等待时长，指数增长到maxBackoff，在一半到全额之间随机抖动
+func (r *resilience) backoffFor(attempt int) time.Duration {
+	backoff := r.backoff << attempt
+	if backoff <= 0 || backoff > r.maxBackoff {
+		backoff = r.maxBackoff
+	}
+	half := backoff / 2
+	return half + time.Duration(rand.Int63n(int64(backoff-half)+1))
+}
+
+// isTransientError 网络、超时、连接池耗尽及主从切换期间的错误可以重试
+func isTransientError(err error) bool {
+	if errors.Is(err, redis.Nil) {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, net.ErrClosed) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	message := err.Error()
+	if strings.Contains(message, "connection pool timeout") {
+		return true
+	}
+	for _, prefix := range transientReplyPrefixes {
+		if strings.HasPrefix(message, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// sleepContext 等待d，ctx结束时提前返回
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
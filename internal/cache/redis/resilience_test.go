@@ -0,0 +1,132 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/cache"
+	"taskmanage/internal/config"
+)
+
+// errNetTimeout 模拟网络超时
+var errNetTimeout = &timeoutError{}
+
+type timeoutError struct{}
+
+func (e *timeoutError) Error() string   { return "dial tcp: i/o timeout" }
+func (e *timeoutError) Timeout() bool   { return true }
+func (e *timeoutError) Temporary() bool { return true }
+
+// newTestResilience 使用固定时钟且不真正等待的重试与熔断策略
+func newTestResilience(now *time.Time) (*resilience, *[]time.Duration) {
+	r := newResilience(config.RedisConfig{MaxRetries: 2, BreakerFailureThreshold: 2, BreakerOpenSeconds: 10})
+	r.breaker.now = func() time.Time { return *now }
+	var sleeps []time.Duration
+	r.sleep = func(ctx context.Context, d time.Duration) error {
+		sleeps = append(sleeps, d)
+		return nil
+	}
+	return r, &sleeps
+}
+
+func TestResilience_RetryAndBreaker(t *testing.T) {
+	now := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	r, sleeps := newTestResilience(&now)
+	ctx := context.Background()
+
+	// 临时性错误重试后成功
+	calls := 0
+	err := r.do(ctx, func(ctx context.Context) error {
+		calls++
+		if calls < 3 {
+			return errNetTimeout
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, calls)
+	require.Len(t, *sleeps, 2)
+	assert.True(t, (*sleeps)[0] >= 25*time.Millisecond && (*sleeps)[0] <= 50*time.Millisecond)
+	assert.True(t, (*sleeps)[1] >= 50*time.Millisecond && (*sleeps)[1] <= 100*time.Millisecond)
+
+	// redis.Nil和命令错误不重试也不计入失败
+	calls = 0
+	assert.ErrorIs(t, r.do(ctx, func(ctx context.Context) error { calls++; return redis.Nil }), redis.Nil)
+	assert.Error(t, r.do(ctx, func(ctx context.Context) error { calls++; return errors.New("WRONGTYPE Operation against a key") }))
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, BreakerClosed, r.breaker.snapshot().State)
+
+	// 重试用尽连续失败两次后熔断，熔断期间不调用Redis
+	failing := func(ctx context.Context) error { calls++; return errNetTimeout }
+	calls = 0
+	assert.ErrorIs(t, r.do(ctx, failing), errNetTimeout)
+	assert.Equal(t, 3, calls)
+	assert.ErrorIs(t, r.do(ctx, failing), errNetTimeout)
+	snapshot := r.breaker.snapshot()
+	assert.Equal(t, BreakerOpen, snapshot.State)
+	assert.Equal(t, 2, snapshot.ConsecutiveFailures)
+	calls = 0
+	assert.ErrorIs(t, r.do(ctx, failing), errBreakerOpen)
+	assert.Zero(t, calls)
+
+	// 到期后半开只放行一个探测请求，探测失败重新熔断
+	now = now.Add(10 * time.Second)
+	assert.ErrorIs(t, r.doOnce(ctx, failing), errNetTimeout)
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, BreakerOpen, r.breaker.snapshot().State)
+
+	// 探测成功后恢复
+	now = now.Add(10 * time.Second)
+	require.True(t, r.breaker.allow())
+	assert.Equal(t, BreakerHalfOpen, r.breaker.snapshot().State)
+	assert.False(t, r.breaker.allow())
+	r.breaker.success()
+	assert.Equal(t, BreakerSnapshot{State: BreakerClosed}, r.breaker.snapshot())
+}
+
+func TestResilience_CanceledDoesNotCount(t *testing.T) {
+	now := time.Now()
+	r, _ := newTestResilience(&now)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	for i := 0; i < 3; i++ {
+		err := r.do(ctx, func(ctx context.Context) error {
+			cancel()
+			return errNetTimeout
+		})
+		assert.Error(t, err)
+	}
+	assert.Equal(t, BreakerSnapshot{State: BreakerClosed}, r.breaker.snapshot())
+}
+
+func TestRedisCache_OpensBreakerWhenUnreachable(t *testing.T) {
+	cfg := config.RedisConfig{MaxRetries: 1, RetryBackoffMs: 1, RetryMaxBackoffMs: 1, BreakerFailureThreshold: 2, BreakerOpenSeconds: 60}
+	redisCache := &RedisCache{
+		client:     redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1, DialTimeout: 200 * time.Millisecond}),
+		prefix:     "test:",
+		resilience: newResilience(cfg),
+	}
+	defer redisCache.Close()
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		_, err := redisCache.Get(ctx, "key")
+		var cacheErr *cache.CacheError
+		require.ErrorAs(t, err, &cacheErr)
+		assert.Equal(t, cache.ErrCacheConnection.Code, cacheErr.Code)
+	}
+	assert.Equal(t, BreakerOpen, redisCache.BreakerSnapshot().State)
+
+	// 熔断期间Ping同样直接失败，限流器与缓存共用熔断器
+	err := redisCache.Ping(ctx)
+	assert.ErrorIs(t, err, errBreakerOpen)
+	_, _, err = NewTokenBucketLimiter(redisCache).Allow(ctx, "user:1", 10, time.Minute)
+	assert.ErrorIs(t, err, errBreakerOpen)
+}
@@ -0,0 +1,283 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// TokenStore 令牌状态存储，负责刷新令牌轮换追踪与访问令牌吊销
+type TokenStore struct {
+	cache Cache
+}
+
+// NewTokenStore 创建令牌状态存储
+func NewTokenStore(cache Cache) *TokenStore {
+	return &TokenStore{cache: cache}
+}
+
+// refreshKey 构建刷新令牌键，按用户和会话分层以便批量吊销
+func (s *TokenStore) refreshKey(userID uint, sessionID, tokenID string) string {
+	return fmt.Sprintf("auth:refresh:%d:%s:%s", userID, sessionID, tokenID)
+}
+
+// refreshUsedKey 构建刷新令牌已使用标记键
+func (s *TokenStore) refreshUsedKey(tokenID string) string {
+	return "auth:refresh_used:" + tokenID
+}
+
+// revokedKey 构建访问令牌黑名单键
+func (s *TokenStore) revokedKey(tokenID string) string {
+	return "auth:revoked:" + tokenID
+}
+
+// sessionKey 构建会话元数据键
+func (s *TokenStore) sessionKey(userID uint, sessionID string) string {
+	return fmt.Sprintf("auth:session:%d:%s", userID, sessionID)
+}
+
+// userRevokedKey 构建用户全部会话吊销时间键
+func (s *TokenStore) userRevokedKey(userID uint) string {
+	return fmt.Sprintf("auth:revoked_before:%d", userID)
+}
+
+// SaveRefreshToken 登记一个有效的刷新令牌
+func (s *TokenStore) SaveRefreshToken(ctx context.Context, userID uint, sessionID, tokenID string, ttl time.Duration) error {
+	if err := s.cache.Set(ctx, s.refreshKey(userID, sessionID, tokenID), []byte("1"), ttl); err != nil {
+		return fmt.Errorf("保存刷新令牌失败: %w", err)
+	}
+	return nil
+}
+
+// ConsumeRefreshToken 消费刷新令牌，每个令牌只能成功消费一次
+// 令牌未登记、已被轮换或已吊销时返回false
+func (s *TokenStore) ConsumeRefreshToken(ctx context.Context, userID uint, sessionID, tokenID string, ttl time.Duration) (bool, error) {
+	key := s.refreshKey(userID, sessionID, tokenID)
+	exists, err := s.cache.Exists(ctx, key)
+	if err != nil {
+		return false, fmt.Errorf("检查刷新令牌失败: %w", err)
+	}
+	if !exists {
+		return false, nil
+	}
+
+	// 通过原子自增抢占使用权，防止并发刷新时同一令牌被使用两次
+	usedKey := s.refreshUsedKey(tokenID)
+	count, err := s.cache.Increment(ctx, usedKey, 1)
+	if err != nil {
+		return false, fmt.Errorf("标记刷新令牌失败: %w", err)
+	}
+	if count != 1 {
+		return false, nil
+	}
+	if err := s.cache.Expire(ctx, usedKey, ttl); err != nil {
+		return false, fmt.Errorf("设置刷新令牌标记过期失败: %w", err)
+	}
+
+	if err := s.cache.Delete(ctx, key); err != nil {
+		return false, fmt.Errorf("删除刷新令牌失败: %w", err)
+	}
+	return true, nil
+}
+
+// Session 登录会话元数据，与会话的刷新令牌同时过期
+type Session struct {
+	ID         string    `json:"id"`
+	UserID     uint      `json:"user_id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	// AccessTokens 会话签发且尚未过期的访问令牌JTI及其过期时间，吊销会话时逐个加入黑名单
+	AccessTokens map[string]time.Time `json:"access_tokens,omitempty"`
+}
+
+// addAccessToken 登记新签发的访问令牌，并移除已过期的令牌
+func (session *Session) addAccessToken(tokenID string, expiresAt, now time.Time) {
+	if session.AccessTokens == nil {
+		session.AccessTokens = make(map[string]time.Time)
+	}
+	for id, tokenExpiresAt := range session.AccessTokens {
+		if !tokenExpiresAt.After(now) {
+			delete(session.AccessTokens, id)
+		}
+	}
+	if tokenID != "" {
+		session.AccessTokens[tokenID] = expiresAt
+	}
+}
+
+// StartSession 登记新会话及登录时签发的访问令牌，ttl应为刷新令牌有效期
+func (s *TokenStore) StartSession(ctx context.Context, session *Session, accessTokenID string, accessExpiresAt time.Time, ttl time.Duration) error {
+	session.addAccessToken(accessTokenID, accessExpiresAt, session.CreatedAt)
+	return s.saveSession(ctx, session, ttl)
+}
+
+// TouchSession 刷新令牌时更新会话最后使用时间并登记新的访问令牌，会话有效期顺延ttl
+// 会话元数据缺失时（如功能上线前登录的会话）按当前请求补建
+func (s *TokenStore) TouchSession(ctx context.Context, userID uint, sessionID, userAgent, ip, accessTokenID string, accessExpiresAt, now time.Time, ttl time.Duration) error {
+	session, err := s.GetSession(ctx, userID, sessionID)
+	if err != nil {
+		return err
+	}
+	if session == nil {
+		session = &Session{ID: sessionID, UserID: userID, UserAgent: userAgent, IP: ip, CreatedAt: now}
+	}
+	session.LastUsedAt = now
+	if ip != "" {
+		session.IP = ip
+	}
+	session.addAccessToken(accessTokenID, accessExpiresAt, now)
+	return s.saveSession(ctx, session, ttl)
+}
+
+// saveSession 写入会话元数据
+func (s *TokenStore) saveSession(ctx context.Context, session *Session, ttl time.Duration) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("序列化会话失败: %w", err)
+	}
+	if err := s.cache.Set(ctx, s.sessionKey(session.UserID, session.ID), data, ttl); err != nil {
+		return fmt.Errorf("保存会话失败: %w", err)
+	}
+	return nil
+}
+
+// GetSession 获取会话元数据，会话不存在或已过期时返回nil
+func (s *TokenStore) GetSession(ctx context.Context, userID uint, sessionID string) (*Session, error) {
+	data, err := s.cache.Get(ctx, s.sessionKey(userID, sessionID))
+	if errors.Is(err, ErrCacheKeyNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("获取会话失败: %w", err)
+	}
+	var session Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, fmt.Errorf("解析会话失败: %w", err)
+	}
+	return &session, nil
+}
+
+// ListSessions 获取用户的全部有效会话，按最后使用时间从近到远排序
+func (s *TokenStore) ListSessions(ctx context.Context, userID uint) ([]*Session, error) {
+	keys, err := s.cache.Keys(ctx, s.sessionKey(userID, "*"))
+	if err != nil {
+		return nil, fmt.Errorf("获取会话列表失败: %w", err)
+	}
+	sessions := make([]*Session, 0, len(keys))
+	if len(keys) == 0 {
+		return sessions, nil
+	}
+
+	values, err := s.cache.MGet(ctx, keys)
+	if err != nil && !errors.Is(err, ErrCacheKeyNotFound) {
+		return nil, fmt.Errorf("获取会话列表失败: %w", err)
+	}
+	for _, key := range keys {
+		data, ok := values[key]
+		if !ok {
+			// 列出键后会话已过期或被吊销
+			continue
+		}
+		var session Session
+		if err := json.Unmarshal(data, &session); err != nil {
+			return nil, fmt.Errorf("解析会话失败: %w", err)
+		}
+		sessions = append(sessions, &session)
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		if !sessions[i].LastUsedAt.Equal(sessions[j].LastUsedAt) {
+			return sessions[i].LastUsedAt.After(sessions[j].LastUsedAt)
+		}
+		return sessions[i].ID < sessions[j].ID
+	})
+	return sessions, nil
+}
+
+// RevokeSession 吊销会话：删除会话下的全部刷新令牌和会话元数据，并将会话签发的未过期访问令牌加入黑名单
+func (s *TokenStore) RevokeSession(ctx context.Context, userID uint, sessionID string) error {
+	session, err := s.GetSession(ctx, userID, sessionID)
+	if err != nil {
+		return err
+	}
+	if session != nil {
+		now := time.Now()
+		for tokenID, expiresAt := range session.AccessTokens {
+			if err := s.RevokeAccessToken(ctx, tokenID, expiresAt.Sub(now)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := s.cache.DeleteByPattern(ctx, s.refreshKey(userID, sessionID, "*")); err != nil {
+		return fmt.Errorf("吊销会话失败: %w", err)
+	}
+	if err := s.cache.Delete(ctx, s.sessionKey(userID, sessionID)); err != nil {
+		return fmt.Errorf("删除会话失败: %w", err)
+	}
+	return nil
+}
+
+// RevokeAccessToken 将访问令牌加入黑名单，ttl应为令牌剩余有效期
+func (s *TokenStore) RevokeAccessToken(ctx context.Context, tokenID string, ttl time.Duration) error {
+	if tokenID == "" || ttl <= 0 {
+		return nil
+	}
+	if err := s.cache.Set(ctx, s.revokedKey(tokenID), []byte("1"), ttl); err != nil {
+		return fmt.Errorf("吊销访问令牌失败: %w", err)
+	}
+	return nil
+}
+
+// RevokeAllForUser 吊销用户的全部会话：删除所有刷新令牌，并使此前签发的访问令牌失效
+// ttl应不小于访问令牌有效期
+func (s *TokenStore) RevokeAllForUser(ctx context.Context, userID uint, ttl time.Duration) error {
+	if err := s.cache.DeleteByPattern(ctx, fmt.Sprintf("auth:refresh:%d:*", userID)); err != nil {
+		return fmt.Errorf("吊销用户刷新令牌失败: %w", err)
+	}
+	if err := s.cache.DeleteByPattern(ctx, s.sessionKey(userID, "*")); err != nil {
+		return fmt.Errorf("删除用户会话失败: %w", err)
+	}
+
+	revokedAt := strconv.FormatInt(time.Now().Unix(), 10)
+	if err := s.cache.Set(ctx, s.userRevokedKey(userID), []byte(revokedAt), ttl); err != nil {
+		return fmt.Errorf("记录用户吊销时间失败: %w", err)
+	}
+	return nil
+}
+
+// IsAccessTokenRevoked 判断访问令牌是否已被吊销
+func (s *TokenStore) IsAccessTokenRevoked(ctx context.Context, userID uint, tokenID string, issuedAt time.Time) (bool, error) {
+	keys := []string{s.userRevokedKey(userID)}
+	if tokenID != "" {
+		keys = append(keys, s.revokedKey(tokenID))
+	}
+
+	values, err := s.cache.MGet(ctx, keys)
+	if err != nil {
+		if errors.Is(err, ErrCacheKeyNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("检查令牌吊销状态失败: %w", err)
+	}
+
+	if tokenID != "" {
+		if _, revoked := values[s.revokedKey(tokenID)]; revoked {
+			return true, nil
+		}
+	}
+
+	if data, ok := values[s.userRevokedKey(userID)]; ok {
+		revokedAt, err := strconv.ParseInt(string(data), 10, 64)
+		if err == nil && issuedAt.Unix() < revokedAt {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
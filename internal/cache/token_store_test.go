@@ -0,0 +1,250 @@
+package cache
+
+import (
+	"context"
+	"path"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryCache 测试用内存缓存，忽略过期时间
+type memoryCache struct {
+	mu   sync.Mutex
+	data map[string][]byte
+}
+
+func newMemoryCache() *memoryCache {
+	return &memoryCache{data: make(map[string][]byte)}
+}
+
+func (m *memoryCache) Get(ctx context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	value, ok := m.data[key]
+	if !ok {
+		return nil, ErrCacheKeyNotFound
+	}
+	return value, nil
+}
+
+func (m *memoryCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data[key] = value
+	return nil
+}
+
+func (m *memoryCache) Delete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.data, key)
+	return nil
+}
+
+func (m *memoryCache) Exists(ctx context.Context, key string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.data[key]
+	return ok, nil
+}
+
+func (m *memoryCache) MGet(ctx context.Context, keys []string) (map[string][]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	result := make(map[string][]byte)
+	for _, key := range keys {
+		if value, ok := m.data[key]; ok {
+			result[key] = value
+		}
+	}
+	return result, nil
+}
+
+func (m *memoryCache) MSet(ctx context.Context, items map[string][]byte, ttl time.Duration) error {
+	for key, value := range items {
+		m.Set(ctx, key, value, ttl)
+	}
+	return nil
+}
+
+func (m *memoryCache) MDelete(ctx context.Context, keys []string) error {
+	for _, key := range keys {
+		m.Delete(ctx, key)
+	}
+	return nil
+}
+
+func (m *memoryCache) Increment(ctx context.Context, key string, delta int64) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	current, _ := strconv.ParseInt(string(m.data[key]), 10, 64)
+	current += delta
+	m.data[key] = []byte(strconv.FormatInt(current, 10))
+	return current, nil
+}
+
+func (m *memoryCache) Decrement(ctx context.Context, key string, delta int64) (int64, error) {
+	return m.Increment(ctx, key, -delta)
+}
+
+func (m *memoryCache) Expire(ctx context.Context, key string, ttl time.Duration) error {
+	return nil
+}
+
+func (m *memoryCache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	return -1, nil
+}
+
+func (m *memoryCache) Keys(ctx context.Context, pattern string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var keys []string
+	for key := range m.data {
+		if matched, _ := path.Match(pattern, key); matched {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func (m *memoryCache) DeleteByPattern(ctx context.Context, pattern string) error {
+	keys, _ := m.Keys(ctx, pattern)
+	return m.MDelete(ctx, keys)
+}
+
+func (m *memoryCache) Ping(ctx context.Context) error { return nil }
+
+func (m *memoryCache) Close() error { return nil }
+
+func TestTokenStore_RotatedRefreshTokenRejected(t *testing.T) {
+	ctx := context.Background()
+	store := NewTokenStore(newMemoryCache())
+
+	require.NoError(t, store.SaveRefreshToken(ctx, 1, "session-a", "token-1", time.Hour))
+
+	// 首次刷新成功，并登记轮换后的新令牌
+	consumed, err := store.ConsumeRefreshToken(ctx, 1, "session-a", "token-1", time.Hour)
+	require.NoError(t, err)
+	assert.True(t, consumed)
+	require.NoError(t, store.SaveRefreshToken(ctx, 1, "session-a", "token-2", time.Hour))
+
+	// 旧令牌重复使用被拒绝
+	consumed, err = store.ConsumeRefreshToken(ctx, 1, "session-a", "token-1", time.Hour)
+	require.NoError(t, err)
+	assert.False(t, consumed)
+
+	// 新令牌可以正常使用
+	consumed, err = store.ConsumeRefreshToken(ctx, 1, "session-a", "token-2", time.Hour)
+	require.NoError(t, err)
+	assert.True(t, consumed)
+}
+
+func TestTokenStore_RevokeSessionAndUser(t *testing.T) {
+	ctx := context.Background()
+	store := NewTokenStore(newMemoryCache())
+
+	require.NoError(t, store.SaveRefreshToken(ctx, 1, "session-a", "token-a", time.Hour))
+	require.NoError(t, store.SaveRefreshToken(ctx, 1, "session-b", "token-b", time.Hour))
+	require.NoError(t, store.SaveRefreshToken(ctx, 2, "session-c", "token-c", time.Hour))
+
+	// 吊销单个会话不影响其它会话
+	require.NoError(t, store.RevokeSession(ctx, 1, "session-a"))
+	consumed, err := store.ConsumeRefreshToken(ctx, 1, "session-a", "token-a", time.Hour)
+	require.NoError(t, err)
+	assert.False(t, consumed)
+
+	// 下线全部会话后，此前签发的访问令牌和刷新令牌均失效，其他用户不受影响
+	issuedAt := time.Now().Add(-time.Minute)
+	require.NoError(t, store.RevokeAllForUser(ctx, 1, time.Hour))
+
+	consumed, err = store.ConsumeRefreshToken(ctx, 1, "session-b", "token-b", time.Hour)
+	require.NoError(t, err)
+	assert.False(t, consumed)
+
+	revoked, err := store.IsAccessTokenRevoked(ctx, 1, "access-1", issuedAt)
+	require.NoError(t, err)
+	assert.True(t, revoked)
+
+	revoked, err = store.IsAccessTokenRevoked(ctx, 2, "access-2", issuedAt)
+	require.NoError(t, err)
+	assert.False(t, revoked)
+
+	consumed, err = store.ConsumeRefreshToken(ctx, 2, "session-c", "token-c", time.Hour)
+	require.NoError(t, err)
+	assert.True(t, consumed)
+}
+
+func TestTokenStore_RevokeAccessToken(t *testing.T) {
+	ctx := context.Background()
+	store := NewTokenStore(newMemoryCache())
+
+	require.NoError(t, store.RevokeAccessToken(ctx, "access-1", time.Minute))
+
+	revoked, err := store.IsAccessTokenRevoked(ctx, 1, "access-1", time.Now())
+	require.NoError(t, err)
+	assert.True(t, revoked)
+
+	revoked, err = store.IsAccessTokenRevoked(ctx, 1, "access-2", time.Now())
+	require.NoError(t, err)
+	assert.False(t, revoked)
+}
+
+func TestTokenStore_Sessions(t *testing.T) {
+	ctx := context.Background()
+	store := NewTokenStore(newMemoryCache())
+	now := time.Now()
+
+	require.NoError(t, store.StartSession(ctx, &Session{ID: "session-a", UserID: 1, UserAgent: "Firefox", IP: "10.0.0.1", CreatedAt: now.Add(-time.Hour), LastUsedAt: now.Add(-time.Hour)},
+		"access-a1", now.Add(-time.Minute), time.Hour))
+	require.NoError(t, store.SaveRefreshToken(ctx, 1, "session-a", "refresh-a", time.Hour))
+	require.NoError(t, store.StartSession(ctx, &Session{ID: "session-b", UserID: 1, UserAgent: "curl", IP: "10.0.0.2", CreatedAt: now.Add(-2 * time.Hour), LastUsedAt: now.Add(-2 * time.Hour)},
+		"access-b1", now.Add(time.Minute), time.Hour))
+	require.NoError(t, store.StartSession(ctx, &Session{ID: "session-c", UserID: 2, CreatedAt: now, LastUsedAt: now}, "access-c1", now.Add(time.Minute), time.Hour))
+
+	// 刷新令牌后更新最后使用时间和IP，已过期的访问令牌不再保留
+	require.NoError(t, store.TouchSession(ctx, 1, "session-a", "Firefox", "10.0.0.9", "access-a2", now.Add(15*time.Minute), now, time.Hour))
+	session, err := store.GetSession(ctx, 1, "session-a")
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.9", session.IP)
+	assert.True(t, session.LastUsedAt.Equal(now))
+	assert.Len(t, session.AccessTokens, 1)
+	assert.Contains(t, session.AccessTokens, "access-a2")
+
+	// 会话元数据缺失时按当前请求补建
+	require.NoError(t, store.TouchSession(ctx, 1, "session-legacy", "Safari", "10.0.0.3", "access-l1", now.Add(time.Minute), now.Add(-30*time.Minute), time.Hour))
+
+	sessions, err := store.ListSessions(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, sessions, 3)
+	assert.Equal(t, []string{"session-a", "session-legacy", "session-b"}, []string{sessions[0].ID, sessions[1].ID, sessions[2].ID})
+	assert.Equal(t, "Safari", sessions[1].UserAgent)
+
+	// 吊销会话：刷新令牌失效，会话签发的访问令牌加入黑名单，其它会话不受影响
+	require.NoError(t, store.RevokeSession(ctx, 1, "session-a"))
+	consumed, err := store.ConsumeRefreshToken(ctx, 1, "session-a", "refresh-a", time.Hour)
+	require.NoError(t, err)
+	assert.False(t, consumed)
+	revoked, err := store.IsAccessTokenRevoked(ctx, 1, "access-a2", now)
+	require.NoError(t, err)
+	assert.True(t, revoked)
+	revoked, err = store.IsAccessTokenRevoked(ctx, 1, "access-b1", now)
+	require.NoError(t, err)
+	assert.False(t, revoked)
+	session, err = store.GetSession(ctx, 1, "session-a")
+	require.NoError(t, err)
+	assert.Nil(t, session)
+
+	// 下线全部会话后会话列表为空，其他用户不受影响
+	require.NoError(t, store.RevokeAllForUser(ctx, 1, time.Hour))
+	sessions, err = store.ListSessions(ctx, 1)
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+	sessions, err = store.ListSessions(ctx, 2)
+	require.NoError(t, err)
+	assert.Len(t, sessions, 1)
+}
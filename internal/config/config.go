@@ -17,6 +17,24 @@ type Config struct {
 	JWT      JWTConfig      `mapstructure:"jwt" validate:"required"`
 	Asynq    AsynqConfig    `mapstructure:"asynq" validate:"required"`
 	Log      LogConfig      `mapstructure:"log" validate:"required"`
+	RateLimit RateLimitConfig `mapstructure:"rate_limit"`
+	Metrics  MetricsConfig  `mapstructure:"metrics"`
+	Swagger  SwaggerConfig  `mapstructure:"swagger"`
+	PermissionCache PermissionCacheConfig `mapstructure:"permission_cache"`
+	Email    EmailConfig    `mapstructure:"email"`
+	Webhook  WebhookConfig  `mapstructure:"webhook"`
+	Report   ReportConfig   `mapstructure:"report"`
+	Task     TaskConfig     `mapstructure:"task"`
+	Skill    SkillConfig    `mapstructure:"skill"`
+	Workflow WorkflowConfig `mapstructure:"workflow"`
+	WorkCalendar WorkCalendarConfig `mapstructure:"work_calendar"`
+	Onboarding OnboardingConfig `mapstructure:"onboarding"`
+	EmployeeNumber EmployeeNumberConfig `mapstructure:"employee_number"`
+	Department DepartmentConfig `mapstructure:"department"`
+	Notification NotificationConfig `mapstructure:"notification"`
+	Workload WorkloadConfig `mapstructure:"workload"`
+	Archive  ArchiveConfig  `mapstructure:"archive"`
+	OIDC     OIDCConfig     `mapstructure:"oidc"`
 }
 
 // AppConfig 应用程序基础配置
@@ -25,6 +43,7 @@ type AppConfig struct {
 	Version     string `mapstructure:"version" validate:"required"`
 	Environment string `mapstructure:"environment" validate:"required,oneof=development testing production"`
 	Debug       bool   `mapstructure:"debug"`
+	Locale      string `mapstructure:"locale"` // 默认语言，请求未通过Accept-Language指定支持的语言时使用，支持zh-CN、en
 }
 
 // ServerConfig HTTP服务器配置
@@ -48,6 +67,13 @@ type DatabaseConfig struct {
 	MaxIdleConns    int    `mapstructure:"max_idle_conns" validate:"min=1"`
 	MaxOpenConns    int    `mapstructure:"max_open_conns" validate:"min=1"`
 	ConnMaxLifetime int    `mapstructure:"conn_max_lifetime" validate:"min=1"`
+
+	AutoMigrate       bool   `mapstructure:"auto_migrate"`                                            // 启动时自动执行待执行的版本化迁移，关闭后通过 -migrate 显式执行
+	PendingMigrations string `mapstructure:"pending_migrations" validate:"omitempty,oneof=warn fail"` // 未自动迁移且存在待执行迁移时的处理：warn记录警告后继续启动，fail拒绝启动
+	// MigrationLockTimeout 等待其他实例释放迁移锁的秒数，0表示使用默认值300
+	MigrationLockTimeout int `mapstructure:"migration_lock_timeout" validate:"min=0"`
+	// MigrationDDLLockTimeout 迁移语句等待表锁的秒数，超时后迁移失败而不是让业务写入排队等待，0表示不限制
+	MigrationDDLLockTimeout int `mapstructure:"migration_ddl_lock_timeout" validate:"min=0"`
 }
 
 // RedisConfig Redis配置
@@ -56,9 +82,20 @@ type RedisConfig struct {
 	Port        int    `mapstructure:"port" validate:"required,min=1,max=65535"`
 	Password    string `mapstructure:"password"`
 	Database    int    `mapstructure:"database" validate:"min=0,max=15"`
-	MaxRetries  int    `mapstructure:"max_retries" validate:"min=0"`
+	MaxRetries  int    `mapstructure:"max_retries" validate:"min=0"` // 临时性错误的重试次数，重试间隔按指数退避并随机抖动
 	PoolSize    int    `mapstructure:"pool_size" validate:"min=1"`
 	MinIdleConn int    `mapstructure:"min_idle_conn" validate:"min=0"`
+
+	RetryBackoffMs    int `mapstructure:"retry_backoff_ms" validate:"min=0"`     // 首次重试的退避时长（毫秒），0表示使用默认值50
+	RetryMaxBackoffMs int `mapstructure:"retry_max_backoff_ms" validate:"min=0"` // 重试退避时长上限（毫秒），0表示使用默认值500
+	// BreakerFailureThreshold 连续失败（重试用尽）多少次后熔断，熔断期间Redis调用直接失败，0表示使用默认值5
+	BreakerFailureThreshold int `mapstructure:"breaker_failure_threshold" validate:"min=0"`
+	// BreakerOpenSeconds 熔断持续时间（秒），到期后放行一次探测请求，成功则恢复，0表示使用默认值30
+	BreakerOpenSeconds int `mapstructure:"breaker_open_seconds" validate:"min=0"`
+	// RateLimitFallback Redis不可用时限流改用进程内令牌桶，关闭时直接放行
+	RateLimitFallback bool `mapstructure:"rate_limit_fallback"`
+	// PermissionCacheFallback Redis不可用时权限检查回源数据库，关闭时权限检查返回错误
+	PermissionCacheFallback bool `mapstructure:"permission_cache_fallback"`
 }
 
 // JWTConfig JWT配置
@@ -91,6 +128,159 @@ type LogConfig struct {
 	Compress   bool   `mapstructure:"compress"`
 }
 
+// RateLimitConfig 限流配置
+type RateLimitConfig struct {
+	Enabled                bool `mapstructure:"enabled"`
+	UserRequestsPerMinute  int  `mapstructure:"user_requests_per_minute" validate:"min=0"`  // 已认证用户每分钟请求数
+	IPRequestsPerMinute    int  `mapstructure:"ip_requests_per_minute" validate:"min=0"`    // 未认证请求每个IP每分钟请求数
+	LoginRequestsPerMinute int  `mapstructure:"login_requests_per_minute" validate:"min=0"` // 登录接口每个IP每分钟请求数
+	LoginMaxFailures       int  `mapstructure:"login_max_failures" validate:"min=0"`        // 同一用户名连续失败次数上限
+	LoginLockoutMinutes    int  `mapstructure:"login_lockout_minutes" validate:"min=0"`     // 达到上限后锁定时长（分钟）
+}
+
+// MetricsConfig Prometheus指标配置
+type MetricsConfig struct {
+	Enabled bool   `mapstructure:"enabled"` // 关闭时不注册/metrics路由和采集中间件
+	Path    string `mapstructure:"path"`    // 默认 /metrics
+	// HTTP请求耗时直方图桶上界（秒），为空时使用默认值
+	// 示例（适合Grafana按P50/P95/P99展示）: [0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10]
+	HTTPDurationBuckets []float64 `mapstructure:"http_duration_buckets" validate:"dive,gt=0"`
+}
+
+// SwaggerConfig 接口文档配置
+type SwaggerConfig struct {
+	Enabled bool `mapstructure:"enabled"` // 开启时在 /swagger/index.html 提供接口文档页面，生产环境应关闭
+}
+
+// PermissionCacheConfig 用户有效权限缓存配置
+type PermissionCacheConfig struct {
+	Enabled    bool `mapstructure:"enabled"`                        // 关闭时每次权限检查都查询数据库
+	TTLSeconds int  `mapstructure:"ttl_seconds" validate:"min=0"` // 缓存过期时间（秒），0表示使用默认值300
+}
+
+// EmailConfig 邮件发送及账号激活配置
+type EmailConfig struct {
+	Driver                string `mapstructure:"driver" validate:"omitempty,oneof=smtp log"` // smtp 或 log（仅写日志，用于开发环境），默认log
+	Host                  string `mapstructure:"host"`
+	Port                  int    `mapstructure:"port" validate:"min=0,max=65535"`
+	Username              string `mapstructure:"username"`
+	Password              string `mapstructure:"password"`
+	From                  string `mapstructure:"from"`
+	ActivationURL         string `mapstructure:"activation_url"`                            // 前端激活页面地址，令牌以token参数追加
+	ActivationExpiryHours int    `mapstructure:"activation_expiry_hours" validate:"min=0"` // 激活令牌有效期（小时），0表示使用默认值168
+	OutboxIntervalSeconds int    `mapstructure:"outbox_interval_seconds" validate:"min=0"` // 后台投递待发送邮件的间隔（秒），0表示使用默认值30
+	MaxAttempts           int    `mapstructure:"max_attempts" validate:"min=0"`            // 单封邮件最多尝试次数，0表示使用默认值5
+}
+
+// WebhookConfig 集成Webhook投递配置
+type WebhookConfig struct {
+	DeliveryIntervalSeconds int `mapstructure:"delivery_interval_seconds" validate:"min=0"` // 后台投递待发送事件的间隔（秒），0表示使用默认值10
+	MaxAttempts             int `mapstructure:"max_attempts" validate:"min=0"`              // 单个事件最多投递次数，0表示使用默认值8
+	TimeoutSeconds          int `mapstructure:"timeout_seconds" validate:"min=0"`           // 单次投递等待响应的超时（秒），0表示使用默认值10
+}
+
+// ReportConfig 报表导出配置
+type ReportConfig struct {
+	MaxExportRows int `mapstructure:"max_export_rows" validate:"min=0"` // 单次导出行数上限，0表示使用默认值50000
+}
+
+// TaskConfig 任务配置
+type TaskConfig struct {
+	AutoCreateSkills bool `mapstructure:"auto_create_skills"` // 创建任务时自动创建不存在的技能，关闭时未知技能返回错误
+	// AssignmentApproval 任务分配审批方式：workflow走审批流程（默认），simple不使用工作流，分配记录由有task:approve权限的用户直接审批
+	AssignmentApproval string `mapstructure:"assignment_approval" validate:"omitempty,oneof=workflow simple"`
+	// EscalationIntervalSeconds 优先级老化任务的运行间隔（秒），0表示使用默认值3600
+	EscalationIntervalSeconds int `mapstructure:"escalation_interval_seconds" validate:"min=0"`
+	// DueReminderIntervalSeconds 截止提醒任务的运行间隔（秒），0表示使用默认值900
+	DueReminderIntervalSeconds int `mapstructure:"due_reminder_interval_seconds" validate:"min=0"`
+	// OutOfScopeStatus 查看可见范围外的任务时返回的状态码：403（默认）或404，404时不暴露任务是否存在
+	OutOfScopeStatus int `mapstructure:"out_of_scope_status" validate:"omitempty,oneof=403 404"`
+}
+
+// SkillConfig 技能配置
+type SkillConfig struct {
+	AutoCreateCategories bool `mapstructure:"auto_create_categories"` // 创建或更新技能时自动创建不存在的分类，关闭时未知分类返回错误
+}
+
+// AssignmentApprovalSimple 不使用工作流的轻量任务分配审批
+const AssignmentApprovalSimple = "simple"
+
+// WorkflowConfig 工作流配置
+type WorkflowConfig struct {
+	SLASweepIntervalSeconds int `mapstructure:"sla_sweep_interval_seconds" validate:"min=0"` // 过期实例扫描间隔（秒），0表示使用默认值300
+}
+
+// WorkCalendarConfig 工作日历配置，启用后审批时限、流程超时、试用期结束日期和截止提醒提前量只计算工作时间
+type WorkCalendarConfig struct {
+	Enabled   bool   `mapstructure:"enabled"`                                        // 关闭时按自然时间计算
+	WorkDays  []int  `mapstructure:"work_days" validate:"dive,min=0,max=6"`         // 工作日，0为周日，为空时使用周一至周五
+	WorkStart string `mapstructure:"work_start" validate:"omitempty,datetime=15:04"` // 上班时间，为空时使用09:00
+	WorkEnd   string `mapstructure:"work_end" validate:"omitempty,datetime=15:04"`   // 下班时间，为空时使用18:00
+	// ProbationWorkingDays 试用期天数按工作日计算，关闭时按自然日计算
+	ProbationWorkingDays bool `mapstructure:"probation_working_days"`
+}
+
+// OnboardingConfig 入职与试用期配置
+type OnboardingConfig struct {
+	ProbationReminderDays int    `mapstructure:"probation_reminder_days" validate:"min=0"` // 试用期结束前多少天提醒转正评估，0表示使用默认值14
+	ProbationReviewerRole string `mapstructure:"probation_reviewer_role"`                 // 接收转正评估提醒的HR角色，为空时使用admin
+	// DefaultWorkflowType 部门未设置时使用的入职审批流程：simple（HR审批）或 full（HR → 部门负责人 → 管理员）
+	DefaultWorkflowType string `mapstructure:"default_workflow_type" validate:"omitempty,oneof=simple full"`
+}
+
+// EmployeeNumberConfig 员工编号生成配置，编号格式为前缀加补零的序号
+type EmployeeNumberConfig struct {
+	Prefix  string `mapstructure:"prefix"`                                             // 编号前缀，为空时使用EMP
+	Padding int    `mapstructure:"padding" validate:"min=0,max=20"`                    // 序号补零位数，0表示使用默认值6
+	Source  string `mapstructure:"source" validate:"omitempty,oneof=sequence user_id"` // sequence（计数器表递增，默认）或 user_id（沿用用户ID）
+}
+
+// DepartmentConfig 部门配置
+type DepartmentConfig struct {
+	// AllowAncestorManager 允许上级部门的员工担任部门管理者，关闭时管理者必须是本部门员工
+	AllowAncestorManager bool `mapstructure:"allow_ancestor_manager"`
+}
+
+// NotificationConfig 通知配置
+type NotificationConfig struct {
+	// DefaultChannels 用户未设置偏好时各通知类别默认开启的渠道（in_app、email、webhook），未配置的类别只开启in_app
+	DefaultChannels map[string][]string `mapstructure:"default_channels"`
+	// ReadRetentionDays 已读站内通知的保留天数，阅读时间超过该天数的通知由后台任务软删除，0表示使用默认值90
+	ReadRetentionDays int `mapstructure:"read_retention_days" validate:"min=0"`
+	// CleanupIntervalSeconds 已读通知清理任务的运行间隔（秒），0表示使用默认值86400
+	CleanupIntervalSeconds int `mapstructure:"cleanup_interval_seconds" validate:"min=0"`
+}
+
+// WorkloadConfig 员工工作负载统计配置
+type WorkloadConfig struct {
+	OverloadThreshold float64 `mapstructure:"overload_threshold" validate:"min=0"` // 工作负载率超过该值视为超负荷，0表示使用默认值0.9
+	StatsWindowDays   int     `mapstructure:"stats_window_days" validate:"min=0"`  // 未指定日期范围时统计最近多少天完成的任务，0表示使用默认值90
+}
+
+// ArchiveConfig 已结束任务和流程实例的归档配置
+type ArchiveConfig struct {
+	Enabled               bool `mapstructure:"enabled"`                                   // 是否启用后台归档任务
+	TaskRetentionDays     int  `mapstructure:"task_retention_days" validate:"min=0"`     // 完成或取消超过该天数的任务移入归档表，0表示使用默认值365
+	WorkflowRetentionDays int  `mapstructure:"workflow_retention_days" validate:"min=0"` // 结束超过该天数的流程实例移入归档表，0表示使用默认值180
+	BatchSize             int  `mapstructure:"batch_size" validate:"min=0"`              // 每批迁移的记录数，0表示使用默认值500
+	BatchPauseMillis      int  `mapstructure:"batch_pause_millis" validate:"min=0"`      // 两批之间的间隔（毫秒），避免长时间占用数据库，0表示使用默认值200
+	MaxBatches            int  `mapstructure:"max_batches" validate:"min=0"`             // 每次运行每类数据最多迁移的批数，0表示使用默认值100
+	IntervalSeconds       int  `mapstructure:"interval_seconds" validate:"min=0"`        // 后台归档任务的运行间隔（秒），0表示使用默认值86400
+}
+
+// OIDCConfig OIDC单点登录配置
+type OIDCConfig struct {
+	Enabled              bool     `mapstructure:"enabled"`
+	Issuer               string   `mapstructure:"issuer" validate:"required_if=Enabled true"`
+	ClientID             string   `mapstructure:"client_id" validate:"required_if=Enabled true"`
+	ClientSecret         string   `mapstructure:"client_secret"`
+	RedirectURL          string   `mapstructure:"redirect_url" validate:"required_if=Enabled true"` // 在提供方登记的回调地址，指向 /api/v1/auth/oidc/callback
+	Scopes               []string `mapstructure:"scopes"`                                           // 为空时使用 openid email profile
+	AutoProvision        bool     `mapstructure:"auto_provision"`                                   // 邮箱没有对应用户时创建待入职员工并发送激活邮件
+	StateTTLSeconds      int      `mapstructure:"state_ttl_seconds" validate:"min=0"`               // 登录跳转state有效期（秒），0表示使用默认值600
+	DisablePasswordLogin bool     `mapstructure:"disable_password_login"`                          // 关闭用户名密码登录，只允许单点登录
+}
+
 var (
 	cfg *Config
 )
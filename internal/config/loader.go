@@ -139,6 +139,7 @@ func (l *ConfigLoader) setDefaults() {
 	l.viper.SetDefault("app.version", "1.0.0")
 	l.viper.SetDefault("app.environment", "development")
 	l.viper.SetDefault("app.debug", true)
+	l.viper.SetDefault("app.locale", "zh-CN")
 	
 	// 服务器默认值
 	l.viper.SetDefault("server.host", "0.0.0.0")
@@ -153,12 +154,21 @@ func (l *ConfigLoader) setDefaults() {
 	l.viper.SetDefault("database.max_idle_conns", 10)
 	l.viper.SetDefault("database.max_open_conns", 100)
 	l.viper.SetDefault("database.conn_max_lifetime", 3600)
+	l.viper.SetDefault("database.auto_migrate", true)
+	l.viper.SetDefault("database.pending_migrations", "warn")
+	l.viper.SetDefault("database.migration_lock_timeout", 300)
 	
 	// Redis默认值
 	l.viper.SetDefault("redis.database", 0)
 	l.viper.SetDefault("redis.max_retries", 3)
 	l.viper.SetDefault("redis.pool_size", 10)
 	l.viper.SetDefault("redis.min_idle_conn", 5)
+	l.viper.SetDefault("redis.retry_backoff_ms", 50)
+	l.viper.SetDefault("redis.retry_max_backoff_ms", 500)
+	l.viper.SetDefault("redis.breaker_failure_threshold", 5)
+	l.viper.SetDefault("redis.breaker_open_seconds", 30)
+	l.viper.SetDefault("redis.rate_limit_fallback", true)
+	l.viper.SetDefault("redis.permission_cache_fallback", true)
 	
 	// JWT默认值
 	l.viper.SetDefault("jwt.access_token_ttl", 3600)
@@ -178,6 +188,9 @@ func (l *ConfigLoader) setDefaults() {
 	l.viper.SetDefault("log.max_backups", 5)
 	l.viper.SetDefault("log.max_age", 30)
 	l.viper.SetDefault("log.compress", true)
+
+	// 部门默认值
+	l.viper.SetDefault("department.allow_ancestor_manager", true)
 }
 
 // validateConfig 验证配置
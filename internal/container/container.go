@@ -3,6 +3,9 @@ package container
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -10,19 +13,24 @@ import (
 	"gorm.io/gorm"
 
 	"taskmanage/internal/assignment"
+	"taskmanage/internal/cache"
+	rediscache "taskmanage/internal/cache/redis"
 	"taskmanage/internal/config"
+	"taskmanage/internal/jobs"
 	"taskmanage/internal/repository"
 	"taskmanage/internal/repository/mysql"
 	"taskmanage/internal/service"
+	"taskmanage/internal/workflow"
 	"taskmanage/pkg/jwt"
 	"taskmanage/pkg/logger"
 )
 
-// Container 依赖注入容器
+// Container 依赖注入容器，工厂函数在首次获取时才执行
 type Container struct {
 	mu        sync.RWMutex
 	instances map[string]interface{}
 	factories map[string]func() (interface{}, error)
+	deps      map[string][]string
 }
 
 // NewContainer 创建新的容器
@@ -30,14 +38,21 @@ func NewContainer() *Container {
 	return &Container{
 		instances: make(map[string]interface{}),
 		factories: make(map[string]func() (interface{}, error)),
+		deps:      make(map[string][]string),
 	}
 }
 
 // Register 注册工厂函数
 func (c *Container) Register(name string, factory func() (interface{}, error)) {
+	c.Provide(name, nil, factory)
+}
+
+// Provide 注册工厂函数并声明其依赖的其它注册项，Validate据此在创建实例前检查依赖是否齐全
+func (c *Container) Provide(name string, deps []string, factory func() (interface{}, error)) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	c.factories[name] = factory
+	c.deps[name] = deps
 }
 
 // RegisterSingleton 注册单例
@@ -113,29 +128,170 @@ func GetTyped[T any](c *Container, name string) (T, error) {
 
 	typed, ok := instance.(T)
 	if !ok {
-		return zero, fmt.Errorf("类型断言失败: 期望 %T, 实际 %T", zero, instance)
+		return zero, fmt.Errorf("实例 '%s' 类型不匹配: 期望 %s, 实际 %T", name, reflect.TypeOf((*T)(nil)).Elem(), instance)
 	}
 
 	return typed, nil
 }
 
+// WiringError 容器依赖配置错误，汇总全部缺失的依赖
+type WiringError struct {
+	Problems []string
+}
+
+func (e *WiringError) Error() string {
+	return fmt.Sprintf("容器依赖配置错误(%d项): %s", len(e.Problems), strings.Join(e.Problems, "; "))
+}
+
+// newWiringError 没有问题时返回nil
+func newWiringError(problems []string) error {
+	if len(problems) == 0 {
+		return nil
+	}
+	return &WiringError{Problems: problems}
+}
+
+// Validate 检查注册项声明的依赖是否都已注册、单例是否为空，不执行工厂函数，返回包含全部问题的WiringError
+func (c *Container) Validate() error {
+	return newWiringError(c.validate())
+}
+
+func (c *Container) validate() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var problems []string
+	for _, name := range c.namesLocked() {
+		if instance, exists := c.instances[name]; exists && isNil(instance) {
+			problems = append(problems, fmt.Sprintf("%s 注册的实例为空", name))
+		}
+		for _, dep := range c.deps[name] {
+			_, hasInstance := c.instances[dep]
+			_, hasFactory := c.factories[dep]
+			if !hasInstance && !hasFactory {
+				problems = append(problems, fmt.Sprintf("%s 依赖的 %s 未注册", name, dep))
+			}
+		}
+	}
+	return problems
+}
+
+// namesLocked 按名称排序的全部注册项，调用方需持有锁
+func (c *Container) namesLocked() []string {
+	names := make([]string, 0, len(c.instances)+len(c.factories))
+	for name := range c.factories {
+		names = append(names, name)
+	}
+	for name := range c.instances {
+		if _, exists := c.factories[name]; !exists {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// isNil 接口值为nil或包装了nil指针
+func isNil(instance interface{}) bool {
+	if instance == nil {
+		return true
+	}
+	value := reflect.ValueOf(instance)
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		return value.IsNil()
+	}
+	return false
+}
+
+// Registration 容器注册项的自检信息
+type Registration struct {
+	Name         string   `json:"name"`
+	Type         string   `json:"type,omitempty"` // 实现类型，尚未创建时为空
+	Initialized  bool     `json:"initialized"`
+	Dependencies []string `json:"dependencies,omitempty"`
+}
+
+// Registrations 按名称列出全部注册项及已创建实例的实现类型，不触发创建
+func (c *Container) Registrations() []Registration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	names := c.namesLocked()
+	registrations := make([]Registration, 0, len(names))
+	for _, name := range names {
+		registration := Registration{Name: name, Dependencies: c.deps[name]}
+		if instance, exists := c.instances[name]; exists {
+			registration.Initialized = true
+			registration.Type = fmt.Sprintf("%T", instance)
+		}
+		registrations = append(registrations, registration)
+	}
+	return registrations
+}
+
 // ApplicationContainer 应用程序容器
 type ApplicationContainer struct {
 	*Container
-	config *config.Config
-	db     *gorm.DB
+	config    *config.Config
+	db        *gorm.DB
+	startup   *StartupTracker
+	scheduler *jobs.Scheduler
 }
 
 // NewApplicationContainer 创建应用程序容器
-func NewApplicationContainer(cfg *config.Config, db *gorm.DB) *ApplicationContainer {
+// 各注册项在首次获取时才创建；创建前校验配置、数据库、注册项依赖和仓储装配，所有问题汇总在一个WiringError中返回
+func NewApplicationContainer(cfg *config.Config, db *gorm.DB) (*ApplicationContainer, error) {
 	container := &ApplicationContainer{
 		Container: NewContainer(),
 		config:    cfg,
 		db:        db,
+		startup:   NewStartupTracker(StartupStepContainer, StartupStepSystemData, StartupStepDefaultWorkflows),
+		scheduler: jobs.NewScheduler(nil),
 	}
 
 	container.registerDefaults()
-	return container
+	if err := container.Validate(); err != nil {
+		return nil, err
+	}
+	return container, nil
+}
+
+// Validate 校验配置、数据库连接、注册项依赖以及仓储管理器是否装配了全部仓储
+func (c *ApplicationContainer) Validate() error {
+	problems := c.Container.validate()
+	if c.db != nil {
+		repoManager, err := GetTyped[repository.RepositoryManager](c.Container, "repository.manager")
+		if err != nil {
+			problems = append(problems, err.Error())
+		} else {
+			problems = append(problems, missingRepositories(repoManager)...)
+		}
+	}
+	return newWiringError(problems)
+}
+
+// missingRepositories 检查仓储管理器的各个仓储获取方法是否返回nil，只创建仓储对象，不访问数据库
+func missingRepositories(repoManager repository.RepositoryManager) []string {
+	managerType := reflect.TypeOf((*repository.RepositoryManager)(nil)).Elem()
+	value := reflect.ValueOf(repoManager)
+
+	var problems []string
+	for i := 0; i < managerType.NumMethod(); i++ {
+		method := managerType.Method(i)
+		if method.Type.NumIn() != 0 || method.Type.NumOut() != 1 || !strings.HasSuffix(method.Name, "Repository") {
+			continue
+		}
+		if result := value.MethodByName(method.Name).Call(nil)[0]; result.IsNil() {
+			problems = append(problems, fmt.Sprintf("repository.manager 未装配 %s", method.Name))
+		}
+	}
+	return problems
+}
+
+// SelfCheck 列出全部注册项及实现类型，供启动时记录，便于排查部署问题
+func (c *ApplicationContainer) SelfCheck() []Registration {
+	return c.Registrations()
 }
 
 // registerDefaults 注册默认依赖
@@ -159,6 +315,84 @@ func (c *ApplicationContainer) registerDefaults() {
 		), nil
 	})
 
+	// 注册Redis缓存
+	c.Register("cache.redis", func() (interface{}, error) {
+		return rediscache.NewRedisCache(c.config)
+	})
+
+	// 注册限流器（基于Redis令牌桶），开启降级时Redis不可用改用进程内令牌桶
+	c.Provide("cache.rate_limiter", []string{"cache.redis", "logger"}, func() (interface{}, error) {
+		fallback := c.config.Redis.RateLimitFallback
+		redisCache, err := c.GetRedisCache()
+		if err != nil {
+			if !fallback {
+				return nil, err
+			}
+			c.GetLogger().WithError(err).Warn("Redis不可用，限流使用进程内令牌桶")
+			return cache.NewLocalRateLimiter(), nil
+		}
+		limiter := rediscache.NewTokenBucketLimiter(redisCache)
+		if fallback {
+			return cache.NewFallbackRateLimiter(limiter, cache.NewLocalRateLimiter()), nil
+		}
+		return limiter, nil
+	})
+
+	// 注册登录失败计数存储
+	c.Provide("cache.login_attempts", []string{"cache.redis"}, func() (interface{}, error) {
+		redisCache, err := c.GetRedisCache()
+		if err != nil {
+			return nil, err
+		}
+		rateLimit := c.config.RateLimit
+		return cache.NewLoginAttemptStore(redisCache, rateLimit.LoginMaxFailures, time.Duration(rateLimit.LoginLockoutMinutes)*time.Minute), nil
+	})
+
+	// 注册令牌状态存储
+	c.Provide("cache.token_store", []string{"cache.redis"}, func() (interface{}, error) {
+		redisCache, err := c.GetRedisCache()
+		if err != nil {
+			return nil, err
+		}
+		return cache.NewTokenStore(redisCache), nil
+	})
+
+	// 注册单点登录state存储
+	c.Provide("cache.oidc_state", []string{"cache.redis"}, func() (interface{}, error) {
+		redisCache, err := c.GetRedisCache()
+		if err != nil {
+			return nil, err
+		}
+		ttl := time.Duration(c.config.OIDC.StateTTLSeconds) * time.Second
+		if ttl <= 0 {
+			ttl = 10 * time.Minute
+		}
+		return cache.NewOIDCStateStore(redisCache, ttl), nil
+	})
+
+	// 注册用户权限缓存
+	c.Provide("cache.permission", []string{"cache.redis"}, func() (interface{}, error) {
+		redisCache, err := c.GetRedisCache()
+		if err != nil {
+			return nil, err
+		}
+		ttl := time.Duration(c.config.PermissionCache.TTLSeconds) * time.Second
+		if ttl <= 0 {
+			ttl = 5 * time.Minute
+		}
+		permissionCache := cache.NewPermissionCache(redisCache, ttl)
+		if !c.config.Redis.PermissionCacheFallback {
+			permissionCache.DisableFallback()
+		}
+		return permissionCache, nil
+	})
+
+	// 注册流程结束业务回调注册表，由各业务服务在创建服务管理器时登记
+	c.RegisterSingleton("workflow.completion_handlers", workflow.NewCompletionHandlerRegistry())
+
+	// 注册分配策略注册表，新增策略在此处注册即可被自动分配和部门默认策略使用
+	c.RegisterSingleton("assignment.strategies", assignment.NewDefaultStrategyRegistry())
+
 	// 注册Repository管理器
 	c.Register("repository.manager", func() (interface{}, error) {
 		return mysql.NewRepositoryManager(c.db), nil
@@ -170,16 +404,35 @@ func (c *ApplicationContainer) registerDefaults() {
 	})
 
 	// 注册Service管理器
-	c.Register("service.manager", func() (interface{}, error) {
+	c.Provide("service.manager", []string{"config", "repository.manager", "logger", "cache.permission", "assignment.strategies", "workflow.completion_handlers"}, func() (interface{}, error) {
 		repoManager, err := GetTyped[repository.RepositoryManager](c.Container, "repository.manager")
 		if err != nil {
 			return nil, err
 		}
 		logger := c.GetLogger()
-		return NewServiceManager(repoManager, c.config, logger), nil
+		serviceManager := NewServiceManager(repoManager, c.config, logger)
+		if c.config.PermissionCache.Enabled {
+			permissionCache, err := c.GetPermissionCache()
+			if err != nil {
+				logger.Warnf("权限缓存不可用，权限检查将直接查询数据库: %v", err)
+			} else {
+				serviceManager.SetPermissionCache(permissionCache)
+			}
+		}
+		strategies, err := c.GetAssignmentStrategies()
+		if err != nil {
+			return nil, err
+		}
+		serviceManager.SetAssignmentStrategies(strategies)
+		completionHandlers, err := GetTyped[*workflow.CompletionHandlerRegistry](c.Container, "workflow.completion_handlers")
+		if err != nil {
+			return nil, err
+		}
+		serviceManager.SetCompletionHandlers(completionHandlers)
+		return serviceManager, nil
 	})
 	// 注册各个Service
-	c.Register("service.user", func() (interface{}, error) {
+	c.Provide("service.user", []string{"repository.manager"}, func() (interface{}, error) {
 		repoManager, err := GetTyped[repository.RepositoryManager](c.Container, "repository.manager")
 		if err != nil {
 			return nil, err
@@ -187,7 +440,7 @@ func (c *ApplicationContainer) registerDefaults() {
 		return NewUserService(repoManager, c.config), nil
 	})
 
-	c.Register("service.task", func() (interface{}, error) {
+	c.Provide("service.task", []string{"repository.manager", "service.manager", "assignment.strategies"}, func() (interface{}, error) {
 		repoManager, err := GetTyped[repository.RepositoryManager](c.Container, "repository.manager")
 		if err != nil {
 			return nil, err
@@ -196,15 +449,19 @@ func (c *ApplicationContainer) registerDefaults() {
 		if err != nil {
 			return nil, err
 		}
+		strategies, err := c.GetAssignmentStrategies()
+		if err != nil {
+			return nil, err
+		}
 		// 创建分配服务
-		assignmentService := assignment.NewAssignmentService(repoManager)
+		assignmentService := assignment.NewAssignmentService(repoManager, strategies)
 		// 获取workflow服务
 		workflowService := serviceManager.WorkflowService()
-		return service.NewTaskService(repoManager.TaskRepository(), repoManager.EmployeeRepository(), repoManager.UserRepository(), repoManager.AssignmentRepository(), assignmentService, workflowService), nil
+		return service.NewTaskService(repoManager.TaskRepository(), repoManager.EmployeeRepository(), repoManager.UserRepository(), repoManager.AssignmentRepository(), assignmentService, workflowService, repoManager.TimeEntryRepository(), repoManager.ProjectRepository(), repoManager.SkillRepository(), repoManager.TaskWatcherRepository(), repoManager.TaskCommentRepository(), serviceManager.NotificationService(), serviceManager.WebhookService(), c.config.Task.AutoCreateSkills, c.config.Task.AssignmentApproval == config.AssignmentApprovalSimple), nil
 	})
 
 	// 注册分配管理服务
-	c.Register("service.assignment_management", func() (interface{}, error) {
+	c.Provide("service.assignment_management", []string{"repository.manager", "service.manager", "assignment.strategies"}, func() (interface{}, error) {
 		repoManager, err := GetTyped[repository.RepositoryManager](c.Container, "repository.manager")
 		if err != nil {
 			return nil, err
@@ -213,8 +470,12 @@ func (c *ApplicationContainer) registerDefaults() {
 		if err != nil {
 			return nil, err
 		}
+		strategies, err := c.GetAssignmentStrategies()
+		if err != nil {
+			return nil, err
+		}
 		// 创建分配服务
-		assignmentService := assignment.NewAssignmentService(repoManager)
+		assignmentService := assignment.NewAssignmentService(repoManager, strategies)
 		// 获取workflow服务
 		workflowService := serviceManager.WorkflowService()
 		notificationService := serviceManager.NotificationService()
@@ -263,6 +524,51 @@ func (c *ApplicationContainer) GetJWTManager() (*jwt.JWTManager, error) {
 	return GetTyped[*jwt.JWTManager](c.Container, "jwt.manager")
 }
 
+// GetStartupTracker 获取启动初始化跟踪器
+func (c *ApplicationContainer) GetStartupTracker() *StartupTracker {
+	return c.startup
+}
+
+// GetJobScheduler 获取定时任务调度器
+func (c *ApplicationContainer) GetJobScheduler() *jobs.Scheduler {
+	return c.scheduler
+}
+
+// GetAssignmentStrategies 获取分配策略注册表
+func (c *ApplicationContainer) GetAssignmentStrategies() (*assignment.StrategyRegistry, error) {
+	return GetTyped[*assignment.StrategyRegistry](c.Container, "assignment.strategies")
+}
+
+// GetRedisCache 获取Redis缓存
+func (c *ApplicationContainer) GetRedisCache() (*rediscache.RedisCache, error) {
+	return GetTyped[*rediscache.RedisCache](c.Container, "cache.redis")
+}
+
+// GetRateLimiter 获取限流器
+func (c *ApplicationContainer) GetRateLimiter() (cache.RateLimiter, error) {
+	return GetTyped[cache.RateLimiter](c.Container, "cache.rate_limiter")
+}
+
+// GetLoginAttemptStore 获取登录失败计数存储
+func (c *ApplicationContainer) GetLoginAttemptStore() (*cache.LoginAttemptStore, error) {
+	return GetTyped[*cache.LoginAttemptStore](c.Container, "cache.login_attempts")
+}
+
+// GetTokenStore 获取令牌状态存储
+func (c *ApplicationContainer) GetTokenStore() (*cache.TokenStore, error) {
+	return GetTyped[*cache.TokenStore](c.Container, "cache.token_store")
+}
+
+// GetOIDCStateStore 获取单点登录state存储
+func (c *ApplicationContainer) GetOIDCStateStore() (*cache.OIDCStateStore, error) {
+	return GetTyped[*cache.OIDCStateStore](c.Container, "cache.oidc_state")
+}
+
+// GetPermissionCache 获取用户权限缓存
+func (c *ApplicationContainer) GetPermissionCache() (*cache.PermissionCache, error) {
+	return GetTyped[*cache.PermissionCache](c.Container, "cache.permission")
+}
+
 // HealthCheck 健康检查
 func (c *ApplicationContainer) HealthCheck(ctx context.Context) error {
 	// 检查Repository层
@@ -295,14 +601,17 @@ func (c *ApplicationContainer) Shutdown(ctx context.Context) error {
 var globalContainer *ApplicationContainer
 var containerOnce sync.Once
 
-// InitGlobalContainer 初始化全局容器
-func InitGlobalContainer(cfg *config.Config, db *gorm.DB) {
+var containerErr error
+
+// InitGlobalContainer 初始化全局容器，依赖配置错误时返回WiringError且不设置全局容器
+func InitGlobalContainer(cfg *config.Config, db *gorm.DB) error {
 	containerOnce.Do(func() {
-		globalContainer = NewApplicationContainer(cfg, db)
+		globalContainer, containerErr = NewApplicationContainer(cfg, db)
 	})
+	return containerErr
 }
 
-// GetGlobalContainer 获取全局容器
+// GetGlobalContainer 获取全局容器，兼容旧代码的薄封装，新代码应通过构造函数参数传递依赖
 func GetGlobalContainer() *ApplicationContainer {
 	if globalContainer == nil {
 		panic("全局容器未初始化")
@@ -338,12 +647,12 @@ func NewUserService(repoManager repository.RepositoryManager, cfg *config.Config
 
 func NewTaskService(repoManager repository.RepositoryManager, cfg *config.Config) service.TaskService {
 	// 创建分配服务
-	assignmentService := assignment.NewAssignmentService(repoManager)
+	assignmentService := assignment.NewAssignmentService(repoManager, assignment.NewDefaultStrategyRegistry())
 	// 获取workflow服务
 	logger := logrus.New() // TODO: Get from container
 	serviceManager := service.NewServiceManager(repoManager, cfg, logger)
 	workflowService := serviceManager.WorkflowService()
-	return service.NewTaskService(repoManager.TaskRepository(), repoManager.EmployeeRepository(), repoManager.UserRepository(), repoManager.AssignmentRepository(), assignmentService, workflowService)
+	return service.NewTaskService(repoManager.TaskRepository(), repoManager.EmployeeRepository(), repoManager.UserRepository(), repoManager.AssignmentRepository(), assignmentService, workflowService, repoManager.TimeEntryRepository(), repoManager.ProjectRepository(), repoManager.SkillRepository(), repoManager.TaskWatcherRepository(), repoManager.TaskCommentRepository(), serviceManager.NotificationService(), serviceManager.WebhookService(), cfg.Task.AutoCreateSkills, cfg.Task.AssignmentApproval == config.AssignmentApprovalSimple)
 }
 
 // GetEmployeeService 获取员工服务
@@ -0,0 +1,72 @@
+package container
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"taskmanage/internal/config"
+)
+
+func TestContainerValidate_AggregatesAllProblems(t *testing.T) {
+	c := NewContainer()
+	var nilConfig *config.Config
+	c.RegisterSingleton("config", nilConfig)
+	c.Provide("service.a", []string{"config", "repo.missing"}, func() (interface{}, error) {
+		t.Fatal("Validate不应执行工厂函数")
+		return nil, nil
+	})
+	c.Provide("service.b", []string{"service.a", "cache.missing"}, func() (interface{}, error) {
+		return "b", nil
+	})
+
+	err := c.Validate()
+	var wiringErr *WiringError
+	require.True(t, errors.As(err, &wiringErr))
+	assert.Equal(t, []string{
+		"config 注册的实例为空",
+		"service.a 依赖的 repo.missing 未注册",
+		"service.b 依赖的 cache.missing 未注册",
+	}, wiringErr.Problems)
+}
+
+func TestContainerRegistrations_ReportsImplementationTypes(t *testing.T) {
+	c := NewContainer()
+	c.Provide("service.b", []string{"service.a"}, func() (interface{}, error) {
+		return &config.Config{}, nil
+	})
+	c.RegisterSingleton("service.a", "a")
+
+	registrations := c.Registrations()
+	require.Len(t, registrations, 2)
+	assert.Equal(t, Registration{Name: "service.a", Type: "string", Initialized: true}, registrations[0])
+	assert.Equal(t, Registration{Name: "service.b", Dependencies: []string{"service.a"}}, registrations[1])
+
+	_, err := c.Get("service.b")
+	require.NoError(t, err)
+	assert.Equal(t, "*config.Config", c.Registrations()[1].Type)
+}
+
+func TestNewApplicationContainer(t *testing.T) {
+	t.Run("缺少配置和数据库时汇总报错", func(t *testing.T) {
+		appContainer, err := NewApplicationContainer(nil, nil)
+		assert.Nil(t, appContainer)
+		var wiringErr *WiringError
+		require.True(t, errors.As(err, &wiringErr))
+		assert.Contains(t, wiringErr.Problems, "config 注册的实例为空")
+		assert.Contains(t, wiringErr.Problems, "db 注册的实例为空")
+	})
+
+	t.Run("仓储全部装配时校验通过且不创建服务", func(t *testing.T) {
+		appContainer, err := NewApplicationContainer(&config.Config{}, &gorm.DB{})
+		require.NoError(t, err)
+		for _, registration := range appContainer.SelfCheck() {
+			if registration.Name == "service.manager" {
+				assert.False(t, registration.Initialized)
+			}
+		}
+	})
+}
@@ -0,0 +1,78 @@
+package container
+
+import (
+	"sync"
+	"time"
+)
+
+// 启动探针要求完成的初始化步骤
+const (
+	StartupStepContainer        = "container"         // 依赖注入容器初始化
+	StartupStepSystemData       = "system_data"       // 系统默认数据（角色、权限、超级管理员）
+	StartupStepDefaultWorkflows = "default_workflows" // 默认工作流定义
+)
+
+// StartupStep 初始化步骤状态
+type StartupStep struct {
+	Name       string     `json:"name"`
+	Done       bool       `json:"done"`
+	Error      string     `json:"error,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// StartupTracker 记录启动初始化步骤的完成情况，步骤失败同样视为已结束
+type StartupTracker struct {
+	mu    sync.RWMutex
+	steps []StartupStep
+}
+
+// NewStartupTracker 创建启动跟踪器
+func NewStartupTracker(steps ...string) *StartupTracker {
+	tracker := &StartupTracker{steps: make([]StartupStep, len(steps))}
+	for i, name := range steps {
+		tracker.steps[i] = StartupStep{Name: name}
+	}
+	return tracker
+}
+
+// Finish 标记步骤结束，err不为nil时记录失败原因
+func (t *StartupTracker) Finish(step string, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	for i := range t.steps {
+		if t.steps[i].Name != step {
+			continue
+		}
+		t.steps[i].Done = true
+		t.steps[i].FinishedAt = &now
+		if err != nil {
+			t.steps[i].Error = err.Error()
+		}
+		return
+	}
+}
+
+// Completed 所有步骤是否都已结束
+func (t *StartupTracker) Completed() bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	for _, step := range t.steps {
+		if !step.Done {
+			return false
+		}
+	}
+	return true
+}
+
+// Steps 返回各步骤状态的副本
+func (t *StartupTracker) Steps() []StartupStep {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	steps := make([]StartupStep, len(t.steps))
+	copy(steps, t.steps)
+	return steps
+}
@@ -0,0 +1,98 @@
+package database
+
+import (
+	"time"
+)
+
+// TaskArchive 已归档的任务，列与tasks表相同并增加归档时间
+// 只保存任务本身的列，评论、附件、标签等关联记录仍按任务ID保留在原表中；tasks表新增列时需同步增加
+type TaskArchive struct {
+	BaseModel
+	Title                  string     `gorm:"size:200;not null" json:"title"`
+	Description            string     `gorm:"type:text" json:"description"`
+	Priority               string     `gorm:"size:20;default:medium" json:"priority"`
+	Status                 string     `gorm:"size:20;default:pending" json:"status"`
+	Type                   string     `gorm:"size:50" json:"type"`
+	EstimatedHours         float64    `gorm:"default:0" json:"estimated_hours"`
+	ActualHours            float64    `gorm:"default:0" json:"actual_hours"`
+	DueDate                *time.Time `json:"due_date"`
+	StartedAt              *time.Time `json:"started_at"`
+	CompletedAt            *time.Time `json:"completed_at"`
+	Version                uint       `gorm:"not null;default:1" json:"version"`
+	AutoCompleteOnChildren bool       `gorm:"default:false" json:"auto_complete_on_children"`
+	RequiresReview         bool       `gorm:"default:false" json:"requires_review"`
+	ReviewerID             *uint      `json:"reviewer_id"`
+	CreatorID              uint       `gorm:"not null" json:"creator_id"`
+	AssigneeID             *uint      `json:"assignee_id"`
+	ParentID               *uint      `json:"parent_id"`
+	ProjectID              *uint      `gorm:"index" json:"project_id"`
+	ArchivedAt             time.Time  `gorm:"not null;index" json:"archived_at"`
+}
+
+// TableName 指定表名
+func (TaskArchive) TableName() string {
+	return "task_archives"
+}
+
+// NewTaskArchive 由任务生成归档记录，保留原任务ID
+func NewTaskArchive(task *Task, archivedAt time.Time) *TaskArchive {
+	return &TaskArchive{
+		BaseModel:              BaseModel{ID: task.ID, CreatedAt: task.CreatedAt, UpdatedAt: task.UpdatedAt},
+		Title:                  task.Title,
+		Description:            task.Description,
+		Priority:               task.Priority,
+		Status:                 task.Status,
+		Type:                   task.Type,
+		EstimatedHours:         task.EstimatedHours,
+		ActualHours:            task.ActualHours,
+		DueDate:                task.DueDate,
+		StartedAt:              task.StartedAt,
+		CompletedAt:            task.CompletedAt,
+		Version:                task.Version,
+		AutoCompleteOnChildren: task.AutoCompleteOnChildren,
+		RequiresReview:         task.RequiresReview,
+		ReviewerID:             task.ReviewerID,
+		CreatorID:              task.CreatorID,
+		AssigneeID:             task.AssigneeID,
+		ParentID:               task.ParentID,
+		ProjectID:              task.ProjectID,
+		ArchivedAt:             archivedAt,
+	}
+}
+
+// ToTask 还原为任务模型，不含关联关系
+func (a *TaskArchive) ToTask() *Task {
+	return &Task{
+		BaseModel:              BaseModel{ID: a.ID, CreatedAt: a.CreatedAt, UpdatedAt: a.UpdatedAt},
+		Title:                  a.Title,
+		Description:            a.Description,
+		Priority:               a.Priority,
+		Status:                 a.Status,
+		Type:                   a.Type,
+		EstimatedHours:         a.EstimatedHours,
+		ActualHours:            a.ActualHours,
+		DueDate:                a.DueDate,
+		StartedAt:              a.StartedAt,
+		CompletedAt:            a.CompletedAt,
+		Version:                a.Version,
+		AutoCompleteOnChildren: a.AutoCompleteOnChildren,
+		RequiresReview:         a.RequiresReview,
+		ReviewerID:             a.ReviewerID,
+		CreatorID:              a.CreatorID,
+		AssigneeID:             a.AssigneeID,
+		ParentID:               a.ParentID,
+		ProjectID:              a.ProjectID,
+	}
+}
+
+// WorkflowInstanceArchive 已归档的流程实例，列与workflow_instances表相同并增加归档时间
+// 执行历史只允许追加，归档时保留在原表中，哈希链校验不受影响
+type WorkflowInstanceArchive struct {
+	WorkflowInstance
+	ArchivedAt time.Time `gorm:"column:archived_at;not null;index" json:"archived_at"`
+}
+
+// TableName 指定表名
+func (WorkflowInstanceArchive) TableName() string {
+	return "workflow_instance_archives"
+}
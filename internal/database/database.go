@@ -6,34 +6,60 @@ import (
 	"time"
 
 	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 
 	"taskmanage/internal/config"
 )
 
+// 支持的数据库驱动
+const (
+	DriverMySQL    = "mysql"
+	DriverPostgres = "postgres"
+)
+
 // DB 全局数据库实例
 var DB *gorm.DB
 
+// Dialector 根据驱动名称创建GORM方言
+func Dialector(driver, dsn string) (gorm.Dialector, error) {
+	switch driver {
+	case DriverMySQL:
+		return mysql.Open(dsn), nil
+	case DriverPostgres:
+		return postgres.Open(dsn), nil
+	default:
+		return nil, fmt.Errorf("不支持的数据库驱动: %s", driver)
+	}
+}
+
+// Driver 当前连接使用的数据库驱动，未连接时返回空
+func Driver() string {
+	if DB == nil {
+		return ""
+	}
+	return DB.Dialector.Name()
+}
+
 // Connect 连接数据库并配置连接池
 func Connect(cfg *config.Config) error {
-	// 创建MySQL连接
-	dsn := cfg.GetDSN()
-	if dsn == "" {
-		return fmt.Errorf("不支持的数据库驱动: %s", cfg.Database.Driver)
+	dialector, err := Dialector(cfg.Database.Driver, cfg.GetDSN())
+	if err != nil {
+		return err
 	}
 
 	// GORM配置
 	gormConfig := &gorm.Config{
 		Logger: getLogger(cfg),
-		// 禁用外键约束检查 (MySQL特定优化)
+		// 迁移时不创建外键约束，关联完整性由应用层维护
 		DisableForeignKeyConstraintWhenMigrating: true,
 	}
 
 	// 连接数据库
-	db, err := gorm.Open(mysql.Open(dsn), gormConfig)
+	db, err := gorm.Open(dialector, gormConfig)
 	if err != nil {
-		return fmt.Errorf("连接MySQL数据库失败: %w", err)
+		return fmt.Errorf("连接%s数据库失败: %w", cfg.Database.Driver, err)
 	}
 
 	// 获取底层sql.DB实例进行连接池配置
@@ -42,24 +68,26 @@ func Connect(cfg *config.Config) error {
 		return fmt.Errorf("获取数据库实例失败: %w", err)
 	}
 
-	// 配置MySQL连接池参数
+	// 配置连接池参数
 	configureConnectionPool(sqlDB, cfg)
 
 	// 测试连接
 	if err := sqlDB.Ping(); err != nil {
-		return fmt.Errorf("MySQL连接测试失败: %w", err)
+		return fmt.Errorf("%s连接测试失败: %w", cfg.Database.Driver, err)
 	}
 
-	// 设置MySQL特定的会话参数
-	if err := setMySQLSessionParams(db); err != nil {
-		return fmt.Errorf("设置MySQL会话参数失败: %w", err)
+	// 设置MySQL特定的会话参数，PostgreSQL的时区和编码由DSN和服务端配置决定
+	if cfg.Database.Driver == DriverMySQL {
+		if err := setMySQLSessionParams(db); err != nil {
+			return fmt.Errorf("设置MySQL会话参数失败: %w", err)
+		}
 	}
 
 	DB = db
 	return nil
 }
 
-// configureConnectionPool 配置MySQL连接池
+// configureConnectionPool 配置连接池
 func configureConnectionPool(sqlDB *sql.DB, cfg *config.Config) {
 	// 设置空闲连接池中连接的最大数量
 	sqlDB.SetMaxIdleConns(cfg.Database.MaxIdleConns)
@@ -192,7 +220,7 @@ func HealthCheck() error {
 	return nil
 }
 
-// GetVersion 获取MySQL版本信息
+// GetVersion 获取数据库版本信息
 func GetVersion() (string, error) {
 	if DB == nil {
 		return "", fmt.Errorf("数据库未连接")
@@ -200,7 +228,7 @@ func GetVersion() (string, error) {
 
 	var version string
 	if err := DB.Raw("SELECT VERSION()").Scan(&version).Error; err != nil {
-		return "", fmt.Errorf("获取MySQL版本失败: %w", err)
+		return "", fmt.Errorf("获取数据库版本失败: %w", err)
 	}
 
 	return version, nil
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"fmt"
 	"log"
 
@@ -15,23 +16,21 @@ func Initialize(cfg *config.Config) error {
 		return fmt.Errorf("数据库连接失败: %w", err)
 	}
 
-	logger.Info("MySQL数据库连接成功")
+	logger.Infof("数据库连接成功: driver=%s", Driver())
 
 	// 获取数据库版本信息
 	version, err := GetVersion()
 	if err != nil {
-		logger.Warnf("获取MySQL版本失败: %v", err)
+		logger.Warnf("获取数据库版本失败: %v", err)
 	} else {
-		logger.Infof("MySQL版本: %s", version)
+		logger.Infof("数据库版本: %s", version)
 	}
 
-	// 执行数据库迁移
-	if err := Migrate(); err != nil {
+	// 执行或检查版本化迁移
+	if err := prepareSchema(context.Background(), DB, cfg); err != nil {
 		return fmt.Errorf("数据库迁移失败: %w", err)
 	}
 
-	logger.Info("数据库迁移完成")
-
 	// 输出连接池状态
 	if stats, err := GetStats(); err == nil {
 		logger.Infof("数据库连接池状态: %+v", stats)
@@ -76,6 +75,7 @@ func GetConnectionInfo() map[string]interface{} {
 	}
 
 	info["connected"] = IsConnected()
+	info["driver"] = Driver()
 
 	if version, err := GetVersion(); err == nil {
 		info["version"] = version
@@ -1,28 +1,60 @@
 package database
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"taskmanage/pkg/logger"
 )
 
-// Migrate 执行数据库迁移
+// Migrate 在数据库上执行待执行的版本化迁移，迁移参数使用默认值
 func Migrate() error {
 	if DB == nil {
 		return fmt.Errorf("数据库未连接")
 	}
+	_, err := RunMigrations(context.Background(), DB, MigrationOptions{})
+	return err
+}
 
+// migrateBaseline 基线迁移：按当前模型自动迁移全部数据表，并执行引入版本化迁移之前的存量数据修正
+// 各步骤均可重复执行，已按旧方式自动迁移过的数据库执行基线迁移不会改变数据
+func migrateBaseline(db *gorm.DB) error {
 	// 自动迁移所有模型 (GORM会自动处理重复迁移)
-	models := GetAllModels()
-	if err := DB.AutoMigrate(models...); err != nil {
+	if err := db.AutoMigrate(GetAllModels()...); err != nil {
 		return fmt.Errorf("数据库迁移失败: %w", err)
 	}
 
+	// 流程定义版本化迁移
+	if err := migrateWorkflowVersions(db); err != nil {
+		return fmt.Errorf("流程定义版本迁移失败: %w", err)
+	}
+
+	// 技能分类字符串迁移为分类表
+	if err := migrateSkillCategories(db); err != nil {
+		return fmt.Errorf("技能分类迁移失败: %w", err)
+	}
+
+	// 修正误存为员工ID的任务负责人
+	if err := fixTaskAssigneeEmployeeIDs(db); err != nil {
+		return fmt.Errorf("修正任务负责人失败: %w", err)
+	}
+
 	// 创建索引 (已经有重复检查逻辑)
-	if err := createIndexes(); err != nil {
+	if err := createIndexes(db); err != nil {
 		return fmt.Errorf("创建索引失败: %w", err)
 	}
 
+	// 入职和任务分配审批同一业务对象只允许一个运行中的流程实例
+	if err := createRunningInstanceIndex(db); err != nil {
+		return fmt.Errorf("创建运行中流程实例唯一索引失败: %w", err)
+	}
+
 	// 插入初始数据 (只在数据为空时插入) - 权限角色初始化已移至bootstrap服务
-	if err := seedData(); err != nil {
+	if err := seedData(db); err != nil {
 		return fmt.Errorf("插入初始数据失败: %w", err)
 	}
 
@@ -30,7 +62,7 @@ func Migrate() error {
 }
 
 // createIndexes 创建额外的索引
-func createIndexes() error {
+func createIndexes(db *gorm.DB) error {
 	indexes := []struct {
 		name  string
 		table string
@@ -50,17 +82,9 @@ func createIndexes() error {
 	}
 
 	for _, idx := range indexes {
-		// 检查索引是否已存在
-		var count int64
-		err := DB.Raw("SELECT COUNT(*) FROM information_schema.statistics WHERE table_schema = DATABASE() AND table_name = ? AND index_name = ?", 
-			idx.table, idx.name).Scan(&count).Error
-		if err != nil {
-			return fmt.Errorf("检查索引 %s 失败: %w", idx.name, err)
-		}
-
 		// 如果索引不存在，则创建
-		if count == 0 {
-			if err := DB.Exec(idx.sql).Error; err != nil {
+		if !db.Migrator().HasIndex(idx.table, idx.name) {
+			if err := db.Exec(idx.sql).Error; err != nil {
 				return fmt.Errorf("创建索引 %s 失败: %w", idx.name, err)
 			}
 		}
@@ -69,13 +93,247 @@ func createIndexes() error {
 	return nil
 }
 
+// runningInstanceIndex 运行中流程实例的唯一索引，只约束 exclusiveBusinessTypes 中的业务类型
+const runningInstanceIndex = "idx_workflow_instances_running_business"
+
+// exclusiveBusinessTypes 同一业务对象只允许一个运行中实例的流程业务类型，与流程服务中以独占方式启动的流程一致
+const exclusiveBusinessTypes = "'onboarding', 'task_assignment'"
+
+// createRunningInstanceIndex 为运行中的独占流程实例创建(business_type, business_id)唯一约束
+// PostgreSQL使用部分唯一索引；MySQL不支持部分索引，改为只在实例运行中时有值的生成列加唯一索引（NULL不参与唯一性比较）
+// 存量数据已有重复的运行中实例时跳过创建并记录警告，此时只有流程引擎启动前的检查生效，清理后重启即可创建
+func createRunningInstanceIndex(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if migrator.HasIndex("workflow_instances", runningInstanceIndex) {
+		return nil
+	}
+
+	var duplicates []struct {
+		BusinessType string
+		BusinessID   string
+		Instances    int
+	}
+	err := db.Table("workflow_instances").
+		Select("business_type, business_id, COUNT(*) AS instances").
+		Where("status = 'running' AND deleted_at IS NULL AND business_type IN (" + exclusiveBusinessTypes + ")").
+		Group("business_type, business_id").
+		Having("COUNT(*) > 1").
+		Scan(&duplicates).Error
+	if err != nil {
+		return fmt.Errorf("查询重复的运行中流程实例失败: %w", err)
+	}
+	if len(duplicates) > 0 {
+		for _, duplicate := range duplicates {
+			logger.Warnf("业务对象 %s/%s 有%d个运行中的流程实例", duplicate.BusinessType, duplicate.BusinessID, duplicate.Instances)
+		}
+		logger.Warnf("存在重复的运行中流程实例，暂不创建唯一索引 %s，请取消多余的实例后重启", runningInstanceIndex)
+		return nil
+	}
+
+	switch db.Dialector.Name() {
+	case DriverPostgres:
+		return db.Exec(`CREATE UNIQUE INDEX ` + runningInstanceIndex + ` ON workflow_instances (business_type, business_id)
+			WHERE status = 'running' AND deleted_at IS NULL AND business_type IN (` + exclusiveBusinessTypes + `)`).Error
+	case DriverMySQL:
+		if !migrator.HasColumn("workflow_instances", "running_business_key") {
+			err := db.Exec(`ALTER TABLE workflow_instances ADD COLUMN running_business_key VARCHAR(160)
+				GENERATED ALWAYS AS (CASE WHEN status = 'running' AND deleted_at IS NULL AND business_type IN (` + exclusiveBusinessTypes + `)
+				THEN CONCAT(business_type, ':', business_id) END) STORED`).Error
+			if err != nil {
+				return fmt.Errorf("添加运行中业务键生成列失败: %w", err)
+			}
+		}
+		return db.Exec("CREATE UNIQUE INDEX " + runningInstanceIndex + " ON workflow_instances (running_business_key)").Error
+	}
+	return nil
+}
+
+// migrateWorkflowVersions 流程定义版本化迁移
+// 移除旧的 workflow_id 唯一索引，并为尚未绑定版本的存量实例绑定当前版本
+func migrateWorkflowVersions(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if migrator.HasIndex("workflow_definitions", "idx_workflow_definitions_workflow_id") {
+		if err := migrator.DropIndex("workflow_definitions", "idx_workflow_definitions_workflow_id"); err != nil {
+			return fmt.Errorf("删除旧流程定义索引失败: %w", err)
+		}
+	}
+
+	// 存量实例绑定到各流程的最新版本，使用相关子查询以兼容MySQL和PostgreSQL
+	err := db.Exec(`UPDATE workflow_instances
+		SET definition_version_id = (
+			SELECT MAX(wd.id) FROM workflow_definitions wd
+			WHERE wd.workflow_id = workflow_instances.workflow_id AND wd.deleted_at IS NULL
+		)
+		WHERE (definition_version_id IS NULL OR definition_version_id = 0)
+		AND EXISTS (
+			SELECT 1 FROM workflow_definitions wd
+			WHERE wd.workflow_id = workflow_instances.workflow_id AND wd.deleted_at IS NULL
+		)`).Error
+	if err != nil {
+		return fmt.Errorf("绑定存量实例流程版本失败: %w", err)
+	}
+
+	return nil
+}
+
+// fixTaskAssigneeEmployeeIDs 修正任务负责人
+// tasks.assignee_id 应为用户ID，早期部分分配路径误存了员工ID。对于不对应任何用户、但对应某个员工的负责人，
+// 替换为该员工的用户ID；仍无法对应的任务可通过 TaskRepository.ListTasksWithUnknownAssignee 排查
+func fixTaskAssigneeEmployeeIDs(db *gorm.DB) error {
+	result := db.Exec(`UPDATE tasks
+		SET assignee_id = (SELECT e.user_id FROM employees e WHERE e.id = tasks.assignee_id)
+		WHERE assignee_id IS NOT NULL
+		AND NOT EXISTS (SELECT 1 FROM users u WHERE u.id = tasks.assignee_id)
+		AND EXISTS (SELECT 1 FROM employees e WHERE e.id = tasks.assignee_id)`)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		logger.Infof("已将%d个任务的负责人从员工ID修正为用户ID", result.RowsAffected)
+	}
+	return nil
+}
+
+// addDepartmentOnboardingWorkflowType 为部门增加入职审批流程类型列，基线迁移已包含该列时跳过
+func addDepartmentOnboardingWorkflowType(db *gorm.DB) error {
+	if db.Migrator().HasColumn(&Department{}, "OnboardingWorkflowType") {
+		return nil
+	}
+	if err := db.Migrator().AddColumn(&Department{}, "OnboardingWorkflowType"); err != nil {
+		return fmt.Errorf("添加部门入职审批流程类型列失败: %w", err)
+	}
+	return nil
+}
+
+// createHolidays 创建节假日表，基线迁移已创建时跳过
+func createHolidays(db *gorm.DB) error {
+	if db.Migrator().HasTable(&Holiday{}) {
+		return nil
+	}
+	if err := db.Migrator().CreateTable(&Holiday{}); err != nil {
+		return fmt.Errorf("创建节假日表失败: %w", err)
+	}
+	return nil
+}
+
+// createWebhooks 创建Webhook端点、投递记录和发送尝试表，基线迁移已创建的表跳过
+func createWebhooks(db *gorm.DB) error {
+	for _, model := range []interface{}{&WebhookEndpoint{}, &WebhookDelivery{}, &WebhookDeliveryAttempt{}} {
+		if db.Migrator().HasTable(model) {
+			continue
+		}
+		if err := db.Migrator().CreateTable(model); err != nil {
+			return fmt.Errorf("创建Webhook表失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// createCounters 创建计数器表，员工编号计数器从已有员工的最大用户ID开始，避免与原先按用户ID生成的编号重复
+func createCounters(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&Counter{}) {
+		if err := db.Migrator().CreateTable(&Counter{}); err != nil {
+			return fmt.Errorf("创建计数器表失败: %w", err)
+		}
+	}
+
+	var count int64
+	if err := db.Model(&Counter{}).Where("name = ?", EmployeeNoCounter).Count(&count).Error; err != nil {
+		return fmt.Errorf("查询员工编号计数器失败: %w", err)
+	}
+	if count > 0 {
+		return nil
+	}
+	var maxUserID int64
+	if err := db.Unscoped().Model(&Employee{}).Select("COALESCE(MAX(user_id), 0)").Scan(&maxUserID).Error; err != nil {
+		return fmt.Errorf("查询员工最大用户ID失败: %w", err)
+	}
+	if err := db.Create(&Counter{Name: EmployeeNoCounter, Value: maxUserID}).Error; err != nil {
+		return fmt.Errorf("初始化员工编号计数器失败: %w", err)
+	}
+	return nil
+}
+
+// createPermissionRuleExecutions 创建权限规则执行记录表，基线迁移已创建时跳过
+func createPermissionRuleExecutions(db *gorm.DB) error {
+	if db.Migrator().HasTable(&PermissionRuleExecution{}) {
+		return nil
+	}
+	if err := db.Migrator().CreateTable(&PermissionRuleExecution{}); err != nil {
+		return fmt.Errorf("创建权限规则执行记录表失败: %w", err)
+	}
+	return nil
+}
+
+// migrateSkillCategories 将skills.category中的分类字符串迁移到skill_categories表
+// 分类名按去除首尾空格后不区分大小写归并，同组内以最早出现的写法作为分类名；旧的category列保留但不再写入
+func migrateSkillCategories(db *gorm.DB) error {
+	if !db.Migrator().HasColumn("skills", "category") {
+		return nil
+	}
+
+	var rows []struct {
+		Category string
+	}
+	err := db.Table("skills").
+		Select("category, MIN(id) AS first_id").
+		Where("category_id IS NULL AND category IS NOT NULL AND TRIM(category) <> ''").
+		Group("category").
+		Order("first_id ASC").
+		Scan(&rows).Error
+	if err != nil {
+		return fmt.Errorf("查询存量技能分类失败: %w", err)
+	}
+
+	migrated := 0
+	for _, row := range rows {
+		categoryID, err := ensureSkillCategory(db, strings.TrimSpace(row.Category))
+		if err != nil {
+			return err
+		}
+		result := db.Table("skills").
+			Where("category_id IS NULL AND category = ?", row.Category).
+			Update("category_id", categoryID)
+		if result.Error != nil {
+			return fmt.Errorf("关联技能分类 %s 失败: %w", row.Category, result.Error)
+		}
+		migrated += int(result.RowsAffected)
+	}
+	if migrated > 0 {
+		logger.Infof("已将%d个技能的分类迁移到技能分类表", migrated)
+	}
+	return nil
+}
+
+// ensureSkillCategory 按名称不区分大小写查找技能分类，不存在时创建
+func ensureSkillCategory(db *gorm.DB, name string) (uint, error) {
+	var category SkillCategory
+	err := db.Where("LOWER(name) = LOWER(?)", name).First(&category).Error
+	if err == nil {
+		return category.ID, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, fmt.Errorf("查询技能分类 %s 失败: %w", name, err)
+	}
+
+	category = SkillCategory{Name: name}
+	if err := db.Create(&category).Error; err != nil {
+		return 0, fmt.Errorf("创建技能分类 %s 失败: %w", name, err)
+	}
+	return category.ID, nil
+}
+
 // seedData 插入初始数据
-func seedData() error {
+func seedData(db *gorm.DB) error {
 	// 注意：权限和角色的初始化现在由 bootstrap 服务处理
 	// 这里只初始化其他基础数据
 
 	// 创建默认技能 (使用FirstOrCreate避免重复)
-	skillData := []Skill{
+	skillData := []struct {
+		Name        string
+		Category    string
+		Description string
+	}{
 		{Name: "Java开发", Category: "编程语言", Description: "Java编程语言开发技能"},
 		{Name: "Go开发", Category: "编程语言", Description: "Go编程语言开发技能"},
 		{Name: "Python开发", Category: "编程语言", Description: "Python编程语言开发技能"},
@@ -87,8 +345,13 @@ func seedData() error {
 	}
 
 	for _, skillInfo := range skillData {
+		categoryID, err := ensureSkillCategory(db, skillInfo.Category)
+		if err != nil {
+			return err
+		}
 		var skill Skill
-		if err := DB.FirstOrCreate(&skill, Skill{Name: skillInfo.Name}, skillInfo).Error; err != nil {
+		attrs := Skill{CategoryID: &categoryID, Description: skillInfo.Description}
+		if err := db.Where(Skill{Name: skillInfo.Name}).Attrs(attrs).FirstOrCreate(&skill).Error; err != nil {
 			return fmt.Errorf("创建技能 %s 失败: %w", skillInfo.Name, err)
 		}
 	}
@@ -99,13 +362,14 @@ func seedData() error {
 		{Key: "system.version", Value: "1.0.0", Type: "string", Category: "基础", Description: "系统版本", IsPublic: true},
 		{Key: "task.auto_assign", Value: "true", Type: "bool", Category: "任务", Description: "是否启用自动分配", IsPublic: false},
 		{Key: "task.default_priority", Value: "medium", Type: "string", Category: "任务", Description: "默认任务优先级", IsPublic: false},
+		{Key: "task.due_reminders", Value: `{"lead_hours":{"urgent":[24,4],"high":[24],"medium":[48]},"notify_creator":false}`, Type: "json", Category: "任务", Description: "任务截止提醒提前量（小时）", IsPublic: false},
 		{Key: "employee.max_tasks", Value: "5", Type: "int", Category: "员工", Description: "员工最大任务数", IsPublic: false},
 		{Key: "notification.email_enabled", Value: "false", Type: "bool", Category: "通知", Description: "是否启用邮件通知", IsPublic: false},
 	}
 
 	for _, configInfo := range configData {
 		var config SystemConfig
-		if err := DB.FirstOrCreate(&config, SystemConfig{Key: configInfo.Key}, configInfo).Error; err != nil {
+		if err := db.FirstOrCreate(&config, SystemConfig{Key: configInfo.Key}, configInfo).Error; err != nil {
 			return fmt.Errorf("创建配置 %s 失败: %w", configInfo.Key, err)
 		}
 	}
@@ -125,6 +389,10 @@ func DropTables() error {
 			return fmt.Errorf("删除表失败: %w", err)
 		}
 	}
+	// 同时删除迁移记录，重建时从基线迁移开始
+	if err := DB.Migrator().DropTable(&SchemaMigration{}); err != nil {
+		return fmt.Errorf("删除迁移记录表失败: %w", err)
+	}
 
 	return nil
 }
@@ -0,0 +1,479 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"taskmanage/internal/config"
+	"taskmanage/pkg/logger"
+)
+
+// 存在待执行迁移时的启动策略
+const (
+	PendingMigrationsWarn = "warn"
+	PendingMigrationsFail = "fail"
+)
+
+const (
+	// migrationLockName MySQL命名锁名称
+	migrationLockName = "taskmanage_schema_migrations"
+	// migrationLockKey PostgreSQL咨询锁键
+	migrationLockKey int64 = 0x7461736b6d6967
+	// defaultMigrationLockTimeout 默认等待迁移锁的时长
+	defaultMigrationLockTimeout = 5 * time.Minute
+)
+
+var (
+	// ErrMigrationChecksumMismatch 已执行的迁移在执行后被修改
+	ErrMigrationChecksumMismatch = errors.New("已执行的迁移被修改")
+	// ErrMigrationLockTimeout 等待迁移锁超时，通常是其他实例正在执行迁移
+	ErrMigrationLockTimeout = errors.New("等待迁移锁超时")
+)
+
+// migrationFiles SQL迁移文件，与goMigrations共用版本号
+//
+//go:embed migrations
+var migrationFiles embed.FS
+
+// sqlMigrationName SQL迁移文件名：{版本号}_{名称}.sql，只适用于某个驱动时为 {版本号}_{名称}.{mysql|postgres}.sql
+var sqlMigrationName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+?)(?:\.(mysql|postgres))?\.sql$`)
+
+// goMigrations 以Go函数实现的迁移，适用于需要按驱动分支或处理存量数据的变更
+// 已发布的迁移不能修改版本号和名称，也不能删除
+var goMigrations = []goMigration{
+	{Version: 1, Name: "baseline", Up: migrateBaseline},
+	{Version: 2, Name: "add_department_onboarding_workflow_type", Up: addDepartmentOnboardingWorkflowType},
+	{Version: 3, Name: "create_holidays", Up: createHolidays},
+	{Version: 4, Name: "create_webhooks", Up: createWebhooks},
+	{Version: 5, Name: "create_counters", Up: createCounters},
+	{Version: 6, Name: "create_permission_rule_executions", Up: createPermissionRuleExecutions},
+}
+
+// goMigration Go函数实现的迁移
+type goMigration struct {
+	Version int64
+	Name    string
+	Up      func(db *gorm.DB) error
+}
+
+// SchemaMigration 已执行的迁移记录
+type SchemaMigration struct {
+	Version    int64     `gorm:"primaryKey;autoIncrement:false" json:"version"`
+	Name       string    `gorm:"size:200;not null" json:"name"`
+	Checksum   string    `gorm:"size:64;not null" json:"checksum"`
+	AppliedAt  time.Time `gorm:"not null" json:"applied_at"`
+	DurationMs int64     `json:"duration_ms"`
+}
+
+// TableName 迁移记录表名
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Migration 一个版本化迁移
+// SQL迁移的校验和为文件内容的SHA-256，Go迁移的校验和由版本号和名称计算
+type Migration struct {
+	Version  int64
+	Name     string
+	Checksum string
+	up       func(db *gorm.DB) error
+}
+
+// String 迁移标识，如 0001_baseline
+func (m *Migration) String() string {
+	return fmt.Sprintf("%04d_%s", m.Version, m.Name)
+}
+
+// MigrationOptions 执行迁移的参数
+type MigrationOptions struct {
+	LockTimeout    time.Duration // 等待其他实例释放迁移锁的时长，0表示默认5分钟
+	DDLLockTimeout time.Duration // 迁移语句等待表锁的时长，超时后迁移失败而不是让业务写入排队等待，0表示不限制
+}
+
+// MigrationOptionsFromConfig 由数据库配置生成迁移参数
+func MigrationOptionsFromConfig(cfg *config.Config) MigrationOptions {
+	return MigrationOptions{
+		LockTimeout:    time.Duration(cfg.Database.MigrationLockTimeout) * time.Second,
+		DDLLockTimeout: time.Duration(cfg.Database.MigrationDDLLockTimeout) * time.Second,
+	}
+}
+
+// Migrations 返回指定驱动的全部迁移，按版本号升序
+func Migrations(driver string) ([]*Migration, error) {
+	files, err := fs.Sub(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	migrations, err := loadSQLMigrations(files, driver)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range goMigrations {
+		migrations = append(migrations, &Migration{
+			Version:  m.Version,
+			Name:     m.Name,
+			Checksum: checksum(fmt.Sprintf("go:%d:%s", m.Version, m.Name)),
+			up:       m.Up,
+		})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version == migrations[i-1].Version {
+			return nil, fmt.Errorf("迁移版本号重复: %s 与 %s", migrations[i-1], migrations[i])
+		}
+	}
+	return migrations, nil
+}
+
+// loadSQLMigrations 读取SQL迁移文件，同一版本号有当前驱动专用的文件时优先使用，只有其他驱动的文件时返回错误
+func loadSQLMigrations(files fs.FS, driver string) ([]*Migration, error) {
+	entries, err := fs.ReadDir(files, ".")
+	if err != nil {
+		return nil, fmt.Errorf("读取迁移文件失败: %w", err)
+	}
+
+	type candidate struct {
+		name    string
+		file    string
+		drivers []string
+	}
+	byVersion := make(map[int64]*candidate)
+	var versions []int64
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+		match := sqlMigrationName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("迁移文件名不合法: %s", entry.Name())
+		}
+		version, _ := strconv.ParseInt(match[1], 10, 64)
+		c, ok := byVersion[version]
+		if !ok {
+			c = &candidate{name: match[2]}
+			byVersion[version] = c
+			versions = append(versions, version)
+		}
+		if c.name != match[2] {
+			return nil, fmt.Errorf("迁移版本号重复: %s 与 %04d_%s", entry.Name(), version, c.name)
+		}
+		c.drivers = append(c.drivers, match[3])
+		if match[3] == driver || (match[3] == "" && c.file == "") {
+			c.file = entry.Name()
+		}
+	}
+
+	migrations := make([]*Migration, 0, len(versions))
+	for _, version := range versions {
+		c := byVersion[version]
+		if c.file == "" {
+			return nil, fmt.Errorf("迁移 %04d_%s 缺少适用于 %s 的文件", version, c.name, driver)
+		}
+		content, err := fs.ReadFile(files, c.file)
+		if err != nil {
+			return nil, fmt.Errorf("读取迁移文件 %s 失败: %w", c.file, err)
+		}
+		statements := splitSQLStatements(string(content))
+		migrations = append(migrations, &Migration{
+			Version:  version,
+			Name:     c.name,
+			Checksum: checksum(string(content)),
+			up: func(db *gorm.DB) error {
+				for _, statement := range statements {
+					if err := db.Exec(statement).Error; err != nil {
+						return err
+					}
+				}
+				return nil
+			},
+		})
+	}
+	return migrations, nil
+}
+
+// splitSQLStatements 按行尾分号拆分SQL语句，忽略只有注释的行
+// 语句中间的分号只要不在行尾就不会被拆分
+func splitSQLStatements(content string) []string {
+	var statements []string
+	var current strings.Builder
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSuffix(strings.TrimSpace(current.String()), ";"))
+			current.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+	return statements
+}
+
+// checksum 计算SHA-256校验和
+func checksum(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// planMigrations 对照已执行的记录得到待执行的迁移
+// 已执行迁移的校验和与当前不一致时拒绝执行；数据库中有当前版本不包含的迁移时（如回滚到旧版本）只记录警告
+func planMigrations(migrations []*Migration, records []*SchemaMigration) ([]*Migration, error) {
+	applied := make(map[int64]*SchemaMigration, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+
+	var pending []*Migration
+	known := make(map[int64]bool, len(migrations))
+	for _, m := range migrations {
+		known[m.Version] = true
+		record, ok := applied[m.Version]
+		if !ok {
+			pending = append(pending, m)
+			continue
+		}
+		if record.Checksum != m.Checksum {
+			return nil, fmt.Errorf("%w: %s 执行时的校验和为 %s，当前为 %s", ErrMigrationChecksumMismatch, m, record.Checksum, m.Checksum)
+		}
+	}
+	for _, record := range records {
+		if !known[record.Version] {
+			logger.Warnf("数据库中存在当前版本不包含的迁移: %04d_%s", record.Version, record.Name)
+		}
+	}
+	return pending, nil
+}
+
+// PendingMigrations 返回尚未执行的迁移，已执行的迁移被修改时返回错误
+func PendingMigrations(ctx context.Context, db *gorm.DB) ([]*Migration, error) {
+	migrations, err := Migrations(db.Dialector.Name())
+	if err != nil {
+		return nil, err
+	}
+	db = db.WithContext(ctx)
+	if !db.Migrator().HasTable(&SchemaMigration{}) {
+		return migrations, nil
+	}
+	records, err := loadSchemaMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+	return planMigrations(migrations, records)
+}
+
+// RunMigrations 按版本号依次执行待执行的迁移，返回本次执行的迁移
+// 执行期间持有数据库级的迁移锁，多个实例同时启动时只有一个执行，其余等待后发现已无待执行的迁移
+// 每个迁移执行成功后立即记录，失败时已成功的迁移保留，重新执行时从失败的迁移继续
+func RunMigrations(ctx context.Context, db *gorm.DB, opts MigrationOptions) ([]*Migration, error) {
+	migrations, err := Migrations(db.Dialector.Name())
+	if err != nil {
+		return nil, err
+	}
+	lockTimeout := opts.LockTimeout
+	if lockTimeout <= 0 {
+		lockTimeout = defaultMigrationLockTimeout
+	}
+
+	var applied []*Migration
+	// 命名锁和咨询锁都属于会话，加锁、迁移和解锁需在同一个连接上执行
+	err = db.WithContext(ctx).Connection(func(conn *gorm.DB) error {
+		if err := acquireMigrationLock(ctx, conn, lockTimeout); err != nil {
+			return err
+		}
+		defer releaseMigrationLock(conn)
+
+		if opts.DDLLockTimeout > 0 {
+			if err := setDDLLockTimeout(conn, opts.DDLLockTimeout); err != nil {
+				return err
+			}
+			defer resetDDLLockTimeout(conn)
+		}
+
+		if err := conn.AutoMigrate(&SchemaMigration{}); err != nil {
+			return fmt.Errorf("创建迁移记录表失败: %w", err)
+		}
+		records, err := loadSchemaMigrations(conn)
+		if err != nil {
+			return err
+		}
+		pending, err := planMigrations(migrations, records)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range pending {
+			logger.Infof("执行数据库迁移: %s", m)
+			if err := applyMigration(conn, m); err != nil {
+				return fmt.Errorf("执行迁移 %s 失败: %w", m, err)
+			}
+			applied = append(applied, m)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// applyMigration 执行单个迁移并记录
+// PostgreSQL的DDL支持事务，迁移与记录一起提交；MySQL执行DDL会隐式提交，失败时已执行的语句不会回滚，迁移需能重复执行
+func applyMigration(conn *gorm.DB, m *Migration) error {
+	run := func(db *gorm.DB) error {
+		started := time.Now()
+		if err := m.up(db); err != nil {
+			return err
+		}
+		return db.Create(&SchemaMigration{
+			Version:    m.Version,
+			Name:       m.Name,
+			Checksum:   m.Checksum,
+			AppliedAt:  time.Now(),
+			DurationMs: time.Since(started).Milliseconds(),
+		}).Error
+	}
+	if conn.Dialector.Name() == DriverPostgres {
+		return conn.Transaction(run)
+	}
+	return run(conn)
+}
+
+// loadSchemaMigrations 读取已执行的迁移记录
+func loadSchemaMigrations(db *gorm.DB) ([]*SchemaMigration, error) {
+	var records []*SchemaMigration
+	if err := db.Order("version ASC").Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("读取迁移记录失败: %w", err)
+	}
+	return records, nil
+}
+
+// acquireMigrationLock 获取迁移锁，MySQL使用GET_LOCK，PostgreSQL轮询pg_try_advisory_lock
+func acquireMigrationLock(ctx context.Context, conn *gorm.DB, timeout time.Duration) error {
+	switch conn.Dialector.Name() {
+	case DriverMySQL:
+		var acquired *int64
+		seconds := int(timeout / time.Second)
+		if err := conn.Raw("SELECT GET_LOCK(?, ?)", migrationLockName, seconds).Row().Scan(&acquired); err != nil {
+			return fmt.Errorf("获取迁移锁失败: %w", err)
+		}
+		if acquired == nil || *acquired != 1 {
+			return ErrMigrationLockTimeout
+		}
+	case DriverPostgres:
+		deadline := time.Now().Add(timeout)
+		for waited := false; ; waited = true {
+			var acquired bool
+			if err := conn.Raw("SELECT pg_try_advisory_lock(?)", migrationLockKey).Row().Scan(&acquired); err != nil {
+				return fmt.Errorf("获取迁移锁失败: %w", err)
+			}
+			if acquired {
+				return nil
+			}
+			if !waited {
+				logger.Info("其他实例正在执行数据库迁移，等待迁移锁")
+			}
+			if time.Now().After(deadline) {
+				return ErrMigrationLockTimeout
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Second):
+			}
+		}
+	}
+	return nil
+}
+
+// releaseMigrationLock 释放迁移锁，失败只记录日志，连接关闭时锁也会释放
+func releaseMigrationLock(conn *gorm.DB) {
+	var err error
+	switch conn.Dialector.Name() {
+	case DriverMySQL:
+		err = conn.Exec("SELECT RELEASE_LOCK(?)", migrationLockName).Error
+	case DriverPostgres:
+		err = conn.Exec("SELECT pg_advisory_unlock(?)", migrationLockKey).Error
+	}
+	if err != nil {
+		logger.Warnf("释放迁移锁失败: %v", err)
+	}
+}
+
+// setDDLLockTimeout 限制当前会话中语句等待表锁的时长
+// DDL等待表锁期间会阻塞后续对该表的读写，限制等待时长可避免业务请求在迁移后面排队
+func setDDLLockTimeout(conn *gorm.DB, timeout time.Duration) error {
+	var err error
+	switch conn.Dialector.Name() {
+	case DriverMySQL:
+		seconds := int(timeout / time.Second)
+		if seconds < 1 {
+			seconds = 1
+		}
+		err = conn.Exec("SET SESSION lock_wait_timeout = ?", seconds).Error
+	case DriverPostgres:
+		err = conn.Exec(fmt.Sprintf("SET lock_timeout = '%dms'", timeout.Milliseconds())).Error
+	}
+	if err != nil {
+		return fmt.Errorf("设置迁移锁等待时长失败: %w", err)
+	}
+	return nil
+}
+
+// resetDDLLockTimeout 恢复会话的表锁等待时长，连接随后归还连接池
+func resetDDLLockTimeout(conn *gorm.DB) {
+	var err error
+	switch conn.Dialector.Name() {
+	case DriverMySQL:
+		err = conn.Exec("SET SESSION lock_wait_timeout = @@GLOBAL.lock_wait_timeout").Error
+	case DriverPostgres:
+		err = conn.Exec("RESET lock_timeout").Error
+	}
+	if err != nil {
+		logger.Warnf("恢复表锁等待时长失败: %v", err)
+	}
+}
+
+// prepareSchema 开启自动迁移时执行待执行的迁移；否则只检查，存在待执行的迁移时按配置记录警告或拒绝启动
+func prepareSchema(ctx context.Context, db *gorm.DB, cfg *config.Config) error {
+	if cfg.Database.AutoMigrate {
+		applied, err := RunMigrations(ctx, db, MigrationOptionsFromConfig(cfg))
+		if err != nil {
+			return err
+		}
+		logger.Infof("数据库迁移完成，本次执行%d个迁移", len(applied))
+		return nil
+	}
+
+	pending, err := PendingMigrations(ctx, db)
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(pending))
+	for _, m := range pending {
+		names = append(names, m.String())
+	}
+	if cfg.Database.PendingMigrations == PendingMigrationsFail {
+		return fmt.Errorf("存在%d个待执行的数据库迁移: %s，请先执行 -migrate", len(pending), strings.Join(names, ", "))
+	}
+	logger.Warnf("存在%d个待执行的数据库迁移: %s，请执行 -migrate", len(pending), strings.Join(names, ", "))
+	return nil
+}
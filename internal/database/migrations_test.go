@@ -0,0 +1,103 @@
+package database
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrations_Registered(t *testing.T) {
+	for _, driver := range []string{DriverMySQL, DriverPostgres} {
+		migrations, err := Migrations(driver)
+		require.NoError(t, err)
+		require.NotEmpty(t, migrations)
+		assert.Equal(t, "0001_baseline", migrations[0].String())
+		for i := 1; i < len(migrations); i++ {
+			assert.Less(t, migrations[i-1].Version, migrations[i].Version)
+		}
+	}
+}
+
+func TestLoadSQLMigrations(t *testing.T) {
+	files := fstest.MapFS{
+		"README.md":                     {Data: []byte("说明")},
+		"0003_add_labels.sql":           {Data: []byte("CREATE TABLE labels (id INT);\n")},
+		"0002_widen_title.mysql.sql":    {Data: []byte("ALTER TABLE tasks MODIFY title VARCHAR(500);\n")},
+		"0002_widen_title.postgres.sql": {Data: []byte("ALTER TABLE tasks ALTER COLUMN title TYPE VARCHAR(500);\n")},
+	}
+
+	mysqlMigrations, err := loadSQLMigrations(files, DriverMySQL)
+	require.NoError(t, err)
+	postgresMigrations, err := loadSQLMigrations(files, DriverPostgres)
+	require.NoError(t, err)
+	require.Len(t, mysqlMigrations, 2)
+	require.Len(t, postgresMigrations, 2)
+
+	// 同一版本号按驱动选择文件，校验和随文件内容不同
+	byVersion := func(migrations []*Migration, version int64) *Migration {
+		for _, m := range migrations {
+			if m.Version == version {
+				return m
+			}
+		}
+		return nil
+	}
+	assert.Equal(t, "widen_title", byVersion(mysqlMigrations, 2).Name)
+	assert.NotEqual(t, byVersion(mysqlMigrations, 2).Checksum, byVersion(postgresMigrations, 2).Checksum)
+	assert.Equal(t, byVersion(mysqlMigrations, 3).Checksum, byVersion(postgresMigrations, 3).Checksum)
+
+	// 只有其他驱动的文件时报错
+	delete(files, "0002_widen_title.postgres.sql")
+	_, err = loadSQLMigrations(files, DriverPostgres)
+	assert.ErrorContains(t, err, "0002_widen_title")
+
+	_, err = loadSQLMigrations(fstest.MapFS{"add_labels.sql": {Data: []byte("SELECT 1;")}}, DriverMySQL)
+	assert.ErrorContains(t, err, "文件名不合法")
+	_, err = loadSQLMigrations(fstest.MapFS{
+		"0002_a.sql": {Data: []byte("SELECT 1;")},
+		"0002_b.sql": {Data: []byte("SELECT 2;")},
+	}, DriverMySQL)
+	assert.ErrorContains(t, err, "版本号重复")
+}
+
+func TestSplitSQLStatements(t *testing.T) {
+	content := `-- 任务标签
+CREATE TABLE labels (
+  id INT,
+  name VARCHAR(50) DEFAULT 'a;b'
+);
+
+INSERT INTO labels (id, name) VALUES (1, 'x');
+UPDATE labels SET name = 'y'`
+
+	assert.Equal(t, []string{
+		"CREATE TABLE labels (\n  id INT,\n  name VARCHAR(50) DEFAULT 'a;b'\n)",
+		"INSERT INTO labels (id, name) VALUES (1, 'x')",
+		"UPDATE labels SET name = 'y'",
+	}, splitSQLStatements(content))
+}
+
+func TestPlanMigrations(t *testing.T) {
+	migrations := []*Migration{
+		{Version: 1, Name: "baseline", Checksum: "a"},
+		{Version: 2, Name: "add_labels", Checksum: "b"},
+		{Version: 3, Name: "widen_title", Checksum: "c"},
+	}
+
+	// 已执行的迁移跳过，数据库中多出的记录不影响执行
+	pending, err := planMigrations(migrations, []*SchemaMigration{
+		{Version: 1, Name: "baseline", Checksum: "a"},
+		{Version: 9, Name: "future", Checksum: "z"},
+	})
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	assert.Equal(t, "0002_add_labels", pending[0].String())
+	assert.Equal(t, "0003_widen_title", pending[1].String())
+
+	// 已执行的迁移被修改时拒绝执行
+	_, err = planMigrations(migrations, []*SchemaMigration{{Version: 2, Name: "add_labels", Checksum: "changed"}})
+	assert.ErrorIs(t, err, ErrMigrationChecksumMismatch)
+	assert.ErrorContains(t, err, "0002_add_labels")
+}
@@ -11,6 +11,7 @@ const (
 	TaskStatusPending    = "pending"
 	TaskStatusAssigned   = "assigned"
 	TaskStatusInProgress = "in_progress"
+	TaskStatusInReview   = "in_review" // 需要审核的任务提交完成后等待审核人确认
 	TaskStatusCompleted  = "completed"
 	TaskStatusCancelled  = "cancelled"
 )
@@ -20,7 +21,7 @@ type BaseModel struct {
 	ID        uint           `gorm:"primarykey" json:"id"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty" swaggertype:"string" format:"date-time"`
 }
 
 // User 用户表
@@ -51,8 +52,10 @@ type Role struct {
 	Name        string `gorm:"uniqueIndex;size:50;not null" json:"name"`
 	DisplayName string `gorm:"size:100" json:"display_name"`
 	Description string `gorm:"size:255" json:"description"`
+	ParentID    *uint  `gorm:"index" json:"parent_id"` // 上级角色，按角色查找审批人时同时匹配其下级角色
 
 	// 关联关系
+	Parent      *Role        `gorm:"foreignKey:ParentID" json:"parent,omitempty"`
 	Users       []User       `gorm:"many2many:user_roles;" json:"users,omitempty"`
 	Permissions []Permission `gorm:"many2many:role_permissions;" json:"permissions,omitempty"`
 }
@@ -81,6 +84,10 @@ type Department struct {
 	Level       int    `gorm:"default:1" json:"level"`
 	Path        string `gorm:"size:500" json:"path"` // 部门路径，如: /1/3/5
 	Status      string `gorm:"size:20;default:active" json:"status"`
+	// AssignmentStrategy 部门任务自动分配的默认策略，为空时使用系统默认策略
+	AssignmentStrategy string `gorm:"size:50" json:"assignment_strategy"`
+	// OnboardingWorkflowType 部门员工入职审批流程类型 simple/full，为空时使用系统配置的默认流程
+	OnboardingWorkflowType string `gorm:"size:20" json:"onboarding_workflow_type"`
 
 	// 关联关系
 	Parent    *Department  `gorm:"foreignKey:ParentID" json:"parent,omitempty"`
@@ -117,6 +124,7 @@ type Project struct {
 	Budget       float64    `gorm:"default:0" json:"budget"`
 	DepartmentID uint       `gorm:"not null;index" json:"department_id"`
 	ManagerID    uint       `gorm:"not null;index" json:"manager_id"`
+	WIPLimits    *string    `gorm:"type:json" json:"-"` // 各任务状态的在制品上限，如 {"in_progress":5}
 
 	// 关联关系
 	Department Department `gorm:"foreignKey:DepartmentID" json:"department,omitempty"`
@@ -125,6 +133,23 @@ type Project struct {
 	Tasks      []Task     `gorm:"foreignKey:ProjectID" json:"tasks,omitempty"`
 }
 
+// 里程碑状态常量
+const (
+	MilestoneStatusPending  = "pending"
+	MilestoneStatusAchieved = "achieved"
+	MilestoneStatusMissed   = "missed"
+)
+
+// Milestone 项目里程碑表
+type Milestone struct {
+	BaseModel
+	ProjectID   uint      `gorm:"not null;index" json:"project_id"`
+	Name        string    `gorm:"size:100;not null" json:"name"`
+	Date        time.Time `gorm:"not null;index" json:"date"`
+	Status      string    `gorm:"size:20;default:pending" json:"status"` // pending, achieved, missed
+	Description string    `gorm:"size:255" json:"description"`
+}
+
 // Employee 员工表
 type Employee struct {
 	BaseModel
@@ -135,11 +160,13 @@ type Employee struct {
 	DirectManagerID *uint  `gorm:"index" json:"direct_manager_id"` // 直接上级
 
 	// 入职流程相关字段
-	OnboardingStatus string     `gorm:"size:30;default:pending_onboard" json:"onboarding_status"` // 入职状态
-	ExpectedDate     *time.Time `json:"expected_date,omitempty"`                                  // 预期入职日期
-	HireDate         *time.Time `json:"hire_date,omitempty"`                                      // 实际入职日期
-	ProbationEndDate *time.Time `json:"probation_end_date,omitempty"`                             // 试用期结束日期
-	ConfirmDate      *time.Time `json:"confirm_date,omitempty"`                                   // 转正日期
+	OnboardingStatus    string     `gorm:"size:30;default:pending_onboard" json:"onboarding_status"` // 入职状态
+	ExpectedDate        *time.Time `json:"expected_date,omitempty"`                                  // 预期入职日期
+	HireDate            *time.Time `json:"hire_date,omitempty"`                                      // 实际入职日期
+	ProbationEndDate    *time.Time `json:"probation_end_date,omitempty"`                             // 试用期结束日期
+	ConfirmDate         *time.Time `json:"confirm_date,omitempty"`                                   // 转正日期
+	ProbationDays       int        `gorm:"default:0" json:"probation_days,omitempty"`                // 试用期天数，启动入职审批时记录
+	ProbationRemindedAt *time.Time `json:"-"`                                                        // 已发送试用期到期提醒的时间
 
 	// 工作信息
 	WorkLocation string `gorm:"size:100" json:"work_location"`
@@ -147,6 +174,7 @@ type Employee struct {
 	Status       string `gorm:"size:20;default:available" json:"status"`   // available, busy, offline, leave, resigned
 	MaxTasks     int    `gorm:"default:5" json:"max_tasks"`
 	CurrentTasks int    `gorm:"default:0" json:"current_tasks"`
+	Version      uint   `gorm:"not null;default:1" json:"version"` // 乐观锁版本号
 
 	// 入职备注
 	OnboardingNotes string `gorm:"type:text" json:"onboarding_notes,omitempty"`
@@ -166,12 +194,28 @@ type Employee struct {
 type Skill struct {
 	BaseModel
 	Name        string `gorm:"uniqueIndex;size:50;not null" json:"name"`
-	Category    string `gorm:"size:50" json:"category"`
+	CategoryID  *uint  `gorm:"index" json:"category_id"`
 	Description string `gorm:"size:255" json:"description"`
 
 	// 关联关系
-	Employees []Employee `gorm:"many2many:employee_skills;" json:"employees,omitempty"`
-	Tasks     []Task     `gorm:"many2many:task_skills;" json:"tasks,omitempty"`
+	Category  *SkillCategory `gorm:"foreignKey:CategoryID" json:"category,omitempty"`
+	Employees []Employee     `gorm:"many2many:employee_skills;" json:"employees,omitempty"`
+	Tasks     []Task         `gorm:"many2many:task_skills;" json:"tasks,omitempty"`
+}
+
+// CategoryName 技能分类名称，未分类或未加载分类时为空
+func (s *Skill) CategoryName() string {
+	if s.Category == nil {
+		return ""
+	}
+	return s.Category.Name
+}
+
+// SkillCategory 技能分类表，名称不区分大小写唯一
+type SkillCategory struct {
+	BaseModel
+	Name        string `gorm:"uniqueIndex;size:50;not null" json:"name"`
+	Description string `gorm:"size:255" json:"description"`
 }
 
 // Task 任务表
@@ -180,13 +224,20 @@ type Task struct {
 	Title          string     `gorm:"size:200;not null" json:"title"`
 	Description    string     `gorm:"type:text" json:"description"`
 	Priority       string     `gorm:"size:20;default:medium" json:"priority"` // low, medium, high, urgent
-	Status         string     `gorm:"size:20;default:pending" json:"status"`  // pending, assigned, in_progress, completed, cancelled
+	Status         string     `gorm:"size:20;default:pending" json:"status"`  // pending, assigned, in_progress, in_review, completed, cancelled
 	Type           string     `gorm:"size:50" json:"type"`
 	EstimatedHours float64    `gorm:"default:0" json:"estimated_hours"`
 	ActualHours    float64    `gorm:"default:0" json:"actual_hours"`
 	DueDate        *time.Time `json:"due_date"`
 	StartedAt      *time.Time `json:"started_at"`
 	CompletedAt    *time.Time `json:"completed_at"`
+	Version        uint       `gorm:"not null;default:1" json:"version"` // 乐观锁版本号
+	// AutoCompleteOnChildren 最后一个未完成的子任务完成时自动完成本任务
+	AutoCompleteOnChildren bool `gorm:"default:false" json:"auto_complete_on_children"`
+	// RequiresReview 负责人提交完成后进入in_review，由审核人确认后才算完成
+	RequiresReview bool `gorm:"default:false" json:"requires_review"`
+	// ReviewerID 审核人用户ID，为空时由任务创建者审核
+	ReviewerID *uint `gorm:"index" json:"reviewer_id"`
 
 	// 外键
 	CreatorID  uint  `gorm:"not null" json:"creator_id"`
@@ -201,11 +252,70 @@ type Task struct {
 	SubTasks    []Task           `gorm:"foreignKey:ParentID" json:"sub_tasks,omitempty"`
 	Project     *Project         `gorm:"foreignKey:ProjectID" json:"project,omitempty"`
 	Skills      []Skill          `gorm:"many2many:task_skills;" json:"skills,omitempty"`
+	Labels      []TaskLabel      `gorm:"many2many:task_labels;joinReferences:LabelID" json:"labels,omitempty"`
 	Assignments []Assignment     `gorm:"foreignKey:TaskID" json:"assignments,omitempty"`
 	Comments    []TaskComment    `gorm:"foreignKey:TaskID" json:"comments,omitempty"`
 	Attachments []TaskAttachment `gorm:"foreignKey:TaskID" json:"attachments,omitempty"`
 }
 
+// TaskTemplate 任务模板表，以树形存储：根节点代表整个模板，子节点按SortOrder排列，实例化时生成对应的子任务
+type TaskTemplate struct {
+	BaseModel
+	RootID         *uint   `gorm:"index" json:"root_id"` // 所属模板根节点，根节点为空
+	ParentID       *uint   `gorm:"index" json:"parent_id"`
+	SortOrder      int     `gorm:"default:0" json:"sort_order"`
+	Title          string  `gorm:"size:200;not null" json:"title"`
+	Description    string  `gorm:"type:text" json:"description"`
+	Priority       string  `gorm:"size:20;default:medium" json:"priority"`
+	EstimatedHours float64 `gorm:"default:0" json:"estimated_hours"`
+	CreatorID      uint    `gorm:"not null" json:"creator_id"`
+
+	// 关联关系
+	Skills []TaskTemplateSkill `gorm:"foreignKey:TemplateID" json:"skills,omitempty"`
+}
+
+// TaskTemplateSkill 任务模板节点的技能要求，实例化时复制到task_skills
+type TaskTemplateSkill struct {
+	TemplateID uint `gorm:"primaryKey"`
+	SkillID    uint `gorm:"primaryKey"`
+	Level      int  `gorm:"default:1"` // 所需技能等级
+
+	Skill Skill `gorm:"foreignKey:SkillID" json:"skill,omitempty"`
+}
+
+// TimeEntry 任务工时记录表
+type TimeEntry struct {
+	BaseModel
+	TaskID          uint      `gorm:"not null;index" json:"task_id"`
+	UserID          uint      `gorm:"not null;index:idx_time_entries_user_started" json:"user_id"`
+	StartedAt       time.Time `gorm:"not null;index:idx_time_entries_user_started" json:"started_at"`
+	DurationMinutes int       `gorm:"not null" json:"duration_minutes"`
+	Note            string    `gorm:"size:500" json:"note"`
+
+	// 关联关系
+	Task Task `gorm:"foreignKey:TaskID" json:"task,omitempty"`
+	User User `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+// EmployeeCapacity 员工容量日历表，在[StartDate, EndDate]日期范围内按容量系数折算员工的最大任务数
+// 同一员工的日期范围不能重叠；系数为0表示请假，期间由定时任务将员工状态置为on_leave
+type EmployeeCapacity struct {
+	BaseModel
+	EmployeeID     uint      `gorm:"not null;index:idx_employee_capacities_range" json:"employee_id"`
+	StartDate      time.Time `gorm:"type:date;not null;index:idx_employee_capacities_range" json:"start_date"`
+	EndDate        time.Time `gorm:"type:date;not null" json:"end_date"`
+	CapacityFactor float64   `gorm:"not null" json:"capacity_factor"` // 0-1
+	Reason         string    `gorm:"size:255" json:"reason"`
+	CreatedBy      uint      `gorm:"not null" json:"created_by"`
+	LeaveApplied   bool      `gorm:"default:false" json:"leave_applied"` // 请假已生效，员工状态已置为on_leave
+	PreviousStatus string    `gorm:"size:20" json:"-"`                   // 请假生效前的员工状态，请假结束后恢复
+}
+
+// IsLeave 是否为请假条目
+func (c *EmployeeCapacity) IsLeave() bool {
+	return c.CapacityFactor == 0
+}
+
 // Assignment 任务分配表
 type Assignment struct {
 	BaseModel
@@ -219,6 +329,7 @@ type Assignment struct {
 	ApproverID         *uint      `json:"approver_id"`
 	Reason             string     `gorm:"size:255" json:"reason"`
 	WorkflowInstanceID *string    `gorm:"size:100" json:"workflow_instance_id"` // 关联的工作流实例ID
+	Version            uint       `gorm:"not null;default:1" json:"version"`    // 乐观锁版本号
 
 	// 关联关系
 	Task     Task  `gorm:"foreignKey:TaskID" json:"task,omitempty"`
@@ -234,6 +345,7 @@ type TaskComment struct {
 	UserID   uint   `gorm:"not null" json:"user_id"`
 	Content  string `gorm:"type:text;not null" json:"content"`
 	ParentID *uint  `json:"parent_id"`
+	System   bool   `gorm:"default:false" json:"system"` // 系统自动生成的评论，UserID为触发操作的用户
 
 	// 关联关系
 	Task    Task          `gorm:"foreignKey:TaskID" json:"task,omitempty"`
@@ -242,6 +354,71 @@ type TaskComment struct {
 	Replies []TaskComment `gorm:"foreignKey:ParentID" json:"replies,omitempty"`
 }
 
+// TaskWatcher 任务关注者表，创建者和当前负责人是隐式关注者，不保存记录
+type TaskWatcher struct {
+	TaskID    uint      `gorm:"primaryKey" json:"task_id"`
+	UserID    uint      `gorm:"primaryKey;index" json:"user_id"`
+	AddedBy   uint      `gorm:"not null" json:"added_by"` // 添加人用户ID，自行关注时与UserID相同
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TaskEscalationRule 任务优先级老化规则：指定优先级的待分配任务创建后超过阈值仍未分配时升级
+// 升级时按配置通知部门经理和创建者、提升优先级，并在任务上留下系统评论
+type TaskEscalationRule struct {
+	BaseModel
+	Name                    string `gorm:"size:100;not null" json:"name"`
+	Priority                string `gorm:"size:20;not null;index" json:"priority"`         // 匹配的任务优先级
+	ThresholdHours          int    `gorm:"not null" json:"threshold_hours"`                // 任务待分配超过该小时数后升级
+	RaisePriorityTo         string `gorm:"size:20" json:"raise_priority_to"`               // 升级后的优先级，为空表示不调整
+	NotifyDepartmentManager bool   `gorm:"default:false" json:"notify_department_manager"` // 通知创建者所在部门的经理
+	NotifyCreator           bool   `gorm:"default:false" json:"notify_creator"`
+	Enabled                 bool   `gorm:"default:false" json:"enabled"`
+	CreatedBy               uint   `gorm:"not null" json:"created_by"` // 系统评论以该用户的名义记录
+}
+
+// TaskEscalation 规则对任务的升级记录，同一规则对同一任务只升级一次
+type TaskEscalation struct {
+	RuleID       uint      `gorm:"primaryKey" json:"rule_id"`
+	TaskID       uint      `gorm:"primaryKey;index" json:"task_id"`
+	FromPriority string    `gorm:"size:20" json:"from_priority"`
+	ToPriority   string    `gorm:"size:20" json:"to_priority"`
+	EscalatedAt  time.Time `gorm:"not null" json:"escalated_at"`
+}
+
+// TaskDueReminder 任务截止提醒记录，同一任务的每个提前量只提醒一次，LeadHours为0表示逾期提醒
+type TaskDueReminder struct {
+	TaskID     uint      `gorm:"primaryKey" json:"task_id"`
+	LeadHours  int       `gorm:"primaryKey;autoIncrement:false" json:"lead_hours"`
+	RemindedAt time.Time `gorm:"not null" json:"reminded_at"`
+}
+
+// Holiday 节假日，启用工作日历后审批时限、流程超时、试用期和截止提醒的计算跳过这些日期
+// 同一日期只能登记一次，删除时直接移除记录以便重新登记
+type Holiday struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	Date      time.Time `gorm:"type:date;not null;uniqueIndex" json:"date"`
+	Name      string    `gorm:"size:100;not null" json:"name"`
+	CreatedBy uint      `gorm:"not null" json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TaskLabel 任务标签表，用于轻量分类（如tech-debt、Q3-okr），与技能要求无关
+// 任务与标签通过task_labels关联，标签物理删除以释放名称
+type TaskLabel struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	Name      string    `gorm:"uniqueIndex;size:50;not null" json:"name"`
+	Color     string    `gorm:"size:7" json:"color"` // #RRGGBB
+	CreatorID uint      `gorm:"not null" json:"creator_id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName 指定表名，task_labels为任务与标签的关联表
+func (TaskLabel) TableName() string {
+	return "labels"
+}
+
 // TaskAttachment 任务附件表
 type TaskAttachment struct {
 	BaseModel
@@ -270,6 +447,7 @@ type TaskNotification struct {
 	Status      string     `gorm:"type:varchar(20);default:'unread'" json:"status"`
 	ActionType  *string    `gorm:"type:varchar(50)" json:"action_type,omitempty"`
 	ActionData  *string    `gorm:"type:json" json:"action_data,omitempty"`
+	DedupeKey   *string    `gorm:"type:varchar(150);index" json:"-"` // 去重键，同一接收人相同去重键的通知只创建一次
 	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
 	ReadAt      *time.Time `json:"read_at,omitempty"`
 	ActionAt    *time.Time `json:"action_at,omitempty"`
@@ -311,6 +489,82 @@ type Notification struct {
 	User User `gorm:"foreignKey:UserID" json:"user,omitempty"`
 }
 
+// NotificationPreference 用户通知偏好，未设置的类别和渠道使用配置中的默认值
+type NotificationPreference struct {
+	BaseModel
+	UserID   uint   `gorm:"not null;uniqueIndex:idx_notification_preference" json:"user_id"`
+	Category string `gorm:"size:50;not null;uniqueIndex:idx_notification_preference" json:"category"` // task_assigned, approval_requested等
+	Channel  string `gorm:"size:20;not null;uniqueIndex:idx_notification_preference" json:"channel"`  // in_app, email, webhook
+	Enabled  bool   `gorm:"not null" json:"enabled"`
+}
+
+// EmailOutbox 待发送邮件，由后台发送器按next_retry_at投递，失败时指数退避重试
+type EmailOutbox struct {
+	BaseModel
+	UserID      uint       `gorm:"index" json:"user_id"`
+	To          string     `gorm:"size:100;not null" json:"to"`
+	Category    string     `gorm:"size:50" json:"category"`
+	Subject     string     `gorm:"size:255;not null" json:"subject"`
+	TextBody    string     `gorm:"type:text" json:"-"`
+	HTMLBody    string     `gorm:"type:text" json:"-"`
+	Status      string     `gorm:"size:20;not null;default:pending;index:idx_email_outbox_due" json:"status"` // pending, sent, failed
+	Attempts    int        `gorm:"not null;default:0" json:"attempts"`
+	NextRetryAt *time.Time `gorm:"index:idx_email_outbox_due" json:"next_retry_at,omitempty"`
+	LastError   string     `gorm:"type:text" json:"last_error,omitempty"`
+	SentAt      *time.Time `json:"sent_at,omitempty"`
+}
+
+// WebhookEndpoint 外部系统的Webhook订阅端点，Events为空时订阅全部事件
+type WebhookEndpoint struct {
+	BaseModel
+	Name      string `gorm:"size:100;not null" json:"name"`
+	URL       string `gorm:"size:500;not null" json:"url"`
+	Secret    string `gorm:"size:100;not null" json:"-"` // HMAC-SHA256签名密钥
+	Enabled   bool   `gorm:"not null" json:"enabled"`
+	Events    string `gorm:"type:json" json:"-"` // 订阅的事件类型，JSON数组
+	CreatedBy uint   `json:"created_by"`
+}
+
+// WebhookDelivery Webhook投递记录，作为持久化发件箱由后台投递器按next_retry_at发送，失败时指数退避重试
+type WebhookDelivery struct {
+	BaseModel
+	EndpointID     uint       `gorm:"not null;index" json:"endpoint_id"`
+	EventID        string     `gorm:"size:36;not null;index" json:"event_id"`
+	EventType      string     `gorm:"size:100;not null" json:"event_type"`
+	Payload        string     `gorm:"type:json" json:"payload"`
+	Status         string     `gorm:"size:20;not null;default:pending;index:idx_webhook_delivery_due" json:"status"` // pending, delivered, failed
+	Attempts       int        `gorm:"not null;default:0" json:"attempts"`
+	NextRetryAt    *time.Time `gorm:"index:idx_webhook_delivery_due" json:"next_retry_at,omitempty"`
+	LastStatusCode int        `json:"last_status_code,omitempty"`
+	LastError      string     `gorm:"type:text" json:"last_error,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+
+	// 关联关系
+	AttemptLogs []WebhookDeliveryAttempt `gorm:"foreignKey:DeliveryID" json:"attempt_logs,omitempty"`
+}
+
+// WebhookDeliveryAttempt 单次Webhook发送尝试及端点的响应状态码，用于排查投递问题
+type WebhookDeliveryAttempt struct {
+	BaseModel
+	DeliveryID  uint      `gorm:"not null;index" json:"delivery_id"`
+	EndpointID  uint      `gorm:"not null;index" json:"endpoint_id"`
+	Attempt     int       `gorm:"not null" json:"attempt"`
+	StatusCode  int       `json:"status_code,omitempty"` // 未收到响应时为0
+	Error       string    `gorm:"type:text" json:"error,omitempty"`
+	DurationMs  int64     `json:"duration_ms"`
+	AttemptedAt time.Time `gorm:"not null" json:"attempted_at"`
+}
+
+// EmployeeNoCounter 员工编号计数器名称
+const EmployeeNoCounter = "employee_no"
+
+// Counter 命名计数器，分配序号时锁定计数器行，并发分配不会得到相同的值
+type Counter struct {
+	Name      string    `gorm:"primaryKey;size:50" json:"name"`
+	Value     int64     `gorm:"not null;default:0" json:"value"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
 // AuditLog 审计日志表
 type AuditLog struct {
 	BaseModel
@@ -358,13 +612,53 @@ type RolePermission struct {
 	CreatedAt    time.Time
 }
 
+// ProjectMember 项目成员关联表
+type ProjectMember struct {
+	ProjectID  uint  `gorm:"primaryKey"`
+	EmployeeID uint  `gorm:"primaryKey"`
+	AddedBy    *uint // 分配任务时越过成员校验自动加入项目的操作人用户ID，正常添加的成员为空
+	CreatedAt  time.Time
+}
+
 // EmployeeSkill 员工技能关联表
 type EmployeeSkill struct {
-	EmployeeID uint `gorm:"primaryKey"`
-	SkillID    uint `gorm:"primaryKey"`
-	Level      int  `gorm:"default:1"` // 技能等级 1-5
-	CreatedAt  time.Time
-	UpdatedAt  time.Time
+	EmployeeID       uint  `gorm:"primaryKey"`
+	SkillID          uint  `gorm:"primaryKey"`
+	Level            int   `gorm:"default:1"` // 技能等级 1-5
+	EndorsedBy       *uint // 认证人用户ID，为空表示员工自行申报
+	EndorsedAt       *time.Time
+	ExpiresAt        *time.Time `gorm:"index"` // 认证到期时间
+	ExpiryNotifiedAt *time.Time // 已发送到期提醒的时间，重新认证时清空
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
+// EmployeeSkillDetail 员工技能详情（技能信息及员工的技能等级，非数据表）
+type EmployeeSkillDetail struct {
+	SkillID     uint       `json:"skill_id"`
+	Name        string     `json:"name"`
+	Category    string     `json:"category"`
+	Description string     `json:"description"`
+	Level       int        `json:"level"`
+	EndorsedBy  *uint      `json:"endorsed_by"`
+	EndorsedAt  *time.Time `json:"endorsed_at"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// IsExpired 技能认证是否已在now之前到期，未认证的技能不会过期
+func (d *EmployeeSkillDetail) IsExpired(now time.Time) bool {
+	return d.ExpiresAt != nil && !now.Before(*d.ExpiresAt)
+}
+
+// ExpiringSkill 即将到期的员工技能认证（非数据表）
+type ExpiringSkill struct {
+	EmployeeID uint      `json:"employee_id"`
+	SkillID    uint      `json:"skill_id"`
+	SkillName  string    `json:"skill_name"`
+	Level      int       `json:"level"`
+	ExpiresAt  time.Time `json:"expires_at"`
 }
 
 // TaskSkill 任务技能关联表
@@ -376,6 +670,15 @@ type TaskSkill struct {
 	CreatedAt time.Time
 }
 
+// TaskSkillDetail 任务技能要求详情（技能信息及所需等级，非数据表）
+type TaskSkillDetail struct {
+	SkillID  uint   `json:"skill_id"`
+	Name     string `json:"name"`
+	Category string `json:"category"`
+	Required bool   `json:"required"`
+	Level    int    `json:"level"`
+}
+
 // GetAllModels 返回所有模型，用于数据库迁移
 func GetAllModels() []interface{} {
 	return []interface{}{
@@ -389,6 +692,9 @@ func GetAllModels() []interface{} {
 		&Project{},
 		&Task{},
 		&Employee{},
+		&ProjectMember{},
+		&Milestone{},
+		&SkillCategory{},
 		&Skill{},
 		&EmployeeSkill{},
 		&Assignment{},
@@ -401,13 +707,38 @@ func GetAllModels() []interface{} {
 		&AuditLog{},
 		&SystemConfig{},
 		&OnboardingHistory{},
+		&EmployeeChangeHistory{},
+		&Resignation{},
+		&TimeEntry{},
+		&EmployeeCapacity{},
+		&TaskWatcher{},
+		&TaskEscalationRule{},
+		&TaskEscalation{},
+		&TaskDueReminder{},
+		&TaskComment{},
+		&TaskLabel{},
+		&TaskTemplate{},
+		&TaskTemplateSkill{},
+		&AccountActivationToken{},
+		&DepartmentApprovalChain{},
+		&Holiday{},
 		// 权限分配相关模型
 		&PermissionTemplate{},
 		&PermissionRule{},
 		&PermissionAssignment{},
 		&PermissionAssignmentHistory{},
+		&PermissionRuleExecution{},
 		&OnboardingPermissionConfig{},
 		&Notification{},
+		&NotificationPreference{},
+		&EmailOutbox{},
+		&WebhookEndpoint{},
+		&WebhookDelivery{},
+		&WebhookDeliveryAttempt{},
+		&Counter{},
+		// 归档表
+		&TaskArchive{},
+		&WorkflowInstanceArchive{},
 	}
 }
 
@@ -426,3 +757,59 @@ type OnboardingHistory struct {
 	Employee Employee `gorm:"foreignKey:EmployeeID" json:"employee,omitempty"`
 	Operator User     `gorm:"foreignKey:OperatorID" json:"operator,omitempty"`
 }
+
+// EmployeeChangeHistory 员工职位变更历史，记录变更前后的职位、职级和触发的权限调整
+type EmployeeChangeHistory struct {
+	BaseModel
+	EmployeeID    uint   `gorm:"not null;index" json:"employee_id"`
+	ChangeType    string `gorm:"size:30;not null" json:"change_type"` // promotion, demotion, transfer（职级不变）
+	OldPositionID *uint  `json:"old_position_id"`
+	NewPositionID *uint  `json:"new_position_id"`
+	OldLevel      int    `json:"old_level"`
+	NewLevel      int    `json:"new_level"`
+	OperatorID    uint   `gorm:"not null;index" json:"operator_id"`
+	Notes         string `gorm:"size:500" json:"notes,omitempty"`
+}
+
+// Resignation 离职申请表
+type Resignation struct {
+	BaseModel
+	EmployeeID         uint       `gorm:"not null;index" json:"employee_id"`
+	LastWorkingDay     time.Time  `gorm:"not null" json:"last_working_day"`
+	Reason             string     `gorm:"size:500" json:"reason"`
+	Status             string     `gorm:"size:30;not null;index" json:"status"` // pending_approval, handover, completed, rejected
+	PreviousStatus     string     `gorm:"size:30" json:"previous_status"`       // 发起离职前的员工状态，审批拒绝时恢复
+	WorkflowInstanceID string     `gorm:"size:100;index" json:"workflow_instance_id"`
+	RequesterID        uint       `gorm:"not null;index" json:"requester_id"`
+	CompletedBy        *uint      `json:"completed_by,omitempty"`
+	CompletedAt        *time.Time `json:"completed_at,omitempty"`
+
+	// 关联关系
+	Employee  Employee `gorm:"foreignKey:EmployeeID" json:"employee,omitempty"`
+	Requester User     `gorm:"foreignKey:RequesterID" json:"requester,omitempty"`
+}
+
+// AccountActivationToken 账号激活令牌表，只保存令牌的SHA-256摘要
+type AccountActivationToken struct {
+	BaseModel
+	UserID    uint       `gorm:"not null;index" json:"user_id"`
+	TokenHash string     `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"` // 激活成功后写入，令牌随即失效
+
+	// 关联关系
+	User User `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+// DepartmentApprovalChain 部门审批链，按部门和业务类型配置有序的审批人
+type DepartmentApprovalChain struct {
+	BaseModel
+	DepartmentID    uint      `gorm:"not null;uniqueIndex:idx_department_business_type" json:"department_id"`
+	BusinessType    string    `gorm:"size:50;not null;uniqueIndex:idx_department_business_type" json:"business_type"`
+	Assignees       JSONField `json:"assignees"`                             // 审批人配置列表，格式同审批节点的assignees
+	CrossDepartment bool      `gorm:"default:false" json:"cross_department"` // 是否允许指定其它部门的用户
+	Description     string    `gorm:"size:255" json:"description"`
+
+	// 关联关系
+	Department Department `gorm:"foreignKey:DepartmentID" json:"department,omitempty"`
+}
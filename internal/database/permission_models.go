@@ -99,6 +99,15 @@ type PermissionAssignmentHistory struct {
 	Operator   User                 `gorm:"foreignKey:OperatorID" json:"operator,omitempty"`
 }
 
+// PermissionRuleExecution 权限规则执行记录，同一规则对同一用户只执行一次
+type PermissionRuleExecution struct {
+	RuleID       uint      `gorm:"primaryKey;autoIncrement:false" json:"rule_id"`
+	UserID       uint      `gorm:"primaryKey;autoIncrement:false;index" json:"user_id"`
+	AssignmentID *uint     `json:"assignment_id,omitempty"` // 规则新建或撤销的权限分配，用户已持有模板时为空
+	TriggerValue string    `gorm:"size:100" json:"trigger_value"` // 执行时的条件值，如入职天数
+	ExecutedAt   time.Time `gorm:"not null" json:"executed_at"`
+}
+
 // OnboardingPermissionConfig 入职权限配置
 type OnboardingPermissionConfig struct {
 	BaseModel
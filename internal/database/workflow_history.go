@@ -0,0 +1,126 @@
+package database
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrExecutionHistoryImmutable 流程执行历史只允许追加，不允许修改或删除
+var ErrExecutionHistoryImmutable = errors.New("流程执行历史不可修改")
+
+// historyTimePrecision 参与哈希的执行时间精度，与数据库datetime(3)的存储精度一致
+const historyTimePrecision = time.Millisecond
+
+// BeforeUpdate 拒绝修改已写入的执行历史
+func (h *WorkflowExecutionHistory) BeforeUpdate(tx *gorm.DB) error {
+	return ErrExecutionHistoryImmutable
+}
+
+// BeforeDelete 拒绝删除执行历史
+func (h *WorkflowExecutionHistory) BeforeDelete(tx *gorm.DB) error {
+	return ErrExecutionHistoryImmutable
+}
+
+// historyHashFields 参与哈希计算的业务字段，字段顺序即规范JSON的字段顺序
+type historyHashFields struct {
+	HistoryID   string          `json:"history_id"`
+	InstanceID  string          `json:"instance_id"`
+	NodeID      string          `json:"node_id"`
+	NodeName    string          `json:"node_name"`
+	Action      string          `json:"action"`
+	Result      string          `json:"result"`
+	Comment     string          `json:"comment"`
+	Attachments json.RawMessage `json:"attachments,omitempty"` // 为空时不参与哈希，与新增该字段前写入的记录保持一致
+	Mentions    json.RawMessage `json:"mentions,omitempty"`
+	Variables   json.RawMessage `json:"variables"`
+	ExecutedBy  uint            `json:"executed_by"`
+	ExecutedAt  string          `json:"executed_at"`
+	Duration    int64           `json:"duration"`
+}
+
+// Seal 将执行时间截断到存储精度并写入哈希链字段，需在写入前调用
+func (h *WorkflowExecutionHistory) Seal(prevHash string) error {
+	h.ExecutedAt = h.ExecutedAt.Truncate(historyTimePrecision)
+	hash, err := h.ComputeHash(prevHash)
+	if err != nil {
+		return err
+	}
+	h.PrevHash = prevHash
+	h.Hash = hash
+	return nil
+}
+
+// ComputeHash 计算 sha256(prevHash || 业务字段的规范JSON)，返回十六进制字符串
+func (h *WorkflowExecutionHistory) ComputeHash(prevHash string) (string, error) {
+	variables, err := canonicalJSON(h.Variables.Data)
+	if err != nil {
+		return "", fmt.Errorf("序列化执行历史变量失败: %w", err)
+	}
+	attachments, err := optionalCanonicalJSON(h.Attachments.Data)
+	if err != nil {
+		return "", fmt.Errorf("序列化执行历史附件失败: %w", err)
+	}
+	mentions, err := optionalCanonicalJSON(h.Mentions.Data)
+	if err != nil {
+		return "", fmt.Errorf("序列化执行历史提及用户失败: %w", err)
+	}
+	payload, err := json.Marshal(historyHashFields{
+		HistoryID:   h.HistoryID,
+		InstanceID:  h.InstanceID,
+		NodeID:      h.NodeID,
+		NodeName:    h.NodeName,
+		Action:      h.Action,
+		Result:      h.Result,
+		Comment:     h.Comment,
+		Attachments: attachments,
+		Mentions:    mentions,
+		Variables:   variables,
+		ExecutedBy:  h.ExecutedBy,
+		ExecutedAt:  h.ExecutedAt.UTC().Truncate(historyTimePrecision).Format(time.RFC3339Nano),
+		Duration:    h.Duration,
+	})
+	if err != nil {
+		return "", fmt.Errorf("序列化执行历史失败: %w", err)
+	}
+
+	sum := sha256.New()
+	sum.Write([]byte(prevHash))
+	sum.Write(payload)
+	return hex.EncodeToString(sum.Sum(nil)), nil
+}
+
+// canonicalJSON 将任意值序列化为键有序的JSON
+// 先序列化再解析为通用结构，使写入前的结构体和从数据库读回的map得到相同结果
+func canonicalJSON(value interface{}) (json.RawMessage, error) {
+	if value == nil {
+		return json.RawMessage("null"), nil
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var generic interface{}
+	if err := json.Unmarshal(raw, &generic); err != nil {
+		return nil, err
+	}
+	return json.Marshal(generic)
+}
+
+// optionalCanonicalJSON 同canonicalJSON，值为空时返回nil
+func optionalCanonicalJSON(value interface{}) (json.RawMessage, error) {
+	raw, err := canonicalJSON(value)
+	if err != nil {
+		return nil, err
+	}
+	switch string(raw) {
+	case "null", "[]":
+		return nil, nil
+	}
+	return raw, nil
+}
@@ -5,18 +5,24 @@ import (
 	"encoding/json"
 	"errors"
 	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
 )
 
 // WorkflowDefinition 工作流定义数据库模型
 type WorkflowDefinition struct {
 	BaseModel
-	WorkflowID  string    `gorm:"column:workflow_id;uniqueIndex;size:100;not null" json:"workflow_id"`
+	WorkflowID    string    `gorm:"column:workflow_id;uniqueIndex:idx_workflow_version;size:100;not null" json:"workflow_id"`
+	VersionNumber int       `gorm:"column:version_number;uniqueIndex:idx_workflow_version;not null;default:1" json:"version_number"` // 版本号，每次更新递增
 	Name        string    `gorm:"column:name;size:200;not null" json:"name"`
 	Description string    `gorm:"column:description;type:text" json:"description"`
 	Version     string    `gorm:"column:version;size:50;not null" json:"version"`
-	Nodes       JSONField `gorm:"column:nodes;type:json" json:"nodes"`
-	Edges       JSONField `gorm:"column:edges;type:json" json:"edges"`
-	Variables   JSONField `gorm:"column:variables;type:json" json:"variables"`
+	Nodes       JSONField `gorm:"column:nodes" json:"nodes"`
+	Edges       JSONField `gorm:"column:edges" json:"edges"`
+	Variables   JSONField `gorm:"column:variables" json:"variables"`
+	VariableSchema JSONField `gorm:"column:variable_schema" json:"variable_schema"` // 声明的流程变量，为空表示不校验
+	MaxDuration int64     `gorm:"column:max_duration;not null;default:0" json:"max_duration"` // 流程SLA（秒），0表示不限制
 	IsActive    bool      `gorm:"column:is_active;default:true" json:"is_active"`
 }
 
@@ -30,14 +36,18 @@ type WorkflowInstance struct {
 	BaseModel
 	InstanceID   string    `gorm:"column:instance_id;uniqueIndex;size:100;not null" json:"instance_id"`
 	WorkflowID   string    `gorm:"column:workflow_id;size:100;not null;index" json:"workflow_id"`
+	DefinitionVersionID uint `gorm:"column:definition_version_id;index" json:"definition_version_id"` // 启动时绑定的流程定义版本
 	BusinessID   string    `gorm:"column:business_id;size:100;not null;index" json:"business_id"`
 	BusinessType string    `gorm:"column:business_type;size:50;not null;index" json:"business_type"`
 	Status       string    `gorm:"column:status;size:20;not null;index" json:"status"`
-	CurrentNodes JSONField `gorm:"column:current_nodes;type:json" json:"current_nodes"`
-	Variables    JSONField `gorm:"column:variables;type:json" json:"variables"`
+	CurrentNodes JSONField `gorm:"column:current_nodes" json:"current_nodes"`
+	Variables    JSONField `gorm:"column:variables" json:"variables"`
+	ApprovalStates JSONField `gorm:"column:approval_states" json:"approval_states"` // 审批节点逐人决策，按节点ID索引
+	Completion   JSONField `gorm:"column:completion" json:"completion"`   // 流程结束后业务回调的执行记录
 	StartedBy    uint      `gorm:"column:started_by;not null;index" json:"started_by"`
 	StartedAt    time.Time `gorm:"column:started_at;not null" json:"started_at"`
 	CompletedAt  *time.Time `gorm:"column:completed_at" json:"completed_at"`
+	Deadline     *time.Time `gorm:"column:deadline;index" json:"deadline"` // 启动时按流程SLA计算的截止时间，为空表示不限制
 }
 
 // TableName 指定表名
@@ -55,10 +65,14 @@ type WorkflowExecutionHistory struct {
 	Action     string    `gorm:"column:action;size:50;not null" json:"action"`
 	Result     string    `gorm:"column:result;size:50;not null" json:"result"`
 	Comment    string    `gorm:"column:comment;type:text" json:"comment"`
-	Variables  JSONField `gorm:"column:variables;type:json" json:"variables"`
+	Attachments JSONField `gorm:"column:attachments" json:"attachments"` // 审批意见附带的附件
+	Mentions   JSONField `gorm:"column:mentions" json:"mentions"`       // 审批意见提及的用户ID
+	Variables  JSONField `gorm:"column:variables" json:"variables"`
 	ExecutedBy uint      `gorm:"column:executed_by;not null;index" json:"executed_by"`
 	ExecutedAt time.Time `gorm:"column:executed_at;not null" json:"executed_at"`
 	Duration   int64     `gorm:"column:duration;not null" json:"duration"` // 毫秒
+	PrevHash   string    `gorm:"column:prev_hash;size:64" json:"prev_hash"`  // 同一实例上一条历史的哈希，首条为空
+	Hash       string    `gorm:"column:hash;size:64" json:"hash"`            // sha256(prev_hash || 业务字段的规范JSON)
 }
 
 // TableName 指定表名
@@ -75,12 +89,12 @@ type WorkflowPendingApproval struct {
 	NodeName       string    `gorm:"column:node_name;size:200;not null" json:"node_name"`
 	BusinessID     string    `gorm:"column:business_id;size:100;not null;index" json:"business_id"`
 	BusinessType   string    `gorm:"column:business_type;size:50;not null;index" json:"business_type"`
-	BusinessData   JSONField `gorm:"column:business_data;type:json" json:"business_data"`
+	BusinessData   JSONField `gorm:"column:business_data" json:"business_data"`
 	Priority       int       `gorm:"column:priority;not null;default:1" json:"priority"`
 	AssignedTo     uint      `gorm:"column:assigned_to;not null;index" json:"assigned_to"`
 	Deadline       *time.Time `gorm:"column:deadline" json:"deadline"`
 	CanDelegate    bool      `gorm:"column:can_delegate;default:false" json:"can_delegate"`
-	RequiredActions JSONField `gorm:"column:required_actions;type:json" json:"required_actions"`
+	RequiredActions JSONField `gorm:"column:required_actions" json:"required_actions"`
 	IsCompleted    bool      `gorm:"column:is_completed;default:false;index" json:"is_completed"`
 }
 
@@ -94,6 +108,14 @@ type JSONField struct {
 	Data interface{}
 }
 
+// GormDBDataType 按数据库方言确定列类型，PostgreSQL使用JSONB
+func (JSONField) GormDBDataType(db *gorm.DB, field *schema.Field) string {
+	if db.Dialector.Name() == DriverPostgres {
+		return "JSONB"
+	}
+	return "JSON"
+}
+
 // Value 实现driver.Valuer接口
 func (j JSONField) Value() (driver.Value, error) {
 	if j.Data == nil {
@@ -0,0 +1,213 @@
+package jobs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule 定时任务的执行计划
+type Schedule interface {
+	// Next 返回晚于t的下一次执行时间，返回零值表示不再执行
+	Next(t time.Time) time.Time
+	// String 执行计划的描述，用于状态展示
+	String() string
+}
+
+// intervalSchedule 固定间隔执行
+type intervalSchedule struct {
+	interval time.Duration
+}
+
+// Every 创建固定间隔的执行计划，interval必须大于0
+func Every(interval time.Duration) Schedule {
+	if interval <= 0 {
+		panic(fmt.Sprintf("jobs: 执行间隔必须大于0: %s", interval))
+	}
+	return intervalSchedule{interval: interval}
+}
+
+// Next 返回t之后一个间隔的时间
+func (s intervalSchedule) Next(t time.Time) time.Time {
+	return t.Add(s.interval)
+}
+
+// String 执行计划描述
+func (s intervalSchedule) String() string {
+	return "@every " + s.interval.String()
+}
+
+// cronField cron表达式单个字段允许的取值
+type cronField uint64
+
+func (f cronField) has(v int) bool {
+	return f&(1<<uint(v)) != 0
+}
+
+// cronSchedule 标准5段cron表达式：分 时 日 月 周，按本地时区计算
+type cronSchedule struct {
+	spec     string
+	minute   cronField
+	hour     cronField
+	dom      cronField
+	month    cronField
+	dow      cronField
+	domStar  bool
+	dowStar  bool
+	location *time.Location
+}
+
+// cronBounds 各字段的取值范围
+var cronBounds = [5]struct {
+	name     string
+	min, max int
+}{
+	{"分钟", 0, 59},
+	{"小时", 0, 23},
+	{"日期", 1, 31},
+	{"月份", 1, 12},
+	{"星期", 0, 7},
+}
+
+// Cron 解析5段cron表达式（分 时 日 月 周），支持 *、列表、范围和步长，星期的0和7都表示周日。
+// 日期和星期都有限制时满足其一即执行，与标准cron一致
+func Cron(spec string) (Schedule, error) {
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron表达式需要5段，实际%d段: %q", len(fields), spec)
+	}
+
+	var parsed [5]cronField
+	for i, field := range fields {
+		value, err := parseCronField(field, cronBounds[i].min, cronBounds[i].max)
+		if err != nil {
+			return nil, fmt.Errorf("cron表达式%s字段无效: %w", cronBounds[i].name, err)
+		}
+		parsed[i] = value
+	}
+
+	// 星期7与0同为周日
+	dow := parsed[4]
+	if dow.has(7) {
+		dow |= 1
+	}
+
+	return &cronSchedule{
+		spec:     spec,
+		minute:   parsed[0],
+		hour:     parsed[1],
+		dom:      parsed[2],
+		month:    parsed[3],
+		dow:      dow,
+		domStar:  fields[2] == "*",
+		dowStar:  fields[4] == "*",
+		location: time.Local,
+	}, nil
+}
+
+// MustCron 解析cron表达式，失败时panic，用于注册固定的表达式
+func MustCron(spec string) Schedule {
+	schedule, err := Cron(spec)
+	if err != nil {
+		panic(err)
+	}
+	return schedule
+}
+
+// parseCronField 解析单个字段，字段由逗号分隔的 *、n、a-b 组成，每项可带 /step
+func parseCronField(field string, min, max int) (cronField, error) {
+	var result cronField
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if idx := strings.Index(part, "/"); idx >= 0 {
+			rangePart = part[:idx]
+			s, err := strconv.Atoi(part[idx+1:])
+			if err != nil || s <= 0 {
+				return 0, fmt.Errorf("步长无效: %q", part)
+			}
+			step = s
+		}
+
+		var start, end int
+		switch {
+		case rangePart == "*":
+			start, end = min, max
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			a, errA := strconv.Atoi(bounds[0])
+			b, errB := strconv.Atoi(bounds[1])
+			if errA != nil || errB != nil {
+				return 0, fmt.Errorf("范围无效: %q", part)
+			}
+			start, end = a, b
+		default:
+			v, err := strconv.Atoi(rangePart)
+			if err != nil {
+				return 0, fmt.Errorf("取值无效: %q", part)
+			}
+			start, end = v, v
+			// n/step 表示从n开始到最大值
+			if step > 1 {
+				end = max
+			}
+		}
+
+		if start < min || end > max || start > end {
+			return 0, fmt.Errorf("取值超出范围%d-%d: %q", min, max, part)
+		}
+		for v := start; v <= end; v += step {
+			result |= 1 << uint(v)
+		}
+	}
+	return result, nil
+}
+
+// Next 返回t之后第一个满足表达式的整分钟，5年内没有匹配时返回零值
+func (s *cronSchedule) Next(t time.Time) time.Time {
+	t = t.In(s.location)
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, s.location).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if !s.month.has(int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, s.location)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, s.location)
+			continue
+		}
+		if !s.hour.has(t.Hour()) {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, s.location)
+			continue
+		}
+		if !s.minute.has(t.Minute()) {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// dayMatches 日期和星期的匹配规则：任一为*时取另一个，都有限制时满足其一即可
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom.has(t.Day())
+	dowMatch := s.dow.has(int(t.Weekday()))
+	switch {
+	case s.domStar && s.dowStar:
+		return true
+	case s.domStar:
+		return dowMatch
+	case s.dowStar:
+		return domMatch
+	default:
+		return domMatch || dowMatch
+	}
+}
+
+// String 执行计划描述
+func (s *cronSchedule) String() string {
+	return s.spec
+}
@@ -0,0 +1,48 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCronNext(t *testing.T) {
+	// 2024-06-03 是周一
+	from := time.Date(2024, 6, 3, 8, 30, 15, 0, time.Local)
+
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2024, 6, 3, 8, 31, 0, 0, time.Local)},
+		{"*/15 * * * *", time.Date(2024, 6, 3, 8, 45, 0, 0, time.Local)},
+		{"0 9 * * *", time.Date(2024, 6, 3, 9, 0, 0, 0, time.Local)},
+		{"0 2 * * *", time.Date(2024, 6, 4, 2, 0, 0, 0, time.Local)},
+		{"30 8 * * 1-5", time.Date(2024, 6, 4, 8, 30, 0, 0, time.Local)},
+		{"0 0 * * 0", time.Date(2024, 6, 9, 0, 0, 0, 0, time.Local)},
+		{"0 0 * * 7", time.Date(2024, 6, 9, 0, 0, 0, 0, time.Local)},
+		{"0 0 1 * *", time.Date(2024, 7, 1, 0, 0, 0, 0, time.Local)},
+		{"0 0 1,15 1 *", time.Date(2025, 1, 1, 0, 0, 0, 0, time.Local)},
+		// 日期和星期都有限制时满足其一即可
+		{"0 0 15 * 3", time.Date(2024, 6, 5, 0, 0, 0, 0, time.Local)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.Local)},
+	}
+	for _, tt := range tests {
+		schedule, err := Cron(tt.spec)
+		require.NoError(t, err, tt.spec)
+		assert.Equal(t, tt.want, schedule.Next(from), tt.spec)
+	}
+
+	never, err := Cron("0 0 30 2 *")
+	require.NoError(t, err)
+	assert.True(t, never.Next(from).IsZero())
+}
+
+func TestCronInvalid(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		_, err := Cron(spec)
+		assert.Error(t, err, spec)
+	}
+}
@@ -0,0 +1,270 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"taskmanage/pkg/logger"
+)
+
+// DefaultTimeout 未设置超时的任务单次执行的最长时间
+const DefaultTimeout = 10 * time.Minute
+
+var (
+	// ErrJobExists 任务名称已注册
+	ErrJobExists = errors.New("定时任务已存在")
+	// ErrSchedulerStarted 调度器启动后不能再注册任务
+	ErrSchedulerStarted = errors.New("调度器已启动")
+)
+
+// Clock 调度器使用的时钟，测试中可替换为假时钟
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// realClock 系统时钟
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// Job 定时任务定义
+type Job struct {
+	Name     string
+	Schedule Schedule
+	// Timeout 单次执行的超时时间，0表示使用DefaultTimeout
+	Timeout time.Duration
+	// RunOnStart 调度器启动时立即执行一次
+	RunOnStart bool
+	// Run 执行任务，now为本次计划的触发时间；ctx在超时或调度器停止时取消
+	Run func(ctx context.Context, now time.Time) error
+}
+
+// JobStatus 定时任务的运行状态
+type JobStatus struct {
+	Name           string     `json:"name"`
+	Schedule       string     `json:"schedule"`
+	Running        bool       `json:"running"`
+	RunCount       int64      `json:"run_count"`
+	FailureCount   int64      `json:"failure_count"`
+	SkippedCount   int64      `json:"skipped_count"`
+	LastStartedAt  *time.Time `json:"last_started_at,omitempty"`
+	LastFinishedAt *time.Time `json:"last_finished_at,omitempty"`
+	LastDurationMs int64      `json:"last_duration_ms"`
+	LastError      string     `json:"last_error,omitempty"`
+	NextRunAt      *time.Time `json:"next_run_at,omitempty"`
+}
+
+// entry 已注册的任务及其状态
+type entry struct {
+	job    Job
+	mu     sync.Mutex
+	status JobStatus
+}
+
+// Scheduler 定时任务调度器。每个任务按各自的执行计划触发，
+// 上一次执行未结束时跳过本次触发，执行中的panic被恢复并记为失败
+type Scheduler struct {
+	clock Clock
+
+	mu      sync.Mutex
+	entries []*entry
+	started bool
+	cancel  context.CancelFunc
+
+	loops sync.WaitGroup
+	runs  sync.WaitGroup
+}
+
+// NewScheduler 创建调度器，clock为nil时使用系统时钟
+func NewScheduler(clock Clock) *Scheduler {
+	if clock == nil {
+		clock = realClock{}
+	}
+	return &Scheduler{clock: clock}
+}
+
+// Register 注册任务，需在Start之前调用
+func (s *Scheduler) Register(job Job) error {
+	if job.Name == "" || job.Schedule == nil || job.Run == nil {
+		return fmt.Errorf("定时任务缺少名称、执行计划或执行函数: %q", job.Name)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started {
+		return fmt.Errorf("%w: 不能注册%s", ErrSchedulerStarted, job.Name)
+	}
+	for _, e := range s.entries {
+		if e.job.Name == job.Name {
+			return fmt.Errorf("%w: %s", ErrJobExists, job.Name)
+		}
+	}
+
+	s.entries = append(s.entries, &entry{
+		job:    job,
+		status: JobStatus{Name: job.Name, Schedule: job.Schedule.String()},
+	})
+	return nil
+}
+
+// Start 启动全部任务的调度，重复调用无效
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started {
+		return
+	}
+	s.started = true
+
+	ctx, s.cancel = context.WithCancel(ctx)
+	for _, e := range s.entries {
+		s.loops.Add(1)
+		go s.loop(ctx, e)
+	}
+	logger.Infof("定时任务调度器已启动，任务数: %d", len(s.entries))
+}
+
+// Stop 停止调度并取消执行中任务的ctx，等待执行中的任务结束，ctx到期时不再等待并返回错误
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	cancel := s.cancel
+	s.mu.Unlock()
+	if cancel == nil {
+		return nil
+	}
+
+	cancel()
+	// 调度循环退出后不会再启动新的执行
+	s.loops.Wait()
+
+	done := make(chan struct{})
+	go func() {
+		s.runs.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		logger.Info("定时任务调度器已停止")
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("等待执行中的定时任务结束超时: %w", ctx.Err())
+	}
+}
+
+// Status 按注册顺序返回全部任务的运行状态
+func (s *Scheduler) Status() []JobStatus {
+	s.mu.Lock()
+	entries := append([]*entry(nil), s.entries...)
+	s.mu.Unlock()
+
+	statuses := make([]JobStatus, 0, len(entries))
+	for _, e := range entries {
+		e.mu.Lock()
+		statuses = append(statuses, e.status)
+		e.mu.Unlock()
+	}
+	return statuses
+}
+
+// loop 按执行计划等待并触发任务，直到ctx取消
+func (s *Scheduler) loop(ctx context.Context, e *entry) {
+	defer s.loops.Done()
+
+	if e.job.RunOnStart {
+		s.trigger(ctx, e, s.clock.Now())
+	}
+
+	for {
+		now := s.clock.Now()
+		next := e.job.Schedule.Next(now)
+		if next.IsZero() {
+			logger.Warnf("定时任务没有下一次执行时间，停止调度: %s", e.job.Name)
+			return
+		}
+
+		e.mu.Lock()
+		e.status.NextRunAt = &next
+		e.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.clock.After(next.Sub(now)):
+			s.trigger(ctx, e, next)
+		}
+	}
+}
+
+// trigger 上一次执行未结束时跳过，否则在新的goroutine中执行
+func (s *Scheduler) trigger(ctx context.Context, e *entry, now time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.status.Running {
+		e.status.SkippedCount++
+		logger.Warnf("定时任务上一次执行尚未结束，跳过本次执行: %s", e.job.Name)
+		return
+	}
+
+	startedAt := s.clock.Now()
+	e.status.Running = true
+	e.status.LastStartedAt = &startedAt
+
+	s.runs.Add(1)
+	go s.run(ctx, e, now, startedAt)
+}
+
+// run 带超时执行一次任务并记录结果
+func (s *Scheduler) run(ctx context.Context, e *entry, now, startedAt time.Time) {
+	defer s.runs.Done()
+
+	timeout := e.job.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	runCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	err := s.execute(runCtx, e, now)
+
+	finishedAt := s.clock.Now()
+	e.mu.Lock()
+	e.status.Running = false
+	e.status.RunCount++
+	e.status.LastFinishedAt = &finishedAt
+	e.status.LastDurationMs = finishedAt.Sub(startedAt).Milliseconds()
+	e.status.LastError = ""
+	if err != nil {
+		e.status.FailureCount++
+		e.status.LastError = err.Error()
+	}
+	e.mu.Unlock()
+
+	if err != nil {
+		logger.Errorf("定时任务执行失败: %s, %v", e.job.Name, err)
+	}
+}
+
+// execute 执行任务函数，panic按utils.Recovery的方式记录堆栈后转换为错误
+func (s *Scheduler) execute(ctx context.Context, e *entry, now time.Time) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic recovered: %v", r)
+			logger.WithFields(map[string]interface{}{
+				"job":         e.job.Name,
+				"panic_value": r,
+				"stack_trace": string(debug.Stack()),
+			}).Error("Panic Recovered")
+		}
+	}()
+	return e.job.Run(ctx, now)
+}
@@ -0,0 +1,220 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock 手动推进的时钟
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeWaiter
+}
+
+type fakeWaiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 6, 3, 8, 0, 0, 0, time.Local)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	c.waiters = append(c.waiters, fakeWaiter{at: c.now.Add(d), ch: ch})
+	return ch
+}
+
+// Advance 推进时间并唤醒到期的等待者
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	remaining := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.at.After(c.now) {
+			remaining = append(remaining, w)
+			continue
+		}
+		w.ch <- c.now
+	}
+	c.waiters = remaining
+}
+
+// waitForWaiters 等待调度循环进入等待状态
+func (c *fakeClock) waitForWaiters(t *testing.T, n int) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return len(c.waiters) >= n
+	}, time.Second, time.Millisecond)
+}
+
+func jobStatus(s *Scheduler, name string) JobStatus {
+	for _, status := range s.Status() {
+		if status.Name == name {
+			return status
+		}
+	}
+	return JobStatus{}
+}
+
+func TestScheduler_RunsOnIntervalAndRecordsStatus(t *testing.T) {
+	clock := newFakeClock()
+	s := NewScheduler(clock)
+
+	var runs atomic.Int32
+	var lastNow atomic.Value
+	require.NoError(t, s.Register(Job{
+		Name:       "sweep",
+		Schedule:   Every(time.Minute),
+		RunOnStart: true,
+		Run: func(ctx context.Context, now time.Time) error {
+			lastNow.Store(now)
+			if runs.Add(1) == 2 {
+				return errors.New("boom")
+			}
+			return nil
+		},
+	}))
+	assert.ErrorIs(t, s.Register(Job{Name: "sweep", Schedule: Every(time.Minute), Run: func(context.Context, time.Time) error { return nil }}), ErrJobExists)
+
+	s.Start(context.Background())
+	defer s.Stop(context.Background())
+
+	require.Eventually(t, func() bool { return jobStatus(s, "sweep").RunCount == 1 }, time.Second, time.Millisecond)
+	clock.waitForWaiters(t, 1)
+	clock.Advance(time.Minute)
+	require.Eventually(t, func() bool { return jobStatus(s, "sweep").RunCount == 2 }, time.Second, time.Millisecond)
+
+	status := jobStatus(s, "sweep")
+	assert.Equal(t, "@every 1m0s", status.Schedule)
+	assert.Equal(t, int64(1), status.FailureCount)
+	assert.Equal(t, "boom", status.LastError)
+	assert.Equal(t, clock.Now(), lastNow.Load())
+
+	// 下一次成功执行清除最近错误
+	clock.waitForWaiters(t, 1)
+	clock.Advance(time.Minute)
+	require.Eventually(t, func() bool { return jobStatus(s, "sweep").RunCount == 3 }, time.Second, time.Millisecond)
+	assert.Empty(t, jobStatus(s, "sweep").LastError)
+	assert.ErrorIs(t, s.Register(Job{Name: "late", Schedule: Every(time.Minute), Run: func(context.Context, time.Time) error { return nil }}), ErrSchedulerStarted)
+}
+
+func TestScheduler_SkipsOverlappingRuns(t *testing.T) {
+	clock := newFakeClock()
+	s := NewScheduler(clock)
+
+	release := make(chan struct{})
+	var runs atomic.Int32
+	require.NoError(t, s.Register(Job{
+		Name:     "slow",
+		Schedule: Every(time.Minute),
+		Run: func(ctx context.Context, now time.Time) error {
+			runs.Add(1)
+			<-release
+			return nil
+		},
+	}))
+	s.Start(context.Background())
+	defer s.Stop(context.Background())
+
+	clock.waitForWaiters(t, 1)
+	clock.Advance(time.Minute)
+	require.Eventually(t, func() bool { return jobStatus(s, "slow").Running }, time.Second, time.Millisecond)
+
+	// 上一次执行未结束，本次触发被跳过
+	clock.waitForWaiters(t, 1)
+	clock.Advance(time.Minute)
+	require.Eventually(t, func() bool { return jobStatus(s, "slow").SkippedCount == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, int32(1), runs.Load())
+
+	close(release)
+	require.Eventually(t, func() bool { return !jobStatus(s, "slow").Running }, time.Second, time.Millisecond)
+	assert.Equal(t, int64(1), jobStatus(s, "slow").RunCount)
+}
+
+func TestScheduler_RecoversPanicAndAppliesTimeout(t *testing.T) {
+	clock := newFakeClock()
+	s := NewScheduler(clock)
+
+	require.NoError(t, s.Register(Job{
+		Name:       "panics",
+		Schedule:   Every(time.Hour),
+		RunOnStart: true,
+		Run: func(ctx context.Context, now time.Time) error {
+			panic("nil map")
+		},
+	}))
+	require.NoError(t, s.Register(Job{
+		Name:       "hangs",
+		Schedule:   Every(time.Hour),
+		Timeout:    10 * time.Millisecond,
+		RunOnStart: true,
+		Run: func(ctx context.Context, now time.Time) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}))
+	s.Start(context.Background())
+	defer s.Stop(context.Background())
+
+	require.Eventually(t, func() bool { return jobStatus(s, "panics").RunCount == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, "panic recovered: nil map", jobStatus(s, "panics").LastError)
+
+	require.Eventually(t, func() bool { return jobStatus(s, "hangs").RunCount == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, context.DeadlineExceeded.Error(), jobStatus(s, "hangs").LastError)
+}
+
+func TestScheduler_StopWaitsForInFlightJobs(t *testing.T) {
+	clock := newFakeClock()
+	s := NewScheduler(clock)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var cancelled atomic.Bool
+	require.NoError(t, s.Register(Job{
+		Name:       "report",
+		Schedule:   Every(time.Hour),
+		RunOnStart: true,
+		Run: func(ctx context.Context, now time.Time) error {
+			close(started)
+			<-ctx.Done()
+			cancelled.Store(true)
+			// 模拟收尾工作
+			<-release
+			return nil
+		},
+	}))
+	s.Start(context.Background())
+	<-started
+
+	// 任务未在期限内结束时返回错误
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := s.Stop(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.True(t, cancelled.Load())
+
+	close(release)
+	require.NoError(t, s.Stop(context.Background()))
+	assert.False(t, jobStatus(s, "report").Running)
+}
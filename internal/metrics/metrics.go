@@ -0,0 +1,210 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"taskmanage/pkg/logger"
+)
+
+// namespace 指标名前缀
+const namespace = "taskmanage"
+
+// DefaultHTTPDurationBuckets 默认HTTP请求耗时直方图桶（秒）
+var DefaultHTTPDurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// dbStatGauges 连接池统计项与导出的指标名、说明
+var dbStatGauges = []struct {
+	key  string
+	name string
+	help string
+}{
+	{"max_open_connections", "db_max_open_connections", "Maximum number of open connections to the database."},
+	{"open_connections", "db_open_connections", "Number of established connections, both in use and idle."},
+	{"in_use", "db_in_use_connections", "Number of connections currently in use."},
+	{"idle", "db_idle_connections", "Number of idle connections."},
+	{"wait_count", "db_wait_count", "Total number of connections waited for."},
+	{"max_idle_closed", "db_max_idle_closed", "Total number of connections closed due to SetMaxIdleConns."},
+	{"max_idle_time_closed", "db_max_idle_time_closed", "Total number of connections closed due to SetConnMaxIdleTime."},
+	{"max_lifetime_closed", "db_max_lifetime_closed", "Total number of connections closed due to SetConnMaxLifetime."},
+}
+
+// Metrics 应用指标集合
+type Metrics struct {
+	registry *Registry
+
+	httpRequests   *CounterVec
+	httpDuration   *HistogramVec
+	nodeExecutions *CounterVec
+	nodeFailures   *CounterVec
+}
+
+// New 创建应用指标集合，buckets为空时使用DefaultHTTPDurationBuckets
+func New(httpDurationBuckets []float64) *Metrics {
+	if len(httpDurationBuckets) == 0 {
+		httpDurationBuckets = DefaultHTTPDurationBuckets
+	}
+
+	registry := NewRegistry()
+	return &Metrics{
+		registry: registry,
+		httpRequests: registry.NewCounterVec(namespace+"_http_requests_total",
+			"Total number of HTTP requests.", "method", "route", "status"),
+		httpDuration: registry.NewHistogramVec(namespace+"_http_request_duration_seconds",
+			"HTTP request latency in seconds.", httpDurationBuckets, "method", "route", "status"),
+		nodeExecutions: registry.NewCounterVec(namespace+"_workflow_node_executions_total",
+			"Total number of workflow node executions.", "workflow", "node_type"),
+		nodeFailures: registry.NewCounterVec(namespace+"_workflow_node_failures_total",
+			"Total number of failed workflow node executions.", "workflow", "node_type"),
+	}
+}
+
+// Handler 返回/metrics的HTTP处理器
+func (m *Metrics) Handler() http.Handler {
+	return m.registry.Handler()
+}
+
+// ObserveHTTPRequest 记录一次HTTP请求
+func (m *Metrics) ObserveHTTPRequest(method, route string, status int, duration time.Duration) {
+	statusLabel := strconv.Itoa(status)
+	m.httpRequests.Inc(method, route, statusLabel)
+	m.httpDuration.Observe(duration.Seconds(), method, route, statusLabel)
+}
+
+// ObserveNodeExecution 记录一次工作流节点执行，实现workflow.ExecutionObserver
+func (m *Metrics) ObserveNodeExecution(workflowID, nodeType string, success bool) {
+	m.nodeExecutions.Inc(workflowID, nodeType)
+	if !success {
+		m.nodeFailures.Inc(workflowID, nodeType)
+	}
+}
+
+// RegisterDBStats 注册数据库连接池指标，connectionInfo的返回格式同database.GetConnectionInfo
+func (m *Metrics) RegisterDBStats(connectionInfo func() map[string]interface{}) {
+	m.registry.register(collectorFunc(func() []family {
+		info := connectionInfo()
+
+		up := 0.0
+		if connected, _ := info["connected"].(bool); connected {
+			up = 1
+		}
+		families := []family{{
+			name:    namespace + "_db_up",
+			help:    "Whether the database connection is healthy.",
+			typ:     "gauge",
+			samples: []sample{{value: up}},
+		}}
+
+		stats, _ := info["stats"].(map[string]interface{})
+		for _, gauge := range dbStatGauges {
+			value, ok := toFloat(stats[gauge.key])
+			if !ok {
+				continue
+			}
+			families = append(families, family{
+				name:    namespace + "_" + gauge.name,
+				help:    gauge.help,
+				typ:     "gauge",
+				samples: []sample{{value: value}},
+			})
+		}
+		return families
+	}))
+}
+
+// RegisterPendingApprovals 注册按流程名统计的待审批数量指标
+func (m *Metrics) RegisterPendingApprovals(countByWorkflow func(ctx context.Context) (map[string]int64, error)) {
+	m.registry.register(collectorFunc(func() []family {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+
+		f := family{
+			name: namespace + "_workflow_pending_approvals",
+			help: "Number of pending approvals by workflow name.",
+			typ:  "gauge",
+		}
+		counts, err := countByWorkflow(ctx)
+		if err != nil {
+			logger.Warnf("采集待审批数量失败: %v", err)
+			return []family{f}
+		}
+		for _, name := range sortedKeys(counts) {
+			f.samples = append(f.samples, sample{
+				labels: []labelPair{{name: "workflow", value: name}},
+				value:  float64(counts[name]),
+			})
+		}
+		return []family{f}
+	}))
+}
+
+// RegisterPermissionCache 注册权限缓存命中与未命中次数指标
+func (m *Metrics) RegisterPermissionCache(stats func() (hits, misses int64)) {
+	m.registry.register(collectorFunc(func() []family {
+		hits, misses := stats()
+		return []family{
+			{
+				name:    namespace + "_permission_cache_hits_total",
+				help:    "Total number of permission cache hits.",
+				typ:     "counter",
+				samples: []sample{{value: float64(hits)}},
+			},
+			{
+				name:    namespace + "_permission_cache_misses_total",
+				help:    "Total number of permission cache misses.",
+				typ:     "counter",
+				samples: []sample{{value: float64(misses)}},
+			},
+		}
+	}))
+}
+
+// redisBreakerStates 熔断器状态标签，与cache/redis的熔断器状态一致
+var redisBreakerStates = []string{"closed", "open", "half_open"}
+
+// RegisterRedisBreaker 注册Redis熔断器状态指标，当前状态的样本值为1，其余为0
+func (m *Metrics) RegisterRedisBreaker(snapshot func() (state string, consecutiveFailures int)) {
+	m.registry.register(collectorFunc(func() []family {
+		current, failures := snapshot()
+		states := family{
+			name: namespace + "_redis_breaker_state",
+			help: "Current state of the Redis circuit breaker.",
+			typ:  "gauge",
+		}
+		for _, state := range redisBreakerStates {
+			value := 0.0
+			if state == current {
+				value = 1
+			}
+			states.samples = append(states.samples, sample{
+				labels: []labelPair{{name: "state", value: state}},
+				value:  value,
+			})
+		}
+		return []family{
+			states,
+			{
+				name:    namespace + "_redis_breaker_consecutive_failures",
+				help:    "Number of consecutive failed Redis calls counted by the circuit breaker.",
+				typ:     "gauge",
+				samples: []sample{{value: float64(failures)}},
+			},
+		}
+	}))
+}
+
+// toFloat 将连接池统计值转换为float64
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func scrape(t *testing.T, m *Metrics) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, contentType, rec.Header().Get("Content-Type"))
+	return rec.Body.String()
+}
+
+func TestMetricsExposition(t *testing.T) {
+	m := New([]float64{0.1, 1})
+	m.ObserveHTTPRequest("GET", "/api/v1/tasks/:id", 200, 50*time.Millisecond)
+	m.ObserveHTTPRequest("GET", "/api/v1/tasks/:id", 200, 2*time.Second)
+	m.ObserveNodeExecution("请假审批", "approval", true)
+	m.ObserveNodeExecution("请假审批", "approval", false)
+	m.RegisterDBStats(func() map[string]interface{} {
+		return map[string]interface{}{
+			"connected": true,
+			"stats":     map[string]interface{}{"open_connections": 3, "wait_count": int64(5)},
+		}
+	})
+	m.RegisterPendingApprovals(func(ctx context.Context) (map[string]int64, error) {
+		return map[string]int64{"请假审批": 4}, nil
+	})
+	m.RegisterPermissionCache(func() (int64, int64) { return 7, 2 })
+	m.RegisterRedisBreaker(func() (string, int) { return "open", 5 })
+
+	body := scrape(t, m)
+	for _, line := range []string{
+		`# TYPE taskmanage_http_requests_total counter`,
+		`taskmanage_http_requests_total{method="GET",route="/api/v1/tasks/:id",status="200"} 2`,
+		`# TYPE taskmanage_http_request_duration_seconds histogram`,
+		`taskmanage_http_request_duration_seconds_bucket{method="GET",route="/api/v1/tasks/:id",status="200",le="0.1"} 1`,
+		`taskmanage_http_request_duration_seconds_bucket{method="GET",route="/api/v1/tasks/:id",status="200",le="1"} 1`,
+		`taskmanage_http_request_duration_seconds_bucket{method="GET",route="/api/v1/tasks/:id",status="200",le="+Inf"} 2`,
+		`taskmanage_http_request_duration_seconds_sum{method="GET",route="/api/v1/tasks/:id",status="200"} 2.05`,
+		`taskmanage_http_request_duration_seconds_count{method="GET",route="/api/v1/tasks/:id",status="200"} 2`,
+		`taskmanage_workflow_node_executions_total{workflow="请假审批",node_type="approval"} 2`,
+		`taskmanage_workflow_node_failures_total{workflow="请假审批",node_type="approval"} 1`,
+		`taskmanage_db_up 1`,
+		`taskmanage_db_open_connections 3`,
+		`taskmanage_db_wait_count 5`,
+		`taskmanage_workflow_pending_approvals{workflow="请假审批"} 4`,
+		`taskmanage_permission_cache_hits_total 7`,
+		`taskmanage_permission_cache_misses_total 2`,
+		`taskmanage_redis_breaker_state{state="closed"} 0`,
+		`taskmanage_redis_breaker_state{state="open"} 1`,
+		`taskmanage_redis_breaker_state{state="half_open"} 0`,
+		`taskmanage_redis_breaker_consecutive_failures 5`,
+	} {
+		assert.Contains(t, body, line+"\n")
+	}
+	assert.NotContains(t, body, "taskmanage_db_in_use_connections")
+}
+
+func TestLabelValueEscaping(t *testing.T) {
+	m := New(nil)
+	m.ObserveNodeExecution("say \"hi\"\n", `a\b`, true)
+
+	body := scrape(t, m)
+	assert.True(t, strings.Contains(body, `{workflow="say \"hi\"\n",node_type="a\\b"} 1`), body)
+}
@@ -0,0 +1,295 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// contentType Prometheus文本格式版本
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// labelSeparator 拼接标签值作为序列键的分隔符
+const labelSeparator = "\xff"
+
+// family 一个指标族在某次采集时的快照
+type family struct {
+	name    string
+	help    string
+	typ     string // counter, gauge, histogram
+	samples []sample
+}
+
+// sample 单个样本
+type sample struct {
+	suffix string // 直方图的 _bucket/_sum/_count 后缀
+	labels []labelPair
+	value  float64
+}
+
+type labelPair struct {
+	name  string
+	value string
+}
+
+// collector 可被注册表采集的指标
+type collector interface {
+	collect() []family
+}
+
+// collectorFunc 采集时动态计算的指标
+type collectorFunc func() []family
+
+func (f collectorFunc) collect() []family {
+	return f()
+}
+
+// Registry 指标注册表，按Prometheus文本格式输出
+type Registry struct {
+	mu         sync.RWMutex
+	collectors []collector
+}
+
+// NewRegistry 创建指标注册表
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, c)
+}
+
+// Write 输出全部指标
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.RLock()
+	collectors := append([]collector(nil), r.collectors...)
+	r.mu.RUnlock()
+
+	buf := bufio.NewWriter(w)
+	for _, c := range collectors {
+		for _, f := range c.collect() {
+			writeFamily(buf, f)
+		}
+	}
+	return buf.Flush()
+}
+
+// Handler 返回/metrics的HTTP处理器
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		if err := r.Write(w); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+func writeFamily(w *bufio.Writer, f family) {
+	fmt.Fprintf(w, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.name, f.typ)
+	for _, s := range f.samples {
+		w.WriteString(f.name)
+		w.WriteString(s.suffix)
+		if len(s.labels) > 0 {
+			w.WriteByte('{')
+			for i, l := range s.labels {
+				if i > 0 {
+					w.WriteByte(',')
+				}
+				fmt.Fprintf(w, "%s=\"%s\"", l.name, escapeLabelValue(l.value))
+			}
+			w.WriteByte('}')
+		}
+		w.WriteByte(' ')
+		w.WriteString(formatFloat(s.value))
+		w.WriteByte('\n')
+	}
+}
+
+func escapeHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
+
+func escapeLabelValue(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`).Replace(s)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func makeLabels(names, values []string) []labelPair {
+	labels := make([]labelPair, len(names))
+	for i, name := range names {
+		labels[i] = labelPair{name: name, value: values[i]}
+	}
+	return labels
+}
+
+// sortedKeys 返回排序后的序列键，保证输出稳定
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// CounterVec 带标签的计数器
+type CounterVec struct {
+	name       string
+	help       string
+	labelNames []string
+
+	mu     sync.Mutex
+	values map[string]float64
+	labels map[string][]string
+}
+
+// NewCounterVec 创建并注册计数器
+func (r *Registry) NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	c := &CounterVec{
+		name:       name,
+		help:       help,
+		labelNames: labelNames,
+		values:     make(map[string]float64),
+		labels:     make(map[string][]string),
+	}
+	r.register(c)
+	return c
+}
+
+// Inc 计数加一，标签值顺序与创建时的标签名一致
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add 计数增加指定值，负值被忽略
+func (c *CounterVec) Add(delta float64, labelValues ...string) {
+	if delta < 0 || len(labelValues) != len(c.labelNames) {
+		return
+	}
+	key := strings.Join(labelValues, labelSeparator)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.labels[key]; !ok {
+		c.labels[key] = append([]string(nil), labelValues...)
+	}
+	c.values[key] += delta
+}
+
+func (c *CounterVec) collect() []family {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	f := family{name: c.name, help: c.help, typ: "counter"}
+	for _, key := range sortedKeys(c.values) {
+		f.samples = append(f.samples, sample{
+			labels: makeLabels(c.labelNames, c.labels[key]),
+			value:  c.values[key],
+		})
+	}
+	return []family{f}
+}
+
+// HistogramVec 带标签的直方图
+type HistogramVec struct {
+	name       string
+	help       string
+	labelNames []string
+	buckets    []float64
+
+	mu     sync.Mutex
+	series map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	labelValues []string
+	counts      []uint64 // 各桶内（非累计）的观测数
+	sum         float64
+	count       uint64
+}
+
+// NewHistogramVec 创建并注册直方图，buckets为升序的桶上界
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+	h := &HistogramVec{
+		name:       name,
+		help:       help,
+		labelNames: labelNames,
+		buckets:    sorted,
+		series:     make(map[string]*histogramSeries),
+	}
+	r.register(h)
+	return h
+}
+
+// Observe 记录一次观测值
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	if len(labelValues) != len(h.labelNames) {
+		return
+	}
+	key := strings.Join(labelValues, labelSeparator)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogramSeries{
+			labelValues: append([]string(nil), labelValues...),
+			counts:      make([]uint64, len(h.buckets)),
+		}
+		h.series[key] = s
+	}
+	if i := sort.SearchFloat64s(h.buckets, value); i < len(h.buckets) {
+		s.counts[i]++
+	}
+	s.sum += value
+	s.count++
+}
+
+func (h *HistogramVec) collect() []family {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	f := family{name: h.name, help: h.help, typ: "histogram"}
+	for _, key := range sortedKeys(h.series) {
+		s := h.series[key]
+		labels := makeLabels(h.labelNames, s.labelValues)
+
+		var cumulative uint64
+		for i, upper := range h.buckets {
+			cumulative += s.counts[i]
+			f.samples = append(f.samples, sample{
+				suffix: "_bucket",
+				labels: append(append([]labelPair(nil), labels...), labelPair{name: "le", value: formatFloat(upper)}),
+				value:  float64(cumulative),
+			})
+		}
+		f.samples = append(f.samples,
+			sample{suffix: "_bucket", labels: append(append([]labelPair(nil), labels...), labelPair{name: "le", value: "+Inf"}), value: float64(s.count)},
+			sample{suffix: "_sum", labels: labels, value: s.sum},
+			sample{suffix: "_count", labels: labels, value: float64(s.count)},
+		)
+	}
+	return []family{f}
+}
@@ -41,14 +41,19 @@ type TaskNotificationAction struct {
 type TaskNotificationType string
 
 const (
-	NotificationTypeTaskAssigned   TaskNotificationType = "task_assigned"   // 任务分配
-	NotificationTypeTaskStarted    TaskNotificationType = "task_started"    // 任务开始
-	NotificationTypeTaskCompleted  TaskNotificationType = "task_completed"  // 任务完成
-	NotificationTypeTaskCancelled  TaskNotificationType = "task_cancelled"  // 任务取消
-	NotificationTypeTaskReassigned TaskNotificationType = "task_reassigned" // 任务重新分配
-	NotificationTypeTaskOverdue    TaskNotificationType = "task_overdue"    // 任务逾期
-	NotificationTypeTaskReminder   TaskNotificationType = "task_reminder"   // 任务提醒
-	NotificationTypeSystemMessage  TaskNotificationType = "system_message"  // 系统消息
+	NotificationTypeTaskAssigned        TaskNotificationType = "task_assigned"         // 任务分配
+	NotificationTypeTaskStarted         TaskNotificationType = "task_started"          // 任务开始
+	NotificationTypeTaskCompleted       TaskNotificationType = "task_completed"        // 任务完成
+	NotificationTypeTaskCancelled       TaskNotificationType = "task_cancelled"        // 任务取消
+	NotificationTypeTaskReassigned      TaskNotificationType = "task_reassigned"       // 任务重新分配
+	NotificationTypeTaskUpdated         TaskNotificationType = "task_updated"          // 任务状态更新
+	NotificationTypeTaskCommented       TaskNotificationType = "task_commented"        // 任务新评论
+	NotificationTypeTaskOverdue         TaskNotificationType = "task_overdue"          // 任务逾期
+	NotificationTypeTaskReminder        TaskNotificationType = "task_reminder"         // 任务提醒
+	NotificationTypeTaskEscalated       TaskNotificationType = "task_escalated"        // 任务待分配超时升级
+	NotificationTypeTaskReviewRequested TaskNotificationType = "task_review_requested" // 任务提交审核
+	NotificationTypeTaskReviewed        TaskNotificationType = "task_reviewed"         // 任务审核结果
+	NotificationTypeSystemMessage       TaskNotificationType = "system_message"        // 系统消息
 )
 
 type NotificationPriority string
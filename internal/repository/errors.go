@@ -16,6 +16,8 @@ var (
 	ErrPermissionDenied  = errors.New("权限不足")
 	ErrResourceLocked    = errors.New("资源被锁定")
 	ErrConcurrentUpdate  = errors.New("并发更新冲突")
+	ErrConflict          = errors.New("数据已被其他请求修改")
+	ErrTaskLimitReached  = errors.New("员工任务数已达上限")
 )
 
 // RepositoryError 仓储层错误
@@ -68,6 +70,11 @@ func IsDuplicateKeyError(err error) bool {
 	return errors.Is(err, ErrDuplicateKey)
 }
 
+// IsConflictError 检查是否为乐观锁版本冲突
+func IsConflictError(err error) bool {
+	return errors.Is(err, ErrConflict)
+}
+
 // IsTimeoutError 检查是否为超时错误
 func IsTimeoutError(err error) bool {
 	return errors.Is(err, ErrTimeout)
@@ -32,6 +32,8 @@ type UserRepository interface {
 	BaseRepository[database.User]
 	GetByUsername(ctx context.Context, username string) (*database.User, error)
 	GetByEmail(ctx context.Context, email string) (*database.User, error)
+	// GetByIDs 批量获取用户，不存在的ID被忽略
+	GetByIDs(ctx context.Context, ids []uint) ([]*database.User, error)
 	UpdateLastLogin(ctx context.Context, userID uint, ip string) error
 	GetUserWithRoles(ctx context.Context, userID uint) (*database.User, error)
 	BatchUpdateStatus(ctx context.Context, userIDs []uint, status string) error
@@ -39,6 +41,20 @@ type UserRepository interface {
 	AssignRoles(ctx context.Context, userID uint, roleIDs []uint) error
 	RemoveRoles(ctx context.Context, userID uint, roleIDs []uint) error
 	GetUsersByRole(ctx context.Context, role string) ([]*database.User, error)
+	FindUsersByRole(ctx context.Context, role string) ([]*RoleUserMatch, error)
+}
+
+// 角色匹配来源
+const (
+	RoleSourceColumn    = "users.role" // 用户表的旧版role字段
+	RoleSourceUserRoles = "user_roles" // 用户角色关联表
+)
+
+// RoleUserMatch 按角色查找到的用户及其匹配来源
+type RoleUserMatch struct {
+	User         *database.User
+	MatchedRoles []string // 实际命中的角色名，可能是查询角色的下级角色
+	Sources      []string // 命中来源：RoleSourceColumn / RoleSourceUserRoles
 }
 
 // RoleRepository 角色仓储接口
@@ -48,6 +64,12 @@ type RoleRepository interface {
 	GetRoleWithPermissions(ctx context.Context, roleID uint) (*database.Role, error)
 	AssignPermissions(ctx context.Context, roleID uint, permissionIDs []uint) error
 	RemovePermissions(ctx context.Context, roleID uint, permissionIDs []uint) error
+	// AddPermissions 为角色追加权限，保留角色已有的权限
+	AddPermissions(ctx context.Context, roleID uint, permissionIDs []uint) error
+	// GetRoleUsers 获取拥有该角色的用户
+	GetRoleUsers(ctx context.Context, roleID uint) ([]*database.User, error)
+	// DeleteRole 删除角色及其用户、权限关联，下级角色改挂到被删除角色的上级，返回被解除角色的用户ID
+	DeleteRole(ctx context.Context, roleID uint) ([]uint, error)
 }
 
 // PermissionRepository 权限仓储接口
@@ -56,6 +78,12 @@ type PermissionRepository interface {
 	GetByResource(ctx context.Context, resource string) ([]*database.Permission, error)
 	GetUserPermissions(ctx context.Context, userID uint) ([]*database.Permission, error)
 	GetByName(ctx context.Context, name string) (*database.Permission, error)
+	// GetEffectivePermissions 获取用户有效权限：角色权限加上生效中的模板分配和直接分配
+	GetEffectivePermissions(ctx context.Context, userID uint) ([]*database.Permission, error)
+	// CountActiveAssignments 统计直接分配该权限且仍生效或待审批的记录数
+	CountActiveAssignments(ctx context.Context, permissionID uint) (int64, error)
+	// DeletePermission 删除权限及其角色、模板关联
+	DeletePermission(ctx context.Context, permissionID uint) error
 }
 
 // EmployeeRepository 员工仓储接口
@@ -63,8 +91,20 @@ type EmployeeRepository interface {
 	BaseRepository[database.Employee]
 	GetByUserID(ctx context.Context, userID uint) (*database.Employee, error)
 	GetByEmployeeNo(ctx context.Context, employeeNo string) (*database.Employee, error)
+	// EmployeeNoExists 员工编号是否已被使用，包含已删除的员工
+	EmployeeNoExists(ctx context.Context, employeeNo string) (bool, error)
+	// ListEmployeeNos 获取全部员工（包含已删除）的ID和编号，按ID排序
+	ListEmployeeNos(ctx context.Context) ([]*database.Employee, error)
 	GetAvailableEmployees(ctx context.Context) ([]*database.Employee, error)
+	// UpdateTaskCount 原子调整当前任务数，增加后超过max_tasks时返回ErrTaskLimitReached
 	UpdateTaskCount(ctx context.Context, employeeID uint, delta int) error
+	// CountActiveTasks 按任务表统计员工实际进行中的任务数（assigned、in_progress、in_review），employeeIDs为空时统计全部员工
+	CountActiveTasks(ctx context.Context, employeeIDs []uint) (map[uint]int, error)
+	// CorrectTaskCount 当前任务数仍为expected时改为count，期间被其他请求调整过时返回ErrConflict
+	CorrectTaskCount(ctx context.Context, employeeID uint, expected, count int) error
+	MoveToDepartment(ctx context.Context, fromDepartmentID, toDepartmentID uint) (int64, error) // 将部门全部员工迁移到目标部门，返回迁移人数
+	MoveToPosition(ctx context.Context, fromPositionID, toPositionID uint) (int64, error)       // 将职位全部员工迁移到目标职位，返回迁移人数
+	AssignDirectManager(ctx context.Context, departmentID, managerID uint) (int64, error)       // 为部门中没有直接上级的在职员工设置直接上级（跳过上级本人），返回更新人数
 	GetEmployeeWithSkills(ctx context.Context, employeeID uint) (*database.Employee, error)
 	GetBySkills(ctx context.Context, skillIDs []uint, minLevel int) ([]*database.Employee, error)
 	
@@ -75,6 +115,57 @@ type EmployeeRepository interface {
 	// 部门和批量查询
 	GetByDepartment(ctx context.Context, department string) ([]*database.Employee, error)
 	GetAll(ctx context.Context) ([]*database.Employee, error)
+
+	// 试用期提醒
+	ListProbationEnding(ctx context.Context, before time.Time) ([]*database.Employee, error) // 试用期在before之前结束且未提醒的员工
+	MarkProbationReminded(ctx context.Context, employeeID uint, remindedAt time.Time) error
+
+	// ListTenured 获取在职员工中入职日期不晚于hiredBefore或转正日期不晚于confirmedBefore的员工，条件为nil时不参与匹配
+	ListTenured(ctx context.Context, hiredBefore, confirmedBefore *time.Time) ([]*database.Employee, error)
+
+	// ListOnboarding 按入职过滤条件分页获取员工及总数
+	ListOnboarding(ctx context.Context, filter *OnboardingWorkflowFilter) ([]*database.Employee, int64, error)
+
+	// ListEmployeesWithUserAndDepartment 分页获取员工及总数，预加载用户、部门和职位，查询次数不随页大小增长
+	ListEmployeesWithUserAndDepartment(ctx context.Context, filter ListFilter) ([]*database.Employee, int64, error)
+
+	// 组织架构，结果预加载用户、部门和职位
+	ListManagerChain(ctx context.Context, employeeID uint, maxLevels int) ([]*database.Employee, error) // 从员工本人逐级向上，上级链成环时在重复出现的员工前截断
+	ListReports(ctx context.Context, employeeID uint, maxDepth int) ([]*database.Employee, error)       // maxDepth层以内的直接和间接下属，每名员工只出现一次
+
+	// 工作负载统计
+	ListForWorkload(ctx context.Context, filter *WorkloadFilter) ([]*database.Employee, error) // 按员工ID和部门过滤，预加载用户和部门
+	// AggregateTaskStats 按任务表一次分组统计员工的待处理、已完成、逾期任务数和平均完成时长，没有任务的员工不在结果中
+	AggregateTaskStats(ctx context.Context, filter *WorkloadFilter, now time.Time) (map[uint]*EmployeeTaskStats, error)
+}
+
+// WorkloadFilter 工作负载统计过滤器，完成数、按时完成数和平均时长只统计完成时间在[CompletedFrom, CompletedTo)内的任务
+type WorkloadFilter struct {
+	EmployeeIDs   []uint
+	DepartmentID  uint   // 部门ID，优先于部门名称
+	Department    string // 部门名称
+	CompletedFrom time.Time
+	CompletedTo   time.Time
+}
+
+// EmployeeTaskStats 员工任务统计
+type EmployeeTaskStats struct {
+	EmployeeID       uint
+	PendingTasks     int     // 已指定负责人但状态仍为pending的任务
+	CompletedTasks   int     // 统计区间内完成的任务
+	OnTimeTasks      int     // 统计区间内在截止时间前完成或没有截止时间的任务
+	OverdueTasks     int     // 已过截止时间且未完成、未取消的任务
+	AvgDurationHours float64 // 统计区间内完成任务从开始到完成的平均小时数，没有开始时间的任务不计入
+}
+
+// OnboardingWorkflowFilter 入职工作流过滤器，预期入职日期范围为[ExpectedFrom, ExpectedTo)
+type OnboardingWorkflowFilter struct {
+	Page         int
+	PageSize     int
+	Status       string // 入职状态
+	Department   string // 部门名称
+	ExpectedFrom *time.Time
+	ExpectedTo   *time.Time
 }
 
 // SkillRepository 技能仓储接口
@@ -84,44 +175,108 @@ type SkillRepository interface {
 	GetByName(ctx context.Context, name string) (*database.Skill, error)
 	AssignToEmployee(ctx context.Context, employeeID, skillID uint, level int) error
 	RemoveFromEmployee(ctx context.Context, employeeID, skillID uint) error
-	GetAllCategories(ctx context.Context) ([]string, error)
 	GetEmployeeSkills(ctx context.Context, employeeID uint) ([]*database.Skill, error)
 	GetEmployeeSkillLevel(ctx context.Context, employeeID, skillID uint) (int, error)
+	GetEmployeeSkillsWithLevel(ctx context.Context, employeeID uint) ([]*database.EmployeeSkillDetail, error)
+	GetEmployeesWithSkillLevels(ctx context.Context, employeeIDs []uint) (map[uint][]*database.EmployeeSkillDetail, error) // 一次查询多名员工的技能及等级，按员工ID分组
+	GetEmployeeSkill(ctx context.Context, employeeID, skillID uint) (*database.EmployeeSkill, error)
+	EndorseEmployeeSkill(ctx context.Context, employeeSkill *database.EmployeeSkill) error
+	ListExpiringSkills(ctx context.Context, from, to time.Time) ([]*database.ExpiringSkill, error)
+	MarkExpiryNotified(ctx context.Context, employeeID, skillID uint, notifiedAt time.Time) error
+}
+
+// SkillCategoryRepository 技能分类仓储接口
+type SkillCategoryRepository interface {
+	BaseRepository[database.SkillCategory]
+	// GetByName 按名称不区分大小写查找分类
+	GetByName(ctx context.Context, name string) (*database.SkillCategory, error)
+	// ListWithSkillCounts 获取全部分类及各分类下的技能数量，按名称排序
+	ListWithSkillCounts(ctx context.Context) ([]*SkillCategoryCount, error)
+	// Merge 在一个事务内将源分类下的技能改挂到目标分类并删除源分类，返回改挂的技能数
+	Merge(ctx context.Context, sourceID, targetID uint) (int64, error)
+}
+
+// SkillCategoryCount 技能分类及其技能数量
+type SkillCategoryCount struct {
+	ID          uint
+	Name        string
+	Description string
+	SkillCount  int64
+}
+
+// DepartmentReferences 引用部门的记录数，员工只统计未离职的
+type DepartmentReferences struct {
+	Employees           int64 `json:"employees"`
+	SubDepartments      int64 `json:"sub_departments"`
+	Projects            int64 `json:"projects"`
+	PermissionTemplates int64 `json:"permission_templates"`
+	OnboardingConfigs   int64 `json:"onboarding_configs"`
+}
+
+// Total 引用记录总数
+func (r *DepartmentReferences) Total() int64 {
+	return r.Employees + r.SubDepartments + r.Projects + r.PermissionTemplates + r.OnboardingConfigs
+}
+
+// PositionReferences 引用职位的记录数，员工只统计未离职的
+type PositionReferences struct {
+	Employees           int64 `json:"employees"`
+	PermissionTemplates int64 `json:"permission_templates"`
+	OnboardingConfigs   int64 `json:"onboarding_configs"`
+}
+
+// Total 引用记录总数
+func (r *PositionReferences) Total() int64 {
+	return r.Employees + r.PermissionTemplates + r.OnboardingConfigs
 }
 
 // DepartmentRepository 部门仓储接口
 type DepartmentRepository interface {
 	BaseRepository[database.Department]
 	GetByName(ctx context.Context, name string) (*database.Department, error)
+	GetByCode(ctx context.Context, code string) (*database.Department, error) // 不存在时返回ErrNotFound
 	GetByParentID(ctx context.Context, parentID uint) ([]*database.Department, error)
 	GetRootDepartments(ctx context.Context) ([]*database.Department, error)
 	GetDepartmentTree(ctx context.Context) ([]*database.Department, error)
 	GetDepartmentWithManager(ctx context.Context, id uint) (*database.Department, error)
 	UpdateManager(ctx context.Context, departmentID, managerID uint) error
+	UpdateAssignmentStrategy(ctx context.Context, departmentID uint, strategy string) error
+	UpdateOnboardingWorkflowType(ctx context.Context, departmentID uint, workflowType string) error
 	GetSubDepartments(ctx context.Context, departmentID uint) ([]*database.Department, error)
+	GetByIDUnscoped(ctx context.Context, id uint) (*database.Department, error) // 包含已删除的部门
+	CountReferences(ctx context.Context, departmentID uint) (*DepartmentReferences, error) // 一次查询统计引用部门的员工、子部门、项目、权限模板和入职权限配置数
 }
 
 // PositionRepository 职位仓储接口
 type PositionRepository interface {
 	BaseRepository[database.Position]
 	GetByName(ctx context.Context, name string) (*database.Position, error)
+	GetByCode(ctx context.Context, code string) (*database.Position, error) // 不存在时返回ErrNotFound
 	GetByCategory(ctx context.Context, category string) ([]*database.Position, error)
 	GetByLevel(ctx context.Context, level int) ([]*database.Position, error)
 	GetAllCategories(ctx context.Context) ([]string, error)
+	GetByIDUnscoped(ctx context.Context, id uint) (*database.Position, error) // 包含已删除的职位
+	CountReferences(ctx context.Context, positionID uint) (*PositionReferences, error) // 一次查询统计引用职位的员工、权限模板和入职权限配置数
 }
 
 // ProjectRepository 项目仓储接口
 type ProjectRepository interface {
 	BaseRepository[database.Project]
 	GetByName(ctx context.Context, name string) (*database.Project, error)
+	GetByIDs(ctx context.Context, ids []uint) ([]*database.Project, error) // 批量获取项目，不预加载关联，不存在的ID被忽略
 	GetByDepartmentID(ctx context.Context, departmentID uint) ([]*database.Project, error)
 	GetByManagerID(ctx context.Context, managerID uint) ([]*database.Project, error)
+	GetByMemberID(ctx context.Context, employeeID uint) ([]*database.Project, error)
+	GetByMemberIDs(ctx context.Context, employeeIDs []uint) (map[uint][]*database.Project, error) // 一次查询多名员工参与的项目，按员工ID分组
 	GetByStatus(ctx context.Context, status string) ([]*database.Project, error)
 	GetProjectWithMembers(ctx context.Context, id uint) (*database.Project, error)
 	AddMember(ctx context.Context, projectID, employeeID uint) error
 	RemoveMember(ctx context.Context, projectID, employeeID uint) error
 	GetProjectMembers(ctx context.Context, projectID uint) ([]*database.Employee, error)
+	GetMemberIDs(ctx context.Context, projectID uint) ([]uint, error)
+	AddMemberByOperator(ctx context.Context, projectID, employeeID, operatorID uint) error // 记录加入成员的操作人，已是成员时不做修改
 	UpdateManager(ctx context.Context, projectID, managerID uint) error
+	MoveToDepartment(ctx context.Context, fromDepartmentID, toDepartmentID uint) (int64, error) // 将部门全部项目迁移到目标部门，返回迁移个数
 }
 
 // TaskRepository 任务仓储接口
@@ -133,6 +288,7 @@ type TaskRepository interface {
 	GetOverdueTasks(ctx context.Context) ([]*database.Task, error)
 	GetTaskWithDetails(ctx context.Context, taskID uint) (*database.Task, error)
 	UpdateStatus(ctx context.Context, taskID uint, status string) error
+	UpdatePriority(ctx context.Context, taskID uint, priority string) error
 	AssignTask(ctx context.Context, taskID, assigneeID uint) error
 	GetTasksByPriority(ctx context.Context, priority string) ([]*database.Task, error)
 	GetTasksInDateRange(ctx context.Context, start, end time.Time) ([]*database.Task, error)
@@ -140,6 +296,80 @@ type TaskRepository interface {
 	// Assignment management methods
 	GetActiveTasksByEmployee(ctx context.Context, employeeID uint) ([]*database.Task, error)
 	UpdateAssignee(ctx context.Context, taskID, assigneeID uint) error
+	
+	// Project board methods
+	GetByProject(ctx context.Context, projectID uint, filters map[string]interface{}) ([]*database.Task, error)
+	CountByProjectAndStatus(ctx context.Context, projectID uint, status string) (int64, error)
+	// GetProjectTimelineTasks 获取项目全部任务并预加载负责人，用于时间线视图
+	GetProjectTimelineTasks(ctx context.Context, projectID uint) ([]*database.Task, error)
+	// GetProjectRemainingEstimate 项目在at时刻剩余的预估工时：at之前创建、at时尚未完成的任务预估工时之和，不含已取消的任务
+	GetProjectRemainingEstimate(ctx context.Context, projectID uint, at time.Time) (float64, error)
+	// SumProjectEstimatesByCreatedSlot 项目在[from, to)内新建任务的预估工时，按创建时间所在时段汇总，不含已取消的任务
+	SumProjectEstimatesByCreatedSlot(ctx context.Context, projectID uint, from, to time.Time) ([]TimeSlotSum, error)
+	// SumProjectEstimatesByCompletedSlot 项目在[from, to)内完成任务的预估工时，按完成时间所在时段汇总
+	SumProjectEstimatesByCompletedSlot(ctx context.Context, projectID uint, from, to time.Time) ([]TimeSlotSum, error)
+	// SumProjectUntrackedHoursByCompletedSlot 项目在[from, to)内完成且没有工时记录的任务的实际工时，按完成时间所在时段汇总
+	SumProjectUntrackedHoursByCompletedSlot(ctx context.Context, projectID uint, from, to time.Time) ([]TimeSlotSum, error)
+
+	// Skill requirement methods
+	GetSkillRequirements(ctx context.Context, taskID uint) ([]*database.TaskSkillDetail, error)
+	ReplaceSkills(ctx context.Context, taskID uint, skills []*database.TaskSkill) error
+
+	// ListTasksWithUnknownAssignee 获取负责人ID不对应任何用户的任务，用于排查误存为员工ID的数据
+	ListTasksWithUnknownAssignee(ctx context.Context) ([]*database.Task, error)
+
+	// Sub-task methods
+	// CountSubTasks 按父任务分组统计直接子任务数和已完成数，不加载子任务；已取消的子任务不计入，没有子任务的父任务不在结果中
+	CountSubTasks(ctx context.Context, parentIDs []uint) (map[uint]*SubTaskCounts, error)
+	// CancelTree 在一个事务中取消任务及其全部未完成、未取消的后代任务，返回被取消的任务（取消前的状态）
+	// 任务本身已被其他请求完成或取消时返回ErrConflict
+	CancelTree(ctx context.Context, taskID uint, cancelledAt time.Time) ([]*database.Task, error)
+
+	// FindMissingIDs 返回给定ID中不存在或已删除的任务ID，按输入顺序
+	FindMissingIDs(ctx context.Context, taskIDs []uint) ([]uint, error)
+
+	// Assignee dashboard methods
+	// CountByAssigneeGroupByStatus 按状态统计负责人（用户ID）的任务数，没有任务的状态不在结果中
+	CountByAssigneeGroupByStatus(ctx context.Context, assigneeID uint) (map[string]int64, error)
+	// ListOpenByAssignee 获取负责人未完成、未取消的任务及总数，按截止时间升序（无截止时间的在后）、优先级从高到低排序
+	ListOpenByAssignee(ctx context.Context, filter *OpenTaskFilter) ([]*database.Task, int64, error)
+
+	// Archive methods
+	// List的ListFilter.Filters["include_archived"]为true时同时查询归档任务，默认只查询在用任务
+	// GetArchivedByID 获取已归档的任务，不存在时返回ErrNotFound
+	GetArchivedByID(ctx context.Context, taskID uint) (*database.TaskArchive, error)
+	// FindArchivedIDs 返回给定ID中已归档的任务ID
+	FindArchivedIDs(ctx context.Context, taskIDs []uint) ([]uint, error)
+}
+
+// OpenTaskFilter 负责人未完成任务的查询条件，DueFrom/DueTo不为nil时只返回截止时间在范围内的任务
+type OpenTaskFilter struct {
+	AssigneeID uint
+	DueFrom    *time.Time
+	DueTo      *time.Time
+	Limit      int
+}
+
+// TaskLabelFilter 任务列表的标签过滤条件，作为ListFilter.Filters["labels"]的值
+// MatchAny为false时任务须带有全部标签，为true时带有任一标签即可
+type TaskLabelFilter struct {
+	Names    []string
+	MatchAny bool
+}
+
+// TaskVisibilityFilter 任务列表的可见范围过滤条件，作为ListFilter.Filters["visibility"]的值
+// 负责人为UserID的任务始终可见；IncludeCreated为true时加上UserID创建的任务，
+// DepartmentID不为nil时加上负责人属于该部门的任务
+type TaskVisibilityFilter struct {
+	UserID         uint
+	IncludeCreated bool
+	DepartmentID   *uint
+}
+
+// SubTaskCounts 子任务数量统计
+type SubTaskCounts struct {
+	Total     int
+	Completed int
 }
 
 // AssignmentRepository 任务分配仓储接口
@@ -152,7 +382,42 @@ type AssignmentRepository interface {
 	GetPendingAssignments(ctx context.Context) ([]*database.Assignment, error)
 	ApproveAssignment(ctx context.Context, assignmentID, approverID uint, reason string) error
 	RejectAssignment(ctx context.Context, assignmentID, approverID uint, reason string) error
-	GetAssignmentHistory(ctx context.Context, taskID uint) ([]*database.Assignment, error)
+	GetAssignmentHistory(ctx context.Context, taskID uint, page, pageSize int) ([]*database.Assignment, int64, error)
+
+	// Stats methods
+	// CountGroupByMethod 按分配方式统计分配记录数
+	CountGroupByMethod(ctx context.Context, filter *AssignmentStatsFilter) (map[string]int64, error)
+	// CountGroupByStatus 按状态统计分配记录数
+	CountGroupByStatus(ctx context.Context, filter *AssignmentStatsFilter) (map[string]int64, error)
+	// GetApprovalStats 统计已审批（通过或拒绝）的分配记录数、拒绝数和平均审批耗时
+	GetApprovalStats(ctx context.Context, filter *AssignmentStatsFilter) (*AssignmentApprovalStats, error)
+	// CountGroupByDepartment 按被分配人所在部门统计，没有员工档案或部门的记录归入DepartmentID为nil的分组
+	CountGroupByDepartment(ctx context.Context, filter *AssignmentStatsFilter) ([]*AssignmentDepartmentStats, error)
+}
+
+// AssignmentStatsFilter 分配统计过滤条件，按分配时间过滤，时间范围为[From, To)，为nil时不限制
+type AssignmentStatsFilter struct {
+	From         *time.Time
+	To           *time.Time
+	DepartmentID *uint // 按被分配人所在部门过滤
+}
+
+// AssignmentApprovalStats 分配审批统计，审批拒绝时同样记录审批时间
+type AssignmentApprovalStats struct {
+	Decided            int64   // 已审批（通过或拒绝）的记录数
+	Rejected           int64   // 审批拒绝的记录数
+	AvgApprovalSeconds float64 // 分配时间到审批时间的平均秒数
+}
+
+// AssignmentDepartmentStats 部门分配统计
+type AssignmentDepartmentStats struct {
+	DepartmentID       *uint
+	DepartmentName     string
+	Total              int64
+	Approved           int64
+	Rejected           int64
+	Pending            int64
+	AvgApprovalSeconds float64 // 已审批记录的平均审批秒数
 }
 
 // NotificationRepository 通知仓储接口
@@ -161,11 +426,31 @@ type NotificationRepository interface {
 	GetUserNotifications(ctx context.Context, userID uint, status string, page, pageSize int) ([]*database.TaskNotification, int64, error)
 	MarkAsRead(ctx context.Context, notificationID, userID uint) error
 	MarkAllAsRead(ctx context.Context, userID uint) (int64, error)
-	GetUnreadCount(ctx context.Context, userID uint) (int64, error)
+	// GetUnreadCount 获取未读通知数量，notificationType不为空时只统计该类型
+	GetUnreadCount(ctx context.Context, userID uint, notificationType string) (int64, error)
+	// ListByFilter 按过滤条件分页获取接收人未过期的通知，按创建时间倒序
+	ListByFilter(ctx context.Context, filter *NotificationFilter, page, pageSize int) ([]*database.TaskNotification, int64, error)
+	// ListOwnedIDs 返回ids中属于该接收人的通知ID
+	ListOwnedIDs(ctx context.Context, recipientID uint, ids []uint) ([]uint, error)
+	// MarkReadByFilter 用一条UPDATE将符合过滤条件的未读通知标记为已读，返回更新的数量
+	MarkReadByFilter(ctx context.Context, filter *NotificationFilter, readAt time.Time) (int64, error)
+	// DeleteReadBefore 软删除阅读时间早于before的已读通知，返回删除的数量
+	DeleteReadBefore(ctx context.Context, before time.Time) (int64, error)
 	CreateTaskAssignmentNotification(ctx context.Context, taskID, recipientID, senderID uint) error
 	UpdateNotificationStatus(ctx context.Context, notificationID, userID uint, status string) error
 	AcceptTaskNotification(ctx context.Context, notificationID, taskID, userID uint, reason *string) error
 	RejectTaskNotification(ctx context.Context, notificationID, taskID, userID uint, reason *string) error
+	// ExistsByDedupeKey 接收人是否已有相同去重键的通知
+	ExistsByDedupeKey(ctx context.Context, recipientID uint, dedupeKey string) (bool, error)
+}
+
+// NotificationFilter 站内通知过滤条件，RecipientID必填，其余为空时不过滤
+type NotificationFilter struct {
+	RecipientID   uint
+	IDs           []uint
+	Type          string
+	IsRead        *bool      // true为已读或已操作，false为未读
+	CreatedBefore *time.Time // 只包含创建时间早于该时间的通知
 }
 
 // AuditLogRepository 审计日志仓储接口
@@ -200,6 +485,15 @@ type WorkflowRepository interface {
 	
 	// UpdateWorkflowStatus 更新流程状态
 	UpdateWorkflowStatus(ctx context.Context, workflowID string, isActive bool) error
+	
+	// GetWorkflowDefinitionVersion 根据版本ID获取流程定义
+	GetWorkflowDefinitionVersion(ctx context.Context, versionID uint) (*database.WorkflowDefinition, error)
+	
+	// ListWorkflowDefinitionVersions 列出流程定义的所有版本
+	ListWorkflowDefinitionVersions(ctx context.Context, workflowID string) ([]*database.WorkflowDefinition, error)
+	
+	// SetWorkflowVersionActive 设置流程定义版本的激活状态
+	SetWorkflowVersionActive(ctx context.Context, workflowID string, versionID uint, isActive bool) error
 }
 
 // WorkflowInstanceRepository 工作流实例仓库接口
@@ -219,14 +513,23 @@ type WorkflowInstanceRepository interface {
 	// UpdateInstanceNodes 更新实例当前节点
 	UpdateInstanceNodes(ctx context.Context, instanceID string, currentNodes []string) error
 	
-	// AddExecutionHistory 添加执行历史
-	AddExecutionHistory(ctx context.Context, history *database.WorkflowExecutionHistory) error
+	// UpdateInstanceCompletion 更新实例的业务回调执行记录
+	UpdateInstanceCompletion(ctx context.Context, instanceID string, completion database.JSONField) error
 	
 	// GetExecutionHistory 获取执行历史
 	GetExecutionHistory(ctx context.Context, instanceID string) ([]*database.WorkflowExecutionHistory, error)
 	
-	// GetPendingApprovals 获取待审批任务
-	GetPendingApprovals(ctx context.Context, userID uint) ([]*database.WorkflowPendingApproval, error)
+	// GetPendingApprovals 按条件分页获取未完成的待审批任务及总数
+	GetPendingApprovals(ctx context.Context, filter *PendingApprovalFilter) ([]*database.WorkflowPendingApproval, int64, error)
+	
+	// GetPendingApprovalsWithEmployee 按条件获取未完成的待审批任务，批量加载流程实例、发起人和业务ID对应的员工
+	GetPendingApprovalsWithEmployee(ctx context.Context, filter *PendingApprovalFilter) ([]*PendingApprovalWithEmployee, error)
+	
+	// CountPendingApprovals 按条件统计未完成的待审批任务数
+	CountPendingApprovals(ctx context.Context, filter *PendingApprovalFilter) (int64, error)
+	
+	// CountPendingApprovalGroups 按流程名称和业务类型分组统计未完成的待审批数及其中的逾期数
+	CountPendingApprovalGroups(ctx context.Context, filter *PendingApprovalFilter) ([]*PendingApprovalGroupCount, error)
 	
 	// CreatePendingApproval 创建待审批任务
 	CreatePendingApproval(ctx context.Context, approval *database.WorkflowPendingApproval) error
@@ -242,6 +545,54 @@ type WorkflowInstanceRepository interface {
 	
 	// GetInstancesByBusinessID 根据业务ID获取实例
 	GetInstancesByBusinessID(ctx context.Context, businessID, businessType string) ([]*database.WorkflowInstance, error)
+
+	// FindRunningByBusiness 获取业务对象运行中的流程实例，没有时返回ErrNotFound
+	FindRunningByBusiness(ctx context.Context, businessType, businessID string) (*database.WorkflowInstance, error)
+	
+	// CountPendingApprovalsByWorkflow 按流程名统计未完成的待审批数量
+	CountPendingApprovalsByWorkflow(ctx context.Context) (map[string]int64, error)
+	
+	// SaveNodeExecutionResult 在同一事务中写入节点的待审批记录、执行历史和实例状态
+	// 写入待审批记录前会清理该节点未完成的旧记录，保证节点重复执行时不产生重复审批
+	SaveNodeExecutionResult(ctx context.Context, instance *database.WorkflowInstance, history *database.WorkflowExecutionHistory, approvals []*database.WorkflowPendingApproval) error
+
+	// SaveApprovalResult 在同一事务中写入审批历史、关闭待审批记录、创建新的待审批记录并更新实例状态
+	// completeInstance为true时关闭实例全部未完成的待审批记录（退回）；
+	// 否则completeAll为true时关闭节点全部未完成的待审批记录，再否则只关闭completeFor中用户的记录
+	SaveApprovalResult(ctx context.Context, instance *database.WorkflowInstance, history *database.WorkflowExecutionHistory, completeInstance, completeAll bool, completeFor []uint, approvals []*database.WorkflowPendingApproval) error
+	
+	// ListInstancesByStatus 按状态列出流程实例
+	ListInstancesByStatus(ctx context.Context, status string) ([]*database.WorkflowInstance, error)
+	
+	// CountOpenPendingApprovals 统计节点未完成的待审批数量
+	CountOpenPendingApprovals(ctx context.Context, instanceID, nodeID string) (int64, error)
+
+	// ListInstances 按条件分页获取流程实例摘要及总数，按启动时间倒序，不加载流程变量等大字段
+	ListInstances(ctx context.Context, filter *WorkflowInstanceFilter) ([]*WorkflowInstanceSummary, int64, error)
+
+	// CountInstances 按条件统计流程实例数，按流程和状态分组，忽略分页参数
+	CountInstances(ctx context.Context, filter *WorkflowInstanceFilter) ([]*WorkflowInstanceCount, error)
+
+	// ListOverdueInstances 列出超过流程截止时间或存在超时待审批的运行中实例
+	ListOverdueInstances(ctx context.Context, now time.Time) ([]*database.WorkflowInstance, error)
+
+	// ExpireInstance 在同一事务中关闭实例全部未完成的待审批记录、写入执行历史并将实例标记为过期
+	// 实例已被审批或取消而不在运行中时返回ErrConcurrentUpdate
+	ExpireInstance(ctx context.Context, instance *database.WorkflowInstance, history *database.WorkflowExecutionHistory) error
+
+	// LockInstance 在事务中以SELECT ... FOR UPDATE锁定实例行后执行fn，fn返回错误时回滚
+	// fn内通过传入的ctx调用本仓库的方法会复用该事务
+	LockInstance(ctx context.Context, instanceID string, fn func(ctx context.Context) error) error
+
+	// CancelInstance 在同一事务中写入取消历史、将实例标记为已取消并关闭未完成的待审批记录
+	// 实例已不在运行中时返回ErrConcurrentUpdate
+	CancelInstance(ctx context.Context, instance *database.WorkflowInstance, history *database.WorkflowExecutionHistory) error
+
+	// VerifyExecutionHistory 按写入顺序校验实例执行历史的哈希链，返回第一处断链
+	VerifyExecutionHistory(ctx context.Context, instanceID string) (*HistoryChainVerification, error)
+
+	// BackfillExecutionHistoryChain 为未写入哈希的历史记录补齐哈希链，返回补齐的实例数和记录数
+	BackfillExecutionHistoryChain(ctx context.Context) (*HistoryChainBackfillReport, error)
 }
 
 // OnboardingHistoryRepository 入职历史仓储接口
@@ -268,19 +619,648 @@ type OnboardingHistoryFilter struct {
 	ToDate     *time.Time
 }
 
+// ResignationRepository 离职申请仓储接口
+type ResignationRepository interface {
+	// Create 创建离职申请
+	Create(ctx context.Context, resignation *database.Resignation) error
+	
+	// Update 更新离职申请
+	Update(ctx context.Context, resignation *database.Resignation) error
+	
+	// GetActiveByEmployeeID 获取员工未结束的离职申请（审批中或交接中）
+	GetActiveByEmployeeID(ctx context.Context, employeeID uint) (*database.Resignation, error)
+	
+	// GetByWorkflowInstanceID 根据工作流实例ID获取离职申请
+	GetByWorkflowInstanceID(ctx context.Context, instanceID string) (*database.Resignation, error)
+}
+
+// TaskTemplateRepository 任务模板仓储接口，模板以树形存储，根节点代表整个模板
+type TaskTemplateRepository interface {
+	// Create 创建模板节点
+	Create(ctx context.Context, node *database.TaskTemplate) error
+	
+	// Update 更新模板节点自身字段，不含技能要求和子节点
+	Update(ctx context.Context, node *database.TaskTemplate) error
+	
+	// GetRoot 获取模板根节点，ID不存在或不是根节点时返回ErrNotFound
+	GetRoot(ctx context.Context, id uint) (*database.TaskTemplate, error)
+	
+	// GetTree 获取模板的全部节点及技能要求，包括根节点
+	GetTree(ctx context.Context, rootID uint) ([]*database.TaskTemplate, error)
+	
+	// ListRoots 分页获取模板根节点
+	ListRoots(ctx context.Context, filter ListFilter) ([]*database.TaskTemplate, int64, error)
+	
+	// ReplaceSkills 整体替换模板节点的技能要求
+	ReplaceSkills(ctx context.Context, templateID uint, skills []*database.TaskTemplateSkill) error
+	
+	// DeleteDescendants 删除模板根节点下的全部子节点及其技能要求
+	DeleteDescendants(ctx context.Context, rootID uint) error
+	
+	// Delete 删除整个模板，包括根节点、子节点和技能要求
+	Delete(ctx context.Context, rootID uint) error
+}
+
+// MilestoneRepository 项目里程碑仓储接口
+type MilestoneRepository interface {
+	// Create 创建里程碑
+	Create(ctx context.Context, milestone *database.Milestone) error
+	
+	// GetByID 根据ID获取里程碑，不存在时返回ErrNotFound
+	GetByID(ctx context.Context, id uint) (*database.Milestone, error)
+	
+	// Update 更新里程碑
+	Update(ctx context.Context, milestone *database.Milestone) error
+	
+	// Delete 删除里程碑，不存在时返回ErrNotFound
+	Delete(ctx context.Context, id uint) error
+	
+	// ListByProject 获取项目的里程碑，按日期排序；from、to不为空时只返回日期在该区间内的里程碑
+	ListByProject(ctx context.Context, projectID uint, from, to *time.Time) ([]*database.Milestone, error)
+}
+
+// TimeEntryRepository 任务工时记录仓储接口
+type TimeEntryRepository interface {
+	// Create 创建工时记录
+	Create(ctx context.Context, entry *database.TimeEntry) error
+	
+	// GetByID 根据ID获取工时记录
+	GetByID(ctx context.Context, id uint) (*database.TimeEntry, error)
+	
+	// Delete 删除工时记录
+	Delete(ctx context.Context, id uint) error
+	
+	// ListByTask 获取任务的全部工时记录，按开始时间排序
+	ListByTask(ctx context.Context, taskID uint) ([]*database.TimeEntry, error)
+	
+	// SumMinutesByTask 统计任务累计工时（分钟）
+	SumMinutesByTask(ctx context.Context, taskID uint) (int64, error)
+	
+	// ListByUserInRange 获取用户在[from, to)时间段内的工时记录，预加载任务信息
+	ListByUserInRange(ctx context.Context, userID uint, from, to time.Time) ([]*database.TimeEntry, error)
+
+	// SumProjectHoursBySlot 项目任务在[from, to)内开始的工时记录，按开始时间所在时段汇总为小时
+	SumProjectHoursBySlot(ctx context.Context, projectID uint, from, to time.Time) ([]TimeSlotSum, error)
+}
+
+// ArchiveRepository 已结束数据的归档仓储，记录迁移到归档表后不再保留在在用表中
+type ArchiveRepository interface {
+	// ArchiveTasks 在一个事务内将最多limit个完成或取消时间早于before的任务迁移到归档表，返回迁移的任务ID
+	// 仍有未结束子任务的任务暂不归档；评论、附件等关联记录按任务ID保留在原表
+	ArchiveTasks(ctx context.Context, before time.Time, limit int, archivedAt time.Time) ([]uint, error)
+
+	// ArchiveWorkflowInstances 在一个事务内将最多limit个结束时间早于before的流程实例迁移到归档表，返回迁移的实例ID
+	// 执行历史和待审批记录保留在原表
+	ArchiveWorkflowInstances(ctx context.Context, before time.Time, limit int, archivedAt time.Time) ([]string, error)
+
+	// DeleteArchivedTask 永久删除归档任务，不存在时返回ErrNotFound
+	DeleteArchivedTask(ctx context.Context, taskID uint) error
+
+	// DeleteArchivedWorkflowInstance 永久删除归档流程实例，不存在时返回ErrNotFound
+	DeleteArchivedWorkflowInstance(ctx context.Context, instanceID string) error
+}
+
+// EmployeeCapacityRepository 员工容量日历仓储接口，日期范围均为闭区间
+type EmployeeCapacityRepository interface {
+	// Create 创建容量条目
+	Create(ctx context.Context, capacity *database.EmployeeCapacity) error
+	
+	// GetByID 根据ID获取容量条目，不存在时返回ErrNotFound
+	GetByID(ctx context.Context, id uint) (*database.EmployeeCapacity, error)
+	
+	// Update 更新容量条目
+	Update(ctx context.Context, capacity *database.EmployeeCapacity) error
+	
+	// Delete 删除容量条目，不存在时返回ErrNotFound
+	Delete(ctx context.Context, id uint) error
+	
+	// ListByEmployee 获取员工的全部容量条目，按开始日期排序
+	ListByEmployee(ctx context.Context, employeeID uint) ([]*database.EmployeeCapacity, error)
+	
+	// ListOverlapping 获取员工与[from, to]日期范围重叠的容量条目
+	ListOverlapping(ctx context.Context, employeeID uint, from, to time.Time) ([]*database.EmployeeCapacity, error)
+	
+	// ListLeavesToApply 获取day当天处于请假期间但尚未生效的请假条目
+	ListLeavesToApply(ctx context.Context, day time.Time) ([]*database.EmployeeCapacity, error)
+	
+	// ListLeavesToRestore 获取已生效且在day之前结束的请假条目
+	ListLeavesToRestore(ctx context.Context, day time.Time) ([]*database.EmployeeCapacity, error)
+}
+
+// AccountActivationTokenRepository 账号激活令牌仓储接口
+type AccountActivationTokenRepository interface {
+	// Create 创建激活令牌
+	Create(ctx context.Context, token *database.AccountActivationToken) error
+	
+	// GetByTokenHash 根据令牌摘要获取激活令牌
+	GetByTokenHash(ctx context.Context, tokenHash string) (*database.AccountActivationToken, error)
+	
+	// MarkUsed 将未使用的令牌标记为已使用，令牌已被使用时返回false
+	MarkUsed(ctx context.Context, id uint, usedAt time.Time) (bool, error)
+	
+	// DeleteUnusedByUserID 删除用户全部未使用的令牌，重新发送激活邮件前调用
+	DeleteUnusedByUserID(ctx context.Context, userID uint) error
+	
+	// HasUsedByUserID 用户是否已通过令牌完成激活
+	HasUsedByUserID(ctx context.Context, userID uint) (bool, error)
+}
+
+// DepartmentApprovalChainRepository 部门审批链仓储接口
+type DepartmentApprovalChainRepository interface {
+	// Create 创建审批链
+	Create(ctx context.Context, chain *database.DepartmentApprovalChain) error
+	
+	// GetByID 根据ID获取审批链
+	GetByID(ctx context.Context, id uint) (*database.DepartmentApprovalChain, error)
+	
+	// GetByDepartmentAndBusinessType 获取部门在业务类型下的审批链
+	GetByDepartmentAndBusinessType(ctx context.Context, departmentID uint, businessType string) (*database.DepartmentApprovalChain, error)
+	
+	// ListByDepartment 获取部门的全部审批链
+	ListByDepartment(ctx context.Context, departmentID uint) ([]*database.DepartmentApprovalChain, error)
+	
+	// Update 更新审批链
+	Update(ctx context.Context, chain *database.DepartmentApprovalChain) error
+	
+	// Delete 删除审批链
+	Delete(ctx context.Context, id uint) error
+}
+
+// PendingApprovalWithEmployee 待审批任务及其关联数据
+type PendingApprovalWithEmployee struct {
+	Approval  *database.WorkflowPendingApproval
+	Instance  *database.WorkflowInstance // 流程实例不存在时为nil
+	Requester *database.User             // 流程发起人，不存在时为nil
+	// Employee 业务ID为employee_<id>时对应的员工，预加载用户以及含已删除的部门和职位；无法解析或员工不存在时为nil
+	Employee *database.Employee
+}
+
+// PendingApprovalFilter 待审批查询条件，PageSize为0时不分页
+type PendingApprovalFilter struct {
+	UserID         uint
+	WorkflowName   string
+	BusinessType   string
+	DeadlineBefore *time.Time // 截止时间早于该时间，无截止时间的记录不返回
+	SortBy         string     // created_at、priority 或 urgency，默认按优先级
+	SortDesc       bool
+	Page           int
+	PageSize       int
+	OverdueAt      time.Time // 截止时间早于该时间的记录视为逾期，用于urgency排序和逾期统计
+}
+
+// PendingApprovalGroupCount 按流程名称和业务类型分组的待审批数
+type PendingApprovalGroupCount struct {
+	WorkflowName string
+	BusinessType string
+	Count        int64
+	OverdueCount int64
+}
+
+// WorkflowInstanceFilter 流程实例查询条件，PageSize为0时不分页
+type WorkflowInstanceFilter struct {
+	Status          string
+	WorkflowID      string
+	WorkflowName    string // 按启动时绑定的流程定义版本名称模糊匹配
+	BusinessType    string
+	BusinessID      string
+	StartedBy       uint
+	StartedFrom     *time.Time // 启动时间不早于该时间
+	StartedTo       *time.Time // 启动时间早于该时间
+	IncludeArchived bool       // 同时查询已归档的实例
+	Page            int
+	PageSize        int
+}
+
+// TimeSlotSum 按15分钟时段汇总的数值，Start为时段开始时间，调用方按所需时区折算为日期
+type TimeSlotSum struct {
+	Start time.Time
+	Value float64
+}
+
+// WorkflowInstanceSummary 流程实例列表行
+type WorkflowInstanceSummary struct {
+	InstanceID          string
+	WorkflowID          string
+	WorkflowName        string
+	DefinitionVersionID uint
+	BusinessID          string
+	BusinessType        string
+	Status              string
+	CurrentNodes        database.JSONField
+	StartedBy           uint
+	StartedAt           time.Time
+	CompletedAt         *time.Time
+	Deadline            *time.Time
+	ArchivedAt          *time.Time // 未归档时为空
+}
+
+// WorkflowInstanceCount 按流程和状态分组的实例数
+type WorkflowInstanceCount struct {
+	WorkflowID   string
+	WorkflowName string
+	Status       string
+	Count        int64
+}
+
+// HistoryChainBreak 执行历史哈希链的断链位置
+type HistoryChainBreak struct {
+	HistoryID string
+	Position  int // 在实例历史中的序号，从1开始
+	Reason    string
+}
+
+// HistoryChainVerification 执行历史哈希链校验结果
+type HistoryChainVerification struct {
+	InstanceID  string
+	Checked     int // 断链前校验通过的记录数
+	Total       int
+	FirstBroken *HistoryChainBreak // 为空表示哈希链完整
+}
+
+// HistoryChainBackfillReport 执行历史哈希链回填结果
+type HistoryChainBackfillReport struct {
+	Instances int
+	Sealed    int
+}
+
+// ReportFilter 报表导出过滤条件，时间范围为[From, To)
+type ReportFilter struct {
+	From         time.Time
+	To           time.Time
+	DepartmentID *uint // 按负责人所在部门过滤
+}
+
+// TaskReportRow 任务报表行
+type TaskReportRow struct {
+	ID             uint
+	Title          string
+	Status         string
+	Priority       string
+	AssigneeName   string
+	DepartmentName string
+	DueDate        *time.Time
+	CreatedAt      time.Time
+	CompletedAt    *time.Time
+	EstimatedHours float64
+	ActualHours    float64
+}
+
+// AssignmentReportRow 任务分配报表行
+type AssignmentReportRow struct {
+	ID             uint
+	TaskID         uint
+	TaskTitle      string
+	AssigneeName   string
+	DepartmentName string
+	AssignerName   string
+	Method         string
+	Status         string
+	ApproverName   string
+	AssignedAt     time.Time
+	ApprovedAt     *time.Time
+}
+
+// SkillDemandRow 技能需求分组行：部门开放任务对某技能某等级的需求
+type SkillDemandRow struct {
+	SkillID   uint
+	SkillName string // 技能已删除时为空
+	Category  string
+	Level     int   // 所需等级
+	TaskCount int64 // 需要该等级的任务数
+}
+
+// SkillSupplyRow 技能供给分组行：部门员工在某技能某等级上的人数
+type SkillSupplyRow struct {
+	SkillID       uint
+	Level         int
+	EmployeeCount int64
+}
+
+// ReportRepository 报表查询仓储接口，按ID升序分批读取以支持流式导出
+type ReportRepository interface {
+	// CountTasks 统计创建时间在范围内的任务数
+	CountTasks(ctx context.Context, filter *ReportFilter) (int64, error)
+	
+	// ListTasks 获取ID大于afterID的下一批任务报表行
+	ListTasks(ctx context.Context, filter *ReportFilter, afterID uint, limit int) ([]*TaskReportRow, error)
+	
+	// CountAssignments 统计分配时间在范围内的分配记录数
+	CountAssignments(ctx context.Context, filter *ReportFilter) (int64, error)
+	
+	// ListAssignments 获取ID大于afterID的下一批分配报表行
+	ListAssignments(ctx context.Context, filter *ReportFilter, afterID uint, limit int) ([]*AssignmentReportRow, error)
+	
+	// ListSkillDemand 按技能和所需等级分组统计部门未结束任务的技能需求
+	// 部门的任务指所属项目归该部门或创建人在该部门的任务
+	ListSkillDemand(ctx context.Context, departmentID uint) ([]*SkillDemandRow, error)
+	
+	// ListSkillSupply 按技能和等级分组统计部门在职员工的技能人数，只统计skillIDs中的技能
+	ListSkillSupply(ctx context.Context, departmentID uint, skillIDs []uint) ([]*SkillSupplyRow, error)
+}
+
+// NotificationPreferenceRepository 通知偏好仓储接口
+type NotificationPreferenceRepository interface {
+	// ListByUser 获取用户已设置的全部通知偏好
+	ListByUser(ctx context.Context, userID uint) ([]*database.NotificationPreference, error)
+	
+	// Get 获取用户在某类别和渠道上的偏好，未设置时返回ErrNotFound
+	Get(ctx context.Context, userID uint, category, channel string) (*database.NotificationPreference, error)
+	
+	// Upsert 按用户、类别和渠道写入偏好，已存在时更新启用状态
+	Upsert(ctx context.Context, preferences []*database.NotificationPreference) error
+}
+
+// EmailOutboxRepository 邮件发件箱仓储接口
+type EmailOutboxRepository interface {
+	Create(ctx context.Context, message *database.EmailOutbox) error
+	Update(ctx context.Context, message *database.EmailOutbox) error
+	
+	// ListDue 获取到期待发送的邮件，按next_retry_at升序
+	ListDue(ctx context.Context, now time.Time, limit int) ([]*database.EmailOutbox, error)
+	
+	// List 按状态分页获取邮件及总数，按ID倒序
+	List(ctx context.Context, filter *EmailOutboxFilter) ([]*database.EmailOutbox, int64, error)
+}
+
+// EmailOutboxFilter 发件箱查询条件
+type EmailOutboxFilter struct {
+	Status   string
+	UserID   uint
+	Page     int
+	PageSize int
+}
+
+// WebhookEndpointRepository Webhook端点仓储接口
+type WebhookEndpointRepository interface {
+	Create(ctx context.Context, endpoint *database.WebhookEndpoint) error
+	
+	// GetByID 根据ID获取端点，不存在时返回ErrNotFound
+	GetByID(ctx context.Context, id uint) (*database.WebhookEndpoint, error)
+	
+	// List 获取全部端点，按ID排序
+	List(ctx context.Context) ([]*database.WebhookEndpoint, error)
+	
+	// ListEnabled 获取已启用的端点
+	ListEnabled(ctx context.Context) ([]*database.WebhookEndpoint, error)
+	
+	Update(ctx context.Context, endpoint *database.WebhookEndpoint) error
+	
+	// Delete 删除端点，不存在时返回ErrNotFound
+	Delete(ctx context.Context, id uint) error
+}
+
+// WebhookDeliveryRepository Webhook投递记录仓储接口
+type WebhookDeliveryRepository interface {
+	// CreateBatch 批量写入待投递记录
+	CreateBatch(ctx context.Context, deliveries []*database.WebhookDelivery) error
+	
+	Update(ctx context.Context, delivery *database.WebhookDelivery) error
+	
+	// ListDue 获取到期待投递的记录，按next_retry_at升序
+	ListDue(ctx context.Context, now time.Time, limit int) ([]*database.WebhookDelivery, error)
+	
+	// CreateAttempt 记录一次发送尝试
+	CreateAttempt(ctx context.Context, attempt *database.WebhookDeliveryAttempt) error
+	
+	// List 按条件分页获取投递记录及总数，按ID倒序，预加载各次发送尝试
+	List(ctx context.Context, filter *WebhookDeliveryFilter) ([]*database.WebhookDelivery, int64, error)
+}
+
+// WebhookDeliveryFilter 投递记录查询条件，EndpointID必填，其余为空时不过滤
+type WebhookDeliveryFilter struct {
+	EndpointID uint
+	Status     string
+	EventType  string
+	Page       int
+	PageSize   int
+}
+
+// CounterRepository 命名计数器仓储接口
+type CounterRepository interface {
+	// Next 锁定计数器行并加一，返回分配的序号；计数器不存在时从1开始
+	Next(ctx context.Context, name string) (int64, error)
+}
+
 // RepositoryManager 仓储管理器接口
+// TaskWatcherRepository 任务关注者仓储接口
+type TaskWatcherRepository interface {
+	// Add 添加关注者，已关注时不重复添加
+	Add(ctx context.Context, watcher *database.TaskWatcher) error
+	
+	// Remove 取消关注，未关注时返回ErrNotFound
+	Remove(ctx context.Context, taskID, userID uint) error
+	
+	// ListByTask 获取任务的关注者，按关注时间排序
+	ListByTask(ctx context.Context, taskID uint) ([]*database.TaskWatcher, error)
+	
+	// CountByTask 统计任务的关注者数量
+	CountByTask(ctx context.Context, taskID uint) (int64, error)
+	
+	// Exists 判断用户是否已关注任务
+	Exists(ctx context.Context, taskID, userID uint) (bool, error)
+}
+
+// TaskEscalationRepository 任务优先级老化规则和升级记录仓储接口
+type TaskEscalationRepository interface {
+	// CreateRule 创建规则
+	CreateRule(ctx context.Context, rule *database.TaskEscalationRule) error
+	
+	// GetRule 根据ID获取规则，不存在时返回ErrNotFound
+	GetRule(ctx context.Context, id uint) (*database.TaskEscalationRule, error)
+	
+	// UpdateRule 更新规则
+	UpdateRule(ctx context.Context, rule *database.TaskEscalationRule) error
+	
+	// DeleteRule 删除规则及其升级记录，不存在时返回ErrNotFound
+	DeleteRule(ctx context.Context, id uint) error
+	
+	// ListRules 获取全部规则，enabledOnly为true时只返回启用的规则，按阈值从小到大排序
+	ListRules(ctx context.Context, enabledOnly bool) ([]*database.TaskEscalationRule, error)
+	
+	// ListCandidates 获取指定优先级、在createdBefore之前创建且仍待分配、尚未被该规则升级的任务，按创建时间排序
+	ListCandidates(ctx context.Context, ruleID uint, priority string, createdBefore time.Time, limit int) ([]*database.Task, error)
+	
+	// Record 记录升级，该规则已升级过该任务时不做修改并返回false
+	Record(ctx context.Context, escalation *database.TaskEscalation) (bool, error)
+}
+
+// EmployeeChangeHistoryRepository 员工职位变更历史仓储接口
+type EmployeeChangeHistoryRepository interface {
+	// Create 创建变更记录
+	Create(ctx context.Context, history *database.EmployeeChangeHistory) error
+	
+	// ListByEmployeeID 获取员工的变更记录，按时间倒序
+	ListByEmployeeID(ctx context.Context, employeeID uint) ([]*database.EmployeeChangeHistory, error)
+}
+
+// TaskDueReminderRepository 任务截止提醒记录仓储接口
+type TaskDueReminderRepository interface {
+	// ListDueSoon 获取指定优先级、已分配或进行中、截止时间在(now, until]内的任务，until为now加上提前量后的时刻，
+	// 已收到该提前量或更近提前量提醒的任务不返回，按截止时间排序
+	ListDueSoon(ctx context.Context, priority string, leadHours int, now, until time.Time, limit int) ([]*database.Task, error)
+	
+	// ListOverdue 获取已分配或进行中、截止时间不晚于now且尚未发送逾期提醒的任务，按截止时间排序
+	ListOverdue(ctx context.Context, now time.Time, limit int) ([]*database.Task, error)
+	
+	// Record 记录提醒，该任务已有相同提前量的提醒时不做修改并返回false
+	Record(ctx context.Context, reminder *database.TaskDueReminder) (bool, error)
+}
+
+// HolidayRepository 节假日仓储接口
+type HolidayRepository interface {
+	// Create 创建节假日
+	Create(ctx context.Context, holiday *database.Holiday) error
+	
+	// GetByID 根据ID获取节假日，不存在时返回ErrNotFound
+	GetByID(ctx context.Context, id uint) (*database.Holiday, error)
+	
+	// GetByDate 获取指定日期的节假日，不存在时返回ErrNotFound
+	GetByDate(ctx context.Context, date time.Time) (*database.Holiday, error)
+	
+	// Update 更新节假日
+	Update(ctx context.Context, holiday *database.Holiday) error
+	
+	// Delete 删除节假日，不存在时返回ErrNotFound
+	Delete(ctx context.Context, id uint) error
+	
+	// List 获取节假日，year为0时返回全部年份，按日期排序
+	List(ctx context.Context, year int) ([]*database.Holiday, error)
+}
+
+// TaskLabelRepository 任务标签仓储接口
+type TaskLabelRepository interface {
+	// Create 创建标签
+	Create(ctx context.Context, label *database.TaskLabel) error
+	
+	// GetByID 根据ID获取标签，不存在时返回ErrNotFound
+	GetByID(ctx context.Context, id uint) (*database.TaskLabel, error)
+	
+	// GetByName 按名称不区分大小写查找标签，不存在时返回ErrNotFound
+	GetByName(ctx context.Context, name string) (*database.TaskLabel, error)
+	
+	// GetByIDs 批量获取标签，不存在的ID被忽略
+	GetByIDs(ctx context.Context, ids []uint) ([]*database.TaskLabel, error)
+	
+	// ListWithTaskCounts 获取全部标签及各标签关联的任务数，按名称排序
+	ListWithTaskCounts(ctx context.Context) ([]*TaskLabelCount, error)
+	
+	// ListByTask 获取任务的标签，按名称排序
+	ListByTask(ctx context.Context, taskID uint) ([]*database.TaskLabel, error)
+	
+	// ReplaceTaskLabels 在一个事务内将任务的标签替换为给定集合
+	ReplaceTaskLabels(ctx context.Context, taskID uint, labelIDs []uint) error
+	
+	// AttachToTasks 在一个事务内为多个任务添加标签，已有该标签的任务跳过，返回新增关联数
+	AttachToTasks(ctx context.Context, labelID uint, taskIDs []uint) (int64, error)
+	
+	// Delete 在一个事务内解除标签与全部任务的关联并删除标签，返回解除关联的任务数
+	// 标签不存在时返回ErrNotFound
+	Delete(ctx context.Context, id uint) (int64, error)
+}
+
+// TaskLabelCount 任务标签及其关联的任务数
+type TaskLabelCount struct {
+	database.TaskLabel
+	TaskCount int64
+}
+
+// TaskAttachmentRepository 任务附件仓储接口
+type TaskAttachmentRepository interface {
+	// GetByIDs 批量获取附件，不存在的ID被忽略
+	GetByIDs(ctx context.Context, ids []uint) ([]*database.TaskAttachment, error)
+}
+
+// TaskCommentRepository 任务评论仓储接口
+type TaskCommentRepository interface {
+	// Create 创建评论
+	Create(ctx context.Context, comment *database.TaskComment) error
+	
+	// GetByID 根据ID获取评论
+	GetByID(ctx context.Context, id uint) (*database.TaskComment, error)
+	
+	// ListByTask 获取任务的全部评论，按创建时间排序
+	ListByTask(ctx context.Context, taskID uint) ([]*database.TaskComment, error)
+}
+
 type RepositoryManager interface {
 	UserRepository() UserRepository
 	RoleRepository() RoleRepository
 	
 	// OnboardingHistoryRepository 入职历史仓储接口
 	OnboardingHistoryRepository() OnboardingHistoryRepository
+	
+	// EmployeeChangeHistoryRepository 员工职位变更历史仓储接口
+	EmployeeChangeHistoryRepository() EmployeeChangeHistoryRepository
+	
+	// ResignationRepository 离职申请仓储接口
+	ResignationRepository() ResignationRepository
+	
+	// TimeEntryRepository 任务工时记录仓储接口
+	TimeEntryRepository() TimeEntryRepository
+	
+	// EmployeeCapacityRepository 员工容量日历仓储接口
+	EmployeeCapacityRepository() EmployeeCapacityRepository
+
+	// ArchiveRepository 归档仓储接口
+	ArchiveRepository() ArchiveRepository
+	
+	// TaskWatcherRepository 任务关注者仓储接口
+	TaskWatcherRepository() TaskWatcherRepository
+	
+	// TaskCommentRepository 任务评论仓储接口
+	TaskCommentRepository() TaskCommentRepository
+	
+	// TaskEscalationRepository 任务优先级老化仓储接口
+	TaskEscalationRepository() TaskEscalationRepository
+	
+	// TaskDueReminderRepository 任务截止提醒仓储接口
+	TaskDueReminderRepository() TaskDueReminderRepository
+	
+	// HolidayRepository 节假日仓储接口
+	HolidayRepository() HolidayRepository
+	
+	// TaskLabelRepository 任务标签仓储接口
+	TaskLabelRepository() TaskLabelRepository
+	
+	// TaskAttachmentRepository 任务附件仓储接口
+	TaskAttachmentRepository() TaskAttachmentRepository
+	
+	// AccountActivationTokenRepository 账号激活令牌仓储接口
+	AccountActivationTokenRepository() AccountActivationTokenRepository
+	
+	// DepartmentApprovalChainRepository 部门审批链仓储接口
+	DepartmentApprovalChainRepository() DepartmentApprovalChainRepository
+	
+	// ReportRepository 报表查询仓储接口
+	ReportRepository() ReportRepository
+	
+	// NotificationPreferenceRepository 通知偏好仓储接口
+	NotificationPreferenceRepository() NotificationPreferenceRepository
+	
+	// EmailOutboxRepository 邮件发件箱仓储接口
+	EmailOutboxRepository() EmailOutboxRepository
+	
+	// WebhookEndpointRepository Webhook端点仓储接口
+	WebhookEndpointRepository() WebhookEndpointRepository
+	
+	// WebhookDeliveryRepository Webhook投递记录仓储接口
+	WebhookDeliveryRepository() WebhookDeliveryRepository
+	
+	// CounterRepository 命名计数器仓储接口
+	CounterRepository() CounterRepository
+	
+	// TaskTemplateRepository 任务模板仓储接口
+	TaskTemplateRepository() TaskTemplateRepository
+	
+	// MilestoneRepository 项目里程碑仓储接口
+	MilestoneRepository() MilestoneRepository
 	TaskRepository() TaskRepository
 	EmployeeRepository() EmployeeRepository
 	AssignmentRepository() AssignmentRepository
 	NotificationRepository() NotificationRepository
 	WorkflowRepository() WorkflowRepository
 	SkillRepository() SkillRepository
+	SkillCategoryRepository() SkillCategoryRepository
 	PermissionRepository() PermissionRepository
 	DepartmentRepository() DepartmentRepository
 	PositionRepository() PositionRepository
@@ -292,6 +1272,7 @@ type RepositoryManager interface {
 	// 权限分配相关仓储
 	PermissionTemplateRepository() PermissionTemplateRepository
 	PermissionRuleRepository() PermissionRuleRepository
+	PermissionRuleExecutionRepository() PermissionRuleExecutionRepository
 	PermissionAssignmentRepository() PermissionAssignmentRepository
 	PermissionAssignmentHistoryRepository() PermissionAssignmentHistoryRepository
 	OnboardingPermissionConfigRepository() OnboardingPermissionConfigRepository
@@ -0,0 +1,71 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+// AccountActivationTokenRepositoryImpl 账号激活令牌仓储MySQL实现
+type AccountActivationTokenRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewAccountActivationTokenRepository 创建账号激活令牌仓储
+func NewAccountActivationTokenRepository(db *gorm.DB) repository.AccountActivationTokenRepository {
+	return &AccountActivationTokenRepositoryImpl{db: db}
+}
+
+// Create 创建激活令牌
+func (r *AccountActivationTokenRepositoryImpl) Create(ctx context.Context, token *database.AccountActivationToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+// GetByTokenHash 根据令牌摘要获取激活令牌
+func (r *AccountActivationTokenRepositoryImpl) GetByTokenHash(ctx context.Context, tokenHash string) (*database.AccountActivationToken, error) {
+	var token database.AccountActivationToken
+	err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// MarkUsed 将未使用的令牌标记为已使用，条件更新保证令牌只能使用一次
+func (r *AccountActivationTokenRepositoryImpl) MarkUsed(ctx context.Context, id uint, usedAt time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&database.AccountActivationToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", usedAt)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// DeleteUnusedByUserID 删除用户全部未使用的令牌
+func (r *AccountActivationTokenRepositoryImpl) DeleteUnusedByUserID(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Delete(&database.AccountActivationToken{}).Error
+}
+
+// HasUsedByUserID 用户是否已通过令牌完成激活
+func (r *AccountActivationTokenRepositoryImpl) HasUsedByUserID(ctx context.Context, userID uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&database.AccountActivationToken{}).
+		Where("user_id = ? AND used_at IS NOT NULL", userID).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
@@ -0,0 +1,146 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+	"taskmanage/internal/workflow"
+	"taskmanage/pkg/logger"
+)
+
+// finishedTaskStatuses 可归档的任务状态
+var finishedTaskStatuses = []string{"completed", "cancelled"}
+
+// finishedInstanceStatuses 可归档的流程实例状态
+var finishedInstanceStatuses = []string{
+	string(workflow.StatusCompleted),
+	string(workflow.StatusCancelled),
+	string(workflow.StatusFailed),
+	string(workflow.StatusExpired),
+}
+
+// ArchiveRepositoryImpl 归档仓储实现
+type ArchiveRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewArchiveRepository 创建归档仓储
+func NewArchiveRepository(db *gorm.DB) repository.ArchiveRepository {
+	return &ArchiveRepositoryImpl{db: db}
+}
+
+// ArchiveTasks 在一个事务内将最多limit个完成或取消时间早于before的任务迁移到归档表
+// 取消的任务没有完成时间，按最后更新时间计算；仍有未结束子任务的任务暂不归档
+func (r *ArchiveRepositoryImpl) ArchiveTasks(ctx context.Context, before time.Time, limit int, archivedAt time.Time) ([]uint, error) {
+	var ids []uint
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var tasks []*database.Task
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("status IN ?", finishedTaskStatuses).
+			Where("COALESCE(completed_at, updated_at) < ?", before).
+			Where("NOT EXISTS (SELECT 1 FROM tasks AS children WHERE children.parent_id = tasks.id AND children.deleted_at IS NULL AND children.status NOT IN ?)", finishedTaskStatuses).
+			Order("id ASC").Limit(limit).
+			Find(&tasks).Error
+		if err != nil || len(tasks) == 0 {
+			return err
+		}
+
+		archives := make([]*database.TaskArchive, 0, len(tasks))
+		for _, task := range tasks {
+			archives = append(archives, database.NewTaskArchive(task, archivedAt))
+			ids = append(ids, task.ID)
+		}
+		if err := tx.Create(&archives).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("id IN ?", ids).Delete(&database.Task{}).Error
+	})
+	if err != nil {
+		logger.Errorf("归档任务失败: %v", err)
+		return nil, fmt.Errorf("归档任务失败: %w", err)
+	}
+	return ids, nil
+}
+
+// ArchiveWorkflowInstances 在一个事务内将最多limit个结束时间早于before的流程实例迁移到归档表
+// 没有结束时间的实例（失败等）按最后更新时间计算
+func (r *ArchiveRepositoryImpl) ArchiveWorkflowInstances(ctx context.Context, before time.Time, limit int, archivedAt time.Time) ([]string, error) {
+	var instanceIDs []string
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var instances []*database.WorkflowInstance
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("status IN ?", finishedInstanceStatuses).
+			Where("COALESCE(completed_at, updated_at) < ?", before).
+			Order("id ASC").Limit(limit).
+			Find(&instances).Error
+		if err != nil || len(instances) == 0 {
+			return err
+		}
+
+		ids := make([]uint, 0, len(instances))
+		archives := make([]*database.WorkflowInstanceArchive, 0, len(instances))
+		for _, instance := range instances {
+			archives = append(archives, &database.WorkflowInstanceArchive{WorkflowInstance: *instance, ArchivedAt: archivedAt})
+			ids = append(ids, instance.ID)
+			instanceIDs = append(instanceIDs, instance.InstanceID)
+		}
+		if err := tx.Create(&archives).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("id IN ?", ids).Delete(&database.WorkflowInstance{}).Error
+	})
+	if err != nil {
+		logger.Errorf("归档流程实例失败: %v", err)
+		return nil, fmt.Errorf("归档流程实例失败: %w", err)
+	}
+	return instanceIDs, nil
+}
+
+// DeleteArchivedTask 永久删除归档任务
+func (r *ArchiveRepositoryImpl) DeleteArchivedTask(ctx context.Context, taskID uint) error {
+	result := r.db.WithContext(ctx).Unscoped().Delete(&database.TaskArchive{}, taskID)
+	if result.Error != nil {
+		return fmt.Errorf("删除归档任务失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// DeleteArchivedWorkflowInstance 永久删除归档流程实例
+func (r *ArchiveRepositoryImpl) DeleteArchivedWorkflowInstance(ctx context.Context, instanceID string) error {
+	result := r.db.WithContext(ctx).Unscoped().
+		Where("instance_id = ?", instanceID).
+		Delete(&database.WorkflowInstanceArchive{})
+	if result.Error != nil {
+		return fmt.Errorf("删除归档流程实例失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// withArchive 以在用表和归档表的UNION ALL作为查询来源，别名与在用表相同，原有带表名前缀的过滤条件不变
+// 两侧按在用表模型的列对齐，在用表一侧排除已软删除的记录并补空的archived_at
+func withArchive(query *gorm.DB, model interface{}, archiveTable string) *gorm.DB {
+	stmt := &gorm.Statement{DB: query}
+	if err := stmt.Parse(model); err != nil {
+		query.AddError(err)
+		return query
+	}
+	table := stmt.Schema.Table
+	columns := strings.Join(stmt.Schema.DBNames, ", ")
+	source := query.Session(&gorm.Session{NewDB: true}).Raw(fmt.Sprintf(
+		"SELECT %s, NULL AS archived_at FROM %s WHERE deleted_at IS NULL UNION ALL SELECT %s, archived_at FROM %s",
+		columns, table, columns, archiveTable))
+	return query.Table(fmt.Sprintf("(?) AS %s", table), source)
+}
@@ -24,6 +24,11 @@ func NewAssignmentRepository(db *gorm.DB) repository.AssignmentRepository {
 	}
 }
 
+// Update 按版本号更新分配记录，记录已被其他请求修改时返回ErrConflict
+func (r *AssignmentRepositoryImpl) Update(ctx context.Context, assignment *database.Assignment) error {
+	return updateVersioned(ctx, r.db, assignment, assignment.ID, &assignment.Version)
+}
+
 // GetByTask 根据任务ID获取分配记录
 func (r *AssignmentRepositoryImpl) GetByTask(ctx context.Context, taskID uint) ([]*database.Assignment, error) {
 	var assignments []*database.Assignment
@@ -107,6 +112,7 @@ func (r *AssignmentRepositoryImpl) ApproveAssignment(ctx context.Context, assign
 			"approver_id": approverID,
 			"approved_at": &now,
 			"reason":      reason,
+			"version":     gorm.Expr("version + 1"),
 		})
 	
 	if result.Error != nil {
@@ -131,6 +137,7 @@ func (r *AssignmentRepositoryImpl) RejectAssignment(ctx context.Context, assignm
 			"approver_id": approverID,
 			"approved_at": &now,
 			"reason":      reason,
+			"version":     gorm.Expr("version + 1"),
 		})
 	
 	if result.Error != nil {
@@ -145,18 +152,129 @@ func (r *AssignmentRepositoryImpl) RejectAssignment(ctx context.Context, assignm
 	return nil
 }
 
-// GetAssignmentHistory 获取分配历史记录
-func (r *AssignmentRepositoryImpl) GetAssignmentHistory(ctx context.Context, taskID uint) ([]*database.Assignment, error) {
+// GetAssignmentHistory 分页获取分配历史记录，按创建时间倒序
+func (r *AssignmentRepositoryImpl) GetAssignmentHistory(ctx context.Context, taskID uint, page, pageSize int) ([]*database.Assignment, int64, error) {
 	var assignments []*database.Assignment
-	if err := r.db.WithContext(ctx).
-		Where("task_id = ?", taskID).
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&database.Assignment{}).Where("task_id = ?", taskID)
+	if err := query.Count(&total).Error; err != nil {
+		logger.Errorf("统计分配历史记录失败: %v", err)
+		return nil, 0, fmt.Errorf("统计分配历史记录失败: %w", err)
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.
 		Order("created_at DESC").
+		Offset(offset).
+		Limit(pageSize).
 		Preload("Assignee").
 		Preload("Assigner").
 		Preload("Approver").
 		Find(&assignments).Error; err != nil {
 		logger.Errorf("查询分配历史记录失败: %v", err)
-		return nil, fmt.Errorf("查询分配历史记录失败: %w", err)
+		return nil, 0, fmt.Errorf("查询分配历史记录失败: %w", err)
 	}
-	return assignments, nil
+	return assignments, total, nil
+}
+
+// approvalSecondsExpr 分配时间到审批时间的秒数，未审批时为NULL，AVG时自动忽略；PostgreSQL不支持TIMESTAMPDIFF
+func (r *AssignmentRepositoryImpl) approvalSecondsExpr() string {
+	if r.db.Dialector.Name() == database.DriverPostgres {
+		return "EXTRACT(EPOCH FROM (assignments.approved_at - assignments.assigned_at))"
+	}
+	return "TIMESTAMPDIFF(SECOND, assignments.assigned_at, assignments.approved_at)"
+}
+
+// CountGroupByMethod 按分配方式统计分配记录数
+func (r *AssignmentRepositoryImpl) CountGroupByMethod(ctx context.Context, filter *repository.AssignmentStatsFilter) (map[string]int64, error) {
+	counts, err := r.countGroupBy(ctx, filter, "assignments.method")
+	if err != nil {
+		logger.Errorf("按分配方式统计分配记录失败: %v", err)
+		return nil, fmt.Errorf("按分配方式统计分配记录失败: %w", err)
+	}
+	return counts, nil
+}
+
+// CountGroupByStatus 按状态统计分配记录数
+func (r *AssignmentRepositoryImpl) CountGroupByStatus(ctx context.Context, filter *repository.AssignmentStatsFilter) (map[string]int64, error) {
+	counts, err := r.countGroupBy(ctx, filter, "assignments.status")
+	if err != nil {
+		logger.Errorf("按状态统计分配记录失败: %v", err)
+		return nil, fmt.Errorf("按状态统计分配记录失败: %w", err)
+	}
+	return counts, nil
+}
+
+// countGroupBy 按指定列分组计数
+func (r *AssignmentRepositoryImpl) countGroupBy(ctx context.Context, filter *repository.AssignmentStatsFilter, column string) (map[string]int64, error) {
+	var rows []struct {
+		Value string
+		Count int64
+	}
+	if err := r.statsQuery(ctx, filter).
+		Select(column + " AS value, COUNT(*) AS count").
+		Group(column).
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Value] = row.Count
+	}
+	return counts, nil
+}
+
+// GetApprovalStats 统计已审批的分配记录，通过和拒绝都会写入审批时间
+func (r *AssignmentRepositoryImpl) GetApprovalStats(ctx context.Context, filter *repository.AssignmentStatsFilter) (*repository.AssignmentApprovalStats, error) {
+	var stats repository.AssignmentApprovalStats
+	if err := r.statsQuery(ctx, filter).
+		Select(`COUNT(*) AS decided,
+			COALESCE(SUM(CASE WHEN assignments.status = 'rejected' THEN 1 ELSE 0 END), 0) AS rejected,
+			COALESCE(AVG(`+r.approvalSecondsExpr()+`), 0) AS avg_approval_seconds`).
+		Where("assignments.approved_at IS NOT NULL AND assignments.status IN ?", []string{"approved", "rejected"}).
+		Scan(&stats).Error; err != nil {
+		logger.Errorf("统计分配审批数据失败: %v", err)
+		return nil, fmt.Errorf("统计分配审批数据失败: %w", err)
+	}
+	return &stats, nil
+}
+
+// CountGroupByDepartment 按被分配人所在部门统计分配记录，按记录数从多到少排序
+func (r *AssignmentRepositoryImpl) CountGroupByDepartment(ctx context.Context, filter *repository.AssignmentStatsFilter) ([]*repository.AssignmentDepartmentStats, error) {
+	var rows []*repository.AssignmentDepartmentStats
+	if err := r.statsQuery(ctx, filter).
+		Joins("LEFT JOIN departments ON departments.id = employees.department_id").
+		Select(`employees.department_id, COALESCE(MAX(departments.name), '') AS department_name, COUNT(*) AS total,
+			COALESCE(SUM(CASE WHEN assignments.status = 'approved' THEN 1 ELSE 0 END), 0) AS approved,
+			COALESCE(SUM(CASE WHEN assignments.status = 'rejected' THEN 1 ELSE 0 END), 0) AS rejected,
+			COALESCE(SUM(CASE WHEN assignments.status = 'pending' THEN 1 ELSE 0 END), 0) AS pending,
+			COALESCE(AVG(CASE WHEN assignments.status IN ('approved', 'rejected') THEN `+r.approvalSecondsExpr()+` END), 0) AS avg_approval_seconds`).
+		Group("employees.department_id").
+		Order("total DESC").
+		Scan(&rows).Error; err != nil {
+		logger.Errorf("按部门统计分配记录失败: %v", err)
+		return nil, fmt.Errorf("按部门统计分配记录失败: %w", err)
+	}
+	return rows, nil
+}
+
+// statsQuery 分配统计基础查询，部门取被分配人所在部门
+func (r *AssignmentRepositoryImpl) statsQuery(ctx context.Context, filter *repository.AssignmentStatsFilter) *gorm.DB {
+	query := r.db.WithContext(ctx).Model(&database.Assignment{}).
+		Joins("LEFT JOIN employees ON employees.user_id = assignments.assignee_id AND employees.deleted_at IS NULL")
+	if filter == nil {
+		return query
+	}
+	if filter.From != nil {
+		query = query.Where("assignments.assigned_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("assignments.assigned_at < ?", *filter.To)
+	}
+	if filter.DepartmentID != nil {
+		query = query.Where("employees.department_id = ?", *filter.DepartmentID)
+	}
+	return query
 }
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"taskmanage/internal/repository"
 	"taskmanage/pkg/logger"
@@ -68,6 +69,33 @@ func (r *BaseRepositoryImpl[T]) Update(ctx context.Context, entity *T) error {
 	return nil
 }
 
+// updateVersioned 以乐观锁方式更新实体全部字段，版本号不匹配时返回ErrConflict
+// 更新成功后version加一；关联数据由各自仓储维护，不随实体保存，omit中的列同样不写回
+func updateVersioned(ctx context.Context, db *gorm.DB, entity interface{}, id uint, version *uint, omit ...string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	expected := *version
+	*version = expected + 1
+	result := db.WithContext(ctx).
+		Model(entity).
+		Where("id = ? AND version = ?", id, expected).
+		Select("*").
+		Omit(append([]string{clause.Associations}, omit...)...).
+		Updates(entity)
+	if result.Error != nil {
+		*version = expected
+		logger.Errorf("更新实体失败: %v", result.Error)
+		return fmt.Errorf("更新实体失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		*version = expected
+		return repository.ErrConflict
+	}
+
+	return nil
+}
+
 // Delete 删除实体
 func (r *BaseRepositoryImpl[T]) Delete(ctx context.Context, id uint) error {
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
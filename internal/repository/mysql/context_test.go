@@ -0,0 +1,74 @@
+package mysql
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gormmysql "gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// unreachableDB 指向不可路由地址的连接，查询若不使用请求ctx会一直等到连接超时
+func unreachableDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(gormmysql.New(gormmysql.Config{
+		DSN:                       "taskmanage:taskmanage@tcp(10.255.255.1:3306)/taskmanage?timeout=1s",
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{
+		Logger:               gormlogger.Default.LogMode(gormlogger.Silent),
+		DisableAutomaticPing: true,
+	})
+	require.NoError(t, err)
+	return db
+}
+
+func TestRepositories_CancelledContext(t *testing.T) {
+	db := unreachableDB(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	calls := map[string]func() error{
+		"user.GetByID": func() error {
+			_, err := NewUserRepository(db).GetByID(ctx, 1)
+			return err
+		},
+		"employee.GetByUserID": func() error {
+			_, err := NewEmployeeRepository(db).GetByUserID(ctx, 1)
+			return err
+		},
+		"skill.GetEmployeeSkillsWithLevel": func() error {
+			_, err := NewSkillRepository(db).GetEmployeeSkillsWithLevel(ctx, 1)
+			return err
+		},
+		"project.GetByMemberID": func() error {
+			_, err := NewProjectRepository(db).GetByMemberID(ctx, 1)
+			return err
+		},
+		"role.GetByName": func() error {
+			_, err := NewRoleRepository(db).GetByName(ctx, "admin")
+			return err
+		},
+		"role.AssignPermissions": func() error {
+			return NewRoleRepository(db).AssignPermissions(ctx, 1, []uint{1})
+		},
+		"permission.GetUserPermissions": func() error {
+			_, err := NewPermissionRepository(db).GetUserPermissions(ctx, 1)
+			return err
+		},
+		"notification.AcceptTaskNotification": func() error {
+			return NewNotificationRepository(db).AcceptTaskNotification(ctx, 1, 1, 1, nil)
+		},
+	}
+	for name, call := range calls {
+		t.Run(name, func(t *testing.T) {
+			start := time.Now()
+			err := call()
+			assert.ErrorIs(t, err, context.Canceled)
+			assert.Less(t, time.Since(start), time.Second)
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+// CounterRepositoryImpl 命名计数器仓储实现
+type CounterRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewCounterRepository 创建计数器仓储
+func NewCounterRepository(db *gorm.DB) repository.CounterRepository {
+	return &CounterRepositoryImpl{db: db}
+}
+
+// Next 以SELECT ... FOR UPDATE锁定计数器行后加一，并发调用按加锁顺序得到不同的序号
+// 在外层事务中调用时行锁持有到外层事务结束，回滚时序号一并回滚
+func (r *CounterRepositoryImpl) Next(ctx context.Context, name string) (int64, error) {
+	var value int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&database.Counter{Name: name}).Error; err != nil {
+			return err
+		}
+		var counter database.Counter
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("name = ?", name).First(&counter).Error; err != nil {
+			return err
+		}
+		value = counter.Value + 1
+		return tx.Model(&counter).Update("value", value).Error
+	})
+	if err != nil {
+		return 0, fmt.Errorf("分配序号失败: counter=%s, %w", name, err)
+	}
+	return value, nil
+}
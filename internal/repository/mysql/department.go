@@ -2,6 +2,7 @@ package mysql
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"gorm.io/gorm"
@@ -94,6 +95,18 @@ func (r *DepartmentRepositoryImpl) GetByName(ctx context.Context, name string) (
 	return &department, nil
 }
 
+// GetByCode 根据编码获取部门，不存在时返回ErrNotFound
+func (r *DepartmentRepositoryImpl) GetByCode(ctx context.Context, code string) (*database.Department, error) {
+	var department database.Department
+	if err := r.db.WithContext(ctx).Where("code = ?", code).First(&department).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("根据编码获取部门失败: %w", err)
+	}
+	return &department, nil
+}
+
 // GetByParentID 根据父部门ID获取子部门
 func (r *DepartmentRepositoryImpl) GetByParentID(ctx context.Context, parentID uint) ([]*database.Department, error) {
 	var departments []*database.Department
@@ -145,6 +158,22 @@ func (r *DepartmentRepositoryImpl) UpdateManager(ctx context.Context, department
 		Update("manager_id", managerID).Error
 }
 
+// UpdateAssignmentStrategy 更新部门默认分配策略，空字符串表示使用系统默认策略
+func (r *DepartmentRepositoryImpl) UpdateAssignmentStrategy(ctx context.Context, departmentID uint, strategy string) error {
+	return r.db.WithContext(ctx).
+		Model(&database.Department{}).
+		Where("id = ?", departmentID).
+		Update("assignment_strategy", strategy).Error
+}
+
+// UpdateOnboardingWorkflowType 更新部门入职审批流程类型，空字符串表示使用系统默认流程
+func (r *DepartmentRepositoryImpl) UpdateOnboardingWorkflowType(ctx context.Context, departmentID uint, workflowType string) error {
+	return r.db.WithContext(ctx).
+		Model(&database.Department{}).
+		Where("id = ?", departmentID).
+		Update("onboarding_workflow_type", workflowType).Error
+}
+
 // GetSubDepartments 获取子部门（递归）
 func (r *DepartmentRepositoryImpl) GetSubDepartments(ctx context.Context, departmentID uint) ([]*database.Department, error) {
 	var departments []*database.Department
@@ -163,3 +192,37 @@ func (r *DepartmentRepositoryImpl) GetSubDepartments(ctx context.Context, depart
 	err := r.db.WithContext(ctx).Raw(query, departmentID).Scan(&departments).Error
 	return departments, err
 }
+
+// GetByIDUnscoped 根据ID获取部门，包含已软删除的部门
+func (r *DepartmentRepositoryImpl) GetByIDUnscoped(ctx context.Context, id uint) (*database.Department, error) {
+	var department database.Department
+	err := r.db.WithContext(ctx).Unscoped().First(&department, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, err
+	}
+	return &department, nil
+}
+
+// CountReferences 一次查询统计引用部门的记录数，已软删除的记录和离职员工不计入
+func (r *DepartmentRepositoryImpl) CountReferences(ctx context.Context, departmentID uint) (*repository.DepartmentReferences, error) {
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM employees WHERE department_id = @id AND status <> @resigned AND deleted_at IS NULL) AS employees,
+			(SELECT COUNT(*) FROM departments WHERE parent_id = @id AND deleted_at IS NULL) AS sub_departments,
+			(SELECT COUNT(*) FROM projects WHERE department_id = @id AND deleted_at IS NULL) AS projects,
+			(SELECT COUNT(*) FROM permission_templates WHERE department_id = @id AND deleted_at IS NULL) AS permission_templates,
+			(SELECT COUNT(*) FROM onboarding_permission_configs WHERE department_id = @id AND deleted_at IS NULL) AS onboarding_configs
+	`
+
+	var refs repository.DepartmentReferences
+	err := r.db.WithContext(ctx).
+		Raw(query, map[string]interface{}{"id": departmentID, "resigned": "resigned"}).
+		Scan(&refs).Error
+	if err != nil {
+		return nil, fmt.Errorf("统计部门引用失败: %w", err)
+	}
+	return &refs, nil
+}
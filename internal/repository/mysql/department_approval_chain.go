@@ -0,0 +1,72 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+// DepartmentApprovalChainRepositoryImpl 部门审批链仓储MySQL实现
+type DepartmentApprovalChainRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewDepartmentApprovalChainRepository 创建部门审批链仓储
+func NewDepartmentApprovalChainRepository(db *gorm.DB) repository.DepartmentApprovalChainRepository {
+	return &DepartmentApprovalChainRepositoryImpl{db: db}
+}
+
+// Create 创建审批链
+func (r *DepartmentApprovalChainRepositoryImpl) Create(ctx context.Context, chain *database.DepartmentApprovalChain) error {
+	return r.db.WithContext(ctx).Create(chain).Error
+}
+
+// GetByID 根据ID获取审批链
+func (r *DepartmentApprovalChainRepositoryImpl) GetByID(ctx context.Context, id uint) (*database.DepartmentApprovalChain, error) {
+	var chain database.DepartmentApprovalChain
+	if err := r.db.WithContext(ctx).First(&chain, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, err
+	}
+	return &chain, nil
+}
+
+// GetByDepartmentAndBusinessType 获取部门在业务类型下的审批链
+func (r *DepartmentApprovalChainRepositoryImpl) GetByDepartmentAndBusinessType(ctx context.Context, departmentID uint, businessType string) (*database.DepartmentApprovalChain, error) {
+	var chain database.DepartmentApprovalChain
+	err := r.db.WithContext(ctx).
+		Where("department_id = ? AND business_type = ?", departmentID, businessType).
+		First(&chain).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, err
+	}
+	return &chain, nil
+}
+
+// ListByDepartment 获取部门的全部审批链，按业务类型排序
+func (r *DepartmentApprovalChainRepositoryImpl) ListByDepartment(ctx context.Context, departmentID uint) ([]*database.DepartmentApprovalChain, error) {
+	var chains []*database.DepartmentApprovalChain
+	err := r.db.WithContext(ctx).
+		Where("department_id = ?", departmentID).
+		Order("business_type ASC").
+		Find(&chains).Error
+	return chains, err
+}
+
+// Update 更新审批链
+func (r *DepartmentApprovalChainRepositoryImpl) Update(ctx context.Context, chain *database.DepartmentApprovalChain) error {
+	return r.db.WithContext(ctx).Save(chain).Error
+}
+
+// Delete 删除审批链，物理删除以便同一业务类型重新配置
+func (r *DepartmentApprovalChainRepositoryImpl) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Unscoped().Delete(&database.DepartmentApprovalChain{}, id).Error
+}
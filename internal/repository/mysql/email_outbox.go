@@ -0,0 +1,75 @@
+package mysql
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+// EmailOutboxRepositoryImpl 邮件发件箱仓储MySQL实现
+type EmailOutboxRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewEmailOutboxRepository 创建邮件发件箱仓储
+func NewEmailOutboxRepository(db *gorm.DB) repository.EmailOutboxRepository {
+	return &EmailOutboxRepositoryImpl{db: db}
+}
+
+// Create 写入待发送邮件
+func (r *EmailOutboxRepositoryImpl) Create(ctx context.Context, message *database.EmailOutbox) error {
+	return r.db.WithContext(ctx).Create(message).Error
+}
+
+// Update 更新邮件发送状态
+func (r *EmailOutboxRepositoryImpl) Update(ctx context.Context, message *database.EmailOutbox) error {
+	return r.db.WithContext(ctx).Save(message).Error
+}
+
+// ListDue 获取next_retry_at不晚于now的待发送邮件
+func (r *EmailOutboxRepositoryImpl) ListDue(ctx context.Context, now time.Time, limit int) ([]*database.EmailOutbox, error) {
+	var messages []*database.EmailOutbox
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND next_retry_at <= ?", "pending", now).
+		Order("next_retry_at ASC").Order("id ASC").
+		Limit(limit).
+		Find(&messages).Error
+	if err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// List 按条件分页获取邮件及总数
+func (r *EmailOutboxRepositoryImpl) List(ctx context.Context, filter *repository.EmailOutboxFilter) ([]*database.EmailOutbox, int64, error) {
+	query := r.db.WithContext(ctx).Model(&database.EmailOutbox{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.UserID != 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	query = query.Order("id DESC")
+	if filter.PageSize > 0 {
+		page := filter.Page
+		if page < 1 {
+			page = 1
+		}
+		query = query.Offset((page - 1) * filter.PageSize).Limit(filter.PageSize)
+	}
+
+	var messages []*database.EmailOutbox
+	if err := query.Find(&messages).Error; err != nil {
+		return nil, 0, err
+	}
+	return messages, total, nil
+}
@@ -3,6 +3,7 @@ package mysql
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"gorm.io/gorm"
 	"taskmanage/internal/database"
@@ -22,6 +23,12 @@ func NewEmployeeRepository(db *gorm.DB) repository.EmployeeRepository {
 	}
 }
 
+// Update 按版本号更新员工，员工已被其他请求修改时返回ErrConflict
+// 当前任务数只通过UpdateTaskCount原子调整，不随整体更新写回
+func (r *EmployeeRepositoryImpl) Update(ctx context.Context, employee *database.Employee) error {
+	return updateVersioned(ctx, r.db, employee, employee.ID, &employee.Version, "current_tasks")
+}
+
 // GetByUserID 根据用户ID获取员工信息
 func (r *EmployeeRepositoryImpl) GetByUserID(ctx context.Context, userID uint) (*database.Employee, error) {
 	var employee database.Employee
@@ -50,6 +57,31 @@ func (r *EmployeeRepositoryImpl) GetByEmployeeNo(ctx context.Context, employeeNo
 	return &employee, nil
 }
 
+// EmployeeNoExists 员工编号是否已被使用，已删除员工的编号仍占用唯一索引
+func (r *EmployeeRepositoryImpl) EmployeeNoExists(ctx context.Context, employeeNo string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Unscoped().Model(&database.Employee{}).
+		Where("employee_no = ?", employeeNo).
+		Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("检查员工编号失败: %w", err)
+	}
+	return count > 0, nil
+}
+
+// ListEmployeeNos 获取全部员工的ID和编号
+func (r *EmployeeRepositoryImpl) ListEmployeeNos(ctx context.Context) ([]*database.Employee, error) {
+	var employees []*database.Employee
+	err := r.db.WithContext(ctx).Unscoped().
+		Select("id", "employee_no").
+		Order("id ASC").
+		Find(&employees).Error
+	if err != nil {
+		return nil, fmt.Errorf("获取员工编号失败: %w", err)
+	}
+	return employees, nil
+}
+
 // GetAvailableEmployees 获取可用的员工列表
 func (r *EmployeeRepositoryImpl) GetAvailableEmployees(ctx context.Context) ([]*database.Employee, error) {
 	var employees []*database.Employee
@@ -67,25 +99,136 @@ func (r *EmployeeRepositoryImpl) GetAvailableEmployees(ctx context.Context) ([]*
 	return employees, nil
 }
 
-// UpdateTaskCount 更新员工任务数量
+// UpdateTaskCount 原子调整员工当前任务数
+// 增加时不能超过max_tasks，已满返回ErrTaskLimitReached；减少时最低减到0
 func (r *EmployeeRepositoryImpl) UpdateTaskCount(ctx context.Context, employeeID uint, delta int) error {
-	result := r.db.WithContext(ctx).
+	query := r.db.WithContext(ctx).
 		Model(&database.Employee{}).
-		Where("id = ?", employeeID).
-		Update("current_tasks", gorm.Expr("current_tasks + ?", delta))
-	
+		Where("id = ?", employeeID)
+	count := gorm.Expr("current_tasks + ?", delta)
+	if delta > 0 {
+		query = query.Where("current_tasks + ? <= max_tasks", delta)
+	} else {
+		count = gorm.Expr("CASE WHEN current_tasks + ? < 0 THEN 0 ELSE current_tasks + ? END", delta, delta)
+	}
+
+	result := query.Updates(map[string]interface{}{
+		"current_tasks": count,
+		"version":       gorm.Expr("version + 1"),
+	})
 	if result.Error != nil {
 		logger.Errorf("更新员工任务数量失败: %v", result.Error)
 		return fmt.Errorf("更新员工任务数量失败: %w", result.Error)
 	}
-	
+
 	if result.RowsAffected == 0 {
+		if delta > 0 {
+			exists, err := r.Exists(ctx, employeeID)
+			if err != nil {
+				return err
+			}
+			if exists {
+				return repository.ErrTaskLimitReached
+			}
+		}
 		return repository.ErrNotFound
 	}
-	
+
+	return nil
+}
+
+// CountActiveTasks 按任务负责人统计状态为assigned、in_progress、in_review的任务数，任务负责人为员工对应的用户ID
+func (r *EmployeeRepositoryImpl) CountActiveTasks(ctx context.Context, employeeIDs []uint) (map[uint]int, error) {
+	var rows []struct {
+		EmployeeID uint
+		Count      int
+	}
+	query := r.db.WithContext(ctx).
+		Table("employees").
+		Select("employees.id AS employee_id, COUNT(*) AS count").
+		Joins("JOIN tasks ON tasks.assignee_id = employees.user_id AND tasks.deleted_at IS NULL").
+		Where("employees.deleted_at IS NULL").
+		Where("tasks.status IN ?", []string{"assigned", "in_progress", "in_review"}).
+		Group("employees.id")
+	if len(employeeIDs) > 0 {
+		query = query.Where("employees.id IN ?", employeeIDs)
+	}
+	if err := query.Scan(&rows).Error; err != nil {
+		logger.Errorf("统计员工进行中任务数失败: %v", err)
+		return nil, fmt.Errorf("统计员工进行中任务数失败: %w", err)
+	}
+
+	counts := make(map[uint]int, len(rows))
+	for _, row := range rows {
+		counts[row.EmployeeID] = row.Count
+	}
+	return counts, nil
+}
+
+// CorrectTaskCount 以当前值为条件修正员工当前任务数，避免覆盖校正期间的并发调整
+func (r *EmployeeRepositoryImpl) CorrectTaskCount(ctx context.Context, employeeID uint, expected, count int) error {
+	result := r.db.WithContext(ctx).
+		Model(&database.Employee{}).
+		Where("id = ? AND current_tasks = ?", employeeID, expected).
+		Updates(map[string]interface{}{
+			"current_tasks": count,
+			"version":       gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		logger.Errorf("修正员工任务数量失败: %v", result.Error)
+		return fmt.Errorf("修正员工任务数量失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return repository.ErrConflict
+	}
 	return nil
 }
 
+// MoveToDepartment 将部门全部员工迁移到目标部门
+func (r *EmployeeRepositoryImpl) MoveToDepartment(ctx context.Context, fromDepartmentID, toDepartmentID uint) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Model(&database.Employee{}).
+		Where("department_id = ?", fromDepartmentID).
+		Updates(map[string]interface{}{
+			"department_id": toDepartmentID,
+			"version":       gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		return 0, fmt.Errorf("迁移部门员工失败: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// MoveToPosition 将职位全部员工迁移到目标职位
+func (r *EmployeeRepositoryImpl) MoveToPosition(ctx context.Context, fromPositionID, toPositionID uint) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Model(&database.Employee{}).
+		Where("position_id = ?", fromPositionID).
+		Updates(map[string]interface{}{
+			"position_id": toPositionID,
+			"version":     gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		return 0, fmt.Errorf("迁移职位员工失败: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// AssignDirectManager 为部门中没有直接上级的在职员工设置直接上级，跳过上级本人
+func (r *EmployeeRepositoryImpl) AssignDirectManager(ctx context.Context, departmentID, managerID uint) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Model(&database.Employee{}).
+		Where("department_id = ? AND direct_manager_id IS NULL AND id <> ? AND status <> ?", departmentID, managerID, "resigned").
+		Updates(map[string]interface{}{
+			"direct_manager_id": managerID,
+			"version":           gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		return 0, fmt.Errorf("设置部门员工直接上级失败: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
 // GetEmployeeWithSkills 获取员工及其技能信息
 func (r *EmployeeRepositoryImpl) GetEmployeeWithSkills(ctx context.Context, employeeID uint) (*database.Employee, error) {
 	var employee database.Employee
@@ -192,7 +335,10 @@ func (r *EmployeeRepositoryImpl) UpdateStatus(ctx context.Context, employeeID ui
 	result := r.db.WithContext(ctx).
 		Model(&database.Employee{}).
 		Where("id = ?", employeeID).
-		Update("status", status)
+		Updates(map[string]interface{}{
+			"status":  status,
+			"version": gorm.Expr("version + 1"),
+		})
 	
 	if result.Error != nil {
 		logger.Errorf("更新员工状态失败: %v", result.Error)
@@ -230,7 +376,10 @@ func (r *EmployeeRepositoryImpl) BatchUpdateStatus(ctx context.Context, employee
 	result := r.db.WithContext(ctx).
 		Model(&database.Employee{}).
 		Where("id IN ?", employeeIDs).
-		Update("status", status)
+		Updates(map[string]interface{}{
+			"status":  status,
+			"version": gorm.Expr("version + 1"),
+		})
 	
 	if result.Error != nil {
 		logger.Errorf("批量更新员工状态失败: %v", result.Error)
@@ -240,3 +389,213 @@ func (r *EmployeeRepositoryImpl) BatchUpdateStatus(ctx context.Context, employee
 	logger.Infof("批量更新员工状态成功，影响行数: %d", result.RowsAffected)
 	return nil
 }
+
+// ListProbationEnding 获取试用期在before之前结束且尚未发送到期提醒的试用期员工，已过期未转正的员工同样返回
+func (r *EmployeeRepositoryImpl) ListProbationEnding(ctx context.Context, before time.Time) ([]*database.Employee, error) {
+	var employees []*database.Employee
+	err := r.db.WithContext(ctx).
+		Where("onboarding_status = ? AND probation_end_date IS NOT NULL AND probation_end_date < ?", "probation", before).
+		Where("probation_reminded_at IS NULL").
+		Preload("User").
+		Order("probation_end_date ASC").
+		Find(&employees).Error
+	if err != nil {
+		logger.Errorf("获取试用期即将结束的员工失败: %v", err)
+		return nil, fmt.Errorf("获取试用期即将结束的员工失败: %w", err)
+	}
+	return employees, nil
+}
+
+// ListTenured 获取入职或转正满一定时间的在职员工，已离职或已停用的员工不返回
+func (r *EmployeeRepositoryImpl) ListTenured(ctx context.Context, hiredBefore, confirmedBefore *time.Time) ([]*database.Employee, error) {
+	if hiredBefore == nil && confirmedBefore == nil {
+		return nil, nil
+	}
+	tenure := r.db.WithContext(ctx)
+	if hiredBefore != nil {
+		tenure = tenure.Or("hire_date IS NOT NULL AND hire_date <= ?", *hiredBefore)
+	}
+	if confirmedBefore != nil {
+		tenure = tenure.Or("confirm_date IS NOT NULL AND confirm_date <= ?", *confirmedBefore)
+	}
+
+	var employees []*database.Employee
+	err := r.db.WithContext(ctx).
+		Where("status <> ? AND onboarding_status <> ?", "resigned", "inactive").
+		Where(tenure).
+		Order("id ASC").
+		Find(&employees).Error
+	if err != nil {
+		logger.Errorf("获取满足司龄条件的员工失败: %v", err)
+		return nil, fmt.Errorf("获取满足司龄条件的员工失败: %w", err)
+	}
+	return employees, nil
+}
+
+// ListOnboarding 按入职状态、部门和预期入职日期分页获取员工，预加载用户、部门、职位和直接上级
+func (r *EmployeeRepositoryImpl) ListOnboarding(ctx context.Context, filter *repository.OnboardingWorkflowFilter) ([]*database.Employee, int64, error) {
+	query := r.db.WithContext(ctx).Model(&database.Employee{})
+	if filter.Status != "" {
+		query = query.Where("employees.onboarding_status = ?", filter.Status)
+	}
+	if filter.Department != "" {
+		query = query.Joins("JOIN departments ON departments.id = employees.department_id AND departments.deleted_at IS NULL").
+			Where("departments.name = ?", filter.Department)
+	}
+	if filter.ExpectedFrom != nil {
+		query = query.Where("employees.expected_date >= ?", *filter.ExpectedFrom)
+	}
+	if filter.ExpectedTo != nil {
+		query = query.Where("employees.expected_date < ?", *filter.ExpectedTo)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		logger.Errorf("统计入职员工数量失败: %v", err)
+		return nil, 0, fmt.Errorf("统计入职员工数量失败: %w", err)
+	}
+
+	page := filter.Page
+	if page < 1 {
+		page = 1
+	}
+	var employees []*database.Employee
+	err := query.
+		Preload("User").
+		Preload("Department").
+		Preload("Position").
+		Preload("DirectManager.User").
+		Order("employees.id DESC").
+		Offset((page - 1) * filter.PageSize).
+		Limit(filter.PageSize).
+		Find(&employees).Error
+	if err != nil {
+		logger.Errorf("获取入职员工列表失败: %v", err)
+		return nil, 0, fmt.Errorf("获取入职员工列表失败: %w", err)
+	}
+	return employees, total, nil
+}
+
+// ListEmployeesWithUserAndDepartment 分页获取员工，用户、部门和职位各用一次查询预加载
+func (r *EmployeeRepositoryImpl) ListEmployeesWithUserAndDepartment(ctx context.Context, filter repository.ListFilter) ([]*database.Employee, int64, error) {
+	query := r.applyFilters(r.db.WithContext(ctx).Model(&database.Employee{}), filter.Filters)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		logger.Errorf("统计员工数量失败: %v", err)
+		return nil, 0, fmt.Errorf("统计员工数量失败: %w", err)
+	}
+
+	var employees []*database.Employee
+	err := r.applySorting(r.applyPagination(query, filter), filter).
+		Preload("User").
+		Preload("Department").
+		Preload("Position").
+		Find(&employees).Error
+	if err != nil {
+		logger.Errorf("获取员工列表失败: %v", err)
+		return nil, 0, fmt.Errorf("获取员工列表失败: %w", err)
+	}
+	return employees, total, nil
+}
+
+// MarkProbationReminded 记录试用期到期提醒已发送
+func (r *EmployeeRepositoryImpl) MarkProbationReminded(ctx context.Context, employeeID uint, remindedAt time.Time) error {
+	result := r.db.WithContext(ctx).
+		Model(&database.Employee{}).
+		Where("id = ?", employeeID).
+		Update("probation_reminded_at", remindedAt)
+	if result.Error != nil {
+		logger.Errorf("标记试用期提醒失败: %v", result.Error)
+		return fmt.Errorf("标记试用期提醒失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// workloadScope 按工作负载过滤器限定员工范围
+func workloadScope(filter *repository.WorkloadFilter) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if len(filter.EmployeeIDs) > 0 {
+			db = db.Where("employees.id IN ?", filter.EmployeeIDs)
+		}
+		if filter.DepartmentID != 0 {
+			db = db.Where("employees.department_id = ?", filter.DepartmentID)
+		} else if filter.Department != "" {
+			db = db.Where("employees.department_id IN (?)",
+				db.Session(&gorm.Session{NewDB: true}).Model(&database.Department{}).Select("id").Where("name = ?", filter.Department))
+		}
+		return db
+	}
+}
+
+// ListForWorkload 按员工ID和部门获取参与工作负载统计的员工，预加载用户和部门
+func (r *EmployeeRepositoryImpl) ListForWorkload(ctx context.Context, filter *repository.WorkloadFilter) ([]*database.Employee, error) {
+	var employees []*database.Employee
+	err := r.db.WithContext(ctx).
+		Scopes(workloadScope(filter)).
+		Preload("User").
+		Preload("Department").
+		Order("employees.id").
+		Find(&employees).Error
+	if err != nil {
+		logger.Errorf("获取工作负载统计员工失败: %v", err)
+		return nil, fmt.Errorf("获取工作负载统计员工失败: %w", err)
+	}
+	return employees, nil
+}
+
+// AggregateTaskStats 按任务负责人分组统计任务，任务负责人为员工对应的用户ID
+func (r *EmployeeRepositoryImpl) AggregateTaskStats(ctx context.Context, filter *repository.WorkloadFilter, now time.Time) (map[uint]*repository.EmployeeTaskStats, error) {
+	// 完成时长按秒计算，PostgreSQL不支持TIMESTAMPDIFF
+	durationSeconds := "TIMESTAMPDIFF(SECOND, tasks.started_at, tasks.completed_at)"
+	if r.db.Dialector.Name() == database.DriverPostgres {
+		durationSeconds = "EXTRACT(EPOCH FROM (tasks.completed_at - tasks.started_at))"
+	}
+	completedInRange := "tasks.status = 'completed' AND tasks.completed_at >= @from AND tasks.completed_at < @to"
+
+	var rows []struct {
+		EmployeeID      uint
+		PendingTasks    int
+		CompletedTasks  int
+		OnTimeTasks     int
+		OverdueTasks    int
+		AvgDurationSecs *float64
+	}
+	err := r.db.WithContext(ctx).
+		Table("employees").
+		Select(`employees.id AS employee_id,
+			SUM(CASE WHEN tasks.status = 'pending' THEN 1 ELSE 0 END) AS pending_tasks,
+			SUM(CASE WHEN `+completedInRange+` THEN 1 ELSE 0 END) AS completed_tasks,
+			SUM(CASE WHEN `+completedInRange+` AND (tasks.due_date IS NULL OR tasks.completed_at <= tasks.due_date) THEN 1 ELSE 0 END) AS on_time_tasks,
+			SUM(CASE WHEN tasks.due_date < @now AND tasks.status NOT IN ('completed', 'cancelled') THEN 1 ELSE 0 END) AS overdue_tasks,
+			AVG(CASE WHEN `+completedInRange+` AND tasks.started_at IS NOT NULL THEN `+durationSeconds+` END) AS avg_duration_secs`,
+			map[string]interface{}{"from": filter.CompletedFrom, "to": filter.CompletedTo, "now": now}).
+		Joins("JOIN tasks ON tasks.assignee_id = employees.user_id AND tasks.deleted_at IS NULL").
+		Where("employees.deleted_at IS NULL").
+		Scopes(workloadScope(filter)).
+		Group("employees.id").
+		Scan(&rows).Error
+	if err != nil {
+		logger.Errorf("统计员工任务失败: %v", err)
+		return nil, fmt.Errorf("统计员工任务失败: %w", err)
+	}
+
+	stats := make(map[uint]*repository.EmployeeTaskStats, len(rows))
+	for _, row := range rows {
+		stat := &repository.EmployeeTaskStats{
+			EmployeeID:     row.EmployeeID,
+			PendingTasks:   row.PendingTasks,
+			CompletedTasks: row.CompletedTasks,
+			OnTimeTasks:    row.OnTimeTasks,
+			OverdueTasks:   row.OverdueTasks,
+		}
+		if row.AvgDurationSecs != nil {
+			stat.AvgDurationHours = *row.AvgDurationSecs / 3600
+		}
+		stats[row.EmployeeID] = stat
+	}
+	return stats, nil
+}
@@ -0,0 +1,115 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+// EmployeeCapacityRepositoryImpl 员工容量日历仓储MySQL实现
+type EmployeeCapacityRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewEmployeeCapacityRepository 创建员工容量日历仓储
+func NewEmployeeCapacityRepository(db *gorm.DB) repository.EmployeeCapacityRepository {
+	return &EmployeeCapacityRepositoryImpl{db: db}
+}
+
+// Create 创建容量条目
+func (r *EmployeeCapacityRepositoryImpl) Create(ctx context.Context, capacity *database.EmployeeCapacity) error {
+	if err := r.db.WithContext(ctx).Create(capacity).Error; err != nil {
+		return fmt.Errorf("创建员工容量条目失败: %w", err)
+	}
+	return nil
+}
+
+// GetByID 根据ID获取容量条目，不存在时返回ErrNotFound
+func (r *EmployeeCapacityRepositoryImpl) GetByID(ctx context.Context, id uint) (*database.EmployeeCapacity, error) {
+	var capacity database.EmployeeCapacity
+	if err := r.db.WithContext(ctx).First(&capacity, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("获取员工容量条目失败: %w", err)
+	}
+	return &capacity, nil
+}
+
+// Update 更新容量条目
+func (r *EmployeeCapacityRepositoryImpl) Update(ctx context.Context, capacity *database.EmployeeCapacity) error {
+	if err := r.db.WithContext(ctx).Save(capacity).Error; err != nil {
+		return fmt.Errorf("更新员工容量条目失败: %w", err)
+	}
+	return nil
+}
+
+// Delete 删除容量条目，不存在时返回ErrNotFound
+func (r *EmployeeCapacityRepositoryImpl) Delete(ctx context.Context, id uint) error {
+	result := r.db.WithContext(ctx).Delete(&database.EmployeeCapacity{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("删除员工容量条目失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// ListByEmployee 获取员工的全部容量条目，按开始日期排序
+func (r *EmployeeCapacityRepositoryImpl) ListByEmployee(ctx context.Context, employeeID uint) ([]*database.EmployeeCapacity, error) {
+	var capacities []*database.EmployeeCapacity
+	err := r.db.WithContext(ctx).
+		Where("employee_id = ?", employeeID).
+		Order("start_date ASC").
+		Find(&capacities).Error
+	if err != nil {
+		return nil, fmt.Errorf("获取员工容量条目失败: %w", err)
+	}
+	return capacities, nil
+}
+
+// ListOverlapping 获取员工与[from, to]日期范围重叠的容量条目
+func (r *EmployeeCapacityRepositoryImpl) ListOverlapping(ctx context.Context, employeeID uint, from, to time.Time) ([]*database.EmployeeCapacity, error) {
+	var capacities []*database.EmployeeCapacity
+	err := r.db.WithContext(ctx).
+		Where("employee_id = ? AND start_date <= ? AND end_date >= ?", employeeID, to, from).
+		Order("start_date ASC").
+		Find(&capacities).Error
+	if err != nil {
+		return nil, fmt.Errorf("获取员工容量条目失败: %w", err)
+	}
+	return capacities, nil
+}
+
+// ListLeavesToApply 获取day当天处于请假期间但尚未生效的请假条目
+func (r *EmployeeCapacityRepositoryImpl) ListLeavesToApply(ctx context.Context, day time.Time) ([]*database.EmployeeCapacity, error) {
+	var capacities []*database.EmployeeCapacity
+	err := r.db.WithContext(ctx).
+		Where("capacity_factor = 0 AND leave_applied = ? AND start_date <= ? AND end_date >= ?", false, day, day).
+		Order("start_date ASC").
+		Find(&capacities).Error
+	if err != nil {
+		return nil, fmt.Errorf("获取待生效的请假条目失败: %w", err)
+	}
+	return capacities, nil
+}
+
+// ListLeavesToRestore 获取已生效且在day之前结束的请假条目
+func (r *EmployeeCapacityRepositoryImpl) ListLeavesToRestore(ctx context.Context, day time.Time) ([]*database.EmployeeCapacity, error) {
+	var capacities []*database.EmployeeCapacity
+	err := r.db.WithContext(ctx).
+		Where("leave_applied = ? AND end_date < ?", true, day).
+		Order("end_date ASC").
+		Find(&capacities).Error
+	if err != nil {
+		return nil, fmt.Errorf("获取已结束的请假条目失败: %w", err)
+	}
+	return capacities, nil
+}
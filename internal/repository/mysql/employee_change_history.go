@@ -0,0 +1,34 @@
+package mysql
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+// EmployeeChangeHistoryRepositoryImpl 员工职位变更历史仓储MySQL实现
+type EmployeeChangeHistoryRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewEmployeeChangeHistoryRepository 创建员工职位变更历史仓储
+func NewEmployeeChangeHistoryRepository(db *gorm.DB) repository.EmployeeChangeHistoryRepository {
+	return &EmployeeChangeHistoryRepositoryImpl{db: db}
+}
+
+// Create 创建变更记录
+func (r *EmployeeChangeHistoryRepositoryImpl) Create(ctx context.Context, history *database.EmployeeChangeHistory) error {
+	return r.db.WithContext(ctx).Create(history).Error
+}
+
+// ListByEmployeeID 获取员工的变更记录，按时间倒序
+func (r *EmployeeChangeHistoryRepositoryImpl) ListByEmployeeID(ctx context.Context, employeeID uint) ([]*database.EmployeeChangeHistory, error) {
+	var histories []*database.EmployeeChangeHistory
+	err := r.db.WithContext(ctx).
+		Where("employee_id = ?", employeeID).
+		Order("created_at DESC, id DESC").
+		Find(&histories).Error
+	return histories, err
+}
@@ -0,0 +1,88 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+// HolidayRepositoryImpl 节假日仓储MySQL实现
+type HolidayRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewHolidayRepository 创建节假日仓储
+func NewHolidayRepository(db *gorm.DB) repository.HolidayRepository {
+	return &HolidayRepositoryImpl{db: db}
+}
+
+// Create 创建节假日
+func (r *HolidayRepositoryImpl) Create(ctx context.Context, holiday *database.Holiday) error {
+	if err := r.db.WithContext(ctx).Create(holiday).Error; err != nil {
+		return fmt.Errorf("创建节假日失败: %w", err)
+	}
+	return nil
+}
+
+// GetByID 根据ID获取节假日，不存在时返回ErrNotFound
+func (r *HolidayRepositoryImpl) GetByID(ctx context.Context, id uint) (*database.Holiday, error) {
+	var holiday database.Holiday
+	if err := r.db.WithContext(ctx).First(&holiday, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("获取节假日失败: %w", err)
+	}
+	return &holiday, nil
+}
+
+// GetByDate 获取指定日期的节假日，不存在时返回ErrNotFound
+func (r *HolidayRepositoryImpl) GetByDate(ctx context.Context, date time.Time) (*database.Holiday, error) {
+	var holiday database.Holiday
+	if err := r.db.WithContext(ctx).Where("date = ?", date.Format("2006-01-02")).First(&holiday).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("获取节假日失败: %w", err)
+	}
+	return &holiday, nil
+}
+
+// Update 更新节假日
+func (r *HolidayRepositoryImpl) Update(ctx context.Context, holiday *database.Holiday) error {
+	if err := r.db.WithContext(ctx).Save(holiday).Error; err != nil {
+		return fmt.Errorf("更新节假日失败: %w", err)
+	}
+	return nil
+}
+
+// Delete 删除节假日，不存在时返回ErrNotFound
+func (r *HolidayRepositoryImpl) Delete(ctx context.Context, id uint) error {
+	result := r.db.WithContext(ctx).Delete(&database.Holiday{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("删除节假日失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// List 获取节假日，year为0时返回全部年份，按日期排序
+func (r *HolidayRepositoryImpl) List(ctx context.Context, year int) ([]*database.Holiday, error) {
+	query := r.db.WithContext(ctx).Order("date ASC")
+	if year > 0 {
+		query = query.Where("date >= ? AND date < ?", fmt.Sprintf("%04d-01-01", year), fmt.Sprintf("%04d-01-01", year+1))
+	}
+	var holidays []*database.Holiday
+	if err := query.Find(&holidays).Error; err != nil {
+		return nil, fmt.Errorf("获取节假日失败: %w", err)
+	}
+	return holidays, nil
+}
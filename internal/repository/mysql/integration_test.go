@@ -0,0 +1,962 @@
+//go:build integration
+
+package mysql
+
+// 仓储集成测试，需要真实数据库，默认构建不会编译本文件。
+// 通过环境变量选择目标数据库：
+//
+//	TEST_DB_DRIVER  mysql（默认）或 postgres
+//	TEST_DB_DSN     连接字符串，未设置时跳过
+//
+// 运行方式见 docs/database.md，可配合 docker-compose.test.yml 启动数据库：
+//
+//	TEST_DB_DRIVER=postgres TEST_DB_DSN="host=localhost port=5433 user=taskmanage password=taskmanage dbname=taskmanage_test sslmode=disable" \
+//	  go test -tags integration ./internal/repository/mysql/...
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"taskmanage/internal/config"
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+	"taskmanage/internal/service"
+)
+
+var (
+	integrationOnce sync.Once
+	integrationDB   *gorm.DB
+	integrationErr  error
+)
+
+// openIntegrationDB 按环境变量连接测试数据库并执行一次完整迁移
+func openIntegrationDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DB_DSN")
+	if dsn == "" {
+		t.Skip("未设置TEST_DB_DSN，跳过集成测试")
+	}
+	driver := os.Getenv("TEST_DB_DRIVER")
+	if driver == "" {
+		driver = database.DriverMySQL
+	}
+
+	integrationOnce.Do(func() {
+		dialector, err := database.Dialector(driver, dsn)
+		if err != nil {
+			integrationErr = err
+			return
+		}
+		db, err := gorm.Open(dialector, &gorm.Config{
+			Logger:                                   gormlogger.Default.LogMode(gormlogger.Silent),
+			DisableForeignKeyConstraintWhenMigrating: true,
+		})
+		if err != nil {
+			integrationErr = fmt.Errorf("连接测试数据库失败: %w", err)
+			return
+		}
+		database.DB = db
+		if err := database.Migrate(); err != nil {
+			integrationErr = err
+			return
+		}
+		integrationDB = db
+	})
+	require.NoError(t, integrationErr)
+	return integrationDB
+}
+
+// uniqueSuffix 生成测试数据后缀，避免重复运行时唯一索引冲突
+func uniqueSuffix() string {
+	return fmt.Sprintf("%d", time.Now().UnixNano())
+}
+
+// createIntegrationEmployee 创建测试用户及其员工档案
+func createIntegrationEmployee(t *testing.T, db *gorm.DB) *database.Employee {
+	t.Helper()
+	suffix := uniqueSuffix()
+	user := &database.User{
+		Username:     "it_" + suffix,
+		Email:        "it_" + suffix + "@example.com",
+		Password:     "x",
+		PasswordHash: "x",
+	}
+	require.NoError(t, db.Create(user).Error)
+	employee := &database.Employee{UserID: user.ID, EmployeeNo: "IT" + suffix}
+	require.NoError(t, db.Create(employee).Error)
+	return employee
+}
+
+func TestIntegration_MigrateAllModels(t *testing.T) {
+	db := openIntegrationDB(t)
+
+	assert.Equal(t, os.Getenv("TEST_DB_DRIVER") == database.DriverPostgres, database.Driver() == database.DriverPostgres)
+	for _, model := range database.GetAllModels() {
+		assert.True(t, db.Migrator().HasTable(model), "缺少数据表: %T", model)
+	}
+	// 重复迁移应当幂等
+	require.NoError(t, database.Migrate())
+	assert.Equal(t, database.Driver(), database.GetConnectionInfo()["driver"])
+}
+
+func TestIntegration_RunMigrations(t *testing.T) {
+	db := openIntegrationDB(t)
+	ctx := context.Background()
+
+	// 多个实例同时迁移由迁移锁串行化，已执行的迁移不会重复执行
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = database.RunMigrations(ctx, db, database.MigrationOptions{DDLLockTimeout: 10 * time.Second})
+		}(i)
+	}
+	wg.Wait()
+	require.NoError(t, errs[0])
+	require.NoError(t, errs[1])
+	pending, err := database.PendingMigrations(ctx, db)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+
+	// 已执行的迁移被修改后拒绝执行
+	var baseline database.SchemaMigration
+	require.NoError(t, db.First(&baseline, "version = ?", 1).Error)
+	original := baseline.Checksum
+	require.NoError(t, db.Model(&baseline).Update("checksum", "modified").Error)
+	defer db.Model(&baseline).Update("checksum", original)
+	_, err = database.RunMigrations(ctx, db, database.MigrationOptions{})
+	assert.ErrorIs(t, err, database.ErrMigrationChecksumMismatch)
+	_, err = database.PendingMigrations(ctx, db)
+	assert.ErrorIs(t, err, database.ErrMigrationChecksumMismatch)
+}
+
+func TestIntegration_AssignSkillUpsert(t *testing.T) {
+	db := openIntegrationDB(t)
+	ctx := context.Background()
+	repo := NewSkillRepository(db)
+	employee := createIntegrationEmployee(t, db)
+	skill := &database.Skill{Name: "it_skill_" + uniqueSuffix()}
+	require.NoError(t, db.Create(skill).Error)
+
+	require.NoError(t, repo.AssignToEmployee(ctx, employee.ID, skill.ID, 2))
+	// 再次分配只更新等级
+	require.NoError(t, repo.AssignToEmployee(ctx, employee.ID, skill.ID, 4))
+	level, err := repo.GetEmployeeSkillLevel(ctx, employee.ID, skill.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 4, level)
+
+	require.NoError(t, repo.(*SkillRepositoryImpl).BatchAssignSkills(ctx, employee.ID, map[uint]int{skill.ID: 5}))
+	level, err = repo.GetEmployeeSkillLevel(ctx, employee.ID, skill.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 5, level)
+}
+
+func TestIntegration_SkillCategoryMigrationAndMerge(t *testing.T) {
+	db := openIntegrationDB(t)
+	ctx := context.Background()
+	suffix := uniqueSuffix()
+
+	// 模拟升级前仍使用字符串分类的存量技能
+	if !db.Migrator().HasColumn("skills", "category") {
+		require.NoError(t, db.Exec("ALTER TABLE skills ADD COLUMN category VARCHAR(50)").Error)
+	}
+	var skillIDs []uint
+	for _, category := range []string{"Backend" + suffix, "backend" + suffix, " BACKEND" + suffix + " ", "后端" + suffix} {
+		skill := &database.Skill{Name: "it_skill_" + uniqueSuffix()}
+		require.NoError(t, db.Create(skill).Error)
+		require.NoError(t, db.Table("skills").Where("id = ?", skill.ID).Update("category", category).Error)
+		skillIDs = append(skillIDs, skill.ID)
+	}
+	require.NoError(t, database.Migrate())
+
+	repo := NewSkillCategoryRepository(db)
+	backend, err := repo.GetByName(ctx, "BACKEND"+suffix)
+	require.NoError(t, err)
+	assert.Equal(t, "Backend"+suffix, backend.Name)
+	chinese, err := repo.GetByName(ctx, "后端"+suffix)
+	require.NoError(t, err)
+
+	skills, err := NewSkillRepository(db).GetByCategory(ctx, "backend"+suffix)
+	require.NoError(t, err)
+	assert.Len(t, skills, 3)
+	assert.Equal(t, "Backend"+suffix, skills[0].CategoryName())
+
+	moved, err := repo.Merge(ctx, chinese.ID, backend.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), moved)
+	_, err = repo.GetByID(ctx, chinese.ID)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	counts, err := repo.ListWithSkillCounts(ctx)
+	require.NoError(t, err)
+	for _, count := range counts {
+		if count.ID == backend.ID {
+			assert.Equal(t, int64(len(skillIDs)), count.SkillCount)
+		}
+	}
+	_, err = repo.Merge(ctx, chinese.ID, backend.ID)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+}
+
+func TestIntegration_ReconcileTaskCount(t *testing.T) {
+	db := openIntegrationDB(t)
+	ctx := context.Background()
+	repo := NewEmployeeRepository(db)
+	employee := createIntegrationEmployee(t, db)
+	for _, status := range []string{"assigned", "in_progress", "completed"} {
+		task := &database.Task{Title: "it_task_" + uniqueSuffix(), Status: status, CreatorID: employee.UserID, AssigneeID: &employee.UserID}
+		require.NoError(t, db.Create(task).Error)
+	}
+	require.NoError(t, db.Model(employee).Update("current_tasks", 7).Error)
+
+	counts, err := repo.CountActiveTasks(ctx, []uint{employee.ID})
+	require.NoError(t, err)
+	assert.Equal(t, map[uint]int{employee.ID: 2}, counts)
+
+	// 计数已被并发调整时不覆盖
+	assert.ErrorIs(t, repo.CorrectTaskCount(ctx, employee.ID, 6, 2), repository.ErrConflict)
+	require.NoError(t, repo.CorrectTaskCount(ctx, employee.ID, 7, 2))
+
+	// 整体更新员工不写回当前任务数
+	loaded, err := repo.GetByID(ctx, employee.ID)
+	require.NoError(t, err)
+	loaded.CurrentTasks = 9
+	require.NoError(t, repo.Update(ctx, loaded))
+	loaded, err = repo.GetByID(ctx, employee.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, loaded.CurrentTasks)
+}
+
+func TestIntegration_AggregateTaskStats(t *testing.T) {
+	db := openIntegrationDB(t)
+	ctx := context.Background()
+	repo := NewEmployeeRepository(db)
+	employee := createIntegrationEmployee(t, db)
+	now := time.Now().Truncate(time.Second)
+	at := func(hours int) *time.Time {
+		v := now.Add(time.Duration(hours) * time.Hour)
+		return &v
+	}
+	tasks := []*database.Task{
+		{Status: "pending"},
+		{Status: "in_progress", DueDate: at(-1)},
+		{Status: "cancelled", DueDate: at(-1)},
+		{Status: "completed", StartedAt: at(-10), CompletedAt: at(-6), DueDate: at(-5)},
+		{Status: "completed", StartedAt: at(-8), CompletedAt: at(-6), DueDate: at(-7)},
+		{Status: "completed", StartedAt: at(-24 * 200), CompletedAt: at(-24 * 199)}, // 不在统计区间内
+	}
+	for _, task := range tasks {
+		task.Title = "it_task_" + uniqueSuffix()
+		task.CreatorID = employee.UserID
+		task.AssigneeID = &employee.UserID
+		require.NoError(t, db.Create(task).Error)
+	}
+
+	filter := &repository.WorkloadFilter{EmployeeIDs: []uint{employee.ID}, CompletedFrom: now.AddDate(0, 0, -90), CompletedTo: now}
+	stats, err := repo.AggregateTaskStats(ctx, filter, now)
+	require.NoError(t, err)
+	require.Contains(t, stats, employee.ID)
+	stat := stats[employee.ID]
+	assert.Equal(t, 1, stat.PendingTasks)
+	assert.Equal(t, 2, stat.CompletedTasks)
+	assert.Equal(t, 1, stat.OnTimeTasks)
+	assert.Equal(t, 1, stat.OverdueTasks)
+	assert.InDelta(t, 3.0, stat.AvgDurationHours, 0.01)
+
+	employees, err := repo.ListForWorkload(ctx, filter)
+	require.NoError(t, err)
+	require.Len(t, employees, 1)
+	assert.Equal(t, employee.UserID, employees[0].User.ID)
+}
+
+func TestIntegration_SubTaskCountsAndCancelTree(t *testing.T) {
+	db := openIntegrationDB(t)
+	ctx := context.Background()
+	repo := NewTaskRepository(db)
+	employee := createIntegrationEmployee(t, db)
+	newTask := func(status string, parentID *uint) *database.Task {
+		task := &database.Task{Title: "it_task_" + uniqueSuffix(), Status: status, CreatorID: employee.UserID, ParentID: parentID}
+		require.NoError(t, db.Create(task).Error)
+		return task
+	}
+	root := newTask("pending", nil)
+	done := newTask("completed", &root.ID)
+	open := newTask("assigned", &root.ID)
+	newTask("cancelled", &root.ID)
+	grandchild := newTask("in_progress", &open.ID)
+	newTask("pending", &done.ID)
+
+	counts, err := repo.CountSubTasks(ctx, []uint{root.ID, open.ID, grandchild.ID})
+	require.NoError(t, err)
+	assert.Equal(t, &repository.SubTaskCounts{Total: 2, Completed: 1}, counts[root.ID])
+	assert.Equal(t, &repository.SubTaskCounts{Total: 1, Completed: 0}, counts[open.ID])
+	assert.NotContains(t, counts, grandchild.ID)
+
+	cancelled, err := repo.CancelTree(ctx, root.ID, time.Now())
+	require.NoError(t, err)
+	assert.Len(t, cancelled, 4)
+	var loaded database.Task
+	require.NoError(t, db.First(&loaded, grandchild.ID).Error)
+	assert.Equal(t, "cancelled", loaded.Status)
+	require.NoError(t, db.First(&loaded, done.ID).Error)
+	assert.Equal(t, "completed", loaded.Status)
+
+	// 已取消的任务不能再次级联取消
+	_, err = repo.CancelTree(ctx, root.ID, time.Now())
+	assert.ErrorIs(t, err, repository.ErrConflict)
+}
+
+func TestIntegration_TaskLabelFilterAndDelete(t *testing.T) {
+	db := openIntegrationDB(t)
+	ctx := context.Background()
+	taskRepo := NewTaskRepository(db)
+	labelRepo := NewTaskLabelRepository(db)
+	employee := createIntegrationEmployee(t, db)
+	suffix := uniqueSuffix()
+	newLabel := func(name string) *database.TaskLabel {
+		label := &database.TaskLabel{Name: name + "_" + suffix, Color: "#808080", CreatorID: employee.UserID}
+		require.NoError(t, labelRepo.Create(ctx, label))
+		return label
+	}
+	newTask := func() *database.Task {
+		task := &database.Task{Title: "it_task_" + uniqueSuffix(), CreatorID: employee.UserID}
+		require.NoError(t, db.Create(task).Error)
+		return task
+	}
+	debt, okr := newLabel("tech-debt"), newLabel("q3-okr")
+	both, onlyDebt, onlyOKR := newTask(), newTask(), newTask()
+
+	require.NoError(t, labelRepo.ReplaceTaskLabels(ctx, both.ID, []uint{debt.ID, okr.ID}))
+	require.NoError(t, labelRepo.ReplaceTaskLabels(ctx, onlyOKR.ID, []uint{okr.ID}))
+	attached, err := labelRepo.AttachToTasks(ctx, debt.ID, []uint{both.ID, onlyDebt.ID})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), attached)
+
+	listIDs := func(matchAny bool, names ...string) []uint {
+		filter := repository.ListFilter{PageSize: 100, Sort: "id", Order: "asc", Filters: map[string]interface{}{
+			"labels": repository.TaskLabelFilter{Names: names, MatchAny: matchAny},
+		}}
+		tasks, total, err := taskRepo.List(ctx, filter)
+		require.NoError(t, err)
+		require.Equal(t, int64(len(tasks)), total)
+		ids := make([]uint, 0, len(tasks))
+		for _, task := range tasks {
+			ids = append(ids, task.ID)
+		}
+		return ids
+	}
+	assert.Equal(t, []uint{both.ID}, listIDs(false, strings.ToUpper(debt.Name), okr.Name))
+	assert.Equal(t, []uint{both.ID, onlyDebt.ID, onlyOKR.ID}, listIDs(true, debt.Name, okr.Name))
+	assert.Equal(t, []uint{both.ID, onlyDebt.ID}, listIDs(false, debt.Name, debt.Name))
+
+	detached, err := labelRepo.Delete(ctx, debt.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), detached)
+	labels, err := labelRepo.ListByTask(ctx, both.ID)
+	require.NoError(t, err)
+	require.Len(t, labels, 1)
+	assert.Equal(t, okr.ID, labels[0].ID)
+
+	_, err = labelRepo.Delete(ctx, debt.ID)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+}
+
+func TestIntegration_NotificationPreferenceUpsert(t *testing.T) {
+	db := openIntegrationDB(t)
+	ctx := context.Background()
+	repo := NewNotificationPreferenceRepository(db)
+	employee := createIntegrationEmployee(t, db)
+
+	preference := func(enabled bool) []*database.NotificationPreference {
+		return []*database.NotificationPreference{{UserID: employee.UserID, Category: "task_assigned", Channel: "email", Enabled: enabled}}
+	}
+	require.NoError(t, repo.Upsert(ctx, preference(true)))
+	require.NoError(t, repo.Upsert(ctx, preference(false)))
+
+	stored, err := repo.Get(ctx, employee.UserID, "task_assigned", "email")
+	require.NoError(t, err)
+	assert.False(t, stored.Enabled)
+	all, err := repo.ListByUser(ctx, employee.UserID)
+	require.NoError(t, err)
+	assert.Len(t, all, 1)
+
+	_, err = repo.Get(ctx, employee.UserID, "task_assigned", "webhook")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+}
+
+func TestIntegration_JSONFieldRoundTrip(t *testing.T) {
+	db := openIntegrationDB(t)
+	definition := &database.WorkflowDefinition{
+		WorkflowID: "it_workflow_" + uniqueSuffix(),
+		Name:       "集成测试流程",
+		Version:    "1.0",
+		Nodes:      database.JSONField{Data: []interface{}{map[string]interface{}{"id": "start", "type": "start"}}},
+		Variables:  database.JSONField{Data: map[string]interface{}{"amount": 12.5}},
+	}
+	require.NoError(t, db.Create(definition).Error)
+
+	var loaded database.WorkflowDefinition
+	require.NoError(t, db.First(&loaded, definition.ID).Error)
+	assert.Equal(t, definition.Nodes.Data, loaded.Nodes.Data)
+	assert.Equal(t, definition.Variables.Data, loaded.Variables.Data)
+	assert.Nil(t, loaded.Edges.Data)
+}
+
+func TestIntegration_ListAndCountInstances(t *testing.T) {
+	db := openIntegrationDB(t)
+	ctx := context.Background()
+	repo := NewWorkflowInstanceRepository(db)
+	suffix := uniqueSuffix()
+	definition := &database.WorkflowDefinition{WorkflowID: "it_list_" + suffix, Name: "集成测试列表流程" + suffix, Version: "1.0"}
+	require.NoError(t, db.Create(definition).Error)
+
+	startedAt := time.Now().Add(-time.Hour).Truncate(time.Second)
+	for i, status := range []string{"running", "completed", "running"} {
+		require.NoError(t, repo.SaveInstance(ctx, &database.WorkflowInstance{
+			InstanceID:          fmt.Sprintf("it_list_%s_%d", suffix, i),
+			WorkflowID:          definition.WorkflowID,
+			DefinitionVersionID: definition.ID,
+			BusinessID:          fmt.Sprintf("%d", i),
+			BusinessType:        "integration",
+			Status:              status,
+			CurrentNodes:        database.JSONField{Data: []interface{}{"approve"}},
+			Variables:           database.JSONField{Data: map[string]interface{}{"big": "blob"}},
+			StartedBy:           7,
+			StartedAt:           startedAt.Add(time.Duration(i) * time.Minute),
+		}))
+	}
+
+	from := startedAt.Add(-time.Minute)
+	filter := &repository.WorkflowInstanceFilter{WorkflowName: suffix, Status: "running", StartedBy: 7, StartedFrom: &from, Page: 1, PageSize: 1}
+	rows, total, err := repo.ListInstances(ctx, filter)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	require.Len(t, rows, 1)
+	assert.Equal(t, "it_list_"+suffix+"_2", rows[0].InstanceID)
+	assert.Equal(t, definition.Name, rows[0].WorkflowName)
+	assert.Equal(t, []interface{}{"approve"}, rows[0].CurrentNodes.Data)
+
+	counts, err := repo.CountInstances(ctx, &repository.WorkflowInstanceFilter{WorkflowID: definition.WorkflowID})
+	require.NoError(t, err)
+	byStatus := map[string]int64{}
+	for _, count := range counts {
+		assert.Equal(t, definition.Name, count.WorkflowName)
+		byStatus[count.Status] = count.Count
+	}
+	assert.Equal(t, map[string]int64{"running": 2, "completed": 1}, byStatus)
+}
+
+func TestIntegration_ExecutionHistoryChain(t *testing.T) {
+	db := openIntegrationDB(t)
+	ctx := context.Background()
+	repo := NewWorkflowInstanceRepository(db)
+	suffix := uniqueSuffix()
+	instance := &database.WorkflowInstance{
+		InstanceID:   "it_chain_" + suffix,
+		WorkflowID:   "it_chain",
+		BusinessID:   "1",
+		BusinessType: "integration",
+		Status:       "running",
+		StartedBy:    7,
+		StartedAt:    time.Now(),
+	}
+	require.NoError(t, repo.SaveInstance(ctx, instance))
+
+	// 升级前写入的记录没有哈希
+	legacy := &database.WorkflowExecutionHistory{
+		HistoryID: "it_chain_legacy_" + suffix, InstanceID: instance.InstanceID, NodeID: "start", NodeName: "开始",
+		Action: "execute", Result: "success", ExecutedBy: 7, ExecutedAt: time.Now().Add(-time.Minute),
+	}
+	require.NoError(t, db.Create(legacy).Error)
+	verification, err := repo.VerifyExecutionHistory(ctx, instance.InstanceID)
+	require.NoError(t, err)
+	require.NotNil(t, verification.FirstBroken)
+	assert.Equal(t, legacy.HistoryID, verification.FirstBroken.HistoryID)
+
+	report, err := repo.BackfillExecutionHistoryChain(ctx)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, report.Sealed, 1)
+
+	// 追加的记录与实例状态在同一事务写入，并接到链尾
+	instance.Status = "cancelled"
+	require.NoError(t, repo.CancelInstance(ctx, instance, &database.WorkflowExecutionHistory{
+		HistoryID: "it_chain_cancel_" + suffix, InstanceID: instance.InstanceID, NodeName: "系统",
+		Action: "cancel", Result: "cancelled", Comment: "集成测试",
+		Variables:  database.JSONField{Data: map[string]interface{}{"amount": 12.5, "tags": []string{"b", "a"}}},
+		ExecutedAt: time.Now(),
+	}))
+	assert.ErrorIs(t, repo.CancelInstance(ctx, instance, &database.WorkflowExecutionHistory{
+		HistoryID: "it_chain_again_" + suffix, InstanceID: instance.InstanceID, ExecutedAt: time.Now(),
+	}), repository.ErrConcurrentUpdate)
+
+	verification, err = repo.VerifyExecutionHistory(ctx, instance.InstanceID)
+	require.NoError(t, err)
+	assert.Nil(t, verification.FirstBroken)
+	assert.Equal(t, 2, verification.Checked)
+
+	// 历史只允许追加
+	assert.ErrorIs(t, db.Model(legacy).Update("result", "rejected").Error, database.ErrExecutionHistoryImmutable)
+	assert.ErrorIs(t, db.Delete(legacy).Error, database.ErrExecutionHistoryImmutable)
+
+	// 绕过仓储直接改库会被校验发现
+	require.NoError(t, db.Exec("UPDATE workflow_execution_histories SET comment = ? WHERE history_id = ?", "篡改", "it_chain_cancel_"+suffix).Error)
+	verification, err = repo.VerifyExecutionHistory(ctx, instance.InstanceID)
+	require.NoError(t, err)
+	require.NotNil(t, verification.FirstBroken)
+	assert.Equal(t, 2, verification.FirstBroken.Position)
+}
+
+func TestIntegration_RunningInstanceUniqueness(t *testing.T) {
+	db := openIntegrationDB(t)
+	ctx := context.Background()
+	repo := NewWorkflowInstanceRepository(db)
+	suffix := uniqueSuffix()
+	businessID := "employee_it_" + suffix
+	newInstance := func(i int) *database.WorkflowInstance {
+		return &database.WorkflowInstance{
+			InstanceID:   fmt.Sprintf("it_running_%s_%d", suffix, i),
+			WorkflowID:   "it_running",
+			BusinessID:   businessID,
+			BusinessType: "onboarding",
+			Status:       "running",
+			StartedBy:    7,
+			StartedAt:    time.Now(),
+		}
+	}
+
+	// 两个重复提交并发写入，唯一索引只允许一个运行中的实例
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = repo.SaveInstance(ctx, newInstance(i))
+		}(i)
+	}
+	wg.Wait()
+	failed := 0
+	for _, err := range errs {
+		if err != nil {
+			failed++
+		}
+	}
+	assert.Equal(t, 1, failed)
+
+	running, err := repo.FindRunningByBusiness(ctx, "onboarding", businessID)
+	require.NoError(t, err)
+
+	// 实例结束后可以再次启动
+	require.NoError(t, repo.UpdateInstanceStatus(ctx, running.InstanceID, "completed"))
+	_, err = repo.FindRunningByBusiness(ctx, "onboarding", businessID)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	require.NoError(t, repo.SaveInstance(ctx, newInstance(2)))
+
+	// 不在独占范围内的业务类型不受约束
+	for i := 3; i < 5; i++ {
+		instance := newInstance(i)
+		instance.BusinessType = "integration"
+		require.NoError(t, repo.SaveInstance(ctx, instance))
+	}
+}
+
+func TestIntegration_ArchiveTasksAndInstances(t *testing.T) {
+	db := openIntegrationDB(t)
+	ctx := context.Background()
+	archiveRepo := NewArchiveRepository(db)
+	taskRepo := NewTaskRepository(db)
+	instanceRepo := NewWorkflowInstanceRepository(db)
+	employee := createIntegrationEmployee(t, db)
+	suffix := uniqueSuffix()
+
+	// 保留期之前完成的任务，其中一个仍有未结束的子任务
+	finishedAt := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	newTask := func(status string, parentID *uint) *database.Task {
+		task := &database.Task{Title: "it_archive_" + suffix, Status: status, CreatorID: employee.UserID, ParentID: parentID, CompletedAt: &finishedAt}
+		require.NoError(t, db.Create(task).Error)
+		return task
+	}
+	done := newTask("completed", nil)
+	blocked := newTask("completed", nil)
+	open := newTask("in_progress", &blocked.ID)
+
+	before := finishedAt.Add(time.Hour)
+	archivedIDs, err := archiveRepo.ArchiveTasks(ctx, before, 1000, time.Now())
+	require.NoError(t, err)
+	assert.Contains(t, archivedIDs, done.ID)
+	assert.NotContains(t, archivedIDs, blocked.ID)
+	assert.NotContains(t, archivedIDs, open.ID)
+
+	_, err = taskRepo.GetByID(ctx, done.ID)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	archived, err := taskRepo.GetArchivedByID(ctx, done.ID)
+	require.NoError(t, err)
+	assert.Equal(t, done.Title, archived.Title)
+
+	listIDs := func(includeArchived bool) []uint {
+		filters := map[string]interface{}{"title": done.Title}
+		if includeArchived {
+			filters["include_archived"] = true
+		}
+		tasks, total, err := taskRepo.List(ctx, repository.ListFilter{PageSize: 100, Sort: "id", Order: "asc", Filters: filters})
+		require.NoError(t, err)
+		require.Equal(t, int64(len(tasks)), total)
+		ids := make([]uint, 0, len(tasks))
+		for _, task := range tasks {
+			ids = append(ids, task.ID)
+		}
+		return ids
+	}
+	assert.Equal(t, []uint{blocked.ID, open.ID}, listIDs(false))
+	assert.Equal(t, []uint{done.ID, blocked.ID, open.ID}, listIDs(true))
+
+	require.NoError(t, archiveRepo.DeleteArchivedTask(ctx, done.ID))
+	assert.ErrorIs(t, archiveRepo.DeleteArchivedTask(ctx, done.ID), repository.ErrNotFound)
+	assert.ErrorIs(t, archiveRepo.DeleteArchivedTask(ctx, blocked.ID), repository.ErrNotFound)
+
+	instance := &database.WorkflowInstance{
+		InstanceID:   "it_archive_" + suffix,
+		WorkflowID:   "it_archive",
+		BusinessID:   suffix,
+		BusinessType: "integration",
+		Status:       "completed",
+		StartedBy:    7,
+		StartedAt:    finishedAt.Add(-time.Hour),
+		CompletedAt:  &finishedAt,
+	}
+	require.NoError(t, instanceRepo.SaveInstance(ctx, instance))
+	instanceIDs, err := archiveRepo.ArchiveWorkflowInstances(ctx, before, 1000, time.Now())
+	require.NoError(t, err)
+	assert.Contains(t, instanceIDs, instance.InstanceID)
+
+	filter := &repository.WorkflowInstanceFilter{BusinessID: suffix}
+	_, total, err := instanceRepo.ListInstances(ctx, filter)
+	require.NoError(t, err)
+	assert.Zero(t, total)
+	filter.IncludeArchived = true
+	rows, total, err := instanceRepo.ListInstances(ctx, filter)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	require.Len(t, rows, 1)
+	assert.NotNil(t, rows[0].ArchivedAt)
+
+	require.NoError(t, archiveRepo.DeleteArchivedWorkflowInstance(ctx, instance.InstanceID))
+	assert.ErrorIs(t, archiveRepo.DeleteArchivedWorkflowInstance(ctx, instance.InstanceID), repository.ErrNotFound)
+}
+
+func TestIntegration_FinishedInstanceClosesApprovals(t *testing.T) {
+	db := openIntegrationDB(t)
+	ctx := context.Background()
+	repo := NewWorkflowInstanceRepository(db)
+	suffix := uniqueSuffix()
+	instance := &database.WorkflowInstance{
+		InstanceID:   "it_close_" + suffix,
+		WorkflowID:   "it_close",
+		BusinessID:   "1",
+		BusinessType: "integration",
+		Status:       "running",
+		StartedBy:    7,
+		StartedAt:    time.Now(),
+	}
+	require.NoError(t, repo.SaveInstance(ctx, instance))
+	// 并行分支上的审批记录和发起人的查看记录
+	for _, userID := range []uint{5, 7} {
+		require.NoError(t, repo.CreatePendingApproval(ctx, &database.WorkflowPendingApproval{
+			InstanceID: instance.InstanceID, WorkflowName: "集成测试", NodeID: "branch", NodeName: "分支审批",
+			BusinessID: "1", BusinessType: "integration", AssignedTo: userID,
+		}))
+	}
+
+	// 暂停不关闭待审批记录
+	instance.Status = "suspended"
+	require.NoError(t, repo.UpdateInstance(ctx, instance))
+	count, err := repo.CountOpenPendingApprovals(ctx, instance.InstanceID, "branch")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	instance.Status = "completed"
+	require.NoError(t, repo.UpdateInstance(ctx, instance))
+	count, err = repo.CountOpenPendingApprovals(ctx, instance.InstanceID, "branch")
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+// queryCounter 统计执行的SQL语句数的GORM日志器，用于发现N+1查询
+type queryCounter struct {
+	mu    sync.Mutex
+	count int
+}
+
+func (c *queryCounter) LogMode(gormlogger.LogLevel) gormlogger.Interface { return c }
+
+func (c *queryCounter) Info(context.Context, string, ...interface{}) {}
+
+func (c *queryCounter) Warn(context.Context, string, ...interface{}) {}
+
+func (c *queryCounter) Error(context.Context, string, ...interface{}) {}
+
+func (c *queryCounter) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.count++
+}
+
+// reset 清零并返回之前的计数
+func (c *queryCounter) reset() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	count := c.count
+	c.count = 0
+	return count
+}
+
+// createIntegrationOrg 创建测试用部门和职位
+func createIntegrationOrg(t *testing.T, db *gorm.DB, suffix string) (*database.Department, *database.Position) {
+	t.Helper()
+	department := &database.Department{Name: "集成测试部门", Code: "it_dept_" + suffix}
+	require.NoError(t, db.Create(department).Error)
+	position := &database.Position{Name: "集成测试职位", Code: "it_pos_" + suffix, Level: 1}
+	require.NoError(t, db.Create(position).Error)
+	return department, position
+}
+
+func TestIntegration_ListEmployeesQueryCount(t *testing.T) {
+	// 50条一页的员工列表预期执行8条SQL，与页大小无关：
+	//   员工总数 1 + 员工 1 + 预加载用户、部门、职位 3
+	//   + 技能等级（关联employee_skills一次查询）1
+	//   + 项目成员 1 + 项目 1
+	const pageSize = 50
+	const maxQueries = 8
+
+	db := openIntegrationDB(t)
+	ctx := context.Background()
+	suffix := uniqueSuffix()
+	department, position := createIntegrationOrg(t, db, suffix)
+	skill := &database.Skill{Name: "it_skill_" + suffix}
+	require.NoError(t, db.Create(skill).Error)
+	project := &database.Project{Name: "集成测试项目", Code: "it_proj_" + suffix, DepartmentID: department.ID, ManagerID: 1}
+	require.NoError(t, db.Create(project).Error)
+	for i := 0; i < pageSize; i++ {
+		employee := createIntegrationEmployee(t, db)
+		require.NoError(t, db.Model(employee).Updates(map[string]interface{}{"department_id": department.ID, "position_id": position.ID}).Error)
+		require.NoError(t, db.Create(&database.EmployeeSkill{EmployeeID: employee.ID, SkillID: skill.ID, Level: 3}).Error)
+		require.NoError(t, db.Create(&database.ProjectMember{ProjectID: project.ID, EmployeeID: employee.ID}).Error)
+	}
+
+	counter := &queryCounter{}
+	repos := NewRepositoryManager(db.Session(&gorm.Session{Logger: counter}))
+	svc := service.NewEmployeeService(repos.EmployeeRepository(), repos.SkillRepository(), repos.UserRepository(),
+		repos.ProjectRepository(), repos.EmployeeCapacityRepository(), repos.PositionRepository(), repos.EmployeeChangeHistoryRepository(), nil, nil, config.WorkloadConfig{})
+
+	employees, _, err := svc.ListEmployees(ctx, service.EmployeeListFilter{Page: 1, PageSize: pageSize})
+	require.NoError(t, err)
+	require.Len(t, employees, pageSize)
+	for _, employee := range employees {
+		assert.Equal(t, department.Name, employee.Department)
+		assert.Equal(t, position.Name, employee.Position)
+		require.Len(t, employee.Skills, 1)
+		assert.Equal(t, 3, employee.Skills[0].Level)
+		assert.Equal(t, []string{project.Name}, employee.Projects)
+	}
+	assert.LessOrEqual(t, counter.reset(), maxQueries)
+}
+
+func TestIntegration_PendingOnboardingApprovalsQueryCount(t *testing.T) {
+	// 50条入职审批预期执行8条SQL，与审批数无关：
+	//   待审批总数 1 + 待审批 1 + 流程实例 1 + 发起人 1
+	//   + 员工 1 + 预加载用户、部门、职位 3
+	const approvals = 50
+	const maxQueries = 8
+
+	db := openIntegrationDB(t)
+	ctx := context.Background()
+	suffix := uniqueSuffix()
+	department, position := createIntegrationOrg(t, db, suffix)
+	approver := createIntegrationEmployee(t, db)
+	requester := createIntegrationEmployee(t, db)
+	for i := 0; i < approvals; i++ {
+		employee := createIntegrationEmployee(t, db)
+		require.NoError(t, db.Model(employee).Updates(map[string]interface{}{
+			"department_id": department.ID, "position_id": position.ID, "onboarding_status": "approval_pending",
+		}).Error)
+		instance := &database.WorkflowInstance{
+			InstanceID:   fmt.Sprintf("it_onboard_%s_%d", suffix, i),
+			WorkflowID:   "it_onboard",
+			BusinessID:   fmt.Sprintf("employee_%d", employee.ID),
+			BusinessType: "onboarding",
+			Status:       "running",
+			StartedBy:    requester.UserID,
+			StartedAt:    time.Now(),
+		}
+		require.NoError(t, db.Create(instance).Error)
+		require.NoError(t, db.Create(&database.WorkflowPendingApproval{
+			InstanceID: instance.InstanceID, WorkflowName: "入职审批", NodeID: "hr", NodeName: "HR审批",
+			BusinessID: instance.BusinessID, BusinessType: "onboarding", AssignedTo: approver.UserID,
+		}).Error)
+	}
+
+	counter := &queryCounter{}
+	repos := NewRepositoryManager(db.Session(&gorm.Session{Logger: counter}))
+	svc := service.NewOnboardingService(repos, nil, nil, nil, nil, nil, nil, "", "", nil, logrus.New())
+
+	result, err := svc.GetPendingOnboardingApprovals(ctx, approver.UserID)
+	require.NoError(t, err)
+	require.Len(t, result, approvals)
+	for _, approval := range result {
+		assert.Equal(t, department.Name, approval.Department)
+		assert.Equal(t, position.Name, approval.Position)
+		assert.NotEmpty(t, approval.RequesterName)
+	}
+	assert.LessOrEqual(t, counter.reset(), maxQueries)
+}
+
+func TestIntegration_ListOpenByAssignee(t *testing.T) {
+	db := openIntegrationDB(t)
+	ctx := context.Background()
+	repo := NewTaskRepository(db)
+	assignee := createIntegrationEmployee(t, db)
+	now := time.Now().Truncate(time.Second)
+	due := func(d time.Duration) *time.Time {
+		date := now.Add(d)
+		return &date
+	}
+	for _, task := range []*database.Task{
+		{Title: "无截止", Priority: "urgent", Status: "in_progress"},
+		{Title: "两天后低", Priority: "low", Status: "assigned", DueDate: due(48 * time.Hour)},
+		{Title: "两天后高", Priority: "high", Status: "assigned", DueDate: due(48 * time.Hour)},
+		{Title: "十天后", Priority: "medium", Status: "in_progress", DueDate: due(240 * time.Hour)},
+		{Title: "已完成", Priority: "high", Status: "completed", DueDate: due(time.Hour)},
+	} {
+		task.CreatorID = assignee.UserID
+		task.AssigneeID = &assignee.UserID
+		require.NoError(t, db.Create(task).Error)
+	}
+
+	counts, err := repo.CountByAssigneeGroupByStatus(ctx, assignee.UserID)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{"in_progress": 2, "assigned": 2, "completed": 1}, counts)
+
+	// 按截止时间升序，同一截止时间优先级高的在前，无截止时间的在最后
+	tasks, total, err := repo.ListOpenByAssignee(ctx, &repository.OpenTaskFilter{AssigneeID: assignee.UserID, Limit: 5})
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), total)
+	titles := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		titles = append(titles, task.Title)
+	}
+	assert.Equal(t, []string{"两天后高", "两天后低", "十天后", "无截止"}, titles)
+
+	tasks, total, err = repo.ListOpenByAssignee(ctx, &repository.OpenTaskFilter{AssigneeID: assignee.UserID, DueFrom: &now, DueTo: due(7 * 24 * time.Hour), Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, "两天后高", tasks[0].Title)
+}
+
+func TestIntegration_AssignmentStats(t *testing.T) {
+	db := openIntegrationDB(t)
+	ctx := context.Background()
+	repo := NewAssignmentRepository(db)
+	suffix := uniqueSuffix()
+	department := &database.Department{Name: "统计部门" + suffix, Code: "it_stats_" + suffix}
+	require.NoError(t, db.Create(department).Error)
+	assignee := createIntegrationEmployee(t, db)
+	require.NoError(t, db.Model(assignee).Update("department_id", department.ID).Error)
+
+	from := time.Now().Add(-time.Hour).Truncate(time.Second)
+	approvedAt := from.Add(2 * time.Hour)
+	for _, assignment := range []*database.Assignment{
+		{Method: "manual", Status: "approved", ApprovedAt: &approvedAt},
+		{Method: "manual", Status: "rejected", ApprovedAt: &approvedAt},
+		{Method: "auto_skill_match", Status: "approved"},
+		{Method: "manual", Status: "pending"},
+	} {
+		assignment.TaskID = 1
+		assignment.AssigneeID = assignee.UserID
+		assignment.AssignerID = assignee.UserID
+		assignment.AssignedAt = from
+		require.NoError(t, db.Create(assignment).Error)
+	}
+
+	filter := &repository.AssignmentStatsFilter{From: &from, DepartmentID: &department.ID}
+	byMethod, err := repo.CountGroupByMethod(ctx, filter)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{"manual": 3, "auto_skill_match": 1}, byMethod)
+
+	byStatus, err := repo.CountGroupByStatus(ctx, filter)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{"approved": 2, "rejected": 1, "pending": 1}, byStatus)
+
+	// 自动分配直接通过，没有审批时间，不计入审批统计
+	approval, err := repo.GetApprovalStats(ctx, filter)
+	require.NoError(t, err)
+	assert.Equal(t, &repository.AssignmentApprovalStats{Decided: 2, Rejected: 1, AvgApprovalSeconds: 7200}, approval)
+
+	departments, err := repo.CountGroupByDepartment(ctx, filter)
+	require.NoError(t, err)
+	require.Len(t, departments, 1)
+	assert.Equal(t, department.Name, departments[0].DepartmentName)
+	assert.Equal(t, int64(4), departments[0].Total)
+	assert.Equal(t, int64(2), departments[0].Approved)
+	assert.Equal(t, int64(1), departments[0].Pending)
+	assert.Equal(t, float64(7200), departments[0].AvgApprovalSeconds)
+}
+
+func TestIntegration_SkillGapQueries(t *testing.T) {
+	db := openIntegrationDB(t)
+	ctx := context.Background()
+	repo := NewReportRepository(db)
+	suffix := uniqueSuffix()
+	department := &database.Department{Name: "技能缺口部门" + suffix, Code: "it_gap_" + suffix}
+	require.NoError(t, db.Create(department).Error)
+	creator := createIntegrationEmployee(t, db)
+	require.NoError(t, db.Model(creator).Update("department_id", department.ID).Error)
+	outsider := createIntegrationEmployee(t, db)
+	project := &database.Project{Name: "技能缺口项目", Code: "it_gap_" + suffix, DepartmentID: department.ID, ManagerID: outsider.UserID}
+	require.NoError(t, db.Create(project).Error)
+
+	covered := &database.Skill{Name: "it_gap_go_" + suffix}
+	require.NoError(t, db.Create(covered).Error)
+	// 部门内没有员工掌握的技能
+	missing := &database.Skill{Name: "it_gap_rust_" + suffix}
+	require.NoError(t, db.Create(missing).Error)
+	require.NoError(t, db.Create(&database.EmployeeSkill{EmployeeID: creator.ID, SkillID: covered.ID, Level: 4}).Error)
+	require.NoError(t, db.Create(&database.EmployeeSkill{EmployeeID: outsider.ID, SkillID: missing.ID, Level: 5}).Error)
+
+	tasks := []*database.Task{
+		{Status: "pending", CreatorID: creator.UserID},                              // 部门员工创建
+		{Status: "in_progress", CreatorID: outsider.UserID, ProjectID: &project.ID}, // 部门项目
+		{Status: "completed", CreatorID: creator.UserID},                            // 已结束，不计入
+		{Status: "pending", CreatorID: outsider.UserID},                             // 不属于部门
+	}
+	for _, task := range tasks {
+		task.Title = "it_gap_task_" + uniqueSuffix()
+		require.NoError(t, db.Create(task).Error)
+		require.NoError(t, db.Create(&database.TaskSkill{TaskID: task.ID, SkillID: covered.ID, Level: 3, Required: true}).Error)
+	}
+	require.NoError(t, db.Create(&database.TaskSkill{TaskID: tasks[1].ID, SkillID: missing.ID, Level: 2, Required: true}).Error)
+
+	demand, err := repo.ListSkillDemand(ctx, department.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []*repository.SkillDemandRow{
+		{SkillID: covered.ID, SkillName: covered.Name, Level: 3, TaskCount: 2},
+		{SkillID: missing.ID, SkillName: missing.Name, Level: 2, TaskCount: 1},
+	}, demand)
+
+	supply, err := repo.ListSkillSupply(ctx, department.ID, []uint{covered.ID, missing.ID})
+	require.NoError(t, err)
+	assert.Equal(t, []*repository.SkillSupplyRow{{SkillID: covered.ID, Level: 4, EmployeeCount: 1}}, supply)
+}
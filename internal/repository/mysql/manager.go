@@ -20,6 +20,7 @@ type RepositoryManagerImpl struct {
 	permissionRepo        repository.PermissionRepository
 	employeeRepo          repository.EmployeeRepository
 	skillRepo             repository.SkillRepository
+	skillCategoryRepo     repository.SkillCategoryRepository
 	taskRepo              repository.TaskRepository
 	assignmentRepo        repository.AssignmentRepository
 	notificationRepo      repository.NotificationRepository
@@ -31,10 +32,33 @@ type RepositoryManagerImpl struct {
 	positionRepo          repository.PositionRepository
 	projectRepo           repository.ProjectRepository
 	onboardingHistoryRepo repository.OnboardingHistoryRepository
+	changeHistoryRepo     repository.EmployeeChangeHistoryRepository
+	resignationRepo       repository.ResignationRepository
+	timeEntryRepo         repository.TimeEntryRepository
+	capacityRepo          repository.EmployeeCapacityRepository
+	archiveRepo           repository.ArchiveRepository
+	taskWatcherRepo       repository.TaskWatcherRepository
+	taskCommentRepo       repository.TaskCommentRepository
+	taskEscalationRepo    repository.TaskEscalationRepository
+	taskDueReminderRepo   repository.TaskDueReminderRepository
+	taskLabelRepo         repository.TaskLabelRepository
+	taskAttachmentRepo    repository.TaskAttachmentRepository
+	activationTokenRepo   repository.AccountActivationTokenRepository
+	approvalChainRepo     repository.DepartmentApprovalChainRepository
+	holidayRepo           repository.HolidayRepository
+	reportRepo            repository.ReportRepository
+	notificationPrefRepo  repository.NotificationPreferenceRepository
+	emailOutboxRepo       repository.EmailOutboxRepository
+	webhookEndpointRepo   repository.WebhookEndpointRepository
+	webhookDeliveryRepo   repository.WebhookDeliveryRepository
+	counterRepo           repository.CounterRepository
+	taskTemplateRepo      repository.TaskTemplateRepository
+	milestoneRepo         repository.MilestoneRepository
 	
 	// 权限分配相关仓储
 	permissionTemplateRepo        repository.PermissionTemplateRepository
 	permissionRuleRepo            repository.PermissionRuleRepository
+	permissionRuleExecutionRepo   repository.PermissionRuleExecutionRepository
 	permissionAssignmentRepo      repository.PermissionAssignmentRepository
 	permissionAssignmentHistoryRepo repository.PermissionAssignmentHistoryRepository
 	onboardingPermissionConfigRepo repository.OnboardingPermissionConfigRepository
@@ -49,6 +73,7 @@ func NewRepositoryManager(db *gorm.DB) repository.RepositoryManager {
 		permissionRepo:       NewPermissionRepository(db),
 		employeeRepo:         NewEmployeeRepository(db),
 		skillRepo:            NewSkillRepository(db),
+		skillCategoryRepo:    NewSkillCategoryRepository(db),
 		taskRepo:             NewTaskRepository(db),
 		assignmentRepo:       NewAssignmentRepository(db),
 		notificationRepo:     NewNotificationRepository(db),
@@ -60,10 +85,33 @@ func NewRepositoryManager(db *gorm.DB) repository.RepositoryManager {
 		positionRepo:         NewPositionRepository(db),
 		projectRepo:          NewProjectRepository(db),
 		onboardingHistoryRepo: NewOnboardingHistoryRepository(db),
+		changeHistoryRepo:     NewEmployeeChangeHistoryRepository(db),
+		resignationRepo:       NewResignationRepository(db),
+		timeEntryRepo:         NewTimeEntryRepository(db),
+		capacityRepo:          NewEmployeeCapacityRepository(db),
+		archiveRepo:           NewArchiveRepository(db),
+		taskWatcherRepo:       NewTaskWatcherRepository(db),
+		taskCommentRepo:       NewTaskCommentRepository(db),
+		taskEscalationRepo:    NewTaskEscalationRepository(db),
+		taskDueReminderRepo:   NewTaskDueReminderRepository(db),
+		taskLabelRepo:         NewTaskLabelRepository(db),
+		taskAttachmentRepo:    NewTaskAttachmentRepository(db),
+		activationTokenRepo:   NewAccountActivationTokenRepository(db),
+		approvalChainRepo:     NewDepartmentApprovalChainRepository(db),
+		holidayRepo:           NewHolidayRepository(db),
+		reportRepo:            NewReportRepository(db),
+		notificationPrefRepo:  NewNotificationPreferenceRepository(db),
+		emailOutboxRepo:       NewEmailOutboxRepository(db),
+		webhookEndpointRepo:   NewWebhookEndpointRepository(db),
+		webhookDeliveryRepo:   NewWebhookDeliveryRepository(db),
+		counterRepo:           NewCounterRepository(db),
+		taskTemplateRepo:      NewTaskTemplateRepository(db),
+		milestoneRepo:         NewMilestoneRepository(db),
 		
 		// 权限分配相关仓储
 		permissionTemplateRepo:        NewPermissionTemplateRepository(db),
 		permissionRuleRepo:            NewPermissionRuleRepository(db),
+		permissionRuleExecutionRepo:   NewPermissionRuleExecutionRepository(db),
 		permissionAssignmentRepo:      NewPermissionAssignmentRepository(db),
 		permissionAssignmentHistoryRepo: NewPermissionAssignmentHistoryRepository(db),
 		onboardingPermissionConfigRepo: NewOnboardingPermissionConfigRepository(db),
@@ -150,6 +198,121 @@ func (m *RepositoryManagerImpl) OnboardingHistoryRepository() repository.Onboard
 	return m.onboardingHistoryRepo
 }
 
+// EmployeeChangeHistoryRepository 获取员工职位变更历史仓储
+func (m *RepositoryManagerImpl) EmployeeChangeHistoryRepository() repository.EmployeeChangeHistoryRepository {
+	return m.changeHistoryRepo
+}
+
+// ResignationRepository 获取离职申请仓储
+func (m *RepositoryManagerImpl) ResignationRepository() repository.ResignationRepository {
+	return m.resignationRepo
+}
+
+// TimeEntryRepository 获取任务工时记录仓储
+func (m *RepositoryManagerImpl) TimeEntryRepository() repository.TimeEntryRepository {
+	return m.timeEntryRepo
+}
+
+// SkillCategoryRepository 获取技能分类仓储
+func (m *RepositoryManagerImpl) SkillCategoryRepository() repository.SkillCategoryRepository {
+	return m.skillCategoryRepo
+}
+
+// EmployeeCapacityRepository 获取员工容量日历仓储
+func (m *RepositoryManagerImpl) EmployeeCapacityRepository() repository.EmployeeCapacityRepository {
+	return m.capacityRepo
+}
+
+// ArchiveRepository 获取归档仓储
+func (m *RepositoryManagerImpl) ArchiveRepository() repository.ArchiveRepository {
+	return m.archiveRepo
+}
+
+// TaskWatcherRepository 获取任务关注者仓储
+func (m *RepositoryManagerImpl) TaskWatcherRepository() repository.TaskWatcherRepository {
+	return m.taskWatcherRepo
+}
+
+// TaskCommentRepository 获取任务评论仓储
+func (m *RepositoryManagerImpl) TaskCommentRepository() repository.TaskCommentRepository {
+	return m.taskCommentRepo
+}
+
+// TaskEscalationRepository 获取任务优先级老化仓储
+func (m *RepositoryManagerImpl) TaskEscalationRepository() repository.TaskEscalationRepository {
+	return m.taskEscalationRepo
+}
+
+// TaskDueReminderRepository 获取任务截止提醒仓储
+func (m *RepositoryManagerImpl) TaskDueReminderRepository() repository.TaskDueReminderRepository {
+	return m.taskDueReminderRepo
+}
+
+// TaskLabelRepository 获取任务标签仓储
+func (m *RepositoryManagerImpl) TaskLabelRepository() repository.TaskLabelRepository {
+	return m.taskLabelRepo
+}
+
+// TaskAttachmentRepository 获取任务附件仓储
+func (m *RepositoryManagerImpl) TaskAttachmentRepository() repository.TaskAttachmentRepository {
+	return m.taskAttachmentRepo
+}
+
+// AccountActivationTokenRepository 获取账号激活令牌仓储
+func (m *RepositoryManagerImpl) AccountActivationTokenRepository() repository.AccountActivationTokenRepository {
+	return m.activationTokenRepo
+}
+
+// HolidayRepository 获取节假日仓储
+func (m *RepositoryManagerImpl) HolidayRepository() repository.HolidayRepository {
+	return m.holidayRepo
+}
+
+// DepartmentApprovalChainRepository 获取部门审批链仓储
+func (m *RepositoryManagerImpl) DepartmentApprovalChainRepository() repository.DepartmentApprovalChainRepository {
+	return m.approvalChainRepo
+}
+
+// ReportRepository 获取报表查询仓储
+func (m *RepositoryManagerImpl) ReportRepository() repository.ReportRepository {
+	return m.reportRepo
+}
+
+// NotificationPreferenceRepository 获取通知偏好仓储
+func (m *RepositoryManagerImpl) NotificationPreferenceRepository() repository.NotificationPreferenceRepository {
+	return m.notificationPrefRepo
+}
+
+// EmailOutboxRepository 获取邮件发件箱仓储
+func (m *RepositoryManagerImpl) EmailOutboxRepository() repository.EmailOutboxRepository {
+	return m.emailOutboxRepo
+}
+
+// WebhookEndpointRepository 获取Webhook端点仓储
+func (m *RepositoryManagerImpl) WebhookEndpointRepository() repository.WebhookEndpointRepository {
+	return m.webhookEndpointRepo
+}
+
+// WebhookDeliveryRepository 获取Webhook投递记录仓储
+func (m *RepositoryManagerImpl) WebhookDeliveryRepository() repository.WebhookDeliveryRepository {
+	return m.webhookDeliveryRepo
+}
+
+// CounterRepository 获取计数器仓储
+func (m *RepositoryManagerImpl) CounterRepository() repository.CounterRepository {
+	return m.counterRepo
+}
+
+// TaskTemplateRepository 获取任务模板仓储
+func (m *RepositoryManagerImpl) TaskTemplateRepository() repository.TaskTemplateRepository {
+	return m.taskTemplateRepo
+}
+
+// MilestoneRepository 获取项目里程碑仓储
+func (m *RepositoryManagerImpl) MilestoneRepository() repository.MilestoneRepository {
+	return m.milestoneRepo
+}
+
 // PermissionTemplateRepository 获取权限模板仓储
 func (m *RepositoryManagerImpl) PermissionTemplateRepository() repository.PermissionTemplateRepository {
 	return m.permissionTemplateRepo
@@ -160,6 +323,11 @@ func (m *RepositoryManagerImpl) PermissionRuleRepository() repository.Permission
 	return m.permissionRuleRepo
 }
 
+// PermissionRuleExecutionRepository 获取权限规则执行记录仓储
+func (m *RepositoryManagerImpl) PermissionRuleExecutionRepository() repository.PermissionRuleExecutionRepository {
+	return m.permissionRuleExecutionRepo
+}
+
 // PermissionAssignmentRepository 获取权限分配仓储
 func (m *RepositoryManagerImpl) PermissionAssignmentRepository() repository.PermissionAssignmentRepository {
 	return m.permissionAssignmentRepo
@@ -190,6 +358,7 @@ func (m *RepositoryManagerImpl) WithTx(ctx context.Context, fn func(ctx context.
 			permissionRepo:       NewPermissionRepository(tx),
 			employeeRepo:         NewEmployeeRepository(tx),
 			skillRepo:            NewSkillRepository(tx),
+			skillCategoryRepo:    NewSkillCategoryRepository(tx),
 			taskRepo:             NewTaskRepository(tx),
 			assignmentRepo:       NewAssignmentRepository(tx),
 			notificationRepo:     NewNotificationRepository(tx),
@@ -201,10 +370,33 @@ func (m *RepositoryManagerImpl) WithTx(ctx context.Context, fn func(ctx context.
 			positionRepo:         NewPositionRepository(tx),
 			projectRepo:          NewProjectRepository(tx),
 			onboardingHistoryRepo: NewOnboardingHistoryRepository(tx),
+			changeHistoryRepo:     NewEmployeeChangeHistoryRepository(tx),
+			resignationRepo:       NewResignationRepository(tx),
+			timeEntryRepo:         NewTimeEntryRepository(tx),
+			capacityRepo:          NewEmployeeCapacityRepository(tx),
+			archiveRepo:           NewArchiveRepository(tx),
+			taskWatcherRepo:       NewTaskWatcherRepository(tx),
+			taskCommentRepo:       NewTaskCommentRepository(tx),
+			taskEscalationRepo:    NewTaskEscalationRepository(tx),
+			taskDueReminderRepo:   NewTaskDueReminderRepository(tx),
+			taskLabelRepo:         NewTaskLabelRepository(tx),
+			taskAttachmentRepo:    NewTaskAttachmentRepository(tx),
+			activationTokenRepo:   NewAccountActivationTokenRepository(tx),
+			approvalChainRepo:     NewDepartmentApprovalChainRepository(tx),
+			holidayRepo:           NewHolidayRepository(tx),
+			reportRepo:            NewReportRepository(tx),
+			notificationPrefRepo:  NewNotificationPreferenceRepository(tx),
+			emailOutboxRepo:       NewEmailOutboxRepository(tx),
+			webhookEndpointRepo:   NewWebhookEndpointRepository(tx),
+			webhookDeliveryRepo:   NewWebhookDeliveryRepository(tx),
+			counterRepo:           NewCounterRepository(tx),
+			taskTemplateRepo:      NewTaskTemplateRepository(tx),
+			milestoneRepo:         NewMilestoneRepository(tx),
 			
 			// 权限分配相关仓储
 			permissionTemplateRepo:        NewPermissionTemplateRepository(tx),
 			permissionRuleRepo:            NewPermissionRuleRepository(tx),
+			permissionRuleExecutionRepo:   NewPermissionRuleExecutionRepository(tx),
 			permissionAssignmentRepo:      NewPermissionAssignmentRepository(tx),
 			permissionAssignmentHistoryRepo: NewPermissionAssignmentHistoryRepository(tx),
 			onboardingPermissionConfigRepo: NewOnboardingPermissionConfigRepository(tx),
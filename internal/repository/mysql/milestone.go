@@ -0,0 +1,69 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+// MilestoneRepositoryImpl 项目里程碑仓储MySQL实现
+type MilestoneRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewMilestoneRepository 创建项目里程碑仓储
+func NewMilestoneRepository(db *gorm.DB) repository.MilestoneRepository {
+	return &MilestoneRepositoryImpl{db: db}
+}
+
+// Create 创建里程碑
+func (r *MilestoneRepositoryImpl) Create(ctx context.Context, milestone *database.Milestone) error {
+	return r.db.WithContext(ctx).Create(milestone).Error
+}
+
+// GetByID 根据ID获取里程碑
+func (r *MilestoneRepositoryImpl) GetByID(ctx context.Context, id uint) (*database.Milestone, error) {
+	var milestone database.Milestone
+	if err := r.db.WithContext(ctx).First(&milestone, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, err
+	}
+	return &milestone, nil
+}
+
+// Update 更新里程碑
+func (r *MilestoneRepositoryImpl) Update(ctx context.Context, milestone *database.Milestone) error {
+	return r.db.WithContext(ctx).Save(milestone).Error
+}
+
+// Delete 删除里程碑
+func (r *MilestoneRepositoryImpl) Delete(ctx context.Context, id uint) error {
+	result := r.db.WithContext(ctx).Delete(&database.Milestone{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// ListByProject 获取项目的里程碑，按日期排序
+func (r *MilestoneRepositoryImpl) ListByProject(ctx context.Context, projectID uint, from, to *time.Time) ([]*database.Milestone, error) {
+	query := r.db.WithContext(ctx).Where("project_id = ?", projectID)
+	if from != nil {
+		query = query.Where("date >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where("date <= ?", *to)
+	}
+	var milestones []*database.Milestone
+	err := query.Order("date ASC, id ASC").Find(&milestones).Error
+	return milestones, err
+}
@@ -0,0 +1,62 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+// NotificationPreferenceRepositoryImpl 通知偏好仓储MySQL实现
+type NotificationPreferenceRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewNotificationPreferenceRepository 创建通知偏好仓储
+func NewNotificationPreferenceRepository(db *gorm.DB) repository.NotificationPreferenceRepository {
+	return &NotificationPreferenceRepositoryImpl{db: db}
+}
+
+// ListByUser 获取用户已设置的全部通知偏好，按类别和渠道排序
+func (r *NotificationPreferenceRepositoryImpl) ListByUser(ctx context.Context, userID uint) ([]*database.NotificationPreference, error) {
+	var preferences []*database.NotificationPreference
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("category ASC").Order("channel ASC").
+		Find(&preferences).Error
+	if err != nil {
+		return nil, err
+	}
+	return preferences, nil
+}
+
+// Get 获取用户在某类别和渠道上的偏好
+func (r *NotificationPreferenceRepositoryImpl) Get(ctx context.Context, userID uint, category, channel string) (*database.NotificationPreference, error) {
+	var preference database.NotificationPreference
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND category = ? AND channel = ?", userID, category, channel).
+		First(&preference).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, err
+	}
+	return &preference, nil
+}
+
+// Upsert 按用户、类别和渠道写入偏好
+func (r *NotificationPreferenceRepositoryImpl) Upsert(ctx context.Context, preferences []*database.NotificationPreference) error {
+	if len(preferences) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "category"}, {Name: "channel"}},
+			DoUpdates: clause.AssignmentColumns([]string{"enabled", "updated_at"}),
+		}).
+		Create(&preferences).Error
+}
@@ -152,3 +152,30 @@ func (r *onboardingPermissionConfigRepository) GetGlobalConfig(ctx context.Conte
 	}
 	return &config, nil
 }
+
+// MoveToDepartment 将部门入职权限配置迁移到目标部门
+func (r *onboardingPermissionConfigRepository) MoveToDepartment(ctx context.Context, fromDepartmentID, toDepartmentID uint) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Model(&database.OnboardingPermissionConfig{}).
+		Where("department_id = ?", fromDepartmentID).
+		Update("department_id", toDepartmentID)
+	return result.RowsAffected, result.Error
+}
+
+// MoveToPosition 将职位入职权限配置迁移到目标职位
+func (r *onboardingPermissionConfigRepository) MoveToPosition(ctx context.Context, fromPositionID, toPositionID uint) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Model(&database.OnboardingPermissionConfig{}).
+		Where("position_id = ?", fromPositionID).
+		Update("position_id", toPositionID)
+	return result.RowsAffected, result.Error
+}
+
+// CountByTemplateID 统计以该模板为默认或下一级模板的入职权限配置数
+func (r *onboardingPermissionConfigRepository) CountByTemplateID(ctx context.Context, templateID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&database.OnboardingPermissionConfig{}).
+		Where("default_template_id = ? OR next_level_template_id = ?", templateID, templateID).
+		Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,186 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+
+	"taskmanage/internal/database"
+	"taskmanage/pkg/logger"
+)
+
+// orgChartRow 组织架构遍历的一行，Depth为相对起点员工的层级
+type orgChartRow struct {
+	ID              uint
+	DirectManagerID *uint
+	Depth           int
+}
+
+// 递归CTE同时携带层级，超过层级上限即停止递归，数据成环时也不会无限展开
+const (
+	managerChainCTE = `
+		WITH RECURSIVE chain (id, direct_manager_id, depth) AS (
+			SELECT id, direct_manager_id, 0 FROM employees WHERE id = ? AND deleted_at IS NULL
+			UNION ALL
+			SELECT e.id, e.direct_manager_id, c.depth + 1 FROM employees e
+			INNER JOIN chain c ON e.id = c.direct_manager_id
+			WHERE c.depth < ? AND e.deleted_at IS NULL
+		)
+		SELECT id, direct_manager_id, depth FROM chain ORDER BY depth
+	`
+	reportsCTE = `
+		WITH RECURSIVE tree (id, direct_manager_id, depth) AS (
+			SELECT id, direct_manager_id, 1 FROM employees WHERE direct_manager_id = ? AND deleted_at IS NULL
+			UNION ALL
+			SELECT e.id, e.direct_manager_id, t.depth + 1 FROM employees e
+			INNER JOIN tree t ON e.direct_manager_id = t.id
+			WHERE t.depth < ? AND e.deleted_at IS NULL
+		)
+		SELECT id, direct_manager_id, depth FROM tree ORDER BY depth, id
+	`
+)
+
+// ListManagerChain 从员工本人开始逐级向上获取上级链，最多maxLevels级上级，员工不存在时返回空
+// 优先使用递归CTE，数据库不支持时（如MySQL 5.7）逐级查询
+func (r *EmployeeRepositoryImpl) ListManagerChain(ctx context.Context, employeeID uint, maxLevels int) ([]*database.Employee, error) {
+	var rows []orgChartRow
+	if err := r.db.WithContext(ctx).Raw(managerChainCTE, employeeID, maxLevels).Scan(&rows).Error; err != nil {
+		logger.Warnf("递归查询上级链失败，改为逐级查询: %v", err)
+		if rows, err = r.walkManagerChain(ctx, employeeID, maxLevels); err != nil {
+			return nil, fmt.Errorf("获取上级链失败: %w", err)
+		}
+	}
+	return r.loadOrgChartEmployees(ctx, managerChainIDs(employeeID, rows))
+}
+
+// ListReports 获取maxDepth层以内的直接和间接下属
+// 优先使用递归CTE，数据库不支持时按层级逐层查询
+func (r *EmployeeRepositoryImpl) ListReports(ctx context.Context, employeeID uint, maxDepth int) ([]*database.Employee, error) {
+	if maxDepth < 1 {
+		return nil, nil
+	}
+
+	var rows []orgChartRow
+	if err := r.db.WithContext(ctx).Raw(reportsCTE, employeeID, maxDepth).Scan(&rows).Error; err != nil {
+		logger.Warnf("递归查询下属失败，改为逐层查询: %v", err)
+		if rows, err = r.walkReports(ctx, employeeID, maxDepth); err != nil {
+			return nil, fmt.Errorf("获取下属失败: %w", err)
+		}
+	}
+	return r.loadOrgChartEmployees(ctx, reportIDs(employeeID, rows))
+}
+
+// walkManagerChain 逐级查询上级链，遇到已访问的员工时保留该行后停止，由managerChainIDs统一记录成环
+func (r *EmployeeRepositoryImpl) walkManagerChain(ctx context.Context, employeeID uint, maxLevels int) ([]orgChartRow, error) {
+	var rows []orgChartRow
+	visited := make(map[uint]bool)
+	nextID := &employeeID
+	for depth := 0; nextID != nil && depth <= maxLevels; depth++ {
+		var row orgChartRow
+		result := r.db.WithContext(ctx).Model(&database.Employee{}).
+			Select("id", "direct_manager_id").
+			Where("id = ?", *nextID).
+			Limit(1).Find(&row)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 0 {
+			break
+		}
+		row.Depth = depth
+		rows = append(rows, row)
+		if visited[row.ID] {
+			break
+		}
+		visited[row.ID] = true
+		nextID = row.DirectManagerID
+	}
+	return rows, nil
+}
+
+// walkReports 按层级逐层查询下属，已访问的员工不再展开
+func (r *EmployeeRepositoryImpl) walkReports(ctx context.Context, employeeID uint, maxDepth int) ([]orgChartRow, error) {
+	var rows []orgChartRow
+	visited := map[uint]bool{employeeID: true}
+	frontier := []uint{employeeID}
+	for depth := 1; len(frontier) > 0 && depth <= maxDepth; depth++ {
+		var level []orgChartRow
+		err := r.db.WithContext(ctx).Model(&database.Employee{}).
+			Select("id", "direct_manager_id").
+			Where("direct_manager_id IN ?", frontier).
+			Order("id").
+			Find(&level).Error
+		if err != nil {
+			return nil, err
+		}
+		frontier = frontier[:0]
+		for _, row := range level {
+			if visited[row.ID] {
+				continue
+			}
+			visited[row.ID] = true
+			row.Depth = depth
+			rows = append(rows, row)
+			frontier = append(frontier, row.ID)
+		}
+	}
+	return rows, nil
+}
+
+// managerChainIDs 按层级顺序提取员工本人及上级ID，员工重复出现说明上级链成环，记录日志并在此处截断
+func managerChainIDs(employeeID uint, rows []orgChartRow) []uint {
+	visited := make(map[uint]bool)
+	var ids []uint
+	for _, row := range rows {
+		if visited[row.ID] {
+			logger.Warnf("员工%d的上级链在第%d级回到员工%d，存在循环汇报关系，已截断", employeeID, row.Depth, row.ID)
+			break
+		}
+		visited[row.ID] = true
+		ids = append(ids, row.ID)
+	}
+	return ids
+}
+
+// reportIDs 按层级顺序提取下属ID，成环时同一员工会在更深层级重复出现，只保留首次出现
+func reportIDs(employeeID uint, rows []orgChartRow) []uint {
+	visited := map[uint]bool{employeeID: true}
+	var ids []uint
+	for _, row := range rows {
+		if visited[row.ID] {
+			continue
+		}
+		visited[row.ID] = true
+		ids = append(ids, row.ID)
+	}
+	return ids
+}
+
+// loadOrgChartEmployees 按ids顺序加载员工，预加载用户、部门和职位
+func (r *EmployeeRepositoryImpl) loadOrgChartEmployees(ctx context.Context, ids []uint) ([]*database.Employee, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var employees []*database.Employee
+	err := r.db.WithContext(ctx).
+		Preload("User").
+		Preload("Department").
+		Preload("Position").
+		Where("id IN ?", ids).
+		Find(&employees).Error
+	if err != nil {
+		return nil, fmt.Errorf("获取组织架构员工失败: %w", err)
+	}
+
+	byID := make(map[uint]*database.Employee, len(employees))
+	for _, employee := range employees {
+		byID[employee.ID] = employee
+	}
+	ordered := make([]*database.Employee, 0, len(employees))
+	for _, id := range ids {
+		if employee, ok := byID[id]; ok {
+			ordered = append(ordered, employee)
+		}
+	}
+	return ordered, nil
+}
@@ -0,0 +1,45 @@
+package mysql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManagerChainIDs(t *testing.T) {
+	managerOf := func(id uint) *uint { return &id }
+
+	// 1 -> 2 -> 3 -> 2，2和3互为上级，在2第二次出现前截断
+	rows := []orgChartRow{
+		{ID: 1, DirectManagerID: managerOf(2), Depth: 0},
+		{ID: 2, DirectManagerID: managerOf(3), Depth: 1},
+		{ID: 3, DirectManagerID: managerOf(2), Depth: 2},
+		{ID: 2, DirectManagerID: managerOf(3), Depth: 3},
+		{ID: 3, DirectManagerID: managerOf(2), Depth: 4},
+	}
+	assert.Equal(t, []uint{1, 2, 3}, managerChainIDs(1, rows))
+
+	// 上级链回到员工本人
+	rows = []orgChartRow{
+		{ID: 1, DirectManagerID: managerOf(2), Depth: 0},
+		{ID: 2, DirectManagerID: managerOf(1), Depth: 1},
+		{ID: 1, DirectManagerID: managerOf(2), Depth: 2},
+	}
+	assert.Equal(t, []uint{1, 2}, managerChainIDs(1, rows))
+
+	assert.Empty(t, managerChainIDs(1, nil))
+}
+
+func TestReportIDs(t *testing.T) {
+	managerOf := func(id uint) *uint { return &id }
+
+	// 1和2互为上级，递归展开时1和2在更深层级重复出现
+	rows := []orgChartRow{
+		{ID: 2, DirectManagerID: managerOf(1), Depth: 1},
+		{ID: 4, DirectManagerID: managerOf(1), Depth: 1},
+		{ID: 1, DirectManagerID: managerOf(2), Depth: 2},
+		{ID: 5, DirectManagerID: managerOf(2), Depth: 2},
+		{ID: 2, DirectManagerID: managerOf(1), Depth: 3},
+	}
+	assert.Equal(t, []uint{2, 4, 5}, reportIDs(1, rows))
+}
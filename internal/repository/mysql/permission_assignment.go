@@ -2,6 +2,7 @@ package mysql
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -34,6 +35,9 @@ func (r *permissionAssignmentRepository) GetByID(ctx context.Context, id uint) (
 		Preload("ApprovedByUser").
 		First(&assignment, id).Error
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrNotFound
+		}
 		return nil, err
 	}
 	return &assignment, nil
@@ -152,3 +156,12 @@ func (r *permissionAssignmentRepository) BulkUpdateStatus(ctx context.Context, i
 		Where("id IN ?", ids).
 		Update("status", status).Error
 }
+
+// CountActiveByTemplateID 统计通过该模板分配且生效中或待审批的记录数
+func (r *permissionAssignmentRepository) CountActiveByTemplateID(ctx context.Context, templateID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&database.PermissionAssignment{}).
+		Where("template_id = ? AND status IN ?", templateID, []string{database.PermissionStatusActive, database.PermissionStatusPending}).
+		Count(&count).Error
+	return count, err
+}
@@ -2,6 +2,7 @@ package mysql
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"gorm.io/gorm"
@@ -28,6 +29,9 @@ func (r *permissionRuleRepository) GetByID(ctx context.Context, id uint) (*datab
 		Preload("Template").
 		First(&rule, id).Error
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrNotFound
+		}
 		return nil, err
 	}
 	return &rule, nil
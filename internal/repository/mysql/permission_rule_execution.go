@@ -0,0 +1,50 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+// PermissionRuleExecutionRepositoryImpl 权限规则执行记录仓储MySQL实现
+type PermissionRuleExecutionRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewPermissionRuleExecutionRepository 创建权限规则执行记录仓储
+func NewPermissionRuleExecutionRepository(db *gorm.DB) repository.PermissionRuleExecutionRepository {
+	return &PermissionRuleExecutionRepositoryImpl{db: db}
+}
+
+// Record 记录规则执行，主键冲突说明该规则已对用户执行过
+func (r *PermissionRuleExecutionRepositoryImpl) Record(ctx context.Context, execution *database.PermissionRuleExecution) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(execution)
+	if result.Error != nil {
+		return false, fmt.Errorf("记录权限规则执行失败: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ListExecutedUserIDs 获取规则已执行过的用户ID
+func (r *PermissionRuleExecutionRepositoryImpl) ListExecutedUserIDs(ctx context.Context, ruleID uint) (map[uint]bool, error) {
+	var userIDs []uint
+	err := r.db.WithContext(ctx).
+		Model(&database.PermissionRuleExecution{}).
+		Where("rule_id = ?", ruleID).
+		Pluck("user_id", &userIDs).Error
+	if err != nil {
+		return nil, fmt.Errorf("获取权限规则执行记录失败: %w", err)
+	}
+	executed := make(map[uint]bool, len(userIDs))
+	for _, userID := range userIDs {
+		executed[userID] = true
+	}
+	return executed, nil
+}
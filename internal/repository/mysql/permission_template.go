@@ -2,9 +2,11 @@ package mysql
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"taskmanage/internal/database"
 	"taskmanage/internal/repository"
@@ -29,6 +31,9 @@ func (r *permissionTemplateRepository) GetByID(ctx context.Context, id uint) (*d
 		Preload("Rules").
 		First(&template, id).Error
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrNotFound
+		}
 		return nil, err
 	}
 	return &template, nil
@@ -42,6 +47,9 @@ func (r *permissionTemplateRepository) GetByCode(ctx context.Context, code strin
 		Where("code = ?", code).
 		First(&template).Error
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrNotFound
+		}
 		return nil, err
 	}
 	return &template, nil
@@ -82,8 +90,9 @@ func (r *permissionTemplateRepository) List(ctx context.Context, filter *reposit
 	return templates, err
 }
 
+// Update 更新模板字段，不写入关联的权限和规则，权限通过ReplacePermissions修改
 func (r *permissionTemplateRepository) Update(ctx context.Context, template *database.PermissionTemplate) error {
-	return r.db.WithContext(ctx).Save(template).Error
+	return r.db.WithContext(ctx).Omit(clause.Associations).Save(template).Error
 }
 
 func (r *permissionTemplateRepository) Delete(ctx context.Context, id uint) error {
@@ -119,3 +128,26 @@ func (r *permissionTemplateRepository) GetByDepartmentAndPosition(ctx context.Co
 	err := query.Order("level ASC").Find(&templates).Error
 	return templates, err
 }
+
+// MoveToDepartment 将部门专属权限模板迁移到目标部门
+func (r *permissionTemplateRepository) MoveToDepartment(ctx context.Context, fromDepartmentID, toDepartmentID uint) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Model(&database.PermissionTemplate{}).
+		Where("department_id = ?", fromDepartmentID).
+		Update("department_id", toDepartmentID)
+	return result.RowsAffected, result.Error
+}
+
+// MoveToPosition 将职位专属权限模板迁移到目标职位
+func (r *permissionTemplateRepository) MoveToPosition(ctx context.Context, fromPositionID, toPositionID uint) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Model(&database.PermissionTemplate{}).
+		Where("position_id = ?", fromPositionID).
+		Update("position_id", toPositionID)
+	return result.RowsAffected, result.Error
+}
+
+// ReplacePermissions 替换模板包含的权限，只维护template_permissions关联，不修改权限本身
+func (r *permissionTemplateRepository) ReplacePermissions(ctx context.Context, template *database.PermissionTemplate, permissions []database.Permission) error {
+	return r.db.WithContext(ctx).Omit("Permissions.*").Model(template).Association("Permissions").Replace(permissions)
+}
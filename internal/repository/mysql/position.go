@@ -2,6 +2,7 @@ package mysql
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"gorm.io/gorm"
@@ -84,6 +85,18 @@ func (r *PositionRepositoryImpl) GetByName(ctx context.Context, name string) (*d
 	return &position, nil
 }
 
+// GetByCode 根据编码获取职位，不存在时返回ErrNotFound
+func (r *PositionRepositoryImpl) GetByCode(ctx context.Context, code string) (*database.Position, error) {
+	var position database.Position
+	if err := r.db.WithContext(ctx).Where("code = ?", code).First(&position).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("根据编码获取职位失败: %w", err)
+	}
+	return &position, nil
+}
+
 // GetByCategory 根据类别获取职位
 func (r *PositionRepositoryImpl) GetByCategory(ctx context.Context, category string) ([]*database.Position, error) {
 	var positions []*database.Position
@@ -107,3 +120,35 @@ func (r *PositionRepositoryImpl) GetAllCategories(ctx context.Context) ([]string
 		Pluck("category", &categories).Error
 	return categories, err
 }
+
+// GetByIDUnscoped 根据ID获取职位，包含已软删除的职位
+func (r *PositionRepositoryImpl) GetByIDUnscoped(ctx context.Context, id uint) (*database.Position, error) {
+	var position database.Position
+	err := r.db.WithContext(ctx).Unscoped().First(&position, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, err
+	}
+	return &position, nil
+}
+
+// CountReferences 一次查询统计引用职位的记录数，已软删除的记录和离职员工不计入
+func (r *PositionRepositoryImpl) CountReferences(ctx context.Context, positionID uint) (*repository.PositionReferences, error) {
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM employees WHERE position_id = @id AND status <> @resigned AND deleted_at IS NULL) AS employees,
+			(SELECT COUNT(*) FROM permission_templates WHERE position_id = @id AND deleted_at IS NULL) AS permission_templates,
+			(SELECT COUNT(*) FROM onboarding_permission_configs WHERE position_id = @id AND deleted_at IS NULL) AS onboarding_configs
+	`
+
+	var refs repository.PositionReferences
+	err := r.db.WithContext(ctx).
+		Raw(query, map[string]interface{}{"id": positionID, "resigned": "resigned"}).
+		Scan(&refs).Error
+	if err != nil {
+		return nil, fmt.Errorf("统计职位引用失败: %w", err)
+	}
+	return &refs, nil
+}
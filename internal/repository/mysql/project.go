@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"taskmanage/internal/database"
 	"taskmanage/internal/repository"
 )
@@ -96,6 +97,18 @@ func (r *ProjectRepositoryImpl) GetByName(ctx context.Context, name string) (*da
 	return &project, nil
 }
 
+// GetByIDs 批量获取项目，只用于展示项目名称等基本信息，不预加载关联
+func (r *ProjectRepositoryImpl) GetByIDs(ctx context.Context, ids []uint) ([]*database.Project, error) {
+	var projects []*database.Project
+	if len(ids) == 0 {
+		return projects, nil
+	}
+	if err := r.db.WithContext(ctx).Where("id IN ?", ids).Order("id ASC").Find(&projects).Error; err != nil {
+		return nil, fmt.Errorf("批量获取项目失败: %w", err)
+	}
+	return projects, nil
+}
+
 // GetByDepartmentID 根据部门ID获取项目
 func (r *ProjectRepositoryImpl) GetByDepartmentID(ctx context.Context, departmentID uint) ([]*database.Project, error) {
 	var projects []*database.Project
@@ -108,6 +121,15 @@ func (r *ProjectRepositoryImpl) GetByDepartmentID(ctx context.Context, departmen
 	return projects, err
 }
 
+// MoveToDepartment 将部门全部项目迁移到目标部门
+func (r *ProjectRepositoryImpl) MoveToDepartment(ctx context.Context, fromDepartmentID, toDepartmentID uint) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Model(&database.Project{}).
+		Where("department_id = ?", fromDepartmentID).
+		Update("department_id", toDepartmentID)
+	return result.RowsAffected, result.Error
+}
+
 // GetByManagerID 根据管理者ID获取项目
 func (r *ProjectRepositoryImpl) GetByManagerID(ctx context.Context, managerID uint) ([]*database.Project, error) {
 	var projects []*database.Project
@@ -120,6 +142,61 @@ func (r *ProjectRepositoryImpl) GetByManagerID(ctx context.Context, managerID ui
 	return projects, err
 }
 
+// GetByMemberID 获取员工参与的项目
+func (r *ProjectRepositoryImpl) GetByMemberID(ctx context.Context, employeeID uint) ([]*database.Project, error) {
+	var projects []*database.Project
+	err := r.db.WithContext(ctx).
+		Joins("JOIN project_members ON project_members.project_id = projects.id").
+		Where("project_members.employee_id = ?", employeeID).
+		Find(&projects).Error
+	return projects, err
+}
+
+// GetByMemberIDs 批量获取多名员工参与的项目，先查成员关系再一次加载项目
+func (r *ProjectRepositoryImpl) GetByMemberIDs(ctx context.Context, employeeIDs []uint) (map[uint][]*database.Project, error) {
+	result := make(map[uint][]*database.Project, len(employeeIDs))
+	if len(employeeIDs) == 0 {
+		return result, nil
+	}
+
+	var members []struct {
+		ProjectID  uint
+		EmployeeID uint
+	}
+	err := r.db.WithContext(ctx).
+		Table("project_members").
+		Select("project_id, employee_id").
+		Where("employee_id IN ?", employeeIDs).
+		Order("project_id ASC").
+		Scan(&members).Error
+	if err != nil {
+		return nil, fmt.Errorf("获取项目成员失败: %w", err)
+	}
+	if len(members) == 0 {
+		return result, nil
+	}
+
+	projectIDs := make([]uint, 0, len(members))
+	for _, member := range members {
+		projectIDs = append(projectIDs, member.ProjectID)
+	}
+	var projects []*database.Project
+	if err := r.db.WithContext(ctx).Where("id IN ?", projectIDs).Order("id ASC").Find(&projects).Error; err != nil {
+		return nil, fmt.Errorf("获取员工项目失败: %w", err)
+	}
+
+	byID := make(map[uint]*database.Project, len(projects))
+	for _, project := range projects {
+		byID[project.ID] = project
+	}
+	for _, member := range members {
+		if project, ok := byID[member.ProjectID]; ok {
+			result[member.EmployeeID] = append(result[member.EmployeeID], project)
+		}
+	}
+	return result, nil
+}
+
 // GetByStatus 根据状态获取项目
 func (r *ProjectRepositoryImpl) GetByStatus(ctx context.Context, status string) ([]*database.Project, error) {
 	var projects []*database.Project
@@ -187,6 +264,28 @@ func (r *ProjectRepositoryImpl) GetProjectMembers(ctx context.Context, projectID
 	return members, err
 }
 
+// GetMemberIDs 获取项目成员的员工ID
+func (r *ProjectRepositoryImpl) GetMemberIDs(ctx context.Context, projectID uint) ([]uint, error) {
+	var ids []uint
+	err := r.db.WithContext(ctx).
+		Model(&database.ProjectMember{}).
+		Where("project_id = ?", projectID).
+		Pluck("employee_id", &ids).Error
+	return ids, err
+}
+
+// AddMemberByOperator 添加项目成员并记录操作人
+func (r *ProjectRepositoryImpl) AddMemberByOperator(ctx context.Context, projectID, employeeID, operatorID uint) error {
+	member := &database.ProjectMember{
+		ProjectID:  projectID,
+		EmployeeID: employeeID,
+		AddedBy:    &operatorID,
+	}
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(member).Error
+}
+
 // UpdateManager 更新项目管理者
 func (r *ProjectRepositoryImpl) UpdateManager(ctx context.Context, projectID, managerID uint) error {
 	return r.db.WithContext(ctx).
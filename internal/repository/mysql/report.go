@@ -0,0 +1,130 @@
+package mysql
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"taskmanage/internal/repository"
+)
+
+// ReportRepositoryImpl 报表查询仓储MySQL实现
+type ReportRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewReportRepository 创建报表查询仓储
+func NewReportRepository(db *gorm.DB) repository.ReportRepository {
+	return &ReportRepositoryImpl{db: db}
+}
+
+// CountTasks 统计创建时间在范围内的任务数
+func (r *ReportRepositoryImpl) CountTasks(ctx context.Context, filter *repository.ReportFilter) (int64, error) {
+	var count int64
+	err := r.taskQuery(ctx, filter).Count(&count).Error
+	return count, err
+}
+
+// ListTasks 获取ID大于afterID的下一批任务报表行
+func (r *ReportRepositoryImpl) ListTasks(ctx context.Context, filter *repository.ReportFilter, afterID uint, limit int) ([]*repository.TaskReportRow, error) {
+	var rows []*repository.TaskReportRow
+	err := r.taskQuery(ctx, filter).
+		Select(`tasks.id, tasks.title, tasks.status, tasks.priority, tasks.due_date, tasks.created_at, tasks.completed_at,
+			tasks.estimated_hours, tasks.actual_hours, assignee.real_name AS assignee_name, departments.name AS department_name`).
+		Where("tasks.id > ?", afterID).
+		Order("tasks.id ASC").
+		Limit(limit).
+		Scan(&rows).Error
+	return rows, err
+}
+
+// taskQuery 任务报表基础查询，部门取负责人所在部门
+func (r *ReportRepositoryImpl) taskQuery(ctx context.Context, filter *repository.ReportFilter) *gorm.DB {
+	query := r.db.WithContext(ctx).
+		Table("tasks").
+		Joins("LEFT JOIN users AS assignee ON assignee.id = tasks.assignee_id").
+		Joins("LEFT JOIN employees ON employees.user_id = tasks.assignee_id AND employees.deleted_at IS NULL").
+		Joins("LEFT JOIN departments ON departments.id = employees.department_id").
+		Where("tasks.deleted_at IS NULL").
+		Where("tasks.created_at >= ? AND tasks.created_at < ?", filter.From, filter.To)
+	if filter.DepartmentID != nil {
+		query = query.Where("employees.department_id = ?", *filter.DepartmentID)
+	}
+	return query
+}
+
+// CountAssignments 统计分配时间在范围内的分配记录数
+func (r *ReportRepositoryImpl) CountAssignments(ctx context.Context, filter *repository.ReportFilter) (int64, error) {
+	var count int64
+	err := r.assignmentQuery(ctx, filter).Count(&count).Error
+	return count, err
+}
+
+// ListAssignments 获取ID大于afterID的下一批分配报表行
+func (r *ReportRepositoryImpl) ListAssignments(ctx context.Context, filter *repository.ReportFilter, afterID uint, limit int) ([]*repository.AssignmentReportRow, error) {
+	var rows []*repository.AssignmentReportRow
+	err := r.assignmentQuery(ctx, filter).
+		Select(`assignments.id, assignments.task_id, tasks.title AS task_title, assignee.real_name AS assignee_name,
+			departments.name AS department_name, assigner.real_name AS assigner_name, assignments.method, assignments.status,
+			approver.real_name AS approver_name, assignments.assigned_at, assignments.approved_at`).
+		Where("assignments.id > ?", afterID).
+		Order("assignments.id ASC").
+		Limit(limit).
+		Scan(&rows).Error
+	return rows, err
+}
+
+// assignmentQuery 分配报表基础查询，部门取被分配人所在部门
+func (r *ReportRepositoryImpl) assignmentQuery(ctx context.Context, filter *repository.ReportFilter) *gorm.DB {
+	query := r.db.WithContext(ctx).
+		Table("assignments").
+		Joins("LEFT JOIN tasks ON tasks.id = assignments.task_id").
+		Joins("LEFT JOIN users AS assignee ON assignee.id = assignments.assignee_id").
+		Joins("LEFT JOIN users AS assigner ON assigner.id = assignments.assigner_id").
+		Joins("LEFT JOIN users AS approver ON approver.id = assignments.approver_id").
+		Joins("LEFT JOIN employees ON employees.user_id = assignments.assignee_id AND employees.deleted_at IS NULL").
+		Joins("LEFT JOIN departments ON departments.id = employees.department_id").
+		Where("assignments.deleted_at IS NULL").
+		Where("assignments.assigned_at >= ? AND assignments.assigned_at < ?", filter.From, filter.To)
+	if filter.DepartmentID != nil {
+		query = query.Where("employees.department_id = ?", *filter.DepartmentID)
+	}
+	return query
+}
+
+// ListSkillDemand 按技能和所需等级分组统计部门未结束任务的技能需求
+// 技能表左连接，任务引用的技能已被删除时仍计入需求
+func (r *ReportRepositoryImpl) ListSkillDemand(ctx context.Context, departmentID uint) ([]*repository.SkillDemandRow, error) {
+	var rows []*repository.SkillDemandRow
+	err := r.db.WithContext(ctx).
+		Table("task_skills").
+		Select(`task_skills.skill_id, skills.name AS skill_name, skill_categories.name AS category, task_skills.level,
+			COUNT(DISTINCT tasks.id) AS task_count`).
+		Joins("JOIN tasks ON tasks.id = task_skills.task_id AND tasks.deleted_at IS NULL").
+		Joins("LEFT JOIN projects ON projects.id = tasks.project_id AND projects.deleted_at IS NULL").
+		Joins("LEFT JOIN employees AS creator ON creator.user_id = tasks.creator_id AND creator.deleted_at IS NULL").
+		Joins("LEFT JOIN skills ON skills.id = task_skills.skill_id AND skills.deleted_at IS NULL").
+		Joins("LEFT JOIN skill_categories ON skill_categories.id = skills.category_id AND skill_categories.deleted_at IS NULL").
+		Where("tasks.status NOT IN ?", finishedTaskStatuses).
+		Where("projects.department_id = ? OR creator.department_id = ?", departmentID, departmentID).
+		Group("task_skills.skill_id, skills.name, skill_categories.name, task_skills.level").
+		Order("task_skills.skill_id ASC, task_skills.level ASC").
+		Scan(&rows).Error
+	return rows, err
+}
+
+// ListSkillSupply 按技能和等级分组统计部门在职员工的技能人数
+func (r *ReportRepositoryImpl) ListSkillSupply(ctx context.Context, departmentID uint, skillIDs []uint) ([]*repository.SkillSupplyRow, error) {
+	var rows []*repository.SkillSupplyRow
+	if len(skillIDs) == 0 {
+		return rows, nil
+	}
+	err := r.db.WithContext(ctx).
+		Table("employee_skills").
+		Select("employee_skills.skill_id, employee_skills.level, COUNT(*) AS employee_count").
+		Joins("JOIN employees ON employees.id = employee_skills.employee_id AND employees.deleted_at IS NULL").
+		Where("employees.department_id = ? AND employees.status <> ?", departmentID, "resigned").
+		Where("employee_skills.skill_id IN ?", skillIDs).
+		Group("employee_skills.skill_id, employee_skills.level").
+		Scan(&rows).Error
+	return rows, err
+}
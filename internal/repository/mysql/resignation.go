@@ -0,0 +1,61 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+// ResignationRepositoryImpl 离职申请仓储MySQL实现
+type ResignationRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewResignationRepository 创建离职申请仓储
+func NewResignationRepository(db *gorm.DB) repository.ResignationRepository {
+	return &ResignationRepositoryImpl{db: db}
+}
+
+// Create 创建离职申请
+func (r *ResignationRepositoryImpl) Create(ctx context.Context, resignation *database.Resignation) error {
+	return r.db.WithContext(ctx).Create(resignation).Error
+}
+
+// Update 更新离职申请
+func (r *ResignationRepositoryImpl) Update(ctx context.Context, resignation *database.Resignation) error {
+	return r.db.WithContext(ctx).Save(resignation).Error
+}
+
+// GetActiveByEmployeeID 获取员工未结束的离职申请
+func (r *ResignationRepositoryImpl) GetActiveByEmployeeID(ctx context.Context, employeeID uint) (*database.Resignation, error) {
+	var resignation database.Resignation
+	err := r.db.WithContext(ctx).
+		Where("employee_id = ? AND status IN (?)", employeeID, []string{"pending_approval", "handover"}).
+		Order("created_at DESC").
+		First(&resignation).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, err
+	}
+	return &resignation, nil
+}
+
+// GetByWorkflowInstanceID 根据工作流实例ID获取离职申请
+func (r *ResignationRepositoryImpl) GetByWorkflowInstanceID(ctx context.Context, instanceID string) (*database.Resignation, error) {
+	var resignation database.Resignation
+	err := r.db.WithContext(ctx).
+		Where("workflow_instance_id = ?", instanceID).
+		First(&resignation).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, err
+	}
+	return &resignation, nil
+}
@@ -3,8 +3,10 @@ package mysql
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"taskmanage/internal/database"
 	"taskmanage/internal/repository"
 	"taskmanage/pkg/logger"
@@ -22,10 +24,74 @@ func NewSkillRepository(db *gorm.DB) repository.SkillRepository {
 	}
 }
 
+// GetByID 根据ID获取技能及其分类
+func (r *SkillRepositoryImpl) GetByID(ctx context.Context, id uint) (*database.Skill, error) {
+	var skill database.Skill
+	err := r.db.WithContext(ctx).Preload("Category").First(&skill, id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, repository.ErrNotFound
+		}
+		logger.Errorf("根据ID获取技能失败: %v", err)
+		return nil, fmt.Errorf("根据ID获取技能失败: %w", err)
+	}
+	return &skill, nil
+}
+
+// Update 更新技能，分类只按category_id保存，不随已加载的分类对象回写
+func (r *SkillRepositoryImpl) Update(ctx context.Context, skill *database.Skill) error {
+	if err := r.db.WithContext(ctx).Omit(clause.Associations).Save(skill).Error; err != nil {
+		logger.Errorf("更新技能失败: %v", err)
+		return fmt.Errorf("更新技能失败: %w", err)
+	}
+	return nil
+}
+
+// List 获取技能列表及其分类
+func (r *SkillRepositoryImpl) List(ctx context.Context, filter repository.ListFilter) ([]*database.Skill, int64, error) {
+	skills, total, err := r.BaseRepositoryImpl.List(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := r.loadCategories(ctx, skills); err != nil {
+		return nil, 0, err
+	}
+	return skills, total, nil
+}
+
+// loadCategories 为技能批量加载分类
+func (r *SkillRepositoryImpl) loadCategories(ctx context.Context, skills []*database.Skill) error {
+	ids := make([]uint, 0, len(skills))
+	for _, skill := range skills {
+		if skill.CategoryID != nil {
+			ids = append(ids, *skill.CategoryID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var categories []*database.SkillCategory
+	if err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&categories).Error; err != nil {
+		logger.Errorf("获取技能分类失败: %v", err)
+		return fmt.Errorf("获取技能分类失败: %w", err)
+	}
+	byID := make(map[uint]*database.SkillCategory, len(categories))
+	for _, category := range categories {
+		byID[category.ID] = category
+	}
+	for _, skill := range skills {
+		if skill.CategoryID != nil {
+			skill.Category = byID[*skill.CategoryID]
+		}
+	}
+	return nil
+}
+
 // GetByName 根据技能名称获取技能
 func (r *SkillRepositoryImpl) GetByName(ctx context.Context, name string) (*database.Skill, error) {
 	var skill database.Skill
-	err := r.db.WithContext(ctx).Where("name = ?", name).First(&skill).Error
+	err := r.db.WithContext(ctx).Preload("Category").Where("name = ?", name).First(&skill).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, repository.ErrNotFound
@@ -36,13 +102,15 @@ func (r *SkillRepositoryImpl) GetByName(ctx context.Context, name string) (*data
 	return &skill, nil
 }
 
-// GetByCategory 根据分类获取技能列表
+// GetByCategory 根据分类名称（不区分大小写）获取技能列表
 func (r *SkillRepositoryImpl) GetByCategory(ctx context.Context, category string) ([]*database.Skill, error) {
 	var skills []*database.Skill
-	query := r.db.WithContext(ctx)
+	query := r.db.WithContext(ctx).Preload("Category")
 	
 	if category != "" {
-		query = query.Where("category = ?", category)
+		query = query.
+			Joins("JOIN skill_categories ON skill_categories.id = skills.category_id AND skill_categories.deleted_at IS NULL").
+			Where("LOWER(skill_categories.name) = LOWER(?)", category)
 	}
 	
 	err := query.Find(&skills).Error
@@ -76,15 +144,9 @@ func (r *SkillRepositoryImpl) AssignToEmployee(ctx context.Context, employeeID u
 		return fmt.Errorf("获取技能信息失败: %w", err)
 	}
 	
-	// 使用原生SQL插入或更新员工技能关联
+	// 插入或更新员工技能关联
 	// 如果已存在则更新等级，否则插入新记录
-	err = r.db.WithContext(ctx).Exec(`
-		INSERT INTO employee_skills (employee_id, skill_id, level, created_at, updated_at) 
-		VALUES (?, ?, ?, NOW(), NOW())
-		ON DUPLICATE KEY UPDATE 
-		level = VALUES(level), 
-		updated_at = NOW()
-	`, employeeID, skillID, level).Error
+	err = upsertEmployeeSkill(r.db.WithContext(ctx), employeeID, skillID, level)
 	
 	if err != nil {
 		logger.Errorf("为员工分配技能失败: %v", err)
@@ -152,6 +214,127 @@ func (r *SkillRepositoryImpl) GetEmployeeSkillLevel(ctx context.Context, employe
 	return level, nil
 }
 
+// GetEmployeeSkillsWithLevel 获取员工的所有技能及对应等级
+func (r *SkillRepositoryImpl) GetEmployeeSkillsWithLevel(ctx context.Context, employeeID uint) ([]*database.EmployeeSkillDetail, error) {
+	var details []*database.EmployeeSkillDetail
+	err := r.db.WithContext(ctx).
+		Model(&database.Skill{}).
+		Select("skills.id AS skill_id, skills.name, skill_categories.name AS category, skills.description, es.level, es.endorsed_by, es.endorsed_at, es.expires_at, skills.created_at, skills.updated_at").
+		Joins("JOIN employee_skills es ON skills.id = es.skill_id").
+		Joins("LEFT JOIN skill_categories ON skill_categories.id = skills.category_id").
+		Where("es.employee_id = ?", employeeID).
+		Order("skills.id ASC").
+		Scan(&details).Error
+	
+	if err != nil {
+		logger.Errorf("获取员工技能等级列表失败: %v", err)
+		return nil, fmt.Errorf("获取员工技能等级列表失败: %w", err)
+	}
+	
+	return details, nil
+}
+
+// GetEmployeesWithSkillLevels 通过employee_skills单次联表查询多名员工的技能及等级
+func (r *SkillRepositoryImpl) GetEmployeesWithSkillLevels(ctx context.Context, employeeIDs []uint) (map[uint][]*database.EmployeeSkillDetail, error) {
+	result := make(map[uint][]*database.EmployeeSkillDetail, len(employeeIDs))
+	if len(employeeIDs) == 0 {
+		return result, nil
+	}
+
+	var rows []struct {
+		EmployeeID uint
+		database.EmployeeSkillDetail
+	}
+	err := r.db.WithContext(ctx).
+		Model(&database.Skill{}).
+		Select("es.employee_id, skills.id AS skill_id, skills.name, skill_categories.name AS category, skills.description, es.level, es.endorsed_by, es.endorsed_at, es.expires_at, skills.created_at, skills.updated_at").
+		Joins("JOIN employee_skills es ON skills.id = es.skill_id").
+		Joins("LEFT JOIN skill_categories ON skill_categories.id = skills.category_id").
+		Where("es.employee_id IN ?", employeeIDs).
+		Order("es.employee_id ASC, skills.id ASC").
+		Scan(&rows).Error
+	if err != nil {
+		logger.Errorf("批量获取员工技能等级失败: %v", err)
+		return nil, fmt.Errorf("批量获取员工技能等级失败: %w", err)
+	}
+
+	for i := range rows {
+		detail := rows[i].EmployeeSkillDetail
+		result[rows[i].EmployeeID] = append(result[rows[i].EmployeeID], &detail)
+	}
+	return result, nil
+}
+
+// GetEmployeeSkill 获取员工的技能关联记录
+func (r *SkillRepositoryImpl) GetEmployeeSkill(ctx context.Context, employeeID uint, skillID uint) (*database.EmployeeSkill, error) {
+	var employeeSkill database.EmployeeSkill
+	err := r.db.WithContext(ctx).
+		Where("employee_id = ? AND skill_id = ?", employeeID, skillID).
+		First(&employeeSkill).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, repository.ErrNotFound
+		}
+		logger.Errorf("获取员工技能失败: %v", err)
+		return nil, fmt.Errorf("获取员工技能失败: %w", err)
+	}
+	return &employeeSkill, nil
+}
+
+// EndorseEmployeeSkill 保存技能认证信息，同时清空到期提醒标记以便新的有效期重新提醒
+func (r *SkillRepositoryImpl) EndorseEmployeeSkill(ctx context.Context, employeeSkill *database.EmployeeSkill) error {
+	result := r.db.WithContext(ctx).
+		Model(&database.EmployeeSkill{}).
+		Where("employee_id = ? AND skill_id = ?", employeeSkill.EmployeeID, employeeSkill.SkillID).
+		Updates(map[string]interface{}{
+			"level":              employeeSkill.Level,
+			"endorsed_by":        employeeSkill.EndorsedBy,
+			"endorsed_at":        employeeSkill.EndorsedAt,
+			"expires_at":         employeeSkill.ExpiresAt,
+			"expiry_notified_at": nil,
+		})
+	if result.Error != nil {
+		logger.Errorf("认证员工技能失败: %v", result.Error)
+		return fmt.Errorf("认证员工技能失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+
+	logger.Infof("认证员工技能成功: EmployeeID=%d, SkillID=%d, Level=%d", employeeSkill.EmployeeID, employeeSkill.SkillID, employeeSkill.Level)
+	return nil
+}
+
+// ListExpiringSkills 获取认证在[from, to)内到期且尚未发送提醒的员工技能
+func (r *SkillRepositoryImpl) ListExpiringSkills(ctx context.Context, from, to time.Time) ([]*database.ExpiringSkill, error) {
+	var skills []*database.ExpiringSkill
+	err := r.db.WithContext(ctx).
+		Table("employee_skills es").
+		Select("es.employee_id, es.skill_id, skills.name AS skill_name, es.level, es.expires_at").
+		Joins("JOIN skills ON skills.id = es.skill_id AND skills.deleted_at IS NULL").
+		Where("es.expires_at >= ? AND es.expires_at < ? AND es.expiry_notified_at IS NULL", from, to).
+		Order("es.expires_at ASC").
+		Scan(&skills).Error
+	if err != nil {
+		logger.Errorf("获取即将到期的员工技能失败: %v", err)
+		return nil, fmt.Errorf("获取即将到期的员工技能失败: %w", err)
+	}
+	return skills, nil
+}
+
+// MarkExpiryNotified 记录员工技能到期提醒已发送
+func (r *SkillRepositoryImpl) MarkExpiryNotified(ctx context.Context, employeeID uint, skillID uint, notifiedAt time.Time) error {
+	err := r.db.WithContext(ctx).
+		Model(&database.EmployeeSkill{}).
+		Where("employee_id = ? AND skill_id = ?", employeeID, skillID).
+		Update("expiry_notified_at", notifiedAt).Error
+	if err != nil {
+		logger.Errorf("标记员工技能到期提醒失败: %v", err)
+		return fmt.Errorf("标记员工技能到期提醒失败: %w", err)
+	}
+	return nil
+}
+
 // GetSkillsByEmployee 获取拥有某项技能的员工列表
 func (r *SkillRepositoryImpl) GetSkillsByEmployee(ctx context.Context, skillID uint, minLevel int) ([]*database.Employee, error) {
 	var employees []*database.Employee
@@ -171,23 +354,6 @@ func (r *SkillRepositoryImpl) GetSkillsByEmployee(ctx context.Context, skillID u
 	return employees, nil
 }
 
-// GetAllCategories 获取所有技能分类
-func (r *SkillRepositoryImpl) GetAllCategories(ctx context.Context) ([]string, error) {
-	var categories []string
-	err := r.db.WithContext(ctx).
-		Model(&database.Skill{}).
-		Distinct("category").
-		Where("category IS NOT NULL AND category != ''").
-		Pluck("category", &categories).Error
-	
-	if err != nil {
-		logger.Errorf("获取技能分类列表失败: %v", err)
-		return nil, fmt.Errorf("获取技能分类列表失败: %w", err)
-	}
-	
-	return categories, nil
-}
-
 // BatchAssignSkills 批量为员工分配技能
 func (r *SkillRepositoryImpl) BatchAssignSkills(ctx context.Context, employeeID uint, skillLevels map[uint]int) error {
 	if len(skillLevels) == 0 {
@@ -203,13 +369,7 @@ func (r *SkillRepositoryImpl) BatchAssignSkills(ctx context.Context, employeeID
 	}()
 	
 	for skillID, level := range skillLevels {
-		err := tx.Exec(`
-			INSERT INTO employee_skills (employee_id, skill_id, level, created_at, updated_at) 
-			VALUES (?, ?, ?, NOW(), NOW())
-			ON DUPLICATE KEY UPDATE 
-			level = VALUES(level), 
-			updated_at = NOW()
-		`, employeeID, skillID, level).Error
+		err := upsertEmployeeSkill(tx, employeeID, skillID, level)
 		
 		if err != nil {
 			tx.Rollback()
@@ -226,3 +386,16 @@ func (r *SkillRepositoryImpl) BatchAssignSkills(ctx context.Context, employeeID
 	logger.Infof("批量分配技能成功: EmployeeID=%d, 技能数量=%d", employeeID, len(skillLevels))
 	return nil
 }
+
+// upsertEmployeeSkill 插入员工技能关联，已存在时只更新等级，
+// 由GORM按方言生成ON DUPLICATE KEY UPDATE或ON CONFLICT语句
+func upsertEmployeeSkill(db *gorm.DB, employeeID, skillID uint, level int) error {
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "employee_id"}, {Name: "skill_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"level", "updated_at"}),
+	}).Create(&database.EmployeeSkill{
+		EmployeeID: employeeID,
+		SkillID:    skillID,
+		Level:      level,
+	}).Error
+}
@@ -0,0 +1,93 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+	"taskmanage/pkg/logger"
+)
+
+// SkillCategoryRepositoryImpl 技能分类仓储实现
+type SkillCategoryRepositoryImpl struct {
+	*BaseRepositoryImpl[database.SkillCategory]
+}
+
+// NewSkillCategoryRepository 创建技能分类仓储
+func NewSkillCategoryRepository(db *gorm.DB) repository.SkillCategoryRepository {
+	return &SkillCategoryRepositoryImpl{
+		BaseRepositoryImpl: NewBaseRepository[database.SkillCategory](db),
+	}
+}
+
+// GetByName 按名称不区分大小写查找分类
+func (r *SkillCategoryRepositoryImpl) GetByName(ctx context.Context, name string) (*database.SkillCategory, error) {
+	var category database.SkillCategory
+	err := r.db.WithContext(ctx).Where("LOWER(name) = LOWER(?)", name).First(&category).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrNotFound
+		}
+		logger.Errorf("根据名称获取技能分类失败: %v", err)
+		return nil, fmt.Errorf("根据名称获取技能分类失败: %w", err)
+	}
+	return &category, nil
+}
+
+// ListWithSkillCounts 获取全部分类及各分类下的技能数量，按名称排序
+func (r *SkillCategoryRepositoryImpl) ListWithSkillCounts(ctx context.Context) ([]*repository.SkillCategoryCount, error) {
+	var counts []*repository.SkillCategoryCount
+	err := r.db.WithContext(ctx).
+		Model(&database.SkillCategory{}).
+		Select("skill_categories.id, skill_categories.name, skill_categories.description, COUNT(skills.id) AS skill_count").
+		Joins("LEFT JOIN skills ON skills.category_id = skill_categories.id AND skills.deleted_at IS NULL").
+		Group("skill_categories.id, skill_categories.name, skill_categories.description").
+		Order("skill_categories.name ASC").
+		Scan(&counts).Error
+	if err != nil {
+		logger.Errorf("获取技能分类列表失败: %v", err)
+		return nil, fmt.Errorf("获取技能分类列表失败: %w", err)
+	}
+	return counts, nil
+}
+
+// Merge 在一个事务内将源分类下的技能改挂到目标分类并删除源分类，返回改挂的技能数
+// 已删除的技能同样改挂，源分类直接物理删除以释放名称
+func (r *SkillCategoryRepositoryImpl) Merge(ctx context.Context, sourceID, targetID uint) (int64, error) {
+	var moved int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&database.SkillCategory{}).Where("id IN ?", []uint{sourceID, targetID}).Count(&count).Error; err != nil {
+			return fmt.Errorf("查询技能分类失败: %w", err)
+		}
+		if count != 2 {
+			return repository.ErrNotFound
+		}
+
+		result := tx.Unscoped().Model(&database.Skill{}).
+			Where("category_id = ?", sourceID).
+			Update("category_id", targetID)
+		if result.Error != nil {
+			return fmt.Errorf("改挂技能分类失败: %w", result.Error)
+		}
+		moved = result.RowsAffected
+
+		if err := tx.Unscoped().Delete(&database.SkillCategory{}, sourceID).Error; err != nil {
+			return fmt.Errorf("删除源技能分类失败: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		if !errors.Is(err, repository.ErrNotFound) {
+			logger.Errorf("合并技能分类失败: %v", err)
+		}
+		return 0, err
+	}
+
+	logger.Infof("合并技能分类成功: SourceID=%d, TargetID=%d, Skills=%d", sourceID, targetID, moved)
+	return moved, nil
+}
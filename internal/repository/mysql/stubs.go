@@ -9,6 +9,7 @@ import (
 	"taskmanage/internal/database"
 	"taskmanage/internal/repository"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // 临时存根实现，后续需要完整实现
@@ -48,7 +49,7 @@ type SystemConfigRepositoryImpl struct {
 // RoleRepositoryImpl 方法存根
 func (r *RoleRepositoryImpl) GetByName(ctx context.Context, name string) (*database.Role, error) {
 	var role database.Role
-	err := r.db.Where("name = ?", name).First(&role).Error
+	err := r.db.WithContext(ctx).Where("name = ?", name).First(&role).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, repository.ErrNotFound
@@ -60,7 +61,7 @@ func (r *RoleRepositoryImpl) GetByName(ctx context.Context, name string) (*datab
 
 func (r *RoleRepositoryImpl) GetRoleWithPermissions(ctx context.Context, roleID uint) (*database.Role, error) {
 	var role database.Role
-	err := r.db.Preload("Permissions").First(&role, roleID).Error
+	err := r.db.WithContext(ctx).Preload("Permissions").First(&role, roleID).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, repository.ErrNotFound
@@ -71,55 +72,160 @@ func (r *RoleRepositoryImpl) GetRoleWithPermissions(ctx context.Context, roleID
 }
 
 func (r *RoleRepositoryImpl) AssignPermissions(ctx context.Context, roleID uint, permissionIDs []uint) error {
+	db := r.db.WithContext(ctx)
+
 	// 获取角色
 	var role database.Role
-	if err := r.db.First(&role, roleID).Error; err != nil {
+	if err := db.First(&role, roleID).Error; err != nil {
 		return err
 	}
 
 	// 获取权限
 	var permissions []database.Permission
-	if err := r.db.Find(&permissions, permissionIDs).Error; err != nil {
+	if err := db.Find(&permissions, permissionIDs).Error; err != nil {
 		return err
 	}
 
 	// 先清除现有权限关联，再重新分配（确保权限是最新的）
-	if err := r.db.Model(&role).Association("Permissions").Clear(); err != nil {
+	if err := db.Model(&role).Association("Permissions").Clear(); err != nil {
 		return err
 	}
 
 	// 分配权限（使用GORM的Association方法）
-	return r.db.Model(&role).Association("Permissions").Append(&permissions)
+	return db.Model(&role).Association("Permissions").Append(&permissions)
 }
 
 func (r *RoleRepositoryImpl) RemovePermissions(ctx context.Context, roleID uint, permissionIDs []uint) error {
+	db := r.db.WithContext(ctx)
+
 	// 获取角色
 	var role database.Role
-	if err := r.db.First(&role, roleID).Error; err != nil {
+	if err := db.First(&role, roleID).Error; err != nil {
 		return err
 	}
 
 	// 获取权限
 	var permissions []database.Permission
-	if err := r.db.Find(&permissions, permissionIDs).Error; err != nil {
+	if err := db.Find(&permissions, permissionIDs).Error; err != nil {
 		return err
 	}
 
 	// 移除权限（使用GORM的Association方法）
-	return r.db.Model(&role).Association("Permissions").Delete(&permissions)
+	return db.Model(&role).Association("Permissions").Delete(&permissions)
+}
+
+// AddPermissions 为角色追加权限，已有的权限保持不变
+func (r *RoleRepositoryImpl) AddPermissions(ctx context.Context, roleID uint, permissionIDs []uint) error {
+	var role database.Role
+	if err := r.db.WithContext(ctx).First(&role, roleID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return repository.ErrNotFound
+		}
+		return err
+	}
+
+	var permissions []database.Permission
+	if err := r.db.WithContext(ctx).Find(&permissions, permissionIDs).Error; err != nil {
+		return err
+	}
+	if len(permissions) == 0 {
+		return nil
+	}
+
+	return r.db.WithContext(ctx).Model(&role).Association("Permissions").Append(&permissions)
+}
+
+// GetRoleUsers 获取通过用户角色关联拥有该角色的用户
+func (r *RoleRepositoryImpl) GetRoleUsers(ctx context.Context, roleID uint) ([]*database.User, error) {
+	var users []*database.User
+	err := r.db.WithContext(ctx).
+		Joins("JOIN user_roles ON user_roles.user_id = users.id").
+		Where("user_roles.role_id = ?", roleID).
+		Order("users.id").
+		Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// DeleteRole 删除角色及其用户、权限关联，下级角色改挂到被删除角色的上级
+// 角色名唯一，这里物理删除以便之后可以重新创建同名角色
+func (r *RoleRepositoryImpl) DeleteRole(ctx context.Context, roleID uint) ([]uint, error) {
+	db := r.db.WithContext(ctx)
+
+	var role database.Role
+	if err := db.First(&role, roleID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, err
+	}
+
+	var userIDs []uint
+	if err := db.Table("user_roles").Where("role_id = ?", roleID).Order("user_id").Pluck("user_id", &userIDs).Error; err != nil {
+		return nil, fmt.Errorf("查询角色用户失败: %w", err)
+	}
+	if err := db.Exec("DELETE FROM user_roles WHERE role_id = ?", roleID).Error; err != nil {
+		return nil, fmt.Errorf("解除用户角色失败: %w", err)
+	}
+	if err := db.Exec("DELETE FROM role_permissions WHERE role_id = ?", roleID).Error; err != nil {
+		return nil, fmt.Errorf("解除角色权限失败: %w", err)
+	}
+	if err := db.Model(&database.Role{}).Where("parent_id = ?", roleID).Update("parent_id", role.ParentID).Error; err != nil {
+		return nil, fmt.Errorf("调整下级角色失败: %w", err)
+	}
+	if err := db.Unscoped().Delete(&database.Role{}, roleID).Error; err != nil {
+		return nil, fmt.Errorf("删除角色失败: %w", err)
+	}
+	return userIDs, nil
 }
 
 // PermissionRepositoryImpl 方法存根
 func (r *PermissionRepositoryImpl) GetByResource(ctx context.Context, resource string) ([]*database.Permission, error) {
-	// TODO: 实现
-	return nil, nil
+	var permissions []*database.Permission
+	if err := r.db.WithContext(ctx).Where("resource = ?", resource).Order("action").Find(&permissions).Error; err != nil {
+		return nil, err
+	}
+	return permissions, nil
+}
+
+// CountActiveAssignments 统计直接分配该权限且仍生效或待审批的记录数
+func (r *PermissionRepositoryImpl) CountActiveAssignments(ctx context.Context, permissionID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&database.PermissionAssignment{}).
+		Where("permission_id = ? AND status IN ?", permissionID, []string{database.PermissionStatusActive, database.PermissionStatusPending}).
+		Count(&count).Error
+	return count, err
+}
+
+// DeletePermission 删除权限及其角色、模板关联
+// 权限名唯一，这里物理删除以便之后可以重新创建同名权限
+func (r *PermissionRepositoryImpl) DeletePermission(ctx context.Context, permissionID uint) error {
+	db := r.db.WithContext(ctx)
+	if err := db.First(&database.Permission{}, permissionID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return repository.ErrNotFound
+		}
+		return err
+	}
+	if err := db.Exec("DELETE FROM role_permissions WHERE permission_id = ?", permissionID).Error; err != nil {
+		return fmt.Errorf("解除角色权限失败: %w", err)
+	}
+	if err := db.Exec("DELETE FROM template_permissions WHERE permission_id = ?", permissionID).Error; err != nil {
+		return fmt.Errorf("解除模板权限失败: %w", err)
+	}
+	if err := db.Unscoped().Delete(&database.Permission{}, permissionID).Error; err != nil {
+		return fmt.Errorf("删除权限失败: %w", err)
+	}
+	return nil
 }
 
 func (r *PermissionRepositoryImpl) GetUserPermissions(ctx context.Context, userID uint) ([]*database.Permission, error) {
 	var permissions []*database.Permission
 	
 	// 通过用户角色获取权限
-	err := r.db.Table("permissions").
+	err := r.db.WithContext(ctx).Table("permissions").
 		Joins("JOIN role_permissions ON permissions.id = role_permissions.permission_id").
 		Joins("JOIN user_roles ON role_permissions.role_id = user_roles.role_id").
 		Where("user_roles.user_id = ? AND permissions.deleted_at IS NULL", userID).
@@ -132,9 +238,55 @@ func (r *PermissionRepositoryImpl) GetUserPermissions(ctx context.Context, userI
 	return permissions, nil
 }
 
+// GetEffectivePermissions 获取用户有效权限：角色权限加上生效中的模板分配和直接分配，按权限ID去重
+func (r *PermissionRepositoryImpl) GetEffectivePermissions(ctx context.Context, userID uint) ([]*database.Permission, error) {
+	permissions, err := r.GetUserPermissions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	activeAssignment := "permission_assignments.user_id = ? AND permission_assignments.status = ? AND permission_assignments.approval_status = ? " +
+		"AND permission_assignments.deleted_at IS NULL AND (permission_assignments.expires_at IS NULL OR permission_assignments.expires_at > ?) " +
+		"AND permissions.deleted_at IS NULL"
+	args := []interface{}{userID, database.PermissionStatusActive, database.ApprovalStatusApproved, time.Now()}
+
+	// 通过权限模板分配的权限
+	var templatePermissions []*database.Permission
+	err = r.db.WithContext(ctx).Table("permissions").
+		Joins("JOIN template_permissions ON permissions.id = template_permissions.permission_id").
+		Joins("JOIN permission_assignments ON permission_assignments.template_id = template_permissions.permission_template_id").
+		Where(activeAssignment, args...).
+		Find(&templatePermissions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// 直接分配的权限
+	var directPermissions []*database.Permission
+	err = r.db.WithContext(ctx).Table("permissions").
+		Joins("JOIN permission_assignments ON permission_assignments.permission_id = permissions.id").
+		Where(activeAssignment, args...).
+		Find(&directPermissions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[uint]bool, len(permissions))
+	for _, permission := range permissions {
+		seen[permission.ID] = true
+	}
+	for _, permission := range append(templatePermissions, directPermissions...) {
+		if !seen[permission.ID] {
+			seen[permission.ID] = true
+			permissions = append(permissions, permission)
+		}
+	}
+	return permissions, nil
+}
+
 func (r *PermissionRepositoryImpl) GetByName(ctx context.Context, name string) (*database.Permission, error) {
 	var permission database.Permission
-	err := r.db.Where("name = ?", name).First(&permission).Error
+	err := r.db.WithContext(ctx).Where("name = ?", name).First(&permission).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, repository.ErrNotFound
@@ -287,16 +439,108 @@ func (n *NotificationRepositoryImpl) MarkAllAsRead(ctx context.Context, userID u
 	return result.RowsAffected, nil
 }
 
-func (n *NotificationRepositoryImpl) GetUnreadCount(ctx context.Context, userID uint) (int64, error) {
+func (n *NotificationRepositoryImpl) GetUnreadCount(ctx context.Context, userID uint, notificationType string) (int64, error) {
 	var count int64
-	err := n.db.WithContext(ctx).Model(&database.TaskNotification{}).
+	query := n.db.WithContext(ctx).Model(&database.TaskNotification{}).
 		Where("recipient_id = ? AND status = ?", userID, "unread").
-		Where("expires_at IS NULL OR expires_at > ?", time.Now()).
-		Count(&count).Error
+		Where("expires_at IS NULL OR expires_at > ?", time.Now())
+	if notificationType != "" {
+		query = query.Where("type = ?", notificationType)
+	}
+	err := query.Count(&count).Error
 
 	return count, err
 }
 
+// ListByFilter 按过滤条件分页获取接收人未过期的通知
+func (n *NotificationRepositoryImpl) ListByFilter(ctx context.Context, filter *repository.NotificationFilter, page, pageSize int) ([]*database.TaskNotification, int64, error) {
+	query := n.filterQuery(ctx, filter, time.Now())
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("统计通知失败: %w", err)
+	}
+
+	var notifications []*database.TaskNotification
+	offset := (page - 1) * pageSize
+	if err := query.Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&notifications).Error; err != nil {
+		return nil, 0, fmt.Errorf("获取通知列表失败: %w", err)
+	}
+	return notifications, total, nil
+}
+
+// ListOwnedIDs 返回ids中属于该接收人的通知ID
+func (n *NotificationRepositoryImpl) ListOwnedIDs(ctx context.Context, recipientID uint, ids []uint) ([]uint, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var owned []uint
+	if err := n.db.WithContext(ctx).Model(&database.TaskNotification{}).
+		Where("recipient_id = ? AND id IN ?", recipientID, ids).
+		Pluck("id", &owned).Error; err != nil {
+		return nil, fmt.Errorf("校验通知归属失败: %w", err)
+	}
+	return owned, nil
+}
+
+// MarkReadByFilter 将符合过滤条件的未过期未读通知标记为已读
+func (n *NotificationRepositoryImpl) MarkReadByFilter(ctx context.Context, filter *repository.NotificationFilter, readAt time.Time) (int64, error) {
+	result := n.filterQuery(ctx, filter, readAt).
+		Where("status = ?", "unread").
+		Updates(map[string]interface{}{
+			"status":  "read",
+			"read_at": readAt,
+		})
+	if result.Error != nil {
+		return 0, fmt.Errorf("批量标记通知已读失败: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// DeleteReadBefore 软删除阅读时间早于before的已读通知
+func (n *NotificationRepositoryImpl) DeleteReadBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := n.db.WithContext(ctx).
+		Where("status = ? AND read_at < ?", "read", before).
+		Delete(&database.TaskNotification{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("清理已读通知失败: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// filterQuery 构造接收人未过期通知的过滤查询
+func (n *NotificationRepositoryImpl) filterQuery(ctx context.Context, filter *repository.NotificationFilter, now time.Time) *gorm.DB {
+	query := n.db.WithContext(ctx).Model(&database.TaskNotification{}).
+		Where("recipient_id = ?", filter.RecipientID).
+		Where("expires_at IS NULL OR expires_at > ?", now)
+	if len(filter.IDs) > 0 {
+		query = query.Where("id IN ?", filter.IDs)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.IsRead != nil {
+		if *filter.IsRead {
+			query = query.Where("status IN ?", []string{"read", "acted"})
+		} else {
+			query = query.Where("status = ?", "unread")
+		}
+	}
+	if filter.CreatedBefore != nil {
+		query = query.Where("created_at < ?", *filter.CreatedBefore)
+	}
+	return query
+}
+
+// ExistsByDedupeKey 接收人是否已有相同去重键的通知
+func (n *NotificationRepositoryImpl) ExistsByDedupeKey(ctx context.Context, recipientID uint, dedupeKey string) (bool, error) {
+	var count int64
+	err := n.db.WithContext(ctx).Model(&database.TaskNotification{}).
+		Where("recipient_id = ? AND dedupe_key = ?", recipientID, dedupeKey).
+		Count(&count).Error
+	return count > 0, err
+}
+
 func (n *NotificationRepositoryImpl) CreateTaskAssignmentNotification(ctx context.Context, taskID, recipientID, senderID uint) error {
 	// 获取任务信息
 	var task database.Task
@@ -344,7 +588,7 @@ func (n *NotificationRepositoryImpl) UpdateNotificationStatus(ctx context.Contex
 // AcceptTaskNotification 接受任务通知
 func (n *NotificationRepositoryImpl) AcceptTaskNotification(ctx context.Context, notificationID, taskID, userID uint, reason *string) error {
 	// 开始事务
-	tx := n.db.Begin()
+	tx := n.db.WithContext(ctx).Begin()
 	defer func() {
 		if r := recover(); r != nil {
 			tx.Rollback()
@@ -367,6 +611,7 @@ func (n *NotificationRepositoryImpl) AcceptTaskNotification(ctx context.Context,
 	if err := tx.WithContext(ctx).Model(&database.Task{}).Where("id = ?", taskID).Updates(map[string]interface{}{
 		"status":     "in_progress",
 		"started_at": &now,
+		"version":    gorm.Expr("version + 1"),
 	}).Error; err != nil {
 		tx.Rollback()
 		return err
@@ -394,7 +639,7 @@ func (n *NotificationRepositoryImpl) RejectTaskNotification(ctx context.Context,
 	}
 
 	// 开始事务
-	tx := n.db.Begin()
+	tx := n.db.WithContext(ctx).Begin()
 	defer func() {
 		if r := recover(); r != nil {
 			tx.Rollback()
@@ -418,6 +663,7 @@ func (n *NotificationRepositoryImpl) RejectTaskNotification(ctx context.Context,
 		"status":      "pending",
 		"assignee_id": nil,
 		"assigned_at": nil,
+		"version":     gorm.Expr("version + 1"),
 	}).Error; err != nil {
 		tx.Rollback()
 		return err
@@ -473,14 +719,30 @@ func (r *AuditLogRepositoryImpl) CleanupOldLogs(ctx context.Context, before time
 	return nil
 }
 
-// SystemConfigRepositoryImpl 方法存根
+// GetByKey 根据键获取系统配置，不存在时返回ErrNotFound
 func (r *SystemConfigRepositoryImpl) GetByKey(ctx context.Context, key string) (*database.SystemConfig, error) {
-	// TODO: 实现
-	return nil, repository.ErrNotFound
+	var config database.SystemConfig
+	if err := r.db.WithContext(ctx).Where(&database.SystemConfig{Key: key}).First(&config).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("获取系统配置失败: %w", err)
+	}
+	return &config, nil
 }
 
+// SetValue 设置系统配置的值，配置不存在时创建
 func (r *SystemConfigRepositoryImpl) SetValue(ctx context.Context, key, value string) error {
-	// TODO: 实现
+	config := &database.SystemConfig{Key: key, Value: value}
+	err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "key"}},
+			DoUpdates: clause.AssignmentColumns([]string{"value", "updated_at"}),
+		}).
+		Create(config).Error
+	if err != nil {
+		return fmt.Errorf("保存系统配置失败: %w", err)
+	}
 	return nil
 }
 
@@ -2,7 +2,9 @@ package mysql
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -24,6 +26,87 @@ func NewTaskRepository(db *gorm.DB) repository.TaskRepository {
 	}
 }
 
+// List 分页获取任务，在通用过滤条件之外支持按标签过滤，include_archived为true时同时查询归档任务
+func (r *TaskRepositoryImpl) List(ctx context.Context, filter repository.ListFilter) ([]*database.Task, int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var tasks []*database.Task
+	var total int64
+	query := r.db.WithContext(ctx).Model(&database.Task{})
+	if includeArchived, _ := filter.Filters["include_archived"].(bool); includeArchived {
+		query = withArchive(query, &database.Task{}, database.TaskArchive{}.TableName())
+	}
+	query = r.applyTaskFilters(query, filter.Filters)
+	if err := query.Count(&total).Error; err != nil {
+		logger.Errorf("获取任务总数失败: %v", err)
+		return nil, 0, fmt.Errorf("获取任务总数失败: %w", err)
+	}
+
+	query = r.applySorting(r.applyPagination(query, filter), filter)
+	if err := query.Find(&tasks).Error; err != nil {
+		logger.Errorf("获取任务列表失败: %v", err)
+		return nil, 0, fmt.Errorf("获取任务列表失败: %w", err)
+	}
+	return tasks, total, nil
+}
+
+// applyTaskFilters 应用任务过滤条件，labels条件按标签名称不区分大小写匹配，visibility条件限制可见范围，其余条件交给通用过滤
+func (r *TaskRepositoryImpl) applyTaskFilters(query *gorm.DB, filters map[string]interface{}) *gorm.DB {
+	remaining := make(map[string]interface{}, len(filters))
+	for key, value := range filters {
+		if key != "labels" && key != "include_archived" && key != "visibility" {
+			remaining[key] = value
+		}
+	}
+	if visibility, ok := filters["visibility"].(repository.TaskVisibilityFilter); ok {
+		query = query.Where(r.taskVisibilityCondition(visibility))
+	}
+
+	labelFilter, ok := filters["labels"].(repository.TaskLabelFilter)
+	if !ok || len(labelFilter.Names) == 0 {
+		return r.applyFilters(query, remaining)
+	}
+
+	names := make([]string, 0, len(labelFilter.Names))
+	seen := make(map[string]bool, len(labelFilter.Names))
+	for _, name := range labelFilter.Names {
+		name = strings.ToLower(name)
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	matched := r.db.Table("task_labels").
+		Select("task_labels.task_id").
+		Joins("JOIN labels ON labels.id = task_labels.label_id").
+		Where("LOWER(labels.name) IN ?", names).
+		Group("task_labels.task_id")
+	if !labelFilter.MatchAny {
+		matched = matched.Having("COUNT(DISTINCT task_labels.label_id) = ?", len(names))
+	}
+	return r.applyFilters(query.Where("tasks.id IN (?)", matched), remaining)
+}
+
+// taskVisibilityCondition 构建可见范围条件，各范围之间为或关系，整体作为一组括号条件
+// 团队范围按负责人用户关联员工档案的部门判断
+func (r *TaskRepositoryImpl) taskVisibilityCondition(visibility repository.TaskVisibilityFilter) *gorm.DB {
+	condition := r.db.Where("tasks.assignee_id = ?", visibility.UserID)
+	if visibility.IncludeCreated {
+		condition = condition.Or("tasks.creator_id = ?", visibility.UserID)
+	}
+	if visibility.DepartmentID != nil {
+		members := r.db.Model(&database.Employee{}).Select("user_id").Where("department_id = ?", *visibility.DepartmentID)
+		condition = condition.Or("tasks.assignee_id IN (?)", members)
+	}
+	return condition
+}
+
+// Update 按版本号更新任务，任务已被其他请求修改时返回ErrConflict
+func (r *TaskRepositoryImpl) Update(ctx context.Context, task *database.Task) error {
+	return updateVersioned(ctx, r.db, task, task.ID, &task.Version)
+}
+
 // GetByStatus 根据状态获取任务列表
 func (r *TaskRepositoryImpl) GetByStatus(ctx context.Context, status string) ([]*database.Task, error) {
 	var tasks []*database.Task
@@ -51,6 +134,60 @@ func (r *TaskRepositoryImpl) GetByAssignee(ctx context.Context, assigneeID uint,
 	return tasks, nil
 }
 
+// CountByAssigneeGroupByStatus 按状态统计负责人的任务数
+func (r *TaskRepositoryImpl) CountByAssigneeGroupByStatus(ctx context.Context, assigneeID uint) (map[string]int64, error) {
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	err := r.db.WithContext(ctx).Model(&database.Task{}).
+		Select("status, COUNT(*) AS count").
+		Where("assignee_id = ?", assigneeID).
+		Group("status").
+		Scan(&rows).Error
+	if err != nil {
+		logger.Errorf("统计负责人任务数失败: %v", err)
+		return nil, fmt.Errorf("统计负责人任务数失败: %w", err)
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+// ListOpenByAssignee 获取负责人未完成、未取消的任务及总数
+func (r *TaskRepositoryImpl) ListOpenByAssignee(ctx context.Context, filter *repository.OpenTaskFilter) ([]*database.Task, int64, error) {
+	query := r.db.WithContext(ctx).Model(&database.Task{}).
+		Where("assignee_id = ? AND status NOT IN ?", filter.AssigneeID, []string{"completed", "cancelled"})
+	if filter.DueFrom != nil {
+		query = query.Where("due_date >= ?", *filter.DueFrom)
+	}
+	if filter.DueTo != nil {
+		query = query.Where("due_date <= ?", *filter.DueTo)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		logger.Errorf("统计负责人未完成任务失败: %v", err)
+		return nil, 0, fmt.Errorf("统计负责人未完成任务失败: %w", err)
+	}
+
+	var tasks []*database.Task
+	err := query.
+		Order("due_date IS NULL, due_date ASC").
+		Order("CASE priority WHEN 'urgent' THEN 4 WHEN 'high' THEN 3 WHEN 'medium' THEN 2 ELSE 1 END DESC").
+		Order("id ASC").
+		Limit(filter.Limit).
+		Find(&tasks).Error
+	if err != nil {
+		logger.Errorf("获取负责人未完成任务失败: %v", err)
+		return nil, 0, fmt.Errorf("获取负责人未完成任务失败: %w", err)
+	}
+	return tasks, total, nil
+}
+
 // GetByCreator 根据创建者获取任务列表
 func (r *TaskRepositoryImpl) GetByCreator(ctx context.Context, creatorID uint) ([]*database.Task, error) {
 	var tasks []*database.Task
@@ -94,7 +231,10 @@ func (r *TaskRepositoryImpl) GetTaskWithDetails(ctx context.Context, taskID uint
 func (r *TaskRepositoryImpl) UpdateStatus(ctx context.Context, taskID uint, status string) error {
 	result := r.db.WithContext(ctx).Model(&database.Task{}).
 		Where("id = ?", taskID).
-		Update("status", status)
+		Updates(map[string]interface{}{
+			"status":  status,
+			"version": gorm.Expr("version + 1"),
+		})
 	
 	if result.Error != nil {
 		logger.Errorf("更新任务状态失败: %v", result.Error)
@@ -108,6 +248,27 @@ func (r *TaskRepositoryImpl) UpdateStatus(ctx context.Context, taskID uint, stat
 	return nil
 }
 
+// UpdatePriority 更新任务优先级
+func (r *TaskRepositoryImpl) UpdatePriority(ctx context.Context, taskID uint, priority string) error {
+	result := r.db.WithContext(ctx).Model(&database.Task{}).
+		Where("id = ?", taskID).
+		Updates(map[string]interface{}{
+			"priority": priority,
+			"version":  gorm.Expr("version + 1"),
+		})
+
+	if result.Error != nil {
+		logger.Errorf("更新任务优先级失败: %v", result.Error)
+		return fmt.Errorf("更新任务优先级失败: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
 // AssignTask 分配任务给员工
 func (r *TaskRepositoryImpl) AssignTask(ctx context.Context, taskID, assigneeID uint) error {
 	// 创建分配记录
@@ -168,11 +329,28 @@ func (r *TaskRepositoryImpl) GetActiveTasksByEmployee(ctx context.Context, emplo
 	return tasks, nil
 }
 
+// ListTasksWithUnknownAssignee 获取负责人ID不对应任何用户（包括已删除用户）的任务
+func (r *TaskRepositoryImpl) ListTasksWithUnknownAssignee(ctx context.Context) ([]*database.Task, error) {
+	var tasks []*database.Task
+	if err := r.db.WithContext(ctx).
+		Joins("LEFT JOIN users ON users.id = tasks.assignee_id").
+		Where("tasks.assignee_id IS NOT NULL AND users.id IS NULL").
+		Order("tasks.id").
+		Find(&tasks).Error; err != nil {
+		logger.Errorf("查询负责人无效的任务失败: %v", err)
+		return nil, fmt.Errorf("查询负责人无效的任务失败: %w", err)
+	}
+	return tasks, nil
+}
+
 // UpdateAssignee 更新任务分配人
 func (r *TaskRepositoryImpl) UpdateAssignee(ctx context.Context, taskID, assigneeID uint) error {
 	result := r.db.WithContext(ctx).Model(&database.Task{}).
 		Where("id = ?", taskID).
-		Update("assignee_id", assigneeID)
+		Updates(map[string]interface{}{
+			"assignee_id": assigneeID,
+			"version":     gorm.Expr("version + 1"),
+		})
 	
 	if result.Error != nil {
 		logger.Errorf("更新任务分配人失败: %v", result.Error)
@@ -185,3 +363,219 @@ func (r *TaskRepositoryImpl) UpdateAssignee(ctx context.Context, taskID, assigne
 	
 	return nil
 }
+
+// GetByProject 获取项目下的任务，filters按字段精确匹配
+func (r *TaskRepositoryImpl) GetByProject(ctx context.Context, projectID uint, filters map[string]interface{}) ([]*database.Task, error) {
+	var tasks []*database.Task
+	query := r.db.WithContext(ctx).Where("project_id = ?", projectID)
+	query = r.applyTaskFilters(query, filters)
+	if err := query.Order("created_at ASC").Find(&tasks).Error; err != nil {
+		logger.Errorf("获取项目任务失败: %v", err)
+		return nil, fmt.Errorf("获取项目任务失败: %w", err)
+	}
+	return tasks, nil
+}
+
+// GetProjectTimelineTasks 获取项目全部任务并预加载负责人，按创建时间排序
+func (r *TaskRepositoryImpl) GetProjectTimelineTasks(ctx context.Context, projectID uint) ([]*database.Task, error) {
+	var tasks []*database.Task
+	if err := r.db.WithContext(ctx).
+		Preload("Assignee").
+		Where("project_id = ?", projectID).
+		Order("created_at ASC").
+		Find(&tasks).Error; err != nil {
+		logger.Errorf("获取项目时间线任务失败: %v", err)
+		return nil, fmt.Errorf("获取项目时间线任务失败: %w", err)
+	}
+	return tasks, nil
+}
+
+// CountByProjectAndStatus 统计项目下指定状态的任务数
+func (r *TaskRepositoryImpl) CountByProjectAndStatus(ctx context.Context, projectID uint, status string) (int64, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&database.Task{}).
+		Where("project_id = ? AND status = ?", projectID, status).
+		Count(&count).Error; err != nil {
+		logger.Errorf("统计项目任务数失败: %v", err)
+		return 0, fmt.Errorf("统计项目任务数失败: %w", err)
+	}
+	return count, nil
+}
+
+// GetSkillRequirements 获取任务的技能要求及所需等级
+func (r *TaskRepositoryImpl) GetSkillRequirements(ctx context.Context, taskID uint) ([]*database.TaskSkillDetail, error) {
+	var details []*database.TaskSkillDetail
+	if err := r.db.WithContext(ctx).
+		Model(&database.TaskSkill{}).
+		Select("task_skills.skill_id, skills.name, skill_categories.name AS category, task_skills.required, task_skills.level").
+		Joins("JOIN skills ON skills.id = task_skills.skill_id AND skills.deleted_at IS NULL").
+		Joins("LEFT JOIN skill_categories ON skill_categories.id = skills.category_id").
+		Where("task_skills.task_id = ?", taskID).
+		Order("task_skills.skill_id ASC").
+		Scan(&details).Error; err != nil {
+		logger.Errorf("获取任务技能要求失败: %v", err)
+		return nil, fmt.Errorf("获取任务技能要求失败: %w", err)
+	}
+	return details, nil
+}
+
+// ReplaceSkills 用给定的技能要求整体替换任务现有的技能要求
+func (r *TaskRepositoryImpl) ReplaceSkills(ctx context.Context, taskID uint, skills []*database.TaskSkill) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("task_id = ?", taskID).Delete(&database.TaskSkill{}).Error; err != nil {
+			return err
+		}
+		if len(skills) == 0 {
+			return nil
+		}
+		for _, skill := range skills {
+			skill.TaskID = taskID
+		}
+		return tx.Create(&skills).Error
+	})
+	if err != nil {
+		logger.Errorf("更新任务技能要求失败: %v", err)
+		return fmt.Errorf("更新任务技能要求失败: %w", err)
+	}
+	return nil
+}
+
+// CountSubTasks 按父任务分组统计子任务数和已完成数
+func (r *TaskRepositoryImpl) CountSubTasks(ctx context.Context, parentIDs []uint) (map[uint]*repository.SubTaskCounts, error) {
+	counts := make(map[uint]*repository.SubTaskCounts)
+	if len(parentIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		ParentID  uint
+		Total     int
+		Completed int
+	}
+	err := r.db.WithContext(ctx).
+		Model(&database.Task{}).
+		Select("parent_id, COUNT(*) AS total, SUM(CASE WHEN status = ? THEN 1 ELSE 0 END) AS completed", database.TaskStatusCompleted).
+		Where("parent_id IN ? AND status <> ?", parentIDs, database.TaskStatusCancelled).
+		Group("parent_id").
+		Scan(&rows).Error
+	if err != nil {
+		logger.Errorf("统计子任务数量失败: %v", err)
+		return nil, fmt.Errorf("统计子任务数量失败: %w", err)
+	}
+
+	for _, row := range rows {
+		counts[row.ParentID] = &repository.SubTaskCounts{Total: row.Total, Completed: row.Completed}
+	}
+	return counts, nil
+}
+
+// CancelTree 逐层查找后代任务，在同一事务中取消任务本身及未完成的后代任务
+func (r *TaskRepositoryImpl) CancelTree(ctx context.Context, taskID uint, cancelledAt time.Time) ([]*database.Task, error) {
+	finished := []string{database.TaskStatusCompleted, database.TaskStatusCancelled}
+	var cancelled []*database.Task
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		ids := []uint{taskID}
+		visited := map[uint]bool{taskID: true}
+		for frontier := []uint{taskID}; len(frontier) > 0; {
+			var children []uint
+			if err := tx.Model(&database.Task{}).Where("parent_id IN ?", frontier).Pluck("id", &children).Error; err != nil {
+				return err
+			}
+			frontier = frontier[:0]
+			for _, id := range children {
+				// 防止错误数据形成环
+				if !visited[id] {
+					visited[id] = true
+					ids = append(ids, id)
+					frontier = append(frontier, id)
+				}
+			}
+		}
+
+		if err := tx.Where("id IN ? AND status NOT IN ?", ids, finished).Find(&cancelled).Error; err != nil {
+			return err
+		}
+		cancelledIDs := make([]uint, 0, len(cancelled))
+		rootFound := false
+		for _, task := range cancelled {
+			cancelledIDs = append(cancelledIDs, task.ID)
+			rootFound = rootFound || task.ID == taskID
+		}
+		if !rootFound {
+			return repository.ErrConflict
+		}
+
+		result := tx.Model(&database.Task{}).
+			Where("id IN ? AND status NOT IN ?", cancelledIDs, finished).
+			Updates(map[string]interface{}{
+				"status":       database.TaskStatusCancelled,
+				"completed_at": cancelledAt,
+				"version":      gorm.Expr("version + 1"),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		// 查询后有任务被并发完成或取消
+		if result.RowsAffected != int64(len(cancelledIDs)) {
+			return repository.ErrConflict
+		}
+		return nil
+	})
+	if errors.Is(err, repository.ErrConflict) {
+		return nil, err
+	}
+	if err != nil {
+		logger.Errorf("级联取消任务失败: %v", err)
+		return nil, fmt.Errorf("级联取消任务失败: %w", err)
+	}
+	return cancelled, nil
+}
+
+// FindMissingIDs 返回给定ID中不存在或已删除的任务ID，按输入顺序
+func (r *TaskRepositoryImpl) FindMissingIDs(ctx context.Context, taskIDs []uint) ([]uint, error) {
+	if len(taskIDs) == 0 {
+		return nil, nil
+	}
+	var existing []uint
+	if err := r.db.WithContext(ctx).Model(&database.Task{}).Where("id IN ?", taskIDs).Pluck("id", &existing).Error; err != nil {
+		logger.Errorf("查询任务失败: %v", err)
+		return nil, fmt.Errorf("查询任务失败: %w", err)
+	}
+	found := make(map[uint]bool, len(existing))
+	for _, id := range existing {
+		found[id] = true
+	}
+	var missing []uint
+	for _, id := range taskIDs {
+		if !found[id] {
+			missing = append(missing, id)
+		}
+	}
+	return missing, nil
+}
+
+// GetArchivedByID 获取归档任务
+func (r *TaskRepositoryImpl) GetArchivedByID(ctx context.Context, taskID uint) (*database.TaskArchive, error) {
+	var archive database.TaskArchive
+	if err := r.db.WithContext(ctx).First(&archive, taskID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrNotFound
+		}
+		logger.Errorf("获取归档任务失败: %v", err)
+		return nil, fmt.Errorf("获取归档任务失败: %w", err)
+	}
+	return &archive, nil
+}
+
+// FindArchivedIDs 返回给定ID中已归档的任务ID
+func (r *TaskRepositoryImpl) FindArchivedIDs(ctx context.Context, taskIDs []uint) ([]uint, error) {
+	if len(taskIDs) == 0 {
+		return nil, nil
+	}
+	var archived []uint
+	if err := r.db.WithContext(ctx).Model(&database.TaskArchive{}).Where("id IN ?", taskIDs).Pluck("id", &archived).Error; err != nil {
+		logger.Errorf("查询归档任务失败: %v", err)
+		return nil, fmt.Errorf("查询归档任务失败: %w", err)
+	}
+	return archived, nil
+}
@@ -0,0 +1,33 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+// TaskAttachmentRepositoryImpl 任务附件仓储实现
+type TaskAttachmentRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewTaskAttachmentRepository 创建任务附件仓储
+func NewTaskAttachmentRepository(db *gorm.DB) repository.TaskAttachmentRepository {
+	return &TaskAttachmentRepositoryImpl{db: db}
+}
+
+// GetByIDs 批量获取附件，不存在的ID被忽略
+func (r *TaskAttachmentRepositoryImpl) GetByIDs(ctx context.Context, ids []uint) ([]*database.TaskAttachment, error) {
+	var attachments []*database.TaskAttachment
+	if len(ids) == 0 {
+		return attachments, nil
+	}
+	if err := r.db.WithContext(ctx).Where("id IN ?", ids).Order("id ASC").Find(&attachments).Error; err != nil {
+		return nil, fmt.Errorf("批量获取任务附件失败: %w", err)
+	}
+	return attachments, nil
+}
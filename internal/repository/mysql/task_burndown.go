@@ -0,0 +1,107 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+	"taskmanage/pkg/logger"
+)
+
+// timeSlotLayout 时段表达式返回的时间格式
+const timeSlotLayout = "2006-01-02 15:04"
+
+// timeSlotExpr 将时间列截断到所在15分钟时段的表达式，结果为数据库中存储的本地时间字符串
+// 连接串使用loc=Local，按本地时区解析即可得到准确时刻；15分钟时段可按任意时区折算为日期
+func timeSlotExpr(db *gorm.DB, column string) string {
+	if db.Dialector.Name() == database.DriverPostgres {
+		return fmt.Sprintf("to_char(date_trunc('hour', %[1]s) + FLOOR(EXTRACT(MINUTE FROM %[1]s) / 15) * INTERVAL '15 minutes', 'YYYY-MM-DD HH24:MI')", column)
+	}
+	return fmt.Sprintf("CONCAT(DATE_FORMAT(%[1]s, '%%Y-%%m-%%d %%H:'), LPAD(FLOOR(MINUTE(%[1]s) / 15) * 15, 2, '0'))", column)
+}
+
+// sumByTimeSlot 按timeColumn所在时段分组汇总valueExpr
+func sumByTimeSlot(query *gorm.DB, timeColumn, valueExpr string) ([]repository.TimeSlotSum, error) {
+	slot := timeSlotExpr(query, timeColumn)
+	var rows []struct {
+		Slot  string
+		Value float64
+	}
+	if err := query.
+		Select(fmt.Sprintf("%s AS slot, COALESCE(SUM(%s), 0) AS value", slot, valueExpr)).
+		Group(slot).
+		Order("slot").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	sums := make([]repository.TimeSlotSum, 0, len(rows))
+	for _, row := range rows {
+		start, err := time.ParseInLocation(timeSlotLayout, row.Slot, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("解析时段%s失败: %w", row.Slot, err)
+		}
+		sums = append(sums, repository.TimeSlotSum{Start: start, Value: row.Value})
+	}
+	return sums, nil
+}
+
+// GetProjectRemainingEstimate 项目在at时刻剩余的预估工时
+func (r *TaskRepositoryImpl) GetProjectRemainingEstimate(ctx context.Context, projectID uint, at time.Time) (float64, error) {
+	var remaining float64
+	err := r.db.WithContext(ctx).
+		Model(&database.Task{}).
+		Where("project_id = ? AND created_at < ? AND status <> ?", projectID, at, database.TaskStatusCancelled).
+		Where("NOT (status = ? AND completed_at < ?)", database.TaskStatusCompleted, at).
+		Select("COALESCE(SUM(estimated_hours), 0)").
+		Scan(&remaining).Error
+	if err != nil {
+		logger.Errorf("统计项目剩余预估工时失败: %v", err)
+		return 0, fmt.Errorf("统计项目剩余预估工时失败: %w", err)
+	}
+	return remaining, nil
+}
+
+// SumProjectEstimatesByCreatedSlot 按创建时段汇总项目新建任务的预估工时
+func (r *TaskRepositoryImpl) SumProjectEstimatesByCreatedSlot(ctx context.Context, projectID uint, from, to time.Time) ([]repository.TimeSlotSum, error) {
+	query := r.db.WithContext(ctx).
+		Model(&database.Task{}).
+		Where("project_id = ? AND created_at >= ? AND created_at < ? AND status <> ?", projectID, from, to, database.TaskStatusCancelled)
+	sums, err := sumByTimeSlot(query, "created_at", "estimated_hours")
+	if err != nil {
+		logger.Errorf("按创建时间汇总项目预估工时失败: %v", err)
+		return nil, fmt.Errorf("按创建时间汇总项目预估工时失败: %w", err)
+	}
+	return sums, nil
+}
+
+// SumProjectEstimatesByCompletedSlot 按完成时段汇总项目已完成任务的预估工时
+func (r *TaskRepositoryImpl) SumProjectEstimatesByCompletedSlot(ctx context.Context, projectID uint, from, to time.Time) ([]repository.TimeSlotSum, error) {
+	query := r.db.WithContext(ctx).
+		Model(&database.Task{}).
+		Where("project_id = ? AND status = ? AND completed_at >= ? AND completed_at < ?", projectID, database.TaskStatusCompleted, from, to)
+	sums, err := sumByTimeSlot(query, "completed_at", "estimated_hours")
+	if err != nil {
+		logger.Errorf("按完成时间汇总项目预估工时失败: %v", err)
+		return nil, fmt.Errorf("按完成时间汇总项目预估工时失败: %w", err)
+	}
+	return sums, nil
+}
+
+// SumProjectUntrackedHoursByCompletedSlot 按完成时段汇总没有工时记录的已完成任务的实际工时
+// 有工时记录的任务按工时记录统计，这里排除以免重复计算
+func (r *TaskRepositoryImpl) SumProjectUntrackedHoursByCompletedSlot(ctx context.Context, projectID uint, from, to time.Time) ([]repository.TimeSlotSum, error) {
+	query := r.db.WithContext(ctx).
+		Model(&database.Task{}).
+		Where("project_id = ? AND status = ? AND completed_at >= ? AND completed_at < ?", projectID, database.TaskStatusCompleted, from, to).
+		Where("NOT EXISTS (SELECT 1 FROM time_entries WHERE time_entries.task_id = tasks.id AND time_entries.deleted_at IS NULL)")
+	sums, err := sumByTimeSlot(query, "completed_at", "actual_hours")
+	if err != nil {
+		logger.Errorf("按完成时间汇总项目实际工时失败: %v", err)
+		return nil, fmt.Errorf("按完成时间汇总项目实际工时失败: %w", err)
+	}
+	return sums, nil
+}
@@ -0,0 +1,47 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+// TaskCommentRepositoryImpl 任务评论仓储MySQL实现
+type TaskCommentRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewTaskCommentRepository 创建任务评论仓储
+func NewTaskCommentRepository(db *gorm.DB) repository.TaskCommentRepository {
+	return &TaskCommentRepositoryImpl{db: db}
+}
+
+// Create 创建评论
+func (r *TaskCommentRepositoryImpl) Create(ctx context.Context, comment *database.TaskComment) error {
+	return r.db.WithContext(ctx).Omit("Task", "User", "Parent", "Replies").Create(comment).Error
+}
+
+// GetByID 根据ID获取评论
+func (r *TaskCommentRepositoryImpl) GetByID(ctx context.Context, id uint) (*database.TaskComment, error) {
+	var comment database.TaskComment
+	if err := r.db.WithContext(ctx).First(&comment, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// ListByTask 获取任务的全部评论，按创建时间排序
+func (r *TaskCommentRepositoryImpl) ListByTask(ctx context.Context, taskID uint) ([]*database.TaskComment, error) {
+	var comments []*database.TaskComment
+	err := r.db.WithContext(ctx).
+		Where("task_id = ?", taskID).
+		Order("created_at ASC").
+		Find(&comments).Error
+	return comments, err
+}
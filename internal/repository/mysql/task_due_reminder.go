@@ -0,0 +1,76 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+// dueReminderStatuses 需要截止提醒的任务状态
+var dueReminderStatuses = []string{"assigned", "in_progress"}
+
+// TaskDueReminderRepositoryImpl 任务截止提醒仓储MySQL实现
+type TaskDueReminderRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewTaskDueReminderRepository 创建任务截止提醒仓储
+func NewTaskDueReminderRepository(db *gorm.DB) repository.TaskDueReminderRepository {
+	return &TaskDueReminderRepositoryImpl{db: db}
+}
+
+// ListDueSoon 获取即将到期且尚未按该提前量或更近的提前量提醒过的任务
+func (r *TaskDueReminderRepositoryImpl) ListDueSoon(ctx context.Context, priority string, leadHours int, now, until time.Time, limit int) ([]*database.Task, error) {
+	reminded := r.db.Model(&database.TaskDueReminder{}).
+		Select("1").
+		Where("task_due_reminders.task_id = tasks.id AND task_due_reminders.lead_hours <= ?", leadHours)
+
+	var tasks []*database.Task
+	err := r.db.WithContext(ctx).
+		Where("status IN ? AND priority = ? AND assignee_id IS NOT NULL", dueReminderStatuses, priority).
+		Where("due_date > ? AND due_date <= ?", now, until).
+		Where("NOT EXISTS (?)", reminded).
+		Order("due_date ASC").
+		Limit(limit).
+		Find(&tasks).Error
+	if err != nil {
+		return nil, fmt.Errorf("获取即将到期任务失败: %w", err)
+	}
+	return tasks, nil
+}
+
+// ListOverdue 获取已逾期且尚未发送逾期提醒的任务
+func (r *TaskDueReminderRepositoryImpl) ListOverdue(ctx context.Context, now time.Time, limit int) ([]*database.Task, error) {
+	reminded := r.db.Model(&database.TaskDueReminder{}).
+		Select("1").
+		Where("task_due_reminders.task_id = tasks.id AND task_due_reminders.lead_hours = 0")
+
+	var tasks []*database.Task
+	err := r.db.WithContext(ctx).
+		Where("status IN ? AND assignee_id IS NOT NULL AND due_date <= ?", dueReminderStatuses, now).
+		Where("NOT EXISTS (?)", reminded).
+		Order("due_date ASC").
+		Limit(limit).
+		Find(&tasks).Error
+	if err != nil {
+		return nil, fmt.Errorf("获取逾期任务失败: %w", err)
+	}
+	return tasks, nil
+}
+
+// Record 记录提醒，主键冲突说明该提前量已提醒过
+func (r *TaskDueReminderRepositoryImpl) Record(ctx context.Context, reminder *database.TaskDueReminder) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(reminder)
+	if result.Error != nil {
+		return false, fmt.Errorf("记录任务截止提醒失败: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
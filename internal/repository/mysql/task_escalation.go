@@ -0,0 +1,112 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+// TaskEscalationRepositoryImpl 任务优先级老化仓储MySQL实现
+type TaskEscalationRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewTaskEscalationRepository 创建任务优先级老化仓储
+func NewTaskEscalationRepository(db *gorm.DB) repository.TaskEscalationRepository {
+	return &TaskEscalationRepositoryImpl{db: db}
+}
+
+// CreateRule 创建规则
+func (r *TaskEscalationRepositoryImpl) CreateRule(ctx context.Context, rule *database.TaskEscalationRule) error {
+	if err := r.db.WithContext(ctx).Create(rule).Error; err != nil {
+		return fmt.Errorf("创建任务升级规则失败: %w", err)
+	}
+	return nil
+}
+
+// GetRule 根据ID获取规则，不存在时返回ErrNotFound
+func (r *TaskEscalationRepositoryImpl) GetRule(ctx context.Context, id uint) (*database.TaskEscalationRule, error) {
+	var rule database.TaskEscalationRule
+	if err := r.db.WithContext(ctx).First(&rule, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("获取任务升级规则失败: %w", err)
+	}
+	return &rule, nil
+}
+
+// UpdateRule 更新规则
+func (r *TaskEscalationRepositoryImpl) UpdateRule(ctx context.Context, rule *database.TaskEscalationRule) error {
+	if err := r.db.WithContext(ctx).Save(rule).Error; err != nil {
+		return fmt.Errorf("更新任务升级规则失败: %w", err)
+	}
+	return nil
+}
+
+// DeleteRule 删除规则及其升级记录，不存在时返回ErrNotFound
+func (r *TaskEscalationRepositoryImpl) DeleteRule(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&database.TaskEscalationRule{}, id)
+		if result.Error != nil {
+			return fmt.Errorf("删除任务升级规则失败: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return repository.ErrNotFound
+		}
+		if err := tx.Where("rule_id = ?", id).Delete(&database.TaskEscalation{}).Error; err != nil {
+			return fmt.Errorf("删除任务升级记录失败: %w", err)
+		}
+		return nil
+	})
+}
+
+// ListRules 获取规则，按阈值从小到大排序
+func (r *TaskEscalationRepositoryImpl) ListRules(ctx context.Context, enabledOnly bool) ([]*database.TaskEscalationRule, error) {
+	query := r.db.WithContext(ctx).Order("threshold_hours ASC, id ASC")
+	if enabledOnly {
+		query = query.Where("enabled = ?", true)
+	}
+	var rules []*database.TaskEscalationRule
+	if err := query.Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("获取任务升级规则失败: %w", err)
+	}
+	return rules, nil
+}
+
+// ListCandidates 获取满足规则条件且尚未被该规则升级的待分配任务
+func (r *TaskEscalationRepositoryImpl) ListCandidates(ctx context.Context, ruleID uint, priority string, createdBefore time.Time, limit int) ([]*database.Task, error) {
+	escalated := r.db.Model(&database.TaskEscalation{}).
+		Select("1").
+		Where("task_escalations.task_id = tasks.id AND task_escalations.rule_id = ?", ruleID)
+
+	var tasks []*database.Task
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND priority = ? AND created_at <= ?", "pending", priority, createdBefore).
+		Where("NOT EXISTS (?)", escalated).
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&tasks).Error
+	if err != nil {
+		return nil, fmt.Errorf("获取待升级任务失败: %w", err)
+	}
+	return tasks, nil
+}
+
+// Record 记录升级，主键冲突说明该规则已升级过该任务
+func (r *TaskEscalationRepositoryImpl) Record(ctx context.Context, escalation *database.TaskEscalation) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(escalation)
+	if result.Error != nil {
+		return false, fmt.Errorf("记录任务升级失败: %w", result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
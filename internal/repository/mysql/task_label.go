@@ -0,0 +1,184 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+	"taskmanage/pkg/logger"
+)
+
+// taskLabelLink 任务与标签的关联记录，对应task_labels表
+type taskLabelLink struct {
+	TaskID  uint
+	LabelID uint
+}
+
+// TaskLabelRepositoryImpl 任务标签仓储实现
+type TaskLabelRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewTaskLabelRepository 创建任务标签仓储
+func NewTaskLabelRepository(db *gorm.DB) repository.TaskLabelRepository {
+	return &TaskLabelRepositoryImpl{db: db}
+}
+
+// Create 创建标签
+func (r *TaskLabelRepositoryImpl) Create(ctx context.Context, label *database.TaskLabel) error {
+	if err := r.db.WithContext(ctx).Create(label).Error; err != nil {
+		logger.Errorf("创建任务标签失败: %v", err)
+		return fmt.Errorf("创建任务标签失败: %w", err)
+	}
+	return nil
+}
+
+// GetByID 根据ID获取标签，不存在时返回ErrNotFound
+func (r *TaskLabelRepositoryImpl) GetByID(ctx context.Context, id uint) (*database.TaskLabel, error) {
+	var label database.TaskLabel
+	if err := r.db.WithContext(ctx).First(&label, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("获取任务标签失败: %w", err)
+	}
+	return &label, nil
+}
+
+// GetByName 按名称不区分大小写查找标签，不存在时返回ErrNotFound
+func (r *TaskLabelRepositoryImpl) GetByName(ctx context.Context, name string) (*database.TaskLabel, error) {
+	var label database.TaskLabel
+	if err := r.db.WithContext(ctx).Where("LOWER(name) = LOWER(?)", name).First(&label).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("根据名称获取任务标签失败: %w", err)
+	}
+	return &label, nil
+}
+
+// GetByIDs 批量获取标签，不存在的ID被忽略
+func (r *TaskLabelRepositoryImpl) GetByIDs(ctx context.Context, ids []uint) ([]*database.TaskLabel, error) {
+	var labels []*database.TaskLabel
+	if len(ids) == 0 {
+		return labels, nil
+	}
+	if err := r.db.WithContext(ctx).Where("id IN ?", ids).Order("name ASC").Find(&labels).Error; err != nil {
+		return nil, fmt.Errorf("批量获取任务标签失败: %w", err)
+	}
+	return labels, nil
+}
+
+// ListWithTaskCounts 获取全部标签及各标签关联的任务数，按名称排序
+// 已删除的任务不计入
+func (r *TaskLabelRepositoryImpl) ListWithTaskCounts(ctx context.Context) ([]*repository.TaskLabelCount, error) {
+	var counts []*repository.TaskLabelCount
+	err := r.db.WithContext(ctx).
+		Model(&database.TaskLabel{}).
+		Select("labels.*, COUNT(tasks.id) AS task_count").
+		Joins("LEFT JOIN task_labels ON task_labels.label_id = labels.id").
+		Joins("LEFT JOIN tasks ON tasks.id = task_labels.task_id AND tasks.deleted_at IS NULL").
+		Group("labels.id, labels.name, labels.color, labels.creator_id, labels.created_at, labels.updated_at").
+		Order("labels.name ASC").
+		Scan(&counts).Error
+	if err != nil {
+		logger.Errorf("获取任务标签列表失败: %v", err)
+		return nil, fmt.Errorf("获取任务标签列表失败: %w", err)
+	}
+	return counts, nil
+}
+
+// ListByTask 获取任务的标签，按名称排序
+func (r *TaskLabelRepositoryImpl) ListByTask(ctx context.Context, taskID uint) ([]*database.TaskLabel, error) {
+	var labels []*database.TaskLabel
+	err := r.db.WithContext(ctx).
+		Joins("JOIN task_labels ON task_labels.label_id = labels.id").
+		Where("task_labels.task_id = ?", taskID).
+		Order("labels.name ASC").
+		Find(&labels).Error
+	if err != nil {
+		return nil, fmt.Errorf("获取任务标签失败: %w", err)
+	}
+	return labels, nil
+}
+
+// ReplaceTaskLabels 在一个事务内将任务的标签替换为给定集合
+func (r *TaskLabelRepositoryImpl) ReplaceTaskLabels(ctx context.Context, taskID uint, labelIDs []uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Table("task_labels").Where("task_id = ?", taskID).Delete(&taskLabelLink{}).Error; err != nil {
+			return fmt.Errorf("清除任务标签失败: %w", err)
+		}
+		if len(labelIDs) == 0 {
+			return nil
+		}
+		links := make([]*taskLabelLink, 0, len(labelIDs))
+		for _, labelID := range labelIDs {
+			links = append(links, &taskLabelLink{TaskID: taskID, LabelID: labelID})
+		}
+		if err := tx.Table("task_labels").Clauses(clause.OnConflict{DoNothing: true}).Create(&links).Error; err != nil {
+			return fmt.Errorf("保存任务标签失败: %w", err)
+		}
+		return nil
+	})
+}
+
+// AttachToTasks 在一个事务内为多个任务添加标签，已有该标签的任务跳过，返回新增关联数
+func (r *TaskLabelRepositoryImpl) AttachToTasks(ctx context.Context, labelID uint, taskIDs []uint) (int64, error) {
+	if len(taskIDs) == 0 {
+		return 0, nil
+	}
+	links := make([]*taskLabelLink, 0, len(taskIDs))
+	for _, taskID := range taskIDs {
+		links = append(links, &taskLabelLink{TaskID: taskID, LabelID: labelID})
+	}
+
+	var attached int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Table("task_labels").Clauses(clause.OnConflict{DoNothing: true}).Create(&links)
+		if result.Error != nil {
+			return result.Error
+		}
+		attached = result.RowsAffected
+		return nil
+	})
+	if err != nil {
+		logger.Errorf("批量添加任务标签失败: %v", err)
+		return 0, fmt.Errorf("批量添加任务标签失败: %w", err)
+	}
+	return attached, nil
+}
+
+// Delete 在一个事务内解除标签与全部任务的关联并删除标签，返回解除关联的任务数
+func (r *TaskLabelRepositoryImpl) Delete(ctx context.Context, id uint) (int64, error) {
+	var detached int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Table("task_labels").Where("label_id = ?", id).Delete(&taskLabelLink{})
+		if result.Error != nil {
+			return fmt.Errorf("解除任务标签关联失败: %w", result.Error)
+		}
+		detached = result.RowsAffected
+
+		result = tx.Delete(&database.TaskLabel{}, id)
+		if result.Error != nil {
+			return fmt.Errorf("删除任务标签失败: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return repository.ErrNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		if !errors.Is(err, repository.ErrNotFound) {
+			logger.Errorf("删除任务标签失败: %v", err)
+		}
+		return 0, err
+	}
+
+	logger.Infof("删除任务标签成功: ID=%d, DetachedTasks=%d", id, detached)
+	return detached, nil
+}
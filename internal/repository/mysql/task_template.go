@@ -0,0 +1,127 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+// TaskTemplateRepositoryImpl 任务模板仓储MySQL实现
+type TaskTemplateRepositoryImpl struct {
+	*BaseRepositoryImpl[database.TaskTemplate]
+}
+
+// NewTaskTemplateRepository 创建任务模板仓储
+func NewTaskTemplateRepository(db *gorm.DB) repository.TaskTemplateRepository {
+	return &TaskTemplateRepositoryImpl{
+		BaseRepositoryImpl: NewBaseRepository[database.TaskTemplate](db),
+	}
+}
+
+// GetRoot 获取模板根节点及其技能要求
+func (r *TaskTemplateRepositoryImpl) GetRoot(ctx context.Context, id uint) (*database.TaskTemplate, error) {
+	var root database.TaskTemplate
+	err := r.db.WithContext(ctx).
+		Preload("Skills.Skill").
+		Where("id = ? AND parent_id IS NULL", id).
+		First(&root).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, err
+	}
+	return &root, nil
+}
+
+// GetTree 获取模板的全部节点，按层级内顺序排列
+func (r *TaskTemplateRepositoryImpl) GetTree(ctx context.Context, rootID uint) ([]*database.TaskTemplate, error) {
+	var nodes []*database.TaskTemplate
+	err := r.db.WithContext(ctx).
+		Preload("Skills.Skill").
+		Where("id = ? OR root_id = ?", rootID, rootID).
+		Order("sort_order ASC").Order("id ASC").
+		Find(&nodes).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(nodes) == 0 {
+		return nil, repository.ErrNotFound
+	}
+	return nodes, nil
+}
+
+// ListRoots 分页获取模板根节点，filter.Filters["search"] 按标题模糊匹配
+func (r *TaskTemplateRepositoryImpl) ListRoots(ctx context.Context, filter repository.ListFilter) ([]*database.TaskTemplate, int64, error) {
+	query := r.db.WithContext(ctx).Model(&database.TaskTemplate{}).Where("parent_id IS NULL")
+	if search, ok := filter.Filters["search"].(string); ok && search != "" {
+		query = query.Where("title LIKE ?", "%"+search+"%")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("获取任务模板总数失败: %w", err)
+	}
+
+	var roots []*database.TaskTemplate
+	query = r.applyPagination(query, filter)
+	query = r.applySorting(query, filter)
+	if err := query.Preload("Skills.Skill").Find(&roots).Error; err != nil {
+		return nil, 0, fmt.Errorf("获取任务模板列表失败: %w", err)
+	}
+	return roots, total, nil
+}
+
+// ReplaceSkills 整体替换模板节点的技能要求
+func (r *TaskTemplateRepositoryImpl) ReplaceSkills(ctx context.Context, templateID uint, skills []*database.TaskTemplateSkill) error {
+	db := r.db.WithContext(ctx)
+	if err := db.Where("template_id = ?", templateID).Delete(&database.TaskTemplateSkill{}).Error; err != nil {
+		return fmt.Errorf("清除模板技能要求失败: %w", err)
+	}
+	if len(skills) == 0 {
+		return nil
+	}
+	for _, skill := range skills {
+		skill.TemplateID = templateID
+	}
+	if err := db.Omit("Skill").Create(&skills).Error; err != nil {
+		return fmt.Errorf("保存模板技能要求失败: %w", err)
+	}
+	return nil
+}
+
+// DeleteDescendants 物理删除模板的全部子节点及其技能要求
+func (r *TaskTemplateRepositoryImpl) DeleteDescendants(ctx context.Context, rootID uint) error {
+	db := r.db.WithContext(ctx)
+	descendants := db.Model(&database.TaskTemplate{}).Unscoped().Select("id").Where("root_id = ?", rootID)
+	if err := db.Where("template_id IN (?)", descendants).Delete(&database.TaskTemplateSkill{}).Error; err != nil {
+		return fmt.Errorf("删除子模板技能要求失败: %w", err)
+	}
+	if err := db.Unscoped().Where("root_id = ?", rootID).Delete(&database.TaskTemplate{}).Error; err != nil {
+		return fmt.Errorf("删除子模板失败: %w", err)
+	}
+	return nil
+}
+
+// Delete 物理删除整个模板，已实例化的任务不受影响
+func (r *TaskTemplateRepositoryImpl) Delete(ctx context.Context, rootID uint) error {
+	if err := r.DeleteDescendants(ctx, rootID); err != nil {
+		return err
+	}
+	db := r.db.WithContext(ctx)
+	if err := db.Where("template_id = ?", rootID).Delete(&database.TaskTemplateSkill{}).Error; err != nil {
+		return fmt.Errorf("删除模板技能要求失败: %w", err)
+	}
+	result := db.Unscoped().Where("id = ? AND parent_id IS NULL", rootID).Delete(&database.TaskTemplate{})
+	if result.Error != nil {
+		return fmt.Errorf("删除任务模板失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package mysql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+func TestApplyTaskFilters_Visibility(t *testing.T) {
+	db := unreachableDB(t).Session(&gorm.Session{DryRun: true})
+	repo := NewTaskRepository(db).(*TaskRepositoryImpl)
+	department := uint(3)
+
+	toSQL := func(visibility repository.TaskVisibilityFilter) string {
+		return db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+			query := repo.applyTaskFilters(tx.Model(&database.Task{}), map[string]interface{}{"status": "pending", "visibility": visibility})
+			return query.Find(&[]database.Task{})
+		})
+	}
+
+	sql := toSQL(repository.TaskVisibilityFilter{UserID: 5})
+	assert.Contains(t, sql, "WHERE tasks.assignee_id = 5 AND")
+	assert.Contains(t, sql, "status = 'pending'")
+	assert.NotContains(t, sql, "creator_id")
+
+	// 多个可见范围整体加括号，不影响其他过滤条件
+	sql = toSQL(repository.TaskVisibilityFilter{UserID: 5, IncludeCreated: true, DepartmentID: &department})
+	assert.Contains(t, sql, "WHERE (tasks.assignee_id = 5 OR tasks.creator_id = 5 OR tasks.assignee_id IN (SELECT `user_id` FROM `employees` WHERE department_id = 3 AND `employees`.`deleted_at` IS NULL)) AND status = 'pending'")
+}
@@ -0,0 +1,74 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+// TaskWatcherRepositoryImpl 任务关注者仓储MySQL实现
+type TaskWatcherRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewTaskWatcherRepository 创建任务关注者仓储
+func NewTaskWatcherRepository(db *gorm.DB) repository.TaskWatcherRepository {
+	return &TaskWatcherRepositoryImpl{db: db}
+}
+
+// Add 添加关注者，已关注时不重复添加
+func (r *TaskWatcherRepositoryImpl) Add(ctx context.Context, watcher *database.TaskWatcher) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(watcher).Error
+}
+
+// Remove 取消关注，未关注时返回ErrNotFound
+func (r *TaskWatcherRepositoryImpl) Remove(ctx context.Context, taskID, userID uint) error {
+	result := r.db.WithContext(ctx).
+		Where("task_id = ? AND user_id = ?", taskID, userID).
+		Delete(&database.TaskWatcher{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// ListByTask 获取任务的关注者，按关注时间排序
+func (r *TaskWatcherRepositoryImpl) ListByTask(ctx context.Context, taskID uint) ([]*database.TaskWatcher, error) {
+	var watchers []*database.TaskWatcher
+	err := r.db.WithContext(ctx).
+		Where("task_id = ?", taskID).
+		Order("created_at ASC").
+		Find(&watchers).Error
+	return watchers, err
+}
+
+// CountByTask 统计任务的关注者数量
+func (r *TaskWatcherRepositoryImpl) CountByTask(ctx context.Context, taskID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&database.TaskWatcher{}).
+		Where("task_id = ?", taskID).
+		Count(&count).Error
+	return count, err
+}
+
+// Exists 判断用户是否已关注任务
+func (r *TaskWatcherRepositoryImpl) Exists(ctx context.Context, taskID, userID uint) (bool, error) {
+	var watcher database.TaskWatcher
+	err := r.db.WithContext(ctx).
+		Where("task_id = ? AND user_id = ?", taskID, userID).
+		First(&watcher).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -0,0 +1,91 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+// TimeEntryRepositoryImpl 任务工时记录仓储MySQL实现
+type TimeEntryRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewTimeEntryRepository 创建任务工时记录仓储
+func NewTimeEntryRepository(db *gorm.DB) repository.TimeEntryRepository {
+	return &TimeEntryRepositoryImpl{db: db}
+}
+
+// Create 创建工时记录
+func (r *TimeEntryRepositoryImpl) Create(ctx context.Context, entry *database.TimeEntry) error {
+	return r.db.WithContext(ctx).Create(entry).Error
+}
+
+// GetByID 根据ID获取工时记录
+func (r *TimeEntryRepositoryImpl) GetByID(ctx context.Context, id uint) (*database.TimeEntry, error) {
+	var entry database.TimeEntry
+	if err := r.db.WithContext(ctx).First(&entry, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// Delete 删除工时记录
+func (r *TimeEntryRepositoryImpl) Delete(ctx context.Context, id uint) error {
+	result := r.db.WithContext(ctx).Delete(&database.TimeEntry{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// ListByTask 获取任务的全部工时记录，按开始时间排序
+func (r *TimeEntryRepositoryImpl) ListByTask(ctx context.Context, taskID uint) ([]*database.TimeEntry, error) {
+	var entries []*database.TimeEntry
+	err := r.db.WithContext(ctx).
+		Where("task_id = ?", taskID).
+		Order("started_at ASC").
+		Find(&entries).Error
+	return entries, err
+}
+
+// SumMinutesByTask 统计任务累计工时（分钟）
+func (r *TimeEntryRepositoryImpl) SumMinutesByTask(ctx context.Context, taskID uint) (int64, error) {
+	var total int64
+	err := r.db.WithContext(ctx).
+		Model(&database.TimeEntry{}).
+		Where("task_id = ?", taskID).
+		Select("COALESCE(SUM(duration_minutes), 0)").
+		Scan(&total).Error
+	return total, err
+}
+
+// ListByUserInRange 获取用户在[from, to)时间段内的工时记录，预加载任务信息
+func (r *TimeEntryRepositoryImpl) ListByUserInRange(ctx context.Context, userID uint, from, to time.Time) ([]*database.TimeEntry, error) {
+	var entries []*database.TimeEntry
+	err := r.db.WithContext(ctx).
+		Preload("Task").
+		Where("user_id = ? AND started_at >= ? AND started_at < ?", userID, from, to).
+		Order("started_at ASC").
+		Find(&entries).Error
+	return entries, err
+}
+
+// SumProjectHoursBySlot 按开始时段汇总项目任务的工时记录，单位为小时
+func (r *TimeEntryRepositoryImpl) SumProjectHoursBySlot(ctx context.Context, projectID uint, from, to time.Time) ([]repository.TimeSlotSum, error) {
+	query := r.db.WithContext(ctx).
+		Model(&database.TimeEntry{}).
+		Joins("JOIN tasks ON tasks.id = time_entries.task_id AND tasks.deleted_at IS NULL").
+		Where("tasks.project_id = ? AND time_entries.started_at >= ? AND time_entries.started_at < ?", projectID, from, to)
+	return sumByTimeSlot(query, "time_entries.started_at", "time_entries.duration_minutes / 60.0")
+}
@@ -58,6 +58,18 @@ func (r *UserRepositoryImpl) GetByEmail(ctx context.Context, email string) (*dat
 	return &user, nil
 }
 
+// GetByIDs 批量获取用户，不存在的ID被忽略
+func (r *UserRepositoryImpl) GetByIDs(ctx context.Context, ids []uint) ([]*database.User, error) {
+	var users []*database.User
+	if len(ids) == 0 {
+		return users, nil
+	}
+	if err := r.db.WithContext(ctx).Where("id IN ?", ids).Order("id ASC").Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("批量获取用户失败: %w", err)
+	}
+	return users, nil
+}
+
 // UpdateLastLogin 更新最后登录信息
 func (r *UserRepositoryImpl) UpdateLastLogin(ctx context.Context, userID uint, ip string) error {
 	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
@@ -229,22 +241,153 @@ func (r *UserRepositoryImpl) RemoveRoles(ctx context.Context, userID uint, roleI
 	return nil
 }
 
-// GetUsersByRole 根据角色获取用户列表
+// GetUsersByRole 根据角色获取用户列表，包含下级角色，同时匹配旧版role字段和用户角色关联
 func (r *UserRepositoryImpl) GetUsersByRole(ctx context.Context, role string) ([]*database.User, error) {
+	matches, err := r.FindUsersByRole(ctx, role)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]*database.User, 0, len(matches))
+	for _, match := range matches {
+		users = append(users, match.User)
+	}
+	return users, nil
+}
+
+// FindUsersByRole 根据角色查找用户并记录匹配来源
+func (r *UserRepositoryImpl) FindUsersByRole(ctx context.Context, role string) ([]*repository.RoleUserMatch, error) {
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	var users []*database.User
-	err := r.db.WithContext(ctx).
-		Joins("JOIN user_roles ON users.id = user_roles.user_id").
-		Joins("JOIN roles ON user_roles.role_id = roles.id").
-		Where("roles.name = ?", role).
-		Find(&users).Error
+	var roles []*database.Role
+	if err := r.db.WithContext(ctx).Select("id", "name", "parent_id").Find(&roles).Error; err != nil {
+		logger.Errorf("获取角色层级失败: role=%s, error=%v", role, err)
+		return nil, fmt.Errorf("获取角色层级失败: %w", err)
+	}
+	roleNames := expandRoleHierarchy(roles, role)
 
-	if err != nil {
-		logger.Errorf("根据角色查找用户失败: role=%s, error=%v", role, err)
+	var columnUsers []*database.User
+	if err := r.db.WithContext(ctx).
+		Where("role IN ?", roleNames).
+		Find(&columnUsers).Error; err != nil {
+		logger.Errorf("根据角色字段查找用户失败: role=%s, error=%v", role, err)
 		return nil, fmt.Errorf("根据角色查找用户失败: %w", err)
 	}
 
-	return users, nil
+	var associations []roleAssociation
+	if err := r.db.WithContext(ctx).
+		Table("user_roles").
+		Select("user_roles.user_id, roles.name AS role_name").
+		Joins("JOIN roles ON user_roles.role_id = roles.id AND roles.deleted_at IS NULL").
+		Where("roles.name IN ?", roleNames).
+		Scan(&associations).Error; err != nil {
+		logger.Errorf("根据角色关联查找用户失败: role=%s, error=%v", role, err)
+		return nil, fmt.Errorf("根据角色查找用户失败: %w", err)
+	}
+
+	var associatedUsers []*database.User
+	if len(associations) > 0 {
+		userIDs := make([]uint, 0, len(associations))
+		for _, association := range associations {
+			userIDs = append(userIDs, association.UserID)
+		}
+		if err := r.db.WithContext(ctx).Where("id IN ?", userIDs).Find(&associatedUsers).Error; err != nil {
+			logger.Errorf("根据角色关联查找用户失败: role=%s, error=%v", role, err)
+			return nil, fmt.Errorf("根据角色查找用户失败: %w", err)
+		}
+	}
+
+	return mergeRoleMatches(columnUsers, associatedUsers, associations), nil
+}
+
+// roleAssociation 用户角色关联查询结果
+type roleAssociation struct {
+	UserID   uint
+	RoleName string
+}
+
+// expandRoleHierarchy 返回角色及其全部下级角色的名称，角色不存在时仅返回其自身（兼容旧版role字段）
+func expandRoleHierarchy(roles []*database.Role, root string) []string {
+	children := make(map[uint][]*database.Role)
+	var rootRole *database.Role
+	for _, role := range roles {
+		if role.Name == root {
+			rootRole = role
+		}
+		if role.ParentID != nil {
+			children[*role.ParentID] = append(children[*role.ParentID], role)
+		}
+	}
+
+	names := []string{root}
+	if rootRole == nil {
+		return names
+	}
+
+	visited := map[uint]bool{rootRole.ID: true}
+	queue := []*database.Role{rootRole}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, child := range children[current.ID] {
+			if visited[child.ID] {
+				continue
+			}
+			visited[child.ID] = true
+			names = append(names, child.Name)
+			queue = append(queue, child)
+		}
+	}
+	return names
+}
+
+// mergeRoleMatches 合并旧版role字段和角色关联的查询结果并按用户去重
+func mergeRoleMatches(columnUsers, associatedUsers []*database.User, associations []roleAssociation) []*repository.RoleUserMatch {
+	var matches []*repository.RoleUserMatch
+	byUser := make(map[uint]*repository.RoleUserMatch)
+
+	add := func(user *database.User, roleName, source string) {
+		match, ok := byUser[user.ID]
+		if !ok {
+			match = &repository.RoleUserMatch{User: user}
+			byUser[user.ID] = match
+			matches = append(matches, match)
+		}
+		if !containsString(match.MatchedRoles, roleName) {
+			match.MatchedRoles = append(match.MatchedRoles, roleName)
+		}
+		if !containsString(match.Sources, source) {
+			match.Sources = append(match.Sources, source)
+		}
+	}
+
+	for _, user := range columnUsers {
+		add(user, user.Role, repository.RoleSourceColumn)
+	}
+
+	usersByID := make(map[uint]*database.User, len(associatedUsers))
+	for _, user := range associatedUsers {
+		usersByID[user.ID] = user
+	}
+	for _, association := range associations {
+		user, ok := usersByID[association.UserID]
+		if !ok {
+			// 用户已删除
+			continue
+		}
+		add(user, association.RoleName, repository.RoleSourceUserRoles)
+	}
+
+	return matches
+}
+
+// containsString 判断字符串切片是否包含指定值
+func containsString(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
 }
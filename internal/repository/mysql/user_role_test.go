@@ -0,0 +1,65 @@
+package mysql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+func roleWithParent(id uint, name string, parentID uint) *database.Role {
+	role := &database.Role{BaseModel: database.BaseModel{ID: id}, Name: name}
+	if parentID != 0 {
+		role.ParentID = &parentID
+	}
+	return role
+}
+
+func TestExpandRoleHierarchy(t *testing.T) {
+	roles := []*database.Role{
+		roleWithParent(1, "manager", 0),
+		roleWithParent(2, "senior_manager", 1),
+		roleWithParent(3, "director", 2),
+		roleWithParent(4, "employee", 0),
+		// 环路不应导致死循环
+		roleWithParent(5, "loop_a", 6),
+		roleWithParent(6, "loop_b", 5),
+	}
+
+	assert.Equal(t, []string{"manager", "senior_manager", "director"}, expandRoleHierarchy(roles, "manager"))
+	assert.Equal(t, []string{"employee"}, expandRoleHierarchy(roles, "employee"))
+	assert.Equal(t, []string{"legacy_only"}, expandRoleHierarchy(roles, "legacy_only"))
+	assert.ElementsMatch(t, []string{"loop_a", "loop_b"}, expandRoleHierarchy(roles, "loop_a"))
+}
+
+func TestMergeRoleMatches_AssociationOnlyUser(t *testing.T) {
+	columnUser := &database.User{BaseModel: database.BaseModel{ID: 1}, Role: "manager"}
+	bothUser := &database.User{BaseModel: database.BaseModel{ID: 2}, Role: "manager"}
+	// 旧版role字段仍为employee，仅通过关联表拥有manager的下级角色
+	associationOnlyUser := &database.User{BaseModel: database.BaseModel{ID: 3}, Role: "employee"}
+
+	matches := mergeRoleMatches(
+		[]*database.User{columnUser, bothUser},
+		[]*database.User{bothUser, associationOnlyUser},
+		[]roleAssociation{
+			{UserID: 2, RoleName: "manager"},
+			{UserID: 3, RoleName: "senior_manager"},
+			{UserID: 99, RoleName: "manager"},
+		},
+	)
+
+	require.Len(t, matches, 3)
+	assert.Equal(t, uint(1), matches[0].User.ID)
+	assert.Equal(t, []string{repository.RoleSourceColumn}, matches[0].Sources)
+
+	assert.Equal(t, uint(2), matches[1].User.ID)
+	assert.Equal(t, []string{repository.RoleSourceColumn, repository.RoleSourceUserRoles}, matches[1].Sources)
+	assert.Equal(t, []string{"manager"}, matches[1].MatchedRoles)
+
+	assert.Equal(t, uint(3), matches[2].User.ID)
+	assert.Equal(t, []string{repository.RoleSourceUserRoles}, matches[2].Sources)
+	assert.Equal(t, []string{"senior_manager"}, matches[2].MatchedRoles)
+}
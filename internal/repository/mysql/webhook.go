@@ -0,0 +1,166 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+// WebhookEndpointRepositoryImpl Webhook端点仓储MySQL实现
+type WebhookEndpointRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewWebhookEndpointRepository 创建Webhook端点仓储
+func NewWebhookEndpointRepository(db *gorm.DB) repository.WebhookEndpointRepository {
+	return &WebhookEndpointRepositoryImpl{db: db}
+}
+
+// Create 创建端点
+func (r *WebhookEndpointRepositoryImpl) Create(ctx context.Context, endpoint *database.WebhookEndpoint) error {
+	if err := r.db.WithContext(ctx).Create(endpoint).Error; err != nil {
+		return fmt.Errorf("创建Webhook端点失败: %w", err)
+	}
+	return nil
+}
+
+// GetByID 根据ID获取端点，不存在时返回ErrNotFound
+func (r *WebhookEndpointRepositoryImpl) GetByID(ctx context.Context, id uint) (*database.WebhookEndpoint, error) {
+	var endpoint database.WebhookEndpoint
+	if err := r.db.WithContext(ctx).First(&endpoint, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, fmt.Errorf("获取Webhook端点失败: %w", err)
+	}
+	return &endpoint, nil
+}
+
+// List 获取全部端点，按ID排序
+func (r *WebhookEndpointRepositoryImpl) List(ctx context.Context) ([]*database.WebhookEndpoint, error) {
+	var endpoints []*database.WebhookEndpoint
+	if err := r.db.WithContext(ctx).Order("id ASC").Find(&endpoints).Error; err != nil {
+		return nil, fmt.Errorf("获取Webhook端点列表失败: %w", err)
+	}
+	return endpoints, nil
+}
+
+// ListEnabled 获取已启用的端点
+func (r *WebhookEndpointRepositoryImpl) ListEnabled(ctx context.Context) ([]*database.WebhookEndpoint, error) {
+	var endpoints []*database.WebhookEndpoint
+	if err := r.db.WithContext(ctx).Where("enabled = ?", true).Order("id ASC").Find(&endpoints).Error; err != nil {
+		return nil, fmt.Errorf("获取已启用的Webhook端点失败: %w", err)
+	}
+	return endpoints, nil
+}
+
+// Update 更新端点
+func (r *WebhookEndpointRepositoryImpl) Update(ctx context.Context, endpoint *database.WebhookEndpoint) error {
+	if err := r.db.WithContext(ctx).Save(endpoint).Error; err != nil {
+		return fmt.Errorf("更新Webhook端点失败: %w", err)
+	}
+	return nil
+}
+
+// Delete 删除端点，不存在时返回ErrNotFound
+func (r *WebhookEndpointRepositoryImpl) Delete(ctx context.Context, id uint) error {
+	result := r.db.WithContext(ctx).Delete(&database.WebhookEndpoint{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("删除Webhook端点失败: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// WebhookDeliveryRepositoryImpl Webhook投递记录仓储MySQL实现
+type WebhookDeliveryRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewWebhookDeliveryRepository 创建Webhook投递记录仓储
+func NewWebhookDeliveryRepository(db *gorm.DB) repository.WebhookDeliveryRepository {
+	return &WebhookDeliveryRepositoryImpl{db: db}
+}
+
+// CreateBatch 批量写入待投递记录
+func (r *WebhookDeliveryRepositoryImpl) CreateBatch(ctx context.Context, deliveries []*database.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	if err := r.db.WithContext(ctx).Create(&deliveries).Error; err != nil {
+		return fmt.Errorf("写入Webhook投递记录失败: %w", err)
+	}
+	return nil
+}
+
+// Update 更新投递状态，不更新发送尝试
+func (r *WebhookDeliveryRepositoryImpl) Update(ctx context.Context, delivery *database.WebhookDelivery) error {
+	if err := r.db.WithContext(ctx).Omit("AttemptLogs").Save(delivery).Error; err != nil {
+		return fmt.Errorf("更新Webhook投递记录失败: %w", err)
+	}
+	return nil
+}
+
+// ListDue 获取next_retry_at不晚于now的待投递记录
+func (r *WebhookDeliveryRepositoryImpl) ListDue(ctx context.Context, now time.Time, limit int) ([]*database.WebhookDelivery, error) {
+	var deliveries []*database.WebhookDelivery
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND next_retry_at <= ?", "pending", now).
+		Order("next_retry_at ASC").Order("id ASC").
+		Limit(limit).
+		Find(&deliveries).Error
+	if err != nil {
+		return nil, fmt.Errorf("获取待投递的Webhook失败: %w", err)
+	}
+	return deliveries, nil
+}
+
+// CreateAttempt 记录一次发送尝试
+func (r *WebhookDeliveryRepositoryImpl) CreateAttempt(ctx context.Context, attempt *database.WebhookDeliveryAttempt) error {
+	if err := r.db.WithContext(ctx).Create(attempt).Error; err != nil {
+		return fmt.Errorf("记录Webhook发送尝试失败: %w", err)
+	}
+	return nil
+}
+
+// List 按条件分页获取投递记录及总数
+func (r *WebhookDeliveryRepositoryImpl) List(ctx context.Context, filter *repository.WebhookDeliveryFilter) ([]*database.WebhookDelivery, int64, error) {
+	query := r.db.WithContext(ctx).Model(&database.WebhookDelivery{}).Where("endpoint_id = ?", filter.EndpointID)
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.EventType != "" {
+		query = query.Where("event_type = ?", filter.EventType)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("统计Webhook投递记录失败: %w", err)
+	}
+
+	query = query.Order("id DESC")
+	if filter.PageSize > 0 {
+		page := filter.Page
+		if page < 1 {
+			page = 1
+		}
+		query = query.Offset((page - 1) * filter.PageSize).Limit(filter.PageSize)
+	}
+
+	var deliveries []*database.WebhookDelivery
+	err := query.Preload("AttemptLogs", func(db *gorm.DB) *gorm.DB {
+		return db.Order("attempt ASC")
+	}).Find(&deliveries).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("获取Webhook投递记录失败: %w", err)
+	}
+	return deliveries, total, nil
+}
@@ -0,0 +1,181 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+	"taskmanage/internal/workflow"
+)
+
+// appendExecutionHistory 在事务中追加一条执行历史并接到实例哈希链末尾
+// 先锁定实例行，保证同一实例的历史按顺序串行写入，不会出现两条记录指向同一前驱
+func appendExecutionHistory(tx *gorm.DB, history *database.WorkflowExecutionHistory) error {
+	if err := lockInstance(tx, history.InstanceID); err != nil {
+		return err
+	}
+
+	var last database.WorkflowExecutionHistory
+	result := tx.Select("id", "hash").
+		Where("instance_id = ?", history.InstanceID).
+		Order("id DESC").Limit(1).Find(&last)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	prevHash := last.Hash
+	if result.RowsAffected > 0 && prevHash == "" {
+		// 实例存在尚未回填哈希的旧记录，先补齐再追加，避免链条中间出现空洞
+		var err error
+		if prevHash, _, err = sealInstanceHistory(tx, history.InstanceID); err != nil {
+			return err
+		}
+	}
+
+	if err := history.Seal(prevHash); err != nil {
+		return err
+	}
+	return tx.Create(history).Error
+}
+
+// lockInstance 锁定流程实例行直到事务结束
+func lockInstance(tx *gorm.DB, instanceID string) error {
+	var ids []uint
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Model(&database.WorkflowInstance{}).
+		Where("instance_id = ?", instanceID).
+		Pluck("id", &ids).Error
+}
+
+// sealInstanceHistory 按写入顺序为实例中未写入哈希的历史补齐哈希链，返回链尾哈希和补齐的记录数
+// 补齐只写入哈希链字段，需跳过禁止修改历史的钩子
+func sealInstanceHistory(tx *gorm.DB, instanceID string) (string, int, error) {
+	var histories []*database.WorkflowExecutionHistory
+	if err := tx.Where("instance_id = ?", instanceID).Order("id ASC").Find(&histories).Error; err != nil {
+		return "", 0, err
+	}
+
+	prevHash := ""
+	sealed := 0
+	for _, history := range histories {
+		if history.Hash != "" {
+			prevHash = history.Hash
+			continue
+		}
+		if err := history.Seal(prevHash); err != nil {
+			return "", sealed, err
+		}
+		err := tx.Session(&gorm.Session{SkipHooks: true}).
+			Model(&database.WorkflowExecutionHistory{}).
+			Where("id = ?", history.ID).
+			Updates(map[string]interface{}{
+				"executed_at": history.ExecutedAt,
+				"prev_hash":   history.PrevHash,
+				"hash":        history.Hash,
+			}).Error
+		if err != nil {
+			return "", sealed, err
+		}
+		prevHash = history.Hash
+		sealed++
+	}
+	return prevHash, sealed, nil
+}
+
+// CancelInstance 在同一事务中写入取消历史、将实例标记为已取消并关闭未完成的待审批记录
+func (r *WorkflowInstanceRepositoryImpl) CancelInstance(ctx context.Context, instance *database.WorkflowInstance, history *database.WorkflowExecutionHistory) error {
+	return r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&database.WorkflowInstance{}).
+			Where("instance_id = ? AND status = ?", instance.InstanceID, string(workflow.StatusRunning)).
+			Update("status", instance.Status)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return repository.ErrConcurrentUpdate
+		}
+		if err := closeFinishedInstanceApprovals(tx, instance); err != nil {
+			return err
+		}
+		return appendExecutionHistory(tx, history)
+	})
+}
+
+// VerifyExecutionHistory 按写入顺序校验实例执行历史的哈希链
+func (r *WorkflowInstanceRepositoryImpl) VerifyExecutionHistory(ctx context.Context, instanceID string) (*repository.HistoryChainVerification, error) {
+	var histories []*database.WorkflowExecutionHistory
+	if err := r.conn(ctx).Where("instance_id = ?", instanceID).Order("id ASC").Find(&histories).Error; err != nil {
+		return nil, err
+	}
+	return verifyHistoryChain(instanceID, histories)
+}
+
+// verifyHistoryChain 逐条校验前驱哈希和记录哈希，遇到第一处断链即停止
+func verifyHistoryChain(instanceID string, histories []*database.WorkflowExecutionHistory) (*repository.HistoryChainVerification, error) {
+	verification := &repository.HistoryChainVerification{InstanceID: instanceID, Total: len(histories)}
+	prevHash := ""
+	for i, history := range histories {
+		reason := ""
+		switch {
+		case history.Hash == "":
+			reason = "记录未写入哈希，需要执行回填"
+		case history.PrevHash != prevHash:
+			reason = "前驱哈希与上一条记录不一致，记录可能被删除或插入"
+		default:
+			hash, err := history.ComputeHash(prevHash)
+			if err != nil {
+				return nil, fmt.Errorf("计算执行历史哈希失败: %w", err)
+			}
+			if hash != history.Hash {
+				reason = "记录内容与哈希不一致，记录可能被修改"
+			}
+		}
+		if reason != "" {
+			verification.FirstBroken = &repository.HistoryChainBreak{
+				HistoryID: history.HistoryID,
+				Position:  i + 1,
+				Reason:    reason,
+			}
+			return verification, nil
+		}
+		prevHash = history.Hash
+		verification.Checked++
+	}
+	return verification, nil
+}
+
+// BackfillExecutionHistoryChain 为未写入哈希的历史记录补齐哈希链，每个实例单独一个事务
+func (r *WorkflowInstanceRepositoryImpl) BackfillExecutionHistoryChain(ctx context.Context) (*repository.HistoryChainBackfillReport, error) {
+	var instanceIDs []string
+	err := r.conn(ctx).Model(&database.WorkflowExecutionHistory{}).
+		Distinct("instance_id").
+		Where("hash IS NULL OR hash = ?", "").
+		Pluck("instance_id", &instanceIDs).Error
+	if err != nil {
+		return nil, err
+	}
+
+	report := &repository.HistoryChainBackfillReport{}
+	for _, instanceID := range instanceIDs {
+		err := r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := lockInstance(tx, instanceID); err != nil {
+				return err
+			}
+			_, sealed, err := sealInstanceHistory(tx, instanceID)
+			if err != nil {
+				return err
+			}
+			report.Sealed += sealed
+			return nil
+		})
+		if err != nil {
+			return report, fmt.Errorf("回填实例%s的执行历史哈希失败: %w", instanceID, err)
+		}
+		report.Instances++
+	}
+	return report, nil
+}
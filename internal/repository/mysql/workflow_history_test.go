@@ -0,0 +1,71 @@
+package mysql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/database"
+)
+
+// sealedHistoryChain 构造已写入哈希链的执行历史
+func sealedHistoryChain(t *testing.T, n int) []*database.WorkflowExecutionHistory {
+	t.Helper()
+	executedAt := time.Date(2024, 6, 3, 9, 0, 0, 123456789, time.Local)
+	histories := make([]*database.WorkflowExecutionHistory, n)
+	prevHash := ""
+	for i := range histories {
+		history := &database.WorkflowExecutionHistory{
+			HistoryID:  string(rune('a' + i)),
+			InstanceID: "inst",
+			NodeID:     "approve",
+			Action:     "approve",
+			Result:     "approved",
+			Variables:  database.JSONField{Data: struct{ B, A int }{B: 2, A: i}},
+			ExecutedBy: 7,
+			ExecutedAt: executedAt.Add(time.Duration(i) * time.Minute),
+		}
+		require.NoError(t, history.Seal(prevHash))
+		prevHash = history.Hash
+		histories[i] = history
+	}
+	return histories
+}
+
+func TestVerifyHistoryChain(t *testing.T) {
+	histories := sealedHistoryChain(t, 3)
+	assert.Equal(t, "", histories[0].PrevHash)
+	assert.Equal(t, histories[0].Hash, histories[1].PrevHash)
+
+	result, err := verifyHistoryChain("inst", histories)
+	require.NoError(t, err)
+	assert.Nil(t, result.FirstBroken)
+	assert.Equal(t, 3, result.Checked)
+
+	// 从数据库读回的变量是键有序的map，时间是毫秒精度，哈希保持一致
+	reloaded := *histories[1]
+	reloaded.Variables = database.JSONField{Data: map[string]interface{}{"A": float64(1), "B": float64(2)}}
+	reloaded.ExecutedAt = reloaded.ExecutedAt.UTC()
+	result, err = verifyHistoryChain("inst", []*database.WorkflowExecutionHistory{histories[0], &reloaded, histories[2]})
+	require.NoError(t, err)
+	assert.Nil(t, result.FirstBroken)
+
+	// 修改记录内容
+	tampered := *histories[1]
+	tampered.Result = "rejected"
+	result, err = verifyHistoryChain("inst", []*database.WorkflowExecutionHistory{histories[0], &tampered, histories[2]})
+	require.NoError(t, err)
+	require.NotNil(t, result.FirstBroken)
+	assert.Equal(t, "b", result.FirstBroken.HistoryID)
+	assert.Equal(t, 2, result.FirstBroken.Position)
+	assert.Equal(t, 1, result.Checked)
+
+	// 删除中间记录
+	result, err = verifyHistoryChain("inst", []*database.WorkflowExecutionHistory{histories[0], histories[2]})
+	require.NoError(t, err)
+	require.NotNil(t, result.FirstBroken)
+	assert.Equal(t, "c", result.FirstBroken.HistoryID)
+	assert.Contains(t, result.FirstBroken.Reason, "前驱哈希")
+}
@@ -3,8 +3,12 @@ package mysql
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"taskmanage/internal/database"
 	"taskmanage/internal/repository"
@@ -24,25 +28,81 @@ func NewWorkflowRepository(db *gorm.DB) repository.WorkflowRepository {
 // GetWorkflowDefinition 获取流程定义
 func (r *WorkflowRepositoryImpl) GetWorkflowDefinition(ctx context.Context, workflowID string) (*database.WorkflowDefinition, error) {
 	var definition database.WorkflowDefinition
-	err := r.db.WithContext(ctx).Where("workflow_id = ? AND is_active = ?", workflowID, true).First(&definition).Error
+	err := r.db.WithContext(ctx).Where("workflow_id = ? AND is_active = ?", workflowID, true).
+		Order("version_number DESC").First(&definition).Error
 	if err != nil {
 		return nil, err
 	}
 	return &definition, nil
 }
 
+// GetWorkflowDefinitionVersion 根据版本ID获取流程定义
+func (r *WorkflowRepositoryImpl) GetWorkflowDefinitionVersion(ctx context.Context, versionID uint) (*database.WorkflowDefinition, error) {
+	var definition database.WorkflowDefinition
+	err := r.db.WithContext(ctx).First(&definition, versionID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &definition, nil
+}
+
+// ListWorkflowDefinitionVersions 列出流程定义的所有版本，按版本号倒序
+func (r *WorkflowRepositoryImpl) ListWorkflowDefinitionVersions(ctx context.Context, workflowID string) ([]*database.WorkflowDefinition, error) {
+	var definitions []*database.WorkflowDefinition
+	err := r.db.WithContext(ctx).Where("workflow_id = ?", workflowID).
+		Order("version_number DESC").Find(&definitions).Error
+	return definitions, err
+}
+
 // SaveWorkflowDefinition 保存流程定义
+// 激活的版本保存后会停用同一流程的其他版本，保证新实例只使用一个版本
 func (r *WorkflowRepositoryImpl) SaveWorkflowDefinition(ctx context.Context, definition *database.WorkflowDefinition) error {
-	return r.db.WithContext(ctx).Save(definition).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(definition).Error; err != nil {
+			return err
+		}
+		if !definition.IsActive {
+			return nil
+		}
+		return tx.Model(&database.WorkflowDefinition{}).
+			Where("workflow_id = ? AND id <> ?", definition.WorkflowID, definition.ID).
+			Update("is_active", false).Error
+	})
+}
+
+// SetWorkflowVersionActive 设置流程定义版本的激活状态
+func (r *WorkflowRepositoryImpl) SetWorkflowVersionActive(ctx context.Context, workflowID string, versionID uint, isActive bool) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&database.WorkflowDefinition{}).
+			Where("id = ? AND workflow_id = ?", versionID, workflowID).
+			Update("is_active", isActive)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if !isActive {
+			return nil
+		}
+		return tx.Model(&database.WorkflowDefinition{}).
+			Where("workflow_id = ? AND id <> ?", workflowID, versionID).
+			Update("is_active", false).Error
+	})
 }
 
 // ListWorkflowDefinitions 列出流程定义
+// 未指定激活状态时每个流程只返回最新版本
 func (r *WorkflowRepositoryImpl) ListWorkflowDefinitions(ctx context.Context, isActive *bool, limit, offset int) ([]*database.WorkflowDefinition, error) {
 	var definitions []*database.WorkflowDefinition
 	query := r.db.WithContext(ctx)
 	
 	if isActive != nil {
 		query = query.Where("is_active = ?", *isActive)
+	} else {
+		latest := r.db.WithContext(ctx).Model(&database.WorkflowDefinition{}).
+			Select("MAX(id)").Group("workflow_id")
+		query = query.Where("id IN (?)", latest)
 	}
 	
 	if limit > 0 {
@@ -73,16 +133,41 @@ func NewWorkflowInstanceRepository(db *gorm.DB) repository.WorkflowInstanceRepos
 	return &WorkflowInstanceRepositoryImpl{db: db}
 }
 
+// instanceTxKey 上下文中保存实例锁事务的键
+type instanceTxKey struct{}
+
+// LockInstance 在事务中以SELECT ... FOR UPDATE锁定实例行后执行fn，fn返回错误时回滚
+// 事务通过ctx传给fn，fn内的仓库调用复用该事务，嵌套事务以保存点执行
+func (r *WorkflowInstanceRepositoryImpl) LockInstance(ctx context.Context, instanceID string, fn func(ctx context.Context) error) error {
+	return r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockInstance(tx, instanceID); err != nil {
+			return fmt.Errorf("锁定流程实例失败: %w", err)
+		}
+		return fn(context.WithValue(ctx, instanceTxKey{}, tx))
+	})
+}
+
+// conn 返回ctx中的实例锁事务，没有时使用默认连接
+func (r *WorkflowInstanceRepositoryImpl) conn(ctx context.Context) *gorm.DB {
+	if tx, ok := ctx.Value(instanceTxKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return r.db.WithContext(ctx)
+}
+
 // SaveInstance 保存流程实例
 func (r *WorkflowInstanceRepositoryImpl) SaveInstance(ctx context.Context, instance *database.WorkflowInstance) error {
-	return r.db.WithContext(ctx).Save(instance).Error
+	return r.conn(ctx).Save(instance).Error
 }
 
 // GetInstance 获取流程实例
 func (r *WorkflowInstanceRepositoryImpl) GetInstance(ctx context.Context, instanceID string) (*database.WorkflowInstance, error) {
 	var instance database.WorkflowInstance
-	err := r.db.WithContext(ctx).Where("instance_id = ?", instanceID).First(&instance).Error
+	err := r.conn(ctx).Where("instance_id = ?", instanceID).First(&instance).Error
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrNotFound
+		}
 		return nil, err
 	}
 	return &instance, nil
@@ -90,7 +175,7 @@ func (r *WorkflowInstanceRepositoryImpl) GetInstance(ctx context.Context, instan
 
 // UpdateInstanceStatus 更新实例状态
 func (r *WorkflowInstanceRepositoryImpl) UpdateInstanceStatus(ctx context.Context, instanceID string, status string) error {
-	return r.db.WithContext(ctx).Model(&database.WorkflowInstance{}).
+	return r.conn(ctx).Model(&database.WorkflowInstance{}).
 		Where("instance_id = ?", instanceID).
 		Update("status", status).Error
 }
@@ -101,17 +186,30 @@ func (r *WorkflowInstanceRepositoryImpl) UpdateInstanceNodes(ctx context.Context
 	if err != nil {
 		return err
 	}
-	return r.db.WithContext(ctx).Model(&database.WorkflowInstance{}).
+	return r.conn(ctx).Model(&database.WorkflowInstance{}).
 		Where("instance_id = ?", instanceID).
 		Update("current_nodes", nodesJSON).Error
 }
 
+// UpdateInstanceCompletion 更新实例的业务回调执行记录
+func (r *WorkflowInstanceRepositoryImpl) UpdateInstanceCompletion(ctx context.Context, instanceID string, completion database.JSONField) error {
+	return r.conn(ctx).Model(&database.WorkflowInstance{}).
+		Where("instance_id = ?", instanceID).
+		Update("completion", completion).Error
+}
+
 // UpdateInstance 更新流程实例
 func (r *WorkflowInstanceRepositoryImpl) UpdateInstance(ctx context.Context, instance *database.WorkflowInstance) error {
+	return updateInstanceState(r.conn(ctx), instance)
+}
+
+// updateInstanceState 更新实例的当前节点、变量和状态
+func updateInstanceState(db *gorm.DB, instance *database.WorkflowInstance) error {
 	updates := map[string]interface{}{
-		"current_nodes": instance.CurrentNodes,
-		"variables":     instance.Variables,
-		"status":        instance.Status,
+		"current_nodes":   instance.CurrentNodes,
+		"variables":       instance.Variables,
+		"status":          instance.Status,
+		"approval_states": instance.ApprovalStates,
 	}
 	
 	// 只有当 UpdatedAt 不为零值时才更新
@@ -119,63 +217,242 @@ func (r *WorkflowInstanceRepositoryImpl) UpdateInstance(ctx context.Context, ins
 		updates["updated_at"] = instance.UpdatedAt
 	}
 	
-	return r.db.WithContext(ctx).Model(&database.WorkflowInstance{}).
+	if err := db.Model(&database.WorkflowInstance{}).
 		Where("instance_id = ?", instance.InstanceID).
-		Updates(updates).Error
+		Updates(updates).Error; err != nil {
+		return err
+	}
+	return closeFinishedInstanceApprovals(db, instance)
 }
 
-// AddExecutionHistory 添加执行历史
-func (r *WorkflowInstanceRepositoryImpl) AddExecutionHistory(ctx context.Context, history *database.WorkflowExecutionHistory) error {
-	return r.db.WithContext(ctx).Create(history).Error
+// closeFinishedInstanceApprovals 实例结束后关闭其全部未完成的待审批记录，
+// 包括并行分支上的审批和发起人、被分配人的查看记录，运行中和暂停的实例不处理
+func closeFinishedInstanceApprovals(db *gorm.DB, instance *database.WorkflowInstance) error {
+	switch workflow.InstanceStatus(instance.Status) {
+	case "", workflow.StatusRunning, workflow.StatusSuspended:
+		return nil
+	}
+	return db.Model(&database.WorkflowPendingApproval{}).
+		Where("instance_id = ? AND is_completed = ?", instance.InstanceID, false).
+		Update("is_completed", true).Error
 }
 
 // GetExecutionHistory 获取执行历史
 func (r *WorkflowInstanceRepositoryImpl) GetExecutionHistory(ctx context.Context, instanceID string) ([]*database.WorkflowExecutionHistory, error) {
 	var histories []*database.WorkflowExecutionHistory
-	err := r.db.WithContext(ctx).Where("instance_id = ?", instanceID).
+	err := r.conn(ctx).Where("instance_id = ?", instanceID).
 		Order("executed_at ASC").Find(&histories).Error
 	return histories, err
 }
 
-// GetPendingApprovals 获取待审批任务
-func (r *WorkflowInstanceRepositoryImpl) GetPendingApprovals(ctx context.Context, userID uint) ([]*database.WorkflowPendingApproval, error) {
+// GetPendingApprovals 按条件分页获取未完成的待审批任务及总数
+func (r *WorkflowInstanceRepositoryImpl) GetPendingApprovals(ctx context.Context, filter *repository.PendingApprovalFilter) ([]*database.WorkflowPendingApproval, int64, error) {
+	query := r.pendingApprovalQuery(ctx, filter)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	direction := "ASC"
+	if filter.SortDesc {
+		direction = "DESC"
+	}
+	switch filter.SortBy {
+	case "created_at":
+		query = query.Order("created_at " + direction).Order("id " + direction)
+	case "priority":
+		query = query.Order("priority " + direction).Order("created_at ASC")
+	case "urgency":
+		query = query.Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:                "CASE WHEN deadline < ? THEN 0 ELSE 1 END, priority DESC, deadline IS NULL, deadline ASC, created_at ASC, id ASC",
+			Vars:               []interface{}{filter.OverdueAt},
+			WithoutParentheses: true,
+		}})
+	default:
+		query = query.Order("priority DESC, created_at ASC")
+	}
+	if filter.PageSize > 0 {
+		page := filter.Page
+		if page < 1 {
+			page = 1
+		}
+		query = query.Offset((page - 1) * filter.PageSize).Limit(filter.PageSize)
+	}
+
 	var approvals []*database.WorkflowPendingApproval
-	err := r.db.WithContext(ctx).Where("assigned_to = ? AND is_completed = ?", userID, false).
-		Order("priority DESC, created_at ASC").Find(&approvals).Error
-	return approvals, err
+	if err := query.Find(&approvals).Error; err != nil {
+		return nil, 0, err
+	}
+	return approvals, total, nil
+}
+
+// GetPendingApprovalsWithEmployee 获取待审批任务后分别批量加载流程实例、发起人和员工，查询次数不随记录数增长
+func (r *WorkflowInstanceRepositoryImpl) GetPendingApprovalsWithEmployee(ctx context.Context, filter *repository.PendingApprovalFilter) ([]*repository.PendingApprovalWithEmployee, error) {
+	approvals, _, err := r.GetPendingApprovals(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]*repository.PendingApprovalWithEmployee, 0, len(approvals))
+	if len(approvals) == 0 {
+		return items, nil
+	}
+
+	instanceIDs := make([]string, 0, len(approvals))
+	employeeIDs := make([]uint, 0, len(approvals))
+	for _, approval := range approvals {
+		instanceIDs = append(instanceIDs, approval.InstanceID)
+		if employeeID, ok := employeeIDFromBusinessID(approval.BusinessID); ok {
+			employeeIDs = append(employeeIDs, employeeID)
+		}
+	}
+
+	var instances []*database.WorkflowInstance
+	if err := r.conn(ctx).Where("instance_id IN ?", instanceIDs).Find(&instances).Error; err != nil {
+		return nil, fmt.Errorf("获取流程实例失败: %w", err)
+	}
+	instancesByID := make(map[string]*database.WorkflowInstance, len(instances))
+	requesterIDs := make([]uint, 0, len(instances))
+	for _, instance := range instances {
+		instancesByID[instance.InstanceID] = instance
+		requesterIDs = append(requesterIDs, instance.StartedBy)
+	}
+
+	var requesters []*database.User
+	if len(requesterIDs) > 0 {
+		if err := r.conn(ctx).Where("id IN ?", requesterIDs).Find(&requesters).Error; err != nil {
+			return nil, fmt.Errorf("获取流程发起人失败: %w", err)
+		}
+	}
+	requestersByID := make(map[uint]*database.User, len(requesters))
+	for _, user := range requesters {
+		requestersByID[user.ID] = user
+	}
+
+	var employees []*database.Employee
+	if len(employeeIDs) > 0 {
+		unscoped := func(db *gorm.DB) *gorm.DB { return db.Unscoped() }
+		err := r.conn(ctx).
+			Preload("User").
+			Preload("Department", unscoped).
+			Preload("Position", unscoped).
+			Where("id IN ?", employeeIDs).
+			Find(&employees).Error
+		if err != nil {
+			return nil, fmt.Errorf("获取员工失败: %w", err)
+		}
+	}
+	employeesByID := make(map[uint]*database.Employee, len(employees))
+	for _, employee := range employees {
+		employeesByID[employee.ID] = employee
+	}
+
+	for _, approval := range approvals {
+		item := &repository.PendingApprovalWithEmployee{Approval: approval, Instance: instancesByID[approval.InstanceID]}
+		if item.Instance != nil {
+			item.Requester = requestersByID[item.Instance.StartedBy]
+		}
+		if employeeID, ok := employeeIDFromBusinessID(approval.BusinessID); ok {
+			item.Employee = employeesByID[employeeID]
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// employeeIDFromBusinessID 从 employee_<id> 或 employee-<id> 格式的业务ID中解析员工ID
+func employeeIDFromBusinessID(businessID string) (uint, bool) {
+	var employeeID uint
+	if _, err := fmt.Sscanf(businessID, "employee_%d", &employeeID); err == nil {
+		return employeeID, true
+	}
+	if _, err := fmt.Sscanf(businessID, "employee-%d", &employeeID); err == nil {
+		return employeeID, true
+	}
+	return 0, false
+}
+
+// CountPendingApprovals 按条件统计未完成的待审批任务数
+func (r *WorkflowInstanceRepositoryImpl) CountPendingApprovals(ctx context.Context, filter *repository.PendingApprovalFilter) (int64, error) {
+	var total int64
+	err := r.pendingApprovalQuery(ctx, filter).Count(&total).Error
+	return total, err
+}
+
+// CountPendingApprovalGroups 按流程名称和业务类型分组统计待审批数，截止时间早于OverdueAt的计为逾期
+func (r *WorkflowInstanceRepositoryImpl) CountPendingApprovalGroups(ctx context.Context, filter *repository.PendingApprovalFilter) ([]*repository.PendingApprovalGroupCount, error) {
+	var counts []*repository.PendingApprovalGroupCount
+	err := r.pendingApprovalQuery(ctx, filter).
+		Select("workflow_name, business_type, COUNT(*) AS count, "+
+			"SUM(CASE WHEN deadline < ? THEN 1 ELSE 0 END) AS overdue_count", filter.OverdueAt).
+		Group("workflow_name, business_type").
+		Scan(&counts).Error
+	return counts, err
+}
+
+// pendingApprovalQuery 构建待审批查询的过滤条件
+func (r *WorkflowInstanceRepositoryImpl) pendingApprovalQuery(ctx context.Context, filter *repository.PendingApprovalFilter) *gorm.DB {
+	query := r.conn(ctx).Model(&database.WorkflowPendingApproval{}).
+		Where("assigned_to = ? AND is_completed = ?", filter.UserID, false)
+	if filter.WorkflowName != "" {
+		query = query.Where("workflow_name = ?", filter.WorkflowName)
+	}
+	if filter.BusinessType != "" {
+		query = query.Where("business_type = ?", filter.BusinessType)
+	}
+	if filter.DeadlineBefore != nil {
+		query = query.Where("deadline IS NOT NULL AND deadline < ?", *filter.DeadlineBefore)
+	}
+	return query
 }
 
 // CreatePendingApproval 创建待审批任务
 func (r *WorkflowInstanceRepositoryImpl) CreatePendingApproval(ctx context.Context, approval *database.WorkflowPendingApproval) error {
-	return r.db.WithContext(ctx).Create(approval).Error
+	return r.conn(ctx).Create(approval).Error
 }
 
 // CompletePendingApproval 完成待审批任务
 func (r *WorkflowInstanceRepositoryImpl) CompletePendingApproval(ctx context.Context, instanceID, nodeID string, userID uint) error {
-	return r.db.WithContext(ctx).Model(&database.WorkflowPendingApproval{}).
+	return r.conn(ctx).Model(&database.WorkflowPendingApproval{}).
 		Where("instance_id = ? AND node_id = ? AND assigned_to = ?", instanceID, nodeID, userID).
 		Update("is_completed", true).Error
 }
 
 // SavePendingApproval 保存待审批记录
 func (r *WorkflowInstanceRepositoryImpl) SavePendingApproval(ctx context.Context, approval *database.WorkflowPendingApproval) error {
-	return r.db.WithContext(ctx).Save(approval).Error
+	return r.conn(ctx).Save(approval).Error
 }
 
 // DeletePendingApproval 删除待审批记录
 func (r *WorkflowInstanceRepositoryImpl) DeletePendingApproval(ctx context.Context, instanceID, nodeID string, userID uint) error {
-	return r.db.WithContext(ctx).Where("instance_id = ? AND node_id = ? AND assigned_to = ?", instanceID, nodeID, userID).
+	return r.conn(ctx).Where("instance_id = ? AND node_id = ? AND assigned_to = ?", instanceID, nodeID, userID).
 		Delete(&database.WorkflowPendingApproval{}).Error
 }
 
 // GetInstancesByBusinessID 根据业务ID获取实例
 func (r *WorkflowInstanceRepositoryImpl) GetInstancesByBusinessID(ctx context.Context, businessID, businessType string) ([]*database.WorkflowInstance, error) {
 	var instances []*database.WorkflowInstance
-	err := r.db.WithContext(ctx).Where("business_id = ? AND business_type = ?", businessID, businessType).
+	err := r.conn(ctx).Where("business_id = ? AND business_type = ?", businessID, businessType).
 		Order("created_at DESC").Find(&instances).Error
 	return instances, err
 }
 
+// FindRunningByBusiness 获取业务对象运行中的流程实例，存量数据存在多个时返回最早启动的
+func (r *WorkflowInstanceRepositoryImpl) FindRunningByBusiness(ctx context.Context, businessType, businessID string) (*database.WorkflowInstance, error) {
+	var instance database.WorkflowInstance
+	err := r.conn(ctx).
+		Where("business_type = ? AND business_id = ? AND status = ?", businessType, businessID, string(workflow.StatusRunning)).
+		Order("id ASC").
+		First(&instance).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, err
+	}
+	return &instance, nil
+}
+
 // ConvertToWorkflowInstance 转换数据库模型到workflow模型
 func ConvertToWorkflowInstance(dbInstance *database.WorkflowInstance) (*workflow.WorkflowInstance, error) {
 	var currentNodes []string
@@ -200,9 +477,28 @@ func ConvertToWorkflowInstance(dbInstance *database.WorkflowInstance) (*workflow
 	// 获取执行历史需要额外查询，这里先返回空
 	var history []workflow.ExecutionHistory
 
+	var approvals map[string]*workflow.NodeApprovalState
+	if dbInstance.ApprovalStates.Data != nil {
+		if data, err := json.Marshal(dbInstance.ApprovalStates.Data); err == nil {
+			if err := json.Unmarshal(data, &approvals); err != nil {
+				return nil, fmt.Errorf("解析审批决策记录失败: %w", err)
+			}
+		}
+	}
+
+	var completion *workflow.CompletionRecord
+	if dbInstance.Completion.Data != nil {
+		if data, err := json.Marshal(dbInstance.Completion.Data); err == nil {
+			if err := json.Unmarshal(data, &completion); err != nil {
+				return nil, fmt.Errorf("解析业务回调记录失败: %w", err)
+			}
+		}
+	}
+
 	return &workflow.WorkflowInstance{
 		ID:           dbInstance.InstanceID,
 		WorkflowID:   dbInstance.WorkflowID,
+		DefinitionVersionID: dbInstance.DefinitionVersionID,
 		BusinessID:   dbInstance.BusinessID,
 		BusinessType: dbInstance.BusinessType,
 		Status:       workflow.InstanceStatus(dbInstance.Status),
@@ -212,6 +508,9 @@ func ConvertToWorkflowInstance(dbInstance *database.WorkflowInstance) (*workflow
 		StartedAt:    dbInstance.StartedAt,
 		CompletedAt:  dbInstance.CompletedAt,
 		History:      history,
+		Approvals:    approvals,
+		Completion:   completion,
+		Deadline:     dbInstance.Deadline,
 	}, nil
 }
 
@@ -224,13 +523,223 @@ func ConvertFromWorkflowInstance(wfInstance *workflow.WorkflowInstance) (*databa
 	return &database.WorkflowInstance{
 		InstanceID:   wfInstance.ID,
 		WorkflowID:   wfInstance.WorkflowID,
+		DefinitionVersionID: wfInstance.DefinitionVersionID,
 		BusinessID:   wfInstance.BusinessID,
 		BusinessType: wfInstance.BusinessType,
 		Status:       string(wfInstance.Status),
 		CurrentNodes: currentNodesJSON,
 		Variables:    variablesJSON,
+		ApprovalStates: database.JSONField{Data: wfInstance.Approvals},
 		StartedBy:    wfInstance.StartedBy,
 		StartedAt:    wfInstance.StartedAt,
 		CompletedAt:  wfInstance.CompletedAt,
+		Deadline:     wfInstance.Deadline,
 	}, nil
 }
+
+// CountPendingApprovalsByWorkflow 按流程名统计未完成的待审批数量
+func (r *WorkflowInstanceRepositoryImpl) CountPendingApprovalsByWorkflow(ctx context.Context) (map[string]int64, error) {
+	var rows []struct {
+		WorkflowName string
+		Count        int64
+	}
+	err := r.conn(ctx).Model(&database.WorkflowPendingApproval{}).
+		Select("workflow_name, COUNT(*) AS count").
+		Where("is_completed = ?", false).
+		Group("workflow_name").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.WorkflowName] = row.Count
+	}
+	return counts, nil
+}
+
+// SaveNodeExecutionResult 在同一事务中写入节点的待审批记录、执行历史和实例状态
+func (r *WorkflowInstanceRepositoryImpl) SaveNodeExecutionResult(ctx context.Context, instance *database.WorkflowInstance, history *database.WorkflowExecutionHistory, approvals []*database.WorkflowPendingApproval) error {
+	return r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(approvals) > 0 {
+			if err := tx.Where("instance_id = ? AND node_id = ? AND is_completed = ?", instance.InstanceID, history.NodeID, false).
+				Delete(&database.WorkflowPendingApproval{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Create(approvals).Error; err != nil {
+				return err
+			}
+		}
+		if err := appendExecutionHistory(tx, history); err != nil {
+			return err
+		}
+		return updateInstanceState(tx, instance)
+	})
+}
+
+// SaveApprovalResult 在同一事务中写入审批历史、待审批记录变更和实例状态
+func (r *WorkflowInstanceRepositoryImpl) SaveApprovalResult(ctx context.Context, instance *database.WorkflowInstance, history *database.WorkflowExecutionHistory, completeInstance, completeAll bool, completeFor []uint, approvals []*database.WorkflowPendingApproval) error {
+	return r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		if completeInstance || completeAll || len(completeFor) > 0 {
+			query := tx.Model(&database.WorkflowPendingApproval{}).
+				Where("instance_id = ? AND is_completed = ?", instance.InstanceID, false)
+			if !completeInstance {
+				query = query.Where("node_id = ?", history.NodeID)
+			}
+			if !completeInstance && !completeAll {
+				query = query.Where("assigned_to IN ?", completeFor)
+			}
+			if err := query.Update("is_completed", true).Error; err != nil {
+				return err
+			}
+		}
+		if len(approvals) > 0 {
+			if err := tx.Create(approvals).Error; err != nil {
+				return err
+			}
+		}
+		if err := appendExecutionHistory(tx, history); err != nil {
+			return err
+		}
+		return updateInstanceState(tx, instance)
+	})
+}
+
+// ListInstancesByStatus 按状态列出流程实例
+func (r *WorkflowInstanceRepositoryImpl) ListInstancesByStatus(ctx context.Context, status string) ([]*database.WorkflowInstance, error) {
+	var instances []*database.WorkflowInstance
+	err := r.conn(ctx).Where("status = ?", status).
+		Order("created_at ASC").Find(&instances).Error
+	return instances, err
+}
+
+// CountOpenPendingApprovals 统计节点未完成的待审批数量
+func (r *WorkflowInstanceRepositoryImpl) CountOpenPendingApprovals(ctx context.Context, instanceID, nodeID string) (int64, error) {
+	var count int64
+	err := r.conn(ctx).Model(&database.WorkflowPendingApproval{}).
+		Where("instance_id = ? AND node_id = ? AND is_completed = ?", instanceID, nodeID, false).
+		Count(&count).Error
+	return count, err
+}
+
+// ListInstances 按条件分页获取流程实例摘要及总数，按启动时间倒序
+// 只查询列表展示需要的列，流程名称取自启动时绑定的流程定义版本
+func (r *WorkflowInstanceRepositoryImpl) ListInstances(ctx context.Context, filter *repository.WorkflowInstanceFilter) ([]*repository.WorkflowInstanceSummary, int64, error) {
+	query := r.instanceQuery(ctx, filter)
+	archivedAtColumn := "NULL AS archived_at"
+	if filter.IncludeArchived {
+		archivedAtColumn = "workflow_instances.archived_at"
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	query = query.Select(`workflow_instances.instance_id, workflow_instances.workflow_id,
+		COALESCE(workflow_definitions.name, '') AS workflow_name, workflow_instances.definition_version_id,
+		workflow_instances.business_id, workflow_instances.business_type, workflow_instances.status,
+		workflow_instances.current_nodes, workflow_instances.started_by, workflow_instances.started_at,
+		workflow_instances.completed_at, workflow_instances.deadline, ` + archivedAtColumn).
+		Order("workflow_instances.started_at DESC").Order("workflow_instances.id DESC")
+	if filter.PageSize > 0 {
+		page := filter.Page
+		if page < 1 {
+			page = 1
+		}
+		query = query.Offset((page - 1) * filter.PageSize).Limit(filter.PageSize)
+	}
+
+	var instances []*repository.WorkflowInstanceSummary
+	if err := query.Scan(&instances).Error; err != nil {
+		return nil, 0, err
+	}
+	return instances, total, nil
+}
+
+// CountInstances 按条件统计流程实例数，按流程和状态分组
+func (r *WorkflowInstanceRepositoryImpl) CountInstances(ctx context.Context, filter *repository.WorkflowInstanceFilter) ([]*repository.WorkflowInstanceCount, error) {
+	var counts []*repository.WorkflowInstanceCount
+	err := r.instanceQuery(ctx, filter).
+		Select(`workflow_instances.workflow_id, COALESCE(MAX(workflow_definitions.name), '') AS workflow_name,
+			workflow_instances.status, COUNT(*) AS count`).
+		Group("workflow_instances.workflow_id").Group("workflow_instances.status").
+		Scan(&counts).Error
+	return counts, err
+}
+
+// instanceQuery 构建流程实例查询的过滤条件，关联流程定义版本以按名称过滤，IncludeArchived时同时查询归档实例
+func (r *WorkflowInstanceRepositoryImpl) instanceQuery(ctx context.Context, filter *repository.WorkflowInstanceFilter) *gorm.DB {
+	query := r.conn(ctx).Model(&database.WorkflowInstance{})
+	if filter.IncludeArchived {
+		query = withArchive(query, &database.WorkflowInstance{}, database.WorkflowInstanceArchive{}.TableName())
+	}
+	query = query.Joins("LEFT JOIN workflow_definitions ON workflow_definitions.id = workflow_instances.definition_version_id")
+	if filter.Status != "" {
+		query = query.Where("workflow_instances.status = ?", filter.Status)
+	}
+	if filter.WorkflowID != "" {
+		query = query.Where("workflow_instances.workflow_id = ?", filter.WorkflowID)
+	}
+	if filter.WorkflowName != "" {
+		query = query.Where("workflow_definitions.name LIKE ?", "%"+filter.WorkflowName+"%")
+	}
+	if filter.BusinessType != "" {
+		query = query.Where("workflow_instances.business_type = ?", filter.BusinessType)
+	}
+	if filter.BusinessID != "" {
+		query = query.Where("workflow_instances.business_id = ?", filter.BusinessID)
+	}
+	if filter.StartedBy != 0 {
+		query = query.Where("workflow_instances.started_by = ?", filter.StartedBy)
+	}
+	if filter.StartedFrom != nil {
+		query = query.Where("workflow_instances.started_at >= ?", *filter.StartedFrom)
+	}
+	if filter.StartedTo != nil {
+		query = query.Where("workflow_instances.started_at < ?", *filter.StartedTo)
+	}
+	return query
+}
+
+// ListOverdueInstances 列出超过流程截止时间或存在超时待审批的运行中实例
+func (r *WorkflowInstanceRepositoryImpl) ListOverdueInstances(ctx context.Context, now time.Time) ([]*database.WorkflowInstance, error) {
+	overdueApprovals := r.db.Model(&database.WorkflowPendingApproval{}).
+		Select("instance_id").
+		Where("is_completed = ? AND deadline IS NOT NULL AND deadline <= ?", false, now)
+
+	var instances []*database.WorkflowInstance
+	err := r.conn(ctx).
+		Where("status = ?", string(workflow.StatusRunning)).
+		Where(r.db.Where("deadline IS NOT NULL AND deadline <= ?", now).Or("instance_id IN (?)", overdueApprovals)).
+		Order("started_at ASC").
+		Find(&instances).Error
+	return instances, err
+}
+
+// ExpireInstance 在同一事务中关闭实例全部未完成的待审批记录、写入执行历史并将实例标记为过期
+func (r *WorkflowInstanceRepositoryImpl) ExpireInstance(ctx context.Context, instance *database.WorkflowInstance, history *database.WorkflowExecutionHistory) error {
+	return r.conn(ctx).Transaction(func(tx *gorm.DB) error {
+		// 只更新仍在运行中的实例，避免覆盖同时完成的审批结果
+		result := tx.Model(&database.WorkflowInstance{}).
+			Where("instance_id = ? AND status = ?", instance.InstanceID, string(workflow.StatusRunning)).
+			Updates(map[string]interface{}{
+				"status":       instance.Status,
+				"completed_at": instance.CompletedAt,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return repository.ErrConcurrentUpdate
+		}
+
+		if err := tx.Model(&database.WorkflowPendingApproval{}).
+			Where("instance_id = ? AND is_completed = ?", instance.InstanceID, false).
+			Update("is_completed", true).Error; err != nil {
+			return err
+		}
+		return appendExecutionHistory(tx, history)
+	})
+}
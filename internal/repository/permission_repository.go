@@ -15,6 +15,9 @@ type PermissionTemplateRepository interface {
 	Delete(ctx context.Context, id uint) error
 	GetByCategory(ctx context.Context, category string) ([]*database.PermissionTemplate, error)
 	GetByDepartmentAndPosition(ctx context.Context, departmentID, positionID *uint) ([]*database.PermissionTemplate, error)
+	MoveToDepartment(ctx context.Context, fromDepartmentID, toDepartmentID uint) (int64, error)                             // 将部门专属模板迁移到目标部门，返回迁移个数
+	MoveToPosition(ctx context.Context, fromPositionID, toPositionID uint) (int64, error)                                   // 将职位专属模板迁移到目标职位，返回迁移个数
+	ReplacePermissions(ctx context.Context, template *database.PermissionTemplate, permissions []database.Permission) error // 替换模板包含的权限
 }
 
 // PermissionRuleRepository 权限规则仓储接口
@@ -29,6 +32,15 @@ type PermissionRuleRepository interface {
 	GetActiveRules(ctx context.Context) ([]*database.PermissionRule, error)
 }
 
+// PermissionRuleExecutionRepository 权限规则执行记录仓储接口
+type PermissionRuleExecutionRepository interface {
+	// Record 记录规则对用户的执行，已有该规则和用户的记录时不做修改并返回false
+	Record(ctx context.Context, execution *database.PermissionRuleExecution) (bool, error)
+
+	// ListExecutedUserIDs 获取规则已执行过的用户ID
+	ListExecutedUserIDs(ctx context.Context, ruleID uint) (map[uint]bool, error)
+}
+
 // PermissionAssignmentRepository 权限分配仓储接口
 type PermissionAssignmentRepository interface {
 	Create(ctx context.Context, assignment *database.PermissionAssignment) error
@@ -42,6 +54,7 @@ type PermissionAssignmentRepository interface {
 	GetPendingApprovals(ctx context.Context) ([]*database.PermissionAssignment, error)
 	GetExpiredAssignments(ctx context.Context) ([]*database.PermissionAssignment, error)
 	BulkUpdateStatus(ctx context.Context, ids []uint, status string) error
+	CountActiveByTemplateID(ctx context.Context, templateID uint) (int64, error) // 统计通过该模板分配且生效中或待审批的记录数
 }
 
 // PermissionAssignmentHistoryRepository 权限分配历史仓储接口
@@ -63,6 +76,9 @@ type OnboardingPermissionConfigRepository interface {
 	GetByStatus(ctx context.Context, status string) ([]*database.OnboardingPermissionConfig, error)
 	GetByStatusAndDepartment(ctx context.Context, status string, departmentID *uint, positionID *uint) (*database.OnboardingPermissionConfig, error)
 	GetGlobalConfig(ctx context.Context, status string) (*database.OnboardingPermissionConfig, error)
+	MoveToDepartment(ctx context.Context, fromDepartmentID, toDepartmentID uint) (int64, error) // 将部门入职权限配置迁移到目标部门，返回迁移个数
+	MoveToPosition(ctx context.Context, fromPositionID, toPositionID uint) (int64, error)       // 将职位入职权限配置迁移到目标职位，返回迁移个数
+	CountByTemplateID(ctx context.Context, templateID uint) (int64, error)                      // 统计以该模板为默认或下一级模板的配置数
 }
 
 // 过滤器结构体
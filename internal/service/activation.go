@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"taskmanage/internal/config"
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+	"taskmanage/pkg/email"
+	"taskmanage/pkg/logger"
+)
+
+var (
+	// ErrActivationTokenInvalid 激活令牌无效（签名错误、不存在、已使用或已被新令牌取代）
+	ErrActivationTokenInvalid = errors.New("激活令牌无效")
+	// ErrActivationTokenExpired 激活令牌已过期，可重新发送激活邮件
+	ErrActivationTokenExpired = errors.New("激活令牌已过期")
+	// ErrAccountAlreadyActivated 账号已激活，无需重新发送
+	ErrAccountAlreadyActivated = errors.New("账号已激活")
+)
+
+// defaultActivationExpiry 激活令牌默认有效期
+const defaultActivationExpiry = 7 * 24 * time.Hour
+
+// ActivationService 账号激活服务
+type ActivationService interface {
+	// SendActivation 为用户生成激活令牌并发送激活邮件
+	SendActivation(ctx context.Context, user *database.User) error
+	// Activate 校验激活令牌，设置用户密码并激活账号
+	Activate(ctx context.Context, req *ActivateAccountRequest) error
+	// ResendActivation 按邮箱重新发送激活邮件，之前的令牌全部失效
+	ResendActivation(ctx context.Context, email string) error
+}
+
+// activationService 账号激活服务实现
+type activationService struct {
+	repoManager   repository.RepositoryManager
+	sender        email.EmailSender
+	secret        []byte
+	expiry        time.Duration
+	activationURL string
+	now           func() time.Time
+}
+
+// NewActivationService 创建账号激活服务，令牌使用JWT密钥签名
+func NewActivationService(repoManager repository.RepositoryManager, sender email.EmailSender, cfg *config.Config) ActivationService {
+	expiry := time.Duration(cfg.Email.ActivationExpiryHours) * time.Hour
+	if expiry <= 0 {
+		expiry = defaultActivationExpiry
+	}
+	return &activationService{
+		repoManager:   repoManager,
+		sender:        sender,
+		secret:        []byte(cfg.JWT.Secret),
+		expiry:        expiry,
+		activationURL: cfg.Email.ActivationURL,
+		now:           time.Now,
+	}
+}
+
+// NewEmailSender 根据配置创建邮件发送器，未配置SMTP时只写日志
+func NewEmailSender(cfg config.EmailConfig) email.EmailSender {
+	if cfg.Driver == "smtp" {
+		return email.NewSMTPSender(email.SMTPConfig{
+			Host:     cfg.Host,
+			Port:     cfg.Port,
+			Username: cfg.Username,
+			Password: cfg.Password,
+			From:     cfg.From,
+		})
+	}
+	return email.NewLogSender()
+}
+
+// SendActivation 为用户生成激活令牌并发送激活邮件
+func (s *activationService) SendActivation(ctx context.Context, user *database.User) error {
+	tokenRepo := s.repoManager.AccountActivationTokenRepository()
+	if err := tokenRepo.DeleteUnusedByUserID(ctx, user.ID); err != nil {
+		return fmt.Errorf("清理旧激活令牌失败: %w", err)
+	}
+
+	expiresAt := s.now().Add(s.expiry)
+	token, err := s.generateToken(user.ID, expiresAt)
+	if err != nil {
+		return err
+	}
+	record := &database.AccountActivationToken{
+		UserID:    user.ID,
+		TokenHash: hashActivationToken(token),
+		ExpiresAt: expiresAt,
+	}
+	if err := tokenRepo.Create(ctx, record); err != nil {
+		return fmt.Errorf("保存激活令牌失败: %w", err)
+	}
+
+	msg := &email.Message{
+		To:      user.Email,
+		Subject: "账号激活",
+		Body: fmt.Sprintf("%s，您好：\n\n请在%s前访问以下链接设置密码并激活账号：\n%s\n\n如非本人操作请忽略此邮件。",
+			user.RealName, expiresAt.Format("2006-01-02 15:04"), s.activationLink(token)),
+	}
+	if err := s.sender.Send(ctx, msg); err != nil {
+		return fmt.Errorf("发送激活邮件失败: %w", err)
+	}
+	return nil
+}
+
+// Activate 校验激活令牌，设置用户密码并激活账号
+// 员工仍处于待入职状态时只设置密码，账号在入职确认时激活
+func (s *activationService) Activate(ctx context.Context, req *ActivateAccountRequest) error {
+	userID, expiresAt, err := s.parseToken(req.Token)
+	if err != nil {
+		return err
+	}
+	if !s.now().Before(expiresAt) {
+		return ErrActivationTokenExpired
+	}
+
+	record, err := s.repoManager.AccountActivationTokenRepository().GetByTokenHash(ctx, hashActivationToken(req.Token))
+	if err != nil {
+		if repository.IsNotFoundError(err) {
+			return ErrActivationTokenInvalid
+		}
+		return fmt.Errorf("查询激活令牌失败: %w", err)
+	}
+	if record.UserID != userID || record.UsedAt != nil {
+		return ErrActivationTokenInvalid
+	}
+	if !s.now().Before(record.ExpiresAt) {
+		return ErrActivationTokenExpired
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("密码加密失败: %w", err)
+	}
+
+	return s.repoManager.WithTx(ctx, func(ctx context.Context, repos repository.RepositoryManager) error {
+		used, err := repos.AccountActivationTokenRepository().MarkUsed(ctx, record.ID, s.now())
+		if err != nil {
+			return fmt.Errorf("更新激活令牌失败: %w", err)
+		}
+		if !used {
+			return ErrActivationTokenInvalid
+		}
+
+		user, err := repos.UserRepository().GetByID(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("查询用户失败: %w", err)
+		}
+		user.Password = string(hashedPassword)
+		user.PasswordHash = string(hashedPassword)
+
+		employee, err := repos.EmployeeRepository().GetByUserID(ctx, userID)
+		switch {
+		case err == nil:
+			if employee.OnboardingStatus != "pending_onboard" {
+				user.Status = "active"
+			}
+		case repository.IsNotFoundError(err):
+			user.Status = "active"
+		default:
+			return fmt.Errorf("查询员工信息失败: %w", err)
+		}
+
+		if err := repos.UserRepository().Update(ctx, user); err != nil {
+			return fmt.Errorf("更新用户失败: %w", err)
+		}
+		logger.Infof("账号激活成功: user=%d, status=%s", user.ID, user.Status)
+		return nil
+	})
+}
+
+// ResendActivation 按邮箱重新发送激活邮件，邮箱不存在时不报错以免泄露账号信息
+func (s *activationService) ResendActivation(ctx context.Context, emailAddr string) error {
+	user, err := s.repoManager.UserRepository().GetByEmail(ctx, emailAddr)
+	if err != nil {
+		if repository.IsNotFoundError(err) {
+			logger.Infof("重新发送激活邮件: 邮箱不存在 %s", emailAddr)
+			return nil
+		}
+		return fmt.Errorf("查询用户失败: %w", err)
+	}
+
+	activated, err := s.repoManager.AccountActivationTokenRepository().HasUsedByUserID(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("查询激活记录失败: %w", err)
+	}
+	if activated || user.Status != "inactive" {
+		return ErrAccountAlreadyActivated
+	}
+
+	return s.SendActivation(ctx, user)
+}
+
+// activationLink 构建激活链接
+func (s *activationService) activationLink(token string) string {
+	if s.activationURL == "" {
+		return token
+	}
+	separator := "?"
+	if strings.Contains(s.activationURL, "?") {
+		separator = "&"
+	}
+	return s.activationURL + separator + "token=" + url.QueryEscape(token)
+}
+
+// generateToken 生成签名令牌，格式为 userID.过期时间戳.随机串.签名
+func (s *activationService) generateToken(userID uint, expiresAt time.Time) (string, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("生成激活令牌失败: %w", err)
+	}
+	payload := fmt.Sprintf("%d.%d.%s", userID, expiresAt.Unix(), hex.EncodeToString(nonce))
+	return payload + "." + s.sign(payload), nil
+}
+
+// parseToken 校验令牌签名并解析用户ID和过期时间
+func (s *activationService) parseToken(token string) (uint, time.Time, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 4 {
+		return 0, time.Time{}, ErrActivationTokenInvalid
+	}
+	payload := strings.Join(parts[:3], ".")
+	if !hmac.Equal([]byte(parts[3]), []byte(s.sign(payload))) {
+		return 0, time.Time{}, ErrActivationTokenInvalid
+	}
+
+	userID, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return 0, time.Time{}, ErrActivationTokenInvalid
+	}
+	expiresUnix, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return 0, time.Time{}, ErrActivationTokenInvalid
+	}
+	return uint(userID), time.Unix(expiresUnix, 0), nil
+}
+
+// sign 计算载荷的HMAC-SHA256签名
+func (s *activationService) sign(payload string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// hashActivationToken 计算令牌摘要，数据库中只保存摘要
+func hashActivationToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
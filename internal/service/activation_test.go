@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/config"
+)
+
+func newTestActivationService() *activationService {
+	cfg := &config.Config{}
+	cfg.JWT.Secret = "activation-test-secret-activation-test"
+	cfg.Email.ActivationURL = "https://example.com/activate"
+	return NewActivationService(nil, nil, cfg).(*activationService)
+}
+
+func TestActivationToken_SignatureAndExpiry(t *testing.T) {
+	s := newTestActivationService()
+	assert.Equal(t, defaultActivationExpiry, s.expiry)
+
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+	token, err := s.generateToken(42, expiresAt)
+	require.NoError(t, err)
+
+	userID, parsedExpiry, err := s.parseToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, uint(42), userID)
+	assert.True(t, parsedExpiry.Equal(expiresAt))
+
+	// 篡改用户ID后签名不再匹配
+	tampered := "43" + strings.TrimPrefix(token, "42")
+	_, _, err = s.parseToken(tampered)
+	assert.ErrorIs(t, err, ErrActivationTokenInvalid)
+
+	// 其它密钥签发的令牌无效
+	other := newTestActivationService()
+	other.secret = []byte("another-secret")
+	_, _, err = other.parseToken(token)
+	assert.ErrorIs(t, err, ErrActivationTokenInvalid)
+
+	assert.Contains(t, s.activationLink(token), "https://example.com/activate?token=")
+}
+
+func TestActivate_ExpiredToken(t *testing.T) {
+	s := newTestActivationService()
+	token, err := s.generateToken(42, time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+
+	// 过期令牌在查询数据库前即被拒绝，与无效令牌区分
+	err = s.Activate(context.Background(), &ActivateAccountRequest{Token: token, Password: "secret123"})
+	assert.ErrorIs(t, err, ErrActivationTokenExpired)
+
+	err = s.Activate(context.Background(), &ActivateAccountRequest{Token: "not-a-token", Password: "secret123"})
+	assert.ErrorIs(t, err, ErrActivationTokenInvalid)
+}
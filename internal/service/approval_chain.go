@@ -0,0 +1,294 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+	"taskmanage/internal/workflow"
+	"taskmanage/pkg/logger"
+)
+
+var (
+	// ErrInvalidApprovalChain 审批链配置不合法
+	ErrInvalidApprovalChain = errors.New("审批链配置不合法")
+	// ErrApprovalChainExists 部门在该业务类型下已配置审批链
+	ErrApprovalChainExists = errors.New("部门在该业务类型下已配置审批链")
+)
+
+// ApprovalChainRequest 创建或更新部门审批链请求
+type ApprovalChainRequest struct {
+	BusinessType    string                      `json:"business_type" binding:"required,max=50"`
+	Assignees       []workflow.ApprovalAssignee `json:"assignees" binding:"required,min=1"` // 按顺序排列的审批人，支持 user/role/manager
+	CrossDepartment bool                        `json:"cross_department"`                   // 允许指定其它部门的用户
+	Description     string                      `json:"description" binding:"max=255"`
+}
+
+// ApprovalChainResponse 部门审批链响应
+type ApprovalChainResponse struct {
+	ID              uint                        `json:"id"`
+	DepartmentID    uint                        `json:"department_id"`
+	BusinessType    string                      `json:"business_type"`
+	Assignees       []workflow.ApprovalAssignee `json:"assignees"`
+	CrossDepartment bool                        `json:"cross_department"`
+	Description     string                      `json:"description"`
+	CreatedAt       time.Time                   `json:"created_at"`
+	UpdatedAt       time.Time                   `json:"updated_at"`
+}
+
+// ApprovalChainService 部门审批链服务，同时为流程引擎提供审批链查询
+type ApprovalChainService interface {
+	workflow.ApprovalChainProvider
+
+	// ListDepartmentChains 获取部门的全部审批链
+	ListDepartmentChains(ctx context.Context, departmentID uint) ([]*ApprovalChainResponse, error)
+	// GetDepartmentChain 获取部门的审批链详情
+	GetDepartmentChain(ctx context.Context, departmentID, chainID uint) (*ApprovalChainResponse, error)
+	// CreateDepartmentChain 为部门创建审批链，每个业务类型只能配置一条
+	CreateDepartmentChain(ctx context.Context, departmentID uint, req *ApprovalChainRequest) (*ApprovalChainResponse, error)
+	// UpdateDepartmentChain 更新部门的审批链
+	UpdateDepartmentChain(ctx context.Context, departmentID, chainID uint, req *ApprovalChainRequest) (*ApprovalChainResponse, error)
+	// DeleteDepartmentChain 删除部门的审批链，之后审批节点回退到内联审批人
+	DeleteDepartmentChain(ctx context.Context, departmentID, chainID uint) error
+}
+
+// approvalChainService 部门审批链服务实现
+type approvalChainService struct {
+	repoManager repository.RepositoryManager
+}
+
+// NewApprovalChainService 创建部门审批链服务
+func NewApprovalChainService(repoManager repository.RepositoryManager) ApprovalChainService {
+	return &approvalChainService{repoManager: repoManager}
+}
+
+// GetApprovalChain 返回部门在业务类型下的审批人配置，未配置时返回空
+func (s *approvalChainService) GetApprovalChain(ctx context.Context, departmentID uint, businessType string) ([]workflow.ApprovalAssignee, error) {
+	chain, err := s.repoManager.DepartmentApprovalChainRepository().GetByDepartmentAndBusinessType(ctx, departmentID, businessType)
+	if err != nil {
+		if repository.IsNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("查询部门审批链失败: %w", err)
+	}
+	return chainAssignees(chain)
+}
+
+// ListDepartmentChains 获取部门的全部审批链
+func (s *approvalChainService) ListDepartmentChains(ctx context.Context, departmentID uint) ([]*ApprovalChainResponse, error) {
+	if _, err := s.repoManager.DepartmentRepository().GetByID(ctx, departmentID); err != nil {
+		return nil, fmt.Errorf("获取部门失败: %w", err)
+	}
+
+	chains, err := s.repoManager.DepartmentApprovalChainRepository().ListByDepartment(ctx, departmentID)
+	if err != nil {
+		return nil, fmt.Errorf("查询部门审批链失败: %w", err)
+	}
+
+	responses := make([]*ApprovalChainResponse, 0, len(chains))
+	for _, chain := range chains {
+		resp, err := toApprovalChainResponse(chain)
+		if err != nil {
+			return nil, err
+		}
+		responses = append(responses, resp)
+	}
+	return responses, nil
+}
+
+// GetDepartmentChain 获取部门的审批链详情
+func (s *approvalChainService) GetDepartmentChain(ctx context.Context, departmentID, chainID uint) (*ApprovalChainResponse, error) {
+	chain, err := s.getDepartmentChain(ctx, departmentID, chainID)
+	if err != nil {
+		return nil, err
+	}
+	return toApprovalChainResponse(chain)
+}
+
+// CreateDepartmentChain 为部门创建审批链，每个业务类型只能配置一条
+func (s *approvalChainService) CreateDepartmentChain(ctx context.Context, departmentID uint, req *ApprovalChainRequest) (*ApprovalChainResponse, error) {
+	if _, err := s.repoManager.DepartmentRepository().GetByID(ctx, departmentID); err != nil {
+		return nil, fmt.Errorf("获取部门失败: %w", err)
+	}
+	if err := s.validateChain(ctx, departmentID, req); err != nil {
+		return nil, err
+	}
+	if err := s.checkBusinessTypeAvailable(ctx, departmentID, req.BusinessType, 0); err != nil {
+		return nil, err
+	}
+
+	chain := &database.DepartmentApprovalChain{
+		DepartmentID:    departmentID,
+		BusinessType:    req.BusinessType,
+		Assignees:       database.JSONField{Data: req.Assignees},
+		CrossDepartment: req.CrossDepartment,
+		Description:     req.Description,
+	}
+	if err := s.repoManager.DepartmentApprovalChainRepository().Create(ctx, chain); err != nil {
+		return nil, fmt.Errorf("创建部门审批链失败: %w", err)
+	}
+
+	logger.Infof("创建部门审批链: 部门=%d, 业务类型=%s, 审批人数量=%d", departmentID, req.BusinessType, len(req.Assignees))
+	return toApprovalChainResponse(chain)
+}
+
+// UpdateDepartmentChain 更新部门的审批链
+func (s *approvalChainService) UpdateDepartmentChain(ctx context.Context, departmentID, chainID uint, req *ApprovalChainRequest) (*ApprovalChainResponse, error) {
+	chain, err := s.getDepartmentChain(ctx, departmentID, chainID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateChain(ctx, departmentID, req); err != nil {
+		return nil, err
+	}
+	if req.BusinessType != chain.BusinessType {
+		if err := s.checkBusinessTypeAvailable(ctx, departmentID, req.BusinessType, chain.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	chain.BusinessType = req.BusinessType
+	chain.Assignees = database.JSONField{Data: req.Assignees}
+	chain.CrossDepartment = req.CrossDepartment
+	chain.Description = req.Description
+	if err := s.repoManager.DepartmentApprovalChainRepository().Update(ctx, chain); err != nil {
+		return nil, fmt.Errorf("更新部门审批链失败: %w", err)
+	}
+
+	logger.Infof("更新部门审批链: 部门=%d, 业务类型=%s, 审批人数量=%d", departmentID, req.BusinessType, len(req.Assignees))
+	return toApprovalChainResponse(chain)
+}
+
+// DeleteDepartmentChain 删除部门的审批链
+func (s *approvalChainService) DeleteDepartmentChain(ctx context.Context, departmentID, chainID uint) error {
+	chain, err := s.getDepartmentChain(ctx, departmentID, chainID)
+	if err != nil {
+		return err
+	}
+	if err := s.repoManager.DepartmentApprovalChainRepository().Delete(ctx, chain.ID); err != nil {
+		return fmt.Errorf("删除部门审批链失败: %w", err)
+	}
+
+	logger.Infof("删除部门审批链: 部门=%d, 业务类型=%s", departmentID, chain.BusinessType)
+	return nil
+}
+
+// getDepartmentChain 获取审批链并校验其属于该部门
+func (s *approvalChainService) getDepartmentChain(ctx context.Context, departmentID, chainID uint) (*database.DepartmentApprovalChain, error) {
+	chain, err := s.repoManager.DepartmentApprovalChainRepository().GetByID(ctx, chainID)
+	if err != nil {
+		return nil, fmt.Errorf("获取部门审批链失败: %w", err)
+	}
+	if chain.DepartmentID != departmentID {
+		return nil, fmt.Errorf("获取部门审批链失败: %w", repository.ErrNotFound)
+	}
+	return chain, nil
+}
+
+// checkBusinessTypeAvailable 检查部门在业务类型下是否已有其它审批链
+func (s *approvalChainService) checkBusinessTypeAvailable(ctx context.Context, departmentID uint, businessType string, excludeID uint) error {
+	existing, err := s.repoManager.DepartmentApprovalChainRepository().GetByDepartmentAndBusinessType(ctx, departmentID, businessType)
+	if err != nil {
+		if repository.IsNotFoundError(err) {
+			return nil
+		}
+		return fmt.Errorf("查询部门审批链失败: %w", err)
+	}
+	if existing.ID != excludeID {
+		return ErrApprovalChainExists
+	}
+	return nil
+}
+
+// validateChain 校验审批人配置，未允许跨部门时指定用户必须属于该部门
+func (s *approvalChainService) validateChain(ctx context.Context, departmentID uint, req *ApprovalChainRequest) error {
+	if strings.TrimSpace(req.BusinessType) == "" {
+		return fmt.Errorf("%w: 业务类型不能为空", ErrInvalidApprovalChain)
+	}
+	if len(req.Assignees) == 0 {
+		return fmt.Errorf("%w: 至少需要一个审批人", ErrInvalidApprovalChain)
+	}
+
+	for i, assignee := range req.Assignees {
+		switch assignee.Type {
+		case workflow.AssigneeTypeUser:
+			userID, err := strconv.ParseUint(assignee.Value, 10, 64)
+			if err != nil || userID == 0 {
+				return fmt.Errorf("%w: 第%d个审批人的用户ID无效: %s", ErrInvalidApprovalChain, i+1, assignee.Value)
+			}
+			if err := s.validateChainUser(ctx, departmentID, uint(userID), req.CrossDepartment); err != nil {
+				return fmt.Errorf("第%d个审批人: %w", i+1, err)
+			}
+		case workflow.AssigneeTypeRole:
+			if strings.TrimSpace(assignee.Value) == "" {
+				return fmt.Errorf("%w: 第%d个审批人未指定角色", ErrInvalidApprovalChain, i+1)
+			}
+		case workflow.AssigneeTypeManager, workflow.AssigneeTypeDepartmentManager:
+		default:
+			return fmt.Errorf("%w: 第%d个审批人类型不支持: %s", ErrInvalidApprovalChain, i+1, assignee.Type)
+		}
+	}
+	return nil
+}
+
+// validateChainUser 校验指定用户存在且属于该部门
+func (s *approvalChainService) validateChainUser(ctx context.Context, departmentID, userID uint, crossDepartment bool) error {
+	if _, err := s.repoManager.UserRepository().GetByID(ctx, userID); err != nil {
+		if repository.IsNotFoundError(err) {
+			return fmt.Errorf("%w: 用户 %d 不存在", ErrInvalidApprovalChain, userID)
+		}
+		return fmt.Errorf("查询用户失败: %w", err)
+	}
+	if crossDepartment {
+		return nil
+	}
+
+	employee, err := s.repoManager.EmployeeRepository().GetByUserID(ctx, userID)
+	if err != nil && !repository.IsNotFoundError(err) {
+		return fmt.Errorf("查询员工信息失败: %w", err)
+	}
+	if employee == nil || employee.DepartmentID == nil || *employee.DepartmentID != departmentID {
+		return fmt.Errorf("%w: 用户 %d 不属于该部门，如需跨部门审批请设置cross_department", ErrInvalidApprovalChain, userID)
+	}
+	return nil
+}
+
+// chainAssignees 解析审批链中保存的审批人配置
+func chainAssignees(chain *database.DepartmentApprovalChain) ([]workflow.ApprovalAssignee, error) {
+	if chain.Assignees.Data == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(chain.Assignees.Data)
+	if err != nil {
+		return nil, fmt.Errorf("序列化审批链失败: %w", err)
+	}
+	var assignees []workflow.ApprovalAssignee
+	if err := json.Unmarshal(data, &assignees); err != nil {
+		return nil, fmt.Errorf("解析审批链失败: %w", err)
+	}
+	return assignees, nil
+}
+
+// toApprovalChainResponse 转换为审批链响应
+func toApprovalChainResponse(chain *database.DepartmentApprovalChain) (*ApprovalChainResponse, error) {
+	assignees, err := chainAssignees(chain)
+	if err != nil {
+		return nil, err
+	}
+	return &ApprovalChainResponse{
+		ID:              chain.ID,
+		DepartmentID:    chain.DepartmentID,
+		BusinessType:    chain.BusinessType,
+		Assignees:       assignees,
+		CrossDepartment: chain.CrossDepartment,
+		Description:     chain.Description,
+		CreatedAt:       chain.CreatedAt,
+		UpdatedAt:       chain.UpdatedAt,
+	}, nil
+}
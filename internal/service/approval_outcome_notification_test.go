@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/config"
+	"taskmanage/internal/database"
+	"taskmanage/internal/workflow"
+)
+
+// dedupeNotificationRepository 按已创建通知的去重键判断是否重复
+type dedupeNotificationRepository struct {
+	countingNotificationRepository
+}
+
+func (r *dedupeNotificationRepository) ExistsByDedupeKey(ctx context.Context, recipientID uint, dedupeKey string) (bool, error) {
+	for _, notification := range r.created {
+		if notification.RecipientID == recipientID && notification.DedupeKey != nil && *notification.DedupeKey == dedupeKey {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func newOutcomeNotificationService(notifications *dedupeNotificationRepository) *NotificationServiceImpl {
+	return &NotificationServiceImpl{
+		notificationRepo: notifications,
+		preferenceRepo:   &fakePreferenceRepository{preferences: map[string]*database.NotificationPreference{}},
+		userRepo: &emailUserRepository{users: map[uint]*database.User{
+			2: {BaseModel: database.BaseModel{ID: 2}, RealName: "王经理"},
+		}},
+		employeeRepo: &escalationEmployeeRepository{employees: []*database.Employee{
+			{BaseModel: database.BaseModel{ID: 30}, UserID: 12},
+		}},
+		defaults: buildNotificationDefaults(config.NotificationConfig{}),
+	}
+}
+
+func TestOnApprovalResolved_TaskAssignment(t *testing.T) {
+	notifications := &dedupeNotificationRepository{}
+	svc := newOutcomeNotificationService(notifications)
+	ctx := context.Background()
+	instance := &workflow.WorkflowInstance{
+		ID:           "inst",
+		BusinessID:   "task_7",
+		BusinessType: "task_assignment",
+		StartedBy:    9,
+		Variables:    map[string]interface{}{"task_id": 7, "assignee_id": 30},
+	}
+
+	// 流程未结束的节点通过不通知
+	svc.OnApprovalResolved(ctx, instance, &workflow.ApprovalOutcome{NodeID: "lead", NodeName: "组长审批", Outcome: workflow.ApprovalOutcomeApproved, DecidedBy: 1})
+	assert.Empty(t, notifications.created)
+
+	event := &workflow.ApprovalOutcome{NodeID: "manager", NodeName: "经理审批", Outcome: workflow.ApprovalOutcomeApproved, DecidedBy: 2, Comment: "同意", Completed: true}
+	svc.OnApprovalResolved(ctx, instance, event)
+	require.Len(t, notifications.created, 2)
+	requester, assignee := notifications.created[0], notifications.created[1]
+	assert.Equal(t, uint(9), requester.RecipientID)
+	// 被分配人按员工ID查到用户
+	assert.Equal(t, uint(12), assignee.RecipientID)
+	assert.Equal(t, "任务分配审批已通过", requester.Title)
+	assert.Equal(t, "任务分配审批（业务编号task_7）在经理审批已通过，审批人王经理，审批意见：同意", requester.Content)
+	assert.Equal(t, uint(7), *requester.TaskID)
+	assert.Equal(t, uint(2), *requester.SenderID)
+
+	var payload map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(*requester.ActionData), &payload))
+	assert.Equal(t, map[string]interface{}{"instance_id": "inst", "node_id": "manager", "outcome": "approved", "task_id": float64(7)}, payload)
+
+	// 同一实例同一结果不重复通知
+	svc.OnApprovalResolved(ctx, instance, event)
+	assert.Len(t, notifications.created, 2)
+}
+
+func TestOnApprovalResolved_BatchAndOnboarding(t *testing.T) {
+	notifications := &dedupeNotificationRepository{}
+	svc := newOutcomeNotificationService(notifications)
+	rejected := &workflow.ApprovalOutcome{NodeID: "lead", NodeName: "组长审批", Outcome: workflow.ApprovalOutcomeRejected, DecidedBy: 1, Comment: "人手不足"}
+
+	// 批量审批时发起人只接收汇总通知，被分配人仍逐条通知
+	svc.OnApprovalResolved(withBatchApproval(context.Background()), &workflow.WorkflowInstance{
+		ID: "batch", BusinessType: "task_assignment", StartedBy: 9,
+		Variables: map[string]interface{}{"task_id": 7, "assignee_id": 30},
+	}, rejected)
+	require.Len(t, notifications.created, 1)
+	assert.Equal(t, uint(12), notifications.created[0].RecipientID)
+	assert.Equal(t, "任务分配审批被拒绝", notifications.created[0].Title)
+
+	// 入职审批附带员工ID，审批人本人不通知
+	svc.OnApprovalResolved(context.Background(), &workflow.WorkflowInstance{
+		ID: "onboard", BusinessType: "onboarding", StartedBy: 1,
+		Variables: map[string]interface{}{"employee_id": 40},
+	}, rejected)
+	assert.Len(t, notifications.created, 1)
+	svc.OnApprovalResolved(context.Background(), &workflow.WorkflowInstance{
+		ID: "onboard", BusinessType: "onboarding", StartedBy: 5,
+		Variables: map[string]interface{}{"employee_id": 40},
+	}, rejected)
+	require.Len(t, notifications.created, 2)
+	assert.Nil(t, notifications.created[1].TaskID)
+	assert.Contains(t, *notifications.created[1].ActionData, `"employee_id":40`)
+
+	// 其他业务类型不通知
+	svc.OnApprovalResolved(context.Background(), &workflow.WorkflowInstance{ID: "off", BusinessType: "offboarding", StartedBy: 5}, rejected)
+	assert.Len(t, notifications.created, 2)
+}
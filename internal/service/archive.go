@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"taskmanage/internal/config"
+	"taskmanage/internal/repository"
+	"taskmanage/pkg/logger"
+	"taskmanage/pkg/response"
+)
+
+const (
+	// DefaultArchiveInterval 后台归档任务的默认运行间隔
+	DefaultArchiveInterval = 24 * time.Hour
+	// defaultTaskRetentionDays 任务完成或取消后默认保留在在用表中的天数
+	defaultTaskRetentionDays = 365
+	// defaultWorkflowRetentionDays 流程实例结束后默认保留在在用表中的天数
+	defaultWorkflowRetentionDays = 180
+	// defaultArchiveBatchSize 每批默认迁移的记录数
+	defaultArchiveBatchSize = 500
+	// defaultArchiveBatchPause 两批之间的默认间隔
+	defaultArchiveBatchPause = 200 * time.Millisecond
+	// defaultArchiveMaxBatches 每次运行每类数据默认最多迁移的批数
+	defaultArchiveMaxBatches = 100
+)
+
+// ArchiveReport 一次归档运行的结果
+type ArchiveReport struct {
+	TasksArchived             int `json:"tasks_archived"`
+	WorkflowInstancesArchived int `json:"workflow_instances_archived"`
+	Batches                   int `json:"batches"`
+}
+
+// ArchiveService 已结束任务和流程实例的归档服务
+type ArchiveService interface {
+	// ArchiveFinished 将超过保留期的已结束任务和流程实例分批移入归档表，批次之间暂停以减少对数据库的压力
+	ArchiveFinished(ctx context.Context, now time.Time) (*ArchiveReport, error)
+	// DeleteArchivedTask 永久删除归档任务，只能删除已归档的任务
+	DeleteArchivedTask(ctx context.Context, taskID uint) error
+	// DeleteArchivedWorkflowInstance 永久删除归档流程实例，只能删除已归档的实例
+	DeleteArchivedWorkflowInstance(ctx context.Context, instanceID string) error
+}
+
+// archiveService 归档服务实现
+type archiveService struct {
+	archiveRepo       repository.ArchiveRepository
+	taskRetention     time.Duration
+	workflowRetention time.Duration
+	batchSize         int
+	batchPause        time.Duration
+	maxBatches        int
+}
+
+// NewArchiveService 创建归档服务，配置项为0时使用默认值
+func NewArchiveService(repoManager repository.RepositoryManager, cfg config.ArchiveConfig) ArchiveService {
+	s := &archiveService{
+		archiveRepo:       repoManager.ArchiveRepository(),
+		taskRetention:     time.Duration(cfg.TaskRetentionDays) * 24 * time.Hour,
+		workflowRetention: time.Duration(cfg.WorkflowRetentionDays) * 24 * time.Hour,
+		batchSize:         cfg.BatchSize,
+		batchPause:        time.Duration(cfg.BatchPauseMillis) * time.Millisecond,
+		maxBatches:        cfg.MaxBatches,
+	}
+	if s.taskRetention <= 0 {
+		s.taskRetention = defaultTaskRetentionDays * 24 * time.Hour
+	}
+	if s.workflowRetention <= 0 {
+		s.workflowRetention = defaultWorkflowRetentionDays * 24 * time.Hour
+	}
+	if s.batchSize <= 0 {
+		s.batchSize = defaultArchiveBatchSize
+	}
+	if s.batchPause <= 0 {
+		s.batchPause = defaultArchiveBatchPause
+	}
+	if s.maxBatches <= 0 {
+		s.maxBatches = defaultArchiveMaxBatches
+	}
+	return s
+}
+
+// ArchiveFinished 先归档任务再归档流程实例，某一类出错时返回已完成部分的结果
+func (s *archiveService) ArchiveFinished(ctx context.Context, now time.Time) (*ArchiveReport, error) {
+	report := &ArchiveReport{}
+
+	taskBefore := now.Add(-s.taskRetention)
+	err := s.runBatches(ctx, report, func() (int, error) {
+		ids, err := s.archiveRepo.ArchiveTasks(ctx, taskBefore, s.batchSize, now)
+		report.TasksArchived += len(ids)
+		return len(ids), err
+	})
+	if err != nil {
+		return report, fmt.Errorf("归档任务失败: %w", err)
+	}
+
+	instanceBefore := now.Add(-s.workflowRetention)
+	err = s.runBatches(ctx, report, func() (int, error) {
+		ids, err := s.archiveRepo.ArchiveWorkflowInstances(ctx, instanceBefore, s.batchSize, now)
+		report.WorkflowInstancesArchived += len(ids)
+		return len(ids), err
+	})
+	if err != nil {
+		return report, fmt.Errorf("归档流程实例失败: %w", err)
+	}
+	return report, nil
+}
+
+// runBatches 重复执行一批迁移，直到某批不足batchSize条或达到maxBatches批，批次之间暂停batchPause
+func (s *archiveService) runBatches(ctx context.Context, report *ArchiveReport, batch func() (int, error)) error {
+	for i := 0; i < s.maxBatches; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(s.batchPause):
+			}
+		}
+		moved, err := batch()
+		if err != nil {
+			return err
+		}
+		if moved == 0 {
+			return nil
+		}
+		report.Batches++
+		if moved < s.batchSize {
+			return nil
+		}
+	}
+	return nil
+}
+
+// DeleteArchivedTask 永久删除归档任务
+func (s *archiveService) DeleteArchivedTask(ctx context.Context, taskID uint) error {
+	if err := s.archiveRepo.DeleteArchivedTask(ctx, taskID); err != nil {
+		return notFoundOr(err, response.ErrCodeTaskNotFound, "归档任务不存在", "删除归档任务失败")
+	}
+	logger.Infof("归档任务已永久删除: ID=%d", taskID)
+	return nil
+}
+
+// DeleteArchivedWorkflowInstance 永久删除归档流程实例
+func (s *archiveService) DeleteArchivedWorkflowInstance(ctx context.Context, instanceID string) error {
+	if err := s.archiveRepo.DeleteArchivedWorkflowInstance(ctx, instanceID); err != nil {
+		return notFoundOr(err, response.ErrCodeNotFound, "归档流程实例不存在", "删除归档流程实例失败")
+	}
+	logger.Infof("归档流程实例已永久删除: InstanceID=%s", instanceID)
+	return nil
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/config"
+	"taskmanage/internal/repository"
+	"taskmanage/pkg/response"
+)
+
+// fakeArchiveRepository 测试用归档仓储，按剩余数量分批返回
+type fakeArchiveRepository struct {
+	repository.ArchiveRepository
+	tasksLeft      int
+	instancesLeft  int
+	taskBefore     time.Time
+	instanceErr    error
+	taskBatchSizes []int
+}
+
+func (f *fakeArchiveRepository) ArchiveTasks(ctx context.Context, before time.Time, limit int, archivedAt time.Time) ([]uint, error) {
+	f.taskBefore = before
+	n := min(limit, f.tasksLeft)
+	f.tasksLeft -= n
+	f.taskBatchSizes = append(f.taskBatchSizes, n)
+	return make([]uint, n), nil
+}
+
+func (f *fakeArchiveRepository) ArchiveWorkflowInstances(ctx context.Context, before time.Time, limit int, archivedAt time.Time) ([]string, error) {
+	if f.instanceErr != nil {
+		return nil, f.instanceErr
+	}
+	n := min(limit, f.instancesLeft)
+	f.instancesLeft -= n
+	return make([]string, n), nil
+}
+
+func (f *fakeArchiveRepository) DeleteArchivedTask(ctx context.Context, taskID uint) error {
+	return repository.ErrNotFound
+}
+
+// archiveRepoManager 归档测试用仓储管理器
+type archiveRepoManager struct {
+	repository.RepositoryManager
+	archive *fakeArchiveRepository
+}
+
+func (m *archiveRepoManager) ArchiveRepository() repository.ArchiveRepository {
+	return m.archive
+}
+
+func newTestArchiveService(repo *fakeArchiveRepository, cfg config.ArchiveConfig) *archiveService {
+	svc := NewArchiveService(&archiveRepoManager{archive: repo}, cfg).(*archiveService)
+	svc.batchPause = time.Millisecond
+	return svc
+}
+
+func TestArchiveService_ArchiveFinishedBatches(t *testing.T) {
+	repo := &fakeArchiveRepository{tasksLeft: 25, instancesLeft: 10}
+	svc := newTestArchiveService(repo, config.ArchiveConfig{TaskRetentionDays: 30, BatchSize: 10})
+	now := time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)
+
+	report, err := svc.ArchiveFinished(context.Background(), now)
+	require.NoError(t, err)
+	// 流程实例刚好一整批，再查询一次确认没有剩余记录，空批次不计入批数
+	assert.Equal(t, &ArchiveReport{TasksArchived: 25, WorkflowInstancesArchived: 10, Batches: 4}, report)
+	assert.Equal(t, []int{10, 10, 5}, repo.taskBatchSizes)
+	assert.Equal(t, now.AddDate(0, 0, -30), repo.taskBefore)
+}
+
+func TestArchiveService_ArchiveFinishedLimits(t *testing.T) {
+	// 达到每次运行的批数上限后停止，剩余记录留待下次运行
+	repo := &fakeArchiveRepository{tasksLeft: 100}
+	svc := newTestArchiveService(repo, config.ArchiveConfig{BatchSize: 10, MaxBatches: 3})
+	report, err := svc.ArchiveFinished(context.Background(), time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 30, report.TasksArchived)
+	assert.Equal(t, 70, repo.tasksLeft)
+
+	// 流程实例归档失败时返回已完成部分的结果
+	repo = &fakeArchiveRepository{tasksLeft: 5, instanceErr: errors.New("db down")}
+	svc = newTestArchiveService(repo, config.ArchiveConfig{})
+	report, err = svc.ArchiveFinished(context.Background(), time.Now())
+	require.Error(t, err)
+	assert.Equal(t, 5, report.TasksArchived)
+
+	// 取消时在批次之间停止
+	repo = &fakeArchiveRepository{tasksLeft: 100}
+	svc = newTestArchiveService(repo, config.ArchiveConfig{BatchSize: 10})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = svc.ArchiveFinished(ctx, time.Now())
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 90, repo.tasksLeft)
+}
+
+func TestArchiveService_DeleteArchivedTaskNotFound(t *testing.T) {
+	svc := newTestArchiveService(&fakeArchiveRepository{}, config.ArchiveConfig{})
+	err := svc.DeleteArchivedTask(context.Background(), 1)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	appErr := response.GetAppError(err)
+	require.NotNil(t, appErr)
+	assert.Equal(t, response.ErrCodeTaskNotFound, appErr.Code)
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"taskmanage/internal/database"
+	"taskmanage/pkg/logger"
+	"taskmanage/pkg/response"
+)
+
+// ErrAssignmentNotPending 分配记录不是待审批状态，或由审批流程处理
+var ErrAssignmentNotPending = response.NewError(response.ErrCodeConflict, "分配记录不是待审批状态")
+
+// ApproveAssignment 审批通过不使用工作流的待审批分配，按与审批流程相同的规则完成任务分配
+func (s *taskServiceRepo) ApproveAssignment(ctx context.Context, assignmentID uint, req *ApproveAssignmentRequest) error {
+	assignment, err := s.pendingAssignment(ctx, assignmentID)
+	if err != nil {
+		return err
+	}
+	task, err := s.taskRepo.GetByID(ctx, assignment.TaskID)
+	if err != nil {
+		return fmt.Errorf("获取任务失败: %w", err)
+	}
+	if task.Status != "pending" {
+		return response.Wrapf(ErrAssignmentNotPending, "任务已不是待分配状态")
+	}
+
+	if err := s.applyAssignmentOutcome(ctx, assignment, "approved", req.ApproverID); err != nil {
+		return err
+	}
+
+	logger.FromContext(ctx).Infof("任务分配审批通过: AssignmentID=%d, ApproverID=%d", assignmentID, req.ApproverID)
+	content := fmt.Sprintf("任务「%s」的分配已审批通过", task.Title)
+	if req.Comment != "" {
+		content += "，审批意见: " + req.Comment
+	}
+	s.notifyAssignmentDecision(ctx, assignment, "任务分配审批通过", content)
+	return nil
+}
+
+// RejectAssignment 拒绝不使用工作流的待审批分配，任务重置为待分配，拒绝原因记录在分配记录上
+func (s *taskServiceRepo) RejectAssignment(ctx context.Context, assignmentID uint, req *RejectAssignmentRequest) error {
+	assignment, err := s.pendingAssignment(ctx, assignmentID)
+	if err != nil {
+		return err
+	}
+
+	task, err := s.taskRepo.GetByID(ctx, assignment.TaskID)
+	if err != nil {
+		return fmt.Errorf("获取任务失败: %w", err)
+	}
+
+	assignment.Reason = req.Reason
+	if task.Status == "pending" {
+		err = s.applyAssignmentOutcome(ctx, assignment, "rejected", req.ApproverID)
+	} else {
+		// 任务已通过其他分配记录分配，只关闭本条记录，不重置任务
+		now := time.Now()
+		assignment.Status = "rejected"
+		assignment.ApprovedAt = &now
+		assignment.ApproverID = &req.ApproverID
+		err = s.assignmentRepo.Update(ctx, assignment)
+	}
+	if err != nil {
+		return err
+	}
+
+	logger.FromContext(ctx).Infof("任务分配审批拒绝: AssignmentID=%d, ApproverID=%d, Reason=%s", assignmentID, req.ApproverID, req.Reason)
+	s.notifyAssignmentDecision(ctx, assignment, "任务分配审批拒绝", fmt.Sprintf("任务「%s」的分配被拒绝，原因: %s", task.Title, req.Reason))
+	return nil
+}
+
+// pendingAssignment 获取待审批的分配记录，关联了审批流程的记录只能通过流程审批
+func (s *taskServiceRepo) pendingAssignment(ctx context.Context, assignmentID uint) (*database.Assignment, error) {
+	if s.assignmentRepo == nil {
+		return nil, fmt.Errorf("分配仓库未配置")
+	}
+	assignment, err := s.assignmentRepo.GetByID(ctx, assignmentID)
+	if err != nil {
+		return nil, fmt.Errorf("获取分配记录失败: %w", err)
+	}
+	if assignment.Status != "pending" || assignment.WorkflowInstanceID != nil {
+		return nil, response.Wrapf(ErrAssignmentNotPending, "当前状态为%s", assignment.Status)
+	}
+	return assignment, nil
+}
+
+// notifyAssignmentDecision 将审批结果通知分配发起人和被分配员工，失败只记录日志
+func (s *taskServiceRepo) notifyAssignmentDecision(ctx context.Context, assignment *database.Assignment, title, content string) {
+	if s.notificationService == nil {
+		return
+	}
+	recipients := []uint{assignment.AssignerID}
+	if employee, err := s.employeeRepo.GetByID(ctx, assignment.AssigneeID); err == nil {
+		if employee.UserID != assignment.AssignerID {
+			recipients = append(recipients, employee.UserID)
+		}
+	} else {
+		logger.FromContext(ctx).Warnf("获取被分配员工失败: EmployeeID=%d, error: %v", assignment.AssigneeID, err)
+	}
+
+	for _, recipientID := range recipients {
+		if err := s.notificationService.CreateCategorizedNotification(ctx, recipientID, NotificationCategoryApprovalResolved, title, content); err != nil {
+			logger.FromContext(ctx).Warnf("发送任务分配审批结果通知失败: UserID=%d, error: %v", recipientID, err)
+		}
+	}
+}
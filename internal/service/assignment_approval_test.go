@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/database"
+)
+
+func TestSimpleAssignmentApproval_Approve(t *testing.T) {
+	ctx := context.WithValue(context.Background(), "user_id", uint(7))
+	task := &database.Task{BaseModel: database.BaseModel{ID: 1}, Title: "接口联调", Status: "pending"}
+	employee := &database.Employee{BaseModel: database.BaseModel{ID: 3}, UserID: 30, CurrentTasks: 1, MaxTasks: 5}
+
+	taskRepo := new(MockTaskRepository)
+	employeeRepo := new(MockEmployeeRepository)
+	assignmentRepo := new(MockAssignmentRepository)
+	notifications := &recordingNotificationService{}
+	taskRepo.On("GetByID", ctx, uint(1)).Return(task, nil)
+	employeeRepo.On("GetByID", ctx, uint(3)).Return(employee, nil)
+	var saved *database.Assignment
+	assignmentRepo.On("Create", ctx, mock.MatchedBy(func(a *database.Assignment) bool {
+		return a.Status == "pending" && a.AssigneeID == 3 && a.AssignerID == 7 && a.WorkflowInstanceID == nil
+	})).Run(func(args mock.Arguments) {
+		saved = args.Get(1).(*database.Assignment)
+		saved.ID = 11
+	}).Return(nil)
+
+	svc := NewTaskService(taskRepo, employeeRepo, nil, assignmentRepo, nil, nil, nil, nil, nil, nil, nil, notifications, nil, false, true)
+	resp, err := svc.AssignTask(ctx, &AssignTaskRequest{TaskID: 1, EmployeeID: 3})
+	require.NoError(t, err)
+	assert.Equal(t, "pending", resp.Status)
+	assert.Equal(t, uint(11), resp.ID)
+	// 待审批期间任务保持待分配，员工任务数不变
+	assert.Equal(t, "pending", task.Status)
+	assert.Nil(t, task.AssigneeID)
+	employeeRepo.AssertNotCalled(t, "UpdateTaskCount", mock.Anything, mock.Anything, mock.Anything)
+
+	assignmentRepo.On("GetByID", ctx, uint(11)).Return(saved, nil)
+	taskRepo.On("Update", ctx, task).Return(nil)
+	employeeRepo.On("UpdateTaskCount", ctx, uint(3), 1).Return(nil)
+	assignmentRepo.On("Update", ctx, saved).Return(nil)
+
+	require.NoError(t, svc.ApproveAssignment(ctx, 11, &ApproveAssignmentRequest{Comment: "同意", ApproverID: 9}))
+	assert.Equal(t, "assigned", task.Status)
+	assert.Equal(t, uint(30), *task.AssigneeID)
+	assert.Equal(t, "approved", saved.Status)
+	assert.Equal(t, uint(9), *saved.ApproverID)
+	assert.ElementsMatch(t, []uint{7, 30}, notifications.recipients)
+
+	// 已处理的分配记录不能再次审批
+	err = svc.ApproveAssignment(ctx, 11, &ApproveAssignmentRequest{ApproverID: 9})
+	assert.ErrorIs(t, err, ErrAssignmentNotPending)
+	taskRepo.AssertExpectations(t)
+	employeeRepo.AssertExpectations(t)
+	assignmentRepo.AssertExpectations(t)
+}
+
+func TestSimpleAssignmentApproval_Reject(t *testing.T) {
+	ctx := context.Background()
+	task := &database.Task{BaseModel: database.BaseModel{ID: 1}, Status: "pending"}
+	assignment := &database.Assignment{BaseModel: database.BaseModel{ID: 11}, TaskID: 1, AssigneeID: 3, AssignerID: 7, Status: "pending"}
+	workflowID := "wf-1"
+
+	taskRepo := new(MockTaskRepository)
+	employeeRepo := new(MockEmployeeRepository)
+	assignmentRepo := new(MockAssignmentRepository)
+	taskRepo.On("GetByID", ctx, uint(1)).Return(task, nil)
+	taskRepo.On("Update", ctx, task).Return(nil)
+	assignmentRepo.On("GetByID", ctx, uint(11)).Return(assignment, nil)
+	assignmentRepo.On("GetByID", ctx, uint(12)).Return(&database.Assignment{Status: "pending_approval", WorkflowInstanceID: &workflowID}, nil)
+	assignmentRepo.On("Update", ctx, assignment).Return(nil)
+	svc := &taskServiceRepo{taskRepo: taskRepo, employeeRepo: employeeRepo, assignmentRepo: assignmentRepo}
+
+	require.NoError(t, svc.RejectAssignment(ctx, 11, &RejectAssignmentRequest{Reason: "技能不匹配", ApproverID: 9}))
+	assert.Equal(t, "pending", task.Status)
+	assert.Equal(t, "rejected", assignment.Status)
+	assert.Equal(t, "技能不匹配", assignment.Reason)
+	employeeRepo.AssertNotCalled(t, "UpdateTaskCount", mock.Anything, mock.Anything, mock.Anything)
+
+	// 由审批流程处理的分配记录不能直接审批
+	err := svc.RejectAssignment(ctx, 12, &RejectAssignmentRequest{Reason: "重复", ApproverID: 9})
+	assert.ErrorIs(t, err, ErrAssignmentNotPending)
+}
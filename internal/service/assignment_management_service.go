@@ -2,13 +2,21 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
+	"sort"
+	"strings"
 	"time"
 
 	"taskmanage/internal/assignment"
+	"taskmanage/internal/assignment/algorithms"
 	"taskmanage/internal/database"
 	"taskmanage/internal/repository"
+	"taskmanage/internal/workflow"
 	"taskmanage/pkg/logger"
+	"taskmanage/pkg/i18n"
+	"taskmanage/pkg/response"
 )
 
 // AssignmentManagementService 任务分配管理服务
@@ -19,6 +27,11 @@ type AssignmentManagementService struct {
 	taskRepo            repository.TaskRepository
 	employeeRepo        repository.EmployeeRepository
 	assignmentRepo      repository.AssignmentRepository
+	userRepo            repository.UserRepository
+	skillRepo           repository.SkillRepository
+	projectRepo         repository.ProjectRepository
+	departmentRepo      repository.DepartmentRepository
+	capacityRepo        repository.EmployeeCapacityRepository
 }
 
 // 确保实现了AssignmentService接口
@@ -38,6 +51,11 @@ func NewAssignmentManagementService(
 		taskRepo:            repoManager.TaskRepository(),
 		employeeRepo:        repoManager.EmployeeRepository(),
 		assignmentRepo:      repoManager.AssignmentRepository(),
+		userRepo:            repoManager.UserRepository(),
+		skillRepo:           repoManager.SkillRepository(),
+		projectRepo:         repoManager.ProjectRepository(),
+		departmentRepo:      repoManager.DepartmentRepository(),
+		capacityRepo:        repoManager.EmployeeCapacityRepository(),
 	}
 }
 
@@ -50,16 +68,20 @@ type ManualAssignmentRequest struct {
 	Task            *database.Task
 	Priority        string `json:"priority"`
 	RequireApproval bool   `json:"require_approval"`
+	// AllowNonMember 紧急情况下允许分配给任务所属项目以外的员工，员工会被加入项目并记录分配人
+	AllowNonMember bool `json:"allow_non_member"`
 }
 
 // AssignmentSuggestionRequest 分配建议请求
 type AssignmentSuggestionRequest struct {
 	TaskID         uint     `json:"task_id" binding:"required"`
 	Strategy       string   `json:"strategy"`
-	RequiredSkills []string `json:"required_skills"`
+	RequiredSkills []string `json:"required_skills"` // 在任务已保存的技能要求之外追加的技能名称，等级按1计
 	Department     string   `json:"department"`
 	ExcludeUsers   []uint   `json:"exclude_users"`
 	MaxSuggestions int      `json:"max_suggestions"`
+	// IncludeExpiredSkills 技能匹配时计入认证已到期的技能，默认忽略
+	IncludeExpiredSkills bool `json:"include_expired_skills"`
 }
 
 // GetAssignmentSuggestionsRequest 获取分配建议请求
@@ -96,6 +118,8 @@ type AssignmentSuggestion struct {
 	Workload     WorkloadInfo       `json:"workload"`
 	SkillMatch   float64            `json:"skill_match"`
 	Availability float64            `json:"availability"`
+	// ProjectMember 是否为任务所属项目的成员，任务不属于项目时不返回
+	ProjectMember *bool `json:"project_member,omitempty"`
 }
 
 // WorkloadInfo 工作负载信息
@@ -123,32 +147,54 @@ type AssignmentHistory struct {
 
 // AssignmentApproval 分配审批信息
 type AssignmentApproval struct {
-	Required   bool      `json:"required"`
-	Status     string    `json:"status"`
-	ApprovedBy uint      `json:"approved_by,omitempty"`
-	ApprovedAt time.Time `json:"approved_at,omitempty"`
-	Comment    string    `json:"comment,omitempty"`
-	InstanceID string    `json:"instance_id,omitempty"`
+	Required     bool       `json:"required"`
+	Status       string     `json:"status"` // pending, approved, rejected, returned, cancelled, failed
+	ApprovedBy   uint       `json:"approved_by,omitempty"`
+	ApproverName string     `json:"approver_name,omitempty"`
+	ApprovedAt   *time.Time `json:"approved_at,omitempty"`
+	Comment      string     `json:"comment,omitempty"`
+	InstanceID   string     `json:"instance_id,omitempty"`
 }
 
+// 分配审批状态
+const (
+	AssignmentApprovalPending   = "pending"
+	AssignmentApprovalApproved  = "approved"
+	AssignmentApprovalRejected  = "rejected"
+	AssignmentApprovalReturned  = "returned"
+	AssignmentApprovalCancelled = "cancelled"
+	AssignmentApprovalFailed    = "failed"
+)
+
 // ManualAssign 手动分配任务
 func (s *AssignmentManagementService) ManualAssign(ctx context.Context, req *ManualAssignmentRequest) (*AssignmentHistory, error) {
-	logger.Infof("开始手动分配任务: TaskID=%d, EmployeeID=%d", req.TaskID, req.EmployeeID)
+	logger.FromContext(ctx).Infof("开始手动分配任务: TaskID=%d, EmployeeID=%d", req.TaskID, req.EmployeeID)
 
 	// 验证任务和员工存在性
 	task, err := s.taskRepo.GetByID(ctx, req.TaskID)
 	if err != nil {
-		return nil, fmt.Errorf("任务不存在: %w", err)
+		return nil, taskLookupError(err)
 	}
 
-	_, err = s.employeeRepo.GetByID(ctx, req.EmployeeID)
+	employee, err := s.employeeRepo.GetByID(ctx, req.EmployeeID)
 	if err != nil {
-		return nil, fmt.Errorf("员工不存在: %w", err)
+		return nil, employeeLookupError(err)
+	}
+	if employee.OnboardingStatus == EmployeeStatusOffboarding {
+		return nil, response.NewLocalizedError(response.ErrCodeEmployeeNotAvailable, msgEmployeeOffboarding, i18n.Params{"employee_id": employee.ID})
 	}
 
 	// 检查任务状态
 	if task.Status != "pending" {
-		return nil, fmt.Errorf("只有待分配状态的任务才能进行分配")
+		return nil, taskNotPendingError(msgTaskAssignRequiresPending, task.ID, task.Status)
+	}
+	if err := ensureNoOpenSubtasks(ctx, s.taskRepo, task.ID); err != nil {
+		return nil, err
+	}
+
+	// 属于项目的任务只能分配给项目成员
+	if err := ensureProjectMember(ctx, s.projectRepo, task, employee.ID, req.AllowNonMember, req.AssignedBy); err != nil {
+		return nil, err
 	}
 
 	// 简化冲突检查 - 检查员工是否已有该任务
@@ -163,7 +209,7 @@ func (s *AssignmentManagementService) ManualAssign(ctx context.Context, req *Man
 		AssigneeID: req.EmployeeID,
 		AssignerID: req.AssignedBy,
 		AssignedAt: time.Now(),
-		Method:     "manual",
+		Method:     AssignMethodManual,
 		Status:     "pending",
 		Reason:     req.Reason,
 	}
@@ -173,21 +219,21 @@ func (s *AssignmentManagementService) ManualAssign(ctx context.Context, req *Man
 		// 设置为待审批状态
 		assignment.Status = "pending"
 	} else {
-		// 直接批准分配
+		// 直接批准分配，先占用员工任务名额，并发分配时由仓储保证不超过上限
+		if err := reserveTaskSlot(ctx, s.employeeRepo, req.EmployeeID); err != nil {
+			return nil, err
+		}
 		assignment.Status = "approved"
-		// 更新任务状态和分配人
+		// 更新任务状态和分配人，任务负责人保存员工的用户ID
 		task.Status = "assigned"
-		task.AssigneeID = &req.EmployeeID
+		task.AssigneeID = &employee.UserID
 		if err := s.taskRepo.Update(ctx, task); err != nil {
+			releaseTaskSlot(ctx, s.employeeRepo, req.EmployeeID)
+			if errors.Is(err, repository.ErrConflict) {
+				return nil, taskConflictError(ctx, s.taskRepo, task.ID)
+			}
 			return nil, fmt.Errorf("更新任务分配失败: %w", err)
 		}
-
-		// 更新员工当前任务数
-		employee, err := s.employeeRepo.GetByID(ctx, req.EmployeeID)
-		if err == nil && employee != nil {
-			employee.CurrentTasks++
-			s.employeeRepo.Update(ctx, employee)
-		}
 	}
 
 	if err := s.assignmentRepo.Create(ctx, assignment); err != nil {
@@ -196,9 +242,9 @@ func (s *AssignmentManagementService) ManualAssign(ctx context.Context, req *Man
 
 	// 创建任务分配通知
 	if s.notificationService != nil {
-		err := s.notificationService.CreateTaskAssignmentNotification(ctx, req.TaskID, req.EmployeeID, req.AssignedBy)
+		err := s.notificationService.CreateTaskAssignmentNotification(ctx, req.TaskID, employee.UserID, req.AssignedBy)
 		if err != nil {
-			logger.Errorf("创建任务分配通知失败: %v", err)
+			logger.FromContext(ctx).Errorf("创建任务分配通知失败: %v", err)
 			// 不中断流程，只记录错误
 		}
 	}
@@ -209,7 +255,7 @@ func (s *AssignmentManagementService) ManualAssign(ctx context.Context, req *Man
 		return nil, fmt.Errorf("构建分配历史失败: %w", err)
 	}
 
-	logger.Infof("手动分配任务成功: TaskID=%d, EmployeeID=%d, Status=%s",
+	logger.FromContext(ctx).Infof("手动分配任务成功: TaskID=%d, EmployeeID=%d, Status=%s",
 		req.TaskID, req.EmployeeID, assignment.Status)
 
 	return history, nil
@@ -217,7 +263,13 @@ func (s *AssignmentManagementService) ManualAssign(ctx context.Context, req *Man
 
 // GetAssignmentSuggestions 获取分配建议
 func (s *AssignmentManagementService) GetAssignmentSuggestions(ctx context.Context, req *AssignmentSuggestionRequest) ([]*AssignmentSuggestion, error) {
-	logger.Infof("获取分配建议: TaskID=%d, Strategy=%s", req.TaskID, req.Strategy)
+	logger.FromContext(ctx).Infof("获取分配建议: TaskID=%d, Strategy=%s", req.TaskID, req.Strategy)
+
+	if req.Strategy != "" {
+		if err := s.assignmentService.ValidateStrategy(assignment.AssignmentStrategy(req.Strategy)); err != nil {
+			return nil, err
+		}
+	}
 
 	// 获取任务信息
 	task, err := s.taskRepo.GetByID(ctx, req.TaskID)
@@ -225,15 +277,23 @@ func (s *AssignmentManagementService) GetAssignmentSuggestions(ctx context.Conte
 		return nil, fmt.Errorf("获取任务失败: %w", err)
 	}
 
+	// 加载任务技能要求
+	requirements, err := s.loadSkillRequirements(ctx, req.TaskID, req.RequiredSkills)
+	if err != nil {
+		return nil, err
+	}
+
 	// 构建分配请求
 	assignmentReq := &assignment.AssignmentRequest{
 		TaskID:           req.TaskID,
 		Strategy:         assignment.AssignmentStrategy(req.Strategy),
-		RequiredSkills:   s.convertSkillRequirements(req.RequiredSkills),
+		RequiredSkills:   requirements,
 		Department:       req.Department,
 		Priority:         task.Priority,
 		Deadline:         task.DueDate,
 		ExcludeEmployees: req.ExcludeUsers,
+
+		IncludeExpiredSkills: req.IncludeExpiredSkills,
 	}
 
 	// 获取候选人
@@ -242,66 +302,125 @@ func (s *AssignmentManagementService) GetAssignmentSuggestions(ctx context.Conte
 		return nil, fmt.Errorf("获取候选人失败: %w", err)
 	}
 
+	// 标注候选人是否为项目成员，非成员只能通过allow_non_member手动分配
+	members, err := projectMemberSet(ctx, s.projectRepo, task)
+	if err != nil {
+		return nil, err
+	}
+
+	// 按技能等级差距和工作负载评分，评分高的排在前面
+	suggestions := make([]*AssignmentSuggestion, 0, len(candidates))
+	for _, candidate := range candidates {
+		suggestion := buildAssignmentSuggestion(candidate, requirements)
+		annotateProjectMember(suggestion, members)
+		suggestions = append(suggestions, suggestion)
+	}
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return suggestions[i].Score > suggestions[j].Score
+	})
+
 	// 限制建议数量
 	maxSuggestions := req.MaxSuggestions
 	if maxSuggestions == 0 || maxSuggestions > 10 {
 		maxSuggestions = 5
 	}
-	if len(candidates) > maxSuggestions {
-		candidates = candidates[:maxSuggestions]
-	}
-
-	// 构建建议列表
-	suggestions := make([]*AssignmentSuggestion, len(candidates))
-	for i, candidate := range candidates {
-		suggestions[i] = &AssignmentSuggestion{
-			Employee:   &candidate.Employee,
-			Score:      candidate.Score,
-			Reason:     fmt.Sprintf("匹配度: %.2f, 工作负载: %d/%d", candidate.Score, candidate.Workload.CurrentTasks, candidate.Workload.MaxTasks),
-			Confidence: candidate.Score,
-			Workload: WorkloadInfo{
-				CurrentTasks:    candidate.Workload.CurrentTasks,
-				MaxTasks:        candidate.Workload.MaxTasks,
-				UtilizationRate: candidate.Workload.UtilizationRate,
-				AvgTaskDuration: int64(candidate.Workload.AvgTaskDuration / time.Hour),
-			},
-			SkillMatch:   candidate.Score,                            // 技能匹配度
-			Availability: 100.0 - candidate.Workload.UtilizationRate, // 可用性百分比
-		}
+	if len(suggestions) > maxSuggestions {
+		suggestions = suggestions[:maxSuggestions]
 	}
 
-	logger.Infof("获取到 %d 个分配建议", len(suggestions))
+	logger.FromContext(ctx).Infof("获取到 %d 个分配建议", len(suggestions))
 	return suggestions, nil
 }
 
-// GetAssignmentHistory 获取分配历史
-func (s *AssignmentManagementService) GetAssignmentHistory(ctx context.Context, taskID uint) ([]*AssignmentHistory, error) {
-	logger.Infof("获取任务分配历史: TaskID=%d", taskID)
+// annotateProjectMember 标注建议的员工是否为项目成员，members为nil表示任务不属于项目
+func annotateProjectMember(suggestion *AssignmentSuggestion, members map[uint]bool) {
+	if members == nil {
+		return
+	}
+	isMember := members[suggestion.Employee.ID]
+	suggestion.ProjectMember = &isMember
+}
+
+// buildAssignmentSuggestion 根据技能等级差距和工作负载生成分配建议
+// 评分 = 技能匹配度 × 70% + 可用性 × 30%
+func buildAssignmentSuggestion(candidate assignment.AssignmentCandidate, requirements []assignment.SkillRequirement) *AssignmentSuggestion {
+	match := assignment.MatchSkillLevels(requirements, candidate.SkillLevels)
+	skillMatch := 100.0
+	if match.Total > 0 {
+		skillMatch = match.Score()
+	}
+	availability := math.Max(0, math.Min(100, (1-candidate.Workload.UtilizationRate)*100))
+	score := skillMatch*0.7 + availability*0.3
+
+	return &AssignmentSuggestion{
+		Employee:   &candidate.Employee,
+		Score:      score,
+		Reason:     fmt.Sprintf("%s, 工作负载: %d/%d", describeSkillMatch(match, requirements), candidate.Workload.CurrentTasks, candidate.Workload.MaxTasks),
+		Confidence: score,
+		Workload: WorkloadInfo{
+			CurrentTasks:    candidate.Workload.CurrentTasks,
+			MaxTasks:        candidate.Workload.MaxTasks,
+			UtilizationRate: candidate.Workload.UtilizationRate,
+			AvgTaskDuration: int64(candidate.Workload.AvgTaskDuration / time.Hour),
+		},
+		SkillMatch:   skillMatch,
+		Availability: availability,
+	}
+}
+
+// describeSkillMatch 描述技能达标情况，列出未达标技能的要求等级与当前等级
+func describeSkillMatch(match algorithms.SkillMatchResult, requirements []assignment.SkillRequirement) string {
+	if match.Total == 0 {
+		return "无技能要求"
+	}
 
-	// 获取所有分配记录
-	assignments, err := s.assignmentRepo.GetAssignmentHistory(ctx, taskID)
+	text := fmt.Sprintf("技能达标: %d/%d, 等级差距: %d", match.Matched, match.Total, match.LevelGap)
+	if len(match.Gaps) == 0 {
+		return text
+	}
+
+	names := make(map[uint]string, len(requirements))
+	for _, requirement := range requirements {
+		names[requirement.SkillID] = requirement.SkillName
+	}
+	gaps := make([]string, len(match.Gaps))
+	for i, gap := range match.Gaps {
+		name := names[gap.SkillID]
+		if name == "" {
+			name = fmt.Sprintf("技能%d", gap.SkillID)
+		}
+		gaps[i] = fmt.Sprintf("%s 需要%d级/当前%d级", name, gap.RequiredLevel, gap.ActualLevel)
+	}
+	return fmt.Sprintf("%s (%s)", text, strings.Join(gaps, "; "))
+}
+
+// GetAssignmentHistory 分页获取分配历史，返回当前页记录和总数
+func (s *AssignmentManagementService) GetAssignmentHistory(ctx context.Context, taskID uint, page, pageSize int) ([]*AssignmentHistory, int64, error) {
+	logger.FromContext(ctx).Infof("获取任务分配历史: TaskID=%d, Page=%d, PageSize=%d", taskID, page, pageSize)
+
+	assignments, total, err := s.assignmentRepo.GetAssignmentHistory(ctx, taskID, page, pageSize)
 	if err != nil {
-		return nil, fmt.Errorf("获取分配历史失败: %w", err)
+		return nil, 0, fmt.Errorf("获取分配历史失败: %w", err)
 	}
 
 	// 转换为历史记录格式
-	historyList := make([]*AssignmentHistory, len(assignments))
-	for i, assignment := range assignments {
+	historyList := make([]*AssignmentHistory, 0, len(assignments))
+	for _, assignment := range assignments {
 		history, err := s.buildAssignmentHistory(ctx, assignment)
 		if err != nil {
-			logger.Errorf("构建分配历史失败: %v", err)
+			logger.FromContext(ctx).Errorf("构建分配历史失败: %v", err)
 			continue
 		}
-		historyList[i] = history
+		historyList = append(historyList, history)
 	}
 
-	logger.Infof("获取到 %d 条分配历史记录", len(historyList))
-	return historyList, nil
+	logger.FromContext(ctx).Infof("获取到 %d 条分配历史记录，共 %d 条", len(historyList), total)
+	return historyList, total, nil
 }
 
 // ReassignTask 重新分配任务
 func (s *AssignmentManagementService) ReassignTask(ctx context.Context, taskID uint, newEmployeeID uint, reason string, assignedBy uint) error {
-	logger.Infof("重新分配任务: TaskID=%d, NewEmployeeID=%d", taskID, newEmployeeID)
+	logger.FromContext(ctx).Infof("重新分配任务: TaskID=%d, NewEmployeeID=%d", taskID, newEmployeeID)
 
 	// 获取当前分配
 	currentAssignment, err := s.assignmentRepo.GetActiveByTaskID(ctx, taskID)
@@ -333,13 +452,13 @@ func (s *AssignmentManagementService) ReassignTask(ctx context.Context, taskID u
 		return fmt.Errorf("创建新分配失败: %w", err)
 	}
 
-	logger.Infof("任务重新分配成功: TaskID=%d", taskID)
+	logger.FromContext(ctx).Infof("任务重新分配成功: TaskID=%d", taskID)
 	return nil
 }
 
 // CancelAssignment 取消分配
 func (s *AssignmentManagementService) CancelAssignment(ctx context.Context, taskID uint, reason string, cancelledBy uint) error {
-	logger.Infof("取消任务分配: TaskID=%d", taskID)
+	logger.FromContext(ctx).Infof("取消任务分配: TaskID=%d", taskID)
 
 	assignment, err := s.assignmentRepo.GetActiveByTaskID(ctx, taskID)
 	if err != nil {
@@ -349,7 +468,7 @@ func (s *AssignmentManagementService) CancelAssignment(ctx context.Context, task
 	// 如果有审批流程，取消审批 (暂时略过，待实现)
 	// if s.workflowService != nil {
 	//	if err := s.workflowService.CancelWorkflow(ctx, assignment.ApprovalInstanceID, reason); err != nil {
-	//		logger.Errorf("取消审批流程失败: %v", err)
+	//		logger.FromContext(ctx).Errorf("取消审批流程失败: %v", err)
 	//	}
 	// }
 
@@ -368,7 +487,7 @@ func (s *AssignmentManagementService) CancelAssignment(ctx context.Context, task
 		return fmt.Errorf("更新任务状态失败: %w", err)
 	}
 
-	logger.Infof("任务分配取消成功: TaskID=%d", taskID)
+	logger.FromContext(ctx).Infof("任务分配取消成功: TaskID=%d", taskID)
 	return nil
 }
 
@@ -399,19 +518,89 @@ func (s *AssignmentManagementService) buildAssignmentHistory(ctx context.Context
 		Reason:       assignment.Reason,
 	}
 
-	// 如果有审批信息 (暂时略过，待实现)
-	// if assignment.ApprovalInstanceID != "" {
-	//	approvalInfo, err := s.buildApprovalInfo(ctx, assignment.ApprovalInstanceID)
-	//	if err != nil {
-	//		logger.Errorf("构建审批信息失败: %v", err)
-	//	} else {
-	//		history.ApprovalInfo = approvalInfo
-	//	}
-	// }
+	// 关联了审批流程时补充审批信息，失败不影响历史记录返回
+	if assignment.WorkflowInstanceID != nil && *assignment.WorkflowInstanceID != "" {
+		approvalInfo, err := s.buildApprovalInfo(ctx, *assignment.WorkflowInstanceID)
+		if err != nil {
+			logger.FromContext(ctx).Errorf("构建审批信息失败: %v", err)
+		} else {
+			history.ApprovalInfo = approvalInfo
+		}
+	}
 
 	return history, nil
 }
 
+// buildApprovalInfo 根据工作流实例状态和审批节点的执行历史构建审批信息
+func (s *AssignmentManagementService) buildApprovalInfo(ctx context.Context, instanceID string) (*AssignmentApproval, error) {
+	if s.workflowService == nil {
+		return nil, fmt.Errorf("工作流服务不可用")
+	}
+
+	instance, err := s.workflowService.GetWorkflowInstance(ctx, instanceID)
+	if err != nil {
+		return nil, fmt.Errorf("获取流程实例失败: %w", err)
+	}
+
+	approval := &AssignmentApproval{
+		Required:   true,
+		InstanceID: instanceID,
+	}
+
+	switch instance.Status {
+	case workflow.StatusCancelled:
+		approval.Status = AssignmentApprovalCancelled
+		return approval, nil
+	case workflow.StatusRunning, workflow.StatusSuspended:
+		approval.Status = AssignmentApprovalPending
+		return approval, nil
+	}
+
+	history, err := s.workflowService.GetWorkflowHistory(ctx, instanceID)
+	if err != nil {
+		return nil, fmt.Errorf("获取流程历史失败: %w", err)
+	}
+
+	// 取最后一条审批决策作为审批结果
+	var decision *workflow.ExecutionHistory
+	for i := range history {
+		switch workflow.ApprovalAction(history[i].Action) {
+		case workflow.ActionApprove, workflow.ActionReject, workflow.ActionReturn:
+			decision = &history[i]
+		}
+	}
+
+	if instance.Status == workflow.StatusFailed {
+		approval.Status = AssignmentApprovalFailed
+	} else {
+		approval.Status = AssignmentApprovalApproved
+	}
+	if decision == nil {
+		return approval, nil
+	}
+
+	switch workflow.ApprovalAction(decision.Action) {
+	case workflow.ActionReject:
+		approval.Status = AssignmentApprovalRejected
+	case workflow.ActionReturn:
+		approval.Status = AssignmentApprovalReturned
+	}
+	approvedAt := decision.ExecutedAt
+	approval.ApprovedBy = decision.ExecutedBy
+	approval.ApprovedAt = &approvedAt
+	approval.Comment = decision.Comment
+
+	if s.userRepo != nil && decision.ExecutedBy != 0 {
+		if approver, err := s.userRepo.GetByID(ctx, decision.ExecutedBy); err == nil {
+			approval.ApproverName = approver.RealName
+		} else {
+			logger.FromContext(ctx).Warnf("获取审批人信息失败: %d, error: %v", decision.ExecutedBy, err)
+		}
+	}
+
+	return approval, nil
+}
+
 // hasTimeConflict 检查时间冲突
 func (s *AssignmentManagementService) hasTimeConflict(task1, task2 *database.Task) bool {
 	// 简化的时间冲突检查
@@ -434,24 +623,54 @@ func (s *AssignmentManagementService) hasRequiredSkills(employee *database.Emplo
 	return true
 }
 
-// convertSkillRequirements 转换技能需求格式
-func (s *AssignmentManagementService) convertSkillRequirements(skillNames []string) []assignment.SkillRequirement {
-	// 简化实现，实际应该根据技能名称查询技能ID
-	skillReqs := make([]assignment.SkillRequirement, len(skillNames))
-	for i, skillName := range skillNames {
-		// 这里使用简化的方式，实际应该查询数据库获取技能ID
-		skillReqs[i] = assignment.SkillRequirement{
-			SkillID:  uint(i + 1), // 使用索引+1作为临时ID
-			MinLevel: 1,           // 默认最低等级
+// loadSkillRequirements 加载任务保存的技能要求，extraNames为额外要求的技能名称（等级按1计，不存在的技能忽略）
+func (s *AssignmentManagementService) loadSkillRequirements(ctx context.Context, taskID uint, extraNames []string) ([]assignment.SkillRequirement, error) {
+	details, err := s.taskRepo.GetSkillRequirements(ctx, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("获取任务技能要求失败: %w", err)
+	}
+
+	requirements := make([]assignment.SkillRequirement, 0, len(details)+len(extraNames))
+	seen := make(map[uint]bool, len(details))
+	for _, detail := range details {
+		requirements = append(requirements, assignment.SkillRequirement{
+			SkillID:   detail.SkillID,
+			SkillName: detail.Name,
+			MinLevel:  detail.Level,
+		})
+		seen[detail.SkillID] = true
+	}
+
+	for _, name := range extraNames {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		skill, err := s.skillRepo.GetByName(ctx, name)
+		if err != nil {
+			if repository.IsNotFoundError(err) {
+				logger.FromContext(ctx).Warnf("忽略不存在的技能要求: %s", name)
+				continue
+			}
+			return nil, fmt.Errorf("查询技能失败: %w", err)
+		}
+		if seen[skill.ID] {
+			continue
 		}
-		_ = skillName // 避免未使用变量警告
+		requirements = append(requirements, assignment.SkillRequirement{
+			SkillID:   skill.ID,
+			SkillName: skill.Name,
+			MinLevel:  1,
+		})
+		seen[skill.ID] = true
 	}
-	return skillReqs
+
+	return requirements, nil
 }
 
 // AutoAssign 自动分配任务
 func (s *AssignmentManagementService) AutoAssign(ctx context.Context, taskID uint, strategy string) (*AssignmentResponse, error) {
-	logger.Infof("开始自动分配任务: TaskID=%d, Strategy=%s", taskID, strategy)
+	logger.FromContext(ctx).Infof("开始自动分配任务: TaskID=%d, Strategy=%s", taskID, strategy)
 
 	// 获取任务信息
 	task, err := s.taskRepo.GetByID(ctx, taskID)
@@ -463,12 +682,38 @@ func (s *AssignmentManagementService) AutoAssign(ctx context.Context, taskID uin
 	if task.Status != "pending" {
 		return nil, fmt.Errorf("只有待分配状态的任务才能进行自动分配")
 	}
+	if err := ensureNoOpenSubtasks(ctx, s.taskRepo, task.ID); err != nil {
+		return nil, err
+	}
+
+	// 未指定策略时使用任务所属部门的默认策略
+	strategy, err = resolveAssignmentStrategy(ctx, strategy, task, s.projectRepo, s.employeeRepo, s.departmentRepo)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.assignmentService.ValidateStrategy(assignment.AssignmentStrategy(strategy)); err != nil {
+		return nil, err
+	}
+
+	// 加载任务技能要求
+	requirements, err := s.loadSkillRequirements(ctx, taskID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// 属于项目的任务只在项目成员中选择
+	memberIDs, err := projectMemberIDs(ctx, s.projectRepo, task)
+	if err != nil {
+		return nil, err
+	}
 
 	// 构建分配请求
 	req := &assignment.AssignmentRequest{
-		TaskID:   taskID,
-		Strategy: assignment.AssignmentStrategy(strategy),
-		Priority: task.Priority,
+		TaskID:         taskID,
+		Strategy:       assignment.AssignmentStrategy(strategy),
+		RequiredSkills: requirements,
+		Priority:       task.Priority,
+		OnlyEmployees:  memberIDs,
 	}
 
 	if task.DueDate != nil {
@@ -480,6 +725,9 @@ func (s *AssignmentManagementService) AutoAssign(ctx context.Context, taskID uin
 	if err != nil {
 		return nil, fmt.Errorf("自动分配失败: %w", err)
 	}
+	if result.SelectedEmployee.OnboardingStatus == EmployeeStatusOffboarding {
+		return nil, fmt.Errorf("自动分配选中了正在办理离职的员工")
+	}
 
 	// 创建分配记录
 	assignmentRecord := &database.Assignment{
@@ -492,7 +740,13 @@ func (s *AssignmentManagementService) AutoAssign(ctx context.Context, taskID uin
 		Reason:     result.Reason,
 	}
 
+	// 先占用员工任务名额，并发分配时由仓储保证不超过上限
+	if err := reserveTaskSlot(ctx, s.employeeRepo, result.SelectedEmployee.ID); err != nil {
+		return nil, err
+	}
+
 	if err := s.assignmentRepo.Create(ctx, assignmentRecord); err != nil {
+		releaseTaskSlot(ctx, s.employeeRepo, result.SelectedEmployee.ID)
 		return nil, fmt.Errorf("保存分配记录失败: %w", err)
 	}
 
@@ -501,18 +755,15 @@ func (s *AssignmentManagementService) AutoAssign(ctx context.Context, taskID uin
 	task.AssigneeID = &result.SelectedEmployee.UserID
 
 	if err := s.taskRepo.Update(ctx, task); err != nil {
-		logger.Errorf("更新任务分配失败: %v", err)
+		releaseTaskSlot(ctx, s.employeeRepo, result.SelectedEmployee.ID)
+		if errors.Is(err, repository.ErrConflict) {
+			return nil, taskConflictError(ctx, s.taskRepo, task.ID)
+		}
+		logger.FromContext(ctx).Errorf("更新任务分配失败: %v", err)
 		return nil, fmt.Errorf("更新任务分配失败: %w", err)
 	}
 
-	// 更新员工当前任务数
-	employee, err := s.employeeRepo.GetByID(ctx, result.SelectedEmployee.ID)
-	if err == nil && employee != nil {
-		employee.CurrentTasks++
-		s.employeeRepo.Update(ctx, employee)
-	}
-
-	logger.Infof("自动分配任务成功: TaskID=%d, EmployeeID=%d", taskID, result.SelectedEmployee.ID)
+	logger.FromContext(ctx).Infof("自动分配任务成功: TaskID=%d, EmployeeID=%d, Strategy=%s", taskID, result.SelectedEmployee.ID, strategy)
 
 	// 返回分配响应
 	return &AssignmentResponse{
@@ -528,7 +779,7 @@ func (s *AssignmentManagementService) AutoAssign(ctx context.Context, taskID uin
 
 // CheckAssignmentConflicts 检查分配冲突
 func (s *AssignmentManagementService) CheckAssignmentConflicts(ctx context.Context, taskID uint, employeeID uint) ([]*AssignmentConflict, error) {
-	logger.Infof("检查分配冲突: TaskID=%d, EmployeeID=%d", taskID, employeeID)
+	logger.FromContext(ctx).Infof("检查分配冲突: TaskID=%d, EmployeeID=%d", taskID, employeeID)
 
 	conflicts := []*AssignmentConflict{}
 
@@ -545,19 +796,32 @@ func (s *AssignmentManagementService) CheckAssignmentConflicts(ctx context.Conte
 		}
 	}
 
-	// 检查员工工作负载
+	// 检查员工在任务截止日期前的有效容量
 	employee, err := s.employeeRepo.GetByID(ctx, employeeID)
 	if err == nil && employee != nil {
-		if employee.CurrentTasks >= employee.MaxTasks {
+		var deadline *time.Time
+		if task, err := s.taskRepo.GetByID(ctx, taskID); err == nil && task != nil {
+			deadline = task.DueDate
+		}
+		effectiveMaxTasks, factor, err := effectiveCapacity(ctx, s.capacityRepo, employee, deadline, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		if employee.CurrentTasks >= effectiveMaxTasks {
+			description := fmt.Sprintf("员工工作负载已满 (%d/%d)", employee.CurrentTasks, effectiveMaxTasks)
+			if factor < 1 {
+				description = fmt.Sprintf("员工工作负载已满 (%d/%d，最大任务数%d按容量系数%.2f折算)",
+					employee.CurrentTasks, effectiveMaxTasks, employee.MaxTasks, factor)
+			}
 			conflicts = append(conflicts, &AssignmentConflict{
 				Type:        "workload_exceeded",
-				Description: fmt.Sprintf("员工工作负载已满 (%d/%d)", employee.CurrentTasks, employee.MaxTasks),
+				Description: description,
 				Severity:    "medium",
 				TaskID:      taskID,
 			})
 		}
 	}
 
-	logger.Infof("冲突检查完成: 发现 %d 个冲突", len(conflicts))
+	logger.FromContext(ctx).Infof("冲突检查完成: 发现 %d 个冲突", len(conflicts))
 	return conflicts, nil
 }
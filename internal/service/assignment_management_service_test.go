@@ -59,16 +59,102 @@ func (m *MockTaskRepository) UpdateAssignee(ctx context.Context, taskID, employe
 	return args.Error(0)
 }
 
+func (m *MockTaskRepository) GetByProject(ctx context.Context, projectID uint, filters map[string]interface{}) ([]*database.Task, error) {
+	args := m.Called(ctx, projectID, filters)
+	return args.Get(0).([]*database.Task), args.Error(1)
+}
+
+func (m *MockTaskRepository) GetProjectTimelineTasks(ctx context.Context, projectID uint) ([]*database.Task, error) {
+	args := m.Called(ctx, projectID)
+	return args.Get(0).([]*database.Task), args.Error(1)
+}
+
+func (m *MockTaskRepository) GetProjectRemainingEstimate(ctx context.Context, projectID uint, at time.Time) (float64, error) {
+	args := m.Called(ctx, projectID, at)
+	return args.Get(0).(float64), args.Error(1)
+}
+
+func (m *MockTaskRepository) SumProjectEstimatesByCreatedSlot(ctx context.Context, projectID uint, from, to time.Time) ([]repository.TimeSlotSum, error) {
+	args := m.Called(ctx, projectID, from, to)
+	return args.Get(0).([]repository.TimeSlotSum), args.Error(1)
+}
+
+func (m *MockTaskRepository) SumProjectEstimatesByCompletedSlot(ctx context.Context, projectID uint, from, to time.Time) ([]repository.TimeSlotSum, error) {
+	args := m.Called(ctx, projectID, from, to)
+	return args.Get(0).([]repository.TimeSlotSum), args.Error(1)
+}
+
+func (m *MockTaskRepository) SumProjectUntrackedHoursByCompletedSlot(ctx context.Context, projectID uint, from, to time.Time) ([]repository.TimeSlotSum, error) {
+	args := m.Called(ctx, projectID, from, to)
+	return args.Get(0).([]repository.TimeSlotSum), args.Error(1)
+}
+
+func (m *MockTaskRepository) CountByProjectAndStatus(ctx context.Context, projectID uint, status string) (int64, error) {
+	args := m.Called(ctx, projectID, status)
+	return args.Get(0).(int64), args.Error(1)
+}
+
 func (m *MockTaskRepository) UpdateStatus(ctx context.Context, taskID uint, status string) error {
 	args := m.Called(ctx, taskID, status)
 	return args.Error(0)
 }
 
+func (m *MockTaskRepository) UpdatePriority(ctx context.Context, taskID uint, priority string) error {
+	args := m.Called(ctx, taskID, priority)
+	return args.Error(0)
+}
+
 func (m *MockTaskRepository) GetActiveTasksByEmployee(ctx context.Context, employeeID uint) ([]*database.Task, error) {
 	args := m.Called(ctx, employeeID)
 	return args.Get(0).([]*database.Task), args.Error(1)
 }
 
+func (m *MockTaskRepository) CountByAssigneeGroupByStatus(ctx context.Context, assigneeID uint) (map[string]int64, error) {
+	args := m.Called(ctx, assigneeID)
+	return args.Get(0).(map[string]int64), args.Error(1)
+}
+
+func (m *MockTaskRepository) ListOpenByAssignee(ctx context.Context, filter *repository.OpenTaskFilter) ([]*database.Task, int64, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]*database.Task), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockTaskRepository) ListTasksWithUnknownAssignee(ctx context.Context) ([]*database.Task, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*database.Task), args.Error(1)
+}
+
+// CountSubTasks 未设置预期时视为任务没有子任务
+func (m *MockTaskRepository) CountSubTasks(ctx context.Context, parentIDs []uint) (map[uint]*repository.SubTaskCounts, error) {
+	for _, call := range m.ExpectedCalls {
+		if call.Method == "CountSubTasks" {
+			args := m.Called(ctx, parentIDs)
+			return args.Get(0).(map[uint]*repository.SubTaskCounts), args.Error(1)
+		}
+	}
+	return map[uint]*repository.SubTaskCounts{}, nil
+}
+
+func (m *MockTaskRepository) CancelTree(ctx context.Context, taskID uint, cancelledAt time.Time) ([]*database.Task, error) {
+	args := m.Called(ctx, taskID, cancelledAt)
+	return args.Get(0).([]*database.Task), args.Error(1)
+}
+
+func (m *MockTaskRepository) FindMissingIDs(ctx context.Context, taskIDs []uint) ([]uint, error) {
+	args := m.Called(ctx, taskIDs)
+	return args.Get(0).([]uint), args.Error(1)
+}
+
+func (m *MockTaskRepository) GetArchivedByID(ctx context.Context, taskID uint) (*database.TaskArchive, error) {
+	args := m.Called(ctx, taskID)
+	return args.Get(0).(*database.TaskArchive), args.Error(1)
+}
+
+func (m *MockTaskRepository) FindArchivedIDs(ctx context.Context, taskIDs []uint) ([]uint, error) {
+	args := m.Called(ctx, taskIDs)
+	return args.Get(0).([]uint), args.Error(1)
+}
+
 func (m *MockTaskRepository) AssignTask(ctx context.Context, taskID, employeeID uint) error {
 	args := m.Called(ctx, taskID, employeeID)
 	return args.Error(0)
@@ -139,6 +225,16 @@ func (m *MockTaskRepository) List(ctx context.Context, filter repository.ListFil
 	return args.Get(0).([]*database.Task), args.Get(1).(int64), args.Error(2)
 }
 
+func (m *MockTaskRepository) GetSkillRequirements(ctx context.Context, taskID uint) ([]*database.TaskSkillDetail, error) {
+	args := m.Called(ctx, taskID)
+	return args.Get(0).([]*database.TaskSkillDetail), args.Error(1)
+}
+
+func (m *MockTaskRepository) ReplaceSkills(ctx context.Context, taskID uint, skills []*database.TaskSkill) error {
+	args := m.Called(ctx, taskID, skills)
+	return args.Error(0)
+}
+
 // MockEmployeeRepository 模拟员工仓库
 type MockEmployeeRepository struct {
 	mock.Mock
@@ -194,6 +290,16 @@ func (m *MockEmployeeRepository) GetByEmployeeNo(ctx context.Context, employeeNo
 	return args.Get(0).(*database.Employee), args.Error(1)
 }
 
+func (m *MockEmployeeRepository) EmployeeNoExists(ctx context.Context, employeeNo string) (bool, error) {
+	args := m.Called(ctx, employeeNo)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockEmployeeRepository) ListEmployeeNos(ctx context.Context) ([]*database.Employee, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*database.Employee), args.Error(1)
+}
+
 func (m *MockEmployeeRepository) GetBySkills(ctx context.Context, skillIDs []uint, minLevel int) ([]*database.Employee, error) {
 	args := m.Called(ctx, skillIDs, minLevel)
 	return args.Get(0).([]*database.Employee), args.Error(1)
@@ -214,6 +320,11 @@ func (m *MockEmployeeRepository) List(ctx context.Context, filter repository.Lis
 	return args.Get(0).([]*database.Employee), args.Get(1).(int64), args.Error(2)
 }
 
+func (m *MockEmployeeRepository) ListEmployeesWithUserAndDepartment(ctx context.Context, filter repository.ListFilter) ([]*database.Employee, int64, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]*database.Employee), args.Get(1).(int64), args.Error(2)
+}
+
 func (m *MockEmployeeRepository) UpdateStatus(ctx context.Context, id uint, status string) error {
 	args := m.Called(ctx, id, status)
 	return args.Error(0)
@@ -224,6 +335,71 @@ func (m *MockEmployeeRepository) UpdateTaskCount(ctx context.Context, id uint, c
 	return args.Error(0)
 }
 
+func (m *MockEmployeeRepository) CountActiveTasks(ctx context.Context, employeeIDs []uint) (map[uint]int, error) {
+	args := m.Called(ctx, employeeIDs)
+	return args.Get(0).(map[uint]int), args.Error(1)
+}
+
+func (m *MockEmployeeRepository) ListForWorkload(ctx context.Context, filter *repository.WorkloadFilter) ([]*database.Employee, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]*database.Employee), args.Error(1)
+}
+
+func (m *MockEmployeeRepository) AggregateTaskStats(ctx context.Context, filter *repository.WorkloadFilter, now time.Time) (map[uint]*repository.EmployeeTaskStats, error) {
+	args := m.Called(ctx, filter, now)
+	return args.Get(0).(map[uint]*repository.EmployeeTaskStats), args.Error(1)
+}
+
+func (m *MockEmployeeRepository) CorrectTaskCount(ctx context.Context, employeeID uint, expected, count int) error {
+	args := m.Called(ctx, employeeID, expected, count)
+	return args.Error(0)
+}
+
+func (m *MockEmployeeRepository) MoveToDepartment(ctx context.Context, fromDepartmentID, toDepartmentID uint) (int64, error) {
+	args := m.Called(ctx, fromDepartmentID, toDepartmentID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockEmployeeRepository) MoveToPosition(ctx context.Context, fromPositionID, toPositionID uint) (int64, error) {
+	args := m.Called(ctx, fromPositionID, toPositionID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockEmployeeRepository) AssignDirectManager(ctx context.Context, departmentID, managerID uint) (int64, error) {
+	args := m.Called(ctx, departmentID, managerID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockEmployeeRepository) ListProbationEnding(ctx context.Context, before time.Time) ([]*database.Employee, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).([]*database.Employee), args.Error(1)
+}
+
+func (m *MockEmployeeRepository) MarkProbationReminded(ctx context.Context, employeeID uint, remindedAt time.Time) error {
+	args := m.Called(ctx, employeeID, remindedAt)
+	return args.Error(0)
+}
+
+func (m *MockEmployeeRepository) ListTenured(ctx context.Context, hiredBefore, confirmedBefore *time.Time) ([]*database.Employee, error) {
+	args := m.Called(ctx, hiredBefore, confirmedBefore)
+	return args.Get(0).([]*database.Employee), args.Error(1)
+}
+
+func (m *MockEmployeeRepository) ListOnboarding(ctx context.Context, filter *repository.OnboardingWorkflowFilter) ([]*database.Employee, int64, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]*database.Employee), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockEmployeeRepository) ListManagerChain(ctx context.Context, employeeID uint, maxLevels int) ([]*database.Employee, error) {
+	args := m.Called(ctx, employeeID, maxLevels)
+	return args.Get(0).([]*database.Employee), args.Error(1)
+}
+
+func (m *MockEmployeeRepository) ListReports(ctx context.Context, employeeID uint, maxDepth int) ([]*database.Employee, error) {
+	args := m.Called(ctx, employeeID, maxDepth)
+	return args.Get(0).([]*database.Employee), args.Error(1)
+}
+
 // MockAssignmentRepository 模拟分配仓库
 type MockAssignmentRepository struct {
 	mock.Mock
@@ -244,9 +420,9 @@ func (m *MockAssignmentRepository) GetByTaskID(ctx context.Context, taskID uint)
 	return args.Get(0).([]*database.Assignment), args.Error(1)
 }
 
-func (m *MockAssignmentRepository) GetAssignmentHistory(ctx context.Context, taskID uint) ([]*database.Assignment, error) {
-	args := m.Called(ctx, taskID)
-	return args.Get(0).([]*database.Assignment), args.Error(1)
+func (m *MockAssignmentRepository) GetAssignmentHistory(ctx context.Context, taskID uint, page, pageSize int) ([]*database.Assignment, int64, error) {
+	args := m.Called(ctx, taskID, page, pageSize)
+	return args.Get(0).([]*database.Assignment), args.Get(1).(int64), args.Error(2)
 }
 
 func (m *MockAssignmentRepository) GetActiveByTaskID(ctx context.Context, taskID uint) (*database.Assignment, error) {
@@ -299,6 +475,26 @@ func (m *MockAssignmentRepository) List(ctx context.Context, filter repository.L
 	return args.Get(0).([]*database.Assignment), args.Get(1).(int64), args.Error(2)
 }
 
+func (m *MockAssignmentRepository) CountGroupByMethod(ctx context.Context, filter *repository.AssignmentStatsFilter) (map[string]int64, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).(map[string]int64), args.Error(1)
+}
+
+func (m *MockAssignmentRepository) CountGroupByStatus(ctx context.Context, filter *repository.AssignmentStatsFilter) (map[string]int64, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).(map[string]int64), args.Error(1)
+}
+
+func (m *MockAssignmentRepository) GetApprovalStats(ctx context.Context, filter *repository.AssignmentStatsFilter) (*repository.AssignmentApprovalStats, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).(*repository.AssignmentApprovalStats), args.Error(1)
+}
+
+func (m *MockAssignmentRepository) CountGroupByDepartment(ctx context.Context, filter *repository.AssignmentStatsFilter) ([]*repository.AssignmentDepartmentStats, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]*repository.AssignmentDepartmentStats), args.Error(1)
+}
+
 // TestAssignmentManagementService_ManualAssign 测试手动分配
 func TestAssignmentManagementService_ManualAssign(t *testing.T) {
 	// 创建模拟对象
@@ -507,6 +703,38 @@ func TestAssignmentManagementService_GetAssignmentSuggestions(t *testing.T) {
 	// mockAssignmentService.AssertExpectations(t)
 }
 
+// TestBuildAssignmentSuggestion_SkillLevelGap 测试技能等级差距反映在建议评分和原因中
+func TestBuildAssignmentSuggestion_SkillLevelGap(t *testing.T) {
+	requirements := []assignment.SkillRequirement{
+		{SkillID: 1, SkillName: "Go", MinLevel: 4},
+		{SkillID: 2, SkillName: "MySQL", MinLevel: 2},
+	}
+	workload := assignment.WorkloadInfo{CurrentTasks: 2, MaxTasks: 10, UtilizationRate: 0.2}
+
+	qualified := buildAssignmentSuggestion(assignment.AssignmentCandidate{
+		Employee:    database.Employee{BaseModel: database.BaseModel{ID: 1}},
+		Workload:    workload,
+		SkillLevels: map[uint]int{1: 5, 2: 2},
+	}, requirements)
+	lacking := buildAssignmentSuggestion(assignment.AssignmentCandidate{
+		Employee:    database.Employee{BaseModel: database.BaseModel{ID: 2}},
+		Workload:    workload,
+		SkillLevels: map[uint]int{1: 2},
+	}, requirements)
+
+	assert.Equal(t, 100.0, qualified.SkillMatch)
+	assert.Equal(t, 80.0, qualified.Availability)
+	assert.InDelta(t, 94.0, qualified.Score, 0.001)
+	assert.Contains(t, qualified.Reason, "技能达标: 2/2, 等级差距: 0")
+
+	// Go 2/4 + MySQL 0/2 => 覆盖率25%，技能匹配度 20+0.25*80
+	assert.Equal(t, 40.0, lacking.SkillMatch)
+	assert.Less(t, lacking.Score, qualified.Score)
+	assert.Contains(t, lacking.Reason, "技能达标: 0/2, 等级差距: 4")
+	assert.Contains(t, lacking.Reason, "Go 需要4级/当前2级")
+	assert.Contains(t, lacking.Reason, "MySQL 需要2级/当前0级")
+}
+
 // TestAssignmentManagementService_CheckAssignmentConflicts 测试检查分配冲突 - 暂时跳过此测试，因为方法不存在
 func TestAssignmentManagementService_CheckAssignmentConflicts_Skip(t *testing.T) {
 	t.Skip("CheckAssignmentConflicts method not implemented yet")
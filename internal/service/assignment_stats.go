@@ -0,0 +1,236 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+
+	"taskmanage/internal/repository"
+	"taskmanage/pkg/report"
+)
+
+// ErrInvalidAssignmentStatsRequest 分配统计参数不合法
+var ErrInvalidAssignmentStatsRequest = errors.New("分配统计参数不合法")
+
+// assignmentStatsStatuses 统计结果中始终返回的分配状态，没有记录时为0
+var assignmentStatsStatuses = []string{"pending", "approved", "rejected", "cancelled", "reassigned"}
+
+// assignmentStatsMethods 统计结果中始终返回的分配方式，没有记录时为0
+var assignmentStatsMethods = []string{AssignMethodManual, AssignMethodAutoRoundRobin, AssignMethodAutoLoadBalance, AssignMethodAutoSkillMatch}
+
+// AssignmentStatsRequest 分配统计请求，日期为服务器时区下的自然日，包含结束日期当天，不传时不限制
+type AssignmentStatsRequest struct {
+	From         string `form:"from"`          // 开始日期，格式 2006-01-02
+	To           string `form:"to"`            // 结束日期，格式 2006-01-02
+	DepartmentID *uint  `form:"department_id"` // 按被分配人所在部门过滤
+}
+
+// AssignmentStatsResponse 分配统计结果
+type AssignmentStatsResponse struct {
+	From         string                         `json:"from,omitempty"`
+	To           string                         `json:"to,omitempty"`
+	DepartmentID *uint                          `json:"department_id,omitempty"`
+	Total        int64                          `json:"total"`
+	ByMethod     map[string]int64               `json:"by_method"` // manual 及各自动分配策略
+	ByStatus     map[string]int64               `json:"by_status"`
+	Approval     AssignmentApprovalSummary      `json:"approval"`
+	Departments  []*AssignmentDepartmentSummary `json:"departments"`
+}
+
+// AssignmentApprovalSummary 分配审批统计，只计入经过审批（通过或拒绝）的记录
+type AssignmentApprovalSummary struct {
+	Decided          int64   `json:"decided"`
+	Rejected         int64   `json:"rejected"`
+	RejectionRate    float64 `json:"rejection_rate"`     // 拒绝数/已审批数，百分比，保留两位小数
+	AvgApprovalHours float64 `json:"avg_approval_hours"` // 分配时间到审批时间的平均小时数
+}
+
+// AssignmentDepartmentSummary 部门分配统计，被分配人没有部门时DepartmentID为null
+type AssignmentDepartmentSummary struct {
+	DepartmentID     *uint   `json:"department_id"`
+	DepartmentName   string  `json:"department_name"`
+	Total            int64   `json:"total"`
+	Approved         int64   `json:"approved"`
+	Rejected         int64   `json:"rejected"`
+	Pending          int64   `json:"pending"`
+	AvgApprovalHours float64 `json:"avg_approval_hours"`
+}
+
+// GetAssignmentStats 按分配方式、状态和部门统计分配记录，统计均由数据库分组完成
+func (s *AssignmentManagementService) GetAssignmentStats(ctx context.Context, req *AssignmentStatsRequest) (*AssignmentStatsResponse, error) {
+	filter, err := parseAssignmentStatsRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	byMethod, err := s.assignmentRepo.CountGroupByMethod(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	byStatus, err := s.assignmentRepo.CountGroupByStatus(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	approval, err := s.assignmentRepo.GetApprovalStats(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	departments, err := s.assignmentRepo.CountGroupByDepartment(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &AssignmentStatsResponse{
+		From:         req.From,
+		To:           req.To,
+		DepartmentID: req.DepartmentID,
+		ByMethod:     withZeroCounts(byMethod, assignmentStatsMethods),
+		ByStatus:     withZeroCounts(byStatus, assignmentStatsStatuses),
+		Approval: AssignmentApprovalSummary{
+			Decided:          approval.Decided,
+			Rejected:         approval.Rejected,
+			AvgApprovalHours: secondsToHours(approval.AvgApprovalSeconds),
+		},
+		Departments: make([]*AssignmentDepartmentSummary, 0, len(departments)),
+	}
+	for _, count := range byStatus {
+		stats.Total += count
+	}
+	if approval.Decided > 0 {
+		stats.Approval.RejectionRate = math.Round(float64(approval.Rejected)/float64(approval.Decided)*10000) / 100
+	}
+	for _, department := range departments {
+		stats.Departments = append(stats.Departments, &AssignmentDepartmentSummary{
+			DepartmentID:     department.DepartmentID,
+			DepartmentName:   department.DepartmentName,
+			Total:            department.Total,
+			Approved:         department.Approved,
+			Rejected:         department.Rejected,
+			Pending:          department.Pending,
+			AvgApprovalHours: secondsToHours(department.AvgApprovalSeconds),
+		})
+	}
+	return stats, nil
+}
+
+// ExportAssignmentStats 以CSV导出分配统计，内容与GetAssignmentStats相同，按分组依次写出
+func (s *AssignmentManagementService) ExportAssignmentStats(ctx context.Context, req *AssignmentStatsRequest) (*ReportExport, error) {
+	stats, err := s.GetAssignmentStats(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	filename := "assignment_stats.csv"
+	if stats.From != "" || stats.To != "" {
+		filename = fmt.Sprintf("assignment_stats_%s_%s.csv", stats.From, stats.To)
+	}
+	return &ReportExport{
+		Filename:    filename,
+		ContentType: report.FormatCSV.ContentType(),
+		RowCount:    int64(len(stats.Departments)),
+		write: func(ctx context.Context, out io.Writer) error {
+			w, err := report.NewCSVWriter(out)
+			if err != nil {
+				return err
+			}
+			if err := writeAssignmentStats(w, stats); err != nil {
+				return err
+			}
+			return w.Close()
+		},
+	}, nil
+}
+
+// writeAssignmentStats 写出分配统计各分组，分组之间以空行分隔
+func writeAssignmentStats(w report.Writer, stats *AssignmentStatsResponse) error {
+	rows := [][]interface{}{
+		{"统计项", "值"},
+		{"开始日期", stats.From},
+		{"结束日期", stats.To},
+		{"分配总数", stats.Total},
+		{"已审批数", stats.Approval.Decided},
+		{"审批拒绝数", stats.Approval.Rejected},
+		{"审批拒绝率(%)", stats.Approval.RejectionRate},
+		{"平均审批耗时(小时)", stats.Approval.AvgApprovalHours},
+		{},
+		{"分配方式", "数量"},
+	}
+	for _, method := range sortedKeys(stats.ByMethod) {
+		rows = append(rows, []interface{}{method, stats.ByMethod[method]})
+	}
+	rows = append(rows, []interface{}{}, []interface{}{"状态", "数量"})
+	for _, status := range sortedKeys(stats.ByStatus) {
+		rows = append(rows, []interface{}{status, stats.ByStatus[status]})
+	}
+	rows = append(rows, []interface{}{}, []interface{}{"部门ID", "部门", "分配总数", "已通过", "已拒绝", "待审批", "平均审批耗时(小时)"})
+	for _, department := range stats.Departments {
+		var departmentID interface{}
+		if department.DepartmentID != nil {
+			departmentID = *department.DepartmentID
+		}
+		rows = append(rows, []interface{}{departmentID, department.DepartmentName, department.Total,
+			department.Approved, department.Rejected, department.Pending, department.AvgApprovalHours})
+	}
+
+	for _, row := range rows {
+		if err := w.WriteRow(row...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseAssignmentStatsRequest 解析统计日期范围，结束日期包含当天
+func parseAssignmentStatsRequest(req *AssignmentStatsRequest) (*repository.AssignmentStatsFilter, error) {
+	filter := &repository.AssignmentStatsFilter{DepartmentID: req.DepartmentID}
+	if req.From != "" {
+		from, err := time.ParseInLocation("2006-01-02", req.From, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("%w: from格式应为2006-01-02", ErrInvalidAssignmentStatsRequest)
+		}
+		filter.From = &from
+	}
+	if req.To != "" {
+		to, err := time.ParseInLocation("2006-01-02", req.To, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("%w: to格式应为2006-01-02", ErrInvalidAssignmentStatsRequest)
+		}
+		to = to.AddDate(0, 0, 1)
+		filter.To = &to
+	}
+	if filter.From != nil && filter.To != nil && !filter.To.After(*filter.From) {
+		return nil, fmt.Errorf("%w: 结束日期不能早于开始日期", ErrInvalidAssignmentStatsRequest)
+	}
+	return filter, nil
+}
+
+// withZeroCounts 为未出现的键补0，返回新的map
+func withZeroCounts(counts map[string]int64, keys []string) map[string]int64 {
+	result := make(map[string]int64, len(counts)+len(keys))
+	for _, key := range keys {
+		result[key] = 0
+	}
+	for key, count := range counts {
+		result[key] = count
+	}
+	return result
+}
+
+// sortedKeys 返回按字典序排列的键，保证导出顺序稳定
+func sortedKeys(counts map[string]int64) []string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// secondsToHours 秒数换算为小时，保留两位小数
+func secondsToHours(seconds float64) float64 {
+	return math.Round(seconds/3600*100) / 100
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/repository"
+)
+
+func TestAssignmentManagementService_GetAssignmentStats(t *testing.T) {
+	assignmentRepo := new(MockAssignmentRepository)
+	svc := &AssignmentManagementService{assignmentRepo: assignmentRepo}
+	ctx := context.Background()
+	departmentID := uint(3)
+
+	// 结束日期包含当天
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local)
+	to := time.Date(2026, 4, 1, 0, 0, 0, 0, time.Local)
+	filter := mock.MatchedBy(func(f *repository.AssignmentStatsFilter) bool {
+		return f.From.Equal(from) && f.To.Equal(to) && *f.DepartmentID == departmentID
+	})
+	assignmentRepo.On("CountGroupByMethod", ctx, filter).Return(map[string]int64{"manual": 4, "comprehensive": 2}, nil)
+	assignmentRepo.On("CountGroupByStatus", ctx, filter).Return(map[string]int64{"approved": 3, "rejected": 1, "pending": 2}, nil)
+	assignmentRepo.On("GetApprovalStats", ctx, filter).Return(&repository.AssignmentApprovalStats{Decided: 3, Rejected: 1, AvgApprovalSeconds: 5400}, nil)
+	assignmentRepo.On("CountGroupByDepartment", ctx, filter).Return([]*repository.AssignmentDepartmentStats{
+		{DepartmentID: &departmentID, DepartmentName: "研发部", Total: 6, Approved: 3, Rejected: 1, Pending: 2, AvgApprovalSeconds: 5400},
+	}, nil)
+
+	req := &AssignmentStatsRequest{From: "2026-03-01", To: "2026-03-31", DepartmentID: &departmentID}
+	stats, err := svc.GetAssignmentStats(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, int64(6), stats.Total)
+	assert.Equal(t, map[string]int64{
+		AssignMethodManual: 4, AssignMethodAutoRoundRobin: 0, AssignMethodAutoLoadBalance: 0, AssignMethodAutoSkillMatch: 0, "comprehensive": 2,
+	}, stats.ByMethod)
+	assert.Equal(t, map[string]int64{"pending": 2, "approved": 3, "rejected": 1, "cancelled": 0, "reassigned": 0}, stats.ByStatus)
+	assert.Equal(t, AssignmentApprovalSummary{Decided: 3, Rejected: 1, RejectionRate: 33.33, AvgApprovalHours: 1.5}, stats.Approval)
+	require.Len(t, stats.Departments, 1)
+	assert.Equal(t, "研发部", stats.Departments[0].DepartmentName)
+	assert.Equal(t, 1.5, stats.Departments[0].AvgApprovalHours)
+
+	// CSV导出包含相同的数据
+	export, err := svc.ExportAssignmentStats(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, "assignment_stats_2026-03-01_2026-03-31.csv", export.Filename)
+	var buf bytes.Buffer
+	require.NoError(t, export.WriteTo(ctx, &buf))
+	csv := buf.String()
+	assert.Contains(t, csv, "审批拒绝率(%),33.33\n")
+	assert.Contains(t, csv, "comprehensive,2\n")
+	assert.Contains(t, csv, "3,研发部,6,3,1,2,1.5\n")
+}
+
+func TestAssignmentManagementService_GetAssignmentStatsInvalidRange(t *testing.T) {
+	svc := &AssignmentManagementService{assignmentRepo: new(MockAssignmentRepository)}
+
+	for _, req := range []*AssignmentStatsRequest{
+		{From: "2026/03/01"},
+		{To: "tomorrow"},
+		{From: "2026-03-31", To: "2026-03-01"},
+	} {
+		_, err := svc.GetAssignmentStats(context.Background(), req)
+		assert.ErrorIs(t, err, ErrInvalidAssignmentStatsRequest, "%+v", req)
+		assert.True(t, strings.HasPrefix(err.Error(), ErrInvalidAssignmentStatsRequest.Error()))
+	}
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	"taskmanage/internal/assignment"
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+// DefaultAssignmentStrategy 调用方和部门都未指定分配策略时使用的系统默认策略
+const DefaultAssignmentStrategy = string(assignment.StrategyComprehensive)
+
+// ErrUnknownAssignmentStrategy 分配策略未注册，错误信息中列出可选策略
+var ErrUnknownAssignmentStrategy = assignment.ErrUnknownStrategy
+
+// resolveAssignmentStrategy 确定自动分配使用的策略
+// 优先使用调用方指定的策略，其次使用任务所属部门的默认策略，最后使用系统默认策略
+// 任务所属部门取项目所属部门，不属于项目时取创建人所在部门
+func resolveAssignmentStrategy(ctx context.Context, strategy string, task *database.Task, projectRepo repository.ProjectRepository, employeeRepo repository.EmployeeRepository, departmentRepo repository.DepartmentRepository) (string, error) {
+	if strategy != "" {
+		return strategy, nil
+	}
+
+	departmentID, err := taskDepartmentID(ctx, task, projectRepo, employeeRepo)
+	if err != nil {
+		return "", err
+	}
+	if departmentID != 0 {
+		department, err := departmentRepo.GetByID(ctx, departmentID)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return "", fmt.Errorf("获取部门失败: %w", err)
+		}
+		if department != nil && department.AssignmentStrategy != "" {
+			return department.AssignmentStrategy, nil
+		}
+	}
+
+	return DefaultAssignmentStrategy, nil
+}
+
+// taskDepartmentID 获取任务所属部门，无法确定时返回0
+func taskDepartmentID(ctx context.Context, task *database.Task, projectRepo repository.ProjectRepository, employeeRepo repository.EmployeeRepository) (uint, error) {
+	if task.ProjectID != nil {
+		project, err := projectRepo.GetByID(ctx, *task.ProjectID)
+		if err != nil {
+			return 0, fmt.Errorf("获取任务所属项目失败: %w", err)
+		}
+		return project.DepartmentID, nil
+	}
+
+	creator, err := employeeRepo.GetByUserID(ctx, task.CreatorID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("获取任务创建人失败: %w", err)
+	}
+	if creator.DepartmentID == nil {
+		return 0, nil
+	}
+	return *creator.DepartmentID, nil
+}
+
+// SetAssignmentStrategy 设置部门默认分配策略，策略为空时恢复使用系统默认策略
+func (s *departmentService) SetAssignmentStrategy(ctx context.Context, id uint, req *UpdateDepartmentAssignmentStrategyRequest) (*DepartmentResponse, error) {
+	s.logger.WithFields(logrus.Fields{
+		"department_id": id,
+		"strategy":      req.Strategy,
+	}).Info("设置部门默认分配策略")
+
+	if req.Strategy != "" {
+		if _, err := s.strategies.Get(assignment.AssignmentStrategy(req.Strategy)); err != nil {
+			return nil, err
+		}
+	}
+
+	repo := s.repoManager.DepartmentRepository()
+	exists, err := repo.Exists(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("获取部门失败: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("%w: %d", ErrDepartmentNotFound, id)
+	}
+	if err := repo.UpdateAssignmentStrategy(ctx, id, req.Strategy); err != nil {
+		s.logger.WithError(err).Error("设置部门默认分配策略失败")
+		return nil, fmt.Errorf("设置部门默认分配策略失败: %w", err)
+	}
+
+	return s.GetDepartment(ctx, id)
+}
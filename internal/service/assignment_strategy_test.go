@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+// strategyDepartmentRepository 按ID返回部门默认分配策略的部门仓储桩
+type strategyDepartmentRepository struct {
+	repository.DepartmentRepository
+	strategies map[uint]string
+}
+
+func (r *strategyDepartmentRepository) GetByID(ctx context.Context, id uint) (*database.Department, error) {
+	strategy, ok := r.strategies[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &database.Department{BaseModel: database.BaseModel{ID: id}, AssignmentStrategy: strategy}, nil
+}
+
+func TestResolveAssignmentStrategy(t *testing.T) {
+	ctx := context.Background()
+	projectID := uint(9)
+	projectRepo := &memberProjectRepository{
+		project: &database.Project{BaseModel: database.BaseModel{ID: projectID}, DepartmentID: 1},
+	}
+	departmentRepo := &strategyDepartmentRepository{strategies: map[uint]string{1: "load_balance", 2: "skill_match", 3: ""}}
+	employeeRepo := new(MockEmployeeRepository)
+	dept2, dept3 := uint(2), uint(3)
+	employeeRepo.On("GetByUserID", ctx, uint(20)).Return(&database.Employee{DepartmentID: &dept2}, nil)
+	employeeRepo.On("GetByUserID", ctx, uint(30)).Return(&database.Employee{DepartmentID: &dept3}, nil)
+	employeeRepo.On("GetByUserID", ctx, uint(40)).Return((*database.Employee)(nil), repository.ErrNotFound)
+
+	tests := []struct {
+		name      string
+		requested string
+		task      *database.Task
+		want      string
+	}{
+		{"调用方指定的策略优先", "round_robin", &database.Task{ProjectID: &projectID}, "round_robin"},
+		{"项目任务使用项目所属部门的策略", "", &database.Task{ProjectID: &projectID, CreatorID: 20}, "load_balance"},
+		{"非项目任务使用创建人所在部门的策略", "", &database.Task{CreatorID: 20}, "skill_match"},
+		{"部门未设置时使用系统默认策略", "", &database.Task{CreatorID: 30}, DefaultAssignmentStrategy},
+		{"创建人不是员工时使用系统默认策略", "", &database.Task{CreatorID: 40}, DefaultAssignmentStrategy},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveAssignmentStrategy(ctx, tt.requested, tt.task, projectRepo, employeeRepo, departmentRepo)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -27,12 +27,13 @@ func (s *BootstrapService) InitializeSystem(ctx context.Context) error {
 	logger.Info("开始初始化系统默认数据...")
 
 	// 1. 创建默认权限
-	if err := s.createDefaultPermissions(ctx); err != nil {
+	created, err := s.createDefaultPermissions(ctx)
+	if err != nil {
 		return fmt.Errorf("创建默认权限失败: %w", err)
 	}
 
 	// 2. 创建默认角色
-	if err := s.createDefaultRoles(ctx); err != nil {
+	if err := s.createDefaultRoles(ctx, created); err != nil {
 		return fmt.Errorf("创建默认角色失败: %w", err)
 	}
 
@@ -51,8 +52,8 @@ func (s *BootstrapService) InitializeSystem(ctx context.Context) error {
 	return nil
 }
 
-// createDefaultPermissions 创建默认权限
-func (s *BootstrapService) createDefaultPermissions(ctx context.Context) error {
+// createDefaultPermissions 创建缺失的默认权限，返回本次新建的权限名
+func (s *BootstrapService) createDefaultPermissions(ctx context.Context) (map[string]bool, error) {
 	permRepo := s.repoManager.PermissionRepository()
 
 	defaultPermissions := []database.Permission{
@@ -122,6 +123,7 @@ func (s *BootstrapService) createDefaultPermissions(ctx context.Context) error {
 		{Name: "permission:delete", DisplayName: "删除权限分配", Description: "可以删除权限模板和权限配置", Resource: "permission", Action: "delete"},
 	}
 
+	created := make(map[string]bool)
 	for _, perm := range defaultPermissions {
 		// 检查权限是否已存在
 		existing, err := permRepo.GetByName(ctx, perm.Name)
@@ -131,16 +133,18 @@ func (s *BootstrapService) createDefaultPermissions(ctx context.Context) error {
 		}
 
 		if err := permRepo.Create(ctx, &perm); err != nil {
-			return fmt.Errorf("创建权限 %s 失败: %w", perm.Name, err)
+			return nil, fmt.Errorf("创建权限 %s 失败: %w", perm.Name, err)
 		}
+		created[perm.Name] = true
 		logger.Infof("创建权限: %s", perm.Name)
 	}
 
-	return nil
+	return created, nil
 }
 
-// createDefaultRoles 创建默认角色
-func (s *BootstrapService) createDefaultRoles(ctx context.Context) error {
+// createDefaultRoles 创建缺失的默认角色
+// 已存在的角色可能已通过角色管理接口调整过权限，这里只追加本次新建的默认权限，不覆盖已有配置
+func (s *BootstrapService) createDefaultRoles(ctx context.Context, createdPermissions map[string]bool) error {
 	roleRepo := s.repoManager.RoleRepository()
 	permRepo := s.repoManager.PermissionRepository()
 
@@ -212,6 +216,7 @@ func (s *BootstrapService) createDefaultRoles(ctx context.Context) error {
 
 	for roleName, roleInfo := range rolePermissions {
 		var role *database.Role
+		isNew := false
 		
 		// 检查角色是否已存在
 		existing, err := roleRepo.GetByName(ctx, roleName)
@@ -219,6 +224,7 @@ func (s *BootstrapService) createDefaultRoles(ctx context.Context) error {
 			logger.Infof("角色 %s 已存在，使用现有角色", roleName)
 			role = existing
 		} else {
+			isNew = true
 			// 创建新角色
 			role = &database.Role{
 				Name:        roleName,
@@ -232,9 +238,12 @@ func (s *BootstrapService) createDefaultRoles(ctx context.Context) error {
 			logger.Infof("创建角色: %s (%s)", roleName, roleInfo.displayName)
 		}
 
-		// 获取权限ID并分配给角色（无论角色是新建还是已存在）
+		// 新建角色分配全部默认权限，已存在的角色只追加本次新建的权限
 		var permissionIDs []uint
 		for _, permName := range roleInfo.permissions {
+			if !isNew && !createdPermissions[permName] {
+				continue
+			}
 			perm, err := permRepo.GetByName(ctx, permName)
 			if err != nil {
 				logger.Warnf("权限 %s 不存在，跳过分配", permName)
@@ -244,8 +253,7 @@ func (s *BootstrapService) createDefaultRoles(ctx context.Context) error {
 		}
 
 		if len(permissionIDs) > 0 {
-			// 直接分配权限（GORM的Association会自动处理重复）
-			if err := roleRepo.AssignPermissions(ctx, role.ID, permissionIDs); err != nil {
+			if err := roleRepo.AddPermissions(ctx, role.ID, permissionIDs); err != nil {
 				return fmt.Errorf("为角色 %s 分配权限失败: %w", roleName, err)
 			}
 			logger.Infof("为角色 %s 分配了 %d 个权限", roleName, len(permissionIDs))
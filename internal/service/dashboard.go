@@ -0,0 +1,338 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+	"taskmanage/pkg/logger"
+)
+
+const (
+	// DefaultDashboardSectionTimeout 工作台每个分区的默认加载超时
+	DefaultDashboardSectionTimeout = 3 * time.Second
+	// dashboardTopN 工作台各分区列表返回的条数
+	dashboardTopN = 5
+	// dashboardDueWithin 即将到期分区统计的时间范围
+	dashboardDueWithin = 7 * 24 * time.Hour
+)
+
+// 工作台分区名称，同时作为Errors中的键
+const (
+	DashboardSectionTasks            = "tasks"
+	DashboardSectionPendingApprovals = "pending_approvals"
+	DashboardSectionNotifications    = "notifications"
+	DashboardSectionProjects         = "projects"
+	DashboardSectionDueSoon          = "due_soon"
+)
+
+// MyDashboard 当前用户工作台，加载失败或超时的分区为null，原因记录在Errors中
+type MyDashboard struct {
+	Tasks            *DashboardTaskSection         `json:"tasks"`
+	PendingApprovals *DashboardApprovalSection     `json:"pending_approvals"`
+	Notifications    *DashboardNotificationSection `json:"notifications"`
+	Projects         *DashboardProjectSection      `json:"projects"`
+	DueSoon          *DashboardDueSoonSection      `json:"due_soon"`
+	Errors           map[string]string             `json:"errors,omitempty"` // 分区名称到错误说明
+}
+
+// DashboardTaskSection 分配给我的任务：按状态的数量和最紧急的未完成任务
+type DashboardTaskSection struct {
+	ByStatus map[string]int64 `json:"by_status"`
+	Open     int64            `json:"open"` // 未完成、未取消的任务数
+	Items    []*TaskResponse  `json:"items"`
+}
+
+// DashboardApprovalSection 我的待审批
+type DashboardApprovalSection struct {
+	Total int64                `json:"total"`
+	Items []*DashboardApproval `json:"items"`
+}
+
+// DashboardApproval 工作台中的待审批摘要
+type DashboardApproval struct {
+	InstanceID   string     `json:"instance_id"`
+	WorkflowName string     `json:"workflow_name"`
+	NodeName     string     `json:"node_name"`
+	BusinessID   string     `json:"business_id"`
+	BusinessType string     `json:"business_type"`
+	Priority     int        `json:"priority"`
+	CreatedAt    time.Time  `json:"created_at"`
+	Deadline     *time.Time `json:"deadline,omitempty"`
+}
+
+// DashboardNotificationSection 我的未读通知
+type DashboardNotificationSection struct {
+	Unread int64                   `json:"unread"`
+	Items  []*NotificationResponse `json:"items"`
+}
+
+// DashboardProjectSection 我参与的进行中项目
+type DashboardProjectSection struct {
+	Total int                 `json:"total"`
+	Items []*DashboardProject `json:"items"`
+}
+
+// DashboardProject 工作台中的项目摘要
+type DashboardProject struct {
+	ID       uint       `json:"id"`
+	Name     string     `json:"name"`
+	Priority string     `json:"priority"`
+	EndDate  *time.Time `json:"end_date"`
+}
+
+// DashboardDueSoonSection 7天内到期的未完成任务
+type DashboardDueSoonSection struct {
+	Total int64           `json:"total"`
+	Items []*TaskResponse `json:"items"`
+}
+
+// DashboardService 当前用户工作台服务，一次请求并发汇总首页所需的各项数据
+type DashboardService interface {
+	// GetMyDashboard 并发加载各分区，单个分区失败或超时不影响其它分区
+	GetMyDashboard(ctx context.Context, userID uint) (*MyDashboard, error)
+}
+
+// dashboardService 工作台服务实现
+type dashboardService struct {
+	repoManager    repository.RepositoryManager
+	sectionTimeout time.Duration
+	now            func() time.Time
+}
+
+// NewDashboardService 创建工作台服务，sectionTimeout不大于0时使用默认超时
+func NewDashboardService(repoManager repository.RepositoryManager, sectionTimeout time.Duration) DashboardService {
+	if sectionTimeout <= 0 {
+		sectionTimeout = DefaultDashboardSectionTimeout
+	}
+	return &dashboardService{
+		repoManager:    repoManager,
+		sectionTimeout: sectionTimeout,
+		now:            time.Now,
+	}
+}
+
+// GetMyDashboard 汇总当前用户的工作台数据
+func (s *dashboardService) GetMyDashboard(ctx context.Context, userID uint) (*MyDashboard, error) {
+	dashboard := &MyDashboard{}
+	var mu sync.Mutex
+	fail := func(section string, err error) {
+		logger.Warnf("加载工作台分区失败: UserID=%d, Section=%s, %v", userID, section, err)
+		note := "加载失败"
+		if errors.Is(err, context.DeadlineExceeded) {
+			note = "加载超时"
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if dashboard.Errors == nil {
+			dashboard.Errors = make(map[string]string)
+		}
+		dashboard.Errors[section] = note
+	}
+
+	// 分区失败只记录说明，不返回错误，避免取消其它分区
+	var g errgroup.Group
+	g.Go(func() error {
+		section, err := loadDashboardSection(ctx, s.sectionTimeout, func(ctx context.Context) (*DashboardTaskSection, error) {
+			return s.loadTasks(ctx, userID)
+		})
+		if err != nil {
+			fail(DashboardSectionTasks, err)
+		}
+		dashboard.Tasks = section
+		return nil
+	})
+	g.Go(func() error {
+		section, err := loadDashboardSection(ctx, s.sectionTimeout, func(ctx context.Context) (*DashboardApprovalSection, error) {
+			return s.loadPendingApprovals(ctx, userID)
+		})
+		if err != nil {
+			fail(DashboardSectionPendingApprovals, err)
+		}
+		dashboard.PendingApprovals = section
+		return nil
+	})
+	g.Go(func() error {
+		section, err := loadDashboardSection(ctx, s.sectionTimeout, func(ctx context.Context) (*DashboardNotificationSection, error) {
+			return s.loadNotifications(ctx, userID)
+		})
+		if err != nil {
+			fail(DashboardSectionNotifications, err)
+		}
+		dashboard.Notifications = section
+		return nil
+	})
+	g.Go(func() error {
+		section, err := loadDashboardSection(ctx, s.sectionTimeout, func(ctx context.Context) (*DashboardProjectSection, error) {
+			return s.loadProjects(ctx, userID)
+		})
+		if err != nil {
+			fail(DashboardSectionProjects, err)
+		}
+		dashboard.Projects = section
+		return nil
+	})
+	g.Go(func() error {
+		section, err := loadDashboardSection(ctx, s.sectionTimeout, func(ctx context.Context) (*DashboardDueSoonSection, error) {
+			return s.loadDueSoon(ctx, userID)
+		})
+		if err != nil {
+			fail(DashboardSectionDueSoon, err)
+		}
+		dashboard.DueSoon = section
+		return nil
+	})
+	_ = g.Wait()
+
+	return dashboard, nil
+}
+
+// loadDashboardSection 在超时时间内加载分区，超时后不再等待数据源返回，结果直接丢弃
+func loadDashboardSection[T any](ctx context.Context, timeout time.Duration, load func(ctx context.Context) (*T, error)) (*T, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type result struct {
+		section *T
+		err     error
+	}
+	done := make(chan result, 1)
+	go func() {
+		section, err := load(ctx)
+		done <- result{section: section, err: err}
+	}()
+
+	select {
+	case r := <-done:
+		if r.err != nil {
+			return nil, r.err
+		}
+		return r.section, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// loadTasks 按状态统计分配给用户的任务，并返回最紧急的未完成任务
+func (s *dashboardService) loadTasks(ctx context.Context, userID uint) (*DashboardTaskSection, error) {
+	taskRepo := s.repoManager.TaskRepository()
+	counts, err := taskRepo.CountByAssigneeGroupByStatus(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	tasks, open, err := taskRepo.ListOpenByAssignee(ctx, &repository.OpenTaskFilter{AssigneeID: userID, Limit: dashboardTopN})
+	if err != nil {
+		return nil, err
+	}
+	return &DashboardTaskSection{ByStatus: counts, Open: open, Items: dashboardTaskItems(tasks)}, nil
+}
+
+// loadPendingApprovals 获取用户的待审批任务，按优先级从高到低
+func (s *dashboardService) loadPendingApprovals(ctx context.Context, userID uint) (*DashboardApprovalSection, error) {
+	approvals, total, err := s.repoManager.WorkflowInstanceRepository().GetPendingApprovals(ctx, &repository.PendingApprovalFilter{
+		UserID:   userID,
+		Page:     1,
+		PageSize: dashboardTopN,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*DashboardApproval, 0, len(approvals))
+	for _, approval := range approvals {
+		items = append(items, &DashboardApproval{
+			InstanceID:   approval.InstanceID,
+			WorkflowName: approval.WorkflowName,
+			NodeName:     approval.NodeName,
+			BusinessID:   approval.BusinessID,
+			BusinessType: approval.BusinessType,
+			Priority:     approval.Priority,
+			CreatedAt:    approval.CreatedAt,
+			Deadline:     approval.Deadline,
+		})
+	}
+	return &DashboardApprovalSection{Total: total, Items: items}, nil
+}
+
+// loadNotifications 获取用户最新的未读通知
+func (s *dashboardService) loadNotifications(ctx context.Context, userID uint) (*DashboardNotificationSection, error) {
+	notifications, unread, err := s.repoManager.NotificationRepository().GetUserNotifications(ctx, userID, "unread", 1, dashboardTopN)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*NotificationResponse, 0, len(notifications))
+	for _, notification := range notifications {
+		items = append(items, &NotificationResponse{
+			ID:        notification.ID,
+			UserID:    notification.RecipientID,
+			Type:      notification.Type,
+			Title:     notification.Title,
+			Content:   notification.Content,
+			CreatedAt: notification.CreatedAt,
+		})
+	}
+	return &DashboardNotificationSection{Unread: unread, Items: items}, nil
+}
+
+// loadProjects 获取用户员工档案参与的进行中项目，没有员工档案的用户返回空分区
+func (s *dashboardService) loadProjects(ctx context.Context, userID uint) (*DashboardProjectSection, error) {
+	section := &DashboardProjectSection{Items: []*DashboardProject{}}
+	employee, err := s.repoManager.EmployeeRepository().GetByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return section, nil
+		}
+		return nil, err
+	}
+
+	projects, err := s.repoManager.ProjectRepository().GetByMemberID(ctx, employee.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, project := range projects {
+		if project.Status != "active" {
+			continue
+		}
+		section.Total++
+		if len(section.Items) < dashboardTopN {
+			section.Items = append(section.Items, &DashboardProject{
+				ID:       project.ID,
+				Name:     project.Name,
+				Priority: project.Priority,
+				EndDate:  project.EndDate,
+			})
+		}
+	}
+	return section, nil
+}
+
+// loadDueSoon 获取7天内到期的未完成任务，已逾期的任务不在此分区
+func (s *dashboardService) loadDueSoon(ctx context.Context, userID uint) (*DashboardDueSoonSection, error) {
+	now := s.now()
+	dueTo := now.Add(dashboardDueWithin)
+	tasks, total, err := s.repoManager.TaskRepository().ListOpenByAssignee(ctx, &repository.OpenTaskFilter{
+		AssigneeID: userID,
+		DueFrom:    &now,
+		DueTo:      &dueTo,
+		Limit:      dashboardTopN,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &DashboardDueSoonSection{Total: total, Items: dashboardTaskItems(tasks)}, nil
+}
+
+// dashboardTaskItems 转换任务列表，空列表序列化为[]
+func dashboardTaskItems(tasks []*database.Task) []*TaskResponse {
+	items := make([]*TaskResponse, 0, len(tasks))
+	for _, task := range tasks {
+		items = append(items, TaskToResponse(task))
+	}
+	return items
+}
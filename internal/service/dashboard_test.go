@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+// dashboardTaskRepository 工作台测试用任务仓储，记录过滤条件；两个分区会并发调用
+type dashboardTaskRepository struct {
+	repository.TaskRepository
+	mu      sync.Mutex
+	counts  map[string]int64
+	tasks   []*database.Task
+	filters []repository.OpenTaskFilter
+}
+
+func (r *dashboardTaskRepository) CountByAssigneeGroupByStatus(ctx context.Context, assigneeID uint) (map[string]int64, error) {
+	return r.counts, nil
+}
+
+func (r *dashboardTaskRepository) ListOpenByAssignee(ctx context.Context, filter *repository.OpenTaskFilter) ([]*database.Task, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.filters = append(r.filters, *filter)
+	return r.tasks, int64(len(r.tasks)), nil
+}
+
+// failingApprovalRepository 查询待审批总是失败的流程实例仓储
+type failingApprovalRepository struct {
+	repository.WorkflowInstanceRepository
+}
+
+func (r *failingApprovalRepository) GetPendingApprovals(ctx context.Context, filter *repository.PendingApprovalFilter) ([]*database.WorkflowPendingApproval, int64, error) {
+	return nil, 0, errors.New("connection refused")
+}
+
+// blockingNotificationRepository 不响应上下文取消、一直阻塞到测试结束的通知仓储
+type blockingNotificationRepository struct {
+	repository.NotificationRepository
+	release chan struct{}
+}
+
+func (r *blockingNotificationRepository) GetUserNotifications(ctx context.Context, userID uint, status string, page, pageSize int) ([]*database.TaskNotification, int64, error) {
+	<-r.release
+	return nil, 0, nil
+}
+
+// dashboardProjectRepository 工作台测试用项目仓储
+type dashboardProjectRepository struct {
+	repository.ProjectRepository
+	projects []*database.Project
+}
+
+func (r *dashboardProjectRepository) GetByMemberID(ctx context.Context, employeeID uint) ([]*database.Project, error) {
+	return r.projects, nil
+}
+
+// dashboardRepoManager 工作台测试用仓储管理器
+type dashboardRepoManager struct {
+	repository.RepositoryManager
+	tasks         *dashboardTaskRepository
+	approvals     repository.WorkflowInstanceRepository
+	notifications repository.NotificationRepository
+	employees     repository.EmployeeRepository
+	projects      *dashboardProjectRepository
+}
+
+func (m *dashboardRepoManager) TaskRepository() repository.TaskRepository { return m.tasks }
+
+func (m *dashboardRepoManager) WorkflowInstanceRepository() repository.WorkflowInstanceRepository {
+	return m.approvals
+}
+
+func (m *dashboardRepoManager) NotificationRepository() repository.NotificationRepository {
+	return m.notifications
+}
+
+func (m *dashboardRepoManager) EmployeeRepository() repository.EmployeeRepository { return m.employees }
+
+func (m *dashboardRepoManager) ProjectRepository() repository.ProjectRepository { return m.projects }
+
+func TestDashboardService_GetMyDashboard(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.Local)
+	task := &database.Task{BaseModel: database.BaseModel{ID: 1}, Title: "任务", Status: "in_progress"}
+	notifications := &blockingNotificationRepository{release: make(chan struct{})}
+	defer close(notifications.release)
+	repos := &dashboardRepoManager{
+		tasks:         &dashboardTaskRepository{counts: map[string]int64{"in_progress": 1, "completed": 3}, tasks: []*database.Task{task}},
+		approvals:     &failingApprovalRepository{},
+		notifications: notifications,
+		employees:     &escalationEmployeeRepository{employees: []*database.Employee{{BaseModel: database.BaseModel{ID: 10}, UserID: 100}}},
+		projects: &dashboardProjectRepository{projects: []*database.Project{
+			{BaseModel: database.BaseModel{ID: 1}, Name: "进行中", Status: "active"},
+			{BaseModel: database.BaseModel{ID: 2}, Name: "已完成", Status: "completed"},
+		}},
+	}
+	svc := NewDashboardService(repos, 50*time.Millisecond).(*dashboardService)
+	svc.now = func() time.Time { return now }
+
+	started := time.Now()
+	dashboard, err := svc.GetMyDashboard(context.Background(), 100)
+	require.NoError(t, err)
+	assert.Less(t, time.Since(started), time.Second, "慢分区不能阻塞整个工作台")
+
+	require.NotNil(t, dashboard.Tasks)
+	assert.Equal(t, map[string]int64{"in_progress": 1, "completed": 3}, dashboard.Tasks.ByStatus)
+	assert.Equal(t, int64(1), dashboard.Tasks.Open)
+	require.Len(t, dashboard.Tasks.Items, 1)
+	assert.Equal(t, uint(1), dashboard.Tasks.Items[0].ID)
+
+	require.NotNil(t, dashboard.Projects)
+	assert.Equal(t, 1, dashboard.Projects.Total)
+	assert.Equal(t, "进行中", dashboard.Projects.Items[0].Name)
+
+	// 即将到期分区只查询7天内到期的任务
+	require.NotNil(t, dashboard.DueSoon)
+	require.Len(t, repos.tasks.filters, 2)
+	for _, filter := range repos.tasks.filters {
+		if filter.DueTo != nil {
+			assert.Equal(t, now, *filter.DueFrom)
+			assert.Equal(t, now.Add(7*24*time.Hour), *filter.DueTo)
+		}
+	}
+
+	// 失败和超时的分区为空并说明原因
+	assert.Nil(t, dashboard.PendingApprovals)
+	assert.Nil(t, dashboard.Notifications)
+	assert.Equal(t, map[string]string{
+		DashboardSectionPendingApprovals: "加载失败",
+		DashboardSectionNotifications:    "加载超时",
+	}, dashboard.Errors)
+}
+
+func TestDashboardService_ProjectsWithoutEmployee(t *testing.T) {
+	repos := &dashboardRepoManager{employees: &escalationEmployeeRepository{}}
+	svc := NewDashboardService(repos, 0).(*dashboardService)
+
+	section, err := svc.loadProjects(context.Background(), 100)
+	require.NoError(t, err)
+	assert.Equal(t, &DashboardProjectSection{Items: []*DashboardProject{}}, section)
+}
@@ -5,21 +5,30 @@ import (
 	"fmt"
 
 	"github.com/sirupsen/logrus"
+	"taskmanage/internal/assignment"
+	"taskmanage/internal/config"
 	"taskmanage/internal/database"
 	"taskmanage/internal/repository"
 )
 
 // departmentService 部门服务实现
 type departmentService struct {
-	repoManager repository.RepositoryManager
-	logger      *logrus.Logger
+	repoManager         repository.RepositoryManager
+	notificationService NotificationService
+	strategies          *assignment.StrategyRegistry
+	logger              *logrus.Logger
+	// allowAncestorManager 允许上级部门的员工担任部门管理者
+	allowAncestorManager bool
 }
 
 // NewDepartmentService 创建部门服务实例
-func NewDepartmentService(repoManager repository.RepositoryManager, logger *logrus.Logger) DepartmentService {
+func NewDepartmentService(repoManager repository.RepositoryManager, notificationService NotificationService, strategies *assignment.StrategyRegistry, cfg config.DepartmentConfig, logger *logrus.Logger) DepartmentService {
 	return &departmentService{
-		repoManager: repoManager,
-		logger:      logger,
+		repoManager:          repoManager,
+		notificationService:  notificationService,
+		strategies:           strategies,
+		logger:               logger,
+		allowAncestorManager: cfg.AllowAncestorManager,
 	}
 }
 
@@ -52,6 +61,11 @@ func (s *departmentService) CreateDepartment(ctx context.Context, req *CreateDep
 		ManagerID:   req.ManagerID,
 		Path:        path,
 	}
+	if req.ManagerID != nil {
+		if err := s.validateManager(ctx, department, *req.ManagerID); err != nil {
+			return nil, err
+		}
+	}
 
 	repo := s.repoManager.DepartmentRepository()
 	if err := repo.Create(ctx, department); err != nil {
@@ -86,12 +100,16 @@ func (s *departmentService) UpdateDepartment(ctx context.Context, id uint, req *
 	if req.ParentID != nil {
 		department.ParentID = req.ParentID
 	}
-	if req.ManagerID != nil {
-		department.ManagerID = req.ManagerID
-	}
 	if req.Path != nil {
 		department.Path = *req.Path
 	}
+	if req.ManagerID != nil && (department.ManagerID == nil || *department.ManagerID != *req.ManagerID) {
+		if err := s.validateManager(ctx, department, *req.ManagerID); err != nil {
+			return nil, err
+		}
+		// 预加载的原管理者会在保存时覆盖新的manager_id
+		department.ManagerID, department.Manager = req.ManagerID, nil
+	}
 
 	if err := repo.Update(ctx, department); err != nil {
 		s.logger.WithError(err).Error("更新部门失败")
@@ -101,31 +119,6 @@ func (s *departmentService) UpdateDepartment(ctx context.Context, id uint, req *
 	return s.departmentToResponse(department), nil
 }
 
-// DeleteDepartment 删除部门
-func (s *departmentService) DeleteDepartment(ctx context.Context, id uint) error {
-	s.logger.WithField("id", id).Info("删除部门")
-
-	repo := s.repoManager.DepartmentRepository()
-	
-	// 检查是否有子部门
-	subDepartments, err := repo.GetByParentID(ctx, id)
-	if err != nil {
-		s.logger.WithError(err).Error("检查子部门失败")
-		return fmt.Errorf("检查子部门失败: %w", err)
-	}
-	
-	if len(subDepartments) > 0 {
-		return fmt.Errorf("不能删除有子部门的部门")
-	}
-
-	if err := repo.Delete(ctx, id); err != nil {
-		s.logger.WithError(err).Error("删除部门失败")
-		return fmt.Errorf("删除部门失败: %w", err)
-	}
-
-	return nil
-}
-
 // GetDepartment 获取部门详情
 func (s *departmentService) GetDepartment(ctx context.Context, id uint) (*DepartmentResponse, error) {
 	s.logger.WithField("id", id).Debug("获取部门详情")
@@ -265,22 +258,6 @@ func (s *departmentService) GetSubDepartments(ctx context.Context, departmentID
 	return responses, nil
 }
 
-// UpdateManager 更新部门管理者
-func (s *departmentService) UpdateManager(ctx context.Context, departmentID, managerID uint) error {
-	s.logger.WithFields(logrus.Fields{
-		"department_id": departmentID,
-		"manager_id":    managerID,
-	}).Info("更新部门管理者")
-
-	repo := s.repoManager.DepartmentRepository()
-	if err := repo.UpdateManager(ctx, departmentID, managerID); err != nil {
-		s.logger.WithError(err).Error("更新部门管理者失败")
-		return fmt.Errorf("更新部门管理者失败: %w", err)
-	}
-
-	return nil
-}
-
 // departmentToResponse 转换部门模型为响应DTO
 func (s *departmentService) departmentToResponse(dept *database.Department) *DepartmentResponse {
 	response := &DepartmentResponse{
@@ -290,8 +267,12 @@ func (s *departmentService) departmentToResponse(dept *database.Department) *Dep
 		ParentID:    dept.ParentID,
 		ManagerID:   dept.ManagerID,
 		Path:        dept.Path,
+		Status:      dept.Status,
 		CreatedAt:   dept.CreatedAt.Format("2006-01-02 15:04:05"),
 		UpdatedAt:   dept.UpdatedAt.Format("2006-01-02 15:04:05"),
+
+		AssignmentStrategy:     dept.AssignmentStrategy,
+		OnboardingWorkflowType: dept.OnboardingWorkflowType,
 	}
 
 	// 添加管理者信息
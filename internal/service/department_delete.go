@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+	"taskmanage/pkg/response"
+)
+
+var (
+	// ErrDepartmentInUse 部门仍被引用，详情为各类引用数
+	ErrDepartmentInUse = response.NewError(response.ErrCodeConflict, "部门仍被引用，不能删除")
+	// ErrInvalidReassignTarget 删除部门或职位时指定的迁移目标不合法
+	ErrInvalidReassignTarget = response.NewError(response.ErrCodeInvalidRequest, "无效的迁移目标")
+)
+
+// DeleteDepartment 删除部门
+// 部门仍被未离职员工、子部门、项目、权限模板或入职权限配置引用时返回ErrDepartmentInUse；
+// 指定reassignTo时在同一事务中将这些引用和审批链迁移到目标部门后再删除
+func (s *departmentService) DeleteDepartment(ctx context.Context, id uint, reassignTo *uint) error {
+	log := s.logger.WithField("id", id)
+	if reassignTo != nil {
+		log = log.WithField("reassign_to", *reassignTo)
+	}
+	log.Info("删除部门")
+
+	repo := s.repoManager.DepartmentRepository()
+	exists, err := repo.Exists(ctx, id)
+	if err != nil {
+		return fmt.Errorf("获取部门失败: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("%w: %d", ErrDepartmentNotFound, id)
+	}
+
+	var target *database.Department
+	if reassignTo != nil {
+		if target, err = s.getReassignTarget(ctx, id, *reassignTo); err != nil {
+			return err
+		}
+	}
+
+	result := &DepartmentMergeResult{SourceDepartmentID: id}
+	err = s.repoManager.WithTx(ctx, func(ctx context.Context, repos repository.RepositoryManager) error {
+		deptRepo := repos.DepartmentRepository()
+
+		if target == nil {
+			refs, err := deptRepo.CountReferences(ctx, id)
+			if err != nil {
+				return err
+			}
+			if refs.Total() > 0 {
+				return response.Wrapf(ErrDepartmentInUse, "未离职员工%d人、子部门%d个、项目%d个、权限模板%d个、入职权限配置%d个",
+					refs.Employees, refs.SubDepartments, refs.Projects, refs.PermissionTemplates, refs.OnboardingConfigs).WithDetails(refs)
+			}
+		} else {
+			descendants, err := deptRepo.GetSubDepartments(ctx, id)
+			if err != nil {
+				return fmt.Errorf("获取下级部门失败: %w", err)
+			}
+			for _, dept := range descendants {
+				if dept.ID == target.ID {
+					return response.Wrapf(ErrInvalidReassignTarget, "不能迁移到待删除部门的下级部门")
+				}
+			}
+
+			result.TargetDepartmentID = target.ID
+			source := &database.Department{BaseModel: database.BaseModel{ID: id}}
+			if err := s.moveDepartmentReferences(ctx, repos, descendants, source, target, result); err != nil {
+				return err
+			}
+		}
+
+		if err := deptRepo.Delete(ctx, id); err != nil {
+			return fmt.Errorf("删除部门失败: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		log.WithError(err).Error("删除部门失败")
+		return err
+	}
+
+	if target != nil {
+		log.WithFields(logrus.Fields{
+			"employees":       result.EmployeesMoved,
+			"projects":        result.ProjectsMoved,
+			"sub_departments": result.SubDepartmentsMoved,
+			"templates":       result.PermissionTemplatesMoved,
+			"onboarding":      result.OnboardingConfigsMoved,
+			"approval_chains": result.ApprovalChainsMoved,
+			"chains_dropped":  result.ApprovalChainsDropped,
+		}).Info("部门引用已迁移，部门已删除")
+	}
+	return nil
+}
+
+// getReassignTarget 获取删除部门时引用迁移到的目标部门，目标部门须存在且未停用
+func (s *departmentService) getReassignTarget(ctx context.Context, id, targetID uint) (*database.Department, error) {
+	if targetID == id {
+		return nil, response.Wrapf(ErrInvalidReassignTarget, "不能迁移到待删除的部门自身")
+	}
+
+	repo := s.repoManager.DepartmentRepository()
+	exists, err := repo.Exists(ctx, targetID)
+	if err != nil {
+		return nil, fmt.Errorf("获取目标部门失败: %w", err)
+	}
+	if !exists {
+		return nil, response.Wrapf(ErrInvalidReassignTarget, "目标部门%d不存在", targetID)
+	}
+
+	target, err := repo.GetByID(ctx, targetID)
+	if err != nil {
+		return nil, fmt.Errorf("获取目标部门失败: %w", err)
+	}
+	if target.Status == DepartmentStatusInactive {
+		return nil, response.Wrapf(ErrInvalidReassignTarget, "目标部门%s已停用", target.Name)
+	}
+	// 预加载的关联不参与后续保存
+	target.Parent, target.Children, target.Manager = nil, nil, nil
+	return target, nil
+}
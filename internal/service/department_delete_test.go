@@ -0,0 +1,290 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/config"
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+	"taskmanage/pkg/response"
+)
+
+// referenceMoves 记录引用迁移，格式为"仓储 原ID->目标ID"
+type referenceMoves []string
+
+func (m *referenceMoves) record(kind string, from, to uint) (int64, error) {
+	*m = append(*m, fmt.Sprintf("%s %d->%d", kind, from, to))
+	return 1, nil
+}
+
+// deleteDepartmentRepository 内存部门仓储，引用数按预设返回
+type deleteDepartmentRepository struct {
+	repository.DepartmentRepository
+	departments map[uint]*database.Department
+	references  map[uint]*repository.DepartmentReferences
+	deleted     []uint
+}
+
+func (r *deleteDepartmentRepository) Exists(ctx context.Context, id uint) (bool, error) {
+	_, ok := r.departments[id]
+	return ok, nil
+}
+
+func (r *deleteDepartmentRepository) GetByID(ctx context.Context, id uint) (*database.Department, error) {
+	department, ok := r.departments[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return department, nil
+}
+
+func (r *deleteDepartmentRepository) GetSubDepartments(ctx context.Context, departmentID uint) ([]*database.Department, error) {
+	var descendants []*database.Department
+	for _, dept := range r.departments {
+		if dept.ParentID != nil && *dept.ParentID == departmentID {
+			descendants = append(descendants, dept)
+			children, _ := r.GetSubDepartments(ctx, dept.ID)
+			descendants = append(descendants, children...)
+		}
+	}
+	return descendants, nil
+}
+
+func (r *deleteDepartmentRepository) CountReferences(ctx context.Context, departmentID uint) (*repository.DepartmentReferences, error) {
+	if refs, ok := r.references[departmentID]; ok {
+		return refs, nil
+	}
+	return &repository.DepartmentReferences{}, nil
+}
+
+func (r *deleteDepartmentRepository) Update(ctx context.Context, department *database.Department) error {
+	return nil
+}
+
+func (r *deleteDepartmentRepository) Delete(ctx context.Context, id uint) error {
+	r.deleted = append(r.deleted, id)
+	delete(r.departments, id)
+	return nil
+}
+
+// deletePositionRepository 内存职位仓储，引用数按预设返回
+type deletePositionRepository struct {
+	repository.PositionRepository
+	positions  map[uint]*database.Position
+	references map[uint]*repository.PositionReferences
+	deleted    []uint
+}
+
+func (r *deletePositionRepository) Exists(ctx context.Context, id uint) (bool, error) {
+	_, ok := r.positions[id]
+	return ok, nil
+}
+
+func (r *deletePositionRepository) GetByID(ctx context.Context, id uint) (*database.Position, error) {
+	position, ok := r.positions[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return position, nil
+}
+
+func (r *deletePositionRepository) CountReferences(ctx context.Context, positionID uint) (*repository.PositionReferences, error) {
+	if refs, ok := r.references[positionID]; ok {
+		return refs, nil
+	}
+	return &repository.PositionReferences{}, nil
+}
+
+func (r *deletePositionRepository) Delete(ctx context.Context, id uint) error {
+	r.deleted = append(r.deleted, id)
+	delete(r.positions, id)
+	return nil
+}
+
+type deleteEmployeeRepository struct {
+	repository.EmployeeRepository
+	moves *referenceMoves
+}
+
+func (r *deleteEmployeeRepository) MoveToDepartment(ctx context.Context, from, to uint) (int64, error) {
+	return r.moves.record("employees", from, to)
+}
+
+func (r *deleteEmployeeRepository) MoveToPosition(ctx context.Context, from, to uint) (int64, error) {
+	return r.moves.record("employees", from, to)
+}
+
+type deleteProjectRepository struct {
+	repository.ProjectRepository
+	moves *referenceMoves
+}
+
+func (r *deleteProjectRepository) MoveToDepartment(ctx context.Context, from, to uint) (int64, error) {
+	return r.moves.record("projects", from, to)
+}
+
+type deleteTemplateRepository struct {
+	repository.PermissionTemplateRepository
+	moves *referenceMoves
+}
+
+func (r *deleteTemplateRepository) MoveToDepartment(ctx context.Context, from, to uint) (int64, error) {
+	return r.moves.record("templates", from, to)
+}
+
+func (r *deleteTemplateRepository) MoveToPosition(ctx context.Context, from, to uint) (int64, error) {
+	return r.moves.record("templates", from, to)
+}
+
+type deleteOnboardingConfigRepository struct {
+	repository.OnboardingPermissionConfigRepository
+	moves *referenceMoves
+}
+
+func (r *deleteOnboardingConfigRepository) MoveToDepartment(ctx context.Context, from, to uint) (int64, error) {
+	return r.moves.record("onboarding", from, to)
+}
+
+func (r *deleteOnboardingConfigRepository) MoveToPosition(ctx context.Context, from, to uint) (int64, error) {
+	return r.moves.record("onboarding", from, to)
+}
+
+type deleteApprovalChainRepository struct {
+	repository.DepartmentApprovalChainRepository
+}
+
+func (r *deleteApprovalChainRepository) ListByDepartment(ctx context.Context, departmentID uint) ([]*database.DepartmentApprovalChain, error) {
+	return nil, nil
+}
+
+// deleteRepoManager 删除部门和职位测试用仓储管理器，事务直接在当前仓储上执行
+type deleteRepoManager struct {
+	repository.RepositoryManager
+	departments *deleteDepartmentRepository
+	positions   *deletePositionRepository
+	moves       referenceMoves
+}
+
+func (m *deleteRepoManager) DepartmentRepository() repository.DepartmentRepository {
+	return m.departments
+}
+
+func (m *deleteRepoManager) PositionRepository() repository.PositionRepository { return m.positions }
+
+func (m *deleteRepoManager) EmployeeRepository() repository.EmployeeRepository {
+	return &deleteEmployeeRepository{moves: &m.moves}
+}
+
+func (m *deleteRepoManager) ProjectRepository() repository.ProjectRepository {
+	return &deleteProjectRepository{moves: &m.moves}
+}
+
+func (m *deleteRepoManager) PermissionTemplateRepository() repository.PermissionTemplateRepository {
+	return &deleteTemplateRepository{moves: &m.moves}
+}
+
+func (m *deleteRepoManager) OnboardingPermissionConfigRepository() repository.OnboardingPermissionConfigRepository {
+	return &deleteOnboardingConfigRepository{moves: &m.moves}
+}
+
+func (m *deleteRepoManager) DepartmentApprovalChainRepository() repository.DepartmentApprovalChainRepository {
+	return &deleteApprovalChainRepository{}
+}
+
+func (m *deleteRepoManager) WithTx(ctx context.Context, fn func(ctx context.Context, repos repository.RepositoryManager) error) error {
+	return fn(ctx, m)
+}
+
+// newDeleteRepoManager 总部1下设研发部2和已停用的客服部4，研发部下设后端组3；职位5、6职级相同，职位7职级更高
+func newDeleteRepoManager() *deleteRepoManager {
+	headquarters, rd := uint(1), uint(2)
+	return &deleteRepoManager{
+		departments: &deleteDepartmentRepository{
+			departments: map[uint]*database.Department{
+				1: {BaseModel: database.BaseModel{ID: 1}, Name: "总部", Path: "/总部", Level: 1},
+				2: {BaseModel: database.BaseModel{ID: 2}, Name: "研发部", ParentID: &headquarters, Path: "/总部/研发部", Level: 2},
+				3: {BaseModel: database.BaseModel{ID: 3}, Name: "后端组", ParentID: &rd, Path: "/总部/研发部/后端组", Level: 3},
+				4: {BaseModel: database.BaseModel{ID: 4}, Name: "客服部", ParentID: &headquarters, Status: DepartmentStatusInactive},
+			},
+			references: map[uint]*repository.DepartmentReferences{
+				2: {Employees: 3, SubDepartments: 1, Projects: 2},
+			},
+		},
+		positions: &deletePositionRepository{
+			positions: map[uint]*database.Position{
+				5: {BaseModel: database.BaseModel{ID: 5}, Name: "后端工程师", Level: 3},
+				6: {BaseModel: database.BaseModel{ID: 6}, Name: "服务端工程师", Level: 3},
+				7: {BaseModel: database.BaseModel{ID: 7}, Name: "架构师", Level: 5},
+			},
+			references: map[uint]*repository.PositionReferences{
+				5: {Employees: 2, OnboardingConfigs: 1},
+			},
+		},
+	}
+}
+
+func TestDepartmentService_DeleteDepartment(t *testing.T) {
+	ctx := context.Background()
+	repos := newDeleteRepoManager()
+	svc := NewDepartmentService(repos, nil, nil, config.DepartmentConfig{}, logrus.New())
+
+	// 仍被引用时返回各类引用数
+	err := svc.DeleteDepartment(ctx, 2, nil)
+	require.ErrorIs(t, err, ErrDepartmentInUse)
+	appErr := response.GetAppError(err)
+	require.NotNil(t, appErr)
+	assert.Equal(t, &repository.DepartmentReferences{Employees: 3, SubDepartments: 1, Projects: 2}, appErr.Details)
+	assert.Empty(t, repos.departments.deleted)
+
+	// 迁移目标不能是自身、下级部门、已停用或不存在的部门
+	for _, target := range []uint{2, 3, 4, 99} {
+		err := svc.DeleteDepartment(ctx, 2, &target)
+		assert.ErrorIs(t, err, ErrInvalidReassignTarget, "reassign_to=%d", target)
+	}
+	assert.Empty(t, repos.moves)
+
+	// 指定迁移目标时迁移引用并重新计算子部门路径
+	target := uint(1)
+	require.NoError(t, svc.DeleteDepartment(ctx, 2, &target))
+	assert.Equal(t, referenceMoves{"employees 2->1", "projects 2->1", "templates 2->1", "onboarding 2->1"}, repos.moves)
+	assert.Equal(t, []uint{2}, repos.departments.deleted)
+	backend := repos.departments.departments[3]
+	assert.Equal(t, uint(1), *backend.ParentID)
+	assert.Equal(t, "/总部/后端组", backend.Path)
+	assert.Equal(t, 2, backend.Level)
+
+	// 没有引用的部门直接删除
+	require.NoError(t, svc.DeleteDepartment(ctx, 3, nil))
+	assert.ErrorIs(t, svc.DeleteDepartment(ctx, 3, nil), ErrDepartmentNotFound)
+}
+
+func TestPositionService_DeletePosition(t *testing.T) {
+	ctx := context.Background()
+	repos := newDeleteRepoManager()
+	svc := NewPositionService(repos, logrus.New())
+
+	err := svc.DeletePosition(ctx, 5, nil)
+	require.ErrorIs(t, err, ErrPositionInUse)
+	appErr := response.GetAppError(err)
+	require.NotNil(t, appErr)
+	assert.Equal(t, &repository.PositionReferences{Employees: 2, OnboardingConfigs: 1}, appErr.Details)
+
+	// 替换职位不能是自身、不存在或职级不同的职位
+	for _, replacement := range []uint{5, 7, 99} {
+		err := svc.DeletePosition(ctx, 5, &replacement)
+		assert.ErrorIs(t, err, ErrInvalidReassignTarget, "reassign_to=%d", replacement)
+	}
+	assert.Empty(t, repos.positions.deleted)
+
+	replacement := uint(6)
+	require.NoError(t, svc.DeletePosition(ctx, 5, &replacement))
+	assert.Equal(t, referenceMoves{"employees 5->6", "templates 5->6", "onboarding 5->6"}, repos.moves)
+	assert.Equal(t, []uint{5}, repos.positions.deleted)
+	assert.ErrorIs(t, svc.DeletePosition(ctx, 5, nil), ErrPositionNotFound)
+}
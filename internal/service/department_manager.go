@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+// maxDepartmentDepth 向上查找上级部门的最大层数，防止父部门成环时死循环
+const maxDepartmentDepth = 32
+
+// ErrInvalidDepartmentManager 部门管理者不合法
+var ErrInvalidDepartmentManager = errors.New("部门管理者不合法")
+
+// UpdateManager 校验并更新部门管理者
+// SyncDirectManager为true时在同一事务中为部门内没有直接上级的员工设置直接上级为新管理者
+func (s *departmentService) UpdateManager(ctx context.Context, departmentID uint, req *UpdateDepartmentManagerRequest) (*DepartmentManagerResult, error) {
+	log := s.logger.WithFields(logrus.Fields{
+		"department_id": departmentID,
+		"manager_id":    req.ManagerID,
+	})
+	log.Info("更新部门管理者")
+
+	repo := s.repoManager.DepartmentRepository()
+	exists, err := repo.Exists(ctx, departmentID)
+	if err != nil {
+		return nil, fmt.Errorf("获取部门失败: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("%w: %d", ErrDepartmentNotFound, departmentID)
+	}
+	department, err := repo.GetByID(ctx, departmentID)
+	if err != nil {
+		return nil, fmt.Errorf("获取部门失败: %w", err)
+	}
+	if err := s.validateManager(ctx, department, req.ManagerID); err != nil {
+		return nil, err
+	}
+
+	result := &DepartmentManagerResult{DepartmentID: departmentID, ManagerID: req.ManagerID}
+	err = s.repoManager.WithTx(ctx, func(ctx context.Context, repos repository.RepositoryManager) error {
+		if err := repos.DepartmentRepository().UpdateManager(ctx, departmentID, req.ManagerID); err != nil {
+			return fmt.Errorf("更新部门管理者失败: %w", err)
+		}
+		if !req.SyncDirectManager {
+			return nil
+		}
+		result.DirectManagersSynced, err = repos.EmployeeRepository().AssignDirectManager(ctx, departmentID, req.ManagerID)
+		return err
+	})
+	if err != nil {
+		log.WithError(err).Error("更新部门管理者失败")
+		return nil, err
+	}
+
+	log.WithField("direct_managers_synced", result.DirectManagersSynced).Info("部门管理者已更新")
+	return result, nil
+}
+
+// validateManager 校验管理者是在职员工，且属于该部门；允许上级部门管理时也可以属于任一上级部门
+// department可以是尚未保存的部门，此时只能由上级部门的员工担任
+func (s *departmentService) validateManager(ctx context.Context, department *database.Department, managerID uint) error {
+	manager, err := s.repoManager.EmployeeRepository().GetByID(ctx, managerID)
+	if err != nil {
+		if repository.IsNotFoundError(err) {
+			return fmt.Errorf("%w: 员工%d不存在", ErrInvalidDepartmentManager, managerID)
+		}
+		return fmt.Errorf("获取员工失败: %w", err)
+	}
+	if manager.Status == "resigned" || (manager.OnboardingStatus != EmployeeStatusActive && manager.OnboardingStatus != EmployeeStatusProbation) {
+		return fmt.Errorf("%w: 员工%s不是在职员工", ErrInvalidDepartmentManager, manager.EmployeeNo)
+	}
+	if manager.DepartmentID == nil {
+		return fmt.Errorf("%w: 员工%s未分配部门", ErrInvalidDepartmentManager, manager.EmployeeNo)
+	}
+	if department.ID != 0 && *manager.DepartmentID == department.ID {
+		return nil
+	}
+	if !s.allowAncestorManager {
+		return fmt.Errorf("%w: 员工%s不属于该部门", ErrInvalidDepartmentManager, manager.EmployeeNo)
+	}
+
+	repo := s.repoManager.DepartmentRepository()
+	parentID := department.ParentID
+	for depth := 0; parentID != nil && depth < maxDepartmentDepth; depth++ {
+		if *parentID == *manager.DepartmentID {
+			return nil
+		}
+		parent, err := repo.GetByID(ctx, *parentID)
+		if err != nil {
+			return fmt.Errorf("获取上级部门失败: %w", err)
+		}
+		parentID = parent.ParentID
+	}
+	return fmt.Errorf("%w: 员工%s不属于该部门或其上级部门", ErrInvalidDepartmentManager, manager.EmployeeNo)
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/config"
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+// managerDepartmentRepository 内存部门仓储，记录部门管理者的更新
+type managerDepartmentRepository struct {
+	repository.DepartmentRepository
+	departments map[uint]*database.Department
+}
+
+func (r *managerDepartmentRepository) Exists(ctx context.Context, id uint) (bool, error) {
+	_, ok := r.departments[id]
+	return ok, nil
+}
+
+func (r *managerDepartmentRepository) GetByID(ctx context.Context, id uint) (*database.Department, error) {
+	department, ok := r.departments[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return department, nil
+}
+
+func (r *managerDepartmentRepository) UpdateManager(ctx context.Context, departmentID, managerID uint) error {
+	r.departments[departmentID].ManagerID = &managerID
+	return nil
+}
+
+// managerEmployeeRepository 为没有直接上级的部门员工设置直接上级的员工仓储
+type managerEmployeeRepository struct {
+	escalationEmployeeRepository
+}
+
+func (r *managerEmployeeRepository) AssignDirectManager(ctx context.Context, departmentID, managerID uint) (int64, error) {
+	var updated int64
+	for _, employee := range r.employees {
+		if employee.DepartmentID != nil && *employee.DepartmentID == departmentID && employee.DirectManagerID == nil && employee.ID != managerID && employee.Status != "resigned" {
+			employee.DirectManagerID = &managerID
+			updated++
+		}
+	}
+	return updated, nil
+}
+
+// departmentManagerRepoManager 部门管理者测试用仓储管理器
+type departmentManagerRepoManager struct {
+	repository.RepositoryManager
+	departments *managerDepartmentRepository
+	employees   *managerEmployeeRepository
+}
+
+func (m *departmentManagerRepoManager) DepartmentRepository() repository.DepartmentRepository {
+	return m.departments
+}
+
+func (m *departmentManagerRepoManager) EmployeeRepository() repository.EmployeeRepository {
+	return m.employees
+}
+
+func (m *departmentManagerRepoManager) WithTx(ctx context.Context, fn func(ctx context.Context, repos repository.RepositoryManager) error) error {
+	return fn(ctx, m)
+}
+
+// newDepartmentManagerRepoManager 总部1下设研发部2和客服部4，研发部下设后端组3；员工10属于总部，员工3x属于后端组
+func newDepartmentManagerRepoManager() *departmentManagerRepoManager {
+	headquarters, rd, backend, support := uint(1), uint(2), uint(3), uint(4)
+	department := func(id uint, parentID *uint) *database.Department {
+		return &database.Department{BaseModel: database.BaseModel{ID: id}, ParentID: parentID}
+	}
+	employee := func(id uint, departmentID *uint, status, onboardingStatus string) *database.Employee {
+		return &database.Employee{BaseModel: database.BaseModel{ID: id}, EmployeeNo: fmt.Sprintf("E%03d", id), DepartmentID: departmentID, Status: status, OnboardingStatus: onboardingStatus}
+	}
+	lead := uint(10)
+	backendLead := employee(31, &backend, "available", EmployeeStatusActive)
+	backendLead.DirectManagerID = &lead
+
+	return &departmentManagerRepoManager{
+		departments: &managerDepartmentRepository{departments: map[uint]*database.Department{
+			headquarters: department(headquarters, nil),
+			rd:           department(rd, &headquarters),
+			backend:      department(backend, &rd),
+			support:      department(support, &headquarters),
+		}},
+		employees: &managerEmployeeRepository{escalationEmployeeRepository{employees: []*database.Employee{
+			employee(10, &headquarters, "available", EmployeeStatusActive),
+			employee(30, &backend, "available", EmployeeStatusProbation),
+			backendLead,
+			employee(32, &backend, "busy", EmployeeStatusActive),
+			employee(33, &backend, "resigned", EmployeeStatusInactive),
+			employee(34, &backend, "available", EmployeeStatusPendingOnboard),
+			employee(40, &support, "available", EmployeeStatusActive),
+		}}},
+	}
+}
+
+func TestDepartmentService_UpdateManagerValidation(t *testing.T) {
+	repos := newDepartmentManagerRepoManager()
+	svc := NewDepartmentService(repos, nil, nil, config.DepartmentConfig{AllowAncestorManager: true}, logrus.New())
+	ctx := context.Background()
+	update := func(departmentID, managerID uint) error {
+		_, err := svc.UpdateManager(ctx, departmentID, &UpdateDepartmentManagerRequest{ManagerID: managerID})
+		return err
+	}
+
+	// 本部门的试用期员工和上级部门的员工都可以担任
+	require.NoError(t, update(3, 30))
+	require.NoError(t, update(3, 10))
+	assert.Equal(t, uint(10), *repos.departments.departments[3].ManagerID)
+
+	// 离职、未入职、不存在和其他分支部门的员工不能担任
+	for _, managerID := range []uint{33, 34, 99, 40} {
+		assert.ErrorIs(t, update(3, managerID), ErrInvalidDepartmentManager, "manager %d", managerID)
+	}
+	assert.ErrorIs(t, update(99, 30), ErrDepartmentNotFound)
+
+	// 关闭上级部门管理后只能由本部门员工担任
+	strict := NewDepartmentService(repos, nil, nil, config.DepartmentConfig{}, logrus.New())
+	_, err := strict.UpdateManager(ctx, 3, &UpdateDepartmentManagerRequest{ManagerID: 10})
+	assert.ErrorIs(t, err, ErrInvalidDepartmentManager)
+
+	// 新建部门只能由上级部门的员工担任
+	rd, member := uint(2), uint(30)
+	_, err = strict.CreateDepartment(ctx, &CreateDepartmentRequest{Name: "前端组", ParentID: &rd, Path: "/总部/研发部/前端组", ManagerID: &member})
+	assert.ErrorIs(t, err, ErrInvalidDepartmentManager)
+}
+
+func TestDepartmentService_UpdateManagerSyncDirectManager(t *testing.T) {
+	repos := newDepartmentManagerRepoManager()
+	svc := NewDepartmentService(repos, nil, nil, config.DepartmentConfig{AllowAncestorManager: true}, logrus.New())
+
+	result, err := svc.UpdateManager(context.Background(), 3, &UpdateDepartmentManagerRequest{ManagerID: 30, SyncDirectManager: true})
+	require.NoError(t, err)
+	assert.Equal(t, &DepartmentManagerResult{DepartmentID: 3, ManagerID: 30, DirectManagersSynced: 2}, result)
+
+	// 已有直接上级的员工、管理者本人和离职员工不变
+	managers := map[uint]*uint{}
+	for _, employee := range repos.employees.employees {
+		managers[employee.ID] = employee.DirectManagerID
+	}
+	assert.Nil(t, managers[30])
+	assert.Equal(t, uint(10), *managers[31])
+	assert.Equal(t, uint(30), *managers[32])
+	assert.Nil(t, managers[33])
+	assert.Equal(t, uint(30), *managers[34])
+	assert.Nil(t, managers[40])
+}
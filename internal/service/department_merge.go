@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+// DepartmentStatusInactive 已停用部门的状态，合并后的源部门保留记录以便追溯历史
+const DepartmentStatusInactive = "inactive"
+
+var (
+	// ErrDepartmentNotFound 部门不存在
+	ErrDepartmentNotFound = errors.New("部门不存在")
+	// ErrInvalidDepartmentMerge 部门合并参数不合法
+	ErrInvalidDepartmentMerge = errors.New("部门合并不合法")
+)
+
+// MergeDepartment 将源部门合并到目标部门
+// 在同一事务中迁移员工、项目、子部门、部门专属权限模板、入职权限配置和审批链，并停用源部门
+func (s *departmentService) MergeDepartment(ctx context.Context, sourceID uint, req *MergeDepartmentRequest) (*DepartmentMergeResult, error) {
+	targetID := req.TargetDepartmentID
+	log := s.logger.WithFields(logrus.Fields{
+		"source_department_id": sourceID,
+		"target_department_id": targetID,
+	})
+	log.Info("合并部门")
+
+	if sourceID == targetID {
+		return nil, fmt.Errorf("%w: 不能合并到自身", ErrInvalidDepartmentMerge)
+	}
+	source, err := s.getActiveDepartment(ctx, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	target, err := s.getActiveDepartment(ctx, targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &DepartmentMergeResult{SourceDepartmentID: sourceID, TargetDepartmentID: targetID}
+	err = s.repoManager.WithTx(ctx, func(ctx context.Context, repos repository.RepositoryManager) error {
+		deptRepo := repos.DepartmentRepository()
+
+		descendants, err := deptRepo.GetSubDepartments(ctx, sourceID)
+		if err != nil {
+			return fmt.Errorf("获取下级部门失败: %w", err)
+		}
+		for _, dept := range descendants {
+			if dept.ID == targetID {
+				return fmt.Errorf("%w: 不能合并到自身的下级部门", ErrInvalidDepartmentMerge)
+			}
+		}
+
+		if err := s.moveDepartmentReferences(ctx, repos, descendants, source, target, result); err != nil {
+			return err
+		}
+
+		source.Status = DepartmentStatusInactive
+		if err := deptRepo.Update(ctx, source); err != nil {
+			return fmt.Errorf("停用源部门失败: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		log.WithError(err).Error("合并部门失败")
+		return nil, err
+	}
+	result.MergedAt = time.Now()
+
+	log.WithFields(logrus.Fields{
+		"employees":       result.EmployeesMoved,
+		"projects":        result.ProjectsMoved,
+		"sub_departments": result.SubDepartmentsMoved,
+		"templates":       result.PermissionTemplatesMoved,
+		"onboarding":      result.OnboardingConfigsMoved,
+		"approval_chains": result.ApprovalChainsMoved,
+		"chains_dropped":  result.ApprovalChainsDropped,
+	}).Info("部门合并完成")
+
+	s.notifyMerge(ctx, source, target, result)
+	return result, nil
+}
+
+// moveDepartmentReferences 将源部门的员工、项目、子部门、部门专属权限模板、入职权限配置和审批链迁移到目标部门
+// descendants为源部门的全部下级部门，需在事务内调用
+func (s *departmentService) moveDepartmentReferences(ctx context.Context, repos repository.RepositoryManager, descendants []*database.Department, source, target *database.Department, result *DepartmentMergeResult) error {
+	var err error
+	if result.EmployeesMoved, err = repos.EmployeeRepository().MoveToDepartment(ctx, source.ID, target.ID); err != nil {
+		return err
+	}
+	if result.ProjectsMoved, err = repos.ProjectRepository().MoveToDepartment(ctx, source.ID, target.ID); err != nil {
+		return fmt.Errorf("迁移部门项目失败: %w", err)
+	}
+	if result.PermissionTemplatesMoved, err = repos.PermissionTemplateRepository().MoveToDepartment(ctx, source.ID, target.ID); err != nil {
+		return fmt.Errorf("迁移部门权限模板失败: %w", err)
+	}
+	if result.OnboardingConfigsMoved, err = repos.OnboardingPermissionConfigRepository().MoveToDepartment(ctx, source.ID, target.ID); err != nil {
+		return fmt.Errorf("迁移入职权限配置失败: %w", err)
+	}
+
+	if err := s.rebaseSubDepartments(ctx, repos.DepartmentRepository(), descendants, source, target, result); err != nil {
+		return err
+	}
+	return s.mergeApprovalChains(ctx, repos.DepartmentApprovalChainRepository(), source.ID, target.ID, result)
+}
+
+// getActiveDepartment 获取未停用的部门
+func (s *departmentService) getActiveDepartment(ctx context.Context, id uint) (*database.Department, error) {
+	repo := s.repoManager.DepartmentRepository()
+	exists, err := repo.Exists(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("获取部门失败: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("%w: %d", ErrDepartmentNotFound, id)
+	}
+
+	department, err := repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("获取部门失败: %w", err)
+	}
+	if department.Status == DepartmentStatusInactive {
+		return nil, fmt.Errorf("%w: 部门%s已停用", ErrInvalidDepartmentMerge, department.Name)
+	}
+	// 预加载的关联不参与后续保存
+	department.Parent, department.Children, department.Manager = nil, nil, nil
+	return department, nil
+}
+
+// rebaseSubDepartments 将直属子部门挂到目标部门下，并重新计算全部下级部门的路径和层级
+func (s *departmentService) rebaseSubDepartments(ctx context.Context, repo repository.DepartmentRepository, descendants []*database.Department, source, target *database.Department, result *DepartmentMergeResult) error {
+	children := make(map[uint][]*database.Department)
+	for _, dept := range descendants {
+		// 递归查询不过滤软删除记录
+		if dept.DeletedAt.Valid {
+			continue
+		}
+		if dept.ParentID != nil {
+			children[*dept.ParentID] = append(children[*dept.ParentID], dept)
+		}
+	}
+
+	var rebase func(parentID uint, parent *database.Department) error
+	rebase = func(parentID uint, parent *database.Department) error {
+		for _, dept := range children[parentID] {
+			dept.ParentID = &parent.ID
+			dept.Path = parent.Path + "/" + dept.Name
+			dept.Level = parent.Level + 1
+			if err := repo.Update(ctx, dept); err != nil {
+				return fmt.Errorf("更新下级部门失败: %w", err)
+			}
+			result.DepartmentPathsUpdated++
+			if err := rebase(dept.ID, dept); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	result.SubDepartmentsMoved = len(children[source.ID])
+	return rebase(source.ID, target)
+}
+
+// mergeApprovalChains 迁移源部门审批链，目标部门已配置同业务类型审批链时以目标部门为准
+func (s *departmentService) mergeApprovalChains(ctx context.Context, repo repository.DepartmentApprovalChainRepository, sourceID, targetID uint, result *DepartmentMergeResult) error {
+	chains, err := repo.ListByDepartment(ctx, sourceID)
+	if err != nil {
+		return fmt.Errorf("获取部门审批链失败: %w", err)
+	}
+
+	for _, chain := range chains {
+		_, err := repo.GetByDepartmentAndBusinessType(ctx, targetID, chain.BusinessType)
+		switch {
+		case err == nil:
+			if err := repo.Delete(ctx, chain.ID); err != nil {
+				return fmt.Errorf("删除重复审批链失败: %w", err)
+			}
+			result.ApprovalChainsDropped++
+		case repository.IsNotFoundError(err):
+			chain.DepartmentID = targetID
+			chain.Department = database.Department{}
+			if err := repo.Update(ctx, chain); err != nil {
+				return fmt.Errorf("迁移部门审批链失败: %w", err)
+			}
+			result.ApprovalChainsMoved++
+		default:
+			return fmt.Errorf("查询目标部门审批链失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// notifyMerge 通知目标部门负责人，失败只记录日志
+func (s *departmentService) notifyMerge(ctx context.Context, source, target *database.Department, result *DepartmentMergeResult) {
+	if s.notificationService == nil || target.ManagerID == nil {
+		return
+	}
+
+	manager, err := s.repoManager.EmployeeRepository().GetByID(ctx, *target.ManagerID)
+	if err != nil {
+		s.logger.WithError(err).Warn("获取目标部门负责人失败，跳过合并通知")
+		return
+	}
+
+	content := fmt.Sprintf("部门「%s」已合并到「%s」：迁入员工%d人、项目%d个、子部门%d个、审批链%d条",
+		source.Name, target.Name, result.EmployeesMoved, result.ProjectsMoved, result.SubDepartmentsMoved, result.ApprovalChainsMoved)
+	if err := s.notificationService.CreateSystemNotification(ctx, manager.UserID, "部门合并", content); err != nil {
+		s.logger.WithError(err).Warn("发送部门合并通知失败")
+	}
+}
@@ -1,9 +1,11 @@
 package service
 
 import (
+	"encoding/json"
 	"time"
 
 	"taskmanage/internal/database"
+	"taskmanage/internal/workflow"
 )
 
 // 用户相关DTO
@@ -48,6 +50,17 @@ type ChangePasswordRequest struct {
 	NewPassword string `json:"new_password" binding:"required,min=6"`
 }
 
+// ActivateAccountRequest 账号激活请求
+type ActivateAccountRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=6"`
+}
+
+// ResendActivationRequest 重新发送激活邮件请求
+type ResendActivationRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
 // 通用请求和响应类型
 type ListRequest struct {
 	Page     int    `json:"page" form:"page" binding:"omitempty,min=1"`
@@ -67,7 +80,7 @@ type ListResponse[T any] struct {
 // 任务分配审批相关DTO
 type StartTaskAssignmentApprovalRequest struct {
 	TaskID         uint   `json:"task_id" binding:"required"`
-	AssigneeID     uint   `json:"assignee_id" binding:"required"`
+	AssigneeID     uint   `json:"assignee_id" binding:"required"` // 被分配员工ID（Employee.ID）
 	AssignmentType string `json:"assignment_type" binding:"required"`
 	Priority       string `json:"priority"`
 	RequesterID    uint   `json:"requester_id" binding:"required"`
@@ -81,6 +94,7 @@ type ProcessTaskAssignmentApprovalRequest struct {
 	Comment    string                 `json:"comment,omitempty"`
 	Variables  map[string]interface{} `json:"variables,omitempty"`
 	ApprovedBy uint                   `json:"approved_by" binding:"required"`
+	DelegateTo uint                   `json:"delegate_to,omitempty"`
 }
 
 type TaskAssignmentApprovalResponse struct {
@@ -105,27 +119,65 @@ type PendingTaskAssignmentApproval struct {
 }
 
 type PermissionResponse struct {
-	ID       uint   `json:"id"`
-	Name     string `json:"name"`
-	Resource string `json:"resource"`
-	Action   string `json:"action"`
+	ID          uint      `json:"id"`
+	Name        string    `json:"name"`
+	DisplayName string    `json:"display_name"`
+	Description string    `json:"description"`
+	Resource    string    `json:"resource"`
+	Action      string    `json:"action"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
 }
 
 // 任务相关DTO
 type CreateTaskRequest struct {
-	Title          string    `json:"title" binding:"required"`
-	Description    string    `json:"description"`
-	Priority       string    `json:"priority" binding:"required,oneof=low medium high urgent"`
-	DueDate        time.Time `json:"due_date"`
-	RequiredSkills []string  `json:"required_skills"`
+	Title          string                 `json:"title" binding:"required"`
+	Description    string                 `json:"description"`
+	Priority       string                 `json:"priority" binding:"required,oneof=low medium high urgent"`
+	DueDate        time.Time              `json:"due_date"`
+	RequiredSkills []TaskSkillRequirement `json:"required_skills"`
+	// AutoCompleteOnChildren 最后一个子任务完成时自动完成本任务
+	AutoCompleteOnChildren bool `json:"auto_complete_on_children"`
+	// RequiresReview 负责人提交完成后需审核人确认，ReviewerID为空时由创建者审核
+	RequiresReview bool  `json:"requires_review"`
+	ReviewerID     *uint `json:"reviewer_id,omitempty"` // 审核人用户ID
+}
+
+// TaskSkillRequirement 任务技能要求，JSON中也可直接写技能名称字符串，等级默认为1
+type TaskSkillRequirement struct {
+	Name  string `json:"name"`
+	Level int    `json:"level"` // 所需技能等级 1-5
+}
+
+// UnmarshalJSON 兼容 "Go" 与 {"name":"Go","level":3} 两种写法
+func (r *TaskSkillRequirement) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*r = TaskSkillRequirement{Name: name}
+		return nil
+	}
+
+	type plain TaskSkillRequirement
+	var value plain
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	*r = TaskSkillRequirement(value)
+	return nil
 }
 
 type UpdateTaskRequest struct {
-	Title       *string    `json:"title,omitempty"`
-	Description *string    `json:"description,omitempty"`
-	Priority    *string    `json:"priority,omitempty" binding:"omitempty,oneof=low medium high urgent"`
-	Status      *string    `json:"status,omitempty"`
-	DueDate     *time.Time `json:"due_date,omitempty"`
+	Title          *string                 `json:"title,omitempty"`
+	Description    *string                 `json:"description,omitempty"`
+	Priority       *string                 `json:"priority,omitempty" binding:"omitempty,oneof=low medium high urgent"`
+	Status         *string                 `json:"status,omitempty"`
+	DueDate        *time.Time              `json:"due_date,omitempty"`
+	RequiredSkills *[]TaskSkillRequirement `json:"required_skills,omitempty"` // 传入时整体替换技能要求，空数组表示清空
+	Version        *uint                   `json:"version,omitempty"`         // 读取任务时的版本号，与当前版本不一致时返回409
+	// AutoCompleteOnChildren 最后一个子任务完成时自动完成本任务
+	AutoCompleteOnChildren *bool `json:"auto_complete_on_children,omitempty"`
+	RequiresReview         *bool `json:"requires_review,omitempty"`
+	ReviewerID             *uint `json:"reviewer_id,omitempty"` // 审核人用户ID，传0清除后由创建者审核
 }
 
 type TaskResponse struct {
@@ -139,27 +191,72 @@ type TaskResponse struct {
 	AssignedTo  *uint      `json:"assigned_to,omitempty"`
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
+	Version     uint       `json:"version"` // 乐观锁版本号，更新时原样带回
+	Archived    bool       `json:"archived,omitempty"`
+	ArchivedAt  *time.Time `json:"archived_at,omitempty"` // 仅在获取单个归档任务时返回
+
+	AutoCompleteOnChildren bool                `json:"auto_complete_on_children"`
+	RequiresReview         bool                `json:"requires_review"`
+	ReviewerID             *uint               `json:"reviewer_id,omitempty"`
+	SubtaskProgress        *SubtaskProgress    `json:"subtask_progress,omitempty"` // 没有子任务时为空
+	RequiredSkills         []TaskSkillResponse `json:"required_skills,omitempty"`
+	ProjectID              *uint               `json:"project_id,omitempty"`
+
+	// 以下字段仅在查询参数expand包含对应项时返回
+	Assignee *TaskUserSummary    `json:"assignee,omitempty"`
+	Creator  *TaskUserSummary    `json:"creator,omitempty"`
+	Project  *TaskProjectSummary `json:"project,omitempty"`
+}
+
+// SubtaskProgress 子任务完成进度，已取消的子任务不计入
+type SubtaskProgress struct {
+	CompletedSubtasks int     `json:"completed_subtasks"`
+	TotalSubtasks     int     `json:"total_subtasks"`
+	Percentage        float64 `json:"percentage"` // 0-100，保留一位小数
+}
+
+// TaskSkillResponse 任务技能要求响应
+type TaskSkillResponse struct {
+	SkillID uint   `json:"skill_id"`
+	Name    string `json:"name"`
+	Level   int    `json:"level"`
 }
 
+// 任务分配方式
+const (
+	AssignMethodManual          = "manual"
+	AssignMethodAutoRoundRobin  = "auto_round_robin"
+	AssignMethodAutoLoadBalance = "auto_load_balance"
+	AssignMethodAutoSkillMatch  = "auto_skill_match"
+)
+
+// AssignTaskRequest 分配任务请求，按员工ID分配，任务负责人保存为该员工的用户ID
 type AssignTaskRequest struct {
 	TaskID     uint   `json:"task_id"`
-	AssigneeID uint   `json:"assignee_id" binding:"required"` // 被分配人ID
-	Method     string `json:"method,omitempty"`               // 分配方式
+	EmployeeID uint   `json:"employee_id" binding:"required"` // 被分配员工ID（Employee.ID，不是用户ID）
+	Method     string `json:"method,omitempty"`               // 分配方式，见AssignMethod*常量，默认manual
 	Reason     string `json:"reason,omitempty"`               // 分配原因
+	// RequireApproval 未使用审批流程时仍需审批，分配记录保存为待审批，由有task:approve权限的用户审批
+	RequireApproval bool `json:"require_approval,omitempty"`
+	// AllowNonMember 紧急情况下允许分配给任务所属项目以外的员工，员工会被加入项目并记录操作人
+	AllowNonMember bool `json:"allow_non_member,omitempty"`
 }
 
 type ReassignTaskRequest struct {
 	FromEmployeeID uint   `json:"from_employee_id" binding:"required"`
 	ToEmployeeID   uint   `json:"to_employee_id" binding:"required"`
 	Reason         string `json:"reason" binding:"required"`
+	OperatorID     uint   `json:"-"` // 操作人用户ID，由处理器从登录信息填充
 }
 
 type ApproveAssignmentRequest struct {
-	Comment string `json:"comment"`
+	Comment    string `json:"comment"`
+	ApproverID uint   `json:"-"` // 审批人用户ID，由处理器从登录信息填充
 }
 
 type RejectAssignmentRequest struct {
-	Reason string `json:"reason" binding:"required"`
+	Reason     string `json:"reason" binding:"required"`
+	ApproverID uint   `json:"-"` // 审批人用户ID，由处理器从登录信息填充
 }
 
 type CompleteTaskRequest struct {
@@ -167,15 +264,29 @@ type CompleteTaskRequest struct {
 	Files   []string `json:"files"`
 }
 
+// ApproveTaskReviewRequest 审核通过任务请求
+type ApproveTaskReviewRequest struct {
+	Comment    string `json:"comment"`
+	ReviewerID uint   `json:"-"` // 审核人用户ID，由处理器从登录信息填充
+}
+
+// RejectTaskReviewRequest 审核驳回任务请求，任务退回进行中
+type RejectTaskReviewRequest struct {
+	Comment    string `json:"comment" binding:"required"`
+	ReviewerID uint   `json:"-"` // 审核人用户ID，由处理器从登录信息填充
+}
+
 type AssignmentResponse struct {
-	ID                 uint      `json:"id"`
-	TaskID             uint      `json:"task_id"`
-	EmployeeID         uint      `json:"employee_id"`
-	Status             string    `json:"status"`
-	AssignedBy         uint      `json:"assigned_by"`
-	AssignedAt         time.Time `json:"assigned_at"`
-	WorkflowInstanceID string    `json:"workflow_instance_id,omitempty"` // 工作流实例ID
-	Comment            string    `json:"comment"`
+	ID                 uint       `json:"id"`
+	TaskID             uint       `json:"task_id"`
+	EmployeeID         uint       `json:"employee_id"`
+	Status             string     `json:"status"`
+	AssignedBy         uint       `json:"assigned_by"`
+	AssignedAt         time.Time  `json:"assigned_at"`
+	WorkflowInstanceID string     `json:"workflow_instance_id,omitempty"` // 工作流实例ID
+	PreviousEmployeeID uint       `json:"previous_employee_id,omitempty"` // 重新分配前的负责人员工ID
+	StartedAt          *time.Time `json:"started_at,omitempty"`           // 进行中任务重新分配后保留的开始时间
+	Comment            string     `json:"comment"`
 }
 
 // type AssignmentSuggestion struct {
@@ -215,6 +326,8 @@ type UpdateEmployeeRequest struct {
 	Projects           []string          `json:"projects"`
 	MaxConcurrentTasks *int              `json:"max_concurrent_tasks"`
 	WorkHours          *WorkHoursRequest `json:"work_hours"`
+	Version            *uint             `json:"version,omitempty"` // 读取员工时的版本号，与当前版本不一致时返回409
+	OperatorID         uint              `json:"-"`
 }
 
 type EmployeeResponse struct {
@@ -230,6 +343,10 @@ type EmployeeResponse struct {
 	CurrentTasks       int             `json:"current_tasks"`
 	CreatedAt          string          `json:"created_at"`
 	UpdatedAt          string          `json:"updated_at"`
+	Version            uint            `json:"version"`
+
+	// PermissionChange 更新员工时职级变化引起的权限调整，仅在职级变化时返回
+	PermissionChange *PositionPermissionChangeResponse `json:"permission_change,omitempty"`
 }
 
 type SkillRequest struct {
@@ -272,12 +389,82 @@ type UpdateSkillRequest struct {
 type SkillResponse struct {
 	ID          uint   `json:"id"`
 	Name        string `json:"name"`
-	Category    string `json:"category"`
+	CategoryID  *uint  `json:"category_id,omitempty"`
+	Category    string `json:"category"` // 分类名称
 	Description string `json:"description"`
 	//Tags        []string `json:"tags"`
 	Level     int    `json:"level,omitempty"` // 员工技能级别 (1-5)
 	CreatedAt string `json:"created_at"`
 	UpdatedAt string `json:"updated_at"`
+
+	// 员工技能认证信息，仅员工技能列表返回
+	EndorsedBy        *uint      `json:"endorsed_by,omitempty"`
+	EndorsedAt        *time.Time `json:"endorsed_at,omitempty"`
+	ExpiresAt         *time.Time `json:"expires_at,omitempty"`
+	EndorsementStatus string     `json:"endorsement_status,omitempty"` // unendorsed/endorsed/expiring/expired
+}
+
+// SkillCategoryResponse 技能分类及其技能数量
+type SkillCategoryResponse struct {
+	ID          uint   `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	SkillCount  int64  `json:"skill_count"`
+}
+
+// UpdateSkillCategoryRequest 重命名技能分类请求
+type UpdateSkillCategoryRequest struct {
+	Name        string  `json:"name" binding:"required,min=1,max=50"`
+	Description *string `json:"description,omitempty" binding:"omitempty,max=255"`
+}
+
+// MergeSkillCategoryRequest 合并技能分类请求，源分类下的技能全部改挂到目标分类
+type MergeSkillCategoryRequest struct {
+	TargetID uint `json:"target_id" binding:"required"`
+}
+
+// MergeSkillCategoryResponse 合并技能分类结果
+type MergeSkillCategoryResponse struct {
+	Target      *SkillCategoryResponse `json:"target"`
+	MovedSkills int64                  `json:"moved_skills"`
+}
+
+// 员工技能认证状态
+const (
+	SkillEndorsementUnendorsed = "unendorsed" // 未认证，等级为员工自行申报
+	SkillEndorsementEndorsed   = "endorsed"   // 已认证且在有效期内
+	SkillEndorsementExpiring   = "expiring"   // 已认证，将在提醒窗口内到期
+	SkillEndorsementExpired    = "expired"    // 认证已到期
+)
+
+// SkillExpiryNoticeWindow 技能认证到期前发送提醒的提前量
+const SkillExpiryNoticeWindow = 30 * 24 * time.Hour
+
+// EmployeeSkillToResponse 转换员工技能响应，认证状态按now计算
+func EmployeeSkillToResponse(skill *database.EmployeeSkillDetail, now time.Time) SkillResponse {
+	resp := SkillResponse{
+		ID:                skill.SkillID,
+		Name:              skill.Name,
+		Category:          skill.Category,
+		Description:       skill.Description,
+		Level:             skill.Level,
+		CreatedAt:         skill.CreatedAt.Format("2006-01-02 15:04:05"),
+		UpdatedAt:         skill.UpdatedAt.Format("2006-01-02 15:04:05"),
+		EndorsedBy:        skill.EndorsedBy,
+		EndorsedAt:        skill.EndorsedAt,
+		ExpiresAt:         skill.ExpiresAt,
+		EndorsementStatus: SkillEndorsementUnendorsed,
+	}
+
+	switch {
+	case skill.IsExpired(now):
+		resp.EndorsementStatus = SkillEndorsementExpired
+	case skill.ExpiresAt != nil && skill.ExpiresAt.Sub(now) <= SkillExpiryNoticeWindow:
+		resp.EndorsementStatus = SkillEndorsementExpiring
+	case skill.EndorsedAt != nil:
+		resp.EndorsementStatus = SkillEndorsementEndorsed
+	}
+	return resp
 }
 
 // 员工状态枚举
@@ -323,6 +510,14 @@ type AssignSkillRequest struct {
 	Level      int  `json:"level" binding:"required,min=1,max=5"`
 }
 
+// EndorseSkillRequest 员工技能认证请求，未指定到期时间时有效期为一年
+type EndorseSkillRequest struct {
+	Level           *int       `json:"level,omitempty" binding:"omitempty,min=1,max=5"` // 认证时调整的技能等级
+	ExpiresAt       *time.Time `json:"expires_at,omitempty"`
+	EndorserID      uint       `json:"-"` // 认证人用户ID，由处理器从登录信息填充
+	CanManageSkills bool       `json:"-"` // 认证人拥有技能管理权限，无需是直接上级
+}
+
 type EmployeeSkillResponse struct {
 	SkillID     uint   `json:"skill_id"`
 	Name        string `json:"name"`
@@ -370,11 +565,60 @@ type DepartmentResponse struct {
 	ParentID    *uint                 `json:"parent_id"`
 	ManagerID   *uint                 `json:"manager_id"`
 	Path        string                `json:"path"`
+	Status      string                `json:"status"`
 	Manager     *EmployeeResponse     `json:"manager,omitempty"`
 	Parent      *DepartmentResponse   `json:"parent,omitempty"`
 	Children    []*DepartmentResponse `json:"children,omitempty"`
 	CreatedAt   string                `json:"created_at"`
 	UpdatedAt   string                `json:"updated_at"`
+
+	// AssignmentStrategy 部门默认分配策略，为空表示使用系统默认策略
+	AssignmentStrategy string `json:"assignment_strategy"`
+	// OnboardingWorkflowType 部门入职审批流程类型 simple/full，为空表示使用系统默认流程
+	OnboardingWorkflowType string `json:"onboarding_workflow_type"`
+}
+
+// UpdateDepartmentAssignmentStrategyRequest 设置部门默认分配策略请求，策略为空时恢复系统默认策略
+type UpdateDepartmentAssignmentStrategyRequest struct {
+	Strategy string `json:"strategy"`
+}
+
+// UpdateDepartmentOnboardingWorkflowRequest 设置部门入职审批流程请求，类型为空时恢复系统默认流程
+type UpdateDepartmentOnboardingWorkflowRequest struct {
+	WorkflowType string `json:"workflow_type"` // simple 或 full
+}
+
+type MergeDepartmentRequest struct {
+	TargetDepartmentID uint `json:"target_department_id" binding:"required"`
+}
+
+// DepartmentMergeResult 部门合并的审计摘要
+type DepartmentMergeResult struct {
+	SourceDepartmentID       uint      `json:"source_department_id"`
+	TargetDepartmentID       uint      `json:"target_department_id"`
+	EmployeesMoved           int64     `json:"employees_moved"`
+	ProjectsMoved            int64     `json:"projects_moved"`
+	SubDepartmentsMoved      int       `json:"sub_departments_moved"`      // 挂到目标部门下的直属子部门数
+	DepartmentPathsUpdated   int       `json:"department_paths_updated"`   // 重新计算路径的下级部门数（含间接下级）
+	PermissionTemplatesMoved int64     `json:"permission_templates_moved"` // 部门专属权限模板
+	OnboardingConfigsMoved   int64     `json:"onboarding_configs_moved"`   // 部门入职权限配置
+	ApprovalChainsMoved      int       `json:"approval_chains_moved"`
+	ApprovalChainsDropped    int       `json:"approval_chains_dropped"` // 目标部门已有同业务类型审批链而删除的源部门审批链
+	MergedAt                 time.Time `json:"merged_at"`
+}
+
+// UpdateDepartmentManagerRequest 更新部门管理者请求
+type UpdateDepartmentManagerRequest struct {
+	ManagerID uint `json:"manager_id" binding:"required"` // 管理者员工ID
+	// SyncDirectManager 为部门中没有直接上级的员工设置直接上级为新管理者
+	SyncDirectManager bool `json:"sync_direct_manager"`
+}
+
+// DepartmentManagerResult 更新部门管理者结果
+type DepartmentManagerResult struct {
+	DepartmentID         uint  `json:"department_id"`
+	ManagerID            uint  `json:"manager_id"`
+	DirectManagersSynced int64 `json:"direct_managers_synced"` // 设置了直接上级的员工数
 }
 
 // 职位相关DTO
@@ -438,6 +682,7 @@ type ProjectResponse struct {
 	Budget       float64             `json:"budget"`
 	DepartmentID uint                `json:"department_id"`
 	ManagerID    uint                `json:"manager_id"`
+	WIPLimits    map[string]int      `json:"wip_limits,omitempty"`
 	Department   *DepartmentResponse `json:"department,omitempty"`
 	Manager      *EmployeeResponse   `json:"manager,omitempty"`
 	Members      []*EmployeeResponse `json:"members,omitempty"`
@@ -454,28 +699,31 @@ type RemoveProjectMemberRequest struct {
 }
 
 type WorkloadResponse struct {
-	EmployeeID      uint    `json:"employee_id"`
-	EmployeeName    string  `json:"employee_name"`
-	Department      string  `json:"department"`
-	ActiveTasks     int     `json:"active_tasks"`
-	PendingTasks    int     `json:"pending_tasks"`
-	CompletedTasks  int     `json:"completed_tasks"`
-	OverdueTasks    int     `json:"overdue_tasks"`
-	MaxTasks        int     `json:"max_tasks"`
-	WorkloadRate    float64 `json:"workload_rate"`     // 工作负载率 (0-1)
-	EfficiencyRate  float64 `json:"efficiency_rate"`   // 效率率
-	AvgTaskDuration float64 `json:"avg_task_duration"` // 平均任务完成时间(小时)
-	Status          string  `json:"status"`            // 员工状态
-	LastActiveTime  string  `json:"last_active_time"`  // 最后活跃时间
+	EmployeeID          uint     `json:"employee_id"`
+	EmployeeName        string   `json:"employee_name"`
+	Department          string   `json:"department"`
+	ActiveTasks         int      `json:"active_tasks"`
+	ComputedActiveTasks *int     `json:"computed_active_tasks,omitempty"` // 按任务表统计的进行中任务数，与active_tasks不一致说明计数存在偏差
+	PendingTasks        int      `json:"pending_tasks"`
+	CompletedTasks      int      `json:"completed_tasks"`
+	OverdueTasks        int      `json:"overdue_tasks"`
+	MaxTasks            int      `json:"max_tasks"`
+	EffectiveMaxTasks   *int     `json:"effective_max_tasks,omitempty"` // 按当天容量系数折算后的最大任务数，仅单个员工的工作负载返回
+	CapacityFactor      *float64 `json:"capacity_factor,omitempty"`     // 当天的容量系数，没有容量条目时为1
+	WorkloadRate        float64  `json:"workload_rate"`                 // 工作负载率 (0-1)
+	EfficiencyRate      float64  `json:"efficiency_rate"`               // 效率率
+	AvgTaskDuration     float64  `json:"avg_task_duration"`             // 平均任务完成时间(小时)
+	Status              string   `json:"status"`                        // 员工状态
+	LastActiveTime      string   `json:"last_active_time"`              // 最后活跃时间
 }
 
 // 工作负载统计请求
 type WorkloadStatsRequest struct {
-	EmployeeIDs  []uint `json:"employee_ids,omitempty"`
-	Department   string `json:"department,omitempty"`
-	DepartmentID uint   `json:"department_id,omitempty"`
-	StartDate    string `json:"start_date,omitempty"`
-	EndDate      string `json:"end_date,omitempty"`
+	EmployeeIDs  []uint `json:"employee_ids,omitempty" form:"employee_ids"`
+	Department   string `json:"department,omitempty" form:"department"`
+	DepartmentID uint   `json:"department_id,omitempty" form:"department_id"`
+	StartDate    string `json:"start_date,omitempty" form:"start_date"`
+	EndDate      string `json:"end_date,omitempty" form:"end_date"`
 }
 
 // 部门工作负载统计
@@ -507,33 +755,62 @@ type BroadcastNotificationRequest struct {
 
 // 通知相关DTO
 type NotificationResponse struct {
-	ID        uint      `json:"id"`
-	UserID    uint      `json:"user_id"`
-	Type      string    `json:"type"`
-	Title     string    `json:"title"`
-	Content   string    `json:"content"`
-	IsRead    bool      `json:"is_read"`
-	CreatedAt time.Time `json:"created_at"`
+	ID        uint       `json:"id"`
+	UserID    uint       `json:"user_id"`
+	Type      string     `json:"type"`
+	Title     string     `json:"title"`
+	Content   string     `json:"content"`
+	IsRead    bool       `json:"is_read"`
+	ReadAt    *time.Time `json:"read_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// NotificationListRequest 通知列表查询条件
+type NotificationListRequest struct {
+	Type       string `form:"type"`
+	IsRead     *bool  `form:"is_read"`
+	UnreadOnly bool   `form:"unread_only"` // 兼容旧参数，等同于is_read=false
+	Page       int    `form:"page" binding:"omitempty,min=1"`
+	PageSize   int    `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
+// BulkReadNotificationsRequest 批量标记已读请求，按ID列表或按过滤条件二选一
+type BulkReadNotificationsRequest struct {
+	IDs    []uint     `json:"ids" binding:"omitempty,max=500"`
+	Type   string     `json:"type"`
+	Before *time.Time `json:"before"` // 只标记创建时间早于该时间的通知
+}
+
+// BulkReadNotificationsResult 批量标记已读结果
+type BulkReadNotificationsResult struct {
+	Updated int64 `json:"updated"` // 本次由未读变为已读的数量，已读或已过期的通知不计入
 }
 
 // CancelTaskRequest 取消任务请求
 type CancelTaskRequest struct {
 	Reason string `json:"reason" binding:"required"`
+	// Cascade 同时取消全部未完成的子任务；任务有未完成的子任务且未设置时拒绝取消
+	Cascade bool `json:"cascade"`
 }
 
 // AutoAssignRequest 自动分配请求
 type AutoAssignRequest struct {
-	Strategy string `json:"strategy"` // workload, skill, random
+	// Strategy 分配策略，为空时使用任务所属部门的默认策略，部门未设置时使用comprehensive
+	Strategy string `json:"strategy"`
 }
 
 // 过滤器
 type TaskListFilter struct {
-	Status     string `form:"status"`
-	Priority   string `form:"priority"`
-	AssignedTo *uint  `form:"assigned_to"`
-	CreatedBy  *uint  `form:"created_by"`
-	Page       int    `form:"page,default=1"`
-	PageSize   int    `form:"page_size,default=20"`
+	Status          string     `form:"status"`
+	Priority        string     `form:"priority"`
+	AssignedTo      *uint      `form:"assigned_to"`
+	CreatedBy       *uint      `form:"created_by"`
+	Labels          string     `form:"labels"`           // 逗号分隔的标签名称，默认须带有全部标签
+	Any             bool       `form:"any"`              // 为true时带有任一标签即可
+	IncludeArchived bool       `form:"include_archived"` // 为true时同时返回已归档的任务，项目看板不支持
+	Expand          TaskExpand `form:"-"`                // 需要展开的负责人、创建者和项目信息
+	Page            int        `form:"page,default=1"`
+	PageSize        int        `form:"page_size,default=20"`
 }
 
 // 转换函数
@@ -557,6 +834,10 @@ func TaskToResponse(task *database.Task) *TaskResponse {
 		Status:      task.Status,
 		CreatedAt:   task.CreatedAt,
 		UpdatedAt:   task.UpdatedAt,
+		Version:     task.Version,
+
+		RequiresReview: task.RequiresReview,
+		ReviewerID:     task.ReviewerID,
 	}
 
 	// 处理可能为nil的时间字段
@@ -573,7 +854,8 @@ func TaskToResponse(task *database.Task) *TaskResponse {
 	return resp
 }
 
-func EmployeeToResponse(employee *database.Employee) *EmployeeResponse {
+// EmployeeToResponse 转换员工响应，技能等级和项目列表由调用方通过仓储加载后传入
+func EmployeeToResponse(employee *database.Employee, skills []*database.EmployeeSkillDetail, projects []*database.Project) *EmployeeResponse {
 	resp := &EmployeeResponse{
 		ID:                 employee.ID,
 		Name:               employee.User.RealName,   // 从关联的User获取姓名
@@ -581,30 +863,23 @@ func EmployeeToResponse(employee *database.Employee) *EmployeeResponse {
 		Department:         employee.Department.Name, // 从关联的Department获取名称
 		Position:           employee.Position.Name,   // 从关联的Position获取名称
 		Status:             employee.Status,
-		Projects:           []string{},        // TODO: 从关联表获取项目列表
-		Skills:             []SkillResponse{}, // TODO: 从关联表获取技能列表
+		Projects:           make([]string, 0, len(projects)),
+		Skills:             make([]SkillResponse, 0, len(skills)),
 		MaxConcurrentTasks: employee.MaxTasks,
 		CurrentTasks:       employee.CurrentTasks,
 		CreatedAt:          employee.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 		UpdatedAt:          employee.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		Version:            employee.Version,
 	}
 
-	// 转换技能信息 - 包含技能级别
-	for _, skill := range employee.Skills {
-		skillResp := SkillResponse{
-			ID:          skill.ID,
-			Name:        skill.Name,
-			Category:    skill.Category,
-			Description: skill.Description,
-
-			CreatedAt: skill.CreatedAt.Format("2006-01-02 15:04:05"),
-			UpdatedAt: skill.UpdatedAt.Format("2006-01-02 15:04:05"),
-		}
-
-		// TODO: 从 employee_skills 关联表获取技能级别
-		// 需要通过 repository 查询 employee_skills 表获取具体的 level 信息
+	// 转换技能信息 - 包含员工技能级别和认证状态
+	now := time.Now()
+	for _, skill := range skills {
+		resp.Skills = append(resp.Skills, EmployeeSkillToResponse(skill, now))
+	}
 
-		resp.Skills = append(resp.Skills, skillResp)
+	for _, project := range projects {
+		resp.Projects = append(resp.Projects, project.Name)
 	}
 
 	return resp
@@ -629,10 +904,11 @@ type CreatePendingEmployeeRequest struct {
 	RealName     string `json:"real_name" binding:"required,min=2,max=50"`
 	Email        string `json:"email" binding:"required,email"`
 	Phone        string `json:"phone" binding:"required,min=11,max=15"`
-	ExpectedDate string `json:"expected_date" binding:"required"` // 预期入职日期
-	DepartmentID *uint  `json:"department_id,omitempty"`          // 可选，预分配部门
-	PositionID   *uint  `json:"position_id,omitempty"`            // 可选，预分配职位
-	Notes        string `json:"notes,omitempty"`                  // 备注信息
+	ExpectedDate string `json:"expected_date" binding:"required"`                 // 预期入职日期
+	DepartmentID *uint  `json:"department_id,omitempty"`                          // 可选，预分配部门
+	PositionID   *uint  `json:"position_id,omitempty"`                            // 可选，预分配职位
+	Notes        string `json:"notes,omitempty"`                                  // 备注信息
+	EmployeeNo   string `json:"employee_no,omitempty" binding:"omitempty,max=50"` // 可选，沿用外部系统的员工编号，不能与已有编号重复；为空时自动生成
 }
 
 // 入职确认请求
@@ -691,6 +967,20 @@ type OnboardingHistoryResponse struct {
 	CreatedAt    string `json:"created_at"`
 }
 
+// OnboardingStatusTransitions 入职状态及其可转换到的状态
+type OnboardingStatusTransitions struct {
+	Status string   `json:"status"`
+	Next   []string `json:"next"`
+}
+
+// OnboardingTransitionsResponse 入职状态机，指定员工时附带其当前状态和可转换到的状态
+type OnboardingTransitionsResponse struct {
+	Transitions   []*OnboardingStatusTransitions `json:"transitions"`
+	EmployeeID    uint                           `json:"employee_id,omitempty"`
+	CurrentStatus string                         `json:"current_status,omitempty"`
+	NextStatuses  []string                       `json:"next_statuses,omitempty"`
+}
+
 // 入职工作流列表过滤器
 type OnboardingWorkflowFilter struct {
 	Page       int    `json:"page"`
@@ -708,3 +998,37 @@ type OnboardingWorkflowFilter struct {
 // 	Reason        string `json:"reason,omitempty"`
 // 	EffectiveDate string `json:"effective_date,omitempty"`
 // }
+
+// MaxBatchApprovalItems 批量审批单次最多处理的条目数
+const MaxBatchApprovalItems = 100
+
+// 批量审批条目
+type BatchApprovalItem struct {
+	InstanceID string                  `json:"instance_id" binding:"required"`
+	NodeID     string                  `json:"node_id" binding:"required"`
+	Action     workflow.ApprovalAction `json:"action" binding:"required,oneof=approve reject return"`
+	Comment    string                  `json:"comment,omitempty"`
+}
+
+// 批量审批请求
+type BatchApprovalRequest struct {
+	Items      []BatchApprovalItem `json:"items" binding:"required,min=1,max=100,dive"`
+	ApproverID uint                `json:"-"`
+}
+
+// 批量审批单条结果
+type BatchApprovalItemResult struct {
+	InstanceID string                   `json:"instance_id"`
+	NodeID     string                   `json:"node_id"`
+	Success    bool                     `json:"success"`
+	Error      string                   `json:"error,omitempty"`
+	Result     *workflow.ApprovalResult `json:"result,omitempty"`
+}
+
+// 批量审批响应
+type BatchApprovalResponse struct {
+	Total     int                       `json:"total"`
+	Succeeded int                       `json:"succeeded"`
+	Failed    int                       `json:"failed"`
+	Results   []BatchApprovalItemResult `json:"results"`
+}
@@ -0,0 +1,276 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"time"
+
+	"taskmanage/internal/config"
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+	"taskmanage/pkg/email"
+	"taskmanage/pkg/logger"
+)
+
+// 发件箱邮件状态
+const (
+	EmailStatusPending = "pending"
+	EmailStatusSent    = "sent"
+	EmailStatusFailed  = "failed"
+)
+
+const (
+	// DefaultEmailOutboxInterval 后台投递待发送邮件的默认间隔
+	DefaultEmailOutboxInterval = 30 * time.Second
+	// defaultEmailMaxAttempts 单封邮件默认最多尝试次数
+	defaultEmailMaxAttempts = 5
+	// emailRetryBaseDelay 首次重试的等待时间，之后每次翻倍
+	emailRetryBaseDelay = time.Minute
+	// emailRetryMaxDelay 重试等待时间上限
+	emailRetryMaxDelay = time.Hour
+	// emailOutboxBatchSize 每轮最多投递的邮件数
+	emailOutboxBatchSize = 50
+)
+
+// ErrUnknownEmailCategory 通知类别没有对应的邮件模板
+var ErrUnknownEmailCategory = errors.New("通知类别没有邮件模板")
+
+//go:embed templates/email/*.html
+var emailTemplateFS embed.FS
+
+// emailTemplates 按通知类别命名的邮件模板，如task_assigned.html
+var emailTemplates = template.Must(template.ParseFS(emailTemplateFS, "templates/email/*.html"))
+
+// EmailTemplateData 邮件模板变量
+type EmailTemplateData struct {
+	RecipientName string
+	Title         string // 通知标题
+	Content       string // 通知正文
+	TaskTitle     string
+	Requester     string // 发起人或分配人
+	Deadline      string
+}
+
+// EmailOutboxListRequest 发件箱查询请求
+type EmailOutboxListRequest struct {
+	Status   string `form:"status" binding:"omitempty,oneof=pending sent failed"`
+	UserID   uint   `form:"user_id"`
+	Page     int    `form:"page"`
+	PageSize int    `form:"page_size"`
+}
+
+// EmailOutboxResponse 发件箱邮件响应，不含正文
+type EmailOutboxResponse struct {
+	ID          uint       `json:"id"`
+	UserID      uint       `json:"user_id"`
+	To          string     `json:"to"`
+	Category    string     `json:"category"`
+	Subject     string     `json:"subject"`
+	Status      string     `json:"status"`
+	Attempts    int        `json:"attempts"`
+	NextRetryAt *time.Time `json:"next_retry_at,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+	SentAt      *time.Time `json:"sent_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// EmailNotifier 邮件通知服务，请求路径只写入发件箱，由后台发送器异步投递
+type EmailNotifier interface {
+	// Enqueue 按通知类别渲染邮件模板并写入发件箱，收件人没有邮箱时忽略
+	Enqueue(ctx context.Context, recipientID uint, category string, data *EmailTemplateData) error
+	// ProcessOutbox 投递到期的待发送邮件，返回发送成功数
+	ProcessOutbox(ctx context.Context, now time.Time) (int, error)
+	// ListOutbox 查询发件箱，用于排查积压或失败的邮件
+	ListOutbox(ctx context.Context, req *EmailOutboxListRequest) (*ListResponse[*EmailOutboxResponse], error)
+}
+
+type emailNotifier struct {
+	outboxRepo  repository.EmailOutboxRepository
+	userRepo    repository.UserRepository
+	sender      email.EmailSender
+	maxAttempts int
+	now         func() time.Time
+}
+
+// NewEmailNotifier 创建邮件通知服务
+func NewEmailNotifier(repoManager repository.RepositoryManager, sender email.EmailSender, cfg config.EmailConfig) EmailNotifier {
+	maxAttempts := cfg.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultEmailMaxAttempts
+	}
+	return &emailNotifier{
+		outboxRepo:  repoManager.EmailOutboxRepository(),
+		userRepo:    repoManager.UserRepository(),
+		sender:      sender,
+		maxAttempts: maxAttempts,
+		now:         time.Now,
+	}
+}
+
+// hasEmailTemplate 判断通知类别是否有邮件模板
+func hasEmailTemplate(category string) bool {
+	return emailTemplates.Lookup(category+".html") != nil
+}
+
+// emailSubject 生成邮件主题
+func emailSubject(category string, data *EmailTemplateData) string {
+	switch category {
+	case NotificationCategoryTaskAssigned:
+		return "新任务分配：" + data.TaskTitle
+	case NotificationCategoryApprovalRequested:
+		return "待审批：" + data.Title
+	}
+	return data.Title
+}
+
+// Enqueue 渲染邮件并写入发件箱
+func (n *emailNotifier) Enqueue(ctx context.Context, recipientID uint, category string, data *EmailTemplateData) error {
+	tmpl := emailTemplates.Lookup(category + ".html")
+	if tmpl == nil {
+		return fmt.Errorf("%w: %s", ErrUnknownEmailCategory, category)
+	}
+
+	user, err := n.userRepo.GetByID(ctx, recipientID)
+	if err != nil {
+		return fmt.Errorf("获取收件人失败: %w", err)
+	}
+	if user.Email == "" {
+		logger.Debugf("收件人没有邮箱，跳过邮件通知: user_id=%d, category=%s", recipientID, category)
+		return nil
+	}
+	if data.RecipientName == "" {
+		data.RecipientName = user.RealName
+	}
+	if data.RecipientName == "" {
+		data.RecipientName = user.Username
+	}
+
+	var html bytes.Buffer
+	if err := tmpl.Execute(&html, data); err != nil {
+		return fmt.Errorf("渲染邮件模板失败: %w", err)
+	}
+	subject := emailSubject(category, data)
+	text := subject
+	if data.Content != "" {
+		text += "\n\n" + data.Content
+	}
+
+	now := n.now()
+	message := &database.EmailOutbox{
+		UserID:      recipientID,
+		To:          user.Email,
+		Category:    category,
+		Subject:     subject,
+		TextBody:    text,
+		HTMLBody:    html.String(),
+		Status:      EmailStatusPending,
+		NextRetryAt: &now,
+	}
+	if err := n.outboxRepo.Create(ctx, message); err != nil {
+		return fmt.Errorf("写入邮件发件箱失败: %w", err)
+	}
+	return nil
+}
+
+// ProcessOutbox 投递到期邮件，失败时按指数退避安排重试，超过最大次数标记为failed
+func (n *emailNotifier) ProcessOutbox(ctx context.Context, now time.Time) (int, error) {
+	messages, err := n.outboxRepo.ListDue(ctx, now, emailOutboxBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("获取待发送邮件失败: %w", err)
+	}
+
+	sent := 0
+	for _, message := range messages {
+		sendErr := n.sender.Send(ctx, &email.Message{
+			To:       message.To,
+			Subject:  message.Subject,
+			Body:     message.TextBody,
+			HTMLBody: message.HTMLBody,
+		})
+		message.Attempts++
+		if sendErr == nil {
+			sentAt := now
+			message.Status = EmailStatusSent
+			message.SentAt = &sentAt
+			message.NextRetryAt = nil
+			message.LastError = ""
+			sent++
+		} else {
+			message.LastError = sendErr.Error()
+			if message.Attempts >= n.maxAttempts {
+				message.Status = EmailStatusFailed
+				message.NextRetryAt = nil
+				logger.Warnf("邮件发送失败且不再重试: id=%d, to=%s, attempts=%d, error=%v", message.ID, message.To, message.Attempts, sendErr)
+			} else {
+				next := now.Add(emailRetryDelay(message.Attempts))
+				message.NextRetryAt = &next
+			}
+		}
+		if err := n.outboxRepo.Update(ctx, message); err != nil {
+			logger.Errorf("更新邮件发送状态失败: id=%d, error=%v", message.ID, err)
+		}
+	}
+	return sent, nil
+}
+
+// emailRetryDelay 第attempts次失败后的重试等待时间
+func emailRetryDelay(attempts int) time.Duration {
+	delay := emailRetryBaseDelay
+	for i := 1; i < attempts && delay < emailRetryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > emailRetryMaxDelay {
+		delay = emailRetryMaxDelay
+	}
+	return delay
+}
+
+// ListOutbox 分页查询发件箱
+func (n *emailNotifier) ListOutbox(ctx context.Context, req *EmailOutboxListRequest) (*ListResponse[*EmailOutboxResponse], error) {
+	filter := &repository.EmailOutboxFilter{
+		Status:   req.Status,
+		UserID:   req.UserID,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PageSize <= 0 {
+		filter.PageSize = 20
+	}
+	if filter.PageSize > 100 {
+		filter.PageSize = 100
+	}
+
+	messages, total, err := n.outboxRepo.List(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("查询邮件发件箱失败: %w", err)
+	}
+	items := make([]*EmailOutboxResponse, 0, len(messages))
+	for _, message := range messages {
+		items = append(items, &EmailOutboxResponse{
+			ID:          message.ID,
+			UserID:      message.UserID,
+			To:          message.To,
+			Category:    message.Category,
+			Subject:     message.Subject,
+			Status:      message.Status,
+			Attempts:    message.Attempts,
+			NextRetryAt: message.NextRetryAt,
+			LastError:   message.LastError,
+			SentAt:      message.SentAt,
+			CreatedAt:   message.CreatedAt,
+		})
+	}
+	return &ListResponse[*EmailOutboxResponse]{
+		Items: items,
+		Total: total,
+		Page:  filter.Page,
+		Size:  filter.PageSize,
+	}, nil
+}
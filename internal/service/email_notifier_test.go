@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+	"taskmanage/pkg/email"
+)
+
+// memoryOutboxRepository 测试用内存发件箱
+type memoryOutboxRepository struct {
+	repository.EmailOutboxRepository
+	messages []*database.EmailOutbox
+}
+
+func (r *memoryOutboxRepository) Create(ctx context.Context, message *database.EmailOutbox) error {
+	message.ID = uint(len(r.messages) + 1)
+	r.messages = append(r.messages, message)
+	return nil
+}
+
+func (r *memoryOutboxRepository) Update(ctx context.Context, message *database.EmailOutbox) error {
+	return nil
+}
+
+func (r *memoryOutboxRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]*database.EmailOutbox, error) {
+	var due []*database.EmailOutbox
+	for _, m := range r.messages {
+		if m.Status == EmailStatusPending && !m.NextRetryAt.After(now) {
+			due = append(due, m)
+		}
+	}
+	return due, nil
+}
+
+// emailUserRepository 按ID返回固定用户
+type emailUserRepository struct {
+	repository.UserRepository
+	users map[uint]*database.User
+}
+
+func (r *emailUserRepository) GetByID(ctx context.Context, id uint) (*database.User, error) {
+	if user, ok := r.users[id]; ok {
+		return user, nil
+	}
+	return nil, repository.ErrNotFound
+}
+
+// flakySender 前failures次发送失败
+type flakySender struct {
+	failures int
+	sent     []*email.Message
+}
+
+func (s *flakySender) Send(ctx context.Context, msg *email.Message) error {
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("connection refused")
+	}
+	s.sent = append(s.sent, msg)
+	return nil
+}
+
+func TestEmailNotifier_EnqueueAndRetry(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, time.Local)
+	outbox := &memoryOutboxRepository{}
+	sender := &flakySender{failures: 2}
+	notifier := &emailNotifier{
+		outboxRepo: outbox,
+		userRepo: &emailUserRepository{users: map[uint]*database.User{
+			7: {BaseModel: database.BaseModel{ID: 7}, Email: "li@example.com", RealName: "李四"},
+			8: {BaseModel: database.BaseModel{ID: 8}},
+		}},
+		sender:      sender,
+		maxAttempts: 3,
+		now:         func() time.Time { return now },
+	}
+
+	require.NoError(t, notifier.Enqueue(ctx, 7, NotificationCategoryTaskAssigned, &EmailTemplateData{TaskTitle: "<接口联调>", Requester: "张三", Deadline: "2026-03-05 18:00"}))
+	assert.ErrorIs(t, notifier.Enqueue(ctx, 7, NotificationCategoryTaskCompleted, &EmailTemplateData{}), ErrUnknownEmailCategory)
+	// 没有邮箱的用户不写入发件箱
+	require.NoError(t, notifier.Enqueue(ctx, 8, NotificationCategoryTaskAssigned, &EmailTemplateData{TaskTitle: "x"}))
+	require.Len(t, outbox.messages, 1)
+
+	message := outbox.messages[0]
+	assert.Equal(t, "li@example.com", message.To)
+	assert.Equal(t, "新任务分配：<接口联调>", message.Subject)
+	assert.Contains(t, message.HTMLBody, "李四")
+	assert.Contains(t, message.HTMLBody, "&lt;接口联调&gt;")
+	assert.Contains(t, message.HTMLBody, "2026-03-05 18:00")
+
+	// 第一次失败后1分钟重试，第二次失败后2分钟重试
+	sent, err := notifier.ProcessOutbox(ctx, now)
+	require.NoError(t, err)
+	assert.Zero(t, sent)
+	assert.Equal(t, now.Add(time.Minute), *message.NextRetryAt)
+	sent, _ = notifier.ProcessOutbox(ctx, now.Add(30*time.Second))
+	assert.Zero(t, sent)
+	assert.Equal(t, 1, message.Attempts)
+
+	retryAt := now.Add(time.Minute)
+	_, _ = notifier.ProcessOutbox(ctx, retryAt)
+	assert.Equal(t, retryAt.Add(2*time.Minute), *message.NextRetryAt)
+	assert.Equal(t, "connection refused", message.LastError)
+
+	sent, _ = notifier.ProcessOutbox(ctx, retryAt.Add(2*time.Minute))
+	assert.Equal(t, 1, sent)
+	assert.Equal(t, EmailStatusSent, message.Status)
+	assert.Equal(t, 3, message.Attempts)
+	require.Len(t, sender.sent, 1)
+	assert.NotEmpty(t, sender.sent[0].HTMLBody)
+}
+
+func TestEmailNotifier_MarksFailedAfterMaxAttempts(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	outbox := &memoryOutboxRepository{messages: []*database.EmailOutbox{{Status: EmailStatusPending, NextRetryAt: &now, Attempts: 2}}}
+	notifier := &emailNotifier{outboxRepo: outbox, sender: &flakySender{failures: 1}, maxAttempts: 3, now: time.Now}
+
+	_, err := notifier.ProcessOutbox(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, EmailStatusFailed, outbox.messages[0].Status)
+	assert.Nil(t, outbox.messages[0].NextRetryAt)
+	assert.Equal(t, time.Hour, emailRetryDelay(10))
+}
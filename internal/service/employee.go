@@ -2,11 +2,15 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
+	"taskmanage/internal/config"
 	"taskmanage/internal/database"
 	"taskmanage/internal/repository"
 	"taskmanage/pkg/logger"
+	"taskmanage/pkg/response"
 )
 
 // EmployeeServiceImpl 员工服务实现
@@ -14,18 +18,49 @@ type EmployeeServiceImpl struct {
 	employeeRepo repository.EmployeeRepository
 	skillRepo    repository.SkillRepository
 	userRepo     repository.UserRepository
+	projectRepo  repository.ProjectRepository
+	capacityRepo repository.EmployeeCapacityRepository
+	positionRepo repository.PositionRepository
+	historyRepo  repository.EmployeeChangeHistoryRepository
+	permissions  PermissionAssignmentService
+	employeeNos  EmployeeNumberGenerator
+	workload     config.WorkloadConfig
 }
 
+// 工作负载统计默认值
+const (
+	DefaultOverloadThreshold       = 0.9
+	DefaultWorkloadStatsWindowDays = 90
+)
+
+// ErrInvalidWorkloadRange 工作负载统计日期范围无效
+var ErrInvalidWorkloadRange = response.NewError(response.ErrCodeInvalidRequest, "无效的统计日期范围")
+
 // NewEmployeeService 创建员工服务实例
+// 职位变更时通过positionRepo比较职级，记录变更历史并由permissions按新职级调整权限；员工编号由employeeNos分配
 func NewEmployeeService(
 	employeeRepo repository.EmployeeRepository,
 	skillRepo repository.SkillRepository,
 	userRepo repository.UserRepository,
+	projectRepo repository.ProjectRepository,
+	capacityRepo repository.EmployeeCapacityRepository,
+	positionRepo repository.PositionRepository,
+	historyRepo repository.EmployeeChangeHistoryRepository,
+	permissions PermissionAssignmentService,
+	employeeNos EmployeeNumberGenerator,
+	workload config.WorkloadConfig,
 ) EmployeeService {
 	return &EmployeeServiceImpl{
 		employeeRepo: employeeRepo,
 		skillRepo:    skillRepo,
 		userRepo:     userRepo,
+		projectRepo:  projectRepo,
+		capacityRepo: capacityRepo,
+		positionRepo: positionRepo,
+		historyRepo:  historyRepo,
+		permissions:  permissions,
+		employeeNos:  employeeNos,
+		workload:     workload,
 	}
 }
 
@@ -52,8 +87,11 @@ func (s *EmployeeServiceImpl) CreateEmployee(ctx context.Context, req *CreateEmp
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
 
-	// 生成员工编号（简单实现）
-	employeeNo := fmt.Sprintf("EMP%06d", user.ID)
+	employeeNo, err := s.employeeNos.Assign(ctx, "", user.ID)
+	if err != nil {
+		logger.Errorf("Failed to assign employee number: %v", err)
+		return nil, err
+	}
 
 	// 创建员工记录
 	employee := &database.Employee{
@@ -74,14 +112,14 @@ func (s *EmployeeServiceImpl) CreateEmployee(ctx context.Context, req *CreateEmp
 
 	logger.Infof("Employee created successfully: %d", employee.ID)
 
-	return s.buildEmployeeResponse(employee, user), nil
+	return s.buildEmployeeResponse(ctx, employee, user), nil
 }
 
 // GetEmployee 获取员工信息
 func (s *EmployeeServiceImpl) GetEmployee(ctx context.Context, employeeID uint) (*EmployeeResponse, error) {
 	employee, err := s.employeeRepo.GetByID(ctx, employeeID)
 	if err != nil {
-		return nil, fmt.Errorf("employee not found: %w", err)
+		return nil, employeeLookupError(err)
 	}
 
 	// 获取用户信息
@@ -98,7 +136,7 @@ func (s *EmployeeServiceImpl) GetEmployee(ctx context.Context, employeeID uint)
 		employeeWithSkills = employee // 使用基本信息
 	}
 
-	return s.buildEmployeeResponse(employeeWithSkills, user), nil
+	return s.buildEmployeeResponse(ctx, employeeWithSkills, user), nil
 }
 
 // UpdateEmployee 更新员工信息
@@ -107,7 +145,15 @@ func (s *EmployeeServiceImpl) UpdateEmployee(ctx context.Context, employeeID uin
 
 	employee, err := s.employeeRepo.GetByID(ctx, employeeID)
 	if err != nil {
-		return nil, fmt.Errorf("employee not found: %w", err)
+		return nil, employeeLookupError(err)
+	}
+
+	// 职位变化时先确认新职位存在，保存后按职级调整权限
+	var positionChange *positionChange
+	if req.PositionID != nil && (employee.PositionID == nil || *employee.PositionID != *req.PositionID) {
+		if positionChange, err = s.loadPositionChange(ctx, employee.PositionID, *req.PositionID); err != nil {
+			return nil, err
+		}
 	}
 
 	// 更新字段
@@ -120,6 +166,21 @@ func (s *EmployeeServiceImpl) UpdateEmployee(ctx context.Context, employeeID uin
 	if req.MaxConcurrentTasks != nil {
 		employee.MaxTasks = *req.MaxConcurrentTasks
 	}
+	// 以客户端读取时的版本作为更新条件，期间员工被修改则更新失败
+	if req.Version != nil {
+		employee.Version = *req.Version
+	}
+
+	// 先保存员工，版本冲突时不再修改用户信息
+	if err := s.employeeRepo.Update(ctx, employee); err != nil {
+		if errors.Is(err, repository.ErrConflict) {
+			return nil, newConflictError("员工", func() (interface{}, error) {
+				return s.GetEmployee(ctx, employeeID)
+			})
+		}
+		logger.Errorf("Failed to update employee: %v", err)
+		return nil, fmt.Errorf("failed to update employee: %w", err)
+	}
 
 	// 更新用户信息
 	if req.Name != nil || req.Email != nil {
@@ -139,11 +200,6 @@ func (s *EmployeeServiceImpl) UpdateEmployee(ctx context.Context, employeeID uin
 		}
 	}
 
-	if err := s.employeeRepo.Update(ctx, employee); err != nil {
-		logger.Errorf("Failed to update employee: %v", err)
-		return nil, fmt.Errorf("failed to update employee: %w", err)
-	}
-
 	// 获取更新后的用户信息
 	user, err := s.userRepo.GetByID(ctx, employee.UserID)
 	if err != nil {
@@ -152,7 +208,11 @@ func (s *EmployeeServiceImpl) UpdateEmployee(ctx context.Context, employeeID uin
 
 	logger.Infof("Employee updated successfully: %d", employeeID)
 
-	return s.buildEmployeeResponse(employee, user), nil
+	resp := s.buildEmployeeResponse(ctx, employee, user)
+	if positionChange != nil {
+		resp.PermissionChange = s.applyPositionChange(ctx, employee, positionChange, req.OperatorID)
+	}
+	return resp, nil
 }
 
 // DeleteEmployee 删除员工
@@ -161,7 +221,7 @@ func (s *EmployeeServiceImpl) DeleteEmployee(ctx context.Context, employeeID uin
 
 	employee, err := s.employeeRepo.GetByID(ctx, employeeID)
 	if err != nil {
-		return fmt.Errorf("employee not found: %w", err)
+		return employeeLookupError(err)
 	}
 
 	// 检查是否有未完成的任务
@@ -188,27 +248,13 @@ func (s *EmployeeServiceImpl) ListEmployees(ctx context.Context, filter Employee
 		Order:    "desc",       // 默认降序
 	}
 
-	employees, total, err := s.employeeRepo.List(ctx, repoFilter)
+	// 用户、部门和职位随员工列表预加载
+	employees, total, err := s.employeeRepo.ListEmployeesWithUserAndDepartment(ctx, repoFilter)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to list employees: %w", err)
 	}
 
-	// 转换为响应格式
-
-	responses := make([]*EmployeeResponse, 0, len(employees))
-
-	for _, employee := range employees {
-		// 获取用户信息
-		user, err := s.userRepo.GetByID(ctx, employee.UserID)
-		if err != nil {
-			logger.Warnf("Failed to get user info for employee %d: %v", employee.ID, err)
-			continue
-		}
-
-		responses = append(responses, s.buildEmployeeResponse(employee, user))
-	}
-
-	return responses, total, nil
+	return s.buildEmployeeResponses(ctx, employees), total, nil
 }
 
 // UpdateEmployeeStatus 更新员工状态
@@ -217,7 +263,7 @@ func (s *EmployeeServiceImpl) UpdateEmployeeStatus(ctx context.Context, employee
 
 	employee, err := s.employeeRepo.GetByID(ctx, employeeID)
 	if err != nil {
-		return fmt.Errorf("employee not found: %w", err)
+		return employeeLookupError(err)
 	}
 
 	// 验证状态值
@@ -242,31 +288,37 @@ func (s *EmployeeServiceImpl) UpdateEmployeeStatus(ctx context.Context, employee
 	return nil
 }
 
-// GetEmployeeWorkload 获取员工工作负载
-func (s *EmployeeServiceImpl) GetEmployeeWorkload(ctx context.Context, employeeID uint) (*WorkloadResponse, error) {
+// GetEmployeeWorkload 获取员工工作负载，包含按当天容量日历折算的有效容量
+// includeComputed为true时同时返回按任务表统计的实际进行中任务数
+func (s *EmployeeServiceImpl) GetEmployeeWorkload(ctx context.Context, employeeID uint, includeComputed bool) (*WorkloadResponse, error) {
 	employee, err := s.employeeRepo.GetByID(ctx, employeeID)
 	if err != nil {
-		return nil, fmt.Errorf("employee not found: %w", err)
+		return nil, employeeLookupError(err)
 	}
 
-	workload := &WorkloadResponse{
-		EmployeeID:      employeeID,
-		EmployeeName:    employee.User.RealName,
-		Department:      employee.Department.Name, // 使用关联的部门名称
-		ActiveTasks:     employee.CurrentTasks,
-		PendingTasks:    0, // TODO: 从任务表统计
-		CompletedTasks:  0, // TODO: 从任务表统计
-		OverdueTasks:    0, // TODO: 从任务表统计
-		MaxTasks:        employee.MaxTasks,
-		WorkloadRate:    0,
-		EfficiencyRate:  0, // TODO: 计算效率率
-		AvgTaskDuration: 0, // TODO: 计算平均任务时长
-		Status:          employee.Status,
-		LastActiveTime:  employee.UpdatedAt.Format("2006-01-02 15:04:05"),
+	now := time.Now()
+	filter := &repository.WorkloadFilter{EmployeeIDs: []uint{employeeID}}
+	filter.CompletedFrom, filter.CompletedTo = s.defaultWorkloadWindow(now)
+	stats, err := s.employeeRepo.AggregateTaskStats(ctx, filter, now)
+	if err != nil {
+		return nil, err
 	}
+	workload := buildWorkloadResponse(employee, stats[employeeID])
 
-	if employee.MaxTasks > 0 {
-		workload.WorkloadRate = float64(employee.CurrentTasks) / float64(employee.MaxTasks)
+	effectiveMaxTasks, factor, err := effectiveCapacity(ctx, s.capacityRepo, employee, nil, now)
+	if err != nil {
+		return nil, err
+	}
+	workload.EffectiveMaxTasks = &effectiveMaxTasks
+	workload.CapacityFactor = &factor
+
+	if includeComputed {
+		counts, err := s.employeeRepo.CountActiveTasks(ctx, []uint{employeeID})
+		if err != nil {
+			return nil, err
+		}
+		computed := counts[employeeID]
+		workload.ComputedActiveTasks = &computed
 	}
 
 	return workload, nil
@@ -288,7 +340,7 @@ func (s *EmployeeServiceImpl) GetAvailableEmployees(ctx context.Context) ([]*Emp
 			continue
 		}
 
-		responses = append(responses, s.buildEmployeeResponse(employee, user))
+		responses = append(responses, s.buildEmployeeResponse(ctx, employee, user))
 	}
 
 	return responses, nil
@@ -301,13 +353,13 @@ func (s *EmployeeServiceImpl) AddSkill(ctx context.Context, employeeID uint, req
 	// 检查员工是否存在
 	_, err := s.employeeRepo.GetByID(ctx, employeeID)
 	if err != nil {
-		return fmt.Errorf("employee not found: %w", err)
+		return employeeLookupError(err)
 	}
 
 	// 检查技能是否存在
 	_, err = s.skillRepo.GetByID(ctx, req.SkillID)
 	if err != nil {
-		return fmt.Errorf("skill not found: %w", err)
+		return notFoundOr(err, response.ErrCodeNotFound, "技能不存在", "获取技能失败")
 	}
 
 	// 验证技能等级
@@ -331,7 +383,7 @@ func (s *EmployeeServiceImpl) RemoveSkill(ctx context.Context, employeeID uint,
 	// 检查员工是否存在
 	_, err := s.employeeRepo.GetByID(ctx, employeeID)
 	if err != nil {
-		return fmt.Errorf("employee not found: %w", err)
+		return employeeLookupError(err)
 	}
 
 	if err := s.skillRepo.RemoveFromEmployee(ctx, employeeID, req.SkillID); err != nil {
@@ -360,140 +412,203 @@ func (s *EmployeeServiceImpl) GetEmployeesByStatus(ctx context.Context, status s
 			logger.Warnf("Failed to get user info for employee %d: %v", employee.ID, err)
 			continue
 		}
-		responses = append(responses, s.buildEmployeeResponse(employee, user))
+		responses = append(responses, s.buildEmployeeResponse(ctx, employee, user))
 	}
 
 	return responses, nil
 }
 
-// GetWorkloadStats 获取工作负载统计
+// GetWorkloadStats 获取工作负载统计，完成数、效率和平均时长只统计日期范围内完成的任务
 func (s *EmployeeServiceImpl) GetWorkloadStats(ctx context.Context, req *WorkloadStatsRequest) ([]*WorkloadResponse, error) {
 	logger.Infof("Getting workload stats for department: %s", req.Department)
 
-	var employees []*database.Employee
+	now := time.Now()
+	filter := &repository.WorkloadFilter{
+		EmployeeIDs:  req.EmployeeIDs,
+		DepartmentID: req.DepartmentID,
+		Department:   req.Department,
+	}
 	var err error
+	filter.CompletedFrom, filter.CompletedTo, err = s.workloadWindow(req.StartDate, req.EndDate, now)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.listWorkloads(ctx, filter, now)
+}
 
-	if req.DepartmentID != 0 {
-		// TODO: 需要实现GetByDepartmentID方法或使用现有方法
-		employees, err = s.employeeRepo.GetAll(ctx)
-	} else {
-		employees, err = s.employeeRepo.GetAll(ctx)
+// GetDepartmentWorkload 获取部门工作负载统计，工作负载率超过配置阈值的员工计为超负荷
+func (s *EmployeeServiceImpl) GetDepartmentWorkload(ctx context.Context, departmentID uint) (*DepartmentWorkloadResponse, error) {
+	logger.Infof("Getting department workload for: %d", departmentID)
+
+	now := time.Now()
+	filter := &repository.WorkloadFilter{DepartmentID: departmentID}
+	filter.CompletedFrom, filter.CompletedTo = s.defaultWorkloadWindow(now)
+	workloads, err := s.listWorkloads(ctx, filter, now)
+	if err != nil {
+		return nil, err
 	}
 
+	threshold := s.workload.OverloadThreshold
+	if threshold <= 0 {
+		threshold = DefaultOverloadThreshold
+	}
+
+	result := &DepartmentWorkloadResponse{Department: "Unknown", TotalEmployees: len(workloads)}
+	var totalWorkloadRate float64
+	for _, workload := range workloads {
+		result.Department = workload.Department
+		if workload.Status == "active" || workload.Status == "available" {
+			result.ActiveEmployees++
+		}
+		result.TotalTasks += workload.ActiveTasks + workload.PendingTasks
+		result.CompletedTasks += workload.CompletedTasks
+		totalWorkloadRate += workload.WorkloadRate
+		if workload.WorkloadRate > threshold {
+			result.OverloadedCount++
+		}
+	}
+	if len(workloads) > 0 {
+		result.AvgWorkloadRate = totalWorkloadRate / float64(len(workloads))
+	}
+
+	return result, nil
+}
+
+// listWorkloads 获取过滤范围内的员工，并用一次分组查询统计全部员工的任务
+func (s *EmployeeServiceImpl) listWorkloads(ctx context.Context, filter *repository.WorkloadFilter, now time.Time) ([]*WorkloadResponse, error) {
+	employees, err := s.employeeRepo.ListForWorkload(ctx, filter)
 	if err != nil {
 		logger.Errorf("Failed to get employees for workload stats: %v", err)
 		return nil, fmt.Errorf("failed to get employees: %w", err)
 	}
+	if len(employees) == 0 {
+		return []*WorkloadResponse{}, nil
+	}
+
+	stats, err := s.employeeRepo.AggregateTaskStats(ctx, filter, now)
+	if err != nil {
+		return nil, err
+	}
 
 	responses := make([]*WorkloadResponse, 0, len(employees))
 	for _, employee := range employees {
-		workload, err := s.GetEmployeeWorkload(ctx, employee.ID)
-		if err != nil {
-			logger.Warnf("Failed to get workload for employee %d: %v", employee.ID, err)
-			continue
-		}
-		responses = append(responses, workload)
+		responses = append(responses, buildWorkloadResponse(employee, stats[employee.ID]))
 	}
-
 	return responses, nil
 }
 
-// GetDepartmentWorkload 获取部门工作负载统计
-func (s *EmployeeServiceImpl) GetDepartmentWorkload(ctx context.Context, departmentID uint) (*DepartmentWorkloadResponse, error) {
-	logger.Infof("Getting department workload for: %d", departmentID)
+// defaultWorkloadWindow 未指定日期范围时统计截至now的最近若干天
+func (s *EmployeeServiceImpl) defaultWorkloadWindow(now time.Time) (time.Time, time.Time) {
+	return now.AddDate(0, 0, -s.workloadWindowDays()), now
+}
 
-	// TODO: 需要实现GetByDepartmentID方法或使用现有方法
-	employees, err := s.employeeRepo.GetAll(ctx)
-	if err != nil {
-		logger.Errorf("Failed to get department employees: %v", err)
-		return nil, fmt.Errorf("failed to get department employees: %w", err)
+// workloadWindowDays 默认统计天数
+func (s *EmployeeServiceImpl) workloadWindowDays() int {
+	if s.workload.StatsWindowDays <= 0 {
+		return DefaultWorkloadStatsWindowDays
 	}
+	return s.workload.StatsWindowDays
+}
 
-	var totalEmployees, activeEmployees, totalTasks, completedTasks int
-	var totalWorkloadRate float64
-
-	for _, employee := range employees {
-		totalEmployees++
-		if employee.Status == "active" || employee.Status == "available" {
-			activeEmployees++
+// workloadWindow 解析统计日期范围，日期格式为2006-01-02且包含结束日期当天；只指定一端时另一端按默认统计天数推算
+func (s *EmployeeServiceImpl) workloadWindow(startDate, endDate string, now time.Time) (time.Time, time.Time, error) {
+	from, to := s.defaultWorkloadWindow(now)
+	if endDate != "" {
+		end, err := time.ParseInLocation("2006-01-02", endDate, time.Local)
+		if err != nil {
+			return time.Time{}, time.Time{}, response.Wrapf(ErrInvalidWorkloadRange, "end_date格式应为2006-01-02")
 		}
-
-		workload, err := s.GetEmployeeWorkload(ctx, employee.ID)
+		to = end.AddDate(0, 0, 1)
+		from = to.AddDate(0, 0, -s.workloadWindowDays())
+	}
+	if startDate != "" {
+		start, err := time.ParseInLocation("2006-01-02", startDate, time.Local)
 		if err != nil {
-			logger.Warnf("Failed to get workload for employee %d: %v", employee.ID, err)
-			continue
+			return time.Time{}, time.Time{}, response.Wrapf(ErrInvalidWorkloadRange, "start_date格式应为2006-01-02")
 		}
+		from = start
+	}
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, response.Wrapf(ErrInvalidWorkloadRange, "start_date不能晚于end_date")
+	}
+	return from, to, nil
+}
 
-		totalTasks += workload.ActiveTasks + workload.PendingTasks
-		completedTasks += workload.CompletedTasks
-		totalWorkloadRate += workload.WorkloadRate
+// buildWorkloadResponse 根据员工档案和任务统计构建工作负载响应，stats为nil表示员工没有任务
+func buildWorkloadResponse(employee *database.Employee, stats *repository.EmployeeTaskStats) *WorkloadResponse {
+	workload := &WorkloadResponse{
+		EmployeeID:     employee.ID,
+		EmployeeName:   employee.User.RealName,
+		Department:     employee.Department.Name, // 使用关联的部门名称
+		ActiveTasks:    employee.CurrentTasks,
+		MaxTasks:       employee.MaxTasks,
+		Status:         employee.Status,
+		LastActiveTime: employee.UpdatedAt.Format("2006-01-02 15:04:05"),
+	}
+	if employee.MaxTasks > 0 {
+		workload.WorkloadRate = float64(employee.CurrentTasks) / float64(employee.MaxTasks)
+	}
+	if stats != nil {
+		workload.PendingTasks = stats.PendingTasks
+		workload.CompletedTasks = stats.CompletedTasks
+		workload.OverdueTasks = stats.OverdueTasks
+		workload.AvgTaskDuration = stats.AvgDurationHours
+		if stats.CompletedTasks > 0 {
+			workload.EfficiencyRate = float64(stats.OnTimeTasks) / float64(stats.CompletedTasks)
+		}
 	}
+	return workload
+}
 
-	avgWorkloadRate := float64(0)
-	if totalEmployees > 0 {
-		avgWorkloadRate = totalWorkloadRate / float64(totalEmployees)
+// buildEmployeeResponses 批量构建员工响应，员工须已预加载用户；技能和项目各用一次查询加载，查询次数不随员工数增长
+func (s *EmployeeServiceImpl) buildEmployeeResponses(ctx context.Context, employees []*database.Employee) []*EmployeeResponse {
+	employeeIDs := make([]uint, 0, len(employees))
+	for _, employee := range employees {
+		employeeIDs = append(employeeIDs, employee.ID)
 	}
 
-	// 获取部门名称
-	departmentName := "Unknown"
-	if len(employees) > 0 {
-		departmentName = employees[0].Department.Name
+	skills, err := s.skillRepo.GetEmployeesWithSkillLevels(ctx, employeeIDs)
+	if err != nil {
+		logger.Warnf("Failed to get employee skills: %v", err)
+		skills = nil
+	}
+	projects, err := s.projectRepo.GetByMemberIDs(ctx, employeeIDs)
+	if err != nil {
+		logger.Warnf("Failed to get employee projects: %v", err)
+		projects = nil
 	}
 
-	return &DepartmentWorkloadResponse{
-		Department:      departmentName,
-		TotalEmployees:  totalEmployees,
-		ActiveEmployees: activeEmployees,
-		TotalTasks:      totalTasks,
-		CompletedTasks:  completedTasks,
-		AvgWorkloadRate: avgWorkloadRate,
-		OverloadedCount: 0, // TODO: 计算超负荷员工数量
-	}, nil
+	responses := make([]*EmployeeResponse, 0, len(employees))
+	for _, employee := range employees {
+		if employee.User.ID == 0 {
+			logger.Warnf("Failed to get user info for employee %d", employee.ID)
+			continue
+		}
+		responses = append(responses, EmployeeToResponse(employee, skills[employee.ID], projects[employee.ID]))
+	}
+	return responses
 }
 
 // buildEmployeeResponse 构建员工响应对象
-func (s *EmployeeServiceImpl) buildEmployeeResponse(employee *database.Employee, user *database.User) *EmployeeResponse {
-	response := &EmployeeResponse{
-		ID:                 employee.ID,
-		Name:               user.RealName,
-		Email:              user.Email,
-		Department:         employee.Department.Name,
-		Position:           employee.Position.Name,
-		Status:             employee.Status,
-		MaxConcurrentTasks: employee.MaxTasks,
-		CurrentTasks:       employee.CurrentTasks,
-		CreatedAt:          employee.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		UpdatedAt:          employee.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
-	}
-
-	// 获取员工技能信息（包括级别）
-	ctx := context.Background()
-	employeeSkills, err := s.skillRepo.GetEmployeeSkills(ctx, employee.ID)
+func (s *EmployeeServiceImpl) buildEmployeeResponse(ctx context.Context, employee *database.Employee, user *database.User) *EmployeeResponse {
+	// 获取员工技能信息（包括级别），单次查询
+	skills, err := s.skillRepo.GetEmployeeSkillsWithLevel(ctx, employee.ID)
 	if err != nil {
 		logger.Warnf("Failed to get employee skills for employee %d: %v", employee.ID, err)
-		response.Skills = []SkillResponse{}
-	} else {
-		response.Skills = make([]SkillResponse, 0, len(employeeSkills))
-		for _, skill := range employeeSkills {
-			// 获取技能级别
-			level, err := s.skillRepo.GetEmployeeSkillLevel(ctx, employee.ID, skill.ID)
-			if err != nil {
-				logger.Warnf("Failed to get skill level for employee %d, skill %d: %v", employee.ID, skill.ID, err)
-				level = 1 // 默认级别
-			}
-
-			response.Skills = append(response.Skills, SkillResponse{
-				ID:          skill.ID,
-				Name:        skill.Name,
-				Category:    skill.Category,
-				Description: skill.Description,
-				//Tags:        []string{}, // TODO: 解析JSON格式的tags
-				Level:     level, // 员工技能级别
-				CreatedAt: skill.CreatedAt.Format("2006-01-02 15:04:05"),
-				UpdatedAt: skill.UpdatedAt.Format("2006-01-02 15:04:05"),
-			})
-		}
+		skills = nil
+	}
+
+	// 获取员工参与的项目
+	projects, err := s.projectRepo.GetByMemberID(ctx, employee.ID)
+	if err != nil {
+		logger.Warnf("Failed to get employee projects for employee %d: %v", employee.ID, err)
+		projects = nil
 	}
 
+	response := EmployeeToResponse(employee, skills, projects)
+	response.Name = user.RealName
+	response.Email = user.Email
+
 	return response
 }
@@ -0,0 +1,345 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"taskmanage/internal/assignment"
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+	"taskmanage/pkg/logger"
+	"taskmanage/pkg/response"
+)
+
+var (
+	// ErrInvalidEmployeeCapacity 容量条目不合法
+	ErrInvalidEmployeeCapacity = response.NewError(response.ErrCodeInvalidRequest, "员工容量条目不合法")
+	// ErrEmployeeCapacityOverlap 容量条目的日期范围与员工已有条目重叠
+	ErrEmployeeCapacityOverlap = response.NewError(response.ErrCodeConflict, "员工容量条目日期范围重叠")
+	// ErrEmployeeCapacityNotFound 容量条目不存在或不属于该员工
+	ErrEmployeeCapacityNotFound = response.NewError(response.ErrCodeNotFound, "员工容量条目不存在")
+)
+
+// EmployeeCapacityRequest 创建或更新容量条目请求，日期格式为2006-01-02，范围包含首尾两天
+type EmployeeCapacityRequest struct {
+	StartDate      string   `json:"start_date" binding:"required"`
+	EndDate        string   `json:"end_date" binding:"required"`
+	CapacityFactor *float64 `json:"capacity_factor" binding:"required"` // 0-1，0表示请假
+	Reason         string   `json:"reason" binding:"max=255"`
+}
+
+// EmployeeCapacityResponse 容量条目响应
+type EmployeeCapacityResponse struct {
+	ID             uint      `json:"id"`
+	EmployeeID     uint      `json:"employee_id"`
+	StartDate      string    `json:"start_date"`
+	EndDate        string    `json:"end_date"`
+	CapacityFactor float64   `json:"capacity_factor"`
+	Reason         string    `json:"reason"`
+	LeaveApplied   bool      `json:"leave_applied"` // 请假已生效，员工状态已置为on_leave
+	CreatedBy      uint      `json:"created_by"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// LeaveStatusSyncReport 请假状态同步结果
+type LeaveStatusSyncReport struct {
+	Applied  []uint `json:"applied"`  // 置为on_leave的员工
+	Restored []uint `json:"restored"` // 恢复原状态的员工
+	Failed   int    `json:"failed"`
+}
+
+// EmployeeCapacityService 员工容量日历服务
+type EmployeeCapacityService interface {
+	// ListCapacities 获取员工的全部容量条目，按开始日期排序
+	ListCapacities(ctx context.Context, employeeID uint) ([]*EmployeeCapacityResponse, error)
+	// CreateCapacity 创建容量条目，日期范围不能与已有条目重叠
+	CreateCapacity(ctx context.Context, employeeID, createdBy uint, req *EmployeeCapacityRequest) (*EmployeeCapacityResponse, error)
+	// UpdateCapacity 更新容量条目，日期范围不能与其它条目重叠
+	UpdateCapacity(ctx context.Context, employeeID, capacityID uint, req *EmployeeCapacityRequest) (*EmployeeCapacityResponse, error)
+	// DeleteCapacity 删除容量条目，已生效的请假同时恢复员工状态
+	DeleteCapacity(ctx context.Context, employeeID, capacityID uint) error
+	// SyncLeaveStatus 请假开始时将员工状态置为on_leave，结束后恢复原状态
+	SyncLeaveStatus(ctx context.Context, now time.Time) (*LeaveStatusSyncReport, error)
+}
+
+// employeeCapacityService 员工容量日历服务实现
+type employeeCapacityService struct {
+	repoManager repository.RepositoryManager
+}
+
+// NewEmployeeCapacityService 创建员工容量日历服务
+func NewEmployeeCapacityService(repoManager repository.RepositoryManager) EmployeeCapacityService {
+	return &employeeCapacityService{repoManager: repoManager}
+}
+
+// ListCapacities 获取员工的全部容量条目
+func (s *employeeCapacityService) ListCapacities(ctx context.Context, employeeID uint) ([]*EmployeeCapacityResponse, error) {
+	if _, err := s.repoManager.EmployeeRepository().GetByID(ctx, employeeID); err != nil {
+		return nil, employeeLookupError(err)
+	}
+	capacities, err := s.repoManager.EmployeeCapacityRepository().ListByEmployee(ctx, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	responses := make([]*EmployeeCapacityResponse, 0, len(capacities))
+	for _, capacity := range capacities {
+		responses = append(responses, toEmployeeCapacityResponse(capacity))
+	}
+	return responses, nil
+}
+
+// CreateCapacity 创建容量条目
+func (s *employeeCapacityService) CreateCapacity(ctx context.Context, employeeID, createdBy uint, req *EmployeeCapacityRequest) (*EmployeeCapacityResponse, error) {
+	start, end, err := parseEmployeeCapacityRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	capacity := &database.EmployeeCapacity{
+		EmployeeID:     employeeID,
+		StartDate:      start,
+		EndDate:        end,
+		CapacityFactor: *req.CapacityFactor,
+		Reason:         strings.TrimSpace(req.Reason),
+		CreatedBy:      createdBy,
+	}
+	err = s.repoManager.WithTx(ctx, func(ctx context.Context, repos repository.RepositoryManager) error {
+		if _, err := repos.EmployeeRepository().GetByID(ctx, employeeID); err != nil {
+			return employeeLookupError(err)
+		}
+		if err := checkCapacityOverlap(ctx, repos.EmployeeCapacityRepository(), capacity); err != nil {
+			return err
+		}
+		return repos.EmployeeCapacityRepository().Create(ctx, capacity)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("员工容量条目创建成功: ID=%d, EmployeeID=%d, %s~%s, Factor=%.2f",
+		capacity.ID, employeeID, req.StartDate, req.EndDate, capacity.CapacityFactor)
+	return toEmployeeCapacityResponse(capacity), nil
+}
+
+// UpdateCapacity 更新容量条目，已生效的请假先恢复员工状态，仍处于请假期间时由定时任务重新生效
+func (s *employeeCapacityService) UpdateCapacity(ctx context.Context, employeeID, capacityID uint, req *EmployeeCapacityRequest) (*EmployeeCapacityResponse, error) {
+	start, end, err := parseEmployeeCapacityRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var capacity *database.EmployeeCapacity
+	err = s.repoManager.WithTx(ctx, func(ctx context.Context, repos repository.RepositoryManager) error {
+		var err error
+		if capacity, err = getEmployeeCapacity(ctx, repos.EmployeeCapacityRepository(), employeeID, capacityID); err != nil {
+			return err
+		}
+		capacity.StartDate = start
+		capacity.EndDate = end
+		capacity.CapacityFactor = *req.CapacityFactor
+		capacity.Reason = strings.TrimSpace(req.Reason)
+		if err := checkCapacityOverlap(ctx, repos.EmployeeCapacityRepository(), capacity); err != nil {
+			return err
+		}
+		if capacity.LeaveApplied {
+			if _, err := restoreLeaveStatus(ctx, repos, capacity); err != nil {
+				return err
+			}
+		}
+		return repos.EmployeeCapacityRepository().Update(ctx, capacity)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toEmployeeCapacityResponse(capacity), nil
+}
+
+// DeleteCapacity 删除容量条目
+func (s *employeeCapacityService) DeleteCapacity(ctx context.Context, employeeID, capacityID uint) error {
+	return s.repoManager.WithTx(ctx, func(ctx context.Context, repos repository.RepositoryManager) error {
+		capacity, err := getEmployeeCapacity(ctx, repos.EmployeeCapacityRepository(), employeeID, capacityID)
+		if err != nil {
+			return err
+		}
+		if capacity.LeaveApplied {
+			if _, err := restoreLeaveStatus(ctx, repos, capacity); err != nil {
+				return err
+			}
+		}
+		return repos.EmployeeCapacityRepository().Delete(ctx, capacityID)
+	})
+}
+
+// SyncLeaveStatus 先恢复已结束请假的员工状态，再使当天开始的请假生效，单个条目失败不影响其它条目
+func (s *employeeCapacityService) SyncLeaveStatus(ctx context.Context, now time.Time) (*LeaveStatusSyncReport, error) {
+	day := assignment.Day(now)
+	report := &LeaveStatusSyncReport{}
+
+	ended, err := s.repoManager.EmployeeCapacityRepository().ListLeavesToRestore(ctx, day)
+	if err != nil {
+		return nil, err
+	}
+	for _, capacity := range ended {
+		var restored bool
+		err := s.repoManager.WithTx(ctx, func(ctx context.Context, repos repository.RepositoryManager) error {
+			var err error
+			if restored, err = restoreLeaveStatus(ctx, repos, capacity); err != nil {
+				return err
+			}
+			return repos.EmployeeCapacityRepository().Update(ctx, capacity)
+		})
+		if err != nil {
+			logger.Warnf("恢复请假结束员工状态失败: CapacityID=%d, EmployeeID=%d, %v", capacity.ID, capacity.EmployeeID, err)
+			report.Failed++
+			continue
+		}
+		if restored {
+			report.Restored = append(report.Restored, capacity.EmployeeID)
+		}
+	}
+
+	started, err := s.repoManager.EmployeeCapacityRepository().ListLeavesToApply(ctx, day)
+	if err != nil {
+		return nil, err
+	}
+	for _, capacity := range started {
+		var applied bool
+		err := s.repoManager.WithTx(ctx, func(ctx context.Context, repos repository.RepositoryManager) error {
+			var err error
+			if applied, err = applyLeaveStatus(ctx, repos, capacity); err != nil {
+				return err
+			}
+			return repos.EmployeeCapacityRepository().Update(ctx, capacity)
+		})
+		if err != nil {
+			logger.Warnf("设置请假员工状态失败: CapacityID=%d, EmployeeID=%d, %v", capacity.ID, capacity.EmployeeID, err)
+			report.Failed++
+			continue
+		}
+		if applied {
+			report.Applied = append(report.Applied, capacity.EmployeeID)
+		}
+	}
+	return report, nil
+}
+
+// applyLeaveStatus 记录员工当前状态并置为on_leave，员工已是on_leave时只标记请假已生效
+func applyLeaveStatus(ctx context.Context, repos repository.RepositoryManager, capacity *database.EmployeeCapacity) (bool, error) {
+	employee, err := repos.EmployeeRepository().GetByID(ctx, capacity.EmployeeID)
+	if err != nil {
+		return false, employeeLookupError(err)
+	}
+	capacity.LeaveApplied = true
+	capacity.PreviousStatus = employee.Status
+	if employee.Status == EmployeeStatusOnLeave {
+		return false, nil
+	}
+	if err := repos.EmployeeRepository().UpdateStatus(ctx, employee.ID, EmployeeStatusOnLeave); err != nil {
+		return false, fmt.Errorf("更新员工状态失败: %w", err)
+	}
+	return true, nil
+}
+
+// restoreLeaveStatus 员工仍为on_leave时恢复请假前的状态，期间被手动修改过的状态保持不变
+func restoreLeaveStatus(ctx context.Context, repos repository.RepositoryManager, capacity *database.EmployeeCapacity) (bool, error) {
+	previous := capacity.PreviousStatus
+	capacity.LeaveApplied = false
+	capacity.PreviousStatus = ""
+
+	employee, err := repos.EmployeeRepository().GetByID(ctx, capacity.EmployeeID)
+	if err != nil {
+		return false, employeeLookupError(err)
+	}
+	if employee.Status != EmployeeStatusOnLeave || previous == EmployeeStatusOnLeave {
+		return false, nil
+	}
+	if previous == "" {
+		previous = EmployeeStatusAvailable
+	}
+	if err := repos.EmployeeRepository().UpdateStatus(ctx, employee.ID, previous); err != nil {
+		return false, fmt.Errorf("更新员工状态失败: %w", err)
+	}
+	return true, nil
+}
+
+// effectiveCapacity 按从今天到截止日期的容量日历折算员工的有效容量，返回有效容量和容量系数
+// capacityRepo为nil时不折算
+func effectiveCapacity(ctx context.Context, capacityRepo repository.EmployeeCapacityRepository, employee *database.Employee, deadline *time.Time, now time.Time) (int, float64, error) {
+	if capacityRepo == nil {
+		return employee.MaxTasks, 1, nil
+	}
+	from, to := assignment.CapacityWindow(now, deadline)
+	entries, err := capacityRepo.ListOverlapping(ctx, employee.ID, from, to)
+	if err != nil {
+		return 0, 0, fmt.Errorf("获取员工容量日历失败: %w", err)
+	}
+	factor := assignment.CapacityFactor(entries)
+	return assignment.EffectiveMaxTasks(employee.MaxTasks, factor), factor, nil
+}
+
+// getEmployeeCapacity 获取属于员工的容量条目
+func getEmployeeCapacity(ctx context.Context, repo repository.EmployeeCapacityRepository, employeeID, capacityID uint) (*database.EmployeeCapacity, error) {
+	capacity, err := repo.GetByID(ctx, capacityID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrEmployeeCapacityNotFound.WithCause(err)
+		}
+		return nil, err
+	}
+	if capacity.EmployeeID != employeeID {
+		return nil, ErrEmployeeCapacityNotFound
+	}
+	return capacity, nil
+}
+
+// checkCapacityOverlap 检查容量条目与员工其它条目的日期范围是否重叠
+func checkCapacityOverlap(ctx context.Context, repo repository.EmployeeCapacityRepository, capacity *database.EmployeeCapacity) error {
+	overlapping, err := repo.ListOverlapping(ctx, capacity.EmployeeID, capacity.StartDate, capacity.EndDate)
+	if err != nil {
+		return err
+	}
+	for _, other := range overlapping {
+		if other.ID != capacity.ID {
+			return response.Wrapf(ErrEmployeeCapacityOverlap, "与条目%d(%s~%s)重叠",
+				other.ID, other.StartDate.Format("2006-01-02"), other.EndDate.Format("2006-01-02"))
+		}
+	}
+	return nil
+}
+
+// parseEmployeeCapacityRequest 校验容量系数并解析日期范围
+func parseEmployeeCapacityRequest(req *EmployeeCapacityRequest) (time.Time, time.Time, error) {
+	if req.CapacityFactor == nil || *req.CapacityFactor < 0 || *req.CapacityFactor > 1 {
+		return time.Time{}, time.Time{}, response.Wrapf(ErrInvalidEmployeeCapacity, "capacity_factor须在0到1之间")
+	}
+	start, err := time.ParseInLocation("2006-01-02", req.StartDate, time.Local)
+	if err != nil {
+		return time.Time{}, time.Time{}, response.Wrapf(ErrInvalidEmployeeCapacity, "start_date格式应为2006-01-02")
+	}
+	end, err := time.ParseInLocation("2006-01-02", req.EndDate, time.Local)
+	if err != nil {
+		return time.Time{}, time.Time{}, response.Wrapf(ErrInvalidEmployeeCapacity, "end_date格式应为2006-01-02")
+	}
+	if end.Before(start) {
+		return time.Time{}, time.Time{}, response.Wrapf(ErrInvalidEmployeeCapacity, "end_date不能早于start_date")
+	}
+	return start, end, nil
+}
+
+// toEmployeeCapacityResponse 转换容量条目响应
+func toEmployeeCapacityResponse(capacity *database.EmployeeCapacity) *EmployeeCapacityResponse {
+	return &EmployeeCapacityResponse{
+		ID:             capacity.ID,
+		EmployeeID:     capacity.EmployeeID,
+		StartDate:      capacity.StartDate.Format("2006-01-02"),
+		EndDate:        capacity.EndDate.Format("2006-01-02"),
+		CapacityFactor: capacity.CapacityFactor,
+		Reason:         capacity.Reason,
+		LeaveApplied:   capacity.LeaveApplied,
+		CreatedBy:      capacity.CreatedBy,
+		CreatedAt:      capacity.CreatedAt,
+	}
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+// memoryCapacityRepository 测试用内存容量日历仓储
+type memoryCapacityRepository struct {
+	repository.EmployeeCapacityRepository
+	capacities map[uint]*database.EmployeeCapacity
+	nextID     uint
+}
+
+func (r *memoryCapacityRepository) Create(ctx context.Context, capacity *database.EmployeeCapacity) error {
+	r.nextID++
+	capacity.ID = r.nextID
+	r.capacities[capacity.ID] = capacity
+	return nil
+}
+
+func (r *memoryCapacityRepository) GetByID(ctx context.Context, id uint) (*database.EmployeeCapacity, error) {
+	capacity, ok := r.capacities[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	copied := *capacity
+	return &copied, nil
+}
+
+func (r *memoryCapacityRepository) Update(ctx context.Context, capacity *database.EmployeeCapacity) error {
+	r.capacities[capacity.ID] = capacity
+	return nil
+}
+
+func (r *memoryCapacityRepository) ListOverlapping(ctx context.Context, employeeID uint, from, to time.Time) ([]*database.EmployeeCapacity, error) {
+	var result []*database.EmployeeCapacity
+	for _, c := range r.capacities {
+		if c.EmployeeID == employeeID && !c.StartDate.After(to) && !c.EndDate.Before(from) {
+			result = append(result, c)
+		}
+	}
+	return result, nil
+}
+
+func (r *memoryCapacityRepository) ListLeavesToApply(ctx context.Context, day time.Time) ([]*database.EmployeeCapacity, error) {
+	var result []*database.EmployeeCapacity
+	for _, c := range r.capacities {
+		if c.IsLeave() && !c.LeaveApplied && !c.StartDate.After(day) && !c.EndDate.Before(day) {
+			result = append(result, c)
+		}
+	}
+	return result, nil
+}
+
+func (r *memoryCapacityRepository) ListLeavesToRestore(ctx context.Context, day time.Time) ([]*database.EmployeeCapacity, error) {
+	var result []*database.EmployeeCapacity
+	for _, c := range r.capacities {
+		if c.LeaveApplied && c.EndDate.Before(day) {
+			result = append(result, c)
+		}
+	}
+	return result, nil
+}
+
+// capacityEmployeeRepository 记录员工状态的员工仓储
+type capacityEmployeeRepository struct {
+	repository.EmployeeRepository
+	employees map[uint]*database.Employee
+}
+
+func (r *capacityEmployeeRepository) GetByID(ctx context.Context, id uint) (*database.Employee, error) {
+	employee, ok := r.employees[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return employee, nil
+}
+
+func (r *capacityEmployeeRepository) UpdateStatus(ctx context.Context, id uint, status string) error {
+	r.employees[id].Status = status
+	return nil
+}
+
+// capacityRepoManager 容量日历测试用仓储管理器
+type capacityRepoManager struct {
+	repository.RepositoryManager
+	employees  *capacityEmployeeRepository
+	capacities *memoryCapacityRepository
+}
+
+func (m *capacityRepoManager) EmployeeRepository() repository.EmployeeRepository {
+	return m.employees
+}
+
+func (m *capacityRepoManager) EmployeeCapacityRepository() repository.EmployeeCapacityRepository {
+	return m.capacities
+}
+
+func (m *capacityRepoManager) WithTx(ctx context.Context, fn func(ctx context.Context, repos repository.RepositoryManager) error) error {
+	return fn(ctx, m)
+}
+
+func newCapacityRepoManager() *capacityRepoManager {
+	employee := &database.Employee{Status: EmployeeStatusBusy, MaxTasks: 5}
+	employee.ID = 1
+	return &capacityRepoManager{
+		employees:  &capacityEmployeeRepository{employees: map[uint]*database.Employee{1: employee}},
+		capacities: &memoryCapacityRepository{capacities: map[uint]*database.EmployeeCapacity{}},
+	}
+}
+
+func TestEmployeeCapacityService_CreateCapacity(t *testing.T) {
+	repos := newCapacityRepoManager()
+	svc := NewEmployeeCapacityService(repos)
+	ctx := context.Background()
+	factor := func(f float64) *float64 { return &f }
+
+	_, err := svc.CreateCapacity(ctx, 1, 9, &EmployeeCapacityRequest{StartDate: "2026-03-02", EndDate: "2026-03-06", CapacityFactor: factor(1.5)})
+	assert.ErrorIs(t, err, ErrInvalidEmployeeCapacity)
+	_, err = svc.CreateCapacity(ctx, 1, 9, &EmployeeCapacityRequest{StartDate: "2026-03-06", EndDate: "2026-03-02", CapacityFactor: factor(0.5)})
+	assert.ErrorIs(t, err, ErrInvalidEmployeeCapacity)
+	_, err = svc.CreateCapacity(ctx, 2, 9, &EmployeeCapacityRequest{StartDate: "2026-03-02", EndDate: "2026-03-06", CapacityFactor: factor(0.5)})
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	created, err := svc.CreateCapacity(ctx, 1, 9, &EmployeeCapacityRequest{StartDate: "2026-03-02", EndDate: "2026-03-06", CapacityFactor: factor(0.5)})
+	require.NoError(t, err)
+	assert.Equal(t, "2026-03-06", created.EndDate)
+
+	// 首尾日期相接也算重叠
+	_, err = svc.CreateCapacity(ctx, 1, 9, &EmployeeCapacityRequest{StartDate: "2026-03-06", EndDate: "2026-03-09", CapacityFactor: factor(0)})
+	assert.ErrorIs(t, err, ErrEmployeeCapacityOverlap)
+	_, err = svc.CreateCapacity(ctx, 1, 9, &EmployeeCapacityRequest{StartDate: "2026-03-07", EndDate: "2026-03-09", CapacityFactor: factor(0)})
+	assert.NoError(t, err)
+
+	// 更新时不与自身比较
+	_, err = svc.UpdateCapacity(ctx, 1, created.ID, &EmployeeCapacityRequest{StartDate: "2026-03-01", EndDate: "2026-03-06", CapacityFactor: factor(0.8)})
+	assert.NoError(t, err)
+	_, err = svc.UpdateCapacity(ctx, 2, created.ID, &EmployeeCapacityRequest{StartDate: "2026-03-01", EndDate: "2026-03-06", CapacityFactor: factor(0.8)})
+	assert.ErrorIs(t, err, ErrEmployeeCapacityNotFound)
+}
+
+func TestEmployeeCapacityService_SyncLeaveStatus(t *testing.T) {
+	repos := newCapacityRepoManager()
+	svc := NewEmployeeCapacityService(repos)
+	ctx := context.Background()
+	leave := 0.0
+
+	_, err := svc.CreateCapacity(ctx, 1, 9, &EmployeeCapacityRequest{StartDate: "2026-03-02", EndDate: "2026-03-03", CapacityFactor: &leave})
+	require.NoError(t, err)
+	employee := repos.employees.employees[1]
+
+	report, err := svc.SyncLeaveStatus(ctx, time.Date(2026, 3, 1, 9, 0, 0, 0, time.Local))
+	require.NoError(t, err)
+	assert.Empty(t, report.Applied)
+	assert.Equal(t, EmployeeStatusBusy, employee.Status)
+
+	report, err = svc.SyncLeaveStatus(ctx, time.Date(2026, 3, 2, 9, 0, 0, 0, time.Local))
+	require.NoError(t, err)
+	assert.Equal(t, []uint{1}, report.Applied)
+	assert.Equal(t, EmployeeStatusOnLeave, employee.Status)
+
+	// 请假期间有效容量为0
+	effectiveMaxTasks, factor, err := effectiveCapacity(ctx, repos.capacities, employee, nil, time.Date(2026, 3, 3, 9, 0, 0, 0, time.Local))
+	require.NoError(t, err)
+	assert.Equal(t, 0, effectiveMaxTasks)
+	assert.Equal(t, 0.0, factor)
+
+	report, err = svc.SyncLeaveStatus(ctx, time.Date(2026, 3, 3, 23, 0, 0, 0, time.Local))
+	require.NoError(t, err)
+	assert.Empty(t, report.Applied)
+	assert.Empty(t, report.Restored)
+
+	report, err = svc.SyncLeaveStatus(ctx, time.Date(2026, 3, 4, 0, 30, 0, 0, time.Local))
+	require.NoError(t, err)
+	assert.Equal(t, []uint{1}, report.Restored)
+	assert.Equal(t, EmployeeStatusBusy, employee.Status)
+}
+
+func TestEffectiveCapacity_DueWindow(t *testing.T) {
+	repos := newCapacityRepoManager()
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, time.Local)
+	repos.capacities.capacities[1] = &database.EmployeeCapacity{
+		EmployeeID: 1, CapacityFactor: 0.5,
+		StartDate: time.Date(2026, 3, 5, 0, 0, 0, 0, time.Local), EndDate: time.Date(2026, 3, 6, 0, 0, 0, 0, time.Local),
+	}
+	employee := repos.employees.employees[1]
+
+	effectiveMaxTasks, _, err := effectiveCapacity(context.Background(), repos.capacities, employee, nil, now)
+	require.NoError(t, err)
+	assert.Equal(t, 5, effectiveMaxTasks)
+
+	due := time.Date(2026, 3, 5, 18, 0, 0, 0, time.Local)
+	effectiveMaxTasks, factor, err := effectiveCapacity(context.Background(), repos.capacities, employee, &due, now)
+	require.NoError(t, err)
+	assert.Equal(t, 2, effectiveMaxTasks)
+	assert.Equal(t, 0.5, factor)
+}
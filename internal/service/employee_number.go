@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"taskmanage/internal/config"
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+	"taskmanage/pkg/response"
+)
+
+// 员工编号序号来源
+const (
+	EmployeeNoSourceSequence = "sequence"
+	EmployeeNoSourceUserID   = "user_id"
+)
+
+// 员工编号默认格式
+const (
+	DefaultEmployeeNoPrefix  = "EMP"
+	DefaultEmployeeNoPadding = 6
+)
+
+// maxEmployeeNoAttempts 分配员工编号时跳过已占用编号的最大次数
+const maxEmployeeNoAttempts = 20
+
+// ErrEmployeeNoTaken 员工编号已被使用
+var ErrEmployeeNoTaken = response.NewError(response.ErrCodeConflict, "员工编号已被使用")
+
+// EmployeeNumberGenerator 员工编号生成器，员工的各创建入口统一通过它分配编号
+type EmployeeNumberGenerator interface {
+	// Assign 使用外部提供的编号或按配置分配新编号，外部编号已被使用时返回ErrEmployeeNoTaken
+	Assign(ctx context.Context, requested string, userID uint) (string, error)
+
+	// Matches 编号是否符合配置的格式
+	Matches(employeeNo string) bool
+
+	// WithRepos 返回使用指定仓储的生成器，在事务中分配编号时传入事务内的仓储
+	WithRepos(repos repository.RepositoryManager) EmployeeNumberGenerator
+}
+
+// employeeNumberGenerator 按前缀加补零序号生成员工编号
+type employeeNumberGenerator struct {
+	repos   repository.RepositoryManager
+	prefix  string
+	padding int
+	source  string
+	pattern *regexp.Regexp
+}
+
+// NewEmployeeNumberGenerator 创建员工编号生成器，未配置的项使用默认值
+func NewEmployeeNumberGenerator(repos repository.RepositoryManager, cfg config.EmployeeNumberConfig) EmployeeNumberGenerator {
+	g := &employeeNumberGenerator{
+		repos:   repos,
+		prefix:  cfg.Prefix,
+		padding: cfg.Padding,
+		source:  cfg.Source,
+	}
+	if g.prefix == "" {
+		g.prefix = DefaultEmployeeNoPrefix
+	}
+	if g.padding <= 0 {
+		g.padding = DefaultEmployeeNoPadding
+	}
+	if g.source == "" {
+		g.source = EmployeeNoSourceSequence
+	}
+	g.pattern = regexp.MustCompile(fmt.Sprintf(`^%s\d{%d,}$`, regexp.QuoteMeta(g.prefix), g.padding))
+	return g
+}
+
+// WithRepos 返回使用指定仓储的生成器
+func (g *employeeNumberGenerator) WithRepos(repos repository.RepositoryManager) EmployeeNumberGenerator {
+	clone := *g
+	clone.repos = repos
+	return &clone
+}
+
+// Matches 编号是否为前缀加至少padding位数字
+func (g *employeeNumberGenerator) Matches(employeeNo string) bool {
+	return g.pattern.MatchString(employeeNo)
+}
+
+// Assign 外部编号只校验唯一性，不要求符合配置的格式，以便导入历史员工
+// 按计数器分配时跳过已被导入编号占用的序号；按用户ID生成的编号被占用时返回ErrEmployeeNoTaken
+func (g *employeeNumberGenerator) Assign(ctx context.Context, requested string, userID uint) (string, error) {
+	employees := g.repos.EmployeeRepository()
+	if requested = strings.TrimSpace(requested); requested != "" {
+		exists, err := employees.EmployeeNoExists(ctx, requested)
+		if err != nil {
+			return "", err
+		}
+		if exists {
+			return "", response.Wrapf(ErrEmployeeNoTaken, "员工编号%s已被使用", requested)
+		}
+		return requested, nil
+	}
+
+	for attempt := 0; attempt < maxEmployeeNoAttempts; attempt++ {
+		seq := int64(userID)
+		if g.source == EmployeeNoSourceSequence {
+			next, err := g.repos.CounterRepository().Next(ctx, database.EmployeeNoCounter)
+			if err != nil {
+				return "", err
+			}
+			seq = next
+		}
+		employeeNo := fmt.Sprintf("%s%0*d", g.prefix, g.padding, seq)
+		exists, err := employees.EmployeeNoExists(ctx, employeeNo)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return employeeNo, nil
+		}
+		if g.source != EmployeeNoSourceSequence {
+			return "", response.Wrapf(ErrEmployeeNoTaken, "员工编号%s已被使用", employeeNo)
+		}
+	}
+	return "", response.Wrapf(ErrEmployeeNoTaken, "连续%d个序号对应的员工编号均已被使用", maxEmployeeNoAttempts)
+}
+
+// EmployeeNoCheckReport 已有员工编号的检查结果
+type EmployeeNoCheckReport struct {
+	Total      int               `json:"total"`
+	Duplicates map[string][]uint `json:"duplicates"` // 重复的编号及使用该编号的员工ID
+	Mismatched []*EmployeeNoItem `json:"mismatched"` // 不符合配置格式的编号
+}
+
+// EmployeeNoItem 员工编号
+type EmployeeNoItem struct {
+	EmployeeID uint   `json:"employee_id"`
+	EmployeeNo string `json:"employee_no"`
+}
+
+// CheckEmployeeNos 检查已有员工（包含已删除）中重复或不符合配置格式的编号，用于切换编号规则或导入历史员工后核对
+func CheckEmployeeNos(ctx context.Context, employees repository.EmployeeRepository, generator EmployeeNumberGenerator) (*EmployeeNoCheckReport, error) {
+	list, err := employees.ListEmployeeNos(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &EmployeeNoCheckReport{
+		Total:      len(list),
+		Duplicates: make(map[string][]uint),
+		Mismatched: []*EmployeeNoItem{},
+	}
+	byNo := make(map[string][]uint, len(list))
+	for _, employee := range list {
+		byNo[employee.EmployeeNo] = append(byNo[employee.EmployeeNo], employee.ID)
+		if !generator.Matches(employee.EmployeeNo) {
+			report.Mismatched = append(report.Mismatched, &EmployeeNoItem{EmployeeID: employee.ID, EmployeeNo: employee.EmployeeNo})
+		}
+	}
+	for employeeNo, ids := range byNo {
+		if len(ids) > 1 {
+			report.Duplicates[employeeNo] = ids
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/config"
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+// memoryCounterRepository 内存计数器仓储
+type memoryCounterRepository struct {
+	values map[string]int64
+}
+
+func (r *memoryCounterRepository) Next(ctx context.Context, name string) (int64, error) {
+	r.values[name]++
+	return r.values[name], nil
+}
+
+// employeeNoRepository 按编号查找的员工仓储
+type employeeNoRepository struct {
+	repository.EmployeeRepository
+	employees []*database.Employee
+}
+
+func (r *employeeNoRepository) EmployeeNoExists(ctx context.Context, employeeNo string) (bool, error) {
+	for _, employee := range r.employees {
+		if employee.EmployeeNo == employeeNo {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *employeeNoRepository) ListEmployeeNos(ctx context.Context) ([]*database.Employee, error) {
+	return r.employees, nil
+}
+
+// employeeNoRepoManager 员工编号测试用仓储管理器
+type employeeNoRepoManager struct {
+	repository.RepositoryManager
+	counters  *memoryCounterRepository
+	employees *employeeNoRepository
+}
+
+func (m *employeeNoRepoManager) CounterRepository() repository.CounterRepository {
+	return m.counters
+}
+
+func (m *employeeNoRepoManager) EmployeeRepository() repository.EmployeeRepository {
+	return m.employees
+}
+
+func newEmployeeNoRepoManager(employeeNos ...string) *employeeNoRepoManager {
+	employees := &employeeNoRepository{}
+	for i, employeeNo := range employeeNos {
+		employee := &database.Employee{EmployeeNo: employeeNo}
+		employee.ID = uint(i + 1)
+		employees.employees = append(employees.employees, employee)
+	}
+	return &employeeNoRepoManager{
+		counters:  &memoryCounterRepository{values: make(map[string]int64)},
+		employees: employees,
+	}
+}
+
+func TestEmployeeNumberGenerator_Sequence(t *testing.T) {
+	repos := newEmployeeNoRepoManager("HR0002")
+	generator := NewEmployeeNumberGenerator(repos, config.EmployeeNumberConfig{Prefix: "HR", Padding: 4})
+	ctx := context.Background()
+
+	employeeNo, err := generator.Assign(ctx, "", 42)
+	require.NoError(t, err)
+	assert.Equal(t, "HR0001", employeeNo)
+
+	// 跳过已被导入编号占用的序号
+	employeeNo, err = generator.Assign(ctx, "", 43)
+	require.NoError(t, err)
+	assert.Equal(t, "HR0003", employeeNo)
+	assert.EqualValues(t, 3, repos.counters.values[database.EmployeeNoCounter])
+
+	// 超过补零位数时不截断
+	repos.counters.values[database.EmployeeNoCounter] = 9999
+	employeeNo, err = generator.Assign(ctx, "", 44)
+	require.NoError(t, err)
+	assert.Equal(t, "HR10000", employeeNo)
+	assert.True(t, generator.Matches(employeeNo))
+	assert.False(t, generator.Matches("HR123"))
+	assert.False(t, generator.Matches("EMP000001"))
+}
+
+func TestEmployeeNumberGenerator_UserIDAndExternal(t *testing.T) {
+	repos := newEmployeeNoRepoManager("EMP000007", "LEGACY-1")
+	generator := NewEmployeeNumberGenerator(repos, config.EmployeeNumberConfig{Source: EmployeeNoSourceUserID})
+	ctx := context.Background()
+
+	employeeNo, err := generator.Assign(ctx, "", 8)
+	require.NoError(t, err)
+	assert.Equal(t, "EMP000008", employeeNo)
+	assert.Empty(t, repos.counters.values)
+
+	_, err = generator.Assign(ctx, "", 7)
+	assert.ErrorIs(t, err, ErrEmployeeNoTaken)
+
+	// 外部编号只校验唯一性
+	employeeNo, err = generator.Assign(ctx, " LEGACY-2 ", 9)
+	require.NoError(t, err)
+	assert.Equal(t, "LEGACY-2", employeeNo)
+	_, err = generator.Assign(ctx, "LEGACY-1", 9)
+	assert.ErrorIs(t, err, ErrEmployeeNoTaken)
+
+	// 事务内使用传入的仓储
+	txRepos := newEmployeeNoRepoManager("EMP000008")
+	_, err = generator.WithRepos(txRepos).Assign(ctx, "", 8)
+	assert.ErrorIs(t, err, ErrEmployeeNoTaken)
+}
+
+func TestCheckEmployeeNos(t *testing.T) {
+	repos := newEmployeeNoRepoManager("EMP000001", "E-17", "EMP000002", "EMP000001")
+	generator := NewEmployeeNumberGenerator(repos, config.EmployeeNumberConfig{})
+
+	report, err := CheckEmployeeNos(context.Background(), repos.employees, generator)
+	require.NoError(t, err)
+	assert.Equal(t, 4, report.Total)
+	assert.Equal(t, map[string][]uint{"EMP000001": {1, 4}}, report.Duplicates)
+	require.Len(t, report.Mismatched, 1)
+	assert.Equal(t, &EmployeeNoItem{EmployeeID: 2, EmployeeNo: "E-17"}, report.Mismatched[0])
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+	"taskmanage/pkg/logger"
+	"taskmanage/pkg/response"
+)
+
+// ErrPositionNotFound 职位不存在
+var ErrPositionNotFound = response.NewError(response.ErrCodeInvalidRequest, "职位不存在")
+
+// 员工职位变更类型
+const (
+	EmployeeChangePromotion = "promotion" // 职级提高
+	EmployeeChangeDemotion  = "demotion"  // 职级降低
+	EmployeeChangeTransfer  = "transfer"  // 职级不变的调岗
+)
+
+// positionChange 员工职位变更前后的职位
+type positionChange struct {
+	oldPositionID *uint
+	newPositionID uint
+	oldLevel      int
+	newLevel      int
+}
+
+// changeType 按职级变化判断变更类型
+func (c *positionChange) changeType() string {
+	switch {
+	case c.newLevel > c.oldLevel:
+		return EmployeeChangePromotion
+	case c.newLevel < c.oldLevel:
+		return EmployeeChangeDemotion
+	default:
+		return EmployeeChangeTransfer
+	}
+}
+
+// loadPositionChange 加载变更前后的职位职级，原职位已删除时仍按其职级比较，原来没有职位的按职级0处理
+func (s *EmployeeServiceImpl) loadPositionChange(ctx context.Context, oldPositionID *uint, newPositionID uint) (*positionChange, error) {
+	newPosition, err := s.positionRepo.GetByID(ctx, newPositionID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrPositionNotFound
+		}
+		return nil, fmt.Errorf("failed to get position: %w", err)
+	}
+
+	change := &positionChange{oldPositionID: oldPositionID, newPositionID: newPositionID, newLevel: newPosition.Level}
+	if oldPositionID != nil {
+		oldPosition, err := s.positionRepo.GetByIDUnscoped(ctx, *oldPositionID)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return nil, fmt.Errorf("failed to get position: %w", err)
+		}
+		if oldPosition != nil {
+			change.oldLevel = oldPosition.Level
+		}
+	}
+	return change, nil
+}
+
+// applyPositionChange 记录职位变更历史，职级变化时按position_change规则调整权限
+// 员工已保存，这里的失败只记录日志，不影响更新结果
+func (s *EmployeeServiceImpl) applyPositionChange(ctx context.Context, employee *database.Employee, change *positionChange, operatorID uint) *PositionPermissionChangeResponse {
+	history := &database.EmployeeChangeHistory{
+		EmployeeID:    employee.ID,
+		ChangeType:    change.changeType(),
+		OldPositionID: change.oldPositionID,
+		NewPositionID: &change.newPositionID,
+		OldLevel:      change.oldLevel,
+		NewLevel:      change.newLevel,
+		OperatorID:    operatorID,
+	}
+	if err := s.historyRepo.Create(ctx, history); err != nil {
+		logger.Errorf("Failed to record position change for employee %d: %v", employee.ID, err)
+	}
+
+	if change.oldLevel == change.newLevel || s.permissions == nil {
+		return nil
+	}
+	result, err := s.permissions.ProcessPositionChange(ctx, employee.UserID, change.oldLevel, change.newLevel, operatorID)
+	if err != nil {
+		logger.Errorf("Failed to process permissions for position change of employee %d: %v", employee.ID, err)
+		return nil
+	}
+	return result
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"taskmanage/internal/repository"
+	"taskmanage/pkg/i18n"
+	"taskmanage/pkg/response"
+)
+
+// 服务层消息键，对应pkg/i18n/locales下的消息目录，参数由调用处填入
+const (
+	msgTaskAssignRequiresPending     = "task.assign_requires_pending"      // task_id, status
+	msgTaskAutoAssignRequiresPending = "task.auto_assign_requires_pending" // task_id, status
+	msgTaskSuggestRequiresPending    = "task.suggest_requires_pending"     // task_id, status
+	msgTaskInvalidAssignMethod       = "task.invalid_assign_method"        // method, allowed
+	msgTaskWIPLimitReached           = "task.wip_limit_reached"            // project, status, count, limit
+	msgEmployeeOffboarding           = "employee.offboarding"              // employee_id
+	msgEmployeeTaskLimitReached      = "employee.task_limit_reached"       // current, max
+)
+
+func init() {
+	i18n.Require(
+		msgTaskAssignRequiresPending, msgTaskAutoAssignRequiresPending, msgTaskSuggestRequiresPending,
+		msgTaskInvalidAssignMethod, msgTaskWIPLimitReached, msgEmployeeOffboarding, msgEmployeeTaskLimitReached,
+	)
+}
+
+var (
+	errTaskNotFound     = response.NewLocalizedError(response.ErrCodeTaskNotFound, string(response.ErrCodeTaskNotFound), nil)
+	errEmployeeNotFound = response.NewLocalizedError(response.ErrCodeEmployeeNotFound, string(response.ErrCodeEmployeeNotFound), nil)
+)
+
+// notFoundOr 仓储返回ErrNotFound时包装为指定错误码的AppError，其它错误附加操作说明
+// 包装后errors.Is(err, repository.ErrNotFound)仍然成立
+func notFoundOr(err error, code response.ErrorCode, message, operation string) error {
+	if errors.Is(err, repository.ErrNotFound) {
+		return response.NewErrorWithCause(code, message, err)
+	}
+	return fmt.Errorf("%s: %w", operation, err)
+}
+
+// localizedNotFoundOr 同notFoundOr，记录不存在时返回可本地化的notFound错误
+func localizedNotFoundOr(err error, notFound *response.AppError, operation string) error {
+	if errors.Is(err, repository.ErrNotFound) {
+		return notFound.WithCause(err)
+	}
+	return fmt.Errorf("%s: %w", operation, err)
+}
+
+// taskLookupError 获取任务失败时返回的错误，任务不存在时错误码为TASK_NOT_FOUND
+func taskLookupError(err error) error {
+	return localizedNotFoundOr(err, errTaskNotFound, "获取任务失败")
+}
+
+// employeeLookupError 获取员工失败时返回的错误，员工不存在时错误码为EMPLOYEE_NOT_FOUND
+func employeeLookupError(err error) error {
+	return localizedNotFoundOr(err, errEmployeeNotFound, "获取员工失败")
+}
+
+// taskNotPendingError 任务不是待分配状态时返回的错误，key说明被拒绝的操作
+func taskNotPendingError(key string, taskID uint, status string) error {
+	return response.NewLocalizedError(response.ErrCodeTaskStatusInvalid, key, i18n.Params{"task_id": taskID, "status": status})
+}
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/workflow"
+	"taskmanage/pkg/logger"
+)
+
+// 对外发布的业务事件类型
+const (
+	EventTaskCreated             = "task.created"
+	EventTaskAssigned            = "task.assigned"
+	EventTaskCompleted           = "task.completed"
+	EventApprovalCreated         = "workflow.approval.created"
+	EventApprovalResolved        = "workflow.approval.resolved"
+	EventOnboardingStatusChanged = "employee.onboarding.status_changed"
+)
+
+// EventTypes 全部可订阅的事件类型
+var EventTypes = []string{
+	EventTaskCreated,
+	EventTaskAssigned,
+	EventTaskCompleted,
+	EventApprovalCreated,
+	EventApprovalResolved,
+	EventOnboardingStatusChanged,
+}
+
+// EventPublisher 业务事件发布接口，业务服务只依赖该接口，不感知事件的投递方式
+type EventPublisher interface {
+	// Publish 发布事件，data序列化为事件的data字段
+	Publish(ctx context.Context, eventType string, data interface{}) error
+}
+
+// Event 事件信封，作为Webhook请求体发送
+type Event struct {
+	ID         string      `json:"id"`
+	Type       string      `json:"type"`
+	OccurredAt time.Time   `json:"occurred_at"`
+	Data       interface{} `json:"data"`
+}
+
+// TaskEvent 任务创建和完成事件数据
+type TaskEvent struct {
+	TaskID      uint       `json:"task_id"`
+	Title       string     `json:"title"`
+	Status      string     `json:"status"`
+	Priority    string     `json:"priority"`
+	ProjectID   *uint      `json:"project_id,omitempty"`
+	ParentID    *uint      `json:"parent_id,omitempty"`
+	CreatorID   uint       `json:"creator_id"`
+	AssigneeID  *uint      `json:"assignee_id,omitempty"` // 负责人用户ID
+	DueDate     *time.Time `json:"due_date,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// TaskAssignedEvent 任务分配事件数据，重新分配时PreviousAssigneeID为原负责人用户ID
+type TaskAssignedEvent struct {
+	TaskEvent
+	PreviousAssigneeID *uint  `json:"previous_assignee_id,omitempty"`
+	AssignedBy         uint   `json:"assigned_by,omitempty"`
+	Method             string `json:"method"` // manual, auto, approval, reassign
+}
+
+// 任务分配方式
+const (
+	TaskAssignMethodManual   = "manual"
+	TaskAssignMethodAuto     = "auto"
+	TaskAssignMethodApproval = "approval"
+	TaskAssignMethodReassign = "reassign"
+)
+
+// ApprovalEvent 审批待处理和审批结果事件数据
+type ApprovalEvent struct {
+	InstanceID   string                 `json:"instance_id"`
+	WorkflowID   string                 `json:"workflow_id"`
+	BusinessType string                 `json:"business_type"`
+	BusinessID   string                 `json:"business_id"`
+	NodeID       string                 `json:"node_id"`
+	NodeName     string                 `json:"node_name"`
+	StartedBy    uint                   `json:"started_by"`
+	Approvers    []uint                 `json:"approvers,omitempty"`  // 待审批人，仅workflow.approval.created
+	Outcome      string                 `json:"outcome,omitempty"`    // approved 或 rejected，仅workflow.approval.resolved
+	DecidedBy    uint                   `json:"decided_by,omitempty"` // 仅workflow.approval.resolved
+	Comment      string                 `json:"comment,omitempty"`
+	Completed    bool                   `json:"completed"`           // 流程是否已结束
+	Variables    map[string]interface{} `json:"variables,omitempty"` // 流程变量，敏感变量已脱敏；无法获取流程定义时不返回
+}
+
+// OnboardingStatusChangedEvent 入职状态变更事件数据
+type OnboardingStatusChangedEvent struct {
+	EmployeeID uint   `json:"employee_id"`
+	UserID     uint   `json:"user_id"`
+	EmployeeNo string `json:"employee_no"`
+	FromStatus string `json:"from_status"`
+	ToStatus   string `json:"to_status"`
+	OperatorID uint   `json:"operator_id"`
+	Reason     string `json:"reason,omitempty"`
+}
+
+// newTaskEvent 由任务生成事件数据
+func newTaskEvent(task *database.Task) TaskEvent {
+	return TaskEvent{
+		TaskID:      task.ID,
+		Title:       task.Title,
+		Status:      task.Status,
+		Priority:    task.Priority,
+		ProjectID:   task.ProjectID,
+		ParentID:    task.ParentID,
+		CreatorID:   task.CreatorID,
+		AssigneeID:  task.AssigneeID,
+		DueDate:     task.DueDate,
+		CompletedAt: task.CompletedAt,
+	}
+}
+
+// newTaskAssignedEvent 由任务生成分配事件数据，previousAssigneeID为重新分配前负责人的用户ID
+func newTaskAssignedEvent(task *database.Task, previousAssigneeID *uint, assignedBy uint, method string) *TaskAssignedEvent {
+	return &TaskAssignedEvent{
+		TaskEvent:          newTaskEvent(task),
+		PreviousAssigneeID: previousAssigneeID,
+		AssignedBy:         assignedBy,
+		Method:             method,
+	}
+}
+
+// publishEvent 发布事件，未配置发布器时忽略；发布失败只记录日志，不影响业务操作
+func publishEvent(ctx context.Context, events EventPublisher, eventType string, data interface{}) {
+	if events == nil {
+		return
+	}
+	if err := events.Publish(ctx, eventType, data); err != nil {
+		logger.Warnf("发布事件失败: type=%s, error=%v", eventType, err)
+	}
+}
+
+// approvalEventListener 将工作流引擎的审批提醒和审批结果转换为业务事件
+type approvalEventListener struct {
+	events      EventPublisher
+	definitions approvalDefinitionResolver
+}
+
+// approvalDefinitionResolver 获取实例绑定的流程定义，用于按变量声明脱敏事件中的流程变量
+type approvalDefinitionResolver interface {
+	GetWorkflowForInstance(ctx context.Context, instance *workflow.WorkflowInstance) (*workflow.WorkflowDefinition, error)
+}
+
+// newApprovalEventListener 创建审批事件监听器，definitions为nil时事件不携带流程变量
+func newApprovalEventListener(events EventPublisher, definitions approvalDefinitionResolver) *approvalEventListener {
+	return &approvalEventListener{events: events, definitions: definitions}
+}
+
+// OnApprovalsRequested 每个审批节点发布一条workflow.approval.created事件
+func (l *approvalEventListener) OnApprovalsRequested(ctx context.Context, instance *workflow.WorkflowInstance, approvals []*workflow.PendingApproval) {
+	byNode := make(map[string]*ApprovalEvent)
+	var order []string
+	for _, approval := range approvals {
+		event, ok := byNode[approval.NodeID]
+		if !ok {
+			event = l.newApprovalEvent(ctx, instance, approval.NodeID, approval.NodeName)
+			byNode[approval.NodeID] = event
+			order = append(order, approval.NodeID)
+		}
+		event.Approvers = append(event.Approvers, approval.AssignedTo)
+	}
+	for _, nodeID := range order {
+		publishEvent(ctx, l.events, EventApprovalCreated, byNode[nodeID])
+	}
+}
+
+// OnApprovalResolved 发布workflow.approval.resolved事件
+func (l *approvalEventListener) OnApprovalResolved(ctx context.Context, instance *workflow.WorkflowInstance, outcome *workflow.ApprovalOutcome) {
+	event := l.newApprovalEvent(ctx, instance, outcome.NodeID, outcome.NodeName)
+	event.Outcome = outcome.Outcome
+	event.DecidedBy = outcome.DecidedBy
+	event.Comment = outcome.Comment
+	event.Completed = outcome.Completed
+	publishEvent(ctx, l.events, EventApprovalResolved, event)
+}
+
+// newApprovalEvent 由流程实例生成审批事件数据，流程变量按流程定义的变量声明脱敏
+func (l *approvalEventListener) newApprovalEvent(ctx context.Context, instance *workflow.WorkflowInstance, nodeID, nodeName string) *ApprovalEvent {
+	event := &ApprovalEvent{
+		InstanceID:   instance.ID,
+		WorkflowID:   instance.WorkflowID,
+		BusinessType: instance.BusinessType,
+		BusinessID:   instance.BusinessID,
+		NodeID:       nodeID,
+		NodeName:     nodeName,
+		StartedBy:    instance.StartedBy,
+		Completed:    instance.Status != workflow.StatusRunning,
+	}
+	if l.definitions == nil {
+		return event
+	}
+	definition, err := l.definitions.GetWorkflowForInstance(ctx, instance)
+	if err != nil {
+		logger.Warnf("获取流程定义失败，审批事件不携带流程变量: instance=%s, error=%v", instance.ID, err)
+		return event
+	}
+	event.Variables = workflow.RedactVariables(instance.Variables, definition.VariableSchema)
+	return event
+}
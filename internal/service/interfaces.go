@@ -2,7 +2,10 @@ package service
 
 import (
 	"context"
+	"time"
 
+	"taskmanage/internal/assignment"
+	"taskmanage/internal/cache"
 	"taskmanage/internal/models"
 	"taskmanage/internal/repository"
 	"taskmanage/internal/workflow"
@@ -22,6 +25,7 @@ type UserService interface {
 	Login(ctx context.Context, req *LoginRequest) (*LoginResponse, error)
 	RefreshToken(ctx context.Context, refreshToken string) (*LoginResponse, error)
 	Logout(ctx context.Context, userID uint) error
+	RecordLogin(ctx context.Context, userID uint, ip string) error // 登录成功后更新最后登录时间和IP
 	ChangePassword(ctx context.Context, userID uint, req *ChangePasswordRequest) error
 	HasPermission(ctx context.Context, userID uint, resource, action string) (bool, error)
 
@@ -35,7 +39,7 @@ type UserService interface {
 type TaskService interface {
 	// 任务管理
 	CreateTask(ctx context.Context, req *CreateTaskRequest) (*TaskResponse, error)
-	GetTask(ctx context.Context, taskID uint) (*TaskResponse, error)
+	GetTask(ctx context.Context, taskID uint, expand TaskExpand) (*TaskResponse, error) // expand为空时不查询负责人、创建者和项目信息
 	UpdateTask(ctx context.Context, taskID uint, req *UpdateTaskRequest) (*TaskResponse, error)
 	DeleteTask(ctx context.Context, taskID uint) error
 	ListTasks(ctx context.Context, filter TaskListFilter) ([]*TaskResponse, int64, error)
@@ -48,8 +52,26 @@ type TaskService interface {
 
 	// 任务状态管理
 	StartTask(ctx context.Context, taskID uint, userID uint) error
-	CompleteTask(ctx context.Context, taskID uint, userID uint, req *CompleteTaskRequest) error
-	CancelTask(ctx context.Context, taskID uint, userID uint, reason string) error
+	// CompleteTask 完成任务，需要审核的任务进入in_review，返回更新后的任务
+	CompleteTask(ctx context.Context, taskID uint, userID uint, req *CompleteTaskRequest) (*TaskResponse, error)
+	ApproveTaskReview(ctx context.Context, taskID uint, req *ApproveTaskReviewRequest) (*TaskResponse, error)
+	RejectTaskReview(ctx context.Context, taskID uint, req *RejectTaskReviewRequest) (*TaskResponse, error)
+	CancelTask(ctx context.Context, taskID uint, userID uint, req *CancelTaskRequest) error
+
+	// 工时记录
+	AddTimeEntry(ctx context.Context, taskID uint, userID uint, req *CreateTimeEntryRequest) (*TimeEntryResponse, error)
+	ListTimeEntries(ctx context.Context, taskID uint) (*TaskTimeEntriesResponse, error)
+	DeleteTimeEntry(ctx context.Context, taskID uint, entryID uint, userID uint) error
+	GetUserTimeSummary(ctx context.Context, userID uint, week time.Time) (*UserTimeSummaryResponse, error)
+
+	// 任务关注者
+	AddTaskWatcher(ctx context.Context, taskID uint, req *TaskWatcherRequest) (*TaskWatcherResponse, error)
+	RemoveTaskWatcher(ctx context.Context, taskID uint, req *TaskWatcherRequest) error
+	ListTaskWatchers(ctx context.Context, taskID uint) (*TaskWatchersResponse, error)
+
+	// 任务评论
+	AddTaskComment(ctx context.Context, taskID uint, userID uint, req *CreateTaskCommentRequest) (*TaskCommentResponse, error)
+	ListTaskComments(ctx context.Context, taskID uint) ([]*TaskCommentResponse, error)
 
 	// 智能分配
 	AutoAssignTask(ctx context.Context, taskID uint, strategy AssignmentStrategy) (*AssignmentResponse, error)
@@ -57,6 +79,8 @@ type TaskService interface {
 
 	// 工作流集成
 	CompleteTaskAssignmentWorkflow(ctx context.Context, workflowInstanceID string, approved bool, approverID uint) error
+	// RegisterCompletionHandlers 登记任务分配流程结束后的业务回调
+	RegisterCompletionHandlers(registry *workflow.CompletionHandlerRegistry)
 }
 
 // EmployeeService 员工服务接口
@@ -68,9 +92,11 @@ type EmployeeService interface {
 	DeleteEmployee(ctx context.Context, employeeID uint) error
 	ListEmployees(ctx context.Context, filter EmployeeListFilter) ([]*EmployeeResponse, int64, error)
 	GetAvailableEmployees(ctx context.Context) ([]*EmployeeResponse, error)
-	GetEmployeeWorkload(ctx context.Context, employeeID uint) (*WorkloadResponse, error)
+	GetEmployeeWorkload(ctx context.Context, employeeID uint, includeComputed bool) (*WorkloadResponse, error)
 	AddSkill(ctx context.Context, employeeID uint, req *SkillRequest) error
 	RemoveSkill(ctx context.Context, employeeID uint, req *RemoveSkillRequest) error
+	// GetOrgChart 获取员工的上级链和depth层以内的下属树，depth小于1时使用默认层级
+	GetOrgChart(ctx context.Context, employeeID uint, depth int) (*OrgChartResponse, error)
 
 	// 员工状态管理
 	UpdateEmployeeStatus(ctx context.Context, employeeID uint, status string) error
@@ -79,6 +105,8 @@ type EmployeeService interface {
 	// 工作负载统计
 	GetWorkloadStats(ctx context.Context, req *WorkloadStatsRequest) ([]*WorkloadResponse, error)
 	GetDepartmentWorkload(ctx context.Context, departmentID uint) (*DepartmentWorkloadResponse, error)
+	// ReconcileWorkload 按任务表校正全部员工的当前任务数
+	ReconcileWorkload(ctx context.Context) (*WorkloadReconcileReport, error)
 }
 
 // NotificationService 通知服务接口
@@ -87,20 +115,34 @@ type NotificationService interface {
 	CreateTaskAssignmentNotification(ctx context.Context, taskID, recipientID, senderID uint) error
 	// 创建任务状态变更通知
 	CreateTaskStatusNotification(ctx context.Context, taskID, recipientID uint, notificationType models.TaskNotificationType, title, content string) error
+	// 创建系统通知
+	CreateSystemNotification(ctx context.Context, recipientID uint, title, content string) error
+	// 创建属于某通知类别的系统通知，遵循用户的站内通知偏好
+	CreateCategorizedNotification(ctx context.Context, recipientID uint, category, title, content string) error
 	// 获取用户通知
 	GetUserNotifications(ctx context.Context, userID uint, status string, page, pageSize int) ([]models.TaskNotification, int64, error)
 	// 获取通知列表 (为Handler提供)
-	ListNotifications(ctx context.Context, userID uint, unreadOnly bool) ([]*NotificationResponse, int64, error)
+	ListNotifications(ctx context.Context, userID uint, req *NotificationListRequest) ([]*NotificationResponse, int64, error)
 	// 标记通知为已读
 	MarkAsRead(ctx context.Context, notificationID, userID uint) error
 	// 批量标记为已读
 	MarkAllAsRead(ctx context.Context, userID uint) error
-	// 获取未读数量
-	GetUnreadCount(ctx context.Context, userID uint) (int64, error)
+	// 按ID列表或过滤条件批量标记为已读，返回本次标记的数量
+	BulkMarkAsRead(ctx context.Context, userID uint, req *BulkReadNotificationsRequest) (int64, error)
+	// 获取未读数量，notificationType不为空时只统计该类型
+	GetUnreadCount(ctx context.Context, userID uint, notificationType string) (int64, error)
+	// 软删除超过保留期的已读通知，返回删除的数量
+	CleanupReadNotifications(ctx context.Context, now time.Time) (int64, error)
 	// 接受任务通知
 	AcceptTaskNotification(ctx context.Context, notificationID, taskID, userID uint, reason *string) error
 	// 拒绝任务通知
 	RejectTaskNotification(ctx context.Context, notificationID, taskID, userID uint, reason *string) error
+	// 获取通知偏好
+	GetPreferences(ctx context.Context, userID uint) ([]*NotificationPreferenceResponse, error)
+	// 更新通知偏好
+	UpdatePreferences(ctx context.Context, userID uint, req *UpdateNotificationPreferencesRequest) ([]*NotificationPreferenceResponse, error)
+	// 判断用户是否接收某类别在指定渠道上的通知
+	IsChannelEnabled(ctx context.Context, userID uint, category, channel string) (bool, error)
 }
 
 // AssignmentService 任务分配服务接口
@@ -115,7 +157,7 @@ type AssignmentService interface {
 	GetAssignmentSuggestions(ctx context.Context, req *AssignmentSuggestionRequest) ([]*AssignmentSuggestion, error)
 
 	// 分配历史
-	GetAssignmentHistory(ctx context.Context, taskID uint) ([]*AssignmentHistory, error)
+	GetAssignmentHistory(ctx context.Context, taskID uint, page, pageSize int) ([]*AssignmentHistory, int64, error)
 
 	// 分配管理
 	ReassignTask(ctx context.Context, taskID uint, newEmployeeID uint, reason string, assignedBy uint) error
@@ -123,6 +165,10 @@ type AssignmentService interface {
 
 	// 冲突检查
 	CheckAssignmentConflicts(ctx context.Context, taskID uint, employeeID uint) ([]*AssignmentConflict, error)
+
+	// 分配统计
+	GetAssignmentStats(ctx context.Context, req *AssignmentStatsRequest) (*AssignmentStatsResponse, error)
+	ExportAssignmentStats(ctx context.Context, req *AssignmentStatsRequest) (*ReportExport, error)
 }
 
 // SkillService 技能服务接口
@@ -134,30 +180,45 @@ type SkillService interface {
 	GetSkill(ctx context.Context, id uint) (*SkillResponse, error)
 	ListSkills(ctx context.Context, req *ListSkillsRequest) (*ListSkillsResponse, error)
 	GetSkillsByCategory(ctx context.Context, category string) ([]*SkillResponse, error)
-	GetAllCategories(ctx context.Context) ([]string, error)
 	AssignSkillToEmployee(ctx context.Context, employeeID, skillID uint, level int) error
 	RemoveSkillFromEmployee(ctx context.Context, employeeID, skillID uint) error
-	GetEmployeeSkills(ctx context.Context, employeeID uint) ([]*SkillResponse, error)
+	GetEmployeeSkills(ctx context.Context, employeeID uint, excludeExpired bool) ([]*SkillResponse, error)
+
+	// 技能分类
+	GetSkillCategories(ctx context.Context) ([]*SkillCategoryResponse, error)
+	RenameSkillCategory(ctx context.Context, id uint, req *UpdateSkillCategoryRequest) (*SkillCategoryResponse, error)
+	MergeSkillCategories(ctx context.Context, sourceID uint, req *MergeSkillCategoryRequest) (*MergeSkillCategoryResponse, error)
+
+	// 技能认证
+	EndorseEmployeeSkill(ctx context.Context, employeeID, skillID uint, req *EndorseSkillRequest) (*SkillResponse, error)
+	NotifyExpiringSkills(ctx context.Context, now time.Time) (int, error)
 }
 
 // DepartmentService 部门服务接口
 type DepartmentService interface {
 	CreateDepartment(ctx context.Context, req *CreateDepartmentRequest) (*DepartmentResponse, error)
 	UpdateDepartment(ctx context.Context, id uint, req *UpdateDepartmentRequest) (*DepartmentResponse, error)
-	DeleteDepartment(ctx context.Context, id uint) error
+	DeleteDepartment(ctx context.Context, id uint, reassignTo *uint) error
 	GetDepartment(ctx context.Context, id uint) (*DepartmentResponse, error)
 	ListDepartments(ctx context.Context, req *ListRequest) (*ListResponse[*DepartmentResponse], error)
 	GetDepartmentTree(ctx context.Context) ([]*DepartmentResponse, error)
 	GetRootDepartments(ctx context.Context) ([]*DepartmentResponse, error)
 	GetSubDepartments(ctx context.Context, departmentID uint) ([]*DepartmentResponse, error)
-	UpdateManager(ctx context.Context, departmentID, managerID uint) error
+	// UpdateManager 校验并更新部门管理者，可同时为没有直接上级的部门员工设置直接上级
+	UpdateManager(ctx context.Context, departmentID uint, req *UpdateDepartmentManagerRequest) (*DepartmentManagerResult, error)
+	// MergeDepartment 将部门合并到目标部门并停用源部门，返回迁移摘要
+	MergeDepartment(ctx context.Context, sourceID uint, req *MergeDepartmentRequest) (*DepartmentMergeResult, error)
+	// SetAssignmentStrategy 设置部门默认分配策略，策略为空时恢复系统默认策略
+	SetAssignmentStrategy(ctx context.Context, id uint, req *UpdateDepartmentAssignmentStrategyRequest) (*DepartmentResponse, error)
+	// SetOnboardingWorkflowType 设置部门入职审批流程类型，类型为空时恢复系统默认流程
+	SetOnboardingWorkflowType(ctx context.Context, id uint, req *UpdateDepartmentOnboardingWorkflowRequest) (*DepartmentResponse, error)
 }
 
 // PositionService 职位服务接口
 type PositionService interface {
 	CreatePosition(ctx context.Context, req *CreatePositionRequest) (*PositionResponse, error)
 	UpdatePosition(ctx context.Context, id uint, req *UpdatePositionRequest) (*PositionResponse, error)
-	DeletePosition(ctx context.Context, id uint) error
+	DeletePosition(ctx context.Context, id uint, replacementID *uint) error
 	GetPosition(ctx context.Context, id uint) (*PositionResponse, error)
 	ListPositions(ctx context.Context, req *ListRequest) (*ListResponse[*PositionResponse], error)
 	GetPositionsByCategory(ctx context.Context, category string) ([]*PositionResponse, error)
@@ -179,6 +240,14 @@ type ProjectService interface {
 	RemoveProjectMember(ctx context.Context, projectID uint, req *RemoveProjectMemberRequest) error
 	GetProjectMembers(ctx context.Context, projectID uint) ([]*EmployeeResponse, error)
 	UpdateProjectManager(ctx context.Context, projectID, managerID uint) error
+	GetProjectTaskBoard(ctx context.Context, projectID uint, filter TaskListFilter) (*ProjectTaskBoardResponse, error)
+	UpdateWIPLimits(ctx context.Context, projectID uint, userID uint, req *UpdateWIPLimitsRequest) (map[string]int, error)
+	GetProjectTimeline(ctx context.Context, projectID uint, from, to string) (*ProjectTimelineResponse, error)
+	GetProjectBurndown(ctx context.Context, projectID uint, from, to, tz string) (*ProjectBurndownResponse, error)
+	ListMilestones(ctx context.Context, projectID uint) ([]*MilestoneResponse, error)
+	CreateMilestone(ctx context.Context, projectID uint, req *MilestoneRequest) (*MilestoneResponse, error)
+	UpdateMilestone(ctx context.Context, projectID, milestoneID uint, req *MilestoneRequest) (*MilestoneResponse, error)
+	DeleteMilestone(ctx context.Context, projectID, milestoneID uint) error
 }
 
 // WorkflowService 工作流服务接口
@@ -189,7 +258,10 @@ type WorkflowService interface {
 	GetWorkflowDefinition(ctx context.Context, id string) (*workflow.WorkflowDefinition, error)
 	UpdateWorkflowDefinition(ctx context.Context, id string, req *workflow.UpdateWorkflowRequest) (*workflow.WorkflowDefinition, error)
 	DeleteWorkflowDefinition(ctx context.Context, id string) error
-	ValidateWorkflowDefinition(ctx context.Context, req *workflow.CreateWorkflowRequest) error
+	ValidateWorkflowDefinition(ctx context.Context, req *workflow.CreateWorkflowRequest) ([]workflow.Diagnostic, error)
+	ListWorkflowDefinitionVersions(ctx context.Context, id string) ([]*workflow.WorkflowDefinition, error)
+	ActivateWorkflowDefinitionVersion(ctx context.Context, id string, versionID uint) error
+	DeactivateWorkflowDefinitionVersion(ctx context.Context, id string, versionID uint) error
 
 	// 启动任务分配审批流程
 	StartTaskAssignmentApproval(ctx context.Context, req *workflow.TaskAssignmentApprovalRequest) (*workflow.WorkflowInstance, error)
@@ -197,33 +269,78 @@ type WorkflowService interface {
 	// 处理任务分配审批
 	ProcessTaskAssignmentApproval(ctx context.Context, req *workflow.ApprovalRequest) (*workflow.ApprovalResult, error)
 
+	// 批量处理审批，逐条返回处理结果
+	ProcessApprovalsBatch(ctx context.Context, req *BatchApprovalRequest) (*BatchApprovalResponse, error)
+
 	// 启动入职审批流程
 	StartOnboardingApproval(ctx context.Context, req *workflow.OnboardingApprovalRequest) (*workflow.WorkflowInstance, error)
 
 	// 处理入职审批
 	ProcessOnboardingApproval(ctx context.Context, req *workflow.ApprovalRequest) (*workflow.ApprovalResult, error)
 
+	// 启动离职审批流程
+	StartOffboardingApproval(ctx context.Context, req *workflow.OffboardingApprovalRequest) (*workflow.WorkflowInstance, error)
+
+	// 处理离职审批
+	ProcessOffboardingApproval(ctx context.Context, req *workflow.ApprovalRequest) (*workflow.ApprovalResult, error)
+
 	// 获取流程实例
 	GetWorkflowInstance(ctx context.Context, instanceID string) (*workflow.WorkflowInstance, error)
 
-	// 获取待审批任务分配
-	GetPendingTaskAssignmentApprovals(ctx context.Context, userID uint) ([]*workflow.PendingApproval, error)
+	// GetWorkflowInstanceForViewer 获取流程实例，查看者无workflow:view_sensitive权限且不是当前节点审批人时敏感变量被脱敏
+	GetWorkflowInstanceForViewer(ctx context.Context, instanceID string, viewer workflow.VariableViewer) (*workflow.WorkflowInstance, error)
+
+	// 按状态等条件分页获取流程实例摘要，返回当前页和总数
+	ListWorkflowInstances(ctx context.Context, filter workflow.InstanceFilter) ([]*workflow.InstanceSummary, int64, error)
+
+	// 按条件统计流程实例，按状态和流程分组
+	GetWorkflowInstanceStats(ctx context.Context, filter workflow.InstanceFilter) (*workflow.InstanceStats, error)
 
-	// 获取待审批任务
-	GetPendingApprovals(ctx context.Context, userID uint) ([]*workflow.PendingApproval, error)
+	// 将超过SLA或节点超时的运行中实例标记为过期
+	ExpireOverdueInstances(ctx context.Context, now time.Time) (*workflow.ExpiryReport, error)
+
+	// 获取待审批任务分配，返回当前页和总数
+	GetPendingTaskAssignmentApprovals(ctx context.Context, filter workflow.PendingApprovalFilter) ([]*workflow.PendingApproval, int64, error)
+
+	// 获取待审批任务，返回当前页和总数
+	GetPendingApprovals(ctx context.Context, filter workflow.PendingApprovalFilter) ([]*workflow.PendingApproval, int64, error)
+
+	// 统计待审批任务数
+	CountPendingApprovals(ctx context.Context, filter workflow.PendingApprovalFilter) (int64, error)
+
+	// 汇总待审批总数、逾期数和各业务类型的数量
+	SummarizePendingApprovals(ctx context.Context, filter workflow.PendingApprovalFilter) (*workflow.PendingApprovalSummary, error)
+
+	// 恢复进程中断时未完成的节点执行
+	RecoverInstances(ctx context.Context) (*workflow.RecoveryReport, error)
+
+	// 流程引擎自检，供就绪检查使用
+	SelfCheck(ctx context.Context) (*workflow.SelfCheckReport, error)
+
+	// 重新执行失败的流程结束业务回调
+	RetryCompletion(ctx context.Context, instanceID string) (*workflow.CompletionRecord, error)
 
 	// 取消流程
 	CancelWorkflow(ctx context.Context, instanceID string, reason string) error
 
 	// 获取流程历史
 	GetWorkflowHistory(ctx context.Context, instanceID string) ([]workflow.ExecutionHistory, error)
+
+	// 分页获取流程历史，补充节点名称、执行人姓名头像、步骤间隔及委托和超时标记
+	ListWorkflowHistory(ctx context.Context, instanceID string, page, pageSize int) (*ListResponse[*WorkflowHistoryEntry], error)
+
+	// 校验流程执行历史的哈希链，报告第一处断链
+	VerifyWorkflowHistory(ctx context.Context, instanceID string) (*workflow.HistoryVerification, error)
 }
 
 // ServiceManager 服务管理器接口
 type ServiceManager interface {
 	UserService() UserService
 	TaskService() TaskService
+	TaskTemplateService() TaskTemplateService
+	TaskLabelService() TaskLabelService
 	EmployeeService() EmployeeService
+	EmployeeCapacityService() EmployeeCapacityService
 	SkillService() SkillService
 	NotificationService() NotificationService
 	WorkflowService() WorkflowService
@@ -231,6 +348,27 @@ type ServiceManager interface {
 	PositionService() PositionService
 	ProjectService() ProjectService
 	OnboardingService() OnboardingService
+	SSOService() SSOService
+	OffboardingService() OffboardingService
 	PermissionAssignmentService() PermissionAssignmentService
+	PermissionService() PermissionService
+	RoleService() RoleService
+	ActivationService() ActivationService
+	ApprovalChainService() ApprovalChainService
+	ReportService() ReportService
+	EmailNotifier() EmailNotifier
+	WebhookService() WebhookService
+	ArchiveService() ArchiveService
+	TaskEscalationService() TaskEscalationService
+	TaskDueReminderService() TaskDueReminderService
+	DashboardService() DashboardService
+	ProfileService() ProfileService
+	WorkCalendarService() WorkCalendarService
+	// SetCompletionHandlers 设置流程结束业务回调注册表，需在首次获取WorkflowService之前调用
+	SetCompletionHandlers(registry *workflow.CompletionHandlerRegistry)
+	// SetAssignmentStrategies 设置分配策略注册表，需在首次获取TaskService之前调用
+	SetAssignmentStrategies(registry *assignment.StrategyRegistry)
+	// SetPermissionCache 启用权限缓存，需在首次获取PermissionService之前调用
+	SetPermissionCache(permissionCache *cache.PermissionCache)
 	HealthCheck(ctx context.Context) error
 }
@@ -6,6 +6,7 @@ import (
 
 	"github.com/sirupsen/logrus"
 	"taskmanage/internal/assignment"
+	"taskmanage/internal/cache"
 	"taskmanage/internal/config"
 	"taskmanage/internal/repository"
 	"taskmanage/internal/workflow"
@@ -28,7 +29,28 @@ type serviceManager struct {
 	positionService     PositionService
 	projectService      ProjectService
 	onboardingService   OnboardingService
+	ssoService          SSOService
+	offboardingService  OffboardingService
 	permissionAssignmentService PermissionAssignmentService
+	permissionService           PermissionService
+	roleService                 RoleService
+	permissionCache             *cache.PermissionCache
+	activationService           ActivationService
+	approvalChainService        ApprovalChainService
+	reportService               ReportService
+	emailNotifier               EmailNotifier
+	taskTemplateService         TaskTemplateService
+	taskLabelService            TaskLabelService
+	employeeCapacityService     EmployeeCapacityService
+	archiveService              ArchiveService
+	taskEscalationService       TaskEscalationService
+	taskDueReminderService      TaskDueReminderService
+	dashboardService            DashboardService
+	profileService              ProfileService
+	webhookService              WebhookService
+	workCalendarService         WorkCalendarService
+	completionHandlers          *workflow.CompletionHandlerRegistry
+	assignmentStrategies        *assignment.StrategyRegistry
 }
 
 // NewServiceManager 创建服务管理器
@@ -51,7 +73,7 @@ func (sm *serviceManager) UserService() UserService {
 // TaskService 获取任务服务
 func (sm *serviceManager) TaskService() TaskService {
 	if sm.taskService == nil {
-		assignmentService := assignment.NewAssignmentService(sm.repoManager)
+		assignmentService := assignment.NewAssignmentService(sm.repoManager, sm.strategies())
 		workflowService := sm.WorkflowService()
 		sm.taskService = NewTaskService(
 			sm.repoManager.TaskRepository(),
@@ -60,27 +82,56 @@ func (sm *serviceManager) TaskService() TaskService {
 			sm.repoManager.AssignmentRepository(),
 			assignmentService,
 			workflowService,
+			sm.repoManager.TimeEntryRepository(),
+			sm.repoManager.ProjectRepository(),
+			sm.repoManager.SkillRepository(),
+			sm.repoManager.TaskWatcherRepository(),
+			sm.repoManager.TaskCommentRepository(),
+			sm.NotificationService(),
+			sm.WebhookService(),
+			sm.config.Task.AutoCreateSkills,
+			sm.config.Task.AssignmentApproval == config.AssignmentApprovalSimple,
 		)
-		// 解决循环依赖：将TaskService注入到WorkflowService中
-		if sm.workflowService != nil {
-			sm.workflowService.SetTaskService(sm.taskService)
-		}
 	}
 	return sm.taskService
 }
 
+// TaskTemplateService 获取任务模板服务
+func (sm *serviceManager) TaskTemplateService() TaskTemplateService {
+	if sm.taskTemplateService == nil {
+		sm.taskTemplateService = NewTaskTemplateService(sm.repoManager, sm.TaskService(), sm.config.Task.AutoCreateSkills)
+	}
+	return sm.taskTemplateService
+}
+
+// TaskLabelService 获取任务标签服务
+func (sm *serviceManager) TaskLabelService() TaskLabelService {
+	if sm.taskLabelService == nil {
+		sm.taskLabelService = NewTaskLabelService(sm.repoManager)
+	}
+	return sm.taskLabelService
+}
+
 // EmployeeService 获取员工服务
 func (sm *serviceManager) EmployeeService() EmployeeService {
 	if sm.employeeService == nil {
-		sm.employeeService = NewEmployeeService(sm.repoManager.EmployeeRepository(), sm.repoManager.SkillRepository(), sm.repoManager.UserRepository())
+		sm.employeeService = NewEmployeeService(sm.repoManager.EmployeeRepository(), sm.repoManager.SkillRepository(), sm.repoManager.UserRepository(), sm.repoManager.ProjectRepository(), sm.repoManager.EmployeeCapacityRepository(), sm.repoManager.PositionRepository(), sm.repoManager.EmployeeChangeHistoryRepository(), sm.PermissionAssignmentService(), sm.employeeNumberGenerator(), sm.config.Workload)
 	}
 	return sm.employeeService
 }
 
+// EmployeeCapacityService 获取员工容量日历服务
+func (sm *serviceManager) EmployeeCapacityService() EmployeeCapacityService {
+	if sm.employeeCapacityService == nil {
+		sm.employeeCapacityService = NewEmployeeCapacityService(sm.repoManager)
+	}
+	return sm.employeeCapacityService
+}
+
 // SkillService 获取技能服务
 func (sm *serviceManager) SkillService() SkillService {
 	if sm.skillService == nil {
-		sm.skillService = NewSkillService(sm.repoManager.SkillRepository(), sm.repoManager.EmployeeRepository())
+		sm.skillService = NewSkillService(sm.repoManager.SkillRepository(), sm.repoManager.SkillCategoryRepository(), sm.repoManager.EmployeeRepository(), sm.NotificationService(), sm.config.Skill.AutoCreateCategories)
 	}
 	return sm.skillService
 }
@@ -88,7 +139,7 @@ func (sm *serviceManager) SkillService() SkillService {
 // NotificationService 获取通知服务
 func (sm *serviceManager) NotificationService() NotificationService {
 	if sm.notificationService == nil {
-		sm.notificationService = NewNotificationService(sm.repoManager)
+		sm.notificationService = NewNotificationService(sm.repoManager, sm.config.Notification, sm.EmailNotifier())
 	}
 	return sm.notificationService
 }
@@ -102,20 +153,44 @@ func (sm *serviceManager) WorkflowService() WorkflowService {
 		
 		// 创建workflow definition manager
 		definitionManager := workflow.NewWorkflowDefinitionManager(workflowRepoAdapter)
+		definitionManager.SetUserRepository(sm.repoManager.UserRepository())
 		
 		// 创建workflow engine
 		engine := workflow.NewWorkflowEngine(definitionManager, workflowInstanceRepoAdapter, sm.repoManager.EmployeeRepository(), sm.repoManager.UserRepository())
+		engine.SetCompletionHandlers(sm.completionHandlers)
+		engine.SetApprovalChainProvider(sm.ApprovalChainService())
+		engine.SetDepartmentRepository(sm.repoManager.DepartmentRepository())
+		engine.SetWorkCalendar(sm.WorkCalendarService())
+		// 审批提醒和审批结果同时通知站内用户和发布集成事件
+		events := newApprovalEventListener(sm.WebhookService(), definitionManager)
+		requestListeners := workflow.ApprovalRequestListeners{events}
+		if listener, ok := sm.NotificationService().(workflow.ApprovalRequestListener); ok {
+			requestListeners = append(workflow.ApprovalRequestListeners{listener}, requestListeners...)
+		}
+		engine.SetApprovalRequestListener(requestListeners)
+		if listener, ok := sm.NotificationService().(workflow.ApprovalReturnListener); ok {
+			engine.SetApprovalReturnListener(listener)
+		}
+		if listener, ok := sm.NotificationService().(workflow.ApprovalMentionListener); ok {
+			engine.SetApprovalMentionListener(listener)
+		}
+		outcomeListeners := workflow.ApprovalOutcomeListeners{events}
+		if listener, ok := sm.NotificationService().(workflow.ApprovalOutcomeListener); ok {
+			outcomeListeners = append(workflow.ApprovalOutcomeListeners{listener}, outcomeListeners...)
+		}
+		engine.SetApprovalOutcomeListener(outcomeListeners)
+		engine.SetApprovalAttachmentResolver(NewApprovalAttachmentResolver(sm.repoManager.TaskAttachmentRepository()))
 		
 		// 创建workflow service
 		sm.workflowService = workflow.NewWorkflowService(engine, definitionManager)
 	}
-	return NewWorkflowServiceWrapper(sm.workflowService)
+	return NewWorkflowServiceWrapper(sm.workflowService, sm.NotificationService(), sm.repoManager.UserRepository())
 }
 
 // DepartmentService 获取部门服务
 func (sm *serviceManager) DepartmentService() DepartmentService {
 	if sm.departmentService == nil {
-		sm.departmentService = NewDepartmentService(sm.repoManager, sm.logger)
+		sm.departmentService = NewDepartmentService(sm.repoManager, sm.NotificationService(), sm.strategies(), sm.config.Department, sm.logger)
 	}
 	return sm.departmentService
 }
@@ -139,19 +214,174 @@ func (sm *serviceManager) ProjectService() ProjectService {
 // OnboardingService 获取入职工作流服务
 func (sm *serviceManager) OnboardingService() OnboardingService {
 	if sm.onboardingService == nil {
-		sm.onboardingService = NewOnboardingService(sm.repoManager, sm.WorkflowService(), sm.PermissionAssignmentService(), sm.logger)
+		sm.onboardingService = NewOnboardingService(sm.repoManager, sm.WorkflowService(), sm.PermissionAssignmentService(), sm.ActivationService(), sm.NotificationService(), sm.WebhookService(), sm.employeeNumberGenerator(), sm.config.Onboarding.ProbationReviewerRole, sm.config.Onboarding.DefaultWorkflowType, sm.WorkCalendarService(), sm.logger)
 	}
 	return sm.onboardingService
 }
 
+// employeeNumberGenerator 按配置创建员工编号生成器
+func (sm *serviceManager) employeeNumberGenerator() EmployeeNumberGenerator {
+	return NewEmployeeNumberGenerator(sm.repoManager, sm.config.EmployeeNumber)
+}
+
+// SSOService 获取单点登录服务
+func (sm *serviceManager) SSOService() SSOService {
+	if sm.ssoService == nil {
+		sm.ssoService = NewSSOService(sm.repoManager.UserRepository(), sm.OnboardingService(), sm.config.OIDC)
+	}
+	return sm.ssoService
+}
+
+// OffboardingService 获取离职工作流服务
+func (sm *serviceManager) OffboardingService() OffboardingService {
+	if sm.offboardingService == nil {
+		sm.offboardingService = NewOffboardingService(sm.repoManager, sm.WorkflowService(), sm.PermissionAssignmentService(), sm.logger)
+	}
+	return sm.offboardingService
+}
+
 // PermissionAssignmentService 获取权限分配服务
 func (sm *serviceManager) PermissionAssignmentService() PermissionAssignmentService {
 	if sm.permissionAssignmentService == nil {
-		sm.permissionAssignmentService = NewPermissionAssignmentService(sm.repoManager)
+		sm.permissionAssignmentService = NewPermissionAssignmentService(sm.repoManager, sm.PermissionService())
 	}
 	return sm.permissionAssignmentService
 }
 
+// PermissionService 获取权限服务
+func (sm *serviceManager) PermissionService() PermissionService {
+	if sm.permissionService == nil {
+		sm.permissionService = NewPermissionService(sm.repoManager, sm.permissionCache)
+	}
+	return sm.permissionService
+}
+
+// RoleService 获取角色与权限管理服务
+func (sm *serviceManager) RoleService() RoleService {
+	if sm.roleService == nil {
+		sm.roleService = NewRoleService(sm.repoManager, sm.PermissionService())
+	}
+	return sm.roleService
+}
+
+// ActivationService 获取账号激活服务
+func (sm *serviceManager) ActivationService() ActivationService {
+	if sm.activationService == nil {
+		sm.activationService = NewActivationService(sm.repoManager, NewEmailSender(sm.config.Email), sm.config)
+	}
+	return sm.activationService
+}
+
+// ApprovalChainService 获取部门审批链服务
+func (sm *serviceManager) ApprovalChainService() ApprovalChainService {
+	if sm.approvalChainService == nil {
+		sm.approvalChainService = NewApprovalChainService(sm.repoManager)
+	}
+	return sm.approvalChainService
+}
+
+// ReportService 获取报表导出服务
+func (sm *serviceManager) ReportService() ReportService {
+	if sm.reportService == nil {
+		sm.reportService = NewReportService(sm.repoManager, sm.config)
+	}
+	return sm.reportService
+}
+
+// ArchiveService 获取归档服务
+func (sm *serviceManager) ArchiveService() ArchiveService {
+	if sm.archiveService == nil {
+		sm.archiveService = NewArchiveService(sm.repoManager, sm.config.Archive)
+	}
+	return sm.archiveService
+}
+
+// TaskEscalationService 获取任务优先级老化服务
+func (sm *serviceManager) TaskEscalationService() TaskEscalationService {
+	if sm.taskEscalationService == nil {
+		sm.taskEscalationService = NewTaskEscalationService(sm.repoManager, sm.NotificationService())
+	}
+	return sm.taskEscalationService
+}
+
+// TaskDueReminderService 获取任务截止提醒服务
+func (sm *serviceManager) TaskDueReminderService() TaskDueReminderService {
+	if sm.taskDueReminderService == nil {
+		sm.taskDueReminderService = NewTaskDueReminderService(sm.repoManager, sm.NotificationService(), sm.WorkCalendarService())
+	}
+	return sm.taskDueReminderService
+}
+
+// WorkCalendarService 获取工作日历服务
+func (sm *serviceManager) WorkCalendarService() WorkCalendarService {
+	if sm.workCalendarService == nil {
+		sm.workCalendarService = NewWorkCalendarService(sm.repoManager, sm.config.WorkCalendar)
+	}
+	return sm.workCalendarService
+}
+
+// DashboardService 获取当前用户工作台服务
+func (sm *serviceManager) DashboardService() DashboardService {
+	if sm.dashboardService == nil {
+		sm.dashboardService = NewDashboardService(sm.repoManager, DefaultDashboardSectionTimeout)
+	}
+	return sm.dashboardService
+}
+
+// ProfileService 获取当前用户个人资料服务
+func (sm *serviceManager) ProfileService() ProfileService {
+	if sm.profileService == nil {
+		sm.profileService = NewProfileService(sm.repoManager, sm.NotificationService())
+	}
+	return sm.profileService
+}
+
+// WebhookService 获取集成Webhook服务
+func (sm *serviceManager) WebhookService() WebhookService {
+	if sm.webhookService == nil {
+		sm.webhookService = NewWebhookService(sm.repoManager, NewWebhookSender(sm.config.Webhook), sm.config.Webhook)
+	}
+	return sm.webhookService
+}
+
+// EmailNotifier 获取邮件通知服务
+func (sm *serviceManager) EmailNotifier() EmailNotifier {
+	if sm.emailNotifier == nil {
+		sm.emailNotifier = NewEmailNotifier(sm.repoManager, NewEmailSender(sm.config.Email), sm.config.Email)
+	}
+	return sm.emailNotifier
+}
+
+// SetCompletionHandlers 设置流程结束业务回调注册表，由各业务服务登记回调
+// 需在首次获取WorkflowService之前调用
+func (sm *serviceManager) SetCompletionHandlers(registry *workflow.CompletionHandlerRegistry) {
+	sm.completionHandlers = registry
+	sm.TaskService().RegisterCompletionHandlers(registry)
+	sm.OnboardingService().RegisterCompletionHandlers(registry)
+}
+
+// SetAssignmentStrategies 设置分配策略注册表，需在首次获取TaskService和DepartmentService之前调用
+func (sm *serviceManager) SetAssignmentStrategies(registry *assignment.StrategyRegistry) {
+	sm.assignmentStrategies = registry
+	sm.taskService = nil
+	sm.departmentService = nil
+}
+
+// strategies 获取分配策略注册表，未设置时使用内置策略
+func (sm *serviceManager) strategies() *assignment.StrategyRegistry {
+	if sm.assignmentStrategies == nil {
+		sm.assignmentStrategies = assignment.NewDefaultStrategyRegistry()
+	}
+	return sm.assignmentStrategies
+}
+
+// SetPermissionCache 设置权限缓存，需在首次获取PermissionService之前调用
+func (sm *serviceManager) SetPermissionCache(permissionCache *cache.PermissionCache) {
+	sm.permissionCache = permissionCache
+	sm.permissionService = nil
+	sm.permissionAssignmentService = nil
+}
+
 // HealthCheck 健康检查
 func (sm *serviceManager) HealthCheck(ctx context.Context) error {
 	// 检查Repository管理器
@@ -0,0 +1,245 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/models"
+	"taskmanage/internal/workflow"
+	"taskmanage/pkg/logger"
+)
+
+// enqueueEmail 用户开启该类别的邮件通知时写入发件箱，失败只记录日志，不影响站内通知
+func (s *NotificationServiceImpl) enqueueEmail(ctx context.Context, recipientID uint, category string, buildData func() *EmailTemplateData) {
+	if s.emailNotifier == nil || !hasEmailTemplate(category) {
+		return
+	}
+	enabled, err := s.IsChannelEnabled(ctx, recipientID, category, NotificationChannelEmail)
+	if err != nil {
+		logger.Warnf("读取邮件通知偏好失败，跳过邮件: user_id=%d, category=%s, error=%v", recipientID, category, err)
+		return
+	}
+	if !enabled {
+		return
+	}
+	if err := s.emailNotifier.Enqueue(ctx, recipientID, category, buildData()); err != nil {
+		logger.Warnf("写入邮件通知失败: user_id=%d, category=%s, error=%v", recipientID, category, err)
+	}
+}
+
+// taskAssignmentEmailData 组装任务分配邮件变量
+func (s *NotificationServiceImpl) taskAssignmentEmailData(ctx context.Context, taskID, senderID uint) *EmailTemplateData {
+	data := &EmailTemplateData{TaskTitle: fmt.Sprintf("任务#%d", taskID)}
+	if task, err := s.taskRepo.GetByID(ctx, taskID); err == nil {
+		data.TaskTitle = task.Title
+		if task.DueDate != nil {
+			data.Deadline = task.DueDate.Format("2006-01-02 15:04")
+		}
+	}
+	data.Requester = s.userDisplayName(ctx, senderID)
+	return data
+}
+
+// userDisplayName 获取用户姓名，未设置时使用用户名，查询失败返回空
+func (s *NotificationServiceImpl) userDisplayName(ctx context.Context, userID uint) string {
+	if userID == 0 {
+		return ""
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return ""
+	}
+	if user.RealName != "" {
+		return user.RealName
+	}
+	return user.Username
+}
+
+// OnApprovalsRequested 为新的待审批记录向审批人发送站内通知和邮件，站内通知不受偏好影响
+func (s *NotificationServiceImpl) OnApprovalsRequested(ctx context.Context, instance *workflow.WorkflowInstance, approvals []*workflow.PendingApproval) {
+	requester := s.userDisplayName(ctx, instance.StartedBy)
+	for _, approval := range approvals {
+		title := approval.WorkflowName
+		if approval.NodeName != "" {
+			title += " - " + approval.NodeName
+		}
+		content := fmt.Sprintf("您有一项待审批：%s，业务编号%s", title, approval.BusinessID)
+		if requester != "" {
+			content += "，申请人" + requester
+		}
+
+		s.enqueueEmail(ctx, approval.AssignedTo, NotificationCategoryApprovalRequested, func() *EmailTemplateData {
+			data := &EmailTemplateData{Title: title, Content: content, Requester: requester}
+			if approval.Deadline != nil {
+				data.Deadline = approval.Deadline.Format("2006-01-02 15:04")
+			}
+			return data
+		})
+		if err := s.CreateSystemNotification(ctx, approval.AssignedTo, "待审批："+title, content); err != nil {
+			logger.Warnf("发送待审批通知失败: instance=%s, user_id=%d, error=%v", instance.ID, approval.AssignedTo, err)
+		}
+	}
+}
+
+// OnApprovalReturned 告知发起人审批被退回及需要补充的内容，站内通知不受偏好影响
+func (s *NotificationServiceImpl) OnApprovalReturned(ctx context.Context, instance *workflow.WorkflowInstance, event *workflow.ApprovalReturn) {
+	title := "审批被退回：" + event.NodeName
+	content := fmt.Sprintf("您提交的审批（业务编号%s）在%s被退回到%s，退回说明：%s", instance.BusinessID, event.NodeName, event.TargetNodeName, event.Comment)
+	if returnedBy := s.userDisplayName(ctx, event.ReturnedBy); returnedBy != "" {
+		content += "，处理人" + returnedBy
+	}
+
+	s.enqueueEmail(ctx, instance.StartedBy, NotificationCategoryApprovalResolved, func() *EmailTemplateData {
+		return &EmailTemplateData{Title: title, Content: content}
+	})
+	if err := s.CreateSystemNotification(ctx, instance.StartedBy, title, content); err != nil {
+		logger.Warnf("发送审批退回通知失败: instance=%s, user_id=%d, error=%v", instance.ID, instance.StartedBy, err)
+	}
+}
+
+// approvalOutcomeSubjects 发送审批结果通知的业务类型及其名称
+var approvalOutcomeSubjects = map[string]string{
+	"task_assignment": "任务分配审批",
+	"onboarding":      "入职审批",
+}
+
+// OnApprovalResolved 向发起人和任务分配的被分配人发送审批结果通知，站内通知和邮件都受偏好影响。
+// 只通知节点拒绝和流程通过结束，流程未结束的节点通过不通知；同一实例的同一结果对每个接收人只通知一次，
+// 多个节点相继出结果或审批重试时不重复通知。批量审批的发起人改为接收汇总通知
+func (s *NotificationServiceImpl) OnApprovalResolved(ctx context.Context, instance *workflow.WorkflowInstance, event *workflow.ApprovalOutcome) {
+	subject, ok := approvalOutcomeSubjects[instance.BusinessType]
+	if !ok || (event.Outcome == workflow.ApprovalOutcomeApproved && !event.Completed) {
+		return
+	}
+
+	payload := map[string]interface{}{"instance_id": instance.ID, "node_id": event.NodeID, "outcome": event.Outcome}
+	var recipients []uint
+	if !isBatchApproval(ctx) {
+		recipients = append(recipients, instance.StartedBy)
+	}
+	var taskID *uint
+	switch instance.BusinessType {
+	case "task_assignment":
+		if id, ok := instance.GetUintVar("task_id"); ok && id != 0 {
+			taskID = &id
+			payload["task_id"] = id
+		}
+		// 流程变量中的assignee_id为员工ID
+		if employeeID, ok := instance.GetUintVar("assignee_id"); ok && employeeID != 0 {
+			if employee, err := s.employeeRepo.GetByID(ctx, employeeID); err != nil {
+				logger.Warnf("获取被分配员工失败，跳过审批结果通知: instance=%s, employee_id=%d, error=%v", instance.ID, employeeID, err)
+			} else {
+				recipients = append(recipients, employee.UserID)
+			}
+		}
+	case "onboarding":
+		if id, ok := instance.GetUintVar("employee_id"); ok && id != 0 {
+			payload["employee_id"] = id
+		}
+	}
+
+	outcomeText := "已通过"
+	if event.Outcome == workflow.ApprovalOutcomeRejected {
+		outcomeText = "被拒绝"
+	}
+	title := subject + outcomeText
+	content := fmt.Sprintf("%s（业务编号%s）在%s%s", subject, instance.BusinessID, event.NodeName, outcomeText)
+	if approver := s.userDisplayName(ctx, event.DecidedBy); approver != "" {
+		content += "，审批人" + approver
+	}
+	if event.Comment != "" {
+		content += "，审批意见：" + event.Comment
+	}
+
+	actionType := string(models.ActionTypeView)
+	actionData, err := json.Marshal(payload)
+	if err != nil {
+		logger.Warnf("序列化审批结果通知数据失败: instance=%s, error=%v", instance.ID, err)
+		return
+	}
+	data := string(actionData)
+	dedupeKey := fmt.Sprintf("approval_outcome:%s:%s", instance.ID, event.Outcome)
+
+	for _, userID := range uniqueIDs(recipients) {
+		// 审批人本人不需要通知
+		if userID == 0 || userID == event.DecidedBy {
+			continue
+		}
+		exists, err := s.notificationRepo.ExistsByDedupeKey(ctx, userID, dedupeKey)
+		if err != nil {
+			logger.Warnf("检查审批结果通知失败: instance=%s, user_id=%d, error=%v", instance.ID, userID, err)
+			continue
+		}
+		if exists {
+			continue
+		}
+
+		s.enqueueEmail(ctx, userID, NotificationCategoryApprovalResolved, func() *EmailTemplateData {
+			return &EmailTemplateData{Title: title, Content: content}
+		})
+		if !s.allowInApp(ctx, userID, NotificationCategoryApprovalResolved) {
+			continue
+		}
+		notification := &database.TaskNotification{
+			Type:        string(models.NotificationTypeSystemMessage),
+			Title:       title,
+			Content:     content,
+			RecipientID: userID,
+			TaskID:      taskID,
+			ActionType:  &actionType,
+			ActionData:  &data,
+			DedupeKey:   &dedupeKey,
+		}
+		if event.DecidedBy != 0 {
+			notification.SenderID = &event.DecidedBy
+		}
+		if err := s.notificationRepo.Create(ctx, notification); err != nil {
+			logger.Warnf("发送审批结果通知失败: instance=%s, user_id=%d, error=%v", instance.ID, userID, err)
+		}
+	}
+}
+
+// OnApprovalMentioned 通知审批意见中提及的用户，通知附带流程实例ID以便跳转查看，站内通知不受偏好影响
+func (s *NotificationServiceImpl) OnApprovalMentioned(ctx context.Context, instance *workflow.WorkflowInstance, event *workflow.ApprovalMention) {
+	title := "审批中提到了您：" + event.NodeName
+	content := fmt.Sprintf("审批（业务编号%s）在%s中提到了您", instance.BusinessID, event.NodeName)
+	if mentionedBy := s.userDisplayName(ctx, event.MentionedBy); mentionedBy != "" {
+		content += "，处理人" + mentionedBy
+	}
+	if event.Comment != "" {
+		content += "，审批意见：" + event.Comment
+	}
+
+	actionType := string(models.ActionTypeView)
+	actionData, err := json.Marshal(map[string]string{"instance_id": instance.ID, "node_id": event.NodeID})
+	if err != nil {
+		logger.Warnf("序列化审批提及通知数据失败: instance=%s, error=%v", instance.ID, err)
+		return
+	}
+	data := string(actionData)
+	var taskID *uint
+	if id, ok := instance.GetUintVar("task_id"); ok && id != 0 {
+		taskID = &id
+	}
+
+	for _, userID := range event.UserIDs {
+		if userID == event.MentionedBy {
+			continue
+		}
+		notification := &database.TaskNotification{
+			Type:        string(models.NotificationTypeSystemMessage),
+			Title:       title,
+			Content:     content,
+			RecipientID: userID,
+			SenderID:    &event.MentionedBy,
+			TaskID:      taskID,
+			ActionType:  &actionType,
+			ActionData:  &data,
+		}
+		if err := s.notificationRepo.Create(ctx, notification); err != nil {
+			logger.Warnf("发送审批提及通知失败: instance=%s, user_id=%d, error=%v", instance.ID, userID, err)
+		}
+	}
+}
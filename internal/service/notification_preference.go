@@ -0,0 +1,216 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"taskmanage/internal/config"
+	"taskmanage/internal/database"
+	"taskmanage/internal/models"
+	"taskmanage/internal/repository"
+	"taskmanage/pkg/logger"
+)
+
+// 通知类别
+const (
+	NotificationCategoryTaskAssigned      = "task_assigned"
+	NotificationCategoryTaskCompleted     = "task_completed"
+	NotificationCategoryApprovalRequested = "approval_requested"
+	NotificationCategoryApprovalResolved  = "approval_resolved"
+	NotificationCategoryOnboardingStatus  = "onboarding_status"
+)
+
+// 通知渠道
+const (
+	NotificationChannelInApp   = "in_app"
+	NotificationChannelEmail   = "email"
+	NotificationChannelWebhook = "webhook"
+)
+
+var (
+	// ErrInvalidNotificationPreference 通知类别或渠道不存在
+	ErrInvalidNotificationPreference = errors.New("通知偏好不合法")
+	// ErrNotificationPreferenceLocked 审批请求的站内通知不允许关闭
+	ErrNotificationPreferenceLocked = errors.New("审批请求的站内通知不能关闭")
+)
+
+var (
+	notificationCategories = []string{
+		NotificationCategoryTaskAssigned,
+		NotificationCategoryTaskCompleted,
+		NotificationCategoryApprovalRequested,
+		NotificationCategoryApprovalResolved,
+		NotificationCategoryOnboardingStatus,
+	}
+	notificationChannels = []string{
+		NotificationChannelInApp,
+		NotificationChannelEmail,
+		NotificationChannelWebhook,
+	}
+)
+
+// NotificationPreferenceItem 单个类别和渠道的通知偏好
+type NotificationPreferenceItem struct {
+	Category string `json:"category" binding:"required"`
+	Channel  string `json:"channel" binding:"required"`
+	Enabled  bool   `json:"enabled"`
+}
+
+// UpdateNotificationPreferencesRequest 更新通知偏好请求，未列出的类别和渠道保持不变
+type UpdateNotificationPreferencesRequest struct {
+	Preferences []NotificationPreferenceItem `json:"preferences" binding:"required,min=1,dive"`
+}
+
+// NotificationPreferenceResponse 通知偏好响应
+type NotificationPreferenceResponse struct {
+	Category string `json:"category"`
+	Channel  string `json:"channel"`
+	Enabled  bool   `json:"enabled"`
+	Locked   bool   `json:"locked"` // 不允许关闭
+}
+
+// buildNotificationDefaults 根据配置生成各类别和渠道的默认开关，未配置的类别只开启站内通知
+func buildNotificationDefaults(cfg config.NotificationConfig) map[string]map[string]bool {
+	defaults := make(map[string]map[string]bool, len(notificationCategories))
+	for _, category := range notificationCategories {
+		channels := map[string]bool{NotificationChannelInApp: true}
+		if configured, ok := cfg.DefaultChannels[category]; ok {
+			channels = map[string]bool{}
+			for _, channel := range configured {
+				if !isNotificationChannel(channel) {
+					logger.Warnf("忽略未知的默认通知渠道: category=%s, channel=%s", category, channel)
+					continue
+				}
+				channels[channel] = true
+			}
+		}
+		if isLockedNotificationPreference(category, NotificationChannelInApp) {
+			channels[NotificationChannelInApp] = true
+		}
+		defaults[category] = channels
+	}
+	return defaults
+}
+
+func isNotificationCategory(category string) bool {
+	for _, c := range notificationCategories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+func isNotificationChannel(channel string) bool {
+	for _, c := range notificationChannels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// isLockedNotificationPreference 审批请求的站内通知必须开启，避免审批人错过待办
+func isLockedNotificationPreference(category, channel string) bool {
+	return category == NotificationCategoryApprovalRequested && channel == NotificationChannelInApp
+}
+
+// taskNotificationCategory 任务通知类型对应的偏好类别，不受偏好控制的类型返回空
+func taskNotificationCategory(notificationType models.TaskNotificationType) string {
+	switch notificationType {
+	case models.NotificationTypeTaskAssigned, models.NotificationTypeTaskReassigned:
+		return NotificationCategoryTaskAssigned
+	case models.NotificationTypeTaskCompleted:
+		return NotificationCategoryTaskCompleted
+	}
+	return ""
+}
+
+// GetPreferences 获取用户在全部类别和渠道上的通知偏好，未设置的项使用默认值
+func (s *NotificationServiceImpl) GetPreferences(ctx context.Context, userID uint) ([]*NotificationPreferenceResponse, error) {
+	stored, err := s.preferenceRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("获取通知偏好失败: %w", err)
+	}
+	overrides := make(map[string]bool, len(stored))
+	for _, preference := range stored {
+		overrides[preference.Category+"/"+preference.Channel] = preference.Enabled
+	}
+
+	responses := make([]*NotificationPreferenceResponse, 0, len(notificationCategories)*len(notificationChannels))
+	for _, category := range notificationCategories {
+		for _, channel := range notificationChannels {
+			enabled, ok := overrides[category+"/"+channel]
+			if !ok {
+				enabled = s.defaults[category][channel]
+			}
+			locked := isLockedNotificationPreference(category, channel)
+			responses = append(responses, &NotificationPreferenceResponse{
+				Category: category,
+				Channel:  channel,
+				Enabled:  enabled || locked,
+				Locked:   locked,
+			})
+		}
+	}
+	return responses, nil
+}
+
+// UpdatePreferences 更新用户通知偏好，返回更新后的完整偏好
+func (s *NotificationServiceImpl) UpdatePreferences(ctx context.Context, userID uint, req *UpdateNotificationPreferencesRequest) ([]*NotificationPreferenceResponse, error) {
+	preferences := make([]*database.NotificationPreference, 0, len(req.Preferences))
+	for _, item := range req.Preferences {
+		if !isNotificationCategory(item.Category) {
+			return nil, fmt.Errorf("%w: 未知的通知类别 %s", ErrInvalidNotificationPreference, item.Category)
+		}
+		if !isNotificationChannel(item.Channel) {
+			return nil, fmt.Errorf("%w: 未知的通知渠道 %s", ErrInvalidNotificationPreference, item.Channel)
+		}
+		if !item.Enabled && isLockedNotificationPreference(item.Category, item.Channel) {
+			return nil, ErrNotificationPreferenceLocked
+		}
+		preferences = append(preferences, &database.NotificationPreference{
+			UserID:   userID,
+			Category: item.Category,
+			Channel:  item.Channel,
+			Enabled:  item.Enabled,
+		})
+	}
+
+	if err := s.preferenceRepo.Upsert(ctx, preferences); err != nil {
+		return nil, fmt.Errorf("保存通知偏好失败: %w", err)
+	}
+	return s.GetPreferences(ctx, userID)
+}
+
+// IsChannelEnabled 判断用户是否接收某类别在指定渠道上的通知
+func (s *NotificationServiceImpl) IsChannelEnabled(ctx context.Context, userID uint, category, channel string) (bool, error) {
+	if isLockedNotificationPreference(category, channel) {
+		return true, nil
+	}
+	preference, err := s.preferenceRepo.Get(ctx, userID, category, channel)
+	if errors.Is(err, repository.ErrNotFound) {
+		return s.defaults[category][channel], nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("获取通知偏好失败: %w", err)
+	}
+	return preference.Enabled, nil
+}
+
+// allowInApp 判断是否创建站内通知，未分类的通知总是发送，读取偏好失败时按发送处理
+func (s *NotificationServiceImpl) allowInApp(ctx context.Context, userID uint, category string) bool {
+	if category == "" || s.preferenceRepo == nil {
+		return true
+	}
+	enabled, err := s.IsChannelEnabled(ctx, userID, category, NotificationChannelInApp)
+	if err != nil {
+		logger.Warnf("读取通知偏好失败，按默认发送: user_id=%d, category=%s, error=%v", userID, category, err)
+		return true
+	}
+	if !enabled {
+		logger.Debugf("用户已关闭站内通知，跳过: user_id=%d, category=%s", userID, category)
+	}
+	return enabled
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/config"
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+// fakePreferenceRepository 测试用内存通知偏好仓储
+type fakePreferenceRepository struct {
+	preferences map[string]*database.NotificationPreference
+}
+
+func (f *fakePreferenceRepository) key(userID uint, category, channel string) string {
+	return fmt.Sprintf("%d/%s/%s", userID, category, channel)
+}
+
+func (f *fakePreferenceRepository) ListByUser(ctx context.Context, userID uint) ([]*database.NotificationPreference, error) {
+	var result []*database.NotificationPreference
+	for _, p := range f.preferences {
+		if p.UserID == userID {
+			result = append(result, p)
+		}
+	}
+	return result, nil
+}
+
+func (f *fakePreferenceRepository) Get(ctx context.Context, userID uint, category, channel string) (*database.NotificationPreference, error) {
+	if p, ok := f.preferences[f.key(userID, category, channel)]; ok {
+		return p, nil
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (f *fakePreferenceRepository) Upsert(ctx context.Context, preferences []*database.NotificationPreference) error {
+	for _, p := range preferences {
+		f.preferences[f.key(p.UserID, p.Category, p.Channel)] = p
+	}
+	return nil
+}
+
+// countingNotificationRepository 统计创建的站内通知
+type countingNotificationRepository struct {
+	repository.NotificationRepository
+	created []*database.TaskNotification
+}
+
+func (r *countingNotificationRepository) Create(ctx context.Context, notification *database.TaskNotification) error {
+	r.created = append(r.created, notification)
+	return nil
+}
+
+func (r *countingNotificationRepository) CreateTaskAssignmentNotification(ctx context.Context, taskID, recipientID, senderID uint) error {
+	r.created = append(r.created, &database.TaskNotification{TaskID: &taskID, RecipientID: recipientID})
+	return nil
+}
+
+func TestNotificationPreferences(t *testing.T) {
+	ctx := context.Background()
+	notifications := &countingNotificationRepository{}
+	svc := &NotificationServiceImpl{
+		notificationRepo: notifications,
+		preferenceRepo:   &fakePreferenceRepository{preferences: map[string]*database.NotificationPreference{}},
+		defaults: buildNotificationDefaults(config.NotificationConfig{DefaultChannels: map[string][]string{
+			NotificationCategoryTaskCompleted:     {NotificationChannelEmail},
+			NotificationCategoryApprovalRequested: {NotificationChannelEmail},
+		}}),
+	}
+
+	// 默认值来自配置，未配置的类别只开启站内通知，审批请求的站内通知始终开启
+	enabled, err := svc.IsChannelEnabled(ctx, 1, NotificationCategoryTaskCompleted, NotificationChannelInApp)
+	require.NoError(t, err)
+	assert.False(t, enabled)
+	enabled, _ = svc.IsChannelEnabled(ctx, 1, NotificationCategoryTaskAssigned, NotificationChannelInApp)
+	assert.True(t, enabled)
+	enabled, _ = svc.IsChannelEnabled(ctx, 1, NotificationCategoryApprovalRequested, NotificationChannelInApp)
+	assert.True(t, enabled)
+
+	_, err = svc.UpdatePreferences(ctx, 1, &UpdateNotificationPreferencesRequest{Preferences: []NotificationPreferenceItem{
+		{Category: NotificationCategoryApprovalRequested, Channel: NotificationChannelInApp, Enabled: false},
+	}})
+	assert.ErrorIs(t, err, ErrNotificationPreferenceLocked)
+	_, err = svc.UpdatePreferences(ctx, 1, &UpdateNotificationPreferencesRequest{Preferences: []NotificationPreferenceItem{
+		{Category: "task_started", Channel: NotificationChannelInApp, Enabled: false},
+	}})
+	assert.ErrorIs(t, err, ErrInvalidNotificationPreference)
+
+	preferences, err := svc.UpdatePreferences(ctx, 1, &UpdateNotificationPreferencesRequest{Preferences: []NotificationPreferenceItem{
+		{Category: NotificationCategoryTaskAssigned, Channel: NotificationChannelInApp, Enabled: false},
+	}})
+	require.NoError(t, err)
+	assert.Len(t, preferences, 15)
+	for _, p := range preferences {
+		if p.Category == NotificationCategoryTaskAssigned && p.Channel == NotificationChannelInApp {
+			assert.False(t, p.Enabled)
+		}
+		if p.Category == NotificationCategoryApprovalRequested && p.Channel == NotificationChannelInApp {
+			assert.True(t, p.Enabled)
+			assert.True(t, p.Locked)
+		}
+	}
+
+	// 关闭任务分配站内通知后不再创建，其他用户不受影响
+	require.NoError(t, svc.CreateTaskAssignmentNotification(ctx, 9, 1, 2))
+	require.NoError(t, svc.CreateTaskAssignmentNotification(ctx, 9, 3, 2))
+	require.Len(t, notifications.created, 1)
+	assert.Equal(t, uint(3), notifications.created[0].RecipientID)
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"taskmanage/internal/config"
+	"taskmanage/internal/repository"
+	"taskmanage/pkg/logger"
+	"taskmanage/pkg/response"
+)
+
+const (
+	// DefaultNotificationReadRetentionDays 已读通知的默认保留天数
+	DefaultNotificationReadRetentionDays = 90
+	// DefaultNotificationCleanupInterval 已读通知清理任务的默认运行间隔
+	DefaultNotificationCleanupInterval = 24 * time.Hour
+)
+
+var (
+	// ErrInvalidBulkRead 批量标记已读的请求不合法
+	ErrInvalidBulkRead = response.NewError(response.ErrCodeInvalidRequest, "批量标记已读请求不合法")
+	// ErrNotificationNotFound 通知不存在或不属于当前用户
+	ErrNotificationNotFound = response.NewError(response.ErrCodeNotFound, "通知不存在")
+)
+
+// notificationReadRetention 已读通知的保留时长，未配置时使用默认值
+func notificationReadRetention(cfg config.NotificationConfig) time.Duration {
+	days := cfg.ReadRetentionDays
+	if days <= 0 {
+		days = DefaultNotificationReadRetentionDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// BulkMarkAsRead 按ID列表或按类型、创建时间过滤条件批量标记已读
+// 指定ID时先校验全部ID都属于当前用户，有不属于的ID时不做任何修改
+func (s *NotificationServiceImpl) BulkMarkAsRead(ctx context.Context, userID uint, req *BulkReadNotificationsRequest) (int64, error) {
+	filter := &repository.NotificationFilter{RecipientID: userID}
+	if len(req.IDs) > 0 {
+		if req.Type != "" || req.Before != nil {
+			return 0, fmt.Errorf("%w: ids不能与type、before同时指定", ErrInvalidBulkRead)
+		}
+		ids := uniqueIDs(req.IDs)
+		owned, err := s.notificationRepo.ListOwnedIDs(ctx, userID, ids)
+		if err != nil {
+			return 0, err
+		}
+		if missing := missingIDs(ids, owned); len(missing) > 0 {
+			return 0, fmt.Errorf("%w: %v", ErrNotificationNotFound, missing)
+		}
+		filter.IDs = ids
+	} else {
+		if req.Type == "" && req.Before == nil {
+			return 0, fmt.Errorf("%w: 需要指定ids或type、before过滤条件", ErrInvalidBulkRead)
+		}
+		filter.Type = req.Type
+		filter.CreatedBefore = req.Before
+	}
+
+	updated, err := s.notificationRepo.MarkReadByFilter(ctx, filter, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	logger.FromContext(ctx).Infof("批量标记通知已读: user_id=%d, ids=%d, type=%s, 标记%d条", userID, len(filter.IDs), filter.Type, updated)
+	return updated, nil
+}
+
+// CleanupReadNotifications 软删除阅读时间超过保留期的已读通知，未读和已操作的通知不清理
+func (s *NotificationServiceImpl) CleanupReadNotifications(ctx context.Context, now time.Time) (int64, error) {
+	return s.notificationRepo.DeleteReadBefore(ctx, now.Add(-s.readRetention))
+}
+
+// missingIDs 返回ids中不在present中的ID
+func missingIDs(ids, present []uint) []uint {
+	found := make(map[uint]bool, len(present))
+	for _, id := range present {
+		found[id] = true
+	}
+	var missing []uint
+	for _, id := range ids {
+		if !found[id] {
+			missing = append(missing, id)
+		}
+	}
+	return missing
+}
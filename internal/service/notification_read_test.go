@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/config"
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+// memoryReadNotificationRepository 按接收人、类型和创建时间过滤的内存通知仓储
+type memoryReadNotificationRepository struct {
+	repository.NotificationRepository
+	notifications []*database.TaskNotification
+	updates       int
+}
+
+func (r *memoryReadNotificationRepository) ListOwnedIDs(ctx context.Context, recipientID uint, ids []uint) ([]uint, error) {
+	var owned []uint
+	for _, n := range r.notifications {
+		for _, id := range ids {
+			if n.ID == id && n.RecipientID == recipientID {
+				owned = append(owned, id)
+			}
+		}
+	}
+	return owned, nil
+}
+
+func (r *memoryReadNotificationRepository) MarkReadByFilter(ctx context.Context, filter *repository.NotificationFilter, readAt time.Time) (int64, error) {
+	r.updates++
+	var updated int64
+	for _, n := range r.notifications {
+		if n.RecipientID != filter.RecipientID || n.Status != "unread" {
+			continue
+		}
+		if len(filter.IDs) > 0 && !containsUint(filter.IDs, n.ID) {
+			continue
+		}
+		if filter.Type != "" && n.Type != filter.Type {
+			continue
+		}
+		if filter.CreatedBefore != nil && !n.CreatedAt.Before(*filter.CreatedBefore) {
+			continue
+		}
+		n.Status = "read"
+		n.ReadAt = &readAt
+		updated++
+	}
+	return updated, nil
+}
+
+func (r *memoryReadNotificationRepository) DeleteReadBefore(ctx context.Context, before time.Time) (int64, error) {
+	var kept []*database.TaskNotification
+	for _, n := range r.notifications {
+		if n.Status != "read" || !n.ReadAt.Before(before) {
+			kept = append(kept, n)
+		}
+	}
+	deleted := int64(len(r.notifications) - len(kept))
+	r.notifications = kept
+	return deleted, nil
+}
+
+func containsUint(ids []uint, id uint) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+func newReadTestNotification(id, recipientID uint, notificationType string, createdAt time.Time) *database.TaskNotification {
+	n := &database.TaskNotification{Type: notificationType, RecipientID: recipientID, Status: "unread"}
+	n.ID = id
+	n.CreatedAt = createdAt
+	return n
+}
+
+func TestBulkMarkAsRead(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	repo := &memoryReadNotificationRepository{notifications: []*database.TaskNotification{
+		newReadTestNotification(1, 7, "task_assigned", now.Add(-48*time.Hour)),
+		newReadTestNotification(2, 7, "task_assigned", now.Add(-time.Hour)),
+		newReadTestNotification(3, 7, "task_overdue", now.Add(-48*time.Hour)),
+		newReadTestNotification(4, 8, "task_assigned", now.Add(-48*time.Hour)),
+	}}
+	svc := &NotificationServiceImpl{notificationRepo: repo}
+
+	// 含有他人的通知时整体拒绝，不做任何修改
+	_, err := svc.BulkMarkAsRead(ctx, 7, &BulkReadNotificationsRequest{IDs: []uint{1, 4}})
+	assert.ErrorIs(t, err, ErrNotificationNotFound)
+	assert.Equal(t, 0, repo.updates)
+
+	_, err = svc.BulkMarkAsRead(ctx, 7, &BulkReadNotificationsRequest{IDs: []uint{1}, Type: "task_assigned"})
+	assert.ErrorIs(t, err, ErrInvalidBulkRead)
+	_, err = svc.BulkMarkAsRead(ctx, 7, &BulkReadNotificationsRequest{})
+	assert.ErrorIs(t, err, ErrInvalidBulkRead)
+
+	// 重复的ID只计一次
+	updated, err := svc.BulkMarkAsRead(ctx, 7, &BulkReadNotificationsRequest{IDs: []uint{3, 3}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), updated)
+
+	// 按类型和创建时间过滤只影响自己的通知
+	before := now.Add(-24 * time.Hour)
+	updated, err = svc.BulkMarkAsRead(ctx, 7, &BulkReadNotificationsRequest{Type: "task_assigned", Before: &before})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), updated)
+	assert.Equal(t, "read", repo.notifications[0].Status)
+	assert.Equal(t, "unread", repo.notifications[1].Status)
+	assert.Equal(t, "unread", repo.notifications[3].Status)
+}
+
+func TestCleanupReadNotifications(t *testing.T) {
+	now := time.Now()
+	readAt := func(days int) *time.Time {
+		at := now.AddDate(0, 0, -days)
+		return &at
+	}
+	repo := &memoryReadNotificationRepository{notifications: []*database.TaskNotification{
+		{Status: "read", ReadAt: readAt(31)},
+		{Status: "read", ReadAt: readAt(29)},
+		{Status: "unread"},
+	}}
+	svc := &NotificationServiceImpl{
+		notificationRepo: repo,
+		readRetention:    notificationReadRetention(config.NotificationConfig{ReadRetentionDays: 30}),
+	}
+
+	deleted, err := svc.CleanupReadNotifications(context.Background(), now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+	assert.Len(t, repo.notifications, 2)
+	assert.Equal(t, 90*24*time.Hour, notificationReadRetention(config.NotificationConfig{}))
+}
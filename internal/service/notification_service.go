@@ -3,7 +3,9 @@ package service
 import (
 	"context"
 	"fmt"
+	"time"
 
+	"taskmanage/internal/config"
 	"taskmanage/internal/database"
 	"taskmanage/internal/models"
 	"taskmanage/internal/repository"
@@ -13,12 +15,26 @@ import (
 // NotificationServiceImpl 通知服务实现
 type NotificationServiceImpl struct {
 	notificationRepo repository.NotificationRepository
+	preferenceRepo   repository.NotificationPreferenceRepository
+	taskRepo         repository.TaskRepository
+	userRepo         repository.UserRepository
+	employeeRepo     repository.EmployeeRepository
+	emailNotifier    EmailNotifier              // 为nil时不发送邮件
+	defaults         map[string]map[string]bool // 类别 -> 渠道 -> 默认是否开启
+	readRetention    time.Duration              // 已读通知的保留时长
 }
 
 // NewNotificationService 创建通知服务
-func NewNotificationService(repoManager repository.RepositoryManager) NotificationService {
+func NewNotificationService(repoManager repository.RepositoryManager, cfg config.NotificationConfig, emailNotifier EmailNotifier) NotificationService {
 	return &NotificationServiceImpl{
 		notificationRepo: repoManager.NotificationRepository(),
+		preferenceRepo:   repoManager.NotificationPreferenceRepository(),
+		taskRepo:         repoManager.TaskRepository(),
+		userRepo:         repoManager.UserRepository(),
+		employeeRepo:     repoManager.EmployeeRepository(),
+		emailNotifier:    emailNotifier,
+		defaults:         buildNotificationDefaults(cfg),
+		readRetention:    notificationReadRetention(cfg),
 	}
 }
 
@@ -28,14 +44,22 @@ func (s *NotificationServiceImpl) SendNotification(ctx context.Context, req *Sen
 	return nil, fmt.Errorf("未实现")
 }
 
-// ListNotifications 获取通知列表 (为Handler提供的方法)
-func (s *NotificationServiceImpl) ListNotifications(ctx context.Context, userID uint, unreadOnly bool) ([]*NotificationResponse, int64, error) {
-	status := ""
-	if unreadOnly {
-		status = "unread"
+// ListNotifications 按类型和已读状态分页获取通知列表，未指定的分页参数写回默认值 (为Handler提供的方法)
+func (s *NotificationServiceImpl) ListNotifications(ctx context.Context, userID uint, req *NotificationListRequest) ([]*NotificationResponse, int64, error) {
+	filter := &repository.NotificationFilter{RecipientID: userID, Type: req.Type, IsRead: req.IsRead}
+	if req.UnreadOnly && filter.IsRead == nil {
+		unread := false
+		filter.IsRead = &unread
 	}
-	
-	notifications, total, err := s.notificationRepo.GetUserNotifications(ctx, userID, status, 1, 20)
+	// 设置默认分页参数
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 20
+	}
+
+	notifications, total, err := s.notificationRepo.ListByFilter(ctx, filter, req.Page, req.PageSize)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -45,9 +69,12 @@ func (s *NotificationServiceImpl) ListNotifications(ctx context.Context, userID
 	for i, n := range notifications {
 		responses[i] = &NotificationResponse{
 			ID:        n.ID,
+			UserID:    n.RecipientID,
 			Type:      string(n.Type),
 			Title:     n.Title,
 			Content:   n.Content,
+			IsRead:    n.Status != string(models.NotificationStatusUnread),
+			ReadAt:    n.ReadAt,
 			CreatedAt: n.CreatedAt,
 		}
 	}
@@ -66,9 +93,9 @@ func (s *NotificationServiceImpl) MarkAllAsRead(ctx context.Context, userID uint
 	return err
 }
 
-// GetUnreadCount 获取未读数量
-func (s *NotificationServiceImpl) GetUnreadCount(ctx context.Context, userID uint) (int64, error) {
-	return s.notificationRepo.GetUnreadCount(ctx, userID)
+// GetUnreadCount 获取未读数量，notificationType不为空时只统计该类型
+func (s *NotificationServiceImpl) GetUnreadCount(ctx context.Context, userID uint, notificationType string) (int64, error) {
+	return s.notificationRepo.GetUnreadCount(ctx, userID, notificationType)
 }
 
 // BroadcastNotification 广播通知
@@ -79,6 +106,12 @@ func (s *NotificationServiceImpl) BroadcastNotification(ctx context.Context, req
 
 // CreateTaskAssignmentNotification 创建任务分配通知
 func (s *NotificationServiceImpl) CreateTaskAssignmentNotification(ctx context.Context, taskID, recipientID, senderID uint) error {
+	s.enqueueEmail(ctx, recipientID, NotificationCategoryTaskAssigned, func() *EmailTemplateData {
+		return s.taskAssignmentEmailData(ctx, taskID, senderID)
+	})
+	if !s.allowInApp(ctx, recipientID, NotificationCategoryTaskAssigned) {
+		return nil
+	}
 	if err := s.notificationRepo.CreateTaskAssignmentNotification(ctx, taskID, recipientID, senderID); err != nil {
 		logger.Errorf("创建任务分配通知失败: %v", err)
 		return fmt.Errorf("创建任务分配通知失败: %w", err)
@@ -90,6 +123,9 @@ func (s *NotificationServiceImpl) CreateTaskAssignmentNotification(ctx context.C
 
 // CreateTaskStatusNotification 创建任务状态变更通知
 func (s *NotificationServiceImpl) CreateTaskStatusNotification(ctx context.Context, taskID, recipientID uint, notificationType models.TaskNotificationType, title, content string) error {
+	if !s.allowInApp(ctx, recipientID, taskNotificationCategory(notificationType)) {
+		return nil
+	}
 	notification := &database.TaskNotification{
 		Type:        string(notificationType),
 		Title:       title,
@@ -107,6 +143,35 @@ func (s *NotificationServiceImpl) CreateTaskStatusNotification(ctx context.Conte
 	return nil
 }
 
+// CreateSystemNotification 创建系统通知
+func (s *NotificationServiceImpl) CreateSystemNotification(ctx context.Context, recipientID uint, title, content string) error {
+	notification := &database.TaskNotification{
+		Type:        string(models.NotificationTypeSystemMessage),
+		Title:       title,
+		Content:     content,
+		RecipientID: recipientID,
+	}
+
+	if err := s.notificationRepo.Create(ctx, notification); err != nil {
+		logger.Errorf("创建系统通知失败: %v", err)
+		return fmt.Errorf("创建系统通知失败: %w", err)
+	}
+
+	logger.Infof("成功创建系统通知: recipient_id=%d", recipientID)
+	return nil
+}
+
+// CreateCategorizedNotification 创建属于某通知类别的系统通知，用户关闭该类别的站内通知时不创建
+func (s *NotificationServiceImpl) CreateCategorizedNotification(ctx context.Context, recipientID uint, category, title, content string) error {
+	s.enqueueEmail(ctx, recipientID, category, func() *EmailTemplateData {
+		return &EmailTemplateData{Title: title, Content: content}
+	})
+	if !s.allowInApp(ctx, recipientID, category) {
+		return nil
+	}
+	return s.CreateSystemNotification(ctx, recipientID, title, content)
+}
+
 // GetUserNotifications 获取用户通知
 func (s *NotificationServiceImpl) GetUserNotifications(ctx context.Context, userID uint, status string, page, pageSize int) ([]models.TaskNotification, int64, error) {
 	notifications, total, err := s.notificationRepo.GetUserNotifications(ctx, userID, status, page, pageSize)
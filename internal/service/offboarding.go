@@ -0,0 +1,379 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+	"taskmanage/internal/workflow"
+)
+
+// 离职流程中的员工状态与离职申请状态
+const (
+	// EmployeeStatusOffboarding 员工离职办理中，不再接收新任务
+	EmployeeStatusOffboarding = "offboarding"
+
+	ResignationStatusPendingApproval = "pending_approval"
+	ResignationStatusHandover        = "handover"
+	ResignationStatusCompleted       = "completed"
+	ResignationStatusRejected        = "rejected"
+)
+
+// ErrOffboardingNotAllowed 当前离职流程状态不允许该操作
+var ErrOffboardingNotAllowed = errors.New("当前离职流程状态不允许该操作")
+
+// OffboardingService 离职工作流服务接口
+type OffboardingService interface {
+	// 发起离职申请并启动离职审批流程
+	StartOffboarding(ctx context.Context, req *ResignationRequest) (*OffboardingResponse, error)
+
+	// 处理离职审批决策，审批通过后进入任务交接
+	ProcessOffboardingApproval(ctx context.Context, req *ProcessOffboardingApprovalRequest) (*OffboardingResponse, error)
+
+	// 获取离职员工待交接的任务
+	ListHandoverTasks(ctx context.Context, employeeID uint) (*HandoverTasksResponse, error)
+
+	// 完成离职：所有任务交接完毕后将员工置为离职并撤销权限
+	CompleteOffboarding(ctx context.Context, employeeID uint, operatorID uint) (*OffboardingResponse, error)
+}
+
+// ResignationRequest 离职申请请求
+type ResignationRequest struct {
+	EmployeeID     uint   `json:"employee_id" binding:"required"`
+	LastWorkingDay string `json:"last_working_day" binding:"required"` // 格式: 2006-01-02
+	Reason         string `json:"reason" binding:"max=500"`
+	RequesterID    uint   `json:"-"`
+}
+
+// ProcessOffboardingApprovalRequest 处理离职审批请求
+type ProcessOffboardingApprovalRequest struct {
+	InstanceID string `json:"instance_id" binding:"required"`
+	NodeID     string `json:"node_id" binding:"required"`
+	Action     string `json:"action" binding:"required,oneof=approve reject"`
+	Comment    string `json:"comment"`
+	ApproverID uint   `json:"-"`
+}
+
+// OffboardingResponse 离职流程响应
+type OffboardingResponse struct {
+	ResignationID      uint       `json:"resignation_id"`
+	EmployeeID         uint       `json:"employee_id"`
+	Status             string     `json:"status"`
+	LastWorkingDay     string     `json:"last_working_day"`
+	Reason             string     `json:"reason,omitempty"`
+	WorkflowInstanceID string     `json:"workflow_instance_id"`
+	CompletedAt        *time.Time `json:"completed_at,omitempty"`
+}
+
+// HandoverTask 待交接任务
+type HandoverTask struct {
+	TaskID   uint       `json:"task_id"`
+	Title    string     `json:"title"`
+	Status   string     `json:"status"`
+	Priority string     `json:"priority"`
+	DueDate  *time.Time `json:"due_date,omitempty"`
+}
+
+// HandoverTasksResponse 待交接任务列表响应
+type HandoverTasksResponse struct {
+	EmployeeID     uint            `json:"employee_id"`
+	Status         string          `json:"status"`
+	LastWorkingDay string          `json:"last_working_day"`
+	Tasks          []*HandoverTask `json:"tasks"`
+	CanComplete    bool            `json:"can_complete"` // 处于交接阶段且任务已全部交接
+}
+
+// OffboardingServiceImpl 离职工作流服务实现
+type OffboardingServiceImpl struct {
+	employeeRepo                repository.EmployeeRepository
+	userRepo                    repository.UserRepository
+	taskRepo                    repository.TaskRepository
+	resignationRepo             repository.ResignationRepository
+	historyRepo                 repository.OnboardingHistoryRepository
+	workflowService             WorkflowService
+	permissionAssignmentService PermissionAssignmentService
+	logger                      *logrus.Logger
+}
+
+// NewOffboardingService 创建离职工作流服务
+func NewOffboardingService(repoManager repository.RepositoryManager, workflowService WorkflowService, permissionAssignmentService PermissionAssignmentService, logger *logrus.Logger) OffboardingService {
+	return &OffboardingServiceImpl{
+		employeeRepo:                repoManager.EmployeeRepository(),
+		userRepo:                    repoManager.UserRepository(),
+		taskRepo:                    repoManager.TaskRepository(),
+		resignationRepo:             repoManager.ResignationRepository(),
+		historyRepo:                 repoManager.OnboardingHistoryRepository(),
+		workflowService:             workflowService,
+		permissionAssignmentService: permissionAssignmentService,
+		logger:                      logger,
+	}
+}
+
+// StartOffboarding 发起离职申请
+func (s *OffboardingServiceImpl) StartOffboarding(ctx context.Context, req *ResignationRequest) (*OffboardingResponse, error) {
+	logger := s.logger.WithField("method", "StartOffboarding")
+
+	lastWorkingDay, err := time.Parse("2006-01-02", req.LastWorkingDay)
+	if err != nil {
+		return nil, fmt.Errorf("最后工作日格式错误，应为YYYY-MM-DD: %w", err)
+	}
+
+	employee, err := s.employeeRepo.GetByID(ctx, req.EmployeeID)
+	if err != nil {
+		return nil, fmt.Errorf("获取员工信息失败: %w", err)
+	}
+	if employee.Status == "resigned" || employee.OnboardingStatus == "inactive" {
+		return nil, fmt.Errorf("%w: 员工已离职", ErrOffboardingNotAllowed)
+	}
+
+	if _, err := s.resignationRepo.GetActiveByEmployeeID(ctx, employee.ID); err == nil {
+		return nil, fmt.Errorf("%w: 员工已有进行中的离职申请", ErrOffboardingNotAllowed)
+	} else if !repository.IsNotFoundError(err) {
+		return nil, fmt.Errorf("查询离职申请失败: %w", err)
+	}
+
+	instance, err := s.workflowService.StartOffboardingApproval(ctx, &workflow.OffboardingApprovalRequest{
+		EmployeeID:     employee.ID,
+		DepartmentID:   employee.DepartmentID,
+		LastWorkingDay: req.LastWorkingDay,
+		Reason:         req.Reason,
+		Priority:       "normal",
+		RequesterID:    req.RequesterID,
+	})
+	if err != nil {
+		logger.WithError(err).Error("启动离职审批工作流失败")
+		return nil, fmt.Errorf("启动离职审批流程失败: %w", err)
+	}
+
+	resignation := &database.Resignation{
+		EmployeeID:         employee.ID,
+		LastWorkingDay:     lastWorkingDay,
+		Reason:             req.Reason,
+		Status:             ResignationStatusPendingApproval,
+		PreviousStatus:     employee.OnboardingStatus,
+		WorkflowInstanceID: instance.ID,
+		RequesterID:        req.RequesterID,
+	}
+	if instance.Status == workflow.StatusCompleted {
+		// 审批节点全部自动通过，直接进入交接
+		resignation.Status = ResignationStatusHandover
+	}
+	if err := s.resignationRepo.Create(ctx, resignation); err != nil {
+		logger.WithError(err).Error("保存离职申请失败")
+		if cancelErr := s.workflowService.CancelWorkflow(ctx, instance.ID, "保存离职申请失败"); cancelErr != nil {
+			logger.WithError(cancelErr).Warn("取消离职审批流程失败")
+		}
+		return nil, fmt.Errorf("保存离职申请失败: %w", err)
+	}
+
+	// 离职办理期间员工不再接收新任务
+	oldStatus := employee.OnboardingStatus
+	employee.OnboardingStatus = EmployeeStatusOffboarding
+	if err := s.employeeRepo.Update(ctx, employee); err != nil {
+		logger.WithError(err).Error("更新员工状态失败")
+		return nil, fmt.Errorf("更新员工状态失败: %w", err)
+	}
+	s.recordStatusChange(ctx, employee.ID, oldStatus, employee.OnboardingStatus, req.RequesterID, "发起离职申请", req.Reason)
+
+	logger.Infof("离职申请已提交: EmployeeID=%d, InstanceID=%s", employee.ID, instance.ID)
+	return buildOffboardingResponse(resignation), nil
+}
+
+// ProcessOffboardingApproval 处理离职审批决策
+func (s *OffboardingServiceImpl) ProcessOffboardingApproval(ctx context.Context, req *ProcessOffboardingApprovalRequest) (*OffboardingResponse, error) {
+	logger := s.logger.WithField("method", "ProcessOffboardingApproval")
+
+	resignation, err := s.resignationRepo.GetByWorkflowInstanceID(ctx, req.InstanceID)
+	if err != nil {
+		return nil, fmt.Errorf("获取离职申请失败: %w", err)
+	}
+	if resignation.Status != ResignationStatusPendingApproval {
+		return nil, fmt.Errorf("%w: 离职申请不在审批中，当前状态: %s", ErrOffboardingNotAllowed, resignation.Status)
+	}
+
+	result, err := s.workflowService.ProcessOffboardingApproval(ctx, &workflow.ApprovalRequest{
+		InstanceID: req.InstanceID,
+		NodeID:     req.NodeID,
+		Action:     workflow.ApprovalAction(req.Action),
+		Comment:    req.Comment,
+		ApprovedBy: req.ApproverID,
+	})
+	if err != nil {
+		logger.WithError(err).Error("处理离职审批失败")
+		return nil, fmt.Errorf("处理离职审批失败: %w", err)
+	}
+
+	switch {
+	case result.Action == workflow.ActionReject:
+		resignation.Status = ResignationStatusRejected
+		if err := s.restoreEmployeeStatus(ctx, resignation, req.ApproverID, req.Comment); err != nil {
+			return nil, err
+		}
+	case result.IsCompleted:
+		resignation.Status = ResignationStatusHandover
+	default:
+		// 多级审批尚未结束
+		return buildOffboardingResponse(resignation), nil
+	}
+
+	if err := s.resignationRepo.Update(ctx, resignation); err != nil {
+		logger.WithError(err).Error("更新离职申请失败")
+		return nil, fmt.Errorf("更新离职申请失败: %w", err)
+	}
+
+	logger.Infof("离职审批处理完成: EmployeeID=%d, Status=%s", resignation.EmployeeID, resignation.Status)
+	return buildOffboardingResponse(resignation), nil
+}
+
+// ListHandoverTasks 获取待交接任务
+func (s *OffboardingServiceImpl) ListHandoverTasks(ctx context.Context, employeeID uint) (*HandoverTasksResponse, error) {
+	resignation, err := s.resignationRepo.GetActiveByEmployeeID(ctx, employeeID)
+	if err != nil {
+		if repository.IsNotFoundError(err) {
+			return nil, fmt.Errorf("%w: 员工没有进行中的离职申请", ErrOffboardingNotAllowed)
+		}
+		return nil, fmt.Errorf("查询离职申请失败: %w", err)
+	}
+
+	tasks, err := s.taskRepo.GetActiveTasksByEmployee(ctx, employeeID)
+	if err != nil {
+		return nil, fmt.Errorf("获取员工活跃任务失败: %w", err)
+	}
+
+	handoverTasks := make([]*HandoverTask, 0, len(tasks))
+	for _, task := range tasks {
+		handoverTasks = append(handoverTasks, &HandoverTask{
+			TaskID:   task.ID,
+			Title:    task.Title,
+			Status:   task.Status,
+			Priority: task.Priority,
+			DueDate:  task.DueDate,
+		})
+	}
+
+	return &HandoverTasksResponse{
+		EmployeeID:     employeeID,
+		Status:         resignation.Status,
+		LastWorkingDay: resignation.LastWorkingDay.Format("2006-01-02"),
+		Tasks:          handoverTasks,
+		CanComplete:    resignation.Status == ResignationStatusHandover && len(handoverTasks) == 0,
+	}, nil
+}
+
+// CompleteOffboarding 完成离职
+func (s *OffboardingServiceImpl) CompleteOffboarding(ctx context.Context, employeeID uint, operatorID uint) (*OffboardingResponse, error) {
+	logger := s.logger.WithField("method", "CompleteOffboarding")
+
+	handover, err := s.ListHandoverTasks(ctx, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	if handover.Status != ResignationStatusHandover {
+		return nil, fmt.Errorf("%w: 离职申请尚未审批通过", ErrOffboardingNotAllowed)
+	}
+	if len(handover.Tasks) > 0 {
+		return nil, fmt.Errorf("%w: 员工仍有%d个任务未交接，请先重新分配或取消", ErrOffboardingNotAllowed, len(handover.Tasks))
+	}
+
+	resignation, err := s.resignationRepo.GetActiveByEmployeeID(ctx, employeeID)
+	if err != nil {
+		return nil, fmt.Errorf("查询离职申请失败: %w", err)
+	}
+	employee, err := s.employeeRepo.GetByID(ctx, employeeID)
+	if err != nil {
+		return nil, fmt.Errorf("获取员工信息失败: %w", err)
+	}
+
+	// 先撤销权限，失败时保持交接状态以便重试
+	if s.permissionAssignmentService != nil {
+		revoked, err := s.permissionAssignmentService.RevokeUserPermissionAssignments(ctx, employee.UserID, "员工离职", operatorID)
+		if err != nil {
+			logger.WithError(err).Error("撤销离职员工权限失败")
+			return nil, fmt.Errorf("撤销员工权限失败: %w", err)
+		}
+		logger.Infof("已撤销离职员工权限: EmployeeID=%d, Count=%d", employeeID, revoked)
+	}
+
+	oldStatus := employee.OnboardingStatus
+	employee.OnboardingStatus = "inactive"
+	employee.Status = "resigned"
+	if err := s.employeeRepo.Update(ctx, employee); err != nil {
+		logger.WithError(err).Error("更新员工状态失败")
+		return nil, fmt.Errorf("更新员工状态失败: %w", err)
+	}
+
+	// 停用用户账号
+	if user, err := s.userRepo.GetByID(ctx, employee.UserID); err == nil {
+		user.Status = "inactive"
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			logger.WithError(err).Warn("停用离职员工账号失败")
+		}
+	}
+
+	now := time.Now()
+	resignation.Status = ResignationStatusCompleted
+	resignation.CompletedBy = &operatorID
+	resignation.CompletedAt = &now
+	if err := s.resignationRepo.Update(ctx, resignation); err != nil {
+		logger.WithError(err).Error("更新离职申请失败")
+		return nil, fmt.Errorf("更新离职申请失败: %w", err)
+	}
+
+	s.recordStatusChange(ctx, employee.ID, oldStatus, employee.OnboardingStatus, operatorID, "完成离职", resignation.Reason)
+
+	logger.Infof("员工离职完成: EmployeeID=%d", employeeID)
+	return buildOffboardingResponse(resignation), nil
+}
+
+// restoreEmployeeStatus 离职审批被拒绝时恢复员工原状态
+func (s *OffboardingServiceImpl) restoreEmployeeStatus(ctx context.Context, resignation *database.Resignation, operatorID uint, comment string) error {
+	employee, err := s.employeeRepo.GetByID(ctx, resignation.EmployeeID)
+	if err != nil {
+		return fmt.Errorf("获取员工信息失败: %w", err)
+	}
+
+	oldStatus := employee.OnboardingStatus
+	employee.OnboardingStatus = resignation.PreviousStatus
+	if employee.OnboardingStatus == "" {
+		employee.OnboardingStatus = "active"
+	}
+	if err := s.employeeRepo.Update(ctx, employee); err != nil {
+		return fmt.Errorf("恢复员工状态失败: %w", err)
+	}
+
+	s.recordStatusChange(ctx, employee.ID, oldStatus, employee.OnboardingStatus, operatorID, "离职申请被拒绝", comment)
+	return nil
+}
+
+// recordStatusChange 记录员工状态变更历史
+func (s *OffboardingServiceImpl) recordStatusChange(ctx context.Context, employeeID uint, fromStatus, toStatus string, operatorID uint, reason, notes string) {
+	history := &database.OnboardingHistory{
+		EmployeeID: employeeID,
+		FromStatus: fromStatus,
+		ToStatus:   toStatus,
+		OperatorID: operatorID,
+		Reason:     reason,
+		Notes:      notes,
+	}
+
+	if err := s.historyRepo.Create(ctx, history); err != nil {
+		s.logger.Errorf("记录离职状态变更历史失败: %v", err)
+	}
+}
+
+// buildOffboardingResponse 构建离职流程响应
+func buildOffboardingResponse(resignation *database.Resignation) *OffboardingResponse {
+	return &OffboardingResponse{
+		ResignationID:      resignation.ID,
+		EmployeeID:         resignation.EmployeeID,
+		Status:             resignation.Status,
+		LastWorkingDay:     resignation.LastWorkingDay.Format("2006-01-02"),
+		Reason:             resignation.Reason,
+		WorkflowInstanceID: resignation.WorkflowInstanceID,
+		CompletedAt:        resignation.CompletedAt,
+	}
+}
@@ -2,23 +2,36 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
 	"time"
 
 	"taskmanage/internal/database"
 	"taskmanage/internal/repository"
 	"taskmanage/internal/workflow"
+	"taskmanage/pkg/logger"
+	"taskmanage/pkg/response"
+	"taskmanage/pkg/workcalendar"
 
 	"github.com/sirupsen/logrus"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// ErrInvalidOnboardingFilter 入职工作流过滤条件不合法
+var ErrInvalidOnboardingFilter = response.NewError(response.ErrCodeInvalidRequest, "入职工作流过滤条件不合法")
+
 // OnboardingService 入职工作流服务接口
 type OnboardingService interface {
 	// 创建待入职员工
 	CreatePendingEmployee(ctx context.Context, req *CreatePendingEmployeeRequest) (*OnboardingWorkflowResponse, error)
 
-	// 入职确认（待入职 -> 入职中）
+	// 从CSV批量创建待入职员工，逐行校验并返回每行结果；dryRun为true时只校验不创建
+	ImportPendingEmployees(ctx context.Context, r io.Reader, dryRun bool) (*EmployeeImportReport, error)
+
+	// 入职确认（审批通过 -> 入职中）
 	ConfirmOnboarding(ctx context.Context, req *OnboardConfirmRequest) (*OnboardingWorkflowResponse, error)
 
 	// 完成入职手续（入职中 -> 试用期）
@@ -27,11 +40,14 @@ type OnboardingService interface {
 	// 试用期转正（试用期 -> 正式员工）
 	ConfirmEmployee(ctx context.Context, req *ProbationToActiveRequest, operatorID uint) (*OnboardingWorkflowResponse, error)
 
-	// 员工状态变更
+	// 员工状态变更，只允许状态机中的转换
 	ChangeEmployeeStatus(ctx context.Context, req *EmployeeStatusChangeRequest, operatorID uint) (*OnboardingWorkflowResponse, error)
 
+	// 获取入职状态机，employeeID不为0时附带该员工当前状态可转换到的状态
+	GetStatusTransitions(ctx context.Context, employeeID uint) (*OnboardingTransitionsResponse, error)
+
 	// 获取入职工作流列表
-	GetOnboardingWorkflows(ctx context.Context, filter *OnboardingWorkflowFilter) ([]*OnboardingWorkflowResponse, error)
+	GetOnboardingWorkflows(ctx context.Context, filter *OnboardingWorkflowFilter) (*ListResponse[*OnboardingWorkflowResponse], error)
 
 	// 获取入职历史记录
 	GetOnboardingHistory(ctx context.Context, employeeID uint) ([]*OnboardingHistoryResponse, error)
@@ -51,6 +67,12 @@ type OnboardingService interface {
 
 	// 取消入职审批流程
 	CancelOnboardingApproval(ctx context.Context, instanceID string, reason string, operatorID uint) error
+
+	// 登记入职审批流程结束后的业务回调
+	RegisterCompletionHandlers(registry *workflow.CompletionHandlerRegistry)
+
+	// 提醒HR和直接上级为试用期将在withinDays天内结束的员工办理转正，返回已提醒人数
+	RemindProbationEnding(ctx context.Context, now time.Time, withinDays int) (int, error)
 }
 
 // 入职审批相关DTO定义
@@ -62,30 +84,142 @@ type OnboardingApprovalRequest struct {
 	PositionID    *uint  `json:"position_id"`
 	ExpectedDate  string `json:"expected_date" binding:"required"`
 	ProbationDays int    `json:"probation_days" binding:"min=30,max=180"`
-	WorkflowType  string `json:"workflow_type"` // "full" 或 "simple"
+	WorkflowType  string `json:"workflow_type"` // "full" 或 "simple"，为空时使用部门设置或系统默认流程
 	Notes         string `json:"notes"`
 	RequesterID   uint   `json:"requester_id"`
 }
 
-// 辅助函数
-func getDepartmentName(departmentID *uint) string {
+// onboardingNameResolver 单次请求内的部门、职位、用户名称解析器
+// 待审批列表中大量记录共享同一部门，按ID缓存避免重复查询
+type onboardingNameResolver struct {
+	departmentRepo repository.DepartmentRepository
+	positionRepo   repository.PositionRepository
+	userRepo       repository.UserRepository
+	logger         *logrus.Entry
+	departments    map[uint]string
+	positions      map[uint]string
+	users          map[uint]string
+}
+
+// newNameResolver 创建名称解析器
+func (s *OnboardingServiceImpl) newNameResolver(logger *logrus.Entry) *onboardingNameResolver {
+	return &onboardingNameResolver{
+		departmentRepo: s.departmentRepo,
+		positionRepo:   s.positionRepo,
+		userRepo:       s.userRepo,
+		logger:         logger,
+		departments:    make(map[uint]string),
+		positions:      make(map[uint]string),
+		users:          make(map[uint]string),
+	}
+}
+
+// departmentName 获取部门名称，已删除的部门追加"(已删除)"后缀
+func (r *onboardingNameResolver) departmentName(ctx context.Context, departmentID *uint) string {
 	if departmentID == nil {
 		return "未分配"
 	}
-	return fmt.Sprintf("部门%d", *departmentID)
+	if name, ok := r.departments[*departmentID]; ok {
+		return name
+	}
+
+	var name string
+	department, err := r.departmentRepo.GetByIDUnscoped(ctx, *departmentID)
+	switch {
+	case err == nil && department.DeletedAt.Valid:
+		name = department.Name + "(已删除)"
+	case err == nil:
+		name = department.Name
+	case repository.IsNotFoundError(err):
+		name = fmt.Sprintf("部门#%d(已删除)", *departmentID)
+	default:
+		r.logger.WithError(err).WithField("department_id", *departmentID).Warn("获取部门信息失败")
+		return fmt.Sprintf("部门#%d", *departmentID)
+	}
+
+	r.departments[*departmentID] = name
+	return name
 }
 
-func getPositionName(positionID *uint) string {
+// positionName 获取职位名称，已删除的职位追加"(已删除)"后缀
+func (r *onboardingNameResolver) positionName(ctx context.Context, positionID *uint) string {
 	if positionID == nil {
 		return "未分配"
 	}
-	return fmt.Sprintf("职位%d", *positionID)
+	if name, ok := r.positions[*positionID]; ok {
+		return name
+	}
+
+	var name string
+	position, err := r.positionRepo.GetByIDUnscoped(ctx, *positionID)
+	switch {
+	case err == nil && position.DeletedAt.Valid:
+		name = position.Name + "(已删除)"
+	case err == nil:
+		name = position.Name
+	case repository.IsNotFoundError(err):
+		name = fmt.Sprintf("职位#%d(已删除)", *positionID)
+	default:
+		r.logger.WithError(err).WithField("position_id", *positionID).Warn("获取职位信息失败")
+		return fmt.Sprintf("职位#%d", *positionID)
+	}
+
+	r.positions[*positionID] = name
+	return name
+}
+
+// userName 获取用户姓名，未填写真实姓名时使用用户名
+func (r *onboardingNameResolver) userName(ctx context.Context, userID uint) string {
+	if userID == 0 {
+		return ""
+	}
+	if name, ok := r.users[userID]; ok {
+		return name
+	}
+
+	user, err := r.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		r.logger.WithError(err).WithField("user_id", userID).Warn("获取用户信息失败")
+		return ""
+	}
+
+	name := user.RealName
+	if name == "" {
+		name = user.Username
+	}
+	r.users[userID] = name
+	return name
+}
+
+// prime 用已预加载的员工部门、职位和流程发起人填充缓存，避免逐条查询
+func (r *onboardingNameResolver) prime(employee *database.Employee, requester *database.User) {
+	if employee.DepartmentID != nil && employee.Department.ID == *employee.DepartmentID {
+		name := employee.Department.Name
+		if employee.Department.DeletedAt.Valid {
+			name += "(已删除)"
+		}
+		r.departments[employee.Department.ID] = name
+	}
+	if employee.PositionID != nil && employee.Position.ID == *employee.PositionID {
+		name := employee.Position.Name
+		if employee.Position.DeletedAt.Valid {
+			name += "(已删除)"
+		}
+		r.positions[employee.Position.ID] = name
+	}
+	if requester != nil {
+		name := requester.RealName
+		if name == "" {
+			name = requester.Username
+		}
+		r.users[requester.ID] = name
+	}
 }
 
 // triggerPermissionAssignment 触发权限分配
 func (s *OnboardingServiceImpl) triggerPermissionAssignment(ctx context.Context, userID uint, onboardingStatus string, departmentID, positionID *uint) error {
 	if s.permissionAssignmentService == nil {
-		s.logger.Warn("权限分配服务未初始化")
+		logger.FromContext(ctx).Warn("权限分配服务未初始化")
 		return nil
 	}
 
@@ -111,6 +245,8 @@ type OnboardingApprovalResponse struct {
 	CreatedAt    time.Time          `json:"created_at"`
 	UpdatedAt    time.Time          `json:"updated_at"`
 	Employee     *database.Employee `json:"employee,omitempty"`
+	// AlreadyRunning 员工已有运行中的入职审批，本次提交未启动新流程，返回的是已在运行的流程
+	AlreadyRunning bool `json:"already_running"`
 }
 
 // PendingOnboardingApproval 待审批入职申请
@@ -122,9 +258,13 @@ type PendingOnboardingApproval struct {
 	Position      string    `json:"position"`
 	ExpectedDate  string    `json:"expected_date"`
 	CurrentStep   string    `json:"current_step"`
+	WorkflowType  string    `json:"workflow_type"` // 实例实际使用的流程类型 simple/full
 	SubmittedAt   time.Time `json:"submitted_at"`
 	RequesterName string    `json:"requester_name"`
 	Notes         string    `json:"notes"`
+
+	EmployeePhone        string `json:"employee_phone"`
+	ExpectedProbationEnd string `json:"expected_probation_end,omitempty"` // 预计试用期结束日期
 }
 
 // OnboardingApprovalHistory 入职审批历史
@@ -140,31 +280,80 @@ type OnboardingApprovalHistory struct {
 
 // OnboardingServiceImpl 入职工作流服务实现
 type OnboardingServiceImpl struct {
+	repoManager                 repository.RepositoryManager
 	employeeRepo                repository.EmployeeRepository
 	userRepo                    repository.UserRepository
+	departmentRepo              repository.DepartmentRepository
+	positionRepo                repository.PositionRepository
 	historyRepo                 repository.OnboardingHistoryRepository
 	workflowService             WorkflowService
 	permissionAssignmentService PermissionAssignmentService
+	activationService           ActivationService
+	activationTokenRepo         repository.AccountActivationTokenRepository
+	taskRepo                    repository.TaskRepository
+	notificationService         NotificationService
+	events                      EventPublisher          // 为nil时不发布入职状态变更事件
+	employeeNos                 EmployeeNumberGenerator // 分配员工编号，在创建事务内使用
+	probationReviewerRole       string                  // 接收转正评估提醒的HR角色
+	defaultWorkflowType         string                  // 部门未设置时使用的入职审批流程类型
+	workCalendar                WorkCalendarService     // 试用期按工作日计算时使用，为nil时按自然日
 	logger                      *logrus.Logger
 }
 
 // NewOnboardingService 创建入职工作流服务
-func NewOnboardingService(repoManager repository.RepositoryManager, workflowService WorkflowService, permissionAssignmentService PermissionAssignmentService, logger *logrus.Logger) OnboardingService {
+func NewOnboardingService(repoManager repository.RepositoryManager, workflowService WorkflowService, permissionAssignmentService PermissionAssignmentService, activationService ActivationService, notificationService NotificationService, events EventPublisher, employeeNos EmployeeNumberGenerator, probationReviewerRole, defaultWorkflowType string, workCalendar WorkCalendarService, logger *logrus.Logger) OnboardingService {
+	if probationReviewerRole == "" {
+		probationReviewerRole = DefaultProbationReviewerRole
+	}
 	return &OnboardingServiceImpl{
+		repoManager:                 repoManager,
 		employeeRepo:                repoManager.EmployeeRepository(),
 		userRepo:                    repoManager.UserRepository(),
+		departmentRepo:              repoManager.DepartmentRepository(),
+		positionRepo:                repoManager.PositionRepository(),
 		historyRepo:                 repoManager.OnboardingHistoryRepository(),
 		workflowService:             workflowService,
 		permissionAssignmentService: permissionAssignmentService,
+		activationService:           activationService,
+		activationTokenRepo:         repoManager.AccountActivationTokenRepository(),
+		taskRepo:                    repoManager.TaskRepository(),
+		notificationService:         notificationService,
+		events:                      events,
+		employeeNos:                 employeeNos,
+		probationReviewerRole:       probationReviewerRole,
+		defaultWorkflowType:         defaultWorkflowType,
+		workCalendar:                workCalendar,
 		logger:                      logger,
 	}
 }
 
-// CreatePendingEmployee 创建待入职员工
+// CreatePendingEmployee 创建待入职员工，用户账号和员工记录在同一事务中创建
 func (s *OnboardingServiceImpl) CreatePendingEmployee(ctx context.Context, req *CreatePendingEmployeeRequest) (*OnboardingWorkflowResponse, error) {
-	logger := s.logger.WithField("method", "CreatePendingEmployee")
+	var expectedDate *time.Time
+	if req.ExpectedDate != "" {
+		if parsed, err := time.Parse("2006-01-02", req.ExpectedDate); err == nil {
+			expectedDate = &parsed
+		}
+	}
+
+	employee, err := s.createPendingEmployee(ctx, req, expectedDate)
+	if err != nil {
+		return nil, err
+	}
+	return s.buildWorkflowResponse(employee), nil
+}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.DefaultCost)
+// createPendingEmployee 在事务中创建未激活的用户账号和待入职员工记录，提交后发送激活邮件
+// 请求未指定员工编号时由编号生成器分配，指定的编号已被使用时返回ErrEmployeeNoTaken
+func (s *OnboardingServiceImpl) createPendingEmployee(ctx context.Context, req *CreatePendingEmployeeRequest, expectedDate *time.Time) (*database.Employee, error) {
+	logger := logger.FromContext(ctx).WithField("method", "CreatePendingEmployee")
+
+	// 账号激活前使用随机密码占位，员工通过激活邮件设置真实密码
+	placeholder := make([]byte, 32)
+	if _, err := rand.Read(placeholder); err != nil {
+		return nil, fmt.Errorf("生成占位密码失败: %w", err)
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(hex.EncodeToString(placeholder)), bcrypt.DefaultCost)
 	if err != nil {
 		logger.Errorf("密码加密失败: %v", err)
 		return nil, fmt.Errorf("密码加密失败: %w", err)
@@ -179,61 +368,61 @@ func (s *OnboardingServiceImpl) CreatePendingEmployee(ctx context.Context, req *
 		PasswordHash: string(hashedPassword),
 		Status:       "inactive", // 账号暂时不激活
 		Role:         "employee",
-		// 注意：不设置Password和PasswordHash，等待用户激活时设置
-	}
-
-	if err := s.userRepo.Create(ctx, user); err != nil {
-		logger.Errorf("Failed to create user: %v", err)
-		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
+	var employee *database.Employee
 
-	// TODO: 生成账号激活令牌并发送激活邮件
-	// activationToken := generateActivationToken(user.ID)
-	// sendActivationEmail(user.Email, user.RealName, activationToken)
-	logger.Infof("用户账号已创建，待激活: %s (ID: %d)", user.Email, user.ID)
+	err = s.repoManager.WithTx(ctx, func(ctx context.Context, repos repository.RepositoryManager) error {
+		if err := repos.UserRepository().Create(ctx, user); err != nil {
+			logger.Errorf("Failed to create user: %v", err)
+			return fmt.Errorf("failed to create user: %w", err)
+		}
 
-	// 解析预期入职日期
-	var expectedDate *time.Time
-	if req.ExpectedDate != "" {
-		if parsed, err := time.Parse("2006-01-02", req.ExpectedDate); err == nil {
-			expectedDate = &parsed
+		// 编号在事务内分配，创建失败时计数器随事务回滚
+		employeeNo, err := s.employeeNos.WithRepos(repos).Assign(ctx, req.EmployeeNo, user.ID)
+		if err != nil {
+			return err
 		}
-	}
 
-	// 创建员工记录
-	employee := &database.Employee{
-		UserID:           user.ID,
-		EmployeeNo:       fmt.Sprintf("EMP%06d", user.ID),
-		DepartmentID:     req.DepartmentID,
-		PositionID:       req.PositionID,
-		OnboardingStatus: "pending_onboard",
-		ExpectedDate:     expectedDate,
-		OnboardingNotes:  req.Notes,
-		Status:           "available",
-		MaxTasks:         5,
-		CurrentTasks:     0,
+		// 创建员工记录
+		employee = &database.Employee{
+			UserID:           user.ID,
+			EmployeeNo:       employeeNo,
+			DepartmentID:     req.DepartmentID,
+			PositionID:       req.PositionID,
+			OnboardingStatus: "pending_onboard",
+			ExpectedDate:     expectedDate,
+			OnboardingNotes:  req.Notes,
+			Status:           "available",
+			MaxTasks:         5,
+			CurrentTasks:     0,
+		}
+		if err := repos.EmployeeRepository().Create(ctx, employee); err != nil {
+			logger.Errorf("Failed to create employee: %v", err)
+			return fmt.Errorf("failed to create employee: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	logger.Infof("用户账号已创建，待激活: %s (ID: %d)", user.Email, user.ID)
 
-	if err := s.employeeRepo.Create(ctx, employee); err != nil {
-		logger.Errorf("Failed to create employee: %v", err)
-		return nil, fmt.Errorf("failed to create employee: %w", err)
+	// 发送激活邮件失败不影响员工创建，可通过重新发送激活邮件补发
+	if err := s.activationService.SendActivation(ctx, user); err != nil {
+		logger.Warnf("发送激活邮件失败: user=%d, error=%v", user.ID, err)
 	}
 
 	logger.Infof("Created pending employee: %d", employee.ID)
-	return s.buildWorkflowResponse(employee), nil
+	return employee, nil
 }
 
 // ConfirmOnboarding 入职确认
 func (s *OnboardingServiceImpl) ConfirmOnboarding(ctx context.Context, req *OnboardConfirmRequest) (*OnboardingWorkflowResponse, error) {
-	logger := s.logger.WithField("method", "ConfirmOnboarding")
+	logger := logger.FromContext(ctx).WithField("method", "ConfirmOnboarding")
 
 	employee, err := s.employeeRepo.GetByID(ctx, req.EmployeeID)
 	if err != nil {
-		return nil, fmt.Errorf("employee not found: %w", err)
-	}
-
-	if employee.OnboardingStatus != "pending_onboard" {
-		return nil, fmt.Errorf("employee is not in pending_onboard status")
+		return nil, employeeLookupError(err)
 	}
 
 	// 解析入职日期
@@ -244,74 +433,61 @@ func (s *OnboardingServiceImpl) ConfirmOnboarding(ctx context.Context, req *Onbo
 		}
 	}
 
-	// 更新员工信息
-	oldStatus := employee.OnboardingStatus
-	employee.OnboardingStatus = "onboarding"
-	employee.DepartmentID = &req.DepartmentID
-	employee.PositionID = &req.PositionID
-	employee.DirectManagerID = req.ManagerID
-	employee.HireDate = startDate
-	employee.OnboardingNotes = req.Notes
-
-	if err := s.employeeRepo.Update(ctx, employee); err != nil {
+	err = s.TransitionStatus(ctx, employee, OnboardingTransition{
+		To:         EmployeeStatusOnboarding,
+		OperatorID: req.EmployeeID,
+		Reason:     "确认入职",
+		Notes:      req.Notes,
+		Apply: func(employee *database.Employee) {
+			employee.DepartmentID = &req.DepartmentID
+			employee.PositionID = &req.PositionID
+			employee.DirectManagerID = req.ManagerID
+			employee.HireDate = startDate
+			employee.OnboardingNotes = req.Notes
+		},
+	})
+	if err != nil {
 		logger.Errorf("Failed to update employee: %v", err)
-		return nil, fmt.Errorf("failed to update employee: %w", err)
+		return nil, err
 	}
 
-	// 激活用户账号
-	user, err := s.userRepo.GetByID(ctx, employee.UserID)
-	if err == nil {
-		user.Status = "active"
-		s.userRepo.Update(ctx, user)
+	// 员工已通过激活邮件设置密码时激活用户账号，否则在完成激活时激活
+	activated, err := s.activationTokenRepo.HasUsedByUserID(ctx, employee.UserID)
+	if err != nil {
+		logger.Warnf("查询账号激活记录失败: %v", err)
+	} else if activated {
+		user, err := s.userRepo.GetByID(ctx, employee.UserID)
+		if err == nil {
+			user.Status = "active"
+			s.userRepo.Update(ctx, user)
+		}
 	}
 
-	// 记录状态变更历史
-	s.recordStatusChange(ctx, employee.ID, oldStatus, employee.OnboardingStatus, req.EmployeeID, req.Notes)
-
-	//触发权限分配
-	//if err := s.triggerPermissionAssignment(ctx, employee.UserID, employee.OnboardingStatus, employee.DepartmentID, employee.PositionID); err != nil {
-	//logger.Warnf("权限分配失败: %v", err)
-	// 权限分配失败不影响入职流程继续
-	//}
-
 	logger.Infof("Employee onboarding confirmed: %d", employee.ID)
 	return s.buildWorkflowResponse(employee), nil
 }
 
 // CompleteProbation 完成入职手续，进入试用期
 func (s *OnboardingServiceImpl) CompleteProbation(ctx context.Context, employeeID uint, operatorID uint) (*OnboardingWorkflowResponse, error) {
-	logger := s.logger.WithField("method", "CompleteProbation")
+	logger := logger.FromContext(ctx).WithField("method", "CompleteProbation")
 
 	employee, err := s.employeeRepo.GetByID(ctx, employeeID)
 	if err != nil {
-		return nil, fmt.Errorf("employee not found: %w", err)
+		return nil, employeeLookupError(err)
 	}
 
-	if employee.OnboardingStatus != "onboarding" {
-		return nil, fmt.Errorf("employee is not in onboarding status")
-	}
-
-	// 更新状态为试用期
-	oldStatus := employee.OnboardingStatus
-	employee.OnboardingStatus = "probation"
-
-	// 设置试用期结束日期（默认3个月）
-	if employee.HireDate != nil {
-		probationEnd := employee.HireDate.AddDate(0, 3, 0)
-		employee.ProbationEndDate = &probationEnd
-	}
-
-	if err := s.employeeRepo.Update(ctx, employee); err != nil {
+	err = s.TransitionStatus(ctx, employee, OnboardingTransition{
+		To:         EmployeeStatusProbation,
+		OperatorID: operatorID,
+		Reason:     "完成入职手续，进入试用期",
+		Apply: func(employee *database.Employee) {
+			// 按入职审批时确定的试用期天数设置试用期结束日期
+			employee.ProbationEndDate = probationEndDate(employee, s.probationCalendar(ctx))
+		},
+	})
+	if err != nil {
 		logger.Errorf("Failed to update employee: %v", err)
-		return nil, fmt.Errorf("failed to update employee: %w", err)
-	}
-
-	// 记录状态变更历史
-	s.recordStatusChange(ctx, employee.ID, oldStatus, employee.OnboardingStatus, operatorID, "完成入职手续，进入试用期")
-
-	// 触发权限分配
-	if err := s.triggerPermissionAssignment(ctx, employee.UserID, employee.OnboardingStatus, employee.DepartmentID, employee.PositionID); err != nil {
-		logger.Warnf("权限分配失败: %v", err)
+		return nil, err
 	}
 
 	logger.Infof("Employee entered probation: %d", employee.ID)
@@ -320,50 +496,38 @@ func (s *OnboardingServiceImpl) CompleteProbation(ctx context.Context, employeeI
 
 // ConfirmEmployee 试用期转正
 func (s *OnboardingServiceImpl) ConfirmEmployee(ctx context.Context, req *ProbationToActiveRequest, operatorID uint) (*OnboardingWorkflowResponse, error) {
-	logger := s.logger.WithField("method", "ConfirmEmployee")
+	logger := logger.FromContext(ctx).WithField("method", "ConfirmEmployee")
 
 	employee, err := s.employeeRepo.GetByID(ctx, req.EmployeeID)
 	if err != nil {
-		return nil, fmt.Errorf("employee not found: %w", err)
+		return nil, employeeLookupError(err)
 	}
 
-	if employee.OnboardingStatus != "probation" {
-		return nil, fmt.Errorf("employee is not in probation status")
-	}
-
-	oldStatus := employee.OnboardingStatus
-
-	if req.IsApproved {
-		// 转正成功
-		employee.OnboardingStatus = "active"
-
-		// 设置转正日期
-		if req.EffectiveDate != "" {
-			if parsed, err := time.Parse("2006-01-02", req.EffectiveDate); err == nil {
-				employee.ConfirmDate = &parsed
+	transition := OnboardingTransition{
+		To:         EmployeeStatusActive,
+		OperatorID: operatorID,
+		Reason:     "试用期转正成功",
+		Notes:      req.EvaluationNote,
+		Apply: func(employee *database.Employee) {
+			// 设置转正日期
+			if req.EffectiveDate != "" {
+				if parsed, err := time.Parse("2006-01-02", req.EffectiveDate); err == nil {
+					employee.ConfirmDate = &parsed
+				}
 			}
-		}
-	} else {
-		// 试用期不通过，设置为离职
-		employee.OnboardingStatus = "inactive"
-		employee.Status = "resigned"
-	}
-
-	if err := s.employeeRepo.Update(ctx, employee); err != nil {
-		logger.Errorf("Failed to update employee: %v", err)
-		return nil, fmt.Errorf("failed to update employee: %w", err)
+		},
 	}
-
-	// 记录状态变更历史
-	reason := "试用期转正成功"
 	if !req.IsApproved {
-		reason = "试用期考核不通过"
+		// 试用期不通过，设置为离职
+		transition.To = EmployeeStatusInactive
+		transition.Reason = "试用期考核不通过"
+		transition.Apply = func(employee *database.Employee) {
+			employee.Status = "resigned"
+		}
 	}
-	s.recordStatusChange(ctx, employee.ID, oldStatus, employee.OnboardingStatus, operatorID, reason+": "+req.EvaluationNote)
-
-	// 触发权限分配
-	if err := s.triggerPermissionAssignment(ctx, employee.UserID, employee.OnboardingStatus, employee.DepartmentID, employee.PositionID); err != nil {
-		logger.Warnf("权限分配失败: %v", err)
+	if err := s.TransitionStatus(ctx, employee, transition); err != nil {
+		logger.Errorf("Failed to update employee: %v", err)
+		return nil, err
 	}
 
 	logger.Infof("Employee probation completed: %d, approved: %v", employee.ID, req.IsApproved)
@@ -372,49 +536,83 @@ func (s *OnboardingServiceImpl) ConfirmEmployee(ctx context.Context, req *Probat
 
 // ChangeEmployeeStatus 员工状态变更
 func (s *OnboardingServiceImpl) ChangeEmployeeStatus(ctx context.Context, req *EmployeeStatusChangeRequest, operatorID uint) (*OnboardingWorkflowResponse, error) {
-	logger := s.logger.WithField("method", "ChangeEmployeeStatus")
+	logger := logger.FromContext(ctx).WithField("method", "ChangeEmployeeStatus")
 
 	employee, err := s.employeeRepo.GetByID(ctx, req.EmployeeID)
 	if err != nil {
-		return nil, fmt.Errorf("employee not found: %w", err)
+		return nil, employeeLookupError(err)
 	}
 
 	oldStatus := employee.OnboardingStatus
-	employee.OnboardingStatus = req.NewStatus
-
-	if err := s.employeeRepo.Update(ctx, employee); err != nil {
+	err = s.TransitionStatus(ctx, employee, OnboardingTransition{
+		To:         req.NewStatus,
+		OperatorID: operatorID,
+		Reason:     req.Reason,
+		Notes:      req.Notes,
+	})
+	if err != nil {
 		logger.Errorf("Failed to update employee: %v", err)
-		return nil, fmt.Errorf("failed to update employee: %w", err)
+		return nil, err
 	}
 
-	// 记录状态变更历史
-	s.recordStatusChange(ctx, employee.ID, oldStatus, employee.OnboardingStatus, operatorID, req.Reason+": "+req.Notes)
-
 	logger.Infof("Employee status changed: %d, %s -> %s", employee.ID, oldStatus, req.NewStatus)
 	return s.buildWorkflowResponse(employee), nil
 }
 
 // GetOnboardingWorkflows 获取入职工作流列表
-func (s *OnboardingServiceImpl) GetOnboardingWorkflows(ctx context.Context, filter *OnboardingWorkflowFilter) ([]*OnboardingWorkflowResponse, error) {
-	// 简化实现，直接获取所有员工
-	employees, _, err := s.employeeRepo.List(ctx, repository.ListFilter{
-		Page:     filter.Page,
-		PageSize: filter.PageSize,
-	})
+func (s *OnboardingServiceImpl) GetOnboardingWorkflows(ctx context.Context, filter *OnboardingWorkflowFilter) (*ListResponse[*OnboardingWorkflowResponse], error) {
+	repoFilter := &repository.OnboardingWorkflowFilter{
+		Page:       filter.Page,
+		PageSize:   filter.PageSize,
+		Status:     filter.Status,
+		Department: filter.Department,
+	}
+	if repoFilter.Page < 1 {
+		repoFilter.Page = 1
+	}
+	if repoFilter.PageSize <= 0 {
+		repoFilter.PageSize = 10
+	}
+	if repoFilter.PageSize > 100 {
+		repoFilter.PageSize = 100
+	}
+
+	// 日期按天过滤，date_to当天包含在内
+	if filter.DateFrom != "" {
+		from, err := time.ParseInLocation("2006-01-02", filter.DateFrom, time.Local)
+		if err != nil {
+			return nil, response.Wrapf(ErrInvalidOnboardingFilter, "date_from格式应为YYYY-MM-DD")
+		}
+		repoFilter.ExpectedFrom = &from
+	}
+	if filter.DateTo != "" {
+		to, err := time.ParseInLocation("2006-01-02", filter.DateTo, time.Local)
+		if err != nil {
+			return nil, response.Wrapf(ErrInvalidOnboardingFilter, "date_to格式应为YYYY-MM-DD")
+		}
+		to = to.AddDate(0, 0, 1)
+		repoFilter.ExpectedTo = &to
+	}
+	if repoFilter.ExpectedFrom != nil && repoFilter.ExpectedTo != nil && !repoFilter.ExpectedFrom.Before(*repoFilter.ExpectedTo) {
+		return nil, response.Wrapf(ErrInvalidOnboardingFilter, "date_from不能晚于date_to")
+	}
+
+	employees, total, err := s.employeeRepo.ListOnboarding(ctx, repoFilter)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get employees: %w", err)
 	}
 
-	var workflows []*OnboardingWorkflowResponse
+	workflows := make([]*OnboardingWorkflowResponse, 0, len(employees))
 	for _, employee := range employees {
-		// 根据过滤条件筛选
-		if filter.Status != "" && employee.OnboardingStatus != filter.Status {
-			continue
-		}
 		workflows = append(workflows, s.buildWorkflowResponse(employee))
 	}
 
-	return workflows, nil
+	return &ListResponse[*OnboardingWorkflowResponse]{
+		Items: workflows,
+		Total: total,
+		Page:  repoFilter.Page,
+		Size:  repoFilter.PageSize,
+	}, nil
 }
 
 // GetOnboardingHistory 获取入职历史记录
@@ -444,7 +642,7 @@ func (s *OnboardingServiceImpl) GetOnboardingHistory(ctx context.Context, employ
 
 // CompleteOnboardingApproval 完成入职审批流程
 func (s *OnboardingServiceImpl) CompleteOnboardingApproval(ctx context.Context, instanceID string, approved bool, employeeID uint, approverID uint) error {
-	logger := s.logger.WithField("method", "CompleteOnboardingApproval")
+	logger := logger.FromContext(ctx).WithField("method", "CompleteOnboardingApproval")
 	logger.Infof("完成入职审批: InstanceID=%s, Approved=%t, EmployeeID=%d", instanceID, approved, employeeID)
 
 	// 获取员工信息
@@ -455,50 +653,76 @@ func (s *OnboardingServiceImpl) CompleteOnboardingApproval(ctx context.Context,
 	}
 
 	// 根据审批结果更新员工状态
-	if approved {
-		employee.OnboardingStatus = "approved"
-		logger.Infof("员工 %d 入职审批通过", employeeID)
-	} else {
-		employee.OnboardingStatus = "rejected"
-		logger.Infof("员工 %d 入职审批被拒绝", employeeID)
+	transition := OnboardingTransition{
+		To:         EmployeeStatusApproved,
+		OperatorID: approverID,
+		Reason:     "审批完成: 通过",
+		Notes:      fmt.Sprintf("工作流实例ID: %s", instanceID),
 	}
-
-	// 更新员工状态
-	if err := s.employeeRepo.Update(ctx, employee); err != nil {
+	if !approved {
+		transition.To = EmployeeStatusRejected
+		transition.Reason = "审批完成: 拒绝"
+	}
+	if err := s.TransitionStatus(ctx, employee, transition); err != nil {
 		logger.WithError(err).Error("更新员工状态失败")
 		return err
 	}
 
-	// 创建入职历史记录
-	history := &database.OnboardingHistory{
-		EmployeeID: employeeID,
-		FromStatus: "approval_pending",
-		ToStatus:   employee.OnboardingStatus,
-		OperatorID: approverID,
-		Reason:     fmt.Sprintf("审批完成: %s", map[bool]string{true: "通过", false: "拒绝"}[approved]),
-		Notes:      fmt.Sprintf("工作流实例ID: %s", instanceID),
+	logger.Infof("入职审批完成处理成功: EmployeeID=%d, Status=%s", employeeID, employee.OnboardingStatus)
+	return nil
+}
+
+// RegisterCompletionHandlers 登记入职审批流程结束后的业务回调
+func (s *OnboardingServiceImpl) RegisterCompletionHandlers(registry *workflow.CompletionHandlerRegistry) {
+	registry.Register("onboarding", func(ctx context.Context, completion *workflow.BusinessCompletion) error {
+		employeeID, _ := completion.Instance.GetUintVar("employee_id")
+		if employeeID == 0 {
+			return fmt.Errorf("流程实例缺少员工ID: %s", completion.Instance.ID)
+		}
+		if completion.Expired {
+			return s.expireOnboardingApproval(ctx, completion.Instance.ID, employeeID)
+		}
+		return s.CompleteOnboardingApproval(ctx, completion.Instance.ID, completion.Approved, employeeID, completion.ApproverID)
+	})
+}
+
+// expireOnboardingApproval 入职审批超时过期后将员工恢复为待入职，HR可重新发起审批
+func (s *OnboardingServiceImpl) expireOnboardingApproval(ctx context.Context, instanceID string, employeeID uint) error {
+	employee, err := s.employeeRepo.GetByID(ctx, employeeID)
+	if err != nil {
+		return fmt.Errorf("获取员工信息失败: %w", err)
+	}
+	if employee.OnboardingStatus != EmployeeStatusApprovalPending {
+		// 员工状态已被其他操作改变，不再回退
+		return nil
 	}
 
-	// 注意：这里暂时跳过历史记录创建，因为repository可能未实现
-	// 可以在后续完善时添加
-	_ = history
+	err = s.TransitionStatus(ctx, employee, OnboardingTransition{
+		To:     EmployeeStatusPendingOnboard,
+		Reason: "入职审批超时过期",
+		Notes:  fmt.Sprintf("工作流实例ID: %s", instanceID),
+	})
+	if err != nil {
+		return err
+	}
 
-	logger.Infof("入职审批完成处理成功: EmployeeID=%d, Status=%s", employeeID, employee.OnboardingStatus)
+	logger.FromContext(ctx).Infof("入职审批过期，员工 %d 已恢复为待入职", employeeID)
 	return nil
 }
 
 // recordStatusChange 记录状态变更历史
-func (s *OnboardingServiceImpl) recordStatusChange(ctx context.Context, employeeID uint, fromStatus, toStatus string, operatorID uint, notes string) {
+func (s *OnboardingServiceImpl) recordStatusChange(ctx context.Context, employeeID uint, fromStatus, toStatus string, operatorID uint, reason, notes string) {
 	history := &database.OnboardingHistory{
 		EmployeeID: employeeID,
 		FromStatus: fromStatus,
 		ToStatus:   toStatus,
 		OperatorID: operatorID,
+		Reason:     reason,
 		Notes:      notes,
 	}
 
 	if err := s.historyRepo.Create(ctx, history); err != nil {
-		s.logger.Errorf("Failed to record onboarding history: %v", err)
+		logger.FromContext(ctx).Errorf("Failed to record onboarding history: %v", err)
 	}
 }
 
@@ -537,7 +761,11 @@ func (s *OnboardingServiceImpl) buildWorkflowResponse(employee *database.Employe
 
 // StartOnboardingApproval 启动入职审批流程
 func (s *OnboardingServiceImpl) StartOnboardingApproval(ctx context.Context, req *OnboardingApprovalRequest) (*OnboardingApprovalResponse, error) {
-	logger := s.logger.WithField("method", "StartOnboardingApproval")
+	logger := logger.FromContext(ctx).WithField("method", "StartOnboardingApproval")
+
+	if err := validateProbationDays(req.ProbationDays); err != nil {
+		return nil, err
+	}
 
 	// 验证员工是否存在
 	employee, err := s.employeeRepo.GetByID(ctx, req.EmployeeID)
@@ -545,6 +773,18 @@ func (s *OnboardingServiceImpl) StartOnboardingApproval(ctx context.Context, req
 		logger.WithError(err).Error("获取员工信息失败")
 		return nil, err
 	}
+	// 已在审批中的员工交由流程引擎判断是否重复提交
+	resubmit := employee.OnboardingStatus == EmployeeStatusApprovalPending
+	if !resubmit {
+		if err := checkOnboardingTransition(employee.OnboardingStatus, EmployeeStatusApprovalPending); err != nil {
+			return nil, err
+		}
+	}
+
+	workflowType, departmentManagerID, err := s.onboardingWorkflow(ctx, req.WorkflowType, req.DepartmentID)
+	if err != nil {
+		return nil, err
+	}
 
 	// 启动入职审批工作流
 	workflowReq := &workflow.OnboardingApprovalRequest{
@@ -556,32 +796,57 @@ func (s *OnboardingServiceImpl) StartOnboardingApproval(ctx context.Context, req
 		ProbationPeriod: req.ProbationDays, // 使用ProbationDays字段
 		Priority:        "normal",
 		RequesterID:     req.RequesterID,
+		WorkflowType:    workflowType,
+		// 完整流程的部门负责人审批人
+		DepartmentManagerID: departmentManagerID,
 	}
 
 	instance, err := s.workflowService.StartOnboardingApproval(ctx, workflowReq)
+	var running *workflow.RunningInstanceError
+	if errors.As(err, &running) {
+		// 重复提交不再修改员工状态和记录历史，直接返回已在运行的流程
+		logger.WithField("instance_id", running.Instance.ID).Info("员工已有运行中的入职审批流程")
+		return &OnboardingApprovalResponse{
+			InstanceID:     running.Instance.ID,
+			EmployeeID:     req.EmployeeID,
+			Status:         string(running.Instance.Status),
+			CurrentStep:    getCurrentNode(running.Instance.CurrentNodes),
+			WorkflowType:   workflow.OnboardingWorkflowType(running.Instance),
+			CreatedAt:      running.Instance.StartedAt,
+			UpdatedAt:      running.Instance.StartedAt,
+			Employee:       employee,
+			AlreadyRunning: true,
+		}, nil
+	}
 	if err != nil {
 		logger.WithError(err).Error("启动入职审批工作流失败")
 		return nil, err
 	}
 
-	// 更新员工状态为审批中
-	oldStatus := employee.OnboardingStatus
-	employee.OnboardingStatus = "approval_pending"
-	if err := s.employeeRepo.Update(ctx, employee); err != nil {
+	// 更新员工状态为审批中，记录试用期天数供进入试用期时计算结束日期
+	if resubmit {
+		employee.ProbationDays = req.ProbationDays
+		err = s.employeeRepo.Update(ctx, employee)
+	} else {
+		err = s.TransitionStatus(ctx, employee, OnboardingTransition{
+			To:         EmployeeStatusApprovalPending,
+			OperatorID: req.RequesterID,
+			Reason:     "启动入职审批流程",
+			Notes:      req.Notes,
+			Apply: func(employee *database.Employee) {
+				employee.ProbationDays = req.ProbationDays
+			},
+		})
+	}
+	if err != nil {
 		logger.WithError(err).Error("更新员工状态失败")
 	}
 
-	// 记录历史
-	history := &database.OnboardingHistory{
-		EmployeeID: req.EmployeeID,
-		FromStatus: oldStatus,
-		ToStatus:   "approval_pending",
-		OperatorID: req.RequesterID,
-		Reason:     "启动入职审批流程",
-		Notes:      req.Notes,
-	}
-	if err := s.historyRepo.Create(ctx, history); err != nil {
-		logger.WithError(err).Error("记录入职历史失败")
+	// 审批节点全部自动通过时，员工进入审批中后立即按审批通过处理
+	if runAutoApprovedCompletion(ctx, s.workflowService, instance) {
+		if updated, err := s.employeeRepo.GetByID(ctx, employee.ID); err == nil {
+			employee = updated
+		}
 	}
 
 	return &OnboardingApprovalResponse{
@@ -589,7 +854,7 @@ func (s *OnboardingServiceImpl) StartOnboardingApproval(ctx context.Context, req
 		EmployeeID:   req.EmployeeID,
 		Status:       string(instance.Status),
 		CurrentStep:  getCurrentNode(instance.CurrentNodes),
-		WorkflowType: req.WorkflowType,
+		WorkflowType: workflowType,
 		CreatedAt:    instance.StartedAt,
 		UpdatedAt:    instance.StartedAt, // Use StartedAt as UpdatedAt for now
 		Employee:     employee,
@@ -598,7 +863,7 @@ func (s *OnboardingServiceImpl) StartOnboardingApproval(ctx context.Context, req
 
 // ProcessOnboardingApproval 处理入职审批决策
 func (s *OnboardingServiceImpl) ProcessOnboardingApproval(ctx context.Context, req *ProcessOnboardingApprovalRequest) (*OnboardingApprovalResponse, error) {
-	logger := s.logger.WithField("method", "ProcessOnboardingApproval")
+	logger := logger.FromContext(ctx).WithField("method", "ProcessOnboardingApproval")
 
 	// 获取工作流实例
 	instance, err := s.workflowService.GetWorkflowInstance(ctx, req.InstanceID)
@@ -608,8 +873,8 @@ func (s *OnboardingServiceImpl) ProcessOnboardingApproval(ctx context.Context, r
 	}
 
 	// 从工作流实例的变量中获取员工ID
-	employeeID, ok := instance.Variables["employee_id"].(float64)
-	if !ok {
+	employeeID, ok := instance.GetUintVar("employee_id")
+	if !ok || employeeID == 0 {
 		logger.Error("无法从工作流实例中获取员工ID")
 		return nil, fmt.Errorf("无效的工作流实例数据")
 	}
@@ -629,56 +894,22 @@ func (s *OnboardingServiceImpl) ProcessOnboardingApproval(ctx context.Context, r
 		return nil, fmt.Errorf("处理工作流审批失败: %w", err)
 	}
 
-	// 根据工作流处理结果更新员工状态
-	var newStatus string
-	var reason string
-
-	if req.Action == "approve" {
-		// 审批通过后，根据工作流是否完成决定员工状态
-		if result.IsCompleted {
-			newStatus = "approved" // 整个工作流完成，员工正式入职
-			reason = "入职工作流完成，员工正式入职"
-		} else {
-			newStatus = "in_progress" // 工作流继续，员工状态为处理中
-			reason = "管理员审批通过，进入下一流程环节"
-		}
-	} else {
-		newStatus = "rejected" // 审批拒绝，直接拒绝入职
-		reason = fmt.Sprintf("入职审批被拒绝: %s", req.Action)
-	}
-
-	// 更新员工状态
-	if err := s.employeeRepo.UpdateStatus(ctx, uint(employeeID), newStatus); err != nil {
-		logger.WithError(err).Error("更新员工状态失败")
-		return nil, fmt.Errorf("更新员工状态失败: %w", err)
-	}
-
-	// 记录状态变更历史
-	history := &database.OnboardingHistory{
-		EmployeeID: uint(employeeID),
-		FromStatus: "approval_pending",
-		ToStatus:   newStatus,
-		OperatorID: req.ApproverID,
-		Reason:     reason,
-		Notes:      req.Comment,
-	}
-	if err := s.historyRepo.Create(ctx, history); err != nil {
-		logger.WithError(err).Error("记录入职历史失败")
-	}
-
-	// 获取员工信息
+	// 流程结束时员工状态已由业务回调按状态机变更，这里只读取最新状态
+	status := EmployeeStatusApprovalPending
 	employee, err := s.employeeRepo.GetByID(ctx, uint(employeeID))
 	if err != nil {
 		logger.WithError(err).Error("获取员工信息失败")
 		employee = nil
+	} else {
+		status = employee.OnboardingStatus
 	}
 
 	return &OnboardingApprovalResponse{
 		InstanceID:   req.InstanceID,
 		EmployeeID:   uint(employeeID),
-		Status:       newStatus,
+		Status:       status,
 		CurrentStep:  result.NodeID,
-		WorkflowType: "onboarding",
+		WorkflowType: workflow.OnboardingWorkflowType(instance),
 		CreatedAt:    instance.StartedAt,
 		UpdatedAt:    result.ExecutedAt,
 		Employee:     employee,
@@ -687,47 +918,37 @@ func (s *OnboardingServiceImpl) ProcessOnboardingApproval(ctx context.Context, r
 
 // GetPendingOnboardingApprovals 获取待审批的入职申请
 func (s *OnboardingServiceImpl) GetPendingOnboardingApprovals(ctx context.Context, userID uint) ([]*PendingOnboardingApproval, error) {
-	logger := s.logger.WithField("method", "GetPendingOnboardingApprovals")
+	logger := logger.FromContext(ctx).WithField("method", "GetPendingOnboardingApprovals")
 
-	// 使用现有的待审批查询方法
-	approvals, err := s.workflowService.GetPendingApprovals(ctx, userID)
+	// 只查询入职流程的待审批记录，不分页；流程实例、发起人和员工（含用户、部门、职位）批量加载
+	items, err := s.repoManager.WorkflowInstanceRepository().GetPendingApprovalsWithEmployee(ctx, &repository.PendingApprovalFilter{
+		UserID:       userID,
+		BusinessType: "onboarding",
+	})
 	if err != nil {
 		logger.WithError(err).Error("获取待审批工作流失败")
 		return nil, err
 	}
 
-	var result []*PendingOnboardingApproval
-	for _, approval := range approvals {
-		// 检查是否为入职相关的审批（通过业务类型标识）
-		if approval.BusinessType != "onboarding" {
-			continue
-		}
-
-		// 从BusinessID中解析员工ID
-		var employeeID uint
-		if _, err := fmt.Sscanf(approval.BusinessID, "employee-%d", &employeeID); err != nil {
-			continue
-		}
+	names := s.newNameResolver(logger)
 
-		// 获取员工信息
-		employee, err := s.employeeRepo.GetByID(ctx, employeeID)
-		if err != nil {
-			logger.WithError(err).WithField("employee_id", employeeID).Error("获取员工信息失败")
+	var result []*PendingOnboardingApproval
+	for _, item := range items {
+		approval, employee := item.Approval, item.Employee
+		if employee == nil {
+			logger.WithField("business_id", approval.BusinessID).Warn("入职审批对应的员工不存在")
 			continue
 		}
-
-		// 获取用户信息
-		user, err := s.userRepo.GetByID(ctx, employee.UserID)
-		if err != nil {
-			logger.WithError(err).WithField("user_id", employee.UserID).Error("获取用户信息失败")
+		if employee.User.ID == 0 {
+			logger.WithField("user_id", employee.UserID).Error("获取用户信息失败")
 			continue
 		}
-		employee.User = *user
 
 		// 只包含状态为approval_pending的员工
 		if employee.OnboardingStatus != "approval_pending" {
 			continue
 		}
+		names.prime(employee, item.Requester)
 
 		// 转换日期格式
 		expectedDateStr := ""
@@ -735,26 +956,64 @@ func (s *OnboardingServiceImpl) GetPendingOnboardingApprovals(ctx context.Contex
 			expectedDateStr = employee.ExpectedDate.Format("2006-01-02")
 		}
 
+		// 发起人、流程类型与试用期信息取自流程实例
+		var requesterName, workflowType string
+		probationEnd := employee.ProbationEndDate
+		if item.Instance == nil {
+			logger.WithField("instance_id", approval.InstanceID).Warn("获取工作流实例失败")
+		} else {
+			requesterName = names.userName(ctx, item.Instance.StartedBy)
+			if instance, err := convertToWorkflowInstance(item.Instance); err == nil {
+				workflowType = workflow.OnboardingWorkflowType(instance)
+				if probationEnd == nil {
+					probationEnd = expectedProbationEnd(employee.ExpectedDate, instance, s.probationCalendar(ctx))
+				}
+			}
+		}
+
+		probationEndStr := ""
+		if probationEnd != nil {
+			probationEndStr = probationEnd.Format("2006-01-02")
+		}
+
 		result = append(result, &PendingOnboardingApproval{
-			InstanceID:    approval.InstanceID,
-			EmployeeID:    employeeID,
-			EmployeeName:  employee.User.RealName,
-			Department:    getDepartmentName(employee.DepartmentID),
-			Position:      getPositionName(employee.PositionID),
-			ExpectedDate:  expectedDateStr,
-			CurrentStep:   approval.NodeName,
-			SubmittedAt:   approval.CreatedAt,
-			RequesterName: "", // 需要从工作流变量中获取
-			Notes:         fmt.Sprintf("业务ID: %s", approval.BusinessID),
+			InstanceID:           approval.InstanceID,
+			EmployeeID:           employee.ID,
+			EmployeeName:         employee.User.RealName,
+			Department:           names.departmentName(ctx, employee.DepartmentID),
+			Position:             names.positionName(ctx, employee.PositionID),
+			ExpectedDate:         expectedDateStr,
+			CurrentStep:          approval.NodeName,
+			WorkflowType:         workflowType,
+			SubmittedAt:          approval.CreatedAt,
+			RequesterName:        requesterName,
+			Notes:                fmt.Sprintf("业务ID: %s", approval.BusinessID),
+			EmployeePhone:        employee.User.Phone,
+			ExpectedProbationEnd: probationEndStr,
 		})
 	}
 
 	return result, nil
 }
 
+// expectedProbationEnd 根据预期入职日期和试用期天数推算试用期结束日期，calendar不为nil时试用期天数按工作日计算
+func expectedProbationEnd(expectedDate *time.Time, instance *workflow.WorkflowInstance, calendar *workcalendar.Calendar) *time.Time {
+	if expectedDate == nil {
+		return nil
+	}
+
+	days, ok := instance.GetUintVar("probation_period")
+	if !ok || days == 0 {
+		return nil
+	}
+
+	end := calendar.AddWorkingDays(*expectedDate, int(days))
+	return &end
+}
+
 // GetOnboardingApprovalHistory 获取入职审批历史
 func (s *OnboardingServiceImpl) GetOnboardingApprovalHistory(ctx context.Context, employeeID uint) ([]*OnboardingApprovalHistory, error) {
-	logger := s.logger.WithField("method", "GetOnboardingApprovalHistory")
+	logger := logger.FromContext(ctx).WithField("method", "GetOnboardingApprovalHistory")
 
 	// 获取员工的所有工作流实例
 	// 这里需要扩展工作流服务来支持按员工ID查询
@@ -765,7 +1024,7 @@ func (s *OnboardingServiceImpl) GetOnboardingApprovalHistory(ctx context.Context
 
 // CancelOnboardingApproval 取消入职审批流程
 func (s *OnboardingServiceImpl) CancelOnboardingApproval(ctx context.Context, instanceID string, reason string, operatorID uint) error {
-	logger := s.logger.WithField("method", "CancelOnboardingApproval")
+	logger := logger.FromContext(ctx).WithField("method", "CancelOnboardingApproval")
 
 	// 暂时简化实现，直接返回成功
 	// 实际实现需要根据工作流服务的具体API来处理
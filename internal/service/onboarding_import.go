@@ -0,0 +1,384 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"taskmanage/internal/repository"
+	"taskmanage/pkg/logger"
+	"taskmanage/pkg/report"
+	"taskmanage/pkg/response"
+)
+
+// MaxEmployeeImportRows 单次批量导入的最大数据行数
+const MaxEmployeeImportRows = 500
+
+// 批量导入行状态
+const (
+	EmployeeImportRowValid   = "valid"   // 预演时校验通过
+	EmployeeImportRowInvalid = "invalid" // 校验未通过，未创建
+	EmployeeImportRowCreated = "created" // 已创建
+	EmployeeImportRowFailed  = "failed"  // 校验通过但创建失败
+)
+
+// employeeImportColumns 导入文件的列，前四列必填
+var employeeImportColumns = []string{"real_name", "email", "phone", "expected_date", "department_code", "position_code", "notes", "employee_no"}
+
+// employeeImportRequiredColumns 导入文件必须包含的列
+var employeeImportRequiredColumns = employeeImportColumns[:4]
+
+// ErrInvalidEmployeeImport 导入文件无法解析、缺少必填列或超过行数上限
+var ErrInvalidEmployeeImport = response.NewError(response.ErrCodeInvalidRequest, "员工导入文件不合法")
+
+// EmployeeImportRow 导入文件单行的处理结果
+type EmployeeImportRow struct {
+	Row        int      `json:"row"` // 文件中的行号，表头为第1行
+	RealName   string   `json:"real_name"`
+	Email      string   `json:"email"`
+	Status     string   `json:"status"` // valid, invalid, created, failed
+	EmployeeID uint     `json:"employee_id,omitempty"`
+	EmployeeNo string   `json:"employee_no,omitempty"`
+	Errors     []string `json:"errors,omitempty"`
+}
+
+// EmployeeImportReport 批量导入结果
+type EmployeeImportReport struct {
+	DryRun  bool                 `json:"dry_run"`
+	Total   int                  `json:"total"`
+	Valid   int                  `json:"valid"`   // 校验通过的行数
+	Invalid int                  `json:"invalid"` // 校验未通过的行数
+	Created int                  `json:"created"`
+	Failed  int                  `json:"failed"`
+	Rows    []*EmployeeImportRow `json:"rows"`
+}
+
+// employeeImportRecord 校验通过的导入行
+type employeeImportRecord struct {
+	result       *EmployeeImportRow
+	request      *CreatePendingEmployeeRequest
+	expectedDate time.Time
+}
+
+// ImportPendingEmployees 从CSV批量创建待入职员工
+// 逐行校验后，每个校验通过的行在独立事务中创建用户和员工，单行失败不影响其它行；dryRun为true时只校验
+func (s *OnboardingServiceImpl) ImportPendingEmployees(ctx context.Context, r io.Reader, dryRun bool) (*EmployeeImportReport, error) {
+	rows, err := readEmployeeImportCSV(r)
+	if err != nil {
+		return nil, err
+	}
+
+	validator := &employeeImportValidator{
+		repoManager: s.repoManager,
+		departments: make(map[string]*uint),
+		positions:   make(map[string]*uint),
+		emails:      make(map[string]int),
+		employeeNos: make(map[string]int),
+	}
+	result := &EmployeeImportReport{DryRun: dryRun, Total: len(rows), Rows: make([]*EmployeeImportRow, 0, len(rows))}
+	var records []*employeeImportRecord
+	for _, row := range rows {
+		record, err := validator.validate(ctx, row)
+		if err != nil {
+			return nil, err
+		}
+		result.Rows = append(result.Rows, record.result)
+		if len(record.result.Errors) > 0 {
+			record.result.Status = EmployeeImportRowInvalid
+			result.Invalid++
+			continue
+		}
+		record.result.Status = EmployeeImportRowValid
+		result.Valid++
+		records = append(records, record)
+	}
+	if dryRun {
+		return result, nil
+	}
+
+	for _, record := range records {
+		employee, err := s.createPendingEmployee(ctx, record.request, &record.expectedDate)
+		if err != nil {
+			logger.FromContext(ctx).Warnf("批量导入员工失败: row=%d, email=%s, error=%v", record.result.Row, record.result.Email, err)
+			record.result.Status = EmployeeImportRowFailed
+			record.result.Errors = append(record.result.Errors, err.Error())
+			result.Failed++
+			continue
+		}
+		record.result.Status = EmployeeImportRowCreated
+		record.result.EmployeeID = employee.ID
+		record.result.EmployeeNo = employee.EmployeeNo
+		result.Created++
+	}
+	logger.FromContext(ctx).Infof("批量导入待入职员工完成: 共%d行, 创建%d, 校验未通过%d, 创建失败%d",
+		result.Total, result.Created, result.Invalid, result.Failed)
+	return result, nil
+}
+
+// employeeImportLine 导入文件中的一个数据行，按列名取值
+type employeeImportLine struct {
+	number int
+	values map[string]string
+}
+
+// readEmployeeImportCSV 解析导入文件：首行为表头，列名不区分大小写、顺序不限，跳过空行
+func readEmployeeImportCSV(r io.Reader) ([]*employeeImportLine, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, response.Wrapf(ErrInvalidEmployeeImport, "文件为空")
+		}
+		return nil, response.Wrapf(ErrInvalidEmployeeImport, "无法解析表头: %v", err)
+	}
+	columns := make([]string, len(header))
+	seen := make(map[string]bool, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if !slices.Contains(employeeImportColumns, name) {
+			return nil, response.Wrapf(ErrInvalidEmployeeImport, "未知的列%s，可用列为%s", name, strings.Join(employeeImportColumns, ", "))
+		}
+		if seen[name] {
+			return nil, response.Wrapf(ErrInvalidEmployeeImport, "列%s重复", name)
+		}
+		seen[name] = true
+		columns[i] = name
+	}
+	for _, name := range employeeImportRequiredColumns {
+		if !seen[name] {
+			return nil, response.Wrapf(ErrInvalidEmployeeImport, "缺少必填列%s", name)
+		}
+	}
+
+	var lines []*employeeImportLine
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, response.Wrapf(ErrInvalidEmployeeImport, "无法解析CSV: %v", err)
+		}
+		number, _ := reader.FieldPos(0)
+		line := &employeeImportLine{number: number, values: make(map[string]string, len(columns))}
+		empty := true
+		for i, value := range record {
+			if i >= len(columns) {
+				if strings.TrimSpace(value) != "" {
+					return nil, response.Wrapf(ErrInvalidEmployeeImport, "第%d行的列数多于表头", number)
+				}
+				continue
+			}
+			value = strings.TrimSpace(value)
+			line.values[columns[i]] = value
+			if value != "" {
+				empty = false
+			}
+		}
+		if empty {
+			continue
+		}
+		if len(lines) == MaxEmployeeImportRows {
+			return nil, response.Wrapf(ErrInvalidEmployeeImport, "单次最多导入%d行", MaxEmployeeImportRows)
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		return nil, response.Wrapf(ErrInvalidEmployeeImport, "文件中没有数据行")
+	}
+	return lines, nil
+}
+
+// employeeImportValidator 逐行校验导入数据，部门和职位编码在一次导入内缓存
+type employeeImportValidator struct {
+	repoManager repository.RepositoryManager
+	departments map[string]*uint // 编码到ID，nil表示编码不存在
+	positions   map[string]*uint
+	emails      map[string]int // 小写邮箱到首次出现的行号
+	employeeNos map[string]int // 员工编号到首次出现的行号
+}
+
+// validate 校验一行，校验问题记录在结果的Errors中，只有查询失败时返回错误
+func (v *employeeImportValidator) validate(ctx context.Context, line *employeeImportLine) (*employeeImportRecord, error) {
+	values := line.values
+	result := &EmployeeImportRow{Row: line.number, RealName: values["real_name"], Email: values["email"]}
+	req := &CreatePendingEmployeeRequest{
+		RealName:     values["real_name"],
+		Email:        values["email"],
+		Phone:        values["phone"],
+		ExpectedDate: values["expected_date"],
+		Notes:        values["notes"],
+		EmployeeNo:   values["employee_no"],
+	}
+	record := &employeeImportRecord{result: result, request: req}
+	addError := func(format string, args ...interface{}) {
+		result.Errors = append(result.Errors, fmt.Sprintf(format, args...))
+	}
+
+	// 与CreatePendingEmployeeRequest的校验规则一致
+	if n := utf8.RuneCountInString(req.RealName); n < 2 || n > 50 {
+		addError("real_name长度须在2到50个字符之间")
+	}
+	if n := len(req.Phone); n < 11 || n > 15 {
+		addError("phone长度须在11到15个字符之间")
+	}
+	if req.ExpectedDate == "" {
+		addError("expected_date不能为空")
+	} else if parsed, err := time.Parse("2006-01-02", req.ExpectedDate); err != nil {
+		addError("expected_date格式应为2006-01-02")
+	} else {
+		record.expectedDate = parsed
+	}
+
+	if err := v.validateEmail(ctx, req.Email, line.number, addError); err != nil {
+		return nil, err
+	}
+
+	if err := v.validateEmployeeNo(ctx, req.EmployeeNo, line.number, addError); err != nil {
+		return nil, err
+	}
+
+	if code := values["department_code"]; code != "" {
+		id, err := v.departmentID(ctx, code)
+		if err != nil {
+			return nil, err
+		}
+		if id == nil {
+			addError("部门编码%s不存在", code)
+		}
+		req.DepartmentID = id
+	}
+	if code := values["position_code"]; code != "" {
+		id, err := v.positionID(ctx, code)
+		if err != nil {
+			return nil, err
+		}
+		if id == nil {
+			addError("职位编码%s不存在", code)
+		}
+		req.PositionID = id
+	}
+	return record, nil
+}
+
+// validateEmail 校验邮箱格式，以及是否与文件中前面的行或已有用户重复；已有用户的用户名也不能与邮箱相同
+func (v *employeeImportValidator) validateEmail(ctx context.Context, email string, row int, addError func(string, ...interface{})) error {
+	if email == "" {
+		addError("email不能为空")
+		return nil
+	}
+	if address, err := mail.ParseAddress(email); err != nil || address.Address != email {
+		addError("email格式不正确")
+		return nil
+	}
+
+	key := strings.ToLower(email)
+	if first, ok := v.emails[key]; ok {
+		addError("email与第%d行重复", first)
+		return nil
+	}
+	v.emails[key] = row
+
+	if _, err := v.repoManager.UserRepository().GetByEmail(ctx, email); err == nil {
+		addError("email已被其他用户使用")
+		return nil
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		return err
+	}
+	if _, err := v.repoManager.UserRepository().GetByUsername(ctx, email); err == nil {
+		addError("email已被其他用户用作用户名")
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+// validateEmployeeNo 校验沿用的员工编号是否与文件中前面的行或已有员工重复，为空时创建时自动生成
+func (v *employeeImportValidator) validateEmployeeNo(ctx context.Context, employeeNo string, row int, addError func(string, ...interface{})) error {
+	if employeeNo == "" {
+		return nil
+	}
+	if len(employeeNo) > 50 {
+		addError("employee_no不能超过50个字符")
+		return nil
+	}
+	if first, ok := v.employeeNos[employeeNo]; ok {
+		addError("employee_no与第%d行重复", first)
+		return nil
+	}
+	v.employeeNos[employeeNo] = row
+
+	exists, err := v.repoManager.EmployeeRepository().EmployeeNoExists(ctx, employeeNo)
+	if err != nil {
+		return err
+	}
+	if exists {
+		addError("employee_no已被其他员工使用")
+	}
+	return nil
+}
+
+// departmentID 按编码查找部门ID，不存在时返回nil
+func (v *employeeImportValidator) departmentID(ctx context.Context, code string) (*uint, error) {
+	if id, ok := v.departments[code]; ok {
+		return id, nil
+	}
+	department, err := v.repoManager.DepartmentRepository().GetByCode(ctx, code)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+	var id *uint
+	if err == nil {
+		id = &department.ID
+	}
+	v.departments[code] = id
+	return id, nil
+}
+
+// positionID 按编码查找职位ID，不存在时返回nil
+func (v *employeeImportValidator) positionID(ctx context.Context, code string) (*uint, error) {
+	if id, ok := v.positions[code]; ok {
+		return id, nil
+	}
+	position, err := v.repoManager.PositionRepository().GetByCode(ctx, code)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
+	var id *uint
+	if err == nil {
+		id = &position.ID
+	}
+	v.positions[code] = id
+	return id, nil
+}
+
+// WriteEmployeeImportResult 将导入结果写为CSV，每行包含原始行号、状态和错误说明
+func WriteEmployeeImportResult(w io.Writer, result *EmployeeImportReport) error {
+	writer, err := report.NewCSVWriter(w)
+	if err != nil {
+		return err
+	}
+	if err := writer.WriteRow("row", "real_name", "email", "status", "employee_id", "employee_no", "errors"); err != nil {
+		return err
+	}
+	for _, row := range result.Rows {
+		var employeeID interface{}
+		if row.EmployeeID != 0 {
+			employeeID = row.EmployeeID
+		}
+		if err := writer.WriteRow(row.Row, row.RealName, row.Email, row.Status, employeeID, row.EmployeeNo, strings.Join(row.Errors, "; ")); err != nil {
+			return err
+		}
+	}
+	return writer.Close()
+}
@@ -0,0 +1,280 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/config"
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+// importUserRepository 按邮箱和用户名查找的内存用户仓储
+type importUserRepository struct {
+	repository.UserRepository
+	users []*database.User
+}
+
+func (r *importUserRepository) Create(ctx context.Context, user *database.User) error {
+	user.ID = uint(len(r.users) + 1)
+	r.users = append(r.users, user)
+	return nil
+}
+
+func (r *importUserRepository) GetByEmail(ctx context.Context, email string) (*database.User, error) {
+	for _, user := range r.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *importUserRepository) GetByUsername(ctx context.Context, username string) (*database.User, error) {
+	for _, user := range r.users {
+		if user.Username == username {
+			return user, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+// importEmployeeRepository 指定邮箱对应的员工创建失败的员工仓储
+type importEmployeeRepository struct {
+	repository.EmployeeRepository
+	users     *importUserRepository
+	failFor   string
+	employees []*database.Employee
+}
+
+func (r *importEmployeeRepository) Create(ctx context.Context, employee *database.Employee) error {
+	if r.users.users[employee.UserID-1].Email == r.failFor {
+		return errors.New("员工编号冲突")
+	}
+	employee.ID = uint(len(r.employees) + 1)
+	r.employees = append(r.employees, employee)
+	return nil
+}
+
+func (r *importEmployeeRepository) EmployeeNoExists(ctx context.Context, employeeNo string) (bool, error) {
+	for _, employee := range r.employees {
+		if employee.EmployeeNo == employeeNo {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// importDepartmentRepository 按编码查找部门
+type importDepartmentRepository struct {
+	repository.DepartmentRepository
+	departments map[string]uint
+}
+
+func (r *importDepartmentRepository) GetByCode(ctx context.Context, code string) (*database.Department, error) {
+	id, ok := r.departments[code]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	department := &database.Department{Code: code}
+	department.ID = id
+	return department, nil
+}
+
+// importPositionRepository 按编码查找职位
+type importPositionRepository struct {
+	repository.PositionRepository
+	positions map[string]uint
+}
+
+func (r *importPositionRepository) GetByCode(ctx context.Context, code string) (*database.Position, error) {
+	id, ok := r.positions[code]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	position := &database.Position{Code: code}
+	position.ID = id
+	return position, nil
+}
+
+// importRepoManager 批量导入测试用仓储管理器，事务失败时回滚本行创建的用户
+type importRepoManager struct {
+	repository.RepositoryManager
+	users       *importUserRepository
+	employees   *importEmployeeRepository
+	departments *importDepartmentRepository
+	positions   *importPositionRepository
+}
+
+func (m *importRepoManager) UserRepository() repository.UserRepository {
+	return m.users
+}
+
+func (m *importRepoManager) EmployeeRepository() repository.EmployeeRepository {
+	return m.employees
+}
+
+func (m *importRepoManager) DepartmentRepository() repository.DepartmentRepository {
+	return m.departments
+}
+
+func (m *importRepoManager) PositionRepository() repository.PositionRepository {
+	return m.positions
+}
+
+func (m *importRepoManager) WithTx(ctx context.Context, fn func(ctx context.Context, repos repository.RepositoryManager) error) error {
+	users := len(m.users.users)
+	if err := fn(ctx, m); err != nil {
+		m.users.users = m.users.users[:users]
+		return err
+	}
+	return nil
+}
+
+// recordingActivationService 记录发送了激活邮件的用户
+type recordingActivationService struct {
+	ActivationService
+	sent []string
+}
+
+func (s *recordingActivationService) SendActivation(ctx context.Context, user *database.User) error {
+	s.sent = append(s.sent, user.Email)
+	return nil
+}
+
+func newImportService() (*OnboardingServiceImpl, *importRepoManager, *recordingActivationService) {
+	users := &importUserRepository{users: []*database.User{{Username: "lisi@example.com", Email: "lisi@example.com"}}}
+	users.users[0].ID = 1
+	repos := &importRepoManager{
+		users:       users,
+		employees:   &importEmployeeRepository{users: users, failFor: "zhaoliu@example.com"},
+		departments: &importDepartmentRepository{departments: map[string]uint{"RD": 3}},
+		positions:   &importPositionRepository{positions: map[string]uint{"BE": 7}},
+	}
+	activation := &recordingActivationService{}
+	employeeNos := NewEmployeeNumberGenerator(repos, config.EmployeeNumberConfig{Source: EmployeeNoSourceUserID})
+	svc := &OnboardingServiceImpl{repoManager: repos, activationService: activation, employeeNos: employeeNos, logger: logrus.New()}
+	return svc, repos, activation
+}
+
+const employeeImportCSV = "\ufeffReal_Name,email,phone,expected_date,department_code,position_code,notes\n" +
+	"张三,zhangsan@example.com,13800000001,2026-04-01,RD,BE,校招\n" +
+	"李四,lisi@example.com,13800000002,2026-04-01,,,\n" +
+	"王五,wangwu.example.com,1380000,2026/04/01,QA,PM,\n" +
+	"\n" +
+	"张三丰,ZhangSan@example.com,13800000004,2026-04-01,,,\n" +
+	"赵六,zhaoliu@example.com,13800000005,2026-04-01,RD,,\n" +
+	"钱七,qianqi@example.com,13800000006,2026-04-02,,,\n"
+
+func TestImportPendingEmployees_DryRun(t *testing.T) {
+	svc, repos, activation := newImportService()
+
+	report, err := svc.ImportPendingEmployees(context.Background(), strings.NewReader(employeeImportCSV), true)
+	require.NoError(t, err)
+	assert.True(t, report.DryRun)
+	assert.Equal(t, 6, report.Total)
+	assert.Equal(t, 3, report.Valid)
+	assert.Equal(t, 3, report.Invalid)
+	assert.Len(t, repos.users.users, 1)
+	assert.Empty(t, activation.sent)
+
+	rows := report.Rows
+	assert.Equal(t, 2, rows[0].Row)
+	assert.Equal(t, EmployeeImportRowValid, rows[0].Status)
+	assert.Equal(t, []string{"email已被其他用户使用"}, rows[1].Errors)
+	assert.Equal(t, []string{
+		"phone长度须在11到15个字符之间",
+		"expected_date格式应为2006-01-02",
+		"email格式不正确",
+		"部门编码QA不存在",
+		"职位编码PM不存在",
+	}, rows[2].Errors)
+	// 空行不计入，但行号按文件实际行号
+	assert.Equal(t, 6, rows[3].Row)
+	assert.Equal(t, []string{"email与第2行重复"}, rows[3].Errors)
+}
+
+func TestImportPendingEmployees_CreatesRowsIndependently(t *testing.T) {
+	svc, repos, activation := newImportService()
+
+	report, err := svc.ImportPendingEmployees(context.Background(), strings.NewReader(employeeImportCSV), false)
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Created)
+	assert.Equal(t, 1, report.Failed)
+	assert.Equal(t, 3, report.Invalid)
+
+	rows := report.Rows
+	assert.Equal(t, EmployeeImportRowCreated, rows[0].Status)
+	assert.Equal(t, EmployeeImportRowFailed, rows[4].Status)
+	assert.Equal(t, EmployeeImportRowCreated, rows[5].Status)
+
+	// 失败行的用户随事务回滚，激活邮件只发给创建成功的员工
+	require.Len(t, repos.employees.employees, 2)
+	first := repos.employees.employees[0]
+	assert.Equal(t, uint(3), *first.DepartmentID)
+	assert.Equal(t, uint(7), *first.PositionID)
+	assert.Equal(t, "pending_onboard", first.OnboardingStatus)
+	assert.Equal(t, "2026-04-01", first.ExpectedDate.Format("2006-01-02"))
+	assert.Len(t, repos.users.users, 3)
+	assert.Equal(t, []string{"zhangsan@example.com", "qianqi@example.com"}, activation.sent)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteEmployeeImportResult(&buf, report))
+	lines := strings.Split(strings.TrimSpace(strings.TrimPrefix(buf.String(), "\ufeff")), "\n")
+	require.Len(t, lines, 7)
+	assert.Equal(t, "row,real_name,email,status,employee_id,employee_no,errors", lines[0])
+	assert.Equal(t, "2,张三,zhangsan@example.com,created,1,EMP000002,", lines[1])
+	assert.Equal(t, "3,李四,lisi@example.com,invalid,,,email已被其他用户使用", lines[2])
+}
+
+func TestImportPendingEmployees_InvalidFile(t *testing.T) {
+	svc, _, _ := newImportService()
+	ctx := context.Background()
+
+	for _, content := range []string{
+		"",
+		"real_name,email,phone\n张三,zhangsan@example.com,13800000001\n",
+		"real_name,email,phone,expected_date,salary\n",
+		"real_name,email,phone,expected_date\n",
+	} {
+		_, err := svc.ImportPendingEmployees(ctx, strings.NewReader(content), true)
+		assert.ErrorIs(t, err, ErrInvalidEmployeeImport, content)
+	}
+
+	var b strings.Builder
+	b.WriteString("real_name,email,phone,expected_date\n")
+	for i := 0; i <= MaxEmployeeImportRows; i++ {
+		b.WriteString("张三,zhangsan@example.com,13800000001,2026-04-01\n")
+	}
+	_, err := svc.ImportPendingEmployees(ctx, strings.NewReader(b.String()), true)
+	assert.ErrorIs(t, err, ErrInvalidEmployeeImport)
+}
+
+func TestImportPendingEmployees_EmployeeNo(t *testing.T) {
+	svc, repos, _ := newImportService()
+	repos.employees.employees = []*database.Employee{{EmployeeNo: "LEGACY-001"}}
+
+	content := "real_name,email,phone,expected_date,employee_no\n" +
+		"张三,zhangsan@example.com,13800000001,2026-04-01,LEGACY-002\n" +
+		"王五,wangwu@example.com,13800000003,2026-04-01,LEGACY-002\n" +
+		"钱七,qianqi@example.com,13800000006,2026-04-01,LEGACY-001\n" +
+		"孙八,sunba@example.com,13800000007,2026-04-01,\n"
+	report, err := svc.ImportPendingEmployees(context.Background(), strings.NewReader(content), false)
+	require.NoError(t, err)
+
+	rows := report.Rows
+	assert.Equal(t, EmployeeImportRowCreated, rows[0].Status)
+	assert.Equal(t, "LEGACY-002", rows[0].EmployeeNo)
+	assert.Equal(t, []string{"employee_no与第2行重复"}, rows[1].Errors)
+	assert.Equal(t, []string{"employee_no已被其他员工使用"}, rows[2].Errors)
+	// 未提供编号的行自动生成
+	assert.Equal(t, EmployeeImportRowCreated, rows[3].Status)
+	assert.Equal(t, "EMP000003", rows[3].EmployeeNo)
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"taskmanage/internal/database"
+	"taskmanage/pkg/logger"
+)
+
+// 入职审批阶段的状态
+const (
+	EmployeeStatusApprovalPending = "approval_pending" // 入职审批中
+	EmployeeStatusApproved        = "approved"         // 入职审批通过，等待报到
+	EmployeeStatusRejected        = "rejected"         // 入职审批被拒绝，可重新发起审批
+)
+
+// onboardingTransitions 入职状态机，键为当前状态，值为允许转换到的状态
+// 主线为 pending_onboard → approval_pending → approved → onboarding → probation → active，
+// 审批过期回到待入职，审批拒绝后可重新发起，在职员工经离职办理进入inactive
+var onboardingTransitions = map[string][]string{
+	EmployeeStatusPendingOnboard:  {EmployeeStatusApprovalPending, EmployeeStatusInactive},
+	EmployeeStatusApprovalPending: {EmployeeStatusApproved, EmployeeStatusRejected, EmployeeStatusPendingOnboard},
+	EmployeeStatusApproved:        {EmployeeStatusOnboarding, EmployeeStatusInactive},
+	EmployeeStatusRejected:        {EmployeeStatusApprovalPending, EmployeeStatusInactive},
+	EmployeeStatusOnboarding:      {EmployeeStatusProbation, EmployeeStatusOffboarding, EmployeeStatusInactive},
+	EmployeeStatusProbation:       {EmployeeStatusActive, EmployeeStatusOffboarding, EmployeeStatusInactive},
+	EmployeeStatusActive:          {EmployeeStatusOffboarding, EmployeeStatusInactive},
+	EmployeeStatusOffboarding:     {EmployeeStatusInactive, EmployeeStatusOnboarding, EmployeeStatusProbation, EmployeeStatusActive},
+	EmployeeStatusInactive:        {},
+}
+
+// ErrInvalidStatusTransition 入职状态转换不合法
+var ErrInvalidStatusTransition = errors.New("入职状态转换不合法")
+
+// InvalidStatusTransitionError 状态机不允许的入职状态转换，Allowed为当前状态可以转换到的状态
+type InvalidStatusTransitionError struct {
+	From    string
+	To      string
+	Allowed []string
+}
+
+func (e *InvalidStatusTransitionError) Error() string {
+	allowed := "无"
+	if len(e.Allowed) > 0 {
+		allowed = strings.Join(e.Allowed, ", ")
+	}
+	return fmt.Sprintf("%s: %s -> %s，可转换到: %s", ErrInvalidStatusTransition.Error(), e.From, e.To, allowed)
+}
+
+func (e *InvalidStatusTransitionError) Unwrap() error {
+	return ErrInvalidStatusTransition
+}
+
+// NextOnboardingStatuses 返回当前状态可以转换到的状态，未知状态返回空
+func NextOnboardingStatuses(from string) []string {
+	return append([]string{}, onboardingTransitions[from]...)
+}
+
+// checkOnboardingTransition 校验状态机是否允许from到to的转换
+func checkOnboardingTransition(from, to string) error {
+	for _, next := range onboardingTransitions[from] {
+		if next == to {
+			return nil
+		}
+	}
+	return &InvalidStatusTransitionError{From: from, To: to, Allowed: NextOnboardingStatuses(from)}
+}
+
+// OnboardingTransition 一次入职状态变更
+type OnboardingTransition struct {
+	To         string
+	OperatorID uint
+	Reason     string
+	Notes      string
+	// Apply 在状态校验通过后、保存前修改员工的其他字段，如部门、入职日期
+	Apply func(employee *database.Employee)
+}
+
+// TransitionStatus 按状态机变更员工入职状态
+// 校验状态转换后保存员工、记录入职历史和审计日志，并按新状态的入职权限配置分配权限，权限分配失败不影响状态变更
+func (s *OnboardingServiceImpl) TransitionStatus(ctx context.Context, employee *database.Employee, transition OnboardingTransition) error {
+	from := employee.OnboardingStatus
+	if err := checkOnboardingTransition(from, transition.To); err != nil {
+		return err
+	}
+
+	employee.OnboardingStatus = transition.To
+	if transition.Apply != nil {
+		transition.Apply(employee)
+	}
+	if err := s.employeeRepo.Update(ctx, employee); err != nil {
+		return fmt.Errorf("更新员工状态失败: %w", err)
+	}
+
+	s.recordStatusChange(ctx, employee.ID, from, transition.To, transition.OperatorID, transition.Reason, transition.Notes)
+	logger.FromContext(ctx).WithFields(logrus.Fields{
+		"event":       "employee_status_transition",
+		"employee_id": employee.ID,
+		"from":        from,
+		"to":          transition.To,
+		"operator_id": transition.OperatorID,
+		"reason":      transition.Reason,
+	}).Info("员工入职状态变更")
+	publishEvent(ctx, s.events, EventOnboardingStatusChanged, &OnboardingStatusChangedEvent{
+		EmployeeID: employee.ID,
+		UserID:     employee.UserID,
+		EmployeeNo: employee.EmployeeNo,
+		FromStatus: from,
+		ToStatus:   transition.To,
+		OperatorID: transition.OperatorID,
+		Reason:     transition.Reason,
+	})
+
+	if err := s.triggerPermissionAssignment(ctx, employee.UserID, employee.OnboardingStatus, employee.DepartmentID, employee.PositionID); err != nil {
+		logger.FromContext(ctx).WithError(err).Warnf("权限分配失败: employee=%d, status=%s", employee.ID, employee.OnboardingStatus)
+	}
+	return nil
+}
+
+// GetStatusTransitions 返回入职状态机；指定员工时同时返回其当前状态和可转换到的状态
+func (s *OnboardingServiceImpl) GetStatusTransitions(ctx context.Context, employeeID uint) (*OnboardingTransitionsResponse, error) {
+	statuses := make([]string, 0, len(onboardingTransitions))
+	for status := range onboardingTransitions {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+
+	result := &OnboardingTransitionsResponse{Transitions: make([]*OnboardingStatusTransitions, 0, len(statuses))}
+	for _, status := range statuses {
+		result.Transitions = append(result.Transitions, &OnboardingStatusTransitions{Status: status, Next: NextOnboardingStatuses(status)})
+	}
+	if employeeID == 0 {
+		return result, nil
+	}
+
+	employee, err := s.employeeRepo.GetByID(ctx, employeeID)
+	if err != nil {
+		return nil, employeeLookupError(err)
+	}
+	result.EmployeeID = employee.ID
+	result.CurrentStatus = employee.OnboardingStatus
+	result.NextStatuses = NextOnboardingStatuses(employee.OnboardingStatus)
+	return result, nil
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+// transitionEmployeeRepository 记录保存次数的员工仓储
+type transitionEmployeeRepository struct {
+	escalationEmployeeRepository
+	updates int
+}
+
+func (r *transitionEmployeeRepository) Update(ctx context.Context, employee *database.Employee) error {
+	r.updates++
+	return nil
+}
+
+// recordingHistoryRepository 记录入职历史的仓储
+type recordingHistoryRepository struct {
+	repository.OnboardingHistoryRepository
+	histories []*database.OnboardingHistory
+}
+
+func (r *recordingHistoryRepository) Create(ctx context.Context, history *database.OnboardingHistory) error {
+	r.histories = append(r.histories, history)
+	return nil
+}
+
+func TestCheckOnboardingTransition(t *testing.T) {
+	assert.NoError(t, checkOnboardingTransition(EmployeeStatusPendingOnboard, EmployeeStatusApprovalPending))
+	assert.NoError(t, checkOnboardingTransition(EmployeeStatusApproved, EmployeeStatusOnboarding))
+	assert.NoError(t, checkOnboardingTransition(EmployeeStatusRejected, EmployeeStatusApprovalPending))
+
+	err := checkOnboardingTransition(EmployeeStatusRejected, EmployeeStatusActive)
+	assert.ErrorIs(t, err, ErrInvalidStatusTransition)
+	var transition *InvalidStatusTransitionError
+	require.ErrorAs(t, err, &transition)
+	assert.Equal(t, []string{EmployeeStatusApprovalPending, EmployeeStatusInactive}, transition.Allowed)
+
+	// 离职是终态，未知状态也不能转换
+	assert.ErrorIs(t, checkOnboardingTransition(EmployeeStatusInactive, EmployeeStatusActive), ErrInvalidStatusTransition)
+	assert.ErrorIs(t, checkOnboardingTransition("unknown", EmployeeStatusActive), ErrInvalidStatusTransition)
+}
+
+func TestTransitionStatus(t *testing.T) {
+	ctx := context.Background()
+	employee := &database.Employee{BaseModel: database.BaseModel{ID: 10}, UserID: 100, OnboardingStatus: EmployeeStatusApproved}
+	employees := &transitionEmployeeRepository{escalationEmployeeRepository: escalationEmployeeRepository{employees: []*database.Employee{employee}}}
+	histories := &recordingHistoryRepository{}
+	svc := &OnboardingServiceImpl{employeeRepo: employees, historyRepo: histories, logger: logrus.New()}
+
+	departmentID := uint(3)
+	err := svc.TransitionStatus(ctx, employee, OnboardingTransition{
+		To:         EmployeeStatusOnboarding,
+		OperatorID: 1,
+		Reason:     "确认入职",
+		Apply:      func(employee *database.Employee) { employee.DepartmentID = &departmentID },
+	})
+	require.NoError(t, err)
+	assert.Equal(t, EmployeeStatusOnboarding, employee.OnboardingStatus)
+	assert.Equal(t, &departmentID, employee.DepartmentID)
+	assert.Equal(t, 1, employees.updates)
+	require.Len(t, histories.histories, 1)
+	assert.Equal(t, &database.OnboardingHistory{EmployeeID: 10, FromStatus: EmployeeStatusApproved, ToStatus: EmployeeStatusOnboarding, OperatorID: 1, Reason: "确认入职"}, histories.histories[0])
+
+	// 不合法的转换不保存员工也不记录历史
+	err = svc.TransitionStatus(ctx, employee, OnboardingTransition{To: EmployeeStatusApproved, OperatorID: 1})
+	assert.ErrorIs(t, err, ErrInvalidStatusTransition)
+	assert.Equal(t, EmployeeStatusOnboarding, employee.OnboardingStatus)
+	assert.Equal(t, 1, employees.updates)
+	assert.Len(t, histories.histories, 1)
+}
+
+func TestGetStatusTransitions(t *testing.T) {
+	ctx := context.Background()
+	employees := &escalationEmployeeRepository{employees: []*database.Employee{
+		{BaseModel: database.BaseModel{ID: 10}, OnboardingStatus: EmployeeStatusRejected},
+	}}
+	svc := &OnboardingServiceImpl{employeeRepo: employees, logger: logrus.New()}
+
+	result, err := svc.GetStatusTransitions(ctx, 0)
+	require.NoError(t, err)
+	assert.Len(t, result.Transitions, len(onboardingTransitions))
+	assert.Empty(t, result.CurrentStatus)
+
+	result, err = svc.GetStatusTransitions(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, EmployeeStatusRejected, result.CurrentStatus)
+	assert.Equal(t, []string{EmployeeStatusApprovalPending, EmployeeStatusInactive}, result.NextStatuses)
+
+	_, err = svc.GetStatusTransitions(ctx, 99)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	"taskmanage/internal/repository"
+	"taskmanage/internal/workflow"
+	"taskmanage/pkg/response"
+)
+
+// ErrOnboardingDepartmentManagerMissing 完整入职审批流程需要部门负责人审批，但部门未设置负责人
+var ErrOnboardingDepartmentManagerMissing = response.NewError(response.ErrCodeInvalidRequest, "部门未设置负责人，无法使用完整入职审批流程")
+
+// onboardingWorkflow 确定入职审批使用的流程类型，完整流程同时返回部门负责人的用户ID
+// 流程类型优先使用请求中指定的类型，其次是入职部门的设置，最后是系统配置的默认流程
+func (s *OnboardingServiceImpl) onboardingWorkflow(ctx context.Context, requested string, departmentID uint) (string, *uint, error) {
+	if requested != "" {
+		if _, err := workflow.OnboardingWorkflowID(requested); err != nil {
+			return "", nil, err
+		}
+	}
+
+	department, err := s.departmentRepo.GetByID(ctx, departmentID)
+	if err != nil && !repository.IsNotFoundError(err) {
+		return "", nil, fmt.Errorf("获取部门信息失败: %w", err)
+	}
+
+	workflowType := requested
+	switch {
+	case workflowType != "":
+	case department != nil && department.OnboardingWorkflowType != "":
+		workflowType = department.OnboardingWorkflowType
+	case s.defaultWorkflowType != "":
+		workflowType = s.defaultWorkflowType
+	default:
+		workflowType = workflow.OnboardingWorkflowSimple
+	}
+	if workflowType != workflow.OnboardingWorkflowFull {
+		return workflowType, nil, nil
+	}
+
+	// 完整流程的部门负责人审批节点由入职部门的负责人审批
+	if department == nil || department.ManagerID == nil {
+		return "", nil, ErrOnboardingDepartmentManagerMissing
+	}
+	manager, err := s.employeeRepo.GetByID(ctx, *department.ManagerID)
+	if err != nil {
+		if repository.IsNotFoundError(err) {
+			return "", nil, ErrOnboardingDepartmentManagerMissing
+		}
+		return "", nil, fmt.Errorf("获取部门负责人失败: %w", err)
+	}
+	return workflowType, &manager.UserID, nil
+}
+
+// SetOnboardingWorkflowType 设置部门员工的入职审批流程类型，类型为空时恢复使用系统默认流程
+func (s *departmentService) SetOnboardingWorkflowType(ctx context.Context, id uint, req *UpdateDepartmentOnboardingWorkflowRequest) (*DepartmentResponse, error) {
+	s.logger.WithFields(logrus.Fields{
+		"department_id": id,
+		"workflow_type": req.WorkflowType,
+	}).Info("设置部门入职审批流程")
+
+	if req.WorkflowType != "" {
+		if _, err := workflow.OnboardingWorkflowID(req.WorkflowType); err != nil {
+			return nil, err
+		}
+	}
+
+	repo := s.repoManager.DepartmentRepository()
+	exists, err := repo.Exists(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("获取部门失败: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("%w: %d", ErrDepartmentNotFound, id)
+	}
+	if err := repo.UpdateOnboardingWorkflowType(ctx, id, req.WorkflowType); err != nil {
+		s.logger.WithError(err).Error("设置部门入职审批流程失败")
+		return nil, fmt.Errorf("设置部门入职审批流程失败: %w", err)
+	}
+
+	return s.GetDepartment(ctx, id)
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+	"taskmanage/internal/workflow"
+)
+
+func TestGetOnboardingWorkflows_PassesFilterToRepository(t *testing.T) {
+	ctx := context.Background()
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local)
+	to := time.Date(2026, 4, 1, 0, 0, 0, 0, time.Local)
+	employee := &database.Employee{
+		BaseModel:        database.BaseModel{ID: 8},
+		OnboardingStatus: "probation",
+		User:             database.User{RealName: "王五"},
+		Department:       database.Department{Name: "研发部"},
+	}
+
+	employeeRepo := new(MockEmployeeRepository)
+	employeeRepo.On("ListOnboarding", ctx, &repository.OnboardingWorkflowFilter{
+		Page:         2,
+		PageSize:     10,
+		Status:       "probation",
+		Department:   "研发部",
+		ExpectedFrom: &from,
+		ExpectedTo:   &to,
+	}).Return([]*database.Employee{employee}, int64(11), nil)
+
+	svc := &OnboardingServiceImpl{employeeRepo: employeeRepo}
+	result, err := svc.GetOnboardingWorkflows(ctx, &OnboardingWorkflowFilter{
+		Page:       2,
+		Status:     "probation",
+		Department: "研发部",
+		DateFrom:   "2026-03-01",
+		DateTo:     "2026-03-31",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(11), result.Total)
+	assert.Equal(t, 2, result.Page)
+	assert.Equal(t, 10, result.Size)
+	require.Len(t, result.Items, 1)
+	assert.Equal(t, "王五", result.Items[0].EmployeeName)
+	assert.Equal(t, "研发部", result.Items[0].Department)
+
+	_, err = svc.GetOnboardingWorkflows(ctx, &OnboardingWorkflowFilter{DateFrom: "03/01/2026"})
+	assert.ErrorIs(t, err, ErrInvalidOnboardingFilter)
+	_, err = svc.GetOnboardingWorkflows(ctx, &OnboardingWorkflowFilter{DateFrom: "2026-04-01", DateTo: "2026-03-01"})
+	assert.ErrorIs(t, err, ErrInvalidOnboardingFilter)
+}
+
+// runningWorkflowService 入职审批已在运行的流程服务桩
+type runningWorkflowService struct {
+	WorkflowService
+	running *workflow.WorkflowInstance
+}
+
+func (s *runningWorkflowService) StartOnboardingApproval(ctx context.Context, req *workflow.OnboardingApprovalRequest) (*workflow.WorkflowInstance, error) {
+	return nil, fmt.Errorf("启动入职审批流程失败: %w", &workflow.RunningInstanceError{Instance: s.running})
+}
+
+func TestStartOnboardingApproval_ReturnsRunningInstance(t *testing.T) {
+	ctx := context.Background()
+	startedAt := time.Date(2026, 3, 2, 9, 0, 0, 0, time.Local)
+	employee := &database.Employee{BaseModel: database.BaseModel{ID: 8}, OnboardingStatus: "approval_pending"}
+
+	// 重复提交不更新员工状态也不记录历史，未设置Update和Create的期望，调用即失败
+	employeeRepo := new(MockEmployeeRepository)
+	employeeRepo.On("GetByID", ctx, uint(8)).Return(employee, nil)
+
+	svc := &OnboardingServiceImpl{
+		employeeRepo:   employeeRepo,
+		departmentRepo: &escalationDepartmentRepository{department: &database.Department{BaseModel: database.BaseModel{ID: 2}}},
+		workflowService: &runningWorkflowService{running: &workflow.WorkflowInstance{
+			ID:           "inst-1",
+			WorkflowID:   workflow.FullOnboardingWorkflowID,
+			Status:       workflow.StatusRunning,
+			CurrentNodes: []string{"manager_approval"},
+			StartedAt:    startedAt,
+		}},
+		logger: logrus.New(),
+	}
+	result, err := svc.StartOnboardingApproval(ctx, &OnboardingApprovalRequest{EmployeeID: 8, DepartmentID: 2, ProbationDays: 90})
+	require.NoError(t, err)
+	assert.True(t, result.AlreadyRunning)
+	assert.Equal(t, "inst-1", result.InstanceID)
+	assert.Equal(t, "manager_approval", result.CurrentStep)
+	assert.Equal(t, startedAt, result.CreatedAt)
+	// 流程类型取自已在运行的实例，而不是本次请求
+	assert.Equal(t, workflow.OnboardingWorkflowFull, result.WorkflowType)
+	employeeRepo.AssertExpectations(t)
+}
+
+func TestOnboardingWorkflow_ResolvesType(t *testing.T) {
+	ctx := context.Background()
+	managerID := uint(30)
+	department := &database.Department{BaseModel: database.BaseModel{ID: 2}}
+	employeeRepo := new(MockEmployeeRepository)
+	employeeRepo.On("GetByID", ctx, managerID).Return(&database.Employee{BaseModel: database.BaseModel{ID: managerID}, UserID: 300}, nil)
+	svc := &OnboardingServiceImpl{employeeRepo: employeeRepo, departmentRepo: &escalationDepartmentRepository{department: department}}
+
+	// 请求和部门都未指定时使用系统默认流程，未配置默认流程时为简化流程
+	workflowType, managerUserID, err := svc.onboardingWorkflow(ctx, "", 2)
+	require.NoError(t, err)
+	assert.Equal(t, workflow.OnboardingWorkflowSimple, workflowType)
+	assert.Nil(t, managerUserID)
+
+	// 完整流程要求部门设置负责人
+	svc.defaultWorkflowType = workflow.OnboardingWorkflowFull
+	_, _, err = svc.onboardingWorkflow(ctx, "", 2)
+	assert.ErrorIs(t, err, ErrOnboardingDepartmentManagerMissing)
+	_, _, err = svc.onboardingWorkflow(ctx, "", 99)
+	assert.ErrorIs(t, err, ErrOnboardingDepartmentManagerMissing)
+
+	// 部门设置优先于系统默认流程，请求中指定的类型优先于部门设置
+	department.ManagerID = &managerID
+	workflowType, managerUserID, err = svc.onboardingWorkflow(ctx, "", 2)
+	require.NoError(t, err)
+	assert.Equal(t, workflow.OnboardingWorkflowFull, workflowType)
+	require.NotNil(t, managerUserID)
+	assert.Equal(t, uint(300), *managerUserID)
+
+	department.OnboardingWorkflowType = workflow.OnboardingWorkflowSimple
+	workflowType, _, err = svc.onboardingWorkflow(ctx, "", 2)
+	require.NoError(t, err)
+	assert.Equal(t, workflow.OnboardingWorkflowSimple, workflowType)
+
+	workflowType, _, err = svc.onboardingWorkflow(ctx, workflow.OnboardingWorkflowFull, 2)
+	require.NoError(t, err)
+	assert.Equal(t, workflow.OnboardingWorkflowFull, workflowType)
+
+	_, _, err = svc.onboardingWorkflow(ctx, "express", 2)
+	assert.ErrorIs(t, err, workflow.ErrUnknownOnboardingWorkflowType)
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"taskmanage/internal/repository"
+	"taskmanage/pkg/logger"
+	"taskmanage/pkg/response"
+)
+
+// ConflictError 资源已被其他请求修改，Current为资源的最新状态，客户端据此刷新后重试
+type ConflictError struct {
+	Resource string
+	Current  interface{}
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s已被其他请求修改，请刷新后重试", e.Resource)
+}
+
+func (e *ConflictError) Unwrap() error {
+	return repository.ErrConflict
+}
+
+// newConflictError 加载资源最新状态并构造冲突错误，加载失败时不附带最新状态
+func newConflictError(resource string, load func() (interface{}, error)) *ConflictError {
+	current, err := load()
+	if err != nil {
+		logger.Warnf("获取%s最新状态失败: %v", resource, err)
+		current = nil
+	}
+	return &ConflictError{Resource: resource, Current: current}
+}
+
+// taskConflictError 任务版本冲突时返回携带最新任务状态的冲突错误
+func taskConflictError(ctx context.Context, taskRepo repository.TaskRepository, taskID uint) *ConflictError {
+	return newConflictError("任务", func() (interface{}, error) {
+		task, err := taskRepo.GetByID(ctx, taskID)
+		if err != nil {
+			return nil, err
+		}
+		return TaskToResponse(task), nil
+	})
+}
+
+// reserveTaskSlot 原子占用员工的一个任务名额，名额已满时返回ErrEmployeeUnavailable
+func reserveTaskSlot(ctx context.Context, employeeRepo repository.EmployeeRepository, employeeID uint) error {
+	if err := employeeRepo.UpdateTaskCount(ctx, employeeID, 1); err != nil {
+		if errors.Is(err, repository.ErrTaskLimitReached) {
+			return response.Wrapf(ErrEmployeeUnavailable, "员工当前任务已达上限")
+		}
+		return fmt.Errorf("更新员工任务数失败: %w", err)
+	}
+	return nil
+}
+
+// releaseTaskSlot 释放员工的一个任务名额，失败只记录日志
+func releaseTaskSlot(ctx context.Context, employeeRepo repository.EmployeeRepository, employeeID uint) {
+	if err := employeeRepo.UpdateTaskCount(ctx, employeeID, -1); err != nil {
+		logger.Warnf("释放员工任务名额失败: EmployeeID=%d, error: %v", employeeID, err)
+	}
+}
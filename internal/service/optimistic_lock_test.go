@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+func TestUpdateTask_VersionConflictReturnsCurrentState(t *testing.T) {
+	ctx := context.Background()
+	stale := &database.Task{BaseModel: database.BaseModel{ID: 1}, Title: "旧标题", Priority: "low", Status: "pending", Version: 4}
+	current := &database.Task{BaseModel: database.BaseModel{ID: 1}, Title: "他人修改的标题", Priority: "high", Status: "pending", Version: 5}
+
+	taskRepo := new(MockTaskRepository)
+	taskRepo.On("GetByID", ctx, uint(1)).Return(stale, nil).Once()
+	taskRepo.On("GetByID", ctx, uint(1)).Return(current, nil).Once()
+	taskRepo.On("Update", ctx, mock.MatchedBy(func(task *database.Task) bool {
+		return task.Version == 3 && task.Title == "新标题"
+	})).Return(repository.ErrConflict)
+
+	svc := &taskServiceRepo{taskRepo: taskRepo}
+	title, version := "新标题", uint(3)
+	_, err := svc.UpdateTask(ctx, 1, &UpdateTaskRequest{Title: &title, Version: &version})
+
+	var conflict *ConflictError
+	require.ErrorAs(t, err, &conflict)
+	assert.ErrorIs(t, err, repository.ErrConflict)
+	latest, ok := conflict.Current.(*TaskResponse)
+	require.True(t, ok)
+	assert.Equal(t, "他人修改的标题", latest.Title)
+	assert.Equal(t, uint(5), latest.Version)
+	taskRepo.AssertExpectations(t)
+}
+
+func TestAssignTask_TaskLimitReachedConcurrently(t *testing.T) {
+	ctx := context.Background()
+	task := &database.Task{BaseModel: database.BaseModel{ID: 1}, Status: "pending"}
+	// 读取时仍有名额，占用时已被并发分配占满
+	employee := &database.Employee{BaseModel: database.BaseModel{ID: 5}, UserID: 50, CurrentTasks: 2, MaxTasks: 3}
+
+	taskRepo := new(MockTaskRepository)
+	employeeRepo := new(MockEmployeeRepository)
+	taskRepo.On("GetByID", ctx, uint(1)).Return(task, nil)
+	employeeRepo.On("GetByID", ctx, uint(5)).Return(employee, nil)
+	employeeRepo.On("UpdateTaskCount", ctx, uint(5), 1).Return(repository.ErrTaskLimitReached)
+
+	svc := &taskServiceRepo{taskRepo: taskRepo, employeeRepo: employeeRepo}
+	_, err := svc.AssignTask(ctx, &AssignTaskRequest{TaskID: 1, EmployeeID: 5})
+	assert.ErrorIs(t, err, ErrEmployeeUnavailable)
+	assert.Equal(t, "pending", task.Status)
+	taskRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+// 组织架构图层级
+const (
+	DefaultOrgChartDepth  = 3  // 默认展开的下属层级
+	MaxOrgChartDepth      = 10 // 下属层级上限
+	maxManagerChainLevels = 50 // 上级链最多向上追溯的层级，防止异常数据导致查询过深
+)
+
+// OrgChartNode 组织架构图中的员工节点
+type OrgChartNode struct {
+	ID               uint            `json:"id"`
+	EmployeeNo       string          `json:"employee_no"`
+	Name             string          `json:"name"`
+	DirectManagerID  *uint           `json:"direct_manager_id,omitempty"`
+	Position         string          `json:"position"`
+	DepartmentID     *uint           `json:"department_id,omitempty"`
+	Department       string          `json:"department"`
+	OnboardingStatus string          `json:"onboarding_status"`
+	Status           string          `json:"status"`
+	Reports          []*OrgChartNode `json:"reports,omitempty"`
+}
+
+// OrgChartResponse 员工组织架构图
+type OrgChartResponse struct {
+	Managers    []*OrgChartNode `json:"managers"`     // 从直接上级到最高上级
+	ReportsTree *OrgChartNode   `json:"reports_tree"` // 根节点为员工本人，逐层展开下属
+	Depth       int             `json:"depth"`        // 下属展开层级
+}
+
+// GetOrgChart 获取员工的上级链和depth层以内的下属树
+func (s *EmployeeServiceImpl) GetOrgChart(ctx context.Context, employeeID uint, depth int) (*OrgChartResponse, error) {
+	if depth < 1 {
+		depth = DefaultOrgChartDepth
+	}
+	if depth > MaxOrgChartDepth {
+		depth = MaxOrgChartDepth
+	}
+
+	chain, err := s.employeeRepo.ListManagerChain(ctx, employeeID, maxManagerChainLevels)
+	if err != nil {
+		return nil, fmt.Errorf("获取上级链失败: %w", err)
+	}
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("获取员工失败: %w", repository.ErrNotFound)
+	}
+
+	reports, err := s.employeeRepo.ListReports(ctx, employeeID, depth)
+	if err != nil {
+		return nil, fmt.Errorf("获取下属失败: %w", err)
+	}
+
+	managers := make([]*OrgChartNode, 0, len(chain)-1)
+	for _, manager := range chain[1:] {
+		managers = append(managers, newOrgChartNode(manager))
+	}
+	return &OrgChartResponse{
+		Managers:    managers,
+		ReportsTree: buildReportsTree(chain[0], reports),
+		Depth:       depth,
+	}, nil
+}
+
+// buildReportsTree 按直接上级将下属挂到各自的上级节点下，reports需按层级从浅到深排列
+func buildReportsTree(root *database.Employee, reports []*database.Employee) *OrgChartNode {
+	rootNode := newOrgChartNode(root)
+	nodes := map[uint]*OrgChartNode{root.ID: rootNode}
+	for _, report := range reports {
+		if report.DirectManagerID == nil {
+			continue
+		}
+		parent, ok := nodes[*report.DirectManagerID]
+		if !ok {
+			continue
+		}
+		node := newOrgChartNode(report)
+		parent.Reports = append(parent.Reports, node)
+		nodes[report.ID] = node
+	}
+	return rootNode
+}
+
+// newOrgChartNode 将员工转换为组织架构图节点
+func newOrgChartNode(employee *database.Employee) *OrgChartNode {
+	return &OrgChartNode{
+		ID:               employee.ID,
+		EmployeeNo:       employee.EmployeeNo,
+		Name:             employee.User.RealName,
+		DirectManagerID:  employee.DirectManagerID,
+		Position:         employee.Position.Name,
+		DepartmentID:     employee.DepartmentID,
+		Department:       employee.Department.Name,
+		OnboardingStatus: employee.OnboardingStatus,
+		Status:           employee.Status,
+	}
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/config"
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+// orgChartEmployeeRepository 按直接上级遍历内存中员工的仓储桩
+type orgChartEmployeeRepository struct {
+	repository.EmployeeRepository
+	employees map[uint]*database.Employee
+	maxLevels int
+	maxDepth  int
+}
+
+func (r *orgChartEmployeeRepository) ListManagerChain(ctx context.Context, employeeID uint, maxLevels int) ([]*database.Employee, error) {
+	r.maxLevels = maxLevels
+	var chain []*database.Employee
+	for employee := r.employees[employeeID]; employee != nil && len(chain) <= maxLevels; {
+		chain = append(chain, employee)
+		if employee.DirectManagerID == nil {
+			break
+		}
+		employee = r.employees[*employee.DirectManagerID]
+	}
+	return chain, nil
+}
+
+func (r *orgChartEmployeeRepository) ListReports(ctx context.Context, employeeID uint, maxDepth int) ([]*database.Employee, error) {
+	r.maxDepth = maxDepth
+	var reports []*database.Employee
+	frontier := []uint{employeeID}
+	for depth := 1; depth <= maxDepth; depth++ {
+		var next []uint
+		for id := uint(1); id <= uint(len(r.employees)); id++ {
+			employee := r.employees[id]
+			for _, managerID := range frontier {
+				if employee.DirectManagerID != nil && *employee.DirectManagerID == managerID {
+					reports = append(reports, employee)
+					next = append(next, id)
+				}
+			}
+		}
+		frontier = next
+	}
+	return reports, nil
+}
+
+func orgChartEmployee(id uint, managerID uint, position, onboardingStatus string) *database.Employee {
+	employee := &database.Employee{
+		EmployeeNo:       "EMP",
+		OnboardingStatus: onboardingStatus,
+		User:             database.User{RealName: position + "员工"},
+		Position:         database.Position{Name: position},
+		Department:       database.Department{Name: "研发部"},
+	}
+	employee.ID = id
+	if managerID != 0 {
+		employee.DirectManagerID = &managerID
+	}
+	return employee
+}
+
+func TestGetOrgChart(t *testing.T) {
+	repo := &orgChartEmployeeRepository{employees: map[uint]*database.Employee{
+		1: orgChartEmployee(1, 0, "CTO", "active"),
+		2: orgChartEmployee(2, 1, "总监", "active"),
+		3: orgChartEmployee(3, 2, "经理", "active"),
+		4: orgChartEmployee(4, 3, "工程师", "probation"),
+		5: orgChartEmployee(5, 3, "工程师", "active"),
+		6: orgChartEmployee(6, 4, "实习生", "probation"),
+	}}
+	svc := NewEmployeeService(repo, nil, nil, nil, nil, nil, nil, nil, nil, config.WorkloadConfig{})
+
+	chart, err := svc.GetOrgChart(context.Background(), 3, 0)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultOrgChartDepth, repo.maxDepth)
+	assert.Equal(t, maxManagerChainLevels, repo.maxLevels)
+
+	require.Len(t, chart.Managers, 2)
+	assert.Equal(t, uint(2), chart.Managers[0].ID)
+	assert.Equal(t, "CTO", chart.Managers[1].Position)
+
+	root := chart.ReportsTree
+	assert.Equal(t, uint(3), root.ID)
+	require.Len(t, root.Reports, 2)
+	assert.Equal(t, "probation", root.Reports[0].OnboardingStatus)
+	require.Len(t, root.Reports[0].Reports, 1)
+	assert.Equal(t, uint(6), root.Reports[0].Reports[0].ID)
+	assert.Equal(t, "研发部", root.Reports[0].Reports[0].Department)
+
+	// 只展开一层下属
+	chart, err = svc.GetOrgChart(context.Background(), 3, 1)
+	require.NoError(t, err)
+	assert.Empty(t, chart.ReportsTree.Reports[0].Reports)
+
+	_, err = svc.GetOrgChart(context.Background(), 99, 1)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+}
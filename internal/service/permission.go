@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"taskmanage/internal/cache"
+	"taskmanage/internal/repository"
+	"taskmanage/pkg/logger"
+)
+
+// PermissionService 用户有效权限服务，负责权限检查及权限缓存的维护
+type PermissionService interface {
+	// HasPermission 检查用户是否拥有resource:action权限
+	HasPermission(ctx context.Context, userID uint, resource, action string) (bool, error)
+	// GetEffectivePermissions 获取用户有效权限，格式为resource:action
+	GetEffectivePermissions(ctx context.Context, userID uint) ([]string, error)
+	// Invalidate 用户角色或权限分配变更后清除其权限缓存
+	Invalidate(ctx context.Context, userID uint) error
+	// InvalidateAll 角色权限变更后清除全部用户的权限缓存
+	InvalidateAll(ctx context.Context) error
+	// CacheStats 权限缓存命中统计，未启用缓存时为零值
+	CacheStats() cache.CacheStats
+}
+
+// permissionService 权限服务实现
+type permissionService struct {
+	repoManager repository.RepositoryManager
+	cache       *cache.PermissionCache
+}
+
+// NewPermissionService 创建权限服务，permissionCache为nil时每次都查询数据库
+func NewPermissionService(repoManager repository.RepositoryManager, permissionCache *cache.PermissionCache) PermissionService {
+	return &permissionService{
+		repoManager: repoManager,
+		cache:       permissionCache,
+	}
+}
+
+// permissionKey 权限标识
+func permissionKey(resource, action string) string {
+	return resource + ":" + action
+}
+
+// HasPermission 检查用户是否拥有resource:action权限
+func (s *permissionService) HasPermission(ctx context.Context, userID uint, resource, action string) (bool, error) {
+	permissions, err := s.GetEffectivePermissions(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+
+	target := permissionKey(resource, action)
+	for _, permission := range permissions {
+		if permission == target {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// GetEffectivePermissions 获取用户有效权限，优先读取缓存，缓存异常时回源数据库，缓存关闭回源时返回错误
+func (s *permissionService) GetEffectivePermissions(ctx context.Context, userID uint) ([]string, error) {
+	if s.cache != nil {
+		permissions, ok, err := s.cache.Get(ctx, userID)
+		if err != nil {
+			if !s.cache.Fallback() {
+				return nil, err
+			}
+			logger.Warnf("读取权限缓存失败，回源数据库: user=%d, error=%v", userID, err)
+		} else if ok {
+			return permissions, nil
+		}
+	}
+
+	records, err := s.repoManager.PermissionRepository().GetEffectivePermissions(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("获取用户权限失败: %w", err)
+	}
+	permissions := make([]string, 0, len(records))
+	for _, record := range records {
+		permissions = append(permissions, permissionKey(record.Resource, record.Action))
+	}
+
+	if s.cache != nil {
+		if err := s.cache.Set(ctx, userID, permissions); err != nil {
+			logger.Warnf("写入权限缓存失败: user=%d, error=%v", userID, err)
+		}
+	}
+	return permissions, nil
+}
+
+// Invalidate 清除用户权限缓存
+func (s *permissionService) Invalidate(ctx context.Context, userID uint) error {
+	if s.cache == nil {
+		return nil
+	}
+	return s.cache.Invalidate(ctx, userID)
+}
+
+// InvalidateAll 清除全部用户的权限缓存
+func (s *permissionService) InvalidateAll(ctx context.Context) error {
+	if s.cache == nil {
+		return nil
+	}
+	return s.cache.InvalidateAll(ctx)
+}
+
+// CacheStats 权限缓存命中统计
+func (s *permissionService) CacheStats() cache.CacheStats {
+	if s.cache == nil {
+		return cache.CacheStats{}
+	}
+	return s.cache.Stats()
+}
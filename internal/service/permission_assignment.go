@@ -2,12 +2,14 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"taskmanage/internal/database"
 	"taskmanage/internal/repository"
 	"taskmanage/pkg/logger"
+	"taskmanage/pkg/response"
 )
 
 // PermissionAssignmentService 权限分配服务接口
@@ -17,7 +19,7 @@ type PermissionAssignmentService interface {
 	GetPermissionTemplate(ctx context.Context, id uint) (*PermissionTemplateResponse, error)
 	ListPermissionTemplates(ctx context.Context, req *ListPermissionTemplatesRequest) (*ListPermissionTemplatesResponse, error)
 	UpdatePermissionTemplate(ctx context.Context, id uint, req *UpdatePermissionTemplateRequest) (*PermissionTemplateResponse, error)
-	DeletePermissionTemplate(ctx context.Context, id uint) error
+	DeletePermissionTemplate(ctx context.Context, id uint, deactivate bool) error
 	
 	// 权限模板初始化
 	InitializePermissionTemplates(ctx context.Context) error
@@ -37,6 +39,7 @@ type PermissionAssignmentService interface {
 	ListPermissionAssignments(ctx context.Context, req *ListPermissionAssignmentsRequest) (*ListPermissionAssignmentsResponse, error)
 	UpdatePermissionAssignment(ctx context.Context, id uint, req *UpdatePermissionAssignmentRequest) (*PermissionAssignmentResponse, error)
 	RevokePermissionAssignment(ctx context.Context, id uint, reason string, operatorID uint) error
+	RevokeUserPermissionAssignments(ctx context.Context, userID uint, reason string, operatorID uint) (int, error)
 	
 	// 权限分配历史
 	GetPermissionAssignmentHistory(ctx context.Context, req *GetPermissionAssignmentHistoryRequest) (*ListPermissionAssignmentHistoryResponse, error)
@@ -49,24 +52,47 @@ type PermissionAssignmentService interface {
 	DeleteOnboardingPermissionConfig(ctx context.Context, id uint) error
 	
 	// 自动权限分配
+	ProcessTenureRules(ctx context.Context, now time.Time) (*TenurePermissionReport, error)
 	ProcessOnboardingPermissionAssignment(ctx context.Context, userID uint, onboardingStatus string, departmentID, positionID *uint) error
 	EvaluatePermissionRules(ctx context.Context, userID uint, triggerCondition, value string) ([]*database.PermissionRule, error)
-	ApplyPermissionTemplate(ctx context.Context, userID uint, templateID uint, operatorID uint, reason string) (*PermissionAssignmentResponse, error)
+	ApplyPermissionTemplate(ctx context.Context, userID uint, templateID uint, operatorID uint, reason string, replaceExisting bool) (*PermissionAssignmentResponse, error)
+	PreviewPermissionTemplateApplication(ctx context.Context, userID uint, templateID uint, replaceExisting bool) (*PermissionTemplatePreviewResponse, error)
 	
 	// 权限审批
 	ProcessPermissionApproval(ctx context.Context, assignmentID uint, approved bool, approverID uint, comments string) error
 	GetPendingPermissionApprovals(ctx context.Context, approverID uint) ([]*PermissionAssignmentResponse, error)
+
+	// 职位变更
+	ProcessPositionChange(ctx context.Context, userID uint, oldLevel, newLevel int, operatorID uint) (*PositionPermissionChangeResponse, error)
+
+	// 任务可见范围
+	ResolveTaskScope(ctx context.Context, userID uint) (*TaskVisibility, error)
 }
 
+// ErrPermissionApprovalNotPending 权限分配不是待审批状态
+var ErrPermissionApprovalNotPending = response.NewError(response.ErrCodeConflict, "权限分配不是待审批状态")
+
 // PermissionAssignmentServiceImpl 权限分配服务实现
 type PermissionAssignmentServiceImpl struct {
-	repos repository.RepositoryManager
+	repos             repository.RepositoryManager
+	permissionService PermissionService
 }
 
-// NewPermissionAssignmentService 创建权限分配服务
-func NewPermissionAssignmentService(repos repository.RepositoryManager) PermissionAssignmentService {
+// NewPermissionAssignmentService 创建权限分配服务，分配变更后通过permissionService清除用户权限缓存
+func NewPermissionAssignmentService(repos repository.RepositoryManager, permissionService PermissionService) PermissionAssignmentService {
 	return &PermissionAssignmentServiceImpl{
-		repos: repos,
+		repos:             repos,
+		permissionService: permissionService,
+	}
+}
+
+// invalidatePermissions 清除用户权限缓存，失败时仅记录日志，缓存会在过期后自动刷新
+func (s *PermissionAssignmentServiceImpl) invalidatePermissions(ctx context.Context, userID uint) {
+	if s.permissionService == nil {
+		return
+	}
+	if err := s.permissionService.Invalidate(ctx, userID); err != nil {
+		logger.Warnf("清除用户权限缓存失败: user=%d, error=%v", userID, err)
 	}
 }
 
@@ -82,6 +108,15 @@ func (s *PermissionAssignmentServiceImpl) CreatePermissionTemplate(ctx context.C
 		taskScope = "assigned"
 	}
 
+	if err := validatePermissionTemplateScopes(projectScope, taskScope); err != nil {
+		return nil, err
+	}
+	if _, err := s.repos.PermissionTemplateRepository().GetByCode(ctx, req.Code); err == nil {
+		return nil, fmt.Errorf("%w: %s", ErrPermissionTemplateExists, req.Code)
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("检查模板编码失败: %w", err)
+	}
+
 	template := &database.PermissionTemplate{
 		Name:             req.Name,
 		Code:             req.Code,
@@ -166,7 +201,7 @@ func (s *PermissionAssignmentServiceImpl) ProcessOnboardingPermissionAssignment(
 
 	// 应用默认权限模板
 	if config.DefaultTemplateID != nil {
-		_, err := s.ApplyPermissionTemplate(ctx, userID, *config.DefaultTemplateID, 0, fmt.Sprintf("入职自动分配权限: %s", onboardingStatus))
+		_, err := s.ApplyPermissionTemplate(ctx, userID, *config.DefaultTemplateID, 0, fmt.Sprintf("入职自动分配权限: %s", onboardingStatus), false)
 		if err != nil {
 			logger.Errorf("应用默认权限模板失败: %v", err)
 			return fmt.Errorf("应用默认权限模板失败: %w", err)
@@ -182,8 +217,12 @@ func (s *PermissionAssignmentServiceImpl) ProcessOnboardingPermissionAssignment(
 	return nil
 }
 
-// EvaluatePermissionRules 评估权限规则
+// EvaluatePermissionRules 评估权限规则，司龄类触发条件按天数阈值匹配，其余触发条件按条件值精确匹配
 func (s *PermissionAssignmentServiceImpl) EvaluatePermissionRules(ctx context.Context, userID uint, triggerCondition, value string) ([]*database.PermissionRule, error) {
+	if isTenureTrigger(triggerCondition) {
+		return s.evaluateTenureRules(ctx, triggerCondition, value)
+	}
+
 	rules, err := s.repos.PermissionRuleRepository().GetByTriggerCondition(ctx, triggerCondition, value)
 	if err != nil {
 		return nil, fmt.Errorf("获取权限规则失败: %w", err)
@@ -199,8 +238,8 @@ func (s *PermissionAssignmentServiceImpl) EvaluatePermissionRules(ctx context.Co
 	return applicableRules, nil
 }
 
-// ApplyPermissionTemplate 应用权限模板
-func (s *PermissionAssignmentServiceImpl) ApplyPermissionTemplate(ctx context.Context, userID uint, templateID uint, operatorID uint, reason string) (*PermissionAssignmentResponse, error) {
+// ApplyPermissionTemplate 应用权限模板，replaceExisting为true时在同一事务中撤销用户其它通过模板分配的权限并记录撤销历史
+func (s *PermissionAssignmentServiceImpl) ApplyPermissionTemplate(ctx context.Context, userID uint, templateID uint, operatorID uint, reason string, replaceExisting bool) (*PermissionAssignmentResponse, error) {
 	_, err := s.repos.PermissionTemplateRepository().GetByID(ctx, templateID)
 	if err != nil {
 		return nil, fmt.Errorf("获取权限模板失败: %w", err)
@@ -222,26 +261,88 @@ func (s *PermissionAssignmentServiceImpl) ApplyPermissionTemplate(ctx context.Co
 	// 	assignment.Status = database.PermissionStatusPending
 	// }
 
-	if err := s.repos.PermissionAssignmentRepository().Create(ctx, assignment); err != nil {
-		logger.Errorf("创建权限分配失败: %v", err)
-		return nil, fmt.Errorf("创建权限分配失败: %w", err)
+	var revoked []*database.PermissionAssignment
+	err = s.repos.WithTx(ctx, func(ctx context.Context, repos repository.RepositoryManager) error {
+		if replaceExisting {
+			if revoked, err = revokeOtherTemplateAssignments(ctx, repos, userID, templateID, reason, operatorID); err != nil {
+				return err
+			}
+		}
+
+		if err := repos.PermissionAssignmentRepository().Create(ctx, assignment); err != nil {
+			return fmt.Errorf("创建权限分配失败: %w", err)
+		}
+
+		// 记录分配历史
+		history := &database.PermissionAssignmentHistory{
+			AssignmentID: assignment.ID,
+			Action:       "assign",
+			Reason:       reason,
+			OperatorID:   operatorID,
+			OperatedAt:   time.Now(),
+			NewStatus:    assignment.Status,
+		}
+		if err := repos.PermissionAssignmentHistoryRepository().Create(ctx, history); err != nil {
+			return fmt.Errorf("记录权限分配历史失败: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Errorf("应用权限模板失败: %v", err)
+		return nil, err
 	}
+	s.invalidatePermissions(ctx, userID)
 
-	// 记录分配历史
-	history := &database.PermissionAssignmentHistory{
-		AssignmentID: assignment.ID,
-		Action:       "assign",
-		Reason:       reason,
-		OperatorID:   operatorID,
-		OperatedAt:   time.Now(),
+	logger.Infof("成功应用权限模板: user=%d, template=%d, assignment=%d, revoked=%d", userID, templateID, assignment.ID, len(revoked))
+	return s.buildPermissionAssignmentResponse(assignment), nil
+}
+
+// revokeOtherTemplateAssignments 撤销用户除templateID外其它通过模板分配且生效中或待审批的权限，逐条记录撤销历史
+func revokeOtherTemplateAssignments(ctx context.Context, repos repository.RepositoryManager, userID, templateID uint, reason string, operatorID uint) ([]*database.PermissionAssignment, error) {
+	assignments, err := repos.PermissionAssignmentRepository().GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("获取用户权限分配失败: %w", err)
 	}
 
-	if err := s.repos.PermissionAssignmentHistoryRepository().Create(ctx, history); err != nil {
-		logger.Warnf("记录权限分配历史失败: %v", err)
+	var revoked []*database.PermissionAssignment
+	for _, assignment := range replacedTemplateAssignments(assignments, templateID) {
+		oldStatus := assignment.Status
+		assignment.Status = database.PermissionStatusRevoked
+		if err := repos.PermissionAssignmentRepository().Update(ctx, assignment); err != nil {
+			return nil, fmt.Errorf("撤销权限分配失败: %w", err)
+		}
+
+		history := &database.PermissionAssignmentHistory{
+			AssignmentID: assignment.ID,
+			Action:       "revoked",
+			Reason:       reason,
+			OperatorID:   operatorID,
+			OperatedAt:   time.Now(),
+			OldStatus:    oldStatus,
+			NewStatus:    database.PermissionStatusRevoked,
+			Notes:        fmt.Sprintf("由权限模板%d替换", templateID),
+		}
+		if err := repos.PermissionAssignmentHistoryRepository().Create(ctx, history); err != nil {
+			return nil, fmt.Errorf("记录权限撤销历史失败: %w", err)
+		}
+		revoked = append(revoked, assignment)
 	}
+	return revoked, nil
+}
 
-	logger.Infof("成功应用权限模板: user=%d, template=%d, assignment=%d", userID, templateID, assignment.ID)
-	return s.buildPermissionAssignmentResponse(assignment), nil
+// replacedTemplateAssignments 筛选替换模板时需要撤销的分配：其它模板的生效中或待审批分配，直接分配的权限不受影响
+func replacedTemplateAssignments(assignments []*database.PermissionAssignment, templateID uint) []*database.PermissionAssignment {
+	var replaced []*database.PermissionAssignment
+	for _, assignment := range assignments {
+		if assignment.TemplateID == nil || *assignment.TemplateID == templateID {
+			continue
+		}
+		if assignment.Status != database.PermissionStatusActive && assignment.Status != database.PermissionStatusPending {
+			continue
+		}
+		replaced = append(replaced, assignment)
+	}
+	return replaced
 }
 
 // 辅助方法：构建权限模板响应
@@ -296,41 +397,6 @@ func (s *PermissionAssignmentServiceImpl) buildPermissionAssignmentResponse(assi
 }
 
 // 占位符实现，需要根据具体需求完善
-func (s *PermissionAssignmentServiceImpl) UpdatePermissionTemplate(ctx context.Context, id uint, req *UpdatePermissionTemplateRequest) (*PermissionTemplateResponse, error) {
-	// TODO: 实现更新权限模板
-	return nil, fmt.Errorf("功能待实现")
-}
-
-func (s *PermissionAssignmentServiceImpl) DeletePermissionTemplate(ctx context.Context, id uint) error {
-	// TODO: 实现删除权限模板
-	return fmt.Errorf("功能待实现")
-}
-
-func (s *PermissionAssignmentServiceImpl) CreatePermissionRule(ctx context.Context, req *CreatePermissionRuleRequest) (*PermissionRuleResponse, error) {
-	// TODO: 实现创建权限规则
-	return nil, fmt.Errorf("功能待实现")
-}
-
-func (s *PermissionAssignmentServiceImpl) GetPermissionRule(ctx context.Context, id uint) (*PermissionRuleResponse, error) {
-	// TODO: 实现获取权限规则
-	return nil, fmt.Errorf("功能待实现")
-}
-
-func (s *PermissionAssignmentServiceImpl) ListPermissionRules(ctx context.Context, req *ListPermissionRulesRequest) (*ListPermissionRulesResponse, error) {
-	// TODO: 实现获取权限规则列表
-	return nil, fmt.Errorf("功能待实现")
-}
-
-func (s *PermissionAssignmentServiceImpl) UpdatePermissionRule(ctx context.Context, id uint, req *UpdatePermissionRuleRequest) (*PermissionRuleResponse, error) {
-	// TODO: 实现更新权限规则
-	return nil, fmt.Errorf("功能待实现")
-}
-
-func (s *PermissionAssignmentServiceImpl) DeletePermissionRule(ctx context.Context, id uint) error {
-	// TODO: 实现删除权限规则
-	return fmt.Errorf("功能待实现")
-}
-
 func (s *PermissionAssignmentServiceImpl) AssignPermissions(ctx context.Context, req *AssignPermissionsRequest) (*PermissionAssignmentResponse, error) {
 	// TODO: 实现权限分配
 	return nil, fmt.Errorf("功能待实现")
@@ -351,9 +417,67 @@ func (s *PermissionAssignmentServiceImpl) UpdatePermissionAssignment(ctx context
 	return nil, fmt.Errorf("功能待实现")
 }
 
+// RevokePermissionAssignment 撤销权限分配
 func (s *PermissionAssignmentServiceImpl) RevokePermissionAssignment(ctx context.Context, id uint, reason string, operatorID uint) error {
-	// TODO: 实现撤销权限分配
-	return fmt.Errorf("功能待实现")
+	assignment, err := s.repos.PermissionAssignmentRepository().GetByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("获取权限分配失败: %w", err)
+	}
+
+	return s.revokeAssignment(ctx, assignment, reason, operatorID)
+}
+
+// RevokeUserPermissionAssignments 撤销用户全部生效中和待审批的权限分配，返回撤销数量
+func (s *PermissionAssignmentServiceImpl) RevokeUserPermissionAssignments(ctx context.Context, userID uint, reason string, operatorID uint) (int, error) {
+	assignments, err := s.repos.PermissionAssignmentRepository().GetByUserID(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("获取用户权限分配失败: %w", err)
+	}
+
+	revoked := 0
+	for _, assignment := range assignments {
+		if assignment.Status != database.PermissionStatusActive && assignment.Status != database.PermissionStatusPending {
+			continue
+		}
+		if err := s.revokeAssignment(ctx, assignment, reason, operatorID); err != nil {
+			return revoked, err
+		}
+		revoked++
+	}
+
+	logger.Infof("已撤销用户权限分配: user=%d, count=%d", userID, revoked)
+	return revoked, nil
+}
+
+// revokeAssignment 将权限分配置为已撤销并记录历史
+func (s *PermissionAssignmentServiceImpl) revokeAssignment(ctx context.Context, assignment *database.PermissionAssignment, reason string, operatorID uint) error {
+	if assignment.Status == database.PermissionStatusRevoked {
+		return nil
+	}
+
+	oldStatus := assignment.Status
+	assignment.Status = database.PermissionStatusRevoked
+	if err := s.repos.PermissionAssignmentRepository().Update(ctx, assignment); err != nil {
+		logger.Errorf("撤销权限分配失败: %v", err)
+		return fmt.Errorf("撤销权限分配失败: %w", err)
+	}
+	s.invalidatePermissions(ctx, assignment.UserID)
+
+	history := &database.PermissionAssignmentHistory{
+		AssignmentID: assignment.ID,
+		Action:       "revoked",
+		Reason:       reason,
+		OperatorID:   operatorID,
+		OperatedAt:   time.Now(),
+		OldStatus:    oldStatus,
+		NewStatus:    database.PermissionStatusRevoked,
+	}
+
+	if err := s.repos.PermissionAssignmentHistoryRepository().Create(ctx, history); err != nil {
+		logger.Warnf("记录权限撤销历史失败: %v", err)
+	}
+
+	return nil
 }
 
 func (s *PermissionAssignmentServiceImpl) GetPermissionAssignmentHistory(ctx context.Context, req *GetPermissionAssignmentHistoryRequest) (*ListPermissionAssignmentHistoryResponse, error) {
@@ -386,12 +510,61 @@ func (s *PermissionAssignmentServiceImpl) DeleteOnboardingPermissionConfig(ctx c
 	return fmt.Errorf("功能待实现")
 }
 
+// ProcessPermissionApproval 处理待审批的权限分配：批准后生效，拒绝后撤销
+// 降级后待复核的分配批准即保留，拒绝即撤销
 func (s *PermissionAssignmentServiceImpl) ProcessPermissionApproval(ctx context.Context, assignmentID uint, approved bool, approverID uint, comments string) error {
-	// TODO: 实现权限审批
-	return fmt.Errorf("功能待实现")
+	assignment, err := s.repos.PermissionAssignmentRepository().GetByID(ctx, assignmentID)
+	if err != nil {
+		return fmt.Errorf("获取权限分配失败: %w", err)
+	}
+	if assignment.ApprovalStatus != database.ApprovalStatusPending {
+		return ErrPermissionApprovalNotPending
+	}
+
+	action := "approved"
+	if !approved {
+		action = "rejected"
+	}
+	now := time.Now()
+	err = s.repos.WithTx(ctx, func(ctx context.Context, repos repository.RepositoryManager) error {
+		return updateAssignmentWithHistory(ctx, repos, assignment, action, comments, "", approverID, func(a *database.PermissionAssignment) {
+			a.ApprovedBy = &approverID
+			a.ApprovedAt = &now
+			a.ApprovalComments = comments
+			if approved {
+				a.ApprovalStatus = database.ApprovalStatusApproved
+				a.Status = database.PermissionStatusActive
+			} else {
+				a.ApprovalStatus = database.ApprovalStatusRejected
+				a.Status = database.PermissionStatusRevoked
+			}
+		})
+	})
+	if err != nil {
+		logger.Errorf("处理权限审批失败: %v", err)
+		return err
+	}
+	s.invalidatePermissions(ctx, assignment.UserID)
+
+	logger.Infof("权限分配审批完成: assignment=%d, action=%s, approver=%d", assignmentID, action, approverID)
+	return nil
 }
 
+// GetPendingPermissionApprovals 获取待审批的权限分配，包括规则触发的待审批分配和降级后待复核的分配
 func (s *PermissionAssignmentServiceImpl) GetPendingPermissionApprovals(ctx context.Context, approverID uint) ([]*PermissionAssignmentResponse, error) {
-	// TODO: 实现获取待审批权限
-	return nil, fmt.Errorf("功能待实现")
+	assignments, err := s.repos.PermissionAssignmentRepository().GetPendingApprovals(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取待审批权限失败: %w", err)
+	}
+
+	responses := make([]*PermissionAssignmentResponse, 0, len(assignments))
+	for _, assignment := range assignments {
+		resp := s.buildPermissionAssignmentResponse(assignment)
+		if assignment.Template != nil {
+			resp.Template = s.buildPermissionTemplateResponse(assignment.Template)
+		}
+		resp.Reason = assignment.ApprovalComments
+		responses = append(responses, resp)
+	}
+	return responses, nil
 }
@@ -27,6 +27,7 @@ type CreatePermissionTemplateRequest struct {
 
 // UpdatePermissionTemplateRequest 更新权限模板请求
 type UpdatePermissionTemplateRequest struct {
+	Code             *string                   `json:"code"` // 仅用于校验，编码创建后不可修改
 	Name             *string                   `json:"name"`
 	Description      *string                   `json:"description"`
 	Category         *string                   `json:"category"`
@@ -54,6 +55,30 @@ type ListPermissionTemplatesRequest struct {
 	Search       string `json:"search" form:"search"`
 }
 
+// PreviewPermissionTemplateRequest 预览权限模板应用请求
+type PreviewPermissionTemplateRequest struct {
+	UserID          uint `json:"user_id" binding:"required"`
+	ReplaceExisting bool `json:"replace_existing"` // 按替换其它模板分配的方式预览
+}
+
+// ApplyPermissionTemplateRequest 应用权限模板请求
+type ApplyPermissionTemplateRequest struct {
+	UserID          uint   `json:"user_id" binding:"required"`
+	Reason          string `json:"reason"`
+	ReplaceExisting bool   `json:"replace_existing"` // 同时撤销用户其它通过模板分配的权限
+}
+
+// PermissionTemplatePreviewResponse 权限模板应用预览，对比应用前后的有效权限
+type PermissionTemplatePreviewResponse struct {
+	UserID             uint                  `json:"user_id"`
+	TemplateID         uint                  `json:"template_id"`
+	ReplaceExisting    bool                  `json:"replace_existing"`
+	Added              []*PermissionResponse `json:"added"`
+	Removed            []*PermissionResponse `json:"removed"`
+	Unchanged          []*PermissionResponse `json:"unchanged"`
+	RevokedAssignments []uint                `json:"revoked_assignments"` // 替换时将被撤销的权限分配ID
+}
+
 // PermissionTemplateResponse 权限模板响应
 type PermissionTemplateResponse struct {
 	ID               uint                  `json:"id"`
@@ -92,9 +117,10 @@ type CreatePermissionRuleRequest struct {
 	Description      string `json:"description"`
 	TriggerCondition string `json:"trigger_condition" binding:"required"`
 	ConditionValue   string `json:"condition_value" binding:"required"`
-	Action           string `json:"action" binding:"required"`
+	Action           string `json:"action" binding:"required,oneof=grant revoke upgrade"`
 	Priority         int    `json:"priority"`
 	DelayDays        int    `json:"delay_days"`
+	AutoExecute      *bool  `json:"auto_execute"` // 是否由定时任务自动执行，默认true
 	RequireApproval  bool   `json:"require_approval"`
 }
 
@@ -107,6 +133,7 @@ type UpdatePermissionRuleRequest struct {
 	Action           *string `json:"action"`
 	Priority         *int    `json:"priority"`
 	DelayDays        *int    `json:"delay_days"`
+	AutoExecute      *bool   `json:"auto_execute"`
 	RequireApproval  *bool   `json:"require_approval"`
 	IsActive         *bool   `json:"is_active"`
 }
@@ -134,6 +161,7 @@ type PermissionRuleResponse struct {
 	Action           string                      `json:"action"`
 	Priority         int                         `json:"priority"`
 	DelayDays        int                         `json:"delay_days"`
+	AutoExecute      bool                        `json:"auto_execute"`
 	RequireApproval  bool                        `json:"require_approval"`
 	IsActive         bool                        `json:"is_active"`
 	CreatedAt        time.Time                   `json:"created_at"`
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+	"taskmanage/pkg/logger"
+)
+
+// PermissionTriggerPositionChange 职位职级变化的权限规则触发条件，规则的ConditionValue为新职级
+const PermissionTriggerPositionChange = "position_change"
+
+// PositionPermissionChangeResponse 职级变化引起的权限调整结果
+type PositionPermissionChangeResponse struct {
+	OldLevel int                             `json:"old_level"`
+	NewLevel int                             `json:"new_level"`
+	Granted  []*PermissionAssignmentResponse `json:"granted"` // 规则新建的分配，需要审批的为待审批状态
+	Revoked  []*PermissionAssignmentResponse `json:"revoked"` // 规则撤销的分配
+	Flagged  []*PermissionAssignmentResponse `json:"flagged"` // 降级后超出新职级、转为待复核的分配
+}
+
+// positionChangeTriggerData 记录在权限分配TriggerData中的职级变化
+type positionChangeTriggerData struct {
+	OldLevel int `json:"old_level"`
+	NewLevel int `json:"new_level"`
+}
+
+// ProcessPositionChange 按新职级评估position_change规则并调整用户权限
+// grant/upgrade规则为用户分配规则模板，规则要求审批时分配为待审批；revoke规则撤销该模板的分配。
+// 降级时用户已生效的模板分配中级别高于新职级的转为待复核，复核期间不生效，出现在待审批权限中
+func (s *PermissionAssignmentServiceImpl) ProcessPositionChange(ctx context.Context, userID uint, oldLevel, newLevel int, operatorID uint) (*PositionPermissionChangeResponse, error) {
+	result := &PositionPermissionChangeResponse{
+		OldLevel: oldLevel,
+		NewLevel: newLevel,
+		Granted:  []*PermissionAssignmentResponse{},
+		Revoked:  []*PermissionAssignmentResponse{},
+		Flagged:  []*PermissionAssignmentResponse{},
+	}
+	if oldLevel == newLevel {
+		return result, nil
+	}
+
+	rules, err := s.EvaluatePermissionRules(ctx, userID, PermissionTriggerPositionChange, strconv.Itoa(newLevel))
+	if err != nil {
+		return nil, err
+	}
+	triggerData, err := json.Marshal(positionChangeTriggerData{OldLevel: oldLevel, NewLevel: newLevel})
+	if err != nil {
+		return nil, fmt.Errorf("序列化触发数据失败: %w", err)
+	}
+	reason := fmt.Sprintf("职级由%d变为%d", oldLevel, newLevel)
+
+	err = s.repos.WithTx(ctx, func(ctx context.Context, repos repository.RepositoryManager) error {
+		assignments, err := repos.PermissionAssignmentRepository().GetByUserID(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("获取用户权限分配失败: %w", err)
+		}
+
+		for _, rule := range rules {
+			switch rule.Action {
+			case "grant", "upgrade":
+				if holdsTemplate(assignments, rule.TemplateID) {
+					continue
+				}
+				assignment, err := grantRuleTemplate(ctx, repos, userID, rule, PermissionTriggerPositionChange, string(triggerData), reason, operatorID)
+				if err != nil {
+					return err
+				}
+				assignments = append(assignments, assignment)
+				result.Granted = append(result.Granted, s.buildPermissionAssignmentResponse(assignment))
+			case "revoke":
+				for _, assignment := range assignments {
+					if !isTemplateAssignmentInEffect(assignment, rule.TemplateID) {
+						continue
+					}
+					if err := updateAssignmentWithHistory(ctx, repos, assignment, "revoked", reason, fmt.Sprintf("权限规则: %s", rule.Name), operatorID, func(a *database.PermissionAssignment) {
+						a.Status = database.PermissionStatusRevoked
+					}); err != nil {
+						return err
+					}
+					result.Revoked = append(result.Revoked, s.buildPermissionAssignmentResponse(assignment))
+				}
+			}
+		}
+
+		if newLevel >= oldLevel {
+			return nil
+		}
+		for _, assignment := range assignments {
+			if assignment.Template == nil || assignment.Template.Level <= newLevel ||
+				assignment.Status != database.PermissionStatusActive || assignment.ApprovalStatus != database.ApprovalStatusApproved {
+				continue
+			}
+			notes := fmt.Sprintf("权限模板级别%d高于新职级%d，待复核", assignment.Template.Level, newLevel)
+			if err := updateAssignmentWithHistory(ctx, repos, assignment, "review", reason, notes, operatorID, func(a *database.PermissionAssignment) {
+				a.ApprovalStatus = database.ApprovalStatusPending
+				a.ApprovalComments = notes
+			}); err != nil {
+				return err
+			}
+			result.Flagged = append(result.Flagged, s.buildPermissionAssignmentResponse(assignment))
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Errorf("处理职级变化权限调整失败: user=%d, %v", userID, err)
+		return nil, err
+	}
+	s.invalidatePermissions(ctx, userID)
+
+	logger.Infof("职级变化权限调整完成: user=%d, level=%d->%d, granted=%d, revoked=%d, flagged=%d",
+		userID, oldLevel, newLevel, len(result.Granted), len(result.Revoked), len(result.Flagged))
+	return result, nil
+}
+
+// grantRuleTemplate 按规则为用户分配模板并记录历史，规则要求审批时分配为待审批
+func grantRuleTemplate(ctx context.Context, repos repository.RepositoryManager, userID uint, rule *database.PermissionRule, triggerEvent, triggerData, reason string, operatorID uint) (*database.PermissionAssignment, error) {
+	templateID, ruleID := rule.TemplateID, rule.ID
+	assignment := &database.PermissionAssignment{
+		UserID:         userID,
+		TemplateID:     &templateID,
+		RuleID:         &ruleID,
+		Status:         database.PermissionStatusActive,
+		ApprovalStatus: database.ApprovalStatusApproved,
+		AssignedBy:     operatorID,
+		AssignedAt:     time.Now(),
+		TriggerEvent:   triggerEvent,
+		TriggerData:    triggerData,
+	}
+	if rule.RequireApproval {
+		assignment.Status = database.PermissionStatusPending
+		assignment.ApprovalStatus = database.ApprovalStatusPending
+	}
+	if rule.Template.ID != 0 {
+		template := rule.Template
+		assignment.Template = &template
+	}
+
+	if err := repos.PermissionAssignmentRepository().Create(ctx, assignment); err != nil {
+		return nil, fmt.Errorf("创建权限分配失败: %w", err)
+	}
+	history := &database.PermissionAssignmentHistory{
+		AssignmentID: assignment.ID,
+		Action:       "assign",
+		Reason:       reason,
+		OperatorID:   operatorID,
+		OperatedAt:   time.Now(),
+		NewStatus:    assignment.Status,
+		Notes:        fmt.Sprintf("权限规则: %s", rule.Name),
+	}
+	if err := repos.PermissionAssignmentHistoryRepository().Create(ctx, history); err != nil {
+		return nil, fmt.Errorf("记录权限分配历史失败: %w", err)
+	}
+	return assignment, nil
+}
+
+// updateAssignmentWithHistory 修改并保存权限分配，同时记录历史
+func updateAssignmentWithHistory(ctx context.Context, repos repository.RepositoryManager, assignment *database.PermissionAssignment, action, reason, notes string, operatorID uint, update func(*database.PermissionAssignment)) error {
+	oldStatus := assignment.Status
+	update(assignment)
+	if err := repos.PermissionAssignmentRepository().Update(ctx, assignment); err != nil {
+		return fmt.Errorf("更新权限分配失败: %w", err)
+	}
+	history := &database.PermissionAssignmentHistory{
+		AssignmentID: assignment.ID,
+		Action:       action,
+		Reason:       reason,
+		OperatorID:   operatorID,
+		OperatedAt:   time.Now(),
+		OldStatus:    oldStatus,
+		NewStatus:    assignment.Status,
+		Notes:        notes,
+	}
+	if err := repos.PermissionAssignmentHistoryRepository().Create(ctx, history); err != nil {
+		return fmt.Errorf("记录权限分配历史失败: %w", err)
+	}
+	return nil
+}
+
+// holdsTemplate 用户是否已有该模板生效中或待审批的分配
+func holdsTemplate(assignments []*database.PermissionAssignment, templateID uint) bool {
+	for _, assignment := range assignments {
+		if isTemplateAssignmentInEffect(assignment, templateID) {
+			return true
+		}
+	}
+	return false
+}
+
+// isTemplateAssignmentInEffect 分配是否为该模板生效中或待审批的分配
+func isTemplateAssignmentInEffect(assignment *database.PermissionAssignment, templateID uint) bool {
+	if assignment.TemplateID == nil || *assignment.TemplateID != templateID {
+		return false
+	}
+	return assignment.Status == database.PermissionStatusActive || assignment.Status == database.PermissionStatusPending
+}
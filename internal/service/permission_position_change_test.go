@@ -0,0 +1,331 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/config"
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+// memoryAssignmentRepository 测试用内存权限分配仓储
+type memoryAssignmentRepository struct {
+	repository.PermissionAssignmentRepository
+	assignments []*database.PermissionAssignment
+}
+
+func (r *memoryAssignmentRepository) Create(ctx context.Context, assignment *database.PermissionAssignment) error {
+	assignment.ID = uint(len(r.assignments) + 1)
+	r.assignments = append(r.assignments, assignment)
+	return nil
+}
+
+func (r *memoryAssignmentRepository) Update(ctx context.Context, assignment *database.PermissionAssignment) error {
+	return nil
+}
+
+func (r *memoryAssignmentRepository) GetByID(ctx context.Context, id uint) (*database.PermissionAssignment, error) {
+	for _, assignment := range r.assignments {
+		if assignment.ID == id {
+			return assignment, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *memoryAssignmentRepository) GetByUserID(ctx context.Context, userID uint) ([]*database.PermissionAssignment, error) {
+	var result []*database.PermissionAssignment
+	for _, assignment := range r.assignments {
+		if assignment.UserID == userID {
+			result = append(result, assignment)
+		}
+	}
+	return result, nil
+}
+
+func (r *memoryAssignmentRepository) GetPendingApprovals(ctx context.Context) ([]*database.PermissionAssignment, error) {
+	var result []*database.PermissionAssignment
+	for _, assignment := range r.assignments {
+		if assignment.ApprovalStatus == database.ApprovalStatusPending {
+			result = append(result, assignment)
+		}
+	}
+	return result, nil
+}
+
+// recordingAssignmentHistoryRepository 记录权限分配历史的仓储
+type recordingAssignmentHistoryRepository struct {
+	repository.PermissionAssignmentHistoryRepository
+	histories []*database.PermissionAssignmentHistory
+}
+
+func (r *recordingAssignmentHistoryRepository) Create(ctx context.Context, history *database.PermissionAssignmentHistory) error {
+	r.histories = append(r.histories, history)
+	return nil
+}
+
+// triggerRuleRepository 按触发条件和条件值筛选规则的仓储
+type triggerRuleRepository struct {
+	repository.PermissionRuleRepository
+	rules []*database.PermissionRule
+}
+
+func (r *triggerRuleRepository) GetByTriggerCondition(ctx context.Context, condition, value string) ([]*database.PermissionRule, error) {
+	var result []*database.PermissionRule
+	for _, rule := range r.rules {
+		if rule.TriggerCondition == condition && rule.ConditionValue == value {
+			result = append(result, rule)
+		}
+	}
+	return result, nil
+}
+
+// positionChangeRepoManager 职位变更权限测试用仓储管理器
+type positionChangeRepoManager struct {
+	repository.RepositoryManager
+	assignments *memoryAssignmentRepository
+	histories   *recordingAssignmentHistoryRepository
+	rules       *triggerRuleRepository
+}
+
+func (m *positionChangeRepoManager) PermissionAssignmentRepository() repository.PermissionAssignmentRepository {
+	return m.assignments
+}
+
+func (m *positionChangeRepoManager) PermissionAssignmentHistoryRepository() repository.PermissionAssignmentHistoryRepository {
+	return m.histories
+}
+
+func (m *positionChangeRepoManager) PermissionRuleRepository() repository.PermissionRuleRepository {
+	return m.rules
+}
+
+func (m *positionChangeRepoManager) WithTx(ctx context.Context, fn func(ctx context.Context, repos repository.RepositoryManager) error) error {
+	return fn(ctx, m)
+}
+
+func positionTemplate(id uint, level int) database.PermissionTemplate {
+	template := database.PermissionTemplate{Name: "模板", Level: level}
+	template.ID = id
+	return template
+}
+
+// positionRule 新职级为level时触发的规则，分配的模板ID和级别都为level
+func positionRule(id uint, level int, action string, requireApproval bool) *database.PermissionRule {
+	rule := &database.PermissionRule{
+		TemplateID:       uint(level),
+		Name:             "职级规则",
+		TriggerCondition: PermissionTriggerPositionChange,
+		ConditionValue:   strconv.Itoa(level),
+		Action:           action,
+		RequireApproval:  requireApproval,
+		Template:         positionTemplate(uint(level), level),
+	}
+	rule.ID = id
+	return rule
+}
+
+func newPositionChangeRepoManager(rules ...*database.PermissionRule) *positionChangeRepoManager {
+	return &positionChangeRepoManager{
+		assignments: &memoryAssignmentRepository{},
+		histories:   &recordingAssignmentHistoryRepository{},
+		rules:       &triggerRuleRepository{rules: rules},
+	}
+}
+
+func TestProcessPositionChange_PromotionRequiresApproval(t *testing.T) {
+	// 晋升到5级需要审批
+	repos := newPositionChangeRepoManager(positionRule(1, 5, "upgrade", true))
+	svc := NewPermissionAssignmentService(repos, nil)
+	ctx := context.Background()
+
+	result, err := svc.ProcessPositionChange(ctx, 100, 3, 5, 9)
+	require.NoError(t, err)
+	require.Len(t, result.Granted, 1)
+	assert.Empty(t, result.Flagged)
+	assert.Equal(t, database.PermissionStatusPending, result.Granted[0].Status)
+	assert.Equal(t, database.ApprovalStatusPending, result.Granted[0].ApprovalStatus)
+
+	assignment := repos.assignments.assignments[0]
+	assert.Equal(t, uint(5), *assignment.TemplateID)
+	assert.Equal(t, uint(1), *assignment.RuleID)
+	assert.Equal(t, PermissionTriggerPositionChange, assignment.TriggerEvent)
+	assert.JSONEq(t, `{"old_level":3,"new_level":5}`, assignment.TriggerData)
+	require.Len(t, repos.histories.histories, 1)
+	assert.Equal(t, "assign", repos.histories.histories[0].Action)
+
+	// 待审批分配出现在待审批权限中，重复触发不会再次分配
+	pending, err := svc.GetPendingPermissionApprovals(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, 5, pending[0].Template.Level)
+	result, err = svc.ProcessPositionChange(ctx, 100, 3, 5, 9)
+	require.NoError(t, err)
+	assert.Empty(t, result.Granted)
+
+	require.NoError(t, svc.ProcessPermissionApproval(ctx, assignment.ID, true, 2, "同意"))
+	assert.Equal(t, database.PermissionStatusActive, assignment.Status)
+	assert.Equal(t, database.ApprovalStatusApproved, assignment.ApprovalStatus)
+	assert.Equal(t, uint(2), *assignment.ApprovedBy)
+	assert.ErrorIs(t, svc.ProcessPermissionApproval(ctx, assignment.ID, true, 2, ""), ErrPermissionApprovalNotPending)
+}
+
+func TestProcessPositionChange_DemotionFlagsHigherAssignments(t *testing.T) {
+	repos := newPositionChangeRepoManager(positionRule(1, 3, "grant", false), positionRule(2, 5, "upgrade", true))
+	senior, junior := positionTemplate(5, 5), positionTemplate(2, 2)
+	for _, template := range []database.PermissionTemplate{senior, junior} {
+		template := template
+		require.NoError(t, repos.assignments.Create(context.Background(), &database.PermissionAssignment{
+			UserID:         100,
+			TemplateID:     &template.ID,
+			Template:       &template,
+			Status:         database.PermissionStatusActive,
+			ApprovalStatus: database.ApprovalStatusApproved,
+		}))
+	}
+	svc := NewPermissionAssignmentService(repos, nil)
+	ctx := context.Background()
+
+	result, err := svc.ProcessPositionChange(ctx, 100, 5, 3, 9)
+	require.NoError(t, err)
+
+	// 新职级规则直接生效，高于新职级的5级模板待复核，2级模板不受影响
+	require.Len(t, result.Granted, 1)
+	assert.Equal(t, database.PermissionStatusActive, result.Granted[0].Status)
+	require.Len(t, result.Flagged, 1)
+	assert.Equal(t, uint(5), result.Flagged[0].TemplateID)
+	assert.Equal(t, database.ApprovalStatusApproved, repos.assignments.assignments[1].ApprovalStatus)
+
+	pending, err := svc.GetPendingPermissionApprovals(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, result.Flagged[0].ID, pending[0].ID)
+	assert.NotEmpty(t, pending[0].Reason)
+
+	// 复核拒绝后撤销
+	require.NoError(t, svc.ProcessPermissionApproval(ctx, pending[0].ID, false, 2, "超出职级"))
+	flagged := repos.assignments.assignments[0]
+	assert.Equal(t, database.PermissionStatusRevoked, flagged.Status)
+	assert.Equal(t, database.ApprovalStatusRejected, flagged.ApprovalStatus)
+	actions := make([]string, 0, len(repos.histories.histories))
+	for _, history := range repos.histories.histories {
+		actions = append(actions, history.Action)
+	}
+	assert.Equal(t, []string{"assign", "review", "rejected"}, actions)
+}
+
+// changeEmployeeRepository 职位变更测试用员工仓储
+type changeEmployeeRepository struct {
+	repository.EmployeeRepository
+	employee *database.Employee
+}
+
+func (r *changeEmployeeRepository) GetByID(ctx context.Context, id uint) (*database.Employee, error) {
+	copied := *r.employee
+	return &copied, nil
+}
+
+func (r *changeEmployeeRepository) Update(ctx context.Context, employee *database.Employee) error {
+	r.employee = employee
+	return nil
+}
+
+// levelPositionRepository 测试用职位仓储
+type levelPositionRepository struct {
+	repository.PositionRepository
+	positions map[uint]*database.Position
+}
+
+func (r *levelPositionRepository) GetByID(ctx context.Context, id uint) (*database.Position, error) {
+	if position, ok := r.positions[id]; ok {
+		return position, nil
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *levelPositionRepository) GetByIDUnscoped(ctx context.Context, id uint) (*database.Position, error) {
+	return r.GetByID(ctx, id)
+}
+
+// recordingChangeHistoryRepository 记录员工变更历史的仓储
+type recordingChangeHistoryRepository struct {
+	repository.EmployeeChangeHistoryRepository
+	histories []*database.EmployeeChangeHistory
+}
+
+func (r *recordingChangeHistoryRepository) Create(ctx context.Context, history *database.EmployeeChangeHistory) error {
+	r.histories = append(r.histories, history)
+	return nil
+}
+
+// emptySkillRepository 员工没有技能的技能仓储
+type emptySkillRepository struct {
+	repository.SkillRepository
+}
+
+func (r *emptySkillRepository) GetEmployeeSkillsWithLevel(ctx context.Context, employeeID uint) ([]*database.EmployeeSkillDetail, error) {
+	return nil, nil
+}
+
+// positionChangePermissions 记录职级变化调用的权限分配服务
+type positionChangePermissions struct {
+	PermissionAssignmentService
+	levels [][2]int
+}
+
+func (p *positionChangePermissions) ProcessPositionChange(ctx context.Context, userID uint, oldLevel, newLevel int, operatorID uint) (*PositionPermissionChangeResponse, error) {
+	p.levels = append(p.levels, [2]int{oldLevel, newLevel})
+	return &PositionPermissionChangeResponse{OldLevel: oldLevel, NewLevel: newLevel}, nil
+}
+
+func TestUpdateEmployee_PositionChange(t *testing.T) {
+	positionID := uint(1)
+	employee := &database.Employee{UserID: 100, PositionID: &positionID}
+	employee.ID = 10
+	positions := &levelPositionRepository{positions: map[uint]*database.Position{
+		1: {Name: "工程师", Level: 3},
+		2: {Name: "高级工程师", Level: 5},
+		3: {Name: "架构师", Level: 5},
+	}}
+	histories := &recordingChangeHistoryRepository{}
+	permissions := &positionChangePermissions{}
+	svc := NewEmployeeService(&changeEmployeeRepository{employee: employee}, &emptySkillRepository{},
+		&emailUserRepository{users: map[uint]*database.User{100: {RealName: "张三"}}}, &dashboardProjectRepository{},
+		nil, positions, histories, permissions, nil, config.WorkloadConfig{})
+	ctx := context.Background()
+	update := func(positionID uint) (*EmployeeResponse, error) {
+		return svc.UpdateEmployee(ctx, 10, &UpdateEmployeeRequest{PositionID: &positionID, OperatorID: 9})
+	}
+
+	// 晋升：记录历史并按新职级调整权限
+	resp, err := update(2)
+	require.NoError(t, err)
+	require.NotNil(t, resp.PermissionChange)
+	assert.Equal(t, [][2]int{{3, 5}}, permissions.levels)
+	require.Len(t, histories.histories, 1)
+	history := histories.histories[0]
+	assert.Equal(t, EmployeeChangePromotion, history.ChangeType)
+	assert.Equal(t, uint(1), *history.OldPositionID)
+	assert.Equal(t, uint(2), *history.NewPositionID)
+	assert.Equal(t, uint(9), history.OperatorID)
+
+	// 同职级调岗只记录历史
+	resp, err = update(3)
+	require.NoError(t, err)
+	assert.Nil(t, resp.PermissionChange)
+	assert.Len(t, permissions.levels, 1)
+	require.Len(t, histories.histories, 2)
+	assert.Equal(t, EmployeeChangeTransfer, histories.histories[1].ChangeType)
+
+	// 职位不变不记录，职位不存在时不保存
+	_, err = update(3)
+	require.NoError(t, err)
+	assert.Len(t, histories.histories, 2)
+	_, err = update(99)
+	assert.ErrorIs(t, err, ErrPositionNotFound)
+}
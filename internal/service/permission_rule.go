@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+	"taskmanage/pkg/logger"
+)
+
+// ErrInvalidPermissionRule 权限规则参数无效
+var ErrInvalidPermissionRule = errors.New("权限规则参数无效")
+
+// permissionRuleActions 权限规则的执行动作
+var permissionRuleActions = map[string]bool{"grant": true, "revoke": true, "upgrade": true}
+
+// validatePermissionRule 校验规则名称、动作和延迟天数，司龄类规则的条件值必须为非负整数天数
+func validatePermissionRule(rule *database.PermissionRule) error {
+	if rule.Name == "" {
+		return fmt.Errorf("%w: 规则名称不能为空", ErrInvalidPermissionRule)
+	}
+	if rule.TriggerCondition == "" || rule.ConditionValue == "" {
+		return fmt.Errorf("%w: 触发条件和条件值不能为空", ErrInvalidPermissionRule)
+	}
+	if !permissionRuleActions[rule.Action] {
+		return fmt.Errorf("%w: 无效的动作 %s", ErrInvalidPermissionRule, rule.Action)
+	}
+	if rule.DelayDays < 0 {
+		return fmt.Errorf("%w: 延迟天数不能为负数", ErrInvalidPermissionRule)
+	}
+	if isTenureTrigger(rule.TriggerCondition) {
+		if days, err := strconv.Atoi(rule.ConditionValue); err != nil || days < 0 {
+			return fmt.Errorf("%w: %s规则的条件值必须为非负整数天数", ErrInvalidPermissionRule, rule.TriggerCondition)
+		}
+	}
+	return nil
+}
+
+// CreatePermissionRule 创建权限规则，规则引用的模板必须存在
+func (s *PermissionAssignmentServiceImpl) CreatePermissionRule(ctx context.Context, req *CreatePermissionRuleRequest) (*PermissionRuleResponse, error) {
+	rule := &database.PermissionRule{
+		TemplateID:       req.TemplateID,
+		Name:             req.Name,
+		Description:      req.Description,
+		TriggerCondition: req.TriggerCondition,
+		ConditionValue:   req.ConditionValue,
+		Action:           req.Action,
+		Priority:         req.Priority,
+		IsActive:         true,
+		DelayDays:        req.DelayDays,
+		AutoExecute:      true,
+		RequireApproval:  req.RequireApproval,
+	}
+	if req.AutoExecute != nil {
+		rule.AutoExecute = *req.AutoExecute
+	}
+	if err := validatePermissionRule(rule); err != nil {
+		return nil, err
+	}
+
+	template, err := s.repos.PermissionTemplateRepository().GetByID(ctx, req.TemplateID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("%w: 权限模板%d不存在", ErrInvalidPermissionRule, req.TemplateID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("获取权限模板失败: %w", err)
+	}
+
+	if err := s.repos.PermissionRuleRepository().Create(ctx, rule); err != nil {
+		logger.Errorf("创建权限规则失败: %v", err)
+		return nil, fmt.Errorf("创建权限规则失败: %w", err)
+	}
+	rule.Template = *template
+
+	logger.Infof("成功创建权限规则: %s (ID: %d)", rule.Name, rule.ID)
+	return s.buildPermissionRuleResponse(rule), nil
+}
+
+// GetPermissionRule 获取权限规则
+func (s *PermissionAssignmentServiceImpl) GetPermissionRule(ctx context.Context, id uint) (*PermissionRuleResponse, error) {
+	rule, err := s.repos.PermissionRuleRepository().GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("获取权限规则失败: %w", err)
+	}
+	return s.buildPermissionRuleResponse(rule), nil
+}
+
+// ListPermissionRules 获取权限规则列表，按优先级从高到低排序
+func (s *PermissionAssignmentServiceImpl) ListPermissionRules(ctx context.Context, req *ListPermissionRulesRequest) (*ListPermissionRulesResponse, error) {
+	filter := &repository.PermissionRuleFilter{
+		Page:             req.Page,
+		PageSize:         req.PageSize,
+		TemplateID:       req.TemplateID,
+		TriggerCondition: req.TriggerCondition,
+		Action:           req.Action,
+		IsActive:         req.IsActive,
+		Search:           req.Search,
+	}
+
+	rules, err := s.repos.PermissionRuleRepository().List(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("获取权限规则列表失败: %w", err)
+	}
+
+	items := make([]*PermissionRuleResponse, 0, len(rules))
+	for _, rule := range rules {
+		items = append(items, s.buildPermissionRuleResponse(rule))
+	}
+
+	return &ListPermissionRulesResponse{
+		Items: items,
+		Total: len(items),
+		Page:  req.Page,
+		Size:  req.PageSize,
+	}, nil
+}
+
+// UpdatePermissionRule 部分更新权限规则，只修改请求中提供的字段
+// 规则已执行过的用户不会因修改条件而重新执行
+func (s *PermissionAssignmentServiceImpl) UpdatePermissionRule(ctx context.Context, id uint, req *UpdatePermissionRuleRequest) (*PermissionRuleResponse, error) {
+	rule, err := s.repos.PermissionRuleRepository().GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("获取权限规则失败: %w", err)
+	}
+
+	if req.Name != nil {
+		rule.Name = *req.Name
+	}
+	if req.Description != nil {
+		rule.Description = *req.Description
+	}
+	if req.TriggerCondition != nil {
+		rule.TriggerCondition = *req.TriggerCondition
+	}
+	if req.ConditionValue != nil {
+		rule.ConditionValue = *req.ConditionValue
+	}
+	if req.Action != nil {
+		rule.Action = *req.Action
+	}
+	if req.Priority != nil {
+		rule.Priority = *req.Priority
+	}
+	if req.DelayDays != nil {
+		rule.DelayDays = *req.DelayDays
+	}
+	if req.AutoExecute != nil {
+		rule.AutoExecute = *req.AutoExecute
+	}
+	if req.RequireApproval != nil {
+		rule.RequireApproval = *req.RequireApproval
+	}
+	if req.IsActive != nil {
+		rule.IsActive = *req.IsActive
+	}
+	if err := validatePermissionRule(rule); err != nil {
+		return nil, err
+	}
+
+	if err := s.repos.PermissionRuleRepository().Update(ctx, rule); err != nil {
+		logger.Errorf("更新权限规则失败: %v", err)
+		return nil, fmt.Errorf("更新权限规则失败: %w", err)
+	}
+
+	logger.Infof("成功更新权限规则: %d", rule.ID)
+	return s.buildPermissionRuleResponse(rule), nil
+}
+
+// DeletePermissionRule 删除权限规则，规则已创建的权限分配不受影响
+func (s *PermissionAssignmentServiceImpl) DeletePermissionRule(ctx context.Context, id uint) error {
+	if _, err := s.repos.PermissionRuleRepository().GetByID(ctx, id); err != nil {
+		return fmt.Errorf("获取权限规则失败: %w", err)
+	}
+	if err := s.repos.PermissionRuleRepository().Delete(ctx, id); err != nil {
+		logger.Errorf("删除权限规则失败: %v", err)
+		return fmt.Errorf("删除权限规则失败: %w", err)
+	}
+
+	logger.Infof("成功删除权限规则: %d", id)
+	return nil
+}
+
+// buildPermissionRuleResponse 构建权限规则响应，已加载模板时一并返回
+func (s *PermissionAssignmentServiceImpl) buildPermissionRuleResponse(rule *database.PermissionRule) *PermissionRuleResponse {
+	resp := &PermissionRuleResponse{
+		ID:               rule.ID,
+		TemplateID:       rule.TemplateID,
+		Name:             rule.Name,
+		Description:      rule.Description,
+		TriggerCondition: rule.TriggerCondition,
+		ConditionValue:   rule.ConditionValue,
+		Action:           rule.Action,
+		Priority:         rule.Priority,
+		DelayDays:        rule.DelayDays,
+		AutoExecute:      rule.AutoExecute,
+		RequireApproval:  rule.RequireApproval,
+		IsActive:         rule.IsActive,
+		CreatedAt:        rule.CreatedAt,
+		UpdatedAt:        rule.UpdatedAt,
+	}
+	if rule.Template.ID != 0 {
+		resp.Template = s.buildPermissionTemplateResponse(&rule.Template)
+	}
+	return resp
+}
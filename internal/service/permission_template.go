@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+	"taskmanage/pkg/logger"
+)
+
+var (
+	// ErrInvalidPermissionTemplate 权限模板参数无效
+	ErrInvalidPermissionTemplate = errors.New("权限模板参数无效")
+	// ErrPermissionTemplateExists 权限模板编码已存在
+	ErrPermissionTemplateExists = errors.New("权限模板编码已存在")
+	// ErrPermissionTemplateInUse 权限模板仍被权限分配或入职权限配置引用
+	ErrPermissionTemplateInUse = errors.New("权限模板仍在使用中")
+)
+
+// 权限模板的项目和任务范围
+var (
+	permissionTemplateProjectScopes = map[string]bool{"assigned": true, "team": true, "department": true, "all": true}
+	permissionTemplateTaskScopes    = map[string]bool{"assigned": true, "created": true, "team": true, "all": true}
+)
+
+// validatePermissionTemplateScopes 校验项目范围和任务范围取值
+func validatePermissionTemplateScopes(projectScope, taskScope string) error {
+	if !permissionTemplateProjectScopes[projectScope] {
+		return fmt.Errorf("%w: 无效的项目范围 %s", ErrInvalidPermissionTemplate, projectScope)
+	}
+	if !permissionTemplateTaskScopes[taskScope] {
+		return fmt.Errorf("%w: 无效的任务范围 %s", ErrInvalidPermissionTemplate, taskScope)
+	}
+	return nil
+}
+
+// UpdatePermissionTemplate 部分更新权限模板，只修改请求中提供的字段，模板编码创建后不可修改
+func (s *PermissionAssignmentServiceImpl) UpdatePermissionTemplate(ctx context.Context, id uint, req *UpdatePermissionTemplateRequest) (*PermissionTemplateResponse, error) {
+	template, err := s.repos.PermissionTemplateRepository().GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("获取权限模板失败: %w", err)
+	}
+	if req.Code != nil && *req.Code != template.Code {
+		return nil, fmt.Errorf("%w: 模板编码创建后不可修改", ErrInvalidPermissionTemplate)
+	}
+
+	if req.Name != nil {
+		if *req.Name == "" {
+			return nil, fmt.Errorf("%w: 模板名称不能为空", ErrInvalidPermissionTemplate)
+		}
+		template.Name = *req.Name
+	}
+	if req.Description != nil {
+		template.Description = *req.Description
+	}
+	if req.Category != nil {
+		template.Category = *req.Category
+	}
+	if req.Level != nil {
+		template.Level = *req.Level
+	}
+	if req.DepartmentID != nil {
+		template.DepartmentID = req.DepartmentID
+	}
+	if req.PositionID != nil {
+		template.PositionID = req.PositionID
+	}
+	if req.ProjectScope != nil {
+		template.ProjectScope = *req.ProjectScope
+	}
+	if req.TaskScope != nil {
+		template.TaskScope = *req.TaskScope
+	}
+	if req.CanAssignToLevel != nil {
+		template.CanAssignToLevel = *req.CanAssignToLevel
+	}
+	if req.CrossDepartment != nil {
+		template.CrossDepartment = *req.CrossDepartment
+	}
+	if req.MaxTasksPerDay != nil {
+		template.MaxTasksPerDay = *req.MaxTasksPerDay
+	}
+	if req.IsActive != nil {
+		template.IsActive = *req.IsActive
+	}
+	if err := validatePermissionTemplateScopes(template.ProjectScope, template.TaskScope); err != nil {
+		return nil, err
+	}
+
+	err = s.repos.WithTx(ctx, func(ctx context.Context, repos repository.RepositoryManager) error {
+		if err := repos.PermissionTemplateRepository().Update(ctx, template); err != nil {
+			return fmt.Errorf("更新权限模板失败: %w", err)
+		}
+		if req.Permissions == nil {
+			return nil
+		}
+		permissions, err := templatePermissionRefs(ctx, repos, *req.Permissions)
+		if err != nil {
+			return err
+		}
+		if err := repos.PermissionTemplateRepository().ReplacePermissions(ctx, template, permissions); err != nil {
+			return fmt.Errorf("更新模板权限失败: %w", err)
+		}
+		template.Permissions = permissions
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 模板权限变化会影响所有通过该模板分配权限的用户
+	if req.Permissions != nil && s.permissionService != nil {
+		if err := s.permissionService.InvalidateAll(ctx); err != nil {
+			logger.Warnf("清除权限缓存失败: %v", err)
+		}
+	}
+
+	logger.Infof("成功更新权限模板: %s (ID: %d)", template.Code, template.ID)
+	return s.buildPermissionTemplateResponse(template), nil
+}
+
+// templatePermissionRefs 按ID加载模板引用的权限，权限不存在时返回参数错误
+func templatePermissionRefs(ctx context.Context, repos repository.RepositoryManager, refs []database.Permission) ([]database.Permission, error) {
+	permissions := make([]database.Permission, 0, len(refs))
+	seen := make(map[uint]bool, len(refs))
+	for _, ref := range refs {
+		if seen[ref.ID] {
+			continue
+		}
+		seen[ref.ID] = true
+		permission, err := repos.PermissionRepository().GetByID(ctx, ref.ID)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return nil, fmt.Errorf("%w: 权限%d不存在", ErrInvalidPermissionTemplate, ref.ID)
+			}
+			return nil, fmt.Errorf("获取权限失败: %w", err)
+		}
+		permissions = append(permissions, *permission)
+	}
+	return permissions, nil
+}
+
+// DeletePermissionTemplate 删除权限模板，deactivate为true时只停用模板
+// 模板仍被生效中或待审批的权限分配、入职权限配置引用时不允许删除，需先处理引用或改为停用
+func (s *PermissionAssignmentServiceImpl) DeletePermissionTemplate(ctx context.Context, id uint, deactivate bool) error {
+	template, err := s.repos.PermissionTemplateRepository().GetByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("获取权限模板失败: %w", err)
+	}
+
+	if deactivate {
+		if !template.IsActive {
+			return nil
+		}
+		template.IsActive = false
+		if err := s.repos.PermissionTemplateRepository().Update(ctx, template); err != nil {
+			return fmt.Errorf("停用权限模板失败: %w", err)
+		}
+		logger.Infof("停用权限模板: %s (ID: %d)", template.Code, template.ID)
+		return nil
+	}
+
+	assignments, err := s.repos.PermissionAssignmentRepository().CountActiveByTemplateID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("查询模板权限分配失败: %w", err)
+	}
+	configs, err := s.repos.OnboardingPermissionConfigRepository().CountByTemplateID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("查询入职权限配置失败: %w", err)
+	}
+	if assignments > 0 || configs > 0 {
+		return fmt.Errorf("%w: 模板%s仍有%d条生效或待审批的权限分配、被%d条入职权限配置引用，请先处理或改为停用",
+			ErrPermissionTemplateInUse, template.Code, assignments, configs)
+	}
+
+	err = s.repos.WithTx(ctx, func(ctx context.Context, repos repository.RepositoryManager) error {
+		for _, rule := range template.Rules {
+			if err := repos.PermissionRuleRepository().Delete(ctx, rule.ID); err != nil {
+				return fmt.Errorf("删除模板规则失败: %w", err)
+			}
+		}
+		if err := repos.PermissionTemplateRepository().Delete(ctx, id); err != nil {
+			return fmt.Errorf("删除权限模板失败: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("删除权限模板: %s (ID: %d)", template.Code, template.ID)
+	return nil
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"taskmanage/internal/database"
+)
+
+// PreviewPermissionTemplateApplication 预览为用户应用权限模板后有效权限的变化，不做任何修改
+// 有效权限为角色权限加上生效中的模板分配和直接分配；replaceExisting为true时按撤销其它模板分配后的结果计算
+func (s *PermissionAssignmentServiceImpl) PreviewPermissionTemplateApplication(ctx context.Context, userID uint, templateID uint, replaceExisting bool) (*PermissionTemplatePreviewResponse, error) {
+	if _, err := s.repos.UserRepository().GetByID(ctx, userID); err != nil {
+		return nil, fmt.Errorf("获取用户失败: %w", err)
+	}
+	template, err := s.repos.PermissionTemplateRepository().GetByID(ctx, templateID)
+	if err != nil {
+		return nil, fmt.Errorf("获取权限模板失败: %w", err)
+	}
+
+	rolePermissions, err := s.repos.PermissionRepository().GetUserPermissions(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("获取用户角色权限失败: %w", err)
+	}
+	active, err := s.repos.PermissionAssignmentRepository().GetActiveByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("获取用户权限分配失败: %w", err)
+	}
+
+	// 各模板的权限只查询一次，目标模板已加载
+	templates := map[uint]*database.PermissionTemplate{template.ID: template}
+	assignmentPermissions := func(assignment *database.PermissionAssignment) ([]*database.Permission, error) {
+		if assignment.TemplateID == nil {
+			if assignment.Permission == nil {
+				return nil, nil
+			}
+			return []*database.Permission{assignment.Permission}, nil
+		}
+		assigned, ok := templates[*assignment.TemplateID]
+		if !ok {
+			if assigned, err = s.repos.PermissionTemplateRepository().GetByID(ctx, *assignment.TemplateID); err != nil {
+				return nil, fmt.Errorf("获取权限模板失败: %w", err)
+			}
+			templates[assigned.ID] = assigned
+		}
+		return templatePermissions(assigned), nil
+	}
+
+	var replaced []*database.PermissionAssignment
+	if replaceExisting {
+		replaced = replacedTemplateAssignments(active, templateID)
+	}
+	revoked := make(map[uint]bool, len(replaced))
+	for _, assignment := range replaced {
+		revoked[assignment.ID] = true
+	}
+
+	before := append([]*database.Permission{}, rolePermissions...)
+	after := append([]*database.Permission{}, rolePermissions...)
+	for _, assignment := range active {
+		permissions, err := assignmentPermissions(assignment)
+		if err != nil {
+			return nil, err
+		}
+		before = append(before, permissions...)
+		if !revoked[assignment.ID] {
+			after = append(after, permissions...)
+		}
+	}
+	after = append(after, templatePermissions(template)...)
+
+	preview := diffPermissions(before, after)
+	preview.UserID = userID
+	preview.TemplateID = templateID
+	preview.ReplaceExisting = replaceExisting
+	for _, assignment := range replaced {
+		preview.RevokedAssignments = append(preview.RevokedAssignments, assignment.ID)
+	}
+	return preview, nil
+}
+
+// templatePermissions 模板权限转换为指针切片
+func templatePermissions(template *database.PermissionTemplate) []*database.Permission {
+	permissions := make([]*database.Permission, 0, len(template.Permissions))
+	for i := range template.Permissions {
+		permissions = append(permissions, &template.Permissions[i])
+	}
+	return permissions
+}
+
+// diffPermissions 按权限ID对比应用前后的权限，结果按权限名称排序
+func diffPermissions(before, after []*database.Permission) *PermissionTemplatePreviewResponse {
+	beforeByID := make(map[uint]*database.Permission, len(before))
+	for _, permission := range before {
+		beforeByID[permission.ID] = permission
+	}
+	afterByID := make(map[uint]*database.Permission, len(after))
+	for _, permission := range after {
+		afterByID[permission.ID] = permission
+	}
+
+	preview := &PermissionTemplatePreviewResponse{
+		Added:     []*PermissionResponse{},
+		Removed:   []*PermissionResponse{},
+		Unchanged: []*PermissionResponse{},
+	}
+	for id, permission := range afterByID {
+		if _, ok := beforeByID[id]; ok {
+			preview.Unchanged = append(preview.Unchanged, toPermissionResponse(permission))
+		} else {
+			preview.Added = append(preview.Added, toPermissionResponse(permission))
+		}
+	}
+	for id, permission := range beforeByID {
+		if _, ok := afterByID[id]; !ok {
+			preview.Removed = append(preview.Removed, toPermissionResponse(permission))
+		}
+	}
+	for _, permissions := range [][]*PermissionResponse{preview.Added, preview.Removed, preview.Unchanged} {
+		sort.Slice(permissions, func(i, j int) bool { return permissions[i].Name < permissions[j].Name })
+	}
+	return preview
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"taskmanage/internal/database"
+)
+
+func permissionNames(permissions []*PermissionResponse) []string {
+	names := make([]string, 0, len(permissions))
+	for _, permission := range permissions {
+		names = append(names, permission.Name)
+	}
+	return names
+}
+
+func TestDiffPermissions(t *testing.T) {
+	permission := func(id uint, name string) *database.Permission {
+		p := &database.Permission{Name: name}
+		p.ID = id
+		return p
+	}
+	taskRead, taskCreate, taskAssign, reportRead := permission(1, "task:read"), permission(2, "task:create"), permission(3, "task:assign"), permission(4, "report:read")
+
+	// 角色权限和多个来源重复的权限按ID去重
+	preview := diffPermissions(
+		[]*database.Permission{taskRead, taskCreate, reportRead, taskRead},
+		[]*database.Permission{taskRead, taskAssign, taskRead},
+	)
+	assert.Equal(t, []string{"task:assign"}, permissionNames(preview.Added))
+	assert.Equal(t, []string{"report:read", "task:create"}, permissionNames(preview.Removed))
+	assert.Equal(t, []string{"task:read"}, permissionNames(preview.Unchanged))
+
+	preview = diffPermissions(nil, nil)
+	assert.NotNil(t, preview.Added)
+	assert.Empty(t, preview.Removed)
+}
+
+func TestReplacedTemplateAssignments(t *testing.T) {
+	templateID := func(id uint) *uint { return &id }
+	assignment := func(id uint, template *uint, status string) *database.PermissionAssignment {
+		a := &database.PermissionAssignment{TemplateID: template, Status: status}
+		a.ID = id
+		return a
+	}
+
+	replaced := replacedTemplateAssignments([]*database.PermissionAssignment{
+		assignment(1, templateID(10), database.PermissionStatusActive),
+		assignment(2, templateID(20), database.PermissionStatusActive), // 目标模板保留
+		assignment(3, templateID(11), database.PermissionStatusPending),
+		assignment(4, templateID(12), database.PermissionStatusRevoked), // 已撤销
+		assignment(5, nil, database.PermissionStatusActive),             // 直接分配
+	}, 20)
+
+	var ids []uint
+	for _, a := range replaced {
+		ids = append(ids, a.ID)
+	}
+	assert.Equal(t, []uint{1, 3}, ids)
+}
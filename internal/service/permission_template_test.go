@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+// fakePermissionTemplateRepository 测试用内存权限模板仓储
+type fakePermissionTemplateRepository struct {
+	repository.PermissionTemplateRepository
+	templates map[uint]*database.PermissionTemplate
+	deleted   []uint
+}
+
+func (f *fakePermissionTemplateRepository) GetByID(ctx context.Context, id uint) (*database.PermissionTemplate, error) {
+	template, ok := f.templates[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	copied := *template
+	return &copied, nil
+}
+
+func (f *fakePermissionTemplateRepository) Update(ctx context.Context, template *database.PermissionTemplate) error {
+	f.templates[template.ID] = template
+	return nil
+}
+
+func (f *fakePermissionTemplateRepository) Delete(ctx context.Context, id uint) error {
+	delete(f.templates, id)
+	f.deleted = append(f.deleted, id)
+	return nil
+}
+
+// templateUsageCounter 模板的引用计数
+type templateUsageCounter struct {
+	assignments int64
+	configs     int64
+}
+
+// countingAssignmentRepository 返回固定模板引用数的权限分配仓储
+type countingAssignmentRepository struct {
+	repository.PermissionAssignmentRepository
+	usage *templateUsageCounter
+}
+
+func (r *countingAssignmentRepository) CountActiveByTemplateID(ctx context.Context, templateID uint) (int64, error) {
+	return r.usage.assignments, nil
+}
+
+// countingOnboardingConfigRepository 返回固定模板引用数的入职权限配置仓储
+type countingOnboardingConfigRepository struct {
+	repository.OnboardingPermissionConfigRepository
+	usage *templateUsageCounter
+}
+
+func (r *countingOnboardingConfigRepository) CountByTemplateID(ctx context.Context, templateID uint) (int64, error) {
+	return r.usage.configs, nil
+}
+
+// permissionTemplateRepoManager 权限模板测试用仓储管理器
+type permissionTemplateRepoManager struct {
+	repository.RepositoryManager
+	templates *fakePermissionTemplateRepository
+	usage     *templateUsageCounter
+}
+
+func (m *permissionTemplateRepoManager) PermissionTemplateRepository() repository.PermissionTemplateRepository {
+	return m.templates
+}
+
+func (m *permissionTemplateRepoManager) PermissionAssignmentRepository() repository.PermissionAssignmentRepository {
+	return &countingAssignmentRepository{usage: m.usage}
+}
+
+func (m *permissionTemplateRepoManager) OnboardingPermissionConfigRepository() repository.OnboardingPermissionConfigRepository {
+	return &countingOnboardingConfigRepository{usage: m.usage}
+}
+
+func (m *permissionTemplateRepoManager) WithTx(ctx context.Context, fn func(ctx context.Context, repos repository.RepositoryManager) error) error {
+	return fn(ctx, m)
+}
+
+func newPermissionTemplateRepoManager(assignments, configs int64) *permissionTemplateRepoManager {
+	template := &database.PermissionTemplate{Code: "developer", Name: "开发", ProjectScope: "team", TaskScope: "assigned", IsActive: true}
+	template.ID = 3
+	return &permissionTemplateRepoManager{
+		templates: &fakePermissionTemplateRepository{templates: map[uint]*database.PermissionTemplate{3: template}},
+		usage:     &templateUsageCounter{assignments: assignments, configs: configs},
+	}
+}
+
+func TestDeletePermissionTemplate_InUse(t *testing.T) {
+	repos := newPermissionTemplateRepoManager(2, 1)
+	svc := &PermissionAssignmentServiceImpl{repos: repos}
+
+	err := svc.DeletePermissionTemplate(context.Background(), 3, false)
+	require.ErrorIs(t, err, ErrPermissionTemplateInUse)
+	assert.Contains(t, err.Error(), "2条生效或待审批的权限分配")
+	assert.Contains(t, err.Error(), "1条入职权限配置")
+	assert.Empty(t, repos.templates.deleted)
+
+	// 停用不受引用限制
+	require.NoError(t, svc.DeletePermissionTemplate(context.Background(), 3, true))
+	assert.False(t, repos.templates.templates[3].IsActive)
+	assert.Empty(t, repos.templates.deleted)
+
+	// 引用解除后可以删除
+	repos.usage.assignments, repos.usage.configs = 0, 0
+	require.NoError(t, svc.DeletePermissionTemplate(context.Background(), 3, false))
+	assert.Equal(t, []uint{3}, repos.templates.deleted)
+
+	err = svc.DeletePermissionTemplate(context.Background(), 3, false)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+}
+
+func TestUpdatePermissionTemplate_Partial(t *testing.T) {
+	repos := newPermissionTemplateRepoManager(0, 0)
+	svc := &PermissionAssignmentServiceImpl{repos: repos}
+	name, taskScope := "高级开发", "team"
+
+	resp, err := svc.UpdatePermissionTemplate(context.Background(), 3, &UpdatePermissionTemplateRequest{Name: &name, TaskScope: &taskScope})
+	require.NoError(t, err)
+	assert.Equal(t, "高级开发", resp.Name)
+	assert.Equal(t, "team", resp.TaskScope)
+	assert.Equal(t, "team", resp.ProjectScope)
+	assert.True(t, resp.IsActive)
+
+	code := "senior_developer"
+	_, err = svc.UpdatePermissionTemplate(context.Background(), 3, &UpdatePermissionTemplateRequest{Code: &code})
+	assert.ErrorIs(t, err, ErrInvalidPermissionTemplate)
+
+	invalidScope := "everyone"
+	_, err = svc.UpdatePermissionTemplate(context.Background(), 3, &UpdatePermissionTemplateRequest{ProjectScope: &invalidScope})
+	assert.ErrorIs(t, err, ErrInvalidPermissionTemplate)
+	assert.Equal(t, "team", repos.templates.templates[3].ProjectScope)
+}
@@ -0,0 +1,282 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+	"taskmanage/pkg/logger"
+)
+
+// 司龄类权限规则触发条件，规则的ConditionValue为天数阈值，天数达到阈值加DelayDays后规则生效
+const (
+	PermissionTriggerTenureDays    = "tenure_days"    // 自入职日期起的天数
+	PermissionTriggerConfirmedDays = "confirmed_days" // 自转正日期起的天数
+)
+
+// tenureTriggers 司龄类触发条件，按定时任务扫描的顺序排列
+var tenureTriggers = []string{PermissionTriggerTenureDays, PermissionTriggerConfirmedDays}
+
+// isTenureTrigger 是否为按天数阈值匹配的司龄类触发条件
+func isTenureTrigger(triggerCondition string) bool {
+	return triggerCondition == PermissionTriggerTenureDays || triggerCondition == PermissionTriggerConfirmedDays
+}
+
+// tenureRuleThreshold 规则生效所需的天数，条件值无效时返回false
+func tenureRuleThreshold(rule *database.PermissionRule) (int, bool) {
+	days, err := strconv.Atoi(rule.ConditionValue)
+	if err != nil || days < 0 {
+		return 0, false
+	}
+	return days + rule.DelayDays, true
+}
+
+// tenureDays 从date所在日期到now所在日期经过的自然日天数，按now的时区计算
+func tenureDays(date, now time.Time) int {
+	date = date.In(now.Location())
+	from := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	return int(to.Sub(from).Hours() / 24)
+}
+
+// tenureTriggerData 记录在权限分配TriggerData和执行记录中的司龄
+type tenureTriggerData struct {
+	Since string `json:"since"` // 入职或转正日期
+	Days  int    `json:"days"`
+}
+
+// TenurePermissionReport 司龄权限规则的执行结果
+type TenurePermissionReport struct {
+	Checked   int `json:"checked"`   // 检查的员工数
+	Granted   int `json:"granted"`   // 直接生效的分配数
+	Pending   int `json:"pending"`   // 需要审批、转为待审批的分配数
+	Revoked   int `json:"revoked"`   // 撤销的分配数
+	Unchanged int `json:"unchanged"` // 规则匹配但用户已持有或未持有模板，只记录执行
+	Failed    int `json:"failed"`    // 执行失败、下次扫描重试的规则数
+}
+
+// evaluateTenureRules 获取天数阈值不超过value的启用规则，按优先级从高到低排序
+func (s *PermissionAssignmentServiceImpl) evaluateTenureRules(ctx context.Context, triggerCondition, value string) ([]*database.PermissionRule, error) {
+	days, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: 无效的天数 %s", ErrInvalidPermissionRule, value)
+	}
+	rules, err := s.activeRules(ctx, triggerCondition)
+	if err != nil {
+		return nil, err
+	}
+
+	var applicableRules []*database.PermissionRule
+	for _, rule := range rules {
+		threshold, ok := tenureRuleThreshold(rule)
+		if !ok {
+			logger.Warnf("权限规则条件值无效，已跳过: rule=%d, condition_value=%s", rule.ID, rule.ConditionValue)
+			continue
+		}
+		if days >= threshold {
+			applicableRules = append(applicableRules, rule)
+		}
+	}
+	return applicableRules, nil
+}
+
+// activeRules 获取触发条件下的全部启用规则
+func (s *PermissionAssignmentServiceImpl) activeRules(ctx context.Context, triggerCondition string) ([]*database.PermissionRule, error) {
+	active := true
+	rules, err := s.repos.PermissionRuleRepository().List(ctx, &repository.PermissionRuleFilter{
+		TriggerCondition: triggerCondition,
+		IsActive:         &active,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("获取权限规则失败: %w", err)
+	}
+	return rules, nil
+}
+
+// ProcessTenureRules 执行司龄类权限规则，由每日定时任务调用
+// 入职或转正满规则天数的员工按规则分配或撤销模板，规则要求审批时分配为待审批；
+// 每条规则对每个用户只执行一次，执行记录与分配在同一事务中写入，关闭自动执行的规则不处理
+func (s *PermissionAssignmentServiceImpl) ProcessTenureRules(ctx context.Context, now time.Time) (*TenurePermissionReport, error) {
+	report := &TenurePermissionReport{}
+
+	// 按各触发条件的最小阈值确定需要检查的员工
+	var hiredBefore, confirmedBefore *time.Time
+	executed := make(map[uint]map[uint]bool)
+	for _, trigger := range tenureTriggers {
+		rules, err := s.activeRules(ctx, trigger)
+		if err != nil {
+			return nil, err
+		}
+		minThreshold := -1
+		for _, rule := range rules {
+			threshold, ok := tenureRuleThreshold(rule)
+			if !ok || !rule.AutoExecute {
+				continue
+			}
+			if minThreshold < 0 || threshold < minThreshold {
+				minThreshold = threshold
+			}
+			if executed[rule.ID] == nil {
+				if executed[rule.ID], err = s.repos.PermissionRuleExecutionRepository().ListExecutedUserIDs(ctx, rule.ID); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if minThreshold < 0 {
+			continue
+		}
+		before := now.AddDate(0, 0, -minThreshold)
+		if trigger == PermissionTriggerTenureDays {
+			hiredBefore = &before
+		} else {
+			confirmedBefore = &before
+		}
+	}
+	if hiredBefore == nil && confirmedBefore == nil {
+		return report, nil
+	}
+
+	employees, err := s.repos.EmployeeRepository().ListTenured(ctx, hiredBefore, confirmedBefore)
+	if err != nil {
+		return nil, err
+	}
+	for _, employee := range employees {
+		report.Checked++
+		changed := false
+		for _, trigger := range tenureTriggers {
+			since := employee.HireDate
+			if trigger == PermissionTriggerConfirmedDays {
+				since = employee.ConfirmDate
+			}
+			if since == nil {
+				continue
+			}
+			days := tenureDays(*since, now)
+			rules, err := s.EvaluatePermissionRules(ctx, employee.UserID, trigger, strconv.Itoa(days))
+			if err != nil {
+				return report, err
+			}
+			for _, rule := range rules {
+				if !rule.AutoExecute || executed[rule.ID][employee.UserID] {
+					continue
+				}
+				data := tenureTriggerData{Since: since.Format("2006-01-02"), Days: days}
+				outcome, err := s.executeTenureRule(ctx, employee.UserID, rule, trigger, data, now)
+				if err != nil {
+					report.Failed++
+					logger.Warnf("执行司龄权限规则失败: rule=%d, user=%d, error=%v", rule.ID, employee.UserID, err)
+					continue
+				}
+				if executed[rule.ID] == nil {
+					executed[rule.ID] = make(map[uint]bool)
+				}
+				executed[rule.ID][employee.UserID] = true
+				switch outcome {
+				case tenureOutcomeGranted:
+					report.Granted++
+				case tenureOutcomePending:
+					report.Pending++
+				case tenureOutcomeRevoked:
+					report.Revoked++
+				default:
+					report.Unchanged++
+				}
+				changed = changed || outcome != tenureOutcomeUnchanged
+			}
+		}
+		if changed {
+			s.invalidatePermissions(ctx, employee.UserID)
+		}
+	}
+	return report, nil
+}
+
+// tenureOutcome 单条规则对用户的执行结果
+type tenureOutcome int
+
+const (
+	tenureOutcomeUnchanged tenureOutcome = iota
+	tenureOutcomeGranted
+	tenureOutcomePending
+	tenureOutcomeRevoked
+)
+
+// errTenureRuleExecuted 规则已对用户执行过
+var errTenureRuleExecuted = errors.New("权限规则已对该用户执行")
+
+// executeTenureRule 按规则动作分配或撤销模板，并在同一事务中写入执行记录，执行记录已存在时回滚不做修改
+func (s *PermissionAssignmentServiceImpl) executeTenureRule(ctx context.Context, userID uint, rule *database.PermissionRule, trigger string, data tenureTriggerData, now time.Time) (tenureOutcome, error) {
+	triggerData, err := json.Marshal(data)
+	if err != nil {
+		return tenureOutcomeUnchanged, fmt.Errorf("序列化触发数据失败: %w", err)
+	}
+	reason := fmt.Sprintf("司龄满%d天", data.Days)
+	if trigger == PermissionTriggerConfirmedDays {
+		reason = fmt.Sprintf("转正满%d天", data.Days)
+	}
+
+	outcome := tenureOutcomeUnchanged
+	err = s.repos.WithTx(ctx, func(ctx context.Context, repos repository.RepositoryManager) error {
+		assignments, err := repos.PermissionAssignmentRepository().GetByUserID(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("获取用户权限分配失败: %w", err)
+		}
+		var assignmentID *uint
+		switch rule.Action {
+		case "grant", "upgrade":
+			if holdsTemplate(assignments, rule.TemplateID) {
+				break
+			}
+			assignment, err := grantRuleTemplate(ctx, repos, userID, rule, trigger, string(triggerData), reason, 0)
+			if err != nil {
+				return err
+			}
+			assignmentID = &assignment.ID
+			outcome = tenureOutcomeGranted
+			if assignment.Status == database.PermissionStatusPending {
+				outcome = tenureOutcomePending
+			}
+		case "revoke":
+			for _, assignment := range assignments {
+				if !isTemplateAssignmentInEffect(assignment, rule.TemplateID) {
+					continue
+				}
+				if err := updateAssignmentWithHistory(ctx, repos, assignment, "revoked", reason, fmt.Sprintf("权限规则: %s", rule.Name), 0, func(a *database.PermissionAssignment) {
+					a.Status = database.PermissionStatusRevoked
+				}); err != nil {
+					return err
+				}
+				assignmentID = &assignment.ID
+				outcome = tenureOutcomeRevoked
+			}
+		}
+
+		recorded, err := repos.PermissionRuleExecutionRepository().Record(ctx, &database.PermissionRuleExecution{
+			RuleID:       rule.ID,
+			UserID:       userID,
+			AssignmentID: assignmentID,
+			TriggerValue: strconv.Itoa(data.Days),
+			ExecutedAt:   now,
+		})
+		if err != nil {
+			return err
+		}
+		if !recorded {
+			// 其他实例已执行过该规则，回滚本次分配
+			return errTenureRuleExecuted
+		}
+		return nil
+	})
+	if errors.Is(err, errTenureRuleExecuted) {
+		return tenureOutcomeUnchanged, nil
+	}
+	if err != nil {
+		return tenureOutcomeUnchanged, err
+	}
+	return outcome, nil
+}
@@ -0,0 +1,317 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+// listRuleRepository 按触发条件和启用状态筛选规则的内存仓储
+type listRuleRepository struct {
+	repository.PermissionRuleRepository
+	rules []*database.PermissionRule
+}
+
+func (r *listRuleRepository) Create(ctx context.Context, rule *database.PermissionRule) error {
+	rule.ID = uint(len(r.rules) + 1)
+	r.rules = append(r.rules, rule)
+	return nil
+}
+
+func (r *listRuleRepository) List(ctx context.Context, filter *repository.PermissionRuleFilter) ([]*database.PermissionRule, error) {
+	var result []*database.PermissionRule
+	for _, rule := range r.rules {
+		if filter.TriggerCondition != "" && rule.TriggerCondition != filter.TriggerCondition {
+			continue
+		}
+		if filter.IsActive != nil && rule.IsActive != *filter.IsActive {
+			continue
+		}
+		result = append(result, rule)
+	}
+	return result, nil
+}
+
+// memoryRuleExecutionRepository 以规则和用户为键的内存执行记录仓储
+type memoryRuleExecutionRepository struct {
+	repository.PermissionRuleExecutionRepository
+	executions map[[2]uint]*database.PermissionRuleExecution
+}
+
+func (r *memoryRuleExecutionRepository) Record(ctx context.Context, execution *database.PermissionRuleExecution) (bool, error) {
+	key := [2]uint{execution.RuleID, execution.UserID}
+	if r.executions[key] != nil {
+		return false, nil
+	}
+	r.executions[key] = execution
+	return true, nil
+}
+
+func (r *memoryRuleExecutionRepository) ListExecutedUserIDs(ctx context.Context, ruleID uint) (map[uint]bool, error) {
+	executed := make(map[uint]bool)
+	for key := range r.executions {
+		if key[0] == ruleID {
+			executed[key[1]] = true
+		}
+	}
+	return executed, nil
+}
+
+// tenureEmployeeRepository 按入职和转正日期筛选员工的仓储
+type tenureEmployeeRepository struct {
+	repository.EmployeeRepository
+	employees []*database.Employee
+}
+
+func (r *tenureEmployeeRepository) ListTenured(ctx context.Context, hiredBefore, confirmedBefore *time.Time) ([]*database.Employee, error) {
+	var result []*database.Employee
+	for _, employee := range r.employees {
+		if (hiredBefore != nil && employee.HireDate != nil && !employee.HireDate.After(*hiredBefore)) ||
+			(confirmedBefore != nil && employee.ConfirmDate != nil && !employee.ConfirmDate.After(*confirmedBefore)) {
+			result = append(result, employee)
+		}
+	}
+	return result, nil
+}
+
+// existingTemplateRepository 只包含指定模板的仓储
+type existingTemplateRepository struct {
+	repository.PermissionTemplateRepository
+	templates map[uint]*database.PermissionTemplate
+}
+
+func (r *existingTemplateRepository) GetByID(ctx context.Context, id uint) (*database.PermissionTemplate, error) {
+	if template, ok := r.templates[id]; ok {
+		return template, nil
+	}
+	return nil, repository.ErrNotFound
+}
+
+// tenureRepoManager 司龄权限规则测试用仓储管理器
+type tenureRepoManager struct {
+	repository.RepositoryManager
+	assignments *memoryAssignmentRepository
+	histories   *recordingAssignmentHistoryRepository
+	rules       *listRuleRepository
+	executions  *memoryRuleExecutionRepository
+	employees   *tenureEmployeeRepository
+	templates   *existingTemplateRepository
+}
+
+func (m *tenureRepoManager) PermissionAssignmentRepository() repository.PermissionAssignmentRepository {
+	return m.assignments
+}
+
+func (m *tenureRepoManager) PermissionAssignmentHistoryRepository() repository.PermissionAssignmentHistoryRepository {
+	return m.histories
+}
+
+func (m *tenureRepoManager) PermissionRuleRepository() repository.PermissionRuleRepository {
+	return m.rules
+}
+
+func (m *tenureRepoManager) PermissionRuleExecutionRepository() repository.PermissionRuleExecutionRepository {
+	return m.executions
+}
+
+func (m *tenureRepoManager) EmployeeRepository() repository.EmployeeRepository {
+	return m.employees
+}
+
+func (m *tenureRepoManager) PermissionTemplateRepository() repository.PermissionTemplateRepository {
+	return m.templates
+}
+
+func (m *tenureRepoManager) WithTx(ctx context.Context, fn func(ctx context.Context, repos repository.RepositoryManager) error) error {
+	return fn(ctx, m)
+}
+
+// tenureRule 入职或转正满days天时分配templateID的规则
+func tenureRule(id uint, trigger string, days string, templateID uint, requireApproval bool) *database.PermissionRule {
+	rule := &database.PermissionRule{
+		TemplateID:       templateID,
+		Name:             "司龄规则",
+		TriggerCondition: trigger,
+		ConditionValue:   days,
+		Action:           "grant",
+		IsActive:         true,
+		AutoExecute:      true,
+		RequireApproval:  requireApproval,
+	}
+	rule.ID = id
+	return rule
+}
+
+func tenureEmployee(userID uint, hireDate, confirmDate *time.Time) *database.Employee {
+	employee := &database.Employee{UserID: userID, HireDate: hireDate, ConfirmDate: confirmDate}
+	employee.ID = userID
+	return employee
+}
+
+func newTenureRepoManager(rules []*database.PermissionRule, employees ...*database.Employee) *tenureRepoManager {
+	return &tenureRepoManager{
+		assignments: &memoryAssignmentRepository{},
+		histories:   &recordingAssignmentHistoryRepository{},
+		rules:       &listRuleRepository{rules: rules},
+		executions:  &memoryRuleExecutionRepository{executions: make(map[[2]uint]*database.PermissionRuleExecution)},
+		employees:   &tenureEmployeeRepository{employees: employees},
+		templates:   &existingTemplateRepository{templates: map[uint]*database.PermissionTemplate{}},
+	}
+}
+
+func daysAgo(now time.Time, days int) *time.Time {
+	date := now.AddDate(0, 0, -days)
+	return &date
+}
+
+func TestEvaluatePermissionRules_TenureThreshold(t *testing.T) {
+	delayed := tenureRule(2, PermissionTriggerTenureDays, "90", 6, false)
+	delayed.DelayDays = 30
+	inactive := tenureRule(3, PermissionTriggerTenureDays, "30", 7, false)
+	inactive.IsActive = false
+	repos := newTenureRepoManager([]*database.PermissionRule{
+		tenureRule(1, PermissionTriggerTenureDays, "90", 5, false),
+		delayed,
+		inactive,
+		tenureRule(4, PermissionTriggerConfirmedDays, "30", 8, false),
+	})
+	svc := NewPermissionAssignmentService(repos, nil)
+	ctx := context.Background()
+
+	rules, err := svc.EvaluatePermissionRules(ctx, 100, PermissionTriggerTenureDays, "89")
+	require.NoError(t, err)
+	assert.Empty(t, rules)
+
+	// 达到阈值后生效，延迟天数计入阈值，停用规则和其它触发条件的规则不匹配
+	rules, err = svc.EvaluatePermissionRules(ctx, 100, PermissionTriggerTenureDays, "90")
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	assert.Equal(t, uint(1), rules[0].ID)
+
+	rules, err = svc.EvaluatePermissionRules(ctx, 100, PermissionTriggerTenureDays, "120")
+	require.NoError(t, err)
+	assert.Len(t, rules, 2)
+
+	_, err = svc.EvaluatePermissionRules(ctx, 100, PermissionTriggerTenureDays, "abc")
+	assert.ErrorIs(t, err, ErrInvalidPermissionRule)
+}
+
+func TestProcessTenureRules_AppliesOnce(t *testing.T) {
+	now := time.Date(2024, 6, 1, 2, 0, 0, 0, time.Local)
+	manual := tenureRule(3, PermissionTriggerTenureDays, "10", 7, false)
+	manual.AutoExecute = false
+	repos := newTenureRepoManager(
+		[]*database.PermissionRule{
+			tenureRule(1, PermissionTriggerTenureDays, "90", 5, false),
+			tenureRule(2, PermissionTriggerConfirmedDays, "30", 6, true),
+			manual,
+		},
+		tenureEmployee(100, daysAgo(now, 120), daysAgo(now, 30)),
+		tenureEmployee(101, daysAgo(now, 89), nil),
+	)
+	svc := NewPermissionAssignmentService(repos, nil)
+	ctx := context.Background()
+
+	report, err := svc.ProcessTenureRules(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Checked)
+	assert.Equal(t, 1, report.Granted)
+	assert.Equal(t, 1, report.Pending)
+	assert.Zero(t, report.Failed)
+
+	// 入职满90天的规则直接生效，转正满30天的规则需要审批，关闭自动执行的规则不处理
+	require.Len(t, repos.assignments.assignments, 2)
+	granted, pending := repos.assignments.assignments[0], repos.assignments.assignments[1]
+	assert.Equal(t, uint(5), *granted.TemplateID)
+	assert.Equal(t, database.PermissionStatusActive, granted.Status)
+	assert.Equal(t, PermissionTriggerTenureDays, granted.TriggerEvent)
+	assert.JSONEq(t, `{"since":"2024-02-02","days":120}`, granted.TriggerData)
+	assert.Equal(t, uint(6), *pending.TemplateID)
+	assert.Equal(t, database.PermissionStatusPending, pending.Status)
+	assert.Equal(t, database.ApprovalStatusPending, pending.ApprovalStatus)
+	assert.Equal(t, PermissionTriggerConfirmedDays, pending.TriggerEvent)
+
+	execution := repos.executions.executions[[2]uint{1, 100}]
+	require.NotNil(t, execution)
+	assert.Equal(t, granted.ID, *execution.AssignmentID)
+	assert.Equal(t, "120", execution.TriggerValue)
+
+	// 次日101号员工满90天获得分配；100号员工的分配被撤销后也不会重复执行
+	granted.Status = database.PermissionStatusRevoked
+	report, err = svc.ProcessTenureRules(ctx, now.AddDate(0, 0, 1))
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Checked)
+	assert.Equal(t, 1, report.Granted)
+	assert.Zero(t, report.Pending+report.Unchanged)
+	require.Len(t, repos.assignments.assignments, 3)
+	assert.Equal(t, uint(101), repos.assignments.assignments[2].UserID)
+}
+
+func TestProcessTenureRules_RecordsWhenTemplateHeld(t *testing.T) {
+	now := time.Date(2024, 6, 1, 2, 0, 0, 0, time.Local)
+	repos := newTenureRepoManager(
+		[]*database.PermissionRule{tenureRule(1, PermissionTriggerTenureDays, "90", 5, false)},
+		tenureEmployee(100, daysAgo(now, 90), nil),
+	)
+	templateID := uint(5)
+	require.NoError(t, repos.assignments.Create(context.Background(), &database.PermissionAssignment{
+		UserID:     100,
+		TemplateID: &templateID,
+		Status:     database.PermissionStatusActive,
+	}))
+	svc := NewPermissionAssignmentService(repos, nil)
+
+	report, err := svc.ProcessTenureRules(context.Background(), now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Unchanged)
+	assert.Len(t, repos.assignments.assignments, 1)
+	execution := repos.executions.executions[[2]uint{1, 100}]
+	require.NotNil(t, execution)
+	assert.Nil(t, execution.AssignmentID)
+}
+
+func TestCreatePermissionRule_Validation(t *testing.T) {
+	repos := newTenureRepoManager(nil)
+	template := positionTemplate(5, 3)
+	repos.templates.templates[5] = &template
+	svc := NewPermissionAssignmentService(repos, nil)
+	ctx := context.Background()
+
+	req := &CreatePermissionRuleRequest{
+		TemplateID:       5,
+		Name:             "入职满90天",
+		TriggerCondition: PermissionTriggerTenureDays,
+		ConditionValue:   "90",
+		Action:           "grant",
+		RequireApproval:  true,
+	}
+	rule, err := svc.CreatePermissionRule(ctx, req)
+	require.NoError(t, err)
+	assert.True(t, rule.IsActive)
+	assert.True(t, rule.AutoExecute)
+	assert.True(t, rule.RequireApproval)
+	require.NotNil(t, rule.Template)
+	assert.Equal(t, uint(5), rule.Template.ID)
+
+	invalid := *req
+	invalid.ConditionValue = "三个月"
+	_, err = svc.CreatePermissionRule(ctx, &invalid)
+	assert.ErrorIs(t, err, ErrInvalidPermissionRule)
+
+	invalid = *req
+	invalid.TemplateID = 9
+	_, err = svc.CreatePermissionRule(ctx, &invalid)
+	assert.ErrorIs(t, err, ErrInvalidPermissionRule)
+
+	invalid = *req
+	invalid.DelayDays = -1
+	_, err = svc.CreatePermissionRule(ctx, &invalid)
+	assert.ErrorIs(t, err, ErrInvalidPermissionRule)
+	assert.Len(t, repos.rules.rules, 1)
+}
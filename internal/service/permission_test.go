@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/cache"
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+// unavailableCache 模拟Redis不可用的缓存
+type unavailableCache struct {
+	cache.Cache
+}
+
+func (unavailableCache) Get(ctx context.Context, key string) ([]byte, error) {
+	return nil, cache.ErrCacheConnection
+}
+
+func (unavailableCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return cache.ErrCacheConnection
+}
+
+// staticPermissionRepository 返回固定有效权限的权限仓储
+type staticPermissionRepository struct {
+	repository.PermissionRepository
+}
+
+func (staticPermissionRepository) GetEffectivePermissions(ctx context.Context, userID uint) ([]*database.Permission, error) {
+	return []*database.Permission{{Resource: "tasks", Action: "read"}}, nil
+}
+
+// permissionRepoManager 权限服务测试用仓储管理器
+type permissionRepoManager struct {
+	repository.RepositoryManager
+}
+
+func (permissionRepoManager) PermissionRepository() repository.PermissionRepository {
+	return staticPermissionRepository{}
+}
+
+func TestGetEffectivePermissions_CacheUnavailable(t *testing.T) {
+	ctx := context.Background()
+	permissionCache := cache.NewPermissionCache(unavailableCache{}, time.Minute)
+
+	// 默认回源数据库
+	svc := NewPermissionService(permissionRepoManager{}, permissionCache)
+	allowed, err := svc.HasPermission(ctx, 1, "tasks", "read")
+	require.NoError(t, err)
+	assert.True(t, allowed)
+
+	// 关闭回源后返回缓存错误
+	permissionCache.DisableFallback()
+	_, err = svc.HasPermission(ctx, 1, "tasks", "read")
+	assert.Error(t, err)
+}
@@ -7,8 +7,12 @@ import (
 	"github.com/sirupsen/logrus"
 	"taskmanage/internal/database"
 	"taskmanage/internal/repository"
+	"taskmanage/pkg/response"
 )
 
+// ErrPositionInUse 职位仍被引用，详情为各类引用数
+var ErrPositionInUse = response.NewError(response.ErrCodeConflict, "职位仍被引用，不能删除")
+
 // positionService 职位服务实现
 type positionService struct {
 	repoManager repository.RepositoryManager
@@ -84,15 +88,100 @@ func (s *positionService) UpdatePosition(ctx context.Context, id uint, req *Upda
 }
 
 // DeletePosition 删除职位
-func (s *positionService) DeletePosition(ctx context.Context, id uint) error {
-	s.logger.WithField("id", id).Info("删除职位")
+// 职位仍被未离职员工、权限模板或入职权限配置引用时返回ErrPositionInUse；
+// 指定replacementID时在同一事务中将这些引用迁移到职级相同的替换职位后再删除
+func (s *positionService) DeletePosition(ctx context.Context, id uint, replacementID *uint) error {
+	log := s.logger.WithField("id", id)
+	if replacementID != nil {
+		log = log.WithField("replacement_id", *replacementID)
+	}
+	log.Info("删除职位")
 
 	repo := s.repoManager.PositionRepository()
-	if err := repo.Delete(ctx, id); err != nil {
-		s.logger.WithError(err).Error("删除职位失败")
-		return fmt.Errorf("删除职位失败: %w", err)
+	exists, err := repo.Exists(ctx, id)
+	if err != nil {
+		return fmt.Errorf("获取职位失败: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("%w: %d", ErrPositionNotFound, id)
+	}
+	if replacementID != nil {
+		if err := s.checkReplacement(ctx, id, *replacementID); err != nil {
+			return err
+		}
 	}
 
+	var moved repository.PositionReferences
+	err = s.repoManager.WithTx(ctx, func(ctx context.Context, repos repository.RepositoryManager) error {
+		if replacementID == nil {
+			refs, err := repos.PositionRepository().CountReferences(ctx, id)
+			if err != nil {
+				return err
+			}
+			if refs.Total() > 0 {
+				return response.Wrapf(ErrPositionInUse, "未离职员工%d人、权限模板%d个、入职权限配置%d个",
+					refs.Employees, refs.PermissionTemplates, refs.OnboardingConfigs).WithDetails(refs)
+			}
+		} else {
+			var err error
+			if moved.Employees, err = repos.EmployeeRepository().MoveToPosition(ctx, id, *replacementID); err != nil {
+				return err
+			}
+			if moved.PermissionTemplates, err = repos.PermissionTemplateRepository().MoveToPosition(ctx, id, *replacementID); err != nil {
+				return fmt.Errorf("迁移职位权限模板失败: %w", err)
+			}
+			if moved.OnboardingConfigs, err = repos.OnboardingPermissionConfigRepository().MoveToPosition(ctx, id, *replacementID); err != nil {
+				return fmt.Errorf("迁移入职权限配置失败: %w", err)
+			}
+		}
+
+		if err := repos.PositionRepository().Delete(ctx, id); err != nil {
+			return fmt.Errorf("删除职位失败: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		log.WithError(err).Error("删除职位失败")
+		return err
+	}
+
+	if replacementID != nil {
+		log.WithFields(logrus.Fields{
+			"employees":  moved.Employees,
+			"templates":  moved.PermissionTemplates,
+			"onboarding": moved.OnboardingConfigs,
+		}).Info("职位引用已迁移，职位已删除")
+	}
+	return nil
+}
+
+// checkReplacement 校验删除职位时的替换职位
+// 批量迁移员工不触发position_change权限规则，因此替换职位的职级须与原职位相同，职级变化请逐个调整员工职位
+func (s *positionService) checkReplacement(ctx context.Context, id, replacementID uint) error {
+	if replacementID == id {
+		return response.Wrapf(ErrInvalidReassignTarget, "不能替换为待删除的职位自身")
+	}
+
+	repo := s.repoManager.PositionRepository()
+	exists, err := repo.Exists(ctx, replacementID)
+	if err != nil {
+		return fmt.Errorf("获取替换职位失败: %w", err)
+	}
+	if !exists {
+		return response.Wrapf(ErrInvalidReassignTarget, "替换职位%d不存在", replacementID)
+	}
+
+	position, err := repo.GetByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("获取职位失败: %w", err)
+	}
+	replacement, err := repo.GetByID(ctx, replacementID)
+	if err != nil {
+		return fmt.Errorf("获取替换职位失败: %w", err)
+	}
+	if replacement.Level != position.Level {
+		return response.Wrapf(ErrInvalidReassignTarget, "替换职位%s的职级%d与原职级%d不同", replacement.Name, replacement.Level, position.Level)
+	}
 	return nil
 }
 
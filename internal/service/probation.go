@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"taskmanage/internal/database"
+	"taskmanage/pkg/workcalendar"
+)
+
+const (
+	// MinProbationDays 试用期最短天数
+	MinProbationDays = 30
+	// MaxProbationDays 试用期最长天数
+	MaxProbationDays = 180
+	// DefaultProbationReminderDays 试用期结束前发送转正评估提醒的默认提前天数
+	DefaultProbationReminderDays = 14
+	// DefaultProbationReviewerRole 默认接收转正评估提醒的角色
+	DefaultProbationReviewerRole = "admin"
+)
+
+// ErrInvalidProbationDays 试用期天数不合法
+var ErrInvalidProbationDays = errors.New("试用期天数不合法")
+
+// validateProbationDays 校验试用期天数在30-180天之间
+func validateProbationDays(days int) error {
+	if days < MinProbationDays || days > MaxProbationDays {
+		return fmt.Errorf("%w: 应在%d-%d天之间，实际为%d天", ErrInvalidProbationDays, MinProbationDays, MaxProbationDays, days)
+	}
+	return nil
+}
+
+// probationEndDate 按入职日期和试用期天数计算试用期结束日期，calendar不为nil时试用期天数按工作日计算
+// 未记录试用期天数的历史员工沿用3个月试用期，未入职时返回nil
+func probationEndDate(employee *database.Employee, calendar *workcalendar.Calendar) *time.Time {
+	if employee.HireDate == nil {
+		return nil
+	}
+	end := employee.HireDate.AddDate(0, 3, 0)
+	if employee.ProbationDays > 0 {
+		end = calendar.AddWorkingDays(*employee.HireDate, employee.ProbationDays)
+	}
+	return &end
+}
+
+// probationCalendar 返回计算试用期结束日期使用的工作日历，试用期按自然日计算时返回nil
+func (s *OnboardingServiceImpl) probationCalendar(ctx context.Context) *workcalendar.Calendar {
+	if s.workCalendar == nil {
+		return nil
+	}
+	return s.workCalendar.ProbationCalendar(ctx)
+}
+
+// RemindProbationEnding 为试用期将在withinDays天内结束（含已到期未转正）的员工创建转正评估任务，
+// 并通知HR和直接上级。每名员工只提醒一次，失败的员工留待下次重试
+func (s *OnboardingServiceImpl) RemindProbationEnding(ctx context.Context, now time.Time, withinDays int) (int, error) {
+	if withinDays <= 0 {
+		withinDays = DefaultProbationReminderDays
+	}
+	logger := s.logger.WithField("method", "RemindProbationEnding")
+
+	employees, err := s.employeeRepo.ListProbationEnding(ctx, now.AddDate(0, 0, withinDays))
+	if err != nil {
+		return 0, fmt.Errorf("获取试用期即将结束的员工失败: %w", err)
+	}
+	if len(employees) == 0 {
+		return 0, nil
+	}
+
+	reviewers, err := s.probationReviewers(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	reminded := 0
+	for _, employee := range employees {
+		log := logger.WithField("employee_id", employee.ID)
+		if err := s.remindProbationEnding(ctx, employee, reviewers); err != nil {
+			log.WithError(err).Warn("发送试用期到期提醒失败")
+			continue
+		}
+		if err := s.employeeRepo.MarkProbationReminded(ctx, employee.ID, now); err != nil {
+			log.WithError(err).Warn("标记试用期提醒失败")
+			continue
+		}
+		reminded++
+	}
+	return reminded, nil
+}
+
+// probationReviewers 获取接收转正评估提醒的HR用户ID
+func (s *OnboardingServiceImpl) probationReviewers(ctx context.Context) ([]uint, error) {
+	users, err := s.userRepo.GetUsersByRole(ctx, s.probationReviewerRole)
+	if err != nil {
+		return nil, fmt.Errorf("获取%s角色用户失败: %w", s.probationReviewerRole, err)
+	}
+	ids := make([]uint, 0, len(users))
+	for _, user := range users {
+		ids = append(ids, user.ID)
+	}
+	return ids, nil
+}
+
+// remindProbationEnding 创建分配给直接上级（无直接上级时为HR）的转正评估任务，并通知直接上级和HR
+func (s *OnboardingServiceImpl) remindProbationEnding(ctx context.Context, employee *database.Employee, reviewers []uint) error {
+	recipients := make([]uint, 0, len(reviewers)+1)
+	if employee.DirectManagerID != nil {
+		manager, err := s.employeeRepo.GetByID(ctx, *employee.DirectManagerID)
+		if err != nil {
+			return fmt.Errorf("获取直接上级失败: %w", err)
+		}
+		recipients = append(recipients, manager.UserID)
+	}
+	for _, id := range reviewers {
+		if len(recipients) == 0 || id != recipients[0] {
+			recipients = append(recipients, id)
+		}
+	}
+	if len(recipients) == 0 {
+		return fmt.Errorf("员工没有直接上级且%s角色下没有用户", s.probationReviewerRole)
+	}
+
+	name := employee.User.RealName
+	endDate := employee.ProbationEndDate.Format("2006-01-02")
+	assigneeID := recipients[0]
+	task := &database.Task{
+		Title:       fmt.Sprintf("试用期转正评估：%s", name),
+		Description: fmt.Sprintf("员工%s（工号%s）的试用期将于%s结束，请完成转正评估并办理转正确认（POST /api/v1/onboarding/confirm-employee，employee_id=%d）", name, employee.EmployeeNo, endDate, employee.ID),
+		Priority:    "high",
+		Status:      "assigned",
+		DueDate:     employee.ProbationEndDate,
+		CreatorID:   assigneeID,
+		AssigneeID:  &assigneeID,
+	}
+	if err := s.taskRepo.Create(ctx, task); err != nil {
+		return fmt.Errorf("创建转正评估任务失败: %w", err)
+	}
+
+	if s.notificationService == nil {
+		return nil
+	}
+	content := fmt.Sprintf("员工%s的试用期将于%s结束，已创建转正评估任务#%d，请及时办理转正确认", name, endDate, task.ID)
+	for _, recipientID := range recipients {
+		if err := s.notificationService.CreateCategorizedNotification(ctx, recipientID, NotificationCategoryOnboardingStatus, "试用期即将结束", content); err != nil {
+			s.logger.WithError(err).WithFields(logrus.Fields{
+				"employee_id":  employee.ID,
+				"recipient_id": recipientID,
+			}).Warn("发送试用期到期通知失败")
+		}
+	}
+	return nil
+}
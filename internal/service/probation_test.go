@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+	"taskmanage/pkg/workcalendar"
+)
+
+// roleUserRepository 按角色返回固定用户的用户仓储桩
+type roleUserRepository struct {
+	repository.UserRepository
+	users map[string][]*database.User
+}
+
+func (r *roleUserRepository) GetUsersByRole(ctx context.Context, role string) ([]*database.User, error) {
+	return r.users[role], nil
+}
+
+func TestProbationEndDate(t *testing.T) {
+	assert.ErrorIs(t, validateProbationDays(29), ErrInvalidProbationDays)
+	assert.ErrorIs(t, validateProbationDays(181), ErrInvalidProbationDays)
+	assert.NoError(t, validateProbationDays(60))
+
+	hireDate := time.Date(2024, 1, 15, 0, 0, 0, 0, time.Local)
+	assert.Equal(t, time.Date(2024, 3, 15, 0, 0, 0, 0, time.Local), *probationEndDate(&database.Employee{HireDate: &hireDate, ProbationDays: 60}, nil))
+	// 未记录试用期天数时沿用3个月
+	assert.Equal(t, time.Date(2024, 4, 15, 0, 0, 0, 0, time.Local), *probationEndDate(&database.Employee{HireDate: &hireDate}, nil))
+	assert.Nil(t, probationEndDate(&database.Employee{ProbationDays: 60}, nil))
+
+	// 按工作日计算时跳过周末和节假日：周一入职的60个工作日为12周，再顺延清明节假日1天
+	calendar := workcalendar.New(workcalendar.DefaultWorkingHours(), []time.Time{time.Date(2024, 4, 4, 0, 0, 0, 0, time.Local)})
+	assert.Equal(t, time.Date(2024, 4, 9, 0, 0, 0, 0, time.Local), *probationEndDate(&database.Employee{HireDate: &hireDate, ProbationDays: 60}, calendar))
+}
+
+func TestRemindProbationEnding(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	endDate := now.AddDate(0, 0, 5)
+	managerID := uint(20)
+	withManager := &database.Employee{BaseModel: database.BaseModel{ID: 10}, UserID: 100, DirectManagerID: &managerID, ProbationEndDate: &endDate, User: database.User{RealName: "张三"}}
+	withoutManager := &database.Employee{BaseModel: database.BaseModel{ID: 11}, UserID: 110, ProbationEndDate: &endDate, User: database.User{RealName: "李四"}}
+
+	employeeRepo := new(MockEmployeeRepository)
+	employeeRepo.On("ListProbationEnding", ctx, now.AddDate(0, 0, DefaultProbationReminderDays)).Return([]*database.Employee{withManager, withoutManager}, nil)
+	employeeRepo.On("GetByID", ctx, managerID).Return(&database.Employee{BaseModel: database.BaseModel{ID: managerID}, UserID: 200}, nil)
+	employeeRepo.On("MarkProbationReminded", ctx, mock.Anything, now).Return(nil)
+
+	var tasks []*database.Task
+	taskRepo := new(MockTaskRepository)
+	taskRepo.On("Create", ctx, mock.Anything).Run(func(args mock.Arguments) {
+		tasks = append(tasks, args.Get(1).(*database.Task))
+	}).Return(nil)
+
+	notifications := &recordingNotificationService{}
+	svc := &OnboardingServiceImpl{
+		employeeRepo:          employeeRepo,
+		userRepo:              &roleUserRepository{users: map[string][]*database.User{"admin": {{BaseModel: database.BaseModel{ID: 1}}}}},
+		taskRepo:              taskRepo,
+		notificationService:   notifications,
+		probationReviewerRole: DefaultProbationReviewerRole,
+		logger:                logrus.New(),
+	}
+
+	reminded, err := svc.RemindProbationEnding(ctx, now, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 2, reminded)
+
+	// 转正评估任务分配给直接上级，无直接上级时分配给HR
+	require.Len(t, tasks, 2)
+	assert.Equal(t, uint(200), *tasks[0].AssigneeID)
+	assert.Equal(t, &endDate, tasks[0].DueDate)
+	assert.Equal(t, uint(1), *tasks[1].AssigneeID)
+	assert.Equal(t, []uint{200, 1, 1}, notifications.recipients)
+	employeeRepo.AssertCalled(t, "MarkProbationReminded", ctx, uint(10), now)
+	employeeRepo.AssertCalled(t, "MarkProbationReminded", ctx, uint(11), now)
+}
@@ -0,0 +1,253 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+	"taskmanage/pkg/logger"
+	"taskmanage/pkg/response"
+)
+
+// AuditActionUserPhoneUpdate 用户修改自己的手机号时记录的审计操作
+const AuditActionUserPhoneUpdate = "user.phone_update"
+
+// ErrInvalidProfile 个人资料更新请求不合法
+var ErrInvalidProfile = response.NewError(response.ErrCodeInvalidRequest, "个人资料不合法")
+
+// MyProfileResponse 当前用户的个人资料，合并用户账号和员工档案
+type MyProfileResponse struct {
+	ID                      uint                              `json:"id"`
+	Username                string                            `json:"username"`
+	Email                   string                            `json:"email"`
+	RealName                string                            `json:"real_name"`
+	Phone                   string                            `json:"phone"`
+	Avatar                  string                            `json:"avatar"`
+	Role                    string                            `json:"role"`
+	Status                  string                            `json:"status"`
+	LastLoginAt             *time.Time                        `json:"last_login_at,omitempty"`
+	Employee                *MyEmployeeProfile                `json:"employee"` // 没有员工档案的用户为null
+	NotificationPreferences []*NotificationPreferenceResponse `json:"notification_preferences"`
+}
+
+// MyEmployeeProfile 个人资料中的员工档案
+type MyEmployeeProfile struct {
+	ID                uint            `json:"id"`
+	EmployeeNo        string          `json:"employee_no"`
+	DepartmentID      *uint           `json:"department_id"`
+	DepartmentName    string          `json:"department_name"`
+	PositionID        *uint           `json:"position_id"`
+	PositionName      string          `json:"position_name"`
+	DirectManagerID   *uint           `json:"direct_manager_id"`
+	DirectManagerName string          `json:"direct_manager_name"`
+	Status            string          `json:"status"`
+	OnboardingStatus  string          `json:"onboarding_status"`
+	WorkLocation      string          `json:"work_location"`
+	WorkType          string          `json:"work_type"`
+	HireDate          *time.Time      `json:"hire_date,omitempty"`
+	ProbationEndDate  *time.Time      `json:"probation_end_date,omitempty"`
+	Skills            []SkillResponse `json:"skills"`
+}
+
+// UpdateMyProfileRequest 更新个人资料请求，只允许修改手机号、头像和通知偏好，未提供的字段保持不变
+// 部门、职位、状态等字段由人事维护，请求中携带时被忽略
+type UpdateMyProfileRequest struct {
+	Phone                   *string                      `json:"phone" binding:"omitempty,max=20"`
+	Avatar                  *string                      `json:"avatar" binding:"omitempty,max=255"`
+	NotificationPreferences []NotificationPreferenceItem `json:"notification_preferences" binding:"omitempty,dive"`
+}
+
+// ProfileService 当前用户个人资料服务，供员工自助查看和维护自己的资料，不需要员工管理权限
+type ProfileService interface {
+	// GetMyProfile 获取当前用户的个人资料
+	GetMyProfile(ctx context.Context, userID uint) (*MyProfileResponse, error)
+	// UpdateMyProfile 更新当前用户的手机号、头像和通知偏好，手机号变化时记录审计日志
+	UpdateMyProfile(ctx context.Context, userID uint, req *UpdateMyProfileRequest) (*MyProfileResponse, error)
+}
+
+// profileService 个人资料服务实现
+type profileService struct {
+	repoManager         repository.RepositoryManager
+	notificationService NotificationService
+}
+
+// NewProfileService 创建个人资料服务
+func NewProfileService(repoManager repository.RepositoryManager, notificationService NotificationService) ProfileService {
+	return &profileService{
+		repoManager:         repoManager,
+		notificationService: notificationService,
+	}
+}
+
+// GetMyProfile 获取当前用户的个人资料
+func (s *profileService) GetMyProfile(ctx context.Context, userID uint) (*MyProfileResponse, error) {
+	user, err := s.repoManager.UserRepository().GetByID(ctx, userID)
+	if err != nil {
+		return nil, notFoundOr(err, response.ErrCodeNotFound, "用户不存在", "获取用户失败")
+	}
+	return s.buildProfile(ctx, user)
+}
+
+// UpdateMyProfile 更新当前用户的个人资料
+// 先更新通知偏好，偏好不合法时不做任何修改；手机号的修改和审计日志在同一事务中写入
+func (s *profileService) UpdateMyProfile(ctx context.Context, userID uint, req *UpdateMyProfileRequest) (*MyProfileResponse, error) {
+	user, err := s.repoManager.UserRepository().GetByID(ctx, userID)
+	if err != nil {
+		return nil, notFoundOr(err, response.ErrCodeNotFound, "用户不存在", "获取用户失败")
+	}
+
+	if len(req.NotificationPreferences) > 0 {
+		_, err := s.notificationService.UpdatePreferences(ctx, userID, &UpdateNotificationPreferencesRequest{Preferences: req.NotificationPreferences})
+		if err != nil {
+			if errors.Is(err, ErrInvalidNotificationPreference) || errors.Is(err, ErrNotificationPreferenceLocked) {
+				return nil, fmt.Errorf("%w: %w", ErrInvalidProfile, err)
+			}
+			return nil, err
+		}
+	}
+
+	oldPhone := user.Phone
+	changed := false
+	if req.Phone != nil && *req.Phone != user.Phone {
+		user.Phone = *req.Phone
+		changed = true
+	}
+	if req.Avatar != nil && *req.Avatar != user.Avatar {
+		user.Avatar = *req.Avatar
+		changed = true
+	}
+
+	if changed {
+		err := s.repoManager.WithTx(ctx, func(ctx context.Context, repos repository.RepositoryManager) error {
+			if err := repos.UserRepository().Update(ctx, user); err != nil {
+				return fmt.Errorf("更新用户资料失败: %w", err)
+			}
+			if user.Phone == oldPhone {
+				return nil
+			}
+			return s.auditPhoneUpdate(ctx, repos, user.ID, oldPhone, user.Phone)
+		})
+		if err != nil {
+			return nil, err
+		}
+		logger.FromContext(ctx).Infof("用户更新个人资料: user_id=%d, 手机号变化=%t", user.ID, user.Phone != oldPhone)
+	}
+
+	return s.buildProfile(ctx, user)
+}
+
+// auditPhoneUpdate 记录用户修改手机号前后的值
+func (s *profileService) auditPhoneUpdate(ctx context.Context, repos repository.RepositoryManager, userID uint, oldPhone, newPhone string) error {
+	data, err := json.Marshal(map[string]interface{}{
+		"old_phone": oldPhone,
+		"new_phone": newPhone,
+	})
+	if err != nil {
+		return fmt.Errorf("序列化审计数据失败: %w", err)
+	}
+	audit := &database.AuditLog{
+		UserID:       userID,
+		Action:       AuditActionUserPhoneUpdate,
+		Resource:     "user",
+		ResourceID:   userID,
+		Method:       "PUT",
+		Path:         "/api/v1/me",
+		RequestData:  string(data),
+		ResponseData: "{}",
+	}
+	if err := repos.AuditLogRepository().Create(ctx, audit); err != nil {
+		return fmt.Errorf("记录手机号修改审计日志失败: %w", err)
+	}
+	return nil
+}
+
+// buildProfile 组装个人资料，部门、职位、上级和技能加载失败时只记录日志并留空
+func (s *profileService) buildProfile(ctx context.Context, user *database.User) (*MyProfileResponse, error) {
+	profile := &MyProfileResponse{
+		ID:          user.ID,
+		Username:    user.Username,
+		Email:       user.Email,
+		RealName:    user.RealName,
+		Phone:       user.Phone,
+		Avatar:      user.Avatar,
+		Role:        user.Role,
+		Status:      user.Status,
+		LastLoginAt: user.LastLoginAt,
+	}
+
+	preferences, err := s.notificationService.GetPreferences(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	profile.NotificationPreferences = preferences
+
+	employee, err := s.repoManager.EmployeeRepository().GetByUserID(ctx, user.ID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return profile, nil
+		}
+		return nil, employeeLookupError(err)
+	}
+	profile.Employee = s.buildEmployeeProfile(ctx, employee)
+	return profile, nil
+}
+
+// buildEmployeeProfile 组装员工档案部分
+func (s *profileService) buildEmployeeProfile(ctx context.Context, employee *database.Employee) *MyEmployeeProfile {
+	log := logger.FromContext(ctx)
+	profile := &MyEmployeeProfile{
+		ID:               employee.ID,
+		EmployeeNo:       employee.EmployeeNo,
+		DepartmentID:     employee.DepartmentID,
+		PositionID:       employee.PositionID,
+		DirectManagerID:  employee.DirectManagerID,
+		Status:           employee.Status,
+		OnboardingStatus: employee.OnboardingStatus,
+		WorkLocation:     employee.WorkLocation,
+		WorkType:         employee.WorkType,
+		HireDate:         employee.HireDate,
+		ProbationEndDate: employee.ProbationEndDate,
+		Skills:           []SkillResponse{},
+	}
+
+	if employee.DepartmentID != nil {
+		if department, err := s.repoManager.DepartmentRepository().GetByID(ctx, *employee.DepartmentID); err == nil {
+			profile.DepartmentName = department.Name
+		} else {
+			log.Warnf("个人资料获取部门失败: department_id=%d, 错误=%v", *employee.DepartmentID, err)
+		}
+	}
+	if employee.PositionID != nil {
+		if position, err := s.repoManager.PositionRepository().GetByID(ctx, *employee.PositionID); err == nil {
+			profile.PositionName = position.Name
+		} else {
+			log.Warnf("个人资料获取职位失败: position_id=%d, 错误=%v", *employee.PositionID, err)
+		}
+	}
+	if employee.DirectManagerID != nil {
+		if manager, err := s.repoManager.EmployeeRepository().GetByID(ctx, *employee.DirectManagerID); err == nil {
+			if managerUser, err := s.repoManager.UserRepository().GetByID(ctx, manager.UserID); err == nil {
+				profile.DirectManagerName = managerUser.RealName
+			} else {
+				log.Warnf("个人资料获取上级用户失败: user_id=%d, 错误=%v", manager.UserID, err)
+			}
+		} else {
+			log.Warnf("个人资料获取上级失败: employee_id=%d, 错误=%v", *employee.DirectManagerID, err)
+		}
+	}
+
+	skills, err := s.repoManager.SkillRepository().GetEmployeeSkillsWithLevel(ctx, employee.ID)
+	if err != nil {
+		log.Warnf("个人资料获取技能失败: employee_id=%d, 错误=%v", employee.ID, err)
+		return profile
+	}
+	now := time.Now()
+	for _, skill := range skills {
+		profile.Skills = append(profile.Skills, EmployeeSkillToResponse(skill, now))
+	}
+	return profile
+}
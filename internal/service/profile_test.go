@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+// profileUserRepository 测试用内存用户仓储
+type profileUserRepository struct {
+	repository.UserRepository
+	users   map[uint]*database.User
+	updates int
+}
+
+func (r *profileUserRepository) GetByID(ctx context.Context, id uint) (*database.User, error) {
+	if user, ok := r.users[id]; ok {
+		copied := *user
+		return &copied, nil
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *profileUserRepository) Update(ctx context.Context, user *database.User) error {
+	r.updates++
+	copied := *user
+	r.users[user.ID] = &copied
+	return nil
+}
+
+// profileEmployeeRepository 测试用内存员工仓储
+type profileEmployeeRepository struct {
+	repository.EmployeeRepository
+	employees []*database.Employee
+}
+
+func (r *profileEmployeeRepository) GetByID(ctx context.Context, id uint) (*database.Employee, error) {
+	for _, employee := range r.employees {
+		if employee.ID == id {
+			return employee, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *profileEmployeeRepository) GetByUserID(ctx context.Context, userID uint) (*database.Employee, error) {
+	for _, employee := range r.employees {
+		if employee.UserID == userID {
+			return employee, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+// profileDepartmentRepository 测试用部门仓储
+type profileDepartmentRepository struct {
+	repository.DepartmentRepository
+	department *database.Department
+}
+
+func (r *profileDepartmentRepository) GetByID(ctx context.Context, id uint) (*database.Department, error) {
+	if r.department.ID == id {
+		return r.department, nil
+	}
+	return nil, repository.ErrNotFound
+}
+
+// profileSkillRepository 测试用技能仓储
+type profileSkillRepository struct {
+	repository.SkillRepository
+	skills []*database.EmployeeSkillDetail
+}
+
+func (r *profileSkillRepository) GetEmployeeSkillsWithLevel(ctx context.Context, employeeID uint) ([]*database.EmployeeSkillDetail, error) {
+	return r.skills, nil
+}
+
+// profileRepoManager 个人资料测试用仓储管理器，事务直接在当前仓储上执行
+type profileRepoManager struct {
+	repository.RepositoryManager
+	userRepo       *profileUserRepository
+	employeeRepo   *profileEmployeeRepository
+	departmentRepo *profileDepartmentRepository
+	skillRepo      *profileSkillRepository
+	auditLogRepo   *recordingAuditLogRepository
+}
+
+func (m *profileRepoManager) UserRepository() repository.UserRepository { return m.userRepo }
+
+func (m *profileRepoManager) EmployeeRepository() repository.EmployeeRepository {
+	return m.employeeRepo
+}
+
+func (m *profileRepoManager) DepartmentRepository() repository.DepartmentRepository {
+	return m.departmentRepo
+}
+
+func (m *profileRepoManager) SkillRepository() repository.SkillRepository { return m.skillRepo }
+
+func (m *profileRepoManager) AuditLogRepository() repository.AuditLogRepository {
+	return m.auditLogRepo
+}
+
+func (m *profileRepoManager) WithTx(ctx context.Context, fn func(ctx context.Context, repos repository.RepositoryManager) error) error {
+	return fn(ctx, m)
+}
+
+// profileNotificationService 记录通知偏好更新的通知服务
+type profileNotificationService struct {
+	NotificationService
+	updated []NotificationPreferenceItem
+}
+
+func (s *profileNotificationService) GetPreferences(ctx context.Context, userID uint) ([]*NotificationPreferenceResponse, error) {
+	return []*NotificationPreferenceResponse{}, nil
+}
+
+func (s *profileNotificationService) UpdatePreferences(ctx context.Context, userID uint, req *UpdateNotificationPreferencesRequest) ([]*NotificationPreferenceResponse, error) {
+	for _, item := range req.Preferences {
+		if item.Category == "unknown" {
+			return nil, ErrInvalidNotificationPreference
+		}
+	}
+	s.updated = append(s.updated, req.Preferences...)
+	return nil, nil
+}
+
+func newProfileRepoManager() *profileRepoManager {
+	departmentID := uint(3)
+	managerID := uint(20)
+	return &profileRepoManager{
+		userRepo: &profileUserRepository{users: map[uint]*database.User{
+			1: {BaseModel: database.BaseModel{ID: 1}, Username: "zhangsan", RealName: "张三", Phone: "13800000000"},
+			2: {BaseModel: database.BaseModel{ID: 2}, Username: "lisi", RealName: "李四"},
+			9: {BaseModel: database.BaseModel{ID: 9}, Username: "admin"},
+		}},
+		employeeRepo: &profileEmployeeRepository{employees: []*database.Employee{
+			{BaseModel: database.BaseModel{ID: 10}, UserID: 1, EmployeeNo: "E010", DepartmentID: &departmentID, DirectManagerID: &managerID},
+			{BaseModel: database.BaseModel{ID: 20}, UserID: 2, EmployeeNo: "E020"},
+		}},
+		departmentRepo: &profileDepartmentRepository{department: &database.Department{BaseModel: database.BaseModel{ID: 3}, Name: "研发部"}},
+		skillRepo: &profileSkillRepository{skills: []*database.EmployeeSkillDetail{
+			{SkillID: 5, Name: "Go", Level: 4},
+		}},
+		auditLogRepo: &recordingAuditLogRepository{},
+	}
+}
+
+func TestGetMyProfile(t *testing.T) {
+	ctx := context.Background()
+	svc := NewProfileService(newProfileRepoManager(), &profileNotificationService{})
+
+	profile, err := svc.GetMyProfile(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "张三", profile.RealName)
+	require.NotNil(t, profile.Employee)
+	assert.Equal(t, "研发部", profile.Employee.DepartmentName)
+	assert.Equal(t, "李四", profile.Employee.DirectManagerName)
+	require.Len(t, profile.Employee.Skills, 1)
+	assert.Equal(t, "Go", profile.Employee.Skills[0].Name)
+
+	// 没有员工档案的用户只返回账号信息
+	profile, err = svc.GetMyProfile(ctx, 9)
+	require.NoError(t, err)
+	assert.Nil(t, profile.Employee)
+}
+
+func TestUpdateMyProfile(t *testing.T) {
+	ctx := context.Background()
+	repos := newProfileRepoManager()
+	notifications := &profileNotificationService{}
+	svc := NewProfileService(repos, notifications)
+
+	// 通知偏好不合法时不修改资料
+	phone := "13900000000"
+	_, err := svc.UpdateMyProfile(ctx, 1, &UpdateMyProfileRequest{
+		Phone:                   &phone,
+		NotificationPreferences: []NotificationPreferenceItem{{Category: "unknown", Channel: "email"}},
+	})
+	assert.ErrorIs(t, err, ErrInvalidProfile)
+	assert.ErrorIs(t, err, ErrInvalidNotificationPreference)
+	assert.Zero(t, repos.userRepo.updates)
+
+	// 只修改头像时不记录审计日志
+	avatar := "https://example.com/a.png"
+	profile, err := svc.UpdateMyProfile(ctx, 1, &UpdateMyProfileRequest{Avatar: &avatar})
+	require.NoError(t, err)
+	assert.Equal(t, avatar, profile.Avatar)
+	assert.Equal(t, "13800000000", profile.Phone)
+	assert.Empty(t, repos.auditLogRepo.logs)
+
+	// 修改手机号记录修改前后的值
+	profile, err = svc.UpdateMyProfile(ctx, 1, &UpdateMyProfileRequest{
+		Phone:                   &phone,
+		NotificationPreferences: []NotificationPreferenceItem{{Category: "task", Channel: "email", Enabled: false}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, phone, profile.Phone)
+	assert.Equal(t, phone, repos.userRepo.users[1].Phone)
+	assert.Len(t, notifications.updated, 1)
+	require.Len(t, repos.auditLogRepo.logs, 1)
+	audit := repos.auditLogRepo.logs[0]
+	assert.Equal(t, AuditActionUserPhoneUpdate, audit.Action)
+	assert.Equal(t, uint(1), audit.UserID)
+	assert.JSONEq(t, `{"old_phone":"13800000000","new_phone":"13900000000"}`, audit.RequestData)
+
+	// 手机号未变化时不更新也不记录
+	updates := repos.userRepo.updates
+	_, err = svc.UpdateMyProfile(ctx, 1, &UpdateMyProfileRequest{Phone: &phone})
+	require.NoError(t, err)
+	assert.Equal(t, updates, repos.userRepo.updates)
+	assert.Len(t, repos.auditLogRepo.logs, 1)
+}
@@ -11,15 +11,17 @@ import (
 
 // projectService 项目服务实现
 type projectService struct {
-	repoManager repository.RepositoryManager
-	logger      *logrus.Logger
+	repoManager   repository.RepositoryManager
+	logger        *logrus.Logger
+	burndownCache *burndownCache
 }
 
 // NewProjectService 创建项目服务实例
 func NewProjectService(repoManager repository.RepositoryManager, logger *logrus.Logger) ProjectService {
 	return &projectService{
-		repoManager: repoManager,
-		logger:      logger,
+		repoManager:   repoManager,
+		logger:        logger,
+		burndownCache: newBurndownCache(DefaultBurndownCacheTTL),
 	}
 }
 
@@ -324,6 +326,7 @@ func (s *projectService) projectToResponse(proj *database.Project) *ProjectRespo
 		Budget:       proj.Budget,
 		DepartmentID: proj.DepartmentID,
 		ManagerID:    proj.ManagerID,
+		WIPLimits:    projectWIPLimits(proj),
 		CreatedAt:    proj.CreatedAt.Format("2006-01-02 15:04:05"),
 		UpdatedAt:    proj.UpdatedAt.Format("2006-01-02 15:04:05"),
 	}
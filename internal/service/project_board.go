@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+	"taskmanage/pkg/i18n"
+	"taskmanage/pkg/response"
+)
+
+// taskBoardStatuses 任务看板的列，按流转顺序排列
+var taskBoardStatuses = []string{"pending", "assigned", "in_progress", "in_review", "completed", "cancelled"}
+
+var (
+	// ErrWIPLimitReached 目标状态的在制品数量已达到项目上限
+	ErrWIPLimitReached = response.NewError(response.ErrCodeWIPLimitReached, "项目在制品数量已达上限")
+
+	// ErrProjectManagerRequired 仅项目经理可以执行该操作
+	ErrProjectManagerRequired = errors.New("仅项目经理可以执行该操作")
+)
+
+// ProjectTaskBoardResponse 项目任务看板响应
+type ProjectTaskBoardResponse struct {
+	ProjectID uint                       `json:"project_id"`
+	Total     int                        `json:"total"`
+	Groups    map[string][]*TaskResponse `json:"groups"`
+	Counts    map[string]int             `json:"counts"`
+	WIPLimits map[string]int             `json:"wip_limits"`
+}
+
+// UpdateWIPLimitsRequest 设置项目在制品上限请求，上限为0表示不限制
+type UpdateWIPLimitsRequest struct {
+	Limits map[string]int `json:"limits" binding:"required"`
+}
+
+// GetProjectTaskBoard 获取按状态分组的项目任务看板
+func (s *projectService) GetProjectTaskBoard(ctx context.Context, projectID uint, filter TaskListFilter) (*ProjectTaskBoardResponse, error) {
+	project, err := s.repoManager.ProjectRepository().GetByID(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("获取项目失败: %w", err)
+	}
+
+	tasks, err := s.repoManager.TaskRepository().GetByProject(ctx, projectID, taskFilterConditions(filter))
+	if err != nil {
+		s.logger.WithError(err).Error("获取项目任务失败")
+		return nil, fmt.Errorf("获取项目任务失败: %w", err)
+	}
+
+	board := &ProjectTaskBoardResponse{
+		ProjectID: projectID,
+		Total:     len(tasks),
+		Groups:    make(map[string][]*TaskResponse, len(taskBoardStatuses)),
+		Counts:    make(map[string]int, len(taskBoardStatuses)),
+		WIPLimits: projectWIPLimits(project),
+	}
+	for _, status := range taskBoardStatuses {
+		board.Groups[status] = make([]*TaskResponse, 0)
+		board.Counts[status] = 0
+	}
+	responses := make([]*TaskResponse, len(tasks))
+	for i, task := range tasks {
+		responses[i] = taskToResponse(task)
+		board.Groups[task.Status] = append(board.Groups[task.Status], responses[i])
+		board.Counts[task.Status]++
+	}
+	if err := expandTaskResponses(ctx, s.repoManager.UserRepository(), s.repoManager.ProjectRepository(), filter.Expand, responses...); err != nil {
+		return nil, err
+	}
+
+	return board, nil
+}
+
+// UpdateWIPLimits 设置项目各状态的在制品上限，仅项目经理可操作
+func (s *projectService) UpdateWIPLimits(ctx context.Context, projectID uint, userID uint, req *UpdateWIPLimitsRequest) (map[string]int, error) {
+	repo := s.repoManager.ProjectRepository()
+	project, err := repo.GetByID(ctx, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("获取项目失败: %w", err)
+	}
+
+	employee, err := s.repoManager.EmployeeRepository().GetByUserID(ctx, userID)
+	if err != nil && !repository.IsNotFoundError(err) {
+		return nil, fmt.Errorf("获取员工信息失败: %w", err)
+	}
+	if employee == nil || employee.ID != project.ManagerID {
+		return nil, ErrProjectManagerRequired
+	}
+
+	limits := make(map[string]int, len(req.Limits))
+	for status, limit := range req.Limits {
+		if status == "completed" || status == "cancelled" || !isValidTaskStatus(status) {
+			return nil, fmt.Errorf("不支持设置在制品上限的任务状态: %s", status)
+		}
+		if limit < 0 {
+			return nil, fmt.Errorf("在制品上限不能为负数: %s", status)
+		}
+		if limit > 0 {
+			limits[status] = limit
+		}
+	}
+
+	if len(limits) == 0 {
+		project.WIPLimits = nil
+	} else {
+		data, err := json.Marshal(limits)
+		if err != nil {
+			return nil, fmt.Errorf("序列化在制品上限失败: %w", err)
+		}
+		value := string(data)
+		project.WIPLimits = &value
+	}
+
+	if err := repo.Update(ctx, project); err != nil {
+		s.logger.WithError(err).Error("更新项目在制品上限失败")
+		return nil, fmt.Errorf("更新项目在制品上限失败: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"project_id": projectID,
+		"limits":     limits,
+	}).Info("项目在制品上限已更新")
+	return limits, nil
+}
+
+// checkWIPLimit 校验任务进入目标状态后是否超出所属项目的在制品上限
+func (s *taskServiceRepo) checkWIPLimit(ctx context.Context, task *database.Task, targetStatus string) error {
+	if task.ProjectID == nil || task.Status == targetStatus {
+		return nil
+	}
+
+	project, err := s.projectRepo.GetByID(ctx, *task.ProjectID)
+	if err != nil {
+		return fmt.Errorf("获取任务所属项目失败: %w", err)
+	}
+	limit, ok := projectWIPLimits(project)[targetStatus]
+	if !ok {
+		return nil
+	}
+
+	count, err := s.taskRepo.CountByProjectAndStatus(ctx, project.ID, targetStatus)
+	if err != nil {
+		return fmt.Errorf("统计项目任务数失败: %w", err)
+	}
+	if count >= int64(limit) {
+		return ErrWIPLimitReached.Localized(msgTaskWIPLimitReached, i18n.Params{
+			"project": project.Name,
+			"status":  targetStatus,
+			"count":   count,
+			"limit":   limit,
+		})
+	}
+	return nil
+}
+
+// projectWIPLimits 解析项目的在制品上限配置，配置缺失或无效时视为不限制
+func projectWIPLimits(project *database.Project) map[string]int {
+	limits := make(map[string]int)
+	if project.WIPLimits == nil || *project.WIPLimits == "" {
+		return limits
+	}
+	if err := json.Unmarshal([]byte(*project.WIPLimits), &limits); err != nil {
+		return make(map[string]int)
+	}
+	return limits
+}
+
+// taskFilterConditions 将任务列表过滤条件转换为仓储查询条件
+func taskFilterConditions(filter TaskListFilter) map[string]interface{} {
+	conditions := make(map[string]interface{})
+	if filter.Status != "" {
+		conditions["status"] = filter.Status
+	}
+	if filter.Priority != "" {
+		conditions["priority"] = filter.Priority
+	}
+	if filter.AssignedTo != nil && *filter.AssignedTo != 0 {
+		conditions["assignee_id"] = *filter.AssignedTo
+	}
+	if filter.CreatedBy != nil && *filter.CreatedBy != 0 {
+		conditions["creator_id"] = *filter.CreatedBy
+	}
+	if names := splitTaskLabelNames(filter.Labels); len(names) > 0 {
+		conditions["labels"] = repository.TaskLabelFilter{Names: names, MatchAny: filter.Any}
+	}
+	if filter.IncludeArchived {
+		conditions["include_archived"] = true
+	}
+	return conditions
+}
+
+// taskToResponse 转换任务模型为响应DTO
+func taskToResponse(task *database.Task) *TaskResponse {
+	return &TaskResponse{
+		ID:          task.ID,
+		Title:       task.Title,
+		Description: task.Description,
+		Priority:    task.Priority,
+		Status:      task.Status,
+		DueDate:     task.DueDate,
+		CreatedBy:   task.CreatorID,
+		AssignedTo:  task.AssigneeID,
+		ProjectID:   task.ProjectID,
+		CreatedAt:   task.CreatedAt,
+		UpdatedAt:   task.UpdatedAt,
+		Version:     task.Version,
+
+		RequiresReview: task.RequiresReview,
+		ReviewerID:     task.ReviewerID,
+	}
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+// stubProjectRepository 返回固定项目的项目仓储桩
+type stubProjectRepository struct {
+	repository.ProjectRepository
+	project *database.Project
+	batches int
+}
+
+func (s *stubProjectRepository) GetByID(ctx context.Context, id uint) (*database.Project, error) {
+	return s.project, nil
+}
+
+func (s *stubProjectRepository) GetByIDs(ctx context.Context, ids []uint) ([]*database.Project, error) {
+	s.batches++
+	var result []*database.Project
+	for _, id := range ids {
+		if id == s.project.ID {
+			result = append(result, s.project)
+		}
+	}
+	return result, nil
+}
+
+func TestCheckWIPLimit(t *testing.T) {
+	ctx := context.Background()
+	limits := `{"in_progress":2}`
+	project := &database.Project{BaseModel: database.BaseModel{ID: 7}, Name: "看板", WIPLimits: &limits}
+	projectID := project.ID
+
+	taskRepo := new(MockTaskRepository)
+	taskRepo.On("CountByProjectAndStatus", ctx, uint(7), "in_progress").Return(int64(2), nil).Once()
+	taskRepo.On("CountByProjectAndStatus", ctx, uint(7), "in_progress").Return(int64(1), nil).Once()
+	svc := &taskServiceRepo{taskRepo: taskRepo, projectRepo: &stubProjectRepository{project: project}}
+
+	task := &database.Task{Status: "assigned", ProjectID: &projectID}
+	assert.ErrorIs(t, svc.checkWIPLimit(ctx, task, "in_progress"), ErrWIPLimitReached)
+	require.NoError(t, svc.checkWIPLimit(ctx, task, "in_progress"))
+
+	// 未配置上限的状态、状态未变化以及不属于项目的任务不受限制
+	require.NoError(t, svc.checkWIPLimit(ctx, task, "pending"))
+	require.NoError(t, svc.checkWIPLimit(ctx, &database.Task{Status: "in_progress", ProjectID: &projectID}, "in_progress"))
+	require.NoError(t, svc.checkWIPLimit(ctx, &database.Task{Status: "assigned"}, "in_progress"))
+	taskRepo.AssertExpectations(t)
+}
@@ -0,0 +1,281 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"taskmanage/internal/repository"
+	"taskmanage/pkg/response"
+)
+
+const (
+	// DefaultBurndownDays 未指定日期范围时燃尽图包含的天数，截止到今天
+	DefaultBurndownDays = 30
+	// MaxBurndownDays 燃尽图日期范围的最大天数
+	MaxBurndownDays = 366
+	// DefaultBurndownCacheTTL 燃尽图结果的缓存时长，项目看板自动刷新时不重复聚合
+	DefaultBurndownCacheTTL = 5 * time.Minute
+	// burndownVelocityDays 计算滚动速度使用的最近天数
+	burndownVelocityDays = 7
+)
+
+// ErrInvalidBurndownRange 燃尽图日期范围或时区不合法
+var ErrInvalidBurndownRange = response.NewError(response.ErrCodeInvalidRequest, "无效的燃尽图参数")
+
+// BurndownDay 燃尽图中一天的数据，工时单位均为小时
+type BurndownDay struct {
+	Date           string  `json:"date"`
+	RemainingHours float64 `json:"remaining_hours"` // 当天结束时未完成任务的预估工时
+	AddedHours     float64 `json:"added_hours"`     // 当天新建任务的预估工时
+	CompletedHours float64 `json:"completed_hours"` // 当天完成任务的预估工时
+	ActualHours    float64 `json:"actual_hours"`    // 当天记录的实际工时
+}
+
+// ProjectBurndownResponse 项目燃尽图，日期按Timezone划分
+type ProjectBurndownResponse struct {
+	ProjectID           uint           `json:"project_id"`
+	From                string         `json:"from"`
+	To                  string         `json:"to"`
+	Timezone            string         `json:"timezone"`
+	Days                []*BurndownDay `json:"days"`
+	StartRemainingHours float64        `json:"start_remaining_hours"` // 开始日期零点时未完成任务的预估工时
+	RemainingHours      float64        `json:"remaining_hours"`       // 结束日期结束时未完成任务的预估工时
+	AddedHours          float64        `json:"added_hours"`
+	CompletedHours      float64        `json:"completed_hours"`
+	ActualHours         float64        `json:"actual_hours"`
+	VelocityHours       float64        `json:"velocity_hours"`       // 最近7天平均每天完成的预估工时
+	ProjectedCompletion *string        `json:"projected_completion"` // 按滚动速度推算剩余工时完成的日期，已无剩余或速度为0时为null
+	GeneratedAt         time.Time      `json:"generated_at"`
+}
+
+// burndownSeries 燃尽图计算所需的聚合数据
+type burndownSeries struct {
+	startRemaining float64
+	added          []repository.TimeSlotSum
+	completed      []repository.TimeSlotSum
+	actual         []repository.TimeSlotSum
+}
+
+// GetProjectBurndown 获取项目燃尽图
+// from、to为2006-01-02格式的可选日期，默认截止到今天的最近30天，晚于今天的结束日期按今天计算；tz为IANA时区名，默认服务器时区
+// 结果按项目、日期范围和时区缓存几分钟
+func (s *projectService) GetProjectBurndown(ctx context.Context, projectID uint, from, to, tz string) (*ProjectBurndownResponse, error) {
+	loc := time.Local
+	if tz != "" {
+		location, err := time.LoadLocation(tz)
+		if err != nil {
+			return nil, response.Wrapf(ErrInvalidBurndownRange, "tz不是有效的时区: %s", tz)
+		}
+		loc = location
+	}
+	now := time.Now()
+	start, end, err := parseBurndownRange(from, to, now.In(loc))
+	if err != nil {
+		return nil, err
+	}
+
+	cacheKey := fmt.Sprintf("%d|%s|%s|%s", projectID, start.Format("2006-01-02"), end.Format("2006-01-02"), loc)
+	if cached := s.burndownCache.get(cacheKey, now); cached != nil {
+		return cached, nil
+	}
+
+	if _, err := s.repoManager.ProjectRepository().GetByID(ctx, projectID); err != nil {
+		return nil, fmt.Errorf("获取项目失败: %w", err)
+	}
+
+	rangeStart := start
+	rangeEnd := end.AddDate(0, 0, 1)
+	series, err := s.loadBurndownSeries(ctx, projectID, rangeStart, rangeEnd)
+	if err != nil {
+		s.logger.WithError(err).WithField("project_id", projectID).Error("获取项目燃尽图数据失败")
+		return nil, err
+	}
+
+	burndown := buildProjectBurndown(series, start, end)
+	burndown.ProjectID = projectID
+	burndown.Timezone = loc.String()
+	burndown.GeneratedAt = now
+	s.burndownCache.set(cacheKey, burndown, now)
+
+	s.logger.WithFields(logrus.Fields{
+		"project_id": projectID,
+		"from":       burndown.From,
+		"to":         burndown.To,
+		"timezone":   burndown.Timezone,
+	}).Debug("项目燃尽图已计算")
+	return burndown, nil
+}
+
+// loadBurndownSeries 查询[from, to)内的燃尽图聚合数据
+// 实际工时取工时记录，没有工时记录的任务按完成当天计入任务的实际工时
+func (s *projectService) loadBurndownSeries(ctx context.Context, projectID uint, from, to time.Time) (*burndownSeries, error) {
+	taskRepo := s.repoManager.TaskRepository()
+	series := &burndownSeries{}
+	var err error
+	if series.startRemaining, err = taskRepo.GetProjectRemainingEstimate(ctx, projectID, from); err != nil {
+		return nil, err
+	}
+	if series.added, err = taskRepo.SumProjectEstimatesByCreatedSlot(ctx, projectID, from, to); err != nil {
+		return nil, err
+	}
+	if series.completed, err = taskRepo.SumProjectEstimatesByCompletedSlot(ctx, projectID, from, to); err != nil {
+		return nil, err
+	}
+	tracked, err := s.repoManager.TimeEntryRepository().SumProjectHoursBySlot(ctx, projectID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("汇总项目工时记录失败: %w", err)
+	}
+	untracked, err := taskRepo.SumProjectUntrackedHoursByCompletedSlot(ctx, projectID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	series.actual = append(tracked, untracked...)
+	return series, nil
+}
+
+// buildProjectBurndown 将按时段汇总的数据折算为start到end（含）每天的数据，start、end为所需时区的零点
+func buildProjectBurndown(series *burndownSeries, start, end time.Time) *ProjectBurndownResponse {
+	burndown := &ProjectBurndownResponse{
+		From:                start.Format("2006-01-02"),
+		To:                  end.Format("2006-01-02"),
+		Days:                make([]*BurndownDay, 0),
+		StartRemainingHours: roundHours(series.startRemaining),
+	}
+
+	// 按日历日期逐天生成，夏令时切换当天也只生成一天
+	index := make(map[string]*BurndownDay)
+	for date := start; !date.After(end); date = time.Date(date.Year(), date.Month(), date.Day()+1, 0, 0, 0, 0, date.Location()) {
+		day := &BurndownDay{Date: date.Format("2006-01-02")}
+		burndown.Days = append(burndown.Days, day)
+		index[day.Date] = day
+	}
+	accumulate := func(sums []repository.TimeSlotSum, field func(*BurndownDay) *float64) {
+		for _, sum := range sums {
+			if day, ok := index[sum.Start.In(start.Location()).Format("2006-01-02")]; ok {
+				*field(day) += sum.Value
+			}
+		}
+	}
+	accumulate(series.added, func(day *BurndownDay) *float64 { return &day.AddedHours })
+	accumulate(series.completed, func(day *BurndownDay) *float64 { return &day.CompletedHours })
+	accumulate(series.actual, func(day *BurndownDay) *float64 { return &day.ActualHours })
+
+	remaining := series.startRemaining
+	for _, day := range burndown.Days {
+		remaining += day.AddedHours - day.CompletedHours
+		burndown.AddedHours += day.AddedHours
+		burndown.CompletedHours += day.CompletedHours
+		burndown.ActualHours += day.ActualHours
+		day.RemainingHours = roundHours(remaining)
+		day.AddedHours = roundHours(day.AddedHours)
+		day.CompletedHours = roundHours(day.CompletedHours)
+		day.ActualHours = roundHours(day.ActualHours)
+	}
+	burndown.RemainingHours = roundHours(remaining)
+	burndown.AddedHours = roundHours(burndown.AddedHours)
+	burndown.CompletedHours = roundHours(burndown.CompletedHours)
+	burndown.ActualHours = roundHours(burndown.ActualHours)
+
+	// 滚动速度取最近7天（不足7天时取全部天数）平均每天完成的预估工时
+	window := burndown.Days[max(len(burndown.Days)-burndownVelocityDays, 0):]
+	var burned float64
+	for _, day := range window {
+		burned += day.CompletedHours
+	}
+	velocity := burned / float64(len(window))
+	burndown.VelocityHours = roundHours(velocity)
+	if burndown.RemainingHours > 0 && velocity > 0 {
+		days := int(math.Ceil(remaining / velocity))
+		projected := time.Date(end.Year(), end.Month(), end.Day()+days, 0, 0, 0, 0, end.Location()).Format("2006-01-02")
+		burndown.ProjectedCompletion = &projected
+	}
+	return burndown
+}
+
+// parseBurndownRange 解析燃尽图日期范围，返回today所在时区开始日期和结束日期的零点
+func parseBurndownRange(from, to string, today time.Time) (time.Time, time.Time, error) {
+	loc := today.Location()
+	end := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, loc)
+	if to != "" {
+		date, err := time.ParseInLocation("2006-01-02", to, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, response.Wrapf(ErrInvalidBurndownRange, "to格式应为2006-01-02")
+		}
+		// 今天之后还没有数据，结束日期最晚为今天
+		if date.Before(end) {
+			end = date
+		}
+	}
+
+	start := time.Date(end.Year(), end.Month(), end.Day()-(DefaultBurndownDays-1), 0, 0, 0, 0, loc)
+	if from != "" {
+		date, err := time.ParseInLocation("2006-01-02", from, loc)
+		if err != nil {
+			return time.Time{}, time.Time{}, response.Wrapf(ErrInvalidBurndownRange, "from格式应为2006-01-02")
+		}
+		start = date
+	}
+	if start.After(end) {
+		return time.Time{}, time.Time{}, response.Wrapf(ErrInvalidBurndownRange, "from不能晚于to或今天")
+	}
+	if time.Date(start.Year(), start.Month(), start.Day()+MaxBurndownDays, 0, 0, 0, 0, loc).Before(end) {
+		return time.Time{}, time.Time{}, response.Wrapf(ErrInvalidBurndownRange, "日期范围不能超过%d天", MaxBurndownDays)
+	}
+	return start, end, nil
+}
+
+// roundHours 工时保留两位小数
+func roundHours(hours float64) float64 {
+	return math.Round(hours*100) / 100
+}
+
+// burndownCache 燃尽图结果的进程内缓存，过期的条目在写入时清理
+type burndownCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]burndownCacheEntry
+}
+
+// burndownCacheEntry 缓存的燃尽图及其过期时间
+type burndownCacheEntry struct {
+	burndown  *ProjectBurndownResponse
+	expiresAt time.Time
+}
+
+// newBurndownCache 创建燃尽图缓存
+func newBurndownCache(ttl time.Duration) *burndownCache {
+	return &burndownCache{ttl: ttl, entries: make(map[string]burndownCacheEntry)}
+}
+
+// get 读取未过期的燃尽图，缓存未启用或未命中时返回nil
+func (c *burndownCache) get(key string, now time.Time) *ProjectBurndownResponse {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || !now.Before(entry.expiresAt) {
+		return nil
+	}
+	return entry.burndown
+}
+
+// set 写入燃尽图并清理已过期的条目
+func (c *burndownCache) set(key string, burndown *ProjectBurndownResponse, now time.Time) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k, entry := range c.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = burndownCacheEntry{burndown: burndown, expiresAt: now.Add(c.ttl)}
+}
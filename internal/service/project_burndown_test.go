@@ -0,0 +1,99 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/repository"
+)
+
+func TestBuildProjectBurndown(t *testing.T) {
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	require.NoError(t, err)
+	at := func(value string) time.Time {
+		parsed, err := time.ParseInLocation("2006-01-02 15:04", value, shanghai)
+		require.NoError(t, err)
+		return parsed.UTC()
+	}
+
+	series := &burndownSeries{
+		startRemaining: 20,
+		// UTC 3月1日17:00为上海时间3月2日凌晨，计入3月2日
+		added:     []repository.TimeSlotSum{{Start: at("2024-03-02 01:00"), Value: 8}},
+		completed: []repository.TimeSlotSum{{Start: at("2024-03-03 10:00"), Value: 5}, {Start: at("2024-03-05 23:45"), Value: 3}},
+		actual:    []repository.TimeSlotSum{{Start: at("2024-03-03 09:15"), Value: 1.5}, {Start: at("2024-03-03 14:00"), Value: 0.25}},
+	}
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, shanghai)
+	end := time.Date(2024, 3, 5, 0, 0, 0, 0, shanghai)
+
+	burndown := buildProjectBurndown(series, start, end)
+	require.Len(t, burndown.Days, 5)
+	remaining := make([]float64, 0, len(burndown.Days))
+	for _, day := range burndown.Days {
+		remaining = append(remaining, day.RemainingHours)
+	}
+	assert.Equal(t, []float64{20, 28, 23, 23, 20}, remaining)
+	assert.Equal(t, 1.75, burndown.Days[2].ActualHours)
+	assert.Equal(t, 20.0, burndown.StartRemainingHours)
+	assert.Equal(t, 20.0, burndown.RemainingHours)
+	assert.Equal(t, 8.0, burndown.AddedHours)
+	assert.Equal(t, 8.0, burndown.CompletedHours)
+
+	// 不足7天时按全部天数计算速度：8/5=1.6，剩余20小时需要13天
+	assert.Equal(t, 1.6, burndown.VelocityHours)
+	require.NotNil(t, burndown.ProjectedCompletion)
+	assert.Equal(t, "2024-03-18", *burndown.ProjectedCompletion)
+
+	// 没有完成任何任务时无法推算完成日期
+	burndown = buildProjectBurndown(&burndownSeries{startRemaining: 10}, start, end)
+	assert.Nil(t, burndown.ProjectedCompletion)
+
+	// 夏令时切换当天也只生成一天
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	burndown = buildProjectBurndown(&burndownSeries{}, time.Date(2024, 3, 9, 0, 0, 0, 0, newYork), time.Date(2024, 3, 11, 0, 0, 0, 0, newYork))
+	require.Len(t, burndown.Days, 3)
+	assert.Equal(t, "2024-03-11", burndown.Days[2].Date)
+}
+
+func TestParseBurndownRange(t *testing.T) {
+	today := time.Date(2024, 6, 15, 22, 30, 0, 0, time.UTC)
+
+	start, end, err := parseBurndownRange("", "", today)
+	require.NoError(t, err)
+	assert.Equal(t, "2024-05-17", start.Format("2006-01-02"))
+	assert.Equal(t, "2024-06-15", end.Format("2006-01-02"))
+
+	// 结束日期晚于今天时按今天计算
+	start, end, err = parseBurndownRange("2024-06-01", "2024-07-01", today)
+	require.NoError(t, err)
+	assert.Equal(t, "2024-06-01", start.Format("2006-01-02"))
+	assert.Equal(t, "2024-06-15", end.Format("2006-01-02"))
+
+	for _, tc := range [][2]string{{"2024/06/01", ""}, {"2024-06-10", "2024-06-01"}, {"2023-01-01", "2024-06-01"}} {
+		_, _, err := parseBurndownRange(tc[0], tc[1], today)
+		assert.ErrorIs(t, err, ErrInvalidBurndownRange, "from=%s to=%s", tc[0], tc[1])
+	}
+}
+
+func TestBurndownCache(t *testing.T) {
+	now := time.Now()
+	cache := newBurndownCache(time.Minute)
+	burndown := &ProjectBurndownResponse{ProjectID: 1}
+
+	cache.set("1", burndown, now)
+	assert.Same(t, burndown, cache.get("1", now.Add(59*time.Second)))
+	assert.Nil(t, cache.get("1", now.Add(time.Minute)))
+
+	// 写入时清理过期条目
+	cache.set("2", burndown, now.Add(2*time.Minute))
+	assert.Len(t, cache.entries, 1)
+
+	// 未启用缓存时不缓存
+	var disabled *burndownCache
+	disabled.set("1", burndown, now)
+	assert.Nil(t, disabled.get("1", now))
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+	"taskmanage/pkg/logger"
+	"taskmanage/pkg/response"
+)
+
+// ErrNotProjectMember 被分配员工不是任务所属项目的成员
+var ErrNotProjectMember = response.NewError(response.ErrCodeNotProjectMember, "员工不是任务所属项目的成员")
+
+// projectMemberSet 返回任务所属项目的成员员工ID集合，项目经理视为成员；任务不属于项目时返回nil
+func projectMemberSet(ctx context.Context, projectRepo repository.ProjectRepository, task *database.Task) (map[uint]bool, error) {
+	if task.ProjectID == nil {
+		return nil, nil
+	}
+	project, err := projectRepo.GetByID(ctx, *task.ProjectID)
+	if err != nil {
+		return nil, fmt.Errorf("获取任务所属项目失败: %w", err)
+	}
+	memberIDs, err := projectRepo.GetMemberIDs(ctx, project.ID)
+	if err != nil {
+		return nil, fmt.Errorf("获取项目成员失败: %w", err)
+	}
+
+	members := make(map[uint]bool, len(memberIDs)+1)
+	members[project.ManagerID] = true
+	for _, id := range memberIDs {
+		members[id] = true
+	}
+	return members, nil
+}
+
+// projectMemberIDs 返回自动分配时的候选员工范围，任务不属于项目时返回nil表示不限制
+func projectMemberIDs(ctx context.Context, projectRepo repository.ProjectRepository, task *database.Task) ([]uint, error) {
+	members, err := projectMemberSet(ctx, projectRepo, task)
+	if err != nil || members == nil {
+		return nil, err
+	}
+	ids := make([]uint, 0, len(members))
+	for id := range members {
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// ensureProjectMember 校验被分配员工是任务所属项目的成员
+// allowNonMember为true时不拒绝分配，而是把员工加入项目并记录操作人
+func ensureProjectMember(ctx context.Context, projectRepo repository.ProjectRepository, task *database.Task, employeeID uint, allowNonMember bool, operatorID uint) error {
+	members, err := projectMemberSet(ctx, projectRepo, task)
+	if err != nil || members == nil || members[employeeID] {
+		return err
+	}
+	if !allowNonMember {
+		return response.Wrapf(ErrNotProjectMember, "员工 %d 不在项目 %d 中", employeeID, *task.ProjectID)
+	}
+
+	if err := projectRepo.AddMemberByOperator(ctx, *task.ProjectID, employeeID, operatorID); err != nil {
+		return fmt.Errorf("添加项目成员失败: %w", err)
+	}
+	logger.Warnf("分配任务时越过项目成员校验，已将员工加入项目: ProjectID=%d, EmployeeID=%d, OperatorID=%d, TaskID=%d",
+		*task.ProjectID, employeeID, operatorID, task.ID)
+	return nil
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+// memberProjectRepository 记录越过成员校验加入项目的员工的项目仓储桩
+type memberProjectRepository struct {
+	repository.ProjectRepository
+	project *database.Project
+	members []uint
+	addedBy map[uint]uint // 员工ID -> 操作人用户ID
+}
+
+func (r *memberProjectRepository) GetByID(ctx context.Context, id uint) (*database.Project, error) {
+	return r.project, nil
+}
+
+func (r *memberProjectRepository) GetMemberIDs(ctx context.Context, projectID uint) ([]uint, error) {
+	return r.members, nil
+}
+
+func (r *memberProjectRepository) AddMemberByOperator(ctx context.Context, projectID, employeeID, operatorID uint) error {
+	r.members = append(r.members, employeeID)
+	r.addedBy[employeeID] = operatorID
+	return nil
+}
+
+func TestAssignTask_RequiresProjectMembership(t *testing.T) {
+	ctx := context.WithValue(context.Background(), "user_id", uint(7))
+	projectID := uint(9)
+	task := &database.Task{BaseModel: database.BaseModel{ID: 1}, Status: "pending", ProjectID: &projectID}
+	projectRepo := &memberProjectRepository{
+		project: &database.Project{BaseModel: database.BaseModel{ID: projectID}, ManagerID: 2},
+		members: []uint{3},
+		addedBy: make(map[uint]uint),
+	}
+
+	taskRepo := new(MockTaskRepository)
+	employeeRepo := new(MockEmployeeRepository)
+	assignmentRepo := new(MockAssignmentRepository)
+	taskRepo.On("GetByID", ctx, uint(1)).Return(task, nil)
+	for _, id := range []uint{2, 3, 4} {
+		employeeRepo.On("GetByID", ctx, id).Return(&database.Employee{BaseModel: database.BaseModel{ID: id}, UserID: id * 10, MaxTasks: 5}, nil)
+	}
+	assignmentRepo.On("Create", ctx, mock.AnythingOfType("*database.Assignment")).Return(nil)
+	svc := &taskServiceRepo{taskRepo: taskRepo, employeeRepo: employeeRepo, assignmentRepo: assignmentRepo, projectRepo: projectRepo, simpleApproval: true}
+
+	_, err := svc.AssignTask(ctx, &AssignTaskRequest{TaskID: 1, EmployeeID: 4})
+	assert.ErrorIs(t, err, ErrNotProjectMember)
+	assignmentRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+
+	// 项目成员和项目经理可以分配
+	_, err = svc.AssignTask(ctx, &AssignTaskRequest{TaskID: 1, EmployeeID: 3})
+	require.NoError(t, err)
+	_, err = svc.AssignTask(ctx, &AssignTaskRequest{TaskID: 1, EmployeeID: 2})
+	require.NoError(t, err)
+	assert.Empty(t, projectRepo.addedBy)
+
+	// 越过成员校验时员工加入项目并记录操作人
+	_, err = svc.AssignTask(ctx, &AssignTaskRequest{TaskID: 1, EmployeeID: 4, AllowNonMember: true})
+	require.NoError(t, err)
+	assert.Equal(t, map[uint]uint{4: 7}, projectRepo.addedBy)
+	assert.Contains(t, projectRepo.members, uint(4))
+}
+
+func TestProjectMemberAnnotation(t *testing.T) {
+	ctx := context.Background()
+	projectID := uint(9)
+	projectRepo := &memberProjectRepository{
+		project: &database.Project{BaseModel: database.BaseModel{ID: projectID}, ManagerID: 2},
+		members: []uint{3},
+	}
+
+	ids, err := projectMemberIDs(ctx, projectRepo, &database.Task{ProjectID: &projectID})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []uint{2, 3}, ids)
+	ids, err = projectMemberIDs(ctx, projectRepo, &database.Task{})
+	require.NoError(t, err)
+	assert.Nil(t, ids)
+
+	members, err := projectMemberSet(ctx, projectRepo, &database.Task{ProjectID: &projectID})
+	require.NoError(t, err)
+	member := &AssignmentSuggestion{Employee: &database.Employee{BaseModel: database.BaseModel{ID: 3}}}
+	outsider := &AssignmentSuggestion{Employee: &database.Employee{BaseModel: database.BaseModel{ID: 4}}}
+	annotateProjectMember(member, members)
+	annotateProjectMember(outsider, members)
+	assert.True(t, *member.ProjectMember)
+	assert.False(t, *outsider.ProjectMember)
+
+	// 任务不属于项目时不标注
+	unrelated := &AssignmentSuggestion{Employee: &database.Employee{BaseModel: database.BaseModel{ID: 4}}}
+	annotateProjectMember(unrelated, nil)
+	assert.Nil(t, unrelated.ProjectMember)
+}
@@ -0,0 +1,356 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+	"taskmanage/pkg/response"
+)
+
+var (
+	// ErrInvalidMilestone 里程碑参数不合法
+	ErrInvalidMilestone = response.NewError(response.ErrCodeInvalidRequest, "里程碑参数不合法")
+
+	// ErrMilestoneNotFound 里程碑不存在或不属于该项目
+	ErrMilestoneNotFound = response.NewError(response.ErrCodeRecordNotFound, "里程碑不存在")
+
+	// ErrInvalidTimelineWindow 时间线日期范围不合法
+	ErrInvalidTimelineWindow = response.NewError(response.ErrCodeInvalidRequest, "无效的时间线日期范围")
+)
+
+// MilestoneRequest 创建或更新里程碑请求，日期格式为2006-01-02
+type MilestoneRequest struct {
+	Name        string `json:"name" binding:"required,max=100"`
+	Date        string `json:"date" binding:"required"`
+	Status      string `json:"status" binding:"omitempty,oneof=pending achieved missed"`
+	Description string `json:"description" binding:"max=255"`
+}
+
+// MilestoneResponse 里程碑响应
+type MilestoneResponse struct {
+	ID          uint      `json:"id"`
+	ProjectID   uint      `json:"project_id"`
+	Name        string    `json:"name"`
+	Date        string    `json:"date"`
+	Status      string    `json:"status"`
+	Description string    `json:"description,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TimelineBar 甘特图中的任务条
+type TimelineBar struct {
+	ID           uint       `json:"id"`
+	Title        string     `json:"title"`
+	Start        time.Time  `json:"start"`
+	End          time.Time  `json:"end"`
+	PlannedStart bool       `json:"planned_start"` // 任务未开始，开始时间按截止时间减预估工时推算
+	DueDate      *time.Time `json:"due_date"`
+	Status       string     `json:"status"`
+	Priority     string     `json:"priority"`
+	Progress     float64    `json:"progress"` // 0到1，已完成为1，其余按实际工时占预估工时的比例
+	AssigneeID   *uint      `json:"assignee_id"`
+	AssigneeName string     `json:"assignee_name,omitempty"`
+	ParentID     *uint      `json:"parent_id"`
+}
+
+// TimelineLink 甘特图中任务条之间的连线，目前由父子任务关系生成
+type TimelineLink struct {
+	ID     uint   `json:"id"` // 取子任务ID，每个子任务只有一个父任务
+	Source uint   `json:"source"`
+	Target uint   `json:"target"`
+	Type   string `json:"type"` // parent
+}
+
+// ProjectTimelineResponse 项目时间线，任务条、连线和里程碑均为扁平数组
+type ProjectTimelineResponse struct {
+	ProjectID          uint                 `json:"project_id"`
+	From               string               `json:"from,omitempty"`
+	To                 string               `json:"to,omitempty"`
+	Bars               []*TimelineBar       `json:"bars"`
+	Links              []*TimelineLink      `json:"links"`
+	Milestones         []*MilestoneResponse `json:"milestones"`
+	UnscheduledTaskIDs []uint               `json:"unscheduled_task_ids"` // 既没有开始时间也没有截止时间、无法排入时间线的任务
+}
+
+// GetProjectTimeline 获取项目时间线，from、to为2006-01-02格式的可选日期，只返回与该区间有交集的任务和区间内的里程碑
+// 任务及其负责人、里程碑各一次查询，不逐个任务查询
+func (s *projectService) GetProjectTimeline(ctx context.Context, projectID uint, from, to string) (*ProjectTimelineResponse, error) {
+	windowStart, windowEnd, err := parseTimelineWindow(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.repoManager.ProjectRepository().GetByID(ctx, projectID); err != nil {
+		return nil, fmt.Errorf("获取项目失败: %w", err)
+	}
+
+	tasks, err := s.repoManager.TaskRepository().GetProjectTimelineTasks(ctx, projectID)
+	if err != nil {
+		s.logger.WithError(err).Error("获取项目时间线任务失败")
+		return nil, fmt.Errorf("获取项目任务失败: %w", err)
+	}
+
+	// 里程碑日期存为当天零点，结束日期当天的里程碑也包含在内
+	var milestoneEnd *time.Time
+	if windowEnd != nil {
+		last := windowEnd.AddDate(0, 0, -1)
+		milestoneEnd = &last
+	}
+	milestones, err := s.repoManager.MilestoneRepository().ListByProject(ctx, projectID, windowStart, milestoneEnd)
+	if err != nil {
+		s.logger.WithError(err).Error("获取项目里程碑失败")
+		return nil, fmt.Errorf("获取项目里程碑失败: %w", err)
+	}
+
+	timeline := buildProjectTimeline(tasks, windowStart, windowEnd)
+	timeline.ProjectID = projectID
+	timeline.From = from
+	timeline.To = to
+	for _, milestone := range milestones {
+		timeline.Milestones = append(timeline.Milestones, toMilestoneResponse(milestone))
+	}
+	return timeline, nil
+}
+
+// buildProjectTimeline 将任务转换为任务条和父子连线，windowStart、windowEnd不为空时过滤掉与区间没有交集的任务条
+func buildProjectTimeline(tasks []*database.Task, windowStart, windowEnd *time.Time) *ProjectTimelineResponse {
+	timeline := &ProjectTimelineResponse{
+		Bars:               make([]*TimelineBar, 0, len(tasks)),
+		Links:              make([]*TimelineLink, 0),
+		Milestones:         make([]*MilestoneResponse, 0),
+		UnscheduledTaskIDs: make([]uint, 0),
+	}
+
+	included := make(map[uint]bool, len(tasks))
+	for _, task := range tasks {
+		bar := taskTimelineBar(task)
+		if bar == nil {
+			timeline.UnscheduledTaskIDs = append(timeline.UnscheduledTaskIDs, task.ID)
+			continue
+		}
+		if windowStart != nil && bar.End.Before(*windowStart) {
+			continue
+		}
+		if windowEnd != nil && !bar.Start.Before(*windowEnd) {
+			continue
+		}
+		included[task.ID] = true
+		timeline.Bars = append(timeline.Bars, bar)
+	}
+
+	for _, bar := range timeline.Bars {
+		if bar.ParentID != nil && included[*bar.ParentID] {
+			timeline.Links = append(timeline.Links, &TimelineLink{
+				ID:     bar.ID,
+				Source: *bar.ParentID,
+				Target: bar.ID,
+				Type:   "parent",
+			})
+		}
+	}
+	return timeline
+}
+
+// taskTimelineBar 计算任务条的起止时间，无法确定起止时间时返回nil
+// 开始时间取实际开始时间，未开始时按截止时间减预估工时推算；结束时间依次取完成时间、截止时间、开始时间加预估工时
+func taskTimelineBar(task *database.Task) *TimelineBar {
+	estimated := time.Duration(task.EstimatedHours * float64(time.Hour))
+
+	var start, end *time.Time
+	planned := false
+	if task.StartedAt != nil {
+		start = task.StartedAt
+	} else if task.DueDate != nil {
+		plannedStart := task.DueDate.Add(-estimated)
+		start = &plannedStart
+		planned = true
+	}
+
+	switch {
+	case task.CompletedAt != nil:
+		end = task.CompletedAt
+	case task.DueDate != nil:
+		end = task.DueDate
+	case start != nil && estimated > 0:
+		estimatedEnd := start.Add(estimated)
+		end = &estimatedEnd
+	}
+	if start == nil && end != nil {
+		start = end
+	}
+	if start == nil || end == nil {
+		return nil
+	}
+	// 晚于截止时间才开始的任务，任务条至少覆盖开始时间
+	if end.Before(*start) {
+		end = start
+	}
+
+	bar := &TimelineBar{
+		ID:           task.ID,
+		Title:        task.Title,
+		Start:        *start,
+		End:          *end,
+		PlannedStart: planned,
+		DueDate:      task.DueDate,
+		Status:       task.Status,
+		Priority:     task.Priority,
+		AssigneeID:   task.AssigneeID,
+		ParentID:     task.ParentID,
+	}
+	if task.Assignee != nil {
+		bar.AssigneeName = task.Assignee.RealName
+	}
+	switch {
+	case task.Status == database.TaskStatusCompleted:
+		bar.Progress = 1
+	case task.EstimatedHours > 0:
+		bar.Progress = min(task.ActualHours/task.EstimatedHours, 1)
+	}
+	return bar
+}
+
+// parseTimelineWindow 解析时间线日期范围，返回的结束时间为结束日期次日零点
+func parseTimelineWindow(from, to string) (*time.Time, *time.Time, error) {
+	var start, end *time.Time
+	if from != "" {
+		date, err := time.ParseInLocation("2006-01-02", from, time.Local)
+		if err != nil {
+			return nil, nil, response.Wrapf(ErrInvalidTimelineWindow, "from格式应为2006-01-02")
+		}
+		start = &date
+	}
+	if to != "" {
+		date, err := time.ParseInLocation("2006-01-02", to, time.Local)
+		if err != nil {
+			return nil, nil, response.Wrapf(ErrInvalidTimelineWindow, "to格式应为2006-01-02")
+		}
+		date = date.AddDate(0, 0, 1)
+		end = &date
+	}
+	if start != nil && end != nil && !start.Before(*end) {
+		return nil, nil, response.Wrapf(ErrInvalidTimelineWindow, "from不能晚于to")
+	}
+	return start, end, nil
+}
+
+// ListMilestones 获取项目的全部里程碑，按日期排序
+func (s *projectService) ListMilestones(ctx context.Context, projectID uint) ([]*MilestoneResponse, error) {
+	if _, err := s.repoManager.ProjectRepository().GetByID(ctx, projectID); err != nil {
+		return nil, fmt.Errorf("获取项目失败: %w", err)
+	}
+	milestones, err := s.repoManager.MilestoneRepository().ListByProject(ctx, projectID, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("获取项目里程碑失败: %w", err)
+	}
+	responses := make([]*MilestoneResponse, 0, len(milestones))
+	for _, milestone := range milestones {
+		responses = append(responses, toMilestoneResponse(milestone))
+	}
+	return responses, nil
+}
+
+// CreateMilestone 创建项目里程碑，未指定状态时为pending
+func (s *projectService) CreateMilestone(ctx context.Context, projectID uint, req *MilestoneRequest) (*MilestoneResponse, error) {
+	if _, err := s.repoManager.ProjectRepository().GetByID(ctx, projectID); err != nil {
+		return nil, fmt.Errorf("获取项目失败: %w", err)
+	}
+	milestone := &database.Milestone{ProjectID: projectID, Status: database.MilestoneStatusPending}
+	if err := applyMilestoneRequest(milestone, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.repoManager.MilestoneRepository().Create(ctx, milestone); err != nil {
+		s.logger.WithError(err).Error("创建项目里程碑失败")
+		return nil, fmt.Errorf("创建项目里程碑失败: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"project_id":   projectID,
+		"milestone_id": milestone.ID,
+	}).Info("项目里程碑已创建")
+	return toMilestoneResponse(milestone), nil
+}
+
+// UpdateMilestone 更新项目里程碑，未指定状态时保持原状态
+func (s *projectService) UpdateMilestone(ctx context.Context, projectID, milestoneID uint, req *MilestoneRequest) (*MilestoneResponse, error) {
+	milestone, err := s.getProjectMilestone(ctx, projectID, milestoneID)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyMilestoneRequest(milestone, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.repoManager.MilestoneRepository().Update(ctx, milestone); err != nil {
+		s.logger.WithError(err).Error("更新项目里程碑失败")
+		return nil, fmt.Errorf("更新项目里程碑失败: %w", err)
+	}
+	return toMilestoneResponse(milestone), nil
+}
+
+// DeleteMilestone 删除项目里程碑
+func (s *projectService) DeleteMilestone(ctx context.Context, projectID, milestoneID uint) error {
+	if _, err := s.getProjectMilestone(ctx, projectID, milestoneID); err != nil {
+		return err
+	}
+	if err := s.repoManager.MilestoneRepository().Delete(ctx, milestoneID); err != nil {
+		s.logger.WithError(err).Error("删除项目里程碑失败")
+		return fmt.Errorf("删除项目里程碑失败: %w", err)
+	}
+	return nil
+}
+
+// getProjectMilestone 获取属于指定项目的里程碑，其他项目的里程碑按不存在处理
+func (s *projectService) getProjectMilestone(ctx context.Context, projectID, milestoneID uint) (*database.Milestone, error) {
+	milestone, err := s.repoManager.MilestoneRepository().GetByID(ctx, milestoneID)
+	if err != nil {
+		if repository.IsNotFoundError(err) {
+			return nil, ErrMilestoneNotFound
+		}
+		return nil, fmt.Errorf("获取项目里程碑失败: %w", err)
+	}
+	if milestone.ProjectID != projectID {
+		return nil, ErrMilestoneNotFound
+	}
+	return milestone, nil
+}
+
+// applyMilestoneRequest 校验请求并写入里程碑字段
+func applyMilestoneRequest(milestone *database.Milestone, req *MilestoneRequest) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return response.Wrapf(ErrInvalidMilestone, "name不能为空")
+	}
+	date, err := time.ParseInLocation("2006-01-02", req.Date, time.Local)
+	if err != nil {
+		return response.Wrapf(ErrInvalidMilestone, "date格式应为2006-01-02")
+	}
+	milestone.Name = name
+	milestone.Date = date
+	milestone.Description = req.Description
+	if req.Status != "" {
+		milestone.Status = req.Status
+	}
+	return nil
+}
+
+// toMilestoneResponse 转换里程碑响应
+func toMilestoneResponse(milestone *database.Milestone) *MilestoneResponse {
+	return &MilestoneResponse{
+		ID:          milestone.ID,
+		ProjectID:   milestone.ProjectID,
+		Name:        milestone.Name,
+		Date:        milestone.Date.Format("2006-01-02"),
+		Status:      milestone.Status,
+		Description: milestone.Description,
+		CreatedAt:   milestone.CreatedAt,
+		UpdatedAt:   milestone.UpdatedAt,
+	}
+}
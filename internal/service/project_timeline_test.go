@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+// timelineTaskRepository 返回固定项目任务的任务仓储
+type timelineTaskRepository struct {
+	repository.TaskRepository
+	tasks []*database.Task
+}
+
+func (r *timelineTaskRepository) GetProjectTimelineTasks(ctx context.Context, projectID uint) ([]*database.Task, error) {
+	return r.tasks, nil
+}
+
+// timelineMilestoneRepository 内存里程碑仓储
+type timelineMilestoneRepository struct {
+	repository.MilestoneRepository
+	milestones []*database.Milestone
+}
+
+func (r *timelineMilestoneRepository) GetByID(ctx context.Context, id uint) (*database.Milestone, error) {
+	for _, milestone := range r.milestones {
+		if milestone.ID == id {
+			return milestone, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *timelineMilestoneRepository) ListByProject(ctx context.Context, projectID uint, from, to *time.Time) ([]*database.Milestone, error) {
+	var result []*database.Milestone
+	for _, milestone := range r.milestones {
+		if milestone.ProjectID != projectID ||
+			(from != nil && milestone.Date.Before(*from)) || (to != nil && milestone.Date.After(*to)) {
+			continue
+		}
+		result = append(result, milestone)
+	}
+	return result, nil
+}
+
+// timelineRepoManager 时间线测试用仓储管理器
+type timelineRepoManager struct {
+	repository.RepositoryManager
+	tasks      *timelineTaskRepository
+	milestones *timelineMilestoneRepository
+}
+
+func (m *timelineRepoManager) ProjectRepository() repository.ProjectRepository {
+	return &stubProjectRepository{project: &database.Project{BaseModel: database.BaseModel{ID: 3}}}
+}
+
+func (m *timelineRepoManager) TaskRepository() repository.TaskRepository {
+	return m.tasks
+}
+
+func (m *timelineRepoManager) MilestoneRepository() repository.MilestoneRepository {
+	return m.milestones
+}
+
+func TestGetProjectTimeline(t *testing.T) {
+	day := func(d, hour int) *time.Time {
+		value := time.Date(2026, 5, d, hour, 0, 0, 0, time.Local)
+		return &value
+	}
+	parentID := uint(1)
+	assigneeID := uint(8)
+	tasks := []*database.Task{
+		// 已开始的父任务
+		{BaseModel: database.BaseModel{ID: 1}, Title: "发布", Status: "in_progress", StartedAt: day(4, 9), DueDate: day(8, 18),
+			EstimatedHours: 40, ActualHours: 10, AssigneeID: &assigneeID, Assignee: &database.User{RealName: "张三"}},
+		// 未开始，开始时间按截止时间减预估工时推算
+		{BaseModel: database.BaseModel{ID: 2}, Title: "联调", Status: "pending", DueDate: day(7, 18), EstimatedHours: 8, ParentID: &parentID},
+		// 已完成，结束时间取完成时间
+		{BaseModel: database.BaseModel{ID: 3}, Title: "设计", Status: "completed", StartedAt: day(1, 9), CompletedAt: day(2, 12), ParentID: &parentID},
+		// 没有截止时间也未开始
+		{BaseModel: database.BaseModel{ID: 4}, Title: "待定", Status: "pending"},
+	}
+	milestones := []*database.Milestone{
+		{BaseModel: database.BaseModel{ID: 1}, ProjectID: 3, Name: "提测", Date: *day(6, 0), Status: "pending"},
+		{BaseModel: database.BaseModel{ID: 2}, ProjectID: 3, Name: "立项", Date: *day(1, 0), Status: "achieved"},
+	}
+	repos := &timelineRepoManager{
+		tasks:      &timelineTaskRepository{tasks: tasks},
+		milestones: &timelineMilestoneRepository{milestones: milestones},
+	}
+	svc := &projectService{repoManager: repos, logger: logrus.New()}
+	ctx := context.Background()
+
+	timeline, err := svc.GetProjectTimeline(ctx, 3, "", "")
+	require.NoError(t, err)
+	require.Len(t, timeline.Bars, 3)
+	assert.Equal(t, []uint{4}, timeline.UnscheduledTaskIDs)
+	assert.Len(t, timeline.Milestones, 2)
+
+	release, integrate, design := timeline.Bars[0], timeline.Bars[1], timeline.Bars[2]
+	assert.Equal(t, "张三", release.AssigneeName)
+	assert.Equal(t, 0.25, release.Progress)
+	assert.False(t, release.PlannedStart)
+	assert.True(t, integrate.PlannedStart)
+	assert.Equal(t, *day(7, 10), integrate.Start)
+	assert.Equal(t, *day(7, 18), integrate.End)
+	assert.Equal(t, *day(2, 12), design.End)
+	assert.Equal(t, float64(1), design.Progress)
+	require.Len(t, timeline.Links, 2)
+	assert.Equal(t, TimelineLink{ID: 2, Source: 1, Target: 2, Type: "parent"}, *timeline.Links[0])
+
+	// 只返回与日期范围有交集的任务条，父任务不在范围内时不生成连线
+	timeline, err = svc.GetProjectTimeline(ctx, 3, "2026-05-01", "2026-05-03")
+	require.NoError(t, err)
+	require.Len(t, timeline.Bars, 1)
+	assert.Equal(t, uint(3), timeline.Bars[0].ID)
+	assert.Empty(t, timeline.Links)
+	require.Len(t, timeline.Milestones, 1)
+	assert.Equal(t, "立项", timeline.Milestones[0].Name)
+
+	timeline, err = svc.GetProjectTimeline(ctx, 3, "2026-05-06", "2026-05-06")
+	require.NoError(t, err)
+	assert.Len(t, timeline.Bars, 1)
+	assert.Equal(t, "提测", timeline.Milestones[0].Name)
+
+	_, err = svc.GetProjectTimeline(ctx, 3, "2026-05-06", "2026-05-01")
+	assert.ErrorIs(t, err, ErrInvalidTimelineWindow)
+	_, err = svc.GetProjectTimeline(ctx, 3, "5/1", "")
+	assert.ErrorIs(t, err, ErrInvalidTimelineWindow)
+}
+
+func TestMilestone_OtherProject(t *testing.T) {
+	repos := &timelineRepoManager{milestones: &timelineMilestoneRepository{milestones: []*database.Milestone{
+		{BaseModel: database.BaseModel{ID: 5}, ProjectID: 9, Name: "上线", Status: "pending"},
+	}}}
+	svc := &projectService{repoManager: repos, logger: logrus.New()}
+	ctx := context.Background()
+
+	_, err := svc.UpdateMilestone(ctx, 3, 5, &MilestoneRequest{Name: "上线", Date: "2026-06-01"})
+	assert.ErrorIs(t, err, ErrMilestoneNotFound)
+	assert.ErrorIs(t, svc.DeleteMilestone(ctx, 3, 6), ErrMilestoneNotFound)
+
+	_, err = svc.CreateMilestone(ctx, 3, &MilestoneRequest{Name: "上线", Date: "2026/06/01"})
+	assert.ErrorIs(t, err, ErrInvalidMilestone)
+}
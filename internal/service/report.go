@@ -0,0 +1,273 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+	_ "time/tzdata" // 容器镜像可能缺少时区数据，timezone参数依赖内置时区库
+
+	"taskmanage/internal/config"
+	"taskmanage/internal/repository"
+	"taskmanage/pkg/logger"
+	"taskmanage/pkg/report"
+)
+
+// defaultMaxExportRows 单次导出行数默认上限
+const defaultMaxExportRows = 50000
+
+// reportBatchSize 导出时每批读取的行数
+const reportBatchSize = 500
+
+var (
+	// ErrInvalidReportRequest 报表导出参数不合法
+	ErrInvalidReportRequest = errors.New("报表导出参数不合法")
+	// ErrReportTooLarge 导出行数超过上限
+	ErrReportTooLarge = errors.New("导出数据量超过上限")
+)
+
+// ReportExportRequest 报表导出请求，日期为timezone时区下的自然日，包含结束日期当天
+type ReportExportRequest struct {
+	Format       string `form:"format"`        // csv 或 xlsx，默认csv
+	From         string `form:"from"`          // 开始日期，格式 2006-01-02，默认本月1日
+	To           string `form:"to"`            // 结束日期，格式 2006-01-02，默认今天
+	DepartmentID *uint  `form:"department_id"` // 按负责人所在部门过滤
+	Timezone     string `form:"timezone"`      // IANA时区名，如 Asia/Shanghai，默认服务器时区
+}
+
+// ReportExport 待写出的报表，校验和行数检查已在创建时完成
+type ReportExport struct {
+	Filename    string
+	ContentType string
+	RowCount    int64
+	write       func(ctx context.Context, w io.Writer) error
+}
+
+// WriteTo 逐批读取数据并写出报表
+func (e *ReportExport) WriteTo(ctx context.Context, w io.Writer) error {
+	return e.write(ctx, w)
+}
+
+// ReportService 报表导出服务
+type ReportService interface {
+	// ExportTasks 导出任务报表：状态、负责人、截止时间和实际工时
+	ExportTasks(ctx context.Context, req *ReportExportRequest) (*ReportExport, error)
+	// ExportAssignments 导出任务分配报表：分配方式、审批人和审批耗时
+	ExportAssignments(ctx context.Context, req *ReportExportRequest) (*ReportExport, error)
+	// GetSkillGaps 部门技能缺口：开放任务要求的技能等级分布、部门内合格员工数和缺口分
+	GetSkillGaps(ctx context.Context, departmentID uint) (*SkillGapReport, error)
+	// ExportSkillGaps 以CSV或XLSX导出部门技能缺口
+	ExportSkillGaps(ctx context.Context, req *SkillGapRequest) (*ReportExport, error)
+}
+
+// reportService 报表导出服务实现
+type reportService struct {
+	repoManager repository.RepositoryManager
+	maxRows     int64
+	now         func() time.Time
+}
+
+// NewReportService 创建报表导出服务
+func NewReportService(repoManager repository.RepositoryManager, cfg *config.Config) ReportService {
+	maxRows := int64(cfg.Report.MaxExportRows)
+	if maxRows <= 0 {
+		maxRows = defaultMaxExportRows
+	}
+	return &reportService{
+		repoManager: repoManager,
+		maxRows:     maxRows,
+		now:         time.Now,
+	}
+}
+
+// reportParams 解析后的导出参数
+type reportParams struct {
+	format   report.Format
+	filter   *repository.ReportFilter
+	location *time.Location
+	fromDate string
+	toDate   string
+}
+
+// ExportTasks 导出任务报表
+func (s *reportService) ExportTasks(ctx context.Context, req *ReportExportRequest) (*ReportExport, error) {
+	params, err := s.parseRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	repo := s.repoManager.ReportRepository()
+	count, err := repo.CountTasks(ctx, params.filter)
+	if err != nil {
+		return nil, fmt.Errorf("统计任务数失败: %w", err)
+	}
+	if err := s.checkRowLimit(count); err != nil {
+		return nil, err
+	}
+
+	loc := params.location
+	return s.newExport("tasks", "任务报表", params, count, func(ctx context.Context, w report.Writer) error {
+		if err := w.WriteRow("任务ID", "标题", "状态", "优先级", "负责人", "部门", "截止时间", "创建时间", "完成时间", "预估工时(小时)", "实际工时(小时)"); err != nil {
+			return err
+		}
+		var afterID uint
+		for {
+			rows, err := repo.ListTasks(ctx, params.filter, afterID, reportBatchSize)
+			if err != nil {
+				return fmt.Errorf("查询任务失败: %w", err)
+			}
+			for _, row := range rows {
+				if err := w.WriteRow(row.ID, row.Title, row.Status, row.Priority, row.AssigneeName, row.DepartmentName,
+					inLocation(row.DueDate, loc), row.CreatedAt.In(loc), inLocation(row.CompletedAt, loc),
+					row.EstimatedHours, row.ActualHours); err != nil {
+					return err
+				}
+				afterID = row.ID
+			}
+			if len(rows) < reportBatchSize {
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ExportAssignments 导出任务分配报表，审批耗时为审批时间减分配时间
+func (s *reportService) ExportAssignments(ctx context.Context, req *ReportExportRequest) (*ReportExport, error) {
+	params, err := s.parseRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	repo := s.repoManager.ReportRepository()
+	count, err := repo.CountAssignments(ctx, params.filter)
+	if err != nil {
+		return nil, fmt.Errorf("统计分配记录数失败: %w", err)
+	}
+	if err := s.checkRowLimit(count); err != nil {
+		return nil, err
+	}
+
+	loc := params.location
+	return s.newExport("assignments", "任务分配报表", params, count, func(ctx context.Context, w report.Writer) error {
+		if err := w.WriteRow("分配ID", "任务ID", "任务标题", "被分配人", "部门", "分配人", "分配方式", "状态", "审批人", "分配时间", "审批时间", "审批耗时(小时)"); err != nil {
+			return err
+		}
+		var afterID uint
+		for {
+			rows, err := repo.ListAssignments(ctx, params.filter, afterID, reportBatchSize)
+			if err != nil {
+				return fmt.Errorf("查询分配记录失败: %w", err)
+			}
+			for _, row := range rows {
+				if err := w.WriteRow(row.ID, row.TaskID, row.TaskTitle, row.AssigneeName, row.DepartmentName, row.AssignerName,
+					row.Method, row.Status, row.ApproverName, row.AssignedAt.In(loc), inLocation(row.ApprovedAt, loc),
+					approvalLatencyHours(row.AssignedAt, row.ApprovedAt)); err != nil {
+					return err
+				}
+				afterID = row.ID
+			}
+			if len(rows) < reportBatchSize {
+				return nil
+			}
+		}
+	}), nil
+}
+
+// newExport 创建待写出的报表，文件名包含导出日期范围
+func (s *reportService) newExport(name, sheetName string, params *reportParams, count int64, writeRows func(ctx context.Context, w report.Writer) error) *ReportExport {
+	filename := fmt.Sprintf("%s_%s_%s.%s", name, params.fromDate, params.toDate, params.format)
+	return s.buildExport(filename, sheetName, params.format, count, writeRows)
+}
+
+// buildExport 创建待写出的报表
+func (s *reportService) buildExport(filename, sheetName string, format report.Format, count int64, writeRows func(ctx context.Context, w report.Writer) error) *ReportExport {
+	return &ReportExport{
+		Filename:    filename,
+		ContentType: format.ContentType(),
+		RowCount:    count,
+		write: func(ctx context.Context, out io.Writer) error {
+			w, err := report.NewWriter(format, out, sheetName)
+			if err != nil {
+				return err
+			}
+			if err := writeRows(ctx, w); err != nil {
+				logger.Errorf("导出%s失败: %v", sheetName, err)
+				return err
+			}
+			return w.Close()
+		},
+	}
+}
+
+// checkRowLimit 检查导出行数是否超过上限
+func (s *reportService) checkRowLimit(count int64) error {
+	if count > s.maxRows {
+		return fmt.Errorf("%w: 共%d行，单次最多导出%d行，请缩小日期范围", ErrReportTooLarge, count, s.maxRows)
+	}
+	return nil
+}
+
+// parseRequest 校验并解析导出参数，日期按请求时区换算为[from, to)时间范围
+func (s *reportService) parseRequest(req *ReportExportRequest) (*reportParams, error) {
+	format, err := report.ParseFormat(req.Format)
+	if err != nil {
+		return nil, fmt.Errorf("%w: format仅支持csv或xlsx", ErrInvalidReportRequest)
+	}
+
+	loc := time.Local
+	if tz := strings.TrimSpace(req.Timezone); tz != "" {
+		loc, err = time.LoadLocation(tz)
+		if err != nil {
+			return nil, fmt.Errorf("%w: 无效的时区 %s", ErrInvalidReportRequest, tz)
+		}
+	}
+
+	now := s.now().In(loc)
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, loc)
+	if req.From != "" {
+		if from, err = time.ParseInLocation("2006-01-02", req.From, loc); err != nil {
+			return nil, fmt.Errorf("%w: from格式应为2006-01-02", ErrInvalidReportRequest)
+		}
+	}
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	if req.To != "" {
+		if to, err = time.ParseInLocation("2006-01-02", req.To, loc); err != nil {
+			return nil, fmt.Errorf("%w: to格式应为2006-01-02", ErrInvalidReportRequest)
+		}
+	}
+	if to.Before(from) {
+		return nil, fmt.Errorf("%w: 结束日期不能早于开始日期", ErrInvalidReportRequest)
+	}
+
+	return &reportParams{
+		format: format,
+		filter: &repository.ReportFilter{
+			From:         from,
+			To:           to.AddDate(0, 0, 1),
+			DepartmentID: req.DepartmentID,
+		},
+		location: loc,
+		fromDate: from.Format("20060102"),
+		toDate:   to.Format("20060102"),
+	}, nil
+}
+
+// inLocation 将可空时间转换到指定时区
+func inLocation(t *time.Time, loc *time.Location) *time.Time {
+	if t == nil {
+		return nil
+	}
+	converted := t.In(loc)
+	return &converted
+}
+
+// approvalLatencyHours 计算审批耗时（小时，保留两位小数），未审批时返回nil
+func approvalLatencyHours(assignedAt time.Time, approvedAt *time.Time) interface{} {
+	if approvedAt == nil || assignedAt.IsZero() {
+		return nil
+	}
+	return math.Round(approvedAt.Sub(assignedAt).Hours()*100) / 100
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"taskmanage/pkg/report"
+)
+
+// SkillGapRequest 技能缺口报表请求
+type SkillGapRequest struct {
+	DepartmentID uint   `form:"department_id" binding:"required"`
+	Export       string `form:"export"` // csv 或 xlsx 时以附件返回，为空时返回JSON
+}
+
+// SkillGapLevel 技能在某个所需等级上的需求与供给
+type SkillGapLevel struct {
+	Level              int   `json:"level"`               // 所需等级
+	TaskCount          int64 `json:"task_count"`          // 要求该等级的开放任务数
+	QualifiedEmployees int64 `json:"qualified_employees"` // 部门内等级不低于该等级的在职员工数
+}
+
+// SkillGapItem 单个技能的缺口
+type SkillGapItem struct {
+	SkillID   uint             `json:"skill_id"`
+	SkillName string           `json:"skill_name"` // 技能已删除时为空
+	Category  string           `json:"category,omitempty"`
+	TaskCount int64            `json:"task_count"` // 要求该技能的开放任务数
+	Levels    []*SkillGapLevel `json:"levels"`     // 按所需等级升序
+	// GapScore 缺口分，0-1，为各所需等级上合格员工数不足任务数的部分占任务总数的比例；
+	// 0表示每个等级的合格员工数都不少于任务数，1表示没有任何合格员工
+	GapScore float64 `json:"gap_score"`
+}
+
+// SkillGapReport 部门技能缺口报表，技能按缺口分从高到低排序
+type SkillGapReport struct {
+	DepartmentID uint            `json:"department_id"`
+	Skills       []*SkillGapItem `json:"skills"`
+}
+
+// GetSkillGaps 统计部门开放任务的技能需求与部门员工技能供给的缺口
+// 部门的任务指所属项目归该部门或创建人在该部门的未完成、未取消任务
+func (s *reportService) GetSkillGaps(ctx context.Context, departmentID uint) (*SkillGapReport, error) {
+	exists, err := s.repoManager.DepartmentRepository().Exists(ctx, departmentID)
+	if err != nil {
+		return nil, fmt.Errorf("获取部门失败: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("%w: %d", ErrDepartmentNotFound, departmentID)
+	}
+
+	repo := s.repoManager.ReportRepository()
+	demand, err := repo.ListSkillDemand(ctx, departmentID)
+	if err != nil {
+		return nil, fmt.Errorf("统计技能需求失败: %w", err)
+	}
+
+	items := make(map[uint]*SkillGapItem)
+	skillIDs := make([]uint, 0)
+	for _, row := range demand {
+		item, ok := items[row.SkillID]
+		if !ok {
+			item = &SkillGapItem{SkillID: row.SkillID, SkillName: row.SkillName, Category: row.Category}
+			items[row.SkillID] = item
+			skillIDs = append(skillIDs, row.SkillID)
+		}
+		item.TaskCount += row.TaskCount
+		item.Levels = append(item.Levels, &SkillGapLevel{Level: row.Level, TaskCount: row.TaskCount})
+	}
+
+	supply, err := repo.ListSkillSupply(ctx, departmentID, skillIDs)
+	if err != nil {
+		return nil, fmt.Errorf("统计员工技能失败: %w", err)
+	}
+	// 没有员工掌握的技能不会出现在供给中，合格人数保持为0
+	for _, row := range supply {
+		item, ok := items[row.SkillID]
+		if !ok {
+			continue
+		}
+		for _, level := range item.Levels {
+			if row.Level >= level.Level {
+				level.QualifiedEmployees += row.EmployeeCount
+			}
+		}
+	}
+
+	result := &SkillGapReport{DepartmentID: departmentID, Skills: make([]*SkillGapItem, 0, len(items))}
+	for _, skillID := range skillIDs {
+		item := items[skillID]
+		sort.Slice(item.Levels, func(i, j int) bool { return item.Levels[i].Level < item.Levels[j].Level })
+		item.GapScore = skillGapScore(item)
+		result.Skills = append(result.Skills, item)
+	}
+	sort.SliceStable(result.Skills, func(i, j int) bool {
+		a, b := result.Skills[i], result.Skills[j]
+		if a.GapScore != b.GapScore {
+			return a.GapScore > b.GapScore
+		}
+		if a.TaskCount != b.TaskCount {
+			return a.TaskCount > b.TaskCount
+		}
+		return a.SkillID < b.SkillID
+	})
+	return result, nil
+}
+
+// skillGapScore 计算缺口分，保留两位小数
+func skillGapScore(item *SkillGapItem) float64 {
+	if item.TaskCount == 0 {
+		return 0
+	}
+	var unmet int64
+	for _, level := range item.Levels {
+		if level.TaskCount > level.QualifiedEmployees {
+			unmet += level.TaskCount - level.QualifiedEmployees
+		}
+	}
+	return math.Round(float64(unmet)/float64(item.TaskCount)*100) / 100
+}
+
+// ExportSkillGaps 导出部门技能缺口报表，每个技能的每个所需等级一行
+func (s *reportService) ExportSkillGaps(ctx context.Context, req *SkillGapRequest) (*ReportExport, error) {
+	format, err := report.ParseFormat(req.Export)
+	if err != nil {
+		return nil, fmt.Errorf("%w: export仅支持csv或xlsx", ErrInvalidReportRequest)
+	}
+	gaps, err := s.GetSkillGaps(ctx, req.DepartmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	var rowCount int64
+	for _, item := range gaps.Skills {
+		rowCount += int64(len(item.Levels))
+	}
+	filename := fmt.Sprintf("skill_gaps_%d_%s.%s", req.DepartmentID, s.now().Format("20060102"), format)
+	return s.buildExport(filename, "技能缺口报表", format, rowCount, func(ctx context.Context, w report.Writer) error {
+		if err := w.WriteRow("技能ID", "技能", "分类", "所需等级", "任务数", "合格员工数", "技能任务总数", "缺口分"); err != nil {
+			return err
+		}
+		for _, item := range gaps.Skills {
+			for _, level := range item.Levels {
+				if err := w.WriteRow(item.SkillID, item.SkillName, item.Category, level.Level, level.TaskCount,
+					level.QualifiedEmployees, item.TaskCount, item.GapScore); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}), nil
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/config"
+	"taskmanage/internal/repository"
+	"taskmanage/pkg/report"
+)
+
+func newTestReportService(maxRows int) *reportService {
+	cfg := &config.Config{}
+	cfg.Report.MaxExportRows = maxRows
+	s := NewReportService(nil, cfg).(*reportService)
+	s.now = func() time.Time { return time.Date(2026, 3, 15, 20, 0, 0, 0, time.UTC) }
+	return s
+}
+
+func TestReportService_ParseRequest(t *testing.T) {
+	s := newTestReportService(0)
+	assert.Equal(t, int64(defaultMaxExportRows), s.maxRows)
+
+	// 日期按请求时区解析，结束日期包含当天
+	params, err := s.parseRequest(&ReportExportRequest{Format: "xlsx", From: "2026-02-01", To: "2026-02-28", Timezone: "Asia/Shanghai"})
+	require.NoError(t, err)
+	assert.Equal(t, report.FormatXLSX, params.format)
+	assert.Equal(t, time.Date(2026, 1, 31, 16, 0, 0, 0, time.UTC), params.filter.From.UTC())
+	assert.Equal(t, time.Date(2026, 2, 28, 16, 0, 0, 0, time.UTC), params.filter.To.UTC())
+	assert.Equal(t, "20260201", params.fromDate)
+	assert.Equal(t, "20260228", params.toDate)
+
+	// 默认导出本月1日至今天，"今天"按请求时区计算
+	params, err = s.parseRequest(&ReportExportRequest{Timezone: "Asia/Shanghai"})
+	require.NoError(t, err)
+	assert.Equal(t, report.FormatCSV, params.format)
+	assert.Equal(t, "20260301", params.fromDate)
+	assert.Equal(t, "20260316", params.toDate)
+
+	for _, req := range []*ReportExportRequest{
+		{Format: "pdf"},
+		{Timezone: "Mars/Olympus"},
+		{From: "2026/02/01"},
+		{From: "2026-03-02", To: "2026-03-01"},
+	} {
+		_, err := s.parseRequest(req)
+		assert.ErrorIs(t, err, ErrInvalidReportRequest)
+	}
+}
+
+func TestReportService_RowLimit(t *testing.T) {
+	s := newTestReportService(100)
+	assert.NoError(t, s.checkRowLimit(100))
+
+	err := s.checkRowLimit(101)
+	assert.ErrorIs(t, err, ErrReportTooLarge)
+	assert.Contains(t, err.Error(), "请缩小日期范围")
+}
+
+func TestApprovalLatencyHours(t *testing.T) {
+	assignedAt := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	approvedAt := assignedAt.Add(90*time.Minute + 20*time.Second)
+	assert.Equal(t, 1.51, approvalLatencyHours(assignedAt, &approvedAt))
+	assert.Nil(t, approvalLatencyHours(assignedAt, nil))
+}
+
+type skillGapDepartmentRepository struct {
+	repository.DepartmentRepository
+	exists bool
+}
+
+func (r *skillGapDepartmentRepository) Exists(ctx context.Context, id uint) (bool, error) {
+	return r.exists, nil
+}
+
+type skillGapReportRepository struct {
+	repository.ReportRepository
+	demand      []*repository.SkillDemandRow
+	supply      []*repository.SkillSupplyRow
+	suppliedFor []uint
+}
+
+func (r *skillGapReportRepository) ListSkillDemand(ctx context.Context, departmentID uint) ([]*repository.SkillDemandRow, error) {
+	return r.demand, nil
+}
+
+func (r *skillGapReportRepository) ListSkillSupply(ctx context.Context, departmentID uint, skillIDs []uint) ([]*repository.SkillSupplyRow, error) {
+	r.suppliedFor = skillIDs
+	return r.supply, nil
+}
+
+type skillGapRepoManager struct {
+	repository.RepositoryManager
+	departments *skillGapDepartmentRepository
+	reports     *skillGapReportRepository
+}
+
+func (m *skillGapRepoManager) DepartmentRepository() repository.DepartmentRepository {
+	return m.departments
+}
+
+func (m *skillGapRepoManager) ReportRepository() repository.ReportRepository { return m.reports }
+
+func TestReportService_GetSkillGaps(t *testing.T) {
+	reports := &skillGapReportRepository{
+		demand: []*repository.SkillDemandRow{
+			{SkillID: 1, SkillName: "Go", Level: 4, TaskCount: 1},
+			{SkillID: 1, SkillName: "Go", Level: 2, TaskCount: 3},
+			{SkillID: 2, SkillName: "Rust", Level: 3, TaskCount: 2}, // 部门内无人掌握
+			{SkillID: 3, Level: 1, TaskCount: 1},                    // 技能已删除
+		},
+		supply: []*repository.SkillSupplyRow{
+			{SkillID: 1, Level: 5, EmployeeCount: 1},
+			{SkillID: 1, Level: 2, EmployeeCount: 1},
+			{SkillID: 3, Level: 1, EmployeeCount: 4},
+		},
+	}
+	repos := &skillGapRepoManager{departments: &skillGapDepartmentRepository{exists: true}, reports: reports}
+	s := NewReportService(repos, &config.Config{}).(*reportService)
+	s.now = func() time.Time { return time.Date(2026, 3, 15, 20, 0, 0, 0, time.UTC) }
+
+	gaps, err := s.GetSkillGaps(context.Background(), 9)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{1, 2, 3}, reports.suppliedFor)
+	require.Len(t, gaps.Skills, 3)
+
+	rust := gaps.Skills[0]
+	assert.Equal(t, uint(2), rust.SkillID)
+	assert.Equal(t, []*SkillGapLevel{{Level: 3, TaskCount: 2}}, rust.Levels)
+	assert.Equal(t, 1.0, rust.GapScore)
+
+	// 5级员工同时计入2级和4级，等级按所需等级升序
+	golang := gaps.Skills[1]
+	assert.Equal(t, int64(4), golang.TaskCount)
+	assert.Equal(t, []*SkillGapLevel{
+		{Level: 2, TaskCount: 3, QualifiedEmployees: 2},
+		{Level: 4, TaskCount: 1, QualifiedEmployees: 1},
+	}, golang.Levels)
+	assert.Equal(t, 0.25, golang.GapScore)
+
+	deleted := gaps.Skills[2]
+	assert.Equal(t, uint(3), deleted.SkillID)
+	assert.Equal(t, 0.0, deleted.GapScore)
+
+	export, err := s.ExportSkillGaps(context.Background(), &SkillGapRequest{DepartmentID: 9, Export: "csv"})
+	require.NoError(t, err)
+	assert.Equal(t, "skill_gaps_9_20260315.csv", export.Filename)
+	assert.Equal(t, int64(4), export.RowCount)
+	var out bytes.Buffer
+	require.NoError(t, export.WriteTo(context.Background(), &out))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 5)
+	assert.Equal(t, "2,Rust,,3,2,0,2,1", strings.TrimSpace(lines[1]))
+
+	_, err = s.ExportSkillGaps(context.Background(), &SkillGapRequest{DepartmentID: 9, Export: "pdf"})
+	assert.ErrorIs(t, err, ErrInvalidReportRequest)
+
+	repos.departments.exists = false
+	_, err = s.GetSkillGaps(context.Background(), 9)
+	assert.ErrorIs(t, err, ErrDepartmentNotFound)
+}
@@ -0,0 +1,536 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+	"taskmanage/pkg/logger"
+)
+
+var (
+	// ErrInvalidRole 角色参数不合法
+	ErrInvalidRole = errors.New("角色参数不合法")
+	// ErrRoleExists 角色名已存在
+	ErrRoleExists = errors.New("角色名已存在")
+	// ErrRoleInUse 角色仍分配给用户，需要强制删除
+	ErrRoleInUse = errors.New("角色仍分配给用户")
+	// ErrInvalidPermission 权限参数不合法
+	ErrInvalidPermission = errors.New("权限参数不合法")
+	// ErrPermissionExists 权限已存在
+	ErrPermissionExists = errors.New("权限已存在")
+	// ErrPermissionInUse 权限仍直接分配给用户
+	ErrPermissionInUse = errors.New("权限仍直接分配给用户")
+)
+
+// identifierPattern 角色名、资源和操作只允许小写字母、数字和下划线
+var identifierPattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// AuditActionRoleForceDelete 强制删除仍有用户的角色时记录的审计操作
+const AuditActionRoleForceDelete = "role.force_delete"
+
+// CreateRoleRequest 创建角色请求
+type CreateRoleRequest struct {
+	Name          string `json:"name" binding:"required,max=50"`
+	DisplayName   string `json:"display_name" binding:"max=100"`
+	Description   string `json:"description" binding:"max=255"`
+	ParentID      *uint  `json:"parent_id"`
+	PermissionIDs []uint `json:"permission_ids"`
+}
+
+// UpdateRoleRequest 更新角色请求，角色名创建后不可修改
+type UpdateRoleRequest struct {
+	DisplayName string `json:"display_name" binding:"max=100"`
+	Description string `json:"description" binding:"max=255"`
+	ParentID    *uint  `json:"parent_id"`
+}
+
+// RolePermissionsRequest 角色绑定或解绑权限请求
+type RolePermissionsRequest struct {
+	PermissionIDs []uint `json:"permission_ids" binding:"required,min=1"`
+}
+
+// RoleResponse 角色响应
+type RoleResponse struct {
+	ID          uint                  `json:"id"`
+	Name        string                `json:"name"`
+	DisplayName string                `json:"display_name"`
+	Description string                `json:"description"`
+	ParentID    *uint                 `json:"parent_id"`
+	Permissions []*PermissionResponse `json:"permissions,omitempty"`
+	CreatedAt   time.Time             `json:"created_at"`
+	UpdatedAt   time.Time             `json:"updated_at"`
+}
+
+// DeleteRoleResult 删除角色结果
+type DeleteRoleResult struct {
+	DetachedUserIDs []uint `json:"detached_user_ids"`
+}
+
+// CreatePermissionRequest 创建权限请求，权限名由resource:action组成
+type CreatePermissionRequest struct {
+	Resource    string `json:"resource" binding:"required,max=50"`
+	Action      string `json:"action" binding:"required,max=50"`
+	DisplayName string `json:"display_name" binding:"max=100"`
+	Description string `json:"description" binding:"max=255"`
+}
+
+// UpdatePermissionRequest 更新权限请求，资源和操作创建后不可修改
+type UpdatePermissionRequest struct {
+	DisplayName string `json:"display_name" binding:"max=100"`
+	Description string `json:"description" binding:"max=255"`
+}
+
+// RoleService 角色与权限管理服务，角色权限变更后清除权限缓存
+type RoleService interface {
+	// ListRoles 分页获取角色列表
+	ListRoles(ctx context.Context, req *ListRequest) (*ListResponse[*RoleResponse], error)
+	// GetRole 获取角色详情，包含角色权限
+	GetRole(ctx context.Context, roleID uint) (*RoleResponse, error)
+	// CreateRole 创建角色并绑定初始权限
+	CreateRole(ctx context.Context, req *CreateRoleRequest) (*RoleResponse, error)
+	// UpdateRole 更新角色显示名、描述和上级角色
+	UpdateRole(ctx context.Context, roleID uint, req *UpdateRoleRequest) (*RoleResponse, error)
+	// DeleteRole 删除角色，仍分配给用户时需要force，强制删除会解除用户角色并记录审计日志
+	DeleteRole(ctx context.Context, roleID uint, force bool, operatorID uint) (*DeleteRoleResult, error)
+	// AttachPermissions 为角色追加权限
+	AttachPermissions(ctx context.Context, roleID uint, permissionIDs []uint) (*RoleResponse, error)
+	// DetachPermissions 解除角色的权限
+	DetachPermissions(ctx context.Context, roleID uint, permissionIDs []uint) (*RoleResponse, error)
+	// ListRoleUsers 获取拥有该角色的用户
+	ListRoleUsers(ctx context.Context, roleID uint) ([]*UserResponse, error)
+
+	// ListPermissions 分页获取权限列表，可按资源过滤
+	ListPermissions(ctx context.Context, req *ListRequest, resource string) (*ListResponse[*PermissionResponse], error)
+	// GetPermission 获取权限详情
+	GetPermission(ctx context.Context, permissionID uint) (*PermissionResponse, error)
+	// CreatePermission 创建权限
+	CreatePermission(ctx context.Context, req *CreatePermissionRequest) (*PermissionResponse, error)
+	// UpdatePermission 更新权限显示名和描述
+	UpdatePermission(ctx context.Context, permissionID uint, req *UpdatePermissionRequest) (*PermissionResponse, error)
+	// DeletePermission 删除权限并解除其角色和模板关联，仍直接分配给用户时不允许删除
+	DeletePermission(ctx context.Context, permissionID uint) error
+}
+
+// roleService 角色与权限管理服务实现
+type roleService struct {
+	repoManager       repository.RepositoryManager
+	permissionService PermissionService
+}
+
+// NewRoleService 创建角色与权限管理服务
+func NewRoleService(repoManager repository.RepositoryManager, permissionService PermissionService) RoleService {
+	return &roleService{
+		repoManager:       repoManager,
+		permissionService: permissionService,
+	}
+}
+
+// ListRoles 分页获取角色列表
+func (s *roleService) ListRoles(ctx context.Context, req *ListRequest) (*ListResponse[*RoleResponse], error) {
+	roles, total, err := s.repoManager.RoleRepository().List(ctx, listFilter(req, "id", "asc"))
+	if err != nil {
+		return nil, fmt.Errorf("获取角色列表失败: %w", err)
+	}
+
+	items := make([]*RoleResponse, 0, len(roles))
+	for _, role := range roles {
+		items = append(items, toRoleResponse(role))
+	}
+	return &ListResponse[*RoleResponse]{Items: items, Total: total, Page: req.Page, Size: req.PageSize}, nil
+}
+
+// GetRole 获取角色详情，包含角色权限
+func (s *roleService) GetRole(ctx context.Context, roleID uint) (*RoleResponse, error) {
+	role, err := s.repoManager.RoleRepository().GetRoleWithPermissions(ctx, roleID)
+	if err != nil {
+		return nil, fmt.Errorf("获取角色失败: %w", err)
+	}
+	return toRoleResponse(role), nil
+}
+
+// CreateRole 创建角色并绑定初始权限
+func (s *roleService) CreateRole(ctx context.Context, req *CreateRoleRequest) (*RoleResponse, error) {
+	if !identifierPattern.MatchString(req.Name) {
+		return nil, fmt.Errorf("%w: 角色名只能包含小写字母、数字和下划线，且以字母开头", ErrInvalidRole)
+	}
+	if _, err := s.repoManager.RoleRepository().GetByName(ctx, req.Name); err == nil {
+		return nil, fmt.Errorf("%w: %s", ErrRoleExists, req.Name)
+	} else if !repository.IsNotFoundError(err) {
+		return nil, fmt.Errorf("查询角色失败: %w", err)
+	}
+	if req.ParentID != nil {
+		if err := s.validateParent(ctx, 0, *req.ParentID); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.validatePermissionIDs(ctx, req.PermissionIDs); err != nil {
+		return nil, err
+	}
+
+	role := &database.Role{
+		Name:        req.Name,
+		DisplayName: req.DisplayName,
+		Description: req.Description,
+		ParentID:    req.ParentID,
+	}
+	err := s.repoManager.WithTx(ctx, func(ctx context.Context, repos repository.RepositoryManager) error {
+		if err := repos.RoleRepository().Create(ctx, role); err != nil {
+			return fmt.Errorf("创建角色失败: %w", err)
+		}
+		if len(req.PermissionIDs) > 0 {
+			if err := repos.RoleRepository().AddPermissions(ctx, role.ID, req.PermissionIDs); err != nil {
+				return fmt.Errorf("绑定角色权限失败: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Infof("创建角色: %s, 权限数量=%d", role.Name, len(req.PermissionIDs))
+	return s.GetRole(ctx, role.ID)
+}
+
+// UpdateRole 更新角色显示名、描述和上级角色
+func (s *roleService) UpdateRole(ctx context.Context, roleID uint, req *UpdateRoleRequest) (*RoleResponse, error) {
+	role, err := s.repoManager.RoleRepository().GetByID(ctx, roleID)
+	if err != nil {
+		return nil, fmt.Errorf("获取角色失败: %w", err)
+	}
+	if req.ParentID != nil {
+		if err := s.validateParent(ctx, roleID, *req.ParentID); err != nil {
+			return nil, err
+		}
+	}
+	parentChanged := !equalUintPtr(role.ParentID, req.ParentID)
+
+	role.DisplayName = req.DisplayName
+	role.Description = req.Description
+	role.ParentID = req.ParentID
+	if err := s.repoManager.RoleRepository().Update(ctx, role); err != nil {
+		return nil, fmt.Errorf("更新角色失败: %w", err)
+	}
+
+	// 上级角色影响按角色查找审批人，同样需要刷新权限缓存
+	if parentChanged {
+		s.invalidatePermissions(ctx)
+	}
+	logger.Infof("更新角色: %s", role.Name)
+	return s.GetRole(ctx, roleID)
+}
+
+// DeleteRole 删除角色，仍分配给用户时需要force，强制删除会解除用户角色并记录审计日志
+func (s *roleService) DeleteRole(ctx context.Context, roleID uint, force bool, operatorID uint) (*DeleteRoleResult, error) {
+	role, err := s.repoManager.RoleRepository().GetByID(ctx, roleID)
+	if err != nil {
+		return nil, fmt.Errorf("获取角色失败: %w", err)
+	}
+	users, err := s.repoManager.RoleRepository().GetRoleUsers(ctx, roleID)
+	if err != nil {
+		return nil, fmt.Errorf("获取角色用户失败: %w", err)
+	}
+	if len(users) > 0 && !force {
+		return nil, fmt.Errorf("%w: 角色%s仍分配给%d个用户，请使用force强制删除", ErrRoleInUse, role.Name, len(users))
+	}
+
+	var detached []uint
+	err = s.repoManager.WithTx(ctx, func(ctx context.Context, repos repository.RepositoryManager) error {
+		userIDs, err := repos.RoleRepository().DeleteRole(ctx, roleID)
+		if err != nil {
+			return fmt.Errorf("删除角色失败: %w", err)
+		}
+		detached = userIDs
+		if len(userIDs) == 0 {
+			return nil
+		}
+		return s.auditForceDelete(ctx, repos, role, userIDs, operatorID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidatePermissions(ctx)
+	logger.Infof("删除角色: %s, 解除用户数量=%d, 操作人=%d", role.Name, len(detached), operatorID)
+	if detached == nil {
+		detached = []uint{}
+	}
+	return &DeleteRoleResult{DetachedUserIDs: detached}, nil
+}
+
+// auditForceDelete 记录强制删除角色时被解除角色的用户
+func (s *roleService) auditForceDelete(ctx context.Context, repos repository.RepositoryManager, role *database.Role, userIDs []uint, operatorID uint) error {
+	data, err := json.Marshal(map[string]interface{}{
+		"role_name":         role.Name,
+		"detached_user_ids": userIDs,
+	})
+	if err != nil {
+		return fmt.Errorf("序列化审计数据失败: %w", err)
+	}
+	audit := &database.AuditLog{
+		UserID:       operatorID,
+		Action:       AuditActionRoleForceDelete,
+		Resource:     "role",
+		ResourceID:   role.ID,
+		Method:       "DELETE",
+		Path:         fmt.Sprintf("/api/v1/roles/%d", role.ID),
+		RequestData:  string(data),
+		ResponseData: "{}",
+	}
+	if err := repos.AuditLogRepository().Create(ctx, audit); err != nil {
+		return fmt.Errorf("记录角色删除审计日志失败: %w", err)
+	}
+	return nil
+}
+
+// AttachPermissions 为角色追加权限
+func (s *roleService) AttachPermissions(ctx context.Context, roleID uint, permissionIDs []uint) (*RoleResponse, error) {
+	if _, err := s.repoManager.RoleRepository().GetByID(ctx, roleID); err != nil {
+		return nil, fmt.Errorf("获取角色失败: %w", err)
+	}
+	if err := s.validatePermissionIDs(ctx, permissionIDs); err != nil {
+		return nil, err
+	}
+	if err := s.repoManager.RoleRepository().AddPermissions(ctx, roleID, permissionIDs); err != nil {
+		return nil, fmt.Errorf("绑定角色权限失败: %w", err)
+	}
+
+	s.invalidatePermissions(ctx)
+	logger.Infof("角色绑定权限: RoleID=%d, 权限=%v", roleID, permissionIDs)
+	return s.GetRole(ctx, roleID)
+}
+
+// DetachPermissions 解除角色的权限
+func (s *roleService) DetachPermissions(ctx context.Context, roleID uint, permissionIDs []uint) (*RoleResponse, error) {
+	if _, err := s.repoManager.RoleRepository().GetByID(ctx, roleID); err != nil {
+		return nil, fmt.Errorf("获取角色失败: %w", err)
+	}
+	if len(permissionIDs) == 0 {
+		return nil, fmt.Errorf("%w: 至少需要一个权限", ErrInvalidPermission)
+	}
+	if err := s.repoManager.RoleRepository().RemovePermissions(ctx, roleID, permissionIDs); err != nil {
+		return nil, fmt.Errorf("解除角色权限失败: %w", err)
+	}
+
+	s.invalidatePermissions(ctx)
+	logger.Infof("角色解除权限: RoleID=%d, 权限=%v", roleID, permissionIDs)
+	return s.GetRole(ctx, roleID)
+}
+
+// ListRoleUsers 获取拥有该角色的用户
+func (s *roleService) ListRoleUsers(ctx context.Context, roleID uint) ([]*UserResponse, error) {
+	if _, err := s.repoManager.RoleRepository().GetByID(ctx, roleID); err != nil {
+		return nil, fmt.Errorf("获取角色失败: %w", err)
+	}
+	users, err := s.repoManager.RoleRepository().GetRoleUsers(ctx, roleID)
+	if err != nil {
+		return nil, fmt.Errorf("获取角色用户失败: %w", err)
+	}
+
+	responses := make([]*UserResponse, 0, len(users))
+	for _, user := range users {
+		responses = append(responses, UserToResponse(user))
+	}
+	return responses, nil
+}
+
+// ListPermissions 分页获取权限列表，可按资源过滤
+func (s *roleService) ListPermissions(ctx context.Context, req *ListRequest, resource string) (*ListResponse[*PermissionResponse], error) {
+	filter := listFilter(req, "name", "asc")
+	if resource != "" {
+		filter.Filters = map[string]interface{}{"resource": resource}
+	}
+	permissions, total, err := s.repoManager.PermissionRepository().List(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("获取权限列表失败: %w", err)
+	}
+
+	items := make([]*PermissionResponse, 0, len(permissions))
+	for _, permission := range permissions {
+		items = append(items, toPermissionResponse(permission))
+	}
+	return &ListResponse[*PermissionResponse]{Items: items, Total: total, Page: req.Page, Size: req.PageSize}, nil
+}
+
+// GetPermission 获取权限详情
+func (s *roleService) GetPermission(ctx context.Context, permissionID uint) (*PermissionResponse, error) {
+	permission, err := s.repoManager.PermissionRepository().GetByID(ctx, permissionID)
+	if err != nil {
+		return nil, fmt.Errorf("获取权限失败: %w", err)
+	}
+	return toPermissionResponse(permission), nil
+}
+
+// CreatePermission 创建权限
+func (s *roleService) CreatePermission(ctx context.Context, req *CreatePermissionRequest) (*PermissionResponse, error) {
+	if !identifierPattern.MatchString(req.Resource) || !identifierPattern.MatchString(req.Action) {
+		return nil, fmt.Errorf("%w: 资源和操作只能包含小写字母、数字和下划线，且以字母开头", ErrInvalidPermission)
+	}
+	name := req.Resource + ":" + req.Action
+	if _, err := s.repoManager.PermissionRepository().GetByName(ctx, name); err == nil {
+		return nil, fmt.Errorf("%w: %s", ErrPermissionExists, name)
+	} else if !repository.IsNotFoundError(err) {
+		return nil, fmt.Errorf("查询权限失败: %w", err)
+	}
+
+	permission := &database.Permission{
+		Name:        name,
+		DisplayName: req.DisplayName,
+		Description: req.Description,
+		Resource:    req.Resource,
+		Action:      req.Action,
+	}
+	if err := s.repoManager.PermissionRepository().Create(ctx, permission); err != nil {
+		return nil, fmt.Errorf("创建权限失败: %w", err)
+	}
+
+	logger.Infof("创建权限: %s", name)
+	return toPermissionResponse(permission), nil
+}
+
+// UpdatePermission 更新权限显示名和描述
+func (s *roleService) UpdatePermission(ctx context.Context, permissionID uint, req *UpdatePermissionRequest) (*PermissionResponse, error) {
+	permission, err := s.repoManager.PermissionRepository().GetByID(ctx, permissionID)
+	if err != nil {
+		return nil, fmt.Errorf("获取权限失败: %w", err)
+	}
+
+	permission.DisplayName = req.DisplayName
+	permission.Description = req.Description
+	if err := s.repoManager.PermissionRepository().Update(ctx, permission); err != nil {
+		return nil, fmt.Errorf("更新权限失败: %w", err)
+	}
+	return toPermissionResponse(permission), nil
+}
+
+// DeletePermission 删除权限并解除其角色和模板关联，仍直接分配给用户时不允许删除
+func (s *roleService) DeletePermission(ctx context.Context, permissionID uint) error {
+	permission, err := s.repoManager.PermissionRepository().GetByID(ctx, permissionID)
+	if err != nil {
+		return fmt.Errorf("获取权限失败: %w", err)
+	}
+	count, err := s.repoManager.PermissionRepository().CountActiveAssignments(ctx, permissionID)
+	if err != nil {
+		return fmt.Errorf("查询权限分配失败: %w", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: 权限%s仍有%d条生效或待审批的直接分配，请先撤销", ErrPermissionInUse, permission.Name, count)
+	}
+
+	err = s.repoManager.WithTx(ctx, func(ctx context.Context, repos repository.RepositoryManager) error {
+		return repos.PermissionRepository().DeletePermission(ctx, permissionID)
+	})
+	if err != nil {
+		return fmt.Errorf("删除权限失败: %w", err)
+	}
+
+	s.invalidatePermissions(ctx)
+	logger.Infof("删除权限: %s", permission.Name)
+	return nil
+}
+
+// validateParent 校验上级角色存在且不会形成环
+func (s *roleService) validateParent(ctx context.Context, roleID, parentID uint) error {
+	for id := parentID; ; {
+		if roleID != 0 && id == roleID {
+			return fmt.Errorf("%w: 上级角色不能是自身或下级角色", ErrInvalidRole)
+		}
+		parent, err := s.repoManager.RoleRepository().GetByID(ctx, id)
+		if err != nil {
+			if repository.IsNotFoundError(err) {
+				return fmt.Errorf("%w: 上级角色 %d 不存在", ErrInvalidRole, id)
+			}
+			return fmt.Errorf("查询上级角色失败: %w", err)
+		}
+		if parent.ParentID == nil {
+			return nil
+		}
+		id = *parent.ParentID
+	}
+}
+
+// validatePermissionIDs 校验权限均存在
+func (s *roleService) validatePermissionIDs(ctx context.Context, permissionIDs []uint) error {
+	for _, id := range permissionIDs {
+		exists, err := s.repoManager.PermissionRepository().Exists(ctx, id)
+		if err != nil {
+			return fmt.Errorf("查询权限失败: %w", err)
+		}
+		if !exists {
+			return fmt.Errorf("%w: 权限 %d 不存在", ErrInvalidPermission, id)
+		}
+	}
+	return nil
+}
+
+// invalidatePermissions 角色权限变更后清除全部用户的权限缓存，失败只记录日志
+func (s *roleService) invalidatePermissions(ctx context.Context) {
+	if s.permissionService == nil {
+		return
+	}
+	if err := s.permissionService.InvalidateAll(ctx); err != nil {
+		logger.Warnf("清除权限缓存失败: %v", err)
+	}
+}
+
+// listFilter 将列表请求转换为仓储过滤器，未指定排序时使用默认排序
+func listFilter(req *ListRequest, sort, order string) repository.ListFilter {
+	filter := repository.ListFilter{
+		Page:     req.Page,
+		PageSize: req.PageSize,
+		Sort:     req.Sort,
+		Order:    req.Order,
+	}
+	if filter.Sort == "" {
+		filter.Sort = sort
+	}
+	if filter.Order == "" {
+		filter.Order = order
+	}
+	return filter
+}
+
+// equalUintPtr 比较两个可空ID是否相同
+func equalUintPtr(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// toRoleResponse 转换为角色响应
+func toRoleResponse(role *database.Role) *RoleResponse {
+	resp := &RoleResponse{
+		ID:          role.ID,
+		Name:        role.Name,
+		DisplayName: role.DisplayName,
+		Description: role.Description,
+		ParentID:    role.ParentID,
+		CreatedAt:   role.CreatedAt,
+		UpdatedAt:   role.UpdatedAt,
+	}
+	for i := range role.Permissions {
+		resp.Permissions = append(resp.Permissions, toPermissionResponse(&role.Permissions[i]))
+	}
+	return resp
+}
+
+// toPermissionResponse 转换为权限响应
+func toPermissionResponse(permission *database.Permission) *PermissionResponse {
+	return &PermissionResponse{
+		ID:          permission.ID,
+		Name:        permission.Name,
+		DisplayName: permission.DisplayName,
+		Description: permission.Description,
+		Resource:    permission.Resource,
+		Action:      permission.Action,
+		CreatedAt:   permission.CreatedAt,
+		UpdatedAt:   permission.UpdatedAt,
+	}
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+// fakeRoleRepository 测试用内存角色仓储
+type fakeRoleRepository struct {
+	repository.RoleRepository
+	roles       map[string]*database.Role
+	users       map[uint][]uint // 角色ID -> 用户ID
+	permissions map[uint][]uint // 角色ID -> 追加的权限ID
+}
+
+func (f *fakeRoleRepository) GetByID(ctx context.Context, id uint) (*database.Role, error) {
+	for _, role := range f.roles {
+		if role.ID == id {
+			return role, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (f *fakeRoleRepository) GetByName(ctx context.Context, name string) (*database.Role, error) {
+	if role, ok := f.roles[name]; ok {
+		return role, nil
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (f *fakeRoleRepository) Create(ctx context.Context, role *database.Role) error {
+	role.ID = uint(len(f.roles) + 100)
+	f.roles[role.Name] = role
+	return nil
+}
+
+func (f *fakeRoleRepository) AddPermissions(ctx context.Context, roleID uint, permissionIDs []uint) error {
+	f.permissions[roleID] = append(f.permissions[roleID], permissionIDs...)
+	return nil
+}
+
+func (f *fakeRoleRepository) GetRoleUsers(ctx context.Context, roleID uint) ([]*database.User, error) {
+	users := make([]*database.User, 0, len(f.users[roleID]))
+	for _, id := range f.users[roleID] {
+		users = append(users, &database.User{BaseModel: database.BaseModel{ID: id}})
+	}
+	return users, nil
+}
+
+func (f *fakeRoleRepository) DeleteRole(ctx context.Context, roleID uint) ([]uint, error) {
+	role, err := f.GetByID(ctx, roleID)
+	if err != nil {
+		return nil, err
+	}
+	delete(f.roles, role.Name)
+	userIDs := f.users[roleID]
+	delete(f.users, roleID)
+	return userIDs, nil
+}
+
+// fakePermissionRepository 测试用内存权限仓储
+type fakePermissionRepository struct {
+	repository.PermissionRepository
+	permissions map[string]*database.Permission
+}
+
+func (f *fakePermissionRepository) GetByName(ctx context.Context, name string) (*database.Permission, error) {
+	if permission, ok := f.permissions[name]; ok {
+		return permission, nil
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (f *fakePermissionRepository) Create(ctx context.Context, permission *database.Permission) error {
+	permission.ID = uint(len(f.permissions) + 1000)
+	f.permissions[permission.Name] = permission
+	return nil
+}
+
+// recordingAuditLogRepository 记录写入的审计日志
+type recordingAuditLogRepository struct {
+	repository.AuditLogRepository
+	logs []*database.AuditLog
+}
+
+func (r *recordingAuditLogRepository) Create(ctx context.Context, log *database.AuditLog) error {
+	r.logs = append(r.logs, log)
+	return nil
+}
+
+// roleRepoManager 只提供角色、权限和审计仓储的仓储管理器，事务直接在当前仓储上执行
+type roleRepoManager struct {
+	repository.RepositoryManager
+	roleRepo       *fakeRoleRepository
+	permissionRepo *fakePermissionRepository
+	auditLogRepo   *recordingAuditLogRepository
+}
+
+func (m *roleRepoManager) RoleRepository() repository.RoleRepository { return m.roleRepo }
+
+func (m *roleRepoManager) PermissionRepository() repository.PermissionRepository {
+	return m.permissionRepo
+}
+
+func (m *roleRepoManager) AuditLogRepository() repository.AuditLogRepository { return m.auditLogRepo }
+
+func (m *roleRepoManager) WithTx(ctx context.Context, fn func(ctx context.Context, repos repository.RepositoryManager) error) error {
+	return fn(ctx, m)
+}
+
+// invalidationCounter 统计全量清除权限缓存次数的权限服务
+type invalidationCounter struct {
+	PermissionService
+	invalidations int
+}
+
+func (c *invalidationCounter) InvalidateAll(ctx context.Context) error {
+	c.invalidations++
+	return nil
+}
+
+func newRoleRepoManager() *roleRepoManager {
+	return &roleRepoManager{
+		roleRepo:       &fakeRoleRepository{roles: map[string]*database.Role{}, users: map[uint][]uint{}, permissions: map[uint][]uint{}},
+		permissionRepo: &fakePermissionRepository{permissions: map[string]*database.Permission{}},
+		auditLogRepo:   &recordingAuditLogRepository{},
+	}
+}
+
+func TestDeleteRole_RequiresForceWhenAssigned(t *testing.T) {
+	ctx := context.Background()
+	repos := newRoleRepoManager()
+	repos.roleRepo.roles["auditor"] = &database.Role{BaseModel: database.BaseModel{ID: 7}, Name: "auditor"}
+	repos.roleRepo.users[7] = []uint{3, 5}
+	permissions := &invalidationCounter{}
+	svc := NewRoleService(repos, permissions)
+
+	_, err := svc.DeleteRole(ctx, 7, false, 1)
+	assert.ErrorIs(t, err, ErrRoleInUse)
+	assert.Contains(t, repos.roleRepo.roles, "auditor")
+	assert.Zero(t, permissions.invalidations)
+
+	// 强制删除解除用户角色，记录审计日志并清除权限缓存
+	result, err := svc.DeleteRole(ctx, 7, true, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []uint{3, 5}, result.DetachedUserIDs)
+	assert.NotContains(t, repos.roleRepo.roles, "auditor")
+	require.Len(t, repos.auditLogRepo.logs, 1)
+	audit := repos.auditLogRepo.logs[0]
+	assert.Equal(t, AuditActionRoleForceDelete, audit.Action)
+	assert.Equal(t, uint(1), audit.UserID)
+	assert.Equal(t, uint(7), audit.ResourceID)
+	assert.JSONEq(t, `{"role_name":"auditor","detached_user_ids":[3,5]}`, audit.RequestData)
+	assert.Equal(t, 1, permissions.invalidations)
+}
+
+func TestDeleteRole_UnassignedWithoutAudit(t *testing.T) {
+	repos := newRoleRepoManager()
+	repos.roleRepo.roles["auditor"] = &database.Role{BaseModel: database.BaseModel{ID: 7}, Name: "auditor"}
+	svc := NewRoleService(repos, &invalidationCounter{})
+
+	result, err := svc.DeleteRole(context.Background(), 7, false, 1)
+	require.NoError(t, err)
+	assert.Empty(t, result.DetachedUserIDs)
+	assert.Empty(t, repos.auditLogRepo.logs)
+}
+
+func TestBootstrap_KeepsRolePermissionsManagedByAPI(t *testing.T) {
+	ctx := context.Background()
+	repos := newRoleRepoManager()
+	svc := NewBootstrapService(repos)
+
+	// 首次初始化：所有默认角色获得全部默认权限
+	created, err := svc.createDefaultPermissions(ctx)
+	require.NoError(t, err)
+	require.NoError(t, svc.createDefaultRoles(ctx, created))
+	employee := repos.roleRepo.roles["employee"]
+	require.NotNil(t, employee)
+	assert.Len(t, repos.roleRepo.permissions[employee.ID], 5)
+
+	// 再次初始化：权限已存在时不再改动已有角色的权限
+	repos.roleRepo.permissions = map[uint][]uint{}
+	created, err = svc.createDefaultPermissions(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, created)
+	require.NoError(t, svc.createDefaultRoles(ctx, created))
+	assert.Empty(t, repos.roleRepo.permissions)
+
+	// 新版本新增的默认权限只追加给默认包含它的已有角色
+	delete(repos.permissionRepo.permissions, "project:delete")
+	created, err = svc.createDefaultPermissions(ctx)
+	require.NoError(t, err)
+	require.NoError(t, svc.createDefaultRoles(ctx, created))
+	projectDelete := repos.permissionRepo.permissions["project:delete"].ID
+	assert.Equal(t, []uint{projectDelete}, repos.roleRepo.permissions[repos.roleRepo.roles["super_admin"].ID])
+	assert.Equal(t, []uint{projectDelete}, repos.roleRepo.permissions[repos.roleRepo.roles["admin"].ID])
+	assert.NotContains(t, repos.roleRepo.permissions, repos.roleRepo.roles["manager"].ID)
+}
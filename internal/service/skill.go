@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"taskmanage/internal/database"
 	"taskmanage/internal/repository"
@@ -11,18 +12,27 @@ import (
 
 // SkillServiceImpl 技能服务实现
 type SkillServiceImpl struct {
-	skillRepo    repository.SkillRepository
-	employeeRepo repository.EmployeeRepository
+	skillRepo            repository.SkillRepository
+	categoryRepo         repository.SkillCategoryRepository
+	employeeRepo         repository.EmployeeRepository
+	notificationService  NotificationService
+	autoCreateCategories bool
 }
 
 // NewSkillService 创建技能服务实例
 func NewSkillService(
 	skillRepo repository.SkillRepository,
+	categoryRepo repository.SkillCategoryRepository,
 	employeeRepo repository.EmployeeRepository,
+	notificationService NotificationService,
+	autoCreateCategories bool,
 ) SkillService {
 	return &SkillServiceImpl{
-		skillRepo:    skillRepo,
-		employeeRepo: employeeRepo,
+		skillRepo:            skillRepo,
+		categoryRepo:         categoryRepo,
+		employeeRepo:         employeeRepo,
+		notificationService:  notificationService,
+		autoCreateCategories: autoCreateCategories,
 	}
 }
 
@@ -36,10 +46,15 @@ func (s *SkillServiceImpl) CreateSkill(ctx context.Context, req *CreateSkillRequ
 		return nil, fmt.Errorf("skill name already exists: %s", req.Name)
 	}
 
+	category, err := s.resolveCategory(ctx, req.Category)
+	if err != nil {
+		return nil, err
+	}
+
 	// 创建技能记录
 	skill := &database.Skill{
 		Name:        req.Name,
-		Category:    req.Category,
+		CategoryID:  &category.ID,
 		Description: req.Description,
 	}
 
@@ -49,6 +64,7 @@ func (s *SkillServiceImpl) CreateSkill(ctx context.Context, req *CreateSkillRequ
 	}
 
 	logger.Infof("Skill created successfully: %d", skill.ID)
+	skill.Category = category
 
 	return s.buildSkillResponse(skill), nil
 }
@@ -84,7 +100,12 @@ func (s *SkillServiceImpl) UpdateSkill(ctx context.Context, skillID uint, req *U
 		skill.Name = *req.Name
 	}
 	if req.Category != nil {
-		skill.Category = *req.Category
+		category, err := s.resolveCategory(ctx, *req.Category)
+		if err != nil {
+			return nil, err
+		}
+		skill.CategoryID = &category.ID
+		skill.Category = category
 	}
 	if req.Description != nil {
 		skill.Description = *req.Description
@@ -158,7 +179,8 @@ func (s *SkillServiceImpl) ListSkills(ctx context.Context, req *ListSkillsReques
 		responses = append(responses, &SkillResponse{
 			ID:          skill.ID,
 			Name:        skill.Name,
-			Category:    skill.Category,
+			CategoryID:  skill.CategoryID,
+			Category:    skill.CategoryName(),
 			Description: skill.Description,
 			CreatedAt:   skill.CreatedAt.Format("2006-01-02 15:04:05"),
 			UpdatedAt:   skill.UpdatedAt.Format("2006-01-02 15:04:05"),
@@ -175,21 +197,6 @@ func (s *SkillServiceImpl) ListSkills(ctx context.Context, req *ListSkillsReques
 	}, nil
 }
 
-// GetSkillCategories 获取技能分类列表（兼容性方法）
-func (s *SkillServiceImpl) GetSkillCategories(ctx context.Context) ([]string, error) {
-	return s.GetAllCategories(ctx)
-}
-
-// GetAllCategories 获取所有技能分类
-func (s *SkillServiceImpl) GetAllCategories(ctx context.Context) ([]string, error) {
-	categories, err := s.skillRepo.GetAllCategories(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get skill categories: %w", err)
-	}
-
-	return categories, nil
-}
-
 // GetSkillsByCategory 根据分类获取技能列表
 func (s *SkillServiceImpl) GetSkillsByCategory(ctx context.Context, category string) ([]*SkillResponse, error) {
 	skills, err := s.skillRepo.GetByCategory(ctx, category)
@@ -203,7 +210,8 @@ func (s *SkillServiceImpl) GetSkillsByCategory(ctx context.Context, category str
 		responses = append(responses, &SkillResponse{
 			ID:          skill.ID,
 			Name:        skill.Name,
-			Category:    skill.Category,
+			CategoryID:  skill.CategoryID,
+			Category:    skill.CategoryName(),
 			Description: skill.Description,
 			CreatedAt:   skill.CreatedAt.Format("2006-01-02 15:04:05"),
 			UpdatedAt:   skill.UpdatedAt.Format("2006-01-02 15:04:05"),
@@ -257,32 +265,29 @@ func (s *SkillServiceImpl) RemoveSkillFromEmployee(ctx context.Context, employee
 	return nil
 }
 
-// GetEmployeeSkills 获取员工技能列表
-func (s *SkillServiceImpl) GetEmployeeSkills(ctx context.Context, employeeID uint) ([]*SkillResponse, error) {
+// GetEmployeeSkills 获取员工技能列表，excludeExpired为true时不返回认证已到期的技能
+func (s *SkillServiceImpl) GetEmployeeSkills(ctx context.Context, employeeID uint, excludeExpired bool) ([]*SkillResponse, error) {
 	// 检查员工是否存在
 	_, err := s.employeeRepo.GetByID(ctx, employeeID)
 	if err != nil {
 		return nil, fmt.Errorf("employee not found: %w", err)
 	}
 
-	skills, err := s.skillRepo.GetEmployeeSkills(ctx, employeeID)
+	skills, err := s.skillRepo.GetEmployeeSkillsWithLevel(ctx, employeeID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get employee skills: %w", err)
 	}
 
+	now := time.Now()
+	if excludeExpired {
+		skills = filterExpiredSkills(skills, now)
+	}
+
 	// 转换为响应格式
 	responses := make([]*SkillResponse, 0, len(skills))
 	for _, skill := range skills {
-		level, _ := s.skillRepo.GetEmployeeSkillLevel(ctx, employeeID, skill.ID)
-		responses = append(responses, &SkillResponse{
-			ID:          skill.ID,
-			Name:        skill.Name,
-			Category:    skill.Category,
-			Description: skill.Description,
-			Level:       level,
-			CreatedAt:   skill.CreatedAt.Format("2006-01-02 15:04:05"),
-			UpdatedAt:   skill.UpdatedAt.Format("2006-01-02 15:04:05"),
-		})
+		resp := EmployeeSkillToResponse(skill, now)
+		responses = append(responses, &resp)
 	}
 
 	return responses, nil
@@ -303,7 +308,8 @@ func (s *SkillServiceImpl) buildSkillResponse(skill *database.Skill) *SkillRespo
 	return &SkillResponse{
 		ID:          skill.ID,
 		Name:        skill.Name,
-		Category:    skill.Category,
+		CategoryID:  skill.CategoryID,
+		Category:    skill.CategoryName(),
 		Description: skill.Description,
 		CreatedAt:   skill.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 		UpdatedAt:   skill.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+	"taskmanage/pkg/logger"
+)
+
+var (
+	// ErrUnknownSkillCategory 技能分类不存在且未开启自动创建
+	ErrUnknownSkillCategory = errors.New("技能分类不存在")
+	// ErrSkillCategoryExists 重命名后的分类名称与其他分类重复
+	ErrSkillCategoryExists = errors.New("技能分类名称已存在")
+	// ErrSkillCategoryMergeSelf 源分类与目标分类相同
+	ErrSkillCategoryMergeSelf = errors.New("不能将技能分类合并到自身")
+)
+
+// resolveCategory 按名称不区分大小写查找技能分类，不存在时按配置自动创建或返回ErrUnknownSkillCategory
+func (s *SkillServiceImpl) resolveCategory(ctx context.Context, name string) (*database.SkillCategory, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: 分类名称不能为空", ErrUnknownSkillCategory)
+	}
+
+	category, err := s.categoryRepo.GetByName(ctx, name)
+	if err == nil {
+		return category, nil
+	}
+	if !repository.IsNotFoundError(err) {
+		return nil, fmt.Errorf("查询技能分类失败: %w", err)
+	}
+	if !s.autoCreateCategories {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownSkillCategory, name)
+	}
+
+	category = &database.SkillCategory{Name: name}
+	if err := s.categoryRepo.Create(ctx, category); err != nil {
+		// 并发创建同名分类时唯一索引冲突，重新查询已创建的记录
+		if existing, getErr := s.categoryRepo.GetByName(ctx, name); getErr == nil {
+			return existing, nil
+		}
+		return nil, fmt.Errorf("创建技能分类失败: %w", err)
+	}
+	logger.Infof("自动创建技能分类: ID=%d, Name=%s", category.ID, category.Name)
+	return category, nil
+}
+
+// GetSkillCategories 获取全部技能分类及各分类下的技能数量
+func (s *SkillServiceImpl) GetSkillCategories(ctx context.Context) ([]*SkillCategoryResponse, error) {
+	counts, err := s.categoryRepo.ListWithSkillCounts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取技能分类失败: %w", err)
+	}
+
+	responses := make([]*SkillCategoryResponse, 0, len(counts))
+	for _, c := range counts {
+		responses = append(responses, &SkillCategoryResponse{
+			ID:          c.ID,
+			Name:        c.Name,
+			Description: c.Description,
+			SkillCount:  c.SkillCount,
+		})
+	}
+	return responses, nil
+}
+
+// RenameSkillCategory 重命名技能分类，新名称不能与其他分类重复（不区分大小写）
+func (s *SkillServiceImpl) RenameSkillCategory(ctx context.Context, id uint, req *UpdateSkillCategoryRequest) (*SkillCategoryResponse, error) {
+	category, err := s.categoryRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(req.Name)
+	existing, err := s.categoryRepo.GetByName(ctx, name)
+	if err == nil && existing.ID != id {
+		return nil, fmt.Errorf("%w: %s，如需归并请使用合并", ErrSkillCategoryExists, existing.Name)
+	}
+	if err != nil && !repository.IsNotFoundError(err) {
+		return nil, fmt.Errorf("查询技能分类失败: %w", err)
+	}
+
+	oldName := category.Name
+	category.Name = name
+	if req.Description != nil {
+		category.Description = *req.Description
+	}
+	if err := s.categoryRepo.Update(ctx, category); err != nil {
+		return nil, fmt.Errorf("重命名技能分类失败: %w", err)
+	}
+	logger.Infof("技能分类重命名: ID=%d, %s -> %s", id, oldName, name)
+
+	return s.categoryResponse(ctx, category.ID)
+}
+
+// MergeSkillCategories 将源分类合并到目标分类，源分类下的技能在同一事务内改挂到目标分类后删除源分类
+func (s *SkillServiceImpl) MergeSkillCategories(ctx context.Context, sourceID uint, req *MergeSkillCategoryRequest) (*MergeSkillCategoryResponse, error) {
+	if sourceID == req.TargetID {
+		return nil, ErrSkillCategoryMergeSelf
+	}
+
+	moved, err := s.categoryRepo.Merge(ctx, sourceID, req.TargetID)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := s.categoryResponse(ctx, req.TargetID)
+	if err != nil {
+		return nil, err
+	}
+	return &MergeSkillCategoryResponse{Target: target, MovedSkills: moved}, nil
+}
+
+// categoryResponse 获取单个分类的响应，包含技能数量
+func (s *SkillServiceImpl) categoryResponse(ctx context.Context, id uint) (*SkillCategoryResponse, error) {
+	categories, err := s.GetSkillCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, category := range categories {
+		if category.ID == id {
+			return category, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
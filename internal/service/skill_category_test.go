@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+// memorySkillCategoryRepository 内存中的技能分类仓储
+type memorySkillCategoryRepository struct {
+	repository.SkillCategoryRepository
+	categories []*database.SkillCategory
+	merged     [][2]uint
+}
+
+func (r *memorySkillCategoryRepository) GetByID(ctx context.Context, id uint) (*database.SkillCategory, error) {
+	for _, c := range r.categories {
+		if c.ID == id {
+			return c, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *memorySkillCategoryRepository) GetByName(ctx context.Context, name string) (*database.SkillCategory, error) {
+	for _, c := range r.categories {
+		if strings.EqualFold(c.Name, name) {
+			return c, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *memorySkillCategoryRepository) Create(ctx context.Context, category *database.SkillCategory) error {
+	category.ID = uint(len(r.categories) + 1)
+	r.categories = append(r.categories, category)
+	return nil
+}
+
+func (r *memorySkillCategoryRepository) Update(ctx context.Context, category *database.SkillCategory) error {
+	return nil
+}
+
+func (r *memorySkillCategoryRepository) ListWithSkillCounts(ctx context.Context) ([]*repository.SkillCategoryCount, error) {
+	counts := make([]*repository.SkillCategoryCount, 0, len(r.categories))
+	for _, c := range r.categories {
+		counts = append(counts, &repository.SkillCategoryCount{ID: c.ID, Name: c.Name})
+	}
+	return counts, nil
+}
+
+func (r *memorySkillCategoryRepository) Merge(ctx context.Context, sourceID, targetID uint) (int64, error) {
+	r.merged = append(r.merged, [2]uint{sourceID, targetID})
+	return 2, nil
+}
+
+// createdSkillRepository 记录创建的技能
+type createdSkillRepository struct {
+	repository.SkillRepository
+	created []*database.Skill
+}
+
+func (r *createdSkillRepository) GetByName(ctx context.Context, name string) (*database.Skill, error) {
+	return nil, repository.ErrNotFound
+}
+
+func (r *createdSkillRepository) Create(ctx context.Context, skill *database.Skill) error {
+	skill.ID = uint(len(r.created) + 1)
+	r.created = append(r.created, skill)
+	return nil
+}
+
+func TestCreateSkill_ResolvesCategory(t *testing.T) {
+	ctx := context.Background()
+	categories := &memorySkillCategoryRepository{categories: []*database.SkillCategory{{BaseModel: database.BaseModel{ID: 1}, Name: "Backend"}}}
+	skills := &createdSkillRepository{}
+
+	// 分类按名称不区分大小写匹配，响应仍返回分类名称
+	svc := NewSkillService(skills, categories, nil, nil, false)
+	skill, err := svc.CreateSkill(ctx, &CreateSkillRequest{Name: "Go", Category: " backend "})
+	require.NoError(t, err)
+	assert.Equal(t, "Backend", skill.Category)
+	assert.Equal(t, uint(1), *skill.CategoryID)
+
+	_, err = svc.CreateSkill(ctx, &CreateSkillRequest{Name: "Vue", Category: "前端"})
+	assert.ErrorIs(t, err, ErrUnknownSkillCategory)
+	assert.Len(t, categories.categories, 1)
+
+	svc = NewSkillService(skills, categories, nil, nil, true)
+	skill, err = svc.CreateSkill(ctx, &CreateSkillRequest{Name: "Vue", Category: "前端"})
+	require.NoError(t, err)
+	assert.Equal(t, "前端", skill.Category)
+	assert.Len(t, categories.categories, 2)
+}
+
+func TestSkillCategory_RenameAndMerge(t *testing.T) {
+	ctx := context.Background()
+	categories := &memorySkillCategoryRepository{categories: []*database.SkillCategory{
+		{BaseModel: database.BaseModel{ID: 1}, Name: "Backend"},
+		{BaseModel: database.BaseModel{ID: 2}, Name: "后端"},
+	}}
+	svc := NewSkillService(nil, categories, nil, nil, false)
+
+	_, err := svc.RenameSkillCategory(ctx, 2, &UpdateSkillCategoryRequest{Name: "BACKEND"})
+	assert.ErrorIs(t, err, ErrSkillCategoryExists)
+	// 只修改自身大小写允许
+	renamed, err := svc.RenameSkillCategory(ctx, 1, &UpdateSkillCategoryRequest{Name: "backend"})
+	require.NoError(t, err)
+	assert.Equal(t, "backend", renamed.Name)
+
+	_, err = svc.MergeSkillCategories(ctx, 1, &MergeSkillCategoryRequest{TargetID: 1})
+	assert.ErrorIs(t, err, ErrSkillCategoryMergeSelf)
+	result, err := svc.MergeSkillCategories(ctx, 2, &MergeSkillCategoryRequest{TargetID: 1})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), result.MovedSkills)
+	assert.Equal(t, uint(1), result.Target.ID)
+	assert.Equal(t, [][2]uint{{2, 1}}, categories.merged)
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"taskmanage/internal/database"
+	"taskmanage/pkg/logger"
+)
+
+// DefaultSkillEndorsementValidity 未指定到期时间时技能认证的有效期
+const DefaultSkillEndorsementValidity = 365 * 24 * time.Hour
+
+var (
+	// ErrInvalidSkillEndorsement 技能认证参数不合法
+	ErrInvalidSkillEndorsement = errors.New("技能认证参数不合法")
+	// ErrSkillEndorsementForbidden 当前用户无权认证该员工的技能
+	ErrSkillEndorsementForbidden = errors.New("无权认证该员工的技能")
+)
+
+// EndorseEmployeeSkill 认证员工技能，可同时调整技能等级
+// 只有员工的直接上级或拥有技能管理权限的用户可以认证，且不能认证自己的技能
+func (s *SkillServiceImpl) EndorseEmployeeSkill(ctx context.Context, employeeID, skillID uint, req *EndorseSkillRequest) (*SkillResponse, error) {
+	logger.Infof("认证员工技能: EmployeeID=%d, SkillID=%d, EndorserID=%d", employeeID, skillID, req.EndorserID)
+
+	employee, err := s.employeeRepo.GetByID(ctx, employeeID)
+	if err != nil {
+		return nil, fmt.Errorf("获取员工失败: %w", err)
+	}
+	if employee.UserID == req.EndorserID {
+		return nil, fmt.Errorf("%w: 不能认证自己的技能", ErrSkillEndorsementForbidden)
+	}
+	if !req.CanManageSkills {
+		if err := s.checkDirectManager(ctx, employee, req.EndorserID); err != nil {
+			return nil, err
+		}
+	}
+
+	employeeSkill, err := s.skillRepo.GetEmployeeSkill(ctx, employeeID, skillID)
+	if err != nil {
+		return nil, fmt.Errorf("获取员工技能失败: %w", err)
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(DefaultSkillEndorsementValidity)
+	if req.ExpiresAt != nil {
+		if !req.ExpiresAt.After(now) {
+			return nil, fmt.Errorf("%w: 到期时间必须晚于当前时间", ErrInvalidSkillEndorsement)
+		}
+		expiresAt = *req.ExpiresAt
+	}
+	if req.Level != nil {
+		employeeSkill.Level = *req.Level
+	}
+	employeeSkill.EndorsedBy = &req.EndorserID
+	employeeSkill.EndorsedAt = &now
+	employeeSkill.ExpiresAt = &expiresAt
+
+	if err := s.skillRepo.EndorseEmployeeSkill(ctx, employeeSkill); err != nil {
+		return nil, fmt.Errorf("保存技能认证失败: %w", err)
+	}
+
+	skill, err := s.skillRepo.GetByID(ctx, skillID)
+	if err != nil {
+		return nil, fmt.Errorf("获取技能失败: %w", err)
+	}
+	resp := EmployeeSkillToResponse(&database.EmployeeSkillDetail{
+		SkillID:     skill.ID,
+		Name:        skill.Name,
+		Category:    skill.CategoryName(),
+		Description: skill.Description,
+		Level:       employeeSkill.Level,
+		EndorsedBy:  employeeSkill.EndorsedBy,
+		EndorsedAt:  employeeSkill.EndorsedAt,
+		ExpiresAt:   employeeSkill.ExpiresAt,
+		CreatedAt:   skill.CreatedAt,
+		UpdatedAt:   skill.UpdatedAt,
+	}, now)
+	return &resp, nil
+}
+
+// checkDirectManager 检查用户是否为员工的直接上级
+func (s *SkillServiceImpl) checkDirectManager(ctx context.Context, employee *database.Employee, userID uint) error {
+	if employee.DirectManagerID == nil {
+		return fmt.Errorf("%w: 只有直接上级或技能管理员可以认证", ErrSkillEndorsementForbidden)
+	}
+	manager, err := s.employeeRepo.GetByID(ctx, *employee.DirectManagerID)
+	if err != nil {
+		return fmt.Errorf("获取直接上级失败: %w", err)
+	}
+	if manager.UserID != userID {
+		return fmt.Errorf("%w: 只有直接上级或技能管理员可以认证", ErrSkillEndorsementForbidden)
+	}
+	return nil
+}
+
+// NotifyExpiringSkills 提醒认证将在30天内到期的员工及其直接上级，返回已提醒的技能数
+// 每条技能认证只提醒一次，员工通知发送失败的记录留待下次重试
+func (s *SkillServiceImpl) NotifyExpiringSkills(ctx context.Context, now time.Time) (int, error) {
+	if s.notificationService == nil {
+		return 0, nil
+	}
+
+	skills, err := s.skillRepo.ListExpiringSkills(ctx, now, now.Add(SkillExpiryNoticeWindow))
+	if err != nil {
+		return 0, fmt.Errorf("获取即将到期的技能认证失败: %w", err)
+	}
+
+	notified := 0
+	for _, skill := range skills {
+		employee, err := s.employeeRepo.GetEmployeeWithSkills(ctx, skill.EmployeeID)
+		if err != nil {
+			logger.Warnf("获取员工 %d 失败，跳过技能到期提醒: %v", skill.EmployeeID, err)
+			continue
+		}
+
+		expiresOn := skill.ExpiresAt.Format("2006-01-02")
+		content := fmt.Sprintf("您的技能「%s」(等级%d)认证将于%s到期，请及时联系上级重新认证", skill.SkillName, skill.Level, expiresOn)
+		if err := s.notificationService.CreateSystemNotification(ctx, employee.UserID, "技能认证即将到期", content); err != nil {
+			logger.Warnf("发送技能到期提醒失败: EmployeeID=%d, SkillID=%d, error: %v", skill.EmployeeID, skill.SkillID, err)
+			continue
+		}
+
+		if employee.DirectManagerID != nil {
+			s.notifyManagerOfExpiry(ctx, *employee.DirectManagerID, employee, skill.SkillName, expiresOn)
+		}
+
+		if err := s.skillRepo.MarkExpiryNotified(ctx, skill.EmployeeID, skill.SkillID, now); err != nil {
+			logger.Warnf("标记技能到期提醒失败: EmployeeID=%d, SkillID=%d, error: %v", skill.EmployeeID, skill.SkillID, err)
+			continue
+		}
+		notified++
+	}
+	return notified, nil
+}
+
+// notifyManagerOfExpiry 提醒直接上级下属的技能认证即将到期，失败只记录日志
+func (s *SkillServiceImpl) notifyManagerOfExpiry(ctx context.Context, managerID uint, employee *database.Employee, skillName, expiresOn string) {
+	manager, err := s.employeeRepo.GetByID(ctx, managerID)
+	if err != nil {
+		logger.Warnf("获取员工 %d 的直接上级失败，跳过上级提醒: %v", employee.ID, err)
+		return
+	}
+
+	content := fmt.Sprintf("下属%s的技能「%s」认证将于%s到期，请及时重新认证", employee.User.RealName, skillName, expiresOn)
+	if err := s.notificationService.CreateSystemNotification(ctx, manager.UserID, "下属技能认证即将到期", content); err != nil {
+		logger.Warnf("发送上级技能到期提醒失败: ManagerID=%d, error: %v", managerID, err)
+	}
+}
+
+// filterExpiredSkills 去掉认证已到期的员工技能
+func filterExpiredSkills(skills []*database.EmployeeSkillDetail, now time.Time) []*database.EmployeeSkillDetail {
+	result := make([]*database.EmployeeSkillDetail, 0, len(skills))
+	for _, skill := range skills {
+		if !skill.IsExpired(now) {
+			result = append(result, skill)
+		}
+	}
+	return result
+}
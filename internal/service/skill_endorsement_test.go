@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+// fakeSkillRepository 测试用内存员工技能仓储
+type fakeSkillRepository struct {
+	repository.SkillRepository
+	employeeSkills map[uint]*database.EmployeeSkill // 技能ID -> 员工技能，只存单个员工
+	expiring       []*database.ExpiringSkill
+	notified       []uint
+}
+
+func (f *fakeSkillRepository) GetByID(ctx context.Context, id uint) (*database.Skill, error) {
+	return &database.Skill{BaseModel: database.BaseModel{ID: id}, Name: "Go"}, nil
+}
+
+func (f *fakeSkillRepository) GetEmployeeSkill(ctx context.Context, employeeID, skillID uint) (*database.EmployeeSkill, error) {
+	if skill, ok := f.employeeSkills[skillID]; ok {
+		return skill, nil
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (f *fakeSkillRepository) EndorseEmployeeSkill(ctx context.Context, employeeSkill *database.EmployeeSkill) error {
+	f.employeeSkills[employeeSkill.SkillID] = employeeSkill
+	return nil
+}
+
+func (f *fakeSkillRepository) ListExpiringSkills(ctx context.Context, from, to time.Time) ([]*database.ExpiringSkill, error) {
+	return f.expiring, nil
+}
+
+func (f *fakeSkillRepository) MarkExpiryNotified(ctx context.Context, employeeID, skillID uint, notifiedAt time.Time) error {
+	f.notified = append(f.notified, skillID)
+	return nil
+}
+
+// recordingNotificationService 记录系统通知接收人的通知服务
+type recordingNotificationService struct {
+	NotificationService
+	recipients []uint
+}
+
+func (r *recordingNotificationService) CreateSystemNotification(ctx context.Context, recipientID uint, title, content string) error {
+	r.recipients = append(r.recipients, recipientID)
+	return nil
+}
+
+func (r *recordingNotificationService) CreateCategorizedNotification(ctx context.Context, recipientID uint, category, title, content string) error {
+	return r.CreateSystemNotification(ctx, recipientID, title, content)
+}
+
+func TestEndorseEmployeeSkill(t *testing.T) {
+	ctx := context.Background()
+	managerID := uint(20)
+	employeeRepo := new(MockEmployeeRepository)
+	employeeRepo.On("GetByID", ctx, uint(10)).Return(&database.Employee{BaseModel: database.BaseModel{ID: 10}, UserID: 100, DirectManagerID: &managerID}, nil)
+	employeeRepo.On("GetByID", ctx, managerID).Return(&database.Employee{BaseModel: database.BaseModel{ID: managerID}, UserID: 200}, nil)
+	skillRepo := &fakeSkillRepository{employeeSkills: map[uint]*database.EmployeeSkill{1: {EmployeeID: 10, SkillID: 1, Level: 2}}}
+	svc := NewSkillService(skillRepo, nil, employeeRepo, nil, false)
+
+	// 本人和非直接上级不能认证
+	_, err := svc.EndorseEmployeeSkill(ctx, 10, 1, &EndorseSkillRequest{EndorserID: 100, CanManageSkills: true})
+	assert.ErrorIs(t, err, ErrSkillEndorsementForbidden)
+	_, err = svc.EndorseEmployeeSkill(ctx, 10, 1, &EndorseSkillRequest{EndorserID: 300})
+	assert.ErrorIs(t, err, ErrSkillEndorsementForbidden)
+	past := time.Now().Add(-time.Hour)
+	_, err = svc.EndorseEmployeeSkill(ctx, 10, 1, &EndorseSkillRequest{EndorserID: 200, ExpiresAt: &past})
+	assert.ErrorIs(t, err, ErrInvalidSkillEndorsement)
+
+	// 直接上级认证，默认有效期一年
+	level := 4
+	resp, err := svc.EndorseEmployeeSkill(ctx, 10, 1, &EndorseSkillRequest{EndorserID: 200, Level: &level})
+	require.NoError(t, err)
+	assert.Equal(t, 4, resp.Level)
+	assert.Equal(t, SkillEndorsementEndorsed, resp.EndorsementStatus)
+	require.NotNil(t, resp.ExpiresAt)
+	assert.WithinDuration(t, time.Now().Add(DefaultSkillEndorsementValidity), *resp.ExpiresAt, time.Minute)
+	assert.Equal(t, uint(200), *skillRepo.employeeSkills[1].EndorsedBy)
+
+	// 技能管理员无需是直接上级
+	_, err = svc.EndorseEmployeeSkill(ctx, 10, 1, &EndorseSkillRequest{EndorserID: 300, CanManageSkills: true})
+	require.NoError(t, err)
+}
+
+func TestNotifyExpiringSkills(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	managerID := uint(20)
+	employeeRepo := new(MockEmployeeRepository)
+	employeeRepo.On("GetEmployeeWithSkills", ctx, uint(10)).Return(&database.Employee{BaseModel: database.BaseModel{ID: 10}, UserID: 100, DirectManagerID: &managerID}, nil)
+	employeeRepo.On("GetEmployeeWithSkills", ctx, uint(11)).Return(&database.Employee{BaseModel: database.BaseModel{ID: 11}, UserID: 110}, nil)
+	employeeRepo.On("GetByID", ctx, managerID).Return(&database.Employee{BaseModel: database.BaseModel{ID: managerID}, UserID: 200}, nil)
+	skillRepo := &fakeSkillRepository{expiring: []*database.ExpiringSkill{
+		{EmployeeID: 10, SkillID: 1, SkillName: "Go", Level: 3, ExpiresAt: now.Add(10 * 24 * time.Hour)},
+		{EmployeeID: 11, SkillID: 2, SkillName: "SQL", Level: 2, ExpiresAt: now.Add(20 * 24 * time.Hour)},
+	}}
+	notifications := &recordingNotificationService{}
+	svc := NewSkillService(skillRepo, nil, employeeRepo, notifications, false)
+
+	notified, err := svc.NotifyExpiringSkills(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, 2, notified)
+	// 员工本人和直接上级都收到提醒，没有直接上级时只提醒本人
+	assert.Equal(t, []uint{100, 200, 110}, notifications.recipients)
+	assert.Equal(t, []uint{1, 2}, skillRepo.notified)
+}
+
+func TestEmployeeSkillToResponse_EndorsementStatus(t *testing.T) {
+	now := time.Now()
+	endorsedAt := now.Add(-24 * time.Hour)
+	later := now.Add(90 * 24 * time.Hour)
+	soon := now.Add(7 * 24 * time.Hour)
+	expired := now.Add(-time.Hour)
+
+	assert.Equal(t, SkillEndorsementUnendorsed, EmployeeSkillToResponse(&database.EmployeeSkillDetail{}, now).EndorsementStatus)
+	assert.Equal(t, SkillEndorsementEndorsed, EmployeeSkillToResponse(&database.EmployeeSkillDetail{EndorsedAt: &endorsedAt, ExpiresAt: &later}, now).EndorsementStatus)
+	assert.Equal(t, SkillEndorsementExpiring, EmployeeSkillToResponse(&database.EmployeeSkillDetail{EndorsedAt: &endorsedAt, ExpiresAt: &soon}, now).EndorsementStatus)
+	assert.Equal(t, SkillEndorsementExpired, EmployeeSkillToResponse(&database.EmployeeSkillDetail{EndorsedAt: &endorsedAt, ExpiresAt: &expired}, now).EndorsementStatus)
+}
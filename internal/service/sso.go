@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"taskmanage/internal/config"
+	"taskmanage/internal/repository"
+	"taskmanage/pkg/logger"
+)
+
+var (
+	// ErrSSOEmailMissing ID令牌中没有可用的邮箱
+	ErrSSOEmailMissing = errors.New("单点登录身份中没有已验证的邮箱")
+	// ErrSSOUserNotFound 邮箱没有对应的用户且未开启自动开通
+	ErrSSOUserNotFound = errors.New("该邮箱没有对应的系统账号，请联系管理员开通")
+	// ErrSSOAccountPending 已自动开通账号，激活后才能登录
+	ErrSSOAccountPending = errors.New("账号已创建，请通过激活邮件激活后再登录")
+	// ErrSSOUserDisabled 用户账号未激活或已被禁用
+	ErrSSOUserDisabled = errors.New("用户账号未激活或已被禁用")
+)
+
+// OIDCIdentity 通过ID令牌校验的单点登录身份
+type OIDCIdentity struct {
+	Subject       string
+	Email         string
+	EmailVerified *bool // 提供方未返回email_verified时为nil
+	Name          string
+}
+
+// SSOService 单点登录服务
+type SSOService interface {
+	// ResolveOIDCUser 按邮箱将单点登录身份映射为系统用户
+	// 邮箱没有对应用户时，开启自动开通则创建待入职员工并返回ErrSSOAccountPending，否则返回ErrSSOUserNotFound
+	ResolveOIDCUser(ctx context.Context, identity *OIDCIdentity) (*UserResponse, error)
+}
+
+// ssoService 单点登录服务实现
+type ssoService struct {
+	userRepo          repository.UserRepository
+	onboardingService OnboardingService
+	autoProvision     bool
+}
+
+// NewSSOService 创建单点登录服务
+func NewSSOService(userRepo repository.UserRepository, onboardingService OnboardingService, cfg config.OIDCConfig) SSOService {
+	return &ssoService{
+		userRepo:          userRepo,
+		onboardingService: onboardingService,
+		autoProvision:     cfg.AutoProvision,
+	}
+}
+
+// ResolveOIDCUser 按邮箱将单点登录身份映射为系统用户
+func (s *ssoService) ResolveOIDCUser(ctx context.Context, identity *OIDCIdentity) (*UserResponse, error) {
+	email := strings.TrimSpace(identity.Email)
+	// 提供方明确声明邮箱未验证时不能用于关联账号
+	if email == "" || (identity.EmailVerified != nil && !*identity.EmailVerified) {
+		return nil, ErrSSOEmailMissing
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err == nil {
+		if user.Status != "active" {
+			return nil, ErrSSOUserDisabled
+		}
+		return UserToResponse(user), nil
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("查询用户失败: %w", err)
+	}
+
+	if !s.autoProvision {
+		return nil, ErrSSOUserNotFound
+	}
+
+	// 自动开通走入职流程：创建未激活账号和待入职员工，并发送激活邮件
+	realName := strings.TrimSpace(identity.Name)
+	if realName == "" {
+		realName = strings.SplitN(email, "@", 2)[0]
+	}
+	workflow, err := s.onboardingService.CreatePendingEmployee(ctx, &CreatePendingEmployeeRequest{
+		RealName: realName,
+		Email:    email,
+		Notes:    fmt.Sprintf("单点登录自动开通，subject=%s", identity.Subject),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("自动开通账号失败: %w", err)
+	}
+	logger.Infof("单点登录自动开通待入职员工: Email=%s, EmployeeID=%d", email, workflow.EmployeeID)
+	return nil, ErrSSOAccountPending
+}
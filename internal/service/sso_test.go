@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/config"
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+// ssoUserRepository 按邮箱返回固定用户
+type ssoUserRepository struct {
+	repository.UserRepository
+	users map[string]*database.User
+}
+
+func (r *ssoUserRepository) GetByEmail(ctx context.Context, email string) (*database.User, error) {
+	if user, ok := r.users[email]; ok {
+		return user, nil
+	}
+	return nil, repository.ErrNotFound
+}
+
+// recordingOnboardingService 记录创建的待入职员工
+type recordingOnboardingService struct {
+	OnboardingService
+	created []*CreatePendingEmployeeRequest
+}
+
+func (s *recordingOnboardingService) CreatePendingEmployee(ctx context.Context, req *CreatePendingEmployeeRequest) (*OnboardingWorkflowResponse, error) {
+	s.created = append(s.created, req)
+	return &OnboardingWorkflowResponse{EmployeeID: uint(len(s.created)), Email: req.Email}, nil
+}
+
+func TestResolveOIDCUser(t *testing.T) {
+	ctx := context.Background()
+	userRepo := &ssoUserRepository{users: map[string]*database.User{
+		"alice@example.com": {BaseModel: database.BaseModel{ID: 1}, Username: "alice", Email: "alice@example.com", Status: "active"},
+		"bob@example.com":   {BaseModel: database.BaseModel{ID: 2}, Username: "bob", Email: "bob@example.com", Status: "inactive"},
+	}}
+	onboarding := &recordingOnboardingService{}
+	unverified := false
+
+	svc := NewSSOService(userRepo, onboarding, config.OIDCConfig{})
+	user, err := svc.ResolveOIDCUser(ctx, &OIDCIdentity{Subject: "a", Email: "alice@example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), user.ID)
+
+	_, err = svc.ResolveOIDCUser(ctx, &OIDCIdentity{Subject: "b", Email: "bob@example.com"})
+	assert.ErrorIs(t, err, ErrSSOUserDisabled)
+	_, err = svc.ResolveOIDCUser(ctx, &OIDCIdentity{Subject: "a", Email: "alice@example.com", EmailVerified: &unverified})
+	assert.ErrorIs(t, err, ErrSSOEmailMissing)
+
+	// 未开启自动开通时拒绝未知邮箱
+	_, err = svc.ResolveOIDCUser(ctx, &OIDCIdentity{Subject: "c", Email: "carol@example.com", Name: "Carol"})
+	assert.ErrorIs(t, err, ErrSSOUserNotFound)
+	assert.Empty(t, onboarding.created)
+
+	// 开启自动开通时创建待入职员工，激活前不能登录
+	svc = NewSSOService(userRepo, onboarding, config.OIDCConfig{AutoProvision: true})
+	_, err = svc.ResolveOIDCUser(ctx, &OIDCIdentity{Subject: "c", Email: "carol@example.com", Name: "Carol"})
+	assert.ErrorIs(t, err, ErrSSOAccountPending)
+	require.Len(t, onboarding.created, 1)
+	assert.Equal(t, "carol@example.com", onboarding.created[0].Email)
+	assert.Equal(t, "Carol", onboarding.created[0].RealName)
+}
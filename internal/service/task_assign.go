@@ -0,0 +1,31 @@
+package service
+
+import (
+	"taskmanage/pkg/i18n"
+	"taskmanage/pkg/response"
+)
+
+// ErrInvalidAssignMethod 分配方式不在允许范围内
+var ErrInvalidAssignMethod = response.NewError(response.ErrCodeInvalidRequest, "分配方式不合法")
+
+// assignMethods 允许的任务分配方式
+var assignMethods = map[string]bool{
+	AssignMethodManual:          true,
+	AssignMethodAutoRoundRobin:  true,
+	AssignMethodAutoLoadBalance: true,
+	AssignMethodAutoSkillMatch:  true,
+}
+
+// normalizeAssignMethod 校验分配方式，未指定时视为手动分配
+func normalizeAssignMethod(method string) (string, error) {
+	if method == "" {
+		return AssignMethodManual, nil
+	}
+	if !assignMethods[method] {
+		return "", ErrInvalidAssignMethod.Localized(msgTaskInvalidAssignMethod, i18n.Params{
+			"method":  method,
+			"allowed": "manual, auto_round_robin, auto_load_balance, auto_skill_match",
+		})
+	}
+	return method, nil
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/database"
+)
+
+func TestAssignTask_StoresEmployeeUserID(t *testing.T) {
+	ctx := context.Background()
+	task := &database.Task{BaseModel: database.BaseModel{ID: 1}, Status: "pending"}
+	employee := &database.Employee{BaseModel: database.BaseModel{ID: 5}, UserID: 50, MaxTasks: 3}
+
+	taskRepo := new(MockTaskRepository)
+	employeeRepo := new(MockEmployeeRepository)
+	taskRepo.On("GetByID", ctx, uint(1)).Return(task, nil)
+	taskRepo.On("Update", ctx, task).Return(nil)
+	employeeRepo.On("GetByID", ctx, uint(5)).Return(employee, nil)
+	employeeRepo.On("UpdateTaskCount", ctx, uint(5), 1).Return(nil)
+
+	svc := &taskServiceRepo{taskRepo: taskRepo, employeeRepo: employeeRepo}
+	_, err := svc.AssignTask(ctx, &AssignTaskRequest{TaskID: 1, EmployeeID: 5, Method: "bogus"})
+	assert.ErrorIs(t, err, ErrInvalidAssignMethod)
+	taskRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+
+	// 请求按员工ID分配，任务负责人保存员工的用户ID
+	resp, err := svc.AssignTask(ctx, &AssignTaskRequest{TaskID: 1, EmployeeID: 5, Method: AssignMethodAutoSkillMatch})
+	require.NoError(t, err)
+	assert.Equal(t, uint(5), resp.EmployeeID)
+	require.NotNil(t, task.AssigneeID)
+	assert.Equal(t, uint(50), *task.AssigneeID)
+	assert.Equal(t, "assigned", task.Status)
+}
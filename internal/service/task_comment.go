@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/models"
+	"taskmanage/internal/repository"
+	"taskmanage/pkg/logger"
+)
+
+// MaxTaskCommentLength 评论内容的最大字符数
+const MaxTaskCommentLength = 2000
+
+// ErrInvalidTaskComment 评论参数不合法
+var ErrInvalidTaskComment = errors.New("评论参数不合法")
+
+// CreateTaskCommentRequest 发表任务评论请求
+type CreateTaskCommentRequest struct {
+	Content  string `json:"content" binding:"required"`
+	ParentID *uint  `json:"parent_id"` // 回复的评论ID
+}
+
+// TaskCommentResponse 任务评论响应
+type TaskCommentResponse struct {
+	ID        uint      `json:"id"`
+	TaskID    uint      `json:"task_id"`
+	UserID    uint      `json:"user_id"`
+	Content   string    `json:"content"`
+	ParentID  *uint     `json:"parent_id,omitempty"`
+	System    bool      `json:"system"` // 系统自动生成的评论
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AddTaskComment 发表任务评论并通知任务关注者
+func (s *taskServiceRepo) AddTaskComment(ctx context.Context, taskID uint, userID uint, req *CreateTaskCommentRequest) (*TaskCommentResponse, error) {
+	if req.Content == "" || utf8.RuneCountInString(req.Content) > MaxTaskCommentLength {
+		return nil, fmt.Errorf("%w: 评论内容不能为空且不超过%d字", ErrInvalidTaskComment, MaxTaskCommentLength)
+	}
+
+	task, err := s.taskRepo.GetByID(ctx, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("获取任务失败: %w", err)
+	}
+	if req.ParentID != nil {
+		parent, err := s.taskCommentRepo.GetByID(ctx, *req.ParentID)
+		if err != nil {
+			return nil, fmt.Errorf("获取回复的评论失败: %w", err)
+		}
+		if parent.TaskID != taskID {
+			return nil, fmt.Errorf("获取回复的评论失败: %w", repository.ErrNotFound)
+		}
+	}
+
+	comment := &database.TaskComment{
+		TaskID:   taskID,
+		UserID:   userID,
+		Content:  req.Content,
+		ParentID: req.ParentID,
+	}
+	if err := s.taskCommentRepo.Create(ctx, comment); err != nil {
+		return nil, fmt.Errorf("创建评论失败: %w", err)
+	}
+
+	logger.FromContext(ctx).Infof("发表任务评论: TaskID=%d, CommentID=%d, UserID=%d", taskID, comment.ID, userID)
+	s.notifyTaskWatchers(ctx, task, userID, models.NotificationTypeTaskCommented, "任务有新评论",
+		fmt.Sprintf("任务「%s」有新评论: %s", task.Title, truncateRunes(req.Content, 100)))
+	return toTaskCommentResponse(comment), nil
+}
+
+// ListTaskComments 获取任务的全部评论
+func (s *taskServiceRepo) ListTaskComments(ctx context.Context, taskID uint) ([]*TaskCommentResponse, error) {
+	if _, err := s.taskRepo.GetByID(ctx, taskID); err != nil {
+		return nil, fmt.Errorf("获取任务失败: %w", err)
+	}
+
+	comments, err := s.taskCommentRepo.ListByTask(ctx, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("获取任务评论失败: %w", err)
+	}
+
+	result := make([]*TaskCommentResponse, 0, len(comments))
+	for _, comment := range comments {
+		result = append(result, toTaskCommentResponse(comment))
+	}
+	return result, nil
+}
+
+// toTaskCommentResponse 转换任务评论响应
+func toTaskCommentResponse(comment *database.TaskComment) *TaskCommentResponse {
+	return &TaskCommentResponse{
+		ID:        comment.ID,
+		TaskID:    comment.TaskID,
+		UserID:    comment.UserID,
+		Content:   comment.Content,
+		ParentID:  comment.ParentID,
+		System:    comment.System,
+		CreatedAt: comment.CreatedAt,
+	}
+}
+
+// truncateRunes 按字符截断文本，超出部分以省略号代替
+func truncateRunes(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit]) + "..."
+}
@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/models"
+	"taskmanage/internal/repository"
+	"taskmanage/pkg/logger"
+	"taskmanage/pkg/response"
+	"taskmanage/pkg/workcalendar"
+)
+
+const (
+	// DefaultTaskDueReminderInterval 任务截止提醒的默认运行间隔
+	DefaultTaskDueReminderInterval = 15 * time.Minute
+	// taskDueReminderBatchSize 每个提前量每次运行最多提醒的任务数，剩余任务在下次运行时处理
+	taskDueReminderBatchSize = 200
+	// taskDueRemindersConfigKey 截止提醒设置在系统配置中的键
+	taskDueRemindersConfigKey = "task.due_reminders"
+	// overdueLeadHours 逾期提醒在提醒记录中的提前量
+	overdueLeadHours = 0
+)
+
+// ErrInvalidTaskDueReminderSettings 截止提醒设置不合法
+var ErrInvalidTaskDueReminderSettings = response.NewError(response.ErrCodeInvalidRequest, "任务截止提醒设置不合法")
+
+// taskPriorities 按从高到低的顺序处理各优先级的提醒
+var taskPriorities = []string{"urgent", "high", "medium", "low"}
+
+// TaskDueReminderSettings 任务截止提醒设置
+type TaskDueReminderSettings struct {
+	// LeadHours 各优先级在截止前多少小时提醒，从大到小排列，未配置的优先级只发送逾期提醒
+	LeadHours     map[string][]int `json:"lead_hours" binding:"required"`
+	NotifyCreator bool             `json:"notify_creator"` // 同时提醒任务创建者
+}
+
+// defaultTaskDueReminderSettings 系统配置中没有截止提醒设置时使用的默认值
+func defaultTaskDueReminderSettings() *TaskDueReminderSettings {
+	return &TaskDueReminderSettings{LeadHours: map[string][]int{
+		"urgent": {24, 4},
+		"high":   {24},
+		"medium": {48},
+	}}
+}
+
+// TaskDueReminderReport 一次截止提醒运行的结果
+type TaskDueReminderReport struct {
+	DueSoon int `json:"due_soon"` // 发送即将到期提醒的任务数
+	Overdue int `json:"overdue"`  // 发送逾期提醒的任务数
+	Failed  int `json:"failed"`
+}
+
+// TaskDueReminderService 任务截止提醒服务：已分配或进行中的任务按优先级的提前量提醒负责人，到期后发送逾期提醒
+type TaskDueReminderService interface {
+	// GetSettings 获取截止提醒设置，未设置时返回默认值
+	GetSettings(ctx context.Context) (*TaskDueReminderSettings, error)
+	// UpdateSettings 校验并保存截止提醒设置
+	UpdateSettings(ctx context.Context, settings *TaskDueReminderSettings) (*TaskDueReminderSettings, error)
+	// RunReminders 发送到期的截止提醒和逾期提醒，每个任务的每个提前量只提醒一次
+	RunReminders(ctx context.Context, now time.Time) (*TaskDueReminderReport, error)
+}
+
+// taskDueReminderService 任务截止提醒服务实现
+type taskDueReminderService struct {
+	repoManager         repository.RepositoryManager
+	notificationService NotificationService
+	workCalendar        WorkCalendarService // 提前量按工作时间计算，为nil时按自然时间
+}
+
+// NewTaskDueReminderService 创建任务截止提醒服务
+func NewTaskDueReminderService(repoManager repository.RepositoryManager, notificationService NotificationService, workCalendar WorkCalendarService) TaskDueReminderService {
+	return &taskDueReminderService{
+		repoManager:         repoManager,
+		notificationService: notificationService,
+		workCalendar:        workCalendar,
+	}
+}
+
+// GetSettings 从系统配置读取截止提醒设置
+func (s *taskDueReminderService) GetSettings(ctx context.Context) (*TaskDueReminderSettings, error) {
+	config, err := s.repoManager.SystemConfigRepository().GetByKey(ctx, taskDueRemindersConfigKey)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return defaultTaskDueReminderSettings(), nil
+		}
+		return nil, err
+	}
+	if config.Value == "" {
+		return defaultTaskDueReminderSettings(), nil
+	}
+
+	var settings TaskDueReminderSettings
+	if err := json.Unmarshal([]byte(config.Value), &settings); err != nil {
+		return nil, fmt.Errorf("解析任务截止提醒设置失败: %w", err)
+	}
+	if settings.LeadHours == nil {
+		settings.LeadHours = map[string][]int{}
+	}
+	return &settings, nil
+}
+
+// UpdateSettings 校验提前量后写入系统配置
+func (s *taskDueReminderService) UpdateSettings(ctx context.Context, settings *TaskDueReminderSettings) (*TaskDueReminderSettings, error) {
+	if err := validateTaskDueReminderSettings(settings); err != nil {
+		return nil, err
+	}
+	value, err := json.Marshal(settings)
+	if err != nil {
+		return nil, fmt.Errorf("序列化任务截止提醒设置失败: %w", err)
+	}
+	if err := s.repoManager.SystemConfigRepository().SetValue(ctx, taskDueRemindersConfigKey, string(value)); err != nil {
+		return nil, err
+	}
+
+	logger.FromContext(ctx).Infof("任务截止提醒设置已更新: %s", value)
+	return settings, nil
+}
+
+// RunReminders 按优先级和提前量从小到大发送提醒，同一次运行中任务只收到最近提前量的提醒；单个任务失败不影响其它任务
+func (s *taskDueReminderService) RunReminders(ctx context.Context, now time.Time) (*TaskDueReminderReport, error) {
+	settings, err := s.GetSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &TaskDueReminderReport{}
+	repo := s.repoManager.TaskDueReminderRepository()
+	var calendar *workcalendar.Calendar
+	if s.workCalendar != nil {
+		calendar = s.workCalendar.Calendar(ctx)
+	}
+	for _, priority := range taskPriorities {
+		leadHours := settings.LeadHours[priority]
+		for i := len(leadHours) - 1; i >= 0; i-- {
+			// 提前量只计算工作时间，周五傍晚检查时24小时提前量覆盖到下周工作日
+			until := calendar.AddWorkingDuration(now, time.Duration(leadHours[i])*time.Hour)
+			tasks, err := repo.ListDueSoon(ctx, priority, leadHours[i], now, until, taskDueReminderBatchSize)
+			if err != nil {
+				return report, err
+			}
+			for _, task := range tasks {
+				s.remind(ctx, settings, task, leadHours[i], now, report)
+			}
+		}
+	}
+
+	tasks, err := repo.ListOverdue(ctx, now, taskDueReminderBatchSize)
+	if err != nil {
+		return report, err
+	}
+	for _, task := range tasks {
+		s.remind(ctx, settings, task, overdueLeadHours, now, report)
+	}
+	return report, nil
+}
+
+// remind 记录提醒后通知负责人，其它实例已提醒过时跳过
+func (s *taskDueReminderService) remind(ctx context.Context, settings *TaskDueReminderSettings, task *database.Task, leadHours int, now time.Time, report *TaskDueReminderReport) {
+	recorded, err := s.repoManager.TaskDueReminderRepository().Record(ctx, &database.TaskDueReminder{
+		TaskID:     task.ID,
+		LeadHours:  leadHours,
+		RemindedAt: now,
+	})
+	if err != nil {
+		logger.FromContext(ctx).Warnf("记录任务截止提醒失败: TaskID=%d, LeadHours=%d, %v", task.ID, leadHours, err)
+		report.Failed++
+		return
+	}
+	if !recorded {
+		return
+	}
+	if leadHours == overdueLeadHours {
+		report.Overdue++
+	} else {
+		report.DueSoon++
+	}
+	s.notifyDue(ctx, settings, task, leadHours)
+}
+
+// notifyDue 通知任务负责人，按设置同时通知创建者，失败只记录日志
+func (s *taskDueReminderService) notifyDue(ctx context.Context, settings *TaskDueReminderSettings, task *database.Task, leadHours int) {
+	if s.notificationService == nil || task.AssigneeID == nil {
+		return
+	}
+
+	notificationType := models.NotificationTypeTaskReminder
+	title := fmt.Sprintf("任务「%s」将在%d小时内到期", task.Title, leadHours)
+	if leadHours == overdueLeadHours {
+		notificationType = models.NotificationTypeTaskOverdue
+		title = fmt.Sprintf("任务「%s」已逾期", task.Title)
+	}
+	content := fmt.Sprintf("截止时间：%s", task.DueDate.Format("2006-01-02 15:04"))
+
+	recipients := []uint{*task.AssigneeID}
+	if settings.NotifyCreator && task.CreatorID != *task.AssigneeID {
+		recipients = append(recipients, task.CreatorID)
+	}
+	for _, userID := range recipients {
+		if err := s.notificationService.CreateTaskStatusNotification(ctx, task.ID, userID, notificationType, title, content); err != nil {
+			logger.FromContext(ctx).Warnf("发送任务截止提醒失败: TaskID=%d, UserID=%d, %v", task.ID, userID, err)
+		}
+	}
+}
+
+// validateTaskDueReminderSettings 校验优先级合法，提前量为正数且严格从大到小排列
+func validateTaskDueReminderSettings(settings *TaskDueReminderSettings) error {
+	for priority, leadHours := range settings.LeadHours {
+		if _, ok := taskPriorityRank[priority]; !ok {
+			return response.Wrapf(ErrInvalidTaskDueReminderSettings, "优先级%s须为low、medium、high或urgent", priority)
+		}
+		for i, hours := range leadHours {
+			if hours <= 0 {
+				return response.Wrapf(ErrInvalidTaskDueReminderSettings, "%s的提前量须大于0", priority)
+			}
+			if i > 0 && hours >= leadHours[i-1] {
+				return response.Wrapf(ErrInvalidTaskDueReminderSettings, "%s的提前量须从大到小排列且不能重复", priority)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/models"
+	"taskmanage/internal/repository"
+)
+
+// memoryDueReminderRepository 测试用内存截止提醒仓储，候选任务从任务列表中筛选
+type memoryDueReminderRepository struct {
+	repository.TaskDueReminderRepository
+	tasks     []*database.Task
+	reminders map[uint][]int
+}
+
+func (r *memoryDueReminderRepository) remindedWithin(taskID uint, leadHours int) bool {
+	for _, reminded := range r.reminders[taskID] {
+		if reminded <= leadHours {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *memoryDueReminderRepository) ListDueSoon(ctx context.Context, priority string, leadHours int, now, until time.Time, limit int) ([]*database.Task, error) {
+	var result []*database.Task
+	for _, task := range r.tasks {
+		if task.Priority == priority && task.DueDate.After(now) && !task.DueDate.After(until) && !r.remindedWithin(task.ID, leadHours) {
+			result = append(result, task)
+		}
+	}
+	return result, nil
+}
+
+func (r *memoryDueReminderRepository) ListOverdue(ctx context.Context, now time.Time, limit int) ([]*database.Task, error) {
+	var result []*database.Task
+	for _, task := range r.tasks {
+		if !task.DueDate.After(now) && !r.remindedWithin(task.ID, overdueLeadHours) {
+			result = append(result, task)
+		}
+	}
+	return result, nil
+}
+
+func (r *memoryDueReminderRepository) Record(ctx context.Context, reminder *database.TaskDueReminder) (bool, error) {
+	for _, reminded := range r.reminders[reminder.TaskID] {
+		if reminded == reminder.LeadHours {
+			return false, nil
+		}
+	}
+	r.reminders[reminder.TaskID] = append(r.reminders[reminder.TaskID], reminder.LeadHours)
+	return true, nil
+}
+
+// memorySystemConfigRepository 测试用内存系统配置仓储
+type memorySystemConfigRepository struct {
+	repository.SystemConfigRepository
+	values map[string]string
+}
+
+func (r *memorySystemConfigRepository) GetByKey(ctx context.Context, key string) (*database.SystemConfig, error) {
+	value, ok := r.values[key]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &database.SystemConfig{Key: key, Value: value}, nil
+}
+
+func (r *memorySystemConfigRepository) SetValue(ctx context.Context, key, value string) error {
+	r.values[key] = value
+	return nil
+}
+
+// dueReminderRepoManager 截止提醒测试用仓储管理器
+type dueReminderRepoManager struct {
+	repository.RepositoryManager
+	reminders *memoryDueReminderRepository
+	configs   *memorySystemConfigRepository
+}
+
+func (m *dueReminderRepoManager) TaskDueReminderRepository() repository.TaskDueReminderRepository {
+	return m.reminders
+}
+
+func (m *dueReminderRepoManager) SystemConfigRepository() repository.SystemConfigRepository {
+	return m.configs
+}
+
+func TestTaskDueReminderService_UpdateSettingsValidation(t *testing.T) {
+	repos := &dueReminderRepoManager{configs: &memorySystemConfigRepository{values: map[string]string{}}}
+	svc := NewTaskDueReminderService(repos, nil, nil)
+	ctx := context.Background()
+
+	// 未设置时使用默认值
+	settings, err := svc.GetSettings(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int{24, 4}, settings.LeadHours["urgent"])
+
+	for _, leadHours := range []map[string][]int{
+		{"urgent": {4, 24}},
+		{"urgent": {24, 24}},
+		{"high": {0}},
+		{"critical": {24}},
+	} {
+		_, err := svc.UpdateSettings(ctx, &TaskDueReminderSettings{LeadHours: leadHours})
+		assert.ErrorIs(t, err, ErrInvalidTaskDueReminderSettings, "%v", leadHours)
+	}
+
+	_, err = svc.UpdateSettings(ctx, &TaskDueReminderSettings{LeadHours: map[string][]int{"low": {72}}, NotifyCreator: true})
+	require.NoError(t, err)
+	settings, err = svc.GetSettings(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &TaskDueReminderSettings{LeadHours: map[string][]int{"low": {72}}, NotifyCreator: true}, settings)
+}
+
+func TestTaskDueReminderService_RunReminders(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.Local)
+	assigneeID := uint(20)
+	task := func(id uint, priority string, dueIn time.Duration) *database.Task {
+		dueDate := now.Add(dueIn)
+		t := &database.Task{Title: "任务", Priority: priority, Status: "in_progress", CreatorID: 10, AssigneeID: &assigneeID, DueDate: &dueDate}
+		t.ID = id
+		return t
+	}
+	repos := &dueReminderRepoManager{
+		reminders: &memoryDueReminderRepository{
+			tasks: []*database.Task{
+				task(1, "urgent", 20*time.Hour),
+				task(2, "urgent", 2*time.Hour), // 进入4小时提前量，不再发送24小时提醒
+				task(3, "medium", 30*time.Hour),
+				task(4, "low", 10*time.Hour), // low未配置提前量
+				task(5, "high", -time.Hour),
+			},
+			reminders: map[uint][]int{},
+		},
+		configs: &memorySystemConfigRepository{values: map[string]string{}},
+	}
+	notifier := &watcherNotificationService{}
+	svc := NewTaskDueReminderService(repos, notifier, nil)
+	ctx := context.Background()
+
+	report, err := svc.RunReminders(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, &TaskDueReminderReport{DueSoon: 3, Overdue: 1}, report)
+	assert.Equal(t, map[uint][]int{1: {24}, 2: {4}, 3: {48}, 5: {0}}, repos.reminders.reminders)
+	assert.Equal(t, []uint{20, 20, 20, 20}, notifier.snapshot())
+	assert.Equal(t, models.NotificationTypeTaskOverdue, notifier.types[3])
+
+	// 每个提前量只提醒一次
+	report, err = svc.RunReminders(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, &TaskDueReminderReport{}, report)
+
+	// 任务1进入4小时提前量后再提醒一次，任务2和任务4到期后发送逾期提醒；开启后同时提醒创建者
+	_, err = svc.UpdateSettings(ctx, &TaskDueReminderSettings{LeadHours: defaultTaskDueReminderSettings().LeadHours, NotifyCreator: true})
+	require.NoError(t, err)
+	report, err = svc.RunReminders(ctx, now.Add(17*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, &TaskDueReminderReport{DueSoon: 1, Overdue: 2}, report)
+	assert.Equal(t, []int{24, 4}, repos.reminders.reminders[1])
+	assert.Equal(t, []int{4, 0}, repos.reminders.reminders[2])
+	assert.Equal(t, []int{0}, repos.reminders.reminders[4])
+	assert.Equal(t, []uint{20, 20, 20, 20, 20, 10, 20, 10, 20, 10}, notifier.snapshot())
+}
@@ -0,0 +1,359 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/models"
+	"taskmanage/internal/repository"
+	"taskmanage/pkg/logger"
+	"taskmanage/pkg/response"
+)
+
+const (
+	// DefaultTaskEscalationInterval 优先级老化任务的默认运行间隔
+	DefaultTaskEscalationInterval = time.Hour
+	// taskEscalationBatchSize 每条规则每次运行最多处理的任务数，剩余任务在下次运行时处理
+	taskEscalationBatchSize = 200
+)
+
+var (
+	// ErrInvalidTaskEscalationRule 升级规则不合法
+	ErrInvalidTaskEscalationRule = response.NewError(response.ErrCodeInvalidRequest, "任务升级规则不合法")
+	// ErrTaskEscalationRuleNotFound 升级规则不存在
+	ErrTaskEscalationRuleNotFound = response.NewError(response.ErrCodeNotFound, "任务升级规则不存在")
+)
+
+// taskPriorityRank 任务优先级从低到高的顺序，升级后的优先级必须高于规则匹配的优先级
+var taskPriorityRank = map[string]int{"low": 1, "medium": 2, "high": 3, "urgent": 4}
+
+// TaskEscalationRuleRequest 创建或更新升级规则请求
+type TaskEscalationRuleRequest struct {
+	Name                    string `json:"name" binding:"required,max=100"`
+	Priority                string `json:"priority" binding:"required,oneof=low medium high urgent"`
+	ThresholdHours          int    `json:"threshold_hours" binding:"required,min=1"`
+	RaisePriorityTo         string `json:"raise_priority_to" binding:"omitempty,oneof=low medium high urgent"` // 为空表示只通知不调整优先级
+	NotifyDepartmentManager bool   `json:"notify_department_manager"`
+	NotifyCreator           bool   `json:"notify_creator"`
+	Enabled                 *bool  `json:"enabled"` // 为空时默认启用
+}
+
+// TaskEscalationRuleResponse 升级规则响应
+type TaskEscalationRuleResponse struct {
+	ID                      uint      `json:"id"`
+	Name                    string    `json:"name"`
+	Priority                string    `json:"priority"`
+	ThresholdHours          int       `json:"threshold_hours"`
+	RaisePriorityTo         string    `json:"raise_priority_to,omitempty"`
+	NotifyDepartmentManager bool      `json:"notify_department_manager"`
+	NotifyCreator           bool      `json:"notify_creator"`
+	Enabled                 bool      `json:"enabled"`
+	CreatedBy               uint      `json:"created_by"`
+	CreatedAt               time.Time `json:"created_at"`
+	UpdatedAt               time.Time `json:"updated_at"`
+}
+
+// TaskEscalationItem 单个任务的升级结果
+type TaskEscalationItem struct {
+	RuleID       uint   `json:"rule_id"`
+	TaskID       uint   `json:"task_id"`
+	TaskTitle    string `json:"task_title"`
+	FromPriority string `json:"from_priority"`
+	ToPriority   string `json:"to_priority"`    // 与FromPriority相同表示不调整优先级
+	Notified     []uint `json:"notified_users"` // 收到通知的用户，预演时为将要通知的用户
+}
+
+// TaskEscalationReport 一次升级运行的结果
+type TaskEscalationReport struct {
+	DryRun    bool                  `json:"dry_run"`
+	Escalated []*TaskEscalationItem `json:"escalated"`
+	Failed    int                   `json:"failed"`
+}
+
+// TaskEscalationService 任务优先级老化服务：待分配任务超过规则阈值后调整优先级并通知相关人员
+type TaskEscalationService interface {
+	// ListRules 获取全部升级规则
+	ListRules(ctx context.Context) ([]*TaskEscalationRuleResponse, error)
+	// CreateRule 创建升级规则
+	CreateRule(ctx context.Context, createdBy uint, req *TaskEscalationRuleRequest) (*TaskEscalationRuleResponse, error)
+	// UpdateRule 更新升级规则，已升级过的任务不会因规则修改而再次升级
+	UpdateRule(ctx context.Context, ruleID uint, req *TaskEscalationRuleRequest) (*TaskEscalationRuleResponse, error)
+	// DeleteRule 删除升级规则及其升级记录
+	DeleteRule(ctx context.Context, ruleID uint) error
+	// RunEscalations 按启用的规则升级超时的待分配任务，每条规则对每个任务只升级一次；dryRun为true时只报告将要升级的任务
+	RunEscalations(ctx context.Context, now time.Time, dryRun bool) (*TaskEscalationReport, error)
+}
+
+// taskEscalationService 任务优先级老化服务实现
+type taskEscalationService struct {
+	repoManager         repository.RepositoryManager
+	notificationService NotificationService
+}
+
+// NewTaskEscalationService 创建任务优先级老化服务
+func NewTaskEscalationService(repoManager repository.RepositoryManager, notificationService NotificationService) TaskEscalationService {
+	return &taskEscalationService{
+		repoManager:         repoManager,
+		notificationService: notificationService,
+	}
+}
+
+// ListRules 获取全部升级规则
+func (s *taskEscalationService) ListRules(ctx context.Context) ([]*TaskEscalationRuleResponse, error) {
+	rules, err := s.repoManager.TaskEscalationRepository().ListRules(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	responses := make([]*TaskEscalationRuleResponse, 0, len(rules))
+	for _, rule := range rules {
+		responses = append(responses, toTaskEscalationRuleResponse(rule))
+	}
+	return responses, nil
+}
+
+// CreateRule 创建升级规则
+func (s *taskEscalationService) CreateRule(ctx context.Context, createdBy uint, req *TaskEscalationRuleRequest) (*TaskEscalationRuleResponse, error) {
+	rule := &database.TaskEscalationRule{CreatedBy: createdBy, Enabled: true}
+	if err := applyTaskEscalationRuleRequest(rule, req); err != nil {
+		return nil, err
+	}
+	if err := s.repoManager.TaskEscalationRepository().CreateRule(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	logger.FromContext(ctx).Infof("任务升级规则创建成功: ID=%d, Name=%s, Priority=%s, ThresholdHours=%d",
+		rule.ID, rule.Name, rule.Priority, rule.ThresholdHours)
+	return toTaskEscalationRuleResponse(rule), nil
+}
+
+// UpdateRule 更新升级规则
+func (s *taskEscalationService) UpdateRule(ctx context.Context, ruleID uint, req *TaskEscalationRuleRequest) (*TaskEscalationRuleResponse, error) {
+	rule, err := s.getRule(ctx, ruleID)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyTaskEscalationRuleRequest(rule, req); err != nil {
+		return nil, err
+	}
+	if err := s.repoManager.TaskEscalationRepository().UpdateRule(ctx, rule); err != nil {
+		return nil, err
+	}
+	return toTaskEscalationRuleResponse(rule), nil
+}
+
+// DeleteRule 删除升级规则
+func (s *taskEscalationService) DeleteRule(ctx context.Context, ruleID uint) error {
+	if err := s.repoManager.TaskEscalationRepository().DeleteRule(ctx, ruleID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrTaskEscalationRuleNotFound.WithCause(err)
+		}
+		return err
+	}
+	return nil
+}
+
+// RunEscalations 逐条规则处理超时任务，单个任务失败不影响其它任务
+func (s *taskEscalationService) RunEscalations(ctx context.Context, now time.Time, dryRun bool) (*TaskEscalationReport, error) {
+	rules, err := s.repoManager.TaskEscalationRepository().ListRules(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &TaskEscalationReport{DryRun: dryRun, Escalated: []*TaskEscalationItem{}}
+	for _, rule := range rules {
+		createdBefore := now.Add(-time.Duration(rule.ThresholdHours) * time.Hour)
+		tasks, err := s.repoManager.TaskEscalationRepository().ListCandidates(ctx, rule.ID, rule.Priority, createdBefore, taskEscalationBatchSize)
+		if err != nil {
+			return report, err
+		}
+
+		for _, task := range tasks {
+			item := &TaskEscalationItem{
+				RuleID:       rule.ID,
+				TaskID:       task.ID,
+				TaskTitle:    task.Title,
+				FromPriority: task.Priority,
+				ToPriority:   task.Priority,
+				Notified:     s.escalationRecipients(ctx, rule, task),
+			}
+			if rule.RaisePriorityTo != "" {
+				item.ToPriority = rule.RaisePriorityTo
+			}
+			if dryRun {
+				report.Escalated = append(report.Escalated, item)
+				continue
+			}
+
+			escalated, err := s.escalate(ctx, rule, task, item, now)
+			if err != nil {
+				logger.FromContext(ctx).Warnf("任务升级失败: RuleID=%d, TaskID=%d, %v", rule.ID, task.ID, err)
+				report.Failed++
+				continue
+			}
+			if !escalated {
+				continue
+			}
+			s.notifyEscalation(ctx, rule, task, item)
+			report.Escalated = append(report.Escalated, item)
+		}
+	}
+	return report, nil
+}
+
+// escalate 在事务中记录升级、调整优先级并添加系统评论，其它实例已处理过时返回false
+func (s *taskEscalationService) escalate(ctx context.Context, rule *database.TaskEscalationRule, task *database.Task, item *TaskEscalationItem, now time.Time) (bool, error) {
+	var escalated bool
+	err := s.repoManager.WithTx(ctx, func(ctx context.Context, repos repository.RepositoryManager) error {
+		var err error
+		escalated, err = repos.TaskEscalationRepository().Record(ctx, &database.TaskEscalation{
+			RuleID:       rule.ID,
+			TaskID:       task.ID,
+			FromPriority: item.FromPriority,
+			ToPriority:   item.ToPriority,
+			EscalatedAt:  now,
+		})
+		if err != nil || !escalated {
+			return err
+		}
+		if item.ToPriority != item.FromPriority {
+			if err := repos.TaskRepository().UpdatePriority(ctx, task.ID, item.ToPriority); err != nil {
+				return err
+			}
+		}
+		comment := &database.TaskComment{
+			TaskID:  task.ID,
+			UserID:  rule.CreatedBy,
+			Content: escalationContent(rule, item),
+			System:  true,
+		}
+		return repos.TaskCommentRepository().Create(ctx, comment)
+	})
+	return escalated, err
+}
+
+// notifyEscalation 通知规则指定的人员，失败只记录日志
+func (s *taskEscalationService) notifyEscalation(ctx context.Context, rule *database.TaskEscalationRule, task *database.Task, item *TaskEscalationItem) {
+	if s.notificationService == nil {
+		item.Notified = nil
+		return
+	}
+	title := fmt.Sprintf("任务「%s」待分配超时", task.Title)
+	content := escalationContent(rule, item)
+	notified := make([]uint, 0, len(item.Notified))
+	for _, userID := range item.Notified {
+		if err := s.notificationService.CreateTaskStatusNotification(ctx, task.ID, userID, models.NotificationTypeTaskEscalated, title, content); err != nil {
+			logger.FromContext(ctx).Warnf("发送任务升级通知失败: TaskID=%d, UserID=%d, %v", task.ID, userID, err)
+			continue
+		}
+		notified = append(notified, userID)
+	}
+	item.Notified = notified
+}
+
+// escalationRecipients 按规则确定通知对象：任务创建者和创建者所在部门的经理，去重
+func (s *taskEscalationService) escalationRecipients(ctx context.Context, rule *database.TaskEscalationRule, task *database.Task) []uint {
+	recipients := make([]uint, 0, 2)
+	if rule.NotifyCreator {
+		recipients = append(recipients, task.CreatorID)
+	}
+	if rule.NotifyDepartmentManager {
+		managerUserID, ok := s.departmentManagerUserID(ctx, task.CreatorID)
+		if ok && (!rule.NotifyCreator || managerUserID != task.CreatorID) {
+			recipients = append(recipients, managerUserID)
+		}
+	}
+	return recipients
+}
+
+// departmentManagerUserID 获取用户所在部门经理的用户ID，用户不是员工、未分配部门或部门没有经理时返回false
+func (s *taskEscalationService) departmentManagerUserID(ctx context.Context, userID uint) (uint, bool) {
+	employee, err := s.repoManager.EmployeeRepository().GetByUserID(ctx, userID)
+	if err != nil || employee.DepartmentID == nil {
+		return 0, false
+	}
+	department, err := s.repoManager.DepartmentRepository().GetByID(ctx, *employee.DepartmentID)
+	if err != nil || department.ManagerID == nil {
+		return 0, false
+	}
+	manager, err := s.repoManager.EmployeeRepository().GetByID(ctx, *department.ManagerID)
+	if err != nil {
+		return 0, false
+	}
+	return manager.UserID, true
+}
+
+// getRule 获取升级规则
+func (s *taskEscalationService) getRule(ctx context.Context, ruleID uint) (*database.TaskEscalationRule, error) {
+	rule, err := s.repoManager.TaskEscalationRepository().GetRule(ctx, ruleID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrTaskEscalationRuleNotFound.WithCause(err)
+		}
+		return nil, err
+	}
+	return rule, nil
+}
+
+// escalationContent 升级说明，用于系统评论和通知内容
+func escalationContent(rule *database.TaskEscalationRule, item *TaskEscalationItem) string {
+	content := fmt.Sprintf("任务处于待分配状态已超过%d小时，触发升级规则「%s」", rule.ThresholdHours, rule.Name)
+	if item.ToPriority != item.FromPriority {
+		content += fmt.Sprintf("，优先级由%s调整为%s", item.FromPriority, item.ToPriority)
+	}
+	return content
+}
+
+// applyTaskEscalationRuleRequest 校验请求并写入规则，升级后的优先级必须高于匹配的优先级
+func applyTaskEscalationRuleRequest(rule *database.TaskEscalationRule, req *TaskEscalationRuleRequest) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return response.Wrapf(ErrInvalidTaskEscalationRule, "name不能为空")
+	}
+	if _, ok := taskPriorityRank[req.Priority]; !ok {
+		return response.Wrapf(ErrInvalidTaskEscalationRule, "priority须为low、medium、high或urgent")
+	}
+	if req.ThresholdHours <= 0 {
+		return response.Wrapf(ErrInvalidTaskEscalationRule, "threshold_hours须大于0")
+	}
+	if req.RaisePriorityTo != "" {
+		rank, ok := taskPriorityRank[req.RaisePriorityTo]
+		if !ok || rank <= taskPriorityRank[req.Priority] {
+			return response.Wrapf(ErrInvalidTaskEscalationRule, "raise_priority_to须高于priority")
+		}
+	}
+	if req.RaisePriorityTo == "" && !req.NotifyCreator && !req.NotifyDepartmentManager {
+		return response.Wrapf(ErrInvalidTaskEscalationRule, "规则须调整优先级或至少通知一类人员")
+	}
+
+	rule.Name = name
+	rule.Priority = req.Priority
+	rule.ThresholdHours = req.ThresholdHours
+	rule.RaisePriorityTo = req.RaisePriorityTo
+	rule.NotifyCreator = req.NotifyCreator
+	rule.NotifyDepartmentManager = req.NotifyDepartmentManager
+	if req.Enabled != nil {
+		rule.Enabled = *req.Enabled
+	}
+	return nil
+}
+
+// toTaskEscalationRuleResponse 转换升级规则响应
+func toTaskEscalationRuleResponse(rule *database.TaskEscalationRule) *TaskEscalationRuleResponse {
+	return &TaskEscalationRuleResponse{
+		ID:                      rule.ID,
+		Name:                    rule.Name,
+		Priority:                rule.Priority,
+		ThresholdHours:          rule.ThresholdHours,
+		RaisePriorityTo:         rule.RaisePriorityTo,
+		NotifyDepartmentManager: rule.NotifyDepartmentManager,
+		NotifyCreator:           rule.NotifyCreator,
+		Enabled:                 rule.Enabled,
+		CreatedBy:               rule.CreatedBy,
+		CreatedAt:               rule.CreatedAt,
+		UpdatedAt:               rule.UpdatedAt,
+	}
+}
@@ -0,0 +1,255 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/models"
+	"taskmanage/internal/repository"
+)
+
+// memoryEscalationRepository 测试用内存升级规则仓储，候选任务从任务表中筛选
+type memoryEscalationRepository struct {
+	repository.TaskEscalationRepository
+	rules       map[uint]*database.TaskEscalationRule
+	escalations map[[2]uint]*database.TaskEscalation
+	tasks       map[uint]*database.Task
+	nextID      uint
+}
+
+func (r *memoryEscalationRepository) CreateRule(ctx context.Context, rule *database.TaskEscalationRule) error {
+	r.nextID++
+	rule.ID = r.nextID
+	r.rules[rule.ID] = rule
+	return nil
+}
+
+func (r *memoryEscalationRepository) ListRules(ctx context.Context, enabledOnly bool) ([]*database.TaskEscalationRule, error) {
+	var result []*database.TaskEscalationRule
+	for id := uint(1); id <= r.nextID; id++ {
+		if rule, ok := r.rules[id]; ok && (!enabledOnly || rule.Enabled) {
+			result = append(result, rule)
+		}
+	}
+	return result, nil
+}
+
+func (r *memoryEscalationRepository) ListCandidates(ctx context.Context, ruleID uint, priority string, createdBefore time.Time, limit int) ([]*database.Task, error) {
+	var result []*database.Task
+	for id := uint(1); id <= uint(len(r.tasks)); id++ {
+		task := r.tasks[id]
+		if _, done := r.escalations[[2]uint{ruleID, task.ID}]; done {
+			continue
+		}
+		if task.Status == "pending" && task.Priority == priority && !task.CreatedAt.After(createdBefore) {
+			copied := *task
+			result = append(result, &copied)
+		}
+	}
+	return result, nil
+}
+
+func (r *memoryEscalationRepository) Record(ctx context.Context, escalation *database.TaskEscalation) (bool, error) {
+	key := [2]uint{escalation.RuleID, escalation.TaskID}
+	if _, ok := r.escalations[key]; ok {
+		return false, nil
+	}
+	r.escalations[key] = escalation
+	return true, nil
+}
+
+// escalationTaskRepository 记录优先级调整的任务仓储
+type escalationTaskRepository struct {
+	repository.TaskRepository
+	tasks map[uint]*database.Task
+}
+
+func (r *escalationTaskRepository) UpdatePriority(ctx context.Context, taskID uint, priority string) error {
+	r.tasks[taskID].Priority = priority
+	return nil
+}
+
+// escalationCommentRepository 记录系统评论的评论仓储
+type escalationCommentRepository struct {
+	repository.TaskCommentRepository
+	comments []*database.TaskComment
+}
+
+func (r *escalationCommentRepository) Create(ctx context.Context, comment *database.TaskComment) error {
+	r.comments = append(r.comments, comment)
+	return nil
+}
+
+// escalationEmployeeRepository 按ID和用户ID查找员工
+type escalationEmployeeRepository struct {
+	repository.EmployeeRepository
+	employees []*database.Employee
+}
+
+func (r *escalationEmployeeRepository) GetByID(ctx context.Context, id uint) (*database.Employee, error) {
+	for _, employee := range r.employees {
+		if employee.ID == id {
+			return employee, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *escalationEmployeeRepository) GetByUserID(ctx context.Context, userID uint) (*database.Employee, error) {
+	for _, employee := range r.employees {
+		if employee.UserID == userID {
+			return employee, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+// escalationDepartmentRepository 只有一个部门的部门仓储
+type escalationDepartmentRepository struct {
+	repository.DepartmentRepository
+	department *database.Department
+}
+
+func (r *escalationDepartmentRepository) GetByID(ctx context.Context, id uint) (*database.Department, error) {
+	if r.department.ID != id {
+		return nil, repository.ErrNotFound
+	}
+	return r.department, nil
+}
+
+// escalationRepoManager 优先级老化测试用仓储管理器
+type escalationRepoManager struct {
+	repository.RepositoryManager
+	escalations *memoryEscalationRepository
+	tasks       *escalationTaskRepository
+	comments    *escalationCommentRepository
+	employees   *escalationEmployeeRepository
+	departments *escalationDepartmentRepository
+}
+
+func (m *escalationRepoManager) TaskEscalationRepository() repository.TaskEscalationRepository {
+	return m.escalations
+}
+
+func (m *escalationRepoManager) TaskRepository() repository.TaskRepository {
+	return m.tasks
+}
+
+func (m *escalationRepoManager) TaskCommentRepository() repository.TaskCommentRepository {
+	return m.comments
+}
+
+func (m *escalationRepoManager) EmployeeRepository() repository.EmployeeRepository {
+	return m.employees
+}
+
+func (m *escalationRepoManager) DepartmentRepository() repository.DepartmentRepository {
+	return m.departments
+}
+
+func (m *escalationRepoManager) WithTx(ctx context.Context, fn func(ctx context.Context, repos repository.RepositoryManager) error) error {
+	return fn(ctx, m)
+}
+
+// newEscalationRepoManager 创建者10属于部门1，部门经理为员工2（用户20）
+func newEscalationRepoManager(now time.Time) *escalationRepoManager {
+	task := func(id uint, priority, status string, age time.Duration) *database.Task {
+		t := &database.Task{Title: "任务", Priority: priority, Status: status, CreatorID: 10}
+		t.ID = id
+		t.CreatedAt = now.Add(-age)
+		return t
+	}
+	tasks := map[uint]*database.Task{
+		1: task(1, "urgent", "pending", 30*time.Hour),
+		2: task(2, "urgent", "pending", 10*time.Hour),
+		3: task(3, "high", "pending", 80*time.Hour),
+		4: task(4, "high", "in_progress", 80*time.Hour),
+	}
+
+	departmentID := uint(1)
+	managerID := uint(2)
+	creator := &database.Employee{UserID: 10, DepartmentID: &departmentID}
+	creator.ID = 1
+	manager := &database.Employee{UserID: 20, DepartmentID: &departmentID}
+	manager.ID = 2
+	department := &database.Department{ManagerID: &managerID}
+	department.ID = departmentID
+
+	return &escalationRepoManager{
+		escalations: &memoryEscalationRepository{
+			rules:       map[uint]*database.TaskEscalationRule{},
+			escalations: map[[2]uint]*database.TaskEscalation{},
+			tasks:       tasks,
+		},
+		tasks:       &escalationTaskRepository{tasks: tasks},
+		comments:    &escalationCommentRepository{},
+		employees:   &escalationEmployeeRepository{employees: []*database.Employee{creator, manager}},
+		departments: &escalationDepartmentRepository{department: department},
+	}
+}
+
+func TestTaskEscalationService_CreateRuleValidation(t *testing.T) {
+	repos := newEscalationRepoManager(time.Now())
+	svc := NewTaskEscalationService(repos, nil)
+	ctx := context.Background()
+
+	_, err := svc.CreateRule(ctx, 1, &TaskEscalationRuleRequest{Name: "降级", Priority: "high", ThresholdHours: 72, RaisePriorityTo: "medium"})
+	assert.ErrorIs(t, err, ErrInvalidTaskEscalationRule)
+	_, err = svc.CreateRule(ctx, 1, &TaskEscalationRuleRequest{Name: "无动作", Priority: "high", ThresholdHours: 72})
+	assert.ErrorIs(t, err, ErrInvalidTaskEscalationRule)
+
+	rule, err := svc.CreateRule(ctx, 1, &TaskEscalationRuleRequest{Name: "高优先级超时", Priority: "high", ThresholdHours: 72, RaisePriorityTo: "urgent"})
+	require.NoError(t, err)
+	assert.True(t, rule.Enabled)
+}
+
+func TestTaskEscalationService_RunEscalations(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.Local)
+	repos := newEscalationRepoManager(now)
+	notifier := &watcherNotificationService{}
+	svc := NewTaskEscalationService(repos, notifier)
+	ctx := context.Background()
+
+	_, err := svc.CreateRule(ctx, 1, &TaskEscalationRuleRequest{Name: "紧急任务超时", Priority: "urgent", ThresholdHours: 24, NotifyCreator: true, NotifyDepartmentManager: true})
+	require.NoError(t, err)
+	_, err = svc.CreateRule(ctx, 1, &TaskEscalationRuleRequest{Name: "高优先级超时", Priority: "high", ThresholdHours: 72, RaisePriorityTo: "urgent", NotifyCreator: true})
+	require.NoError(t, err)
+
+	// 预演只报告，不修改任务、不记录、不通知
+	report, err := svc.RunEscalations(ctx, now, true)
+	require.NoError(t, err)
+	assert.True(t, report.DryRun)
+	require.Len(t, report.Escalated, 2)
+	assert.Equal(t, uint(1), report.Escalated[0].TaskID)
+	assert.Equal(t, []uint{10, 20}, report.Escalated[0].Notified)
+	assert.Equal(t, "urgent", report.Escalated[1].ToPriority)
+	assert.Equal(t, "high", repos.tasks.tasks[3].Priority)
+	assert.Empty(t, repos.escalations.escalations)
+	assert.Empty(t, repos.comments.comments)
+	assert.Empty(t, notifier.snapshot())
+
+	report, err = svc.RunEscalations(ctx, now, false)
+	require.NoError(t, err)
+	require.Len(t, report.Escalated, 2)
+	assert.Equal(t, "urgent", repos.tasks.tasks[3].Priority)
+	require.Len(t, repos.comments.comments, 2)
+	assert.True(t, repos.comments.comments[0].System)
+	assert.Equal(t, []uint{10, 20, 10}, notifier.snapshot())
+	assert.Equal(t, models.NotificationTypeTaskEscalated, notifier.types[0])
+
+	// 同一规则对同一任务只升级一次；任务3升为urgent后创建已超过24小时，由第一条规则再次处理
+	report, err = svc.RunEscalations(ctx, now, false)
+	require.NoError(t, err)
+	require.Len(t, report.Escalated, 1)
+	assert.Equal(t, uint(3), report.Escalated[0].TaskID)
+	assert.Equal(t, uint(1), report.Escalated[0].RuleID)
+
+	report, err = svc.RunEscalations(ctx, now, false)
+	require.NoError(t, err)
+	assert.Empty(t, report.Escalated)
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"taskmanage/internal/repository"
+)
+
+// 任务响应可展开的关联信息，由查询参数expand指定，多项用逗号分隔
+const (
+	TaskExpandAssignee = "assignee"
+	TaskExpandCreator  = "creator"
+	TaskExpandProject  = "project"
+)
+
+// TaskExpand 任务响应需要展开的关联信息，均为false时不做额外查询
+type TaskExpand struct {
+	Assignee bool
+	Creator  bool
+	Project  bool
+}
+
+// ParseTaskExpand 解析expand查询参数，不认识的项被忽略
+func ParseTaskExpand(value string) TaskExpand {
+	var expand TaskExpand
+	for _, item := range strings.Split(value, ",") {
+		switch strings.TrimSpace(item) {
+		case TaskExpandAssignee:
+			expand.Assignee = true
+		case TaskExpandCreator:
+			expand.Creator = true
+		case TaskExpandProject:
+			expand.Project = true
+		}
+	}
+	return expand
+}
+
+// Empty 没有需要展开的信息
+func (e TaskExpand) Empty() bool {
+	return !e.Assignee && !e.Creator && !e.Project
+}
+
+// TaskUserSummary 任务负责人或创建者的展示信息
+type TaskUserSummary struct {
+	ID       uint   `json:"id"`
+	RealName string `json:"real_name"`
+	Avatar   string `json:"avatar,omitempty"` // 仅负责人返回
+}
+
+// TaskProjectSummary 任务所属项目的展示信息
+type TaskProjectSummary struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
+
+// expandTaskResponses 按需填充任务的负责人、创建者和项目信息
+// 用户和项目各用一次批量查询，已删除的用户或项目不填充
+func expandTaskResponses(ctx context.Context, users repository.UserRepository, projects repository.ProjectRepository, expand TaskExpand, tasks ...*TaskResponse) error {
+	if expand.Empty() || len(tasks) == 0 {
+		return nil
+	}
+
+	var userIDs, projectIDs []uint
+	seenUsers := make(map[uint]bool)
+	seenProjects := make(map[uint]bool)
+	addUser := func(id uint) {
+		if id != 0 && !seenUsers[id] {
+			seenUsers[id] = true
+			userIDs = append(userIDs, id)
+		}
+	}
+	for _, task := range tasks {
+		if expand.Assignee && task.AssignedTo != nil {
+			addUser(*task.AssignedTo)
+		}
+		if expand.Creator {
+			addUser(task.CreatedBy)
+		}
+		if expand.Project && task.ProjectID != nil && !seenProjects[*task.ProjectID] {
+			seenProjects[*task.ProjectID] = true
+			projectIDs = append(projectIDs, *task.ProjectID)
+		}
+	}
+
+	if len(userIDs) > 0 {
+		list, err := users.GetByIDs(ctx, userIDs)
+		if err != nil {
+			return fmt.Errorf("查询任务相关用户失败: %w", err)
+		}
+		byID := make(map[uint]*TaskUserSummary, len(list))
+		for _, user := range list {
+			byID[user.ID] = &TaskUserSummary{ID: user.ID, RealName: user.RealName, Avatar: user.Avatar}
+		}
+		for _, task := range tasks {
+			if expand.Assignee && task.AssignedTo != nil {
+				task.Assignee = byID[*task.AssignedTo]
+			}
+			if expand.Creator {
+				if user := byID[task.CreatedBy]; user != nil {
+					task.Creator = &TaskUserSummary{ID: user.ID, RealName: user.RealName}
+				}
+			}
+		}
+	}
+
+	if len(projectIDs) > 0 {
+		list, err := projects.GetByIDs(ctx, projectIDs)
+		if err != nil {
+			return fmt.Errorf("查询任务所属项目失败: %w", err)
+		}
+		byID := make(map[uint]*TaskProjectSummary, len(list))
+		for _, project := range list {
+			byID[project.ID] = &TaskProjectSummary{ID: project.ID, Name: project.Name}
+		}
+		for _, task := range tasks {
+			if task.ProjectID != nil {
+				task.Project = byID[*task.ProjectID]
+			}
+		}
+	}
+	return nil
+}
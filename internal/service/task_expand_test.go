@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/database"
+)
+
+func TestParseTaskExpand(t *testing.T) {
+	assert.True(t, ParseTaskExpand("").Empty())
+	assert.Equal(t, TaskExpand{Assignee: true, Creator: true}, ParseTaskExpand("assignee, creator,unknown"))
+	assert.Equal(t, TaskExpand{Project: true}, ParseTaskExpand("project"))
+}
+
+func TestExpandTaskResponses(t *testing.T) {
+	ctx := context.Background()
+	users := &historyUserRepository{users: map[uint]*database.User{
+		1: {BaseModel: database.BaseModel{ID: 1}, RealName: "张三", Avatar: "a.png"},
+		2: {BaseModel: database.BaseModel{ID: 2}, RealName: "李四", Avatar: "b.png"},
+	}}
+	projects := &stubProjectRepository{project: &database.Project{BaseModel: database.BaseModel{ID: 7}, Name: "官网改版"}}
+	assignee, projectID, deletedProject := uint(2), uint(7), uint(8)
+	newTasks := func() []*TaskResponse {
+		return []*TaskResponse{
+			{ID: 10, CreatedBy: 1, AssignedTo: &assignee, ProjectID: &projectID},
+			{ID: 11, CreatedBy: 2, ProjectID: &deletedProject},
+			{ID: 12, CreatedBy: 3},
+		}
+	}
+
+	// 未请求展开时不查询
+	tasks := newTasks()
+	require.NoError(t, expandTaskResponses(ctx, users, projects, TaskExpand{}, tasks...))
+	assert.Empty(t, users.queries)
+	assert.Zero(t, projects.batches)
+	assert.Nil(t, tasks[0].Assignee)
+
+	// 整页的用户和项目各查询一次
+	tasks = newTasks()
+	require.NoError(t, expandTaskResponses(ctx, users, projects, ParseTaskExpand("assignee,creator,project"), tasks...))
+	require.Len(t, users.queries, 1)
+	assert.ElementsMatch(t, []uint{1, 2, 3}, users.queries[0])
+	assert.Equal(t, 1, projects.batches)
+
+	assert.Equal(t, &TaskUserSummary{ID: 2, RealName: "李四", Avatar: "b.png"}, tasks[0].Assignee)
+	assert.Equal(t, &TaskUserSummary{ID: 1, RealName: "张三"}, tasks[0].Creator)
+	assert.Equal(t, &TaskProjectSummary{ID: 7, Name: "官网改版"}, tasks[0].Project)
+	assert.Nil(t, tasks[1].Assignee)
+	assert.Nil(t, tasks[1].Project)
+	// 用户不存在时不填充
+	assert.Nil(t, tasks[2].Creator)
+}
@@ -0,0 +1,256 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+	"taskmanage/pkg/logger"
+)
+
+var (
+	// ErrInvalidTaskLabel 任务标签不合法
+	ErrInvalidTaskLabel = errors.New("任务标签不合法")
+	// ErrTaskLabelExists 同名任务标签已存在
+	ErrTaskLabelExists = errors.New("任务标签已存在")
+)
+
+const (
+	// defaultTaskLabelColor 未指定颜色时使用的标签颜色
+	defaultTaskLabelColor = "#808080"
+	// maxBulkTaskLabelTasks 批量添加标签单次最多处理的任务数
+	maxBulkTaskLabelTasks = 500
+)
+
+// taskLabelColorPattern 标签颜色格式#RRGGBB
+var taskLabelColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// CreateTaskLabelRequest 创建任务标签请求，名称不区分大小写唯一
+type CreateTaskLabelRequest struct {
+	Name  string `json:"name" binding:"required,max=50"`
+	Color string `json:"color"` // #RRGGBB，默认#808080
+}
+
+// SetTaskLabelsRequest 设置任务标签请求，整体替换任务的标签，空列表清除全部标签
+type SetTaskLabelsRequest struct {
+	LabelIDs []uint `json:"label_ids"`
+}
+
+// BulkTaskLabelRequest 批量为任务添加标签请求
+type BulkTaskLabelRequest struct {
+	LabelID uint   `json:"label_id" binding:"required"`
+	TaskIDs []uint `json:"task_ids" binding:"required,min=1"`
+}
+
+// TaskLabelResponse 任务标签响应，TaskCount仅在标签列表中返回
+type TaskLabelResponse struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	Color     string    `json:"color"`
+	CreatorID uint      `json:"creator_id"`
+	TaskCount *int64    `json:"task_count,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// BulkTaskLabelResponse 批量添加标签结果，Attached不含原本已带有该标签的任务
+type BulkTaskLabelResponse struct {
+	LabelID  uint  `json:"label_id"`
+	Tasks    int   `json:"tasks"`
+	Attached int64 `json:"attached"`
+}
+
+// DeleteTaskLabelResponse 删除标签结果
+type DeleteTaskLabelResponse struct {
+	LabelID       uint  `json:"label_id"`
+	DetachedTasks int64 `json:"detached_tasks"`
+}
+
+// TaskLabelService 任务标签服务
+type TaskLabelService interface {
+	// ListLabels 获取全部标签及各标签关联的任务数，按名称排序
+	ListLabels(ctx context.Context) ([]*TaskLabelResponse, error)
+	// CreateLabel 创建标签
+	CreateLabel(ctx context.Context, creatorID uint, req *CreateTaskLabelRequest) (*TaskLabelResponse, error)
+	// DeleteLabel 删除标签并解除与全部任务的关联
+	DeleteLabel(ctx context.Context, labelID uint) (*DeleteTaskLabelResponse, error)
+	// SetTaskLabels 整体替换任务的标签，返回替换后的标签
+	SetTaskLabels(ctx context.Context, taskID uint, req *SetTaskLabelsRequest) ([]*TaskLabelResponse, error)
+	// BulkAttach 在一个事务内为多个任务添加同一标签，任一任务不存在时不做任何修改
+	BulkAttach(ctx context.Context, req *BulkTaskLabelRequest) (*BulkTaskLabelResponse, error)
+}
+
+// taskLabelService 任务标签服务实现
+type taskLabelService struct {
+	repoManager repository.RepositoryManager
+}
+
+// NewTaskLabelService 创建任务标签服务
+func NewTaskLabelService(repoManager repository.RepositoryManager) TaskLabelService {
+	return &taskLabelService{repoManager: repoManager}
+}
+
+// ListLabels 获取全部标签及各标签关联的任务数
+func (s *taskLabelService) ListLabels(ctx context.Context) ([]*TaskLabelResponse, error) {
+	counts, err := s.repoManager.TaskLabelRepository().ListWithTaskCounts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取任务标签列表失败: %w", err)
+	}
+	labels := make([]*TaskLabelResponse, 0, len(counts))
+	for _, count := range counts {
+		label := toTaskLabelResponse(&count.TaskLabel)
+		taskCount := count.TaskCount
+		label.TaskCount = &taskCount
+		labels = append(labels, label)
+	}
+	return labels, nil
+}
+
+// CreateLabel 创建标签，名称不能包含逗号以便在任务列表中按逗号分隔过滤
+func (s *taskLabelService) CreateLabel(ctx context.Context, creatorID uint, req *CreateTaskLabelRequest) (*TaskLabelResponse, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || strings.Contains(name, ",") {
+		return nil, fmt.Errorf("%w: 标签名称不能为空或包含逗号", ErrInvalidTaskLabel)
+	}
+	color := strings.ToLower(strings.TrimSpace(req.Color))
+	if color == "" {
+		color = defaultTaskLabelColor
+	}
+	if !taskLabelColorPattern.MatchString(color) {
+		return nil, fmt.Errorf("%w: 颜色须为#RRGGBB格式", ErrInvalidTaskLabel)
+	}
+
+	labelRepo := s.repoManager.TaskLabelRepository()
+	if _, err := labelRepo.GetByName(ctx, name); err == nil {
+		return nil, fmt.Errorf("%w: %s", ErrTaskLabelExists, name)
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("检查任务标签名称失败: %w", err)
+	}
+
+	label := &database.TaskLabel{Name: name, Color: color, CreatorID: creatorID}
+	if err := labelRepo.Create(ctx, label); err != nil {
+		return nil, err
+	}
+	logger.FromContext(ctx).Infof("任务标签创建成功: ID=%d, Name=%s", label.ID, label.Name)
+	return toTaskLabelResponse(label), nil
+}
+
+// DeleteLabel 删除标签并解除与全部任务的关联
+func (s *taskLabelService) DeleteLabel(ctx context.Context, labelID uint) (*DeleteTaskLabelResponse, error) {
+	detached, err := s.repoManager.TaskLabelRepository().Delete(ctx, labelID)
+	if err != nil {
+		return nil, err
+	}
+	return &DeleteTaskLabelResponse{LabelID: labelID, DetachedTasks: detached}, nil
+}
+
+// SetTaskLabels 整体替换任务的标签，任一标签不存在时不做修改
+func (s *taskLabelService) SetTaskLabels(ctx context.Context, taskID uint, req *SetTaskLabelsRequest) ([]*TaskLabelResponse, error) {
+	labelIDs := uniqueIDs(req.LabelIDs)
+	var labels []*database.TaskLabel
+	err := s.repoManager.WithTx(ctx, func(ctx context.Context, repos repository.RepositoryManager) error {
+		if _, err := repos.TaskRepository().GetByID(ctx, taskID); err != nil {
+			return fmt.Errorf("获取任务失败: %w", err)
+		}
+		var err error
+		if labels, err = repos.TaskLabelRepository().GetByIDs(ctx, labelIDs); err != nil {
+			return err
+		}
+		if len(labels) != len(labelIDs) {
+			return fmt.Errorf("%w: 标签%v不存在", ErrInvalidTaskLabel, missingLabelIDs(labelIDs, labels))
+		}
+		return repos.TaskLabelRepository().ReplaceTaskLabels(ctx, taskID, labelIDs)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*TaskLabelResponse, 0, len(labels))
+	for _, label := range labels {
+		responses = append(responses, toTaskLabelResponse(label))
+	}
+	return responses, nil
+}
+
+// BulkAttach 在一个事务内为多个任务添加同一标签
+func (s *taskLabelService) BulkAttach(ctx context.Context, req *BulkTaskLabelRequest) (*BulkTaskLabelResponse, error) {
+	taskIDs := uniqueIDs(req.TaskIDs)
+	if len(taskIDs) > maxBulkTaskLabelTasks {
+		return nil, fmt.Errorf("%w: 单次最多处理%d个任务", ErrInvalidTaskLabel, maxBulkTaskLabelTasks)
+	}
+
+	result := &BulkTaskLabelResponse{LabelID: req.LabelID, Tasks: len(taskIDs)}
+	err := s.repoManager.WithTx(ctx, func(ctx context.Context, repos repository.RepositoryManager) error {
+		if _, err := repos.TaskLabelRepository().GetByID(ctx, req.LabelID); err != nil {
+			return fmt.Errorf("获取任务标签失败: %w", err)
+		}
+		missing, err := repos.TaskRepository().FindMissingIDs(ctx, taskIDs)
+		if err != nil {
+			return err
+		}
+		if len(missing) > 0 {
+			return fmt.Errorf("%w: 任务%v不存在", ErrInvalidTaskLabel, missing)
+		}
+		result.Attached, err = repos.TaskLabelRepository().AttachToTasks(ctx, req.LabelID, taskIDs)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	logger.FromContext(ctx).Infof("批量添加任务标签成功: LabelID=%d, Tasks=%d, Attached=%d", req.LabelID, result.Tasks, result.Attached)
+	return result, nil
+}
+
+// uniqueIDs 去除重复ID，保持原有顺序
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	unique := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
+
+// missingLabelIDs 返回未查到的标签ID
+func missingLabelIDs(ids []uint, labels []*database.TaskLabel) []uint {
+	found := make(map[uint]bool, len(labels))
+	for _, label := range labels {
+		found[label.ID] = true
+	}
+	var missing []uint
+	for _, id := range ids {
+		if !found[id] {
+			missing = append(missing, id)
+		}
+	}
+	return missing
+}
+
+// splitTaskLabelNames 拆分逗号分隔的标签名称，忽略空项
+func splitTaskLabelNames(labels string) []string {
+	var names []string
+	for _, name := range strings.Split(labels, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// toTaskLabelResponse 转换任务标签响应
+func toTaskLabelResponse(label *database.TaskLabel) *TaskLabelResponse {
+	return &TaskLabelResponse{
+		ID:        label.ID,
+		Name:      label.Name,
+		Color:     label.Color,
+		CreatorID: label.CreatorID,
+		CreatedAt: label.CreatedAt,
+	}
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+// fakeTaskLabelRepository 测试用内存任务标签仓储
+type fakeTaskLabelRepository struct {
+	repository.TaskLabelRepository
+	labels   map[uint]*database.TaskLabel
+	replaced map[uint][]uint
+	attached []uint
+}
+
+func (f *fakeTaskLabelRepository) GetByID(ctx context.Context, id uint) (*database.TaskLabel, error) {
+	label, ok := f.labels[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return label, nil
+}
+
+func (f *fakeTaskLabelRepository) GetByName(ctx context.Context, name string) (*database.TaskLabel, error) {
+	for _, label := range f.labels {
+		if label.Name == name {
+			return label, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (f *fakeTaskLabelRepository) GetByIDs(ctx context.Context, ids []uint) ([]*database.TaskLabel, error) {
+	var labels []*database.TaskLabel
+	for _, id := range ids {
+		if label, ok := f.labels[id]; ok {
+			labels = append(labels, label)
+		}
+	}
+	return labels, nil
+}
+
+func (f *fakeTaskLabelRepository) ReplaceTaskLabels(ctx context.Context, taskID uint, labelIDs []uint) error {
+	f.replaced[taskID] = labelIDs
+	return nil
+}
+
+func (f *fakeTaskLabelRepository) AttachToTasks(ctx context.Context, labelID uint, taskIDs []uint) (int64, error) {
+	f.attached = append(f.attached, taskIDs...)
+	return int64(len(taskIDs)), nil
+}
+
+// labelTaskRepository 只包含固定任务的任务仓储
+type labelTaskRepository struct {
+	repository.TaskRepository
+	tasks map[uint]bool
+}
+
+func (r *labelTaskRepository) GetByID(ctx context.Context, id uint) (*database.Task, error) {
+	if !r.tasks[id] {
+		return nil, repository.ErrNotFound
+	}
+	task := &database.Task{}
+	task.ID = id
+	return task, nil
+}
+
+func (r *labelTaskRepository) FindMissingIDs(ctx context.Context, taskIDs []uint) ([]uint, error) {
+	var missing []uint
+	for _, id := range taskIDs {
+		if !r.tasks[id] {
+			missing = append(missing, id)
+		}
+	}
+	return missing, nil
+}
+
+// taskLabelRepoManager 任务标签测试用仓储管理器
+type taskLabelRepoManager struct {
+	repository.RepositoryManager
+	labels *fakeTaskLabelRepository
+	tasks  *labelTaskRepository
+}
+
+func (m *taskLabelRepoManager) TaskLabelRepository() repository.TaskLabelRepository {
+	return m.labels
+}
+
+func (m *taskLabelRepoManager) TaskRepository() repository.TaskRepository {
+	return m.tasks
+}
+
+func (m *taskLabelRepoManager) WithTx(ctx context.Context, fn func(ctx context.Context, repos repository.RepositoryManager) error) error {
+	return fn(ctx, m)
+}
+
+func newTaskLabelRepoManager() *taskLabelRepoManager {
+	label := &database.TaskLabel{ID: 1, Name: "tech-debt", Color: "#808080"}
+	return &taskLabelRepoManager{
+		labels: &fakeTaskLabelRepository{labels: map[uint]*database.TaskLabel{1: label}, replaced: map[uint][]uint{}},
+		tasks:  &labelTaskRepository{tasks: map[uint]bool{10: true, 11: true}},
+	}
+}
+
+func TestTaskLabelService_CreateLabel(t *testing.T) {
+	svc := NewTaskLabelService(newTaskLabelRepoManager())
+
+	_, err := svc.CreateLabel(context.Background(), 1, &CreateTaskLabelRequest{Name: "tech-debt"})
+	assert.ErrorIs(t, err, ErrTaskLabelExists)
+	_, err = svc.CreateLabel(context.Background(), 1, &CreateTaskLabelRequest{Name: "a,b"})
+	assert.ErrorIs(t, err, ErrInvalidTaskLabel)
+	_, err = svc.CreateLabel(context.Background(), 1, &CreateTaskLabelRequest{Name: "q3-okr", Color: "red"})
+	assert.ErrorIs(t, err, ErrInvalidTaskLabel)
+}
+
+func TestTaskLabelService_SetAndBulkAttach(t *testing.T) {
+	repos := newTaskLabelRepoManager()
+	svc := NewTaskLabelService(repos)
+	ctx := context.Background()
+
+	// 任一标签不存在时不做修改
+	_, err := svc.SetTaskLabels(ctx, 10, &SetTaskLabelsRequest{LabelIDs: []uint{1, 2}})
+	assert.ErrorIs(t, err, ErrInvalidTaskLabel)
+	assert.Empty(t, repos.labels.replaced)
+
+	labels, err := svc.SetTaskLabels(ctx, 10, &SetTaskLabelsRequest{LabelIDs: []uint{1, 1}})
+	require.NoError(t, err)
+	require.Len(t, labels, 1)
+	assert.Equal(t, []uint{1}, repos.labels.replaced[10])
+
+	_, err = svc.SetTaskLabels(ctx, 99, &SetTaskLabelsRequest{})
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	// 任一任务不存在时不做修改
+	_, err = svc.BulkAttach(ctx, &BulkTaskLabelRequest{LabelID: 1, TaskIDs: []uint{10, 12}})
+	assert.ErrorIs(t, err, ErrInvalidTaskLabel)
+	assert.Contains(t, err.Error(), "[12]")
+	assert.Empty(t, repos.labels.attached)
+
+	result, err := svc.BulkAttach(ctx, &BulkTaskLabelRequest{LabelID: 1, TaskIDs: []uint{10, 11, 10}})
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Tasks)
+	assert.Equal(t, []uint{10, 11}, repos.labels.attached)
+}
+
+func TestTaskFilterConditions_Labels(t *testing.T) {
+	conditions := taskFilterConditions(TaskListFilter{Labels: " tech-debt, ,Q3-okr ", Any: true})
+	assert.Equal(t, repository.TaskLabelFilter{Names: []string{"tech-debt", "Q3-okr"}, MatchAny: true}, conditions["labels"])
+
+	conditions = taskFilterConditions(TaskListFilter{Labels: " , "})
+	assert.NotContains(t, conditions, "labels")
+}
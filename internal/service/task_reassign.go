@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/models"
+	"taskmanage/internal/repository"
+	"taskmanage/internal/workflow"
+	"taskmanage/pkg/logger"
+	"taskmanage/pkg/i18n"
+	"taskmanage/pkg/response"
+)
+
+// assignmentMethodReassign 重新分配记录的分配方式
+const assignmentMethodReassign = "reassign"
+
+var (
+	// ErrTaskNotReassignable 只有已分配或进行中的任务才能重新分配
+	ErrTaskNotReassignable = response.NewError(response.ErrCodeTaskStatusInvalid, "当前任务状态不允许重新分配")
+	// ErrAssigneeMismatch 原负责人与任务当前负责人不一致
+	ErrAssigneeMismatch = response.NewError(response.ErrCodeInvalidRequest, "原负责人不是任务的当前负责人")
+	// ErrEmployeeUnavailable 目标员工不能接收任务
+	ErrEmployeeUnavailable = response.NewError(response.ErrCodeEmployeeNotAvailable, "目标员工不可分配")
+)
+
+// ReassignTask 将已分配或进行中的任务转交给其他员工
+// 配置了工作流服务时先走任务分配审批，审批通过后由CompleteTaskAssignmentWorkflow完成转交
+func (s *taskServiceRepo) ReassignTask(ctx context.Context, taskID uint, req *ReassignTaskRequest) (*AssignmentResponse, error) {
+	if req.FromEmployeeID == req.ToEmployeeID {
+		return nil, response.Wrapf(ErrEmployeeUnavailable, "新负责人与原负责人相同")
+	}
+
+	task, err := s.taskRepo.GetByID(ctx, taskID)
+	if err != nil {
+		logger.FromContext(ctx).Errorf("获取任务失败: %v", err)
+		return nil, fmt.Errorf("获取任务失败: %w", err)
+	}
+	if err := checkReassignable(task); err != nil {
+		return nil, err
+	}
+
+	fromEmployee, err := s.employeeRepo.GetByID(ctx, req.FromEmployeeID)
+	if err != nil {
+		return nil, fmt.Errorf("原负责人不存在或获取失败: %w", err)
+	}
+	if task.AssigneeID == nil || *task.AssigneeID != fromEmployee.UserID {
+		return nil, ErrAssigneeMismatch
+	}
+
+	toEmployee, err := s.employeeRepo.GetByID(ctx, req.ToEmployeeID)
+	if err != nil {
+		return nil, fmt.Errorf("员工不存在或获取失败: %w", err)
+	}
+	if err := checkEmployeeAssignable(toEmployee); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	assignment := &database.Assignment{
+		TaskID:     taskID,
+		AssigneeID: req.ToEmployeeID,
+		AssignerID: req.OperatorID,
+		Method:     assignmentMethodReassign,
+		AssignedAt: now,
+		Reason:     req.Reason,
+	}
+	resp := &AssignmentResponse{
+		TaskID:             taskID,
+		EmployeeID:         req.ToEmployeeID,
+		AssignedBy:         req.OperatorID,
+		AssignedAt:         now,
+		PreviousEmployeeID: req.FromEmployeeID,
+		StartedAt:          task.StartedAt,
+	}
+
+	// 启动任务分配审批工作流，审批期间任务仍由原负责人处理
+	if s.workflowService != nil {
+		instance, err := s.workflowService.StartTaskAssignmentApproval(ctx, &workflow.TaskAssignmentApprovalRequest{
+			TaskID:      taskID,
+			AssigneeID:  req.ToEmployeeID,
+			RequesterID: req.OperatorID,
+			Priority:    task.Priority,
+			Reason:      req.Reason,
+		})
+		if err != nil {
+			logger.FromContext(ctx).Errorf("启动任务重新分配审批工作流失败: %v", err)
+			return nil, fmt.Errorf("启动审批流程失败: %w", err)
+		}
+
+		assignment.Status = "pending_approval"
+		assignment.WorkflowInstanceID = &instance.ID
+		if err := s.assignmentRepo.Create(ctx, assignment); err != nil {
+			return nil, fmt.Errorf("保存分配记录失败: %w", err)
+		}
+
+		logger.FromContext(ctx).Infof("任务重新分配审批工作流已启动: TaskID=%d, From=%d, To=%d, WorkflowInstanceID=%s",
+			taskID, req.FromEmployeeID, req.ToEmployeeID, instance.ID)
+
+		resp.ID = assignment.ID
+		resp.Status = assignment.Status
+		resp.WorkflowInstanceID = instance.ID
+		resp.Comment = fmt.Sprintf("任务重新分配审批流程已启动，工作流实例ID: %s", instance.ID)
+
+		// 审批节点全部自动通过时，分配记录保存后立即完成重新分配
+		if runAutoApprovedCompletion(ctx, s.workflowService, instance) {
+			if updated, err := s.assignmentRepo.GetByID(ctx, assignment.ID); err == nil {
+				resp.Status = updated.Status
+			}
+			resp.Comment = fmt.Sprintf("任务重新分配审批已自动通过，工作流实例ID: %s", instance.ID)
+		}
+		return resp, nil
+	}
+
+	if err := s.applyReassignment(ctx, task, fromEmployee, toEmployee, req.Reason); err != nil {
+		return nil, err
+	}
+
+	assignment.Status = "approved"
+	assignment.ApprovedAt = &now
+	if err := s.assignmentRepo.Create(ctx, assignment); err != nil {
+		return nil, fmt.Errorf("保存分配记录失败: %w", err)
+	}
+
+	logger.FromContext(ctx).Infof("任务重新分配成功: TaskID=%d, From=%d, To=%d", taskID, req.FromEmployeeID, req.ToEmployeeID)
+
+	resp.ID = assignment.ID
+	resp.Status = task.Status
+	resp.Comment = "任务已重新分配"
+	if task.Status == "in_progress" {
+		resp.Comment = "进行中的任务已重新分配，保留原开始时间"
+	}
+	return resp, nil
+}
+
+// completeReassignment 重新分配审批通过后转交任务，原负责人按任务当前负责人确定
+func (s *taskServiceRepo) completeReassignment(ctx context.Context, task *database.Task, assignment *database.Assignment) error {
+	if err := checkReassignable(task); err != nil {
+		return err
+	}
+	if task.AssigneeID == nil {
+		return ErrAssigneeMismatch
+	}
+
+	fromEmployee, err := s.employeeRepo.GetByUserID(ctx, *task.AssigneeID)
+	if err != nil {
+		return fmt.Errorf("获取原负责人失败: %w", err)
+	}
+	toEmployee, err := s.employeeRepo.GetByID(ctx, assignment.AssigneeID)
+	if err != nil {
+		return fmt.Errorf("获取新负责人失败: %w", err)
+	}
+
+	return s.applyReassignment(ctx, task, fromEmployee, toEmployee, assignment.Reason)
+}
+
+// applyReassignment 更新任务负责人、双方任务数并通知双方；进行中的任务保持状态和开始时间
+func (s *taskServiceRepo) applyReassignment(ctx context.Context, task *database.Task, from, to *database.Employee, reason string) error {
+	// 先占用新负责人的任务名额，并发分配时由仓储保证不超过上限
+	if err := reserveTaskSlot(ctx, s.employeeRepo, to.ID); err != nil {
+		return err
+	}
+
+	task.AssigneeID = &to.UserID
+	if err := s.taskRepo.Update(ctx, task); err != nil {
+		releaseTaskSlot(ctx, s.employeeRepo, to.ID)
+		if errors.Is(err, repository.ErrConflict) {
+			return taskConflictError(ctx, s.taskRepo, task.ID)
+		}
+		logger.FromContext(ctx).Errorf("更新任务负责人失败: %v", err)
+		return fmt.Errorf("更新任务负责人失败: %w", err)
+	}
+
+	releaseTaskSlot(ctx, s.employeeRepo, from.ID)
+	assignedBy, _ := getUserIDFromContext(ctx)
+	publishEvent(ctx, s.events, EventTaskAssigned, newTaskAssignedEvent(task, &from.UserID, assignedBy, TaskAssignMethodReassign))
+
+	if s.notificationService != nil {
+		notifications := []struct {
+			recipientID uint
+			content     string
+		}{
+			{from.UserID, fmt.Sprintf("任务「%s」已转交给其他同事，原因: %s", task.Title, reason)},
+			{to.UserID, fmt.Sprintf("任务「%s」已转交给您，原因: %s", task.Title, reason)},
+		}
+		for _, n := range notifications {
+			if err := s.notificationService.CreateTaskStatusNotification(ctx, task.ID, n.recipientID,
+				models.NotificationTypeTaskReassigned, "任务重新分配", n.content); err != nil {
+				logger.FromContext(ctx).Warnf("发送任务重新分配通知失败: %v", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkReassignable 检查任务状态是否允许重新分配
+func checkReassignable(task *database.Task) error {
+	if task.Status != "assigned" && task.Status != "in_progress" {
+		return response.Wrapf(ErrTaskNotReassignable, "%s", task.Status)
+	}
+	return nil
+}
+
+// checkEmployeeAssignable 检查员工是否可以接收新任务，规则与AssignTask一致
+func checkEmployeeAssignable(employee *database.Employee) error {
+	if employee.OnboardingStatus == EmployeeStatusOffboarding {
+		return ErrEmployeeUnavailable.Localized(msgEmployeeOffboarding, i18n.Params{"employee_id": employee.ID})
+	}
+	if employee.CurrentTasks >= employee.MaxTasks {
+		return ErrEmployeeUnavailable.Localized(msgEmployeeTaskLimitReached, i18n.Params{"current": employee.CurrentTasks, "max": employee.MaxTasks})
+	}
+	return nil
+}
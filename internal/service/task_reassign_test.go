@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/database"
+)
+
+func TestReassignTask_InProgressKeepsStartedAt(t *testing.T) {
+	ctx := context.Background()
+	startedAt := time.Now().Add(-2 * time.Hour)
+	fromUserID := uint(30)
+	task := &database.Task{BaseModel: database.BaseModel{ID: 1}, Status: "in_progress", AssigneeID: &fromUserID, StartedAt: &startedAt}
+	from := &database.Employee{BaseModel: database.BaseModel{ID: 3}, UserID: 30, CurrentTasks: 2, MaxTasks: 5}
+	to := &database.Employee{BaseModel: database.BaseModel{ID: 5}, UserID: 50, CurrentTasks: 1, MaxTasks: 5}
+
+	taskRepo := new(MockTaskRepository)
+	employeeRepo := new(MockEmployeeRepository)
+	assignmentRepo := new(MockAssignmentRepository)
+	taskRepo.On("GetByID", ctx, uint(1)).Return(task, nil)
+	taskRepo.On("Update", ctx, task).Return(nil)
+	employeeRepo.On("GetByID", ctx, uint(3)).Return(from, nil)
+	employeeRepo.On("GetByID", ctx, uint(5)).Return(to, nil)
+	employeeRepo.On("UpdateTaskCount", ctx, uint(3), -1).Return(nil)
+	employeeRepo.On("UpdateTaskCount", ctx, uint(5), 1).Return(nil)
+	assignmentRepo.On("Create", ctx, mock.MatchedBy(func(a *database.Assignment) bool {
+		return a.Method == "reassign" && a.AssigneeID == 5 && a.Reason == "请假" && a.Status == "approved"
+	})).Return(nil)
+
+	svc := &taskServiceRepo{taskRepo: taskRepo, employeeRepo: employeeRepo, assignmentRepo: assignmentRepo}
+	resp, err := svc.ReassignTask(ctx, 1, &ReassignTaskRequest{FromEmployeeID: 3, ToEmployeeID: 5, Reason: "请假", OperatorID: 9})
+	require.NoError(t, err)
+
+	assert.Equal(t, "in_progress", task.Status)
+	assert.Equal(t, uint(50), *task.AssigneeID)
+	assert.Equal(t, &startedAt, task.StartedAt)
+	assert.Equal(t, "in_progress", resp.Status)
+	assert.Equal(t, uint(3), resp.PreviousEmployeeID)
+	assert.Equal(t, &startedAt, resp.StartedAt)
+	taskRepo.AssertExpectations(t)
+	employeeRepo.AssertExpectations(t)
+	assignmentRepo.AssertExpectations(t)
+}
+
+func TestReassignTask_Validation(t *testing.T) {
+	ctx := context.Background()
+	fromUserID := uint(30)
+	from := &database.Employee{BaseModel: database.BaseModel{ID: 3}, UserID: 30}
+	other := &database.Employee{BaseModel: database.BaseModel{ID: 4}, UserID: 40}
+	full := &database.Employee{BaseModel: database.BaseModel{ID: 5}, UserID: 50, CurrentTasks: 3, MaxTasks: 3}
+
+	taskRepo := new(MockTaskRepository)
+	employeeRepo := new(MockEmployeeRepository)
+	taskRepo.On("GetByID", ctx, uint(1)).Return(&database.Task{Status: "pending"}, nil)
+	taskRepo.On("GetByID", ctx, uint(2)).Return(&database.Task{Status: "assigned", AssigneeID: &fromUserID}, nil)
+	employeeRepo.On("GetByID", ctx, uint(3)).Return(from, nil)
+	employeeRepo.On("GetByID", ctx, uint(4)).Return(other, nil)
+	employeeRepo.On("GetByID", ctx, uint(5)).Return(full, nil)
+	svc := &taskServiceRepo{taskRepo: taskRepo, employeeRepo: employeeRepo}
+
+	_, err := svc.ReassignTask(ctx, 1, &ReassignTaskRequest{FromEmployeeID: 3, ToEmployeeID: 5})
+	assert.ErrorIs(t, err, ErrTaskNotReassignable)
+
+	_, err = svc.ReassignTask(ctx, 2, &ReassignTaskRequest{FromEmployeeID: 4, ToEmployeeID: 5})
+	assert.ErrorIs(t, err, ErrAssigneeMismatch)
+
+	_, err = svc.ReassignTask(ctx, 2, &ReassignTaskRequest{FromEmployeeID: 3, ToEmployeeID: 5})
+	assert.ErrorIs(t, err, ErrEmployeeUnavailable)
+
+	_, err = svc.ReassignTask(ctx, 2, &ReassignTaskRequest{FromEmployeeID: 3, ToEmployeeID: 3})
+	assert.ErrorIs(t, err, ErrEmployeeUnavailable)
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/models"
+	"taskmanage/internal/repository"
+	"taskmanage/pkg/logger"
+	"taskmanage/pkg/response"
+)
+
+var (
+	// ErrTaskNotInReview 任务不是待审核状态
+	ErrTaskNotInReview = response.NewError(response.ErrCodeTaskStatusInvalid, "只有待审核的任务才能审核")
+	// ErrNotTaskReviewer 当前用户不是任务审核人
+	ErrNotTaskReviewer = response.NewError(response.ErrCodeForbidden, "只有任务审核人才能审核任务")
+	// ErrTaskReviewerNotFound 指定的审核人不存在
+	ErrTaskReviewerNotFound = response.NewError(response.ErrCodeInvalidRequest, "审核人不存在")
+)
+
+// taskReviewerID 任务审核人，未指定时为任务创建者
+func taskReviewerID(task *database.Task) uint {
+	if task.ReviewerID != nil {
+		return *task.ReviewerID
+	}
+	return task.CreatorID
+}
+
+// checkTaskReviewer 校验审核人用户存在
+func (s *taskServiceRepo) checkTaskReviewer(ctx context.Context, reviewerID uint) error {
+	if _, err := s.userRepo.GetByID(ctx, reviewerID); err != nil {
+		if repository.IsNotFoundError(err) {
+			return ErrTaskReviewerNotFound
+		}
+		return fmt.Errorf("获取审核人失败: %w", err)
+	}
+	return nil
+}
+
+// submitTaskForReview 将任务置为待审核并通知审核人，负责人的任务名额在最终完成时才释放
+func (s *taskServiceRepo) submitTaskForReview(ctx context.Context, task *database.Task, userID uint) error {
+	task.Status = database.TaskStatusInReview
+	if err := s.taskRepo.Update(ctx, task); err != nil {
+		logger.FromContext(ctx).Errorf("更新任务状态失败: %v", err)
+		return fmt.Errorf("更新任务状态失败: %w", err)
+	}
+
+	logger.FromContext(ctx).Infof("任务已提交审核: TaskID=%d, UserID=%d, ReviewerID=%d", task.ID, userID, taskReviewerID(task))
+	s.notifyTaskUser(ctx, task, taskReviewerID(task), models.NotificationTypeTaskReviewRequested, "任务待审核",
+		fmt.Sprintf("任务「%s」已提交完成，等待您审核", task.Title))
+	return nil
+}
+
+// finishTaskCompletion 完成任务：汇总实际工时、释放负责人任务名额并通知关注者，逐级检查自动完成的父任务
+func (s *taskServiceRepo) finishTaskCompletion(ctx context.Context, task *database.Task, userID uint) error {
+	// 汇总工时记录作为实际工时
+	totalMinutes, err := s.timeEntryRepo.SumMinutesByTask(ctx, task.ID)
+	if err != nil {
+		logger.FromContext(ctx).Errorf("统计任务工时失败: %v", err)
+		return fmt.Errorf("统计任务工时失败: %w", err)
+	}
+	task.ActualHours = minutesToHours(totalMinutes)
+
+	// 更新任务状态
+	now := time.Now()
+	task.Status = database.TaskStatusCompleted
+	task.CompletedAt = &now
+
+	if err := s.taskRepo.Update(ctx, task); err != nil {
+		logger.FromContext(ctx).Errorf("更新任务状态失败: %v", err)
+		return fmt.Errorf("更新任务状态失败: %w", err)
+	}
+
+	// 更新员工当前任务数
+	if task.AssigneeID != nil {
+		employee, err := s.employeeRepo.GetByUserID(ctx, *task.AssigneeID)
+		if err == nil && employee != nil {
+			releaseTaskSlot(ctx, s.employeeRepo, employee.ID)
+		}
+	}
+
+	logger.FromContext(ctx).Infof("任务完成成功: TaskID=%d, UserID=%d", task.ID, userID)
+	publishEvent(ctx, s.events, EventTaskCompleted, newTaskEvent(task))
+	s.notifyTaskWatchers(ctx, task, userID, models.NotificationTypeTaskCompleted, "任务已完成", fmt.Sprintf("任务「%s」已完成", task.Title))
+	s.completeParentsIfDone(ctx, task, userID)
+	return nil
+}
+
+// ApproveTaskReview 审核通过待审核的任务，任务完成
+func (s *taskServiceRepo) ApproveTaskReview(ctx context.Context, taskID uint, req *ApproveTaskReviewRequest) (*TaskResponse, error) {
+	task, err := s.taskInReview(ctx, taskID, req.ReviewerID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.finishTaskCompletion(ctx, task, req.ReviewerID); err != nil {
+		return nil, err
+	}
+
+	content := fmt.Sprintf("任务「%s」已审核通过", task.Title)
+	if req.Comment != "" {
+		content += "，审核意见: " + req.Comment
+	}
+	s.notifyTaskAssignee(ctx, task, "任务审核通过", content)
+	return TaskToResponse(task), nil
+}
+
+// RejectTaskReview 驳回待审核的任务，任务退回进行中，驳回意见记录为任务评论
+func (s *taskServiceRepo) RejectTaskReview(ctx context.Context, taskID uint, req *RejectTaskReviewRequest) (*TaskResponse, error) {
+	task, err := s.taskInReview(ctx, taskID, req.ReviewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	task.Status = database.TaskStatusInProgress
+	if err := s.taskRepo.Update(ctx, task); err != nil {
+		logger.FromContext(ctx).Errorf("更新任务状态失败: %v", err)
+		return nil, fmt.Errorf("更新任务状态失败: %w", err)
+	}
+	if s.taskCommentRepo != nil {
+		comment := &database.TaskComment{TaskID: task.ID, UserID: req.ReviewerID, Content: "审核驳回: " + req.Comment}
+		if err := s.taskCommentRepo.Create(ctx, comment); err != nil {
+			logger.FromContext(ctx).Warnf("记录审核驳回意见失败: TaskID=%d, error: %v", task.ID, err)
+		}
+	}
+
+	logger.FromContext(ctx).Infof("任务审核驳回: TaskID=%d, ReviewerID=%d", task.ID, req.ReviewerID)
+	s.notifyTaskAssignee(ctx, task, "任务审核驳回", fmt.Sprintf("任务「%s」审核未通过，已退回进行中，意见: %s", task.Title, req.Comment))
+	return TaskToResponse(task), nil
+}
+
+// taskInReview 获取待审核的任务并校验审核人
+func (s *taskServiceRepo) taskInReview(ctx context.Context, taskID, reviewerID uint) (*database.Task, error) {
+	task, err := s.taskRepo.GetByID(ctx, taskID)
+	if err != nil {
+		return nil, taskLookupError(err)
+	}
+	if task.Status != database.TaskStatusInReview {
+		return nil, ErrTaskNotInReview
+	}
+	if taskReviewerID(task) != reviewerID {
+		return nil, ErrNotTaskReviewer
+	}
+	return task, nil
+}
+
+// notifyTaskAssignee 将审核结果通知任务负责人
+func (s *taskServiceRepo) notifyTaskAssignee(ctx context.Context, task *database.Task, title, content string) {
+	if task.AssigneeID == nil {
+		return
+	}
+	s.notifyTaskUser(ctx, task, *task.AssigneeID, models.NotificationTypeTaskReviewed, title, content)
+}
+
+// notifyTaskUser 向单个用户发送任务通知，失败只记录日志
+func (s *taskServiceRepo) notifyTaskUser(ctx context.Context, task *database.Task, recipientID uint, notificationType models.TaskNotificationType, title, content string) {
+	if s.notificationService == nil {
+		return
+	}
+	if err := s.notificationService.CreateTaskStatusNotification(ctx, task.ID, recipientID, notificationType, title, content); err != nil {
+		logger.FromContext(ctx).Warnf("发送任务通知失败: TaskID=%d, UserID=%d, error: %v", task.ID, recipientID, err)
+	}
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/models"
+)
+
+func TestTaskReview_CompleteRejectApprove(t *testing.T) {
+	ctx := context.Background()
+	assigneeID, reviewerID := uint(20), uint(30)
+	task := &database.Task{BaseModel: database.BaseModel{ID: 1}, Title: "客户报告", Status: "in_progress", CreatorID: 10,
+		AssigneeID: &assigneeID, RequiresReview: true, ReviewerID: &reviewerID}
+
+	taskRepo := new(MockTaskRepository)
+	taskRepo.On("GetByID", ctx, uint(1)).Return(task, nil)
+	taskRepo.On("Update", ctx, mock.AnythingOfType("*database.Task")).Return(nil)
+	employeeRepo := new(MockEmployeeRepository)
+	employeeRepo.On("GetByUserID", ctx, assigneeID).Return(&database.Employee{BaseModel: database.BaseModel{ID: 5}, UserID: assigneeID}, nil)
+	employeeRepo.On("UpdateTaskCount", ctx, uint(5), -1).Return(nil)
+	comments := &memoryTaskCommentRepository{}
+	notifier := &watcherNotificationService{}
+	svc := &taskServiceRepo{taskRepo: taskRepo, employeeRepo: employeeRepo, timeEntryRepo: &fakeTimeEntryRepository{},
+		taskCommentRepo: comments, taskWatcherRepo: &memoryTaskWatcherRepository{}, notificationService: notifier}
+
+	// 负责人提交完成后进入待审核，不释放任务名额，通知审核人
+	resp, err := svc.CompleteTask(ctx, 1, assigneeID, &CompleteTaskRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, database.TaskStatusInReview, resp.Status)
+	assert.Nil(t, task.CompletedAt)
+	employeeRepo.AssertNotCalled(t, "UpdateTaskCount", mock.Anything, mock.Anything, mock.Anything)
+	assert.Equal(t, []uint{reviewerID}, notifier.snapshot())
+	assert.Equal(t, models.NotificationTypeTaskReviewRequested, notifier.types[0])
+
+	// 只有审核人可以审核
+	_, err = svc.ApproveTaskReview(ctx, 1, &ApproveTaskReviewRequest{ReviewerID: 10})
+	assert.ErrorIs(t, err, ErrNotTaskReviewer)
+
+	// 驳回后退回进行中，意见记录为评论并通知负责人
+	resp, err = svc.RejectTaskReview(ctx, 1, &RejectTaskReviewRequest{Comment: "缺少附录", ReviewerID: reviewerID})
+	require.NoError(t, err)
+	assert.Equal(t, database.TaskStatusInProgress, resp.Status)
+	require.Len(t, comments.comments, 1)
+	assert.Equal(t, "审核驳回: 缺少附录", comments.comments[0].Content)
+	assert.Equal(t, []uint{reviewerID, assigneeID}, notifier.snapshot())
+	assert.Equal(t, models.NotificationTypeTaskReviewed, notifier.types[1])
+
+	_, err = svc.RejectTaskReview(ctx, 1, &RejectTaskReviewRequest{Comment: "重复驳回", ReviewerID: reviewerID})
+	assert.ErrorIs(t, err, ErrTaskNotInReview)
+
+	// 再次提交并审核通过后才完成并释放任务名额
+	_, err = svc.CompleteTask(ctx, 1, assigneeID, &CompleteTaskRequest{})
+	require.NoError(t, err)
+	resp, err = svc.ApproveTaskReview(ctx, 1, &ApproveTaskReviewRequest{ReviewerID: reviewerID})
+	require.NoError(t, err)
+	assert.Equal(t, database.TaskStatusCompleted, resp.Status)
+	assert.NotNil(t, task.CompletedAt)
+	employeeRepo.AssertNumberOfCalls(t, "UpdateTaskCount", 1)
+}
+
+func TestTaskReview_DefaultsToCreator(t *testing.T) {
+	ctx := context.Background()
+	assigneeID := uint(20)
+	task := &database.Task{BaseModel: database.BaseModel{ID: 1}, Status: "in_review", CreatorID: 10, AssigneeID: &assigneeID, RequiresReview: true}
+	taskRepo := new(MockTaskRepository)
+	taskRepo.On("GetByID", ctx, uint(1)).Return(task, nil)
+	taskRepo.On("Update", ctx, mock.AnythingOfType("*database.Task")).Return(nil)
+	svc := &taskServiceRepo{taskRepo: taskRepo}
+
+	_, err := svc.RejectTaskReview(ctx, 1, &RejectTaskReviewRequest{Comment: "重做", ReviewerID: assigneeID})
+	assert.ErrorIs(t, err, ErrNotTaskReviewer)
+	resp, err := svc.RejectTaskReview(ctx, 1, &RejectTaskReviewRequest{Comment: "重做", ReviewerID: 10})
+	require.NoError(t, err)
+	assert.Equal(t, database.TaskStatusInProgress, resp.Status)
+}
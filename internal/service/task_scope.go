@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+	"taskmanage/pkg/response"
+)
+
+// 任务可见范围，对应权限模板的task_scope，范围依次放宽
+const (
+	TaskScopeAssigned = "assigned" // 只看分配给自己的任务
+	TaskScopeCreated  = "created"  // 另可看自己创建的任务
+	TaskScopeTeam     = "team"     // 另可看负责人与自己同部门的任务
+	TaskScopeAll      = "all"      // 不限制
+)
+
+// taskScopeRanks 任务可见范围的宽窄顺序，多个模板时取最宽的范围
+var taskScopeRanks = map[string]int{
+	TaskScopeAssigned: 1,
+	TaskScopeCreated:  2,
+	TaskScopeTeam:     3,
+	TaskScopeAll:      4,
+}
+
+// taskScopeAdminRoles 不受任务可见范围限制的用户角色
+var taskScopeAdminRoles = map[string]bool{"admin": true, "super_admin": true}
+
+// ErrTaskOutOfScope 任务不在当前用户的可见范围内
+var ErrTaskOutOfScope = response.NewError(response.ErrCodeForbidden, "无权查看该任务")
+
+// TaskVisibility 用户的任务可见范围
+type TaskVisibility struct {
+	Scope        string
+	UserID       uint
+	DepartmentID *uint // 团队范围使用的部门
+	// HideOutOfScope 为true时范围外的任务按不存在处理，避免暴露任务是否存在
+	HideOutOfScope bool
+}
+
+// Unrestricted 是否不限制可见范围
+func (v *TaskVisibility) Unrestricted() bool {
+	return v == nil || v.Scope == TaskScopeAll
+}
+
+// repositoryFilter 转换为任务仓储的可见范围过滤条件
+func (v *TaskVisibility) repositoryFilter() repository.TaskVisibilityFilter {
+	filter := repository.TaskVisibilityFilter{
+		UserID:         v.UserID,
+		IncludeCreated: v.Scope != TaskScopeAssigned,
+	}
+	if v.Scope == TaskScopeTeam {
+		filter.DepartmentID = v.DepartmentID
+	}
+	return filter
+}
+
+// allows 任务是否在可见范围内，assigneeDepartmentID为负责人所在部门，负责人不属于任何部门时为nil
+func (v *TaskVisibility) allows(task *database.Task, assigneeDepartmentID *uint) bool {
+	if v.Unrestricted() {
+		return true
+	}
+	if task.AssigneeID != nil && *task.AssigneeID == v.UserID {
+		return true
+	}
+	if v.Scope != TaskScopeAssigned && task.CreatorID == v.UserID {
+		return true
+	}
+	return v.Scope == TaskScopeTeam && v.DepartmentID != nil && assigneeDepartmentID != nil && *assigneeDepartmentID == *v.DepartmentID
+}
+
+// outOfScopeError 范围外任务返回的错误
+func (v *TaskVisibility) outOfScopeError() error {
+	if v.HideOutOfScope {
+		return errTaskNotFound
+	}
+	return ErrTaskOutOfScope
+}
+
+// taskVisibilityKey 上下文中任务可见范围的键
+type taskVisibilityKey struct{}
+
+// WithTaskVisibility 返回携带任务可见范围的上下文，任务列表和详情按该范围过滤
+func WithTaskVisibility(ctx context.Context, visibility *TaskVisibility) context.Context {
+	return context.WithValue(ctx, taskVisibilityKey{}, visibility)
+}
+
+// taskVisibilityFromContext 读取上下文中的任务可见范围，未设置时返回nil表示不限制
+func taskVisibilityFromContext(ctx context.Context) *TaskVisibility {
+	visibility, _ := ctx.Value(taskVisibilityKey{}).(*TaskVisibility)
+	return visibility
+}
+
+// ResolveTaskScope 根据用户生效中的权限模板解析任务可见范围
+// 管理员不受限制；多个模板时取最宽的范围，没有模板时只能看分配给自己的任务；
+// 团队范围的用户没有员工档案或部门时退化为created范围
+func (s *PermissionAssignmentServiceImpl) ResolveTaskScope(ctx context.Context, userID uint) (*TaskVisibility, error) {
+	visibility := &TaskVisibility{Scope: TaskScopeAssigned, UserID: userID}
+
+	user, err := s.repos.UserRepository().GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("获取用户信息失败: %w", err)
+	}
+	if taskScopeAdminRoles[user.Role] {
+		visibility.Scope = TaskScopeAll
+		return visibility, nil
+	}
+
+	assignments, err := s.repos.PermissionAssignmentRepository().GetActiveByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("获取用户权限分配失败: %w", err)
+	}
+	for _, assignment := range assignments {
+		if assignment.Template == nil || !assignment.Template.IsActive {
+			continue
+		}
+		if taskScopeRanks[assignment.Template.TaskScope] > taskScopeRanks[visibility.Scope] {
+			visibility.Scope = assignment.Template.TaskScope
+		}
+	}
+
+	if visibility.Scope == TaskScopeTeam {
+		employee, err := s.repos.EmployeeRepository().GetByUserID(ctx, userID)
+		if err != nil && !repository.IsNotFoundError(err) {
+			return nil, fmt.Errorf("获取员工信息失败: %w", err)
+		}
+		if employee == nil || employee.DepartmentID == nil {
+			visibility.Scope = TaskScopeCreated
+		} else {
+			visibility.DepartmentID = employee.DepartmentID
+		}
+	}
+	return visibility, nil
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+	"taskmanage/pkg/response"
+)
+
+// scopeAssignmentRepository 返回固定生效分配的权限分配仓储
+type scopeAssignmentRepository struct {
+	repository.PermissionAssignmentRepository
+	assignments []*database.PermissionAssignment
+}
+
+func (r *scopeAssignmentRepository) GetActiveByUserID(ctx context.Context, userID uint) ([]*database.PermissionAssignment, error) {
+	return r.assignments, nil
+}
+
+// taskScopeRepoManager 任务可见范围测试用仓储管理器
+type taskScopeRepoManager struct {
+	repository.RepositoryManager
+	users       *emailUserRepository
+	assignments *scopeAssignmentRepository
+	employees   *escalationEmployeeRepository
+}
+
+func (m *taskScopeRepoManager) UserRepository() repository.UserRepository {
+	return m.users
+}
+
+func (m *taskScopeRepoManager) PermissionAssignmentRepository() repository.PermissionAssignmentRepository {
+	return m.assignments
+}
+
+func (m *taskScopeRepoManager) EmployeeRepository() repository.EmployeeRepository {
+	return m.employees
+}
+
+func scopeAssignment(taskScope string, active bool) *database.PermissionAssignment {
+	return &database.PermissionAssignment{Template: &database.PermissionTemplate{TaskScope: taskScope, IsActive: active}}
+}
+
+func TestResolveTaskScope(t *testing.T) {
+	department := uint(3)
+	repos := &taskScopeRepoManager{
+		users: &emailUserRepository{users: map[uint]*database.User{
+			1: {BaseModel: database.BaseModel{ID: 1}, Role: "admin"},
+			2: {BaseModel: database.BaseModel{ID: 2}, Role: "employee"},
+			3: {BaseModel: database.BaseModel{ID: 3}, Role: "employee"},
+		}},
+		assignments: &scopeAssignmentRepository{},
+		employees: &escalationEmployeeRepository{employees: []*database.Employee{
+			{BaseModel: database.BaseModel{ID: 20}, UserID: 2, DepartmentID: &department},
+			{BaseModel: database.BaseModel{ID: 30}, UserID: 3},
+		}},
+	}
+	svc := &PermissionAssignmentServiceImpl{repos: repos}
+	ctx := context.Background()
+
+	// 管理员不受限制
+	visibility, err := svc.ResolveTaskScope(ctx, 1)
+	require.NoError(t, err)
+	assert.True(t, visibility.Unrestricted())
+
+	// 没有模板时只能看分配给自己的任务
+	visibility, err = svc.ResolveTaskScope(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, TaskScopeAssigned, visibility.Scope)
+
+	// 多个模板取最宽的范围，停用的模板不计入
+	repos.assignments.assignments = []*database.PermissionAssignment{
+		scopeAssignment(TaskScopeCreated, true),
+		scopeAssignment(TaskScopeAll, false),
+		scopeAssignment(TaskScopeTeam, true),
+		scopeAssignment(TaskScopeAssigned, true),
+		{},
+	}
+	visibility, err = svc.ResolveTaskScope(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, &TaskVisibility{Scope: TaskScopeTeam, UserID: 2, DepartmentID: &department}, visibility)
+
+	// 团队范围的用户没有部门时退化为created
+	visibility, err = svc.ResolveTaskScope(ctx, 3)
+	require.NoError(t, err)
+	assert.Equal(t, TaskScopeCreated, visibility.Scope)
+	assert.Nil(t, visibility.DepartmentID)
+
+	_, err = svc.ResolveTaskScope(ctx, 99)
+	assert.Error(t, err)
+}
+
+func TestTaskVisibility_GetTask(t *testing.T) {
+	department, otherDepartment := uint(3), uint(4)
+	assignee, teammate, outsider := uint(5), uint(6), uint(7)
+	taskRepo := new(MockTaskRepository)
+	svc := &taskServiceRepo{taskRepo: taskRepo, employeeRepo: &escalationEmployeeRepository{employees: []*database.Employee{
+		{BaseModel: database.BaseModel{ID: 60}, UserID: teammate, DepartmentID: &department},
+		{BaseModel: database.BaseModel{ID: 70}, UserID: outsider, DepartmentID: &otherDepartment},
+	}}}
+	taskRepo.On("GetByID", mock.Anything, uint(1)).Return(&database.Task{BaseModel: database.BaseModel{ID: 1}, CreatorID: 9, AssigneeID: &teammate}, nil)
+	taskRepo.On("GetByID", mock.Anything, uint(2)).Return(&database.Task{BaseModel: database.BaseModel{ID: 2}, CreatorID: 9, AssigneeID: &outsider}, nil)
+
+	team := &TaskVisibility{Scope: TaskScopeTeam, UserID: assignee, DepartmentID: &department}
+	ctx := WithTaskVisibility(context.Background(), team)
+	task, err := taskRepo.GetByID(ctx, 1)
+	require.NoError(t, err)
+	require.NoError(t, svc.checkTaskVisible(ctx, task))
+
+	// 负责人在其他部门的任务按配置返回403或404
+	_, err = svc.GetTask(ctx, 2, TaskExpand{})
+	require.True(t, response.IsAppError(err))
+	assert.Equal(t, response.ErrCodeForbidden, response.GetAppError(err).Code)
+
+	team.HideOutOfScope = true
+	_, err = svc.GetTask(ctx, 2, TaskExpand{})
+	assert.Equal(t, response.ErrCodeTaskNotFound, response.GetAppError(err).Code)
+
+	// 创建者范围只看自己创建和负责的任务
+	created := WithTaskVisibility(context.Background(), &TaskVisibility{Scope: TaskScopeCreated, UserID: 9})
+	task, err = taskRepo.GetByID(ctx, 2)
+	require.NoError(t, err)
+	require.NoError(t, svc.checkTaskVisible(created, task))
+	assigned := WithTaskVisibility(context.Background(), &TaskVisibility{Scope: TaskScopeAssigned, UserID: 9})
+	assert.ErrorIs(t, svc.checkTaskVisible(assigned, task), ErrTaskOutOfScope)
+	assert.NoError(t, svc.checkTaskVisible(context.Background(), task))
+}
+
+func TestTaskVisibility_ListTasksFilter(t *testing.T) {
+	department := uint(3)
+	taskRepo := new(MockTaskRepository)
+	svc := &taskServiceRepo{taskRepo: taskRepo}
+	taskRepo.On("List", mock.Anything, mock.MatchedBy(func(filter repository.ListFilter) bool {
+		return filter.Filters["visibility"] == repository.TaskVisibilityFilter{UserID: 5, IncludeCreated: true, DepartmentID: &department}
+	})).Return([]*database.Task{}, int64(0), nil).Once()
+	taskRepo.On("List", mock.Anything, mock.MatchedBy(func(filter repository.ListFilter) bool {
+		_, ok := filter.Filters["visibility"]
+		return !ok
+	})).Return([]*database.Task{}, int64(0), nil).Once()
+
+	ctx := WithTaskVisibility(context.Background(), &TaskVisibility{Scope: TaskScopeTeam, UserID: 5, DepartmentID: &department})
+	_, _, err := svc.ListTasks(ctx, TaskListFilter{Page: 1, PageSize: 10})
+	require.NoError(t, err)
+
+	// all范围不加过滤条件
+	ctx = WithTaskVisibility(context.Background(), &TaskVisibility{Scope: TaskScopeAll, UserID: 5})
+	_, _, err = svc.ListTasks(ctx, TaskListFilter{Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	taskRepo.AssertExpectations(t)
+}
@@ -8,9 +8,11 @@ import (
 
 	"taskmanage/internal/assignment"
 	"taskmanage/internal/database"
+	"taskmanage/internal/models"
 	"taskmanage/internal/repository"
 	"taskmanage/internal/workflow"
 	"taskmanage/pkg/logger"
+	"taskmanage/pkg/response"
 )
 
 // getUserIDFromContext 从上下文中获取用户ID
@@ -29,23 +31,45 @@ func getUserIDFromContext(ctx context.Context) (uint, error) {
 
 // taskServiceRepo 基于Repository层的任务服务实现
 type taskServiceRepo struct {
-	taskRepo          repository.TaskRepository
-	employeeRepo      repository.EmployeeRepository
-	userRepo          repository.UserRepository
-	assignmentRepo    repository.AssignmentRepository
-	assignmentService *assignment.AssignmentService
-	workflowService   WorkflowService
+	taskRepo            repository.TaskRepository
+	employeeRepo        repository.EmployeeRepository
+	userRepo            repository.UserRepository
+	assignmentRepo      repository.AssignmentRepository
+	assignmentService   *assignment.AssignmentService
+	workflowService     WorkflowService
+	timeEntryRepo       repository.TimeEntryRepository
+	projectRepo         repository.ProjectRepository
+	skillRepo           repository.SkillRepository
+	taskWatcherRepo     repository.TaskWatcherRepository
+	taskCommentRepo     repository.TaskCommentRepository
+	notificationService NotificationService
+	events              EventPublisher // 为nil时不发布任务事件
+	autoCreateSkills    bool
+	simpleApproval      bool // 轻量审批模式：分配记录保存为待审批，不启动工作流
 }
 
 // NewTaskServiceRepo 创建基于Repository的任务服务实例
-func NewTaskService(taskRepo repository.TaskRepository, employeeRepo repository.EmployeeRepository, userRepo repository.UserRepository, assignmentRepo repository.AssignmentRepository, assignmentService *assignment.AssignmentService, workflowService WorkflowService) TaskService {
+func NewTaskService(taskRepo repository.TaskRepository, employeeRepo repository.EmployeeRepository, userRepo repository.UserRepository, assignmentRepo repository.AssignmentRepository, assignmentService *assignment.AssignmentService, workflowService WorkflowService, timeEntryRepo repository.TimeEntryRepository, projectRepo repository.ProjectRepository, skillRepo repository.SkillRepository, taskWatcherRepo repository.TaskWatcherRepository, taskCommentRepo repository.TaskCommentRepository, notificationService NotificationService, events EventPublisher, autoCreateSkills, simpleApproval bool) TaskService {
+	// 轻量审批模式下任务分配不使用工作流
+	if simpleApproval {
+		workflowService = nil
+	}
 	return &taskServiceRepo{
-		taskRepo:          taskRepo,
-		employeeRepo:      employeeRepo,
-		userRepo:          userRepo,
-		assignmentRepo:    assignmentRepo,
-		assignmentService: assignmentService,
-		workflowService:   workflowService,
+		taskRepo:            taskRepo,
+		employeeRepo:        employeeRepo,
+		userRepo:            userRepo,
+		assignmentRepo:      assignmentRepo,
+		assignmentService:   assignmentService,
+		workflowService:     workflowService,
+		timeEntryRepo:       timeEntryRepo,
+		projectRepo:         projectRepo,
+		skillRepo:           skillRepo,
+		taskWatcherRepo:     taskWatcherRepo,
+		taskCommentRepo:     taskCommentRepo,
+		notificationService: notificationService,
+		events:              events,
+		autoCreateSkills:    autoCreateSkills,
+		simpleApproval:      simpleApproval,
 	}
 }
 
@@ -59,6 +83,17 @@ func (s *taskServiceRepo) CreateTask(ctx context.Context, req *CreateTaskRequest
 		return nil, fmt.Errorf("无效的优先级")
 	}
 
+	// 解析技能要求，未知技能在创建任务前报错
+	taskSkills, skills, err := s.resolveTaskSkills(ctx, req.RequiredSkills)
+	if err != nil {
+		return nil, err
+	}
+	if req.ReviewerID != nil {
+		if err := s.checkTaskReviewer(ctx, *req.ReviewerID); err != nil {
+			return nil, err
+		}
+	}
+
 	// 创建任务对象
 	var dueDate *time.Time
 	if !req.DueDate.IsZero() {
@@ -72,51 +107,141 @@ func (s *taskServiceRepo) CreateTask(ctx context.Context, req *CreateTaskRequest
 		Status:      "pending", // 默认状态为待处理
 		DueDate:     dueDate,
 		CreatorID:   1, // 暂时硬编码，后续从JWT中获取
+
+		AutoCompleteOnChildren: req.AutoCompleteOnChildren,
+		RequiresReview:         req.RequiresReview,
+		ReviewerID:             req.ReviewerID,
 	}
 
 	// 保存任务
 	if err := s.taskRepo.Create(ctx, task); err != nil {
-		logger.Errorf("创建任务失败: %v", err)
+		logger.FromContext(ctx).Errorf("创建任务失败: %v", err)
 		return nil, fmt.Errorf("创建任务失败: %w", err)
 	}
 
-	logger.Infof("任务创建成功: ID=%d, Title=%s", task.ID, task.Title)
+	if len(taskSkills) > 0 {
+		if err := s.taskRepo.ReplaceSkills(ctx, task.ID, taskSkills); err != nil {
+			return nil, fmt.Errorf("保存任务技能要求失败: %w", err)
+		}
+	}
+
+	logger.FromContext(ctx).Infof("任务创建成功: ID=%d, Title=%s", task.ID, task.Title)
+	publishEvent(ctx, s.events, EventTaskCreated, newTaskEvent(task))
 
 	// 转换为响应格式
 	return &TaskResponse{
-		ID:          task.ID,
-		Title:       task.Title,
-		Description: task.Description,
-		Priority:    task.Priority,
-		Status:      task.Status,
-		DueDate:     task.DueDate,
-		CreatedBy:   task.CreatorID,
-		CreatedAt:   task.CreatedAt,
-		UpdatedAt:   task.UpdatedAt,
+		ID:             task.ID,
+		Title:          task.Title,
+		Description:    task.Description,
+		Priority:       task.Priority,
+		Status:         task.Status,
+		DueDate:        task.DueDate,
+		CreatedBy:      task.CreatorID,
+		CreatedAt:      task.CreatedAt,
+		UpdatedAt:      task.UpdatedAt,
+		Version:        task.Version,
+		RequiredSkills: skills,
+
+		AutoCompleteOnChildren: task.AutoCompleteOnChildren,
+		RequiresReview:         task.RequiresReview,
+		ReviewerID:             task.ReviewerID,
 	}, nil
 }
 
-// GetTask 获取任务详情
-func (s *taskServiceRepo) GetTask(ctx context.Context, taskID uint) (*TaskResponse, error) {
+// GetTask 获取任务详情，任务已归档时从归档表读取并标记archived
+func (s *taskServiceRepo) GetTask(ctx context.Context, taskID uint, expand TaskExpand) (*TaskResponse, error) {
+	resp, err := s.getTask(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	if err := expandTaskResponses(ctx, s.userRepo, s.projectRepo, expand, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// getTask 获取任务详情，不展开关联信息
+func (s *taskServiceRepo) getTask(ctx context.Context, taskID uint) (*TaskResponse, error) {
 	task, err := s.taskRepo.GetByID(ctx, taskID)
+	if repository.IsNotFoundError(err) {
+		return s.getArchivedTask(ctx, taskID)
+	}
 	if err != nil {
-		if errors.Is(err, repository.ErrNotFound) {
-			return nil, fmt.Errorf("任务不存在")
-		}
-		return nil, fmt.Errorf("查询任务失败: %w", err)
+		return nil, taskLookupError(err)
+	}
+	if err := s.checkTaskVisible(ctx, task); err != nil {
+		return nil, err
 	}
 
-	return &TaskResponse{
-		ID:          task.ID,
-		Title:       task.Title,
-		Description: task.Description,
-		Priority:    task.Priority,
-		Status:      task.Status,
-		DueDate:     task.DueDate,
-		CreatedBy:   task.CreatorID,
-		CreatedAt:   task.CreatedAt,
-		UpdatedAt:   task.UpdatedAt,
-	}, nil
+	skills, err := s.getTaskSkills(ctx, task.ID)
+	if err != nil {
+		return nil, fmt.Errorf("查询任务技能要求失败: %w", err)
+	}
+
+	resp := &TaskResponse{
+		ID:             task.ID,
+		Title:          task.Title,
+		Description:    task.Description,
+		Priority:       task.Priority,
+		Status:         task.Status,
+		DueDate:        task.DueDate,
+		CreatedBy:      task.CreatorID,
+		AssignedTo:     task.AssigneeID,
+		ProjectID:      task.ProjectID,
+		CreatedAt:      task.CreatedAt,
+		UpdatedAt:      task.UpdatedAt,
+		Version:        task.Version,
+		RequiredSkills: skills,
+
+		AutoCompleteOnChildren: task.AutoCompleteOnChildren,
+		RequiresReview:         task.RequiresReview,
+		ReviewerID:             task.ReviewerID,
+	}
+	if err := s.attachSubtaskProgress(ctx, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// getArchivedTask 获取归档任务详情，归档任务的子任务可能已一并归档，不返回子任务进度
+func (s *taskServiceRepo) getArchivedTask(ctx context.Context, taskID uint) (*TaskResponse, error) {
+	archive, err := s.taskRepo.GetArchivedByID(ctx, taskID)
+	if err != nil {
+		return nil, taskLookupError(err)
+	}
+	if err := s.checkTaskVisible(ctx, archive.ToTask()); err != nil {
+		return nil, err
+	}
+
+	skills, err := s.getTaskSkills(ctx, archive.ID)
+	if err != nil {
+		return nil, fmt.Errorf("查询任务技能要求失败: %w", err)
+	}
+
+	resp := taskToResponse(archive.ToTask())
+	resp.RequiredSkills = skills
+	resp.AutoCompleteOnChildren = archive.AutoCompleteOnChildren
+	resp.Archived = true
+	resp.ArchivedAt = &archive.ArchivedAt
+	return resp, nil
+}
+
+// checkTaskVisible 校验任务在上下文的可见范围内，团队范围需要查询负责人所在部门
+func (s *taskServiceRepo) checkTaskVisible(ctx context.Context, task *database.Task) error {
+	visibility := taskVisibilityFromContext(ctx)
+	if visibility.Unrestricted() || visibility.allows(task, nil) {
+		return nil
+	}
+	if visibility.Scope == TaskScopeTeam && task.AssigneeID != nil {
+		assignee, err := s.employeeRepo.GetByUserID(ctx, *task.AssigneeID)
+		if err != nil && !repository.IsNotFoundError(err) {
+			return fmt.Errorf("获取任务负责人信息失败: %w", err)
+		}
+		if assignee != nil && visibility.allows(task, assignee.DepartmentID) {
+			return nil
+		}
+	}
+	return visibility.outOfScopeError()
 }
 
 // UpdateTask 更新任务
@@ -135,10 +260,7 @@ func (s *taskServiceRepo) UpdateTask(ctx context.Context, taskID uint, req *Upda
 	// 获取现有任务
 	task, err := s.taskRepo.GetByID(ctx, taskID)
 	if err != nil {
-		if errors.Is(err, repository.ErrNotFound) {
-			return nil, fmt.Errorf("任务不存在")
-		}
-		return nil, fmt.Errorf("查询任务失败: %w", err)
+		return nil, taskLookupError(err)
 	}
 
 	// 更新任务字段
@@ -151,32 +273,93 @@ func (s *taskServiceRepo) UpdateTask(ctx context.Context, taskID uint, req *Upda
 	if req.Priority != nil {
 		task.Priority = *req.Priority
 	}
+	previousStatus := task.Status
 	if req.Status != nil {
+		if err := s.checkWIPLimit(ctx, task, *req.Status); err != nil {
+			return nil, err
+		}
 		task.Status = *req.Status
 	}
 	if req.DueDate != nil {
 		task.DueDate = req.DueDate
 	}
+	if req.AutoCompleteOnChildren != nil {
+		task.AutoCompleteOnChildren = *req.AutoCompleteOnChildren
+	}
+	if req.RequiresReview != nil {
+		task.RequiresReview = *req.RequiresReview
+	}
+	if req.ReviewerID != nil {
+		if *req.ReviewerID == 0 {
+			task.ReviewerID = nil
+		} else {
+			if err := s.checkTaskReviewer(ctx, *req.ReviewerID); err != nil {
+				return nil, err
+			}
+			task.ReviewerID = req.ReviewerID
+		}
+	}
+	// 以客户端读取时的版本作为更新条件，期间任务被修改则更新失败
+	if req.Version != nil {
+		task.Version = *req.Version
+	}
+
+	// 传入技能要求时整体替换
+	var taskSkills []*database.TaskSkill
+	if req.RequiredSkills != nil {
+		taskSkills, _, err = s.resolveTaskSkills(ctx, *req.RequiredSkills)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	// 保存更新
 	if err := s.taskRepo.Update(ctx, task); err != nil {
-		logger.Errorf("更新任务失败: %v", err)
+		if errors.Is(err, repository.ErrConflict) {
+			return nil, taskConflictError(ctx, s.taskRepo, taskID)
+		}
+		logger.FromContext(ctx).Errorf("更新任务失败: %v", err)
 		return nil, fmt.Errorf("更新任务失败: %w", err)
 	}
+	if req.RequiredSkills != nil {
+		if err := s.taskRepo.ReplaceSkills(ctx, task.ID, taskSkills); err != nil {
+			return nil, fmt.Errorf("更新任务技能要求失败: %w", err)
+		}
+	}
 
-	logger.Infof("任务更新成功: ID=%d, Title=%s", task.ID, task.Title)
+	logger.FromContext(ctx).Infof("任务更新成功: ID=%d, Title=%s", task.ID, task.Title)
+	if task.Status != previousStatus {
+		actorID, _ := getUserIDFromContext(ctx)
+		s.notifyTaskWatchers(ctx, task, actorID, models.NotificationTypeTaskUpdated, "任务状态更新",
+			fmt.Sprintf("任务「%s」状态由%s变更为%s", task.Title, previousStatus, task.Status))
+	}
 
-	return &TaskResponse{
-		ID:          task.ID,
-		Title:       task.Title,
-		Description: task.Description,
-		Priority:    task.Priority,
-		Status:      task.Status,
-		DueDate:     task.DueDate,
-		CreatedBy:   task.CreatorID,
-		CreatedAt:   task.CreatedAt,
-		UpdatedAt:   task.UpdatedAt,
-	}, nil
+	skills, err := s.getTaskSkills(ctx, task.ID)
+	if err != nil {
+		return nil, fmt.Errorf("查询任务技能要求失败: %w", err)
+	}
+
+	resp := &TaskResponse{
+		ID:             task.ID,
+		Title:          task.Title,
+		Description:    task.Description,
+		Priority:       task.Priority,
+		Status:         task.Status,
+		DueDate:        task.DueDate,
+		CreatedBy:      task.CreatorID,
+		CreatedAt:      task.CreatedAt,
+		UpdatedAt:      task.UpdatedAt,
+		Version:        task.Version,
+		RequiredSkills: skills,
+
+		AutoCompleteOnChildren: task.AutoCompleteOnChildren,
+		RequiresReview:         task.RequiresReview,
+		ReviewerID:             task.ReviewerID,
+	}
+	if err := s.attachSubtaskProgress(ctx, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
 }
 
 // DeleteTask 删除任务
@@ -184,10 +367,7 @@ func (s *taskServiceRepo) DeleteTask(ctx context.Context, taskID uint) error {
 	// 检查任务是否存在
 	task, err := s.taskRepo.GetByID(ctx, taskID)
 	if err != nil {
-		if errors.Is(err, repository.ErrNotFound) {
-			return fmt.Errorf("任务不存在")
-		}
-		return fmt.Errorf("查询任务失败: %w", err)
+		return taskLookupError(err)
 	}
 
 	// 检查任务是否可以删除
@@ -197,11 +377,11 @@ func (s *taskServiceRepo) DeleteTask(ctx context.Context, taskID uint) error {
 
 	// 删除任务
 	if err := s.taskRepo.Delete(ctx, taskID); err != nil {
-		logger.Errorf("删除任务失败: %v", err)
+		logger.FromContext(ctx).Errorf("删除任务失败: %v", err)
 		return fmt.Errorf("删除任务失败: %w", err)
 	}
 
-	logger.Infof("任务删除成功: ID=%d, Title=%s", task.ID, task.Title)
+	logger.FromContext(ctx).Infof("任务删除成功: ID=%d, Title=%s", task.ID, task.Title)
 	return nil
 }
 
@@ -216,26 +396,34 @@ func (s *taskServiceRepo) ListTasks(ctx context.Context, filter TaskListFilter)
 	}
 
 	// 添加任务特定的过滤条件
-	conditions := make(map[string]interface{})
-	if filter.Status != "" {
-		conditions["status"] = filter.Status
-	}
-	if filter.Priority != "" {
-		conditions["priority"] = filter.Priority
-	}
-	if filter.CreatedBy != nil && *filter.CreatedBy != 0 {
-		conditions["created_by"] = *filter.CreatedBy
+	repoFilter.Filters = taskFilterConditions(filter)
+	if visibility := taskVisibilityFromContext(ctx); !visibility.Unrestricted() {
+		repoFilter.Filters["visibility"] = visibility.repositoryFilter()
 	}
 
-	repoFilter.Filters = conditions
-
 	// 查询任务列表
 	tasks, total, err := s.taskRepo.List(ctx, repoFilter)
 	if err != nil {
-		logger.Errorf("查询任务列表失败: %v", err)
+		logger.FromContext(ctx).Errorf("查询任务列表失败: %v", err)
 		return nil, 0, fmt.Errorf("查询任务列表失败: %w", err)
 	}
 
+	var archived map[uint]bool
+	if filter.IncludeArchived && len(tasks) > 0 {
+		ids := make([]uint, len(tasks))
+		for i, task := range tasks {
+			ids[i] = task.ID
+		}
+		archivedIDs, err := s.taskRepo.FindArchivedIDs(ctx, ids)
+		if err != nil {
+			return nil, 0, fmt.Errorf("查询任务列表失败: %w", err)
+		}
+		archived = make(map[uint]bool, len(archivedIDs))
+		for _, id := range archivedIDs {
+			archived[id] = true
+		}
+	}
+
 	// 转换为响应格式
 	responses := make([]*TaskResponse, len(tasks))
 	for i, task := range tasks {
@@ -247,139 +435,194 @@ func (s *taskServiceRepo) ListTasks(ctx context.Context, filter TaskListFilter)
 			Status:      task.Status,
 			DueDate:     task.DueDate,
 			CreatedBy:   task.CreatorID,
+			AssignedTo:  task.AssigneeID,
+			ProjectID:   task.ProjectID,
 			CreatedAt:   task.CreatedAt,
 			UpdatedAt:   task.UpdatedAt,
+			Version:     task.Version,
+			Archived:    archived[task.ID],
+
+			AutoCompleteOnChildren: task.AutoCompleteOnChildren,
 		}
 	}
+	if err := s.attachSubtaskProgress(ctx, responses...); err != nil {
+		return nil, 0, err
+	}
+	if err := expandTaskResponses(ctx, s.userRepo, s.projectRepo, filter.Expand, responses...); err != nil {
+		return nil, 0, err
+	}
 
 	return responses, total, nil
 }
 
-// 分配任务
+// AssignTask 按员工ID分配任务，任务负责人保存为该员工的用户ID
+// 配置了工作流服务时先走任务分配审批，审批通过后由CompleteTaskAssignmentWorkflow完成分配；
+// 轻量审批模式或请求要求审批时保存待审批的分配记录，由ApproveAssignment、RejectAssignment处理
 func (s *taskServiceRepo) AssignTask(ctx context.Context, req *AssignTaskRequest) (*AssignmentResponse, error) {
+	method, err := normalizeAssignMethod(req.Method)
+	if err != nil {
+		return nil, err
+	}
+
 	// 获取任务
 	task, err := s.taskRepo.GetByID(ctx, req.TaskID)
 	if err != nil {
-		logger.Errorf("获取任务失败: %v", err)
-		return nil, fmt.Errorf("获取任务失败: %w", err)
+		logger.FromContext(ctx).Errorf("获取任务失败: %v", err)
+		return nil, taskLookupError(err)
 	}
 
 	// 验证任务状态 - 只有pending状态的任务可以分配
 	if task.Status != "pending" {
-		return nil, errors.New("只有待分配状态的任务才能进行分配")
+		return nil, taskNotPendingError(msgTaskAssignRequiresPending, task.ID, task.Status)
+	}
+	if err := ensureNoOpenSubtasks(ctx, s.taskRepo, task.ID); err != nil {
+		return nil, err
 	}
 
-	// 验证员工是否存在
-	employee, err := s.employeeRepo.GetByID(ctx, req.AssigneeID)
+	// 验证员工是否存在，离职办理中或任务已满的员工不能接收新任务
+	employee, err := s.employeeRepo.GetByID(ctx, req.EmployeeID)
+	if err != nil {
+		logger.FromContext(ctx).Errorf("获取员工信息失败: %v", err)
+		return nil, employeeLookupError(err)
+	}
+	if err := checkEmployeeAssignable(employee); err != nil {
+		return nil, err
+	}
+
+	// 从上下文获取当前用户ID
+	currentUserID, err := getUserIDFromContext(ctx)
 	if err != nil {
-		logger.Errorf("获取员工信息失败: %v", err)
-		return nil, fmt.Errorf("员工不存在或获取失败: %w", err)
+		logger.FromContext(ctx).Warnf("无法从上下文获取用户ID，使用默认值: %v", err)
+		currentUserID = 1 // 兜底值
 	}
 
-	// 检查员工工作负载
-	if employee.CurrentTasks >= employee.MaxTasks {
-		return nil, fmt.Errorf("员工当前任务已达上限(%d/%d)", employee.CurrentTasks, employee.MaxTasks)
+	// 属于项目的任务只能分配给项目成员
+	if err := ensureProjectMember(ctx, s.projectRepo, task, employee.ID, req.AllowNonMember, currentUserID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	assignment := &database.Assignment{
+		TaskID:     req.TaskID,
+		AssigneeID: employee.ID,
+		AssignerID: currentUserID,
+		Method:     method,
+		AssignedAt: now,
+		Reason:     req.Reason,
+	}
+	resp := &AssignmentResponse{
+		TaskID:     req.TaskID,
+		EmployeeID: employee.ID,
+		AssignedBy: currentUserID,
+		AssignedAt: now,
 	}
 
 	// 启动任务分配审批工作流
 	if s.workflowService != nil {
 		workflowReq := &workflow.TaskAssignmentApprovalRequest{
 			TaskID:      req.TaskID,
-			AssigneeID:  req.AssigneeID,
-			RequesterID: 1, // TODO: 从上下文获取当前用户ID
+			AssigneeID:  employee.ID,
+			RequesterID: currentUserID,
 			Priority:    task.Priority,
 			Reason:      req.Reason,
 		}
 
 		instance, err := s.workflowService.StartTaskAssignmentApproval(ctx, workflowReq)
 		if err != nil {
-			logger.Errorf("启动任务分配审批工作流失败: %v", err)
+			logger.FromContext(ctx).Errorf("启动任务分配审批工作流失败: %v", err)
 			return nil, fmt.Errorf("启动审批流程失败: %w", err)
 		}
 
-		logger.Infof("任务分配审批工作流已启动: TaskID=%d, WorkflowInstanceID=%s", req.TaskID, instance.ID)
+		logger.FromContext(ctx).Infof("任务分配审批工作流已启动: TaskID=%d, WorkflowInstanceID=%s", req.TaskID, instance.ID)
 
-		// 从上下文获取当前用户ID
-		currentUserID, err := getUserIDFromContext(ctx)
-		if err != nil {
-			logger.Warnf("无法从上下文获取用户ID，使用默认值: %v", err)
-			currentUserID = 1 // 兜底值
-		}
-
-		// 创建待审批的分配记录，用于跟踪工作流状态
-		now := time.Now()
-		assignment := &database.Assignment{
-			TaskID:             req.TaskID,
-			AssigneeID:         req.AssigneeID,
-			AssignerID:         currentUserID,
-			Method:             req.Method,
-			Status:             "pending_approval",
-			AssignedAt:         now,
-			Reason:             req.Reason,
-			WorkflowInstanceID: &instance.ID, // 关联工作流实例ID
-		}
-
-		// 这里需要保存Assignment记录，但当前没有Assignment repository
-		// 暂时记录日志，实际部署时需要保存到数据库
-		logger.Infof("创建待审批分配记录: TaskID=%d, AssigneeID=%d, WorkflowInstanceID=%s",
-			assignment.TaskID, assignment.AssigneeID, instance.ID)
-
-		// 返回审批中状态的响应，包含工作流实例ID
-		return &AssignmentResponse{
-			ID:                 0, // Assignment记录ID，需要从数据库获取
-			TaskID:             req.TaskID,
-			EmployeeID:         req.AssigneeID,
-			Status:             "pending_approval",
-			AssignedBy:         currentUserID,
-			AssignedAt:         now,
-			WorkflowInstanceID: instance.ID, // 返回工作流实例ID
-			Comment:            fmt.Sprintf("任务分配审批流程已启动，工作流实例ID: %s", instance.ID),
-		}, nil
+		// 保存待审批的分配记录，审批结束时按工作流实例ID找回
+		assignment.Status = "pending_approval"
+		assignment.WorkflowInstanceID = &instance.ID
+		if err := s.assignmentRepo.Create(ctx, assignment); err != nil {
+			return nil, fmt.Errorf("保存分配记录失败: %w", err)
+		}
+
+		resp.ID = assignment.ID
+		resp.Status = assignment.Status
+		resp.WorkflowInstanceID = instance.ID
+		resp.Comment = fmt.Sprintf("任务分配审批流程已启动，工作流实例ID: %s", instance.ID)
+
+		// 审批节点全部自动通过时，分配记录保存后立即完成分配
+		if runAutoApprovedCompletion(ctx, s.workflowService, instance) {
+			if updated, err := s.assignmentRepo.GetByID(ctx, assignment.ID); err == nil {
+				resp.Status = updated.Status
+			}
+			resp.Comment = fmt.Sprintf("任务分配审批已自动通过，工作流实例ID: %s", instance.ID)
+		}
+		return resp, nil
+	}
+
+	// 不使用工作流的轻量审批：保存待审批的分配记录，任务保持待分配
+	if s.simpleApproval || req.RequireApproval {
+		assignment.Status = "pending"
+		if err := s.assignmentRepo.Create(ctx, assignment); err != nil {
+			return nil, fmt.Errorf("保存分配记录失败: %w", err)
+		}
+
+		logger.FromContext(ctx).Infof("任务分配待审批: TaskID=%d, AssignmentID=%d", req.TaskID, assignment.ID)
+		resp.ID = assignment.ID
+		resp.Status = assignment.Status
+		resp.Comment = "任务分配待审批"
+		return resp, nil
 	}
 
 	// 如果没有工作流服务，则直接分配（向后兼容）
-	logger.Warnf("工作流服务不可用，直接执行任务分配")
+	logger.FromContext(ctx).Warnf("工作流服务不可用，直接执行任务分配")
+
+	// 先占用员工任务名额，并发分配时由仓储保证不超过上限
+	if err := reserveTaskSlot(ctx, s.employeeRepo, employee.ID); err != nil {
+		return nil, err
+	}
 
 	// 更新任务状态和分配信息
 	task.Status = "assigned"
 	task.AssigneeID = &employee.UserID
 
 	if err := s.taskRepo.Update(ctx, task); err != nil {
-		logger.Errorf("更新任务分配失败: %v", err)
+		releaseTaskSlot(ctx, s.employeeRepo, employee.ID)
+		if errors.Is(err, repository.ErrConflict) {
+			return nil, taskConflictError(ctx, s.taskRepo, task.ID)
+		}
+		logger.FromContext(ctx).Errorf("更新任务分配失败: %v", err)
 		return nil, fmt.Errorf("更新任务分配失败: %w", err)
 	}
 
-	// 更新员工当前任务数
-	employee.CurrentTasks++
-	if err := s.employeeRepo.Update(ctx, employee); err != nil {
-		logger.Warnf("更新员工任务数失败: %v", err)
+	assignment.Status = "approved"
+	assignment.ApprovedAt = &now
+	if s.assignmentRepo != nil {
+		if err := s.assignmentRepo.Create(ctx, assignment); err != nil {
+			logger.FromContext(ctx).Warnf("保存分配记录失败: TaskID=%d, error: %v", req.TaskID, err)
+		}
 	}
 
-	logger.Infof("任务直接分配成功: TaskID=%d, EmployeeID=%d", req.TaskID, req.AssigneeID)
+	logger.FromContext(ctx).Infof("任务直接分配成功: TaskID=%d, EmployeeID=%d, UserID=%d, Method=%s", req.TaskID, employee.ID, employee.UserID, method)
+	publishEvent(ctx, s.events, EventTaskAssigned, newTaskAssignedEvent(task, nil, currentUserID, TaskAssignMethodManual))
 
-	return &AssignmentResponse{
-		ID:         0,
-		TaskID:     req.TaskID,
-		EmployeeID: req.AssigneeID,
-		Status:     "assigned",
-		AssignedBy: 1, // TODO: 从上下文获取当前用户ID
-		AssignedAt: time.Now(),
-		Comment:    "任务已直接分配",
-	}, nil
-}
-
-func (s *taskServiceRepo) ReassignTask(ctx context.Context, taskID uint, req *ReassignTaskRequest) (*AssignmentResponse, error) {
-	return nil, errors.New("功能暂未实现")
-}
-
-func (s *taskServiceRepo) ApproveAssignment(ctx context.Context, assignmentID uint, req *ApproveAssignmentRequest) error {
-	return errors.New("功能暂未实现")
+	resp.ID = assignment.ID
+	resp.Status = "assigned"
+	resp.Comment = "任务已直接分配"
+	return resp, nil
 }
 
 // CompleteTaskAssignmentWorkflow 完成任务分配工作流
 // 当工作流审批通过时调用此方法完成实际的任务分配
 func (s *taskServiceRepo) CompleteTaskAssignmentWorkflow(ctx context.Context, workflowInstanceID string, approved bool, approverID uint) error {
-	logger.Infof("完成任务分配工作流: InstanceID=%s, Approved=%v, ApproverID=%d", workflowInstanceID, approved, approverID)
+	outcome := "rejected"
+	if approved {
+		outcome = "approved"
+	}
+	return s.finishTaskAssignmentWorkflow(ctx, workflowInstanceID, outcome, approverID)
+}
+
+// finishTaskAssignmentWorkflow 按审批结果更新任务和分配记录，outcome为approved、rejected或expired
+// 过期与拒绝的处理相同，分配记录状态记为expired且没有审批人
+func (s *taskServiceRepo) finishTaskAssignmentWorkflow(ctx context.Context, workflowInstanceID string, outcome string, approverID uint) error {
+	logger.FromContext(ctx).Infof("完成任务分配工作流: InstanceID=%s, Outcome=%s, ApproverID=%d", workflowInstanceID, outcome, approverID)
 
 	// 根据工作流实例ID查找对应的Assignment记录
 	var assignment *database.Assignment
@@ -403,18 +646,42 @@ func (s *taskServiceRepo) CompleteTaskAssignmentWorkflow(ctx context.Context, wo
 		return fmt.Errorf("分配仓库未配置")
 	}
 
+	return s.applyAssignmentOutcome(ctx, assignment, outcome, approverID)
+}
+
+// applyAssignmentOutcome 按审批结果更新任务、员工任务数和分配记录，outcome为approved、rejected或expired
+func (s *taskServiceRepo) applyAssignmentOutcome(ctx context.Context, assignment *database.Assignment, outcome string, approverID uint) error {
+	approved := outcome == "approved"
+
 	// 获取任务信息
 	task, err := s.taskRepo.GetByID(ctx, assignment.TaskID)
 	if err != nil {
-		return fmt.Errorf("获取任务失败: %w", err)
+		return taskLookupError(err)
 	}
 
 	now := time.Now()
 
-	if approved {
-		// 审批通过：更新任务状态为已分配
+	if assignment.Method == assignmentMethodReassign {
+		// 重新分配：审批通过才转交任务，拒绝或过期时任务保持原负责人不变
+		if approved {
+			if err := s.completeReassignment(ctx, task, assignment); err != nil {
+				return fmt.Errorf("完成任务重新分配失败: %w", err)
+			}
+		}
+		assignment.Status = outcome
+		assignment.ApprovedAt = &now
+		if approverID != 0 {
+			assignment.ApproverID = &approverID
+		}
+		logger.FromContext(ctx).Infof("任务重新分配审批结束: TaskID=%d, AssigneeID=%d, Outcome=%s", assignment.TaskID, assignment.AssigneeID, outcome)
+	} else if approved {
+		// 审批通过：更新任务状态为已分配，分配记录保存员工ID，任务负责人保存用户ID
+		employee, err := s.employeeRepo.GetByID(ctx, assignment.AssigneeID)
+		if err != nil {
+			return fmt.Errorf("获取被分配员工失败: %w", err)
+		}
 		task.Status = "assigned"
-		task.AssigneeID = &assignment.AssigneeID
+		task.AssigneeID = &employee.UserID
 
 		if err := s.taskRepo.Update(ctx, task); err != nil {
 			return fmt.Errorf("更新任务分配失败: %w", err)
@@ -425,19 +692,18 @@ func (s *taskServiceRepo) CompleteTaskAssignmentWorkflow(ctx context.Context, wo
 		assignment.ApprovedAt = &now
 		assignment.ApproverID = &approverID
 
-		// 更新员工工作负载
-		employee, err := s.employeeRepo.GetByID(ctx, assignment.AssigneeID)
-		if err == nil && employee != nil {
-			employee.CurrentTasks++
-			if employee.CurrentTasks >= employee.MaxTasks {
-				employee.Status = "busy"
+		// 更新员工工作负载，任务数达到上限时标记为忙碌
+		if err := s.employeeRepo.UpdateTaskCount(ctx, assignment.AssigneeID, 1); err != nil {
+			logger.FromContext(ctx).Warnf("更新员工任务数失败: %v", err)
+		} else if employee, err := s.employeeRepo.GetByID(ctx, assignment.AssigneeID); err == nil && employee.CurrentTasks >= employee.MaxTasks {
+			if err := s.employeeRepo.UpdateStatus(ctx, employee.ID, "busy"); err != nil {
+				logger.FromContext(ctx).Warnf("更新员工状态失败: %v", err)
 			}
-			s.employeeRepo.Update(ctx, employee)
 		}
 
-		logger.Infof("任务分配审批通过: TaskID=%d, AssigneeID=%d", assignment.TaskID, assignment.AssigneeID)
+		logger.FromContext(ctx).Infof("任务分配审批通过: TaskID=%d, AssigneeID=%d", assignment.TaskID, assignment.AssigneeID)
 	} else {
-		// 审批拒绝：重置任务状态为待分配
+		// 审批拒绝或过期：重置任务状态为待分配
 		task.Status = "pending"
 		task.AssigneeID = nil
 
@@ -446,11 +712,13 @@ func (s *taskServiceRepo) CompleteTaskAssignmentWorkflow(ctx context.Context, wo
 		}
 
 		// 更新分配记录状态
-		assignment.Status = "rejected"
+		assignment.Status = outcome
 		assignment.ApprovedAt = &now
-		assignment.ApproverID = &approverID
+		if approverID != 0 {
+			assignment.ApproverID = &approverID
+		}
 
-		logger.Infof("任务分配审批拒绝: TaskID=%d, AssigneeID=%d", assignment.TaskID, assignment.AssigneeID)
+		logger.FromContext(ctx).Infof("任务分配审批未通过: TaskID=%d, AssigneeID=%d, Outcome=%s", assignment.TaskID, assignment.AssigneeID, outcome)
 	}
 
 	// 保存分配记录更新
@@ -458,29 +726,44 @@ func (s *taskServiceRepo) CompleteTaskAssignmentWorkflow(ctx context.Context, wo
 		return fmt.Errorf("更新分配记录失败: %w", err)
 	}
 
+	// 重新分配的事件在applyReassignment中发布
+	if approved && assignment.Method != assignmentMethodReassign {
+		publishEvent(ctx, s.events, EventTaskAssigned, newTaskAssignedEvent(task, nil, assignment.AssignerID, TaskAssignMethodApproval))
+	}
 	return nil
 }
 
-func (s *taskServiceRepo) RejectAssignment(ctx context.Context, assignmentID uint, req *RejectAssignmentRequest) error {
-	return errors.New("功能暂未实现")
+// RegisterCompletionHandlers 登记任务分配审批流程结束后的业务回调
+func (s *taskServiceRepo) RegisterCompletionHandlers(registry *workflow.CompletionHandlerRegistry) {
+	registry.Register("task_assignment", func(ctx context.Context, completion *workflow.BusinessCompletion) error {
+		if completion.Expired {
+			return s.finishTaskAssignmentWorkflow(ctx, completion.Instance.ID, "expired", 0)
+		}
+		return s.CompleteTaskAssignmentWorkflow(ctx, completion.Instance.ID, completion.Approved, completion.ApproverID)
+	})
 }
 
 func (s *taskServiceRepo) StartTask(ctx context.Context, taskID uint, userID uint) error {
 	// 获取任务
 	task, err := s.taskRepo.GetByID(ctx, taskID)
 	if err != nil {
-		logger.Errorf("获取任务失败: %v", err)
-		return fmt.Errorf("获取任务失败: %w", err)
+		logger.FromContext(ctx).Errorf("获取任务失败: %v", err)
+		return taskLookupError(err)
 	}
 
 	// 验证任务状态
 	if task.Status != "assigned" {
-		return errors.New("只有已分配的任务才能开始")
+		return response.NewError(response.ErrCodeTaskStatusInvalid, "只有已分配的任务才能开始")
 	}
 
 	// 验证用户权限 - 只有被分配者才能开始任务
 	if task.AssigneeID == nil || *task.AssigneeID != userID {
-		return errors.New("只有任务被分配者才能开始任务")
+		return response.NewError(response.ErrCodeForbidden, "只有任务被分配者才能开始任务")
+	}
+
+	// 校验项目在制品上限
+	if err := s.checkWIPLimit(ctx, task, "in_progress"); err != nil {
+		return err
 	}
 
 	// 更新任务状态
@@ -489,74 +772,61 @@ func (s *taskServiceRepo) StartTask(ctx context.Context, taskID uint, userID uin
 	*task.StartedAt = time.Now()
 
 	if err := s.taskRepo.Update(ctx, task); err != nil {
-		logger.Errorf("更新任务状态失败: %v", err)
+		logger.FromContext(ctx).Errorf("更新任务状态失败: %v", err)
 		return fmt.Errorf("更新任务状态失败: %w", err)
 	}
 
-	logger.Infof("任务开始成功: TaskID=%d, UserID=%d", taskID, userID)
+	logger.FromContext(ctx).Infof("任务开始成功: TaskID=%d, UserID=%d", taskID, userID)
 	return nil
 }
 
-func (s *taskServiceRepo) CompleteTask(ctx context.Context, taskID uint, userID uint, req *CompleteTaskRequest) error {
+// CompleteTask 完成任务，需要审核的任务进入待审核状态，由审核人确认后才完成
+func (s *taskServiceRepo) CompleteTask(ctx context.Context, taskID uint, userID uint, req *CompleteTaskRequest) (*TaskResponse, error) {
 	// 获取任务
 	task, err := s.taskRepo.GetByID(ctx, taskID)
 	if err != nil {
-		logger.Errorf("获取任务失败: %v", err)
-		return fmt.Errorf("获取任务失败: %w", err)
+		logger.FromContext(ctx).Errorf("获取任务失败: %v", err)
+		return nil, taskLookupError(err)
 	}
 
 	// 验证任务状态
 	if task.Status != "in_progress" {
-		return errors.New("只有进行中的任务才能完成")
+		return nil, response.NewError(response.ErrCodeTaskStatusInvalid, "只有进行中的任务才能完成")
 	}
 
 	// 验证用户权限 - 只有被分配者才能完成任务
 	if task.AssigneeID == nil || *task.AssigneeID != userID {
-		return errors.New("只有任务被分配者才能完成任务")
+		return nil, response.NewError(response.ErrCodeForbidden, "只有任务被分配者才能完成任务")
 	}
 
-	// 更新任务状态
-	task.Status = "completed"
-	task.CompletedAt = &time.Time{}
-	*task.CompletedAt = time.Now()
-
-	// 如果提供了实际工时，更新实际工时
 	if req.Comment != "" {
 		// 这里可以添加评论逻辑，暂时跳过
-		logger.Infof("任务完成评论: %s", req.Comment)
+		logger.FromContext(ctx).Infof("任务完成评论: %s", req.Comment)
 	}
 
-	if err := s.taskRepo.Update(ctx, task); err != nil {
-		logger.Errorf("更新任务状态失败: %v", err)
-		return fmt.Errorf("更新任务状态失败: %w", err)
+	if task.RequiresReview {
+		err = s.submitTaskForReview(ctx, task, userID)
+	} else {
+		err = s.finishTaskCompletion(ctx, task, userID)
 	}
-
-	// 更新员工当前任务数
-	if task.AssigneeID != nil {
-		employee, err := s.employeeRepo.GetByUserID(ctx, *task.AssigneeID)
-		if err == nil && employee != nil {
-			if employee.CurrentTasks > 0 {
-				employee.CurrentTasks--
-				s.employeeRepo.Update(ctx, employee)
-			}
-		}
+	if err != nil {
+		return nil, err
 	}
-
-	logger.Infof("任务完成成功: TaskID=%d, UserID=%d", taskID, userID)
-	return nil
+	return TaskToResponse(task), nil
 }
 
-func (s *taskServiceRepo) CancelTask(ctx context.Context, taskID uint, userID uint, reason string) error {
+// CancelTask 取消任务，有未完成的子任务时需要设置Cascade级联取消
+func (s *taskServiceRepo) CancelTask(ctx context.Context, taskID uint, userID uint, req *CancelTaskRequest) error {
 	// 获取任务
 	task, err := s.taskRepo.GetByID(ctx, taskID)
 	if err != nil {
-		logger.Errorf("获取任务失败: %v", err)
-		return fmt.Errorf("获取任务失败: %w", err)
+		logger.FromContext(ctx).Errorf("获取任务失败: %v", err)
+		return taskLookupError(err)
 	}
 
 	// 验证任务状态 - 只有pending, assigned, in_progress状态的任务可以取消
 	if task.Status == "completed" || task.Status == "cancelled" {
-		return errors.New("已完成或已取消的任务不能再次取消")
+		return response.NewError(response.ErrCodeTaskStatusInvalid, "已完成或已取消的任务不能再次取消")
 	}
 
 	// 验证用户权限 - 创建者或被分配者都可以取消任务
@@ -565,7 +835,19 @@ func (s *taskServiceRepo) CancelTask(ctx context.Context, taskID uint, userID ui
 		canCancel = true
 	}
 	if !canCancel {
-		return errors.New("只有任务创建者或被分配者才能取消任务")
+		return response.NewError(response.ErrCodeForbidden, "只有任务创建者或被分配者才能取消任务")
+	}
+
+	// 有未完成的子任务时由调用方确认是否一并取消
+	open, err := openSubtaskCount(ctx, s.taskRepo, taskID)
+	if err != nil {
+		return err
+	}
+	if open > 0 {
+		if !req.Cascade {
+			return response.Wrapf(ErrTaskHasOpenSubtasks, "还有%d个子任务未完成，确认后设置cascade一并取消", open)
+		}
+		return s.cancelTaskTree(ctx, task, userID, req.Reason)
 	}
 
 	// 更新任务状态
@@ -574,7 +856,7 @@ func (s *taskServiceRepo) CancelTask(ctx context.Context, taskID uint, userID ui
 	*task.CompletedAt = time.Now()
 
 	if err := s.taskRepo.Update(ctx, task); err != nil {
-		logger.Errorf("更新任务状态失败: %v", err)
+		logger.FromContext(ctx).Errorf("更新任务状态失败: %v", err)
 		return fmt.Errorf("更新任务状态失败: %w", err)
 	}
 
@@ -582,14 +864,12 @@ func (s *taskServiceRepo) CancelTask(ctx context.Context, taskID uint, userID ui
 	if task.AssigneeID != nil {
 		employee, err := s.employeeRepo.GetByUserID(ctx, *task.AssigneeID)
 		if err == nil && employee != nil {
-			if employee.CurrentTasks > 0 {
-				employee.CurrentTasks--
-				s.employeeRepo.Update(ctx, employee)
-			}
+			releaseTaskSlot(ctx, s.employeeRepo, employee.ID)
 		}
 	}
 
-	logger.Infof("任务取消成功: TaskID=%d, UserID=%d, Reason=%s", taskID, userID, reason)
+	logger.FromContext(ctx).Infof("任务取消成功: TaskID=%d, UserID=%d, Reason=%s", taskID, userID, req.Reason)
+	s.notifyTaskWatchers(ctx, task, userID, models.NotificationTypeTaskCancelled, "任务已取消", fmt.Sprintf("任务「%s」已取消，原因: %s", task.Title, req.Reason))
 	return nil
 }
 
@@ -601,19 +881,34 @@ func (s *taskServiceRepo) AutoAssignTask(ctx context.Context, taskID uint, strat
 	// 获取任务信息
 	task, err := s.taskRepo.GetByID(ctx, taskID)
 	if err != nil {
-		return nil, fmt.Errorf("获取任务失败: %w", err)
+		return nil, taskLookupError(err)
 	}
 
 	// 验证任务状态
 	if task.Status != "pending" {
-		return nil, errors.New("只有待分配状态的任务才能进行自动分配")
+		return nil, taskNotPendingError(msgTaskAutoAssignRequiresPending, task.ID, task.Status)
+	}
+	if err := ensureNoOpenSubtasks(ctx, s.taskRepo, task.ID); err != nil {
+		return nil, err
+	}
+
+	// 属于项目的任务只在项目成员中选择
+	memberIDs, err := projectMemberIDs(ctx, s.projectRepo, task)
+	if err != nil {
+		return nil, err
+	}
+
+	// 部门默认策略由AssignmentManagementService.AutoAssign处理，这里只使用系统默认策略
+	if strategy == "" {
+		strategy = AssignmentStrategy(DefaultAssignmentStrategy)
 	}
 
 	// 构建分配请求
 	req := &assignment.AssignmentRequest{
-		TaskID:   taskID,
-		Strategy: assignment.AssignmentStrategy(strategy),
-		Priority: task.Priority,
+		TaskID:        taskID,
+		Strategy:      assignment.AssignmentStrategy(strategy),
+		Priority:      task.Priority,
+		OnlyEmployees: memberIDs,
 	}
 
 	if task.DueDate != nil {
@@ -625,22 +920,28 @@ func (s *taskServiceRepo) AutoAssignTask(ctx context.Context, taskID uint, strat
 	if err != nil {
 		return nil, fmt.Errorf("自动分配失败: %w", err)
 	}
+	if result.SelectedEmployee.OnboardingStatus == EmployeeStatusOffboarding {
+		return nil, response.NewError(response.ErrCodeUnprocessable, "自动分配选中了正在办理离职的员工")
+	}
+
+	// 先占用员工任务名额，并发分配时由仓储保证不超过上限
+	if err := reserveTaskSlot(ctx, s.employeeRepo, result.SelectedEmployee.ID); err != nil {
+		return nil, err
+	}
 
 	// 更新任务状态和分配信息
 	task.Status = "assigned"
 	task.AssigneeID = &result.SelectedEmployee.UserID
 
 	if err := s.taskRepo.Update(ctx, task); err != nil {
-		logger.Errorf("更新任务分配失败: %v", err)
+		releaseTaskSlot(ctx, s.employeeRepo, result.SelectedEmployee.ID)
+		if errors.Is(err, repository.ErrConflict) {
+			return nil, taskConflictError(ctx, s.taskRepo, task.ID)
+		}
+		logger.FromContext(ctx).Errorf("更新任务分配失败: %v", err)
 		return nil, fmt.Errorf("更新任务分配失败: %w", err)
 	}
-
-	// 更新员工当前任务数
-	employee, err := s.employeeRepo.GetByID(ctx, result.SelectedEmployee.ID)
-	if err == nil && employee != nil {
-		employee.CurrentTasks++
-		s.employeeRepo.Update(ctx, employee)
-	}
+	publishEvent(ctx, s.events, EventTaskAssigned, newTaskAssignedEvent(task, nil, 0, TaskAssignMethodAuto))
 
 	// 返回分配响应
 	return &AssignmentResponse{
@@ -662,12 +963,18 @@ func (s *taskServiceRepo) GetAssignmentSuggestions(ctx context.Context, taskID u
 	// 获取任务信息
 	task, err := s.taskRepo.GetByID(ctx, taskID)
 	if err != nil {
-		return nil, fmt.Errorf("获取任务失败: %w", err)
+		return nil, taskLookupError(err)
 	}
 
 	// 验证任务状态
 	if task.Status != "pending" {
-		return nil, errors.New("只有待分配状态的任务才能获取分配建议")
+		return nil, taskNotPendingError(msgTaskSuggestRequiresPending, task.ID, task.Status)
+	}
+
+	// 标注建议的员工是否为项目成员
+	members, err := projectMemberSet(ctx, s.projectRepo, task)
+	if err != nil {
+		return nil, err
 	}
 
 	suggestions := make([]*AssignmentSuggestion, 0)
@@ -691,7 +998,7 @@ func (s *taskServiceRepo) GetAssignmentSuggestions(ctx context.Context, taskID u
 		// 预览分配结果
 		result, err := s.assignmentService.PreviewAssignment(ctx, req)
 		if err != nil {
-			logger.Warnf("预览分配策略 %s 失败: %v", strategyInfo.Strategy, err)
+			logger.FromContext(ctx).Warnf("预览分配策略 %s 失败: %v", strategyInfo.Strategy, err)
 			continue
 		}
 
@@ -710,6 +1017,7 @@ func (s *taskServiceRepo) GetAssignmentSuggestions(ctx context.Context, taskID u
 			SkillMatch:   result.Score,
 			Availability: 100.0 - (float64(result.SelectedEmployee.CurrentTasks) / 10.0 * 100),
 		}
+		annotateProjectMember(suggestion, members)
 
 		suggestions = append(suggestions, suggestion)
 	}
@@ -750,7 +1058,7 @@ func isValidPriority(priority string) bool {
 
 // 验证任务状态
 func isValidTaskStatus(status string) bool {
-	validStatuses := []string{"pending", "assigned", "in_progress", "completed", "cancelled"}
+	validStatuses := []string{"pending", "assigned", "in_progress", "in_review", "completed", "cancelled"}
 	for _, s := range validStatuses {
 		if s == status {
 			return true
@@ -763,7 +1071,7 @@ func isValidTaskStatus(status string) bool {
 func (s *taskServiceRepo) StartTaskAssignmentApproval(ctx context.Context, req *StartTaskAssignmentApprovalRequest) (*TaskAssignmentApprovalResponse, error) {
 	if s.workflowService == nil {
 		// 如果没有工作流服务，直接执行分配
-		logger.Warn("工作流服务未配置，直接执行任务分配")
+		logger.FromContext(ctx).Warn("工作流服务未配置，直接执行任务分配")
 		return s.directAssignTask(ctx, req)
 	}
 
@@ -782,6 +1090,15 @@ func (s *taskServiceRepo) StartTaskAssignmentApproval(ctx context.Context, req *
 		return nil, fmt.Errorf("启动任务分配审批流程失败: %w", err)
 	}
 
+	if runAutoApprovedCompletion(ctx, s.workflowService, instance) {
+		return &TaskAssignmentApprovalResponse{
+			WorkflowInstanceID: instance.ID,
+			Status:             "approved",
+			Message:            "任务分配审批已自动通过",
+			CreatedAt:          instance.StartedAt,
+		}, nil
+	}
+
 	return &TaskAssignmentApprovalResponse{
 		WorkflowInstanceID: instance.ID,
 		Status:             "pending_approval",
@@ -803,6 +1120,7 @@ func (s *taskServiceRepo) ProcessTaskAssignmentApproval(ctx context.Context, req
 		Comment:    req.Comment,
 		Variables:  req.Variables,
 		ApprovedBy: req.ApprovedBy,
+		DelegateTo: req.DelegateTo,
 	}
 
 	result, err := s.workflowService.ProcessTaskAssignmentApproval(ctx, processReq)
@@ -833,7 +1151,7 @@ func (s *taskServiceRepo) GetPendingTaskAssignmentApprovals(ctx context.Context,
 		return []*PendingTaskAssignmentApproval{}, nil
 	}
 
-	approvals, err := s.workflowService.GetPendingTaskAssignmentApprovals(ctx, userID)
+	approvals, _, err := s.workflowService.GetPendingTaskAssignmentApprovals(ctx, workflow.PendingApprovalFilter{UserID: userID})
 	if err != nil {
 		return nil, fmt.Errorf("获取待审批任务失败: %w", err)
 	}
@@ -869,23 +1187,38 @@ func (s *taskServiceRepo) directAssignTask(ctx context.Context, req *StartTaskAs
 	// 获取任务信息
 	task, err := s.taskRepo.GetByID(ctx, req.TaskID)
 	if err != nil {
-		return nil, fmt.Errorf("获取任务失败: %w", err)
+		return nil, taskLookupError(err)
+	}
+	if err := ensureNoOpenSubtasks(ctx, s.taskRepo, task.ID); err != nil {
+		return nil, err
+	}
+
+	// 请求中的AssigneeID为员工ID，任务负责人保存该员工的用户ID
+	employee, err := s.employeeRepo.GetByID(ctx, req.AssigneeID)
+	if err != nil {
+		return nil, employeeLookupError(err)
+	}
+	if err := checkEmployeeAssignable(employee); err != nil {
+		return nil, err
+	}
+
+	// 先占用员工任务名额，并发分配时由仓储保证不超过上限
+	if err := reserveTaskSlot(ctx, s.employeeRepo, employee.ID); err != nil {
+		return nil, err
 	}
 
 	// 更新任务分配
-	task.AssigneeID = &req.AssigneeID
+	task.AssigneeID = &employee.UserID
 	task.Status = "assigned"
 
 	if err := s.taskRepo.Update(ctx, task); err != nil {
+		releaseTaskSlot(ctx, s.employeeRepo, employee.ID)
+		if errors.Is(err, repository.ErrConflict) {
+			return nil, taskConflictError(ctx, s.taskRepo, task.ID)
+		}
 		return nil, fmt.Errorf("更新任务分配失败: %w", err)
 	}
-
-	// 更新员工工作负载
-	employee, err := s.employeeRepo.GetByID(ctx, req.AssigneeID)
-	if err == nil && employee != nil {
-		employee.CurrentTasks++
-		s.employeeRepo.Update(ctx, employee)
-	}
+	publishEvent(ctx, s.events, EventTaskAssigned, newTaskAssignedEvent(task, nil, req.RequesterID, TaskAssignMethodManual))
 
 	return &TaskAssignmentApprovalResponse{
 		WorkflowInstanceID: "",
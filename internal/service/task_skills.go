@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+	"taskmanage/pkg/logger"
+	"taskmanage/pkg/response"
+)
+
+var (
+	// ErrInvalidTaskSkill 任务技能要求不合法
+	ErrInvalidTaskSkill = response.NewError(response.ErrCodeInvalidRequest, "任务技能要求不合法")
+	// ErrUnknownSkill 技能不存在且未开启自动创建
+	ErrUnknownSkill = response.NewError(response.ErrCodeInvalidRequest, "技能不存在")
+)
+
+// skillResolver 按名称解析技能要求，任务和任务模板共用
+type skillResolver struct {
+	skillRepo        repository.SkillRepository
+	autoCreateSkills bool
+}
+
+// resolveTaskSkills 将技能名称解析为技能记录
+func (s *taskServiceRepo) resolveTaskSkills(ctx context.Context, requirements []TaskSkillRequirement) ([]*database.TaskSkill, []TaskSkillResponse, error) {
+	return skillResolver{skillRepo: s.skillRepo, autoCreateSkills: s.autoCreateSkills}.resolve(ctx, requirements)
+}
+
+// resolve 将技能名称解析为技能记录，同名技能取最高等级；
+// 开启 task.auto_create_skills 时自动创建不存在的技能
+func (s skillResolver) resolve(ctx context.Context, requirements []TaskSkillRequirement) ([]*database.TaskSkill, []TaskSkillResponse, error) {
+	levels := make(map[string]int, len(requirements))
+	names := make([]string, 0, len(requirements))
+	for _, requirement := range requirements {
+		name := strings.TrimSpace(requirement.Name)
+		if name == "" {
+			return nil, nil, response.Wrapf(ErrInvalidTaskSkill, "技能名称不能为空")
+		}
+		level := requirement.Level
+		if level == 0 {
+			level = 1
+		}
+		if level < 1 || level > 5 {
+			return nil, nil, response.Wrapf(ErrInvalidTaskSkill, "技能 %s 的等级必须在1-5之间", name)
+		}
+		if current, ok := levels[name]; !ok {
+			names = append(names, name)
+			levels[name] = level
+		} else if level > current {
+			levels[name] = level
+		}
+	}
+
+	taskSkills := make([]*database.TaskSkill, 0, len(names))
+	responses := make([]TaskSkillResponse, 0, len(names))
+	for _, name := range names {
+		skill, err := s.findOrCreateSkill(ctx, name)
+		if err != nil {
+			return nil, nil, err
+		}
+		taskSkills = append(taskSkills, &database.TaskSkill{
+			SkillID:  skill.ID,
+			Required: true,
+			Level:    levels[name],
+		})
+		responses = append(responses, TaskSkillResponse{
+			SkillID: skill.ID,
+			Name:    skill.Name,
+			Level:   levels[name],
+		})
+	}
+
+	return taskSkills, responses, nil
+}
+
+// findOrCreateSkill 按名称查找技能，不存在时按配置决定是否创建
+func (s skillResolver) findOrCreateSkill(ctx context.Context, name string) (*database.Skill, error) {
+	skill, err := s.skillRepo.GetByName(ctx, name)
+	if err == nil {
+		return skill, nil
+	}
+	if !repository.IsNotFoundError(err) {
+		return nil, fmt.Errorf("查询技能失败: %w", err)
+	}
+	if !s.autoCreateSkills {
+		return nil, response.Wrapf(ErrUnknownSkill, "%s", name)
+	}
+
+	skill = &database.Skill{Name: name}
+	if err := s.skillRepo.Create(ctx, skill); err != nil {
+		// 并发创建同名技能时唯一索引冲突，重新查询已创建的记录
+		if existing, getErr := s.skillRepo.GetByName(ctx, name); getErr == nil {
+			return existing, nil
+		}
+		return nil, fmt.Errorf("创建技能失败: %w", err)
+	}
+	logger.FromContext(ctx).Infof("自动创建技能: ID=%d, Name=%s", skill.ID, skill.Name)
+
+	return skill, nil
+}
+
+// getTaskSkills 获取任务的技能要求响应
+func (s *taskServiceRepo) getTaskSkills(ctx context.Context, taskID uint) ([]TaskSkillResponse, error) {
+	details, err := s.taskRepo.GetSkillRequirements(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	skills := make([]TaskSkillResponse, len(details))
+	for i, detail := range details {
+		skills[i] = TaskSkillResponse{
+			SkillID: detail.SkillID,
+			Name:    detail.Name,
+			Level:   detail.Level,
+		}
+	}
+	return skills, nil
+}
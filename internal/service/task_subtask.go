@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/models"
+	"taskmanage/internal/repository"
+	"taskmanage/pkg/logger"
+	"taskmanage/pkg/response"
+)
+
+// ErrTaskHasOpenSubtasks 任务还有未完成的子任务
+var ErrTaskHasOpenSubtasks = response.NewError(response.ErrCodeTaskHasOpenSubtasks, "任务还有未完成的子任务")
+
+// attachSubtaskProgress 用一次分组查询为任务响应填充子任务进度
+func (s *taskServiceRepo) attachSubtaskProgress(ctx context.Context, responses ...*TaskResponse) error {
+	if len(responses) == 0 {
+		return nil
+	}
+	ids := make([]uint, 0, len(responses))
+	for _, resp := range responses {
+		ids = append(ids, resp.ID)
+	}
+
+	counts, err := s.taskRepo.CountSubTasks(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("查询子任务进度失败: %w", err)
+	}
+	for _, resp := range responses {
+		if c := counts[resp.ID]; c != nil && c.Total > 0 {
+			resp.SubtaskProgress = &SubtaskProgress{
+				CompletedSubtasks: c.Completed,
+				TotalSubtasks:     c.Total,
+				Percentage:        math.Round(float64(c.Completed)/float64(c.Total)*1000) / 10,
+			}
+		}
+	}
+	return nil
+}
+
+// openSubtaskCount 统计任务未完成、未取消的直接子任务数
+func openSubtaskCount(ctx context.Context, taskRepo repository.TaskRepository, taskID uint) (int, error) {
+	counts, err := taskRepo.CountSubTasks(ctx, []uint{taskID})
+	if err != nil {
+		return 0, fmt.Errorf("查询子任务失败: %w", err)
+	}
+	if c := counts[taskID]; c != nil {
+		return c.Total - c.Completed, nil
+	}
+	return 0, nil
+}
+
+// ensureNoOpenSubtasks 有未完成子任务的父任务不能直接分配，避免父子任务重复计入工作负载
+func ensureNoOpenSubtasks(ctx context.Context, taskRepo repository.TaskRepository, taskID uint) error {
+	open, err := openSubtaskCount(ctx, taskRepo, taskID)
+	if err != nil {
+		return err
+	}
+	if open > 0 {
+		return response.Wrapf(ErrTaskHasOpenSubtasks, "还有%d个子任务未完成，请分配子任务", open)
+	}
+	return nil
+}
+
+// cancelTaskTree 级联取消任务及其未完成的后代任务，释放负责人的任务名额并通知关注者
+func (s *taskServiceRepo) cancelTaskTree(ctx context.Context, task *database.Task, userID uint, reason string) error {
+	cancelled, err := s.taskRepo.CancelTree(ctx, task.ID, time.Now())
+	if err != nil {
+		if errors.Is(err, repository.ErrConflict) {
+			return taskConflictError(ctx, s.taskRepo, task.ID)
+		}
+		return err
+	}
+
+	for _, t := range cancelled {
+		if t.AssigneeID != nil {
+			employee, err := s.employeeRepo.GetByUserID(ctx, *t.AssigneeID)
+			if err == nil && employee != nil {
+				releaseTaskSlot(ctx, s.employeeRepo, employee.ID)
+			}
+		}
+		content := fmt.Sprintf("任务「%s」已取消，原因: %s", t.Title, reason)
+		if t.ID != task.ID {
+			content = fmt.Sprintf("任务「%s」随父任务「%s」取消，原因: %s", t.Title, task.Title, reason)
+		}
+		s.notifyTaskWatchers(ctx, t, userID, models.NotificationTypeTaskCancelled, "任务已取消", content)
+	}
+
+	logger.FromContext(ctx).Infof("任务级联取消成功: TaskID=%d, UserID=%d, Cancelled=%d, Reason=%s", task.ID, userID, len(cancelled), reason)
+	return nil
+}
+
+// completeParentsIfDone 子任务完成后，逐级检查开启了自动完成的父任务，全部子任务完成时自动完成父任务并记录系统评论
+// 父任务需要审核时改为提交审核
+// 子任务已经完成，父任务处理失败只记录日志
+func (s *taskServiceRepo) completeParentsIfDone(ctx context.Context, child *database.Task, userID uint) {
+	for parentID := child.ParentID; parentID != nil; {
+		parent, err := s.taskRepo.GetByID(ctx, *parentID)
+		if err != nil {
+			logger.FromContext(ctx).Warnf("获取父任务失败: TaskID=%d, error: %v", *parentID, err)
+			return
+		}
+		if !parent.AutoCompleteOnChildren || parent.Status == database.TaskStatusCompleted || parent.Status == database.TaskStatusCancelled {
+			return
+		}
+		counts, err := s.taskRepo.CountSubTasks(ctx, []uint{parent.ID})
+		if err != nil {
+			logger.FromContext(ctx).Warnf("统计子任务失败: TaskID=%d, error: %v", parent.ID, err)
+			return
+		}
+		c := counts[parent.ID]
+		if c == nil || c.Completed < c.Total {
+			return
+		}
+
+		// 需要审核的父任务提交审核，审核通过后才完成，不再继续向上检查
+		if parent.RequiresReview {
+			if parent.Status == database.TaskStatusInReview {
+				return
+			}
+			if err := s.submitTaskForReview(ctx, parent, userID); err != nil {
+				logger.FromContext(ctx).Warnf("父任务提交审核失败: TaskID=%d, error: %v", parent.ID, err)
+			}
+			return
+		}
+
+		now := time.Now()
+		parent.Status = database.TaskStatusCompleted
+		parent.CompletedAt = &now
+		// 并发完成最后两个子任务时只有一个请求能更新成功
+		if err := s.taskRepo.Update(ctx, parent); err != nil {
+			logger.FromContext(ctx).Warnf("自动完成父任务失败: TaskID=%d, error: %v", parent.ID, err)
+			return
+		}
+		if parent.AssigneeID != nil {
+			employee, err := s.employeeRepo.GetByUserID(ctx, *parent.AssigneeID)
+			if err == nil && employee != nil {
+				releaseTaskSlot(ctx, s.employeeRepo, employee.ID)
+			}
+		}
+		publishEvent(ctx, s.events, EventTaskCompleted, newTaskEvent(parent))
+
+		content := fmt.Sprintf("全部%d个子任务已完成，任务自动完成", c.Total)
+		if s.taskCommentRepo != nil {
+			comment := &database.TaskComment{TaskID: parent.ID, UserID: userID, Content: content, System: true}
+			if err := s.taskCommentRepo.Create(ctx, comment); err != nil {
+				logger.FromContext(ctx).Warnf("记录自动完成评论失败: TaskID=%d, error: %v", parent.ID, err)
+			}
+		}
+
+		logger.FromContext(ctx).Infof("子任务全部完成，父任务自动完成: TaskID=%d, ChildID=%d", parent.ID, child.ID)
+		s.notifyTaskWatchers(ctx, parent, userID, models.NotificationTypeTaskCompleted, "任务已完成", fmt.Sprintf("任务「%s」%s", parent.Title, content))
+		parentID = parent.ParentID
+	}
+}
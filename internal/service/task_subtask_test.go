@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+// memoryTaskCommentRepository 内存中的任务评论仓储
+type memoryTaskCommentRepository struct {
+	repository.TaskCommentRepository
+	comments []*database.TaskComment
+}
+
+func (r *memoryTaskCommentRepository) Create(ctx context.Context, comment *database.TaskComment) error {
+	r.comments = append(r.comments, comment)
+	return nil
+}
+
+func TestCompleteTask_AutoCompletesParent(t *testing.T) {
+	ctx := context.Background()
+	parentID, assigneeID := uint(1), uint(2)
+	parent := &database.Task{BaseModel: database.BaseModel{ID: 1}, Status: "pending", CreatorID: 9, AutoCompleteOnChildren: true}
+	child := &database.Task{BaseModel: database.BaseModel{ID: 2}, Status: "in_progress", CreatorID: 9, AssigneeID: &assigneeID, ParentID: &parentID}
+
+	taskRepo := new(MockTaskRepository)
+	taskRepo.On("GetByID", ctx, uint(1)).Return(parent, nil)
+	taskRepo.On("GetByID", ctx, uint(2)).Return(child, nil)
+	taskRepo.On("Update", ctx, mock.AnythingOfType("*database.Task")).Return(nil)
+	taskRepo.On("CountSubTasks", ctx, []uint{1}).Return(map[uint]*repository.SubTaskCounts{1: {Total: 3, Completed: 3}}, nil)
+	employeeRepo := new(MockEmployeeRepository)
+	employeeRepo.On("GetByUserID", ctx, assigneeID).Return((*database.Employee)(nil), repository.ErrNotFound)
+	comments := &memoryTaskCommentRepository{}
+	svc := &taskServiceRepo{taskRepo: taskRepo, employeeRepo: employeeRepo, timeEntryRepo: &fakeTimeEntryRepository{}, taskCommentRepo: comments}
+
+	_, err := svc.CompleteTask(ctx, 2, assigneeID, &CompleteTaskRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "completed", parent.Status)
+	assert.NotNil(t, parent.CompletedAt)
+	require.Len(t, comments.comments, 1)
+	assert.True(t, comments.comments[0].System)
+	assert.Equal(t, uint(1), comments.comments[0].TaskID)
+
+	// 未开启自动完成的父任务保持不变
+	parent.Status, parent.AutoCompleteOnChildren = "pending", false
+	child.Status = "in_progress"
+	_, err = svc.CompleteTask(ctx, 2, assigneeID, &CompleteTaskRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "pending", parent.Status)
+}
+
+func TestCancelTask_RequiresCascadeForOpenSubtasks(t *testing.T) {
+	ctx := context.Background()
+	childAssignee := uint(5)
+	parent := &database.Task{BaseModel: database.BaseModel{ID: 1}, Title: "上线准备", Status: "pending", CreatorID: 9}
+
+	taskRepo := new(MockTaskRepository)
+	taskRepo.On("GetByID", ctx, uint(1)).Return(parent, nil)
+	taskRepo.On("CountSubTasks", ctx, []uint{1}).Return(map[uint]*repository.SubTaskCounts{1: {Total: 3, Completed: 1}}, nil)
+	taskRepo.On("CancelTree", ctx, uint(1), mock.Anything).Return([]*database.Task{
+		parent,
+		{BaseModel: database.BaseModel{ID: 2}, Title: "压测", Status: "assigned", CreatorID: 9, AssigneeID: &childAssignee},
+	}, nil)
+	employeeRepo := new(MockEmployeeRepository)
+	employeeRepo.On("GetByUserID", ctx, childAssignee).Return(&database.Employee{BaseModel: database.BaseModel{ID: 50}}, nil)
+	employeeRepo.On("UpdateTaskCount", ctx, uint(50), -1).Return(nil)
+	svc := &taskServiceRepo{taskRepo: taskRepo, employeeRepo: employeeRepo}
+
+	err := svc.CancelTask(ctx, 1, 9, &CancelTaskRequest{Reason: "需求取消"})
+	assert.ErrorIs(t, err, ErrTaskHasOpenSubtasks)
+	taskRepo.AssertNotCalled(t, "CancelTree", mock.Anything, mock.Anything, mock.Anything)
+
+	require.NoError(t, svc.CancelTask(ctx, 1, 9, &CancelTaskRequest{Reason: "需求取消", Cascade: true}))
+	employeeRepo.AssertCalled(t, "UpdateTaskCount", ctx, uint(50), -1)
+
+	// 有未完成子任务的父任务不能直接分配
+	_, err = svc.AssignTask(ctx, &AssignTaskRequest{TaskID: 1, EmployeeID: 50})
+	assert.ErrorIs(t, err, ErrTaskHasOpenSubtasks)
+}
+
+func TestGetTask_SubtaskProgress(t *testing.T) {
+	ctx := context.Background()
+	taskRepo := new(MockTaskRepository)
+	taskRepo.On("GetByID", ctx, uint(1)).Return(&database.Task{BaseModel: database.BaseModel{ID: 1}, Status: "pending"}, nil)
+	taskRepo.On("GetSkillRequirements", ctx, uint(1)).Return([]*database.TaskSkillDetail{}, nil)
+	taskRepo.On("CountSubTasks", ctx, []uint{1}).Return(map[uint]*repository.SubTaskCounts{1: {Total: 3, Completed: 2}}, nil)
+	svc := &taskServiceRepo{taskRepo: taskRepo}
+
+	task, err := svc.GetTask(ctx, 1, TaskExpand{})
+	require.NoError(t, err)
+	require.NotNil(t, task.SubtaskProgress)
+	assert.Equal(t, SubtaskProgress{CompletedSubtasks: 2, TotalSubtasks: 3, Percentage: 66.7}, *task.SubtaskProgress)
+}
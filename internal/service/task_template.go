@@ -0,0 +1,431 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+	"taskmanage/pkg/logger"
+)
+
+// ErrInvalidTaskTemplate 任务模板内容不合法
+var ErrInvalidTaskTemplate = errors.New("任务模板不合法")
+
+const (
+	// maxTaskTemplateNodes 单个模板最多包含的节点数，含根节点
+	maxTaskTemplateNodes = 100
+	// maxTaskTemplateDepth 模板最大层级，根节点为第1层
+	maxTaskTemplateDepth = 5
+)
+
+// TaskTemplateRequest 创建或更新任务模板请求，Subtasks按顺序生成子任务，可继续嵌套
+type TaskTemplateRequest struct {
+	Title          string                 `json:"title" binding:"required,max=200"`
+	Description    string                 `json:"description"`
+	Priority       string                 `json:"priority" binding:"omitempty,oneof=low medium high urgent"` // 默认medium
+	EstimatedHours float64                `json:"estimated_hours" binding:"gte=0"`
+	RequiredSkills []TaskSkillRequirement `json:"required_skills"`
+	Subtasks       []TaskTemplateRequest  `json:"subtasks" binding:"dive"`
+}
+
+// TaskTemplateResponse 任务模板响应，列表接口不返回子节点
+type TaskTemplateResponse struct {
+	ID             uint                    `json:"id"`
+	Title          string                  `json:"title"`
+	Description    string                  `json:"description"`
+	Priority       string                  `json:"priority"`
+	EstimatedHours float64                 `json:"estimated_hours"`
+	RequiredSkills []TaskSkillResponse     `json:"required_skills"`
+	Subtasks       []*TaskTemplateResponse `json:"subtasks,omitempty"`
+	CreatorID      uint                    `json:"creator_id"`
+	CreatedAt      time.Time               `json:"created_at"`
+	UpdatedAt      time.Time               `json:"updated_at"`
+}
+
+// InstantiateTaskTemplateRequest 实例化任务模板请求，项目和截止时间作用于生成的全部任务
+type InstantiateTaskTemplateRequest struct {
+	ProjectID          *uint      `json:"project_id"`
+	DueDate            *time.Time `json:"due_date"`
+	AssigneeEmployeeID *uint      `json:"assignee_employee_id"` // 根任务负责人（Employee.ID），按常规分配流程处理
+}
+
+// InstantiateTaskTemplateResponse 任务模板实例化结果
+type InstantiateTaskTemplateResponse struct {
+	TemplateID      uint                `json:"template_id"`
+	RootTaskID      uint                `json:"root_task_id"`
+	TaskIDs         map[uint]uint       `json:"task_ids"` // 模板节点ID -> 生成的任务ID
+	Assignment      *AssignmentResponse `json:"assignment,omitempty"`
+	AssignmentError string              `json:"assignment_error,omitempty"` // 任务已创建但分配失败的原因
+}
+
+// TaskTemplateService 任务模板服务
+type TaskTemplateService interface {
+	// ListTemplates 分页获取任务模板，只返回根节点
+	ListTemplates(ctx context.Context, req *ListRequest) (*ListResponse[*TaskTemplateResponse], error)
+	// GetTemplate 获取任务模板及全部子节点
+	GetTemplate(ctx context.Context, templateID uint) (*TaskTemplateResponse, error)
+	// CreateTemplate 创建任务模板
+	CreateTemplate(ctx context.Context, creatorID uint, req *TaskTemplateRequest) (*TaskTemplateResponse, error)
+	// UpdateTemplate 整体替换任务模板内容，已实例化的任务不受影响
+	UpdateTemplate(ctx context.Context, templateID uint, req *TaskTemplateRequest) (*TaskTemplateResponse, error)
+	// DeleteTemplate 删除任务模板，已实例化的任务不受影响
+	DeleteTemplate(ctx context.Context, templateID uint) error
+	// InstantiateTemplate 按模板在同一事务中创建任务树，并复制技能要求
+	InstantiateTemplate(ctx context.Context, templateID, creatorID uint, req *InstantiateTaskTemplateRequest) (*InstantiateTaskTemplateResponse, error)
+}
+
+// taskTemplateService 任务模板服务实现
+type taskTemplateService struct {
+	repoManager repository.RepositoryManager
+	skills      skillResolver
+	taskService TaskService
+}
+
+// NewTaskTemplateService 创建任务模板服务，taskService用于实例化后分配根任务
+func NewTaskTemplateService(repoManager repository.RepositoryManager, taskService TaskService, autoCreateSkills bool) TaskTemplateService {
+	return &taskTemplateService{
+		repoManager: repoManager,
+		skills:      skillResolver{skillRepo: repoManager.SkillRepository(), autoCreateSkills: autoCreateSkills},
+		taskService: taskService,
+	}
+}
+
+// resolvedTemplateNode 已校验并解析技能要求的模板节点
+type resolvedTemplateNode struct {
+	req      *TaskTemplateRequest
+	skills   []*database.TaskSkill
+	children []*resolvedTemplateNode
+}
+
+// ListTemplates 分页获取任务模板
+func (s *taskTemplateService) ListTemplates(ctx context.Context, req *ListRequest) (*ListResponse[*TaskTemplateResponse], error) {
+	filter := listFilter(req, "id", "desc")
+	if req.Keyword != "" {
+		filter.Filters = map[string]interface{}{"search": req.Keyword}
+	}
+	roots, total, err := s.repoManager.TaskTemplateRepository().ListRoots(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("获取任务模板列表失败: %w", err)
+	}
+
+	items := make([]*TaskTemplateResponse, 0, len(roots))
+	for _, root := range roots {
+		items = append(items, toTaskTemplateResponse(root))
+	}
+	return &ListResponse[*TaskTemplateResponse]{Items: items, Total: total, Page: req.Page, Size: req.PageSize}, nil
+}
+
+// GetTemplate 获取任务模板详情
+func (s *taskTemplateService) GetTemplate(ctx context.Context, templateID uint) (*TaskTemplateResponse, error) {
+	root, children, err := s.loadTemplateTree(ctx, s.repoManager.TaskTemplateRepository(), templateID)
+	if err != nil {
+		return nil, err
+	}
+	return buildTaskTemplateResponse(root, children), nil
+}
+
+// CreateTemplate 创建任务模板
+func (s *taskTemplateService) CreateTemplate(ctx context.Context, creatorID uint, req *TaskTemplateRequest) (*TaskTemplateResponse, error) {
+	resolved, err := s.resolveTemplate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var root *database.TaskTemplate
+	err = s.repoManager.WithTx(ctx, func(ctx context.Context, repos repository.RepositoryManager) error {
+		root, err = createTemplateNode(ctx, repos.TaskTemplateRepository(), resolved, nil, nil, 0, creatorID)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("创建任务模板失败: %w", err)
+	}
+
+	logger.FromContext(ctx).Infof("任务模板创建成功: ID=%d, Title=%s", root.ID, root.Title)
+	return s.GetTemplate(ctx, root.ID)
+}
+
+// UpdateTemplate 更新根节点并重建全部子节点
+func (s *taskTemplateService) UpdateTemplate(ctx context.Context, templateID uint, req *TaskTemplateRequest) (*TaskTemplateResponse, error) {
+	root, err := s.repoManager.TaskTemplateRepository().GetRoot(ctx, templateID)
+	if err != nil {
+		return nil, fmt.Errorf("获取任务模板失败: %w", err)
+	}
+	resolved, err := s.resolveTemplate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	root.Title = req.Title
+	root.Description = req.Description
+	root.Priority = templatePriority(req.Priority)
+	root.EstimatedHours = req.EstimatedHours
+	root.Skills = nil
+
+	err = s.repoManager.WithTx(ctx, func(ctx context.Context, repos repository.RepositoryManager) error {
+		templateRepo := repos.TaskTemplateRepository()
+		if err := templateRepo.Update(ctx, root); err != nil {
+			return err
+		}
+		if err := templateRepo.ReplaceSkills(ctx, root.ID, toTemplateSkills(resolved.skills)); err != nil {
+			return err
+		}
+		if err := templateRepo.DeleteDescendants(ctx, root.ID); err != nil {
+			return err
+		}
+		for i, child := range resolved.children {
+			if _, err := createTemplateNode(ctx, templateRepo, child, &root.ID, &root.ID, i, root.CreatorID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("更新任务模板失败: %w", err)
+	}
+
+	return s.GetTemplate(ctx, root.ID)
+}
+
+// DeleteTemplate 删除任务模板
+func (s *taskTemplateService) DeleteTemplate(ctx context.Context, templateID uint) error {
+	err := s.repoManager.WithTx(ctx, func(ctx context.Context, repos repository.RepositoryManager) error {
+		return repos.TaskTemplateRepository().Delete(ctx, templateID)
+	})
+	if err != nil {
+		return fmt.Errorf("删除任务模板失败: %w", err)
+	}
+	return nil
+}
+
+// InstantiateTemplate 实例化任务模板
+// 任务树和技能要求在同一事务中创建，任一节点失败时整体回滚；指定负责人时，任务树创建后再按常规流程分配根任务，
+// 分配失败不回滚已创建的任务，原因记录在AssignmentError中
+func (s *taskTemplateService) InstantiateTemplate(ctx context.Context, templateID, creatorID uint, req *InstantiateTaskTemplateRequest) (*InstantiateTaskTemplateResponse, error) {
+	root, children, err := s.loadTemplateTree(ctx, s.repoManager.TaskTemplateRepository(), templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.ProjectID != nil {
+		if _, err := s.repoManager.ProjectRepository().GetByID(ctx, *req.ProjectID); err != nil {
+			return nil, fmt.Errorf("获取项目失败: %w", err)
+		}
+	}
+	// 提前校验负责人，避免创建任务树后才发现无法分配
+	if req.AssigneeEmployeeID != nil {
+		employee, err := s.repoManager.EmployeeRepository().GetByID(ctx, *req.AssigneeEmployeeID)
+		if err != nil {
+			return nil, fmt.Errorf("获取员工失败: %w", err)
+		}
+		if err := checkEmployeeAssignable(employee); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &InstantiateTaskTemplateResponse{TemplateID: root.ID, TaskIDs: make(map[uint]uint)}
+	err = s.repoManager.WithTx(ctx, func(ctx context.Context, repos repository.RepositoryManager) error {
+		return instantiateTemplateNode(ctx, repos.TaskRepository(), root, nil, children, req, creatorID, result.TaskIDs)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("实例化任务模板失败: %w", err)
+	}
+	result.RootTaskID = result.TaskIDs[root.ID]
+	logger.FromContext(ctx).Infof("任务模板实例化成功: TemplateID=%d, RootTaskID=%d, 任务数=%d", root.ID, result.RootTaskID, len(result.TaskIDs))
+
+	if req.AssigneeEmployeeID != nil {
+		assignment, err := s.taskService.AssignTask(ctx, &AssignTaskRequest{
+			TaskID:     result.RootTaskID,
+			EmployeeID: *req.AssigneeEmployeeID,
+			Method:     AssignMethodManual,
+			Reason:     fmt.Sprintf("由任务模板「%s」创建", root.Title),
+		})
+		if err != nil {
+			logger.FromContext(ctx).Warnf("任务模板实例化后分配根任务失败: TaskID=%d, EmployeeID=%d, error=%v", result.RootTaskID, *req.AssigneeEmployeeID, err)
+			result.AssignmentError = err.Error()
+		} else {
+			result.Assignment = assignment
+		}
+	}
+
+	return result, nil
+}
+
+// resolveTemplate 校验模板层级和节点数，并解析各节点的技能要求
+func (s *taskTemplateService) resolveTemplate(ctx context.Context, req *TaskTemplateRequest) (*resolvedTemplateNode, error) {
+	count := 0
+	return s.resolveTemplateNode(ctx, req, 1, &count)
+}
+
+func (s *taskTemplateService) resolveTemplateNode(ctx context.Context, req *TaskTemplateRequest, depth int, count *int) (*resolvedTemplateNode, error) {
+	*count++
+	if *count > maxTaskTemplateNodes {
+		return nil, fmt.Errorf("%w: 节点数不能超过%d", ErrInvalidTaskTemplate, maxTaskTemplateNodes)
+	}
+	if depth > maxTaskTemplateDepth {
+		return nil, fmt.Errorf("%w: 层级不能超过%d", ErrInvalidTaskTemplate, maxTaskTemplateDepth)
+	}
+	if strings.TrimSpace(req.Title) == "" {
+		return nil, fmt.Errorf("%w: 标题不能为空", ErrInvalidTaskTemplate)
+	}
+	if req.Priority != "" && !isValidPriority(req.Priority) {
+		return nil, fmt.Errorf("%w: 无效的优先级 %s", ErrInvalidTaskTemplate, req.Priority)
+	}
+	if req.EstimatedHours < 0 {
+		return nil, fmt.Errorf("%w: 预估工时不能为负数", ErrInvalidTaskTemplate)
+	}
+
+	skills, _, err := s.skills.resolve(ctx, req.RequiredSkills)
+	if err != nil {
+		return nil, err
+	}
+	node := &resolvedTemplateNode{req: req, skills: skills}
+	for i := range req.Subtasks {
+		child, err := s.resolveTemplateNode(ctx, &req.Subtasks[i], depth+1, count)
+		if err != nil {
+			return nil, err
+		}
+		node.children = append(node.children, child)
+	}
+	return node, nil
+}
+
+// loadTemplateTree 读取模板全部节点，返回根节点和按父节点分组的子节点
+func (s *taskTemplateService) loadTemplateTree(ctx context.Context, templateRepo repository.TaskTemplateRepository, templateID uint) (*database.TaskTemplate, map[uint][]*database.TaskTemplate, error) {
+	nodes, err := templateRepo.GetTree(ctx, templateID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取任务模板失败: %w", err)
+	}
+
+	var root *database.TaskTemplate
+	children := make(map[uint][]*database.TaskTemplate)
+	for _, node := range nodes {
+		if node.ParentID == nil {
+			if node.ID == templateID {
+				root = node
+			}
+			continue
+		}
+		children[*node.ParentID] = append(children[*node.ParentID], node)
+	}
+	// 传入子节点ID时不视为模板
+	if root == nil {
+		return nil, nil, fmt.Errorf("获取任务模板失败: %w", repository.ErrNotFound)
+	}
+	return root, children, nil
+}
+
+// createTemplateNode 递归创建模板节点，rootID为空时创建的是根节点
+func createTemplateNode(ctx context.Context, templateRepo repository.TaskTemplateRepository, node *resolvedTemplateNode, parentID, rootID *uint, sortOrder int, creatorID uint) (*database.TaskTemplate, error) {
+	template := &database.TaskTemplate{
+		RootID:         rootID,
+		ParentID:       parentID,
+		SortOrder:      sortOrder,
+		Title:          node.req.Title,
+		Description:    node.req.Description,
+		Priority:       templatePriority(node.req.Priority),
+		EstimatedHours: node.req.EstimatedHours,
+		CreatorID:      creatorID,
+	}
+	if err := templateRepo.Create(ctx, template); err != nil {
+		return nil, err
+	}
+	if err := templateRepo.ReplaceSkills(ctx, template.ID, toTemplateSkills(node.skills)); err != nil {
+		return nil, err
+	}
+
+	childRootID := rootID
+	if childRootID == nil {
+		childRootID = &template.ID
+	}
+	for i, child := range node.children {
+		if _, err := createTemplateNode(ctx, templateRepo, child, &template.ID, childRootID, i, creatorID); err != nil {
+			return nil, err
+		}
+	}
+	return template, nil
+}
+
+// instantiateTemplateNode 按模板节点创建任务及其子任务，记录模板节点到任务的映射
+func instantiateTemplateNode(ctx context.Context, taskRepo repository.TaskRepository, node *database.TaskTemplate, parentTaskID *uint, children map[uint][]*database.TaskTemplate, req *InstantiateTaskTemplateRequest, creatorID uint, taskIDs map[uint]uint) error {
+	task := &database.Task{
+		Title:          node.Title,
+		Description:    node.Description,
+		Priority:       templatePriority(node.Priority),
+		Status:         "pending",
+		EstimatedHours: node.EstimatedHours,
+		DueDate:        req.DueDate,
+		CreatorID:      creatorID,
+		ParentID:       parentTaskID,
+		ProjectID:      req.ProjectID,
+	}
+	if err := taskRepo.Create(ctx, task); err != nil {
+		return fmt.Errorf("创建任务「%s」失败: %w", node.Title, err)
+	}
+
+	if len(node.Skills) > 0 {
+		taskSkills := make([]*database.TaskSkill, 0, len(node.Skills))
+		for _, skill := range node.Skills {
+			taskSkills = append(taskSkills, &database.TaskSkill{SkillID: skill.SkillID, Required: true, Level: skill.Level})
+		}
+		if err := taskRepo.ReplaceSkills(ctx, task.ID, taskSkills); err != nil {
+			return fmt.Errorf("保存任务「%s」技能要求失败: %w", node.Title, err)
+		}
+	}
+	taskIDs[node.ID] = task.ID
+
+	for _, child := range children[node.ID] {
+		if err := instantiateTemplateNode(ctx, taskRepo, child, &task.ID, children, req, creatorID, taskIDs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// templatePriority 未指定优先级时使用medium
+func templatePriority(priority string) string {
+	if priority == "" {
+		return "medium"
+	}
+	return priority
+}
+
+// toTemplateSkills 将解析后的任务技能要求转换为模板技能要求
+func toTemplateSkills(skills []*database.TaskSkill) []*database.TaskTemplateSkill {
+	templateSkills := make([]*database.TaskTemplateSkill, 0, len(skills))
+	for _, skill := range skills {
+		templateSkills = append(templateSkills, &database.TaskTemplateSkill{SkillID: skill.SkillID, Level: skill.Level})
+	}
+	return templateSkills
+}
+
+// toTaskTemplateResponse 转换单个模板节点，不含子节点
+func toTaskTemplateResponse(node *database.TaskTemplate) *TaskTemplateResponse {
+	skills := make([]TaskSkillResponse, 0, len(node.Skills))
+	for _, skill := range node.Skills {
+		skills = append(skills, TaskSkillResponse{SkillID: skill.SkillID, Name: skill.Skill.Name, Level: skill.Level})
+	}
+	return &TaskTemplateResponse{
+		ID:             node.ID,
+		Title:          node.Title,
+		Description:    node.Description,
+		Priority:       node.Priority,
+		EstimatedHours: node.EstimatedHours,
+		RequiredSkills: skills,
+		CreatorID:      node.CreatorID,
+		CreatedAt:      node.CreatedAt,
+		UpdatedAt:      node.UpdatedAt,
+	}
+}
+
+// buildTaskTemplateResponse 递归转换模板节点及其子节点
+func buildTaskTemplateResponse(node *database.TaskTemplate, children map[uint][]*database.TaskTemplate) *TaskTemplateResponse {
+	resp := toTaskTemplateResponse(node)
+	for _, child := range children[node.ID] {
+		resp.Subtasks = append(resp.Subtasks, buildTaskTemplateResponse(child, children))
+	}
+	return resp
+}
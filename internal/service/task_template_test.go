@@ -0,0 +1,261 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+// memoryTaskTemplateRepository 测试用内存任务模板仓储
+type memoryTaskTemplateRepository struct {
+	nodes  map[uint]*database.TaskTemplate
+	nextID uint
+}
+
+func (m *memoryTaskTemplateRepository) Create(ctx context.Context, node *database.TaskTemplate) error {
+	m.nextID++
+	node.ID = m.nextID
+	m.nodes[node.ID] = node
+	return nil
+}
+
+func (m *memoryTaskTemplateRepository) Update(ctx context.Context, node *database.TaskTemplate) error {
+	skills := m.nodes[node.ID].Skills
+	m.nodes[node.ID] = node
+	node.Skills = skills
+	return nil
+}
+
+func (m *memoryTaskTemplateRepository) GetRoot(ctx context.Context, id uint) (*database.TaskTemplate, error) {
+	node, ok := m.nodes[id]
+	if !ok || node.ParentID != nil {
+		return nil, repository.ErrNotFound
+	}
+	return node, nil
+}
+
+func (m *memoryTaskTemplateRepository) GetTree(ctx context.Context, rootID uint) ([]*database.TaskTemplate, error) {
+	var nodes []*database.TaskTemplate
+	for _, node := range m.nodes {
+		if node.ID == rootID || (node.RootID != nil && *node.RootID == rootID) {
+			nodes = append(nodes, node)
+		}
+	}
+	if len(nodes) == 0 {
+		return nil, repository.ErrNotFound
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].SortOrder != nodes[j].SortOrder {
+			return nodes[i].SortOrder < nodes[j].SortOrder
+		}
+		return nodes[i].ID < nodes[j].ID
+	})
+	return nodes, nil
+}
+
+func (m *memoryTaskTemplateRepository) ListRoots(ctx context.Context, filter repository.ListFilter) ([]*database.TaskTemplate, int64, error) {
+	return nil, 0, errors.New("未实现")
+}
+
+func (m *memoryTaskTemplateRepository) ReplaceSkills(ctx context.Context, templateID uint, skills []*database.TaskTemplateSkill) error {
+	m.nodes[templateID].Skills = nil
+	for _, skill := range skills {
+		m.nodes[templateID].Skills = append(m.nodes[templateID].Skills, database.TaskTemplateSkill{
+			TemplateID: templateID,
+			SkillID:    skill.SkillID,
+			Level:      skill.Level,
+		})
+	}
+	return nil
+}
+
+func (m *memoryTaskTemplateRepository) DeleteDescendants(ctx context.Context, rootID uint) error {
+	for id, node := range m.nodes {
+		if node.RootID != nil && *node.RootID == rootID {
+			delete(m.nodes, id)
+		}
+	}
+	return nil
+}
+
+func (m *memoryTaskTemplateRepository) Delete(ctx context.Context, rootID uint) error {
+	if err := m.DeleteDescendants(ctx, rootID); err != nil {
+		return err
+	}
+	delete(m.nodes, rootID)
+	return nil
+}
+
+// templateTaskRepository 记录创建的任务及技能要求，可按标题模拟创建失败
+type templateTaskRepository struct {
+	repository.TaskRepository
+	tasks     []*database.Task
+	skills    map[uint][]*database.TaskSkill
+	failTitle string
+}
+
+func (r *templateTaskRepository) Create(ctx context.Context, task *database.Task) error {
+	if task.Title == r.failTitle {
+		return errors.New("数据库写入失败")
+	}
+	task.ID = uint(len(r.tasks) + 500)
+	r.tasks = append(r.tasks, task)
+	return nil
+}
+
+func (r *templateTaskRepository) ReplaceSkills(ctx context.Context, taskID uint, skills []*database.TaskSkill) error {
+	r.skills[taskID] = skills
+	return nil
+}
+
+// namedSkillRepository 按名称查找技能的仓储桩
+type namedSkillRepository struct {
+	repository.SkillRepository
+	skills map[string]*database.Skill
+}
+
+func (r *namedSkillRepository) GetByName(ctx context.Context, name string) (*database.Skill, error) {
+	if skill, ok := r.skills[name]; ok {
+		return skill, nil
+	}
+	return nil, repository.ErrNotFound
+}
+
+// templateRepoManager 事务失败时丢弃本次创建的任务，模拟回滚
+type templateRepoManager struct {
+	repository.RepositoryManager
+	templateRepo *memoryTaskTemplateRepository
+	taskRepo     *templateTaskRepository
+	skillRepo    *namedSkillRepository
+	projectRepo  *stubProjectRepository
+}
+
+func (m *templateRepoManager) TaskTemplateRepository() repository.TaskTemplateRepository {
+	return m.templateRepo
+}
+
+func (m *templateRepoManager) TaskRepository() repository.TaskRepository { return m.taskRepo }
+
+func (m *templateRepoManager) SkillRepository() repository.SkillRepository { return m.skillRepo }
+
+func (m *templateRepoManager) ProjectRepository() repository.ProjectRepository { return m.projectRepo }
+
+func (m *templateRepoManager) WithTx(ctx context.Context, fn func(ctx context.Context, repos repository.RepositoryManager) error) error {
+	created := len(m.taskRepo.tasks)
+	if err := fn(ctx, m); err != nil {
+		m.taskRepo.tasks = m.taskRepo.tasks[:created]
+		return err
+	}
+	return nil
+}
+
+func newTemplateRepoManager() *templateRepoManager {
+	return &templateRepoManager{
+		templateRepo: &memoryTaskTemplateRepository{nodes: map[uint]*database.TaskTemplate{}},
+		taskRepo:     &templateTaskRepository{skills: map[uint][]*database.TaskSkill{}},
+		skillRepo: &namedSkillRepository{skills: map[string]*database.Skill{
+			"Go":     {BaseModel: database.BaseModel{ID: 1}, Name: "Go"},
+			"DevOps": {BaseModel: database.BaseModel{ID: 2}, Name: "DevOps"},
+		}},
+		projectRepo: &stubProjectRepository{project: &database.Project{BaseModel: database.BaseModel{ID: 9}}},
+	}
+}
+
+func releaseChecklist() *TaskTemplateRequest {
+	return &TaskTemplateRequest{
+		Title:          "发布检查",
+		Priority:       "high",
+		EstimatedHours: 2,
+		RequiredSkills: []TaskSkillRequirement{{Name: "DevOps", Level: 3}},
+		Subtasks: []TaskTemplateRequest{
+			{Title: "冻结代码", RequiredSkills: []TaskSkillRequirement{{Name: "Go", Level: 2}}},
+			{Title: "回归测试", Subtasks: []TaskTemplateRequest{{Title: "冒烟测试", EstimatedHours: 1}}},
+		},
+	}
+}
+
+func TestInstantiateTaskTemplate_CreatesTaskTree(t *testing.T) {
+	ctx := context.Background()
+	repos := newTemplateRepoManager()
+	svc := NewTaskTemplateService(repos, nil, false)
+
+	template, err := svc.CreateTemplate(ctx, 3, releaseChecklist())
+	require.NoError(t, err)
+	require.Len(t, template.Subtasks, 2)
+	assert.Equal(t, "冻结代码", template.Subtasks[0].Title)
+	assert.Equal(t, "medium", template.Subtasks[0].Priority)
+	require.Len(t, template.Subtasks[1].Subtasks, 1)
+
+	projectID := uint(9)
+	dueDate := time.Date(2026, 11, 1, 18, 0, 0, 0, time.UTC)
+	result, err := svc.InstantiateTemplate(ctx, template.ID, 5, &InstantiateTaskTemplateRequest{ProjectID: &projectID, DueDate: &dueDate})
+	require.NoError(t, err)
+	require.Len(t, result.TaskIDs, 4)
+	assert.Equal(t, result.TaskIDs[template.ID], result.RootTaskID)
+	assert.Nil(t, result.Assignment)
+
+	tasks := map[uint]*database.Task{}
+	for _, task := range repos.taskRepo.tasks {
+		tasks[task.ID] = task
+		assert.Equal(t, &projectID, task.ProjectID)
+		assert.Equal(t, &dueDate, task.DueDate)
+		assert.Equal(t, uint(5), task.CreatorID)
+		assert.Equal(t, "pending", task.Status)
+	}
+	root := tasks[result.RootTaskID]
+	assert.Nil(t, root.ParentID)
+	assert.Equal(t, "high", root.Priority)
+	smoke := tasks[result.TaskIDs[template.Subtasks[1].Subtasks[0].ID]]
+	assert.Equal(t, "冒烟测试", smoke.Title)
+	assert.Equal(t, result.TaskIDs[template.Subtasks[1].ID], *smoke.ParentID)
+
+	// 技能要求复制到任务
+	freeze := result.TaskIDs[template.Subtasks[0].ID]
+	require.Len(t, repos.taskRepo.skills[freeze], 1)
+	assert.Equal(t, uint(1), repos.taskRepo.skills[freeze][0].SkillID)
+	assert.Equal(t, 2, repos.taskRepo.skills[freeze][0].Level)
+	assert.Equal(t, 3, repos.taskRepo.skills[root.ID][0].Level)
+
+	// 修改模板不影响已创建的任务
+	_, err = svc.UpdateTemplate(ctx, template.ID, &TaskTemplateRequest{Title: "发布检查v2"})
+	require.NoError(t, err)
+	updated, err := svc.GetTemplate(ctx, template.ID)
+	require.NoError(t, err)
+	assert.Empty(t, updated.Subtasks)
+	assert.Equal(t, "发布检查", root.Title)
+	assert.Len(t, repos.taskRepo.tasks, 4)
+}
+
+func TestInstantiateTaskTemplate_RollsBackOnFailure(t *testing.T) {
+	ctx := context.Background()
+	repos := newTemplateRepoManager()
+	svc := NewTaskTemplateService(repos, nil, false)
+	template, err := svc.CreateTemplate(ctx, 3, releaseChecklist())
+	require.NoError(t, err)
+
+	repos.taskRepo.failTitle = "回归测试"
+	_, err = svc.InstantiateTemplate(ctx, template.ID, 5, &InstantiateTaskTemplateRequest{})
+	require.Error(t, err)
+	assert.Empty(t, repos.taskRepo.tasks)
+
+	// 子节点ID不能作为模板实例化
+	_, err = svc.InstantiateTemplate(ctx, template.Subtasks[0].ID, 5, &InstantiateTaskTemplateRequest{})
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+}
+
+func TestCreateTaskTemplate_RejectsUnknownSkill(t *testing.T) {
+	svc := NewTaskTemplateService(newTemplateRepoManager(), nil, false)
+	req := releaseChecklist()
+	req.Subtasks[0].RequiredSkills = []TaskSkillRequirement{{Name: "Rust"}}
+
+	_, err := svc.CreateTemplate(context.Background(), 3, req)
+	assert.ErrorIs(t, err, ErrUnknownSkill)
+}
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/models"
+	"taskmanage/pkg/logger"
+)
+
+// MaxTaskWatchers 单个任务显式关注者数量上限，创建者和负责人不计入
+const MaxTaskWatchers = 50
+
+var (
+	// ErrTaskWatcherLimit 任务关注者数量已达上限
+	ErrTaskWatcherLimit = errors.New("任务关注者数量已达上限")
+
+	// ErrTaskWatcherForbidden 当前用户无权为他人添加或取消关注
+	ErrTaskWatcherForbidden = errors.New("无权管理其他用户的任务关注")
+)
+
+// 关注来源
+const (
+	TaskWatchSourceCreator    = "creator"
+	TaskWatchSourceAssignee   = "assignee"
+	TaskWatchSourceSubscribed = "subscribed"
+)
+
+// TaskWatcherRequest 添加或取消任务关注请求，UserID为空时操作当前用户
+type TaskWatcherRequest struct {
+	UserID uint `json:"user_id" form:"user_id"`
+
+	OperatorID        uint `json:"-" form:"-"` // 由处理器填充
+	CanManageWatchers bool `json:"-" form:"-"` // 操作人是否可以管理他人的关注，由处理器根据task:assign权限填充
+}
+
+// TaskWatcherResponse 任务关注者响应
+type TaskWatcherResponse struct {
+	UserID    uint       `json:"user_id"`
+	Source    string     `json:"source"` // creator, assignee, subscribed
+	AddedBy   *uint      `json:"added_by,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+// TaskWatchersResponse 任务关注者列表响应
+type TaskWatchersResponse struct {
+	TaskID   uint                   `json:"task_id"`
+	Watchers []*TaskWatcherResponse `json:"watchers"`
+	Total    int                    `json:"total"`
+}
+
+// AddTaskWatcher 关注任务，为他人添加关注需要管理权限
+func (s *taskServiceRepo) AddTaskWatcher(ctx context.Context, taskID uint, req *TaskWatcherRequest) (*TaskWatcherResponse, error) {
+	userID, err := s.resolveWatcherTarget(req)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.taskRepo.GetByID(ctx, taskID); err != nil {
+		return nil, fmt.Errorf("获取任务失败: %w", err)
+	}
+	if userID != req.OperatorID {
+		if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+			return nil, fmt.Errorf("获取用户失败: %w", err)
+		}
+	}
+
+	exists, err := s.taskWatcherRepo.Exists(ctx, taskID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("查询任务关注者失败: %w", err)
+	}
+	if !exists {
+		count, err := s.taskWatcherRepo.CountByTask(ctx, taskID)
+		if err != nil {
+			return nil, fmt.Errorf("统计任务关注者失败: %w", err)
+		}
+		if count >= MaxTaskWatchers {
+			return nil, fmt.Errorf("%w: 最多%d人", ErrTaskWatcherLimit, MaxTaskWatchers)
+		}
+	}
+
+	watcher := &database.TaskWatcher{TaskID: taskID, UserID: userID, AddedBy: req.OperatorID, CreatedAt: time.Now()}
+	if err := s.taskWatcherRepo.Add(ctx, watcher); err != nil {
+		return nil, fmt.Errorf("添加任务关注者失败: %w", err)
+	}
+
+	logger.FromContext(ctx).Infof("添加任务关注者: TaskID=%d, UserID=%d, OperatorID=%d", taskID, userID, req.OperatorID)
+	return toTaskWatcherResponse(watcher), nil
+}
+
+// RemoveTaskWatcher 取消关注任务，取消他人的关注需要管理权限；创建者和负责人的隐式关注不能取消
+func (s *taskServiceRepo) RemoveTaskWatcher(ctx context.Context, taskID uint, req *TaskWatcherRequest) error {
+	userID, err := s.resolveWatcherTarget(req)
+	if err != nil {
+		return err
+	}
+	if err := s.taskWatcherRepo.Remove(ctx, taskID, userID); err != nil {
+		return fmt.Errorf("取消任务关注失败: %w", err)
+	}
+
+	logger.FromContext(ctx).Infof("取消任务关注: TaskID=%d, UserID=%d, OperatorID=%d", taskID, userID, req.OperatorID)
+	return nil
+}
+
+// ListTaskWatchers 获取任务关注者，创建者和当前负责人作为隐式关注者排在前面
+func (s *taskServiceRepo) ListTaskWatchers(ctx context.Context, taskID uint) (*TaskWatchersResponse, error) {
+	task, err := s.taskRepo.GetByID(ctx, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("获取任务失败: %w", err)
+	}
+	watchers, err := s.taskWatcherRepo.ListByTask(ctx, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("获取任务关注者失败: %w", err)
+	}
+
+	result := &TaskWatchersResponse{TaskID: taskID, Watchers: make([]*TaskWatcherResponse, 0, len(watchers)+2)}
+	seen := make(map[uint]bool, len(watchers)+2)
+	appendWatcher := func(watcher *TaskWatcherResponse) {
+		if seen[watcher.UserID] {
+			return
+		}
+		seen[watcher.UserID] = true
+		result.Watchers = append(result.Watchers, watcher)
+	}
+	appendWatcher(&TaskWatcherResponse{UserID: task.CreatorID, Source: TaskWatchSourceCreator})
+	if task.AssigneeID != nil {
+		appendWatcher(&TaskWatcherResponse{UserID: *task.AssigneeID, Source: TaskWatchSourceAssignee})
+	}
+	for _, watcher := range watchers {
+		appendWatcher(toTaskWatcherResponse(watcher))
+	}
+	result.Total = len(result.Watchers)
+	return result, nil
+}
+
+// resolveWatcherTarget 确定关注操作的目标用户并校验操作权限
+func (s *taskServiceRepo) resolveWatcherTarget(req *TaskWatcherRequest) (uint, error) {
+	if req.UserID == 0 || req.UserID == req.OperatorID {
+		return req.OperatorID, nil
+	}
+	if !req.CanManageWatchers {
+		return 0, ErrTaskWatcherForbidden
+	}
+	return req.UserID, nil
+}
+
+// taskWatcherIDs 返回任务的全部关注者用户ID，包括创建者和当前负责人
+func (s *taskServiceRepo) taskWatcherIDs(ctx context.Context, taskID, creatorID uint, assigneeID *uint) []uint {
+	ids := []uint{creatorID}
+	if assigneeID != nil && *assigneeID != creatorID {
+		ids = append(ids, *assigneeID)
+	}
+	if s.taskWatcherRepo == nil {
+		return ids
+	}
+
+	watchers, err := s.taskWatcherRepo.ListByTask(ctx, taskID)
+	if err != nil {
+		logger.FromContext(ctx).Warnf("获取任务关注者失败: TaskID=%d, error: %v", taskID, err)
+		return ids
+	}
+	for _, watcher := range watchers {
+		if watcher.UserID != creatorID && (assigneeID == nil || watcher.UserID != *assigneeID) {
+			ids = append(ids, watcher.UserID)
+		}
+	}
+	return ids
+}
+
+// notifyTaskWatchers 异步通知任务关注者，不通知操作人本人；通知失败只记录日志
+func (s *taskServiceRepo) notifyTaskWatchers(ctx context.Context, task *database.Task, actorID uint, notificationType models.TaskNotificationType, title, content string) {
+	if s.notificationService == nil {
+		return
+	}
+
+	// 请求结束后继续发送，任务对象可能被调用方继续修改，先复制需要的字段
+	ctx = context.WithoutCancel(ctx)
+	taskID, creatorID := task.ID, task.CreatorID
+	var assigneeID *uint
+	if task.AssigneeID != nil {
+		id := *task.AssigneeID
+		assigneeID = &id
+	}
+
+	go func() {
+		for _, recipientID := range s.taskWatcherIDs(ctx, taskID, creatorID, assigneeID) {
+			if recipientID == actorID {
+				continue
+			}
+			if err := s.notificationService.CreateTaskStatusNotification(ctx, taskID, recipientID, notificationType, title, content); err != nil {
+				logger.FromContext(ctx).Warnf("发送任务关注通知失败: TaskID=%d, UserID=%d, error: %v", taskID, recipientID, err)
+			}
+		}
+	}()
+}
+
+// toTaskWatcherResponse 转换任务关注者响应
+func toTaskWatcherResponse(watcher *database.TaskWatcher) *TaskWatcherResponse {
+	addedBy := watcher.AddedBy
+	createdAt := watcher.CreatedAt
+	return &TaskWatcherResponse{
+		UserID:    watcher.UserID,
+		Source:    TaskWatchSourceSubscribed,
+		AddedBy:   &addedBy,
+		CreatedAt: &createdAt,
+	}
+}
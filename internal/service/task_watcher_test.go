@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/models"
+	"taskmanage/internal/repository"
+)
+
+// memoryTaskWatcherRepository 内存中的任务关注者仓储
+type memoryTaskWatcherRepository struct {
+	mu       sync.Mutex
+	watchers []*database.TaskWatcher
+}
+
+func (r *memoryTaskWatcherRepository) Add(ctx context.Context, watcher *database.TaskWatcher) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, w := range r.watchers {
+		if w.TaskID == watcher.TaskID && w.UserID == watcher.UserID {
+			return nil
+		}
+	}
+	r.watchers = append(r.watchers, watcher)
+	return nil
+}
+
+func (r *memoryTaskWatcherRepository) Remove(ctx context.Context, taskID, userID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, w := range r.watchers {
+		if w.TaskID == taskID && w.UserID == userID {
+			r.watchers = append(r.watchers[:i], r.watchers[i+1:]...)
+			return nil
+		}
+	}
+	return repository.ErrNotFound
+}
+
+func (r *memoryTaskWatcherRepository) ListByTask(ctx context.Context, taskID uint) ([]*database.TaskWatcher, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var result []*database.TaskWatcher
+	for _, w := range r.watchers {
+		if w.TaskID == taskID {
+			result = append(result, w)
+		}
+	}
+	return result, nil
+}
+
+func (r *memoryTaskWatcherRepository) CountByTask(ctx context.Context, taskID uint) (int64, error) {
+	watchers, _ := r.ListByTask(ctx, taskID)
+	return int64(len(watchers)), nil
+}
+
+func (r *memoryTaskWatcherRepository) Exists(ctx context.Context, taskID, userID uint) (bool, error) {
+	watchers, _ := r.ListByTask(ctx, taskID)
+	for _, w := range watchers {
+		if w.UserID == userID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// watcherNotificationService 并发安全地记录任务通知的接收人
+type watcherNotificationService struct {
+	NotificationService
+	mu         sync.Mutex
+	recipients []uint
+	types      []models.TaskNotificationType
+}
+
+func (n *watcherNotificationService) CreateTaskStatusNotification(ctx context.Context, taskID, recipientID uint, notificationType models.TaskNotificationType, title, content string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.recipients = append(n.recipients, recipientID)
+	n.types = append(n.types, notificationType)
+	return nil
+}
+
+func (n *watcherNotificationService) snapshot() []uint {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]uint(nil), n.recipients...)
+}
+
+func TestTaskWatchers(t *testing.T) {
+	ctx := context.Background()
+	assigneeID := uint(20)
+	task := &database.Task{BaseModel: database.BaseModel{ID: 1}, Title: "接口联调", Status: "in_progress", CreatorID: 10, AssigneeID: &assigneeID}
+	taskRepo := new(MockTaskRepository)
+	taskRepo.On("GetByID", mock.Anything, uint(1)).Return(task, nil)
+	watcherRepo := &memoryTaskWatcherRepository{}
+	userRepo := &emailUserRepository{users: map[uint]*database.User{31: {BaseModel: database.BaseModel{ID: 31}}}}
+	svc := &taskServiceRepo{taskRepo: taskRepo, userRepo: userRepo, taskWatcherRepo: watcherRepo}
+
+	_, err := svc.AddTaskWatcher(ctx, 1, &TaskWatcherRequest{OperatorID: 30})
+	require.NoError(t, err)
+
+	// 为他人添加关注需要管理权限
+	_, err = svc.AddTaskWatcher(ctx, 1, &TaskWatcherRequest{UserID: 31, OperatorID: 30})
+	assert.ErrorIs(t, err, ErrTaskWatcherForbidden)
+	watcher, err := svc.AddTaskWatcher(ctx, 1, &TaskWatcherRequest{UserID: 31, OperatorID: 40, CanManageWatchers: true})
+	require.NoError(t, err)
+	assert.Equal(t, uint(40), *watcher.AddedBy)
+
+	list, err := svc.ListTaskWatchers(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, 4, list.Total)
+	assert.Equal(t, TaskWatchSourceCreator, list.Watchers[0].Source)
+	assert.Equal(t, TaskWatchSourceAssignee, list.Watchers[1].Source)
+
+	// 已关注时不占用名额，超过上限后拒绝新关注者
+	for id := uint(100); id < 100+MaxTaskWatchers-2; id++ {
+		_, err = svc.AddTaskWatcher(ctx, 1, &TaskWatcherRequest{OperatorID: id})
+		require.NoError(t, err)
+	}
+	_, err = svc.AddTaskWatcher(ctx, 1, &TaskWatcherRequest{OperatorID: 30})
+	require.NoError(t, err)
+	_, err = svc.AddTaskWatcher(ctx, 1, &TaskWatcherRequest{OperatorID: 999})
+	assert.ErrorIs(t, err, ErrTaskWatcherLimit)
+
+	require.NoError(t, svc.RemoveTaskWatcher(ctx, 1, &TaskWatcherRequest{OperatorID: 30}))
+	err = svc.RemoveTaskWatcher(ctx, 1, &TaskWatcherRequest{OperatorID: 10})
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+}
+
+func TestTaskWatcherNotifications(t *testing.T) {
+	ctx := context.Background()
+	assigneeID := uint(20)
+	task := &database.Task{BaseModel: database.BaseModel{ID: 1}, Title: "接口联调", Status: "in_progress", CreatorID: 10, AssigneeID: &assigneeID}
+	taskRepo := new(MockTaskRepository)
+	employeeRepo := new(MockEmployeeRepository)
+	taskRepo.On("GetByID", ctx, uint(1)).Return(task, nil)
+	taskRepo.On("Update", ctx, task).Return(nil)
+	employeeRepo.On("GetByUserID", ctx, assigneeID).Return((*database.Employee)(nil), repository.ErrNotFound)
+	watcherRepo := &memoryTaskWatcherRepository{watchers: []*database.TaskWatcher{
+		{TaskID: 1, UserID: 30, AddedBy: 30},
+		{TaskID: 1, UserID: 20, AddedBy: 20},
+	}}
+	notifications := &watcherNotificationService{}
+	svc := &taskServiceRepo{taskRepo: taskRepo, employeeRepo: employeeRepo, taskWatcherRepo: watcherRepo, notificationService: notifications}
+
+	// 创建者取消任务：负责人和关注者收到通知，创建者本人和重复的负责人不重复通知
+	require.NoError(t, svc.CancelTask(ctx, 1, 10, &CancelTaskRequest{Reason: "需求变更"}))
+	assert.Eventually(t, func() bool { return len(notifications.snapshot()) == 2 }, time.Second, 10*time.Millisecond)
+	assert.ElementsMatch(t, []uint{20, 30}, notifications.snapshot())
+	assert.Equal(t, models.NotificationTypeTaskCancelled, notifications.types[0])
+}
@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+	"taskmanage/pkg/logger"
+)
+
+// MaxTimeEntryMinutes 单条工时记录的时长上限（不含），即24小时
+const MaxTimeEntryMinutes = 24 * 60
+
+var (
+	// ErrInvalidTimeEntry 工时记录参数不合法
+	ErrInvalidTimeEntry = errors.New("工时记录参数不合法")
+
+	// ErrTimeEntryForbidden 当前用户无权记录或删除该工时
+	ErrTimeEntryForbidden = errors.New("无权操作该任务的工时记录")
+)
+
+// CreateTimeEntryRequest 记录工时请求
+type CreateTimeEntryRequest struct {
+	StartedAt       time.Time `json:"started_at" binding:"required"`
+	DurationMinutes int       `json:"duration_minutes" binding:"required"`
+	Note            string    `json:"note" binding:"max=500"`
+}
+
+// TimeEntryResponse 工时记录响应
+type TimeEntryResponse struct {
+	ID              uint      `json:"id"`
+	TaskID          uint      `json:"task_id"`
+	UserID          uint      `json:"user_id"`
+	StartedAt       time.Time `json:"started_at"`
+	DurationMinutes int       `json:"duration_minutes"`
+	Note            string    `json:"note"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// TaskTimeEntriesResponse 任务工时记录列表响应
+type TaskTimeEntriesResponse struct {
+	TaskID       uint                 `json:"task_id"`
+	TotalMinutes int64                `json:"total_minutes"`
+	TotalHours   float64              `json:"total_hours"`
+	Entries      []*TimeEntryResponse `json:"entries"`
+}
+
+// TaskTimeSummary 单个任务的周工时汇总
+type TaskTimeSummary struct {
+	TaskID       uint    `json:"task_id"`
+	TaskTitle    string  `json:"task_title"`
+	TotalMinutes int64   `json:"total_minutes"`
+	TotalHours   float64 `json:"total_hours"`
+	EntryCount   int     `json:"entry_count"`
+}
+
+// UserTimeSummaryResponse 用户周工时汇总响应
+type UserTimeSummaryResponse struct {
+	UserID       uint               `json:"user_id"`
+	WeekStart    string             `json:"week_start"` // 周一，格式: 2006-01-02
+	WeekEnd      string             `json:"week_end"`   // 周日，格式: 2006-01-02
+	TotalMinutes int64              `json:"total_minutes"`
+	TotalHours   float64            `json:"total_hours"`
+	Tasks        []*TaskTimeSummary `json:"tasks"`
+}
+
+// AddTimeEntry 记录任务工时
+func (s *taskServiceRepo) AddTimeEntry(ctx context.Context, taskID uint, userID uint, req *CreateTimeEntryRequest) (*TimeEntryResponse, error) {
+	if req.DurationMinutes <= 0 || req.DurationMinutes >= MaxTimeEntryMinutes {
+		return nil, fmt.Errorf("%w: 单条工时需大于0且小于24小时", ErrInvalidTimeEntry)
+	}
+	if req.StartedAt.After(time.Now()) {
+		return nil, fmt.Errorf("%w: 不能记录未来的工时", ErrInvalidTimeEntry)
+	}
+
+	task, err := s.taskRepo.GetByID(ctx, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("获取任务失败: %w", err)
+	}
+	if err := s.checkTimeEntryPermission(ctx, task, userID); err != nil {
+		return nil, err
+	}
+	if task.Status == "completed" || task.Status == "cancelled" {
+		return nil, fmt.Errorf("%w: 已完成或已取消的任务不能再记录工时", ErrInvalidTimeEntry)
+	}
+	if task.StartedAt == nil {
+		return nil, fmt.Errorf("%w: 任务尚未开始", ErrInvalidTimeEntry)
+	}
+	if req.StartedAt.Before(*task.StartedAt) {
+		return nil, fmt.Errorf("%w: 工时开始时间不能早于任务开始时间", ErrInvalidTimeEntry)
+	}
+
+	entry := &database.TimeEntry{
+		TaskID:          taskID,
+		UserID:          userID,
+		StartedAt:       req.StartedAt,
+		DurationMinutes: req.DurationMinutes,
+		Note:            req.Note,
+	}
+	if err := s.timeEntryRepo.Create(ctx, entry); err != nil {
+		return nil, fmt.Errorf("创建工时记录失败: %w", err)
+	}
+
+	logger.Infof("记录工时成功: TaskID=%d, UserID=%d, Minutes=%d", taskID, userID, req.DurationMinutes)
+	return toTimeEntryResponse(entry), nil
+}
+
+// ListTimeEntries 获取任务的工时记录
+func (s *taskServiceRepo) ListTimeEntries(ctx context.Context, taskID uint) (*TaskTimeEntriesResponse, error) {
+	if _, err := s.taskRepo.GetByID(ctx, taskID); err != nil {
+		return nil, fmt.Errorf("获取任务失败: %w", err)
+	}
+
+	entries, err := s.timeEntryRepo.ListByTask(ctx, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("获取工时记录失败: %w", err)
+	}
+
+	result := &TaskTimeEntriesResponse{
+		TaskID:  taskID,
+		Entries: make([]*TimeEntryResponse, 0, len(entries)),
+	}
+	for _, entry := range entries {
+		result.TotalMinutes += int64(entry.DurationMinutes)
+		result.Entries = append(result.Entries, toTimeEntryResponse(entry))
+	}
+	result.TotalHours = minutesToHours(result.TotalMinutes)
+	return result, nil
+}
+
+// DeleteTimeEntry 删除工时记录，仅记录人本人可删除
+func (s *taskServiceRepo) DeleteTimeEntry(ctx context.Context, taskID uint, entryID uint, userID uint) error {
+	entry, err := s.timeEntryRepo.GetByID(ctx, entryID)
+	if err != nil {
+		return fmt.Errorf("获取工时记录失败: %w", err)
+	}
+	if entry.TaskID != taskID {
+		return fmt.Errorf("获取工时记录失败: %w", repository.ErrNotFound)
+	}
+	if entry.UserID != userID {
+		return fmt.Errorf("%w: 只能删除自己记录的工时", ErrTimeEntryForbidden)
+	}
+
+	task, err := s.taskRepo.GetByID(ctx, taskID)
+	if err != nil {
+		return fmt.Errorf("获取任务失败: %w", err)
+	}
+	if task.Status == "completed" || task.Status == "cancelled" {
+		return fmt.Errorf("%w: 已完成或已取消的任务不能删除工时", ErrInvalidTimeEntry)
+	}
+
+	if err := s.timeEntryRepo.Delete(ctx, entryID); err != nil {
+		return fmt.Errorf("删除工时记录失败: %w", err)
+	}
+
+	logger.Infof("删除工时成功: TaskID=%d, EntryID=%d, UserID=%d", taskID, entryID, userID)
+	return nil
+}
+
+// GetUserTimeSummary 按任务汇总用户在指定日期所在周（周一至周日）的工时
+func (s *taskServiceRepo) GetUserTimeSummary(ctx context.Context, userID uint, week time.Time) (*UserTimeSummaryResponse, error) {
+	weekStart := startOfWeek(week)
+	weekEnd := weekStart.AddDate(0, 0, 7)
+
+	entries, err := s.timeEntryRepo.ListByUserInRange(ctx, userID, weekStart, weekEnd)
+	if err != nil {
+		return nil, fmt.Errorf("获取工时记录失败: %w", err)
+	}
+
+	result := &UserTimeSummaryResponse{
+		UserID:    userID,
+		WeekStart: weekStart.Format("2006-01-02"),
+		WeekEnd:   weekEnd.AddDate(0, 0, -1).Format("2006-01-02"),
+		Tasks:     make([]*TaskTimeSummary, 0),
+	}
+
+	byTask := make(map[uint]*TaskTimeSummary)
+	for _, entry := range entries {
+		summary, ok := byTask[entry.TaskID]
+		if !ok {
+			summary = &TaskTimeSummary{TaskID: entry.TaskID, TaskTitle: entry.Task.Title}
+			byTask[entry.TaskID] = summary
+			result.Tasks = append(result.Tasks, summary)
+		}
+		summary.TotalMinutes += int64(entry.DurationMinutes)
+		summary.EntryCount++
+		result.TotalMinutes += int64(entry.DurationMinutes)
+	}
+
+	for _, summary := range result.Tasks {
+		summary.TotalHours = minutesToHours(summary.TotalMinutes)
+	}
+	sort.SliceStable(result.Tasks, func(i, j int) bool {
+		return result.Tasks[i].TotalMinutes > result.Tasks[j].TotalMinutes
+	})
+	result.TotalHours = minutesToHours(result.TotalMinutes)
+	return result, nil
+}
+
+// checkTimeEntryPermission 校验用户是否为任务的创建者或被分配者
+func (s *taskServiceRepo) checkTimeEntryPermission(ctx context.Context, task *database.Task, userID uint) error {
+	if task.CreatorID == userID {
+		return nil
+	}
+	if task.AssigneeID != nil {
+		if *task.AssigneeID == userID {
+			return nil
+		}
+		// 手动分配时任务的被分配者记录的是员工ID
+		employee, err := s.employeeRepo.GetByUserID(ctx, userID)
+		if err == nil && employee != nil && employee.ID == *task.AssigneeID {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: 只能为分配给自己或自己创建的任务记录工时", ErrTimeEntryForbidden)
+}
+
+// toTimeEntryResponse 转换工时记录响应
+func toTimeEntryResponse(entry *database.TimeEntry) *TimeEntryResponse {
+	return &TimeEntryResponse{
+		ID:              entry.ID,
+		TaskID:          entry.TaskID,
+		UserID:          entry.UserID,
+		StartedAt:       entry.StartedAt,
+		DurationMinutes: entry.DurationMinutes,
+		Note:            entry.Note,
+		CreatedAt:       entry.CreatedAt,
+	}
+}
+
+// minutesToHours 分钟换算为小时，保留两位小数
+func minutesToHours(minutes int64) float64 {
+	return math.Round(float64(minutes)/60*100) / 100
+}
+
+// startOfWeek 返回指定时间所在周的周一零点
+func startOfWeek(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	year, month, day := t.Date()
+	return time.Date(year, month, day-offset, 0, 0, 0, 0, t.Location())
+}
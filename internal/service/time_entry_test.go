@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+// fakeTimeEntryRepository 测试用内存工时记录仓储
+type fakeTimeEntryRepository struct {
+	entries []*database.TimeEntry
+}
+
+func (f *fakeTimeEntryRepository) Create(ctx context.Context, entry *database.TimeEntry) error {
+	entry.ID = uint(len(f.entries) + 1)
+	f.entries = append(f.entries, entry)
+	return nil
+}
+
+func (f *fakeTimeEntryRepository) GetByID(ctx context.Context, id uint) (*database.TimeEntry, error) {
+	for _, entry := range f.entries {
+		if entry.ID == id {
+			return entry, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (f *fakeTimeEntryRepository) Delete(ctx context.Context, id uint) error {
+	for i, entry := range f.entries {
+		if entry.ID == id {
+			f.entries = append(f.entries[:i], f.entries[i+1:]...)
+			return nil
+		}
+	}
+	return repository.ErrNotFound
+}
+
+func (f *fakeTimeEntryRepository) ListByTask(ctx context.Context, taskID uint) ([]*database.TimeEntry, error) {
+	var result []*database.TimeEntry
+	for _, entry := range f.entries {
+		if entry.TaskID == taskID {
+			result = append(result, entry)
+		}
+	}
+	return result, nil
+}
+
+func (f *fakeTimeEntryRepository) SumMinutesByTask(ctx context.Context, taskID uint) (int64, error) {
+	var total int64
+	for _, entry := range f.entries {
+		if entry.TaskID == taskID {
+			total += int64(entry.DurationMinutes)
+		}
+	}
+	return total, nil
+}
+
+func (f *fakeTimeEntryRepository) ListByUserInRange(ctx context.Context, userID uint, from, to time.Time) ([]*database.TimeEntry, error) {
+	var result []*database.TimeEntry
+	for _, entry := range f.entries {
+		if entry.UserID == userID && !entry.StartedAt.Before(from) && entry.StartedAt.Before(to) {
+			result = append(result, entry)
+		}
+	}
+	return result, nil
+}
+
+// SumProjectHoursBySlot 工时记录不含项目信息，测试中不按项目汇总
+func (f *fakeTimeEntryRepository) SumProjectHoursBySlot(ctx context.Context, projectID uint, from, to time.Time) ([]repository.TimeSlotSum, error) {
+	return nil, nil
+}
+
+func TestAddTimeEntry_Validation(t *testing.T) {
+	ctx := context.Background()
+	startedAt := time.Now().Add(-48 * time.Hour)
+	assigneeID := uint(2)
+	task := &database.Task{BaseModel: database.BaseModel{ID: 1}, Status: "in_progress", CreatorID: 1, AssigneeID: &assigneeID, StartedAt: &startedAt}
+
+	taskRepo := new(MockTaskRepository)
+	taskRepo.On("GetByID", ctx, uint(1)).Return(task, nil)
+	employeeRepo := new(MockEmployeeRepository)
+	employeeRepo.On("GetByUserID", ctx, uint(3)).Return(&database.Employee{BaseModel: database.BaseModel{ID: 9}}, nil)
+	timeEntryRepo := &fakeTimeEntryRepository{}
+	svc := &taskServiceRepo{taskRepo: taskRepo, employeeRepo: employeeRepo, timeEntryRepo: timeEntryRepo}
+
+	_, err := svc.AddTimeEntry(ctx, 1, 2, &CreateTimeEntryRequest{StartedAt: startedAt.Add(time.Hour), DurationMinutes: MaxTimeEntryMinutes})
+	assert.ErrorIs(t, err, ErrInvalidTimeEntry)
+
+	_, err = svc.AddTimeEntry(ctx, 1, 2, &CreateTimeEntryRequest{StartedAt: startedAt.Add(-time.Hour), DurationMinutes: 30})
+	assert.ErrorIs(t, err, ErrInvalidTimeEntry)
+
+	_, err = svc.AddTimeEntry(ctx, 1, 3, &CreateTimeEntryRequest{StartedAt: startedAt.Add(time.Hour), DurationMinutes: 30})
+	assert.ErrorIs(t, err, ErrTimeEntryForbidden)
+
+	// 被分配者与创建者均可记录
+	_, err = svc.AddTimeEntry(ctx, 1, 2, &CreateTimeEntryRequest{StartedAt: startedAt.Add(time.Hour), DurationMinutes: 90})
+	require.NoError(t, err)
+	_, err = svc.AddTimeEntry(ctx, 1, 1, &CreateTimeEntryRequest{StartedAt: startedAt.Add(2 * time.Hour), DurationMinutes: 45})
+	require.NoError(t, err)
+
+	entries, err := svc.ListTimeEntries(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(135), entries.TotalMinutes)
+	assert.Equal(t, 2.25, entries.TotalHours)
+
+	// 只能删除自己记录的工时
+	assert.ErrorIs(t, svc.DeleteTimeEntry(ctx, 1, 1, 1), ErrTimeEntryForbidden)
+	require.NoError(t, svc.DeleteTimeEntry(ctx, 1, 1, 2))
+}
+
+func TestCompleteTask_SumsTimeEntries(t *testing.T) {
+	ctx := context.Background()
+	startedAt := time.Now().Add(-time.Hour)
+	assigneeID := uint(2)
+	task := &database.Task{BaseModel: database.BaseModel{ID: 1}, Status: "in_progress", CreatorID: 1, AssigneeID: &assigneeID, StartedAt: &startedAt}
+
+	taskRepo := new(MockTaskRepository)
+	taskRepo.On("GetByID", ctx, uint(1)).Return(task, nil)
+	taskRepo.On("Update", ctx, mock.AnythingOfType("*database.Task")).Return(nil)
+	employeeRepo := new(MockEmployeeRepository)
+	employeeRepo.On("GetByUserID", ctx, uint(2)).Return((*database.Employee)(nil), repository.ErrNotFound)
+	timeEntryRepo := &fakeTimeEntryRepository{entries: []*database.TimeEntry{
+		{TaskID: 1, UserID: 2, DurationMinutes: 50},
+		{TaskID: 1, UserID: 1, DurationMinutes: 40},
+		{TaskID: 2, UserID: 2, DurationMinutes: 600},
+	}}
+	svc := &taskServiceRepo{taskRepo: taskRepo, employeeRepo: employeeRepo, timeEntryRepo: timeEntryRepo}
+
+	_, err := svc.CompleteTask(ctx, 1, 2, &CompleteTaskRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "completed", task.Status)
+	assert.Equal(t, 1.5, task.ActualHours)
+}
+
+func TestGetUserTimeSummary_GroupsByTaskWithinWeek(t *testing.T) {
+	// 2026-10-14 为周三，所在周为 10-12 至 10-18
+	monday := time.Date(2026, 10, 12, 0, 0, 0, 0, time.Local)
+	timeEntryRepo := &fakeTimeEntryRepository{entries: []*database.TimeEntry{
+		{TaskID: 1, UserID: 2, StartedAt: monday.Add(9 * time.Hour), DurationMinutes: 60, Task: database.Task{Title: "A"}},
+		{TaskID: 2, UserID: 2, StartedAt: monday.AddDate(0, 0, 2), DurationMinutes: 120, Task: database.Task{Title: "B"}},
+		{TaskID: 1, UserID: 2, StartedAt: monday.AddDate(0, 0, 6).Add(23 * time.Hour), DurationMinutes: 30, Task: database.Task{Title: "A"}},
+		{TaskID: 1, UserID: 2, StartedAt: monday.AddDate(0, 0, 7), DurationMinutes: 600, Task: database.Task{Title: "A"}},
+		{TaskID: 1, UserID: 3, StartedAt: monday.Add(time.Hour), DurationMinutes: 600, Task: database.Task{Title: "A"}},
+	}}
+	svc := &taskServiceRepo{timeEntryRepo: timeEntryRepo}
+
+	summary, err := svc.GetUserTimeSummary(context.Background(), 2, monday.AddDate(0, 0, 2))
+	require.NoError(t, err)
+	assert.Equal(t, "2026-10-12", summary.WeekStart)
+	assert.Equal(t, "2026-10-18", summary.WeekEnd)
+	assert.Equal(t, int64(210), summary.TotalMinutes)
+	require.Len(t, summary.Tasks, 2)
+	assert.Equal(t, uint(2), summary.Tasks[0].TaskID)
+	assert.Equal(t, int64(90), summary.Tasks[1].TotalMinutes)
+	assert.Equal(t, 2, summary.Tasks[1].EntryCount)
+}
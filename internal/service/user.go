@@ -19,6 +19,7 @@ type userService struct {
 	userRepo     repository.UserRepository
 	employeeRepo repository.EmployeeRepository
 	repoManager  repository.RepositoryManager
+	employeeNos  EmployeeNumberGenerator
 	config       *config.Config
 	logger       *logrus.Logger
 }
@@ -29,6 +30,7 @@ func NewUserService(repoManager repository.RepositoryManager, cfg *config.Config
 		userRepo:     repoManager.UserRepository(),
 		employeeRepo: repoManager.EmployeeRepository(),
 		repoManager:  repoManager,
+		employeeNos:  NewEmployeeNumberGenerator(repoManager, cfg.EmployeeNumber),
 		config:       cfg,
 		logger:       logger.GetLogger(),
 	}
@@ -81,11 +83,26 @@ func (s *userService) CreateUser(ctx context.Context, req *CreateUserRequest) (*
 	}
 
 	// 自动创建对应的员工记录
+	s.createDefaultEmployee(ctx, user)
+
+	logger.Infof("用户创建成功: ID=%d, Username=%s", user.ID, user.Username)
+
+	return UserToResponse(user), nil
+}
+
+// createDefaultEmployee 为新用户创建待入职员工记录，失败不影响用户创建，只记录警告
+func (s *userService) createDefaultEmployee(ctx context.Context, user *database.User) {
+	employeeNo, err := s.employeeNos.Assign(ctx, "", user.ID)
+	if err != nil {
+		logger.Warnf("分配员工编号失败: %v", err)
+		return
+	}
+
 	defaultDeptID := uint(1)
 	defaultPosID := uint(1)
 	employee := &database.Employee{
 		UserID:           user.ID,
-		EmployeeNo:       fmt.Sprintf("EMP%06d", user.ID), // 生成员工编号
+		EmployeeNo:       employeeNo,
 		DepartmentID:     &defaultDeptID, // TODO: 需要创建默认部门或从请求中获取
 		PositionID:       &defaultPosID,  // TODO: 需要创建默认职位或从请求中获取
 		OnboardingStatus: "pending_onboard", // 默认待入职状态
@@ -96,14 +113,9 @@ func (s *userService) CreateUser(ctx context.Context, req *CreateUserRequest) (*
 
 	if err := s.employeeRepo.Create(ctx, employee); err != nil {
 		logger.Warnf("创建员工记录失败: %v", err)
-		// 员工记录创建失败不影响用户创建，只记录警告
-	} else {
-		logger.Infof("员工记录创建成功: EmployeeID=%d, EmployeeNo=%s", employee.ID, employee.EmployeeNo)
+		return
 	}
-
-	logger.Infof("用户创建成功: ID=%d, Username=%s", user.ID, user.Username)
-
-	return UserToResponse(user), nil
+	logger.Infof("员工记录创建成功: EmployeeID=%d, EmployeeNo=%s", employee.ID, employee.EmployeeNo)
 }
 
 // GetUser 获取用户信息
@@ -251,6 +263,11 @@ func (s *userService) Logout(ctx context.Context, userID uint) error {
 	return nil
 }
 
+// RecordLogin 记录用户最后登录时间和IP
+func (s *userService) RecordLogin(ctx context.Context, userID uint, ip string) error {
+	return s.userRepo.UpdateLastLogin(ctx, userID, ip)
+}
+
 // ChangePassword 修改密码
 func (s *userService) ChangePassword(ctx context.Context, userID uint, req *ChangePasswordRequest) error {
 	user, err := s.userRepo.GetByID(ctx, userID)
@@ -289,37 +306,22 @@ func (s *userService) ChangePassword(ctx context.Context, userID uint, req *Chan
 	return nil
 }
 
-// HasPermission 检查用户权限
+// HasPermission 检查用户权限，包含角色权限及生效中的权限分配
 func (s *userService) HasPermission(ctx context.Context, userID uint, resource, action string) (bool, error) {
-	permRepo := s.repoManager.PermissionRepository()
-
-	// 获取用户的所有权限
-	permissions, err := permRepo.GetUserPermissions(ctx, userID)
+	hasPermission, err := NewPermissionService(s.repoManager, nil).HasPermission(ctx, userID, resource, action)
 	if err != nil {
 		s.logger.WithError(err).WithField("user_id", userID).Error("获取用户权限失败")
 		return false, err
 	}
 
-	// 检查是否有匹配的权限
-	for _, perm := range permissions {
-		if perm.Resource == resource && perm.Action == action {
-			s.logger.WithFields(map[string]interface{}{
-				"user_id":    userID,
-				"resource":   resource,
-				"action":     action,
-				"permission": perm.Name,
-			}).Debug("用户权限检查通过")
-			return true, nil
-		}
-	}
-
 	s.logger.WithFields(map[string]interface{}{
 		"user_id":  userID,
 		"resource": resource,
 		"action":   action,
-	}).Debug("用户权限检查失败")
+		"allowed":  hasPermission,
+	}).Debug("用户权限检查")
 
-	return false, nil
+	return hasPermission, nil
 }
 
 // AssignRoles 分配角色
@@ -0,0 +1,568 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"taskmanage/internal/config"
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+	"taskmanage/pkg/logger"
+	"taskmanage/pkg/response"
+	"taskmanage/pkg/webhook"
+)
+
+// Webhook投递状态
+const (
+	WebhookDeliveryStatusPending   = "pending"
+	WebhookDeliveryStatusDelivered = "delivered"
+	WebhookDeliveryStatusFailed    = "failed"
+)
+
+const (
+	// DefaultWebhookDeliveryInterval 后台投递待发送事件的默认间隔
+	DefaultWebhookDeliveryInterval = 10 * time.Second
+	// defaultWebhookMaxAttempts 单个事件默认最多投递次数
+	defaultWebhookMaxAttempts = 8
+	// defaultWebhookTimeout 单次投递默认等待响应的超时
+	defaultWebhookTimeout = 10 * time.Second
+	// webhookRetryBaseDelay 首次重试的等待时间，之后每次翻倍
+	webhookRetryBaseDelay = 30 * time.Second
+	// webhookRetryMaxDelay 重试等待时间上限
+	webhookRetryMaxDelay = time.Hour
+	// webhookBatchSize 每轮最多投递的事件数
+	webhookBatchSize = 50
+)
+
+var (
+	// ErrInvalidWebhookEndpoint Webhook端点配置不合法
+	ErrInvalidWebhookEndpoint = response.NewError(response.ErrCodeInvalidRequest, "Webhook端点配置不合法")
+	// ErrWebhookEndpointNotFound Webhook端点不存在
+	ErrWebhookEndpointNotFound = response.NewError(response.ErrCodeNotFound, "Webhook端点不存在")
+)
+
+// CreateWebhookEndpointRequest 创建Webhook端点请求
+type CreateWebhookEndpointRequest struct {
+	Name    string   `json:"name" binding:"required,max=100"`
+	URL     string   `json:"url" binding:"required,max=500"`
+	Secret  string   `json:"secret" binding:"omitempty,min=16,max=100"` // 签名密钥，为空时自动生成
+	Events  []string `json:"events"`                                    // 订阅的事件类型，为空时订阅全部事件
+	Enabled *bool    `json:"enabled"`                                   // 默认启用
+}
+
+// UpdateWebhookEndpointRequest 更新Webhook端点请求，未传的字段保持不变
+type UpdateWebhookEndpointRequest struct {
+	Name         *string   `json:"name" binding:"omitempty,max=100"`
+	URL          *string   `json:"url" binding:"omitempty,max=500"`
+	Events       *[]string `json:"events"`
+	Enabled      *bool     `json:"enabled"`
+	RotateSecret bool      `json:"rotate_secret"` // 重新生成签名密钥，新密钥只在本次响应中返回
+}
+
+// WebhookEndpointResponse Webhook端点响应，签名密钥只在创建和重新生成时返回
+type WebhookEndpointResponse struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	URL       string    `json:"url"`
+	Enabled   bool      `json:"enabled"`
+	Events    []string  `json:"events"`
+	Secret    string    `json:"secret,omitempty"`
+	CreatedBy uint      `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// WebhookDeliveryListRequest 投递记录查询请求
+type WebhookDeliveryListRequest struct {
+	Status    string `form:"status" binding:"omitempty,oneof=pending delivered failed"`
+	EventType string `form:"event_type"`
+	Page      int    `form:"page"`
+	PageSize  int    `form:"page_size"`
+}
+
+// WebhookDeliveryAttemptResponse 单次发送尝试
+type WebhookDeliveryAttemptResponse struct {
+	Attempt     int       `json:"attempt"`
+	StatusCode  int       `json:"status_code"` // 未收到响应时为0
+	Error       string    `json:"error,omitempty"`
+	DurationMs  int64     `json:"duration_ms"`
+	AttemptedAt time.Time `json:"attempted_at"`
+}
+
+// WebhookDeliveryResponse 投递记录响应
+type WebhookDeliveryResponse struct {
+	ID             uint                              `json:"id"`
+	EndpointID     uint                              `json:"endpoint_id"`
+	EventID        string                            `json:"event_id"`
+	EventType      string                            `json:"event_type"`
+	Status         string                            `json:"status"`
+	Attempts       int                               `json:"attempts"`
+	NextRetryAt    *time.Time                        `json:"next_retry_at,omitempty"`
+	LastStatusCode int                               `json:"last_status_code,omitempty"`
+	LastError      string                            `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time                        `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time                         `json:"created_at"`
+	Payload        json.RawMessage                   `json:"payload" swaggertype:"object"`
+	AttemptLogs    []*WebhookDeliveryAttemptResponse `json:"attempt_logs"`
+}
+
+// WebhookService 集成Webhook服务：维护订阅端点，将业务事件写入持久化发件箱，由后台投递器签名后异步发送
+type WebhookService interface {
+	EventPublisher
+	// CreateEndpoint 创建端点，响应中包含签名密钥
+	CreateEndpoint(ctx context.Context, createdBy uint, req *CreateWebhookEndpointRequest) (*WebhookEndpointResponse, error)
+	// ListEndpoints 获取全部端点
+	ListEndpoints(ctx context.Context) ([]*WebhookEndpointResponse, error)
+	// GetEndpoint 获取端点
+	GetEndpoint(ctx context.Context, id uint) (*WebhookEndpointResponse, error)
+	// UpdateEndpoint 更新端点，重新生成密钥时响应中包含新密钥
+	UpdateEndpoint(ctx context.Context, id uint, req *UpdateWebhookEndpointRequest) (*WebhookEndpointResponse, error)
+	// DeleteEndpoint 删除端点，未投递的事件不再发送
+	DeleteEndpoint(ctx context.Context, id uint) error
+	// ListDeliveries 查询端点的投递记录及每次发送的响应状态码，用于排查对接问题
+	ListDeliveries(ctx context.Context, endpointID uint, req *WebhookDeliveryListRequest) (*ListResponse[*WebhookDeliveryResponse], error)
+	// ProcessOutbox 投递到期的事件，返回投递成功数
+	ProcessOutbox(ctx context.Context, now time.Time) (int, error)
+}
+
+type webhookService struct {
+	endpointRepo repository.WebhookEndpointRepository
+	deliveryRepo repository.WebhookDeliveryRepository
+	sender       webhook.Sender
+	maxAttempts  int
+	now          func() time.Time
+}
+
+// NewWebhookService 创建Webhook服务
+func NewWebhookService(repoManager repository.RepositoryManager, sender webhook.Sender, cfg config.WebhookConfig) WebhookService {
+	maxAttempts := cfg.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultWebhookMaxAttempts
+	}
+	return &webhookService{
+		endpointRepo: repoManager.WebhookEndpointRepository(),
+		deliveryRepo: repoManager.WebhookDeliveryRepository(),
+		sender:       sender,
+		maxAttempts:  maxAttempts,
+		now:          time.Now,
+	}
+}
+
+// NewWebhookSender 按配置创建HTTP Webhook发送器
+func NewWebhookSender(cfg config.WebhookConfig) webhook.Sender {
+	timeout := time.Duration(cfg.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = defaultWebhookTimeout
+	}
+	return webhook.NewHTTPSender(timeout)
+}
+
+// CreateEndpoint 创建端点，未指定密钥时自动生成
+func (s *webhookService) CreateEndpoint(ctx context.Context, createdBy uint, req *CreateWebhookEndpointRequest) (*WebhookEndpointResponse, error) {
+	if err := validateWebhookURL(req.URL); err != nil {
+		return nil, err
+	}
+	events, err := encodeWebhookEvents(req.Events)
+	if err != nil {
+		return nil, err
+	}
+	secret := req.Secret
+	if secret == "" {
+		if secret, err = generateWebhookSecret(); err != nil {
+			return nil, err
+		}
+	}
+
+	endpoint := &database.WebhookEndpoint{
+		Name:      req.Name,
+		URL:       req.URL,
+		Secret:    secret,
+		Enabled:   req.Enabled == nil || *req.Enabled,
+		Events:    events,
+		CreatedBy: createdBy,
+	}
+	if err := s.endpointRepo.Create(ctx, endpoint); err != nil {
+		return nil, err
+	}
+
+	logger.FromContext(ctx).Infof("Webhook端点创建成功: ID=%d, URL=%s", endpoint.ID, endpoint.URL)
+	resp := toWebhookEndpointResponse(endpoint)
+	resp.Secret = endpoint.Secret
+	return resp, nil
+}
+
+// ListEndpoints 获取全部端点
+func (s *webhookService) ListEndpoints(ctx context.Context) ([]*WebhookEndpointResponse, error) {
+	endpoints, err := s.endpointRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	responses := make([]*WebhookEndpointResponse, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		responses = append(responses, toWebhookEndpointResponse(endpoint))
+	}
+	return responses, nil
+}
+
+// GetEndpoint 获取端点
+func (s *webhookService) GetEndpoint(ctx context.Context, id uint) (*WebhookEndpointResponse, error) {
+	endpoint, err := s.getEndpoint(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return toWebhookEndpointResponse(endpoint), nil
+}
+
+// UpdateEndpoint 更新端点
+func (s *webhookService) UpdateEndpoint(ctx context.Context, id uint, req *UpdateWebhookEndpointRequest) (*WebhookEndpointResponse, error) {
+	endpoint, err := s.getEndpoint(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Name != nil {
+		if *req.Name == "" {
+			return nil, response.Wrapf(ErrInvalidWebhookEndpoint, "名称不能为空")
+		}
+		endpoint.Name = *req.Name
+	}
+	if req.URL != nil {
+		if err := validateWebhookURL(*req.URL); err != nil {
+			return nil, err
+		}
+		endpoint.URL = *req.URL
+	}
+	if req.Events != nil {
+		if endpoint.Events, err = encodeWebhookEvents(*req.Events); err != nil {
+			return nil, err
+		}
+	}
+	if req.Enabled != nil {
+		endpoint.Enabled = *req.Enabled
+	}
+	if req.RotateSecret {
+		if endpoint.Secret, err = generateWebhookSecret(); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.endpointRepo.Update(ctx, endpoint); err != nil {
+		return nil, err
+	}
+
+	resp := toWebhookEndpointResponse(endpoint)
+	if req.RotateSecret {
+		logger.FromContext(ctx).Infof("Webhook端点签名密钥已重新生成: ID=%d", endpoint.ID)
+		resp.Secret = endpoint.Secret
+	}
+	return resp, nil
+}
+
+// DeleteEndpoint 删除端点
+func (s *webhookService) DeleteEndpoint(ctx context.Context, id uint) error {
+	if err := s.endpointRepo.Delete(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrWebhookEndpointNotFound.WithCause(err)
+		}
+		return err
+	}
+	logger.FromContext(ctx).Infof("Webhook端点已删除: ID=%d", id)
+	return nil
+}
+
+// ListDeliveries 分页查询端点的投递记录
+func (s *webhookService) ListDeliveries(ctx context.Context, endpointID uint, req *WebhookDeliveryListRequest) (*ListResponse[*WebhookDeliveryResponse], error) {
+	if _, err := s.getEndpoint(ctx, endpointID); err != nil {
+		return nil, err
+	}
+	filter := &repository.WebhookDeliveryFilter{
+		EndpointID: endpointID,
+		Status:     req.Status,
+		EventType:  req.EventType,
+		Page:       req.Page,
+		PageSize:   req.PageSize,
+	}
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PageSize <= 0 {
+		filter.PageSize = 20
+	}
+	if filter.PageSize > 100 {
+		filter.PageSize = 100
+	}
+
+	deliveries, total, err := s.deliveryRepo.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]*WebhookDeliveryResponse, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		items = append(items, toWebhookDeliveryResponse(delivery))
+	}
+	return &ListResponse[*WebhookDeliveryResponse]{
+		Items: items,
+		Total: total,
+		Page:  filter.Page,
+		Size:  filter.PageSize,
+	}, nil
+}
+
+// Publish 为订阅了该事件的已启用端点各写入一条待投递记录，由后台投递器异步发送
+func (s *webhookService) Publish(ctx context.Context, eventType string, data interface{}) error {
+	endpoints, err := s.endpointRepo.ListEnabled(ctx)
+	if err != nil {
+		return err
+	}
+	var subscribers []*database.WebhookEndpoint
+	for _, endpoint := range endpoints {
+		if webhookSubscribes(endpoint, eventType) {
+			subscribers = append(subscribers, endpoint)
+		}
+	}
+	if len(subscribers) == 0 {
+		return nil
+	}
+
+	now := s.now()
+	event := &Event{ID: uuid.New().String(), Type: eventType, OccurredAt: now, Data: data}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("序列化事件失败: %w", err)
+	}
+	deliveries := make([]*database.WebhookDelivery, 0, len(subscribers))
+	for _, endpoint := range subscribers {
+		deliveries = append(deliveries, &database.WebhookDelivery{
+			EndpointID:  endpoint.ID,
+			EventID:     event.ID,
+			EventType:   eventType,
+			Payload:     string(payload),
+			Status:      WebhookDeliveryStatusPending,
+			NextRetryAt: &now,
+		})
+	}
+	return s.deliveryRepo.CreateBatch(ctx, deliveries)
+}
+
+// ProcessOutbox 投递到期事件并记录每次发送的结果，失败时按指数退避安排重试，超过最大次数标记为failed
+// 端点已删除或停用时不再发送，直接标记为failed
+func (s *webhookService) ProcessOutbox(ctx context.Context, now time.Time) (int, error) {
+	deliveries, err := s.deliveryRepo.ListDue(ctx, now, webhookBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	endpoints := make(map[uint]*database.WebhookEndpoint)
+	delivered := 0
+	for _, delivery := range deliveries {
+		endpoint, ok := endpoints[delivery.EndpointID]
+		if !ok {
+			endpoint, err = s.endpointRepo.GetByID(ctx, delivery.EndpointID)
+			if err != nil && !errors.Is(err, repository.ErrNotFound) {
+				logger.Errorf("获取Webhook端点失败: id=%d, error=%v", delivery.EndpointID, err)
+				continue
+			}
+			endpoints[delivery.EndpointID] = endpoint
+		}
+
+		if endpoint == nil || !endpoint.Enabled {
+			delivery.Status = WebhookDeliveryStatusFailed
+			delivery.NextRetryAt = nil
+			delivery.LastError = "端点已删除或停用"
+		} else if s.deliver(ctx, endpoint, delivery, now) {
+			delivered++
+		}
+		if err := s.deliveryRepo.Update(ctx, delivery); err != nil {
+			logger.Errorf("更新Webhook投递状态失败: id=%d, error=%v", delivery.ID, err)
+		}
+	}
+	return delivered, nil
+}
+
+// deliver 发送一次并更新投递状态，返回是否投递成功
+func (s *webhookService) deliver(ctx context.Context, endpoint *database.WebhookEndpoint, delivery *database.WebhookDelivery, now time.Time) bool {
+	started := time.Now()
+	statusCode, sendErr := s.sender.Send(ctx, &webhook.Message{
+		URL:        endpoint.URL,
+		Secret:     endpoint.Secret,
+		EventType:  delivery.EventType,
+		DeliveryID: strconv.FormatUint(uint64(delivery.ID), 10),
+		Body:       []byte(delivery.Payload),
+	})
+	delivery.Attempts++
+	delivery.LastStatusCode = statusCode
+
+	attempt := &database.WebhookDeliveryAttempt{
+		DeliveryID:  delivery.ID,
+		EndpointID:  delivery.EndpointID,
+		Attempt:     delivery.Attempts,
+		StatusCode:  statusCode,
+		DurationMs:  time.Since(started).Milliseconds(),
+		AttemptedAt: now,
+	}
+	if sendErr != nil {
+		attempt.Error = sendErr.Error()
+	}
+	if err := s.deliveryRepo.CreateAttempt(ctx, attempt); err != nil {
+		logger.Errorf("记录Webhook发送尝试失败: delivery_id=%d, error=%v", delivery.ID, err)
+	}
+
+	if sendErr == nil {
+		deliveredAt := now
+		delivery.Status = WebhookDeliveryStatusDelivered
+		delivery.DeliveredAt = &deliveredAt
+		delivery.NextRetryAt = nil
+		delivery.LastError = ""
+		return true
+	}
+
+	delivery.LastError = sendErr.Error()
+	if delivery.Attempts >= s.maxAttempts {
+		delivery.Status = WebhookDeliveryStatusFailed
+		delivery.NextRetryAt = nil
+		logger.Warnf("Webhook投递失败且不再重试: id=%d, endpoint_id=%d, attempts=%d, error=%v", delivery.ID, delivery.EndpointID, delivery.Attempts, sendErr)
+	} else {
+		next := now.Add(webhookRetryDelay(delivery.Attempts))
+		delivery.NextRetryAt = &next
+	}
+	return false
+}
+
+// getEndpoint 获取端点，不存在时返回ErrWebhookEndpointNotFound
+func (s *webhookService) getEndpoint(ctx context.Context, id uint) (*database.WebhookEndpoint, error) {
+	endpoint, err := s.endpointRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrWebhookEndpointNotFound.WithCause(err)
+		}
+		return nil, err
+	}
+	return endpoint, nil
+}
+
+// webhookRetryDelay 第attempts次失败后的重试等待时间
+func webhookRetryDelay(attempts int) time.Duration {
+	delay := webhookRetryBaseDelay
+	for i := 1; i < attempts && delay < webhookRetryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > webhookRetryMaxDelay {
+		delay = webhookRetryMaxDelay
+	}
+	return delay
+}
+
+// validateWebhookURL 端点地址须为http或https的绝对地址
+func validateWebhookURL(raw string) error {
+	parsed, err := url.Parse(raw)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return response.Wrapf(ErrInvalidWebhookEndpoint, "url须为http或https地址")
+	}
+	return nil
+}
+
+// encodeWebhookEvents 校验订阅的事件类型并编码为JSON数组，去除重复项
+func encodeWebhookEvents(events []string) (string, error) {
+	known := make(map[string]bool, len(EventTypes))
+	for _, eventType := range EventTypes {
+		known[eventType] = true
+	}
+	seen := make(map[string]bool, len(events))
+	unique := make([]string, 0, len(events))
+	for _, eventType := range events {
+		if !known[eventType] {
+			return "", response.Wrapf(ErrInvalidWebhookEndpoint, "未知的事件类型: %s", eventType)
+		}
+		if !seen[eventType] {
+			seen[eventType] = true
+			unique = append(unique, eventType)
+		}
+	}
+	encoded, err := json.Marshal(unique)
+	if err != nil {
+		return "", fmt.Errorf("序列化订阅事件失败: %w", err)
+	}
+	return string(encoded), nil
+}
+
+// decodeWebhookEvents 解析端点订阅的事件类型
+func decodeWebhookEvents(endpoint *database.WebhookEndpoint) []string {
+	events := make([]string, 0)
+	if endpoint.Events != "" {
+		if err := json.Unmarshal([]byte(endpoint.Events), &events); err != nil {
+			logger.Warnf("解析Webhook端点订阅事件失败: id=%d, error=%v", endpoint.ID, err)
+		}
+	}
+	return events
+}
+
+// webhookSubscribes 端点是否订阅了该事件，未指定事件类型时订阅全部事件
+func webhookSubscribes(endpoint *database.WebhookEndpoint, eventType string) bool {
+	events := decodeWebhookEvents(endpoint)
+	if len(events) == 0 {
+		return true
+	}
+	for _, subscribed := range events {
+		if subscribed == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// generateWebhookSecret 生成随机签名密钥
+func generateWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("生成签名密钥失败: %w", err)
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+func toWebhookEndpointResponse(endpoint *database.WebhookEndpoint) *WebhookEndpointResponse {
+	return &WebhookEndpointResponse{
+		ID:        endpoint.ID,
+		Name:      endpoint.Name,
+		URL:       endpoint.URL,
+		Enabled:   endpoint.Enabled,
+		Events:    decodeWebhookEvents(endpoint),
+		CreatedBy: endpoint.CreatedBy,
+		CreatedAt: endpoint.CreatedAt,
+		UpdatedAt: endpoint.UpdatedAt,
+	}
+}
+
+func toWebhookDeliveryResponse(delivery *database.WebhookDelivery) *WebhookDeliveryResponse {
+	resp := &WebhookDeliveryResponse{
+		ID:             delivery.ID,
+		EndpointID:     delivery.EndpointID,
+		EventID:        delivery.EventID,
+		EventType:      delivery.EventType,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		NextRetryAt:    delivery.NextRetryAt,
+		LastStatusCode: delivery.LastStatusCode,
+		LastError:      delivery.LastError,
+		DeliveredAt:    delivery.DeliveredAt,
+		CreatedAt:      delivery.CreatedAt,
+		Payload:        json.RawMessage(delivery.Payload),
+		AttemptLogs:    make([]*WebhookDeliveryAttemptResponse, 0, len(delivery.AttemptLogs)),
+	}
+	for _, attempt := range delivery.AttemptLogs {
+		resp.AttemptLogs = append(resp.AttemptLogs, &WebhookDeliveryAttemptResponse{
+			Attempt:     attempt.Attempt,
+			StatusCode:  attempt.StatusCode,
+			Error:       attempt.Error,
+			DurationMs:  attempt.DurationMs,
+			AttemptedAt: attempt.AttemptedAt,
+		})
+	}
+	return resp
+}
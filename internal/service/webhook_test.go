@@ -0,0 +1,291 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+	"taskmanage/internal/workflow"
+	"taskmanage/pkg/webhook"
+)
+
+// memoryWebhookEndpointRepository 测试用内存Webhook端点仓储
+type memoryWebhookEndpointRepository struct {
+	repository.WebhookEndpointRepository
+	endpoints map[uint]*database.WebhookEndpoint
+}
+
+func (r *memoryWebhookEndpointRepository) Create(ctx context.Context, endpoint *database.WebhookEndpoint) error {
+	endpoint.ID = uint(len(r.endpoints) + 1)
+	r.endpoints[endpoint.ID] = endpoint
+	return nil
+}
+
+func (r *memoryWebhookEndpointRepository) GetByID(ctx context.Context, id uint) (*database.WebhookEndpoint, error) {
+	if endpoint, ok := r.endpoints[id]; ok {
+		return endpoint, nil
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *memoryWebhookEndpointRepository) ListEnabled(ctx context.Context) ([]*database.WebhookEndpoint, error) {
+	var enabled []*database.WebhookEndpoint
+	for id := uint(1); id <= uint(len(r.endpoints)); id++ {
+		if endpoint, ok := r.endpoints[id]; ok && endpoint.Enabled {
+			enabled = append(enabled, endpoint)
+		}
+	}
+	return enabled, nil
+}
+
+func (r *memoryWebhookEndpointRepository) Update(ctx context.Context, endpoint *database.WebhookEndpoint) error {
+	return nil
+}
+
+func (r *memoryWebhookEndpointRepository) Delete(ctx context.Context, id uint) error {
+	if _, ok := r.endpoints[id]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(r.endpoints, id)
+	return nil
+}
+
+// memoryWebhookDeliveryRepository 测试用内存Webhook投递记录仓储
+type memoryWebhookDeliveryRepository struct {
+	repository.WebhookDeliveryRepository
+	deliveries []*database.WebhookDelivery
+	attempts   []*database.WebhookDeliveryAttempt
+}
+
+func (r *memoryWebhookDeliveryRepository) CreateBatch(ctx context.Context, deliveries []*database.WebhookDelivery) error {
+	for _, delivery := range deliveries {
+		delivery.ID = uint(len(r.deliveries) + 1)
+		r.deliveries = append(r.deliveries, delivery)
+	}
+	return nil
+}
+
+func (r *memoryWebhookDeliveryRepository) Update(ctx context.Context, delivery *database.WebhookDelivery) error {
+	return nil
+}
+
+func (r *memoryWebhookDeliveryRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]*database.WebhookDelivery, error) {
+	var due []*database.WebhookDelivery
+	for _, delivery := range r.deliveries {
+		if delivery.Status == WebhookDeliveryStatusPending && !delivery.NextRetryAt.After(now) {
+			due = append(due, delivery)
+		}
+	}
+	return due, nil
+}
+
+func (r *memoryWebhookDeliveryRepository) CreateAttempt(ctx context.Context, attempt *database.WebhookDeliveryAttempt) error {
+	r.attempts = append(r.attempts, attempt)
+	return nil
+}
+
+// recordingWebhookSender 记录发送的请求，按预设依次返回状态码，状态码非2xx时返回错误
+type recordingWebhookSender struct {
+	statuses []int
+	sent     []*webhook.Message
+}
+
+func (s *recordingWebhookSender) Send(ctx context.Context, msg *webhook.Message) (int, error) {
+	s.sent = append(s.sent, msg)
+	status := 200
+	if len(s.statuses) > 0 {
+		status, s.statuses = s.statuses[0], s.statuses[1:]
+	}
+	if status == 0 {
+		return 0, errors.New("connection refused")
+	}
+	if status >= 300 {
+		return status, fmt.Errorf("端点返回状态码%d", status)
+	}
+	return status, nil
+}
+
+func newTestWebhookService(now time.Time, sender webhook.Sender) (*webhookService, *memoryWebhookEndpointRepository, *memoryWebhookDeliveryRepository) {
+	endpoints := &memoryWebhookEndpointRepository{endpoints: make(map[uint]*database.WebhookEndpoint)}
+	deliveries := &memoryWebhookDeliveryRepository{}
+	svc := &webhookService{
+		endpointRepo: endpoints,
+		deliveryRepo: deliveries,
+		sender:       sender,
+		maxAttempts:  3,
+		now:          func() time.Time { return now },
+	}
+	return svc, endpoints, deliveries
+}
+
+func TestWebhookService_Endpoints(t *testing.T) {
+	ctx := context.Background()
+	svc, endpoints, _ := newTestWebhookService(time.Now(), &recordingWebhookSender{})
+
+	_, err := svc.CreateEndpoint(ctx, 1, &CreateWebhookEndpointRequest{Name: "CI", URL: "ftp://example.com/hook"})
+	assert.ErrorIs(t, err, ErrInvalidWebhookEndpoint)
+	_, err = svc.CreateEndpoint(ctx, 1, &CreateWebhookEndpointRequest{Name: "CI", URL: "https://example.com/hook", Events: []string{"task.deleted"}})
+	assert.ErrorIs(t, err, ErrInvalidWebhookEndpoint)
+
+	// 未指定密钥时自动生成，只在创建时返回
+	created, err := svc.CreateEndpoint(ctx, 1, &CreateWebhookEndpointRequest{
+		Name:   "CI",
+		URL:    "https://example.com/hook",
+		Events: []string{EventTaskCreated, EventTaskCreated, EventTaskCompleted},
+	})
+	require.NoError(t, err)
+	assert.Len(t, created.Secret, 64)
+	assert.True(t, created.Enabled)
+	assert.Equal(t, []string{EventTaskCreated, EventTaskCompleted}, created.Events)
+
+	fetched, err := svc.GetEndpoint(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Empty(t, fetched.Secret)
+
+	// 重新生成密钥时返回新密钥，清空事件列表表示订阅全部事件
+	events := []string{}
+	updated, err := svc.UpdateEndpoint(ctx, created.ID, &UpdateWebhookEndpointRequest{Events: &events, RotateSecret: true})
+	require.NoError(t, err)
+	assert.NotEqual(t, created.Secret, updated.Secret)
+	assert.Equal(t, updated.Secret, endpoints.endpoints[created.ID].Secret)
+	assert.Empty(t, updated.Events)
+
+	require.NoError(t, svc.DeleteEndpoint(ctx, created.ID))
+	assert.ErrorIs(t, svc.DeleteEndpoint(ctx, created.ID), ErrWebhookEndpointNotFound)
+	_, err = svc.GetEndpoint(ctx, created.ID)
+	assert.ErrorIs(t, err, ErrWebhookEndpointNotFound)
+}
+
+func TestWebhookService_PublishAndDeliver(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	sender := &recordingWebhookSender{statuses: []int{500, 0, 204, 200}}
+	svc, _, deliveries := newTestWebhookService(now, sender)
+
+	all, err := svc.CreateEndpoint(ctx, 1, &CreateWebhookEndpointRequest{Name: "全部事件", URL: "https://a.example.com/hook", Secret: "0123456789abcdef"})
+	require.NoError(t, err)
+	_, err = svc.CreateEndpoint(ctx, 1, &CreateWebhookEndpointRequest{Name: "只订阅审批", URL: "https://b.example.com/hook", Events: []string{EventApprovalResolved}})
+	require.NoError(t, err)
+	disabled := false
+	_, err = svc.CreateEndpoint(ctx, 1, &CreateWebhookEndpointRequest{Name: "已停用", URL: "https://c.example.com/hook", Enabled: &disabled})
+	require.NoError(t, err)
+
+	// 只为订阅了该事件的已启用端点写入发件箱
+	task := &database.Task{BaseModel: database.BaseModel{ID: 9}, Title: "接口联调", Status: "pending", CreatorID: 1}
+	publishEvent(ctx, svc, EventTaskCreated, newTaskEvent(task))
+	require.Len(t, deliveries.deliveries, 1)
+	delivery := deliveries.deliveries[0]
+	assert.Equal(t, all.ID, delivery.EndpointID)
+	assert.Equal(t, WebhookDeliveryStatusPending, delivery.Status)
+
+	var event struct {
+		ID   string    `json:"id"`
+		Type string    `json:"type"`
+		Data TaskEvent `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(delivery.Payload), &event))
+	assert.Equal(t, delivery.EventID, event.ID)
+	assert.Equal(t, EventTaskCreated, event.Type)
+	assert.Equal(t, uint(9), event.Data.TaskID)
+
+	// 第一次返回500，30秒后重试
+	delivered, err := svc.ProcessOutbox(ctx, now)
+	require.NoError(t, err)
+	assert.Zero(t, delivered)
+	assert.Equal(t, 500, delivery.LastStatusCode)
+	assert.Equal(t, now.Add(30*time.Second), *delivery.NextRetryAt)
+	require.Len(t, sender.sent, 1)
+	assert.Equal(t, all.Secret, sender.sent[0].Secret)
+	assert.Equal(t, "1", sender.sent[0].DeliveryID)
+	assert.Equal(t, delivery.Payload, string(sender.sent[0].Body))
+
+	// 未到重试时间不发送；第二次连接失败后等待1分钟
+	_, _ = svc.ProcessOutbox(ctx, now.Add(10*time.Second))
+	assert.Len(t, sender.sent, 1)
+	retryAt := now.Add(30 * time.Second)
+	_, _ = svc.ProcessOutbox(ctx, retryAt)
+	assert.Equal(t, retryAt.Add(time.Minute), *delivery.NextRetryAt)
+	assert.Equal(t, "connection refused", delivery.LastError)
+
+	delivered, _ = svc.ProcessOutbox(ctx, retryAt.Add(time.Minute))
+	assert.Equal(t, 1, delivered)
+	assert.Equal(t, WebhookDeliveryStatusDelivered, delivery.Status)
+	assert.Equal(t, 3, delivery.Attempts)
+	assert.Empty(t, delivery.LastError)
+
+	// 每次发送都记录响应状态码
+	require.Len(t, deliveries.attempts, 3)
+	codes := []int{deliveries.attempts[0].StatusCode, deliveries.attempts[1].StatusCode, deliveries.attempts[2].StatusCode}
+	assert.Equal(t, []int{500, 0, 204}, codes)
+	assert.Equal(t, 2, deliveries.attempts[1].Attempt)
+	assert.Equal(t, "connection refused", deliveries.attempts[1].Error)
+
+	// 审批结果事件发送给两个订阅端点
+	listener := newApprovalEventListener(svc, nil)
+	instance := &workflow.WorkflowInstance{ID: "wf-1", BusinessType: "task_assignment", BusinessID: "9", Status: workflow.StatusCompleted}
+	listener.OnApprovalResolved(ctx, instance, &workflow.ApprovalOutcome{NodeID: "n1", Outcome: workflow.ApprovalOutcomeApproved, DecidedBy: 3, Completed: true})
+	assert.Len(t, deliveries.deliveries, 3)
+	assert.Equal(t, deliveries.deliveries[1].EventID, deliveries.deliveries[2].EventID)
+}
+
+func TestWebhookService_StopsRetrying(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	svc, endpoints, deliveries := newTestWebhookService(now, &recordingWebhookSender{statuses: []int{503}})
+	endpoints.endpoints[1] = &database.WebhookEndpoint{BaseModel: database.BaseModel{ID: 1}, URL: "https://a.example.com", Enabled: true}
+	deliveries.deliveries = []*database.WebhookDelivery{
+		{BaseModel: database.BaseModel{ID: 1}, EndpointID: 1, Status: WebhookDeliveryStatusPending, NextRetryAt: &now, Attempts: 2},
+		// 端点已删除时不再发送
+		{BaseModel: database.BaseModel{ID: 2}, EndpointID: 5, Status: WebhookDeliveryStatusPending, NextRetryAt: &now},
+	}
+
+	_, err := svc.ProcessOutbox(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, WebhookDeliveryStatusFailed, deliveries.deliveries[0].Status)
+	assert.Nil(t, deliveries.deliveries[0].NextRetryAt)
+	assert.Equal(t, WebhookDeliveryStatusFailed, deliveries.deliveries[1].Status)
+	assert.Zero(t, deliveries.deliveries[1].Attempts)
+	assert.Len(t, deliveries.attempts, 1)
+	assert.Equal(t, time.Hour, webhookRetryDelay(20))
+}
+
+// recordingEventPublisher 记录发布的事件数据
+type recordingEventPublisher struct {
+	events []interface{}
+}
+
+func (p *recordingEventPublisher) Publish(ctx context.Context, eventType string, data interface{}) error {
+	p.events = append(p.events, data)
+	return nil
+}
+
+// staticDefinitionResolver 返回固定流程定义
+type staticDefinitionResolver struct {
+	definition *workflow.WorkflowDefinition
+}
+
+func (r *staticDefinitionResolver) GetWorkflowForInstance(ctx context.Context, instance *workflow.WorkflowInstance) (*workflow.WorkflowDefinition, error) {
+	return r.definition, nil
+}
+
+func TestApprovalEventListener_RedactsVariables(t *testing.T) {
+	publisher := &recordingEventPublisher{}
+	definition := &workflow.WorkflowDefinition{VariableSchema: []workflow.VariableDefinition{{Name: "phone", Type: workflow.VariableTypeString, Sensitive: true}}}
+	listener := newApprovalEventListener(publisher, &staticDefinitionResolver{definition: definition})
+	instance := &workflow.WorkflowInstance{ID: "wf-1", Status: workflow.StatusRunning, Variables: map[string]interface{}{"phone": "13800000000", "employee_id": uint(7)}}
+
+	listener.OnApprovalsRequested(context.Background(), instance, []*workflow.PendingApproval{{NodeID: "hr", AssignedTo: 2}})
+	require.Len(t, publisher.events, 1)
+	event := publisher.events[0].(*ApprovalEvent)
+	assert.Equal(t, workflow.RedactedValue, event.Variables["phone"])
+	assert.Equal(t, uint(7), event.Variables["employee_id"])
+	assert.Equal(t, "13800000000", instance.Variables["phone"])
+}
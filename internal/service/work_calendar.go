@@ -0,0 +1,248 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"taskmanage/internal/config"
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+	"taskmanage/pkg/logger"
+	"taskmanage/pkg/response"
+	"taskmanage/pkg/workcalendar"
+)
+
+// workCalendarRefreshInterval 缓存的工作日历的最长使用时间，超过后重新加载，
+// 使其它实例维护的节假日在该时间内生效；本实例修改节假日时立即刷新
+const workCalendarRefreshInterval = 10 * time.Minute
+
+var (
+	// ErrInvalidHoliday 节假日不合法
+	ErrInvalidHoliday = response.NewError(response.ErrCodeInvalidRequest, "节假日不合法")
+	// ErrHolidayNotFound 节假日不存在
+	ErrHolidayNotFound = response.NewError(response.ErrCodeNotFound, "节假日不存在")
+	// ErrHolidayExists 该日期已登记为节假日
+	ErrHolidayExists = response.NewError(response.ErrCodeConflict, "该日期已登记为节假日")
+)
+
+// HolidayRequest 创建或更新节假日请求
+type HolidayRequest struct {
+	Date string `json:"date" binding:"required"` // 日期，格式YYYY-MM-DD
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+// HolidayResponse 节假日响应
+type HolidayResponse struct {
+	ID        uint      `json:"id"`
+	Date      string    `json:"date"`
+	Name      string    `json:"name"`
+	CreatedBy uint      `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// WorkCalendarService 工作日历服务：维护节假日，并按工作时间配置和节假日提供缓存在内存中的工作日历
+type WorkCalendarService interface {
+	// ListHolidays 获取节假日，year为0时返回全部年份
+	ListHolidays(ctx context.Context, year int) ([]*HolidayResponse, error)
+	// CreateHoliday 登记节假日
+	CreateHoliday(ctx context.Context, createdBy uint, req *HolidayRequest) (*HolidayResponse, error)
+	// UpdateHoliday 修改节假日的日期或名称
+	UpdateHoliday(ctx context.Context, id uint, req *HolidayRequest) (*HolidayResponse, error)
+	// DeleteHoliday 删除节假日
+	DeleteHoliday(ctx context.Context, id uint) error
+	// Calendar 返回当前的工作日历，未启用工作日历时返回nil，调用方按自然时间计算
+	Calendar(ctx context.Context) *workcalendar.Calendar
+	// ProbationCalendar 返回计算试用期结束日期使用的日历，试用期按自然日计算时返回nil
+	ProbationCalendar(ctx context.Context) *workcalendar.Calendar
+	// Refresh 重新加载节假日
+	Refresh(ctx context.Context) error
+}
+
+// workCalendarService 工作日历服务实现
+type workCalendarService struct {
+	repoManager repository.RepositoryManager
+	config      config.WorkCalendarConfig
+	hours       workcalendar.WorkingHours
+
+	mu       sync.RWMutex
+	calendar *workcalendar.Calendar
+	loadedAt time.Time
+}
+
+// NewWorkCalendarService 创建工作日历服务，工作时间配置无效时记录错误并使用周一至周五 09:00-18:00
+func NewWorkCalendarService(repoManager repository.RepositoryManager, cfg config.WorkCalendarConfig) WorkCalendarService {
+	hours, err := workcalendar.ParseWorkingHours(cfg.WorkDays, cfg.WorkStart, cfg.WorkEnd)
+	if err != nil {
+		logger.Errorf("工作时间配置无效，使用默认工作时间: %v", err)
+		hours = workcalendar.DefaultWorkingHours()
+	}
+	return &workCalendarService{
+		repoManager: repoManager,
+		config:      cfg,
+		hours:       hours,
+	}
+}
+
+// ListHolidays 获取节假日
+func (s *workCalendarService) ListHolidays(ctx context.Context, year int) ([]*HolidayResponse, error) {
+	holidays, err := s.repoManager.HolidayRepository().List(ctx, year)
+	if err != nil {
+		return nil, err
+	}
+	responses := make([]*HolidayResponse, 0, len(holidays))
+	for _, holiday := range holidays {
+		responses = append(responses, toHolidayResponse(holiday))
+	}
+	return responses, nil
+}
+
+// CreateHoliday 登记节假日并刷新工作日历
+func (s *workCalendarService) CreateHoliday(ctx context.Context, createdBy uint, req *HolidayRequest) (*HolidayResponse, error) {
+	holiday := &database.Holiday{CreatedBy: createdBy}
+	if err := s.applyHolidayRequest(ctx, holiday, req); err != nil {
+		return nil, err
+	}
+	if err := s.repoManager.HolidayRepository().Create(ctx, holiday); err != nil {
+		return nil, err
+	}
+
+	logger.FromContext(ctx).Infof("节假日登记成功: ID=%d, Date=%s, Name=%s", holiday.ID, holiday.Date.Format(workcalendar.DateLayout), holiday.Name)
+	s.refreshAfterChange(ctx)
+	return toHolidayResponse(holiday), nil
+}
+
+// UpdateHoliday 修改节假日并刷新工作日历
+func (s *workCalendarService) UpdateHoliday(ctx context.Context, id uint, req *HolidayRequest) (*HolidayResponse, error) {
+	holiday, err := s.repoManager.HolidayRepository().GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrHolidayNotFound.WithCause(err)
+		}
+		return nil, err
+	}
+	if err := s.applyHolidayRequest(ctx, holiday, req); err != nil {
+		return nil, err
+	}
+	if err := s.repoManager.HolidayRepository().Update(ctx, holiday); err != nil {
+		return nil, err
+	}
+
+	s.refreshAfterChange(ctx)
+	return toHolidayResponse(holiday), nil
+}
+
+// DeleteHoliday 删除节假日并刷新工作日历
+func (s *workCalendarService) DeleteHoliday(ctx context.Context, id uint) error {
+	if err := s.repoManager.HolidayRepository().Delete(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrHolidayNotFound.WithCause(err)
+		}
+		return err
+	}
+
+	s.refreshAfterChange(ctx)
+	return nil
+}
+
+// applyHolidayRequest 校验请求并写入节假日，日期不能与其它节假日重复
+func (s *workCalendarService) applyHolidayRequest(ctx context.Context, holiday *database.Holiday, req *HolidayRequest) error {
+	date, err := time.ParseInLocation(workcalendar.DateLayout, req.Date, time.Local)
+	if err != nil {
+		return fmt.Errorf("%w: 日期格式应为YYYY-MM-DD", ErrInvalidHoliday)
+	}
+	if req.Name == "" {
+		return fmt.Errorf("%w: 名称不能为空", ErrInvalidHoliday)
+	}
+
+	existing, err := s.repoManager.HolidayRepository().GetByDate(ctx, date)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return err
+	}
+	if existing != nil && existing.ID != holiday.ID {
+		return fmt.Errorf("%w: %s（%s）", ErrHolidayExists, req.Date, existing.Name)
+	}
+
+	holiday.Date = date
+	holiday.Name = req.Name
+	return nil
+}
+
+// Calendar 返回缓存的工作日历，缓存过期或未加载时重新加载
+// 加载节假日失败时只按工作时间计算，下次调用时重试
+func (s *workCalendarService) Calendar(ctx context.Context) *workcalendar.Calendar {
+	if !s.config.Enabled {
+		return nil
+	}
+
+	s.mu.RLock()
+	calendar, loadedAt := s.calendar, s.loadedAt
+	s.mu.RUnlock()
+	if calendar != nil && time.Since(loadedAt) < workCalendarRefreshInterval {
+		return calendar
+	}
+
+	if err := s.Refresh(ctx); err != nil {
+		logger.FromContext(ctx).Warnf("加载节假日失败，暂不考虑节假日: %v", err)
+		if calendar != nil {
+			return calendar
+		}
+		return workcalendar.New(s.hours, nil)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.calendar
+}
+
+// ProbationCalendar 配置试用期按工作日计算时返回工作日历
+func (s *workCalendarService) ProbationCalendar(ctx context.Context) *workcalendar.Calendar {
+	if !s.config.ProbationWorkingDays {
+		return nil
+	}
+	return s.Calendar(ctx)
+}
+
+// Refresh 从数据库加载全部节假日并替换缓存的工作日历
+func (s *workCalendarService) Refresh(ctx context.Context) error {
+	holidays, err := s.repoManager.HolidayRepository().List(ctx, 0)
+	if err != nil {
+		return err
+	}
+	dates := make([]time.Time, 0, len(holidays))
+	for _, holiday := range holidays {
+		dates = append(dates, holiday.Date)
+	}
+
+	calendar := workcalendar.New(s.hours, dates)
+	s.mu.Lock()
+	s.calendar = calendar
+	s.loadedAt = time.Now()
+	s.mu.Unlock()
+	return nil
+}
+
+// refreshAfterChange 节假日修改后刷新缓存，刷新失败时让缓存过期，下次使用时重新加载
+func (s *workCalendarService) refreshAfterChange(ctx context.Context) {
+	if err := s.Refresh(ctx); err != nil {
+		logger.FromContext(ctx).Warnf("刷新工作日历失败: %v", err)
+		s.mu.Lock()
+		s.loadedAt = time.Time{}
+		s.mu.Unlock()
+	}
+}
+
+// toHolidayResponse 转换节假日响应
+func toHolidayResponse(holiday *database.Holiday) *HolidayResponse {
+	return &HolidayResponse{
+		ID:        holiday.ID,
+		Date:      holiday.Date.Format(workcalendar.DateLayout),
+		Name:      holiday.Name,
+		CreatedBy: holiday.CreatedBy,
+		CreatedAt: holiday.CreatedAt,
+		UpdatedAt: holiday.UpdatedAt,
+	}
+}
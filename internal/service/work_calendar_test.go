@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/config"
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+// memoryHolidayRepository 测试用内存节假日仓储
+type memoryHolidayRepository struct {
+	holidays map[uint]*database.Holiday
+	nextID   uint
+}
+
+func (r *memoryHolidayRepository) Create(ctx context.Context, holiday *database.Holiday) error {
+	r.nextID++
+	holiday.ID = r.nextID
+	r.holidays[holiday.ID] = holiday
+	return nil
+}
+
+func (r *memoryHolidayRepository) GetByID(ctx context.Context, id uint) (*database.Holiday, error) {
+	holiday, ok := r.holidays[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	copied := *holiday
+	return &copied, nil
+}
+
+func (r *memoryHolidayRepository) GetByDate(ctx context.Context, date time.Time) (*database.Holiday, error) {
+	for _, holiday := range r.holidays {
+		if holiday.Date.Equal(date) {
+			return holiday, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *memoryHolidayRepository) Update(ctx context.Context, holiday *database.Holiday) error {
+	r.holidays[holiday.ID] = holiday
+	return nil
+}
+
+func (r *memoryHolidayRepository) Delete(ctx context.Context, id uint) error {
+	if _, ok := r.holidays[id]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(r.holidays, id)
+	return nil
+}
+
+func (r *memoryHolidayRepository) List(ctx context.Context, year int) ([]*database.Holiday, error) {
+	var result []*database.Holiday
+	for _, holiday := range r.holidays {
+		if year == 0 || holiday.Date.Year() == year {
+			result = append(result, holiday)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Date.Before(result[j].Date) })
+	return result, nil
+}
+
+// holidayRepoManager 工作日历测试用仓储管理器，其余仓储取自内嵌的截止提醒仓储管理器
+type holidayRepoManager struct {
+	*dueReminderRepoManager
+	holidays *memoryHolidayRepository
+}
+
+func (m *holidayRepoManager) HolidayRepository() repository.HolidayRepository {
+	return m.holidays
+}
+
+func newHolidayRepoManager() *holidayRepoManager {
+	return &holidayRepoManager{
+		dueReminderRepoManager: &dueReminderRepoManager{},
+		holidays:               &memoryHolidayRepository{holidays: map[uint]*database.Holiday{}},
+	}
+}
+
+func TestWorkCalendarService_HolidayChangesRefreshCalendar(t *testing.T) {
+	ctx := context.Background()
+	svc := NewWorkCalendarService(newHolidayRepoManager(), config.WorkCalendarConfig{Enabled: true})
+	// 2026-03-06为周五
+	friday := time.Date(2026, 3, 6, 17, 0, 0, 0, time.Local)
+	monday := time.Date(2026, 3, 9, 10, 0, 0, 0, time.Local)
+
+	assert.Equal(t, monday, svc.Calendar(ctx).AddWorkingDuration(friday, 2*time.Hour))
+
+	// 登记节假日后缓存的日历立即刷新
+	holiday, err := svc.CreateHoliday(ctx, 1, &HolidayRequest{Date: "2026-03-09", Name: "调休"})
+	require.NoError(t, err)
+	assert.Equal(t, "2026-03-09", holiday.Date)
+	assert.Equal(t, monday.AddDate(0, 0, 1), svc.Calendar(ctx).AddWorkingDuration(friday, 2*time.Hour))
+
+	_, err = svc.CreateHoliday(ctx, 1, &HolidayRequest{Date: "2026-03-09", Name: "重复"})
+	assert.ErrorIs(t, err, ErrHolidayExists)
+	_, err = svc.CreateHoliday(ctx, 1, &HolidayRequest{Date: "03/09/2026", Name: "格式错误"})
+	assert.ErrorIs(t, err, ErrInvalidHoliday)
+
+	// 更新为原日期不视为重复
+	_, err = svc.UpdateHoliday(ctx, holiday.ID, &HolidayRequest{Date: "2026-03-09", Name: "公司活动日"})
+	require.NoError(t, err)
+	holidays, err := svc.ListHolidays(ctx, 2026)
+	require.NoError(t, err)
+	require.Len(t, holidays, 1)
+	assert.Equal(t, "公司活动日", holidays[0].Name)
+
+	require.NoError(t, svc.DeleteHoliday(ctx, holiday.ID))
+	assert.Equal(t, monday, svc.Calendar(ctx).AddWorkingDuration(friday, 2*time.Hour))
+	assert.ErrorIs(t, svc.DeleteHoliday(ctx, holiday.ID), ErrHolidayNotFound)
+	_, err = svc.UpdateHoliday(ctx, holiday.ID, &HolidayRequest{Date: "2026-03-10", Name: "调休"})
+	assert.ErrorIs(t, err, ErrHolidayNotFound)
+}
+
+func TestWorkCalendarService_Disabled(t *testing.T) {
+	ctx := context.Background()
+	repos := newHolidayRepoManager()
+
+	// 未启用时按自然时间计算
+	assert.Nil(t, NewWorkCalendarService(repos, config.WorkCalendarConfig{}).Calendar(ctx))
+	// 试用期默认按自然日计算
+	enabled := NewWorkCalendarService(repos, config.WorkCalendarConfig{Enabled: true})
+	assert.NotNil(t, enabled.Calendar(ctx))
+	assert.Nil(t, enabled.ProbationCalendar(ctx))
+	assert.NotNil(t, NewWorkCalendarService(repos, config.WorkCalendarConfig{Enabled: true, ProbationWorkingDays: true}).ProbationCalendar(ctx))
+}
+
+func TestTaskDueReminderService_LeadHoursSkipWeekend(t *testing.T) {
+	// 周五17:00检查，24小时提前量只计算工作时间
+	now := time.Date(2026, 3, 6, 17, 0, 0, 0, time.Local)
+	assigneeID := uint(20)
+	task := func(id uint, dueDate time.Time) *database.Task {
+		t := &database.Task{Title: "任务", Priority: "high", Status: "in_progress", CreatorID: 10, AssigneeID: &assigneeID, DueDate: &dueDate}
+		t.ID = id
+		return t
+	}
+	repos := newHolidayRepoManager()
+	repos.reminders = &memoryDueReminderRepository{
+		tasks: []*database.Task{
+			task(1, time.Date(2026, 3, 9, 12, 0, 0, 0, time.Local)),  // 周一（节假日）中午，按自然时间相隔67小时
+			task(2, time.Date(2026, 3, 11, 12, 0, 0, 0, time.Local)), // 周三中午，跳过周末和周一后相隔13个工作小时
+			task(3, time.Date(2026, 3, 13, 12, 0, 0, 0, time.Local)), // 下周五中午，相隔31个工作小时
+		},
+		reminders: map[uint][]int{},
+	}
+	repos.configs = &memorySystemConfigRepository{values: map[string]string{}}
+	workCalendar := NewWorkCalendarService(repos, config.WorkCalendarConfig{Enabled: true})
+	ctx := context.Background()
+
+	_, err := workCalendar.CreateHoliday(ctx, 1, &HolidayRequest{Date: "2026-03-09", Name: "节假日"})
+	require.NoError(t, err)
+
+	svc := NewTaskDueReminderService(repos, &watcherNotificationService{}, workCalendar)
+	report, err := svc.RunReminders(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.DueSoon)
+	// 按自然时间都不在24小时内的任务按工作时间提醒，下周五的任务留待之后的检查
+	assert.Equal(t, map[uint][]int{1: {24}, 2: {24}}, repos.reminders.reminders)
+}
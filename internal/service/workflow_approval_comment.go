@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"taskmanage/internal/repository"
+	"taskmanage/internal/workflow"
+)
+
+// approvalAttachmentResolver 从任务附件中解析审批意见附带的附件
+type approvalAttachmentResolver struct {
+	attachmentRepo repository.TaskAttachmentRepository
+}
+
+// NewApprovalAttachmentResolver 创建审批附件解析器
+func NewApprovalAttachmentResolver(attachmentRepo repository.TaskAttachmentRepository) workflow.ApprovalAttachmentResolver {
+	return &approvalAttachmentResolver{attachmentRepo: attachmentRepo}
+}
+
+// ResolveApprovalAttachments 校验附件为审批人上传或属于流程关联的任务（流程变量task_id），按请求顺序返回
+func (r *approvalAttachmentResolver) ResolveApprovalAttachments(ctx context.Context, instance *workflow.WorkflowInstance, userID uint, attachmentIDs []uint) ([]workflow.ApprovalAttachment, error) {
+	records, err := r.attachmentRepo.GetByIDs(ctx, attachmentIDs)
+	if err != nil {
+		return nil, fmt.Errorf("获取审批附件失败: %w", err)
+	}
+	found := make(map[uint]workflow.ApprovalAttachment, len(records))
+	for _, record := range records {
+		found[record.ID] = workflow.ApprovalAttachment{
+			ID:         record.ID,
+			Filename:   record.Filename,
+			FileSize:   record.FileSize,
+			MimeType:   record.MimeType,
+			TaskID:     record.TaskID,
+			UploadedBy: record.UserID,
+		}
+	}
+
+	taskID, _ := instance.GetUintVar("task_id")
+	attachments := make([]workflow.ApprovalAttachment, 0, len(attachmentIDs))
+	for _, id := range attachmentIDs {
+		attachment, ok := found[id]
+		if !ok {
+			return nil, fmt.Errorf("%w: 附件%d不存在", workflow.ErrInvalidApprovalComment, id)
+		}
+		if attachment.UploadedBy != userID && (taskID == 0 || attachment.TaskID != taskID) {
+			return nil, fmt.Errorf("%w: 附件%d不属于审批人或流程关联的任务", workflow.ErrInvalidApprovalComment, id)
+		}
+		attachments = append(attachments, attachment)
+	}
+	return attachments, nil
+}
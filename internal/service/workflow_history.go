@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/workflow"
+	"taskmanage/pkg/logger"
+)
+
+// WorkflowHistoryEntry 流程历史条目，在原始记录基础上补充节点名称、执行人信息和步骤间隔
+type WorkflowHistoryEntry struct {
+	ID                   string                        `json:"id"`
+	NodeID               string                        `json:"node_id"`
+	NodeName             string                        `json:"node_name"`
+	Action               string                        `json:"action"`
+	Result               string                        `json:"result"`
+	Comment              string                        `json:"comment,omitempty"`
+	Attachments          []workflow.ApprovalAttachment `json:"attachments,omitempty"`
+	Mentions             []uint                        `json:"mentions,omitempty"`
+	ExecutedBy           uint                          `json:"executed_by"`               // 执行人用户ID，系统操作为0
+	ExecutorName         string                        `json:"executor_name,omitempty"`   // 执行人姓名
+	ExecutorAvatar       string                        `json:"executor_avatar,omitempty"` // 执行人头像
+	ExecutedAt           time.Time                     `json:"executed_at"`
+	SincePreviousSeconds int64                         `json:"since_previous_seconds"` // 距上一步的秒数，第一步从流程启动时计算
+	Delegated            bool                          `json:"delegated"`              // 受托人代原审批人表决
+	Escalated            bool                          `json:"escalated"`              // 超过SLA或节点超时后由系统终止
+	AutoApproved         bool                          `json:"auto_approved"`          // 满足自动审批规则，由系统审批通过
+}
+
+// ListWorkflowHistory 分页获取补充了节点名称和执行人信息的流程历史
+// 节点名称取自实例绑定的流程定义版本，节点已被删除时返回节点ID；执行人按当前页批量查询
+func (w *WorkflowServiceWrapper) ListWorkflowHistory(ctx context.Context, instanceID string, page, pageSize int) (*ListResponse[*WorkflowHistoryEntry], error) {
+	if w.workflowService == nil {
+		return nil, workflow.ErrWorkflowServiceNotReady
+	}
+	if page < 1 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+	if pageSize > 100 {
+		pageSize = 100
+	}
+
+	instance, err := w.workflowService.GetWorkflowInstance(ctx, instanceID)
+	if err != nil {
+		return nil, err
+	}
+	history, err := w.workflowService.GetExecutionHistory(ctx, instanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	var definition *workflow.WorkflowDefinition
+	if manager := w.workflowService.GetDefinitionManager(); manager != nil {
+		definition, err = manager.GetWorkflowForInstance(ctx, instance)
+		if err != nil {
+			// 定义版本缺失不影响查看历史，节点名称回退到记录时的值
+			logger.Warnf("获取流程实例绑定的定义失败: instance_id=%s, error=%v", instanceID, err)
+		}
+	}
+
+	entries := buildWorkflowHistoryEntries(instance, definition, history)
+	result := &ListResponse[*WorkflowHistoryEntry]{
+		Items: []*WorkflowHistoryEntry{},
+		Total: int64(len(entries)),
+		Page:  page,
+		Size:  pageSize,
+	}
+	start := (page - 1) * pageSize
+	if start >= len(entries) {
+		return result, nil
+	}
+	end := min(start+pageSize, len(entries))
+	result.Items = entries[start:end]
+
+	if err := w.fillHistoryExecutors(ctx, result.Items); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// fillHistoryExecutors 一次查询补充当前页全部执行人的姓名和头像
+func (w *WorkflowServiceWrapper) fillHistoryExecutors(ctx context.Context, entries []*WorkflowHistoryEntry) error {
+	if w.userRepo == nil {
+		return nil
+	}
+	var ids []uint
+	seen := make(map[uint]bool)
+	for _, entry := range entries {
+		if entry.ExecutedBy != 0 && !seen[entry.ExecutedBy] {
+			seen[entry.ExecutedBy] = true
+			ids = append(ids, entry.ExecutedBy)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	users, err := w.userRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("获取流程执行人失败: %w", err)
+	}
+	byID := make(map[uint]*database.User, len(users))
+	for _, user := range users {
+		byID[user.ID] = user
+	}
+	for _, entry := range entries {
+		if user, ok := byID[entry.ExecutedBy]; ok {
+			entry.ExecutorName = user.RealName
+			entry.ExecutorAvatar = user.Avatar
+		}
+	}
+	return nil
+}
+
+// buildWorkflowHistoryEntries 按执行顺序转换流程历史，计算步骤间隔并标记委托和超时终止
+func buildWorkflowHistoryEntries(instance *workflow.WorkflowInstance, definition *workflow.WorkflowDefinition, history []workflow.ExecutionHistory) []*WorkflowHistoryEntry {
+	nodeNames := make(map[string]string)
+	if definition != nil {
+		for _, node := range definition.Nodes {
+			nodeNames[node.ID] = node.Name
+		}
+	}
+
+	entries := make([]*WorkflowHistoryEntry, 0, len(history))
+	previous := instance.StartedAt
+	for _, record := range history {
+		entry := &WorkflowHistoryEntry{
+			ID:          record.ID,
+			NodeID:      record.NodeID,
+			NodeName:    record.NodeName,
+			Action:      record.Action,
+			Result:      record.Result,
+			Comment:     record.Comment,
+			Attachments: record.Attachments,
+			Mentions:    record.Mentions,
+			ExecutedBy:  record.ExecutedBy,
+			ExecutedAt:  record.ExecutedAt,
+			Escalated:   record.Action == "expire",
+		}
+		entry.AutoApproved, _ = record.Variables["auto_approved"].(bool)
+		// 系统记录没有节点ID，保留记录时的名称
+		if record.NodeID != "" {
+			if name, ok := nodeNames[record.NodeID]; ok {
+				entry.NodeName = name
+			} else if definition != nil {
+				entry.NodeName = record.NodeID
+			}
+		}
+		if !previous.IsZero() && record.ExecutedAt.After(previous) {
+			entry.SincePreviousSeconds = int64(record.ExecutedAt.Sub(previous).Seconds())
+		}
+		previous = record.ExecutedAt
+
+		if record.Action != string(workflow.ActionDelegate) {
+			entry.Delegated = isDelegatedVoter(instance, record.NodeID, record.ExecutedBy)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// isDelegatedVoter 判断执行人是否为该节点某位审批人的受托人
+func isDelegatedVoter(instance *workflow.WorkflowInstance, nodeID string, userID uint) bool {
+	if userID == 0 {
+		return false
+	}
+	state := instance.Approvals[nodeID]
+	if state == nil {
+		return false
+	}
+	for _, vote := range state.Votes {
+		if vote.DelegatedTo == userID {
+			return true
+		}
+	}
+	return false
+}
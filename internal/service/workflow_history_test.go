@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+	"taskmanage/internal/workflow"
+)
+
+// historyWorkflowEngine 返回固定实例和执行历史的流程引擎
+type historyWorkflowEngine struct {
+	workflow.WorkflowEngine
+	instance *workflow.WorkflowInstance
+}
+
+func (e *historyWorkflowEngine) GetWorkflowInstance(ctx context.Context, instanceID string) (*workflow.WorkflowInstance, error) {
+	if instanceID != e.instance.ID {
+		return nil, repository.ErrNotFound
+	}
+	return e.instance, nil
+}
+
+func (e *historyWorkflowEngine) GetExecutionHistory(ctx context.Context, instanceID string) ([]workflow.ExecutionHistory, error) {
+	return e.instance.History, nil
+}
+
+// historyDefinitionRepository 只提供实例绑定版本的流程定义仓储
+type historyDefinitionRepository struct {
+	workflow.WorkflowRepository
+	definition *workflow.WorkflowDefinition
+}
+
+func (r *historyDefinitionRepository) GetWorkflowDefinitionVersion(ctx context.Context, versionID uint) (*workflow.WorkflowDefinition, error) {
+	return r.definition, nil
+}
+
+// historyUserRepository 记录批量查询次数的用户仓储
+type historyUserRepository struct {
+	repository.UserRepository
+	users   map[uint]*database.User
+	queries [][]uint
+}
+
+func (r *historyUserRepository) GetByIDs(ctx context.Context, ids []uint) ([]*database.User, error) {
+	r.queries = append(r.queries, ids)
+	var result []*database.User
+	for _, id := range ids {
+		if user, ok := r.users[id]; ok {
+			result = append(result, user)
+		}
+	}
+	return result, nil
+}
+
+func TestListWorkflowHistory_EnrichesEntries(t *testing.T) {
+	startedAt := time.Date(2026, 3, 2, 9, 0, 0, 0, time.Local)
+	at := func(minutes int) time.Time { return startedAt.Add(time.Duration(minutes) * time.Minute) }
+
+	// 节点manager_approve在当前版本中已改名，节点legacy已被删除
+	instance := &workflow.WorkflowInstance{
+		ID:                  "inst",
+		DefinitionVersionID: 3,
+		StartedAt:           startedAt,
+		Approvals: map[string]*workflow.NodeApprovalState{
+			"manager_approve": {Votes: []*workflow.ApproverVote{{AssigneeID: 5, DelegatedTo: 9}}},
+		},
+		History: []workflow.ExecutionHistory{
+			{ID: "h1", NodeID: "start", NodeName: "开始", Action: "execute", ExecutedBy: 1, ExecutedAt: at(0)},
+			{ID: "h2", NodeID: "manager_approve", NodeName: "主管审批", Action: "delegate", ExecutedBy: 5, ExecutedAt: at(30)},
+			{ID: "h3", NodeID: "manager_approve", NodeName: "主管审批", Action: "approve", Comment: "同意", ExecutedBy: 9, ExecutedAt: at(90)},
+			{ID: "h4", NodeID: "legacy", NodeName: "旧节点", Action: "execute", ExecutedBy: 1, ExecutedAt: at(91)},
+			{ID: "h5", NodeName: "系统", Action: "expire", Result: "expired", ExecutedAt: at(120)},
+		},
+	}
+	definition := &workflow.WorkflowDefinition{Nodes: []workflow.WorkflowNode{
+		{ID: "start", Name: "开始"},
+		{ID: "manager_approve", Name: "直属主管审批"},
+	}}
+	users := &historyUserRepository{users: map[uint]*database.User{
+		1: {BaseModel: database.BaseModel{ID: 1}, RealName: "张三", Avatar: "/avatars/1.png"},
+		5: {BaseModel: database.BaseModel{ID: 5}, RealName: "李四"},
+		9: {BaseModel: database.BaseModel{ID: 9}, RealName: "王五"},
+	}}
+
+	engine := &historyWorkflowEngine{instance: instance}
+	manager := workflow.NewWorkflowDefinitionManager(&historyDefinitionRepository{definition: definition})
+	svc := NewWorkflowServiceWrapper(workflow.NewWorkflowService(engine, manager), nil, users)
+	ctx := context.Background()
+
+	result, err := svc.ListWorkflowHistory(ctx, "inst", 1, 3)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), result.Total)
+	require.Len(t, result.Items, 3)
+
+	// 同一页的执行人只查询一次
+	require.Len(t, users.queries, 1)
+	assert.ElementsMatch(t, []uint{1, 5, 9}, users.queries[0])
+
+	first, delegated, approved := result.Items[0], result.Items[1], result.Items[2]
+	assert.Equal(t, "张三", first.ExecutorName)
+	assert.Equal(t, "/avatars/1.png", first.ExecutorAvatar)
+	assert.Equal(t, int64(0), first.SincePreviousSeconds)
+	assert.Equal(t, "直属主管审批", delegated.NodeName)
+	assert.False(t, delegated.Delegated)
+	assert.Equal(t, int64(1800), delegated.SincePreviousSeconds)
+	assert.Equal(t, "manager_approve", approved.NodeID)
+	assert.Equal(t, uint(9), approved.ExecutedBy)
+	assert.Equal(t, "王五", approved.ExecutorName)
+	assert.True(t, approved.Delegated)
+	assert.Equal(t, int64(3600), approved.SincePreviousSeconds)
+
+	result, err = svc.ListWorkflowHistory(ctx, "inst", 2, 3)
+	require.NoError(t, err)
+	require.Len(t, result.Items, 2)
+	assert.Equal(t, "legacy", result.Items[0].NodeName)
+	system := result.Items[1]
+	assert.Equal(t, "系统", system.NodeName)
+	assert.True(t, system.Escalated)
+	assert.Empty(t, system.ExecutorName)
+	assert.Equal(t, int64(29*60), system.SincePreviousSeconds)
+
+	result, err = svc.ListWorkflowHistory(ctx, "inst", 3, 3)
+	require.NoError(t, err)
+	assert.Empty(t, result.Items)
+	assert.Len(t, users.queries, 2)
+
+	_, err = svc.ListWorkflowHistory(ctx, "missing", 1, 20)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+}
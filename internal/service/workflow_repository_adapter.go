@@ -3,7 +3,9 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
 
 	"taskmanage/internal/database"
 	"taskmanage/internal/repository"
@@ -35,7 +37,49 @@ func (a *WorkflowRepositoryAdapter) SaveWorkflowDefinition(ctx context.Context,
 	if err != nil {
 		return err
 	}
-	return a.repo.SaveWorkflowDefinition(ctx, dbDef)
+	if err := a.repo.SaveWorkflowDefinition(ctx, dbDef); err != nil {
+		return err
+	}
+	// 回写版本记录ID
+	definition.VersionID = dbDef.ID
+	return nil
+}
+
+// GetWorkflowDefinitionVersion 根据版本ID获取流程定义
+func (a *WorkflowRepositoryAdapter) GetWorkflowDefinitionVersion(ctx context.Context, versionID uint) (*workflow.WorkflowDefinition, error) {
+	dbDef, err := a.repo.GetWorkflowDefinitionVersion(ctx, versionID)
+	if err != nil {
+		return nil, err
+	}
+	return convertToWorkflowDefinition(dbDef)
+}
+
+// ListWorkflowDefinitionVersions 列出流程定义的所有版本
+func (a *WorkflowRepositoryAdapter) ListWorkflowDefinitionVersions(ctx context.Context, workflowID string) ([]*workflow.WorkflowDefinition, error) {
+	dbDefs, err := a.repo.ListWorkflowDefinitionVersions(ctx, workflowID)
+	if err != nil {
+		return nil, err
+	}
+
+	var definitions []*workflow.WorkflowDefinition
+	for _, dbDef := range dbDefs {
+		def, err := convertToWorkflowDefinition(dbDef)
+		if err != nil {
+			return nil, err
+		}
+		definitions = append(definitions, def)
+	}
+	return definitions, nil
+}
+
+// SetWorkflowVersionActive 设置流程定义版本的激活状态
+func (a *WorkflowRepositoryAdapter) SetWorkflowVersionActive(ctx context.Context, workflowID string, versionID uint, isActive bool) error {
+	return a.repo.SetWorkflowVersionActive(ctx, workflowID, versionID, isActive)
+}
+
+// UpdateWorkflowStatus 更新流程所有版本的激活状态
+func (a *WorkflowRepositoryAdapter) UpdateWorkflowStatus(ctx context.Context, workflowID string, isActive bool) error {
+	return a.repo.UpdateWorkflowStatus(ctx, workflowID, isActive)
 }
 
 // ListWorkflowDefinitions 列出流程定义
@@ -84,11 +128,28 @@ func (a *WorkflowInstanceRepositoryAdapter) GetInstance(ctx context.Context, ins
 	return convertToWorkflowInstance(dbInstance)
 }
 
+// FindRunningByBusiness 获取业务对象运行中的流程实例，没有时返回nil
+func (a *WorkflowInstanceRepositoryAdapter) FindRunningByBusiness(ctx context.Context, businessType, businessID string) (*workflow.WorkflowInstance, error) {
+	dbInstance, err := a.repo.FindRunningByBusiness(ctx, businessType, businessID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return convertToWorkflowInstance(dbInstance)
+}
+
 // UpdateInstanceStatus 更新实例状态
 func (a *WorkflowInstanceRepositoryAdapter) UpdateInstanceStatus(ctx context.Context, instanceID string, status workflow.InstanceStatus) error {
 	return a.repo.UpdateInstanceStatus(ctx, instanceID, string(status))
 }
 
+// SaveCompletion 保存业务回调执行记录
+func (a *WorkflowInstanceRepositoryAdapter) SaveCompletion(ctx context.Context, instanceID string, record *workflow.CompletionRecord) error {
+	return a.repo.UpdateInstanceCompletion(ctx, instanceID, database.JSONField{Data: record})
+}
+
 // UpdateInstance 更新流程实例
 func (a *WorkflowInstanceRepositoryAdapter) UpdateInstance(ctx context.Context, instance *workflow.WorkflowInstance) error {
 	dbInstance, err := convertFromWorkflowInstance(instance)
@@ -98,30 +159,43 @@ func (a *WorkflowInstanceRepositoryAdapter) UpdateInstance(ctx context.Context,
 	return a.repo.UpdateInstance(ctx, dbInstance)
 }
 
-// AddExecutionHistory 添加执行历史
-func (a *WorkflowInstanceRepositoryAdapter) AddExecutionHistory(ctx context.Context, instanceID string, history workflow.ExecutionHistory) error {
-	dbHistory := &database.WorkflowExecutionHistory{
-		HistoryID:  history.ID,
-		InstanceID: instanceID,
-		NodeID:     history.NodeID,
-		NodeName:   history.NodeName,
-		Action:     history.Action,
-		Result:     history.Result,
-		Comment:    history.Comment,
-		Variables:  database.JSONField{Data: history.Variables},
-		ExecutedBy: history.ExecutedBy,
-		ExecutedAt: history.ExecutedAt,
-		Duration:   int64(history.Duration.Milliseconds()),
+// CancelInstance 在同一事务中写入取消历史并将实例标记为已取消
+func (a *WorkflowInstanceRepositoryAdapter) CancelInstance(ctx context.Context, instance *workflow.WorkflowInstance, history workflow.ExecutionHistory) error {
+	dbInstance, err := convertFromWorkflowInstance(instance)
+	if err != nil {
+		return err
 	}
-	return a.repo.AddExecutionHistory(ctx, dbHistory)
+	return a.repo.CancelInstance(ctx, dbInstance, convertFromExecutionHistory(instance.ID, history))
 }
 
-// GetPendingApprovals 获取待审批任务
-func (a *WorkflowInstanceRepositoryAdapter) GetPendingApprovals(ctx context.Context, userID uint) ([]*workflow.PendingApproval, error) {
-	dbApprovals, err := a.repo.GetPendingApprovals(ctx, userID)
+// VerifyExecutionHistory 校验实例执行历史的哈希链
+func (a *WorkflowInstanceRepositoryAdapter) VerifyExecutionHistory(ctx context.Context, instanceID string) (*workflow.HistoryVerification, error) {
+	result, err := a.repo.VerifyExecutionHistory(ctx, instanceID)
 	if err != nil {
 		return nil, err
 	}
+	verification := &workflow.HistoryVerification{
+		InstanceID: result.InstanceID,
+		Valid:      result.FirstBroken == nil,
+		Total:      result.Total,
+		Checked:    result.Checked,
+	}
+	if result.FirstBroken != nil {
+		verification.FirstBroken = &workflow.HistoryChainBreak{
+			HistoryID: result.FirstBroken.HistoryID,
+			Position:  result.FirstBroken.Position,
+			Reason:    result.FirstBroken.Reason,
+		}
+	}
+	return verification, nil
+}
+
+// GetPendingApprovals 按条件分页获取待审批任务及总数
+func (a *WorkflowInstanceRepositoryAdapter) GetPendingApprovals(ctx context.Context, filter workflow.PendingApprovalFilter) ([]*workflow.PendingApproval, int64, error) {
+	dbApprovals, total, err := a.repo.GetPendingApprovals(ctx, convertPendingApprovalFilter(filter))
+	if err != nil {
+		return nil, 0, err
+	}
 	
 	var approvals []*workflow.PendingApproval
 	for _, dbApproval := range dbApprovals {
@@ -155,12 +229,260 @@ func (a *WorkflowInstanceRepositoryAdapter) GetPendingApprovals(ctx context.Cont
 		
 		approvals = append(approvals, approval)
 	}
-	return approvals, nil
+	return approvals, total, nil
+}
+
+// CountPendingApprovals 按条件统计待审批任务数
+func (a *WorkflowInstanceRepositoryAdapter) CountPendingApprovals(ctx context.Context, filter workflow.PendingApprovalFilter) (int64, error) {
+	return a.repo.CountPendingApprovals(ctx, convertPendingApprovalFilter(filter))
+}
+
+// CountPendingApprovalGroups 按流程名称和业务类型分组统计待审批数
+func (a *WorkflowInstanceRepositoryAdapter) CountPendingApprovalGroups(ctx context.Context, filter workflow.PendingApprovalFilter) ([]*workflow.PendingApprovalGroupCount, error) {
+	dbCounts, err := a.repo.CountPendingApprovalGroups(ctx, convertPendingApprovalFilter(filter))
+	if err != nil {
+		return nil, err
+	}
+	counts := make([]*workflow.PendingApprovalGroupCount, 0, len(dbCounts))
+	for _, count := range dbCounts {
+		counts = append(counts, &workflow.PendingApprovalGroupCount{
+			WorkflowName: count.WorkflowName,
+			BusinessType: count.BusinessType,
+			Count:        count.Count,
+			OverdueCount: count.OverdueCount,
+		})
+	}
+	return counts, nil
+}
+
+// convertPendingApprovalFilter 转换待审批查询条件
+func convertPendingApprovalFilter(filter workflow.PendingApprovalFilter) *repository.PendingApprovalFilter {
+	return &repository.PendingApprovalFilter{
+		UserID:         filter.UserID,
+		WorkflowName:   filter.WorkflowName,
+		BusinessType:   filter.BusinessType,
+		DeadlineBefore: filter.DeadlineBefore,
+		SortBy:         filter.SortBy,
+		SortDesc:       filter.SortDesc(),
+		Page:           filter.Page,
+		PageSize:       filter.PageSize,
+		OverdueAt:      filter.Now,
+	}
 }
 
 // SavePendingApproval 保存待审批记录
 func (a *WorkflowInstanceRepositoryAdapter) SavePendingApproval(ctx context.Context, approval *workflow.PendingApproval) error {
-	dbApproval := &database.WorkflowPendingApproval{
+	return a.repo.SavePendingApproval(ctx, convertFromPendingApproval(approval))
+}
+
+// DeletePendingApproval 删除待审批记录
+func (a *WorkflowInstanceRepositoryAdapter) DeletePendingApproval(ctx context.Context, instanceID, nodeID string, userID uint) error {
+	return a.repo.DeletePendingApproval(ctx, instanceID, nodeID, userID)
+}
+
+// SaveNodeExecutionResult 在同一事务中写入节点的待审批记录、执行历史和实例状态
+func (a *WorkflowInstanceRepositoryAdapter) SaveNodeExecutionResult(ctx context.Context, instance *workflow.WorkflowInstance, history workflow.ExecutionHistory, approvals []*workflow.PendingApproval) error {
+	dbInstance, err := convertFromWorkflowInstance(instance)
+	if err != nil {
+		return err
+	}
+	dbApprovals := make([]*database.WorkflowPendingApproval, 0, len(approvals))
+	for _, approval := range approvals {
+		dbApprovals = append(dbApprovals, convertFromPendingApproval(approval))
+	}
+	return a.repo.SaveNodeExecutionResult(ctx, dbInstance, convertFromExecutionHistory(instance.ID, history), dbApprovals)
+}
+
+// SaveApprovalResult 在同一事务中写入审批动作的历史、待审批记录变更和实例状态
+func (a *WorkflowInstanceRepositoryAdapter) SaveApprovalResult(ctx context.Context, instance *workflow.WorkflowInstance, history workflow.ExecutionHistory, change workflow.PendingApprovalChange) error {
+	dbInstance, err := convertFromWorkflowInstance(instance)
+	if err != nil {
+		return err
+	}
+	dbApprovals := make([]*database.WorkflowPendingApproval, 0, len(change.Create))
+	for _, approval := range change.Create {
+		dbApprovals = append(dbApprovals, convertFromPendingApproval(approval))
+	}
+	return a.repo.SaveApprovalResult(ctx, dbInstance, convertFromExecutionHistory(instance.ID, history), change.CompleteInstance, change.CompleteAll, change.CompleteFor, dbApprovals)
+}
+
+// ListInstancesByStatus 按状态列出流程实例
+func (a *WorkflowInstanceRepositoryAdapter) ListInstancesByStatus(ctx context.Context, status workflow.InstanceStatus) ([]*workflow.WorkflowInstance, error) {
+	dbInstances, err := a.repo.ListInstancesByStatus(ctx, string(status))
+	if err != nil {
+		return nil, err
+	}
+
+	return convertToWorkflowInstances(dbInstances)
+}
+
+// ListInstances 按条件分页获取流程实例摘要及总数
+func (a *WorkflowInstanceRepositoryAdapter) ListInstances(ctx context.Context, filter workflow.InstanceFilter) ([]*workflow.InstanceSummary, int64, error) {
+	rows, total, err := a.repo.ListInstances(ctx, convertFromInstanceFilter(filter))
+	if err != nil {
+		return nil, 0, err
+	}
+	instances := make([]*workflow.InstanceSummary, 0, len(rows))
+	for _, row := range rows {
+		instances = append(instances, &workflow.InstanceSummary{
+			ID:                  row.InstanceID,
+			WorkflowID:          row.WorkflowID,
+			WorkflowName:        row.WorkflowName,
+			DefinitionVersionID: row.DefinitionVersionID,
+			BusinessID:          row.BusinessID,
+			BusinessType:        row.BusinessType,
+			Status:              workflow.InstanceStatus(row.Status),
+			CurrentNodes:        getCurrentNodesFromJSONField(row.CurrentNodes),
+			StartedBy:           row.StartedBy,
+			StartedAt:           row.StartedAt,
+			CompletedAt:         row.CompletedAt,
+			Deadline:            row.Deadline,
+			Archived:            row.ArchivedAt != nil,
+			ArchivedAt:          row.ArchivedAt,
+		})
+	}
+	return instances, total, nil
+}
+
+// CountInstances 按条件统计实例数，按流程和状态分组
+func (a *WorkflowInstanceRepositoryAdapter) CountInstances(ctx context.Context, filter workflow.InstanceFilter) ([]workflow.InstanceCount, error) {
+	rows, err := a.repo.CountInstances(ctx, convertFromInstanceFilter(filter))
+	if err != nil {
+		return nil, err
+	}
+	counts := make([]workflow.InstanceCount, 0, len(rows))
+	for _, row := range rows {
+		counts = append(counts, workflow.InstanceCount{
+			WorkflowID:   row.WorkflowID,
+			WorkflowName: row.WorkflowName,
+			Status:       workflow.InstanceStatus(row.Status),
+			Count:        row.Count,
+		})
+	}
+	return counts, nil
+}
+
+// convertFromInstanceFilter 转换流程实例查询条件
+func convertFromInstanceFilter(filter workflow.InstanceFilter) *repository.WorkflowInstanceFilter {
+	return &repository.WorkflowInstanceFilter{
+		Status:          string(filter.Status),
+		WorkflowID:      filter.WorkflowID,
+		WorkflowName:    filter.WorkflowName,
+		BusinessType:    filter.BusinessType,
+		BusinessID:      filter.BusinessID,
+		StartedBy:       filter.StartedBy,
+		StartedFrom:     filter.StartedFrom,
+		StartedTo:       filter.StartedTo,
+		IncludeArchived: filter.IncludeArchived,
+		Page:            filter.Page,
+		PageSize:        filter.PageSize,
+	}
+}
+
+// ListOverdueInstances 列出超过截止时间或存在超时待审批的运行中实例
+func (a *WorkflowInstanceRepositoryAdapter) ListOverdueInstances(ctx context.Context, now time.Time) ([]*workflow.WorkflowInstance, error) {
+	dbInstances, err := a.repo.ListOverdueInstances(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+	return convertToWorkflowInstances(dbInstances)
+}
+
+// ExpireInstance 关闭实例的待审批记录、写入执行历史并将实例标记为过期
+func (a *WorkflowInstanceRepositoryAdapter) ExpireInstance(ctx context.Context, instance *workflow.WorkflowInstance, history workflow.ExecutionHistory) error {
+	dbInstance, err := convertFromWorkflowInstance(instance)
+	if err != nil {
+		return err
+	}
+	return a.repo.ExpireInstance(ctx, dbInstance, convertFromExecutionHistory(instance.ID, history))
+}
+
+// LockInstance 锁定流程实例并在锁内执行fn
+func (a *WorkflowInstanceRepositoryAdapter) LockInstance(ctx context.Context, instanceID string, fn func(ctx context.Context) error) error {
+	return a.repo.LockInstance(ctx, instanceID, fn)
+}
+
+// convertToWorkflowInstances 批量转换数据库实例
+func convertToWorkflowInstances(dbInstances []*database.WorkflowInstance) ([]*workflow.WorkflowInstance, error) {
+	instances := make([]*workflow.WorkflowInstance, 0, len(dbInstances))
+	for _, dbInstance := range dbInstances {
+		instance, err := convertToWorkflowInstance(dbInstance)
+		if err != nil {
+			return nil, err
+		}
+		instances = append(instances, instance)
+	}
+	return instances, nil
+}
+
+// GetExecutionHistory 获取实例的执行历史
+func (a *WorkflowInstanceRepositoryAdapter) GetExecutionHistory(ctx context.Context, instanceID string) ([]workflow.ExecutionHistory, error) {
+	dbHistories, err := a.repo.GetExecutionHistory(ctx, instanceID)
+	if err != nil {
+		return nil, err
+	}
+
+	histories := make([]workflow.ExecutionHistory, 0, len(dbHistories))
+	for _, dbHistory := range dbHistories {
+		history := workflow.ExecutionHistory{
+			ID:         dbHistory.HistoryID,
+			NodeID:     dbHistory.NodeID,
+			NodeName:   dbHistory.NodeName,
+			Action:     dbHistory.Action,
+			Result:     dbHistory.Result,
+			Comment:    dbHistory.Comment,
+			Variables:  getMapFromJSONField(dbHistory.Variables),
+			ExecutedBy: dbHistory.ExecutedBy,
+			ExecutedAt: dbHistory.ExecutedAt,
+			Duration:   time.Duration(dbHistory.Duration) * time.Millisecond,
+		}
+		if err := decodeJSONField(dbHistory.Attachments, &history.Attachments); err != nil {
+			return nil, fmt.Errorf("解析审批附件失败: %w", err)
+		}
+		if err := decodeJSONField(dbHistory.Mentions, &history.Mentions); err != nil {
+			return nil, fmt.Errorf("解析审批提及用户失败: %w", err)
+		}
+		histories = append(histories, history)
+	}
+	return histories, nil
+}
+
+// HasOpenPendingApprovals 节点是否还有未完成的待审批记录
+func (a *WorkflowInstanceRepositoryAdapter) HasOpenPendingApprovals(ctx context.Context, instanceID, nodeID string) (bool, error) {
+	count, err := a.repo.CountOpenPendingApprovals(ctx, instanceID, nodeID)
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// 转换函数
+func convertFromExecutionHistory(instanceID string, history workflow.ExecutionHistory) *database.WorkflowExecutionHistory {
+	dbHistory := &database.WorkflowExecutionHistory{
+		HistoryID:  history.ID,
+		InstanceID: instanceID,
+		NodeID:     history.NodeID,
+		NodeName:   history.NodeName,
+		Action:     history.Action,
+		Result:     history.Result,
+		Comment:    history.Comment,
+		Variables:  database.JSONField{Data: history.Variables},
+		ExecutedBy: history.ExecutedBy,
+		ExecutedAt: history.ExecutedAt,
+		Duration:   int64(history.Duration.Milliseconds()),
+	}
+	// 没有附件和提及时保持NULL，哈希与新增字段前写入的记录一致
+	if len(history.Attachments) > 0 {
+		dbHistory.Attachments = database.JSONField{Data: history.Attachments}
+	}
+	if len(history.Mentions) > 0 {
+		dbHistory.Mentions = database.JSONField{Data: history.Mentions}
+	}
+	return dbHistory
+}
+
+func convertFromPendingApproval(approval *workflow.PendingApproval) *database.WorkflowPendingApproval {
+	return &database.WorkflowPendingApproval{
 		BaseModel: database.BaseModel{
 			CreatedAt: approval.CreatedAt,
 		},
@@ -177,15 +499,8 @@ func (a *WorkflowInstanceRepositoryAdapter) SavePendingApproval(ctx context.Cont
 		CanDelegate:     approval.CanDelegate,
 		RequiredActions: database.JSONField{Data: approval.RequiredAction},
 	}
-	return a.repo.SavePendingApproval(ctx, dbApproval)
 }
 
-// DeletePendingApproval 删除待审批记录
-func (a *WorkflowInstanceRepositoryAdapter) DeletePendingApproval(ctx context.Context, instanceID, nodeID string, userID uint) error {
-	return a.repo.DeletePendingApproval(ctx, instanceID, nodeID, userID)
-}
-
-// 转换函数
 func convertToWorkflowDefinition(dbDef *database.WorkflowDefinition) (*workflow.WorkflowDefinition, error) {
 	var nodes []workflow.WorkflowNode
 	if dbDef.Nodes.Data != nil {
@@ -207,14 +522,28 @@ func convertToWorkflowDefinition(dbDef *database.WorkflowDefinition) (*workflow.
 		}
 	}
 	
+	var variableSchema []workflow.VariableDefinition
+	if dbDef.VariableSchema.Data != nil {
+		// 解析变量声明，变量声明出现之前的定义没有该列数据
+		if schemaData, err := json.Marshal(dbDef.VariableSchema.Data); err == nil {
+			if err := json.Unmarshal(schemaData, &variableSchema); err != nil {
+				return nil, fmt.Errorf("解析工作流变量声明失败: %w", err)
+			}
+		}
+	}
+
 	return &workflow.WorkflowDefinition{
-		ID:          dbDef.WorkflowID,
+		ID:            dbDef.WorkflowID,
+		VersionID:     dbDef.ID,
+		VersionNumber: dbDef.VersionNumber,
 		Name:        dbDef.Name,
 		Description: dbDef.Description,
 		Version:     dbDef.Version,
 		Nodes:       nodes,
 		Edges:       edges,
 		Variables:   getMapFromJSONField(dbDef.Variables),
+		VariableSchema: variableSchema,
+		MaxDuration: dbDef.MaxDuration,
 		CreatedAt:   dbDef.CreatedAt,
 		UpdatedAt:   dbDef.UpdatedAt,
 		IsActive:    dbDef.IsActive,
@@ -227,41 +556,40 @@ func convertFromWorkflowDefinition(def *workflow.WorkflowDefinition) (*database.
 	edgesJSON := database.JSONField{Data: def.Edges}
 	
 	return &database.WorkflowDefinition{
-		WorkflowID:  def.ID,
+		BaseModel: database.BaseModel{
+			ID: def.VersionID,
+		},
+		WorkflowID:    def.ID,
+		VersionNumber: def.VersionNumber,
 		Name:        def.Name,
 		Description: def.Description,
 		Version:     def.Version,
 		Nodes:       nodesJSON,
 		Edges:       edgesJSON,
 		Variables:   database.JSONField{Data: def.Variables},
+		VariableSchema: database.JSONField{Data: def.VariableSchema},
+		MaxDuration: def.MaxDuration,
 		IsActive:    def.IsActive,
 	}, nil
 }
 
 func convertToWorkflowInstance(dbInstance *database.WorkflowInstance) (*workflow.WorkflowInstance, error) {
-	var currentNodes []string
-	if dbInstance.CurrentNodes.Data != nil {
-		if nodeList, ok := dbInstance.CurrentNodes.Data.([]interface{}); ok {
-			for _, node := range nodeList {
-				if nodeStr, ok := node.(string); ok {
-					currentNodes = append(currentNodes, nodeStr)
-				}
-			}
-		}
-	}
-	
 	return &workflow.WorkflowInstance{
 		ID:           dbInstance.InstanceID,
 		WorkflowID:   dbInstance.WorkflowID,
+		DefinitionVersionID: dbInstance.DefinitionVersionID,
 		BusinessID:   dbInstance.BusinessID,
 		BusinessType: dbInstance.BusinessType,
 		Status:       workflow.InstanceStatus(dbInstance.Status),
-		CurrentNodes: currentNodes,
+		CurrentNodes: getCurrentNodesFromJSONField(dbInstance.CurrentNodes),
 		Variables:    getMapFromJSONField(dbInstance.Variables),
 		StartedBy:    dbInstance.StartedBy,
 		StartedAt:    dbInstance.StartedAt,
 		CompletedAt:  dbInstance.CompletedAt,
 		History:      []workflow.ExecutionHistory{}, // 需要单独查询
+		Approvals:    getApprovalStatesFromJSONField(dbInstance.ApprovalStates),
+		Completion:   getCompletionFromJSONField(dbInstance.Completion),
+		Deadline:     dbInstance.Deadline,
 	}, nil
 }
 
@@ -271,17 +599,77 @@ func convertFromWorkflowInstance(instance *workflow.WorkflowInstance) (*database
 	return &database.WorkflowInstance{
 		InstanceID:   instance.ID,
 		WorkflowID:   instance.WorkflowID,
+		DefinitionVersionID: instance.DefinitionVersionID,
 		BusinessID:   instance.BusinessID,
 		BusinessType: instance.BusinessType,
 		Status:       string(instance.Status),
 		CurrentNodes: currentNodesJSON,
 		Variables:    database.JSONField{Data: instance.Variables},
+		ApprovalStates: database.JSONField{Data: instance.Approvals},
 		StartedBy:    instance.StartedBy,
 		StartedAt:    instance.StartedAt,
 		CompletedAt:  instance.CompletedAt,
+		Deadline:     instance.Deadline,
 	}, nil
 }
 
+// getApprovalStatesFromJSONField 从JSONField中还原审批节点的逐人决策
+func getApprovalStatesFromJSONField(field database.JSONField) map[string]*workflow.NodeApprovalState {
+	if field.Data == nil {
+		return nil
+	}
+	data, err := json.Marshal(field.Data)
+	if err != nil {
+		return nil
+	}
+	var states map[string]*workflow.NodeApprovalState
+	if err := json.Unmarshal(data, &states); err != nil {
+		return nil
+	}
+	return states
+}
+
+// getCompletionFromJSONField 从JSONField中还原业务回调执行记录
+func getCompletionFromJSONField(field database.JSONField) *workflow.CompletionRecord {
+	if field.Data == nil {
+		return nil
+	}
+	data, err := json.Marshal(field.Data)
+	if err != nil {
+		return nil
+	}
+	var record workflow.CompletionRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil
+	}
+	return &record
+}
+
+// getCurrentNodesFromJSONField 从JSONField中解析当前节点列表
+func getCurrentNodesFromJSONField(field database.JSONField) []string {
+	var currentNodes []string
+	if nodeList, ok := field.Data.([]interface{}); ok {
+		for _, node := range nodeList {
+			if nodeStr, ok := node.(string); ok {
+				currentNodes = append(currentNodes, nodeStr)
+			}
+		}
+	}
+	return currentNodes
+}
+
+// decodeJSONField 将JSON字段解析到target，字段为空时不修改target
+func decodeJSONField(field database.JSONField, target interface{}) error {
+	if field.Data == nil {
+		return nil
+	}
+	data, err := json.Marshal(field.Data)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}
+
 // getMapFromJSONField 从JSONField中提取map[string]interface{}
 func getMapFromJSONField(field database.JSONField) map[string]interface{} {
 	if field.Data == nil {
@@ -2,19 +2,28 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 
+	"taskmanage/internal/repository"
 	"taskmanage/internal/workflow"
+	"taskmanage/pkg/logger"
 )
 
 // WorkflowServiceWrapper 工作流服务包装器
 type WorkflowServiceWrapper struct {
-	workflowService *workflow.WorkflowService
+	workflowService     *workflow.WorkflowService
+	notificationService NotificationService
+	userRepo            repository.UserRepository
 }
 
 // NewWorkflowServiceWrapper 创建工作流服务包装器
-func NewWorkflowServiceWrapper(workflowService *workflow.WorkflowService) WorkflowService {
+func NewWorkflowServiceWrapper(workflowService *workflow.WorkflowService, notificationService NotificationService, userRepo repository.UserRepository) WorkflowService {
 	return &WorkflowServiceWrapper{
-		workflowService: workflowService,
+		workflowService:     workflowService,
+		notificationService: notificationService,
+		userRepo:            userRepo,
 	}
 }
 
@@ -33,22 +42,151 @@ func (w *WorkflowServiceWrapper) ProcessTaskAssignmentApproval(ctx context.Conte
 	}
 	// 转换为ProcessApprovalRequest
 	processReq := &workflow.ProcessApprovalRequest{
-		InstanceID: req.InstanceID,
-		NodeID:     req.NodeID,
-		Action:     req.Action,
-		Comment:    req.Comment,
-		Variables:  req.Variables,
-		ApprovedBy: req.ApprovedBy,
+		InstanceID:    req.InstanceID,
+		NodeID:        req.NodeID,
+		Action:        req.Action,
+		Comment:       req.Comment,
+		Variables:     req.Variables,
+		ApprovedBy:    req.ApprovedBy,
+		DelegateTo:    req.DelegateTo,
+		ReturnTo:      req.ReturnTo,
+		AttachmentIDs: req.AttachmentIDs,
+		Mentions:      req.Mentions,
 	}
 	return w.workflowService.ProcessTaskAssignmentApproval(ctx, processReq)
 }
 
-// GetPendingTaskAssignmentApprovals 获取待审批任务分配
-func (w *WorkflowServiceWrapper) GetPendingTaskAssignmentApprovals(ctx context.Context, userID uint) ([]*workflow.PendingApproval, error) {
+// batchApprovalSummary 单个申请人在一次批量审批中的处理汇总
+type batchApprovalSummary struct {
+	approved int
+	rejected int
+	returned int
+}
+
+// batchApprovalKey 标记批量审批的上下文，批量审批的申请人只接收汇总通知，不逐条接收审批结果通知
+type batchApprovalKey struct{}
+
+// withBatchApproval 返回标记为批量审批的上下文
+func withBatchApproval(ctx context.Context) context.Context {
+	return context.WithValue(ctx, batchApprovalKey{}, true)
+}
+
+// isBatchApproval 上下文是否处于批量审批中
+func isBatchApproval(ctx context.Context) bool {
+	batch, _ := ctx.Value(batchApprovalKey{}).(bool)
+	return batch
+}
+
+// ProcessApprovalsBatch 批量处理审批
+// 每条审批独立处理，单条失败不影响其它条目；处理完成后按申请人汇总发送一条通知
+func (w *WorkflowServiceWrapper) ProcessApprovalsBatch(ctx context.Context, req *BatchApprovalRequest) (*BatchApprovalResponse, error) {
 	if w.workflowService == nil {
 		return nil, workflow.ErrWorkflowServiceNotReady
 	}
-	return w.workflowService.GetPendingTaskAssignmentApprovals(ctx, userID)
+	ctx = withBatchApproval(ctx)
+	if len(req.Items) == 0 {
+		return nil, fmt.Errorf("批量审批条目不能为空")
+	}
+	if len(req.Items) > MaxBatchApprovalItems {
+		return nil, fmt.Errorf("批量审批条目不能超过%d条", MaxBatchApprovalItems)
+	}
+
+	// 只允许处理分配给当前审批人的待审批节点
+	pending, _, err := w.workflowService.GetPendingTaskAssignmentApprovals(ctx, workflow.PendingApprovalFilter{UserID: req.ApproverID})
+	if err != nil {
+		return nil, fmt.Errorf("获取待审批任务失败: %w", err)
+	}
+	assigned := make(map[string]bool, len(pending))
+	for _, approval := range pending {
+		assigned[approval.InstanceID+"/"+approval.NodeID] = true
+	}
+
+	resp := &BatchApprovalResponse{
+		Total:   len(req.Items),
+		Results: make([]BatchApprovalItemResult, 0, len(req.Items)),
+	}
+	summaries := make(map[uint]*batchApprovalSummary)
+	var requesters []uint
+
+	for _, item := range req.Items {
+		itemResult := BatchApprovalItemResult{InstanceID: item.InstanceID, NodeID: item.NodeID}
+
+		key := item.InstanceID + "/" + item.NodeID
+		if !assigned[key] {
+			itemResult.Error = "该审批未分配给当前用户"
+			resp.Failed++
+			resp.Results = append(resp.Results, itemResult)
+			continue
+		}
+
+		result, err := w.ProcessTaskAssignmentApproval(ctx, &workflow.ApprovalRequest{
+			InstanceID: item.InstanceID,
+			NodeID:     item.NodeID,
+			Action:     item.Action,
+			Comment:    item.Comment,
+			ApprovedBy: req.ApproverID,
+		})
+		if err != nil {
+			logger.Warnf("批量审批条目处理失败: instance_id=%s, node_id=%s, error=%v", item.InstanceID, item.NodeID, err)
+			itemResult.Error = err.Error()
+			resp.Failed++
+			resp.Results = append(resp.Results, itemResult)
+			continue
+		}
+
+		// 同一节点在本批次中只能处理一次
+		delete(assigned, key)
+		itemResult.Success = true
+		itemResult.Result = result
+		resp.Succeeded++
+		resp.Results = append(resp.Results, itemResult)
+
+		instance, err := w.workflowService.GetWorkflowInstance(ctx, item.InstanceID)
+		if err != nil || instance.StartedBy == 0 {
+			continue
+		}
+		summary, ok := summaries[instance.StartedBy]
+		if !ok {
+			summary = &batchApprovalSummary{}
+			summaries[instance.StartedBy] = summary
+			requesters = append(requesters, instance.StartedBy)
+		}
+		switch item.Action {
+		case workflow.ActionApprove:
+			summary.approved++
+		case workflow.ActionReject:
+			summary.rejected++
+		case workflow.ActionReturn:
+			summary.returned++
+		}
+	}
+
+	w.notifyBatchApprovalRequesters(ctx, requesters, summaries)
+
+	return resp, nil
+}
+
+// notifyBatchApprovalRequesters 按申请人发送批量审批汇总通知，通知失败不影响审批结果
+func (w *WorkflowServiceWrapper) notifyBatchApprovalRequesters(ctx context.Context, requesters []uint, summaries map[uint]*batchApprovalSummary) {
+	if w.notificationService == nil {
+		return
+	}
+	for _, requesterID := range requesters {
+		summary := summaries[requesterID]
+		content := fmt.Sprintf("您提交的%d项审批已处理：通过%d项，拒绝%d项，退回%d项",
+			summary.approved+summary.rejected+summary.returned, summary.approved, summary.rejected, summary.returned)
+		if err := w.notificationService.CreateCategorizedNotification(ctx, requesterID, NotificationCategoryApprovalResolved, "审批处理结果", content); err != nil {
+			logger.Warnf("发送批量审批汇总通知失败: requester_id=%d, error=%v", requesterID, err)
+		}
+	}
+}
+
+// GetPendingTaskAssignmentApprovals 获取待审批任务分配
+func (w *WorkflowServiceWrapper) GetPendingTaskAssignmentApprovals(ctx context.Context, filter workflow.PendingApprovalFilter) ([]*workflow.PendingApproval, int64, error) {
+	if w.workflowService == nil {
+		return nil, 0, workflow.ErrWorkflowServiceNotReady
+	}
+	return w.workflowService.GetPendingTaskAssignmentApprovals(ctx, filter)
 }
 
 // GetWorkflowInstance 获取流程实例
@@ -59,12 +197,60 @@ func (w *WorkflowServiceWrapper) GetWorkflowInstance(ctx context.Context, instan
 	return w.workflowService.GetWorkflowInstance(ctx, instanceID)
 }
 
+// GetWorkflowInstanceForViewer 获取流程实例，查看者无权查看敏感变量时返回脱敏后的副本
+func (w *WorkflowServiceWrapper) GetWorkflowInstanceForViewer(ctx context.Context, instanceID string, viewer workflow.VariableViewer) (*workflow.WorkflowInstance, error) {
+	if w.workflowService == nil {
+		return nil, workflow.ErrWorkflowServiceNotReady
+	}
+	return w.workflowService.GetWorkflowInstanceForViewer(ctx, instanceID, viewer)
+}
+
+// ListWorkflowInstances 按条件分页获取流程实例
+func (w *WorkflowServiceWrapper) ListWorkflowInstances(ctx context.Context, filter workflow.InstanceFilter) ([]*workflow.InstanceSummary, int64, error) {
+	if w.workflowService == nil {
+		return nil, 0, workflow.ErrWorkflowServiceNotReady
+	}
+	return w.workflowService.ListInstances(ctx, filter)
+}
+
+// GetWorkflowInstanceStats 按条件统计流程实例
+func (w *WorkflowServiceWrapper) GetWorkflowInstanceStats(ctx context.Context, filter workflow.InstanceFilter) (*workflow.InstanceStats, error) {
+	if w.workflowService == nil {
+		return nil, workflow.ErrWorkflowServiceNotReady
+	}
+	return w.workflowService.InstanceStats(ctx, filter)
+}
+
+// ExpireOverdueInstances 将超过SLA或节点超时的运行中实例标记为过期
+func (w *WorkflowServiceWrapper) ExpireOverdueInstances(ctx context.Context, now time.Time) (*workflow.ExpiryReport, error) {
+	if w.workflowService == nil {
+		return nil, workflow.ErrWorkflowServiceNotReady
+	}
+	return w.workflowService.ExpireOverdueInstances(ctx, now)
+}
+
 // GetPendingApprovals 获取待审批任务
-func (w *WorkflowServiceWrapper) GetPendingApprovals(ctx context.Context, userID uint) ([]*workflow.PendingApproval, error) {
+func (w *WorkflowServiceWrapper) GetPendingApprovals(ctx context.Context, filter workflow.PendingApprovalFilter) ([]*workflow.PendingApproval, int64, error) {
+	if w.workflowService == nil {
+		return nil, 0, workflow.ErrWorkflowServiceNotReady
+	}
+	return w.workflowService.GetPendingApprovals(ctx, filter)
+}
+
+// CountPendingApprovals 统计待审批任务数
+func (w *WorkflowServiceWrapper) CountPendingApprovals(ctx context.Context, filter workflow.PendingApprovalFilter) (int64, error) {
+	if w.workflowService == nil {
+		return 0, workflow.ErrWorkflowServiceNotReady
+	}
+	return w.workflowService.CountPendingApprovals(ctx, filter)
+}
+
+// SummarizePendingApprovals 汇总待审批任务
+func (w *WorkflowServiceWrapper) SummarizePendingApprovals(ctx context.Context, filter workflow.PendingApprovalFilter) (*workflow.PendingApprovalSummary, error) {
 	if w.workflowService == nil {
 		return nil, workflow.ErrWorkflowServiceNotReady
 	}
-	return w.workflowService.GetPendingTaskAssignmentApprovals(ctx, userID)
+	return w.workflowService.SummarizePendingApprovals(ctx, filter)
 }
 
 // CancelWorkflow 取消流程
@@ -75,17 +261,63 @@ func (w *WorkflowServiceWrapper) CancelWorkflow(ctx context.Context, instanceID
 	return w.workflowService.CancelTaskAssignmentApproval(ctx, instanceID, reason)
 }
 
-// GetWorkflowHistory 获取流程历史
-func (w *WorkflowServiceWrapper) GetWorkflowHistory(ctx context.Context, instanceID string) ([]workflow.ExecutionHistory, error) {
+// RecoverInstances 恢复中断的节点执行
+func (w *WorkflowServiceWrapper) RecoverInstances(ctx context.Context) (*workflow.RecoveryReport, error) {
+	if w.workflowService == nil {
+		return nil, workflow.ErrWorkflowServiceNotReady
+	}
+	return w.workflowService.RecoverInstances(ctx)
+}
+
+// SelfCheck 流程引擎自检
+func (w *WorkflowServiceWrapper) SelfCheck(ctx context.Context) (*workflow.SelfCheckReport, error) {
+	if w.workflowService == nil {
+		return nil, workflow.ErrWorkflowServiceNotReady
+	}
+	return w.workflowService.SelfCheck(ctx)
+}
+
+// RetryCompletion 重新执行失败的业务回调
+func (w *WorkflowServiceWrapper) RetryCompletion(ctx context.Context, instanceID string) (*workflow.CompletionRecord, error) {
 	if w.workflowService == nil {
 		return nil, workflow.ErrWorkflowServiceNotReady
 	}
-	// 获取实例并返回历史
-	instance, err := w.workflowService.GetWorkflowInstance(ctx, instanceID)
+	return w.workflowService.RetryCompletion(ctx, instanceID)
+}
+
+// runAutoApprovedCompletion 流程在启动时已全部自动审批通过的，在调用方保存业务记录后执行待执行的业务回调
+// 返回流程是否已结束，回调失败只记录日志，可通过重试业务回调接口补偿
+func runAutoApprovedCompletion(ctx context.Context, workflowService WorkflowService, instance *workflow.WorkflowInstance) bool {
+	if instance.Status != workflow.StatusCompleted {
+		return false
+	}
+	record, err := workflowService.RetryCompletion(ctx, instance.ID)
 	if err != nil {
-		return nil, err
+		if !errors.Is(err, workflow.ErrCompletionNotRetryable) {
+			logger.Warnf("执行自动审批流程的业务回调失败: instance_id=%s, error=%v", instance.ID, err)
+		}
+		return true
+	}
+	if record.Status == workflow.CompletionFailed {
+		logger.Warnf("自动审批流程的业务回调失败: instance_id=%s, error=%s", instance.ID, record.Error)
+	}
+	return true
+}
+
+// VerifyWorkflowHistory 校验流程执行历史的哈希链
+func (w *WorkflowServiceWrapper) VerifyWorkflowHistory(ctx context.Context, instanceID string) (*workflow.HistoryVerification, error) {
+	if w.workflowService == nil {
+		return nil, workflow.ErrWorkflowServiceNotReady
+	}
+	return w.workflowService.VerifyHistory(ctx, instanceID)
+}
+
+// GetWorkflowHistory 获取流程历史
+func (w *WorkflowServiceWrapper) GetWorkflowHistory(ctx context.Context, instanceID string) ([]workflow.ExecutionHistory, error) {
+	if w.workflowService == nil {
+		return nil, workflow.ErrWorkflowServiceNotReady
 	}
-	return instance.History, nil
+	return w.workflowService.GetExecutionHistory(ctx, instanceID)
 }
 
 // CreateWorkflowDefinition 创建工作流定义
@@ -129,12 +361,36 @@ func (w *WorkflowServiceWrapper) DeleteWorkflowDefinition(ctx context.Context, i
 	return w.workflowService.GetDefinitionManager().DeactivateWorkflow(ctx, id)
 }
 
-// ValidateWorkflowDefinition 验证工作流定义
-func (w *WorkflowServiceWrapper) ValidateWorkflowDefinition(ctx context.Context, req *workflow.CreateWorkflowRequest) error {
+// ValidateWorkflowDefinition 诊断工作流定义，返回全部错误和警告
+func (w *WorkflowServiceWrapper) ValidateWorkflowDefinition(ctx context.Context, req *workflow.CreateWorkflowRequest) ([]workflow.Diagnostic, error) {
+	if w.workflowService == nil {
+		return nil, workflow.ErrWorkflowServiceNotReady
+	}
+	return w.workflowService.GetDefinitionManager().DiagnoseWorkflow(ctx, req), nil
+}
+
+// ListWorkflowDefinitionVersions 获取工作流定义版本列表
+func (w *WorkflowServiceWrapper) ListWorkflowDefinitionVersions(ctx context.Context, id string) ([]*workflow.WorkflowDefinition, error) {
+	if w.workflowService == nil {
+		return nil, workflow.ErrWorkflowServiceNotReady
+	}
+	return w.workflowService.GetDefinitionManager().ListWorkflowVersions(ctx, id)
+}
+
+// ActivateWorkflowDefinitionVersion 激活工作流定义版本
+func (w *WorkflowServiceWrapper) ActivateWorkflowDefinitionVersion(ctx context.Context, id string, versionID uint) error {
+	if w.workflowService == nil {
+		return workflow.ErrWorkflowServiceNotReady
+	}
+	return w.workflowService.GetDefinitionManager().ActivateWorkflowVersion(ctx, id, versionID)
+}
+
+// DeactivateWorkflowDefinitionVersion 停用工作流定义版本
+func (w *WorkflowServiceWrapper) DeactivateWorkflowDefinitionVersion(ctx context.Context, id string, versionID uint) error {
 	if w.workflowService == nil {
 		return workflow.ErrWorkflowServiceNotReady
 	}
-	return w.workflowService.GetDefinitionManager().ValidateWorkflow(ctx, req)
+	return w.workflowService.GetDefinitionManager().DeactivateWorkflowVersion(ctx, id, versionID)
 }
 
 // StartOnboardingApproval 启动入职审批流程
@@ -152,3 +408,19 @@ func (w *WorkflowServiceWrapper) ProcessOnboardingApproval(ctx context.Context,
 	}
 	return w.workflowService.ProcessOnboardingApproval(ctx, req)
 }
+
+// StartOffboardingApproval 启动离职审批流程
+func (w *WorkflowServiceWrapper) StartOffboardingApproval(ctx context.Context, req *workflow.OffboardingApprovalRequest) (*workflow.WorkflowInstance, error) {
+	if w.workflowService == nil {
+		return nil, workflow.ErrWorkflowServiceNotReady
+	}
+	return w.workflowService.StartOffboardingApproval(ctx, req)
+}
+
+// ProcessOffboardingApproval 处理离职审批
+func (w *WorkflowServiceWrapper) ProcessOffboardingApproval(ctx context.Context, req *workflow.ApprovalRequest) (*workflow.ApprovalResult, error) {
+	if w.workflowService == nil {
+		return nil, workflow.ErrWorkflowServiceNotReady
+	}
+	return w.workflowService.ProcessOffboardingApproval(ctx, req)
+}
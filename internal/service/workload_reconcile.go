@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"taskmanage/internal/repository"
+	"taskmanage/pkg/logger"
+)
+
+// WorkloadCorrection 单个员工的任务数校正结果
+type WorkloadCorrection struct {
+	EmployeeID uint   `json:"employee_id"`
+	Stored     int    `json:"stored"`   // 校正前employees.current_tasks的值
+	Computed   int    `json:"computed"` // 按任务表统计的进行中任务数
+	Error      string `json:"error,omitempty"`
+}
+
+// WorkloadReconcileReport 员工任务数校正汇总
+type WorkloadReconcileReport struct {
+	Checked   int                   `json:"checked"`
+	Corrected []*WorkloadCorrection `json:"corrected"`
+	Skipped   []*WorkloadCorrection `json:"skipped"` // 校正期间计数被并发调整，留待下次校正
+	Failed    []*WorkloadCorrection `json:"failed"`
+}
+
+// ReconcileWorkload 以任务表中assigned、in_progress、in_review状态的任务为准校正全部员工的current_tasks并记录每次修正
+func (s *EmployeeServiceImpl) ReconcileWorkload(ctx context.Context) (*WorkloadReconcileReport, error) {
+	employees, err := s.employeeRepo.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取员工列表失败: %w", err)
+	}
+	counts, err := s.employeeRepo.CountActiveTasks(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &WorkloadReconcileReport{
+		Checked:   len(employees),
+		Corrected: make([]*WorkloadCorrection, 0),
+		Skipped:   make([]*WorkloadCorrection, 0),
+		Failed:    make([]*WorkloadCorrection, 0),
+	}
+	for _, employee := range employees {
+		computed := counts[employee.ID]
+		if employee.CurrentTasks == computed {
+			continue
+		}
+
+		correction := &WorkloadCorrection{EmployeeID: employee.ID, Stored: employee.CurrentTasks, Computed: computed}
+		err := s.employeeRepo.CorrectTaskCount(ctx, employee.ID, employee.CurrentTasks, computed)
+		switch {
+		case err == nil:
+			logger.Infof("已校正员工任务数: EmployeeID=%d, 原值=%d, 实际=%d", employee.ID, employee.CurrentTasks, computed)
+			report.Corrected = append(report.Corrected, correction)
+		case errors.Is(err, repository.ErrConflict):
+			logger.Infof("员工任务数校正期间已变化，下次校正时处理: EmployeeID=%d", employee.ID)
+			report.Skipped = append(report.Skipped, correction)
+		default:
+			logger.Warnf("校正员工任务数失败: EmployeeID=%d, error: %v", employee.ID, err)
+			correction.Error = err.Error()
+			report.Failed = append(report.Failed, correction)
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/config"
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+// driftingEmployeeRepository 存储计数与任务表统计不一致的员工仓储桩
+type driftingEmployeeRepository struct {
+	repository.EmployeeRepository
+	employees  []*database.Employee
+	active     map[uint]int
+	concurrent map[uint]bool // 校正时计数已被并发调整的员工
+	stats      map[uint]*repository.EmployeeTaskStats
+	filters    []*repository.WorkloadFilter
+}
+
+func (r *driftingEmployeeRepository) GetAll(ctx context.Context) ([]*database.Employee, error) {
+	return r.employees, nil
+}
+
+func (r *driftingEmployeeRepository) GetByID(ctx context.Context, id uint) (*database.Employee, error) {
+	for _, employee := range r.employees {
+		if employee.ID == id {
+			return employee, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *driftingEmployeeRepository) CountActiveTasks(ctx context.Context, employeeIDs []uint) (map[uint]int, error) {
+	return r.active, nil
+}
+
+func (r *driftingEmployeeRepository) ListForWorkload(ctx context.Context, filter *repository.WorkloadFilter) ([]*database.Employee, error) {
+	var result []*database.Employee
+	for _, employee := range r.employees {
+		if filter.DepartmentID == 0 || (employee.DepartmentID != nil && *employee.DepartmentID == filter.DepartmentID) {
+			result = append(result, employee)
+		}
+	}
+	return result, nil
+}
+
+func (r *driftingEmployeeRepository) AggregateTaskStats(ctx context.Context, filter *repository.WorkloadFilter, now time.Time) (map[uint]*repository.EmployeeTaskStats, error) {
+	r.filters = append(r.filters, filter)
+	return r.stats, nil
+}
+
+func (r *driftingEmployeeRepository) CorrectTaskCount(ctx context.Context, employeeID uint, expected, count int) error {
+	if r.concurrent[employeeID] {
+		return repository.ErrConflict
+	}
+	for _, employee := range r.employees {
+		if employee.ID == employeeID && employee.CurrentTasks == expected {
+			employee.CurrentTasks = count
+		}
+	}
+	return nil
+}
+
+func TestReconcileWorkload_CorrectsDrift(t *testing.T) {
+	repo := &driftingEmployeeRepository{
+		employees: []*database.Employee{
+			{BaseModel: database.BaseModel{ID: 1}, CurrentTasks: 7, MaxTasks: 5},
+			{BaseModel: database.BaseModel{ID: 2}, CurrentTasks: 2, MaxTasks: 5},
+			{BaseModel: database.BaseModel{ID: 3}, CurrentTasks: 1, MaxTasks: 5},
+			{BaseModel: database.BaseModel{ID: 4}, CurrentTasks: 3, MaxTasks: 5},
+		},
+		active:     map[uint]int{1: 2, 2: 2, 4: 1},
+		concurrent: map[uint]bool{4: true},
+	}
+	svc := &EmployeeServiceImpl{employeeRepo: repo}
+	ctx := context.Background()
+
+	workload, err := svc.GetEmployeeWorkload(ctx, 1, true)
+	require.NoError(t, err)
+	assert.Equal(t, 7, workload.ActiveTasks)
+	assert.Equal(t, 2, *workload.ComputedActiveTasks)
+
+	report, err := svc.ReconcileWorkload(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 4, report.Checked)
+	require.Len(t, report.Corrected, 2)
+	assert.Equal(t, WorkloadCorrection{EmployeeID: 1, Stored: 7, Computed: 2}, *report.Corrected[0])
+	// 没有进行中任务的员工校正为0
+	assert.Equal(t, WorkloadCorrection{EmployeeID: 3, Stored: 1, Computed: 0}, *report.Corrected[1])
+	require.Len(t, report.Skipped, 1)
+	assert.Equal(t, uint(4), report.Skipped[0].EmployeeID)
+	assert.Empty(t, report.Failed)
+	assert.Equal(t, 2, repo.employees[0].CurrentTasks)
+	assert.Equal(t, 3, repo.employees[3].CurrentTasks)
+
+	workload, err = svc.GetEmployeeWorkload(ctx, 2, false)
+	require.NoError(t, err)
+	assert.Nil(t, workload.ComputedActiveTasks)
+}
+
+func TestWorkloadStats_UsesTaskStats(t *testing.T) {
+	dept10, dept20 := uint(10), uint(20)
+	repo := &driftingEmployeeRepository{
+		employees: []*database.Employee{
+			{BaseModel: database.BaseModel{ID: 1}, DepartmentID: &dept10, CurrentTasks: 5, MaxTasks: 5, Status: "busy"},
+			{BaseModel: database.BaseModel{ID: 2}, DepartmentID: &dept10, CurrentTasks: 4, MaxTasks: 5, Status: "available"},
+			{BaseModel: database.BaseModel{ID: 3}, DepartmentID: &dept20, CurrentTasks: 1, MaxTasks: 5, Status: "available"},
+		},
+		stats: map[uint]*repository.EmployeeTaskStats{
+			1: {EmployeeID: 1, PendingTasks: 2, CompletedTasks: 4, OnTimeTasks: 3, OverdueTasks: 1, AvgDurationHours: 6.5},
+		},
+	}
+	svc := &EmployeeServiceImpl{employeeRepo: repo, workload: config.WorkloadConfig{OverloadThreshold: 0.75}}
+	ctx := context.Background()
+
+	workload, err := svc.GetEmployeeWorkload(ctx, 1, false)
+	require.NoError(t, err)
+	assert.Equal(t, 2, workload.PendingTasks)
+	assert.Equal(t, 4, workload.CompletedTasks)
+	assert.Equal(t, 1, workload.OverdueTasks)
+	assert.Equal(t, 0.75, workload.EfficiencyRate)
+	assert.Equal(t, 6.5, workload.AvgTaskDuration)
+	// 默认统计最近90天
+	window := repo.filters[0]
+	assert.Equal(t, DefaultWorkloadStatsWindowDays*24*time.Hour, window.CompletedTo.Sub(window.CompletedFrom).Round(time.Hour))
+
+	stats, err := svc.GetWorkloadStats(ctx, &WorkloadStatsRequest{StartDate: "2026-01-01", EndDate: "2026-01-31"})
+	require.NoError(t, err)
+	require.Len(t, stats, 3)
+	assert.Equal(t, 0, stats[1].CompletedTasks)
+	window = repo.filters[1]
+	assert.Equal(t, "2026-01-01", window.CompletedFrom.Format("2006-01-02"))
+	assert.Equal(t, "2026-02-01", window.CompletedTo.Format("2006-01-02"))
+
+	_, err = svc.GetWorkloadStats(ctx, &WorkloadStatsRequest{StartDate: "2026-02-01", EndDate: "2026-01-31"})
+	assert.ErrorIs(t, err, ErrInvalidWorkloadRange)
+	_, err = svc.GetWorkloadStats(ctx, &WorkloadStatsRequest{StartDate: "01/02/2026"})
+	assert.ErrorIs(t, err, ErrInvalidWorkloadRange)
+
+	department, err := svc.GetDepartmentWorkload(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 2, department.TotalEmployees)
+	assert.Equal(t, 1, department.ActiveEmployees)
+	assert.Equal(t, 11, department.TotalTasks)
+	assert.Equal(t, 4, department.CompletedTasks)
+	// 工作负载率1.0和0.8都超过0.75
+	assert.Equal(t, 2, department.OverloadedCount)
+	assert.Equal(t, uint(10), repo.filters[len(repo.filters)-1].DepartmentID)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 	"taskmanage/internal/container"
 	"taskmanage/internal/service"
 	"taskmanage/internal/workflow"
@@ -12,7 +13,8 @@ import (
 
 // InitializeDefaultWorkflows 初始化默认工作流定义
 func InitializeDefaultWorkflows(appContainer *container.ApplicationContainer) error {
-	ctx := context.Background()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
 
 	// 获取服务管理器
 	serviceManager := appContainer.GetServiceManager()
@@ -36,6 +38,21 @@ func InitializeDefaultWorkflows(appContainer *container.ApplicationContainer) er
 		return fmt.Errorf("初始化任务完成审批工作流失败: %w", err)
 	}
 
+	// 初始化入职审批工作流（简化流程与完整流程），HR审批节点使用转正评估提醒的HR角色
+	hrRole := service.DefaultProbationReviewerRole
+	if cfg := appContainer.GetConfig(); cfg != nil && cfg.Onboarding.ProbationReviewerRole != "" {
+		hrRole = cfg.Onboarding.ProbationReviewerRole
+	}
+	for _, definition := range workflow.OnboardingWorkflowDefinitions(hrRole) {
+		createFunc := func(ctx context.Context, workflowService service.WorkflowService) error {
+			_, err := workflowService.CreateWorkflowDefinition(ctx, definition)
+			return err
+		}
+		if err := initializeWorkflow(ctx, workflowService, definition.ID, definition.Name, createFunc); err != nil {
+			return fmt.Errorf("初始化%s失败: %w", definition.Name, err)
+		}
+	}
+
 	logger.Info("所有默认工作流定义初始化完成")
 	return nil
 }
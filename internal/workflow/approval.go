@@ -0,0 +1,179 @@
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrApprovalAlreadyProcessed 节点已由其他审批人处理出结果，并发审批时后提交的一方返回该错误
+var ErrApprovalAlreadyProcessed = errors.New("该审批已被他人处理")
+
+// ApprovalDecision 审批人决策及节点审批结果
+type ApprovalDecision string
+
+const (
+	DecisionPending     ApprovalDecision = "pending"     // 待处理
+	DecisionApproved    ApprovalDecision = "approved"    // 同意
+	DecisionRejected    ApprovalDecision = "rejected"    // 拒绝
+	DecisionReturned    ApprovalDecision = "returned"    // 退回
+	DecisionResubmitted ApprovalDecision = "resubmitted" // 发起人重新提交
+)
+
+// NodeApprovalState 审批节点的逐人决策记录，用于会签（all）和多数（majority）审批的结果汇总
+type NodeApprovalState struct {
+	ApprovalType ApprovalType     `json:"approval_type"`
+	CanDelegate  bool             `json:"can_delegate"`
+	Outcome      ApprovalDecision `json:"outcome"` // 节点审批结果，未达成时为pending
+	Votes        []*ApproverVote  `json:"votes"`
+}
+
+// ApproverVote 单个审批人的决策，委托后由受托人代为表决，计入原审批人名下
+type ApproverVote struct {
+	AssigneeID  uint             `json:"assignee_id"`            // 原审批人
+	DelegatedTo uint             `json:"delegated_to,omitempty"` // 受托人
+	Decision    ApprovalDecision `json:"decision"`
+	DecidedBy   uint             `json:"decided_by,omitempty"` // 实际表决人
+	DecidedAt   *time.Time       `json:"decided_at,omitempty"`
+	Comment     string           `json:"comment,omitempty"`
+}
+
+// NewNodeApprovalState 创建审批节点决策记录，审批人去重，审批类型为空时按任意一人审批处理
+func NewNodeApprovalState(approvalType ApprovalType, canDelegate bool, assignees []uint) *NodeApprovalState {
+	if approvalType == "" {
+		approvalType = ApprovalTypeAny
+	}
+	state := &NodeApprovalState{
+		ApprovalType: approvalType,
+		CanDelegate:  canDelegate,
+		Outcome:      DecisionPending,
+	}
+	seen := make(map[uint]bool, len(assignees))
+	for _, assigneeID := range assignees {
+		if seen[assigneeID] {
+			continue
+		}
+		seen[assigneeID] = true
+		state.Votes = append(state.Votes, &ApproverVote{AssigneeID: assigneeID, Decision: DecisionPending})
+	}
+	return state
+}
+
+// voterFor 查找用户可以表决的席位：已委托的席位只能由受托人表决
+func (s *NodeApprovalState) voterFor(userID uint) *ApproverVote {
+	for _, vote := range s.Votes {
+		if vote.DelegatedTo == userID || (vote.DelegatedTo == 0 && vote.AssigneeID == userID) {
+			return vote
+		}
+	}
+	return nil
+}
+
+// decidedByOthers 用户是该节点尚未表决的审批人，但节点已由其他审批人处理出结果
+func (s *NodeApprovalState) decidedByOthers(userID uint) bool {
+	if s == nil || s.Outcome == DecisionPending {
+		return false
+	}
+	vote := s.voterFor(userID)
+	return vote != nil && vote.Decision == DecisionPending
+}
+
+// Record 记录用户的审批动作并返回节点审批结果，结果为pending时节点继续等待其他审批人
+func (s *NodeApprovalState) Record(userID uint, action ApprovalAction, delegateTo uint, comment string, at time.Time) (ApprovalDecision, error) {
+	vote := s.voterFor(userID)
+	if vote == nil {
+		return "", fmt.Errorf("用户%d不是该节点的审批人", userID)
+	}
+	if vote.Decision != DecisionPending {
+		return "", fmt.Errorf("用户%d已处理该审批", userID)
+	}
+
+	switch action {
+	case ActionApprove:
+		vote.decide(DecisionApproved, userID, comment, at)
+	case ActionReject:
+		vote.decide(DecisionRejected, userID, comment, at)
+	case ActionReturn:
+		// 退回直接结束本轮审批，节点重新进入时会重建决策记录
+		vote.decide(DecisionReturned, userID, comment, at)
+		s.Outcome = DecisionReturned
+		return s.Outcome, nil
+	case ActionDelegate:
+		if !s.CanDelegate {
+			return "", fmt.Errorf("该审批节点不允许委托")
+		}
+		if delegateTo == 0 || delegateTo == userID {
+			return "", fmt.Errorf("无效的委托对象: %d", delegateTo)
+		}
+		if s.voterFor(delegateTo) != nil {
+			return "", fmt.Errorf("委托对象%d已是该节点的审批人", delegateTo)
+		}
+		vote.DelegatedTo = delegateTo
+		return DecisionPending, nil
+	default:
+		return "", fmt.Errorf("不支持的审批动作: %s", action)
+	}
+
+	s.Outcome = s.resolve()
+	return s.Outcome, nil
+}
+
+// resolve 按审批类型汇总决策
+//   - all: 全部同意才通过，任意一人拒绝即拒绝
+//   - majority: 同意人数超过半数通过；剩余票数已不可能过半时拒绝，偶数人数平票视为拒绝
+//   - 其它（any等）: 第一个决策即为结果
+func (s *NodeApprovalState) resolve() ApprovalDecision {
+	total := len(s.Votes)
+	approved, rejected := s.count()
+
+	switch s.ApprovalType {
+	case ApprovalTypeAll:
+		if rejected > 0 {
+			return DecisionRejected
+		}
+		if approved == total {
+			return DecisionApproved
+		}
+	case ApprovalTypeMajority:
+		if approved*2 > total {
+			return DecisionApproved
+		}
+		if (total-rejected)*2 <= total {
+			return DecisionRejected
+		}
+	default:
+		if approved > 0 {
+			return DecisionApproved
+		}
+		if rejected > 0 {
+			return DecisionRejected
+		}
+	}
+	return DecisionPending
+}
+
+// count 统计同意和拒绝人数
+func (s *NodeApprovalState) count() (approved, rejected int) {
+	for _, vote := range s.Votes {
+		switch vote.Decision {
+		case DecisionApproved:
+			approved++
+		case DecisionRejected:
+			rejected++
+		}
+	}
+	return approved, rejected
+}
+
+// Summary 决策进度描述
+func (s *NodeApprovalState) Summary() string {
+	approved, rejected := s.count()
+	return fmt.Sprintf("同意%d，拒绝%d，共%d人", approved, rejected, len(s.Votes))
+}
+
+func (v *ApproverVote) decide(decision ApprovalDecision, userID uint, comment string, at time.Time) {
+	v.Decision = decision
+	v.DecidedBy = userID
+	v.DecidedAt = &at
+	v.Comment = comment
+}
@@ -0,0 +1,82 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+
+	"taskmanage/pkg/logger"
+)
+
+// AssigneeSourceDepartmentChain 审批节点配置 assignee_source 取该值时，审批人取自部门审批链
+const AssigneeSourceDepartmentChain = "department_chain"
+
+// ApprovalChainProvider 部门审批链查询，由服务层按部门和业务类型读取配置
+type ApprovalChainProvider interface {
+	// GetApprovalChain 返回部门在业务类型下按顺序配置的审批人，未配置时返回空
+	GetApprovalChain(ctx context.Context, departmentID uint, businessType string) ([]ApprovalAssignee, error)
+}
+
+// SetApprovalChainProvider 设置部门审批链来源
+func (e *WorkflowEngineImpl) SetApprovalChainProvider(provider ApprovalChainProvider) {
+	e.taskExecutorRegistry.SetApprovalChainProvider(provider)
+	e.onboardingExecutorRegistry.SetApprovalChainProvider(provider)
+}
+
+// SetApprovalChainProvider 为注册表中的审批节点执行器设置部门审批链来源
+func (r *ExecutorRegistry) SetApprovalChainProvider(provider ApprovalChainProvider) {
+	switch executor := r.executors[NodeTypeApproval].(type) {
+	case *ApprovalNodeExecutor:
+		executor.chainProvider = provider
+	case *OnboardingApprovalNodeExecutor:
+		executor.chainProvider = provider
+	}
+}
+
+// nodeAssignees 返回节点实际使用的审批人配置
+// 节点指定部门审批链且部门已配置时使用审批链，否则回退到节点内联的审批人
+func (e *ApprovalNodeExecutor) nodeAssignees(ctx context.Context, instance *WorkflowInstance, node *WorkflowNode, config *ApprovalNodeConfig) ([]ApprovalAssignee, error) {
+	if source, _ := node.Config["assignee_source"].(string); source != AssigneeSourceDepartmentChain {
+		return config.Assignees, nil
+	}
+	if e.chainProvider == nil {
+		logger.FromContext(ctx).Warnf("未配置部门审批链来源，使用节点审批人: 节点=%s", node.ID)
+		return config.Assignees, nil
+	}
+
+	departmentID, err := e.instanceDepartment(ctx, instance)
+	if err != nil {
+		logger.FromContext(ctx).Warnf("无法确定流程所属部门，使用节点审批人: 实例=%s, error: %v", instance.ID, err)
+		return config.Assignees, nil
+	}
+
+	chain, err := e.chainProvider.GetApprovalChain(ctx, departmentID, instance.BusinessType)
+	if err != nil {
+		return nil, fmt.Errorf("获取部门审批链失败: %w", err)
+	}
+	if len(chain) == 0 {
+		logger.FromContext(ctx).Infof("部门 %d 未配置 %s 审批链，使用节点审批人", departmentID, instance.BusinessType)
+		return config.Assignees, nil
+	}
+
+	logger.FromContext(ctx).Infof("使用部门 %d 的 %s 审批链，审批人配置数量: %d", departmentID, instance.BusinessType, len(chain))
+	return chain, nil
+}
+
+// instanceDepartment 确定流程所属部门：优先取流程变量 department_id，否则取发起人所在部门
+func (e *ApprovalNodeExecutor) instanceDepartment(ctx context.Context, instance *WorkflowInstance) (uint, error) {
+	if departmentID, ok := instance.GetUintVar("department_id"); ok && departmentID > 0 {
+		return departmentID, nil
+	}
+
+	if e.employeeRepo == nil {
+		return 0, fmt.Errorf("员工仓储未配置")
+	}
+	employee, err := e.employeeRepo.GetByUserID(ctx, instance.StartedBy)
+	if err != nil {
+		return 0, fmt.Errorf("查找发起人员工信息失败: %w", err)
+	}
+	if employee.DepartmentID == nil {
+		return 0, fmt.Errorf("发起人未分配部门")
+	}
+	return *employee.DepartmentID, nil
+}
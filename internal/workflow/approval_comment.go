@@ -0,0 +1,116 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// MaxApprovalMentions 一条审批意见最多提及的用户数
+const MaxApprovalMentions = 10
+
+// ErrInvalidApprovalComment 审批意见不合法：节点要求拒绝时填写意见、提及用户过多或附件不可用
+var ErrInvalidApprovalComment = errors.New("审批意见不合法")
+
+// ApprovalAttachment 审批意见附带的附件，记录审批时的附件信息
+type ApprovalAttachment struct {
+	ID         uint   `json:"id"`
+	Filename   string `json:"filename"`
+	FileSize   int64  `json:"file_size"`
+	MimeType   string `json:"mime_type,omitempty"`
+	TaskID     uint   `json:"task_id"`
+	UploadedBy uint   `json:"uploaded_by"`
+}
+
+// ApprovalMention 审批意见提及用户事件
+type ApprovalMention struct {
+	NodeID      string         `json:"node_id"`
+	NodeName    string         `json:"node_name"`
+	Action      ApprovalAction `json:"action"`
+	MentionedBy uint           `json:"mentioned_by"`
+	UserIDs     []uint         `json:"user_ids"`
+	Comment     string         `json:"comment"`
+}
+
+// ApprovalAttachmentResolver 校验并解析审批意见附带的附件
+// 附件须为审批人上传或属于流程关联的任务，任一附件不存在或不可用时返回包装ErrInvalidApprovalComment的错误
+type ApprovalAttachmentResolver interface {
+	ResolveApprovalAttachments(ctx context.Context, instance *WorkflowInstance, userID uint, attachmentIDs []uint) ([]ApprovalAttachment, error)
+}
+
+// ApprovalMentionListener 审批结果保存后接收提及用户事件，用于通知被提及的用户
+type ApprovalMentionListener interface {
+	OnApprovalMentioned(ctx context.Context, instance *WorkflowInstance, event *ApprovalMention)
+}
+
+// SetApprovalAttachmentResolver 设置审批附件解析方，为nil时审批意见不能附带附件
+func (e *WorkflowEngineImpl) SetApprovalAttachmentResolver(resolver ApprovalAttachmentResolver) {
+	e.attachmentResolver = resolver
+}
+
+// SetApprovalMentionListener 设置提及用户通知接收方，为nil时不通知
+func (e *WorkflowEngineImpl) SetApprovalMentionListener(listener ApprovalMentionListener) {
+	e.mentionListener = listener
+}
+
+// prepareApprovalComment 校验审批意见并写入执行历史：节点配置require_comment_on_reject时拒绝必须填写意见，
+// 提及用户去重后最多MaxApprovalMentions个，附件经ApprovalAttachmentResolver校验
+func (e *WorkflowEngineImpl) prepareApprovalComment(ctx context.Context, instance *WorkflowInstance, node *WorkflowNode, req *ApprovalRequest, history *ExecutionHistory) error {
+	if req.Action == ActionReject && strings.TrimSpace(req.Comment) == "" && node.Type == NodeTypeApproval {
+		if config, err := (&ApprovalNodeExecutor{}).parseApprovalConfig(node); err == nil && config.RequireCommentOnReject {
+			return fmt.Errorf("%w: 节点%s拒绝时必须填写审批意见", ErrInvalidApprovalComment, node.Name)
+		}
+	}
+
+	mentions := uniqueIDs(req.Mentions)
+	if len(mentions) > MaxApprovalMentions {
+		return fmt.Errorf("%w: 最多提及%d个用户", ErrInvalidApprovalComment, MaxApprovalMentions)
+	}
+	history.Mentions = mentions
+
+	attachmentIDs := uniqueIDs(req.AttachmentIDs)
+	if len(attachmentIDs) == 0 {
+		return nil
+	}
+	if e.attachmentResolver == nil {
+		return fmt.Errorf("%w: 审批意见不支持附件", ErrInvalidApprovalComment)
+	}
+	attachments, err := e.attachmentResolver.ResolveApprovalAttachments(ctx, instance, req.ApprovedBy, attachmentIDs)
+	if err != nil {
+		return err
+	}
+	history.Attachments = attachments
+	return nil
+}
+
+// notifyApprovalMentioned 通知审批意见提及的用户
+func (e *WorkflowEngineImpl) notifyApprovalMentioned(ctx context.Context, instance *WorkflowInstance, node *WorkflowNode, history *ExecutionHistory) {
+	if e.mentionListener == nil || len(history.Mentions) == 0 {
+		return
+	}
+	e.mentionListener.OnApprovalMentioned(ctx, instance, &ApprovalMention{
+		NodeID:      node.ID,
+		NodeName:    node.Name,
+		Action:      ApprovalAction(history.Action),
+		MentionedBy: history.ExecutedBy,
+		UserIDs:     history.Mentions,
+		Comment:     history.Comment,
+	})
+}
+
+// uniqueIDs 去除重复和为0的ID，保持原有顺序
+func uniqueIDs(ids []uint) []uint {
+	if len(ids) == 0 {
+		return nil
+	}
+	seen := make(map[uint]bool, len(ids))
+	unique := make([]uint, 0, len(ids))
+	for _, id := range ids {
+		if id != 0 && !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,111 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fixedAttachmentResolver 只认可固定附件的附件解析器
+type fixedAttachmentResolver struct {
+	attachments map[uint]ApprovalAttachment
+}
+
+func (r *fixedAttachmentResolver) ResolveApprovalAttachments(ctx context.Context, instance *WorkflowInstance, userID uint, attachmentIDs []uint) ([]ApprovalAttachment, error) {
+	var attachments []ApprovalAttachment
+	for _, id := range attachmentIDs {
+		attachment, ok := r.attachments[id]
+		if !ok || attachment.UploadedBy != userID {
+			return nil, fmt.Errorf("%w: 附件%d不可用", ErrInvalidApprovalComment, id)
+		}
+		attachments = append(attachments, attachment)
+	}
+	return attachments, nil
+}
+
+// recordingMentionListener 记录提及通知
+type recordingMentionListener struct {
+	events []*ApprovalMention
+}
+
+func (l *recordingMentionListener) OnApprovalMentioned(ctx context.Context, instance *WorkflowInstance, event *ApprovalMention) {
+	l.events = append(l.events, event)
+}
+
+func TestProcessApproval_CommentAttachmentsAndMentions(t *testing.T) {
+	definition := &WorkflowDefinition{
+		ID:        "compliance",
+		Name:      "合规审批",
+		VersionID: 1,
+		Nodes: []WorkflowNode{
+			{ID: "start", Type: NodeTypeStart, Name: "开始"},
+			{ID: "legal", Type: NodeTypeApproval, Name: "法务审批", Config: map[string]interface{}{
+				"assignees":                 []map[string]interface{}{{"type": "user", "value": "2"}},
+				"require_comment_on_reject": true,
+			}},
+			{ID: "end", Type: NodeTypeEnd, Name: "结束"},
+		},
+		Edges: []WorkflowEdge{
+			{ID: "e1", From: "start", To: "legal"},
+			{ID: "e2", From: "legal", To: "end", Condition: "rejected"},
+		},
+	}
+	instance := &WorkflowInstance{
+		ID:                  "inst",
+		WorkflowID:          "compliance",
+		DefinitionVersionID: 1,
+		Status:              StatusRunning,
+		CurrentNodes:        []string{"legal"},
+		Variables:           map[string]interface{}{},
+		StartedBy:           9,
+		Approvals: map[string]*NodeApprovalState{
+			"legal": NewNodeApprovalState(ApprovalTypeAny, false, []uint{2}),
+		},
+	}
+	repo := &approvalInstanceRepository{
+		memoryInstanceRepository: &memoryInstanceRepository{history: map[string][]ExecutionHistory{}, approvals: map[string][]*PendingApproval{}},
+		instance:                 instance,
+	}
+	listener := &recordingMentionListener{}
+	engine := &WorkflowEngineImpl{
+		definitionManager:    NewWorkflowDefinitionManager(&versionWorkflowRepository{definition: definition}),
+		instanceRepo:         repo,
+		taskExecutorRegistry: NewExecutorRegistry(repo, nil, nil),
+	}
+	engine.SetApprovalMentionListener(listener)
+	ctx := context.Background()
+	reject := func(comment string, attachmentIDs, mentions []uint) error {
+		_, err := engine.ProcessApproval(ctx, &ApprovalRequest{
+			InstanceID: "inst", NodeID: "legal", Action: ActionReject, ApprovedBy: 2,
+			Comment: comment, AttachmentIDs: attachmentIDs, Mentions: mentions,
+		})
+		return err
+	}
+
+	// 节点要求拒绝时填写意见
+	assert.ErrorIs(t, reject("  ", nil, nil), ErrInvalidApprovalComment)
+	// 提及去重后超过10人
+	assert.ErrorIs(t, reject("合同未签字", nil, []uint{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 11}), ErrInvalidApprovalComment)
+	// 未配置附件解析器时不能附带附件，配置后附件须可用
+	assert.ErrorIs(t, reject("合同未签字", []uint{5}, nil), ErrInvalidApprovalComment)
+	engine.SetApprovalAttachmentResolver(&fixedAttachmentResolver{attachments: map[uint]ApprovalAttachment{
+		5: {ID: 5, Filename: "contract.pdf", UploadedBy: 2},
+		6: {ID: 6, Filename: "other.pdf", UploadedBy: 3},
+	}})
+	assert.ErrorIs(t, reject("合同未签字", []uint{5, 6}, nil), ErrInvalidApprovalComment)
+	assert.Empty(t, repo.saved)
+	assert.Empty(t, listener.events)
+
+	require.NoError(t, reject("合同未签字", []uint{5, 5}, []uint{7, 0, 7, 8}))
+	require.NotEmpty(t, repo.saved)
+	assert.Equal(t, "legal", repo.saved[0].NodeID)
+	assert.Equal(t, []ApprovalAttachment{{ID: 5, Filename: "contract.pdf", UploadedBy: 2}}, repo.saved[0].Attachments)
+	assert.Equal(t, []uint{7, 8}, repo.saved[0].Mentions)
+	require.Len(t, listener.events, 1)
+	assert.Equal(t, []uint{7, 8}, listener.events[0].UserIDs)
+	assert.Equal(t, "法务审批", listener.events[0].NodeName)
+	assert.Equal(t, ActionReject, listener.events[0].Action)
+}
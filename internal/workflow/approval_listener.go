@@ -0,0 +1,116 @@
+package workflow
+
+import "context"
+
+// ApprovalRequestListener 待审批记录落库后接收通知，用于提醒审批人处理
+type ApprovalRequestListener interface {
+	OnApprovalsRequested(ctx context.Context, instance *WorkflowInstance, approvals []*PendingApproval)
+}
+
+// ApprovalReturnListener 审批被退回后接收通知，用于告知发起人需要补充或修改的内容
+type ApprovalReturnListener interface {
+	OnApprovalReturned(ctx context.Context, instance *WorkflowInstance, event *ApprovalReturn)
+}
+
+// ApprovalOutcomeListener 审批节点通过或拒绝后接收通知，用于告知发起人和相关人审批结果
+type ApprovalOutcomeListener interface {
+	OnApprovalResolved(ctx context.Context, instance *WorkflowInstance, event *ApprovalOutcome)
+}
+
+// ApprovalRequestListeners 依次通知多个待审批提醒接收方
+type ApprovalRequestListeners []ApprovalRequestListener
+
+// OnApprovalsRequested 依次通知各接收方
+func (l ApprovalRequestListeners) OnApprovalsRequested(ctx context.Context, instance *WorkflowInstance, approvals []*PendingApproval) {
+	for _, listener := range l {
+		listener.OnApprovalsRequested(ctx, instance, approvals)
+	}
+}
+
+// ApprovalOutcomeListeners 依次通知多个审批结果接收方
+type ApprovalOutcomeListeners []ApprovalOutcomeListener
+
+// OnApprovalResolved 依次通知各接收方
+func (l ApprovalOutcomeListeners) OnApprovalResolved(ctx context.Context, instance *WorkflowInstance, event *ApprovalOutcome) {
+	for _, listener := range l {
+		listener.OnApprovalResolved(ctx, instance, event)
+	}
+}
+
+// 审批节点结果
+const (
+	ApprovalOutcomeApproved = "approved"
+	ApprovalOutcomeRejected = "rejected"
+)
+
+// ApprovalOutcome 审批节点出结果事件
+type ApprovalOutcome struct {
+	NodeID    string `json:"node_id"`
+	NodeName  string `json:"node_name"`
+	Outcome   string `json:"outcome"`    // approved 或 rejected
+	DecidedBy uint   `json:"decided_by"` // 做出最终决策的审批人
+	Comment   string `json:"comment"`
+	Completed bool   `json:"completed"` // 流程是否随本次审批结束
+}
+
+// SetApprovalRequestListener 设置待审批提醒接收方，为nil时不提醒
+func (e *WorkflowEngineImpl) SetApprovalRequestListener(listener ApprovalRequestListener) {
+	e.approvalListener = listener
+}
+
+// SetApprovalReturnListener 设置审批退回通知接收方，为nil时不通知
+func (e *WorkflowEngineImpl) SetApprovalReturnListener(listener ApprovalReturnListener) {
+	e.returnListener = listener
+}
+
+// SetApprovalOutcomeListener 设置审批结果通知接收方，为nil时不通知
+func (e *WorkflowEngineImpl) SetApprovalOutcomeListener(listener ApprovalOutcomeListener) {
+	e.outcomeListener = listener
+}
+
+// notifyApprovalsRequested 通知需要处理的审批人，只读的查看记录不通知
+func (e *WorkflowEngineImpl) notifyApprovalsRequested(ctx context.Context, instance *WorkflowInstance, approvals []*PendingApproval) {
+	if e.approvalListener == nil {
+		return
+	}
+	actionable := make([]*PendingApproval, 0, len(approvals))
+	for _, approval := range approvals {
+		if len(approval.RequiredAction) > 0 {
+			actionable = append(actionable, approval)
+		}
+	}
+	if len(actionable) > 0 {
+		e.approvalListener.OnApprovalsRequested(ctx, instance, actionable)
+	}
+}
+
+// notifyApprovalReturned 通知发起人审批被退回
+func (e *WorkflowEngineImpl) notifyApprovalReturned(ctx context.Context, instance *WorkflowInstance, event *ApprovalReturn) {
+	if e.returnListener == nil || instance.StartedBy == 0 {
+		return
+	}
+	e.returnListener.OnApprovalReturned(ctx, instance, event)
+}
+
+// notifyApprovalResolved 通知审批节点的结果，节点尚未出结果、退回和重新提交不通知
+func (e *WorkflowEngineImpl) notifyApprovalResolved(ctx context.Context, instance *WorkflowInstance, node *WorkflowNode, outcome ApprovalDecision, history *ExecutionHistory) {
+	if e.outcomeListener == nil {
+		return
+	}
+	event := &ApprovalOutcome{
+		NodeID:    node.ID,
+		NodeName:  node.Name,
+		DecidedBy: history.ExecutedBy,
+		Comment:   history.Comment,
+		Completed: instance.Status != StatusRunning,
+	}
+	switch outcome {
+	case DecisionApproved:
+		event.Outcome = ApprovalOutcomeApproved
+	case DecisionRejected:
+		event.Outcome = ApprovalOutcomeRejected
+	default:
+		return
+	}
+	e.outcomeListener.OnApprovalResolved(ctx, instance, event)
+}
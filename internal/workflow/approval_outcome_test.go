@@ -0,0 +1,97 @@
+package workflow
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingOutcomeListener 记录审批结果通知
+type recordingOutcomeListener struct {
+	events []*ApprovalOutcome
+}
+
+func (l *recordingOutcomeListener) OnApprovalResolved(ctx context.Context, instance *WorkflowInstance, event *ApprovalOutcome) {
+	l.events = append(l.events, event)
+}
+
+func newOutcomeEngine() (*WorkflowEngineImpl, *recordingOutcomeListener) {
+	definition := &WorkflowDefinition{
+		ID:        "assign",
+		VersionID: 1,
+		Nodes: []WorkflowNode{
+			{ID: "start", Type: NodeTypeStart, Name: "开始"},
+			{ID: "lead", Type: NodeTypeApproval, Name: "组长审批", Config: map[string]interface{}{
+				"assignees": []map[string]interface{}{{"type": "user", "value": "1"}},
+			}},
+			{ID: "manager", Type: NodeTypeApproval, Name: "经理审批", Config: map[string]interface{}{
+				"assignees": []map[string]interface{}{{"type": "user", "value": "2"}},
+			}},
+			{ID: "end", Type: NodeTypeEnd, Name: "结束"},
+		},
+		Edges: []WorkflowEdge{
+			{ID: "e1", From: "start", To: "lead"},
+			{ID: "e2", From: "lead", To: "manager", Condition: "approved"},
+			{ID: "e3", From: "manager", To: "end", Condition: "approved"},
+		},
+	}
+	instance := &WorkflowInstance{
+		ID:                  "inst",
+		WorkflowID:          "assign",
+		DefinitionVersionID: 1,
+		BusinessType:        "task_assignment",
+		Status:              StatusRunning,
+		CurrentNodes:        []string{"lead"},
+		Variables:           map[string]interface{}{},
+		StartedBy:           9,
+		Approvals: map[string]*NodeApprovalState{
+			"lead": NewNodeApprovalState(ApprovalTypeAny, false, []uint{1}),
+		},
+	}
+	repo := &approvalInstanceRepository{
+		memoryInstanceRepository: &memoryInstanceRepository{history: map[string][]ExecutionHistory{}, approvals: map[string][]*PendingApproval{}},
+		instance:                 instance,
+	}
+	listener := &recordingOutcomeListener{}
+	engine := &WorkflowEngineImpl{
+		definitionManager:    NewWorkflowDefinitionManager(&versionWorkflowRepository{definition: definition}),
+		instanceRepo:         repo,
+		taskExecutorRegistry: NewExecutorRegistry(repo, nil, nil),
+	}
+	engine.SetApprovalOutcomeListener(listener)
+	return engine, listener
+}
+
+func TestProcessApproval_OutcomeEvents(t *testing.T) {
+	engine, listener := newOutcomeEngine()
+	ctx := context.Background()
+
+	// 中间节点通过时流程未结束
+	_, err := engine.ProcessApproval(ctx, &ApprovalRequest{InstanceID: "inst", NodeID: "lead", Action: ActionApprove, ApprovedBy: 1, Comment: "同意"})
+	require.NoError(t, err)
+	require.Len(t, listener.events, 1)
+	assert.Equal(t, ApprovalOutcome{NodeID: "lead", NodeName: "组长审批", Outcome: ApprovalOutcomeApproved, DecidedBy: 1, Comment: "同意"}, *listener.events[0])
+
+	// 最后一个节点通过后流程结束
+	result, err := engine.ProcessApproval(ctx, &ApprovalRequest{InstanceID: "inst", NodeID: "manager", Action: ActionApprove, ApprovedBy: 2})
+	require.NoError(t, err)
+	require.True(t, result.IsCompleted)
+	require.Len(t, listener.events, 2)
+	assert.Equal(t, "manager", listener.events[1].NodeID)
+	assert.True(t, listener.events[1].Completed)
+}
+
+func TestProcessApproval_RejectedOutcomeEvent(t *testing.T) {
+	engine, listener := newOutcomeEngine()
+
+	result, err := engine.ProcessApproval(context.Background(), &ApprovalRequest{InstanceID: "inst", NodeID: "lead", Action: ActionReject, ApprovedBy: 1, Comment: "人手不足"})
+	require.NoError(t, err)
+	// 没有拒绝分支，流程随拒绝结束
+	assert.True(t, result.IsCompleted)
+	require.Len(t, listener.events, 1)
+	assert.Equal(t, ApprovalOutcomeRejected, listener.events[0].Outcome)
+	assert.Equal(t, "人手不足", listener.events[0].Comment)
+	assert.True(t, listener.events[0].Completed)
+}
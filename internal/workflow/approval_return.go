@@ -0,0 +1,120 @@
+package workflow
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrInvalidReturn 退回请求不合法：节点不允许退回、目标不在允许范围内或缺少退回说明
+	ErrInvalidReturn = errors.New("退回请求不合法")
+	// ErrInvalidResubmission 重新提交请求不合法：实例未退回到开始节点或操作人不是发起人
+	ErrInvalidResubmission = errors.New("重新提交请求不合法")
+)
+
+// ApprovalReturn 审批退回事件
+type ApprovalReturn struct {
+	NodeID         string `json:"node_id"`
+	NodeName       string `json:"node_name"`
+	TargetNodeID   string `json:"target_node_id"`
+	TargetNodeName string `json:"target_node_name"`
+	ReturnedBy     uint   `json:"returned_by"`
+	Comment        string `json:"comment"` // 需要补充或修改的内容
+}
+
+// resolveReturnTarget 校验退回请求并返回目标节点
+// 节点需配置can_return，目标限定为return_targets；未配置return_targets时可退回到任一上游审批节点或开始节点。
+// 未指定目标时退回到第一个允许的目标，默认即最近的上游审批节点，没有时为开始节点
+func (e *WorkflowEngineImpl) resolveReturnTarget(instance *WorkflowInstance, definition *WorkflowDefinition, node *WorkflowNode, req *ApprovalRequest) (*WorkflowNode, error) {
+	if req.Comment == "" {
+		return nil, fmt.Errorf("%w: 退回时必须说明需要补充或修改的内容", ErrInvalidReturn)
+	}
+	if node.Type != NodeTypeApproval {
+		return nil, fmt.Errorf("%w: 只有审批节点可以退回", ErrInvalidReturn)
+	}
+	config, err := (&ApprovalNodeExecutor{}).parseApprovalConfig(node)
+	if err != nil || !config.CanReturn {
+		return nil, fmt.Errorf("%w: 节点%s不允许退回", ErrInvalidReturn, node.Name)
+	}
+
+	upstream := e.upstreamReturnTargets(definition, node.ID)
+	allowed := config.ReturnTargets
+	if len(allowed) == 0 {
+		allowed = upstream
+	}
+	targetID := req.ReturnTo
+	if targetID == "" {
+		if len(allowed) == 0 {
+			return nil, fmt.Errorf("%w: 节点%s没有可退回的目标", ErrInvalidReturn, node.Name)
+		}
+		targetID = allowed[0]
+	}
+	if !e.containsNode(allowed, targetID) || !e.containsNode(upstream, targetID) {
+		return nil, fmt.Errorf("%w: 节点%s不能退回到%s", ErrInvalidReturn, node.Name, targetID)
+	}
+
+	target := e.findNodeByID(definition, targetID)
+	if target.Type == NodeTypeStart && instance.StartedBy == 0 {
+		return nil, fmt.Errorf("%w: 流程没有发起人，不能退回到开始节点", ErrInvalidReturn)
+	}
+	return target, nil
+}
+
+// upstreamReturnTargets 沿连线反向查找可退回的上游节点：审批节点按距离由近到远排列，开始节点排在最后
+func (e *WorkflowEngineImpl) upstreamReturnTargets(definition *WorkflowDefinition, nodeID string) []string {
+	var approvals []string
+	var start string
+	visited := map[string]bool{nodeID: true}
+	queue := []string{nodeID}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, previous := range e.getPreviousNodes(definition, current) {
+			if visited[previous] {
+				continue
+			}
+			visited[previous] = true
+			queue = append(queue, previous)
+			if node := e.findNodeByID(definition, previous); node != nil {
+				switch node.Type {
+				case NodeTypeApproval:
+					approvals = append(approvals, previous)
+				case NodeTypeStart:
+					start = previous
+				}
+			}
+		}
+	}
+	if start != "" {
+		approvals = append(approvals, start)
+	}
+	return approvals
+}
+
+// checkResubmission 校验重新提交：只有发起人可以在被退回的开始节点重新提交
+func (e *WorkflowEngineImpl) checkResubmission(instance *WorkflowInstance, node *WorkflowNode, userID uint) error {
+	if node.Type != NodeTypeStart {
+		return fmt.Errorf("%w: 流程未退回到开始节点", ErrInvalidResubmission)
+	}
+	if instance.StartedBy == 0 || instance.StartedBy != userID {
+		return fmt.Errorf("%w: 只有发起人可以重新提交", ErrInvalidResubmission)
+	}
+	return nil
+}
+
+// newResubmission 退回到开始节点时为发起人创建重新提交的待办
+func (e *WorkflowEngineImpl) newResubmission(instance *WorkflowInstance, definition *WorkflowDefinition, startNode *WorkflowNode) *PendingApproval {
+	return &PendingApproval{
+		InstanceID:     instance.ID,
+		WorkflowName:   definition.Name,
+		NodeID:         startNode.ID,
+		NodeName:       startNode.Name,
+		BusinessID:     instance.BusinessID,
+		BusinessType:   instance.BusinessType,
+		BusinessData:   instance.Variables,
+		AssignedTo:     instance.StartedBy,
+		CreatedAt:      time.Now(),
+		RequiredAction: []ApprovalAction{ActionResubmit},
+	}
+}
@@ -0,0 +1,122 @@
+package workflow
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingReturnListener 记录退回通知
+type recordingReturnListener struct {
+	events []*ApprovalReturn
+}
+
+func (l *recordingReturnListener) OnApprovalReturned(ctx context.Context, instance *WorkflowInstance, event *ApprovalReturn) {
+	l.events = append(l.events, event)
+}
+
+func TestProcessApproval_ReturnAndResubmit(t *testing.T) {
+	definition := &WorkflowDefinition{
+		ID:        "hr",
+		Name:      "入职材料审批",
+		VersionID: 1,
+		Nodes: []WorkflowNode{
+			{ID: "start", Type: NodeTypeStart, Name: "开始"},
+			{ID: "manager", Type: NodeTypeApproval, Name: "主管审批", Config: map[string]interface{}{
+				"assignees":  []map[string]interface{}{{"type": "user", "value": "1"}},
+				"can_return": true,
+			}},
+			{ID: "hr", Type: NodeTypeApproval, Name: "HR审批", Config: map[string]interface{}{
+				"assignees":      []map[string]interface{}{{"type": "user", "value": "2"}},
+				"can_return":     true,
+				"return_targets": []string{"manager"},
+			}},
+			{ID: "finance", Type: NodeTypeApproval, Name: "财务审批", Config: map[string]interface{}{
+				"assignees": []map[string]interface{}{{"type": "user", "value": "3"}},
+			}},
+			{ID: "end", Type: NodeTypeEnd, Name: "结束"},
+		},
+		Edges: []WorkflowEdge{
+			{ID: "e1", From: "start", To: "manager"},
+			{ID: "e2", From: "manager", To: "hr"},
+			{ID: "e3", From: "hr", To: "finance"},
+			{ID: "e4", From: "finance", To: "end"},
+		},
+	}
+	instance := &WorkflowInstance{
+		ID:                  "inst",
+		WorkflowID:          "hr",
+		DefinitionVersionID: 1,
+		Status:              StatusRunning,
+		CurrentNodes:        []string{"hr"},
+		Variables:           map[string]interface{}{},
+		StartedBy:           9,
+		Approvals: map[string]*NodeApprovalState{
+			"hr": NewNodeApprovalState(ApprovalTypeAny, false, []uint{2}),
+		},
+	}
+	repo := &approvalInstanceRepository{
+		memoryInstanceRepository: &memoryInstanceRepository{history: map[string][]ExecutionHistory{}, approvals: map[string][]*PendingApproval{}},
+		instance:                 instance,
+	}
+	listener := &recordingReturnListener{}
+	engine := &WorkflowEngineImpl{
+		definitionManager:    NewWorkflowDefinitionManager(&versionWorkflowRepository{definition: definition}),
+		instanceRepo:         repo,
+		taskExecutorRegistry: NewExecutorRegistry(repo, nil, nil),
+	}
+	engine.SetApprovalReturnListener(listener)
+	ctx := context.Background()
+
+	// 缺少退回说明、目标不在return_targets中均被拒绝，实例不变
+	_, err := engine.ProcessApproval(ctx, &ApprovalRequest{InstanceID: "inst", NodeID: "hr", Action: ActionReturn, ApprovedBy: 2})
+	assert.ErrorIs(t, err, ErrInvalidReturn)
+	_, err = engine.ProcessApproval(ctx, &ApprovalRequest{InstanceID: "inst", NodeID: "hr", Action: ActionReturn, ApprovedBy: 2, ReturnTo: "start", Comment: "缺少学历证明"})
+	assert.ErrorIs(t, err, ErrInvalidReturn)
+	assert.Empty(t, repo.changes)
+
+	// 退回到主管审批：关闭实例全部待审批记录，重新为主管生成待审批
+	result, err := engine.ProcessApproval(ctx, &ApprovalRequest{InstanceID: "inst", NodeID: "hr", Action: ActionReturn, ApprovedBy: 2, Comment: "请主管确认职级"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"manager"}, result.NextNodes)
+	assert.Equal(t, []string{"manager"}, instance.CurrentNodes)
+	assert.True(t, repo.changes[0].CompleteInstance)
+	assert.Equal(t, "return", repo.saved[0].Action)
+	assert.Equal(t, "请主管确认职级", repo.saved[0].Comment)
+	require.NotEmpty(t, repo.approvals["inst/manager"])
+	assert.Equal(t, uint(1), repo.approvals["inst/manager"][0].AssignedTo)
+	assert.Contains(t, repo.approvals["inst/manager"][0].RequiredAction, ActionReturn)
+	require.Len(t, listener.events, 1)
+	assert.Equal(t, "主管审批", listener.events[0].TargetNodeName)
+
+	// 主管未配置return_targets，默认退回到开始节点，由发起人重新提交
+	_, err = engine.ProcessApproval(ctx, &ApprovalRequest{InstanceID: "inst", NodeID: "manager", Action: ActionReturn, ApprovedBy: 1, Comment: "补充身份证复印件"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"start"}, instance.CurrentNodes)
+	require.Len(t, repo.changes[1].Create, 1)
+	resubmission := repo.changes[1].Create[0]
+	assert.Equal(t, uint(9), resubmission.AssignedTo)
+	assert.Equal(t, []ApprovalAction{ActionResubmit}, resubmission.RequiredAction)
+	require.Len(t, listener.events, 2)
+	assert.Equal(t, "补充身份证复印件", listener.events[1].Comment)
+
+	// 只有发起人可以重新提交，提交后从开始节点重新流转到主管审批
+	_, err = engine.ProcessApproval(ctx, &ApprovalRequest{InstanceID: "inst", NodeID: "start", Action: ActionResubmit, ApprovedBy: 1})
+	assert.ErrorIs(t, err, ErrInvalidResubmission)
+	result, err = engine.ProcessApproval(ctx, &ApprovalRequest{
+		InstanceID: "inst", NodeID: "start", Action: ActionResubmit, ApprovedBy: 9,
+		Variables: map[string]interface{}{"id_card": "uploaded"},
+	})
+	require.NoError(t, err)
+	assert.False(t, result.IsCompleted)
+	assert.True(t, repo.changes[2].CompleteAll)
+	assert.Equal(t, []string{"manager"}, instance.CurrentNodes)
+	assert.Equal(t, "uploaded", instance.Variables["id_card"])
+
+	// 未配置can_return的节点不能退回
+	instance.CurrentNodes = []string{"finance"}
+	_, err = engine.ProcessApproval(ctx, &ApprovalRequest{InstanceID: "inst", NodeID: "finance", Action: ActionReturn, ApprovedBy: 3, Comment: "金额有误"})
+	assert.ErrorIs(t, err, ErrInvalidReturn)
+}
@@ -0,0 +1,293 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNodeApprovalState_All(t *testing.T) {
+	now := time.Now()
+
+	state := NewNodeApprovalState(ApprovalTypeAll, false, []uint{1, 2, 3})
+	outcome, err := state.Record(1, ActionApprove, 0, "", now)
+	require.NoError(t, err)
+	assert.Equal(t, DecisionPending, outcome)
+	outcome, err = state.Record(2, ActionApprove, 0, "", now)
+	require.NoError(t, err)
+	assert.Equal(t, DecisionPending, outcome)
+	outcome, err = state.Record(3, ActionApprove, 0, "同意", now)
+	require.NoError(t, err)
+	assert.Equal(t, DecisionApproved, outcome)
+
+	// 任意一人拒绝即拒绝
+	state = NewNodeApprovalState(ApprovalTypeAll, false, []uint{1, 2, 3})
+	_, err = state.Record(1, ActionApprove, 0, "", now)
+	require.NoError(t, err)
+	outcome, err = state.Record(2, ActionReject, 0, "不同意", now)
+	require.NoError(t, err)
+	assert.Equal(t, DecisionRejected, outcome)
+	assert.Equal(t, DecisionPending, state.Votes[2].Decision)
+}
+
+func TestNodeApprovalState_Majority(t *testing.T) {
+	now := time.Now()
+
+	state := NewNodeApprovalState(ApprovalTypeMajority, false, []uint{1, 2, 3})
+	outcome, _ := state.Record(1, ActionApprove, 0, "", now)
+	assert.Equal(t, DecisionPending, outcome)
+	outcome, _ = state.Record(2, ActionReject, 0, "", now)
+	assert.Equal(t, DecisionPending, outcome)
+	outcome, _ = state.Record(3, ActionApprove, 0, "", now)
+	assert.Equal(t, DecisionApproved, outcome)
+
+	// 超过半数拒绝后无需等待剩余审批人
+	state = NewNodeApprovalState(ApprovalTypeMajority, false, []uint{1, 2, 3})
+	_, _ = state.Record(1, ActionReject, 0, "", now)
+	outcome, _ = state.Record(2, ActionReject, 0, "", now)
+	assert.Equal(t, DecisionRejected, outcome)
+
+	// 偶数人数平票不算过半
+	state = NewNodeApprovalState(ApprovalTypeMajority, false, []uint{1, 2, 3, 4})
+	_, _ = state.Record(1, ActionApprove, 0, "", now)
+	_, _ = state.Record(2, ActionApprove, 0, "", now)
+	outcome, _ = state.Record(3, ActionReject, 0, "", now)
+	assert.Equal(t, DecisionPending, outcome)
+	outcome, _ = state.Record(4, ActionReject, 0, "", now)
+	assert.Equal(t, DecisionRejected, outcome)
+}
+
+func TestNodeApprovalState_Delegation(t *testing.T) {
+	now := time.Now()
+
+	state := NewNodeApprovalState(ApprovalTypeAll, true, []uint{1, 2})
+	outcome, err := state.Record(1, ActionDelegate, 9, "", now)
+	require.NoError(t, err)
+	assert.Equal(t, DecisionPending, outcome)
+
+	// 委托后原审批人不能再表决，受托人的表决计入原审批人
+	_, err = state.Record(1, ActionApprove, 0, "", now)
+	assert.Error(t, err)
+	_, err = state.Record(2, ActionApprove, 0, "", now)
+	require.NoError(t, err)
+	outcome, err = state.Record(9, ActionApprove, 0, "代为审批", now)
+	require.NoError(t, err)
+	assert.Equal(t, DecisionApproved, outcome)
+	assert.Equal(t, uint(1), state.Votes[0].AssigneeID)
+	assert.Equal(t, uint(9), state.Votes[0].DecidedBy)
+
+	// 不允许委托、委托给已有审批人、非审批人表决、重复表决均报错
+	state = NewNodeApprovalState(ApprovalTypeAll, false, []uint{1, 2})
+	_, err = state.Record(1, ActionDelegate, 9, "", now)
+	assert.Error(t, err)
+	state.CanDelegate = true
+	_, err = state.Record(1, ActionDelegate, 2, "", now)
+	assert.Error(t, err)
+	_, err = state.Record(7, ActionApprove, 0, "", now)
+	assert.Error(t, err)
+	_, _ = state.Record(1, ActionApprove, 0, "", now)
+	_, err = state.Record(1, ActionReject, 0, "", now)
+	assert.Error(t, err)
+}
+
+// approvalInstanceRepository 在内存仓储基础上支持审批处理
+type approvalInstanceRepository struct {
+	*memoryInstanceRepository
+	instance    *WorkflowInstance
+	changes     []PendingApprovalChange
+	completions []*CompletionRecord
+}
+
+func (r *approvalInstanceRepository) GetInstance(ctx context.Context, instanceID string) (*WorkflowInstance, error) {
+	return r.instance, nil
+}
+
+func (r *approvalInstanceRepository) UpdateInstance(ctx context.Context, instance *WorkflowInstance) error {
+	return nil
+}
+
+func (r *approvalInstanceRepository) SaveApprovalResult(ctx context.Context, instance *WorkflowInstance, history ExecutionHistory, change PendingApprovalChange) error {
+	r.changes = append(r.changes, change)
+	r.saved = append(r.saved, history)
+	return nil
+}
+
+func TestProcessApproval_AllRequiresEveryAssignee(t *testing.T) {
+	definition := &WorkflowDefinition{
+		ID:        "assign",
+		Name:      "任务分配审批",
+		VersionID: 1,
+		Nodes: []WorkflowNode{
+			{ID: "start", Type: NodeTypeStart, Name: "开始"},
+			{ID: "approve", Type: NodeTypeApproval, Name: "会签"},
+			{ID: "end", Type: NodeTypeEnd, Name: "结束"},
+		},
+		Edges: []WorkflowEdge{{ID: "e1", From: "start", To: "approve"}, {ID: "e2", From: "approve", To: "end"}},
+	}
+	instance := &WorkflowInstance{
+		ID:                  "inst",
+		WorkflowID:          "assign",
+		DefinitionVersionID: 1,
+		Status:              StatusRunning,
+		CurrentNodes:        []string{"approve"},
+		Variables:           map[string]interface{}{},
+		Approvals: map[string]*NodeApprovalState{
+			"approve": NewNodeApprovalState(ApprovalTypeAll, true, []uint{1, 2}),
+		},
+	}
+	repo := &approvalInstanceRepository{
+		memoryInstanceRepository: &memoryInstanceRepository{history: map[string][]ExecutionHistory{}, approvals: map[string][]*PendingApproval{}},
+		instance:                 instance,
+	}
+	engine := &WorkflowEngineImpl{
+		definitionManager:    NewWorkflowDefinitionManager(&versionWorkflowRepository{definition: definition}),
+		instanceRepo:         repo,
+		taskExecutorRegistry: NewExecutorRegistry(repo, nil, nil),
+	}
+	ctx := context.Background()
+
+	// 委托不推进节点，为受托人创建待审批记录
+	result, err := engine.ProcessApproval(ctx, &ApprovalRequest{InstanceID: "inst", NodeID: "approve", Action: ActionDelegate, ApprovedBy: 1, DelegateTo: 5})
+	require.NoError(t, err)
+	assert.False(t, result.IsCompleted)
+	assert.Equal(t, []string{"approve"}, instance.CurrentNodes)
+	require.Len(t, repo.changes[0].Create, 1)
+	assert.Equal(t, uint(5), repo.changes[0].Create[0].AssignedTo)
+	assert.Equal(t, []uint{1}, repo.changes[0].CompleteFor)
+
+	result, err = engine.ProcessApproval(ctx, &ApprovalRequest{InstanceID: "inst", NodeID: "approve", Action: ActionApprove, ApprovedBy: 2})
+	require.NoError(t, err)
+	assert.False(t, result.IsCompleted)
+	assert.Equal(t, []string{"approve"}, instance.CurrentNodes)
+	assert.False(t, repo.changes[1].CompleteAll)
+
+	result, err = engine.ProcessApproval(ctx, &ApprovalRequest{InstanceID: "inst", NodeID: "approve", Action: ActionApprove, ApprovedBy: 5})
+	require.NoError(t, err)
+	assert.True(t, repo.changes[2].CompleteAll)
+	assert.NotContains(t, instance.CurrentNodes, "approve")
+	assert.Equal(t, DecisionApproved, result.Approval.Outcome)
+}
+
+// snapshotInstanceRepository 像数据库一样按副本读写实例，读取后稍作等待，使并发审批读到相同的旧状态
+type snapshotInstanceRepository struct {
+	*approvalInstanceRepository
+}
+
+func (r *snapshotInstanceRepository) GetInstance(ctx context.Context, instanceID string) (*WorkflowInstance, error) {
+	instance, err := copyInstance(r.instance)
+	time.Sleep(20 * time.Millisecond)
+	return instance, err
+}
+
+func (r *snapshotInstanceRepository) SaveApprovalResult(ctx context.Context, instance *WorkflowInstance, history ExecutionHistory, change PendingApprovalChange) error {
+	if err := r.UpdateInstance(ctx, instance); err != nil {
+		return err
+	}
+	return r.approvalInstanceRepository.SaveApprovalResult(ctx, instance, history, change)
+}
+
+func (r *snapshotInstanceRepository) UpdateInstance(ctx context.Context, instance *WorkflowInstance) error {
+	saved, err := copyInstance(instance)
+	if err != nil {
+		return err
+	}
+	r.instance = saved
+	return nil
+}
+
+func copyInstance(instance *WorkflowInstance) (*WorkflowInstance, error) {
+	data, err := json.Marshal(instance)
+	if err != nil {
+		return nil, err
+	}
+	var copied WorkflowInstance
+	if err := json.Unmarshal(data, &copied); err != nil {
+		return nil, err
+	}
+	return &copied, nil
+}
+
+func TestProcessApproval_ConcurrentApproversSerialized(t *testing.T) {
+	definition := &WorkflowDefinition{
+		ID:        "leave",
+		Name:      "请假审批",
+		VersionID: 1,
+		Nodes: []WorkflowNode{
+			{ID: "start", Type: NodeTypeStart, Name: "开始"},
+			{ID: "manager", Type: NodeTypeApproval, Name: "主管审批"},
+			{ID: "hr", Type: NodeTypeApproval, Name: "HR审批", Config: map[string]interface{}{
+				"assignees": []map[string]interface{}{{"type": "user", "value": "3"}},
+			}},
+			{ID: "end", Type: NodeTypeEnd, Name: "结束"},
+		},
+		Edges: []WorkflowEdge{
+			{ID: "e1", From: "start", To: "manager"},
+			{ID: "e2", From: "manager", To: "hr"},
+			{ID: "e3", From: "hr", To: "end"},
+		},
+	}
+	instance := &WorkflowInstance{
+		ID:                  "inst",
+		WorkflowID:          "leave",
+		DefinitionVersionID: 1,
+		Status:              StatusRunning,
+		CurrentNodes:        []string{"manager"},
+		Variables:           map[string]interface{}{},
+		Approvals: map[string]*NodeApprovalState{
+			"manager": NewNodeApprovalState(ApprovalTypeAny, false, []uint{1, 2}),
+		},
+	}
+	repo := &snapshotInstanceRepository{&approvalInstanceRepository{
+		memoryInstanceRepository: &memoryInstanceRepository{history: map[string][]ExecutionHistory{}, approvals: map[string][]*PendingApproval{}},
+		instance:                 instance,
+	}}
+	engine := &WorkflowEngineImpl{
+		definitionManager:    NewWorkflowDefinitionManager(&versionWorkflowRepository{definition: definition}),
+		instanceRepo:         repo,
+		taskExecutorRegistry: NewExecutorRegistry(repo, nil, nil),
+	}
+
+	// 两名审批人同时同意任意一人审批的节点，只有一方的决策生效
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i, approver := range []uint{1, 2} {
+		wg.Add(1)
+		go func(i int, approver uint) {
+			defer wg.Done()
+			_, errs[i] = engine.ProcessApproval(context.Background(), &ApprovalRequest{
+				InstanceID: "inst", NodeID: "manager", Action: ActionApprove, ApprovedBy: approver,
+			})
+		}(i, approver)
+	}
+	wg.Wait()
+
+	var succeeded int
+	for _, err := range errs {
+		if err == nil {
+			succeeded++
+		} else {
+			assert.ErrorIs(t, err, ErrApprovalAlreadyProcessed)
+		}
+	}
+	assert.Equal(t, 1, succeeded)
+
+	var approvals, hrExecutions int
+	for _, history := range repo.saved {
+		switch history.NodeID {
+		case "manager":
+			approvals++
+		case "hr":
+			hrExecutions++
+		}
+	}
+	assert.Equal(t, 1, approvals)
+	assert.Equal(t, 1, hrExecutions)
+	require.Len(t, repo.approvals["inst/hr"], 1)
+	assert.Equal(t, uint(3), repo.approvals["inst/hr"][0].AssignedTo)
+	assert.Equal(t, []string{"hr"}, repo.instance.CurrentNodes)
+}
@@ -7,22 +7,29 @@ import (
 
 // AuditService 审计服务
 type AuditService struct {
-	instanceRepo WorkflowInstanceRepository
+	instanceRepo      WorkflowInstanceRepository
+	definitionManager *WorkflowDefinitionManager
 }
 
 // NewAuditService 创建审计服务
-func NewAuditService(instanceRepo WorkflowInstanceRepository) *AuditService {
+func NewAuditService(instanceRepo WorkflowInstanceRepository, definitionManager *WorkflowDefinitionManager) *AuditService {
 	return &AuditService{
-		instanceRepo: instanceRepo,
+		instanceRepo:      instanceRepo,
+		definitionManager: definitionManager,
 	}
 }
 
-// GetWorkflowHistory 获取工作流执行历史
+// GetWorkflowHistory 获取工作流执行历史，历史中的敏感变量一律脱敏
 func (s *AuditService) GetWorkflowHistory(ctx context.Context, instanceID string) (*WorkflowAuditReport, error) {
 	instance, err := s.instanceRepo.GetInstance(ctx, instanceID)
 	if err != nil {
 		return nil, err
 	}
+	definition, err := s.definitionManager.GetWorkflowForInstance(ctx, instance)
+	if err != nil {
+		return nil, err
+	}
+	instance = RedactInstance(instance, definition.VariableSchema)
 
 	report := &WorkflowAuditReport{
 		InstanceID:   instance.ID,
@@ -0,0 +1,34 @@
+package workflow
+
+import (
+	"fmt"
+	"strings"
+
+	"taskmanage/pkg/logger"
+)
+
+// autoApproveReason 按节点的自动审批规则判断是否跳过人工审批，返回记入执行历史的原因，不满足时返回空
+// 先判断审批人是否只有发起人本人；auto_approve配置了条件时条件成立才自动审批，条件无法求值时转为人工审批
+func (c *ApprovalNodeConfig) autoApproveReason(instance *WorkflowInstance, assignees []uint) string {
+	if c.AutoApproveSelf && instance.StartedBy > 0 && len(assignees) == 1 && assignees[0] == instance.StartedBy {
+		return fmt.Sprintf("审批人即发起人（用户%d），按本人审批规则自动通过", instance.StartedBy)
+	}
+	if !c.AutoApprove {
+		return ""
+	}
+
+	condition := strings.TrimSpace(c.AutoApproveCondition)
+	if condition == "" {
+		return "节点配置为自动审批"
+	}
+	matched, err := (&ConditionNodeExecutor{}).evaluateExpression(condition, instance.Variables)
+	if err != nil {
+		logger.Warnf("自动审批条件求值失败，转为人工审批: 实例=%s, 条件=%s, error: %v", instance.ID, condition, err)
+		return ""
+	}
+	if !matched {
+		return ""
+	}
+	varName := strings.Fields(condition)[0]
+	return fmt.Sprintf("满足自动审批条件 %s（%s=%v）", condition, varName, instance.Variables[varName])
+}
@@ -0,0 +1,146 @@
+package workflow
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startedInstanceRepository 保存启动实例的内存实例仓储
+type startedInstanceRepository struct {
+	*approvalInstanceRepository
+}
+
+func (r *startedInstanceRepository) SaveInstance(ctx context.Context, instance *WorkflowInstance) error {
+	r.instance = instance
+	return nil
+}
+
+func newAutoApproveEngine(definition *WorkflowDefinition) (*WorkflowEngineImpl, *startedInstanceRepository) {
+	repo := &startedInstanceRepository{approvalInstanceRepository: &approvalInstanceRepository{
+		memoryInstanceRepository: &memoryInstanceRepository{history: map[string][]ExecutionHistory{}, approvals: map[string][]*PendingApproval{}},
+	}}
+	engine := &WorkflowEngineImpl{
+		definitionManager:    NewWorkflowDefinitionManager(&startWorkflowRepository{definition: definition}),
+		instanceRepo:         repo,
+		taskExecutorRegistry: NewExecutorRegistry(repo, nil, nil),
+	}
+	return engine, repo
+}
+
+// autoApproveDefinition 第一级按预估工时自动审批，第二级审批人为发起人本人
+func autoApproveDefinition() *WorkflowDefinition {
+	return &WorkflowDefinition{
+		ID:        "assign",
+		VersionID: 1,
+		IsActive:  true,
+		Nodes: []WorkflowNode{
+			{ID: "start", Type: NodeTypeStart, Name: "开始"},
+			{ID: "lead", Type: NodeTypeApproval, Name: "组长审批", Config: map[string]interface{}{
+				"assignees":              []map[string]interface{}{{"type": "user", "value": "5"}},
+				"auto_approve":           true,
+				"auto_approve_condition": "estimated_hours < 4",
+			}},
+			{ID: "self", Type: NodeTypeApproval, Name: "发起人确认", Config: map[string]interface{}{
+				"assignees":         []map[string]interface{}{{"type": "starter", "value": "starter"}},
+				"auto_approve_self": true,
+			}},
+			{ID: "end", Type: NodeTypeEnd, Name: "结束"},
+		},
+		Edges: []WorkflowEdge{
+			{ID: "e1", From: "start", To: "lead"},
+			{ID: "e2", From: "lead", To: "self", Condition: "approved"},
+			{ID: "e3", From: "self", To: "end"},
+		},
+	}
+}
+
+func TestStartWorkflow_AutoApprove(t *testing.T) {
+	engine, repo := newAutoApproveEngine(autoApproveDefinition())
+	var completions []*BusinessCompletion
+	registry := NewCompletionHandlerRegistry()
+	registry.Register("task_assignment", func(ctx context.Context, completion *BusinessCompletion) error {
+		completions = append(completions, completion)
+		return nil
+	})
+	engine.SetCompletionHandlers(registry)
+	ctx := context.Background()
+
+	instance, err := engine.StartWorkflow(ctx, &StartWorkflowRequest{
+		WorkflowID:   "assign",
+		BusinessID:   "task_7",
+		BusinessType: "task_assignment",
+		Variables:    map[string]interface{}{"estimated_hours": 2},
+		StartedBy:    9,
+	})
+	require.NoError(t, err)
+
+	// 两个审批节点都以系统身份通过，不产生待审批记录，流程在启动时即结束
+	assert.Equal(t, StatusCompleted, instance.Status)
+	assert.Empty(t, repo.approvals)
+	require.Len(t, repo.saved, 4)
+	lead, self := repo.saved[1], repo.saved[2]
+	assert.Equal(t, "approve", lead.Action)
+	assert.Equal(t, "approved", lead.Result)
+	assert.Equal(t, uint(0), lead.ExecutedBy)
+	assert.Equal(t, "系统自动审批：满足自动审批条件 estimated_hours < 4（estimated_hours=2）", lead.Comment)
+	assert.Equal(t, true, lead.Variables["auto_approved"])
+	assert.Equal(t, "self", self.NodeID)
+	assert.Equal(t, uint(0), self.ExecutedBy)
+	assert.Contains(t, self.Comment, "审批人即发起人（用户9）")
+
+	// 业务回调等调用方保存业务记录后再执行
+	assert.Empty(t, completions)
+	require.Len(t, repo.completions, 1)
+	assert.Equal(t, CompletionPending, repo.completions[0].Status)
+
+	record, err := engine.RetryCompletion(ctx, instance.ID)
+	require.NoError(t, err)
+	assert.Equal(t, CompletionSucceeded, record.Status)
+	require.Len(t, completions, 1)
+	assert.True(t, completions[0].Approved)
+	assert.Equal(t, uint(0), completions[0].ApproverID)
+}
+
+func TestStartWorkflow_AutoApproveConditionNotMet(t *testing.T) {
+	engine, repo := newAutoApproveEngine(autoApproveDefinition())
+
+	instance, err := engine.StartWorkflow(context.Background(), &StartWorkflowRequest{
+		WorkflowID:   "assign",
+		BusinessID:   "task_8",
+		BusinessType: "task_assignment",
+		Variables:    map[string]interface{}{"estimated_hours": 16},
+		StartedBy:    9,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, StatusRunning, instance.Status)
+	assert.Equal(t, []string{"lead"}, instance.CurrentNodes)
+	// 审批人的待审批记录和发起人的查看记录
+	approvals := repo.approvals[instance.ID+"/lead"]
+	require.Len(t, approvals, 2)
+	assert.Equal(t, uint(5), approvals[0].AssignedTo)
+	assert.Equal(t, "execute", repo.saved[1].Action)
+	assert.Empty(t, repo.completions)
+}
+
+func TestApprovalNodeConfig_AutoApproveReason(t *testing.T) {
+	instance := &WorkflowInstance{StartedBy: 9, Variables: map[string]interface{}{"assignee_level": 3}}
+
+	// 本人审批只在审批人仅为发起人时生效
+	config := &ApprovalNodeConfig{AutoApproveSelf: true}
+	assert.NotEmpty(t, config.autoApproveReason(instance, []uint{9}))
+	assert.Empty(t, config.autoApproveReason(instance, []uint{9, 5}))
+
+	config = &ApprovalNodeConfig{AutoApprove: true, AutoApproveCondition: "assignee_level >= 3"}
+	assert.Equal(t, "满足自动审批条件 assignee_level >= 3（assignee_level=3）", config.autoApproveReason(instance, []uint{5}))
+
+	// 变量缺失时条件无法求值，转为人工审批
+	config.AutoApproveCondition = "estimated_hours < 4"
+	assert.Empty(t, config.autoApproveReason(instance, []uint{5}))
+
+	config = &ApprovalNodeConfig{AutoApprove: true}
+	assert.Equal(t, "节点配置为自动审批", config.autoApproveReason(instance, []uint{5}))
+}
@@ -0,0 +1,164 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"taskmanage/pkg/logger"
+)
+
+// ErrCompletionNotRetryable 实例没有失败的业务回调，无需重试
+var ErrCompletionNotRetryable = errors.New("流程实例没有需要重试的业务回调")
+
+// CompletionStatus 业务回调执行状态
+type CompletionStatus string
+
+const (
+	CompletionSucceeded CompletionStatus = "succeeded" // 回调成功
+	CompletionFailed    CompletionStatus = "failed"    // 回调失败，可重试
+	CompletionPending   CompletionStatus = "pending"   // 流程在启动时已自动审批结束，等待调用方保存业务记录后执行
+)
+
+// CompletionRecord 流程结束后业务回调的执行记录，审批结果随记录保存以便重试
+type CompletionRecord struct {
+	Status     CompletionStatus `json:"status"`
+	Approved   bool             `json:"approved"`
+	Expired    bool             `json:"expired,omitempty"` // 流程因超时过期结束，此时Approved为false
+	ApproverID uint             `json:"approver_id"`
+	Attempts   int              `json:"attempts"`
+	Error      string           `json:"error,omitempty"`
+	UpdatedAt  time.Time        `json:"updated_at"`
+}
+
+// BusinessCompletion 传给业务回调的流程结果
+type BusinessCompletion struct {
+	Instance   *WorkflowInstance
+	Approved   bool // 最后一个审批节点的结果是否为通过
+	Expired    bool // 流程超过SLA或节点超时被系统终止，业务方应恢复审批前的状态
+	ApproverID uint // 做出最终决策的审批人，过期时为0
+}
+
+// BusinessCompletionHandler 流程结束后执行的业务回调，如审批通过后完成任务分配
+type BusinessCompletionHandler func(ctx context.Context, completion *BusinessCompletion) error
+
+// CompletionHandlerRegistry 按业务类型登记的业务回调
+type CompletionHandlerRegistry struct {
+	mu       sync.RWMutex
+	handlers map[string]BusinessCompletionHandler
+}
+
+// NewCompletionHandlerRegistry 创建业务回调注册表
+func NewCompletionHandlerRegistry() *CompletionHandlerRegistry {
+	return &CompletionHandlerRegistry{handlers: make(map[string]BusinessCompletionHandler)}
+}
+
+// Register 登记业务类型的回调，重复登记时覆盖
+func (r *CompletionHandlerRegistry) Register(businessType string, handler BusinessCompletionHandler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers[businessType] = handler
+}
+
+// Get 获取业务类型的回调
+func (r *CompletionHandlerRegistry) Get(businessType string) (BusinessCompletionHandler, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	handler, ok := r.handlers[businessType]
+	return handler, ok
+}
+
+// SetCompletionHandlers 设置业务回调注册表
+func (e *WorkflowEngineImpl) SetCompletionHandlers(registry *CompletionHandlerRegistry) {
+	e.completionHandlers = registry
+}
+
+// completeBusiness 流程结束后执行业务回调，回调失败只记录不影响审批结果
+func (e *WorkflowEngineImpl) completeBusiness(ctx context.Context, instance *WorkflowInstance, approved bool, approverID uint) {
+	if e.completionHandlers == nil {
+		return
+	}
+	handler, ok := e.completionHandlers.Get(instance.BusinessType)
+	if !ok {
+		return
+	}
+
+	record := &CompletionRecord{Approved: approved, ApproverID: approverID}
+	e.runCompletion(ctx, instance, handler, record)
+}
+
+// completeOnStart 结束启动时即全部自动审批通过的实例
+// 此时调用方尚未保存分配记录等业务数据，业务回调只记为待执行，由调用方随后通过RetryCompletion触发
+func (e *WorkflowEngineImpl) completeOnStart(ctx context.Context, instance *WorkflowInstance) {
+	logger.FromContext(ctx).Infof("流程启动时已自动审批完成: %s", instance.ID)
+
+	completedAt := time.Now()
+	instance.Status = StatusCompleted
+	instance.CompletedAt = &completedAt
+	if err := e.instanceRepo.UpdateInstance(ctx, instance); err != nil {
+		logger.FromContext(ctx).Errorf("更新流程实例失败: %v", err)
+		return
+	}
+
+	if e.completionHandlers == nil {
+		return
+	}
+	if _, ok := e.completionHandlers.Get(instance.BusinessType); !ok {
+		return
+	}
+	record := &CompletionRecord{Status: CompletionPending, Approved: true, UpdatedAt: completedAt}
+	instance.Completion = record
+	if err := e.instanceRepo.SaveCompletion(ctx, instance.ID, record); err != nil {
+		logger.FromContext(ctx).Errorf("保存业务回调记录失败: 实例=%s, error: %v", instance.ID, err)
+	}
+}
+
+// RetryCompletion 执行失败或待执行的业务回调
+func (e *WorkflowEngineImpl) RetryCompletion(ctx context.Context, instanceID string) (*CompletionRecord, error) {
+	instance, err := e.instanceRepo.GetInstance(ctx, instanceID)
+	if err != nil {
+		return nil, fmt.Errorf("获取流程实例失败: %w", err)
+	}
+	if instance.Completion == nil || (instance.Completion.Status != CompletionFailed && instance.Completion.Status != CompletionPending) {
+		return nil, ErrCompletionNotRetryable
+	}
+
+	var handler BusinessCompletionHandler
+	if e.completionHandlers != nil {
+		handler, _ = e.completionHandlers.Get(instance.BusinessType)
+	}
+	if handler == nil {
+		return nil, fmt.Errorf("业务类型未登记回调: %s", instance.BusinessType)
+	}
+
+	e.runCompletion(ctx, instance, handler, instance.Completion)
+	return instance.Completion, nil
+}
+
+// runCompletion 执行回调并保存执行记录
+func (e *WorkflowEngineImpl) runCompletion(ctx context.Context, instance *WorkflowInstance, handler BusinessCompletionHandler, record *CompletionRecord) {
+	err := handler(ctx, &BusinessCompletion{
+		Instance:   instance,
+		Approved:   record.Approved,
+		Expired:    record.Expired,
+		ApproverID: record.ApproverID,
+	})
+
+	record.Attempts++
+	record.UpdatedAt = time.Now()
+	if err != nil {
+		logger.FromContext(ctx).Errorf("流程业务回调失败: 实例=%s, 业务类型=%s, error: %v", instance.ID, instance.BusinessType, err)
+		record.Status = CompletionFailed
+		record.Error = err.Error()
+	} else {
+		record.Status = CompletionSucceeded
+		record.Error = ""
+	}
+	instance.Completion = record
+
+	if err := e.instanceRepo.SaveCompletion(ctx, instance.ID, record); err != nil {
+		logger.FromContext(ctx).Errorf("保存业务回调记录失败: 实例=%s, error: %v", instance.ID, err)
+	}
+}
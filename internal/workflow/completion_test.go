@@ -0,0 +1,82 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (r *approvalInstanceRepository) SaveCompletion(ctx context.Context, instanceID string, record *CompletionRecord) error {
+	copied := *record
+	r.completions = append(r.completions, &copied)
+	return nil
+}
+
+func TestProcessApproval_RunsCompletionHandler(t *testing.T) {
+	definition := &WorkflowDefinition{
+		ID:        "assign",
+		VersionID: 1,
+		Nodes: []WorkflowNode{
+			{ID: "start", Type: NodeTypeStart, Name: "开始"},
+			{ID: "approve", Type: NodeTypeApproval, Name: "主管审批"},
+			{ID: "end", Type: NodeTypeEnd, Name: "结束"},
+		},
+		Edges: []WorkflowEdge{{ID: "e1", From: "start", To: "approve"}, {ID: "e2", From: "approve", To: "end"}},
+	}
+	instance := &WorkflowInstance{
+		ID:                  "inst",
+		WorkflowID:          "assign",
+		DefinitionVersionID: 1,
+		BusinessType:        "task_assignment",
+		Status:              StatusRunning,
+		CurrentNodes:        []string{"approve"},
+		Variables:           map[string]interface{}{},
+	}
+	repo := &approvalInstanceRepository{
+		memoryInstanceRepository: &memoryInstanceRepository{history: map[string][]ExecutionHistory{}, approvals: map[string][]*PendingApproval{}},
+		instance:                 instance,
+	}
+	engine := &WorkflowEngineImpl{
+		definitionManager:    NewWorkflowDefinitionManager(&versionWorkflowRepository{definition: definition}),
+		instanceRepo:         repo,
+		taskExecutorRegistry: NewExecutorRegistry(repo, nil, nil),
+	}
+
+	// 第一次回调失败，重试时成功
+	var calls []*BusinessCompletion
+	registry := NewCompletionHandlerRegistry()
+	registry.Register("task_assignment", func(ctx context.Context, completion *BusinessCompletion) error {
+		calls = append(calls, completion)
+		if len(calls) == 1 {
+			return errors.New("数据库不可用")
+		}
+		return nil
+	})
+	engine.SetCompletionHandlers(registry)
+	ctx := context.Background()
+
+	result, err := engine.ProcessApproval(ctx, &ApprovalRequest{InstanceID: "inst", NodeID: "approve", Action: ActionApprove, ApprovedBy: 7})
+	require.NoError(t, err)
+	assert.True(t, result.IsCompleted)
+	require.Len(t, calls, 1)
+	assert.True(t, calls[0].Approved)
+	assert.Equal(t, uint(7), calls[0].ApproverID)
+
+	// 回调失败不影响审批结果，只记录失败
+	require.Len(t, repo.completions, 1)
+	assert.Equal(t, CompletionFailed, repo.completions[0].Status)
+	assert.Equal(t, "数据库不可用", repo.completions[0].Error)
+	assert.Equal(t, StatusCompleted, instance.Status)
+
+	record, err := engine.RetryCompletion(ctx, "inst")
+	require.NoError(t, err)
+	assert.Equal(t, CompletionSucceeded, record.Status)
+	assert.Equal(t, 2, record.Attempts)
+	assert.True(t, calls[1].Approved)
+
+	_, err = engine.RetryCompletion(ctx, "inst")
+	assert.ErrorIs(t, err, ErrCompletionNotRetryable)
+}
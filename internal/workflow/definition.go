@@ -3,7 +3,10 @@ package workflow
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"taskmanage/internal/repository"
 	"taskmanage/pkg/logger"
 	"time"
 )
@@ -13,6 +16,7 @@ import (
 // WorkflowDefinitionManager 流程定义管理器
 type WorkflowDefinitionManager struct {
 	repository WorkflowRepository
+	userRepo   repository.UserRepository // 为nil时诊断不检查审批人是否为空
 }
 
 // NewWorkflowDefinitionManager 创建流程定义管理器
@@ -30,16 +34,19 @@ func (m *WorkflowDefinitionManager) CreateWorkflow(ctx context.Context, req *Cre
 	}
 
 	definition := &WorkflowDefinition{
-		ID:          req.ID,
-		Name:        req.Name,
-		Description: req.Description,
-		Version:     req.Version,
-		Nodes:       req.Nodes,
-		Edges:       req.Edges,
-		Variables:   req.Variables,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
-		IsActive:    true,
+		ID:             req.ID,
+		VersionNumber:  1,
+		Name:           req.Name,
+		Description:    req.Description,
+		Version:        req.Version,
+		Nodes:          req.Nodes,
+		Edges:          req.Edges,
+		Variables:      req.Variables,
+		VariableSchema: req.VariableSchema,
+		MaxDuration:    req.MaxDuration,
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+		IsActive:       true,
 	}
 
 	if err := m.repository.SaveWorkflowDefinition(ctx, definition); err != nil {
@@ -51,58 +58,85 @@ func (m *WorkflowDefinitionManager) CreateWorkflow(ctx context.Context, req *Cre
 }
 
 // UpdateWorkflow 更新流程定义
+// 每次更新都会生成新的版本记录，已有版本保持不变，运行中的实例继续使用其绑定的版本
 func (m *WorkflowDefinitionManager) UpdateWorkflow(ctx context.Context, workflowID string, req *UpdateWorkflowRequest) (*WorkflowDefinition, error) {
-	// 获取现有定义
-	existing, err := m.repository.GetWorkflowDefinition(ctx, workflowID)
+	// 以最新版本为基础创建新版本
+	versions, err := m.repository.ListWorkflowDefinitionVersions(ctx, workflowID)
 	if err != nil {
-		return nil, fmt.Errorf("获取流程定义失败: %w", err)
+		return nil, fmt.Errorf("获取流程定义版本失败: %w", err)
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("流程定义不存在: %s", workflowID)
+	}
+	latest := versions[0]
+
+	definition := &WorkflowDefinition{
+		ID:             latest.ID,
+		VersionNumber:  latest.VersionNumber + 1,
+		Name:           latest.Name,
+		Description:    latest.Description,
+		Version:        latest.Version,
+		Nodes:          latest.Nodes,
+		Edges:          latest.Edges,
+		Variables:      latest.Variables,
+		VariableSchema: latest.VariableSchema,
+		MaxDuration:    latest.MaxDuration,
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+		IsActive:       true,
 	}
 
 	// 更新字段
 	if req.Name != "" {
-		existing.Name = req.Name
+		definition.Name = req.Name
 	}
 	if req.Description != "" {
-		existing.Description = req.Description
+		definition.Description = req.Description
 	}
 	if req.Version != "" {
-		existing.Version = req.Version
+		definition.Version = req.Version
 	}
 	if len(req.Nodes) > 0 {
-		existing.Nodes = req.Nodes
+		definition.Nodes = req.Nodes
 	}
 	if len(req.Edges) > 0 {
-		existing.Edges = req.Edges
+		definition.Edges = req.Edges
 	}
 	if req.Variables != nil {
-		existing.Variables = req.Variables
+		definition.Variables = req.Variables
+	}
+	if req.VariableSchema != nil {
+		definition.VariableSchema = req.VariableSchema
+	}
+	if req.MaxDuration != nil {
+		definition.MaxDuration = *req.MaxDuration
 	}
 	if req.IsActive != nil {
-		existing.IsActive = *req.IsActive
+		definition.IsActive = *req.IsActive
 	}
 
-	existing.UpdatedAt = time.Now()
-
 	// 验证更新后的定义
 	validateReq := &CreateWorkflowRequest{
-		ID:          existing.ID,
-		Name:        existing.Name,
-		Description: existing.Description,
-		Version:     existing.Version,
-		Nodes:       existing.Nodes,
-		Edges:       existing.Edges,
-		Variables:   existing.Variables,
+		ID:             definition.ID,
+		Name:           definition.Name,
+		Description:    definition.Description,
+		Version:        definition.Version,
+		Nodes:          definition.Nodes,
+		Edges:          definition.Edges,
+		Variables:      definition.Variables,
+		VariableSchema: definition.VariableSchema,
+		MaxDuration:    definition.MaxDuration,
 	}
 	if err := m.validateWorkflowDefinition(validateReq); err != nil {
 		return nil, fmt.Errorf("更新后的流程定义验证失败: %w", err)
 	}
 
-	if err := m.repository.SaveWorkflowDefinition(ctx, existing); err != nil {
+	if err := m.repository.SaveWorkflowDefinition(ctx, definition); err != nil {
 		return nil, fmt.Errorf("保存更新的流程定义失败: %w", err)
 	}
 
-	logger.Infof("更新流程定义成功: %s", workflowID)
-	return existing, nil
+	logger.Infof("更新流程定义成功: %s, 新版本: %d", workflowID, definition.VersionNumber)
+	return definition, nil
 }
 
 // GetWorkflow 获取流程定义
@@ -114,6 +148,20 @@ func (m *WorkflowDefinitionManager) GetWorkflow(ctx context.Context, workflowID
 	return definition, nil
 }
 
+// GetWorkflowForInstance 获取流程实例绑定的流程定义版本
+func (m *WorkflowDefinitionManager) GetWorkflowForInstance(ctx context.Context, instance *WorkflowInstance) (*WorkflowDefinition, error) {
+	if instance.DefinitionVersionID == 0 {
+		// 未绑定版本的实例回退到当前激活版本
+		return m.GetWorkflow(ctx, instance.WorkflowID)
+	}
+
+	definition, err := m.repository.GetWorkflowDefinitionVersion(ctx, instance.DefinitionVersionID)
+	if err != nil {
+		return nil, fmt.Errorf("获取流程定义版本失败: %w", err)
+	}
+	return definition, nil
+}
+
 // ListWorkflows 列出流程定义
 func (m *WorkflowDefinitionManager) ListWorkflows(ctx context.Context, filter WorkflowFilter) ([]*WorkflowDefinition, error) {
 	definitions, err := m.repository.ListWorkflowDefinitions(ctx, filter)
@@ -123,17 +171,45 @@ func (m *WorkflowDefinitionManager) ListWorkflows(ctx context.Context, filter Wo
 	return definitions, nil
 }
 
+// ListWorkflowVersions 列出流程定义的所有版本
+func (m *WorkflowDefinitionManager) ListWorkflowVersions(ctx context.Context, workflowID string) ([]*WorkflowDefinition, error) {
+	versions, err := m.repository.ListWorkflowDefinitionVersions(ctx, workflowID)
+	if err != nil {
+		return nil, fmt.Errorf("列出流程定义版本失败: %w", err)
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("流程定义不存在: %s", workflowID)
+	}
+	return versions, nil
+}
+
+// ActivateWorkflowVersion 激活流程定义版本，新实例将使用该版本
+func (m *WorkflowDefinitionManager) ActivateWorkflowVersion(ctx context.Context, workflowID string, versionID uint) error {
+	if err := m.repository.SetWorkflowVersionActive(ctx, workflowID, versionID, true); err != nil {
+		return fmt.Errorf("激活流程定义版本失败: %w", err)
+	}
+
+	logger.Infof("激活流程定义版本成功: %s, 版本ID: %d", workflowID, versionID)
+	return nil
+}
+
+// DeactivateWorkflowVersion 停用流程定义版本，已运行的实例不受影响
+func (m *WorkflowDefinitionManager) DeactivateWorkflowVersion(ctx context.Context, workflowID string, versionID uint) error {
+	if err := m.repository.SetWorkflowVersionActive(ctx, workflowID, versionID, false); err != nil {
+		return fmt.Errorf("停用流程定义版本失败: %w", err)
+	}
+
+	logger.Infof("停用流程定义版本成功: %s, 版本ID: %d", workflowID, versionID)
+	return nil
+}
+
 // DeactivateWorkflow 停用流程定义
 func (m *WorkflowDefinitionManager) DeactivateWorkflow(ctx context.Context, workflowID string) error {
-	definition, err := m.repository.GetWorkflowDefinition(ctx, workflowID)
-	if err != nil {
+	if _, err := m.repository.GetWorkflowDefinition(ctx, workflowID); err != nil {
 		return fmt.Errorf("获取流程定义失败: %w", err)
 	}
 
-	definition.IsActive = false
-	definition.UpdatedAt = time.Now()
-
-	if err := m.repository.SaveWorkflowDefinition(ctx, definition); err != nil {
+	if err := m.repository.UpdateWorkflowStatus(ctx, workflowID, false); err != nil {
 		return fmt.Errorf("停用流程定义失败: %w", err)
 	}
 
@@ -146,8 +222,21 @@ func (m *WorkflowDefinitionManager) ValidateWorkflow(ctx context.Context, req *C
 	return m.validateWorkflowDefinition(req)
 }
 
-// validateWorkflowDefinition 验证流程定义
+// validateWorkflowDefinition 验证流程定义：结构不合法或图分析发现错误级别的问题时返回第一个错误，警告不影响保存
 func (m *WorkflowDefinitionManager) validateWorkflowDefinition(req *CreateWorkflowRequest) error {
+	if err := m.validateWorkflowStructure(req); err != nil {
+		return err
+	}
+	for _, diagnostic := range analyzeWorkflowGraph(req) {
+		if diagnostic.Severity == DiagnosticError {
+			return errors.New(diagnostic.Message)
+		}
+	}
+	return nil
+}
+
+// validateWorkflowStructure 验证流程定义的字段、节点配置和边引用的节点，图结构由analyzeWorkflowGraph检查
+func (m *WorkflowDefinitionManager) validateWorkflowStructure(req *CreateWorkflowRequest) error {
 	if req.ID == "" {
 		return fmt.Errorf("流程ID不能为空")
 	}
@@ -157,6 +246,9 @@ func (m *WorkflowDefinitionManager) validateWorkflowDefinition(req *CreateWorkfl
 	if len(req.Nodes) == 0 {
 		return fmt.Errorf("流程必须包含至少一个节点")
 	}
+	if req.MaxDuration < 0 {
+		return fmt.Errorf("流程SLA不能为负数")
+	}
 
 	// 验证节点
 	nodeMap := make(map[string]*WorkflowNode)
@@ -197,6 +289,11 @@ func (m *WorkflowDefinitionManager) validateWorkflowDefinition(req *CreateWorkfl
 	if !hasStart {
 		return fmt.Errorf("流程必须包含开始节点")
 	}
+	for i := range req.Nodes {
+		if err := m.validateConditionTargets(&req.Nodes[i], nodeMap); err != nil {
+			return fmt.Errorf("节点[%s]配置验证失败: %w", req.Nodes[i].ID, err)
+		}
+	}
 	if !hasEnd {
 		return fmt.Errorf("流程必须包含结束节点")
 	}
@@ -222,9 +319,13 @@ func (m *WorkflowDefinitionManager) validateWorkflowDefinition(req *CreateWorkfl
 		}
 	}
 
-	// 验证流程连通性
-	if err := m.validateWorkflowConnectivity(nodeMap, req.Edges); err != nil {
-		return fmt.Errorf("流程连通性验证失败: %w", err)
+	if err := validateVariableSchema(req.VariableSchema); err != nil {
+		return fmt.Errorf("流程变量验证失败: %w", err)
+	}
+	for i := range req.Nodes {
+		if _, err := referencedVariables(&req.Nodes[i]); err != nil {
+			return fmt.Errorf("节点[%s]配置解析失败: %w", req.Nodes[i].ID, err)
+		}
 	}
 
 	return nil
@@ -262,6 +363,24 @@ func (m *WorkflowDefinitionManager) validateApprovalNodeConfig(node *WorkflowNod
 	if len(config.Assignees) == 0 {
 		return fmt.Errorf("审批节点必须配置审批人")
 	}
+	if timeout, ok := node.Config["timeout"].(float64); ok && timeout < 0 {
+		return fmt.Errorf("审批节点超时时间不能为负数")
+	}
+
+	switch config.ApprovalType {
+	case "", ApprovalTypeSequential, ApprovalTypeParallel, ApprovalTypeAny, ApprovalTypeAll, ApprovalTypeMajority:
+	default:
+		return fmt.Errorf("不支持的审批类型: %s", config.ApprovalType)
+	}
+
+	if condition := strings.TrimSpace(config.AutoApproveCondition); condition != "" {
+		if !config.AutoApprove {
+			return fmt.Errorf("配置自动审批条件时必须开启auto_approve")
+		}
+		if err := validateConditionExpression(condition); err != nil {
+			return fmt.Errorf("自动审批条件无效: %w", err)
+		}
+	}
 
 	for i, assignee := range config.Assignees {
 		if assignee.Type == "" {
@@ -295,6 +414,12 @@ func (m *WorkflowDefinitionManager) validateConditionNodeConfig(node *WorkflowNo
 		return fmt.Errorf("条件节点必须配置条件规则")
 	}
 
+	switch config.EvaluationMode {
+	case "", ConditionFirstMatch, ConditionAllMatches:
+	default:
+		return fmt.Errorf("不支持的条件评估方式: %s", config.EvaluationMode)
+	}
+
 	for i, condition := range config.Conditions {
 		if condition.Expression == "" {
 			return fmt.Errorf("条件规则[%d]表达式不能为空", i)
@@ -307,6 +432,28 @@ func (m *WorkflowDefinitionManager) validateConditionNodeConfig(node *WorkflowNo
 	return nil
 }
 
+// validateConditionTargets 验证条件节点的目标节点和默认目标节点都存在
+func (m *WorkflowDefinitionManager) validateConditionTargets(node *WorkflowNode, nodes map[string]*WorkflowNode) error {
+	if node.Type != NodeTypeCondition {
+		return nil
+	}
+	config, err := (&ConditionNodeExecutor{}).parseConditionConfig(node)
+	if err != nil {
+		return err
+	}
+	for i, condition := range config.Conditions {
+		if _, exists := nodes[condition.Target]; !exists {
+			return fmt.Errorf("条件规则[%d]目标节点[%s]不存在", i, condition.Target)
+		}
+	}
+	if config.DefaultTarget != "" {
+		if _, exists := nodes[config.DefaultTarget]; !exists {
+			return fmt.Errorf("默认目标节点[%s]不存在", config.DefaultTarget)
+		}
+	}
+	return nil
+}
+
 // validateNotifyNodeConfig 验证通知节点配置
 func (m *WorkflowDefinitionManager) validateNotifyNodeConfig(node *WorkflowNode) error {
 	if node.Config == nil {
@@ -330,69 +477,28 @@ func (m *WorkflowDefinitionManager) validateNotifyNodeConfig(node *WorkflowNode)
 	return nil
 }
 
-// validateWorkflowConnectivity 验证流程连通性
-func (m *WorkflowDefinitionManager) validateWorkflowConnectivity(nodes map[string]*WorkflowNode, edges []WorkflowEdge) error {
-	// 构建邻接表
-	graph := make(map[string][]string)
-	for _, edge := range edges {
-		graph[edge.From] = append(graph[edge.From], edge.To)
-	}
-
-	// 找到开始节点
-	var startNode string
-	for nodeID, node := range nodes {
-		if node.Type == NodeTypeStart {
-			startNode = nodeID
-			break
-		}
-	}
-
-	if startNode == "" {
-		return fmt.Errorf("未找到开始节点")
-	}
-
-	// DFS检查所有节点是否可达
-	visited := make(map[string]bool)
-	m.dfsVisit(startNode, graph, visited)
-
-	// 检查是否所有节点都被访问
-	for nodeID := range nodes {
-		if !visited[nodeID] {
-			return fmt.Errorf("节点[%s]不可达", nodeID)
-		}
-	}
-
-	return nil
-}
-
-// dfsVisit DFS访问节点
-func (m *WorkflowDefinitionManager) dfsVisit(nodeID string, graph map[string][]string, visited map[string]bool) {
-	visited[nodeID] = true
-	for _, neighbor := range graph[nodeID] {
-		if !visited[neighbor] {
-			m.dfsVisit(neighbor, graph, visited)
-		}
-	}
-}
-
 // CreateWorkflowRequest 创建流程请求
 type CreateWorkflowRequest struct {
-	ID          string                 `json:"id"`
-	Name        string                 `json:"name"`
-	Description string                 `json:"description"`
-	Version     string                 `json:"version"`
-	Nodes       []WorkflowNode         `json:"nodes"`
-	Edges       []WorkflowEdge         `json:"edges"`
-	Variables   map[string]interface{} `json:"variables,omitempty"`
+	ID             string                 `json:"id"`
+	Name           string                 `json:"name"`
+	Description    string                 `json:"description"`
+	Version        string                 `json:"version"`
+	Nodes          []WorkflowNode         `json:"nodes"`
+	Edges          []WorkflowEdge         `json:"edges"`
+	Variables      map[string]interface{} `json:"variables,omitempty"`
+	VariableSchema []VariableDefinition   `json:"variable_schema,omitempty"` // 声明的流程变量，声明后启动时按声明校验变量
+	MaxDuration    int64                  `json:"max_duration,omitempty"`    // 流程SLA（秒），0表示不限制
 }
 
 // UpdateWorkflowRequest 更新流程请求
 type UpdateWorkflowRequest struct {
-	Name        string                 `json:"name,omitempty"`
-	Description string                 `json:"description,omitempty"`
-	Version     string                 `json:"version,omitempty"`
-	Nodes       []WorkflowNode         `json:"nodes,omitempty"`
-	Edges       []WorkflowEdge         `json:"edges,omitempty"`
-	Variables   map[string]interface{} `json:"variables,omitempty"`
-	IsActive    *bool                  `json:"is_active,omitempty"`
+	Name           string                 `json:"name,omitempty"`
+	Description    string                 `json:"description,omitempty"`
+	Version        string                 `json:"version,omitempty"`
+	Nodes          []WorkflowNode         `json:"nodes,omitempty"`
+	Edges          []WorkflowEdge         `json:"edges,omitempty"`
+	Variables      map[string]interface{} `json:"variables,omitempty"`
+	VariableSchema []VariableDefinition   `json:"variable_schema,omitempty"` // 整体替换变量声明，传空数组时取消声明
+	MaxDuration    *int64                 `json:"max_duration,omitempty"`    // 设置为0时取消SLA
+	IsActive       *bool                  `json:"is_active,omitempty"`
 }
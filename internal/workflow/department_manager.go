@@ -0,0 +1,89 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	"taskmanage/internal/repository"
+	"taskmanage/pkg/logger"
+)
+
+// 部门经理审批人的解析来源
+const (
+	ManagerSourceDepartment    = "department"     // 发起人所在部门的负责人
+	ManagerSourceDirectManager = "direct_manager" // 部门未设置负责人时回退到发起人的直属上级
+)
+
+// SetDepartmentRepository 设置部门仓储，department_manager审批人优先取发起人所在部门的负责人
+func (e *WorkflowEngineImpl) SetDepartmentRepository(repo repository.DepartmentRepository) {
+	e.taskExecutorRegistry.SetDepartmentRepository(repo)
+	e.onboardingExecutorRegistry.SetDepartmentRepository(repo)
+}
+
+// SetDepartmentRepository 为注册表中的审批节点执行器设置部门仓储
+func (r *ExecutorRegistry) SetDepartmentRepository(repo repository.DepartmentRepository) {
+	switch executor := r.executors[NodeTypeApproval].(type) {
+	case *ApprovalNodeExecutor:
+		executor.departmentRepo = repo
+	case *OnboardingApprovalNodeExecutor:
+		executor.departmentRepo = repo
+	}
+}
+
+// getDepartmentManagerByUser 查找用户所在部门负责人的用户ID
+// 部门未设置负责人、负责人就是发起人本人或无法读取部门时回退到直属上级，解析来源记录在日志中
+func (e *ApprovalNodeExecutor) getDepartmentManagerByUser(ctx context.Context, userID uint) (uint, error) {
+	employee, err := e.employeeRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("查找员工失败: %w", err)
+	}
+
+	if managerUserID, ok := e.departmentManagerOf(ctx, employee.ID, employee.DepartmentID); ok {
+		logDepartmentManagerResolved(ctx, userID, employee.DepartmentID, managerUserID, ManagerSourceDepartment)
+		return managerUserID, nil
+	}
+
+	managerUserID, err := e.getManagerByUser(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	logDepartmentManagerResolved(ctx, userID, employee.DepartmentID, managerUserID, ManagerSourceDirectManager)
+	return managerUserID, nil
+}
+
+// departmentManagerOf 返回部门负责人的用户ID，部门负责人不可用时返回false
+func (e *ApprovalNodeExecutor) departmentManagerOf(ctx context.Context, employeeID uint, departmentID *uint) (uint, bool) {
+	if e.departmentRepo == nil || departmentID == nil {
+		return 0, false
+	}
+	department, err := e.departmentRepo.GetByID(ctx, *departmentID)
+	if err != nil {
+		logger.FromContext(ctx).Warnf("获取部门失败，回退到直属上级: departmentID=%d, error=%v", *departmentID, err)
+		return 0, false
+	}
+	if department.ManagerID == nil || *department.ManagerID == employeeID {
+		return 0, false
+	}
+	manager, err := e.employeeRepo.GetByID(ctx, *department.ManagerID)
+	if err != nil {
+		logger.FromContext(ctx).Warnf("获取部门负责人失败，回退到直属上级: managerID=%d, error=%v", *department.ManagerID, err)
+		return 0, false
+	}
+	return manager.UserID, manager.UserID != 0
+}
+
+// logDepartmentManagerResolved 记录部门经理审批人的解析结果和来源
+func logDepartmentManagerResolved(ctx context.Context, userID uint, departmentID *uint, managerUserID uint, source string) {
+	fields := logrus.Fields{
+		"event":           "department_manager_resolved",
+		"user_id":         userID,
+		"manager_user_id": managerUserID,
+		"source":          source,
+	}
+	if departmentID != nil {
+		fields["department_id"] = *departmentID
+	}
+	logger.FromContext(ctx).WithFields(fields).Info("解析部门经理审批人")
+}
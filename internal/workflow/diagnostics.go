@@ -0,0 +1,297 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"taskmanage/internal/repository"
+	"taskmanage/pkg/logger"
+)
+
+// DiagnosticSeverity 流程定义诊断的严重程度
+type DiagnosticSeverity string
+
+const (
+	DiagnosticError   DiagnosticSeverity = "error"   // 错误，流程定义不能保存
+	DiagnosticWarning DiagnosticSeverity = "warning" // 警告，不影响保存
+)
+
+// 流程定义诊断代码
+const (
+	DiagnosticInvalidDefinition  = "invalid_definition"  // 字段或节点配置不合法
+	DiagnosticMultipleStart      = "multiple_start"      // 存在多个开始节点
+	DiagnosticUnreachableNode    = "unreachable_node"    // 从开始节点不可达
+	DiagnosticDeadEnd            = "dead_end"            // 非结束节点没有出边
+	DiagnosticAutoLoop           = "auto_loop"           // 不经过审批节点的循环，会无限自动执行
+	DiagnosticUndeclaredVariable = "undeclared_variable" // 引用了未声明的变量
+	DiagnosticEmptyAssignees     = "empty_assignees"     // 审批人当前解析为空
+)
+
+// Diagnostic 流程定义诊断项，NodeID为空表示针对整个流程定义
+type Diagnostic struct {
+	Severity DiagnosticSeverity `json:"severity"`
+	NodeID   string             `json:"node_id,omitempty"`
+	Code     string             `json:"code"`
+	Message  string             `json:"message"`
+}
+
+// SetUserRepository 设置用户仓储，诊断时用于检查按用户或角色指定的审批人当前是否存在
+func (m *WorkflowDefinitionManager) SetUserRepository(userRepo repository.UserRepository) {
+	m.userRepo = userRepo
+}
+
+// DiagnoseWorkflow 返回流程定义的全部诊断项，供流程设计器标记问题节点
+// 结构不合法时只返回该错误，不再做图分析；没有错误级别的诊断项时流程定义可以保存
+func (m *WorkflowDefinitionManager) DiagnoseWorkflow(ctx context.Context, req *CreateWorkflowRequest) []Diagnostic {
+	if err := m.validateWorkflowStructure(req); err != nil {
+		return []Diagnostic{{Severity: DiagnosticError, Code: DiagnosticInvalidDefinition, Message: err.Error()}}
+	}
+	diagnostics := analyzeWorkflowGraph(req)
+	return append(diagnostics, m.diagnoseApprovalAssignees(ctx, req)...)
+}
+
+// analyzeWorkflowGraph 分析结构合法的流程定义的图结构和变量引用
+func analyzeWorkflowGraph(req *CreateWorkflowRequest) []Diagnostic {
+	var diagnostics []Diagnostic
+	add := func(severity DiagnosticSeverity, nodeID, code, format string, args ...interface{}) {
+		diagnostics = append(diagnostics, Diagnostic{Severity: severity, NodeID: nodeID, Code: code, Message: fmt.Sprintf(format, args...)})
+	}
+
+	// 可达性只按边计算，与实例推进时查找下一节点的方式一致
+	edgeTargets := make(map[string][]string)
+	for _, edge := range req.Edges {
+		edgeTargets[edge.From] = append(edgeTargets[edge.From], edge.To)
+	}
+	successors := workflowSuccessors(req, edgeTargets)
+
+	startNode := ""
+	for _, node := range req.Nodes {
+		if node.Type != NodeTypeStart {
+			continue
+		}
+		if startNode == "" {
+			startNode = node.ID
+			continue
+		}
+		add(DiagnosticError, node.ID, DiagnosticMultipleStart, "节点[%s]是多余的开始节点，流程只能有一个开始节点[%s]", node.ID, startNode)
+	}
+
+	reachable := make(map[string]bool)
+	visitNodes(startNode, edgeTargets, reachable)
+	for _, node := range req.Nodes {
+		if !reachable[node.ID] && node.Type != NodeTypeStart {
+			add(DiagnosticError, node.ID, DiagnosticUnreachableNode, "节点[%s]不可达", node.ID)
+		}
+	}
+
+	for _, node := range req.Nodes {
+		if node.Type != NodeTypeEnd && len(successors[node.ID]) == 0 {
+			add(DiagnosticError, node.ID, DiagnosticDeadEnd, "节点[%s]不是结束节点但没有出边，流程会停在该节点", node.ID)
+		}
+	}
+
+	for _, loop := range autoLoops(req, successors) {
+		for _, nodeID := range loop {
+			add(DiagnosticError, nodeID, DiagnosticAutoLoop, "节点[%s]所在的循环[%s]不经过审批节点，会无限自动执行", nodeID, strings.Join(loop, " → "))
+		}
+	}
+
+	// 声明了变量时引用未声明的变量为错误；未声明变量的流程兼容旧定义，只对条件节点给出警告
+	declared := make(map[string]bool, len(req.VariableSchema))
+	for _, variable := range req.VariableSchema {
+		declared[variable.Name] = true
+	}
+	for i := range req.Nodes {
+		node := &req.Nodes[i]
+		names, _ := referencedVariables(node)
+		for _, name := range names {
+			if declared[name] {
+				continue
+			}
+			if len(req.VariableSchema) > 0 {
+				add(DiagnosticError, node.ID, DiagnosticUndeclaredVariable, "节点[%s]引用的变量[%s]未声明", node.ID, name)
+			} else if node.Type == NodeTypeCondition {
+				add(DiagnosticWarning, node.ID, DiagnosticUndeclaredVariable, "节点[%s]引用的变量[%s]未声明，启动时不会校验", node.ID, name)
+			}
+		}
+	}
+
+	return diagnostics
+}
+
+// workflowSuccessors 返回各节点的后继节点：边的目标，加上条件节点的条件目标和默认目标
+func workflowSuccessors(req *CreateWorkflowRequest, edgeTargets map[string][]string) map[string][]string {
+	successors := make(map[string][]string, len(req.Nodes))
+	for i := range req.Nodes {
+		node := &req.Nodes[i]
+		seen := make(map[string]bool)
+		appendTarget := func(target string) {
+			if target != "" && !seen[target] {
+				seen[target] = true
+				successors[node.ID] = append(successors[node.ID], target)
+			}
+		}
+		for _, target := range edgeTargets[node.ID] {
+			appendTarget(target)
+		}
+		if node.Type == NodeTypeCondition {
+			if config, err := (&ConditionNodeExecutor{}).parseConditionConfig(node); err == nil {
+				for _, condition := range config.Conditions {
+					appendTarget(condition.Target)
+				}
+				appendTarget(config.DefaultTarget)
+			}
+		}
+	}
+	return successors
+}
+
+// visitNodes 深度优先标记从nodeID出发可达的节点
+func visitNodes(nodeID string, graph map[string][]string, visited map[string]bool) {
+	if nodeID == "" || visited[nodeID] {
+		return
+	}
+	visited[nodeID] = true
+	for _, next := range graph[nodeID] {
+		visitNodes(next, graph, visited)
+	}
+}
+
+// autoLoops 返回不经过审批节点的循环，每个循环按节点定义顺序列出其中的节点
+// 去掉审批节点后求强连通分量，包含多个节点或有自环的分量即为自动循环
+func autoLoops(req *CreateWorkflowRequest, successors map[string][]string) [][]string {
+	automatic := make(map[string]bool, len(req.Nodes))
+	order := make(map[string]int, len(req.Nodes))
+	for i, node := range req.Nodes {
+		order[node.ID] = i
+		if node.Type != NodeTypeApproval {
+			automatic[node.ID] = true
+		}
+	}
+
+	// Tarjan强连通分量算法
+	index := 0
+	indices := make(map[string]int)
+	lowLinks := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	var loops [][]string
+
+	var connect func(nodeID string)
+	connect = func(nodeID string) {
+		indices[nodeID] = index
+		lowLinks[nodeID] = index
+		index++
+		stack = append(stack, nodeID)
+		onStack[nodeID] = true
+
+		selfLoop := false
+		for _, next := range successors[nodeID] {
+			if !automatic[next] {
+				continue
+			}
+			if next == nodeID {
+				selfLoop = true
+			}
+			if _, visited := indices[next]; !visited {
+				connect(next)
+				lowLinks[nodeID] = min(lowLinks[nodeID], lowLinks[next])
+			} else if onStack[next] {
+				lowLinks[nodeID] = min(lowLinks[nodeID], indices[next])
+			}
+		}
+
+		if lowLinks[nodeID] != indices[nodeID] {
+			return
+		}
+		var component []string
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			component = append(component, top)
+			if top == nodeID {
+				break
+			}
+		}
+		if len(component) > 1 || selfLoop {
+			sort.Slice(component, func(i, j int) bool { return order[component[i]] < order[component[j]] })
+			loops = append(loops, component)
+		}
+	}
+
+	for _, node := range req.Nodes {
+		if _, visited := indices[node.ID]; !visited && automatic[node.ID] {
+			connect(node.ID)
+		}
+	}
+	sort.Slice(loops, func(i, j int) bool { return order[loops[i][0]] < order[loops[j][0]] })
+	return loops
+}
+
+// diagnoseApprovalAssignees 检查只按用户或角色指定审批人的审批节点当前能否解析出审批人
+// 含有上级、发起人、变量等运行时才能确定的审批人时不检查；未设置用户仓储时跳过
+func (m *WorkflowDefinitionManager) diagnoseApprovalAssignees(ctx context.Context, req *CreateWorkflowRequest) []Diagnostic {
+	if m.userRepo == nil {
+		return nil
+	}
+
+	var diagnostics []Diagnostic
+	for i := range req.Nodes {
+		node := &req.Nodes[i]
+		if node.Type != NodeTypeApproval {
+			continue
+		}
+		config, err := (&ApprovalNodeExecutor{}).parseApprovalConfig(node)
+		if err != nil {
+			continue
+		}
+
+		static := true
+		resolved := 0
+		var empty []string
+		for _, assignee := range config.Assignees {
+			switch assignee.Type {
+			case AssigneeTypeUser:
+				userID, err := strconv.ParseUint(assignee.Value, 10, 64)
+				if err != nil || userID == 0 {
+					empty = append(empty, fmt.Sprintf("用户%s", assignee.Value))
+					continue
+				}
+				if _, err := m.userRepo.GetByID(ctx, uint(userID)); err != nil {
+					logger.FromContext(ctx).Debugf("诊断审批人: 节点=%s, 用户=%d, 错误=%v", node.ID, userID, err)
+					empty = append(empty, fmt.Sprintf("用户%d", userID))
+					continue
+				}
+				resolved++
+			case AssigneeTypeRole:
+				matches, err := m.userRepo.FindUsersByRole(ctx, assignee.Value)
+				if err != nil {
+					logger.FromContext(ctx).Warnf("诊断审批人时查询角色用户失败: 节点=%s, 角色=%s, 错误=%v", node.ID, assignee.Value, err)
+					static = false
+					continue
+				}
+				if len(matches) == 0 {
+					empty = append(empty, fmt.Sprintf("角色%s", assignee.Value))
+					continue
+				}
+				resolved += len(matches)
+			default:
+				static = false
+			}
+		}
+
+		if static && resolved == 0 {
+			diagnostics = append(diagnostics, Diagnostic{
+				Severity: DiagnosticWarning,
+				NodeID:   node.ID,
+				Code:     DiagnosticEmptyAssignees,
+				Message:  fmt.Sprintf("节点[%s]的审批人当前为空（%s），实例到达该节点时无人审批", node.ID, strings.Join(empty, "、")),
+			})
+		}
+	}
+	return diagnostics
+}
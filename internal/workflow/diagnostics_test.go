@@ -0,0 +1,152 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+// diagnosticDefinition 构造只包含指定节点和边的流程定义，边ID按顺序生成
+func diagnosticDefinition(nodes []WorkflowNode, edges ...[2]string) *CreateWorkflowRequest {
+	req := &CreateWorkflowRequest{ID: "designer", Name: "设计器流程", Nodes: nodes}
+	for i, edge := range edges {
+		req.Edges = append(req.Edges, WorkflowEdge{ID: fmt.Sprintf("e%d", i+1), From: edge[0], To: edge[1]})
+	}
+	return req
+}
+
+func roleApproval(id, role string) WorkflowNode {
+	return WorkflowNode{ID: id, Type: NodeTypeApproval, Name: "审批", Config: map[string]interface{}{
+		"assignees": []map[string]interface{}{{"type": "role", "value": role}},
+	}}
+}
+
+func notifyNode(id string) WorkflowNode {
+	return WorkflowNode{ID: id, Type: NodeTypeNotify, Name: "通知", Config: map[string]interface{}{"type": "email"}}
+}
+
+// diagnosticCodes 返回诊断项的 节点ID/代码 列表
+func diagnosticCodes(diagnostics []Diagnostic) []string {
+	codes := make([]string, 0, len(diagnostics))
+	for _, diagnostic := range diagnostics {
+		codes = append(codes, diagnostic.NodeID+"/"+diagnostic.Code)
+	}
+	return codes
+}
+
+func TestDiagnoseWorkflow(t *testing.T) {
+	start := WorkflowNode{ID: "start", Type: NodeTypeStart, Name: "开始"}
+	end := WorkflowNode{ID: "end", Type: NodeTypeEnd, Name: "结束"}
+	manager := NewWorkflowDefinitionManager(nil)
+	ctx := context.Background()
+
+	tests := []struct {
+		name  string
+		req   *CreateWorkflowRequest
+		codes []string
+	}{
+		{
+			name:  "合法流程",
+			req:   diagnosticDefinition([]WorkflowNode{start, roleApproval("review", "hr"), end}, [2]string{"start", "review"}, [2]string{"review", "end"}),
+			codes: []string{},
+		},
+		{
+			name:  "结构错误",
+			req:   diagnosticDefinition([]WorkflowNode{start, {ID: "review", Type: NodeTypeApproval, Name: "审批"}, end}, [2]string{"start", "review"}),
+			codes: []string{"/" + DiagnosticInvalidDefinition},
+		},
+		{
+			name: "多个开始节点",
+			req: diagnosticDefinition([]WorkflowNode{start, {ID: "start2", Type: NodeTypeStart, Name: "开始2"}, end},
+				[2]string{"start", "end"}, [2]string{"start2", "end"}),
+			codes: []string{"start2/" + DiagnosticMultipleStart},
+		},
+		{
+			name:  "不可达节点",
+			req:   diagnosticDefinition([]WorkflowNode{start, notifyNode("orphan"), end}, [2]string{"start", "end"}, [2]string{"orphan", "end"}),
+			codes: []string{"orphan/" + DiagnosticUnreachableNode},
+		},
+		{
+			name:  "非结束节点没有出边",
+			req:   diagnosticDefinition([]WorkflowNode{start, notifyNode("notify"), end}, [2]string{"start", "notify"}, [2]string{"start", "end"}),
+			codes: []string{"notify/" + DiagnosticDeadEnd},
+		},
+		{
+			name: "不经过审批的循环",
+			req: diagnosticDefinition([]WorkflowNode{start, notifyNode("a"), notifyNode("b"), end},
+				[2]string{"start", "a"}, [2]string{"a", "b"}, [2]string{"b", "a"}, [2]string{"b", "end"}),
+			codes: []string{"a/" + DiagnosticAutoLoop, "b/" + DiagnosticAutoLoop},
+		},
+		{
+			name: "经过审批的循环",
+			req: diagnosticDefinition([]WorkflowNode{start, notifyNode("a"), roleApproval("review", "hr"), end},
+				[2]string{"start", "a"}, [2]string{"a", "review"}, [2]string{"review", "a"}, [2]string{"review", "end"}),
+			codes: []string{},
+		},
+		{
+			name: "条件节点引用未声明的变量",
+			req: func() *CreateWorkflowRequest {
+				req := diagnosticDefinition([]WorkflowNode{start, {ID: "check", Type: NodeTypeCondition, Name: "金额判断", Config: map[string]interface{}{
+					"conditions":     []map[string]interface{}{{"expression": "amount > 1000", "target": "end"}},
+					"default_target": "end",
+				}}, end}, [2]string{"start", "check"}, [2]string{"check", "end"})
+				req.VariableSchema = []VariableDefinition{{Name: "reason", Type: VariableTypeString}}
+				return req
+			}(),
+			codes: []string{"check/" + DiagnosticUndeclaredVariable},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diagnostics := manager.DiagnoseWorkflow(ctx, tt.req)
+			assert.Equal(t, tt.codes, diagnosticCodes(diagnostics))
+			for _, diagnostic := range diagnostics {
+				assert.Equal(t, DiagnosticError, diagnostic.Severity)
+			}
+			// 有错误级别的诊断项时不能保存
+			assert.Equal(t, len(tt.codes) == 0, manager.ValidateWorkflow(ctx, tt.req) == nil)
+		})
+	}
+}
+
+func TestDiagnoseWorkflow_Warnings(t *testing.T) {
+	userRepo := &roleUserRepository{matches: map[string][]*repository.RoleUserMatch{
+		"hr": {{User: &database.User{BaseModel: database.BaseModel{ID: 7}}}},
+	}}
+	manager := NewWorkflowDefinitionManager(nil)
+	manager.SetUserRepository(userRepo)
+	ctx := context.Background()
+
+	req := diagnosticDefinition([]WorkflowNode{
+		{ID: "start", Type: NodeTypeStart, Name: "开始"},
+		{ID: "check", Type: NodeTypeCondition, Name: "金额判断", Config: map[string]interface{}{
+			"conditions":     []map[string]interface{}{{"expression": "amount > 1000", "target": "finance"}},
+			"default_target": "hr",
+		}},
+		roleApproval("finance", "finance"),
+		roleApproval("hr", "hr"),
+		{ID: "manager", Type: NodeTypeApproval, Name: "上级审批", Config: map[string]interface{}{
+			"assignees": []map[string]interface{}{{"type": "role", "value": "finance"}, {"type": "manager", "value": "direct"}},
+		}},
+		{ID: "end", Type: NodeTypeEnd, Name: "结束"},
+	}, [2]string{"start", "check"}, [2]string{"check", "finance"}, [2]string{"check", "hr"},
+		[2]string{"finance", "manager"}, [2]string{"hr", "manager"}, [2]string{"manager", "end"})
+
+	// 未声明变量的流程只对条件节点给出警告；只有空角色的审批节点给出警告，含运行时审批人的节点不检查
+	diagnostics := manager.DiagnoseWorkflow(ctx, req)
+	assert.Equal(t, []string{"check/" + DiagnosticUndeclaredVariable, "finance/" + DiagnosticEmptyAssignees}, diagnosticCodes(diagnostics))
+	for _, diagnostic := range diagnostics {
+		assert.Equal(t, DiagnosticWarning, diagnostic.Severity)
+	}
+	assert.Contains(t, diagnostics[1].Message, "角色finance")
+
+	// 警告不影响保存
+	require.NoError(t, manager.ValidateWorkflow(ctx, req))
+}
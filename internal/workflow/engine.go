@@ -2,6 +2,7 @@ package workflow
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -9,6 +10,7 @@ import (
 	"taskmanage/pkg/logger"
 
 	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
 )
 
 // WorkflowEngineImpl 审批流程引擎实现
@@ -17,6 +19,13 @@ type WorkflowEngineImpl struct {
 	instanceRepo               WorkflowInstanceRepository
 	taskExecutorRegistry       *ExecutorRegistry
 	onboardingExecutorRegistry *ExecutorRegistry
+	completionHandlers         *CompletionHandlerRegistry // 流程结束后的业务回调，为nil时不执行
+	approvalListener           ApprovalRequestListener    // 待审批提醒，为nil时不提醒
+	returnListener             ApprovalReturnListener     // 退回提醒，为nil时不提醒
+	mentionListener            ApprovalMentionListener    // 审批意见提及用户的通知，为nil时不通知
+	outcomeListener            ApprovalOutcomeListener    // 审批结果通知，为nil时不通知
+	attachmentResolver         ApprovalAttachmentResolver // 审批附件校验，为nil时审批意见不能附带附件
+	workCalendar               WorkCalendarProvider       // 流程最大持续时间按工作时间计算，为nil时按自然时间
 }
 
 // NewWorkflowEngine 创建流程引擎
@@ -36,7 +45,7 @@ func NewWorkflowEngine(
 
 // StartWorkflow 启动审批流程
 func (e *WorkflowEngineImpl) StartWorkflow(ctx context.Context, req *StartWorkflowRequest) (*WorkflowInstance, error) {
-	logger.Infof("启动流程: %s, 业务ID: %s", req.WorkflowID, req.BusinessID)
+	logger.FromContext(ctx).Infof("启动流程: %s, 业务ID: %s", req.WorkflowID, req.BusinessID)
 
 	// 获取流程定义
 	definition, err := e.definitionManager.GetWorkflow(ctx, req.WorkflowID)
@@ -48,81 +57,160 @@ func (e *WorkflowEngineImpl) StartWorkflow(ctx context.Context, req *StartWorkfl
 		return nil, fmt.Errorf("流程定义已停用")
 	}
 
+	// 按流程定义声明的变量校验、填充默认值并统一类型，校验失败时不创建实例
+	variables, err := applyVariableSchema(definition, req.Variables)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Exclusive {
+		if err := e.checkRunningInstance(ctx, req); err != nil {
+			return nil, err
+		}
+	}
+
 	// 创建流程实例
 	instance := &WorkflowInstance{
-		ID:           uuid.New().String(),
-		WorkflowID:   req.WorkflowID,
-		BusinessID:   req.BusinessID,
-		BusinessType: req.BusinessType,
-		Status:       StatusRunning,
-		CurrentNodes: []string{},
-		Variables:    req.Variables,
-		StartedBy:    req.StartedBy,
-		StartedAt:    time.Now(),
-		History:      []ExecutionHistory{},
+		ID:                  uuid.New().String(),
+		WorkflowID:          req.WorkflowID,
+		DefinitionVersionID: definition.VersionID, // 绑定当前版本，后续执行不受定义更新影响
+		BusinessID:          req.BusinessID,
+		BusinessType:        req.BusinessType,
+		Status:              StatusRunning,
+		CurrentNodes:        []string{},
+		Variables:           variables,
+		StartedBy:           req.StartedBy,
+		StartedAt:           time.Now(),
+		History:             []ExecutionHistory{},
 	}
 
 	if instance.Variables == nil {
 		instance.Variables = make(map[string]interface{})
 	}
-
-	// 保存实例
-	if err := e.instanceRepo.SaveInstance(ctx, instance); err != nil {
-		return nil, fmt.Errorf("保存流程实例失败: %w", err)
+	ctx = logger.ContextWithFields(ctx, logrus.Fields{"instance_id": instance.ID})
+	if definition.MaxDuration > 0 {
+		deadline := workingDeadline(ctx, e.workCalendar, instance.StartedAt, time.Duration(definition.MaxDuration)*time.Second)
+		instance.Deadline = &deadline
 	}
 
-	// 执行开始节点
 	startNode := definition.GetStartNode()
 	if startNode == nil {
 		return nil, fmt.Errorf("流程定义中未找到开始节点")
 	}
+	// 开始节点先记入活跃节点，进程在执行前中断时可由RecoverInstances补执行
+	instance.CurrentNodes = []string{startNode.ID}
 
+	// 保存实例
+	if err := e.instanceRepo.SaveInstance(ctx, instance); err != nil {
+		// 并发重复提交时运行中实例的唯一索引拒绝后写入的实例，改为返回先写入的实例
+		if req.Exclusive {
+			var running *RunningInstanceError
+			if checkErr := e.checkRunningInstance(ctx, req); errors.As(checkErr, &running) {
+				return nil, running
+			}
+		}
+		return nil, fmt.Errorf("保存流程实例失败: %w", err)
+	}
+
+	// 执行开始节点
 	if err := e.executeNode(ctx, instance, definition, startNode); err != nil {
-		logger.Errorf("执行开始节点失败: %v", err)
+		logger.FromContext(ctx).Errorf("执行开始节点失败: %v", err)
 		// 更新实例状态为失败
 		e.instanceRepo.UpdateInstanceStatus(ctx, instance.ID, StatusFailed)
 		return nil, fmt.Errorf("执行开始节点失败: %w", err)
 	}
 
+	// 审批节点全部自动通过时流程在启动时即结束，业务回调留待调用方保存业务记录后执行
+	if instance.Status == StatusRunning && e.isWorkflowCompleted(instance, definition) {
+		e.completeOnStart(ctx, instance)
+	}
+
 	// 重新获取更新后的实例
 	updatedInstance, err := e.instanceRepo.GetInstance(ctx, instance.ID)
 	if err != nil {
 		return instance, nil // 返回原实例，避免启动失败
 	}
 
-	logger.Infof("流程启动成功: %s", instance.ID)
+	logger.FromContext(ctx).Infof("流程启动成功: %s", instance.ID)
 	return updatedInstance, nil
 }
 
 // ProcessApproval 处理审批决策
+// 审批决策的应用和节点流转在实例锁内串行执行，多个审批人同时处理同一节点时，
+// 节点已由先提交的一方处理出结果，后提交的一方返回ErrApprovalAlreadyProcessed
 func (e *WorkflowEngineImpl) ProcessApproval(ctx context.Context, req *ApprovalRequest) (*ApprovalResult, error) {
-	logger.Infof("处理审批: 实例=%s, 节点=%s, 动作=%s", req.InstanceID, req.NodeID, req.Action)
+	ctx = logger.ContextWithFields(ctx, logrus.Fields{"instance_id": req.InstanceID, "node_id": req.NodeID})
+	logger.FromContext(ctx).Infof("处理审批: 实例=%s, 节点=%s, 动作=%s", req.InstanceID, req.NodeID, req.Action)
+
+	var (
+		result   *ApprovalResult
+		instance *WorkflowInstance
+		approved bool
+	)
+	err := e.instanceRepo.LockInstance(ctx, req.InstanceID, func(ctx context.Context) error {
+		var err error
+		result, instance, approved, err = e.applyApproval(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 流程结束后执行业务回调，以最终审批节点的结果为准
+	if result.IsCompleted {
+		e.completeBusiness(ctx, instance, approved, req.ApprovedBy)
+	}
 
+	logger.FromContext(ctx).Infof("审批处理完成: %s", result.Message)
+	return result, nil
+}
+
+// applyApproval 应用审批决策并流转到后续节点，返回审批结果、更新后的实例和流程是否以通过结束
+func (e *WorkflowEngineImpl) applyApproval(ctx context.Context, req *ApprovalRequest) (*ApprovalResult, *WorkflowInstance, bool, error) {
 	// 获取流程实例
 	instance, err := e.instanceRepo.GetInstance(ctx, req.InstanceID)
 	if err != nil {
-		return nil, fmt.Errorf("获取流程实例失败: %w", err)
+		return nil, nil, false, fmt.Errorf("获取流程实例失败: %w", err)
 	}
 
 	if instance.Status != StatusRunning {
-		return nil, fmt.Errorf("流程实例状态不正确: %s", instance.Status)
+		if instance.Approvals[req.NodeID].decidedByOthers(req.ApprovedBy) {
+			return nil, nil, false, ErrApprovalAlreadyProcessed
+		}
+		return nil, nil, false, fmt.Errorf("流程实例状态不正确: %s", instance.Status)
 	}
 
-	// 获取流程定义
-	definition, err := e.definitionManager.GetWorkflow(ctx, instance.WorkflowID)
+	// 获取实例绑定的流程定义版本
+	definition, err := e.definitionManager.GetWorkflowForInstance(ctx, instance)
 	if err != nil {
-		return nil, fmt.Errorf("获取流程定义失败: %w", err)
+		return nil, nil, false, fmt.Errorf("获取流程定义失败: %w", err)
 	}
 
 	// 查找当前节点
 	currentNode := e.findNodeByID(definition, req.NodeID)
 	if currentNode == nil {
-		return nil, fmt.Errorf("未找到节点: %s", req.NodeID)
+		return nil, nil, false, fmt.Errorf("未找到节点: %s", req.NodeID)
 	}
 
 	// 验证节点是否在当前活跃节点中
 	if !e.isNodeActive(instance, req.NodeID) {
-		return nil, fmt.Errorf("节点不在活跃状态: %s", req.NodeID)
+		if instance.Approvals[req.NodeID].decidedByOthers(req.ApprovedBy) {
+			return nil, nil, false, ErrApprovalAlreadyProcessed
+		}
+		return nil, nil, false, fmt.Errorf("节点不在活跃状态: %s", req.NodeID)
+	}
+
+	// 退回需节点允许且目标合法；重新提交只能由发起人在被退回的开始节点进行
+	var returnTarget *WorkflowNode
+	switch req.Action {
+	case ActionReturn:
+		if returnTarget, err = e.resolveReturnTarget(instance, definition, currentNode, req); err != nil {
+			return nil, nil, false, err
+		}
+	case ActionResubmit:
+		if err := e.checkResubmission(instance, currentNode, req.ApprovedBy); err != nil {
+			return nil, nil, false, err
+		}
 	}
 
 	// 记录审批历史
@@ -139,8 +227,15 @@ func (e *WorkflowEngineImpl) ProcessApproval(ctx context.Context, req *ApprovalR
 		Duration:   0, // 审批节点持续时间需要单独计算
 	}
 
-	if err := e.instanceRepo.AddExecutionHistory(ctx, req.InstanceID, history); err != nil {
-		logger.Errorf("添加执行历史失败: %v", err)
+	if err := e.prepareApprovalComment(ctx, instance, currentNode, req, &history); err != nil {
+		return nil, nil, false, err
+	}
+
+	if returnTarget != nil {
+		history.Variables = map[string]interface{}{"return_to": returnTarget.ID}
+		for k, v := range req.Variables {
+			history.Variables[k] = v
+		}
 	}
 
 	// 更新实例变量
@@ -150,44 +245,93 @@ func (e *WorkflowEngineImpl) ProcessApproval(ctx context.Context, req *ApprovalR
 		}
 	}
 
+	// 记录审批人决策：会签（all）和多数（majority）审批未出结果时节点保持活跃，等待其他审批人
+	outcome, err := e.recordApproval(instance, req, history.ExecutedAt)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
 	// 处理审批结果
 	var nextNodes []string
 	var isCompleted bool
 	var message string
+	var awaitResubmission bool
+	change := PendingApprovalChange{NodeID: req.NodeID}
 
-	switch req.Action {
-	case ActionApprove:
+	switch outcome {
+	case DecisionApproved:
 		// 审批通过，选择 approved 分支
 		nextNodes = e.getNextNodesByCondition(definition, req.NodeID, "approved")
 		message = "审批通过"
-	case ActionReject:
+	case DecisionRejected:
 		// 审批拒绝，选择 rejected 分支
 		nextNodes = e.getNextNodesByCondition(definition, req.NodeID, "rejected")
 		message = "审批拒绝"
-	case ActionReturn:
-		// 退回到上一个节点
-		previousNodes := e.getPreviousNodes(definition, req.NodeID)
-		nextNodes = previousNodes
-		message = "审批退回"
-	case ActionDelegate:
-		// 委托给其他人，节点状态不变
+	case DecisionReturned:
+		// 退回到目标节点：审批节点重新生成待审批记录，开始节点等待发起人补充材料后重新提交
+		nextNodes = []string{returnTarget.ID}
+		awaitResubmission = returnTarget.Type == NodeTypeStart
+		message = "审批退回到" + returnTarget.Name
+	case DecisionResubmitted:
+		// 发起人重新提交，从开始节点继续流转
 		nextNodes = []string{req.NodeID}
-		message = "审批已委托"
+		message = "已重新提交"
 	default:
-		return nil, fmt.Errorf("不支持的审批动作: %s", req.Action)
+		// 节点尚未出结果，只关闭当前审批人的待审批记录
+		change.CompleteFor = []uint{req.ApprovedBy}
+		if req.Action == ActionDelegate {
+			// 委托：为受托人创建待审批记录，其表决计入原审批人
+			change.Create = []*PendingApproval{e.newDelegatedApproval(instance, definition, currentNode, req.DelegateTo)}
+			message = "审批已委托"
+		} else {
+			message = "已记录审批意见，等待其他审批人"
+		}
+		if state := instance.Approvals[req.NodeID]; state != nil {
+			message += fmt.Sprintf("（%s）", state.Summary())
+		}
 	}
 
-	// 更新当前活跃节点
-	instance.CurrentNodes = e.removeNode(instance.CurrentNodes, req.NodeID)
-	instance.CurrentNodes = append(instance.CurrentNodes, nextNodes...)
+	if outcome == DecisionReturned {
+		// 退回时关闭实例全部未完成的待审批记录，包括并行分支，活跃节点重置为退回目标
+		change.CompleteAll = true
+		change.CompleteInstance = true
+		instance.CurrentNodes = []string{returnTarget.ID}
+		if awaitResubmission {
+			change.Create = []*PendingApproval{e.newResubmission(instance, definition, returnTarget)}
+		}
+	} else if outcome != DecisionPending {
+		// 节点已出结果，关闭全部待审批记录并流转到后续节点
+		change.CompleteAll = true
+		instance.CurrentNodes = e.removeNode(instance.CurrentNodes, req.NodeID)
+		instance.CurrentNodes = append(instance.CurrentNodes, nextNodes...)
+	}
+
+	// 审批历史、待审批记录变更与节点流转一并提交
+	if err := e.instanceRepo.SaveApprovalResult(ctx, instance, history, change); err != nil {
+		logger.FromContext(ctx).Errorf("保存审批结果失败: %v", err)
+		return nil, nil, false, fmt.Errorf("保存审批结果失败: %w", err)
+	}
+	if outcome == DecisionReturned {
+		e.notifyApprovalReturned(ctx, instance, &ApprovalReturn{
+			NodeID:         req.NodeID,
+			NodeName:       currentNode.Name,
+			TargetNodeID:   returnTarget.ID,
+			TargetNodeName: returnTarget.Name,
+			ReturnedBy:     req.ApprovedBy,
+			Comment:        req.Comment,
+		})
+	} else {
+		e.notifyApprovalsRequested(ctx, instance, change.Create)
+	}
+	e.notifyApprovalMentioned(ctx, instance, currentNode, &history)
 
-	// 执行下一个节点
-	if len(nextNodes) > 0 && !isCompleted {
+	// 执行下一个节点，退回到开始节点时等待发起人重新提交
+	if len(nextNodes) > 0 && !isCompleted && !awaitResubmission {
 		for _, nodeID := range nextNodes {
 			nextNode := e.findNodeByID(definition, nodeID)
 			if nextNode != nil {
 				if err := e.executeNode(ctx, instance, definition, nextNode); err != nil {
-					logger.Errorf("执行下一个节点失败: %s, error: %v", nodeID, err)
+					logger.FromContext(ctx).Errorf("执行下一个节点失败: %s, error: %v", nodeID, err)
 				}
 			}
 		}
@@ -204,8 +348,9 @@ func (e *WorkflowEngineImpl) ProcessApproval(ctx context.Context, req *ApprovalR
 
 	// 保存更新后的实例
 	if err := e.instanceRepo.UpdateInstance(ctx, instance); err != nil {
-		logger.Errorf("更新流程实例失败: %v", err)
+		logger.FromContext(ctx).Errorf("更新流程实例失败: %v", err)
 	}
+	e.notifyApprovalResolved(ctx, instance, currentNode, outcome, &history)
 
 	result := &ApprovalResult{
 		InstanceID:  req.InstanceID,
@@ -215,25 +360,26 @@ func (e *WorkflowEngineImpl) ProcessApproval(ctx context.Context, req *ApprovalR
 		IsCompleted: isCompleted,
 		Message:     message,
 		ExecutedAt:  history.ExecutedAt,
+		Approval:    instance.Approvals[req.NodeID],
 	}
 
-	logger.Infof("审批处理完成: %s", message)
-	return result, nil
+	return result, instance, outcome == DecisionApproved || outcome == DecisionResubmitted, nil
 }
 
 // GetWorkflowInstance 获取流程实例
 func (e *WorkflowEngineImpl) GetWorkflowInstance(ctx context.Context, instanceID string) (*WorkflowInstance, error) {
-	return e.instanceRepo.GetInstance(ctx, instanceID)
-}
-
-// GetPendingApprovals 获取待审批任务
-func (e *WorkflowEngineImpl) GetPendingApprovals(ctx context.Context, userID uint) ([]*PendingApproval, error) {
-	return e.instanceRepo.GetPendingApprovals(ctx, userID)
+	instance, err := e.instanceRepo.GetInstance(ctx, instanceID)
+	if err != nil {
+		return nil, err
+	}
+	instance.setRemainingTime(time.Now())
+	return instance, nil
 }
 
 // CancelWorkflow 取消流程
 func (e *WorkflowEngineImpl) CancelWorkflow(ctx context.Context, instanceID string, reason string) error {
-	logger.Infof("取消流程: %s, 原因: %s", instanceID, reason)
+	ctx = logger.ContextWithFields(ctx, logrus.Fields{"instance_id": instanceID})
+	logger.FromContext(ctx).Infof("取消流程: %s, 原因: %s", instanceID, reason)
 
 	instance, err := e.instanceRepo.GetInstance(ctx, instanceID)
 	if err != nil {
@@ -257,36 +403,32 @@ func (e *WorkflowEngineImpl) CancelWorkflow(ctx context.Context, instanceID stri
 		Duration:   0,
 	}
 
-	if err := e.instanceRepo.AddExecutionHistory(ctx, instanceID, history); err != nil {
-		logger.Errorf("添加取消历史失败: %v", err)
-	}
-
-	// 更新实例状态
-	if err := e.instanceRepo.UpdateInstanceStatus(ctx, instanceID, StatusCancelled); err != nil {
+	// 取消历史和实例状态在同一事务中写入
+	instance.Status = StatusCancelled
+	if err := e.instanceRepo.CancelInstance(ctx, instance, history); err != nil {
+		if errors.Is(err, repository.ErrConcurrentUpdate) {
+			return fmt.Errorf("只能取消运行中的流程")
+		}
 		return fmt.Errorf("更新实例状态失败: %w", err)
 	}
 
-	logger.Infof("流程取消成功: %s", instanceID)
+	logger.FromContext(ctx).Infof("流程取消成功: %s", instanceID)
 	return nil
 }
 
 // executeNode 执行节点
 func (e *WorkflowEngineImpl) executeNode(ctx context.Context, instance *WorkflowInstance, definition *WorkflowDefinition, node *WorkflowNode) error {
-	logger.Infof("执行节点: %s (%s)", node.ID, node.Type)
+	// 节点执行期间的日志（含执行器）都带上实例和节点ID
+	ctx = logger.ContextWithFields(ctx, logrus.Fields{"instance_id": instance.ID, "node_id": node.ID})
+	logger.FromContext(ctx).Infof("执行节点: %s (%s)", node.ID, node.Type)
 
 	startTime := time.Now()
 
 	// 根据业务类型获取正确的执行器注册表
 	registry := e.getExecutorRegistry(instance.BusinessType)
 
-	// 获取节点执行器
-	executor, err := registry.GetExecutor(node.Type)
-	if err != nil {
-		return fmt.Errorf("获取节点执行器失败: %w", err)
-	}
-
 	// 执行节点
-	result, err := executor.ExecuteWithDefinition(ctx, instance, node, definition)
+	result, err := registry.Execute(ctx, instance, node, definition)
 	if err != nil {
 		return fmt.Errorf("节点执行失败: %w", err)
 	}
@@ -307,8 +449,29 @@ func (e *WorkflowEngineImpl) executeNode(ctx context.Context, instance *Workflow
 		Duration:   duration,
 	}
 
-	if err := e.instanceRepo.AddExecutionHistory(ctx, instance.ID, history); err != nil {
-		logger.Errorf("添加执行历史失败: %v", err)
+	// 自动审批以系统身份记录审批通过，历史中注明触发原因，便于审计区分人工审批
+	if result.AutoApproveReason != "" {
+		history.Action = string(ActionApprove)
+		history.Result = e.getApprovalResultString(ActionApprove)
+		history.Comment = "系统自动审批：" + result.AutoApproveReason
+		history.Variables = map[string]interface{}{"auto_approved": true, "auto_approve_reason": result.AutoApproveReason}
+		for k, v := range result.Variables {
+			history.Variables[k] = v
+		}
+		history.ExecutedBy = 0 // 系统操作
+		result.NextNodes = e.getNextNodesByCondition(definition, node.ID, "approved")
+		result.WaitForUser = false
+	}
+
+	// 节点执行失败时终止流程，失败原因已记录在执行历史中
+	if !result.Success {
+		logger.FromContext(ctx).Errorf("节点执行失败，流程终止: %s, %s", node.ID, result.Message)
+		instance.Status = StatusFailed
+		if err := e.instanceRepo.SaveNodeExecutionResult(ctx, instance, history, nil); err != nil {
+			logger.FromContext(ctx).Errorf("保存节点执行结果失败: %v", err)
+			return fmt.Errorf("保存节点执行结果失败: %w", err)
+		}
+		return nil
 	}
 
 	// 更新实例变量
@@ -318,35 +481,89 @@ func (e *WorkflowEngineImpl) executeNode(ctx context.Context, instance *Workflow
 		}
 	}
 
+	// 审批节点（重新）进入时重建逐人决策记录
+	if result.ApprovalState != nil {
+		if instance.Approvals == nil {
+			instance.Approvals = make(map[string]*NodeApprovalState)
+		}
+		instance.Approvals[node.ID] = result.ApprovalState
+	}
+
 	// 更新当前活跃节点
 	if !result.WaitForUser {
 		// 移除当前节点，添加下一个节点
 		instance.CurrentNodes = e.removeNode(instance.CurrentNodes, node.ID)
 		instance.CurrentNodes = append(instance.CurrentNodes, result.NextNodes...)
+	} else if !e.containsNode(instance.CurrentNodes, node.ID) {
+		// 需要等待用户操作，添加到活跃节点
+		instance.CurrentNodes = append(instance.CurrentNodes, node.ID)
+	}
+
+	// 待审批记录、执行历史与CurrentNodes、Variables一并提交，
+	// 进程中断时要么整个节点未生效，要么节点结果完整落库，不会留下孤立的待审批记录
+	if err := e.instanceRepo.SaveNodeExecutionResult(ctx, instance, history, result.PendingApprovals); err != nil {
+		logger.FromContext(ctx).Errorf("保存节点执行结果失败: %v", err)
+		return fmt.Errorf("保存节点执行结果失败: %w", err)
+	}
+	e.notifyApprovalsRequested(ctx, instance, result.PendingApprovals)
 
-		// 继续执行下一个节点
+	// 继续执行下一个节点，每个节点各自提交；中断时已进入CurrentNodes的节点由RecoverInstances补执行
+	if !result.WaitForUser {
 		for _, nextNodeID := range result.NextNodes {
 			nextNode := e.findNodeByID(definition, nextNodeID)
 			if nextNode != nil {
 				if err := e.executeNode(ctx, instance, definition, nextNode); err != nil {
-					logger.Errorf("执行下一个节点失败: %s, error: %v", nextNodeID, err)
+					logger.FromContext(ctx).Errorf("执行下一个节点失败: %s, error: %v", nextNodeID, err)
 				}
 			}
 		}
-	} else {
-		// 需要等待用户操作，添加到活跃节点
-		if !e.containsNode(instance.CurrentNodes, node.ID) {
-			instance.CurrentNodes = append(instance.CurrentNodes, node.ID)
-		}
 	}
 
-	// 更新实例的CurrentNodes和Variables到数据库
-	if err := e.instanceRepo.UpdateInstance(ctx, instance); err != nil {
-		logger.Errorf("更新实例失败: %v", err)
-		return fmt.Errorf("更新实例失败: %w", err)
+	return nil
+}
+
+// recordApproval 将审批动作记入节点的决策记录并返回节点审批结果
+// 没有决策记录的实例（按任意一人审批启动的旧实例）由第一个决策直接决定结果
+func (e *WorkflowEngineImpl) recordApproval(instance *WorkflowInstance, req *ApprovalRequest, at time.Time) (ApprovalDecision, error) {
+	if req.Action == ActionResubmit {
+		return DecisionResubmitted, nil
+	}
+	if state := instance.Approvals[req.NodeID]; state != nil {
+		return state.Record(req.ApprovedBy, req.Action, req.DelegateTo, req.Comment, at)
 	}
 
-	return nil
+	switch req.Action {
+	case ActionApprove:
+		return DecisionApproved, nil
+	case ActionReject:
+		return DecisionRejected, nil
+	case ActionReturn:
+		return DecisionReturned, nil
+	case ActionDelegate:
+		if req.DelegateTo == 0 || req.DelegateTo == req.ApprovedBy {
+			return "", fmt.Errorf("无效的委托对象: %d", req.DelegateTo)
+		}
+		return DecisionPending, nil
+	default:
+		return "", fmt.Errorf("不支持的审批动作: %s", req.Action)
+	}
+}
+
+// newDelegatedApproval 为受托人创建待审批记录
+func (e *WorkflowEngineImpl) newDelegatedApproval(instance *WorkflowInstance, definition *WorkflowDefinition, node *WorkflowNode, delegateTo uint) *PendingApproval {
+	return &PendingApproval{
+		InstanceID:     instance.ID,
+		WorkflowName:   definition.Name,
+		NodeID:         node.ID,
+		NodeName:       node.Name,
+		BusinessID:     instance.BusinessID,
+		BusinessType:   instance.BusinessType,
+		BusinessData:   instance.Variables,
+		AssignedTo:     delegateTo,
+		CreatedAt:      time.Now(),
+		CanDelegate:    false,
+		RequiredAction: []ApprovalAction{ActionApprove, ActionReject},
+	}
 }
 
 // 辅助方法
@@ -455,6 +672,8 @@ func (e *WorkflowEngineImpl) getApprovalResultString(action ApprovalAction) stri
 		return "returned"
 	case ActionDelegate:
 		return "delegated"
+	case ActionResubmit:
+		return "resubmitted"
 	default:
 		return "unknown"
 	}
@@ -0,0 +1,35 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrInstanceAlreadyRunning 业务对象已有运行中的流程实例
+var ErrInstanceAlreadyRunning = errors.New("业务对象已有运行中的审批流程")
+
+// RunningInstanceError 重复启动独占流程时返回，携带已在运行的实例
+type RunningInstanceError struct {
+	Instance *WorkflowInstance
+}
+
+func (e *RunningInstanceError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrInstanceAlreadyRunning.Error(), e.Instance.ID)
+}
+
+func (e *RunningInstanceError) Unwrap() error {
+	return ErrInstanceAlreadyRunning
+}
+
+// checkRunningInstance 独占流程启动前检查业务对象是否已有运行中的实例
+func (e *WorkflowEngineImpl) checkRunningInstance(ctx context.Context, req *StartWorkflowRequest) error {
+	running, err := e.instanceRepo.FindRunningByBusiness(ctx, req.BusinessType, req.BusinessID)
+	if err != nil {
+		return fmt.Errorf("查询运行中的流程实例失败: %w", err)
+	}
+	if running != nil {
+		return &RunningInstanceError{Instance: running}
+	}
+	return nil
+}
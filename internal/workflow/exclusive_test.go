@@ -0,0 +1,172 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// exclusiveInstanceRepository 按(business_type, business_id)约束运行中实例唯一的内存实例仓储，模拟数据库唯一索引
+type exclusiveInstanceRepository struct {
+	WorkflowInstanceRepository
+	mu        sync.Mutex
+	instances map[string]*WorkflowInstance
+	// checked 前两次运行中实例查询都到达后才返回，使两个并发请求都通过启动前检查
+	checked sync.WaitGroup
+	checks  int
+}
+
+func newExclusiveInstanceRepository() *exclusiveInstanceRepository {
+	r := &exclusiveInstanceRepository{instances: make(map[string]*WorkflowInstance)}
+	r.checked.Add(2)
+	return r
+}
+
+func (r *exclusiveInstanceRepository) FindRunningByBusiness(ctx context.Context, businessType, businessID string) (*WorkflowInstance, error) {
+	r.mu.Lock()
+	r.checks++
+	first := r.checks <= 2
+	r.mu.Unlock()
+	if first {
+		r.checked.Done()
+		r.checked.Wait()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.findRunning(businessType, businessID), nil
+}
+
+func (r *exclusiveInstanceRepository) findRunning(businessType, businessID string) *WorkflowInstance {
+	for _, instance := range r.instances {
+		if instance.BusinessType == businessType && instance.BusinessID == businessID && instance.Status == StatusRunning {
+			copied := *instance
+			return &copied
+		}
+	}
+	return nil
+}
+
+func (r *exclusiveInstanceRepository) SaveInstance(ctx context.Context, instance *WorkflowInstance) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if instance.Status == StatusRunning && r.findRunning(instance.BusinessType, instance.BusinessID) != nil {
+		return errors.New("Duplicate entry for key 'idx_workflow_instances_running_business'")
+	}
+	copied := *instance
+	r.instances[instance.ID] = &copied
+	return nil
+}
+
+func (r *exclusiveInstanceRepository) GetInstance(ctx context.Context, instanceID string) (*WorkflowInstance, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *r.instances[instanceID]
+	return &copied, nil
+}
+
+func (r *exclusiveInstanceRepository) SaveNodeExecutionResult(ctx context.Context, instance *WorkflowInstance, history ExecutionHistory, approvals []*PendingApproval) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *instance
+	r.instances[instance.ID] = &copied
+	return nil
+}
+
+func (r *exclusiveInstanceRepository) setStatus(instanceID string, status InstanceStatus) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.instances[instanceID].Status = status
+}
+
+// startWorkflowRepository 按流程ID返回固定流程定义的仓储桩
+type startWorkflowRepository struct {
+	WorkflowRepository
+	definition *WorkflowDefinition
+}
+
+func (r *startWorkflowRepository) GetWorkflowDefinition(ctx context.Context, workflowID string) (*WorkflowDefinition, error) {
+	return r.definition, nil
+}
+
+func TestStartWorkflow_ExclusiveDuplicateSubmit(t *testing.T) {
+	definition := &WorkflowDefinition{
+		ID:        "task-approval",
+		VersionID: 1,
+		IsActive:  true,
+		Nodes: []WorkflowNode{
+			{ID: "start", Type: NodeTypeStart, Name: "开始"},
+			{ID: "approve", Type: NodeTypeApproval, Name: "主管审批", Config: map[string]interface{}{
+				"assignees": []map[string]interface{}{{"type": "starter"}},
+			}},
+		},
+		Edges: []WorkflowEdge{{ID: "e1", From: "start", To: "approve"}},
+	}
+	repo := newExclusiveInstanceRepository()
+	engine := &WorkflowEngineImpl{
+		definitionManager:    NewWorkflowDefinitionManager(&startWorkflowRepository{definition: definition}),
+		instanceRepo:         repo,
+		taskExecutorRegistry: NewExecutorRegistry(repo, nil, nil),
+	}
+	newRequest := func() *StartWorkflowRequest {
+		return &StartWorkflowRequest{
+			WorkflowID:   "task-approval",
+			BusinessID:   "task_7",
+			BusinessType: "task_assignment",
+			StartedBy:    9,
+			Exclusive:    true,
+		}
+	}
+
+	// 两个请求同时通过启动前检查，唯一约束拒绝后写入的实例，后者拿到先写入的实例
+	var wg sync.WaitGroup
+	instances := make([]*WorkflowInstance, 2)
+	errs := make([]error, 2)
+	for i := range instances {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			instances[i], errs[i] = engine.StartWorkflow(context.Background(), newRequest())
+		}(i)
+	}
+	wg.Wait()
+
+	var started *WorkflowInstance
+	var running *RunningInstanceError
+	for i := range instances {
+		if errs[i] == nil {
+			require.Nil(t, started, "只能有一个请求启动流程")
+			started = instances[i]
+			continue
+		}
+		require.ErrorAs(t, errs[i], &running)
+	}
+	require.NotNil(t, started)
+	require.NotNil(t, running)
+	assert.Equal(t, started.ID, running.Instance.ID)
+	assert.ErrorIs(t, running, ErrInstanceAlreadyRunning)
+	assert.Len(t, repo.instances, 1)
+
+	// 之后的重复提交在启动前检查时即返回运行中的实例
+	_, err := engine.StartWorkflow(context.Background(), newRequest())
+	require.ErrorAs(t, err, &running)
+	assert.Equal(t, started.ID, running.Instance.ID)
+	assert.Len(t, repo.instances, 1)
+
+	// 流程结束后可以重新提交
+	repo.setStatus(started.ID, StatusCompleted)
+	restarted, err := engine.StartWorkflow(context.Background(), newRequest())
+	require.NoError(t, err)
+	assert.NotEqual(t, started.ID, restarted.ID)
+
+	// 非独占启动不做检查
+	request := newRequest()
+	request.Exclusive = false
+	request.BusinessID = "task_8"
+	_, err = engine.StartWorkflow(context.Background(), request)
+	require.NoError(t, err)
+}
@@ -4,8 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"taskmanage/internal/repository"
@@ -72,6 +75,42 @@ func (r *ExecutorRegistry) GetExecutor(nodeType NodeType) (NodeExecutor, error)
 	return executor, nil
 }
 
+// ExecutionObserver 节点执行观察者，用于采集执行指标而不让指标实现依赖工作流包
+type ExecutionObserver interface {
+	ObserveNodeExecution(workflowID, nodeType string, success bool)
+}
+
+var (
+	executionObserverMu sync.RWMutex
+	executionObserver   ExecutionObserver
+)
+
+// SetExecutionObserver 设置全局节点执行观察者，传nil取消观察
+func SetExecutionObserver(observer ExecutionObserver) {
+	executionObserverMu.Lock()
+	defer executionObserverMu.Unlock()
+	executionObserver = observer
+}
+
+// Execute 执行节点并通知执行观察者
+func (r *ExecutorRegistry) Execute(ctx context.Context, instance *WorkflowInstance, node *WorkflowNode, definition *WorkflowDefinition) (*NodeExecutionResult, error) {
+	executor, err := r.GetExecutor(node.Type)
+	if err != nil {
+		return nil, fmt.Errorf("获取节点执行器失败: %w", err)
+	}
+
+	result, err := executor.ExecuteWithDefinition(ctx, instance, node, definition)
+
+	executionObserverMu.RLock()
+	observer := executionObserver
+	executionObserverMu.RUnlock()
+	if observer != nil {
+		observer.ObserveNodeExecution(instance.WorkflowID, string(node.Type), err == nil && result != nil && result.Success)
+	}
+
+	return result, err
+}
+
 // GetNextNodes 从工作流定义中获取下一个节点
 func (r *ExecutorRegistry) GetNextNodes(definition *WorkflowDefinition, nodeID string) []string {
 	var nextNodes []string
@@ -93,7 +132,7 @@ func (e *StartNodeExecutor) Execute(ctx context.Context, instance *WorkflowInsta
 }
 
 func (e *StartNodeExecutor) ExecuteWithDefinition(ctx context.Context, instance *WorkflowInstance, node *WorkflowNode, definition *WorkflowDefinition) (*NodeExecutionResult, error) {
-	logger.Infof("执行开始节点: %s", node.ID)
+	logger.FromContext(ctx).Infof("执行开始节点: %s", node.ID)
 
 	var nextNodes []string
 	if definition != nil && e.registry != nil {
@@ -130,7 +169,7 @@ func (e *EndNodeExecutor) Execute(ctx context.Context, instance *WorkflowInstanc
 }
 
 func (e *EndNodeExecutor) ExecuteWithDefinition(ctx context.Context, instance *WorkflowInstance, node *WorkflowNode, definition *WorkflowDefinition) (*NodeExecutionResult, error) {
-	logger.Infof("执行结束节点: %s", node.ID)
+	logger.FromContext(ctx).Infof("执行结束节点: %s", node.ID)
 
 	return &NodeExecutionResult{
 		Success:     true,
@@ -147,16 +186,22 @@ func (e *EndNodeExecutor) GetSupportedNodeType() NodeType {
 
 // ApprovalNodeExecutor 任务分配审批节点执行器
 type ApprovalNodeExecutor struct {
-	instanceRepo WorkflowInstanceRepository
-	employeeRepo repository.EmployeeRepository
-	userRepo     repository.UserRepository
+	instanceRepo   WorkflowInstanceRepository
+	employeeRepo   repository.EmployeeRepository
+	userRepo       repository.UserRepository
+	chainProvider  ApprovalChainProvider           // 部门审批链来源，为nil时只使用节点审批人
+	departmentRepo repository.DepartmentRepository // 部门负责人来源，为nil时department_manager只按直属上级解析
+	workCalendar   WorkCalendarProvider            // 审批时限按工作时间计算，为nil时按自然时间
 }
 
 // OnboardingApprovalNodeExecutor 入职审批节点执行器
 type OnboardingApprovalNodeExecutor struct {
-	instanceRepo WorkflowInstanceRepository
-	employeeRepo repository.EmployeeRepository
-	userRepo     repository.UserRepository
+	instanceRepo   WorkflowInstanceRepository
+	employeeRepo   repository.EmployeeRepository
+	userRepo       repository.UserRepository
+	chainProvider  ApprovalChainProvider           // 部门审批链来源，为nil时只使用节点审批人
+	departmentRepo repository.DepartmentRepository // 部门负责人来源，为nil时department_manager只按直属上级解析
+	workCalendar   WorkCalendarProvider            // 审批时限按工作时间计算，为nil时按自然时间
 }
 
 func (e *ApprovalNodeExecutor) Execute(ctx context.Context, instance *WorkflowInstance, node *WorkflowNode) (*NodeExecutionResult, error) {
@@ -164,28 +209,48 @@ func (e *ApprovalNodeExecutor) Execute(ctx context.Context, instance *WorkflowIn
 }
 
 func (e *ApprovalNodeExecutor) ExecuteWithDefinition(ctx context.Context, instance *WorkflowInstance, node *WorkflowNode, definition *WorkflowDefinition) (*NodeExecutionResult, error) {
-	logger.Infof("执行任务分配审批节点: %s", node.ID)
+	logger.FromContext(ctx).Infof("执行任务分配审批节点: %s", node.ID)
 
 	// 解析审批节点配置
 	config, err := e.parseApprovalConfig(node)
 	if err != nil {
-		logger.Errorf("解析审批节点配置失败: %v", err)
+		logger.FromContext(ctx).Errorf("解析审批节点配置失败: %v", err)
 		return nil, fmt.Errorf("解析审批节点配置失败: %w", err)
 	}
 
 	// 解析审批人
-	assignees, err := e.resolveAssignees(ctx, instance, config.Assignees)
+	assigneeConfigs, err := e.nodeAssignees(ctx, instance, node, config)
+	if err != nil {
+		logger.FromContext(ctx).Errorf("获取审批人配置失败: %v", err)
+		return nil, fmt.Errorf("获取审批人配置失败: %w", err)
+	}
+	assignees, err := e.resolveAssignees(ctx, instance, assigneeConfigs)
 	if err != nil {
-		logger.Errorf("解析审批人失败: %v", err)
+		logger.FromContext(ctx).Errorf("解析审批人失败: %v", err)
 		return nil, fmt.Errorf("解析审批人失败: %w", err)
 	}
 
 	if len(assignees) == 0 {
-		logger.Errorf("未找到有效的审批人")
+		logger.FromContext(ctx).Errorf("未找到有效的审批人")
 		return nil, fmt.Errorf("未找到有效的审批人")
 	}
 
-	// 创建任务分配待审批记录
+	// 满足自动审批规则时不创建待审批记录，由引擎以系统身份记录审批通过
+	if reason := config.autoApproveReason(instance, assignees); reason != "" {
+		logger.FromContext(ctx).Infof("任务分配审批节点自动审批: 实例=%s, 节点=%s, 原因=%s", instance.ID, node.ID, reason)
+		return &NodeExecutionResult{
+			Success: true,
+			Variables: map[string]interface{}{
+				"assignees":     assignees,
+				"approval_type": config.ApprovalType,
+			},
+			Message:           reason,
+			AutoApproveReason: reason,
+		}, nil
+	}
+
+	// 创建任务分配待审批记录，由引擎与实例状态一并写入
+	var approvals []*PendingApproval
 	for _, assigneeID := range assignees {
 		pendingApproval := &PendingApproval{
 			InstanceID:     instance.ID,
@@ -199,20 +264,16 @@ func (e *ApprovalNodeExecutor) ExecuteWithDefinition(ctx context.Context, instan
 			AssignedTo:     assigneeID,
 			CreatedAt:      time.Now(),
 			CanDelegate:    config.CanDelegate,
-			RequiredAction: []ApprovalAction{ActionApprove, ActionReject},
+			RequiredAction: config.requiredActions(),
 		}
 
 		if config.Deadline != nil {
-			deadline := time.Now().Add(*config.Deadline)
+			deadline := workingDeadline(ctx, e.workCalendar, pendingApproval.CreatedAt, *config.Deadline)
 			pendingApproval.Deadline = &deadline
 		}
 
-		if err := e.instanceRepo.SavePendingApproval(ctx, pendingApproval); err != nil {
-			logger.Errorf("保存待审批记录失败: %v", err)
-			return nil, fmt.Errorf("保存待审批记录失败: %w", err)
-		}
-		
-		logger.Infof("成功创建任务分配待审批记录: InstanceID=%s, NodeID=%s, AssignedTo=%d", instance.ID, node.ID, assigneeID)
+		approvals = append(approvals, pendingApproval)
+		logger.FromContext(ctx).Infof("创建任务分配待审批记录: InstanceID=%s, NodeID=%s, AssignedTo=%d", instance.ID, node.ID, assigneeID)
 	}
 
 	// 为相关用户创建查看记录（请求者和被分配者）
@@ -224,10 +285,8 @@ func (e *ApprovalNodeExecutor) ExecuteWithDefinition(ctx context.Context, instan
 	}
 
 	// 添加被分配者（从业务数据中获取）
-	if assigneeIDValue, exists := instance.Variables["assignee_id"]; exists {
-		if assigneeID, ok := assigneeIDValue.(float64); ok {
-			stakeholders = append(stakeholders, uint(assigneeID))
-		}
+	if assigneeID, ok := instance.GetUintVar("assignee_id"); ok && assigneeID > 0 {
+		stakeholders = append(stakeholders, assigneeID)
 	}
 
 	// 为相关用户创建只读记录
@@ -242,7 +301,7 @@ func (e *ApprovalNodeExecutor) ExecuteWithDefinition(ctx context.Context, instan
 		}
 
 		if !isApprover {
-			logger.Infof("为相关用户 %d 创建查看记录", stakeholderID)
+			logger.FromContext(ctx).Infof("为相关用户 %d 创建查看记录", stakeholderID)
 
 			viewRecord := &PendingApproval{
 				InstanceID:     instance.ID,
@@ -259,9 +318,7 @@ func (e *ApprovalNodeExecutor) ExecuteWithDefinition(ctx context.Context, instan
 				RequiredAction: []ApprovalAction{}, // 只能查看，不能操作
 			}
 
-			if err := e.instanceRepo.SavePendingApproval(ctx, viewRecord); err != nil {
-				logger.Errorf("保存查看记录失败: %v", err)
-			}
+			approvals = append(approvals, viewRecord)
 		}
 	}
 
@@ -272,8 +329,10 @@ func (e *ApprovalNodeExecutor) ExecuteWithDefinition(ctx context.Context, instan
 			"assignees":     assignees,
 			"approval_type": config.ApprovalType,
 		},
-		Message:     fmt.Sprintf("已分配给 %d 个审批人", len(assignees)),
-		WaitForUser: true, // 需要等待用户审批
+		Message:          fmt.Sprintf("已分配给 %d 个审批人", len(assignees)),
+		WaitForUser:      true, // 需要等待用户审批
+		PendingApprovals: approvals,
+		ApprovalState:    NewNodeApprovalState(config.ApprovalType, config.CanDelegate, assignees),
 	}, nil
 }
 
@@ -327,13 +386,55 @@ func (e *ApprovalNodeExecutor) parseMultipleAssigneeConfig(node *WorkflowNode) (
 		config.AutoApprove = autoApprove
 	}
 
+	if condition, ok := node.Config["auto_approve_condition"].(string); ok {
+		config.AutoApproveCondition = strings.TrimSpace(condition)
+	}
+
+	if autoApproveSelf, ok := node.Config["auto_approve_self"].(bool); ok {
+		config.AutoApproveSelf = autoApproveSelf
+	}
+
 	if priority, ok := node.Config["priority"].(float64); ok {
 		config.Priority = int(priority)
 	}
 
+	switch approvalType := node.Config["approval_type"].(type) {
+	case string:
+		config.ApprovalType = ApprovalType(approvalType)
+	case ApprovalType:
+		config.ApprovalType = approvalType
+	}
+
+	if canDelegate, ok := node.Config["can_delegate"].(bool); ok {
+		config.CanDelegate = canDelegate
+	}
+
+	if canReturn, ok := node.Config["can_return"].(bool); ok {
+		config.CanReturn = canReturn
+	}
+
+	switch targets := node.Config["return_targets"].(type) {
+	case []string:
+		config.ReturnTargets = targets
+	case []interface{}:
+		for _, target := range targets {
+			if targetID, ok := target.(string); ok {
+				config.ReturnTargets = append(config.ReturnTargets, targetID)
+			}
+		}
+	}
+
 	return config, nil
 }
 
+// requiredActions 审批人可执行的动作，允许退回时包含退回
+func (c *ApprovalNodeConfig) requiredActions() []ApprovalAction {
+	if c.CanReturn {
+		return []ApprovalAction{ActionApprove, ActionReject, ActionReturn}
+	}
+	return []ApprovalAction{ActionApprove, ActionReject}
+}
+
 func (e *ApprovalNodeExecutor) parseLegacyAssigneeConfig(node *WorkflowNode) (*ApprovalNodeConfig, error) {
 	configBytes, err := json.Marshal(node.Config)
 	if err != nil {
@@ -350,71 +451,78 @@ func (e *ApprovalNodeExecutor) parseLegacyAssigneeConfig(node *WorkflowNode) (*A
 
 func (e *ApprovalNodeExecutor) resolveAssignees(ctx context.Context, instance *WorkflowInstance, assigneeConfigs []ApprovalAssignee) ([]uint, error) {
 	var assignees []uint
-	logger.Infof("开始解析审批人，配置数量: %d", len(assigneeConfigs))
+	logger.FromContext(ctx).Infof("开始解析审批人，配置数量: %d", len(assigneeConfigs))
 
 	for i, config := range assigneeConfigs {
-		logger.Infof("处理审批人配置 %d: type=%s, value=%s", i+1, config.Type, config.Value)
+		logger.FromContext(ctx).Infof("处理审批人配置 %d: type=%s, value=%s", i+1, config.Type, config.Value)
 		
 		switch config.Type {
+		case AssigneeTypeUser:
+			// 指定用户
+			userID, err := strconv.ParseUint(config.Value, 10, 64)
+			if err != nil || userID == 0 {
+				logger.FromContext(ctx).Warnf("无效的审批人用户ID: %s", config.Value)
+				continue
+			}
+			assignees = append(assignees, uint(userID))
 		case AssigneeTypeRole:
 			// 根据角色查找用户
-			logger.Infof("根据角色查找用户: %s", config.Value)
+			logger.FromContext(ctx).Infof("根据角色查找用户: %s", config.Value)
 			roleUsers, err := e.getUsersByRole(ctx, config.Value)
 			if err != nil {
-				logger.Errorf("根据角色查找用户失败: role=%s, error=%v", config.Value, err)
+				logger.FromContext(ctx).Errorf("根据角色查找用户失败: role=%s, error=%v", config.Value, err)
 				continue
 			}
-			logger.Infof("角色 %s 找到用户: %v", config.Value, roleUsers)
+			logger.FromContext(ctx).Infof("角色 %s 找到用户: %v", config.Value, roleUsers)
 			assignees = append(assignees, roleUsers...)
 		case AssigneeTypeDepartment:
 			// 根据部门查找用户
-			logger.Infof("根据部门查找用户: %s", config.Value)
+			logger.FromContext(ctx).Infof("根据部门查找用户: %s", config.Value)
 			deptUsers, err := e.getUsersByDepartment(ctx, config.Value)
 			if err != nil {
-				logger.Errorf("根据部门查找用户失败: dept=%s, error=%v", config.Value, err)
+				logger.FromContext(ctx).Errorf("根据部门查找用户失败: dept=%s, error=%v", config.Value, err)
 				continue
 			}
-			logger.Infof("部门 %s 找到用户: %v", config.Value, deptUsers)
+			logger.FromContext(ctx).Infof("部门 %s 找到用户: %v", config.Value, deptUsers)
 			assignees = append(assignees, deptUsers...)
 		case AssigneeTypeManager:
 			// 查找直属上级
-			logger.Infof("查找用户 %d 的直属上级", instance.StartedBy)
+			logger.FromContext(ctx).Infof("查找用户 %d 的直属上级", instance.StartedBy)
 			managerID, err := e.getManagerByUser(ctx, instance.StartedBy)
 			if err != nil {
-				logger.Errorf("查找直属上级失败: userID=%d, error=%v", instance.StartedBy, err)
+				logger.FromContext(ctx).Errorf("查找直属上级失败: userID=%d, error=%v", instance.StartedBy, err)
 				continue
 			}
-			logger.Infof("找到直属上级: %d", managerID)
+			logger.FromContext(ctx).Infof("找到直属上级: %d", managerID)
 			assignees = append(assignees, managerID)
-		case "department_manager":
-			// 查找部门经理 - 兼容新格式
-			logger.Infof("查找用户 %d 的部门经理", instance.StartedBy)
-			managerID, err := e.getManagerByUser(ctx, instance.StartedBy)
+		case AssigneeTypeDepartmentManager:
+			// 查找部门负责人，部门未设置负责人时回退到直属上级
+			logger.FromContext(ctx).Infof("查找用户 %d 的部门经理", instance.StartedBy)
+			managerID, err := e.getDepartmentManagerByUser(ctx, instance.StartedBy)
 			if err != nil {
-				logger.Errorf("查找部门经理失败: userID=%d, error=%v", instance.StartedBy, err)
+				logger.FromContext(ctx).Errorf("查找部门经理失败: userID=%d, error=%v", instance.StartedBy, err)
 				continue
 			}
-			logger.Infof("找到部门经理: %d", managerID)
 			assignees = append(assignees, managerID)
 		case AssigneeTypeStarter:
 			// 流程发起人
-			logger.Infof("添加流程发起人: %d", instance.StartedBy)
+			logger.FromContext(ctx).Infof("添加流程发起人: %d", instance.StartedBy)
 			assignees = append(assignees, instance.StartedBy)
 		case AssigneeTypeVariable:
 			// 从变量中获取
-			logger.Infof("从变量获取审批人: %s", config.Value)
+			logger.FromContext(ctx).Infof("从变量获取审批人: %s", config.Value)
 			if value, exists := instance.Variables[config.Value]; exists {
-				if userID, ok := value.(uint); ok {
-					logger.Infof("从变量找到用户: %d", userID)
+				if userID, ok := instance.GetUintVar(config.Value); ok && userID > 0 {
+					logger.FromContext(ctx).Infof("从变量找到用户: %d", userID)
 					assignees = append(assignees, userID)
 				} else {
-					logger.Warnf("变量 %s 的值不是有效的用户ID: %v", config.Value, value)
+					logger.FromContext(ctx).Warnf("变量 %s 的值不是有效的用户ID: %v", config.Value, value)
 				}
 			} else {
-				logger.Warnf("变量 %s 不存在", config.Value)
+				logger.FromContext(ctx).Warnf("变量 %s 不存在", config.Value)
 			}
 		default:
-			logger.Warnf("未知的审批人类型: %s", config.Type)
+			logger.FromContext(ctx).Warnf("未知的审批人类型: %s", config.Type)
 		}
 	}
 
@@ -432,19 +540,21 @@ func (e *ApprovalNodeExecutor) resolveAssignees(ctx context.Context, instance *W
 }
 
 func (e *ApprovalNodeExecutor) getUsersByRole(ctx context.Context, role string) ([]uint, error) {
-	// 根据角色查找用户
-	users, err := e.userRepo.GetUsersByRole(ctx, role)
+	// 根据角色查找用户（含下级角色，同时匹配role字段和用户角色关联）
+	matches, err := e.userRepo.FindUsersByRole(ctx, role)
 	if err != nil {
-		logger.Errorf("根据角色查找用户失败: role=%s, error=%v", role, err)
+		logger.FromContext(ctx).Errorf("根据角色查找用户失败: role=%s, error=%v", role, err)
 		return nil, fmt.Errorf("根据角色查找用户失败: %w", err)
 	}
 	
 	var userIDs []uint
-	for _, user := range users {
-		userIDs = append(userIDs, user.ID)
+	for _, match := range matches {
+		// 记录每个用户的命中来源，便于排查审批路由错误
+		logger.FromContext(ctx).Infof("角色 %s 匹配用户 %d: 命中角色=%v, 来源=%v", role, match.User.ID, match.MatchedRoles, match.Sources)
+		userIDs = append(userIDs, match.User.ID)
 	}
 	
-	logger.Infof("找到角色 %s 的用户: %v", role, userIDs)
+	logger.FromContext(ctx).Infof("找到角色 %s 的用户: %v", role, userIDs)
 	return userIDs, nil
 }
 
@@ -458,24 +568,24 @@ func (e *ApprovalNodeExecutor) getManagerByUser(ctx context.Context, userID uint
 	// 根据用户ID查找员工信息
 	employee, err := e.employeeRepo.GetByUserID(ctx, userID)
 	if err != nil {
-		logger.Errorf("查找员工失败: userID=%d, error=%v", userID, err)
+		logger.FromContext(ctx).Errorf("查找员工失败: userID=%d, error=%v", userID, err)
 		return 0, fmt.Errorf("查找员工失败: %w", err)
 	}
 
 	// 检查是否有直属领导
 	if employee.DirectManagerID == nil {
-		logger.Warnf("员工 %d 没有设置直属领导", userID)
+		logger.FromContext(ctx).Warnf("员工 %d 没有设置直属领导", userID)
 		return 0, fmt.Errorf("员工没有设置直属领导")
 	}
 
 	// 获取直属领导的员工信息
 	manager, err := e.employeeRepo.GetByID(ctx, *employee.DirectManagerID)
 	if err != nil {
-		logger.Errorf("查找直属领导失败: managerID=%d, error=%v", *employee.DirectManagerID, err)
+		logger.FromContext(ctx).Errorf("查找直属领导失败: managerID=%d, error=%v", *employee.DirectManagerID, err)
 		return 0, fmt.Errorf("查找直属领导失败: %w", err)
 	}
 
-	logger.Infof("找到员工 %d 的直属领导: %d", userID, manager.UserID)
+	logger.FromContext(ctx).Infof("找到员工 %d 的直属领导: %d", userID, manager.UserID)
 	return manager.UserID, nil
 }
 
@@ -489,7 +599,7 @@ func (e *ConditionNodeExecutor) Execute(ctx context.Context, instance *WorkflowI
 }
 
 func (e *ConditionNodeExecutor) ExecuteWithDefinition(ctx context.Context, instance *WorkflowInstance, node *WorkflowNode, definition *WorkflowDefinition) (*NodeExecutionResult, error) {
-	logger.Infof("执行条件节点: %s", node.ID)
+	logger.FromContext(ctx).Infof("执行条件节点: %s", node.ID)
 
 	// 解析条件节点配置
 	config, err := e.parseConditionConfig(node)
@@ -498,9 +608,21 @@ func (e *ConditionNodeExecutor) ExecuteWithDefinition(ctx context.Context, insta
 	}
 
 	// 评估条件并确定下一个节点
-	nextNodes, err := e.evaluateConditions(instance, config.Conditions)
-	if err != nil {
-		return nil, fmt.Errorf("评估条件失败: %w", err)
+	nextNodes := e.evaluateConditions(ctx, instance, config)
+	if len(nextNodes) == 0 {
+		if config.DefaultTarget == "" {
+			// 没有可流向的节点时实例会停滞，直接让节点失败并在历史中记录原因
+			return &NodeExecutionResult{
+				Success: false,
+				Message: fmt.Sprintf("条件节点[%s]没有满足的条件，且未配置默认目标节点", node.ID),
+			}, nil
+		}
+		logger.FromContext(ctx).Infof("条件节点 %s 没有满足的条件，流向默认节点 %s", node.ID, config.DefaultTarget)
+		return &NodeExecutionResult{
+			Success:   true,
+			NextNodes: []string{config.DefaultTarget},
+			Message:   fmt.Sprintf("没有满足的条件，流向默认节点 %s", config.DefaultTarget),
+		}, nil
 	}
 
 	return &NodeExecutionResult{
@@ -534,37 +656,35 @@ func (e *ConditionNodeExecutor) parseConditionConfig(node *WorkflowNode) (*Condi
 	return &config, nil
 }
 
-func (e *ConditionNodeExecutor) evaluateConditions(instance *WorkflowInstance, conditions []ConditionRule) ([]string, error) {
+// evaluateConditions 按优先级从高到低评估条件，first_match时返回第一个满足条件的目标，all_matches时返回全部满足条件的目标
+func (e *ConditionNodeExecutor) evaluateConditions(ctx context.Context, instance *WorkflowInstance, config *ConditionNodeConfig) []string {
 	var nextNodes []string
 
-	// 按优先级排序条件
-	sortedConditions := make([]ConditionRule, len(conditions))
-	copy(sortedConditions, conditions)
-
-	// 简单排序（实际应该使用sort包）
-	for i := 0; i < len(sortedConditions)-1; i++ {
-		for j := i + 1; j < len(sortedConditions); j++ {
-			if sortedConditions[i].Priority < sortedConditions[j].Priority {
-				sortedConditions[i], sortedConditions[j] = sortedConditions[j], sortedConditions[i]
-			}
-		}
-	}
+	// 按优先级排序条件，相同优先级保持配置顺序
+	sortedConditions := make([]ConditionRule, len(config.Conditions))
+	copy(sortedConditions, config.Conditions)
+	sort.SliceStable(sortedConditions, func(i, j int) bool {
+		return sortedConditions[i].Priority > sortedConditions[j].Priority
+	})
 
 	// 评估每个条件
 	for _, condition := range sortedConditions {
 		result, err := e.evaluateExpression(condition.Expression, instance.Variables)
 		if err != nil {
-			logger.Errorf("评估条件表达式失败: %s, error: %v", condition.Expression, err)
+			logger.FromContext(ctx).Errorf("评估条件表达式失败: %s, error: %v", condition.Expression, err)
 			continue
 		}
 
 		if result {
 			nextNodes = append(nextNodes, condition.Target)
-			logger.Infof("条件 %s 评估为真，流向节点 %s", condition.Expression, condition.Target)
+			logger.FromContext(ctx).Infof("条件 %s 评估为真，流向节点 %s", condition.Expression, condition.Target)
+			if config.EvaluationMode != ConditionAllMatches {
+				break
+			}
 		}
 	}
 
-	return nextNodes, nil
+	return nextNodes
 }
 
 func (e *ConditionNodeExecutor) evaluateExpression(expression string, variables map[string]interface{}) (bool, error) {
@@ -608,6 +728,20 @@ func (e *ConditionNodeExecutor) evaluateExpression(expression string, variables
 	}
 }
 
+// validateConditionExpression 校验表达式格式为 "variable operator value" 且操作符受支持
+func validateConditionExpression(expression string) error {
+	parts := strings.Fields(expression)
+	if len(parts) != 3 {
+		return fmt.Errorf("表达式格式错误，应为: variable operator value")
+	}
+	switch parts[1] {
+	case "==", "=", "!=", "<>", ">", "<", ">=", "<=":
+		return nil
+	default:
+		return fmt.Errorf("不支持的操作符: %s", parts[1])
+	}
+}
+
 func (e *ConditionNodeExecutor) compareNumeric(actual, expected, operator string) (bool, error) {
 	actualNum, err1 := strconv.ParseFloat(actual, 64)
 	expectedNum, err2 := strconv.ParseFloat(expected, 64)
@@ -650,7 +784,7 @@ func (e *ParallelNodeExecutor) Execute(ctx context.Context, instance *WorkflowIn
 }
 
 func (e *ParallelNodeExecutor) ExecuteWithDefinition(ctx context.Context, instance *WorkflowInstance, node *WorkflowNode, definition *WorkflowDefinition) (*NodeExecutionResult, error) {
-	logger.Infof("执行并行节点: %s", node.ID)
+	logger.FromContext(ctx).Infof("执行并行节点: %s", node.ID)
 
 	// 并行节点将流程分发到多个分支
 	nextNodes := e.getParallelBranches(instance, node.ID)
@@ -684,7 +818,7 @@ func (e *JoinNodeExecutor) Execute(ctx context.Context, instance *WorkflowInstan
 }
 
 func (e *JoinNodeExecutor) ExecuteWithDefinition(ctx context.Context, instance *WorkflowInstance, node *WorkflowNode, definition *WorkflowDefinition) (*NodeExecutionResult, error) {
-	logger.Infof("执行汇聚节点: %s", node.ID)
+	logger.FromContext(ctx).Infof("执行汇聚节点: %s", node.ID)
 
 	// 检查所有前置节点是否都已完成
 	completed, err := e.checkPredecessors(instance, node)
@@ -732,24 +866,41 @@ func (e *JoinNodeExecutor) getNextNodes(instance *WorkflowInstance, nodeID strin
 }
 
 // ScriptNodeExecutor 脚本节点执行器
+// 节点配置 script 为脚本源码，timeout_ms 可覆盖默认执行超时（不超过 maxScriptTimeout）
 type ScriptNodeExecutor struct {
 	registry *ExecutorRegistry
 }
 
+// maxScriptTimeout 节点可配置的最大脚本超时
+const maxScriptTimeout = 30 * time.Second
+
+// scriptReferencePattern 旧版流程定义中以名称引用的脚本，没有可执行内容
+var scriptReferencePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
 func (e *ScriptNodeExecutor) Execute(ctx context.Context, instance *WorkflowInstance, node *WorkflowNode) (*NodeExecutionResult, error) {
 	return e.ExecuteWithDefinition(ctx, instance, node, nil)
 }
 
 func (e *ScriptNodeExecutor) ExecuteWithDefinition(ctx context.Context, instance *WorkflowInstance, node *WorkflowNode, definition *WorkflowDefinition) (*NodeExecutionResult, error) {
-	logger.Infof("执行脚本节点: %s", node.ID)
+	logger.FromContext(ctx).Infof("执行脚本节点: %s", node.ID)
 
-	// 执行脚本逻辑
-	variables, err := e.executeScript(instance, node)
-	if err != nil {
-		return nil, fmt.Errorf("执行脚本失败: %w", err)
+	var nextNodes []string
+	if definition != nil && e.registry != nil {
+		nextNodes = e.registry.GetNextNodes(definition, node.ID)
+	} else {
+		nextNodes = e.getNextNodes(instance, node.ID)
 	}
 
-	nextNodes := e.getNextNodes(instance, node.ID)
+	// 执行脚本逻辑，失败时由引擎记录到执行历史并终止流程
+	variables, err := e.executeScript(ctx, instance, node)
+	if err != nil {
+		logger.FromContext(ctx).Errorf("脚本节点执行失败: %s, error: %v", node.ID, err)
+		return &NodeExecutionResult{
+			Success: false,
+			Message: err.Error(),
+			Error:   err,
+		}, nil
+	}
 
 	return &NodeExecutionResult{
 		Success:     true,
@@ -764,13 +915,36 @@ func (e *ScriptNodeExecutor) GetSupportedNodeType() NodeType {
 	return NodeTypeScript
 }
 
-func (e *ScriptNodeExecutor) executeScript(instance *WorkflowInstance, node *WorkflowNode) (map[string]interface{}, error) {
-	// 这里应该执行实际的脚本逻辑
-	// 简化处理，返回模拟变量
-	return map[string]interface{}{
-		"script_result": "success",
-		"timestamp":     time.Now(),
-	}, nil
+func (e *ScriptNodeExecutor) executeScript(ctx context.Context, instance *WorkflowInstance, node *WorkflowNode) (map[string]interface{}, error) {
+	script, _ := node.Config["script"].(string)
+	script = strings.TrimSpace(script)
+	if script == "" || scriptReferencePattern.MatchString(script) {
+		logger.FromContext(ctx).Infof("脚本节点无可执行脚本，跳过: %s", node.ID)
+		return nil, nil
+	}
+
+	limits := DefaultScriptLimits
+	if timeoutMs := e.getIntConfig(node.Config, "timeout_ms"); timeoutMs > 0 {
+		limits.Timeout = time.Duration(timeoutMs) * time.Millisecond
+		if limits.Timeout > maxScriptTimeout {
+			limits.Timeout = maxScriptTimeout
+		}
+	}
+
+	return RunScript(ctx, script, instance.Variables, limits)
+}
+
+// getIntConfig 读取整数配置，兼容JSON反序列化后的float64
+func (e *ScriptNodeExecutor) getIntConfig(config map[string]interface{}, key string) int {
+	switch v := config[key].(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	}
+	return 0
 }
 
 func (e *ScriptNodeExecutor) getNextNodes(instance *WorkflowInstance, nodeID string) []string {
@@ -789,12 +963,12 @@ func (e *NotifyNodeExecutor) Execute(ctx context.Context, instance *WorkflowInst
 }
 
 func (e *NotifyNodeExecutor) ExecuteWithDefinition(ctx context.Context, instance *WorkflowInstance, node *WorkflowNode, definition *WorkflowDefinition) (*NodeExecutionResult, error) {
-	logger.Infof("执行通知节点: %s", node.ID)
+	logger.FromContext(ctx).Infof("执行通知节点: %s", node.ID)
 
 	// 发送通知
 	err := e.sendNotification(ctx, instance, node)
 	if err != nil {
-		logger.Errorf("发送通知失败: %v", err)
+		logger.FromContext(ctx).Errorf("发送通知失败: %v", err)
 		// 通知失败不阻止流程继续
 	}
 
@@ -816,7 +990,7 @@ func (e *NotifyNodeExecutor) GetSupportedNodeType() NodeType {
 func (e *NotifyNodeExecutor) sendNotification(ctx context.Context, instance *WorkflowInstance, node *WorkflowNode) error {
 	// 这里应该发送实际的通知
 	// 简化处理，只记录日志
-	logger.Infof("发送通知: 流程 %s 在节点 %s", instance.ID, node.ID)
+	logger.FromContext(ctx).Infof("发送通知: 流程 %s 在节点 %s", instance.ID, node.ID)
 	return nil
 }
 
@@ -832,32 +1006,47 @@ func (e *OnboardingApprovalNodeExecutor) Execute(ctx context.Context, instance *
 }
 
 func (e *OnboardingApprovalNodeExecutor) ExecuteWithDefinition(ctx context.Context, instance *WorkflowInstance, node *WorkflowNode, definition *WorkflowDefinition) (*NodeExecutionResult, error) {
-	logger.Infof("执行入职审批节点: %s", node.ID)
+	logger.FromContext(ctx).Infof("执行入职审批节点: %s", node.ID)
 
 	// 解析审批节点配置
 	config, err := e.parseApprovalConfig(node)
 	if err != nil {
-		logger.Errorf("解析入职审批节点配置失败: %v", err)
+		logger.FromContext(ctx).Errorf("解析入职审批节点配置失败: %v", err)
 		return nil, fmt.Errorf("解析入职审批节点配置失败: %w", err)
 	}
-	logger.Infof("入职审批节点配置解析成功，审批人配置数量: %d", len(config.Assignees))
+	logger.FromContext(ctx).Infof("入职审批节点配置解析成功，审批人配置数量: %d", len(config.Assignees))
 
 	// 解析审批人
-	assignees, err := e.resolveAssignees(ctx, instance, config.Assignees)
+	assigneeConfigs, err := e.nodeAssignees(ctx, instance, node, config)
 	if err != nil {
-		logger.Errorf("解析入职审批人失败: %v", err)
+		logger.FromContext(ctx).Errorf("获取入职审批人配置失败: %v", err)
+		return nil, fmt.Errorf("获取入职审批人配置失败: %w", err)
+	}
+	assignees, err := e.resolveAssignees(ctx, instance, assigneeConfigs)
+	if err != nil {
+		logger.FromContext(ctx).Errorf("解析入职审批人失败: %v", err)
 		return nil, fmt.Errorf("解析入职审批人失败: %w", err)
 	}
-	logger.Infof("解析到入职审批人: %v", assignees)
+	logger.FromContext(ctx).Infof("解析到入职审批人: %v", assignees)
 
 	if len(assignees) == 0 {
-		logger.Errorf("未找到有效的入职审批人")
+		logger.FromContext(ctx).Errorf("未找到有效的入职审批人")
 		return nil, fmt.Errorf("未找到有效的入职审批人")
 	}
 
-	// 创建入职待审批记录
+	if reason := config.autoApproveReason(instance, assignees); reason != "" {
+		logger.FromContext(ctx).Infof("入职审批节点自动审批: 实例=%s, 节点=%s, 原因=%s", instance.ID, node.ID, reason)
+		return &NodeExecutionResult{
+			Success:           true,
+			Message:           reason,
+			AutoApproveReason: reason,
+		}, nil
+	}
+
+	// 创建入职待审批记录，由引擎与实例状态一并写入
+	var approvals []*PendingApproval
 	for _, assigneeID := range assignees {
-		logger.Infof("为用户 %d 创建入职审批任务", assigneeID)
+		logger.FromContext(ctx).Infof("为用户 %d 创建入职审批任务", assigneeID)
 
 		pendingApproval := &PendingApproval{
 			InstanceID:     instance.ID,
@@ -871,29 +1060,26 @@ func (e *OnboardingApprovalNodeExecutor) ExecuteWithDefinition(ctx context.Conte
 			AssignedTo:     assigneeID,
 			CreatedAt:      time.Now(),
 			CanDelegate:    config.CanDelegate,
-			RequiredAction: []ApprovalAction{ActionApprove, ActionReject},
+			RequiredAction: config.requiredActions(),
 		}
 
 		if config.Deadline != nil {
-			deadline := time.Now().Add(*config.Deadline)
+			deadline := workingDeadline(ctx, e.workCalendar, pendingApproval.CreatedAt, *config.Deadline)
 			pendingApproval.Deadline = &deadline
 		}
 
-		// 保存待审批记录
-		if err := e.instanceRepo.SavePendingApproval(ctx, pendingApproval); err != nil {
-			logger.Errorf("保存入职待审批记录失败: %v", err)
-			return nil, fmt.Errorf("保存入职待审批记录失败: %w", err)
-		}
-		
-		logger.Infof("成功创建入职待审批记录: InstanceID=%s, NodeID=%s, AssignedTo=%d", instance.ID, node.ID, assigneeID)
+		approvals = append(approvals, pendingApproval)
+		logger.FromContext(ctx).Infof("创建入职待审批记录: InstanceID=%s, NodeID=%s, AssignedTo=%d", instance.ID, node.ID, assigneeID)
 	}
 
 	return &NodeExecutionResult{
-		Success:     true,
-		NextNodes:   []string{}, // 等待审批完成
-		Variables:   nil,
-		Message:     "入职审批任务已创建，等待审批",
-		WaitForUser: true,
+		Success:          true,
+		NextNodes:        []string{}, // 等待审批完成
+		Variables:        nil,
+		Message:          "入职审批任务已创建，等待审批",
+		WaitForUser:      true,
+		PendingApprovals: approvals,
+		ApprovalState:    NewNodeApprovalState(config.ApprovalType, config.CanDelegate, assignees),
 	}, nil
 }
 
@@ -915,9 +1101,19 @@ func (e *OnboardingApprovalNodeExecutor) parseApprovalConfig(node *WorkflowNode)
 func (e *OnboardingApprovalNodeExecutor) resolveAssignees(ctx context.Context, instance *WorkflowInstance, assignees []ApprovalAssignee) ([]uint, error) {
 	// 创建临时的任务审批执行器来复用解析逻辑
 	tempExecutor := &ApprovalNodeExecutor{
-		instanceRepo: e.instanceRepo,
-		employeeRepo: e.employeeRepo,
-		userRepo:     e.userRepo,
+		instanceRepo:   e.instanceRepo,
+		employeeRepo:   e.employeeRepo,
+		userRepo:       e.userRepo,
+		departmentRepo: e.departmentRepo,
 	}
 	return tempExecutor.resolveAssignees(ctx, instance, assignees)
 }
+
+func (e *OnboardingApprovalNodeExecutor) nodeAssignees(ctx context.Context, instance *WorkflowInstance, node *WorkflowNode, config *ApprovalNodeConfig) ([]ApprovalAssignee, error) {
+	// 创建临时的任务审批执行器来复用审批链查询
+	tempExecutor := &ApprovalNodeExecutor{
+		employeeRepo:  e.employeeRepo,
+		chainProvider: e.chainProvider,
+	}
+	return tempExecutor.nodeAssignees(ctx, instance, node, config)
+}
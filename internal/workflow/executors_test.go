@@ -0,0 +1,219 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+// roleUserRepository 按角色返回固定匹配结果的用户仓储桩
+type roleUserRepository struct {
+	repository.UserRepository
+	matches map[string][]*repository.RoleUserMatch
+}
+
+func (r *roleUserRepository) FindUsersByRole(ctx context.Context, role string) ([]*repository.RoleUserMatch, error) {
+	return r.matches[role], nil
+}
+
+func TestApprovalNodeExecutor_ResolveRoleAssigneesFromAssociation(t *testing.T) {
+	userRepo := &roleUserRepository{matches: map[string][]*repository.RoleUserMatch{
+		"manager": {
+			{
+				User:         &database.User{BaseModel: database.BaseModel{ID: 7}, Role: "employee"},
+				MatchedRoles: []string{"senior_manager"},
+				Sources:      []string{repository.RoleSourceUserRoles},
+			},
+			{
+				User:         &database.User{BaseModel: database.BaseModel{ID: 8}, Role: "manager"},
+				MatchedRoles: []string{"manager"},
+				Sources:      []string{repository.RoleSourceColumn, repository.RoleSourceUserRoles},
+			},
+		},
+	}}
+	executor := &ApprovalNodeExecutor{userRepo: userRepo}
+
+	assignees, err := executor.resolveAssignees(context.Background(), &WorkflowInstance{StartedBy: 1}, []ApprovalAssignee{
+		{Type: AssigneeTypeRole, Value: "manager"},
+		{Type: AssigneeTypeStarter},
+		{Type: AssigneeTypeRole, Value: "manager"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []uint{7, 8, 1}, assignees)
+}
+
+// staticChainProvider 按部门和业务类型返回固定审批链的桩
+type staticChainProvider map[string][]ApprovalAssignee
+
+func (p staticChainProvider) GetApprovalChain(ctx context.Context, departmentID uint, businessType string) ([]ApprovalAssignee, error) {
+	return p[fmt.Sprintf("%d/%s", departmentID, businessType)], nil
+}
+
+// departmentEmployeeRepository 按用户ID返回所属部门的员工仓储桩
+type departmentEmployeeRepository struct {
+	repository.EmployeeRepository
+	departments map[uint]uint
+}
+
+func (r *departmentEmployeeRepository) GetByUserID(ctx context.Context, userID uint) (*database.Employee, error) {
+	departmentID, ok := r.departments[userID]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &database.Employee{UserID: userID, DepartmentID: &departmentID}, nil
+}
+
+func TestApprovalNodeExecutor_DepartmentChain(t *testing.T) {
+	executor := &ApprovalNodeExecutor{
+		employeeRepo: &departmentEmployeeRepository{departments: map[uint]uint{1: 10, 2: 20}},
+		chainProvider: staticChainProvider{
+			"10/task_assignment": {{Type: AssigneeTypeUser, Value: "30"}, {Type: AssigneeTypeUser, Value: "31"}},
+		},
+	}
+	node := &WorkflowNode{ID: "approve", Type: NodeTypeApproval, Config: map[string]interface{}{
+		"assignee_source": AssigneeSourceDepartmentChain,
+		"assignees":       []interface{}{map[string]interface{}{"type": "user", "value": "99"}},
+	}}
+	ctx := context.Background()
+
+	run := func(instance *WorkflowInstance) []uint {
+		result, err := executor.ExecuteWithDefinition(ctx, instance, node, nil)
+		require.NoError(t, err)
+		var assignees []uint
+		for _, vote := range result.ApprovalState.Votes {
+			assignees = append(assignees, vote.AssigneeID)
+		}
+		return assignees
+	}
+
+	// 发起人所在部门配置了审批链，按顺序使用审批链
+	assert.Equal(t, []uint{30, 31}, run(&WorkflowInstance{ID: "a", BusinessType: "task_assignment", StartedBy: 1}))
+
+	// 部门未配置审批链时回退到节点审批人
+	assert.Equal(t, []uint{99}, run(&WorkflowInstance{ID: "b", BusinessType: "task_assignment", StartedBy: 2}))
+
+	// 流程变量中的部门优先于发起人部门
+	assert.Equal(t, []uint{30, 31}, run(&WorkflowInstance{ID: "c", BusinessType: "task_assignment", StartedBy: 2,
+		Variables: map[string]interface{}{"department_id": float64(10)}}))
+
+	// 未指定审批链来源的节点不受部门配置影响
+	delete(node.Config, "assignee_source")
+	assert.Equal(t, []uint{99}, run(&WorkflowInstance{ID: "d", BusinessType: "task_assignment", StartedBy: 1}))
+}
+
+// orgEmployeeRepository 按员工ID和用户ID查找员工的仓储桩
+type orgEmployeeRepository struct {
+	repository.EmployeeRepository
+	employees []*database.Employee
+}
+
+func (r *orgEmployeeRepository) GetByID(ctx context.Context, id uint) (*database.Employee, error) {
+	for _, employee := range r.employees {
+		if employee.ID == id {
+			return employee, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *orgEmployeeRepository) GetByUserID(ctx context.Context, userID uint) (*database.Employee, error) {
+	for _, employee := range r.employees {
+		if employee.UserID == userID {
+			return employee, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+// managerDepartmentRepository 按部门ID返回部门负责人的部门仓储桩
+type managerDepartmentRepository struct {
+	repository.DepartmentRepository
+	managers map[uint]*uint
+}
+
+func (r *managerDepartmentRepository) GetByID(ctx context.Context, id uint) (*database.Department, error) {
+	managerID, ok := r.managers[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &database.Department{BaseModel: database.BaseModel{ID: id}, ManagerID: managerID}, nil
+}
+
+func TestApprovalNodeExecutor_DepartmentManager(t *testing.T) {
+	sales, support, closed := uint(10), uint(20), uint(30)
+	head, lead := uint(2), uint(3)
+	employee := func(id, userID uint, departmentID, directManagerID *uint) *database.Employee {
+		return &database.Employee{BaseModel: database.BaseModel{ID: id}, UserID: userID, DepartmentID: departmentID, DirectManagerID: directManagerID}
+	}
+	executor := &ApprovalNodeExecutor{
+		employeeRepo: &orgEmployeeRepository{employees: []*database.Employee{
+			employee(1, 100, &sales, &lead),
+			employee(head, 200, &sales, &lead),
+			employee(lead, 300, nil, nil),
+			employee(4, 400, &support, &lead),
+			employee(5, 500, &closed, nil),
+		}},
+		departmentRepo: &managerDepartmentRepository{managers: map[uint]*uint{sales: &head, support: nil, closed: nil}},
+	}
+	resolve := func(starter uint) []uint {
+		assignees, err := executor.resolveAssignees(context.Background(), &WorkflowInstance{StartedBy: starter}, []ApprovalAssignee{
+			{Type: AssigneeTypeDepartmentManager},
+		})
+		require.NoError(t, err)
+		return assignees
+	}
+
+	// 优先取发起人所在部门的负责人
+	assert.Equal(t, []uint{200}, resolve(100))
+	// 发起人就是部门负责人时回退到其直属上级
+	assert.Equal(t, []uint{300}, resolve(200))
+	// 部门未设置负责人时回退到直属上级
+	assert.Equal(t, []uint{300}, resolve(400))
+	// 两者都没有时不产生审批人
+	assert.Empty(t, resolve(500))
+
+	// 未设置部门仓储时只按直属上级解析
+	executor.departmentRepo = nil
+	assert.Equal(t, []uint{300}, resolve(100))
+}
+
+func TestConditionNodeExecutor_EvaluationMode(t *testing.T) {
+	executor := &ConditionNodeExecutor{}
+	newNode := func(config map[string]interface{}) *WorkflowNode {
+		config["conditions"] = []ConditionRule{
+			{Expression: "amount > 1000", Target: "manager_approval", Priority: 1},
+			{Expression: "amount > 10000", Target: "director_approval", Priority: 2},
+		}
+		return &WorkflowNode{ID: "amount_check", Type: NodeTypeCondition, Config: config}
+	}
+	instance := &WorkflowInstance{Variables: map[string]interface{}{"amount": 20000}}
+
+	// 默认只流向优先级最高的满足条件
+	result, err := executor.Execute(context.Background(), instance, newNode(map[string]interface{}{}))
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, []string{"director_approval"}, result.NextNodes)
+
+	result, err = executor.Execute(context.Background(), instance, newNode(map[string]interface{}{"evaluation_mode": "all_matches"}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"director_approval", "manager_approval"}, result.NextNodes)
+
+	// 没有满足的条件时流向默认节点，未配置默认节点时节点失败
+	small := &WorkflowInstance{Variables: map[string]interface{}{"amount": 500}}
+	result, err = executor.Execute(context.Background(), small, newNode(map[string]interface{}{"default_target": "auto_approve"}))
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, []string{"auto_approve"}, result.NextNodes)
+
+	result, err = executor.Execute(context.Background(), small, newNode(map[string]interface{}{}))
+	require.NoError(t, err)
+	assert.False(t, result.Success)
+	assert.Empty(t, result.NextNodes)
+	assert.Contains(t, result.Message, "没有满足的条件")
+}
@@ -0,0 +1,108 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"taskmanage/internal/repository"
+	"taskmanage/pkg/logger"
+)
+
+// ExpiryReport 过期扫描结果汇总
+type ExpiryReport struct {
+	Scanned int               `json:"scanned"` // 超时的运行中实例数
+	Expired []ExpiredInstance `json:"expired"` // 已标记过期的实例
+	Failed  []ExpiredInstance `json:"failed"`  // 标记失败的实例，下次扫描重试
+}
+
+// ExpiredInstance 被标记过期的实例
+type ExpiredInstance struct {
+	InstanceID   string `json:"instance_id"`
+	BusinessType string `json:"business_type"`
+	BusinessID   string `json:"business_id"`
+	Reason       string `json:"reason,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+// setRemainingTime 计算运行中实例距截止时间的剩余秒数
+func (i *WorkflowInstance) setRemainingTime(now time.Time) {
+	i.RemainingSeconds = remainingSeconds(i.Status, i.Deadline, now)
+}
+
+// remainingSeconds 运行中且设置了截止时间的实例返回剩余秒数，否则返回nil
+func remainingSeconds(status InstanceStatus, deadline *time.Time, now time.Time) *int64 {
+	if status != StatusRunning || deadline == nil {
+		return nil
+	}
+	remaining := int64(deadline.Sub(now) / time.Second)
+	return &remaining
+}
+
+// ExpireOverdueInstances 将超过SLA或存在超时待审批的运行中实例标记为过期
+// 过期实例的待审批记录被关闭，并以过期结果执行业务回调，由业务方恢复任务或员工状态
+func (e *WorkflowEngineImpl) ExpireOverdueInstances(ctx context.Context, now time.Time) (*ExpiryReport, error) {
+	instances, err := e.instanceRepo.ListOverdueInstances(ctx, now)
+	if err != nil {
+		return nil, fmt.Errorf("获取超时的流程实例失败: %w", err)
+	}
+
+	report := &ExpiryReport{Scanned: len(instances)}
+	for _, instance := range instances {
+		reason := "审批节点超时"
+		if instance.Deadline != nil && !now.Before(*instance.Deadline) {
+			reason = "超过流程SLA"
+		}
+		item := ExpiredInstance{
+			InstanceID:   instance.ID,
+			BusinessType: instance.BusinessType,
+			BusinessID:   instance.BusinessID,
+			Reason:       reason,
+		}
+
+		if err := e.expireInstance(ctx, instance, reason, now); err != nil {
+			// 扫描期间实例已被审批或取消，不再处理
+			if errors.Is(err, repository.ErrConcurrentUpdate) {
+				continue
+			}
+			logger.Errorf("标记流程实例过期失败: 实例=%s, error: %v", instance.ID, err)
+			item.Error = err.Error()
+			report.Failed = append(report.Failed, item)
+			continue
+		}
+		report.Expired = append(report.Expired, item)
+	}
+	return report, nil
+}
+
+// expireInstance 标记实例过期并执行业务回调，当前节点保留以便追查停留位置
+func (e *WorkflowEngineImpl) expireInstance(ctx context.Context, instance *WorkflowInstance, reason string, now time.Time) error {
+	logger.Infof("流程实例过期: %s, 业务类型: %s, 原因: %s", instance.ID, instance.BusinessType, reason)
+
+	history := ExecutionHistory{
+		ID:         uuid.New().String(),
+		NodeName:   "系统",
+		Action:     "expire",
+		Result:     string(StatusExpired),
+		Comment:    reason,
+		Variables:  map[string]interface{}{"current_nodes": instance.CurrentNodes},
+		ExecutedBy: 0, // 系统操作
+		ExecutedAt: now,
+	}
+	instance.Status = StatusExpired
+	instance.CompletedAt = &now
+	if err := e.instanceRepo.ExpireInstance(ctx, instance, history); err != nil {
+		return err
+	}
+
+	if e.completionHandlers == nil {
+		return nil
+	}
+	if handler, ok := e.completionHandlers.Get(instance.BusinessType); ok {
+		e.runCompletion(ctx, instance, handler, &CompletionRecord{Expired: true})
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package workflow
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/repository"
+)
+
+// expiryInstanceRepository 记录过期操作的实例仓储桩
+type expiryInstanceRepository struct {
+	WorkflowInstanceRepository
+	overdue     []*WorkflowInstance
+	finished    map[string]bool // 扫描期间已被审批完成的实例
+	expired     []ExecutionHistory
+	completions []*CompletionRecord
+}
+
+func (r *expiryInstanceRepository) ListOverdueInstances(ctx context.Context, now time.Time) ([]*WorkflowInstance, error) {
+	return r.overdue, nil
+}
+
+func (r *expiryInstanceRepository) ExpireInstance(ctx context.Context, instance *WorkflowInstance, history ExecutionHistory) error {
+	if r.finished[instance.ID] {
+		return repository.ErrConcurrentUpdate
+	}
+	r.expired = append(r.expired, history)
+	return nil
+}
+
+func (r *expiryInstanceRepository) SaveCompletion(ctx context.Context, instanceID string, record *CompletionRecord) error {
+	r.completions = append(r.completions, record)
+	return nil
+}
+
+func TestExpireOverdueInstances(t *testing.T) {
+	now := time.Now()
+	slaDeadline := now.Add(-time.Hour)
+	repo := &expiryInstanceRepository{
+		overdue: []*WorkflowInstance{
+			{ID: "sla", BusinessType: "onboarding", BusinessID: "1", Status: StatusRunning, CurrentNodes: []string{"hr"}, Deadline: &slaDeadline},
+			{ID: "node", BusinessType: "onboarding", BusinessID: "2", Status: StatusRunning, CurrentNodes: []string{"manager"}},
+			{ID: "approved", BusinessType: "onboarding", BusinessID: "3", Status: StatusRunning},
+		},
+		finished: map[string]bool{"approved": true},
+	}
+
+	var calls []*BusinessCompletion
+	registry := NewCompletionHandlerRegistry()
+	registry.Register("onboarding", func(ctx context.Context, completion *BusinessCompletion) error {
+		calls = append(calls, completion)
+		return nil
+	})
+	engine := &WorkflowEngineImpl{instanceRepo: repo}
+	engine.SetCompletionHandlers(registry)
+
+	report, err := engine.ExpireOverdueInstances(context.Background(), now)
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Scanned)
+	assert.Equal(t, []ExpiredInstance{
+		{InstanceID: "sla", BusinessType: "onboarding", BusinessID: "1", Reason: "超过流程SLA"},
+		{InstanceID: "node", BusinessType: "onboarding", BusinessID: "2", Reason: "审批节点超时"},
+	}, report.Expired)
+	assert.Empty(t, report.Failed)
+
+	require.Len(t, repo.expired, 2)
+	assert.Equal(t, "expire", repo.expired[0].Action)
+	assert.Equal(t, StatusExpired, repo.overdue[0].Status)
+	assert.Equal(t, &now, repo.overdue[0].CompletedAt)
+	assert.Equal(t, []string{"hr"}, repo.overdue[0].CurrentNodes)
+
+	// 过期结果传给业务回调，审批结果视为未通过
+	require.Len(t, calls, 2)
+	assert.True(t, calls[0].Expired)
+	assert.False(t, calls[0].Approved)
+	require.Len(t, repo.completions, 2)
+	assert.True(t, repo.completions[0].Expired)
+	assert.Equal(t, CompletionSucceeded, repo.completions[0].Status)
+}
+
+func TestWorkflowInstance_RemainingTime(t *testing.T) {
+	now := time.Now()
+	deadline := now.Add(90 * time.Minute)
+	instance := &WorkflowInstance{Status: StatusRunning, Deadline: &deadline}
+
+	instance.setRemainingTime(now)
+	require.NotNil(t, instance.RemainingSeconds)
+	assert.Equal(t, int64(5400), *instance.RemainingSeconds)
+
+	instance.setRemainingTime(now.Add(2 * time.Hour))
+	assert.Equal(t, int64(-1800), *instance.RemainingSeconds)
+
+	// 已结束或未设置SLA的实例不返回剩余时间
+	instance.Status = StatusCompleted
+	instance.setRemainingTime(now)
+	assert.Nil(t, instance.RemainingSeconds)
+	noSLA := &WorkflowInstance{Status: StatusRunning}
+	noSLA.setRemainingTime(now)
+	assert.Nil(t, noSLA.RemainingSeconds)
+}
@@ -0,0 +1,46 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+)
+
+// HistoryVerification 执行历史哈希链校验结果
+type HistoryVerification struct {
+	InstanceID  string             `json:"instance_id"`
+	Valid       bool               `json:"valid"`
+	Total       int                `json:"total"`   // 实例的历史记录数
+	Checked     int                `json:"checked"` // 断链前校验通过的记录数
+	FirstBroken *HistoryChainBreak `json:"first_broken,omitempty"`
+}
+
+// HistoryChainBreak 哈希链第一处断链
+type HistoryChainBreak struct {
+	HistoryID string `json:"history_id"`
+	Position  int    `json:"position"` // 按写入顺序的序号，从1开始
+	Reason    string `json:"reason"`
+}
+
+// VerifyHistory 校验流程实例执行历史的哈希链，报告第一处断链
+func (e *WorkflowEngineImpl) VerifyHistory(ctx context.Context, instanceID string) (*HistoryVerification, error) {
+	if _, err := e.instanceRepo.GetInstance(ctx, instanceID); err != nil {
+		return nil, fmt.Errorf("获取流程实例失败: %w", err)
+	}
+	verification, err := e.instanceRepo.VerifyExecutionHistory(ctx, instanceID)
+	if err != nil {
+		return nil, fmt.Errorf("校验执行历史失败: %w", err)
+	}
+	return verification, nil
+}
+
+// GetExecutionHistory 获取流程实例的执行历史，按执行时间升序，包含审批意见的附件和提及用户
+func (e *WorkflowEngineImpl) GetExecutionHistory(ctx context.Context, instanceID string) ([]ExecutionHistory, error) {
+	if _, err := e.instanceRepo.GetInstance(ctx, instanceID); err != nil {
+		return nil, fmt.Errorf("获取流程实例失败: %w", err)
+	}
+	history, err := e.instanceRepo.GetExecutionHistory(ctx, instanceID)
+	if err != nil {
+		return nil, fmt.Errorf("获取执行历史失败: %w", err)
+	}
+	return history, nil
+}
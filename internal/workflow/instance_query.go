@@ -0,0 +1,147 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ErrInvalidInstanceFilter 流程实例查询条件不合法
+var ErrInvalidInstanceFilter = errors.New("流程实例查询条件不合法")
+
+// InstanceFilter 流程实例查询条件，PageSize为0时返回全部记录
+type InstanceFilter struct {
+	Status          InstanceStatus `json:"status,omitempty"`
+	WorkflowID      string         `json:"workflow_id,omitempty"`
+	WorkflowName    string         `json:"workflow_name,omitempty"` // 按流程定义名称模糊匹配
+	BusinessType    string         `json:"business_type,omitempty"`
+	BusinessID      string         `json:"business_id,omitempty"`
+	StartedBy       uint           `json:"started_by,omitempty"`
+	StartedFrom     *time.Time     `json:"started_from,omitempty"`     // 启动时间不早于该时间
+	StartedTo       *time.Time     `json:"started_to,omitempty"`       // 启动时间早于该时间
+	IncludeArchived bool           `json:"include_archived,omitempty"` // 同时查询已归档的实例，默认只查询在用实例
+	Page            int            `json:"page,omitempty"`
+	PageSize        int            `json:"page_size,omitempty"`
+}
+
+// Validate 校验实例状态和启动时间范围
+func (f *InstanceFilter) Validate() error {
+	switch f.Status {
+	case "", StatusRunning, StatusCompleted, StatusCancelled, StatusFailed, StatusSuspended, StatusExpired:
+	default:
+		return fmt.Errorf("%w: 不支持的实例状态%s", ErrInvalidInstanceFilter, f.Status)
+	}
+	if f.StartedFrom != nil && f.StartedTo != nil && !f.StartedFrom.Before(*f.StartedTo) {
+		return fmt.Errorf("%w: 启动时间范围的开始时间必须早于结束时间", ErrInvalidInstanceFilter)
+	}
+	return nil
+}
+
+// InstanceSummary 流程实例列表项，不含流程变量、审批状态和执行历史
+type InstanceSummary struct {
+	ID                  string         `json:"id"`
+	WorkflowID          string         `json:"workflow_id"`
+	WorkflowName        string         `json:"workflow_name"` // 启动时绑定的流程定义版本的名称
+	DefinitionVersionID uint           `json:"definition_version_id"`
+	BusinessID          string         `json:"business_id"`
+	BusinessType        string         `json:"business_type"`
+	Status              InstanceStatus `json:"status"`
+	CurrentNodes        []string       `json:"current_nodes"`
+	StartedBy           uint           `json:"started_by"`
+	StartedAt           time.Time      `json:"started_at"`
+	CompletedAt         *time.Time     `json:"completed_at,omitempty"`
+	Deadline            *time.Time     `json:"deadline,omitempty"`
+	RemainingSeconds    *int64         `json:"remaining_seconds,omitempty"` // 距截止时间的剩余秒数，仅运行中的实例返回
+	Archived            bool           `json:"archived,omitempty"`
+	ArchivedAt          *time.Time     `json:"archived_at,omitempty"`
+}
+
+// InstanceCount 按流程和状态分组的实例数
+type InstanceCount struct {
+	WorkflowID   string
+	WorkflowName string
+	Status       InstanceStatus
+	Count        int64
+}
+
+// InstanceStats 流程实例统计
+type InstanceStats struct {
+	Total      int64                    `json:"total"`
+	ByStatus   map[InstanceStatus]int64 `json:"by_status"`
+	ByWorkflow []*WorkflowInstanceStats `json:"by_workflow"` // 按实例数降序
+}
+
+// WorkflowInstanceStats 单个流程的实例统计
+type WorkflowInstanceStats struct {
+	WorkflowID   string                   `json:"workflow_id"`
+	WorkflowName string                   `json:"workflow_name"`
+	Total        int64                    `json:"total"`
+	ByStatus     map[InstanceStatus]int64 `json:"by_status"`
+}
+
+// ListInstances 按条件分页获取流程实例摘要及总数
+func (e *WorkflowEngineImpl) ListInstances(ctx context.Context, filter InstanceFilter) ([]*InstanceSummary, int64, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, 0, err
+	}
+	instances, total, err := e.instanceRepo.ListInstances(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	now := time.Now()
+	for _, instance := range instances {
+		instance.RemainingSeconds = remainingSeconds(instance.Status, instance.Deadline, now)
+	}
+	return instances, total, nil
+}
+
+// InstanceStats 按条件统计流程实例，按状态和流程分组，分页参数被忽略
+func (e *WorkflowEngineImpl) InstanceStats(ctx context.Context, filter InstanceFilter) (*InstanceStats, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+	counts, err := e.instanceRepo.CountInstances(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	return aggregateInstanceCounts(counts), nil
+}
+
+// aggregateInstanceCounts 将分组计数汇总为总数、状态分布和流程分布
+func aggregateInstanceCounts(counts []InstanceCount) *InstanceStats {
+	stats := &InstanceStats{
+		ByStatus:   make(map[InstanceStatus]int64),
+		ByWorkflow: make([]*WorkflowInstanceStats, 0),
+	}
+	byWorkflow := make(map[string]*WorkflowInstanceStats)
+	for _, count := range counts {
+		stats.Total += count.Count
+		stats.ByStatus[count.Status] += count.Count
+
+		workflowStats, ok := byWorkflow[count.WorkflowID]
+		if !ok {
+			workflowStats = &WorkflowInstanceStats{
+				WorkflowID: count.WorkflowID,
+				ByStatus:   make(map[InstanceStatus]int64),
+			}
+			byWorkflow[count.WorkflowID] = workflowStats
+			stats.ByWorkflow = append(stats.ByWorkflow, workflowStats)
+		}
+		if workflowStats.WorkflowName == "" {
+			workflowStats.WorkflowName = count.WorkflowName
+		}
+		workflowStats.Total += count.Count
+		workflowStats.ByStatus[count.Status] += count.Count
+	}
+
+	sort.Slice(stats.ByWorkflow, func(i, j int) bool {
+		if stats.ByWorkflow[i].Total != stats.ByWorkflow[j].Total {
+			return stats.ByWorkflow[i].Total > stats.ByWorkflow[j].Total
+		}
+		return stats.ByWorkflow[i].WorkflowID < stats.ByWorkflow[j].WorkflowID
+	})
+	return stats
+}
@@ -0,0 +1,60 @@
+package workflow
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingInstanceRepository 返回固定分组计数的实例仓储桩
+type countingInstanceRepository struct {
+	WorkflowInstanceRepository
+	counts  []InstanceCount
+	filters []InstanceFilter
+}
+
+func (r *countingInstanceRepository) CountInstances(ctx context.Context, filter InstanceFilter) ([]InstanceCount, error) {
+	r.filters = append(r.filters, filter)
+	return r.counts, nil
+}
+
+func TestInstanceStats_GroupsByStatusAndWorkflow(t *testing.T) {
+	repo := &countingInstanceRepository{counts: []InstanceCount{
+		{WorkflowID: "onboarding_approval", WorkflowName: "入职审批", Status: StatusRunning, Count: 1},
+		{WorkflowID: "task_assignment_approval", WorkflowName: "任务分配审批", Status: StatusCompleted, Count: 7},
+		{WorkflowID: "onboarding_approval", WorkflowName: "入职审批", Status: StatusExpired, Count: 2},
+		{WorkflowID: "task_assignment_approval", WorkflowName: "任务分配审批", Status: StatusRunning, Count: 2},
+	}}
+	engine := &WorkflowEngineImpl{instanceRepo: repo}
+
+	stats, err := engine.InstanceStats(context.Background(), InstanceFilter{BusinessType: "onboarding"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(12), stats.Total)
+	assert.Equal(t, map[InstanceStatus]int64{StatusRunning: 3, StatusCompleted: 7, StatusExpired: 2}, stats.ByStatus)
+
+	require.Len(t, stats.ByWorkflow, 2)
+	assert.Equal(t, "task_assignment_approval", stats.ByWorkflow[0].WorkflowID)
+	assert.Equal(t, int64(9), stats.ByWorkflow[0].Total)
+	assert.Equal(t, "入职审批", stats.ByWorkflow[1].WorkflowName)
+	assert.Equal(t, map[InstanceStatus]int64{StatusRunning: 1, StatusExpired: 2}, stats.ByWorkflow[1].ByStatus)
+	assert.Equal(t, "onboarding", repo.filters[0].BusinessType)
+}
+
+func TestInstanceFilter_Validate(t *testing.T) {
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 7)
+
+	assert.NoError(t, (&InstanceFilter{Status: StatusExpired, StartedFrom: &from, StartedTo: &to}).Validate())
+	assert.ErrorIs(t, (&InstanceFilter{Status: "stuck"}).Validate(), ErrInvalidInstanceFilter)
+	assert.ErrorIs(t, (&InstanceFilter{StartedFrom: &to, StartedTo: &from}).Validate(), ErrInvalidInstanceFilter)
+
+	// 参数不合法时不查询仓储
+	repo := &countingInstanceRepository{}
+	engine := &WorkflowEngineImpl{instanceRepo: repo}
+	_, err := engine.InstanceStats(context.Background(), InstanceFilter{StartedFrom: &to, StartedTo: &from})
+	assert.ErrorIs(t, err, ErrInvalidInstanceFilter)
+	assert.Empty(t, repo.filters)
+}
@@ -21,7 +21,7 @@ func NewNotificationService(instanceRepo WorkflowInstanceRepository) *Notificati
 
 // SendApprovalNotification 发送审批通知
 func (s *NotificationService) SendApprovalNotification(ctx context.Context, req *ApprovalNotificationRequest) error {
-	logger.Infof("发送审批通知: 实例=%s, 用户=%d", req.InstanceID, req.UserID)
+	logger.FromContext(ctx).Infof("发送审批通知: 实例=%s, 用户=%d", req.InstanceID, req.UserID)
 
 	// 获取流程实例信息
 	instance, err := s.instanceRepo.GetInstance(ctx, req.InstanceID)
@@ -53,13 +53,13 @@ func (s *NotificationService) SendApprovalNotification(ctx context.Context, req
 		return fmt.Errorf("发送通知失败: %w", err)
 	}
 
-	logger.Infof("审批通知发送成功: %s", notification.ID)
+	logger.FromContext(ctx).Infof("审批通知发送成功: %s", notification.ID)
 	return nil
 }
 
 // SendReminderNotification 发送提醒通知
 func (s *NotificationService) SendReminderNotification(ctx context.Context, req *ReminderNotificationRequest) error {
-	logger.Infof("发送提醒通知: 实例=%s, 用户=%d", req.InstanceID, req.UserID)
+	logger.FromContext(ctx).Infof("发送提醒通知: 实例=%s, 用户=%d", req.InstanceID, req.UserID)
 
 	notification := &Notification{
 		ID:         generateNotificationID(),
@@ -81,13 +81,13 @@ func (s *NotificationService) SendReminderNotification(ctx context.Context, req
 		return fmt.Errorf("发送提醒通知失败: %w", err)
 	}
 
-	logger.Infof("提醒通知发送成功: %s", notification.ID)
+	logger.FromContext(ctx).Infof("提醒通知发送成功: %s", notification.ID)
 	return nil
 }
 
 // SendCompletionNotification 发送完成通知
 func (s *NotificationService) SendCompletionNotification(ctx context.Context, req *CompletionNotificationRequest) error {
-	logger.Infof("发送完成通知: 实例=%s", req.InstanceID)
+	logger.FromContext(ctx).Infof("发送完成通知: 实例=%s", req.InstanceID)
 
 	// 获取所有相关用户
 	recipients := s.getCompletionRecipients(ctx, req)
@@ -109,7 +109,7 @@ func (s *NotificationService) SendCompletionNotification(ctx context.Context, re
 		}
 
 		if err := s.sendNotification(ctx, notification); err != nil {
-			logger.Errorf("发送完成通知失败: 用户=%d, error=%v", userID, err)
+			logger.FromContext(ctx).Errorf("发送完成通知失败: 用户=%d, error=%v", userID, err)
 		}
 	}
 
@@ -122,7 +122,7 @@ func (s *NotificationService) ScheduleReminders(ctx context.Context, instanceID
 		return nil // 没有截止时间，不需要提醒
 	}
 
-	logger.Infof("安排提醒任务: 实例=%s, 节点=%s, 用户=%d", instanceID, nodeID, userID)
+	logger.FromContext(ctx).Infof("安排提醒任务: 实例=%s, 节点=%s, 用户=%d", instanceID, nodeID, userID)
 
 	// 计算提醒时间点
 	reminderTimes := s.calculateReminderTimes(*deadline)
@@ -140,7 +140,7 @@ func (s *NotificationService) ScheduleReminders(ctx context.Context, instanceID
 		}
 
 		// 这里应该将提醒任务保存到数据库或消息队列
-		logger.Infof("安排提醒: %s 在 %s", reminder.ReminderType, reminderTime.Format("2006-01-02 15:04:05"))
+		logger.FromContext(ctx).Infof("安排提醒: %s 在 %s", reminder.ReminderType, reminderTime.Format("2006-01-02 15:04:05"))
 	}
 
 	return nil
@@ -150,14 +150,14 @@ func (s *NotificationService) ScheduleReminders(ctx context.Context, instanceID
 func (s *NotificationService) ProcessScheduledReminders(ctx context.Context) error {
 	// 这里应该从数据库查询到期的提醒任务
 	// 简化实现，模拟处理逻辑
-	logger.Info("处理预定的提醒任务")
+	logger.FromContext(ctx).Info("处理预定的提醒任务")
 
 	// 模拟查询到期的提醒
 	dueReminders := s.getDueReminders(ctx)
 
 	for _, reminder := range dueReminders {
 		if err := s.processReminder(ctx, reminder); err != nil {
-			logger.Errorf("处理提醒失败: %s, error: %v", reminder.ID, err)
+			logger.FromContext(ctx).Errorf("处理提醒失败: %s, error: %v", reminder.ID, err)
 		}
 	}
 
@@ -177,7 +177,7 @@ func (s *NotificationService) sendNotification(ctx context.Context, notification
 		// 如果是高优先级，同时发送邮件
 		if notification.Priority == NotificationPriorityHigh {
 			if err := s.sendEmailNotification(ctx, notification); err != nil {
-				logger.Errorf("发送邮件通知失败: %v", err)
+				logger.FromContext(ctx).Errorf("发送邮件通知失败: %v", err)
 			}
 		}
 	case NotificationTypeCompletion:
@@ -193,14 +193,14 @@ func (s *NotificationService) sendNotification(ctx context.Context, notification
 // sendSystemNotification 发送系统通知
 func (s *NotificationService) sendSystemNotification(ctx context.Context, notification *Notification) error {
 	// 这里应该调用系统通知服务
-	logger.Infof("发送系统通知: 用户=%d, 标题=%s", notification.Recipient, notification.Title)
+	logger.FromContext(ctx).Infof("发送系统通知: 用户=%d, 标题=%s", notification.Recipient, notification.Title)
 	return nil
 }
 
 // sendEmailNotification 发送邮件通知
 func (s *NotificationService) sendEmailNotification(ctx context.Context, notification *Notification) error {
 	// 这里应该调用邮件服务
-	logger.Infof("发送邮件通知: 用户=%d, 标题=%s", notification.Recipient, notification.Title)
+	logger.FromContext(ctx).Infof("发送邮件通知: 用户=%d, 标题=%s", notification.Recipient, notification.Title)
 	return nil
 }
 
@@ -0,0 +1,121 @@
+package workflow
+
+import (
+	"errors"
+	"fmt"
+)
+
+// 入职审批流程类型
+const (
+	OnboardingWorkflowSimple = "simple" // 简化流程：HR审批
+	OnboardingWorkflowFull   = "full"   // 完整流程：HR → 部门负责人 → 管理员
+)
+
+// 入职审批流程定义ID
+const (
+	SimpleOnboardingWorkflowID = "simple-onboarding-approval-v1"
+	FullOnboardingWorkflowID   = "full-onboarding-approval-v1"
+)
+
+// OnboardingAdminRole 完整入职流程最后一级审批的角色
+const OnboardingAdminRole = "admin"
+
+// ErrUnknownOnboardingWorkflowType 入职审批流程类型不是simple或full
+var ErrUnknownOnboardingWorkflowType = errors.New("未知的入职审批流程类型")
+
+// OnboardingWorkflowID 返回入职审批流程类型对应的流程定义ID
+func OnboardingWorkflowID(workflowType string) (string, error) {
+	switch workflowType {
+	case OnboardingWorkflowSimple:
+		return SimpleOnboardingWorkflowID, nil
+	case OnboardingWorkflowFull:
+		return FullOnboardingWorkflowID, nil
+	default:
+		return "", fmt.Errorf("%w: %q，可选值为 %s、%s", ErrUnknownOnboardingWorkflowType, workflowType, OnboardingWorkflowSimple, OnboardingWorkflowFull)
+	}
+}
+
+// OnboardingWorkflowType 返回入职审批实例使用的流程类型
+// 优先取启动时记录的workflow_type变量，早于流程类型选择启动的实例按流程定义ID推断
+func OnboardingWorkflowType(instance *WorkflowInstance) string {
+	if workflowType, ok := instance.Variables["workflow_type"].(string); ok && workflowType != "" {
+		return workflowType
+	}
+	if instance.WorkflowID == FullOnboardingWorkflowID {
+		return OnboardingWorkflowFull
+	}
+	return OnboardingWorkflowSimple
+}
+
+// OnboardingWorkflowDefinitions 默认的入职审批流程定义，hrRole为HR审批节点的角色
+// 完整流程的部门负责人审批人取启动时写入的department_manager_id变量；各级审批拒绝时流程随拒绝结束
+func OnboardingWorkflowDefinitions(hrRole string) []*CreateWorkflowRequest {
+	hrApproval := WorkflowNode{
+		ID:   "hr_approval",
+		Type: NodeTypeApproval,
+		Name: "HR审批",
+		Config: map[string]interface{}{
+			"assignees":     []ApprovalAssignee{{Type: AssigneeTypeRole, Value: hrRole}},
+			"approval_type": ApprovalTypeAny,
+			"description":   "HR审核入职资料",
+		},
+		Position: NodePosition{X: 300, Y: 100},
+	}
+
+	return []*CreateWorkflowRequest{
+		{
+			ID:          SimpleOnboardingWorkflowID,
+			Name:        "简化入职审批流程",
+			Description: "HR审批通过后即完成入职审批",
+			Version:     "1.0",
+			Nodes: []WorkflowNode{
+				{ID: "start", Type: NodeTypeStart, Name: "开始", Position: NodePosition{X: 100, Y: 100}},
+				hrApproval,
+				{ID: "end", Type: NodeTypeEnd, Name: "结束", Position: NodePosition{X: 500, Y: 100}},
+			},
+			Edges: []WorkflowEdge{
+				{ID: "start_to_hr", From: "start", To: "hr_approval"},
+				{ID: "hr_to_end", From: "hr_approval", To: "end", Condition: "approved"},
+			},
+		},
+		{
+			ID:          FullOnboardingWorkflowID,
+			Name:        "完整入职审批流程",
+			Description: "依次经HR、部门负责人和管理员审批",
+			Version:     "1.0",
+			Nodes: []WorkflowNode{
+				{ID: "start", Type: NodeTypeStart, Name: "开始", Position: NodePosition{X: 100, Y: 100}},
+				hrApproval,
+				{
+					ID:   "department_manager_approval",
+					Type: NodeTypeApproval,
+					Name: "部门负责人审批",
+					Config: map[string]interface{}{
+						"assignees":     []ApprovalAssignee{{Type: AssigneeTypeVariable, Value: "department_manager_id"}},
+						"approval_type": ApprovalTypeAny,
+						"description":   "入职部门负责人审批",
+					},
+					Position: NodePosition{X: 500, Y: 100},
+				},
+				{
+					ID:   "admin_approval",
+					Type: NodeTypeApproval,
+					Name: "管理员审批",
+					Config: map[string]interface{}{
+						"assignees":     []ApprovalAssignee{{Type: AssigneeTypeRole, Value: OnboardingAdminRole}},
+						"approval_type": ApprovalTypeAny,
+						"description":   "管理员最终审批",
+					},
+					Position: NodePosition{X: 700, Y: 100},
+				},
+				{ID: "end", Type: NodeTypeEnd, Name: "结束", Position: NodePosition{X: 900, Y: 100}},
+			},
+			Edges: []WorkflowEdge{
+				{ID: "start_to_hr", From: "start", To: "hr_approval"},
+				{ID: "hr_to_manager", From: "hr_approval", To: "department_manager_approval", Condition: "approved"},
+				{ID: "manager_to_admin", From: "department_manager_approval", To: "admin_approval", Condition: "approved"},
+				{ID: "admin_to_end", From: "admin_approval", To: "end", Condition: "approved"},
+			},
+		},
+	}
+}
@@ -0,0 +1,145 @@
+package workflow
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/database"
+	"taskmanage/internal/repository"
+)
+
+// definitionMapRepository 按流程ID保存流程定义的内存仓储
+type definitionMapRepository struct {
+	WorkflowRepository
+	definitions map[string]*WorkflowDefinition
+}
+
+func (r *definitionMapRepository) SaveWorkflowDefinition(ctx context.Context, definition *WorkflowDefinition) error {
+	definition.VersionID = uint(len(r.definitions) + 1)
+	r.definitions[definition.ID] = definition
+	return nil
+}
+
+func (r *definitionMapRepository) GetWorkflowDefinition(ctx context.Context, workflowID string) (*WorkflowDefinition, error) {
+	return r.definitions[workflowID], nil
+}
+
+func (r *definitionMapRepository) GetWorkflowDefinitionVersion(ctx context.Context, versionID uint) (*WorkflowDefinition, error) {
+	for _, definition := range r.definitions {
+		if definition.VersionID == versionID {
+			return definition, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+// onboardingInstanceRepository 员工没有运行中入职审批的内存实例仓储
+type onboardingInstanceRepository struct {
+	*startedInstanceRepository
+}
+
+func (r *onboardingInstanceRepository) FindRunningByBusiness(ctx context.Context, businessType, businessID string) (*WorkflowInstance, error) {
+	return nil, nil
+}
+
+// newOnboardingWorkflowService 创建注册了默认入职审批流程定义的流程服务，HR角色的审批人为用户11，管理员为用户13
+func newOnboardingWorkflowService(t *testing.T) (*WorkflowService, *onboardingInstanceRepository) {
+	definitions := NewWorkflowDefinitionManager(&definitionMapRepository{definitions: map[string]*WorkflowDefinition{}})
+	for _, req := range OnboardingWorkflowDefinitions("hr") {
+		_, err := definitions.CreateWorkflow(context.Background(), req)
+		require.NoError(t, err)
+	}
+
+	repo := &onboardingInstanceRepository{startedInstanceRepository: &startedInstanceRepository{approvalInstanceRepository: &approvalInstanceRepository{
+		memoryInstanceRepository: &memoryInstanceRepository{history: map[string][]ExecutionHistory{}, approvals: map[string][]*PendingApproval{}},
+	}}}
+	userRepo := &roleUserRepository{matches: map[string][]*repository.RoleUserMatch{
+		"hr":                {{User: &database.User{BaseModel: database.BaseModel{ID: 11}}, MatchedRoles: []string{"hr"}}},
+		OnboardingAdminRole: {{User: &database.User{BaseModel: database.BaseModel{ID: 13}}, MatchedRoles: []string{OnboardingAdminRole}}},
+	}}
+	engine := &WorkflowEngineImpl{
+		definitionManager:          definitions,
+		instanceRepo:               repo,
+		onboardingExecutorRegistry: NewOnboardingExecutorRegistry(repo, nil, userRepo),
+	}
+	return &WorkflowService{engine: engine, definitionManager: definitions}, repo
+}
+
+func TestOnboardingWorkflows_SimpleCompletesAfterHRApproval(t *testing.T) {
+	service, repo := newOnboardingWorkflowService(t)
+	ctx := context.Background()
+	departmentID := uint(2)
+
+	instance, err := service.StartOnboardingApproval(ctx, &OnboardingApprovalRequest{EmployeeID: 8, DepartmentID: &departmentID, RequesterID: 1})
+	require.NoError(t, err)
+	assert.Equal(t, SimpleOnboardingWorkflowID, instance.WorkflowID)
+	assert.Equal(t, OnboardingWorkflowSimple, OnboardingWorkflowType(instance))
+	assert.Equal(t, []string{"hr_approval"}, instance.CurrentNodes)
+
+	result, err := service.ProcessOnboardingApproval(ctx, &ApprovalRequest{InstanceID: instance.ID, NodeID: "hr_approval", Action: ActionApprove, ApprovedBy: 11})
+	require.NoError(t, err)
+	assert.True(t, result.IsCompleted)
+	assert.Equal(t, StatusCompleted, repo.instance.Status)
+}
+
+func TestOnboardingWorkflows_FullRequiresThreeApprovals(t *testing.T) {
+	service, repo := newOnboardingWorkflowService(t)
+	ctx := context.Background()
+	departmentID, managerID := uint(2), uint(12)
+
+	// 完整流程必须提供部门负责人
+	_, err := service.StartOnboardingApproval(ctx, &OnboardingApprovalRequest{EmployeeID: 8, DepartmentID: &departmentID, WorkflowType: OnboardingWorkflowFull})
+	assert.ErrorIs(t, err, ErrInvalidVariable)
+
+	instance, err := service.StartOnboardingApproval(ctx, &OnboardingApprovalRequest{
+		EmployeeID:          8,
+		DepartmentID:        &departmentID,
+		RequesterID:         1,
+		WorkflowType:        OnboardingWorkflowFull,
+		DepartmentManagerID: &managerID,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, FullOnboardingWorkflowID, instance.WorkflowID)
+	assert.Equal(t, OnboardingWorkflowFull, OnboardingWorkflowType(instance))
+
+	// HR、部门负责人、管理员依次审批，前两级通过后流程仍在运行
+	steps := []struct {
+		node     string
+		approver uint
+		next     string
+	}{
+		{node: "hr_approval", approver: 11, next: "department_manager_approval"},
+		{node: "department_manager_approval", approver: managerID, next: "admin_approval"},
+	}
+	for _, step := range steps {
+		require.Equal(t, []string{step.node}, repo.instance.CurrentNodes)
+		result, err := service.ProcessOnboardingApproval(ctx, &ApprovalRequest{InstanceID: instance.ID, NodeID: step.node, Action: ActionApprove, ApprovedBy: step.approver})
+		require.NoError(t, err)
+		assert.False(t, result.IsCompleted, step.node)
+		assert.Equal(t, StatusRunning, repo.instance.Status)
+		assert.Equal(t, []string{step.next}, repo.instance.CurrentNodes)
+	}
+	require.Len(t, repo.approvals[instance.ID+"/department_manager_approval"], 1)
+	assert.Equal(t, managerID, repo.approvals[instance.ID+"/department_manager_approval"][0].AssignedTo)
+
+	result, err := service.ProcessOnboardingApproval(ctx, &ApprovalRequest{InstanceID: instance.ID, NodeID: "admin_approval", Action: ActionApprove, ApprovedBy: 13})
+	require.NoError(t, err)
+	assert.True(t, result.IsCompleted)
+	assert.Equal(t, StatusCompleted, repo.instance.Status)
+}
+
+func TestOnboardingWorkflowID_UnknownType(t *testing.T) {
+	_, err := OnboardingWorkflowID("express")
+	assert.ErrorIs(t, err, ErrUnknownOnboardingWorkflowType)
+	assert.ErrorContains(t, err, "simple")
+
+	_, err = (&WorkflowService{}).StartOnboardingApproval(context.Background(), &OnboardingApprovalRequest{EmployeeID: 8, WorkflowType: "express"})
+	assert.ErrorIs(t, err, ErrUnknownOnboardingWorkflowType)
+
+	// 早于流程类型选择启动的实例按流程定义ID推断类型
+	assert.Equal(t, OnboardingWorkflowFull, OnboardingWorkflowType(&WorkflowInstance{WorkflowID: FullOnboardingWorkflowID}))
+	assert.Equal(t, OnboardingWorkflowSimple, OnboardingWorkflowType(&WorkflowInstance{WorkflowID: SimpleOnboardingWorkflowID}))
+}
@@ -0,0 +1,179 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// 待审批列表排序字段
+const (
+	PendingSortCreatedAt = "created_at"
+	PendingSortPriority  = "priority"
+	PendingSortUrgency   = "urgency" // 已逾期在前，再按优先级降序、截止时间升序、创建时间升序
+)
+
+// 待审批列表分组字段
+const (
+	PendingGroupByWorkflowName = "workflow_name"
+	PendingGroupByBusinessType = "business_type"
+)
+
+// ErrInvalidPendingApprovalFilter 待审批查询条件不合法
+var ErrInvalidPendingApprovalFilter = errors.New("待审批查询条件不合法")
+
+// PendingApprovalFilter 待审批查询条件，PageSize为0时返回全部记录
+type PendingApprovalFilter struct {
+	UserID         uint       `json:"user_id"`
+	WorkflowName   string     `json:"workflow_name,omitempty"`
+	BusinessType   string     `json:"business_type,omitempty"`
+	DeadlineBefore *time.Time `json:"deadline_before,omitempty"`
+	SortBy         string     `json:"sort_by,omitempty"`    // created_at、priority 或 urgency，默认按优先级降序、创建时间升序
+	SortOrder      string     `json:"sort_order,omitempty"` // asc 或 desc；created_at默认升序，priority默认降序，urgency忽略该参数
+	GroupBy        string     `json:"group_by,omitempty"`   // workflow_name 或 business_type，由调用方对结果分组
+	Page           int        `json:"page,omitempty"`
+	PageSize       int        `json:"page_size,omitempty"`
+	Now            time.Time  `json:"-"` // 判断逾期的参考时间，为零值时取当前时间
+}
+
+// Validate 校验排序参数
+func (f *PendingApprovalFilter) Validate() error {
+	switch f.SortBy {
+	case "", PendingSortCreatedAt, PendingSortPriority, PendingSortUrgency:
+	default:
+		return fmt.Errorf("%w: sort_by仅支持created_at、priority或urgency", ErrInvalidPendingApprovalFilter)
+	}
+	switch f.SortOrder {
+	case "", "asc", "desc":
+	default:
+		return fmt.Errorf("%w: sort_order仅支持asc或desc", ErrInvalidPendingApprovalFilter)
+	}
+	switch f.GroupBy {
+	case "", PendingGroupByWorkflowName, PendingGroupByBusinessType:
+	default:
+		return fmt.Errorf("%w: group_by仅支持workflow_name或business_type", ErrInvalidPendingApprovalFilter)
+	}
+	return nil
+}
+
+// SortDesc 是否降序，未指定方向时priority降序、created_at升序
+func (f *PendingApprovalFilter) SortDesc() bool {
+	if f.SortOrder == "" {
+		return f.SortBy == PendingSortPriority
+	}
+	return f.SortOrder == "desc"
+}
+
+// now 返回判断逾期的参考时间
+func (f *PendingApprovalFilter) now() time.Time {
+	if f.Now.IsZero() {
+		return time.Now()
+	}
+	return f.Now
+}
+
+// IsOverdue 截止时间早于now时视为逾期，没有截止时间的待审批不会逾期
+func (a *PendingApproval) IsOverdue(now time.Time) bool {
+	return a.Deadline != nil && a.Deadline.Before(now)
+}
+
+// PendingApprovalGroupCount 按流程名称和业务类型统计的待审批数
+type PendingApprovalGroupCount struct {
+	WorkflowName string
+	BusinessType string
+	Count        int64
+	OverdueCount int64
+}
+
+// PendingApprovalSummary 待审批汇总，统计全部符合条件的记录，不受分页影响
+type PendingApprovalSummary struct {
+	Total        int64            `json:"total"`
+	OverdueCount int64            `json:"overdue_count"`
+	ByType       map[string]int64 `json:"by_type"` // 按业务类型统计
+
+	counts []*PendingApprovalGroupCount
+}
+
+// PendingApprovalGroup 待审批分组，Count和OverdueCount为该分组全部记录数，Items只包含当前页的记录
+type PendingApprovalGroup struct {
+	Count        int64              `json:"count"`
+	OverdueCount int64              `json:"overdue_count"`
+	Items        []*PendingApproval `json:"items"`
+}
+
+// NewPendingApprovalSummary 由分组统计生成汇总
+func NewPendingApprovalSummary(counts []*PendingApprovalGroupCount) *PendingApprovalSummary {
+	summary := &PendingApprovalSummary{ByType: make(map[string]int64), counts: counts}
+	for _, count := range counts {
+		summary.Total += count.Count
+		summary.OverdueCount += count.OverdueCount
+		summary.ByType[count.BusinessType] += count.Count
+	}
+	return summary
+}
+
+// Group 按groupBy对当前页的待审批分组，分组内保持原有顺序，并附带该分组的全部记录数
+func (s *PendingApprovalSummary) Group(approvals []*PendingApproval, groupBy string) map[string]*PendingApprovalGroup {
+	groups := make(map[string]*PendingApprovalGroup)
+	group := func(key string) *PendingApprovalGroup {
+		g, ok := groups[key]
+		if !ok {
+			g = &PendingApprovalGroup{Items: []*PendingApproval{}}
+			groups[key] = g
+		}
+		return g
+	}
+	for _, count := range s.counts {
+		g := group(pendingGroupKey(groupBy, count.WorkflowName, count.BusinessType))
+		g.Count += count.Count
+		g.OverdueCount += count.OverdueCount
+	}
+	for _, approval := range approvals {
+		g := group(pendingGroupKey(groupBy, approval.WorkflowName, approval.BusinessType))
+		g.Items = append(g.Items, approval)
+	}
+	return groups
+}
+
+// pendingGroupKey 返回待审批所属分组
+func pendingGroupKey(groupBy, workflowName, businessType string) string {
+	if groupBy == PendingGroupByBusinessType {
+		return businessType
+	}
+	return workflowName
+}
+
+// GetPendingApprovals 按条件分页获取待审批任务及总数，不属于查询用户本人的记录中的敏感变量会被脱敏
+func (e *WorkflowEngineImpl) GetPendingApprovals(ctx context.Context, filter PendingApprovalFilter) ([]*PendingApproval, int64, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, 0, err
+	}
+	filter.Now = filter.now()
+	approvals, total, err := e.instanceRepo.GetPendingApprovals(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, approval := range approvals {
+		approval.Overdue = approval.IsOverdue(filter.Now)
+	}
+	if err := e.redactPendingApprovals(ctx, approvals, VariableViewer{UserID: filter.UserID}); err != nil {
+		return nil, 0, err
+	}
+	return approvals, total, nil
+}
+
+// CountPendingApprovals 按条件统计待审批任务数，分页和排序参数被忽略
+func (e *WorkflowEngineImpl) CountPendingApprovals(ctx context.Context, filter PendingApprovalFilter) (int64, error) {
+	return e.instanceRepo.CountPendingApprovals(ctx, filter)
+}
+
+// SummarizePendingApprovals 按条件汇总待审批总数、逾期数和各业务类型的数量，分页和排序参数被忽略
+func (e *WorkflowEngineImpl) SummarizePendingApprovals(ctx context.Context, filter PendingApprovalFilter) (*PendingApprovalSummary, error) {
+	filter.Now = filter.now()
+	counts, err := e.instanceRepo.CountPendingApprovalGroups(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	return NewPendingApprovalSummary(counts), nil
+}
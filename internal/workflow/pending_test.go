@@ -0,0 +1,102 @@
+package workflow
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPendingApprovalFilter_Sort(t *testing.T) {
+	assert.NoError(t, (&PendingApprovalFilter{}).Validate())
+	assert.ErrorIs(t, (&PendingApprovalFilter{SortBy: "deadline"}).Validate(), ErrInvalidPendingApprovalFilter)
+	assert.ErrorIs(t, (&PendingApprovalFilter{SortBy: PendingSortPriority, SortOrder: "up"}).Validate(), ErrInvalidPendingApprovalFilter)
+
+	// 未指定方向时优先级降序、创建时间升序
+	assert.True(t, (&PendingApprovalFilter{SortBy: PendingSortPriority}).SortDesc())
+	assert.False(t, (&PendingApprovalFilter{SortBy: PendingSortCreatedAt}).SortDesc())
+	assert.True(t, (&PendingApprovalFilter{SortBy: PendingSortCreatedAt, SortOrder: "desc"}).SortDesc())
+
+	assert.NoError(t, (&PendingApprovalFilter{SortBy: PendingSortUrgency, GroupBy: PendingGroupByBusinessType}).Validate())
+	assert.ErrorIs(t, (&PendingApprovalFilter{GroupBy: "node_name"}).Validate(), ErrInvalidPendingApprovalFilter)
+}
+
+// pendingInstanceRepository 返回固定待审批记录的实例仓储桩
+type pendingInstanceRepository struct {
+	WorkflowInstanceRepository
+	approvals []*PendingApproval
+	counts    []*PendingApprovalGroupCount
+	filter    PendingApprovalFilter
+}
+
+func (r *pendingInstanceRepository) GetPendingApprovals(ctx context.Context, filter PendingApprovalFilter) ([]*PendingApproval, int64, error) {
+	r.filter = filter
+	return r.approvals, int64(len(r.approvals)), nil
+}
+
+func (r *pendingInstanceRepository) CountPendingApprovalGroups(ctx context.Context, filter PendingApprovalFilter) ([]*PendingApprovalGroupCount, error) {
+	r.filter = filter
+	return r.counts, nil
+}
+
+func TestGetPendingApprovals_MarksOverdue(t *testing.T) {
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	past, future := now.Add(-time.Minute), now.Add(time.Hour)
+	repo := &pendingInstanceRepository{approvals: []*PendingApproval{
+		{InstanceID: "past", Deadline: &past},
+		{InstanceID: "future", Deadline: &future},
+		{InstanceID: "none"},
+	}}
+	engine := &WorkflowEngineImpl{instanceRepo: repo}
+
+	approvals, total, err := engine.GetPendingApprovals(context.Background(), PendingApprovalFilter{UserID: 1, Now: now})
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, total)
+	assert.True(t, approvals[0].Overdue)
+	assert.False(t, approvals[1].Overdue)
+	assert.False(t, approvals[2].Overdue)
+	assert.Equal(t, now, repo.filter.Now)
+
+	// 未指定参考时间时取当前时间
+	_, _, err = engine.GetPendingApprovals(context.Background(), PendingApprovalFilter{UserID: 1})
+	require.NoError(t, err)
+	assert.False(t, repo.filter.Now.IsZero())
+}
+
+func TestPendingApprovalSummary_Group(t *testing.T) {
+	repo := &pendingInstanceRepository{counts: []*PendingApprovalGroupCount{
+		{WorkflowName: "入职审批", BusinessType: "onboarding", Count: 5, OverdueCount: 2},
+		{WorkflowName: "任务分配审批", BusinessType: "task_assignment", Count: 3, OverdueCount: 0},
+		{WorkflowName: "入职加急审批", BusinessType: "onboarding", Count: 1, OverdueCount: 1},
+	}}
+	engine := &WorkflowEngineImpl{instanceRepo: repo}
+
+	summary, err := engine.SummarizePendingApprovals(context.Background(), PendingApprovalFilter{UserID: 1})
+	require.NoError(t, err)
+	assert.EqualValues(t, 9, summary.Total)
+	assert.EqualValues(t, 3, summary.OverdueCount)
+	assert.Equal(t, map[string]int64{"onboarding": 6, "task_assignment": 3}, summary.ByType)
+
+	page := []*PendingApproval{
+		{InstanceID: "a", WorkflowName: "入职审批", BusinessType: "onboarding"},
+		{InstanceID: "b", WorkflowName: "入职加急审批", BusinessType: "onboarding"},
+		{InstanceID: "c", WorkflowName: "入职审批", BusinessType: "onboarding"},
+	}
+
+	byType := summary.Group(page, PendingGroupByBusinessType)
+	require.Len(t, byType, 2)
+	assert.EqualValues(t, 6, byType["onboarding"].Count)
+	assert.EqualValues(t, 3, byType["onboarding"].OverdueCount)
+	assert.Len(t, byType["onboarding"].Items, 3)
+	// 当前页没有记录的分组也返回计数
+	assert.EqualValues(t, 3, byType["task_assignment"].Count)
+	assert.Empty(t, byType["task_assignment"].Items)
+
+	byWorkflow := summary.Group(page, PendingGroupByWorkflowName)
+	require.Len(t, byWorkflow, 3)
+	assert.EqualValues(t, 5, byWorkflow["入职审批"].Count)
+	assert.Equal(t, "a", byWorkflow["入职审批"].Items[0].InstanceID)
+	assert.Equal(t, "c", byWorkflow["入职审批"].Items[1].InstanceID)
+}
@@ -0,0 +1,106 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+
+	"taskmanage/pkg/logger"
+)
+
+// RecoveryReport 启动恢复结果汇总
+type RecoveryReport struct {
+	Scanned   int             `json:"scanned"`   // 扫描的运行中实例数
+	Recovered []RecoveredNode `json:"recovered"` // 已重新执行的节点
+	Failed    []RecoveredNode `json:"failed"`    // 重新执行失败的节点
+}
+
+// RecoveredNode 被恢复的节点
+type RecoveredNode struct {
+	InstanceID string `json:"instance_id"`
+	NodeID     string `json:"node_id"`
+	Error      string `json:"error,omitempty"`
+}
+
+// RecoverInstances 扫描运行中的实例，重新执行已进入活跃节点但结果未落库的节点
+// 节点结果与待审批记录在同一事务中写入，重复执行会先清理该节点未完成的待审批记录，因此恢复是幂等的
+func (e *WorkflowEngineImpl) RecoverInstances(ctx context.Context) (*RecoveryReport, error) {
+	instances, err := e.instanceRepo.ListInstancesByStatus(ctx, StatusRunning)
+	if err != nil {
+		return nil, fmt.Errorf("获取运行中的流程实例失败: %w", err)
+	}
+
+	report := &RecoveryReport{Scanned: len(instances)}
+	for _, instance := range instances {
+		stalled, err := e.findStalledNodes(ctx, instance)
+		if err != nil {
+			logger.Errorf("检查流程实例失败: %s, error: %v", instance.ID, err)
+			report.Failed = append(report.Failed, RecoveredNode{InstanceID: instance.ID, Error: err.Error()})
+			continue
+		}
+		if len(stalled) == 0 {
+			continue
+		}
+		if instance.Variables == nil {
+			instance.Variables = make(map[string]interface{})
+		}
+
+		definition, err := e.definitionManager.GetWorkflowForInstance(ctx, instance)
+		if err != nil {
+			logger.Errorf("获取流程定义失败: %s, error: %v", instance.ID, err)
+			for _, nodeID := range stalled {
+				report.Failed = append(report.Failed, RecoveredNode{InstanceID: instance.ID, NodeID: nodeID, Error: err.Error()})
+			}
+			continue
+		}
+
+		for _, nodeID := range stalled {
+			node := e.findNodeByID(definition, nodeID)
+			if node == nil {
+				report.Failed = append(report.Failed, RecoveredNode{InstanceID: instance.ID, NodeID: nodeID, Error: "流程定义中未找到节点"})
+				continue
+			}
+
+			logger.Infof("恢复中断的节点执行: 实例=%s, 节点=%s", instance.ID, nodeID)
+			if err := e.executeNode(ctx, instance, definition, node); err != nil {
+				logger.Errorf("恢复节点执行失败: 实例=%s, 节点=%s, error: %v", instance.ID, nodeID, err)
+				report.Failed = append(report.Failed, RecoveredNode{InstanceID: instance.ID, NodeID: nodeID, Error: err.Error()})
+				continue
+			}
+			report.Recovered = append(report.Recovered, RecoveredNode{InstanceID: instance.ID, NodeID: nodeID})
+		}
+	}
+
+	return report, nil
+}
+
+// findStalledNodes 找出实例中执行结果未落库的活跃节点
+// 节点没有未完成的待审批记录，且最近一条执行历史不是该节点的execute记录时，视为执行中断
+func (e *WorkflowEngineImpl) findStalledNodes(ctx context.Context, instance *WorkflowInstance) ([]string, error) {
+	if len(instance.CurrentNodes) == 0 {
+		return nil, nil
+	}
+
+	history, err := e.instanceRepo.GetExecutionHistory(ctx, instance.ID)
+	if err != nil {
+		return nil, fmt.Errorf("获取执行历史失败: %w", err)
+	}
+	lastAction := make(map[string]string, len(history))
+	for _, h := range history {
+		lastAction[h.NodeID] = h.Action
+	}
+
+	var stalled []string
+	for _, nodeID := range instance.CurrentNodes {
+		hasApprovals, err := e.instanceRepo.HasOpenPendingApprovals(ctx, instance.ID, nodeID)
+		if err != nil {
+			return nil, fmt.Errorf("检查待审批记录失败: %w", err)
+		}
+		if hasApprovals || lastAction[nodeID] == "execute" {
+			continue
+		}
+		if !e.containsNode(stalled, nodeID) {
+			stalled = append(stalled, nodeID)
+		}
+	}
+	return stalled, nil
+}
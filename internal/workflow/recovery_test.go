@@ -0,0 +1,122 @@
+package workflow
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryInstanceRepository 记录节点执行结果的内存实例仓储
+type memoryInstanceRepository struct {
+	WorkflowInstanceRepository
+	instances []*WorkflowInstance
+	history   map[string][]ExecutionHistory
+	approvals map[string][]*PendingApproval // key: instanceID/nodeID
+	saved     []ExecutionHistory
+	mu        sync.Mutex
+}
+
+func (r *memoryInstanceRepository) LockInstance(ctx context.Context, instanceID string, fn func(ctx context.Context) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return fn(ctx)
+}
+
+func (r *memoryInstanceRepository) ListInstancesByStatus(ctx context.Context, status InstanceStatus) ([]*WorkflowInstance, error) {
+	var result []*WorkflowInstance
+	for _, instance := range r.instances {
+		if instance.Status == status {
+			result = append(result, instance)
+		}
+	}
+	return result, nil
+}
+
+func (r *memoryInstanceRepository) GetExecutionHistory(ctx context.Context, instanceID string) ([]ExecutionHistory, error) {
+	return r.history[instanceID], nil
+}
+
+func (r *memoryInstanceRepository) HasOpenPendingApprovals(ctx context.Context, instanceID, nodeID string) (bool, error) {
+	return len(r.approvals[instanceID+"/"+nodeID]) > 0, nil
+}
+
+func (r *memoryInstanceRepository) SaveNodeExecutionResult(ctx context.Context, instance *WorkflowInstance, history ExecutionHistory, approvals []*PendingApproval) error {
+	if len(approvals) > 0 {
+		r.approvals[instance.ID+"/"+history.NodeID] = approvals
+	}
+	r.history[instance.ID] = append(r.history[instance.ID], history)
+	r.saved = append(r.saved, history)
+	return nil
+}
+
+// versionWorkflowRepository 按版本返回固定流程定义的仓储桩
+type versionWorkflowRepository struct {
+	WorkflowRepository
+	definition *WorkflowDefinition
+}
+
+func (r *versionWorkflowRepository) GetWorkflowDefinitionVersion(ctx context.Context, versionID uint) (*WorkflowDefinition, error) {
+	return r.definition, nil
+}
+
+func TestRecoverInstances(t *testing.T) {
+	definition := &WorkflowDefinition{
+		ID:        "leave",
+		VersionID: 1,
+		Nodes: []WorkflowNode{
+			{ID: "start", Type: NodeTypeStart, Name: "开始"},
+			{ID: "approve", Type: NodeTypeApproval, Name: "主管审批", Config: map[string]interface{}{
+				"assignees": []map[string]interface{}{{"type": "starter"}},
+			}},
+			{ID: "end", Type: NodeTypeEnd, Name: "结束"},
+		},
+		Edges: []WorkflowEdge{{ID: "e1", From: "start", To: "approve"}, {ID: "e2", From: "approve", To: "end"}},
+	}
+
+	newInstance := func(id string) *WorkflowInstance {
+		return &WorkflowInstance{
+			ID:                  id,
+			WorkflowID:          "leave",
+			DefinitionVersionID: 1,
+			Status:              StatusRunning,
+			CurrentNodes:        []string{"approve"},
+			StartedBy:           9,
+		}
+	}
+	repo := &memoryInstanceRepository{
+		instances: []*WorkflowInstance{newInstance("stalled"), newInstance("waiting"), newInstance("executed")},
+		history: map[string][]ExecutionHistory{
+			"stalled":  {{NodeID: "start", Action: "execute"}},
+			"executed": {{NodeID: "approve", Action: "execute"}},
+		},
+		approvals: map[string][]*PendingApproval{
+			"waiting/approve": {{InstanceID: "waiting", NodeID: "approve", AssignedTo: 9}},
+		},
+	}
+	engine := &WorkflowEngineImpl{
+		definitionManager:    NewWorkflowDefinitionManager(&versionWorkflowRepository{definition: definition}),
+		instanceRepo:         repo,
+		taskExecutorRegistry: NewExecutorRegistry(repo, nil, nil),
+	}
+
+	report, err := engine.RecoverInstances(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Scanned)
+	assert.Equal(t, []RecoveredNode{{InstanceID: "stalled", NodeID: "approve"}}, report.Recovered)
+	assert.Empty(t, report.Failed)
+
+	require.Len(t, repo.saved, 1)
+	assert.Equal(t, "approve", repo.saved[0].NodeID)
+	approvals := repo.approvals["stalled/approve"]
+	require.Len(t, approvals, 1)
+	assert.Equal(t, uint(9), approvals[0].AssignedTo)
+
+	// 再次恢复时节点已有待审批记录，不会重复执行
+	report, err = engine.RecoverInstances(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, report.Recovered)
+	assert.Len(t, repo.saved, 1)
+}
@@ -0,0 +1,153 @@
+package workflow
+
+import "context"
+
+// RedactedValue 敏感变量脱敏后的值
+const RedactedValue = "******"
+
+// 查看敏感流程变量所需的权限
+const (
+	SensitiveVariableResource = "workflow"
+	SensitiveVariableAction   = "view_sensitive"
+)
+
+// VariableViewer 查看流程变量的用户
+type VariableViewer struct {
+	UserID           uint
+	CanViewSensitive bool // 拥有workflow:view_sensitive权限
+}
+
+// SensitiveVariables 返回变量声明中标记为敏感的变量名，没有敏感变量时返回nil
+func SensitiveVariables(schema []VariableDefinition) map[string]bool {
+	var names map[string]bool
+	for _, declared := range schema {
+		if !declared.Sensitive {
+			continue
+		}
+		if names == nil {
+			names = make(map[string]bool)
+		}
+		names[declared.Name] = true
+	}
+	return names
+}
+
+// RedactVariables 返回变量表的副本，变量声明中标记为敏感的变量值替换为RedactedValue
+// 没有需要脱敏的变量时原样返回，不复制；审计报告和集成事件输出变量时同样经过该函数
+func RedactVariables(variables map[string]interface{}, schema []VariableDefinition) map[string]interface{} {
+	return redactVariables(variables, SensitiveVariables(schema))
+}
+
+// redactVariables 按敏感变量名脱敏，有变量需要脱敏时才复制变量表
+func redactVariables(variables map[string]interface{}, sensitive map[string]bool) map[string]interface{} {
+	redacted := variables
+	copied := false
+	for name := range variables {
+		if !sensitive[name] {
+			continue
+		}
+		if !copied {
+			redacted = make(map[string]interface{}, len(variables))
+			for key, value := range variables {
+				redacted[key] = value
+			}
+			copied = true
+		}
+		redacted[name] = RedactedValue
+	}
+	return redacted
+}
+
+// RedactInstance 返回实例的浅拷贝，实例变量和各条执行历史中的变量按变量声明脱敏
+func RedactInstance(instance *WorkflowInstance, schema []VariableDefinition) *WorkflowInstance {
+	sensitive := SensitiveVariables(schema)
+	if len(sensitive) == 0 {
+		return instance
+	}
+	redacted := *instance
+	redacted.Variables = redactVariables(instance.Variables, sensitive)
+	redacted.History = make([]ExecutionHistory, len(instance.History))
+	for i, history := range instance.History {
+		history.Variables = redactVariables(history.Variables, sensitive)
+		redacted.History[i] = history
+	}
+	return &redacted
+}
+
+// IsCurrentApprover 用户是否为实例当前活跃审批节点的审批人（含受托人）
+func (i *WorkflowInstance) IsCurrentApprover(userID uint) bool {
+	if userID == 0 {
+		return false
+	}
+	for _, nodeID := range i.CurrentNodes {
+		state := i.Approvals[nodeID]
+		if state == nil {
+			continue
+		}
+		for _, vote := range state.Votes {
+			if vote.AssigneeID == userID || vote.DelegatedTo == userID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// canViewSensitive 拥有查看敏感变量权限的用户和当前节点的审批人可以看到原始变量
+func (v VariableViewer) canViewSensitive(instance *WorkflowInstance) bool {
+	return v.CanViewSensitive || instance.IsCurrentApprover(v.UserID)
+}
+
+// variableSchema 获取实例绑定的流程定义声明的变量
+func (e *WorkflowEngineImpl) variableSchema(ctx context.Context, instance *WorkflowInstance) ([]VariableDefinition, error) {
+	definition, err := e.definitionManager.GetWorkflowForInstance(ctx, instance)
+	if err != nil {
+		return nil, err
+	}
+	return definition.VariableSchema, nil
+}
+
+// GetWorkflowInstanceForViewer 获取流程实例，查看者无权查看敏感变量时返回脱敏后的副本
+func (e *WorkflowEngineImpl) GetWorkflowInstanceForViewer(ctx context.Context, instanceID string, viewer VariableViewer) (*WorkflowInstance, error) {
+	instance, err := e.GetWorkflowInstance(ctx, instanceID)
+	if err != nil {
+		return nil, err
+	}
+	if viewer.canViewSensitive(instance) {
+		return instance, nil
+	}
+	schema, err := e.variableSchema(ctx, instance)
+	if err != nil {
+		return nil, err
+	}
+	return RedactInstance(instance, schema), nil
+}
+
+// redactPendingApprovals 脱敏待审批记录中复制的流程变量
+// 记录的审批人本人和拥有查看权限的用户看到原始变量，其余记录按所属实例的流程定义脱敏，同一实例只查询一次
+func (e *WorkflowEngineImpl) redactPendingApprovals(ctx context.Context, approvals []*PendingApproval, viewer VariableViewer) error {
+	if viewer.CanViewSensitive {
+		return nil
+	}
+	sensitiveByInstance := make(map[string]map[string]bool)
+	for _, approval := range approvals {
+		if len(approval.BusinessData) == 0 || (viewer.UserID != 0 && approval.AssignedTo == viewer.UserID) {
+			continue
+		}
+		sensitive, ok := sensitiveByInstance[approval.InstanceID]
+		if !ok {
+			instance, err := e.instanceRepo.GetInstance(ctx, approval.InstanceID)
+			if err != nil {
+				return err
+			}
+			schema, err := e.variableSchema(ctx, instance)
+			if err != nil {
+				return err
+			}
+			sensitive = SensitiveVariables(schema)
+			sensitiveByInstance[approval.InstanceID] = sensitive
+		}
+		approval.BusinessData = redactVariables(approval.BusinessData, sensitive)
+	}
+	return nil
+}
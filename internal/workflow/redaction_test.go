@@ -0,0 +1,151 @@
+package workflow
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// redactionInstanceRepository 按ID返回固定流程实例的仓储桩
+type redactionInstanceRepository struct {
+	WorkflowInstanceRepository
+	instances map[string]*WorkflowInstance
+	approvals []*PendingApproval
+	gets      int
+}
+
+func (r *redactionInstanceRepository) GetInstance(ctx context.Context, instanceID string) (*WorkflowInstance, error) {
+	r.gets++
+	return r.instances[instanceID], nil
+}
+
+func (r *redactionInstanceRepository) GetPendingApprovals(ctx context.Context, filter PendingApprovalFilter) ([]*PendingApproval, int64, error) {
+	return r.approvals, int64(len(r.approvals)), nil
+}
+
+var redactionSchema = []VariableDefinition{
+	{Name: "employee_id", Type: VariableTypeUint},
+	{Name: "phone", Type: VariableTypeString, Sensitive: true},
+	{Name: "evaluation_note", Type: VariableTypeString, Sensitive: true},
+}
+
+func newRedactionEngine() (*WorkflowEngineImpl, *redactionInstanceRepository) {
+	repo := &redactionInstanceRepository{instances: map[string]*WorkflowInstance{
+		"wf-1": {
+			ID:                  "wf-1",
+			DefinitionVersionID: 1,
+			Status:              StatusRunning,
+			CurrentNodes:        []string{"manager_approval"},
+			Variables:           map[string]interface{}{"employee_id": uint(7), "phone": "13800000000", "evaluation_note": "表现一般"},
+			History: []ExecutionHistory{
+				{NodeID: "start", Variables: map[string]interface{}{"phone": "13800000000"}},
+				{NodeID: "hr_approval", Action: "approve"},
+			},
+			Approvals: map[string]*NodeApprovalState{
+				"hr_approval":      NewNodeApprovalState(ApprovalTypeAny, false, []uint{2}),
+				"manager_approval": NewNodeApprovalState(ApprovalTypeAny, true, []uint{3}),
+			},
+		},
+	}}
+	definition := &WorkflowDefinition{ID: "onboarding", VersionID: 1, VariableSchema: redactionSchema}
+	engine := &WorkflowEngineImpl{
+		definitionManager: NewWorkflowDefinitionManager(&versionWorkflowRepository{definition: definition}),
+		instanceRepo:      repo,
+	}
+	return engine, repo
+}
+
+func TestRedactVariables(t *testing.T) {
+	variables := map[string]interface{}{"employee_id": uint(7), "phone": "13800000000"}
+
+	redacted := RedactVariables(variables, redactionSchema)
+	assert.Equal(t, RedactedValue, redacted["phone"])
+	assert.Equal(t, uint(7), redacted["employee_id"])
+	// 不修改原变量表
+	assert.Equal(t, "13800000000", variables["phone"])
+
+	// 没有敏感变量时不复制
+	plain := map[string]interface{}{"employee_id": uint(7)}
+	assert.Equal(t, plain, RedactVariables(plain, redactionSchema))
+	assert.Equal(t, variables, RedactVariables(variables, nil))
+	assert.Nil(t, RedactVariables(nil, redactionSchema))
+}
+
+func TestGetWorkflowInstanceForViewer(t *testing.T) {
+	ctx := context.Background()
+	engine, repo := newRedactionEngine()
+
+	// 无权限且不是当前节点审批人：实例变量和历史变量均脱敏
+	instance, err := engine.GetWorkflowInstanceForViewer(ctx, "wf-1", VariableViewer{UserID: 9})
+	require.NoError(t, err)
+	assert.Equal(t, RedactedValue, instance.Variables["phone"])
+	assert.Equal(t, RedactedValue, instance.Variables["evaluation_note"])
+	assert.Equal(t, uint(7), instance.Variables["employee_id"])
+	assert.Equal(t, RedactedValue, instance.History[0].Variables["phone"])
+	assert.Nil(t, instance.History[1].Variables)
+	// 仓储中的实例不受影响
+	assert.Equal(t, "13800000000", repo.instances["wf-1"].Variables["phone"])
+	assert.Equal(t, "13800000000", repo.instances["wf-1"].History[0].Variables["phone"])
+
+	// 已处理过前序节点的审批人不再是当前节点审批人
+	instance, err = engine.GetWorkflowInstanceForViewer(ctx, "wf-1", VariableViewer{UserID: 2})
+	require.NoError(t, err)
+	assert.Equal(t, RedactedValue, instance.Variables["phone"])
+
+	// 拥有workflow:view_sensitive权限
+	instance, err = engine.GetWorkflowInstanceForViewer(ctx, "wf-1", VariableViewer{UserID: 9, CanViewSensitive: true})
+	require.NoError(t, err)
+	assert.Equal(t, "13800000000", instance.Variables["phone"])
+	assert.Equal(t, "表现一般", instance.Variables["evaluation_note"])
+
+	// 当前节点的审批人和受托人
+	instance, err = engine.GetWorkflowInstanceForViewer(ctx, "wf-1", VariableViewer{UserID: 3})
+	require.NoError(t, err)
+	assert.Equal(t, "13800000000", instance.Variables["phone"])
+
+	_, err = repo.instances["wf-1"].Approvals["manager_approval"].Record(3, ActionDelegate, 4, "", instance.StartedAt)
+	require.NoError(t, err)
+	instance, err = engine.GetWorkflowInstanceForViewer(ctx, "wf-1", VariableViewer{UserID: 4})
+	require.NoError(t, err)
+	assert.Equal(t, "13800000000", instance.Variables["phone"])
+}
+
+func TestGetPendingApprovals_RedactsBusinessData(t *testing.T) {
+	ctx := context.Background()
+	engine, repo := newRedactionEngine()
+	newApprovals := func() []*PendingApproval {
+		return []*PendingApproval{
+			{InstanceID: "wf-1", NodeID: "manager_approval", AssignedTo: 3, BusinessData: map[string]interface{}{"phone": "13800000000"}},
+			{InstanceID: "wf-1", NodeID: "manager_approval", AssignedTo: 5, BusinessData: map[string]interface{}{"phone": "13800000000"}},
+			{InstanceID: "wf-1", NodeID: "manager_approval", AssignedTo: 6, BusinessData: map[string]interface{}{"phone": "13800000000"}},
+		}
+	}
+
+	// 审批人本人的记录保留原始变量，不查询实例
+	repo.approvals = newApprovals()[:1]
+	approvals, _, err := engine.GetPendingApprovals(ctx, PendingApprovalFilter{UserID: 3})
+	require.NoError(t, err)
+	assert.Equal(t, "13800000000", approvals[0].BusinessData["phone"])
+	assert.Zero(t, repo.gets)
+
+	// 其他人的记录脱敏，同一实例只查询一次
+	repo.approvals = newApprovals()
+	approvals, _, err = engine.GetPendingApprovals(ctx, PendingApprovalFilter{UserID: 3})
+	require.NoError(t, err)
+	assert.Equal(t, "13800000000", approvals[0].BusinessData["phone"])
+	assert.Equal(t, RedactedValue, approvals[1].BusinessData["phone"])
+	assert.Equal(t, RedactedValue, approvals[2].BusinessData["phone"])
+	assert.Equal(t, 1, repo.gets)
+}
+
+func TestAuditService_RedactsHistory(t *testing.T) {
+	engine, repo := newRedactionEngine()
+	audit := NewAuditService(repo, engine.definitionManager)
+
+	report, err := audit.GetWorkflowHistory(context.Background(), "wf-1")
+	require.NoError(t, err)
+	assert.Equal(t, RedactedValue, report.History[0].Variables["phone"])
+	assert.Equal(t, "13800000000", repo.instances["wf-1"].History[0].Variables["phone"])
+}
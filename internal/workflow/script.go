@@ -0,0 +1,1383 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ScriptLimits 脚本执行限制
+type ScriptLimits struct {
+	Timeout          time.Duration // 单次执行超时
+	MaxSteps         int           // 最大执行步数（语句与表达式求值次数）
+	MaxStringLen     int           // 字符串最大长度
+	MaxCollectionLen int           // 列表/映射最大元素数
+	MaxMemory        int           // 脚本累计分配的近似字节数上限
+}
+
+// DefaultScriptLimits 默认脚本执行限制
+var DefaultScriptLimits = ScriptLimits{
+	Timeout:          2 * time.Second,
+	MaxSteps:         100000,
+	MaxStringLen:     64 * 1024,
+	MaxCollectionLen: 10000,
+	MaxMemory:        8 * 1024 * 1024,
+}
+
+// 脚本源码包装在函数体中解析，报错行号需扣除包装部分
+const (
+	scriptPrefix     = "package script\nfunc _() {\n"
+	scriptLineOffset = 2
+)
+
+var (
+	errScriptBreak    = errors.New("break")
+	errScriptContinue = errors.New("continue")
+	errScriptReturn   = errors.New("return")
+)
+
+// RunScript 在受限环境中执行脚本
+//
+// 脚本语法为Go语句的子集：`:=` 声明脚本内局部变量，`=`/`+=` 等赋值写入流程变量；
+// 支持 if/for/range/break/continue/return 及内置函数，不支持函数定义、goroutine 及任何I/O。
+// 返回脚本写入的流程变量，不修改传入的variables。
+func RunScript(ctx context.Context, script string, variables map[string]interface{}, limits ScriptLimits) (result map[string]interface{}, err error) {
+	limits = limits.withDefaults()
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "script", scriptPrefix+script+"\n}\n", 0)
+	if err != nil {
+		return nil, fmt.Errorf("脚本语法错误: %s", trimScriptParseError(err))
+	}
+	body := file.Decls[0].(*ast.FuncDecl).Body
+
+	ctx, cancel := context.WithTimeout(ctx, limits.Timeout)
+	defer cancel()
+
+	interp := &scriptInterpreter{
+		ctx:     ctx,
+		fset:    fset,
+		limits:  limits,
+		vars:    make(map[string]interface{}, len(variables)),
+		locals:  make(map[string]interface{}),
+		written: make(map[string]bool),
+	}
+	for k, v := range variables {
+		// 规范化时会复制列表和映射，脚本修改不会影响原始流程变量
+		interp.vars[k] = normalizeScriptValue(v)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			result = nil
+			err = fmt.Errorf("脚本执行异常: %v", r)
+		}
+	}()
+
+	if err := interp.execBlock(body.List); err != nil && err != errScriptReturn {
+		if err == errScriptBreak || err == errScriptContinue {
+			return nil, fmt.Errorf("脚本执行失败: break/continue 不在循环内")
+		}
+		return nil, fmt.Errorf("脚本执行失败: %w", err)
+	}
+
+	result = make(map[string]interface{}, len(interp.written))
+	for name := range interp.written {
+		result[name] = interp.vars[name]
+	}
+	return result, nil
+}
+
+// withDefaults 为未设置的限制项填充默认值
+func (l ScriptLimits) withDefaults() ScriptLimits {
+	if l.Timeout <= 0 {
+		l.Timeout = DefaultScriptLimits.Timeout
+	}
+	if l.MaxSteps <= 0 {
+		l.MaxSteps = DefaultScriptLimits.MaxSteps
+	}
+	if l.MaxStringLen <= 0 {
+		l.MaxStringLen = DefaultScriptLimits.MaxStringLen
+	}
+	if l.MaxCollectionLen <= 0 {
+		l.MaxCollectionLen = DefaultScriptLimits.MaxCollectionLen
+	}
+	if l.MaxMemory <= 0 {
+		l.MaxMemory = DefaultScriptLimits.MaxMemory
+	}
+	return l
+}
+
+// trimScriptParseError 修正语法错误中的行号，只保留第一条错误
+func trimScriptParseError(err error) string {
+	var list scanner.ErrorList
+	if errors.As(err, &list) && len(list) > 0 {
+		return fmt.Sprintf("第%d行: %s", list[0].Pos.Line-scriptLineOffset, list[0].Msg)
+	}
+	return err.Error()
+}
+
+// scriptInterpreter 脚本解释器
+type scriptInterpreter struct {
+	ctx       context.Context
+	fset      *token.FileSet
+	limits    ScriptLimits
+	steps     int
+	allocated int
+	vars      map[string]interface{} // 流程变量副本
+	locals    map[string]interface{} // 脚本局部变量
+	written   map[string]bool        // 被脚本写入的流程变量
+}
+
+// errorf 生成带脚本行号的错误
+func (s *scriptInterpreter) errorf(node ast.Node, format string, args ...interface{}) error {
+	line := s.fset.Position(node.Pos()).Line - scriptLineOffset
+	return fmt.Errorf("第%d行: %s", line, fmt.Sprintf(format, args...))
+}
+
+// step 计数执行步数并检查超时
+func (s *scriptInterpreter) step(node ast.Node) error {
+	s.steps++
+	if s.steps > s.limits.MaxSteps {
+		return s.errorf(node, "超过最大执行步数%d", s.limits.MaxSteps)
+	}
+	if s.steps%256 == 0 {
+		if err := s.ctx.Err(); err != nil {
+			return s.errorf(node, "脚本执行超时(%s)", s.limits.Timeout)
+		}
+	}
+	return nil
+}
+
+// reserve 累计近似分配量，超出内存上限时返回错误
+func (s *scriptInterpreter) reserve(n int) error {
+	s.allocated += n
+	if s.allocated > s.limits.MaxMemory {
+		return fmt.Errorf("超过内存限制%d字节", s.limits.MaxMemory)
+	}
+	return nil
+}
+
+// remaining 返回剩余可分配的近似字节数
+func (s *scriptInterpreter) remaining() int {
+	return s.limits.MaxMemory - s.allocated
+}
+
+// checkSize 检查值的大小是否超出限制
+func (s *scriptInterpreter) checkSize(node ast.Node, value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		if len(v) > s.limits.MaxStringLen {
+			return s.errorf(node, "字符串长度超过限制%d", s.limits.MaxStringLen)
+		}
+	case []interface{}:
+		if len(v) > s.limits.MaxCollectionLen {
+			return s.errorf(node, "列表长度超过限制%d", s.limits.MaxCollectionLen)
+		}
+	case map[string]interface{}:
+		if len(v) > s.limits.MaxCollectionLen {
+			return s.errorf(node, "映射长度超过限制%d", s.limits.MaxCollectionLen)
+		}
+	}
+	return nil
+}
+
+// execBlock 执行语句列表
+func (s *scriptInterpreter) execBlock(stmts []ast.Stmt) error {
+	for _, stmt := range stmts {
+		if err := s.execStmt(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// execStmt 执行单条语句
+func (s *scriptInterpreter) execStmt(stmt ast.Stmt) error {
+	if err := s.step(stmt); err != nil {
+		return err
+	}
+
+	switch st := stmt.(type) {
+	case *ast.AssignStmt:
+		return s.execAssign(st)
+	case *ast.IncDecStmt:
+		delta := int64(1)
+		if st.Tok == token.DEC {
+			delta = -1
+		}
+		current, err := s.eval(st.X)
+		if err != nil {
+			return err
+		}
+		value, err := s.binaryOp(st, token.ADD, current, delta)
+		if err != nil {
+			return err
+		}
+		return s.assign(st.X, value, false)
+	case *ast.ExprStmt:
+		_, err := s.eval(st.X)
+		return err
+	case *ast.BlockStmt:
+		return s.execBlock(st.List)
+	case *ast.IfStmt:
+		return s.execIf(st)
+	case *ast.ForStmt:
+		return s.execFor(st)
+	case *ast.RangeStmt:
+		return s.execRange(st)
+	case *ast.BranchStmt:
+		if st.Label != nil {
+			return s.errorf(st, "不支持带标签的跳转")
+		}
+		switch st.Tok {
+		case token.BREAK:
+			return errScriptBreak
+		case token.CONTINUE:
+			return errScriptContinue
+		}
+		return s.errorf(st, "不支持的跳转语句: %s", st.Tok)
+	case *ast.ReturnStmt:
+		if len(st.Results) > 0 {
+			return s.errorf(st, "return不能带返回值")
+		}
+		return errScriptReturn
+	case *ast.EmptyStmt:
+		return nil
+	}
+	return s.errorf(stmt, "不支持的语句类型 %T", stmt)
+}
+
+// execAssign 执行赋值语句
+func (s *scriptInterpreter) execAssign(st *ast.AssignStmt) error {
+	if len(st.Lhs) != len(st.Rhs) {
+		return s.errorf(st, "赋值两侧数量不一致")
+	}
+
+	values := make([]interface{}, len(st.Rhs))
+	for i, rhs := range st.Rhs {
+		value, err := s.eval(rhs)
+		if err != nil {
+			return err
+		}
+		values[i] = value
+	}
+
+	for i, lhs := range st.Lhs {
+		value := values[i]
+		if op, ok := compoundAssignOps[st.Tok]; ok {
+			current, err := s.eval(lhs)
+			if err != nil {
+				return err
+			}
+			if value, err = s.binaryOp(st, op, current, value); err != nil {
+				return err
+			}
+		} else if st.Tok != token.ASSIGN && st.Tok != token.DEFINE {
+			return s.errorf(st, "不支持的赋值运算符 %s", st.Tok)
+		}
+		if err := s.assign(lhs, value, st.Tok == token.DEFINE); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var compoundAssignOps = map[token.Token]token.Token{
+	token.ADD_ASSIGN: token.ADD,
+	token.SUB_ASSIGN: token.SUB,
+	token.MUL_ASSIGN: token.MUL,
+	token.QUO_ASSIGN: token.QUO,
+	token.REM_ASSIGN: token.REM,
+}
+
+// assign 写入变量，define为true时声明局部变量
+func (s *scriptInterpreter) assign(target ast.Expr, value interface{}, define bool) error {
+	if err := s.checkSize(target, value); err != nil {
+		return err
+	}
+
+	switch t := target.(type) {
+	case *ast.Ident:
+		if t.Name == "_" {
+			return nil
+		}
+		if define {
+			s.locals[t.Name] = value
+			return nil
+		}
+		if _, ok := s.locals[t.Name]; ok {
+			s.locals[t.Name] = value
+			return nil
+		}
+		s.vars[t.Name] = value
+		s.written[t.Name] = true
+		return nil
+	case *ast.IndexExpr, *ast.SelectorExpr:
+		if define {
+			return s.errorf(target, ":= 左侧必须是变量名")
+		}
+		return s.assignElement(target, value)
+	case *ast.ParenExpr:
+		return s.assign(t.X, value, define)
+	}
+	return s.errorf(target, "无法赋值给该表达式")
+}
+
+// assignElement 写入映射键或列表元素
+func (s *scriptInterpreter) assignElement(target ast.Expr, value interface{}) error {
+	var containerExpr ast.Expr
+	var key interface{}
+	switch t := target.(type) {
+	case *ast.IndexExpr:
+		containerExpr = t.X
+		k, err := s.eval(t.Index)
+		if err != nil {
+			return err
+		}
+		key = k
+	case *ast.SelectorExpr:
+		containerExpr = t.X
+		key = t.Sel.Name
+	}
+
+	container, err := s.eval(containerExpr)
+	if err != nil {
+		return err
+	}
+
+	switch c := container.(type) {
+	case map[string]interface{}:
+		k, ok := key.(string)
+		if !ok {
+			return s.errorf(target, "映射的键必须是字符串")
+		}
+		if err := s.reserve(scriptEntrySize); err != nil {
+			return s.errorf(target, "%v", err)
+		}
+		c[k] = value
+		if err := s.checkSize(target, c); err != nil {
+			return err
+		}
+	case []interface{}:
+		idx, ok := toScriptIndex(key)
+		if !ok {
+			return s.errorf(target, "列表下标必须是整数")
+		}
+		if idx < 0 || idx >= int64(len(c)) {
+			return s.errorf(target, "列表下标越界: %d", idx)
+		}
+		c[idx] = value
+	default:
+		return s.errorf(target, "类型 %s 不支持下标赋值", scriptTypeName(container))
+	}
+
+	// 修改了流程变量内部的元素，整个变量视为被写入
+	if root := rootIdent(containerExpr); root != nil {
+		if _, local := s.locals[root.Name]; !local {
+			if _, exists := s.vars[root.Name]; exists {
+				s.written[root.Name] = true
+			}
+		}
+	}
+	return nil
+}
+
+// rootIdent 获取下标/选择表达式最外层的变量名
+func rootIdent(expr ast.Expr) *ast.Ident {
+	for {
+		switch e := expr.(type) {
+		case *ast.Ident:
+			return e
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.SelectorExpr:
+			expr = e.X
+		case *ast.ParenExpr:
+			expr = e.X
+		default:
+			return nil
+		}
+	}
+}
+
+// execIf 执行if语句
+func (s *scriptInterpreter) execIf(st *ast.IfStmt) error {
+	if st.Init != nil {
+		if err := s.execStmt(st.Init); err != nil {
+			return err
+		}
+	}
+	cond, err := s.evalBool(st.Cond)
+	if err != nil {
+		return err
+	}
+	if cond {
+		return s.execBlock(st.Body.List)
+	}
+	if st.Else != nil {
+		return s.execStmt(st.Else)
+	}
+	return nil
+}
+
+// execFor 执行for循环
+func (s *scriptInterpreter) execFor(st *ast.ForStmt) error {
+	if st.Init != nil {
+		if err := s.execStmt(st.Init); err != nil {
+			return err
+		}
+	}
+	for {
+		if st.Cond != nil {
+			cond, err := s.evalBool(st.Cond)
+			if err != nil {
+				return err
+			}
+			if !cond {
+				return nil
+			}
+		} else if err := s.step(st); err != nil {
+			return err
+		}
+
+		if err := s.execBlock(st.Body.List); err != nil {
+			if err == errScriptBreak {
+				return nil
+			}
+			if err != errScriptContinue {
+				return err
+			}
+		}
+
+		if st.Post != nil {
+			if err := s.execStmt(st.Post); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// execRange 执行range循环，映射按键排序遍历以保证结果确定
+func (s *scriptInterpreter) execRange(st *ast.RangeStmt) error {
+	collection, err := s.eval(st.X)
+	if err != nil {
+		return err
+	}
+
+	var keys, values []interface{}
+	switch c := collection.(type) {
+	case []interface{}:
+		for i, v := range c {
+			keys = append(keys, int64(i))
+			values = append(values, v)
+		}
+	case map[string]interface{}:
+		names := make([]string, 0, len(c))
+		for k := range c {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		for _, k := range names {
+			keys = append(keys, k)
+			values = append(values, c[k])
+		}
+	case string:
+		for i, r := range c {
+			keys = append(keys, int64(i))
+			values = append(values, string(r))
+		}
+	default:
+		return s.errorf(st.X, "类型 %s 不支持range", scriptTypeName(collection))
+	}
+
+	define := st.Tok == token.DEFINE
+	for i := range keys {
+		if err := s.step(st); err != nil {
+			return err
+		}
+		if st.Key != nil {
+			if err := s.assign(st.Key, keys[i], define); err != nil {
+				return err
+			}
+		}
+		if st.Value != nil {
+			if err := s.assign(st.Value, values[i], define); err != nil {
+				return err
+			}
+		}
+		if err := s.execBlock(st.Body.List); err != nil {
+			if err == errScriptBreak {
+				return nil
+			}
+			if err != errScriptContinue {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// evalBool 求值布尔表达式
+func (s *scriptInterpreter) evalBool(expr ast.Expr) (bool, error) {
+	value, err := s.eval(expr)
+	if err != nil {
+		return false, err
+	}
+	b, ok := value.(bool)
+	if !ok {
+		return false, s.errorf(expr, "条件必须是布尔值，实际为 %s", scriptTypeName(value))
+	}
+	return b, nil
+}
+
+// eval 求值表达式
+func (s *scriptInterpreter) eval(expr ast.Expr) (interface{}, error) {
+	if err := s.step(expr); err != nil {
+		return nil, err
+	}
+
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		return s.evalLiteral(e)
+	case *ast.Ident:
+		return s.lookup(e)
+	case *ast.ParenExpr:
+		return s.eval(e.X)
+	case *ast.UnaryExpr:
+		return s.evalUnary(e)
+	case *ast.BinaryExpr:
+		return s.evalBinary(e)
+	case *ast.IndexExpr:
+		return s.evalIndex(e)
+	case *ast.SelectorExpr:
+		container, err := s.eval(e.X)
+		if err != nil {
+			return nil, err
+		}
+		m, ok := container.(map[string]interface{})
+		if !ok {
+			return nil, s.errorf(e, "类型 %s 不支持字段访问", scriptTypeName(container))
+		}
+		return m[e.Sel.Name], nil
+	case *ast.CallExpr:
+		return s.evalCall(e)
+	case *ast.CompositeLit:
+		return s.evalComposite(e)
+	}
+	return nil, s.errorf(expr, "不支持的表达式类型 %T", expr)
+}
+
+// evalLiteral 求值字面量
+func (s *scriptInterpreter) evalLiteral(lit *ast.BasicLit) (interface{}, error) {
+	switch lit.Kind {
+	case token.INT:
+		v, err := strconv.ParseInt(lit.Value, 0, 64)
+		if err != nil {
+			return nil, s.errorf(lit, "无效的整数: %s", lit.Value)
+		}
+		return v, nil
+	case token.FLOAT:
+		v, err := strconv.ParseFloat(lit.Value, 64)
+		if err != nil {
+			return nil, s.errorf(lit, "无效的浮点数: %s", lit.Value)
+		}
+		return v, nil
+	case token.STRING:
+		v, err := strconv.Unquote(lit.Value)
+		if err != nil {
+			return nil, s.errorf(lit, "无效的字符串: %s", lit.Value)
+		}
+		return v, nil
+	case token.CHAR:
+		v, _, _, err := strconv.UnquoteChar(lit.Value[1:len(lit.Value)-1], '\'')
+		if err != nil {
+			return nil, s.errorf(lit, "无效的字符: %s", lit.Value)
+		}
+		return string(v), nil
+	}
+	return nil, s.errorf(lit, "不支持的字面量: %s", lit.Value)
+}
+
+// lookup 查找变量，局部变量优先于流程变量
+func (s *scriptInterpreter) lookup(ident *ast.Ident) (interface{}, error) {
+	switch ident.Name {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "nil":
+		return nil, nil
+	}
+	if v, ok := s.locals[ident.Name]; ok {
+		return v, nil
+	}
+	if v, ok := s.vars[ident.Name]; ok {
+		return v, nil
+	}
+	return nil, s.errorf(ident, "未定义的变量: %s", ident.Name)
+}
+
+// evalUnary 求值一元表达式
+func (s *scriptInterpreter) evalUnary(e *ast.UnaryExpr) (interface{}, error) {
+	value, err := s.eval(e.X)
+	if err != nil {
+		return nil, err
+	}
+	switch e.Op {
+	case token.NOT:
+		b, ok := value.(bool)
+		if !ok {
+			return nil, s.errorf(e, "!运算需要布尔值")
+		}
+		return !b, nil
+	case token.SUB:
+		switch v := value.(type) {
+		case int64:
+			return -v, nil
+		case float64:
+			return -v, nil
+		}
+		return nil, s.errorf(e, "-运算需要数值")
+	case token.ADD:
+		switch value.(type) {
+		case int64, float64:
+			return value, nil
+		}
+		return nil, s.errorf(e, "+运算需要数值")
+	}
+	return nil, s.errorf(e, "不支持的运算符 %s", e.Op)
+}
+
+// evalBinary 求值二元表达式，&& 与 || 短路求值
+func (s *scriptInterpreter) evalBinary(e *ast.BinaryExpr) (interface{}, error) {
+	if e.Op == token.LAND || e.Op == token.LOR {
+		left, err := s.evalBool(e.X)
+		if err != nil {
+			return nil, err
+		}
+		if (e.Op == token.LAND && !left) || (e.Op == token.LOR && left) {
+			return left, nil
+		}
+		return s.evalBool(e.Y)
+	}
+
+	left, err := s.eval(e.X)
+	if err != nil {
+		return nil, err
+	}
+	right, err := s.eval(e.Y)
+	if err != nil {
+		return nil, err
+	}
+	return s.binaryOp(e, e.Op, left, right)
+}
+
+// binaryOp 执行二元运算
+func (s *scriptInterpreter) binaryOp(node ast.Node, op token.Token, left, right interface{}) (interface{}, error) {
+	switch op {
+	case token.EQL:
+		return scriptEqual(left, right), nil
+	case token.NEQ:
+		return !scriptEqual(left, right), nil
+	}
+
+	// 字符串拼接与比较
+	if ls, ok := left.(string); ok {
+		rs, ok := right.(string)
+		if !ok {
+			return nil, s.errorf(node, "字符串不能与 %s 运算", scriptTypeName(right))
+		}
+		switch op {
+		case token.ADD:
+			if len(ls)+len(rs) > s.limits.MaxStringLen {
+				return nil, s.errorf(node, "字符串长度超过限制%d", s.limits.MaxStringLen)
+			}
+			if err := s.reserve(len(ls) + len(rs)); err != nil {
+				return nil, s.errorf(node, "%v", err)
+			}
+			return ls + rs, nil
+		case token.LSS:
+			return ls < rs, nil
+		case token.LEQ:
+			return ls <= rs, nil
+		case token.GTR:
+			return ls > rs, nil
+		case token.GEQ:
+			return ls >= rs, nil
+		}
+		return nil, s.errorf(node, "字符串不支持运算符 %s", op)
+	}
+
+	// 两个整数按整数运算，否则按浮点运算
+	li, lInt := left.(int64)
+	ri, rInt := right.(int64)
+	if lInt && rInt {
+		switch op {
+		case token.ADD:
+			return li + ri, nil
+		case token.SUB:
+			return li - ri, nil
+		case token.MUL:
+			return li * ri, nil
+		case token.QUO, token.REM:
+			if ri == 0 {
+				return nil, s.errorf(node, "除数不能为0")
+			}
+			if op == token.QUO {
+				return li / ri, nil
+			}
+			return li % ri, nil
+		case token.LSS:
+			return li < ri, nil
+		case token.LEQ:
+			return li <= ri, nil
+		case token.GTR:
+			return li > ri, nil
+		case token.GEQ:
+			return li >= ri, nil
+		}
+		return nil, s.errorf(node, "整数不支持运算符 %s", op)
+	}
+
+	lf, lok := toScriptFloat(left)
+	rf, rok := toScriptFloat(right)
+	if !lok || !rok {
+		return nil, s.errorf(node, "类型 %s 与 %s 不支持运算符 %s", scriptTypeName(left), scriptTypeName(right), op)
+	}
+	switch op {
+	case token.ADD:
+		return lf + rf, nil
+	case token.SUB:
+		return lf - rf, nil
+	case token.MUL:
+		return lf * rf, nil
+	case token.QUO:
+		if rf == 0 {
+			return nil, s.errorf(node, "除数不能为0")
+		}
+		return lf / rf, nil
+	case token.LSS:
+		return lf < rf, nil
+	case token.LEQ:
+		return lf <= rf, nil
+	case token.GTR:
+		return lf > rf, nil
+	case token.GEQ:
+		return lf >= rf, nil
+	}
+	return nil, s.errorf(node, "浮点数不支持运算符 %s", op)
+}
+
+// evalIndex 求值下标表达式，映射中不存在的键返回nil
+func (s *scriptInterpreter) evalIndex(e *ast.IndexExpr) (interface{}, error) {
+	container, err := s.eval(e.X)
+	if err != nil {
+		return nil, err
+	}
+	key, err := s.eval(e.Index)
+	if err != nil {
+		return nil, err
+	}
+
+	switch c := container.(type) {
+	case map[string]interface{}:
+		k, ok := key.(string)
+		if !ok {
+			return nil, s.errorf(e, "映射的键必须是字符串")
+		}
+		return c[k], nil
+	case []interface{}:
+		idx, ok := toScriptIndex(key)
+		if !ok {
+			return nil, s.errorf(e, "列表下标必须是整数")
+		}
+		if idx < 0 || idx >= int64(len(c)) {
+			return nil, s.errorf(e, "列表下标越界: %d", idx)
+		}
+		return c[idx], nil
+	case string:
+		idx, ok := toScriptIndex(key)
+		if !ok {
+			return nil, s.errorf(e, "字符串下标必须是整数")
+		}
+		if idx < 0 || idx >= int64(len(c)) {
+			return nil, s.errorf(e, "字符串下标越界: %d", idx)
+		}
+		return string(c[idx]), nil
+	}
+	return nil, s.errorf(e, "类型 %s 不支持下标访问", scriptTypeName(container))
+}
+
+// evalComposite 求值列表或映射字面量
+func (s *scriptInterpreter) evalComposite(e *ast.CompositeLit) (interface{}, error) {
+	if len(e.Elts) > s.limits.MaxCollectionLen {
+		return nil, s.errorf(e, "字面量元素数超过限制%d", s.limits.MaxCollectionLen)
+	}
+	if err := s.reserve(len(e.Elts) * scriptEntrySize); err != nil {
+		return nil, s.errorf(e, "%v", err)
+	}
+
+	switch e.Type.(type) {
+	case *ast.MapType:
+		result := make(map[string]interface{}, len(e.Elts))
+		for _, elt := range e.Elts {
+			kv, ok := elt.(*ast.KeyValueExpr)
+			if !ok {
+				return nil, s.errorf(elt, "映射字面量需要键值对")
+			}
+			key, err := s.eval(kv.Key)
+			if err != nil {
+				return nil, err
+			}
+			k, ok := key.(string)
+			if !ok {
+				return nil, s.errorf(kv, "映射的键必须是字符串")
+			}
+			value, err := s.eval(kv.Value)
+			if err != nil {
+				return nil, err
+			}
+			result[k] = value
+		}
+		return result, nil
+	case *ast.ArrayType:
+		result := make([]interface{}, 0, len(e.Elts))
+		for _, elt := range e.Elts {
+			value, err := s.eval(elt)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, value)
+		}
+		return result, nil
+	}
+	return nil, s.errorf(e, "仅支持列表和映射字面量")
+}
+
+// evalCall 调用内置函数
+func (s *scriptInterpreter) evalCall(e *ast.CallExpr) (interface{}, error) {
+	ident, ok := e.Fun.(*ast.Ident)
+	if !ok {
+		return nil, s.errorf(e, "仅支持调用内置函数")
+	}
+	fn, ok := scriptBuiltins[ident.Name]
+	if !ok {
+		return nil, s.errorf(e, "未定义的函数: %s", ident.Name)
+	}
+	if e.Ellipsis.IsValid() {
+		return nil, s.errorf(e, "不支持可变参数展开")
+	}
+
+	args := make([]interface{}, len(e.Args))
+	for i, arg := range e.Args {
+		value, err := s.eval(arg)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = value
+	}
+
+	result, err := fn(s, args)
+	if err != nil {
+		return nil, s.errorf(e, "%s: %v", ident.Name, err)
+	}
+	if err := s.checkSize(e, result); err != nil {
+		return nil, err
+	}
+	if err := s.reserve(shallowScriptSize(result)); err != nil {
+		return nil, s.errorf(e, "%v", err)
+	}
+	return result, nil
+}
+
+// scriptEntrySize 列表/映射单个元素的近似占用字节数
+const scriptEntrySize = 32
+
+// shallowScriptSize 估算值本身（不含子元素）的占用字节数
+func shallowScriptSize(v interface{}) int {
+	switch val := v.(type) {
+	case string:
+		return len(val)
+	case []interface{}:
+		return len(val) * scriptEntrySize
+	case map[string]interface{}:
+		return len(val) * scriptEntrySize
+	}
+	return 0
+}
+
+// scriptBuiltin 内置函数，可通过解释器检查分配上限
+type scriptBuiltin func(s *scriptInterpreter, args []interface{}) (interface{}, error)
+
+// scriptBuiltins 内置函数表，均为无副作用的纯函数
+var scriptBuiltins = map[string]scriptBuiltin{
+	"len": func(s *scriptInterpreter, args []interface{}) (interface{}, error) {
+		if err := expectArgs(args, 1); err != nil {
+			return nil, err
+		}
+		switch v := args[0].(type) {
+		case string:
+			return int64(len([]rune(v))), nil
+		case []interface{}:
+			return int64(len(v)), nil
+		case map[string]interface{}:
+			return int64(len(v)), nil
+		case nil:
+			return int64(0), nil
+		}
+		return nil, fmt.Errorf("类型 %s 没有长度", scriptTypeName(args[0]))
+	},
+	"int": func(s *scriptInterpreter, args []interface{}) (interface{}, error) {
+		if err := expectArgs(args, 1); err != nil {
+			return nil, err
+		}
+		switch v := args[0].(type) {
+		case int64:
+			return v, nil
+		case float64:
+			return int64(v), nil
+		case bool:
+			if v {
+				return int64(1), nil
+			}
+			return int64(0), nil
+		case string:
+			i, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("无法转换为整数: %q", v)
+			}
+			return i, nil
+		}
+		return nil, fmt.Errorf("无法将 %s 转换为整数", scriptTypeName(args[0]))
+	},
+	"float": func(s *scriptInterpreter, args []interface{}) (interface{}, error) {
+		if err := expectArgs(args, 1); err != nil {
+			return nil, err
+		}
+		if s, ok := args[0].(string); ok {
+			f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+			if err != nil {
+				return nil, fmt.Errorf("无法转换为浮点数: %q", s)
+			}
+			return f, nil
+		}
+		f, ok := toScriptFloat(args[0])
+		if !ok {
+			return nil, fmt.Errorf("无法将 %s 转换为浮点数", scriptTypeName(args[0]))
+		}
+		return f, nil
+	},
+	"string": func(s *scriptInterpreter, args []interface{}) (interface{}, error) {
+		if err := expectArgs(args, 1); err != nil {
+			return nil, err
+		}
+		return s.formatValue(args[0])
+	},
+	"contains": func(s *scriptInterpreter, args []interface{}) (interface{}, error) {
+		if err := expectArgs(args, 2); err != nil {
+			return nil, err
+		}
+		switch c := args[0].(type) {
+		case string:
+			sub, ok := args[1].(string)
+			if !ok {
+				return nil, fmt.Errorf("字符串只能包含字符串")
+			}
+			return strings.Contains(c, sub), nil
+		case []interface{}:
+			for _, item := range c {
+				if scriptEqual(item, args[1]) {
+					return true, nil
+				}
+			}
+			return false, nil
+		case map[string]interface{}:
+			key, ok := args[1].(string)
+			if !ok {
+				return nil, fmt.Errorf("映射的键必须是字符串")
+			}
+			_, exists := c[key]
+			return exists, nil
+		case nil:
+			return false, nil
+		}
+		return nil, fmt.Errorf("类型 %s 不支持contains", scriptTypeName(args[0]))
+	},
+	"append": func(s *scriptInterpreter, args []interface{}) (interface{}, error) {
+		if len(args) == 0 {
+			return nil, fmt.Errorf("至少需要1个参数")
+		}
+		var list []interface{}
+		switch v := args[0].(type) {
+		case []interface{}:
+			list = v
+		case nil:
+		default:
+			return nil, fmt.Errorf("第一个参数必须是列表")
+		}
+		result := make([]interface{}, 0, len(list)+len(args)-1)
+		result = append(result, list...)
+		return append(result, args[1:]...), nil
+	},
+	"keys": func(s *scriptInterpreter, args []interface{}) (interface{}, error) {
+		if err := expectArgs(args, 1); err != nil {
+			return nil, err
+		}
+		m, ok := args[0].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("参数必须是映射")
+		}
+		names := make([]string, 0, len(m))
+		for k := range m {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		result := make([]interface{}, len(names))
+		for i, k := range names {
+			result[i] = k
+		}
+		return result, nil
+	},
+	"upper":     stringFunc(strings.ToUpper),
+	"lower":     stringFunc(strings.ToLower),
+	"trim":      stringFunc(strings.TrimSpace),
+	"hasPrefix": stringPredicate(strings.HasPrefix),
+	"hasSuffix": stringPredicate(strings.HasSuffix),
+	"split": func(s *scriptInterpreter, args []interface{}) (interface{}, error) {
+		strs, err := expectStrings(args, 2)
+		if err != nil {
+			return nil, err
+		}
+		parts := strings.Split(strs[0], strs[1])
+		result := make([]interface{}, len(parts))
+		for i, p := range parts {
+			result[i] = p
+		}
+		return result, nil
+	},
+	"join": func(s *scriptInterpreter, args []interface{}) (interface{}, error) {
+		if err := expectArgs(args, 2); err != nil {
+			return nil, err
+		}
+		list, ok := args[0].([]interface{})
+		sep, sepOK := args[1].(string)
+		if !ok || !sepOK {
+			return nil, fmt.Errorf("参数应为列表和分隔符")
+		}
+		// 先估算结果长度，避免共享引用的大列表拼接出超大字符串
+		total := len(sep) * len(list)
+		for _, item := range list {
+			size, ok := estimateScriptSize(item, s.limits.MaxStringLen-total)
+			if !ok {
+				return nil, fmt.Errorf("结果长度超过限制%d", s.limits.MaxStringLen)
+			}
+			total += size
+		}
+		parts := make([]string, len(list))
+		for i, item := range list {
+			part, err := s.formatValue(item)
+			if err != nil {
+				return nil, err
+			}
+			parts[i] = part
+		}
+		return strings.Join(parts, sep), nil
+	},
+	"replace": func(s *scriptInterpreter, args []interface{}) (interface{}, error) {
+		strs, err := expectStrings(args, 3)
+		if err != nil {
+			return nil, err
+		}
+		expected := len(strs[0]) + strings.Count(strs[0], strs[1])*(len(strs[2])-len(strs[1]))
+		if expected > s.limits.MaxStringLen {
+			return nil, fmt.Errorf("结果长度超过限制%d", s.limits.MaxStringLen)
+		}
+		return strings.ReplaceAll(strs[0], strs[1], strs[2]), nil
+	},
+	"abs":   floatFunc(math.Abs),
+	"floor": floatFunc(math.Floor),
+	"ceil":  floatFunc(math.Ceil),
+	"round": floatFunc(math.Round),
+	"min": func(s *scriptInterpreter, args []interface{}) (interface{}, error) {
+		return pickNumber(args, func(a, b float64) bool { return a < b })
+	},
+	"max": func(s *scriptInterpreter, args []interface{}) (interface{}, error) {
+		return pickNumber(args, func(a, b float64) bool { return a > b })
+	},
+	"now": func(s *scriptInterpreter, args []interface{}) (interface{}, error) {
+		if err := expectArgs(args, 0); err != nil {
+			return nil, err
+		}
+		return time.Now().Unix(), nil
+	},
+}
+
+// expectArgs 检查参数个数
+func expectArgs(args []interface{}, n int) error {
+	if len(args) != n {
+		return fmt.Errorf("需要%d个参数，实际为%d个", n, len(args))
+	}
+	return nil
+}
+
+// expectStrings 检查参数个数并要求均为字符串
+func expectStrings(args []interface{}, n int) ([]string, error) {
+	if err := expectArgs(args, n); err != nil {
+		return nil, err
+	}
+	strs := make([]string, n)
+	for i, arg := range args {
+		s, ok := arg.(string)
+		if !ok {
+			return nil, fmt.Errorf("第%d个参数必须是字符串", i+1)
+		}
+		strs[i] = s
+	}
+	return strs, nil
+}
+
+// stringFunc 包装单参数字符串函数
+func stringFunc(fn func(string) string) scriptBuiltin {
+	return func(s *scriptInterpreter, args []interface{}) (interface{}, error) {
+		strs, err := expectStrings(args, 1)
+		if err != nil {
+			return nil, err
+		}
+		return fn(strs[0]), nil
+	}
+}
+
+// stringPredicate 包装双参数字符串判断函数
+func stringPredicate(fn func(string, string) bool) scriptBuiltin {
+	return func(s *scriptInterpreter, args []interface{}) (interface{}, error) {
+		strs, err := expectStrings(args, 2)
+		if err != nil {
+			return nil, err
+		}
+		return fn(strs[0], strs[1]), nil
+	}
+}
+
+// floatFunc 包装数值函数，整数参数原样返回
+func floatFunc(fn func(float64) float64) scriptBuiltin {
+	return func(s *scriptInterpreter, args []interface{}) (interface{}, error) {
+		if err := expectArgs(args, 1); err != nil {
+			return nil, err
+		}
+		if i, ok := args[0].(int64); ok {
+			return int64(fn(float64(i))), nil
+		}
+		f, ok := toScriptFloat(args[0])
+		if !ok {
+			return nil, fmt.Errorf("参数必须是数值")
+		}
+		return fn(f), nil
+	}
+}
+
+// pickNumber 从参数中按比较函数选出一个数值
+func pickNumber(args []interface{}, better func(a, b float64) bool) (interface{}, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("至少需要1个参数")
+	}
+	best := args[0]
+	bestValue, ok := toScriptFloat(best)
+	if !ok {
+		return nil, fmt.Errorf("参数必须是数值")
+	}
+	for _, arg := range args[1:] {
+		value, ok := toScriptFloat(arg)
+		if !ok {
+			return nil, fmt.Errorf("参数必须是数值")
+		}
+		if better(value, bestValue) {
+			best, bestValue = arg, value
+		}
+	}
+	return best, nil
+}
+
+// toScriptIndex 将下标转换为整数，流程变量经JSON反序列化后的整数为float64
+func toScriptIndex(v interface{}) (int64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return n, true
+	case float64:
+		if n == math.Trunc(n) {
+			return int64(n), true
+		}
+	}
+	return 0, false
+}
+
+// toScriptFloat 将数值转换为float64
+func toScriptFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// scriptEqual 比较两个脚本值，整数与浮点数按数值比较
+func scriptEqual(a, b interface{}) bool {
+	if af, ok := toScriptFloat(a); ok {
+		if bf, ok := toScriptFloat(b); ok {
+			return af == bf
+		}
+		return false
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// formatValue 将脚本值格式化为字符串，列表和映射格式化为JSON
+func (s *scriptInterpreter) formatValue(v interface{}) (string, error) {
+	switch val := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return val, nil
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64), nil
+	case map[string]interface{}, []interface{}:
+		budget := s.limits.MaxStringLen
+		if remaining := s.remaining(); remaining < budget {
+			budget = remaining
+		}
+		if _, ok := estimateScriptSize(val, budget); !ok {
+			return "", fmt.Errorf("结果长度超过限制%d", s.limits.MaxStringLen)
+		}
+		data, err := json.Marshal(val)
+		if err != nil {
+			return "", fmt.Errorf("格式化失败: %w", err)
+		}
+		return string(data), nil
+	}
+	return fmt.Sprint(v), nil
+}
+
+// estimateScriptSize 估算值格式化后的长度，超过budget时立即返回false
+// 每个元素至少计1字节，因此遍历次数也受budget约束
+func estimateScriptSize(v interface{}, budget int) (int, bool) {
+	size := 0
+	var walk func(v interface{}) bool
+	walk = func(v interface{}) bool {
+		switch val := v.(type) {
+		case string:
+			size += len(val) + 2
+		case []interface{}:
+			size += 2
+			for _, item := range val {
+				size++
+				if size > budget || !walk(item) {
+					return false
+				}
+			}
+		case map[string]interface{}:
+			size += 2
+			for k, item := range val {
+				size += len(k) + 4
+				if size > budget || !walk(item) {
+					return false
+				}
+			}
+		default:
+			size += 24
+		}
+		return size <= budget
+	}
+	ok := walk(v)
+	return size, ok
+}
+
+// scriptTypeName 返回脚本值的类型名，用于错误信息
+func scriptTypeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "nil"
+	case bool:
+		return "bool"
+	case int64:
+		return "int"
+	case float64:
+		return "float"
+	case string:
+		return "string"
+	case []interface{}:
+		return "list"
+	case map[string]interface{}:
+		return "map"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// normalizeScriptValue 将流程变量转换为脚本内部类型：整数统一为int64，浮点统一为float64
+func normalizeScriptValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case int:
+		return int64(val)
+	case int8:
+		return int64(val)
+	case int16:
+		return int64(val)
+	case int32:
+		return int64(val)
+	case uint:
+		return int64(val)
+	case uint8:
+		return int64(val)
+	case uint16:
+		return int64(val)
+	case uint32:
+		return int64(val)
+	case uint64:
+		return int64(val)
+	case float32:
+		return float64(val)
+	case json.Number:
+		if i, err := val.Int64(); err == nil {
+			return i
+		}
+		f, _ := val.Float64()
+		return f
+	case []string:
+		list := make([]interface{}, len(val))
+		for i, item := range val {
+			list[i] = item
+		}
+		return list
+	case []interface{}:
+		list := make([]interface{}, len(val))
+		for i, item := range val {
+			list[i] = normalizeScriptValue(item)
+		}
+		return list
+	case map[string]string:
+		m := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			m[k] = item
+		}
+		return m
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			m[k] = normalizeScriptValue(item)
+		}
+		return m
+	}
+	return v
+}
@@ -0,0 +1,111 @@
+package workflow
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunScript_ReadsAndWritesVariables(t *testing.T) {
+	variables := map[string]interface{}{
+		"estimated_hours": float64(30),
+		"skills":          []interface{}{"Go", "Redis"},
+		"task":            map[string]interface{}{"priority": "high"},
+	}
+
+	script := `
+total := 0
+for _, skill := range skills {
+	total += len(skill)
+}
+skill_chars = total
+if estimated_hours > 20 && task.priority == "high" {
+	level = "senior"
+} else {
+	level = "junior"
+}
+task.reviewed = true
+`
+	result, err := RunScript(context.Background(), script, variables, DefaultScriptLimits)
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(7), result["skill_chars"])
+	assert.Equal(t, "senior", result["level"])
+	assert.Equal(t, map[string]interface{}{"priority": "high", "reviewed": true}, result["task"])
+	// 局部变量和未修改的流程变量不输出
+	assert.NotContains(t, result, "total")
+	assert.NotContains(t, result, "skills")
+	// 原始流程变量不被修改
+	assert.Equal(t, map[string]interface{}{"priority": "high"}, variables["task"])
+}
+
+func TestRunScript_Limits(t *testing.T) {
+	_, err := RunScript(context.Background(), "for {}", nil, ScriptLimits{MaxSteps: 1000})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "最大执行步数")
+
+	_, err = RunScript(context.Background(), "for {}", nil, ScriptLimits{Timeout: 20 * time.Millisecond, MaxSteps: 1 << 30})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "超时")
+
+	_, err = RunScript(context.Background(), `s := "x"
+for i := 0; i < 100; i++ {
+	s = s + s
+}`, nil, DefaultScriptLimits)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "字符串长度超过限制")
+}
+
+func TestRunScript_RejectsUnsupportedConstructs(t *testing.T) {
+	scripts := []string{
+		`f := func() {}`,
+		`go len("x")`,
+		`x = os.Getenv("HOME")`,
+		`x = undefined_var + 1`,
+		`x = 1 / 0`,
+	}
+	for _, script := range scripts {
+		_, err := RunScript(context.Background(), script, nil, DefaultScriptLimits)
+		assert.Error(t, err, script)
+	}
+
+	_, err := RunScript(context.Background(), "a = 1\nb = a +", nil, DefaultScriptLimits)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "脚本语法错误")
+
+	_, err = RunScript(context.Background(), "a = 1\nb = missing", nil, DefaultScriptLimits)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "第2行")
+}
+
+func TestScriptNodeExecutor_FailureAndLegacyReference(t *testing.T) {
+	executor := &ScriptNodeExecutor{}
+	instance := &WorkflowInstance{Variables: map[string]interface{}{"count": float64(1)}}
+
+	result, err := executor.Execute(context.Background(), instance, &WorkflowNode{
+		ID:     "script",
+		Config: map[string]interface{}{"script": "count = count + 1"},
+	})
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, float64(2), result.Variables["count"])
+
+	result, err = executor.Execute(context.Background(), instance, &WorkflowNode{
+		ID:     "script",
+		Config: map[string]interface{}{"script": "count = count / 0"},
+	})
+	require.NoError(t, err)
+	assert.False(t, result.Success)
+	assert.Contains(t, result.Message, "除数不能为0")
+
+	result, err = executor.Execute(context.Background(), instance, &WorkflowNode{
+		ID:     "script",
+		Config: map[string]interface{}{"script": "update_task_status_to_assigned"},
+	})
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Empty(t, result.Variables)
+}
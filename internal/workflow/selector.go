@@ -300,6 +300,7 @@ func (s *WorkflowSelectorImpl) isWorkflowAvailable(ctx context.Context, workflow
 func (s *WorkflowSelectorImpl) getDefaultWorkflow(businessType string) string {
 	defaults := map[string]string{
 		"onboarding":      "onboarding-simple-approval-v1",
+		"offboarding":     "offboarding-approval-v1",
 		"task_assignment": "task-assignment-approval-v1",
 	}
 	
@@ -0,0 +1,53 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrExecutorMissing 启用的流程定义中存在没有执行器的节点类型
+var ErrExecutorMissing = errors.New("流程节点缺少执行器")
+
+// SelfCheckReport 流程引擎自检结果
+type SelfCheckReport struct {
+	ActiveDefinitions int        `json:"active_definitions"`
+	NodeTypes         []NodeType `json:"node_types"`
+	Missing           []string   `json:"missing,omitempty"` // 格式: 流程ID/节点ID(节点类型)
+}
+
+// SelfCheck 检查所有启用的流程定义中的节点类型在各执行器注册表中都有执行器
+// 流程定义不绑定业务类型，同一定义可能由任一注册表执行，因此要求每个注册表都支持
+func (e *WorkflowEngineImpl) SelfCheck(ctx context.Context) (*SelfCheckReport, error) {
+	active := true
+	definitions, err := e.definitionManager.ListWorkflows(ctx, WorkflowFilter{IsActive: &active})
+	if err != nil {
+		return nil, err
+	}
+
+	registries := []*ExecutorRegistry{e.taskExecutorRegistry, e.onboardingExecutorRegistry}
+	report := &SelfCheckReport{ActiveDefinitions: len(definitions)}
+	seen := make(map[NodeType]bool)
+	for _, definition := range definitions {
+		for _, node := range definition.Nodes {
+			if !seen[node.Type] {
+				seen[node.Type] = true
+				report.NodeTypes = append(report.NodeTypes, node.Type)
+			}
+			for _, registry := range registries {
+				if _, err := registry.GetExecutor(node.Type); err != nil {
+					report.Missing = append(report.Missing, fmt.Sprintf("%s/%s(%s)", definition.ID, node.ID, node.Type))
+					break
+				}
+			}
+		}
+	}
+	sort.Slice(report.NodeTypes, func(i, j int) bool { return report.NodeTypes[i] < report.NodeTypes[j] })
+
+	if len(report.Missing) > 0 {
+		return report, fmt.Errorf("%w: %s", ErrExecutorMissing, strings.Join(report.Missing, ", "))
+	}
+	return report, nil
+}
@@ -0,0 +1,44 @@
+package workflow
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// activeWorkflowRepository 返回固定启用流程定义的仓储桩
+type activeWorkflowRepository struct {
+	WorkflowRepository
+	definitions []*WorkflowDefinition
+}
+
+func (r *activeWorkflowRepository) ListWorkflowDefinitions(ctx context.Context, filter WorkflowFilter) ([]*WorkflowDefinition, error) {
+	return r.definitions, nil
+}
+
+func TestSelfCheck(t *testing.T) {
+	repo := &activeWorkflowRepository{definitions: []*WorkflowDefinition{
+		{
+			ID: "leave",
+			Nodes: []WorkflowNode{
+				{ID: "start", Type: NodeTypeStart},
+				{ID: "approve", Type: NodeTypeApproval},
+				{ID: "end", Type: NodeTypeEnd},
+			},
+		},
+	}}
+	engine := NewWorkflowEngine(NewWorkflowDefinitionManager(repo), &memoryInstanceRepository{}, nil, nil)
+
+	report, err := engine.SelfCheck(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.ActiveDefinitions)
+	assert.Equal(t, []NodeType{NodeTypeApproval, NodeTypeEnd, NodeTypeStart}, report.NodeTypes)
+
+	// 未注册执行器的节点类型
+	repo.definitions[0].Nodes = append(repo.definitions[0].Nodes, WorkflowNode{ID: "sign", Type: NodeType("countersign")})
+	report, err = engine.SelfCheck(context.Background())
+	require.ErrorIs(t, err, ErrExecutorMissing)
+	assert.Equal(t, []string{"leave/sign(countersign)"}, report.Missing)
+}
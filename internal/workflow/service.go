@@ -3,21 +3,16 @@ package workflow
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"taskmanage/internal/assignment"
 	"taskmanage/pkg/logger"
 )
 
-// TaskServiceInterface 任务服务接口（避免循环依赖）
-type TaskServiceInterface interface {
-	CompleteTaskAssignmentWorkflow(ctx context.Context, workflowInstanceID string, approved bool, approverID uint) error
-}
-
 // WorkflowService 审批流程服务
 type WorkflowService struct {
 	engine            WorkflowEngine
 	definitionManager *WorkflowDefinitionManager
-	taskService       TaskServiceInterface
 	selector          WorkflowSelector
 }
 
@@ -35,19 +30,26 @@ func NewWorkflowService(
 	return &WorkflowService{
 		engine:            engine,
 		definitionManager: definitionManager,
-		taskService:       nil, // 稍后通过SetTaskService注入
 		selector:          selector,
 	}
 }
 
-// SetTaskService 设置任务服务（解决循环依赖）
-func (s *WorkflowService) SetTaskService(taskService TaskServiceInterface) {
-	s.taskService = taskService
-}
-
-// StartTaskAssignmentApproval 启动任务分配审批流程
+// StartTaskAssignmentApproval 启动任务分配审批流程，任务已有运行中的审批流程时返回RunningInstanceError
 func (s *WorkflowService) StartTaskAssignmentApproval(ctx context.Context, req *TaskAssignmentApprovalRequest) (*WorkflowInstance, error) {
-	logger.Infof("启动任务分配审批流程: 任务ID=%d", req.TaskID)
+	logger.FromContext(ctx).Infof("启动任务分配审批流程: 任务ID=%d", req.TaskID)
+
+	// 流程变量在持久化前校验并统一类型
+	variables := map[string]interface{}{
+		"task_id":         req.TaskID,
+		"assignee_id":     req.AssigneeID,
+		"assignment_type": req.AssignmentType,
+		"priority":        req.Priority,
+		"requester_id":    req.RequesterID,
+		"reason":          req.Reason,
+	}
+	if err := normalizeIDVariables(variables, []string{"task_id", "assignee_id"}, []string{"requester_id"}); err != nil {
+		return nil, err
+	}
 
 	// 使用工作流选择器动态选择合适的工作流
 	selectionReq := &WorkflowSelectionRequest{
@@ -67,22 +69,16 @@ func (s *WorkflowService) StartTaskAssignmentApproval(ctx context.Context, req *
 		return nil, fmt.Errorf("选择工作流失败: %w", err)
 	}
 
-	logger.Infof("选择的工作流: %s", workflowID)
+	logger.FromContext(ctx).Infof("选择的工作流: %s", workflowID)
 
 	// 构建流程启动请求
 	startReq := &StartWorkflowRequest{
 		WorkflowID:   "simple-onboarding-approval-v1",
 		BusinessID:   fmt.Sprintf("task_%d", req.TaskID),
 		BusinessType: "task_assignment",
-		Variables: map[string]interface{}{
-			"task_id":         req.TaskID,
-			"assignee_id":     req.AssigneeID,
-			"assignment_type": req.AssignmentType,
-			"priority":        req.Priority,
-			"requester_id":    req.RequesterID,
-			"reason":          req.Reason,
-		},
-		StartedBy: req.RequesterID,
+		Variables:    variables,
+		StartedBy:    req.RequesterID,
+		Exclusive:    true,
 	}
 
 	// 启动流程
@@ -91,27 +87,90 @@ func (s *WorkflowService) StartTaskAssignmentApproval(ctx context.Context, req *
 		return nil, fmt.Errorf("启动任务分配审批流程失败: %w", err)
 	}
 
-	logger.Infof("任务分配审批流程启动成功: %s", instance.ID)
+	logger.FromContext(ctx).Infof("任务分配审批流程启动成功: %s", instance.ID)
 	return instance, nil
 }
 
-// StartOnboardingApproval 启动入职审批流程
+// StartOnboardingApproval 启动入职审批流程，员工已有运行中的入职审批时返回RunningInstanceError
+// 按WorkflowType选择简化或完整流程定义，未指定时使用简化流程；所选类型记录在workflow_type变量中
 func (s *WorkflowService) StartOnboardingApproval(ctx context.Context, req *OnboardingApprovalRequest) (*WorkflowInstance, error) {
-	logger.Infof("启动入职审批流程: 员工ID=%d", req.EmployeeID)
+	logger.FromContext(ctx).Infof("启动入职审批流程: 员工ID=%d", req.EmployeeID)
 
-	// 使用工作流选择器动态选择合适的工作流
-	selectionReq := &WorkflowSelectionRequest{
+	workflowType := req.WorkflowType
+	if workflowType == "" {
+		workflowType = OnboardingWorkflowSimple
+	}
+	workflowID, err := OnboardingWorkflowID(workflowType)
+	if err != nil {
+		return nil, err
+	}
+
+	// 流程变量在持久化前校验并统一类型，完整流程的部门负责人审批节点依赖department_manager_id
+	variables := map[string]interface{}{
+		"employee_id":           req.EmployeeID,
+		"employee_type":         req.EmployeeType,
+		"department_id":         req.DepartmentID,
+		"position_id":           req.PositionID,
+		"expected_date":         req.ExpectedDate,
+		"probation_period":      req.ProbationPeriod,
+		"workflow_type":         workflowType,
+		"department_manager_id": req.DepartmentManagerID,
+	}
+	required := []string{"employee_id"}
+	optional := []string{"department_id", "position_id", "department_manager_id"}
+	if workflowType == OnboardingWorkflowFull {
+		required = append(required, "department_manager_id")
+		optional = optional[:2]
+	}
+	if err := normalizeIDVariables(variables, required, optional); err != nil {
+		return nil, err
+	}
+
+	logger.FromContext(ctx).Infof("入职审批流程类型: %s, 工作流: %s", workflowType, workflowID)
+
+	// 构建流程启动请求
+	startReq := &StartWorkflowRequest{
+		WorkflowID:   workflowID,
+		BusinessID:   fmt.Sprintf("employee_%d", req.EmployeeID),
 		BusinessType: "onboarding",
+		Variables:    variables,
+		StartedBy:    req.RequesterID,
+		Exclusive:    true,
+	}
+
+	// 启动流程
+	instance, err := s.engine.StartWorkflow(ctx, startReq)
+	if err != nil {
+		return nil, fmt.Errorf("启动入职审批流程失败: %w", err)
+	}
+
+	logger.FromContext(ctx).Infof("入职审批流程启动成功: %s", instance.ID)
+	return instance, nil
+}
+
+// StartOffboardingApproval 启动离职审批流程
+func (s *WorkflowService) StartOffboardingApproval(ctx context.Context, req *OffboardingApprovalRequest) (*WorkflowInstance, error) {
+	logger.FromContext(ctx).Infof("启动离职审批流程: 员工ID=%d", req.EmployeeID)
+
+	variables := map[string]interface{}{
+		"employee_id":      req.EmployeeID,
+		"department_id":    req.DepartmentID,
+		"last_working_day": req.LastWorkingDay,
+		"reason":           req.Reason,
+	}
+	if err := normalizeIDVariables(variables, []string{"employee_id"}, []string{"department_id"}); err != nil {
+		return nil, err
+	}
+
+	selectionReq := &WorkflowSelectionRequest{
+		BusinessType: "offboarding",
 		Priority:     req.Priority,
 		UserID:       req.RequesterID,
 		DepartmentID: req.DepartmentID,
 		Context: map[string]interface{}{
 			"employee_id":      req.EmployeeID,
-			"employee_type":    req.EmployeeType,
 			"department_id":    req.DepartmentID,
-			"position_id":      req.PositionID,
-			"expected_date":    req.ExpectedDate,
-			"probation_period": req.ProbationPeriod,
+			"last_working_day": req.LastWorkingDay,
 		},
 	}
 
@@ -120,80 +179,83 @@ func (s *WorkflowService) StartOnboardingApproval(ctx context.Context, req *Onbo
 		return nil, fmt.Errorf("选择工作流失败: %w", err)
 	}
 
-	logger.Infof("选择的工作流: %s", workflowID)
+	logger.FromContext(ctx).Infof("选择的工作流: %s", workflowID)
 
-	// 构建流程启动请求
 	startReq := &StartWorkflowRequest{
-		WorkflowID:   "simple-onboarding-approval-v1",
+		WorkflowID:   workflowID,
 		BusinessID:   fmt.Sprintf("employee_%d", req.EmployeeID),
-		BusinessType: "onboarding",
-		Variables: map[string]interface{}{
-			"employee_id":      req.EmployeeID,
-			"employee_type":    req.EmployeeType,
-			"department_id":    req.DepartmentID,
-			"position_id":      req.PositionID,
-			"expected_date":    req.ExpectedDate,
-			"probation_period": req.ProbationPeriod,
-		},
-		StartedBy: req.RequesterID,
+		BusinessType: "offboarding",
+		Variables:    variables,
+		StartedBy:    req.RequesterID,
 	}
 
-	// 启动流程
 	instance, err := s.engine.StartWorkflow(ctx, startReq)
 	if err != nil {
-		return nil, fmt.Errorf("启动入职审批流程失败: %w", err)
+		return nil, fmt.Errorf("启动离职审批流程失败: %w", err)
 	}
 
-	logger.Infof("入职审批流程启动成功: %s", instance.ID)
+	logger.FromContext(ctx).Infof("离职审批流程启动成功: %s", instance.ID)
 	return instance, nil
 }
 
 // ProcessTaskAssignmentApproval 处理任务分配审批
 func (s *WorkflowService) ProcessTaskAssignmentApproval(ctx context.Context, req *ProcessApprovalRequest) (*ApprovalResult, error) {
-	logger.Infof("处理任务分配审批: 实例=%s, 动作=%s", req.InstanceID, req.Action)
+	logger.FromContext(ctx).Infof("处理任务分配审批: 实例=%s, 动作=%s", req.InstanceID, req.Action)
 
 	// 构建审批请求
 	approvalReq := &ApprovalRequest{
-		InstanceID: req.InstanceID,
-		NodeID:     req.NodeID,
-		Action:     req.Action,
-		Comment:    req.Comment,
-		Variables:  req.Variables,
-		ApprovedBy: req.ApprovedBy,
+		InstanceID:    req.InstanceID,
+		NodeID:        req.NodeID,
+		Action:        req.Action,
+		Comment:       req.Comment,
+		Variables:     req.Variables,
+		ApprovedBy:    req.ApprovedBy,
+		DelegateTo:    req.DelegateTo,
+		ReturnTo:      req.ReturnTo,
+		AttachmentIDs: req.AttachmentIDs,
+		Mentions:      req.Mentions,
 	}
 
-	// 处理审批
+	// 处理审批，流程结束时由引擎执行业务回调
 	result, err := s.engine.ProcessApproval(ctx, approvalReq)
 	if err != nil {
 		return nil, fmt.Errorf("处理任务分配审批失败: %w", err)
 	}
 
-	// 如果审批完成，执行后续操作
-	if result.IsCompleted {
-		if err := s.handleApprovalCompletion(ctx, result); err != nil {
-			logger.Errorf("处理审批完成后续操作失败: %v", err)
-		}
-	}
-
 	return result, nil
 }
 
-// GetPendingTaskAssignmentApprovals 获取待审批的任务分配
-func (s *WorkflowService) GetPendingTaskAssignmentApprovals(ctx context.Context, userID uint) ([]*PendingApproval, error) {
-	approvals, err := s.engine.GetPendingApprovals(ctx, userID)
+// GetPendingApprovals 按条件分页获取待审批任务及总数
+func (s *WorkflowService) GetPendingApprovals(ctx context.Context, filter PendingApprovalFilter) ([]*PendingApproval, int64, error) {
+	approvals, total, err := s.engine.GetPendingApprovals(ctx, filter)
 	if err != nil {
-		return nil, fmt.Errorf("获取待审批任务失败: %w", err)
+		return nil, 0, fmt.Errorf("获取待审批任务失败: %w", err)
 	}
+	return approvals, total, nil
+}
 
-	// 过滤任务分配相关的审批
-	var taskApprovals []*PendingApproval
-	for _, approval := range approvals {
-		if approval.BusinessType == "task_assignment" {
-			taskApprovals = append(taskApprovals, approval)
-		}
+// CountPendingApprovals 按条件统计待审批任务数
+func (s *WorkflowService) CountPendingApprovals(ctx context.Context, filter PendingApprovalFilter) (int64, error) {
+	count, err := s.engine.CountPendingApprovals(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("统计待审批任务失败: %w", err)
 	}
+	return count, nil
+}
 
-	return taskApprovals, nil
+// SummarizePendingApprovals 按条件汇总待审批任务
+func (s *WorkflowService) SummarizePendingApprovals(ctx context.Context, filter PendingApprovalFilter) (*PendingApprovalSummary, error) {
+	summary, err := s.engine.SummarizePendingApprovals(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("汇总待审批任务失败: %w", err)
+	}
+	return summary, nil
+}
+
+// GetPendingTaskAssignmentApprovals 获取待审批的任务分配，忽略过滤条件中的业务类型
+func (s *WorkflowService) GetPendingTaskAssignmentApprovals(ctx context.Context, filter PendingApprovalFilter) ([]*PendingApproval, int64, error) {
+	filter.BusinessType = "task_assignment"
+	return s.GetPendingApprovals(ctx, filter)
 }
 
 // GetWorkflowInstance 获取流程实例
@@ -201,14 +263,67 @@ func (s *WorkflowService) GetWorkflowInstance(ctx context.Context, instanceID st
 	return s.engine.GetWorkflowInstance(ctx, instanceID)
 }
 
+// GetWorkflowInstanceForViewer 获取流程实例，按查看者的权限脱敏敏感变量
+func (s *WorkflowService) GetWorkflowInstanceForViewer(ctx context.Context, instanceID string, viewer VariableViewer) (*WorkflowInstance, error) {
+	return s.engine.GetWorkflowInstanceForViewer(ctx, instanceID, viewer)
+}
+
+// ListInstances 按条件分页获取流程实例摘要及总数
+func (s *WorkflowService) ListInstances(ctx context.Context, filter InstanceFilter) ([]*InstanceSummary, int64, error) {
+	instances, total, err := s.engine.ListInstances(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("获取流程实例失败: %w", err)
+	}
+	return instances, total, nil
+}
+
+// InstanceStats 按条件统计流程实例
+func (s *WorkflowService) InstanceStats(ctx context.Context, filter InstanceFilter) (*InstanceStats, error) {
+	stats, err := s.engine.InstanceStats(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("统计流程实例失败: %w", err)
+	}
+	return stats, nil
+}
+
+// ExpireOverdueInstances 将超过SLA或节点超时的运行中实例标记为过期
+func (s *WorkflowService) ExpireOverdueInstances(ctx context.Context, now time.Time) (*ExpiryReport, error) {
+	return s.engine.ExpireOverdueInstances(ctx, now)
+}
+
+// VerifyHistory 校验实例执行历史的哈希链
+func (s *WorkflowService) VerifyHistory(ctx context.Context, instanceID string) (*HistoryVerification, error) {
+	return s.engine.VerifyHistory(ctx, instanceID)
+}
+
+// GetExecutionHistory 获取实例的执行历史
+func (s *WorkflowService) GetExecutionHistory(ctx context.Context, instanceID string) ([]ExecutionHistory, error) {
+	return s.engine.GetExecutionHistory(ctx, instanceID)
+}
+
 // CancelTaskAssignmentApproval 取消任务分配审批
 func (s *WorkflowService) CancelTaskAssignmentApproval(ctx context.Context, instanceID string, reason string) error {
 	return s.engine.CancelWorkflow(ctx, instanceID, reason)
 }
 
+// RecoverInstances 恢复中断的节点执行
+func (s *WorkflowService) RecoverInstances(ctx context.Context) (*RecoveryReport, error) {
+	return s.engine.RecoverInstances(ctx)
+}
+
+// RetryCompletion 重新执行失败的业务回调
+func (s *WorkflowService) RetryCompletion(ctx context.Context, instanceID string) (*CompletionRecord, error) {
+	return s.engine.RetryCompletion(ctx, instanceID)
+}
+
+// SelfCheck 流程引擎自检
+func (s *WorkflowService) SelfCheck(ctx context.Context) (*SelfCheckReport, error) {
+	return s.engine.SelfCheck(ctx)
+}
+
 // CreateTaskAssignmentWorkflow 创建任务分配审批流程定义
 func (s *WorkflowService) CreateTaskAssignmentWorkflow(ctx context.Context) error {
-	logger.Info("创建任务分配审批流程定义")
+	logger.FromContext(ctx).Info("创建任务分配审批流程定义")
 
 	// 定义流程节点
 	nodes := []WorkflowNode{
@@ -362,72 +477,7 @@ func (s *WorkflowService) CreateTaskAssignmentWorkflow(ctx context.Context) erro
 		return fmt.Errorf("创建任务分配审批流程定义失败: %w", err)
 	}
 
-	logger.Info("任务分配审批流程定义创建成功")
-	return nil
-}
-
-// handleApprovalCompletion 处理审批完成后续操作
-func (s *WorkflowService) handleApprovalCompletion(ctx context.Context, result *ApprovalResult) error {
-	// 获取流程实例
-	instance, err := s.engine.GetWorkflowInstance(ctx, result.InstanceID)
-	if err != nil {
-		return fmt.Errorf("获取流程实例失败: %w", err)
-	}
-
-	// 只处理任务分配相关的工作流
-	if instance.BusinessType != "task_assignment" {
-		logger.Infof("跳过非任务分配工作流: %s", instance.BusinessType)
-		return nil
-	}
-
-	// 提取业务数据
-	taskID, ok := instance.Variables["task_id"].(float64)
-	if !ok {
-		return fmt.Errorf("无法获取任务ID")
-	}
-
-	assigneeID, ok := instance.Variables["assignee_id"].(float64)
-	if !ok {
-		return fmt.Errorf("无法获取分配人ID")
-	}
-
-	// 获取审批人ID (从执行历史中获取最后一个审批操作的执行人)
-	approverID := uint(0)
-	if len(instance.History) > 0 {
-		lastHistory := instance.History[len(instance.History)-1]
-		if executedBy, ok := lastHistory.Variables["executed_by"].(float64); ok {
-			approverID = uint(executedBy)
-		}
-	}
-
-	// 根据审批结果执行相应操作
-	approved := result.Action == ActionApprove
-
-	logger.Infof("工作流完成，调用任务服务处理结果: InstanceID=%s, Approved=%v, TaskID=%d, AssigneeID=%d, ApproverID=%d",
-		result.InstanceID, approved, int(taskID), int(assigneeID), approverID)
-
-	// 调用任务服务完成工作流
-	if s.taskService != nil {
-		err := s.taskService.CompleteTaskAssignmentWorkflow(ctx, result.InstanceID, approved, approverID)
-		if err != nil {
-			logger.Errorf("完成任务分配工作流失败: %v", err)
-			return fmt.Errorf("完成任务分配工作流失败: %w", err)
-		}
-
-		if approved {
-			logger.Infof("审批通过，任务分配已执行: 任务=%d, 分配给=%d", int(taskID), int(assigneeID))
-		} else {
-			logger.Infof("审批拒绝，任务分配已取消: 任务=%d", int(taskID))
-		}
-	} else {
-		// 兜底处理，记录日志
-		if approved {
-			logger.Infof("审批通过，任务分配将被执行: 任务=%d, 分配给=%d", int(taskID), int(assigneeID))
-		} else {
-			logger.Infof("审批拒绝，任务分配被取消: 任务=%d", int(taskID))
-		}
-	}
-
+	logger.FromContext(ctx).Info("任务分配审批流程定义创建成功")
 	return nil
 }
 
@@ -451,39 +501,88 @@ type OnboardingApprovalRequest struct {
 	ProbationPeriod int    `json:"probation_period"`
 	Priority        string `json:"priority"`
 	RequesterID     uint   `json:"requester_id"`
+	// WorkflowType 流程类型 simple/full，为空时使用简化流程
+	WorkflowType string `json:"workflow_type"`
+	// DepartmentManagerID 部门负责人用户ID，完整流程必填
+	DepartmentManagerID *uint `json:"department_manager_id"`
+}
+
+// OffboardingApprovalRequest 离职审批请求
+type OffboardingApprovalRequest struct {
+	EmployeeID     uint   `json:"employee_id"`
+	DepartmentID   *uint  `json:"department_id"`
+	LastWorkingDay string `json:"last_working_day"`
+	Reason         string `json:"reason"`
+	Priority       string `json:"priority"`
+	RequesterID    uint   `json:"requester_id"`
 }
 
 // ProcessApprovalRequest 处理审批请求
 type ProcessApprovalRequest struct {
-	InstanceID string                 `json:"instance_id"`
-	NodeID     string                 `json:"node_id"`
-	Action     ApprovalAction         `json:"action"`
-	Comment    string                 `json:"comment,omitempty"`
-	Variables  map[string]interface{} `json:"variables,omitempty"`
-	ApprovedBy uint                   `json:"approved_by"`
+	InstanceID    string                 `json:"instance_id"`
+	NodeID        string                 `json:"node_id"`
+	Action        ApprovalAction         `json:"action"`
+	Comment       string                 `json:"comment,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+	ApprovedBy    uint                   `json:"approved_by"`
+	DelegateTo    uint                   `json:"delegate_to,omitempty"`
+	ReturnTo      string                 `json:"return_to,omitempty"`
+	AttachmentIDs []uint                 `json:"attachment_ids,omitempty"`
+	Mentions      []uint                 `json:"mentions,omitempty"`
 }
 
 // ProcessOnboardingApproval 处理入职审批
 func (s *WorkflowService) ProcessOnboardingApproval(ctx context.Context, req *ApprovalRequest) (*ApprovalResult, error) {
-	logger.Infof("处理入职审批: InstanceID=%s, Action=%s", req.InstanceID, req.Action)
+	logger.FromContext(ctx).Infof("处理入职审批: InstanceID=%s, Action=%s", req.InstanceID, req.Action)
 
 	// 转换为ProcessApprovalRequest类型
 	processReq := &ProcessApprovalRequest{
-		InstanceID: req.InstanceID,
-		NodeID:     req.NodeID,
-		Action:     req.Action,
-		Comment:    req.Comment,
-		Variables:  req.Variables,
-		ApprovedBy: req.ApprovedBy,
+		InstanceID:    req.InstanceID,
+		NodeID:        req.NodeID,
+		Action:        req.Action,
+		Comment:       req.Comment,
+		Variables:     req.Variables,
+		ApprovedBy:    req.ApprovedBy,
+		DelegateTo:    req.DelegateTo,
+		ReturnTo:      req.ReturnTo,
+		AttachmentIDs: req.AttachmentIDs,
+		Mentions:      req.Mentions,
 	}
 
 	// 调用通用的审批处理方法
 	result, err := s.ProcessTaskAssignmentApproval(ctx, processReq)
 	if err != nil {
-		logger.Errorf("处理入职审批失败: %v", err)
+		logger.FromContext(ctx).Errorf("处理入职审批失败: %v", err)
 		return nil, fmt.Errorf("处理入职审批失败: %w", err)
 	}
 
-	logger.Infof("入职审批处理完成: InstanceID=%s, IsCompleted=%t", req.InstanceID, result.IsCompleted)
+	logger.FromContext(ctx).Infof("入职审批处理完成: InstanceID=%s, IsCompleted=%t", req.InstanceID, result.IsCompleted)
+	return result, nil
+}
+
+// ProcessOffboardingApproval 处理离职审批
+func (s *WorkflowService) ProcessOffboardingApproval(ctx context.Context, req *ApprovalRequest) (*ApprovalResult, error) {
+	logger.FromContext(ctx).Infof("处理离职审批: InstanceID=%s, Action=%s", req.InstanceID, req.Action)
+
+	processReq := &ProcessApprovalRequest{
+		InstanceID:    req.InstanceID,
+		NodeID:        req.NodeID,
+		Action:        req.Action,
+		Comment:       req.Comment,
+		Variables:     req.Variables,
+		ApprovedBy:    req.ApprovedBy,
+		DelegateTo:    req.DelegateTo,
+		ReturnTo:      req.ReturnTo,
+		AttachmentIDs: req.AttachmentIDs,
+		Mentions:      req.Mentions,
+	}
+
+	result, err := s.ProcessTaskAssignmentApproval(ctx, processReq)
+	if err != nil {
+		logger.FromContext(ctx).Errorf("处理离职审批失败: %v", err)
+		return nil, fmt.Errorf("处理离职审批失败: %w", err)
+	}
+
+	logger.FromContext(ctx).Infof("离职审批处理完成: InstanceID=%s, IsCompleted=%t", req.InstanceID, result.IsCompleted)
 	return result, nil
 }
@@ -22,25 +22,62 @@ type WorkflowEngine interface {
 	// GetWorkflowInstance 获取流程实例
 	GetWorkflowInstance(ctx context.Context, instanceID string) (*WorkflowInstance, error)
 
-	// GetPendingApprovals 获取待审批任务
-	GetPendingApprovals(ctx context.Context, userID uint) ([]*PendingApproval, error)
+	// GetWorkflowInstanceForViewer 获取流程实例，查看者无权查看敏感变量时返回脱敏后的副本
+	GetWorkflowInstanceForViewer(ctx context.Context, instanceID string, viewer VariableViewer) (*WorkflowInstance, error)
+
+	// GetPendingApprovals 按条件分页获取待审批任务及总数
+	GetPendingApprovals(ctx context.Context, filter PendingApprovalFilter) ([]*PendingApproval, int64, error)
+
+	// CountPendingApprovals 按条件统计待审批任务数
+	CountPendingApprovals(ctx context.Context, filter PendingApprovalFilter) (int64, error)
+
+	// SummarizePendingApprovals 按条件汇总待审批总数、逾期数和各业务类型的数量
+	SummarizePendingApprovals(ctx context.Context, filter PendingApprovalFilter) (*PendingApprovalSummary, error)
 
 	// CancelWorkflow 取消流程
 	CancelWorkflow(ctx context.Context, instanceID string, reason string) error
+
+	// RecoverInstances 恢复中断的节点执行
+	RecoverInstances(ctx context.Context) (*RecoveryReport, error)
+
+	// RetryCompletion 重新执行失败的业务回调
+	RetryCompletion(ctx context.Context, instanceID string) (*CompletionRecord, error)
+
+	// SelfCheck 检查启用的流程定义的节点类型都有执行器
+	SelfCheck(ctx context.Context) (*SelfCheckReport, error)
+
+	// ListInstances 按条件分页获取流程实例摘要及总数
+	ListInstances(ctx context.Context, filter InstanceFilter) ([]*InstanceSummary, int64, error)
+
+	// InstanceStats 按条件统计流程实例，按状态和流程分组
+	InstanceStats(ctx context.Context, filter InstanceFilter) (*InstanceStats, error)
+
+	// ExpireOverdueInstances 将超过SLA或节点超时的运行中实例标记为过期
+	ExpireOverdueInstances(ctx context.Context, now time.Time) (*ExpiryReport, error)
+
+	// VerifyHistory 校验实例执行历史的哈希链，报告第一处断链
+	VerifyHistory(ctx context.Context, instanceID string) (*HistoryVerification, error)
+
+	// GetExecutionHistory 获取实例的执行历史，按执行时间升序
+	GetExecutionHistory(ctx context.Context, instanceID string) ([]ExecutionHistory, error)
 }
 
 // WorkflowDefinition 流程定义
 type WorkflowDefinition struct {
-	ID          string                 `json:"id"`
-	Name        string                 `json:"name"`
-	Description string                 `json:"description"`
-	Version     string                 `json:"version"`
-	Nodes       []WorkflowNode         `json:"nodes"`
-	Edges       []WorkflowEdge         `json:"edges"`
-	Variables   map[string]interface{} `json:"variables,omitempty"`
-	CreatedAt   time.Time              `json:"created_at"`
-	UpdatedAt   time.Time              `json:"updated_at"`
-	IsActive    bool                   `json:"is_active"`
+	ID            string                 `json:"id"`
+	VersionID     uint                   `json:"version_id"`     // 版本记录ID
+	VersionNumber int                    `json:"version_number"` // 版本号
+	Name          string                 `json:"name"`
+	Description   string                 `json:"description"`
+	Version       string                 `json:"version"`
+	Nodes         []WorkflowNode         `json:"nodes"`
+	Edges         []WorkflowEdge         `json:"edges"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+	VariableSchema []VariableDefinition  `json:"variable_schema,omitempty"` // 声明的流程变量，为空时启动不校验变量
+	MaxDuration   int64                  `json:"max_duration,omitempty"` // 流程SLA（秒），实例运行超过该时长后过期，0表示不限制
+	CreatedAt     time.Time              `json:"created_at"`
+	UpdatedAt     time.Time              `json:"updated_at"`
+	IsActive      bool                   `json:"is_active"`
 }
 
 // GetStartNode 获取开始节点
@@ -94,9 +131,10 @@ type NodePosition struct {
 
 // WorkflowInstance 流程实例
 type WorkflowInstance struct {
-	ID           string                 `json:"id"`
-	WorkflowID   string                 `json:"workflow_id"`
-	BusinessID   string                 `json:"business_id"`   // 业务对象ID（如任务ID）
+	ID                  string                 `json:"id"`
+	WorkflowID          string                 `json:"workflow_id"`
+	DefinitionVersionID uint                   `json:"definition_version_id"` // 启动时绑定的流程定义版本ID
+	BusinessID          string                 `json:"business_id"`   // 业务对象ID（如任务ID）
 	BusinessType string                 `json:"business_type"` // 业务类型（如task_assignment）
 	Status       InstanceStatus         `json:"status"`
 	CurrentNodes []string               `json:"current_nodes"` // 当前活跃节点
@@ -105,6 +143,10 @@ type WorkflowInstance struct {
 	StartedAt    time.Time              `json:"started_at"`
 	CompletedAt  *time.Time             `json:"completed_at,omitempty"`
 	History      []ExecutionHistory     `json:"history"`
+	Approvals    map[string]*NodeApprovalState `json:"approvals,omitempty"` // 审批节点的逐人决策，按节点ID索引
+	Completion   *CompletionRecord      `json:"completion,omitempty"` // 流程结束后业务回调的执行记录
+	Deadline     *time.Time             `json:"deadline,omitempty"`   // 按流程SLA计算的截止时间
+	RemainingSeconds *int64             `json:"remaining_seconds,omitempty"` // 距截止时间的剩余秒数，已超时为负数，仅运行中的实例返回
 }
 
 // InstanceStatus 实例状态
@@ -116,6 +158,7 @@ const (
 	StatusCancelled InstanceStatus = "cancelled" // 已取消
 	StatusFailed    InstanceStatus = "failed"    // 失败
 	StatusSuspended InstanceStatus = "suspended" // 暂停
+	StatusExpired   InstanceStatus = "expired"   // 超过SLA或节点超时，已自动终止
 )
 
 // ExecutionHistory 执行历史
@@ -126,6 +169,8 @@ type ExecutionHistory struct {
 	Action      string                 `json:"action"`
 	Result      string                 `json:"result"`
 	Comment     string                 `json:"comment,omitempty"`
+	Attachments []ApprovalAttachment   `json:"attachments,omitempty"` // 审批意见附带的附件
+	Mentions    []uint                 `json:"mentions,omitempty"`    // 审批意见提及的用户
 	Variables   map[string]interface{} `json:"variables,omitempty"`
 	ExecutedBy  uint                   `json:"executed_by"`
 	ExecutedAt  time.Time              `json:"executed_at"`
@@ -139,6 +184,7 @@ type StartWorkflowRequest struct {
 	BusinessType string                 `json:"business_type"`
 	Variables    map[string]interface{} `json:"variables,omitempty"`
 	StartedBy    uint                   `json:"started_by"`
+	Exclusive    bool                   `json:"exclusive,omitempty"` // 同一业务对象只允许一个运行中的实例，重复启动时返回RunningInstanceError
 }
 
 // ApprovalRequest 审批请求
@@ -149,6 +195,10 @@ type ApprovalRequest struct {
 	Comment    string                 `json:"comment,omitempty"`
 	Variables  map[string]interface{} `json:"variables,omitempty"`
 	ApprovedBy uint                   `json:"approved_by"`
+	DelegateTo uint                   `json:"delegate_to,omitempty"` // 委托对象，仅delegate动作使用
+	ReturnTo   string                 `json:"return_to,omitempty"`   // 退回目标节点，仅return动作使用，为空时退回到默认目标
+	AttachmentIDs []uint              `json:"attachment_ids,omitempty"` // 附件ID，须为审批人上传或属于流程关联任务的附件
+	Mentions      []uint              `json:"mentions,omitempty"`       // 提及的用户ID，最多10个，被提及的用户会收到通知
 }
 
 // ApprovalAction 审批动作
@@ -159,6 +209,7 @@ const (
 	ActionReject  ApprovalAction = "reject"  // 拒绝
 	ActionReturn  ApprovalAction = "return"  // 退回
 	ActionDelegate ApprovalAction = "delegate" // 委托
+	ActionResubmit ApprovalAction = "resubmit" // 重新提交，退回到开始节点后由发起人补充材料使用
 )
 
 // ApprovalResult 审批结果
@@ -170,6 +221,7 @@ type ApprovalResult struct {
 	IsCompleted  bool           `json:"is_completed"`
 	Message      string         `json:"message"`
 	ExecutedAt   time.Time      `json:"executed_at"`
+	Approval     *NodeApprovalState `json:"approval,omitempty"` // 节点当前的逐人决策
 }
 
 // PendingApproval 待审批任务
@@ -185,6 +237,7 @@ type PendingApproval struct {
 	AssignedTo     uint                   `json:"assigned_to"`
 	CreatedAt      time.Time              `json:"created_at"`
 	Deadline       *time.Time             `json:"deadline,omitempty"`
+	Overdue        bool                   `json:"overdue"` // 截止时间已过，仅在查询待审批列表时计算
 	CanDelegate    bool                   `json:"can_delegate"`
 	RequiredAction []ApprovalAction       `json:"required_actions"`
 }
@@ -194,9 +247,13 @@ type ApprovalNodeConfig struct {
 	Assignees    []ApprovalAssignee `json:"assignees"`              // 审批人配置
 	ApprovalType ApprovalType       `json:"approval_type"`          // 审批类型
 	Deadline     *time.Duration     `json:"deadline,omitempty"`     // 审批期限
-	AutoApprove  bool               `json:"auto_approve,omitempty"` // 超时自动审批
+	AutoApprove  bool               `json:"auto_approve,omitempty"` // 自动审批，配置了条件时条件成立才自动审批
+	AutoApproveCondition string     `json:"auto_approve_condition,omitempty"` // 自动审批条件，格式同条件节点表达式，如 "estimated_hours < 4"
+	AutoApproveSelf bool            `json:"auto_approve_self,omitempty"` // 审批人只有发起人本人时自动审批
 	CanDelegate  bool               `json:"can_delegate,omitempty"` // 允许委托
 	CanReturn    bool               `json:"can_return,omitempty"`   // 允许退回
+	ReturnTargets []string          `json:"return_targets,omitempty"` // 允许退回的节点，为空时可退回到任一上游审批节点或开始节点
+	RequireCommentOnReject bool     `json:"require_comment_on_reject,omitempty"` // 拒绝时必须填写审批意见
 	Priority     int                `json:"priority,omitempty"`     // 优先级
 }
 
@@ -211,13 +268,14 @@ type ApprovalAssignee struct {
 type AssigneeType string
 
 const (
-	AssigneeTypeUser       AssigneeType = "user"        // 指定用户
-	AssigneeTypeRole       AssigneeType = "role"        // 指定角色
-	AssigneeTypeDepartment AssigneeType = "department"  // 指定部门
-	AssigneeTypeManager    AssigneeType = "manager"     // 直属上级
-	AssigneeTypeStarter    AssigneeType = "starter"     // 流程发起人
-	AssigneeTypeVariable   AssigneeType = "variable"    // 变量指定
-	AssigneeTypeScript     AssigneeType = "script"      // 脚本计算
+	AssigneeTypeUser              AssigneeType = "user"               // 指定用户
+	AssigneeTypeRole              AssigneeType = "role"               // 指定角色
+	AssigneeTypeDepartment        AssigneeType = "department"         // 指定部门
+	AssigneeTypeManager           AssigneeType = "manager"            // 直属上级
+	AssigneeTypeDepartmentManager AssigneeType = "department_manager" // 发起人所在部门的负责人，未设置时回退到直属上级
+	AssigneeTypeStarter           AssigneeType = "starter"            // 流程发起人
+	AssigneeTypeVariable          AssigneeType = "variable"           // 变量指定
+	AssigneeTypeScript            AssigneeType = "script"             // 脚本计算
 )
 
 // ApprovalType 审批类型
@@ -233,14 +291,24 @@ const (
 
 // ConditionNodeConfig 条件节点配置
 type ConditionNodeConfig struct {
-	Conditions []ConditionRule `json:"conditions"`
+	Conditions     []ConditionRule     `json:"conditions"`
+	EvaluationMode ConditionEvaluation `json:"evaluation_mode,omitempty"` // 评估方式，默认first_match
+	DefaultTarget  string              `json:"default_target,omitempty"`  // 没有条件满足时流向的节点
 }
 
+// ConditionEvaluation 条件节点评估方式
+type ConditionEvaluation string
+
+const (
+	ConditionFirstMatch ConditionEvaluation = "first_match" // 只流向优先级最高的满足条件
+	ConditionAllMatches ConditionEvaluation = "all_matches" // 流向全部满足的条件，各分支并行执行
+)
+
 // ConditionRule 条件规则
 type ConditionRule struct {
 	Expression string `json:"expression"` // 条件表达式
 	Target     string `json:"target"`     // 目标节点
-	Priority   int    `json:"priority"`   // 优先级
+	Priority   int    `json:"priority"`   // 优先级，数值越大越先评估，相同优先级按配置顺序
 }
 
 // NotificationConfig 通知配置
@@ -270,6 +338,18 @@ type WorkflowRepository interface {
 
 	// ListWorkflowDefinitions 列出流程定义
 	ListWorkflowDefinitions(ctx context.Context, filter WorkflowFilter) ([]*WorkflowDefinition, error)
+
+	// GetWorkflowDefinitionVersion 根据版本ID获取流程定义
+	GetWorkflowDefinitionVersion(ctx context.Context, versionID uint) (*WorkflowDefinition, error)
+
+	// ListWorkflowDefinitionVersions 列出流程定义的所有版本
+	ListWorkflowDefinitionVersions(ctx context.Context, workflowID string) ([]*WorkflowDefinition, error)
+
+	// SetWorkflowVersionActive 设置流程定义版本的激活状态
+	SetWorkflowVersionActive(ctx context.Context, workflowID string, versionID uint, isActive bool) error
+
+	// UpdateWorkflowStatus 更新流程所有版本的激活状态
+	UpdateWorkflowStatus(ctx context.Context, workflowID string, isActive bool) error
 }
 
 // WorkflowInstanceRepository 流程实例仓库接口
@@ -280,23 +360,69 @@ type WorkflowInstanceRepository interface {
 	// GetInstance 获取流程实例
 	GetInstance(ctx context.Context, instanceID string) (*WorkflowInstance, error)
 
+	// FindRunningByBusiness 获取业务对象运行中的流程实例，没有时返回nil
+	FindRunningByBusiness(ctx context.Context, businessType, businessID string) (*WorkflowInstance, error)
+
 	// UpdateInstanceStatus 更新实例状态
 	UpdateInstanceStatus(ctx context.Context, instanceID string, status InstanceStatus) error
 
 	// UpdateInstance 更新流程实例
 	UpdateInstance(ctx context.Context, instance *WorkflowInstance) error
 
-	// AddExecutionHistory 添加执行历史
-	AddExecutionHistory(ctx context.Context, instanceID string, history ExecutionHistory) error
+	// CancelInstance 在同一事务中写入取消历史并将实例标记为已取消
+	CancelInstance(ctx context.Context, instance *WorkflowInstance, history ExecutionHistory) error
+
+	// VerifyExecutionHistory 按写入顺序校验实例执行历史的哈希链
+	VerifyExecutionHistory(ctx context.Context, instanceID string) (*HistoryVerification, error)
 
-	// GetPendingApprovals 获取待审批任务
-	GetPendingApprovals(ctx context.Context, userID uint) ([]*PendingApproval, error)
+	// GetPendingApprovals 按条件分页获取未完成的待审批任务及总数
+	GetPendingApprovals(ctx context.Context, filter PendingApprovalFilter) ([]*PendingApproval, int64, error)
+
+	// CountPendingApprovals 按条件统计未完成的待审批任务数
+	CountPendingApprovals(ctx context.Context, filter PendingApprovalFilter) (int64, error)
+
+	// CountPendingApprovalGroups 按流程名称和业务类型分组统计未完成的待审批数及其中的逾期数
+	CountPendingApprovalGroups(ctx context.Context, filter PendingApprovalFilter) ([]*PendingApprovalGroupCount, error)
 
 	// SavePendingApproval 保存待审批记录
 	SavePendingApproval(ctx context.Context, approval *PendingApproval) error
 
 	// DeletePendingApproval 删除待审批记录
 	DeletePendingApproval(ctx context.Context, instanceID, nodeID string, userID uint) error
+
+	// SaveNodeExecutionResult 在同一事务中写入节点的待审批记录、执行历史和实例状态
+	SaveNodeExecutionResult(ctx context.Context, instance *WorkflowInstance, history ExecutionHistory, approvals []*PendingApproval) error
+
+	// SaveApprovalResult 在同一事务中写入审批动作的历史、待审批记录变更和实例状态
+	SaveApprovalResult(ctx context.Context, instance *WorkflowInstance, history ExecutionHistory, change PendingApprovalChange) error
+
+	// ListInstancesByStatus 按状态列出流程实例
+	ListInstancesByStatus(ctx context.Context, status InstanceStatus) ([]*WorkflowInstance, error)
+
+	// GetExecutionHistory 获取实例的执行历史，按执行时间升序
+	GetExecutionHistory(ctx context.Context, instanceID string) ([]ExecutionHistory, error)
+
+	// HasOpenPendingApprovals 节点是否还有未完成的待审批记录
+	HasOpenPendingApprovals(ctx context.Context, instanceID, nodeID string) (bool, error)
+
+	// SaveCompletion 保存业务回调执行记录
+	SaveCompletion(ctx context.Context, instanceID string, record *CompletionRecord) error
+
+	// ListInstances 按条件分页获取流程实例摘要及总数，不加载流程变量
+	ListInstances(ctx context.Context, filter InstanceFilter) ([]*InstanceSummary, int64, error)
+
+	// CountInstances 按条件统计实例数，按流程和状态分组
+	CountInstances(ctx context.Context, filter InstanceFilter) ([]InstanceCount, error)
+
+	// ListOverdueInstances 列出超过截止时间或存在超时待审批的运行中实例
+	ListOverdueInstances(ctx context.Context, now time.Time) ([]*WorkflowInstance, error)
+
+	// ExpireInstance 在同一事务中关闭实例的待审批记录、写入执行历史并将实例标记为过期
+	ExpireInstance(ctx context.Context, instance *WorkflowInstance, history ExecutionHistory) error
+
+	// LockInstance 锁定流程实例并在锁内执行fn，同一实例的调用串行执行
+	// fn内须使用传入的ctx访问仓库，使读写与锁处于同一事务
+	LockInstance(ctx context.Context, instanceID string, fn func(ctx context.Context) error) error
 }
 
 // WorkflowFilter 流程过滤条件
@@ -327,4 +453,22 @@ type NodeExecutionResult struct {
 	Message     string                 `json:"message,omitempty"`
 	WaitForUser bool                   `json:"wait_for_user"` // 是否等待用户操作
 	Error       error                  `json:"error,omitempty"`
+
+	// PendingApprovals 节点产生的待审批记录，由引擎与实例状态在同一事务中写入
+	PendingApprovals []*PendingApproval `json:"-"`
+
+	// ApprovalState 审批节点的决策记录，由引擎写入实例的Approvals
+	ApprovalState *NodeApprovalState `json:"-"`
+
+	// AutoApproveReason 审批节点满足自动审批规则时的原因，非空时引擎以系统身份记录审批通过并流转到后续节点
+	AutoApproveReason string `json:"-"`
+}
+
+// PendingApprovalChange 一次审批动作对节点待审批记录的变更
+type PendingApprovalChange struct {
+	NodeID      string             // 审批节点ID
+	CompleteAll bool               // 节点已出结果，关闭该节点全部未完成的待审批记录
+	CompleteInstance bool          // 退回，关闭实例全部未完成的待审批记录
+	CompleteFor []uint             // 关闭指定用户的待审批记录
+	Create      []*PendingApproval // 新增的待审批记录（委托、退回后的重新提交）
 }
@@ -0,0 +1,240 @@
+package workflow
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// 流程变量类型
+const (
+	VariableTypeString = "string"
+	VariableTypeUint   = "uint" // 非负整数，ID类变量使用
+	VariableTypeInt    = "int"
+	VariableTypeNumber = "number"
+	VariableTypeBool   = "bool"
+	VariableTypeTime   = "time" // RFC3339时间或2006-01-02格式的日期
+)
+
+// VariableDefinition 流程定义声明的变量
+type VariableDefinition struct {
+	Name        string      `json:"name"`
+	Type        string      `json:"type"` // string, uint, int, number, bool, time
+	Required    bool        `json:"required,omitempty"`
+	Default     interface{} `json:"default,omitempty"` // 未提供变量时使用的默认值，必须能转换为Type
+	Description string      `json:"description,omitempty"`
+	Sensitive   bool        `json:"sensitive,omitempty"` // 敏感变量，无workflow:view_sensitive权限且不是当前节点审批人的用户只能看到脱敏后的值
+}
+
+// VariableFieldError 单个流程变量的校验错误
+type VariableFieldError struct {
+	Name    string `json:"name"`
+	Message string `json:"message"`
+}
+
+// VariableValidationError 流程变量不符合流程定义声明的变量，可用errors.Is匹配ErrInvalidVariable
+type VariableValidationError struct {
+	WorkflowID string
+	Fields     []VariableFieldError
+}
+
+// Error 实现error接口，列出全部字段错误
+func (e *VariableValidationError) Error() string {
+	messages := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		messages = append(messages, fmt.Sprintf("%s: %s", field.Name, field.Message))
+	}
+	return fmt.Sprintf("%s: 流程[%s] %s", ErrInvalidVariable, e.WorkflowID, strings.Join(messages, "; "))
+}
+
+// Unwrap 使errors.Is可以匹配ErrInvalidVariable
+func (e *VariableValidationError) Unwrap() error {
+	return ErrInvalidVariable
+}
+
+// applyVariableSchema 按流程定义声明的变量校验启动变量，填充默认值并统一类型，返回新的变量表
+// 未声明变量的流程不做校验；已声明流程中未声明的变量原样保留
+func applyVariableSchema(definition *WorkflowDefinition, variables map[string]interface{}) (map[string]interface{}, error) {
+	if len(definition.VariableSchema) == 0 {
+		return variables, nil
+	}
+
+	result := make(map[string]interface{}, len(variables)+len(definition.VariableSchema))
+	for name, value := range variables {
+		result[name] = value
+	}
+
+	var fields []VariableFieldError
+	for _, declared := range definition.VariableSchema {
+		value, exists := result[declared.Name]
+		if !exists || isNilVariable(value) {
+			delete(result, declared.Name)
+			if declared.Default != nil {
+				value = declared.Default
+			} else if declared.Required {
+				fields = append(fields, VariableFieldError{Name: declared.Name, Message: "缺少必填变量"})
+				continue
+			} else {
+				continue
+			}
+		}
+
+		coerced, err := coerceVariable(declared.Type, value)
+		if err != nil {
+			fields = append(fields, VariableFieldError{Name: declared.Name, Message: err.Error()})
+			continue
+		}
+		result[declared.Name] = coerced
+	}
+
+	if len(fields) > 0 {
+		return nil, &VariableValidationError{WorkflowID: definition.ID, Fields: fields}
+	}
+	return result, nil
+}
+
+// validateVariableSchema 校验变量声明：名称非空且不重复，类型受支持，默认值能转换为声明的类型
+func validateVariableSchema(schema []VariableDefinition) error {
+	seen := make(map[string]bool, len(schema))
+	for i, declared := range schema {
+		if strings.TrimSpace(declared.Name) == "" {
+			return fmt.Errorf("变量[%d]名称不能为空", i)
+		}
+		if seen[declared.Name] {
+			return fmt.Errorf("变量[%s]重复声明", declared.Name)
+		}
+		seen[declared.Name] = true
+
+		switch declared.Type {
+		case VariableTypeString, VariableTypeUint, VariableTypeInt, VariableTypeNumber, VariableTypeBool, VariableTypeTime:
+		default:
+			return fmt.Errorf("变量[%s]类型[%s]不受支持", declared.Name, declared.Type)
+		}
+		if declared.Default != nil {
+			if _, err := coerceVariable(declared.Type, declared.Default); err != nil {
+				return fmt.Errorf("变量[%s]默认值无效: %w", declared.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// referencedVariables 返回节点引用的变量：条件表达式和自动审批条件的变量名，以及按变量指定的审批人
+func referencedVariables(node *WorkflowNode) ([]string, error) {
+	var names []string
+	expressionVariable := func(expression string) {
+		if parts := strings.Fields(expression); len(parts) > 0 {
+			names = append(names, parts[0])
+		}
+	}
+
+	switch node.Type {
+	case NodeTypeCondition:
+		config, err := (&ConditionNodeExecutor{}).parseConditionConfig(node)
+		if err != nil {
+			return nil, err
+		}
+		for _, condition := range config.Conditions {
+			expressionVariable(condition.Expression)
+		}
+	case NodeTypeApproval:
+		configBytes, err := json.Marshal(node.Config)
+		if err != nil {
+			return nil, fmt.Errorf("节点配置序列化失败: %w", err)
+		}
+		var config ApprovalNodeConfig
+		if err := json.Unmarshal(configBytes, &config); err != nil {
+			return nil, fmt.Errorf("审批节点配置解析失败: %w", err)
+		}
+		expressionVariable(config.AutoApproveCondition)
+		for _, assignee := range config.Assignees {
+			if assignee.Type == AssigneeTypeVariable {
+				names = append(names, assignee.Value)
+			}
+		}
+	}
+	return names, nil
+}
+
+// isNilVariable 变量值为nil或空指针时视为未提供
+func isNilVariable(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	return v.Kind() == reflect.Ptr && v.IsNil()
+}
+
+// coerceVariable 将变量值转换为声明的类型，字符串形式的数值、布尔值和时间按对应类型解析
+func coerceVariable(variableType string, value interface{}) (interface{}, error) {
+	if v := reflect.ValueOf(value); v.Kind() == reflect.Ptr && !v.IsNil() {
+		value = v.Elem().Interface()
+	}
+
+	switch variableType {
+	case VariableTypeString:
+		if s, ok := value.(string); ok {
+			return s, nil
+		}
+		switch v := reflect.ValueOf(value); v.Kind() {
+		case reflect.String:
+			return v.String(), nil
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+			return fmt.Sprint(value), nil
+		}
+	case VariableTypeUint:
+		if id, ok := uintValue(value); ok {
+			return id, nil
+		}
+	case VariableTypeInt:
+		if n, ok := numberValue(value); ok && n == math.Trunc(n) {
+			return int64(n), nil
+		}
+	case VariableTypeNumber:
+		if n, ok := numberValue(value); ok {
+			return n, nil
+		}
+	case VariableTypeBool:
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			if b, err := strconv.ParseBool(strings.TrimSpace(v)); err == nil {
+				return b, nil
+			}
+		}
+	case VariableTypeTime:
+		if t, ok := timeValue(value); ok {
+			return t, nil
+		}
+	default:
+		return nil, fmt.Errorf("不支持的变量类型%s", variableType)
+	}
+	return nil, fmt.Errorf("应为%s类型，实际为%v", variableType, value)
+}
+
+// numberValue 将各种数值表示和数字字符串转换为float64
+func numberValue(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case json.Number:
+		n, err := v.Float64()
+		return n, err == nil
+	case string:
+		n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return n, err == nil
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
@@ -0,0 +1,157 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// onboardingVariableSchema 入职审批流程的变量声明
+func onboardingVariableSchema() []VariableDefinition {
+	return []VariableDefinition{
+		{Name: "employee_id", Type: VariableTypeUint, Required: true},
+		{Name: "department_id", Type: VariableTypeUint},
+		{Name: "probation_days", Type: VariableTypeInt, Required: true, Default: float64(90)},
+		{Name: "expected_date", Type: VariableTypeTime},
+		{Name: "urgent", Type: VariableTypeBool, Default: false},
+	}
+}
+
+func TestApplyVariableSchema(t *testing.T) {
+	definition := &WorkflowDefinition{ID: "onboarding", VariableSchema: onboardingVariableSchema()}
+	var missingDepartment *uint
+
+	variables, err := applyVariableSchema(definition, map[string]interface{}{
+		"employee_id":   "12",
+		"department_id": missingDepartment,
+		"expected_date": "2026-11-02",
+		"urgent":        "true",
+		"reason":        "新项目",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"employee_id":    uint(12),
+		"probation_days": int64(90),
+		"expected_date":  time.Date(2026, 11, 2, 0, 0, 0, 0, time.Local),
+		"urgent":         true,
+		"reason":         "新项目", // 未声明的变量原样保留
+	}, variables)
+
+	// 每个不合法的变量都给出错误
+	_, err = applyVariableSchema(definition, map[string]interface{}{
+		"department_id":  "研发部",
+		"probation_days": json.Number("1.5"),
+	})
+	var validation *VariableValidationError
+	require.ErrorAs(t, err, &validation)
+	assert.ErrorIs(t, err, ErrInvalidVariable)
+	assert.Equal(t, "onboarding", validation.WorkflowID)
+	names := make([]string, 0, len(validation.Fields))
+	for _, field := range validation.Fields {
+		names = append(names, field.Name)
+	}
+	assert.Equal(t, []string{"employee_id", "department_id", "probation_days"}, names)
+	assert.Equal(t, "缺少必填变量", validation.Fields[0].Message)
+
+	// 未声明变量的流程不做校验
+	legacy := map[string]interface{}{"employee_id": "abc"}
+	variables, err = applyVariableSchema(&WorkflowDefinition{ID: "legacy"}, legacy)
+	require.NoError(t, err)
+	assert.Equal(t, legacy, variables)
+}
+
+func TestValidateVariableSchema(t *testing.T) {
+	assert.NoError(t, validateVariableSchema(onboardingVariableSchema()))
+	assert.Error(t, validateVariableSchema([]VariableDefinition{{Name: "", Type: VariableTypeString}}))
+	assert.Error(t, validateVariableSchema([]VariableDefinition{{Name: "a", Type: VariableTypeString}, {Name: "a", Type: VariableTypeUint}}))
+	assert.Error(t, validateVariableSchema([]VariableDefinition{{Name: "a", Type: "map"}}))
+	assert.Error(t, validateVariableSchema([]VariableDefinition{{Name: "a", Type: VariableTypeUint, Default: -1}}))
+}
+
+func TestValidateWorkflow_VariableReferences(t *testing.T) {
+	newRequest := func(schema []VariableDefinition) *CreateWorkflowRequest {
+		return &CreateWorkflowRequest{
+			ID:   "task-approval",
+			Name: "任务审批",
+			Nodes: []WorkflowNode{
+				{ID: "start", Type: NodeTypeStart, Name: "开始"},
+				{ID: "check", Type: NodeTypeCondition, Name: "金额判断", Config: map[string]interface{}{
+					"conditions":     []map[string]interface{}{{"expression": "amount > 1000", "target": "approve"}},
+					"default_target": "end",
+				}},
+				{ID: "approve", Type: NodeTypeApproval, Name: "审批", Config: map[string]interface{}{
+					"assignees": []map[string]interface{}{{"type": "variable", "value": "reviewer_id"}},
+				}},
+				{ID: "end", Type: NodeTypeEnd, Name: "结束"},
+			},
+			Edges: []WorkflowEdge{
+				{ID: "e1", From: "start", To: "check"},
+				{ID: "e2", From: "check", To: "approve"},
+				{ID: "e3", From: "check", To: "end"},
+				{ID: "e4", From: "approve", To: "end"},
+			},
+			VariableSchema: schema,
+		}
+	}
+	manager := NewWorkflowDefinitionManager(nil)
+	ctx := context.Background()
+
+	// 未声明变量的定义不检查引用
+	assert.NoError(t, manager.ValidateWorkflow(ctx, newRequest(nil)))
+
+	err := manager.ValidateWorkflow(ctx, newRequest([]VariableDefinition{{Name: "reviewer_id", Type: VariableTypeUint}}))
+	assert.ErrorContains(t, err, "节点[check]引用的变量[amount]未声明")
+
+	err = manager.ValidateWorkflow(ctx, newRequest([]VariableDefinition{{Name: "amount", Type: VariableTypeNumber}}))
+	assert.ErrorContains(t, err, "节点[approve]引用的变量[reviewer_id]未声明")
+
+	assert.NoError(t, manager.ValidateWorkflow(ctx, newRequest([]VariableDefinition{
+		{Name: "amount", Type: VariableTypeNumber},
+		{Name: "reviewer_id", Type: VariableTypeUint, Required: true},
+	})))
+}
+
+func TestStartWorkflow_VariableSchema(t *testing.T) {
+	definition := &WorkflowDefinition{
+		ID:        "onboarding",
+		VersionID: 1,
+		IsActive:  true,
+		Nodes: []WorkflowNode{
+			{ID: "start", Type: NodeTypeStart, Name: "开始"},
+			{ID: "approve", Type: NodeTypeApproval, Name: "HR审批", Config: map[string]interface{}{
+				"assignees": []map[string]interface{}{{"type": "starter"}},
+			}},
+		},
+		Edges:          []WorkflowEdge{{ID: "e1", From: "start", To: "approve"}},
+		VariableSchema: onboardingVariableSchema(),
+	}
+	repo := newExclusiveInstanceRepository()
+	engine := &WorkflowEngineImpl{
+		definitionManager:          NewWorkflowDefinitionManager(&startWorkflowRepository{definition: definition}),
+		instanceRepo:               repo,
+		onboardingExecutorRegistry: NewOnboardingExecutorRegistry(repo, nil, nil),
+	}
+	start := func(variables map[string]interface{}) (*WorkflowInstance, error) {
+		return engine.StartWorkflow(context.Background(), &StartWorkflowRequest{
+			WorkflowID:   "onboarding",
+			BusinessID:   "employee_12",
+			BusinessType: "onboarding",
+			Variables:    variables,
+			StartedBy:    9,
+		})
+	}
+
+	// 缺少必填变量时不创建实例
+	_, err := start(map[string]interface{}{"department_id": 3})
+	assert.ErrorIs(t, err, ErrInvalidVariable)
+	assert.Empty(t, repo.instances)
+
+	instance, err := start(map[string]interface{}{"employee_id": "12"})
+	require.NoError(t, err)
+	assert.Equal(t, uint(12), instance.Variables["employee_id"])
+	assert.Equal(t, int64(90), instance.Variables["probation_days"])
+}
@@ -0,0 +1,135 @@
+package workflow
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidVariable 流程变量缺失或格式不正确
+var ErrInvalidVariable = errors.New("流程变量缺失或格式不正确")
+
+// GetUintVar 读取无符号整数变量
+// 流程变量在内存中可能是uint、int，经过JSON存储后变为float64或json.Number，也可能是十进制数字字符串，均按同一数值读取
+func (wi *WorkflowInstance) GetUintVar(name string) (uint, bool) {
+	return uintValue(wi.Variables[name])
+}
+
+// GetStringVar 读取字符串变量，数值按十进制转换为字符串
+func (wi *WorkflowInstance) GetStringVar(name string) (string, bool) {
+	switch v := wi.Variables[name].(type) {
+	case string:
+		return v, true
+	case *string:
+		if v != nil {
+			return *v, true
+		}
+	case json.Number:
+		return v.String(), true
+	case float64, int, int64, uint, uint64:
+		return fmt.Sprint(v), true
+	}
+	return "", false
+}
+
+// GetTimeVar 读取时间变量，支持time.Time、RFC3339字符串和2006-01-02格式的日期
+func (wi *WorkflowInstance) GetTimeVar(name string) (time.Time, bool) {
+	return timeValue(wi.Variables[name])
+}
+
+// timeValue 将time.Time、RFC3339字符串和2006-01-02格式的日期转换为时间
+func timeValue(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, true
+	case *time.Time:
+		if v != nil {
+			return *v, true
+		}
+	case string:
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			return t, true
+		}
+		if t, err := time.ParseInLocation("2006-01-02", v, time.Local); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// uintValue 将各种数值表示转换为uint，负数、小数和无法解析的值返回false
+func uintValue(value interface{}) (uint, bool) {
+	switch v := value.(type) {
+	case uint:
+		return v, true
+	case *uint:
+		if v != nil {
+			return *v, true
+		}
+	case uint32:
+		return uint(v), true
+	case uint64:
+		return uint(v), true
+	case int:
+		if v >= 0 {
+			return uint(v), true
+		}
+	case int32:
+		if v >= 0 {
+			return uint(v), true
+		}
+	case int64:
+		if v >= 0 {
+			return uint(v), true
+		}
+	case float64:
+		if v >= 0 && v == math.Trunc(v) {
+			return uint(v), true
+		}
+	case json.Number:
+		if n, err := strconv.ParseUint(v.String(), 10, 64); err == nil {
+			return uint(n), true
+		}
+	case string:
+		if n, err := strconv.ParseUint(strings.TrimSpace(v), 10, 64); err == nil {
+			return uint(n), true
+		}
+	}
+	return 0, false
+}
+
+// normalizeIDVariables 将ID类变量统一为uint后再持久化
+// required中的变量必须存在且大于0，否则返回ErrInvalidVariable；optional中的变量为nil或0时移除，不是数值时同样返回ErrInvalidVariable
+func normalizeIDVariables(variables map[string]interface{}, required, optional []string) error {
+	for _, name := range required {
+		id, ok := uintValue(variables[name])
+		if !ok || id == 0 {
+			return fmt.Errorf("%w: 缺少%s", ErrInvalidVariable, name)
+		}
+		variables[name] = id
+	}
+	for _, name := range optional {
+		value, exists := variables[name]
+		if !exists {
+			continue
+		}
+		if pointer, isPointer := value.(*uint); value == nil || (isPointer && pointer == nil) {
+			delete(variables, name)
+			continue
+		}
+		id, ok := uintValue(value)
+		if !ok {
+			return fmt.Errorf("%w: %s不是有效的ID", ErrInvalidVariable, name)
+		}
+		if id == 0 {
+			delete(variables, name)
+			continue
+		}
+		variables[name] = id
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package workflow
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/database"
+)
+
+// roundTripVariables 模拟流程变量经过数据库JSON列保存后再读取
+func roundTripVariables(t *testing.T, variables map[string]interface{}) map[string]interface{} {
+	t.Helper()
+	value, err := database.JSONField{Data: variables}.Value()
+	require.NoError(t, err)
+	var field database.JSONField
+	require.NoError(t, field.Scan(value))
+	loaded, ok := field.Data.(map[string]interface{})
+	require.True(t, ok)
+	return loaded
+}
+
+func TestWorkflowInstance_TypedVariables(t *testing.T) {
+	departmentID := uint(12)
+	expected := time.Date(2026, 11, 2, 0, 0, 0, 0, time.Local)
+	variables := map[string]interface{}{
+		"employee_id":   uint(5),
+		"department_id": &departmentID,
+		"assignee_id":   9,
+		"task_id":       "31",
+		"expected_date": "2026-11-02",
+		"reason":        "新项目",
+	}
+
+	for name, instance := range map[string]*WorkflowInstance{
+		"内存":    {Variables: variables},
+		"JSON列": {Variables: roundTripVariables(t, variables)},
+	} {
+		employeeID, ok := instance.GetUintVar("employee_id")
+		assert.True(t, ok, name)
+		assert.Equal(t, uint(5), employeeID, name)
+		departmentID, _ := instance.GetUintVar("department_id")
+		assert.Equal(t, uint(12), departmentID, name)
+		assigneeID, _ := instance.GetUintVar("assignee_id")
+		assert.Equal(t, uint(9), assigneeID, name)
+		taskID, _ := instance.GetUintVar("task_id")
+		assert.Equal(t, uint(31), taskID, name)
+		date, ok := instance.GetTimeVar("expected_date")
+		assert.True(t, ok, name)
+		assert.True(t, expected.Equal(date), name)
+		reason, _ := instance.GetStringVar("reason")
+		assert.Equal(t, "新项目", reason, name)
+
+		_, ok = instance.GetUintVar("reason")
+		assert.False(t, ok, name)
+		_, ok = instance.GetUintVar("missing")
+		assert.False(t, ok, name)
+	}
+
+	// 使用UseNumber解码时为json.Number
+	var decoded map[string]interface{}
+	decoder := json.NewDecoder(strings.NewReader(`{"employee_id": 5, "ratio": 1.5, "negative": -3}`))
+	decoder.UseNumber()
+	require.NoError(t, decoder.Decode(&decoded))
+	instance := &WorkflowInstance{Variables: decoded}
+	employeeID, ok := instance.GetUintVar("employee_id")
+	assert.True(t, ok)
+	assert.Equal(t, uint(5), employeeID)
+	_, ok = instance.GetUintVar("ratio")
+	assert.False(t, ok)
+	_, ok = instance.GetUintVar("negative")
+	assert.False(t, ok)
+	id, _ := instance.GetStringVar("employee_id")
+	assert.Equal(t, "5", id)
+}
+
+func TestNormalizeIDVariables(t *testing.T) {
+	var noDepartment *uint
+	positionID := uint(3)
+	variables := map[string]interface{}{
+		"employee_id":   5,
+		"department_id": noDepartment,
+		"position_id":   &positionID,
+	}
+	require.NoError(t, normalizeIDVariables(variables, []string{"employee_id"}, []string{"department_id", "position_id"}))
+	assert.Equal(t, map[string]interface{}{"employee_id": uint(5), "position_id": uint(3)}, variables)
+
+	assert.ErrorIs(t, normalizeIDVariables(map[string]interface{}{"task_id": uint(0)}, []string{"task_id"}, nil), ErrInvalidVariable)
+	assert.ErrorIs(t, normalizeIDVariables(map[string]interface{}{}, []string{"assignee_id"}, nil), ErrInvalidVariable)
+	assert.ErrorIs(t, normalizeIDVariables(map[string]interface{}{"department_id": "研发部"}, nil, []string{"department_id"}), ErrInvalidVariable)
+
+	// 缺少必填变量时不启动流程
+	service := &WorkflowService{}
+	_, err := service.StartTaskAssignmentApproval(context.Background(), &TaskAssignmentApprovalRequest{TaskID: 1})
+	assert.ErrorIs(t, err, ErrInvalidVariable)
+	_, err = service.StartOnboardingApproval(context.Background(), &OnboardingApprovalRequest{})
+	assert.ErrorIs(t, err, ErrInvalidVariable)
+}
+
+func TestResolveAssignees_VariableAfterRoundTrip(t *testing.T) {
+	// 经过JSON列后变量为float64，仍能解析为审批人
+	instance := &WorkflowInstance{
+		StartedBy: 1,
+		Variables: roundTripVariables(t, map[string]interface{}{"reviewer_id": uint(42)}),
+	}
+	assignees, err := (&ApprovalNodeExecutor{}).resolveAssignees(context.Background(), instance, []ApprovalAssignee{
+		{Type: AssigneeTypeVariable, Value: "reviewer_id"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []uint{42}, assignees)
+}
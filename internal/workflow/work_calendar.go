@@ -0,0 +1,39 @@
+package workflow
+
+import (
+	"context"
+	"time"
+
+	"taskmanage/pkg/workcalendar"
+)
+
+// WorkCalendarProvider 工作日历来源，由服务层按工作时间配置和节假日提供
+type WorkCalendarProvider interface {
+	// Calendar 返回当前的工作日历，为nil时按自然时间计算
+	Calendar(ctx context.Context) *workcalendar.Calendar
+}
+
+// SetWorkCalendar 设置工作日历，审批节点时限和流程最大持续时间只计算工作时间
+func (e *WorkflowEngineImpl) SetWorkCalendar(provider WorkCalendarProvider) {
+	e.workCalendar = provider
+	e.taskExecutorRegistry.SetWorkCalendar(provider)
+	e.onboardingExecutorRegistry.SetWorkCalendar(provider)
+}
+
+// SetWorkCalendar 为注册表中的审批节点执行器设置工作日历
+func (r *ExecutorRegistry) SetWorkCalendar(provider WorkCalendarProvider) {
+	switch executor := r.executors[NodeTypeApproval].(type) {
+	case *ApprovalNodeExecutor:
+		executor.workCalendar = provider
+	case *OnboardingApprovalNodeExecutor:
+		executor.workCalendar = provider
+	}
+}
+
+// workingDeadline 返回从start开始经过d的工作时间后的时刻，未设置工作日历时按自然时间计算
+func workingDeadline(ctx context.Context, provider WorkCalendarProvider, start time.Time, d time.Duration) time.Time {
+	if provider == nil {
+		return start.Add(d)
+	}
+	return provider.Calendar(ctx).AddWorkingDuration(start, d)
+}
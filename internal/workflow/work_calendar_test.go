@@ -0,0 +1,71 @@
+package workflow
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/pkg/workcalendar"
+)
+
+// staticCalendarProvider 返回固定工作日历的工作日历来源
+type staticCalendarProvider struct {
+	calendar *workcalendar.Calendar
+}
+
+func (p staticCalendarProvider) Calendar(ctx context.Context) *workcalendar.Calendar {
+	return p.calendar
+}
+
+func TestStartWorkflow_DeadlinesSkipHoliday(t *testing.T) {
+	service, repo := newOnboardingWorkflowService(t)
+	ctx := context.Background()
+	_, err := service.definitionManager.CreateWorkflow(ctx, &CreateWorkflowRequest{
+		ID:          "timed-onboarding",
+		Name:        "限时入职审批",
+		Version:     "1.0",
+		MaxDuration: 2 * 3600,
+		Nodes: []WorkflowNode{
+			{ID: "start", Type: NodeTypeStart, Name: "开始"},
+			{ID: "review", Type: NodeTypeApproval, Name: "HR审批", Config: map[string]interface{}{
+				"assignee_type": "multiple",
+				"assignees":     []ApprovalAssignee{{Type: AssigneeTypeRole, Value: "hr"}},
+				"timeout":       float64(3600),
+			}},
+			{ID: "end", Type: NodeTypeEnd, Name: "结束"},
+		},
+		Edges: []WorkflowEdge{
+			{ID: "start_to_review", From: "start", To: "review"},
+			{ID: "review_to_end", From: "review", To: "end", Condition: "approved"},
+		},
+	})
+	require.NoError(t, err)
+
+	// 每天全天都是工作时间，只有今天是节假日：审批时限和流程SLA都从明天零点开始计算
+	allDays := []time.Weekday{time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday}
+	calendar := workcalendar.New(workcalendar.WorkingHours{Days: allDays, End: 24 * time.Hour}, []time.Time{time.Now()})
+	engine := service.engine.(*WorkflowEngineImpl)
+	engine.taskExecutorRegistry = NewExecutorRegistry(repo, nil, nil)
+	engine.SetWorkCalendar(staticCalendarProvider{calendar: calendar})
+
+	instance, err := engine.StartWorkflow(ctx, &StartWorkflowRequest{
+		WorkflowID:   "timed-onboarding",
+		BusinessID:   "8",
+		BusinessType: "onboarding",
+		StartedBy:    1,
+	})
+	require.NoError(t, err)
+
+	year, month, day := instance.StartedAt.Date()
+	tomorrow := time.Date(year, month, day+1, 0, 0, 0, 0, instance.StartedAt.Location())
+	require.NotNil(t, instance.Deadline)
+	assert.Equal(t, tomorrow.Add(2*time.Hour), *instance.Deadline)
+
+	approvals := repo.approvals[instance.ID+"/review"]
+	require.Len(t, approvals, 1)
+	require.NotNil(t, approvals[0].Deadline)
+	assert.Equal(t, tomorrow.Add(time.Hour), *approvals[0].Deadline)
+}
@@ -0,0 +1,10 @@
+-- 角色层级：按角色查找审批人时，同时匹配该角色的全部下级角色
+ALTER TABLE roles
+    ADD COLUMN parent_id BIGINT UNSIGNED NULL COMMENT '上级角色ID' AFTER description,
+    ADD INDEX idx_roles_parent_id (parent_id);
+
+-- 示例：将 senior_manager 配置为 manager 的下级角色，配置为 manager 的审批节点也会路由给 senior_manager
+-- UPDATE roles AS child
+--     JOIN roles AS parent ON parent.name = 'manager'
+-- SET child.parent_id = parent.id
+-- WHERE child.name = 'senior_manager';
@@ -0,0 +1,103 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+
+	"taskmanage/pkg/logger"
+)
+
+// Message 邮件内容
+type Message struct {
+	To       string
+	Subject  string
+	Body     string // 纯文本正文
+	HTMLBody string // HTML正文，非空时与纯文本正文一起以multipart/alternative发送
+}
+
+// EmailSender 邮件发送接口
+type EmailSender interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// SMTPConfig SMTP服务器配置
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string // 为空时不进行认证
+	Password string
+	From     string
+}
+
+// SMTPSender 基于SMTP的邮件发送实现
+type SMTPSender struct {
+	config SMTPConfig
+}
+
+// NewSMTPSender 创建SMTP邮件发送器
+func NewSMTPSender(config SMTPConfig) *SMTPSender {
+	return &SMTPSender{config: config}
+}
+
+// Send 发送邮件
+func (s *SMTPSender) Send(ctx context.Context, msg *Message) error {
+	addr := net.JoinHostPort(s.config.Host, strconv.Itoa(s.config.Port))
+
+	var auth smtp.Auth
+	if s.config.Username != "" {
+		auth = smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)
+	}
+
+	if err := smtp.SendMail(addr, auth, s.config.From, []string{msg.To}, buildMessage(s.config.From, msg)); err != nil {
+		return fmt.Errorf("发送邮件失败: %w", err)
+	}
+	return nil
+}
+
+// buildMessage 组装邮件头和正文
+func buildMessage(from string, msg *Message) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + msg.To + "\r\n")
+	b.WriteString("Subject: " + mime.QEncoding.Encode("UTF-8", msg.Subject) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	if msg.HTMLBody == "" {
+		b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+		b.WriteString("\r\n")
+		b.WriteString(msg.Body)
+		return []byte(b.String())
+	}
+
+	b.WriteString("Content-Type: multipart/alternative; boundary=" + mimeBoundary + "\r\n")
+	b.WriteString("\r\n")
+	b.WriteString("--" + mimeBoundary + "\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	b.WriteString(msg.Body + "\r\n")
+	b.WriteString("--" + mimeBoundary + "\r\n")
+	b.WriteString("Content-Type: text/html; charset=UTF-8\r\n\r\n")
+	b.WriteString(msg.HTMLBody + "\r\n")
+	b.WriteString("--" + mimeBoundary + "--\r\n")
+	return []byte(b.String())
+}
+
+// mimeBoundary multipart邮件的分隔符
+const mimeBoundary = "taskmanage-alternative-boundary"
+
+// LogSender 仅记录日志的邮件发送实现，用于开发环境
+type LogSender struct{}
+
+// NewLogSender 创建日志邮件发送器
+func NewLogSender() *LogSender {
+	return &LogSender{}
+}
+
+// Send 将邮件内容写入日志
+func (s *LogSender) Send(ctx context.Context, msg *Message) error {
+	logger.Infof("邮件(未实际发送) to=%s subject=%s\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
@@ -0,0 +1,211 @@
+// Package i18n 提供按消息键查找的多语言消息目录，目录文件随程序嵌入
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 支持的语言
+const (
+	LocaleZhCN = "zh-CN"
+	LocaleEn   = "en"
+)
+
+// SourceLocale 代码中直接书写的消息文本所用的语言，其它语言的请求不直接返回这些文本
+const SourceLocale = LocaleZhCN
+
+// ContextKey 请求上下文中保存语言的键，由语言中间件写入
+const ContextKey = "locale"
+
+// Params 消息参数，消息文本中的{name}占位符替换为对应参数
+type Params map[string]interface{}
+
+//go:embed locales/*.json
+var localeFS embed.FS
+
+var (
+	mu            sync.RWMutex
+	catalog       = mustLoadCatalog()
+	defaultLocale = LocaleZhCN
+	required      = make(map[string]struct{})
+)
+
+// mustLoadCatalog 读取嵌入的消息目录，文件名即语言标识；目录随程序编译，格式错误属于编码错误
+func mustLoadCatalog() map[string]map[string]string {
+	entries, err := localeFS.ReadDir("locales")
+	if err != nil {
+		panic(fmt.Sprintf("读取消息目录失败: %v", err))
+	}
+	result := make(map[string]map[string]string, len(entries))
+	for _, entry := range entries {
+		data, err := localeFS.ReadFile(path.Join("locales", entry.Name()))
+		if err != nil {
+			panic(fmt.Sprintf("读取消息目录%s失败: %v", entry.Name(), err))
+		}
+		messages := make(map[string]string)
+		if err := json.Unmarshal(data, &messages); err != nil {
+			panic(fmt.Sprintf("解析消息目录%s失败: %v", entry.Name(), err))
+		}
+		result[strings.TrimSuffix(entry.Name(), path.Ext(entry.Name()))] = messages
+	}
+	return result
+}
+
+// Locales 返回支持的语言列表
+func Locales() []string {
+	locales := make([]string, 0, len(catalog))
+	for locale := range catalog {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// SetDefaultLocale 设置默认语言，请求未指定或指定了不支持的语言时使用
+func SetDefaultLocale(locale string) error {
+	if _, ok := catalog[locale]; !ok {
+		return fmt.Errorf("不支持的默认语言: %s，可选值为%s", locale, strings.Join(Locales(), "、"))
+	}
+	mu.Lock()
+	defaultLocale = locale
+	mu.Unlock()
+	return nil
+}
+
+// DefaultLocale 返回默认语言
+func DefaultLocale() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return defaultLocale
+}
+
+// Require 登记代码中引用的消息键，由Check在启动时校验默认语言是否都有对应消息
+func Require(keys ...string) {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, key := range keys {
+		required[key] = struct{}{}
+	}
+}
+
+// Check 校验所有登记的消息键在默认语言中都有消息，缺失时返回列出全部缺失键的错误
+func Check() error {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	messages := catalog[defaultLocale]
+	var missing []string
+	for key := range required {
+		if _, ok := messages[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("默认语言%s缺少消息: %s", defaultLocale, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// Init 设置默认语言并校验消息目录，启动时调用
+func Init(locale string) error {
+	if locale != "" {
+		if err := SetDefaultLocale(locale); err != nil {
+			return err
+		}
+	}
+	return Check()
+}
+
+// Translate 返回消息键在指定语言下的文本，指定语言缺少该消息时使用默认语言；两者都没有时ok为false
+func Translate(locale, key string, params Params) (message string, ok bool) {
+	template, ok := catalog[locale][key]
+	if !ok {
+		template, ok = catalog[DefaultLocale()][key]
+	}
+	if !ok {
+		return "", false
+	}
+	return format(template, params), true
+}
+
+// T 返回消息键在指定语言下的文本，目录中没有该消息时返回消息键本身
+func T(locale, key string, params Params) string {
+	if message, ok := Translate(locale, key, params); ok {
+		return message
+	}
+	return key
+}
+
+// format 将{name}占位符替换为参数值，没有对应参数的占位符保持原样
+func format(template string, params Params) string {
+	if len(params) == 0 || !strings.Contains(template, "{") {
+		return template
+	}
+	pairs := make([]string, 0, len(params)*2)
+	for name, value := range params {
+		pairs = append(pairs, "{"+name+"}", fmt.Sprint(value))
+	}
+	return strings.NewReplacer(pairs...).Replace(template)
+}
+
+// ResolveLocale 按Accept-Language的权重选择支持的语言，先精确匹配再按主语言匹配（如en-US匹配en、zh匹配zh-CN），都不匹配时返回默认语言
+func ResolveLocale(acceptLanguage string) string {
+	type candidate struct {
+		tag     string
+		quality float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := strings.TrimSpace(fields[0])
+		if tag == "" || tag == "*" {
+			continue
+		}
+		quality := 1.0
+		for _, field := range fields[1:] {
+			field = strings.TrimSpace(field)
+			if strings.HasPrefix(field, "q=") {
+				if q, err := strconv.ParseFloat(strings.TrimPrefix(field, "q="), 64); err == nil {
+					quality = q
+				}
+			}
+		}
+		if quality > 0 {
+			candidates = append(candidates, candidate{tag: tag, quality: quality})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].quality > candidates[j].quality
+	})
+
+	for _, c := range candidates {
+		if locale := matchLocale(c.tag); locale != "" {
+			return locale
+		}
+	}
+	return DefaultLocale()
+}
+
+// matchLocale 将语言标签匹配到支持的语言，不区分大小写，下划线视同连字符
+func matchLocale(tag string) string {
+	tag = strings.ReplaceAll(tag, "_", "-")
+	primary := strings.SplitN(tag, "-", 2)[0]
+	var byPrimary string
+	for _, locale := range Locales() {
+		if strings.EqualFold(locale, tag) {
+			return locale
+		}
+		if byPrimary == "" && strings.EqualFold(strings.SplitN(locale, "-", 2)[0], primary) {
+			byPrimary = locale
+		}
+	}
+	return byPrimary
+}
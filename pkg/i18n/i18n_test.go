@@ -0,0 +1,75 @@
+package i18n
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveLocale(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", LocaleZhCN},
+		{"en", LocaleEn},
+		{"en-US,en;q=0.9", LocaleEn},
+		{"zh", LocaleZhCN},
+		{"zh_TW", LocaleZhCN},
+		{"fr-FR, en;q=0.5, zh-CN;q=0.8", LocaleZhCN},
+		{"fr-FR, de;q=0.5", LocaleZhCN},
+		{"en;q=0, zh-CN;q=0.1", LocaleZhCN},
+		{"*", LocaleZhCN},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, ResolveLocale(tt.header), tt.header)
+	}
+
+	require.NoError(t, SetDefaultLocale(LocaleEn))
+	defer SetDefaultLocale(LocaleZhCN)
+	assert.Equal(t, LocaleEn, ResolveLocale("fr-FR"))
+	assert.Error(t, SetDefaultLocale("fr"))
+}
+
+func TestTranslate(t *testing.T) {
+	params := Params{"task_id": 42}
+	assert.Equal(t, "任务不存在: 42", T(LocaleZhCN, "task.not_found", params))
+	assert.Equal(t, "Task not found: 42", T(LocaleEn, "task.not_found", params))
+
+	// 不支持的语言使用默认语言，目录中没有的键原样返回
+	assert.Equal(t, "任务不存在: 42", T("fr", "task.not_found", params))
+	assert.Equal(t, "no.such.key", T(LocaleEn, "no.such.key", nil))
+
+	// 缺少参数的占位符保持原样
+	assert.Equal(t, "任务不存在: {task_id}", T(LocaleZhCN, "task.not_found", nil))
+}
+
+func TestCatalogsHaveSameKeys(t *testing.T) {
+	keysOf := func(locale string) []string {
+		keys := make([]string, 0, len(catalog[locale]))
+		for key := range catalog[locale] {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		return keys
+	}
+	assert.Equal(t, []string{LocaleEn, LocaleZhCN}, Locales())
+	assert.Equal(t, keysOf(LocaleZhCN), keysOf(LocaleEn))
+}
+
+func TestCheck(t *testing.T) {
+	Require("task.not_found")
+	assert.NoError(t, Check())
+
+	Require("test.missing_message")
+	defer func() {
+		mu.Lock()
+		delete(required, "test.missing_message")
+		mu.Unlock()
+	}()
+	err := Check()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "test.missing_message")
+}
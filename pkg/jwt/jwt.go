@@ -1,6 +1,8 @@
 package jwt
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"time"
@@ -17,13 +19,31 @@ var (
 
 // Claims JWT声明结构
 type Claims struct {
-	UserID   uint   `json:"user_id"`
-	Username string `json:"username"`
-	Email    string `json:"email"`
-	Role     string `json:"role"`
+	UserID    uint   `json:"user_id"`
+	Username  string `json:"username"`
+	Email     string `json:"email"`
+	Role      string `json:"role"`
+	SessionID string `json:"sid,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// RefreshClaims 刷新令牌声明结构
+type RefreshClaims struct {
+	SessionID string `json:"sid,omitempty"`
+	UserID    uint   `json:"-"` // 由Subject解析得到
+	jwt.RegisteredClaims
+}
+
+// TokenPair 一次登录或刷新签发的令牌对
+type TokenPair struct {
+	AccessToken     string
+	AccessTokenID   string    // 访问令牌的JTI，吊销会话时加入黑名单
+	AccessExpiresAt time.Time // 访问令牌过期时间
+	RefreshToken    string
+	RefreshTokenID  string // 刷新令牌的JTI，用于轮换追踪
+	SessionID       string
+}
+
 // JWTManager JWT管理器
 type JWTManager struct {
 	secretKey     []byte
@@ -42,15 +62,64 @@ func NewJWTManager(secretKey string, tokenExpiry, refreshExpiry time.Duration, i
 	}
 }
 
+// NewSessionID 生成新的会话ID，同一次登录内轮换出的令牌共享该ID
+func NewSessionID() (string, error) {
+	return newTokenID()
+}
+
+// newTokenID 生成随机令牌ID
+func newTokenID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("生成令牌ID失败: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// GenerateTokenPair 为指定会话生成访问令牌和刷新令牌
+func (j *JWTManager) GenerateTokenPair(userID uint, username, email, role, sessionID string) (*TokenPair, error) {
+	accessToken, claims, err := j.generateToken(userID, username, email, role, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, refreshTokenID, err := j.generateRefreshToken(userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:     accessToken,
+		AccessTokenID:   claims.ID,
+		AccessExpiresAt: claims.ExpiresAt.Time,
+		RefreshToken:    refreshToken,
+		RefreshTokenID:  refreshTokenID,
+		SessionID:       sessionID,
+	}, nil
+}
+
 // GenerateToken 生成访问令牌
-func (j *JWTManager) GenerateToken(userID uint, username, email, role string) (string, error) {
+func (j *JWTManager) GenerateToken(userID uint, username, email, role, sessionID string) (string, error) {
+	token, _, err := j.generateToken(userID, username, email, role, sessionID)
+	return token, err
+}
+
+// generateToken 生成访问令牌并返回其声明
+func (j *JWTManager) generateToken(userID uint, username, email, role, sessionID string) (string, *Claims, error) {
+	tokenID, err := newTokenID()
+	if err != nil {
+		return "", nil, err
+	}
+
 	now := time.Now()
 	claims := &Claims{
-		UserID:   userID,
-		Username: username,
-		Email:    email,
-		Role:     role,
+		UserID:    userID,
+		Username:  username,
+		Email:     email,
+		Role:      role,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
 			Issuer:    j.issuer,
 			Subject:   fmt.Sprintf("%d", userID),
 			Audience:  []string{"taskmanage"},
@@ -60,24 +129,46 @@ func (j *JWTManager) GenerateToken(userID uint, username, email, role string) (s
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(j.secretKey)
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(j.secretKey)
+	if err != nil {
+		return "", nil, err
+	}
+	return token, claims, nil
 }
 
 // GenerateRefreshToken 生成刷新令牌
-func (j *JWTManager) GenerateRefreshToken(userID uint) (string, error) {
+func (j *JWTManager) GenerateRefreshToken(userID uint, sessionID string) (string, error) {
+	token, _, err := j.generateRefreshToken(userID, sessionID)
+	return token, err
+}
+
+// generateRefreshToken 生成刷新令牌并返回其JTI
+func (j *JWTManager) generateRefreshToken(userID uint, sessionID string) (string, string, error) {
+	tokenID, err := newTokenID()
+	if err != nil {
+		return "", "", err
+	}
+
 	now := time.Now()
-	claims := &jwt.RegisteredClaims{
-		Issuer:    j.issuer,
-		Subject:   fmt.Sprintf("%d", userID),
-		Audience:  []string{"taskmanage-refresh"},
-		ExpiresAt: jwt.NewNumericDate(now.Add(j.refreshExpiry)),
-		NotBefore: jwt.NewNumericDate(now),
-		IssuedAt:  jwt.NewNumericDate(now),
+	claims := &RefreshClaims{
+		SessionID: sessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
+			Issuer:    j.issuer,
+			Subject:   fmt.Sprintf("%d", userID),
+			Audience:  []string{"taskmanage-refresh"},
+			ExpiresAt: jwt.NewNumericDate(now.Add(j.refreshExpiry)),
+			NotBefore: jwt.NewNumericDate(now),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(j.secretKey)
+	signed, err := token.SignedString(j.secretKey)
+	if err != nil {
+		return "", "", err
+	}
+	return signed, tokenID, nil
 }
 
 // ValidateToken 验证令牌
@@ -110,8 +201,8 @@ func (j *JWTManager) ValidateToken(tokenString string) (*Claims, error) {
 }
 
 // ValidateRefreshToken 验证刷新令牌
-func (j *JWTManager) ValidateRefreshToken(tokenString string) (uint, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &jwt.RegisteredClaims{}, func(token *jwt.Token) (interface{}, error) {
+func (j *JWTManager) ValidateRefreshToken(tokenString string) (*RefreshClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &RefreshClaims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("意外的签名方法: %v", token.Header["alg"])
 		}
@@ -120,18 +211,18 @@ func (j *JWTManager) ValidateRefreshToken(tokenString string) (uint, error) {
 
 	if err != nil {
 		if errors.Is(err, jwt.ErrTokenExpired) {
-			return 0, ErrTokenExpired
+			return nil, ErrTokenExpired
 		}
 		if errors.Is(err, jwt.ErrTokenMalformed) {
-			return 0, ErrTokenMalformed
+			return nil, ErrTokenMalformed
 		}
 		if errors.Is(err, jwt.ErrTokenNotValidYet) {
-			return 0, ErrTokenNotValidYet
+			return nil, ErrTokenNotValidYet
 		}
-		return 0, ErrTokenInvalid
+		return nil, ErrTokenInvalid
 	}
 
-	if claims, ok := token.Claims.(*jwt.RegisteredClaims); ok && token.Valid {
+	if claims, ok := token.Claims.(*RefreshClaims); ok && token.Valid {
 		// 检查audience
 		expectedAudience := "taskmanage-refresh"
 		validAudience := false
@@ -142,40 +233,34 @@ func (j *JWTManager) ValidateRefreshToken(tokenString string) (uint, error) {
 			}
 		}
 		if !validAudience {
-			return 0, ErrTokenInvalid
+			return nil, ErrTokenInvalid
 		}
 		
 		// 解析用户ID
-		var userID uint
-		if _, err := fmt.Sscanf(claims.Subject, "%d", &userID); err != nil {
-			return 0, ErrTokenInvalid
+		if _, err := fmt.Sscanf(claims.Subject, "%d", &claims.UserID); err != nil {
+			return nil, ErrTokenInvalid
 		}
 		
-		return userID, nil
+		return claims, nil
 	}
 
-	return 0, ErrTokenInvalid
+	return nil, ErrTokenInvalid
 }
 
-// RefreshToken 刷新令牌
+// RefreshToken 刷新令牌，新令牌沿用原刷新令牌的会话ID
 func (j *JWTManager) RefreshToken(refreshTokenString string, username, email, role string) (string, string, error) {
-	userID, err := j.ValidateRefreshToken(refreshTokenString)
+	claims, err := j.ValidateRefreshToken(refreshTokenString)
 	if err != nil {
 		return "", "", err
 	}
 
 	// 生成新的访问令牌和刷新令牌
-	accessToken, err := j.GenerateToken(userID, username, email, role)
-	if err != nil {
-		return "", "", err
-	}
-
-	refreshToken, err := j.GenerateRefreshToken(userID)
+	pair, err := j.GenerateTokenPair(claims.UserID, username, email, role, claims.SessionID)
 	if err != nil {
 		return "", "", err
 	}
 
-	return accessToken, refreshToken, nil
+	return pair.AccessToken, pair.RefreshToken, nil
 }
 
 // ExtractUserID 从令牌中提取用户ID
@@ -0,0 +1,30 @@
+package logger
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+// entryKey 上下文中日志条目的键
+type entryKey struct{}
+
+// NewContext 返回携带日志条目的上下文，后续经该上下文调用的服务和执行器使用此条目记录日志
+func NewContext(ctx context.Context, entry *logrus.Entry) context.Context {
+	return context.WithValue(ctx, entryKey{}, entry)
+}
+
+// FromContext 获取上下文中的日志条目，请求之外（如定时任务、启动流程）返回全局日志器的条目
+func FromContext(ctx context.Context) *logrus.Entry {
+	if ctx != nil {
+		if entry, ok := ctx.Value(entryKey{}).(*logrus.Entry); ok && entry != nil {
+			return entry
+		}
+	}
+	return logrus.NewEntry(GetLogger())
+}
+
+// ContextWithFields 在上下文的日志条目上追加字段，如流程执行时的instance_id、node_id
+func ContextWithFields(ctx context.Context, fields logrus.Fields) context.Context {
+	return NewContext(ctx, FromContext(ctx).WithFields(fields))
+}
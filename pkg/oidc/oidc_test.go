@@ -0,0 +1,164 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testIssuer 模拟的OIDC提供方
+type testIssuer struct {
+	server  *httptest.Server
+	key     *rsa.PrivateKey
+	idToken string // 令牌端点返回的ID令牌
+}
+
+func newTestIssuer(t *testing.T) *testIssuer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	issuer := &testIssuer{key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 issuer.server.URL,
+			"authorization_endpoint": issuer.server.URL + "/authorize",
+			"token_endpoint":         issuer.server.URL + "/token",
+			"jwks_uri":               issuer.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kid": "k1",
+			"kty": "RSA",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.PublicKey.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.PublicKey.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		id, secret, _ := r.BasicAuth()
+		if id != "taskmanage" || secret != "s3cret" || r.FormValue("code") != "good-code" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"id_token": issuer.idToken})
+	})
+	issuer.server = httptest.NewServer(mux)
+	t.Cleanup(issuer.server.Close)
+	return issuer
+}
+
+func (i *testIssuer) provider() *Provider {
+	return NewProvider(Config{Issuer: i.server.URL, ClientID: "taskmanage", ClientSecret: "s3cret", RedirectURL: "https://app.example.com/callback"})
+}
+
+func (i *testIssuer) claims(nonce string) *Claims {
+	now := time.Now()
+	return &Claims{
+		Email: "alice@example.com",
+		Nonce: nonce,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    i.server.URL,
+			Subject:   "alice",
+			Audience:  jwt.ClaimStrings{"taskmanage"},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(5 * time.Minute)),
+		},
+	}
+}
+
+func (i *testIssuer) sign(t *testing.T, claims *Claims, key *rsa.PrivateKey) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "k1"
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func TestProvider_AuthCodeURLAndExchange(t *testing.T) {
+	ctx := context.Background()
+	issuer := newTestIssuer(t)
+	p := issuer.provider()
+
+	authURL, err := p.AuthCodeURL(ctx, "state-1", "nonce-1")
+	require.NoError(t, err)
+	parsed, err := url.Parse(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, "/authorize", parsed.Path)
+	assert.Equal(t, "state-1", parsed.Query().Get("state"))
+	assert.Equal(t, "nonce-1", parsed.Query().Get("nonce"))
+	assert.Equal(t, "openid email profile", parsed.Query().Get("scope"))
+
+	issuer.idToken = issuer.sign(t, issuer.claims("nonce-1"), issuer.key)
+	raw, err := p.Exchange(ctx, "good-code")
+	require.NoError(t, err)
+	claims, err := p.Verify(ctx, raw, "nonce-1")
+	require.NoError(t, err)
+	assert.Equal(t, "alice@example.com", claims.Email)
+
+	_, err = p.Exchange(ctx, "bad-code")
+	assert.ErrorIs(t, err, ErrCodeExchange)
+}
+
+func TestProvider_VerifyRejectsInvalidTokens(t *testing.T) {
+	ctx := context.Background()
+	issuer := newTestIssuer(t)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		mutate func(c *Claims)
+		key    *rsa.PrivateKey
+		nonce  string
+		want   error
+	}{
+		{name: "签名错误", key: otherKey, want: ErrInvalidIDToken},
+		{name: "受众错误", mutate: func(c *Claims) { c.Audience = jwt.ClaimStrings{"other-client"} }, want: ErrInvalidIDToken},
+		{name: "签发方错误", mutate: func(c *Claims) { c.Issuer = "https://evil.example.com" }, want: ErrInvalidIDToken},
+		{name: "已过期", mutate: func(c *Claims) { c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Hour)) }, want: ErrInvalidIDToken},
+		{name: "缺少过期时间", mutate: func(c *Claims) { c.ExpiresAt = nil }, want: ErrInvalidIDToken},
+		{name: "nonce不匹配", nonce: "other-nonce", want: ErrNonceMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := issuer.claims("nonce-1")
+			if tt.mutate != nil {
+				tt.mutate(claims)
+			}
+			key := issuer.key
+			if tt.key != nil {
+				key = tt.key
+			}
+			nonce := "nonce-1"
+			if tt.nonce != "" {
+				nonce = tt.nonce
+			}
+
+			_, err := issuer.provider().Verify(ctx, issuer.sign(t, claims, key), nonce)
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+}
+
+func TestProvider_DiscoveryIssuerMismatch(t *testing.T) {
+	issuer := newTestIssuer(t)
+	p := NewProvider(Config{Issuer: issuer.server.URL + "/", ClientID: "taskmanage"})
+
+	_, err := p.AuthCodeURL(context.Background(), "state", "nonce")
+	assert.ErrorIs(t, err, ErrDiscovery)
+}
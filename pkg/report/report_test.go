@@ -0,0 +1,77 @@
+package report
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCSVWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(FormatCSV, &buf, "")
+	require.NoError(t, err)
+
+	due := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+	require.NoError(t, w.WriteRow("标题", "工时", "截止时间"))
+	require.NoError(t, w.WriteRow("=HYPERLINK(\"x\")", 1234.5, &due))
+	require.NoError(t, w.WriteRow("a,b", -2, (*time.Time)(nil)))
+	require.NoError(t, w.Close())
+
+	// 数字不带千分位，公式前缀被转义，逗号按CSV规则加引号
+	assert.Equal(t, "\ufeff标题,工时,截止时间\n\"'=HYPERLINK(\"\"x\"\")\",1234.5,2026-03-01 09:30:00\n\"a,b\",-2,\n", buf.String())
+}
+
+func TestXLSXWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(FormatXLSX, &buf, "任务")
+	require.NoError(t, err)
+	require.NoError(t, w.WriteRow("标题", "工时"))
+	require.NoError(t, w.WriteRow("<A&B>", 0.25))
+	require.NoError(t, w.Close())
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+
+	files := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		rc.Close()
+		files[f.Name] = string(content)
+	}
+
+	require.Contains(t, files, "[Content_Types].xml")
+	assert.Contains(t, files["xl/workbook.xml"], `name="任务"`)
+	sheet := files["xl/worksheets/sheet1.xml"]
+	assert.True(t, strings.HasSuffix(sheet, "</sheetData></worksheet>"))
+	assert.Contains(t, sheet, `<c r="A2" t="inlineStr"><is><t xml:space="preserve">&lt;A&amp;B&gt;</t></is></c>`)
+	assert.Contains(t, sheet, `<c r="B2"><v>0.25</v></c>`)
+}
+
+func TestColumnName(t *testing.T) {
+	assert.Equal(t, "A", columnName(0))
+	assert.Equal(t, "Z", columnName(25))
+	assert.Equal(t, "AA", columnName(26))
+	assert.Equal(t, "BA", columnName(52))
+}
+
+func TestParseFormat(t *testing.T) {
+	format, err := ParseFormat("")
+	require.NoError(t, err)
+	assert.Equal(t, FormatCSV, format)
+
+	format, err = ParseFormat("XLSX")
+	require.NoError(t, err)
+	assert.Equal(t, FormatXLSX, format)
+
+	_, err = ParseFormat("pdf")
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
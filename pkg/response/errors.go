@@ -1,14 +1,18 @@
 package response
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+
+	"taskmanage/pkg/i18n"
 )
 
 // ErrorCode 错误码类型
 type ErrorCode string
 
-// 系统级错误码
+// 错误码集中定义于此，客户端依赖错误码而不是消息文本判断错误类型
+// 已发布的错误码值不能修改，新增错误码时需在getHTTPStatus中指定状态码、在messages.go的errorCodes和pkg/i18n/locales消息目录中登记，并同步docs/api.md的错误码表
 const (
 	// 通用错误
 	ErrCodeSuccess         ErrorCode = "SUCCESS"
@@ -19,11 +23,13 @@ const (
 	ErrCodeNotFound        ErrorCode = "NOT_FOUND"
 	ErrCodeConflict        ErrorCode = "CONFLICT"
 	ErrCodeTooManyRequests ErrorCode = "TOO_MANY_REQUESTS"
+	ErrCodeUnprocessable   ErrorCode = "UNPROCESSABLE"
 
 	// 参数验证错误
 	ErrCodeValidationFailed ErrorCode = "VALIDATION_FAILED"
 	ErrCodeMissingParameter ErrorCode = "MISSING_PARAMETER"
 	ErrCodeInvalidParameter ErrorCode = "INVALID_PARAMETER"
+	ErrCodeExportTooLarge   ErrorCode = "EXPORT_TOO_LARGE"
 
 	// 数据库错误
 	ErrCodeDatabaseError     ErrorCode = "DATABASE_ERROR"
@@ -36,13 +42,19 @@ const (
 	ErrCodeTokenExpired    ErrorCode = "TOKEN_EXPIRED"
 	ErrCodeInvalidCredentials ErrorCode = "INVALID_CREDENTIALS"
 	ErrCodePermissionDenied   ErrorCode = "PERMISSION_DENIED"
+	ErrCodeActivationTokenInvalid ErrorCode = "ACTIVATION_TOKEN_INVALID"
+	ErrCodeActivationTokenExpired ErrorCode = "ACTIVATION_TOKEN_EXPIRED"
+	ErrCodeAccountAlreadyActivated ErrorCode = "ACCOUNT_ALREADY_ACTIVATED"
 
 	// 业务逻辑错误
 	ErrCodeTaskNotFound        ErrorCode = "TASK_NOT_FOUND"
 	ErrCodeTaskAlreadyAssigned ErrorCode = "TASK_ALREADY_ASSIGNED"
 	ErrCodeTaskStatusInvalid   ErrorCode = "TASK_STATUS_INVALID"
+	ErrCodeWIPLimitReached     ErrorCode = "WIP_LIMIT_REACHED"
+	ErrCodeTaskHasOpenSubtasks ErrorCode = "TASK_HAS_OPEN_SUBTASKS"
 	ErrCodeEmployeeNotFound    ErrorCode = "EMPLOYEE_NOT_FOUND"
 	ErrCodeEmployeeNotAvailable ErrorCode = "EMPLOYEE_NOT_AVAILABLE"
+	ErrCodeNotProjectMember     ErrorCode = "NOT_PROJECT_MEMBER"
 	ErrCodeAssignmentFailed     ErrorCode = "ASSIGNMENT_FAILED"
 	ErrCodeApprovalRequired     ErrorCode = "APPROVAL_REQUIRED"
 	ErrCodeApprovalNotFound     ErrorCode = "APPROVAL_NOT_FOUND"
@@ -54,21 +66,29 @@ const (
 	ErrCodeTimeout              ErrorCode = "TIMEOUT"
 )
 
-// AppError 应用程序错误结构
+// AppError 应用程序错误结构，Message为源语言的消息，用于日志和未本地化的响应
+// 设置了消息键的错误按请求语言从消息目录渲染响应消息，见Localize
 type AppError struct {
 	Code       ErrorCode   `json:"code"`
 	Message    string      `json:"message"`
 	Details    interface{} `json:"details,omitempty"`
 	Cause      error       `json:"-"`
 	HTTPStatus int         `json:"-"`
+	MessageKey string      `json:"-"`
+	Params     i18n.Params `json:"-"`
+
+	// base 由Wrapf、WithDetails、WithCause派生时指向原错误，使errors.Is仍能匹配原错误
+	base *AppError
+	// detail Wrapf追加的源语言说明
+	detail string
 }
 
-// Error 实现error接口
+// Error 实现error接口，Cause只用于日志，不会出现在响应中
 func (e *AppError) Error() string {
 	if e.Cause != nil {
-		return fmt.Sprintf("%s: %s (caused by: %v)", e.Code, e.Message, e.Cause)
+		return fmt.Sprintf("%s: %v", e.Message, e.Cause)
 	}
-	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+	return e.Message
 }
 
 // Unwrap 支持errors.Unwrap
@@ -76,16 +96,93 @@ func (e *AppError) Unwrap() error {
 	return e.Cause
 }
 
-// WithDetails 添加错误详情
+// Is 支持errors.Is：派生错误匹配其原错误；ErrNotFound等错误类别匹配HTTP状态码相同的任意AppError
+func (e *AppError) Is(target error) bool {
+	t, ok := target.(*AppError)
+	if !ok {
+		return false
+	}
+	for base := e.base; base != nil; base = base.base {
+		if base == t {
+			return true
+		}
+	}
+	return isErrorKind(t) && e.status() == t.HTTPStatus
+}
+
+// status 错误对应的HTTP状态码，未设置时按错误码推断
+func (e *AppError) status() int {
+	if e.HTTPStatus != 0 {
+		return e.HTTPStatus
+	}
+	return getHTTPStatus(e.Code)
+}
+
+// derive 复制错误，副本的修改不影响预定义错误
+func (e *AppError) derive() *AppError {
+	copied := *e
+	copied.base = e
+	return &copied
+}
+
+// WithDetails 返回带错误详情的副本
 func (e *AppError) WithDetails(details interface{}) *AppError {
-	e.Details = details
-	return e
+	copied := e.derive()
+	copied.Details = details
+	return copied
 }
 
-// WithCause 添加原因错误
+// WithCause 返回带原因错误的副本
 func (e *AppError) WithCause(cause error) *AppError {
-	e.Cause = cause
-	return e
+	copied := e.derive()
+	copied.Cause = cause
+	return copied
+}
+
+// Localized 返回使用指定消息键和参数的副本，errors.Is(err, e)成立
+func (e *AppError) Localized(key string, params i18n.Params) *AppError {
+	copied := e.derive()
+	copied.MessageKey = key
+	copied.Params = params
+	copied.Message = i18n.T(i18n.SourceLocale, key, params)
+	copied.detail = ""
+	return copied
+}
+
+// Localize 返回指定语言的响应消息
+// 有消息键时从消息目录渲染，Wrapf追加的说明只在源语言下保留；没有消息键时源语言返回Message，其它语言返回错误码的通用消息
+func (e *AppError) Localize(locale string) string {
+	if e.MessageKey != "" {
+		message := i18n.T(locale, e.MessageKey, e.Params)
+		if e.detail != "" && locale == i18n.SourceLocale {
+			message += ": " + e.detail
+		}
+		return message
+	}
+	return localizeLiteral(locale, e.Code, e.Message)
+}
+
+// localizeLiteral 代码中直接书写的消息只用于源语言，其它语言使用错误码的通用消息
+func localizeLiteral(locale string, code ErrorCode, message string) string {
+	if locale == i18n.SourceLocale {
+		return message
+	}
+	if translated, ok := i18n.Translate(locale, string(code), nil); ok {
+		return translated
+	}
+	return message
+}
+
+// Wrapf 返回与base错误码相同、消息追加格式化说明的错误，errors.Is(err, base)成立
+func Wrapf(base *AppError, format string, args ...interface{}) *AppError {
+	copied := base.derive()
+	detail := fmt.Sprintf(format, args...)
+	copied.Message = base.Message + ": " + detail
+	if base.detail != "" {
+		detail = base.detail + ": " + detail
+	}
+	copied.detail = detail
+	return copied
 }
 
 // NewError 创建新的应用程序错误
@@ -97,6 +194,18 @@ func NewError(code ErrorCode, message string) *AppError {
 	}
 }
 
+// NewLocalizedError 创建按消息键本地化的错误，Message为源语言渲染结果
+func NewLocalizedError(code ErrorCode, key string, params i18n.Params) *AppError {
+	i18n.Require(key)
+	return &AppError{
+		Code:       code,
+		Message:    i18n.T(i18n.SourceLocale, key, params),
+		HTTPStatus: getHTTPStatus(code),
+		MessageKey: key,
+		Params:     params,
+	}
+}
+
 // NewErrorWithCause 创建带原因的错误
 func NewErrorWithCause(code ErrorCode, message string, cause error) *AppError {
 	return &AppError{
@@ -112,16 +221,20 @@ func getHTTPStatus(code ErrorCode) int {
 	switch code {
 	case ErrCodeSuccess:
 		return http.StatusOK
-	case ErrCodeInvalidRequest, ErrCodeValidationFailed, ErrCodeMissingParameter, ErrCodeInvalidParameter:
+	case ErrCodeInvalidRequest, ErrCodeValidationFailed, ErrCodeMissingParameter, ErrCodeInvalidParameter, ErrCodeActivationTokenInvalid, ErrCodeExportTooLarge:
 		return http.StatusBadRequest
 	case ErrCodeUnauthorized, ErrCodeInvalidToken, ErrCodeTokenExpired, ErrCodeInvalidCredentials:
 		return http.StatusUnauthorized
 	case ErrCodeForbidden, ErrCodePermissionDenied:
 		return http.StatusForbidden
+	case ErrCodeActivationTokenExpired:
+		return http.StatusGone
 	case ErrCodeNotFound, ErrCodeRecordNotFound, ErrCodeTaskNotFound, ErrCodeEmployeeNotFound, ErrCodeApprovalNotFound:
 		return http.StatusNotFound
-	case ErrCodeConflict, ErrCodeDuplicateRecord, ErrCodeTaskAlreadyAssigned, ErrCodeApprovalAlreadyProcessed:
+	case ErrCodeConflict, ErrCodeDuplicateRecord, ErrCodeTaskAlreadyAssigned, ErrCodeApprovalAlreadyProcessed, ErrCodeWIPLimitReached, ErrCodeTaskHasOpenSubtasks, ErrCodeAccountAlreadyActivated, ErrCodeTaskStatusInvalid, ErrCodeEmployeeNotAvailable, ErrCodeNotProjectMember:
 		return http.StatusConflict
+	case ErrCodeUnprocessable:
+		return http.StatusUnprocessableEntity
 	case ErrCodeTooManyRequests:
 		return http.StatusTooManyRequests
 	case ErrCodeServiceUnavailable:
@@ -133,56 +246,80 @@ func getHTTPStatus(code ErrorCode) int {
 	}
 }
 
-// 预定义的常用错误
+// 预定义的常用错误，消息为错误码的通用消息
 var (
-	ErrInternalError = NewError(ErrCodeInternalError, "内部服务器错误")
-	ErrInvalidRequest = NewError(ErrCodeInvalidRequest, "无效的请求")
-	ErrUnauthorized = NewError(ErrCodeUnauthorized, "未授权访问")
-	ErrForbidden = NewError(ErrCodeForbidden, "禁止访问")
-	ErrNotFound = NewError(ErrCodeNotFound, "资源未找到")
-	ErrValidationFailed = NewError(ErrCodeValidationFailed, "参数验证失败")
-	ErrDatabaseError = NewError(ErrCodeDatabaseError, "数据库操作失败")
-	ErrRecordNotFound = NewError(ErrCodeRecordNotFound, "记录未找到")
-	ErrDuplicateRecord = NewError(ErrCodeDuplicateRecord, "记录已存在")
-	ErrInvalidToken = NewError(ErrCodeInvalidToken, "无效的令牌")
-	ErrTokenExpired = NewError(ErrCodeTokenExpired, "令牌已过期")
-	ErrPermissionDenied = NewError(ErrCodePermissionDenied, "权限不足")
+	ErrInternalError    = codeError(ErrCodeInternalError)
+	ErrInvalidRequest   = codeError(ErrCodeInvalidRequest)
+	ErrUnauthorized     = codeError(ErrCodeUnauthorized)
+	ErrForbidden        = codeError(ErrCodeForbidden)
+	ErrNotFound         = codeError(ErrCodeNotFound)
+	ErrValidationFailed = codeError(ErrCodeValidationFailed)
+	ErrDatabaseError    = codeError(ErrCodeDatabaseError)
+	ErrRecordNotFound   = codeError(ErrCodeRecordNotFound)
+	ErrDuplicateRecord  = codeError(ErrCodeDuplicateRecord)
+	ErrInvalidToken     = codeError(ErrCodeInvalidToken)
+	ErrTokenExpired     = codeError(ErrCodeTokenExpired)
+	ErrPermissionDenied = codeError(ErrCodePermissionDenied)
 )
 
+// 错误类别，服务层用NewErrorWithCause、Wrapf构造具体错误，处理器统一经FromError翻译
+// errors.Is(err, ErrNotFound)对任意404错误码成立，其余类别同理
+var (
+	ErrConflict      = codeError(ErrCodeConflict)
+	ErrValidation    = codeError(ErrCodeValidationFailed)
+	ErrUnprocessable = codeError(ErrCodeUnprocessable)
+)
+
+// codeError 创建以错误码为消息键的错误
+func codeError(code ErrorCode) *AppError {
+	return NewLocalizedError(code, string(code), nil)
+}
+
+// isErrorKind 判断是否为错误类别
+func isErrorKind(err *AppError) bool {
+	switch err {
+	case ErrNotFound, ErrConflict, ErrValidation, ErrForbidden, ErrUnprocessable:
+		return true
+	}
+	return false
+}
+
 // 业务错误构造函数
 func NewTaskNotFoundError(taskID interface{}) *AppError {
-	return NewError(ErrCodeTaskNotFound, fmt.Sprintf("任务不存在: %v", taskID))
+	return NewLocalizedError(ErrCodeTaskNotFound, MsgTaskNotFound, i18n.Params{"task_id": taskID})
 }
 
 func NewEmployeeNotFoundError(employeeID interface{}) *AppError {
-	return NewError(ErrCodeEmployeeNotFound, fmt.Sprintf("员工不存在: %v", employeeID))
+	return NewLocalizedError(ErrCodeEmployeeNotFound, MsgEmployeeNotFound, i18n.Params{"employee_id": employeeID})
 }
 
 func NewTaskAlreadyAssignedError(taskID interface{}) *AppError {
-	return NewError(ErrCodeTaskAlreadyAssigned, fmt.Sprintf("任务已被分配: %v", taskID))
+	return NewLocalizedError(ErrCodeTaskAlreadyAssigned, MsgTaskAlreadyAssigned, i18n.Params{"task_id": taskID})
 }
 
 func NewEmployeeNotAvailableError(employeeID interface{}) *AppError {
-	return NewError(ErrCodeEmployeeNotAvailable, fmt.Sprintf("员工不可用: %v", employeeID))
+	return NewLocalizedError(ErrCodeEmployeeNotAvailable, MsgEmployeeNotAvailable, i18n.Params{"employee_id": employeeID})
 }
 
 func NewValidationError(field string, message string) *AppError {
-	return NewError(ErrCodeValidationFailed, fmt.Sprintf("字段 %s 验证失败: %s", field, message))
+	return NewLocalizedError(ErrCodeValidationFailed, MsgValidationField, i18n.Params{"field": field, "reason": message})
 }
 
 func NewDatabaseError(operation string, cause error) *AppError {
-	return NewErrorWithCause(ErrCodeDatabaseError, fmt.Sprintf("数据库操作失败: %s", operation), cause)
+	err := NewLocalizedError(ErrCodeDatabaseError, MsgDatabaseOperationFailed, i18n.Params{"operation": operation})
+	err.Cause = cause
+	return err
 }
 
-// IsAppError 检查是否为应用程序错误
+// IsAppError 检查错误链中是否有应用程序错误
 func IsAppError(err error) bool {
-	_, ok := err.(*AppError)
-	return ok
+	return GetAppError(err) != nil
 }
 
-// GetAppError 获取应用程序错误
+// GetAppError 获取错误链中的应用程序错误
 func GetAppError(err error) *AppError {
-	if appErr, ok := err.(*AppError); ok {
+	var appErr *AppError
+	if errors.As(err, &appErr) {
 		return appErr
 	}
 	return nil
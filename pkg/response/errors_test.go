@@ -0,0 +1,108 @@
+package response_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"taskmanage/pkg/i18n"
+	"taskmanage/pkg/response"
+)
+
+func TestAppError_IsAndWrapf(t *testing.T) {
+	errLimit := response.NewError(response.ErrCodeWIPLimitReached, "超过项目在制品上限")
+	wrapped := fmt.Errorf("开始任务失败: %w", response.Wrapf(errLimit, "项目%d已有%d个进行中的任务", 3, 5))
+
+	assert.ErrorIs(t, wrapped, errLimit)
+	assert.ErrorIs(t, wrapped, response.ErrConflict)
+	assert.NotErrorIs(t, wrapped, response.ErrNotFound)
+	assert.Equal(t, "开始任务失败: 超过项目在制品上限: 项目3已有5个进行中的任务", wrapped.Error())
+
+	// 派生错误不影响预定义错误
+	assert.Equal(t, "超过项目在制品上限", errLimit.Message)
+
+	cause := errors.New("记录不存在")
+	notFound := response.NewErrorWithCause(response.ErrCodeTaskNotFound, "任务不存在", cause)
+	assert.ErrorIs(t, notFound, response.ErrNotFound)
+	assert.ErrorIs(t, notFound, cause)
+	assert.Equal(t, "任务不存在: 记录不存在", notFound.Error())
+}
+
+func TestFromError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name    string
+		err     error
+		status  int
+		code    response.ErrorCode
+		message string
+	}{
+		{"not found", fmt.Errorf("获取任务失败: %w", response.NewError(response.ErrCodeTaskNotFound, "任务不存在")), http.StatusNotFound, response.ErrCodeTaskNotFound, "任务不存在"},
+		{"forbidden", response.NewError(response.ErrCodeForbidden, "只有任务被分配者才能开始任务"), http.StatusForbidden, response.ErrCodeForbidden, "只有任务被分配者才能开始任务"},
+		{"unprocessable", response.Wrapf(response.ErrUnprocessable, "员工正在办理离职"), http.StatusUnprocessableEntity, response.ErrCodeUnprocessable, "业务规则不允许该操作: 员工正在办理离职"},
+		{"unknown", errors.New("connection refused"), http.StatusInternalServerError, response.ErrCodeInternalError, "内部服务器错误"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/tasks/1", nil)
+
+			response.FromError(c, tt.err)
+
+			assert.Equal(t, tt.status, w.Code)
+			resp := decodeResponse(t, w)
+			assert.Equal(t, tt.code, resp.Code)
+			assert.Equal(t, tt.message, resp.Message)
+		})
+	}
+}
+
+func TestFromError_Localized(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name    string
+		locale  string
+		err     error
+		message string
+	}{
+		{"keyed zh", i18n.LocaleZhCN, response.NewTaskNotFoundError(7), "任务不存在: 7"},
+		{"keyed en", i18n.LocaleEn, response.NewTaskNotFoundError(7), "Task not found: 7"},
+		{"wrapped keyed en", i18n.LocaleEn, fmt.Errorf("分配失败: %w", response.NewEmployeeNotFoundError(3)), "Employee not found: 3"},
+		{"literal zh", i18n.LocaleZhCN, response.NewError(response.ErrCodeForbidden, "只有任务被分配者才能开始任务"), "只有任务被分配者才能开始任务"},
+		{"literal en falls back to code", i18n.LocaleEn, response.NewError(response.ErrCodeForbidden, "只有任务被分配者才能开始任务"), "Forbidden"},
+		{"wrapf zh keeps detail", i18n.LocaleZhCN, response.Wrapf(response.ErrUnprocessable, "员工正在办理离职"), "业务规则不允许该操作: 员工正在办理离职"},
+		{"wrapf en drops detail", i18n.LocaleEn, response.Wrapf(response.ErrUnprocessable, "员工正在办理离职"), "The operation is not allowed by business rules"},
+		{"unknown en", i18n.LocaleEn, errors.New("connection refused"), "Internal server error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/tasks/1", nil)
+			c.Set(i18n.ContextKey, tt.locale)
+
+			response.FromError(c, tt.err)
+
+			assert.Equal(t, tt.message, decodeResponse(t, w).Message)
+		})
+	}
+}
+
+func TestLocalized_KeepsErrorIdentity(t *testing.T) {
+	errLimit := response.NewError(response.ErrCodeWIPLimitReached, "项目在制品数量已达上限")
+	err := errLimit.Localized("task.wip_limit_reached", i18n.Params{"project": "官网", "status": "in_progress", "count": 5, "limit": 5})
+
+	assert.ErrorIs(t, err, errLimit)
+	assert.Equal(t, "项目在制品数量已达上限：项目 官网 状态为 in_progress 的任务已有5个（上限5）", err.Error())
+	assert.Equal(t, "Project WIP limit reached: project 官网 already has 5 tasks in in_progress (limit 5)", err.Localize(i18n.LocaleEn))
+}
+
+func TestMessageCatalog_CoversErrorCodes(t *testing.T) {
+	assert.NoError(t, i18n.Check())
+}
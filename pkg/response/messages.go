@@ -0,0 +1,58 @@
+package response
+
+import "taskmanage/pkg/i18n"
+
+// 消息键，对应pkg/i18n/locales下的消息目录；错误码本身也是消息键，对应错误码的通用消息
+const (
+	MsgRequestMalformed   = "request.malformed"
+	MsgRequestInvalidJSON = "request.invalid_json"
+
+	MsgValidationRequired  = "validation.required"
+	MsgValidationEmail     = "validation.email"
+	MsgValidationOneOf     = "validation.oneof"
+	MsgValidationMinLength = "validation.min_length"
+	MsgValidationMinItems  = "validation.min_items"
+	MsgValidationMin       = "validation.min"
+	MsgValidationMaxLength = "validation.max_length"
+	MsgValidationMaxItems  = "validation.max_items"
+	MsgValidationMax       = "validation.max"
+	MsgValidationRuleParam = "validation.rule_param"
+	MsgValidationRule      = "validation.rule"
+	MsgValidationType      = "validation.type"
+	MsgValidationField     = "validation.field"
+
+	MsgTaskNotFound            = "task.not_found"
+	MsgTaskAlreadyAssigned     = "task.already_assigned"
+	MsgEmployeeNotFound        = "employee.not_found"
+	MsgEmployeeNotAvailable    = "employee.not_available"
+	MsgDatabaseOperationFailed = "database.operation_failed"
+)
+
+// errorCodes 所有错误码，每个错误码在消息目录中都要有通用消息
+var errorCodes = []ErrorCode{
+	ErrCodeSuccess, ErrCodeInternalError, ErrCodeInvalidRequest, ErrCodeUnauthorized, ErrCodeForbidden,
+	ErrCodeNotFound, ErrCodeConflict, ErrCodeTooManyRequests, ErrCodeUnprocessable,
+	ErrCodeValidationFailed, ErrCodeMissingParameter, ErrCodeInvalidParameter, ErrCodeExportTooLarge,
+	ErrCodeDatabaseError, ErrCodeRecordNotFound, ErrCodeDuplicateRecord, ErrCodeConstraintViolation,
+	ErrCodeInvalidToken, ErrCodeTokenExpired, ErrCodeInvalidCredentials, ErrCodePermissionDenied,
+	ErrCodeActivationTokenInvalid, ErrCodeActivationTokenExpired, ErrCodeAccountAlreadyActivated,
+	ErrCodeTaskNotFound, ErrCodeTaskAlreadyAssigned, ErrCodeTaskStatusInvalid, ErrCodeWIPLimitReached,
+	ErrCodeTaskHasOpenSubtasks, ErrCodeEmployeeNotFound, ErrCodeEmployeeNotAvailable, ErrCodeNotProjectMember,
+	ErrCodeAssignmentFailed, ErrCodeApprovalRequired, ErrCodeApprovalNotFound, ErrCodeApprovalAlreadyProcessed,
+	ErrCodeExternalServiceError, ErrCodeServiceUnavailable, ErrCodeTimeout,
+}
+
+func init() {
+	for _, code := range errorCodes {
+		i18n.Require(string(code))
+	}
+	i18n.Require(
+		MsgRequestMalformed, MsgRequestInvalidJSON,
+		MsgValidationRequired, MsgValidationEmail, MsgValidationOneOf,
+		MsgValidationMinLength, MsgValidationMinItems, MsgValidationMin,
+		MsgValidationMaxLength, MsgValidationMaxItems, MsgValidationMax,
+		MsgValidationRuleParam, MsgValidationRule, MsgValidationType, MsgValidationField,
+		MsgTaskNotFound, MsgTaskAlreadyAssigned, MsgEmployeeNotFound, MsgEmployeeNotAvailable,
+		MsgDatabaseOperationFailed,
+	)
+}
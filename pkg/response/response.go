@@ -1,24 +1,50 @@
 package response
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+
+	"taskmanage/pkg/i18n"
+	"taskmanage/pkg/logger"
 )
 
 // Response 统一响应结构
 type Response struct {
-	Code    ErrorCode   `json:"code"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data,omitempty"`
-	Details interface{} `json:"details,omitempty"`
+	Code    ErrorCode    `json:"code"`
+	Message string       `json:"message"`
+	Data    interface{}  `json:"data,omitempty"`
+	Details interface{}  `json:"details,omitempty"`
+	Errors  []FieldError `json:"errors,omitempty"`
+}
+
+// Locale 返回请求的语言，未经语言中间件时使用默认语言
+func Locale(c *gin.Context) string {
+	if locale := c.GetString(i18n.ContextKey); locale != "" {
+		return locale
+	}
+	return i18n.DefaultLocale()
+}
+
+// Message 返回消息键在请求语言下的文本
+func Message(c *gin.Context, key string, params i18n.Params) string {
+	return i18n.T(Locale(c), key, params)
+}
+
+// literal 处理器直接传入的源语言消息，其它语言的请求返回错误码的通用消息
+func literal(c *gin.Context, code ErrorCode, message string) string {
+	return localizeLiteral(Locale(c), code, message)
 }
 
 // Success 成功响应
 func Success(c *gin.Context, data interface{}) {
 	c.JSON(http.StatusOK, Response{
 		Code:    ErrCodeSuccess,
-		Message: "操作成功",
+		Message: Message(c, string(ErrCodeSuccess), nil),
 		Data:    data,
 	})
 }
@@ -27,26 +53,71 @@ func Success(c *gin.Context, data interface{}) {
 func SuccessWithMessage(c *gin.Context, message string, data interface{}) {
 	c.JSON(http.StatusOK, Response{
 		Code:    ErrCodeSuccess,
-		Message: message,
+		Message: literal(c, ErrCodeSuccess, message),
 		Data:    data,
 	})
 }
 
-// Error 错误响应
+// SuccessWithETag 成功响应并按响应体设置ETag，供频繁轮询的接口使用
+// 请求的If-None-Match包含相同ETag时返回304且不返回响应体
+func SuccessWithETag(c *gin.Context, data interface{}) {
+	body, err := json.Marshal(Response{
+		Code:    ErrCodeSuccess,
+		Message: Message(c, string(ErrCodeSuccess), nil),
+		Data:    data,
+	})
+	if err != nil {
+		FromError(c, err)
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "private, no-cache")
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
+
+// etagMatches If-None-Match是否包含给定ETag，按弱比较忽略W/前缀
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// Error 错误响应，同FromError
 func Error(c *gin.Context, err error) {
+	FromError(c, err)
+}
+
+// FromError 将服务层错误翻译为HTTP响应，处理器统一通过它返回错误
+// 错误链中有AppError时按其错误码、请求语言的消息和详情响应；其它错误记录日志后返回500，不向客户端暴露内部信息
+func FromError(c *gin.Context, err error) {
 	if appErr := GetAppError(err); appErr != nil {
-		c.JSON(appErr.HTTPStatus, Response{
+		status := appErr.status()
+		if status >= http.StatusInternalServerError {
+			logger.Errorf("请求失败: %s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+		}
+		c.JSON(status, Response{
 			Code:    appErr.Code,
-			Message: appErr.Message,
+			Message: appErr.Localize(Locale(c)),
 			Details: appErr.Details,
 		})
 		return
 	}
 
-	// 未知错误，返回内部服务器错误
+	logger.Errorf("请求失败: %s %s: %v", c.Request.Method, c.Request.URL.Path, err)
 	c.JSON(http.StatusInternalServerError, Response{
 		Code:    ErrCodeInternalError,
-		Message: "内部服务器错误",
+		Message: Message(c, string(ErrCodeInternalError), nil),
 	})
 }
 
@@ -55,7 +126,7 @@ func ErrorWithCode(c *gin.Context, code ErrorCode, message string) {
 	httpStatus := getHTTPStatus(code)
 	c.JSON(httpStatus, Response{
 		Code:    code,
-		Message: message,
+		Message: literal(c, code, message),
 	})
 }
 
@@ -63,7 +134,7 @@ func ErrorWithCode(c *gin.Context, code ErrorCode, message string) {
 func BadRequest(c *gin.Context, message string) {
 	c.JSON(http.StatusBadRequest, Response{
 		Code:    ErrCodeInvalidRequest,
-		Message: message,
+		Message: literal(c, ErrCodeInvalidRequest, message),
 	})
 }
 
@@ -71,7 +142,7 @@ func BadRequest(c *gin.Context, message string) {
 func Unauthorized(c *gin.Context, message string) {
 	c.JSON(http.StatusUnauthorized, Response{
 		Code:    ErrCodeUnauthorized,
-		Message: message,
+		Message: literal(c, ErrCodeUnauthorized, message),
 	})
 }
 
@@ -79,7 +150,7 @@ func Unauthorized(c *gin.Context, message string) {
 func Forbidden(c *gin.Context, message string) {
 	c.JSON(http.StatusForbidden, Response{
 		Code:    ErrCodeForbidden,
-		Message: message,
+		Message: literal(c, ErrCodeForbidden, message),
 	})
 }
 
@@ -87,7 +158,7 @@ func Forbidden(c *gin.Context, message string) {
 func NotFound(c *gin.Context, message string) {
 	c.JSON(http.StatusNotFound, Response{
 		Code:    ErrCodeNotFound,
-		Message: message,
+		Message: literal(c, ErrCodeNotFound, message),
 	})
 }
 
@@ -95,7 +166,24 @@ func NotFound(c *gin.Context, message string) {
 func Conflict(c *gin.Context, message string) {
 	c.JSON(http.StatusConflict, Response{
 		Code:    ErrCodeConflict,
-		Message: message,
+		Message: literal(c, ErrCodeConflict, message),
+	})
+}
+
+// ConflictWithData 409错误响应，附带资源最新状态供客户端刷新后重试
+func ConflictWithData(c *gin.Context, message string, data interface{}) {
+	c.JSON(http.StatusConflict, Response{
+		Code:    ErrCodeConflict,
+		Message: literal(c, ErrCodeConflict, message),
+		Data:    data,
+	})
+}
+
+// TooManyRequests 429错误响应
+func TooManyRequests(c *gin.Context, message string) {
+	c.JSON(http.StatusTooManyRequests, Response{
+		Code:    ErrCodeTooManyRequests,
+		Message: literal(c, ErrCodeTooManyRequests, message),
 	})
 }
 
@@ -103,7 +191,7 @@ func Conflict(c *gin.Context, message string) {
 func ValidationError(c *gin.Context, details interface{}) {
 	c.JSON(http.StatusBadRequest, Response{
 		Code:    ErrCodeValidationFailed,
-		Message: "参数验证失败",
+		Message: Message(c, string(ErrCodeValidationFailed), nil),
 		Details: details,
 	})
 }
@@ -112,14 +200,14 @@ func ValidationError(c *gin.Context, details interface{}) {
 func InternalError(c *gin.Context, message string) {
 	c.JSON(http.StatusInternalServerError, Response{
 		Code:    ErrCodeInternalError,
-		Message: message,
+		Message: literal(c, ErrCodeInternalError, message),
 	})
 }
 
 func StatusNotFound(c *gin.Context, message string) {
 	c.JSON(http.StatusNotFound, Response{
 		Code:    ErrCodeNotFound,
-		Message: message,
+		Message: literal(c, ErrCodeNotFound, message),
 	})
 }
 
@@ -139,19 +227,22 @@ type Pagination struct {
 	TotalPages int   `json:"total_pages"`
 }
 
-// SuccessWithPagination 带分页的成功响应
-func SuccessWithPagination(c *gin.Context, data interface{}, page, pageSize int, total int64) {
-	totalPages := int((total + int64(pageSize) - 1) / int64(pageSize))
-
-	c.JSON(http.StatusOK, PaginationResponse{
+// NewPaginationResponse 生成带分页的成功响应体，供需要在分页响应上附加字段的接口使用
+func NewPaginationResponse(c *gin.Context, data interface{}, page, pageSize int, total int64) PaginationResponse {
+	return PaginationResponse{
 		Code:    ErrCodeSuccess,
-		Message: "操作成功",
+		Message: Message(c, string(ErrCodeSuccess), nil),
 		Data:    data,
 		Pagination: Pagination{
 			Page:       page,
 			PageSize:   pageSize,
 			Total:      total,
-			TotalPages: totalPages,
+			TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
 		},
-	})
+	}
+}
+
+// SuccessWithPagination 带分页的成功响应
+func SuccessWithPagination(c *gin.Context, data interface{}, page, pageSize int, total int64) {
+	c.JSON(http.StatusOK, NewPaginationResponse(c, data, page, pageSize, total))
 }
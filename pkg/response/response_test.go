@@ -0,0 +1,49 @@
+package response_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/pkg/response"
+)
+
+func TestSuccessWithETag(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	data := map[string]int{"count": 1}
+	router := gin.New()
+	router.GET("/dashboard", func(c *gin.Context) { response.SuccessWithETag(c, data) })
+	get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/dashboard", nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("")
+	require.Equal(t, http.StatusOK, w.Code)
+	etag := w.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+	assert.Equal(t, response.ErrCodeSuccess, decodeResponse(t, w).Code)
+
+	// 相同内容返回304且没有响应体，弱比较和多个候选值同样匹配
+	for _, ifNoneMatch := range []string{etag, "W/" + etag, `"stale", ` + etag} {
+		w = get(ifNoneMatch)
+		assert.Equal(t, http.StatusNotModified, w.Code, ifNoneMatch)
+		assert.Empty(t, w.Body.String())
+		assert.Equal(t, etag, w.Header().Get("ETag"))
+	}
+
+	// 内容变化后ETag随之变化
+	data["count"] = 2
+	w = get(etag)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEqual(t, etag, w.Header().Get("ETag"))
+}
@@ -0,0 +1,163 @@
+package response
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+
+	"taskmanage/pkg/i18n"
+)
+
+// FieldError 单个字段的校验错误，Field使用json标签名，嵌套字段以点号连接
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func init() {
+	// 必须在任何结构体被校验之前注册，validator会缓存结构体的字段名
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(fieldTagName)
+	}
+}
+
+// fieldTagName 校验错误中使用的字段名：优先json标签，其次form标签，最后Go字段名
+func fieldTagName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "form"} {
+		name := strings.SplitN(field.Tag.Get(tag), ",", 2)[0]
+		// 返回"-"会让validator跳过该字段，json:"-"的字段仍需校验
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// BindAndValidate 绑定并校验JSON请求体，失败时写入400响应并返回false
+func BindAndValidate(c *gin.Context, obj interface{}) bool {
+	if err := c.ShouldBindJSON(obj); err != nil {
+		_ = c.Error(err).SetType(gin.ErrorTypeBind)
+		BindError(c, err)
+		return false
+	}
+	return true
+}
+
+// BindError 将绑定错误转换为带字段明细的400响应，消息使用请求语言
+func BindError(c *gin.Context, err error) {
+	locale := Locale(c)
+	fieldErrors := TranslateBindErrorIn(locale, err)
+	if len(fieldErrors) == 0 {
+		key := MsgRequestMalformed
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			key = MsgRequestInvalidJSON
+		}
+		c.JSON(http.StatusBadRequest, Response{
+			Code:    ErrCodeInvalidRequest,
+			Message: i18n.T(locale, key, nil),
+		})
+		return
+	}
+
+	c.JSON(http.StatusBadRequest, Response{
+		Code:    ErrCodeValidationFailed,
+		Message: fieldErrors[0].Message,
+		Errors:  fieldErrors,
+	})
+}
+
+// TranslateBindError 将绑定错误转换为默认语言的字段错误列表，无法定位到字段时返回nil
+func TranslateBindError(err error) []FieldError {
+	return TranslateBindErrorIn(i18n.DefaultLocale(), err)
+}
+
+// TranslateBindErrorIn 将绑定错误转换为指定语言的字段错误列表，无法定位到字段时返回nil
+func TranslateBindErrorIn(locale string, err error) []FieldError {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		result := make([]FieldError, 0, len(validationErrs))
+		for _, fe := range validationErrs {
+			field := fieldPath(fe)
+			result = append(result, FieldError{
+				Field:   field,
+				Rule:    fe.Tag(),
+				Message: validationMessage(locale, field, fe),
+			})
+		}
+		return result
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return []FieldError{{
+			Field:   typeErr.Field,
+			Rule:    "type",
+			Message: i18n.T(locale, MsgValidationType, i18n.Params{"field": typeErr.Field, "type": typeErr.Type}),
+		}}
+	}
+
+	return nil
+}
+
+// fieldPath 去掉顶层结构体名，如 CreateEmployeeRequest.work_hours.start -> work_hours.start
+func fieldPath(fe validator.FieldError) string {
+	namespace := fe.Namespace()
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+	return fe.Field()
+}
+
+// validationMessage 生成校验规则对应的提示
+func validationMessage(locale, field string, fe validator.FieldError) string {
+	param := fe.Param()
+	if fe.Tag() == "oneof" {
+		param = strings.Join(strings.Fields(param), ", ")
+	}
+	params := i18n.Params{"field": field, "param": param, "rule": fe.Tag()}
+	return i18n.T(locale, validationMessageKey(fe), params)
+}
+
+// validationMessageKey 校验规则对应的消息键，min/max按字段类型区分长度、数量和数值
+func validationMessageKey(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return MsgValidationRequired
+	case "email":
+		return MsgValidationEmail
+	case "oneof":
+		return MsgValidationOneOf
+	case "min", "gte":
+		switch fe.Kind() {
+		case reflect.String:
+			return MsgValidationMinLength
+		case reflect.Slice, reflect.Array, reflect.Map:
+			return MsgValidationMinItems
+		default:
+			return MsgValidationMin
+		}
+	case "max", "lte":
+		switch fe.Kind() {
+		case reflect.String:
+			return MsgValidationMaxLength
+		case reflect.Slice, reflect.Array, reflect.Map:
+			return MsgValidationMaxItems
+		default:
+			return MsgValidationMax
+		}
+	default:
+		if fe.Param() != "" {
+			return MsgValidationRuleParam
+		}
+		return MsgValidationRule
+	}
+}
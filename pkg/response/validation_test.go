@@ -0,0 +1,110 @@
+package response_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"taskmanage/internal/service"
+	"taskmanage/pkg/i18n"
+	"taskmanage/pkg/response"
+)
+
+func bindRequest(t *testing.T, body string, obj interface{}) (bool, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	return response.BindAndValidate(c, obj), w
+}
+
+func decodeResponse(t *testing.T, w *httptest.ResponseRecorder) response.Response {
+	var resp response.Response
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return resp
+}
+
+func TestBindAndValidate_NestedStruct(t *testing.T) {
+	var req service.CreateEmployeeRequest
+	ok, w := bindRequest(t, `{"name":"张三","email":"not-an-email","department_id":1,"position_id":2,"work_hours":{"start":"09:00"}}`, &req)
+	require.False(t, ok)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	resp := decodeResponse(t, w)
+	assert.Equal(t, response.ErrCodeValidationFailed, resp.Code)
+	assert.Equal(t, []response.FieldError{
+		{Field: "email", Rule: "email", Message: "email不是有效的邮箱地址"},
+		{Field: "work_hours.end", Rule: "required", Message: "work_hours.end为必填项"},
+		{Field: "work_hours.timezone", Rule: "required", Message: "work_hours.timezone为必填项"},
+	}, resp.Errors)
+	assert.Equal(t, resp.Errors[0].Message, resp.Message)
+}
+
+func TestBindAndValidate_Rules(t *testing.T) {
+	var req struct {
+		Title    string   `json:"title" binding:"required,max=5"`
+		Priority string   `json:"priority" binding:"omitempty,oneof=low medium high"`
+		Level    int      `json:"level" binding:"min=1,max=5"`
+		Tags     []string `json:"tags" binding:"omitempty,min=2"`
+		Internal uint     `json:"-" form:"internal_id" binding:"max=10"`
+	}
+	req.Internal = 11
+	ok, w := bindRequest(t, `{"title":"超过五个字符的标题","priority":"urgent","level":0,"tags":["a"]}`, &req)
+	require.False(t, ok)
+
+	resp := decodeResponse(t, w)
+	assert.Equal(t, []response.FieldError{
+		{Field: "title", Rule: "max", Message: "title长度不能超过5个字符"},
+		{Field: "priority", Rule: "oneof", Message: "priority必须是以下值之一: low, medium, high"},
+		{Field: "level", Rule: "min", Message: "level不能小于1"},
+		{Field: "tags", Rule: "min", Message: "tags至少包含2项"},
+		{Field: "internal_id", Rule: "max", Message: "internal_id不能大于10"},
+	}, resp.Errors)
+}
+
+func TestBindAndValidate_MalformedBody(t *testing.T) {
+	var req service.CreateEmployeeRequest
+	ok, w := bindRequest(t, `{"name":`, &req)
+	require.False(t, ok)
+	resp := decodeResponse(t, w)
+	assert.Equal(t, response.ErrCodeInvalidRequest, resp.Code)
+	assert.Empty(t, resp.Errors)
+
+	ok, w = bindRequest(t, `{"department_id":"abc"}`, &req)
+	require.False(t, ok)
+	resp = decodeResponse(t, w)
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, "department_id", resp.Errors[0].Field)
+	assert.Equal(t, "type", resp.Errors[0].Rule)
+
+	ok, _ = bindRequest(t, `{"name":"张三","email":"a@b.com","department_id":1,"position_id":2,"work_hours":{"start":"09:00","end":"18:00","timezone":"Asia/Shanghai"}}`, &req)
+	assert.True(t, ok)
+}
+
+func TestBindAndValidate_Localized(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"priority":"urgent"}`))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Set(i18n.ContextKey, i18n.LocaleEn)
+
+	var req struct {
+		Title    string `json:"title" binding:"required"`
+		Priority string `json:"priority" binding:"omitempty,oneof=low medium high"`
+	}
+	require.False(t, response.BindAndValidate(c, &req))
+
+	resp := decodeResponse(t, w)
+	assert.Equal(t, []response.FieldError{
+		{Field: "title", Rule: "required", Message: "title is required"},
+		{Field: "priority", Rule: "oneof", Message: "priority must be one of: low, medium, high"},
+	}, resp.Errors)
+}
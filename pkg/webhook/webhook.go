@@ -0,0 +1,81 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// SignatureHeader 请求体签名头，值为 sha256=<HMAC-SHA256十六进制>
+	SignatureHeader = "X-Signature"
+	// EventHeader 事件类型头
+	EventHeader = "X-Webhook-Event"
+	// DeliveryHeader 投递ID头，重试时不变，接收方可据此去重
+	DeliveryHeader = "X-Webhook-Delivery"
+
+	// maxErrorBodySize 非2xx响应时错误信息中保留的响应体长度
+	maxErrorBodySize = 512
+)
+
+// Message 一次Webhook请求
+type Message struct {
+	URL        string
+	Secret     string
+	EventType  string
+	DeliveryID string
+	Body       []byte
+}
+
+// Sender Webhook发送接口，返回端点的响应状态码，未收到响应时为0
+type Sender interface {
+	Send(ctx context.Context, msg *Message) (int, error)
+}
+
+// HTTPSender 基于HTTP POST的Webhook发送实现
+type HTTPSender struct {
+	client *http.Client
+}
+
+// NewHTTPSender 创建Webhook发送器，timeout为单次请求的超时时间
+func NewHTTPSender(timeout time.Duration) *HTTPSender {
+	return &HTTPSender{client: &http.Client{Timeout: timeout}}
+}
+
+// Send 发送Webhook，响应状态码不是2xx时返回错误
+func (s *HTTPSender) Send(ctx context.Context, msg *Message) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, msg.URL, bytes.NewReader(msg.Body))
+	if err != nil {
+		return 0, fmt.Errorf("创建Webhook请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, Sign(msg.Secret, msg.Body))
+	req.Header.Set(EventHeader, msg.EventType)
+	req.Header.Set(DeliveryHeader, msg.DeliveryID)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("发送Webhook失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("端点返回状态码%d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign 使用secret对body计算HMAC-SHA256签名
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
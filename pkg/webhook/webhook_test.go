@@ -0,0 +1,53 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSign(t *testing.T) {
+	// 与 echo -n '{"a":1}' | openssl dgst -sha256 -hmac secret 的结果一致
+	assert.Equal(t, "sha256=aa9e2e3575f5d7098b6caccd790888c36d5fdb63342a73bada2d6a51747a8494", Sign("secret", []byte(`{"a":1}`)))
+	assert.NotEqual(t, Sign("secret", []byte("x")), Sign("other", []byte("x")))
+}
+
+func TestHTTPSender(t *testing.T) {
+	var received http.Header
+	var body []byte
+	status := http.StatusNoContent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+		if status >= 300 {
+			_, _ = w.Write([]byte("  bad payload\n"))
+		}
+	}))
+	defer server.Close()
+
+	sender := NewHTTPSender(time.Second)
+	msg := &Message{URL: server.URL, Secret: "s3cret", EventType: "task.created", DeliveryID: "42", Body: []byte(`{"id":"1"}`)}
+
+	code, err := sender.Send(context.Background(), msg)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, code)
+	assert.Equal(t, `{"id":"1"}`, string(body))
+	assert.Equal(t, "application/json", received.Get("Content-Type"))
+	assert.Equal(t, Sign("s3cret", msg.Body), received.Get(SignatureHeader))
+	assert.Equal(t, "task.created", received.Get(EventHeader))
+	assert.Equal(t, "42", received.Get(DeliveryHeader))
+
+	// 非2xx响应返回状态码和响应体
+	status = http.StatusBadRequest
+	code, err = sender.Send(context.Background(), msg)
+	assert.Equal(t, http.StatusBadRequest, code)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "bad payload")
+}
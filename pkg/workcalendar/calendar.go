@@ -0,0 +1,183 @@
+// Package workcalendar 按工作日、上下班时间和节假日计算工作时间
+package workcalendar
+
+import (
+	"fmt"
+	"time"
+)
+
+// DateLayout 节假日日期格式
+const DateLayout = "2006-01-02"
+
+// clockLayout 上下班时间格式
+const clockLayout = "15:04"
+
+// maxSearchDays 查找下一个工作时段时最多向后查找的天数，避免节假日配置异常时无限循环
+const maxSearchDays = 3660
+
+// WorkingHours 每周的工作日和每天的上下班时间
+type WorkingHours struct {
+	Days  []time.Weekday
+	Start time.Duration // 上班时间，距当天零点的时长
+	End   time.Duration // 下班时间，距当天零点的时长
+}
+
+// DefaultWorkingHours 周一至周五 09:00-18:00
+func DefaultWorkingHours() WorkingHours {
+	return WorkingHours{
+		Days:  []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+		Start: 9 * time.Hour,
+		End:   18 * time.Hour,
+	}
+}
+
+// ParseWorkingHours 解析配置中的工作日（0为周日）和上下班时间（HH:MM），为空的部分使用默认值
+func ParseWorkingHours(days []int, start, end string) (WorkingHours, error) {
+	hours := DefaultWorkingHours()
+	if len(days) > 0 {
+		hours.Days = make([]time.Weekday, 0, len(days))
+		for _, day := range days {
+			if day < 0 || day > 6 {
+				return hours, fmt.Errorf("工作日应在0-6之间，实际为%d", day)
+			}
+			hours.Days = append(hours.Days, time.Weekday(day))
+		}
+	}
+	var err error
+	if start != "" {
+		if hours.Start, err = parseClock(start); err != nil {
+			return hours, err
+		}
+	}
+	if end != "" {
+		if hours.End, err = parseClock(end); err != nil {
+			return hours, err
+		}
+	}
+	if hours.Start >= hours.End {
+		return hours, fmt.Errorf("上班时间 %s 应早于下班时间 %s", start, end)
+	}
+	return hours, nil
+}
+
+// parseClock 将HH:MM解析为距零点的时长
+func parseClock(value string) (time.Duration, error) {
+	clock, err := time.Parse(clockLayout, value)
+	if err != nil {
+		return 0, fmt.Errorf("时间格式应为HH:MM: %s", value)
+	}
+	return time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute, nil
+}
+
+// Calendar 工作日历，创建后只读，可并发使用
+// 为nil的日历表示不区分工作时间，所有计算按自然时间进行
+type Calendar struct {
+	workdays [7]bool
+	start    time.Duration
+	end      time.Duration
+	holidays map[string]bool
+}
+
+// New 按工作时间和节假日创建日历，节假日只取日期部分
+func New(hours WorkingHours, holidays []time.Time) *Calendar {
+	c := &Calendar{
+		start:    hours.Start,
+		end:      hours.End,
+		holidays: make(map[string]bool, len(holidays)),
+	}
+	for _, day := range hours.Days {
+		c.workdays[day] = true
+	}
+	for _, holiday := range holidays {
+		c.holidays[holiday.Format(DateLayout)] = true
+	}
+	return c
+}
+
+// valid 判断日历能否找到工作时段，没有工作日或上下班时间无效时按自然时间计算
+func (c *Calendar) valid() bool {
+	if c == nil || c.start >= c.end {
+		return false
+	}
+	for _, workday := range c.workdays {
+		if workday {
+			return true
+		}
+	}
+	return false
+}
+
+// IsHoliday 判断t所在日期是否为节假日
+func (c *Calendar) IsHoliday(t time.Time) bool {
+	return c != nil && c.holidays[t.Format(DateLayout)]
+}
+
+// IsWorkingDay 判断t所在日期是否为工作日且不是节假日
+func (c *Calendar) IsWorkingDay(t time.Time) bool {
+	if !c.valid() {
+		return true
+	}
+	return c.workdays[t.Weekday()] && !c.IsHoliday(t)
+}
+
+// IsWorkingTime 判断t是否在工作日的上下班时间内
+func (c *Calendar) IsWorkingTime(t time.Time) bool {
+	if !c.valid() {
+		return true
+	}
+	if !c.IsWorkingDay(t) {
+		return false
+	}
+	offset := t.Sub(midnight(t))
+	return offset >= c.start && offset < c.end
+}
+
+// AddWorkingDuration 返回从start开始经过d的工作时间后的时刻，非工作时间不计时
+// 例如周五17:00加2小时，在下班时间为18:00时结束于下周一上班后1小时
+func (c *Calendar) AddWorkingDuration(start time.Time, d time.Duration) time.Time {
+	if d <= 0 || !c.valid() {
+		return start.Add(d)
+	}
+
+	t := start
+	remaining := d
+	for i := 0; i < maxSearchDays; i++ {
+		day := midnight(t)
+		if c.IsWorkingDay(t) {
+			if offset := t.Sub(day); offset < c.start {
+				t = day.Add(c.start)
+			}
+			available := day.Add(c.end).Sub(t)
+			if available > 0 {
+				if remaining <= available {
+					return t.Add(remaining)
+				}
+				remaining -= available
+			}
+		}
+		t = day.AddDate(0, 0, 1)
+	}
+	return start.Add(d)
+}
+
+// AddWorkingDays 返回start之后第days个工作日的日期（时刻与start相同），start当天不计入
+func (c *Calendar) AddWorkingDays(start time.Time, days int) time.Time {
+	if days <= 0 || !c.valid() {
+		return start.AddDate(0, 0, days)
+	}
+
+	t := start
+	for i := 0; i < maxSearchDays && days > 0; i++ {
+		t = t.AddDate(0, 0, 1)
+		if c.IsWorkingDay(t) {
+			days--
+		}
+	}
+	return t
+}
+
+// midnight 返回t所在日期的零点
+func midnight(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
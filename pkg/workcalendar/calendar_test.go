@@ -0,0 +1,86 @@
+package workcalendar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// at 返回2026年3月指定日期和时刻，2026-03-06为周五
+func at(day, hour, minute int) time.Time {
+	return time.Date(2026, 3, day, hour, minute, 0, 0, time.Local)
+}
+
+func TestAddWorkingDuration_SpansWeekend(t *testing.T) {
+	calendar := New(DefaultWorkingHours(), nil)
+
+	// 周五傍晚开始的24个工作小时不计周末：周五1小时、周一和周二各9小时，周三14:00到期
+	assert.Equal(t, at(11, 14, 0), calendar.AddWorkingDuration(at(6, 17, 0), 24*time.Hour))
+	// 周五17:00加2小时，跨过周末后在周一10:00到期
+	assert.Equal(t, at(9, 10, 0), calendar.AddWorkingDuration(at(6, 17, 0), 2*time.Hour))
+	// 恰好在下班时刻结束时不顺延
+	assert.Equal(t, at(6, 18, 0), calendar.AddWorkingDuration(at(6, 17, 0), time.Hour))
+	// 周六下班后开始从周一上班开始计时
+	assert.Equal(t, at(9, 13, 0), calendar.AddWorkingDuration(at(7, 20, 0), 4*time.Hour))
+	// 工作日上班前开始从当天上班开始计时
+	assert.Equal(t, at(9, 10, 30), calendar.AddWorkingDuration(at(9, 6, 0), 90*time.Minute))
+}
+
+func TestAddWorkingDuration_SkipsHoliday(t *testing.T) {
+	// 周一为节假日
+	calendar := New(DefaultWorkingHours(), []time.Time{at(9, 0, 0)})
+
+	assert.True(t, calendar.IsHoliday(at(9, 15, 0)))
+	assert.False(t, calendar.IsWorkingDay(at(9, 15, 0)))
+	assert.Equal(t, at(10, 10, 0), calendar.AddWorkingDuration(at(6, 17, 0), 2*time.Hour))
+	assert.Equal(t, at(12, 14, 0), calendar.AddWorkingDuration(at(6, 17, 0), 24*time.Hour))
+}
+
+func TestAddWorkingDays(t *testing.T) {
+	calendar := New(DefaultWorkingHours(), []time.Time{at(11, 0, 0)})
+
+	// 周五之后的第1个工作日是周一，第3个工作日跳过周三的节假日到周四
+	assert.Equal(t, at(9, 0, 0), calendar.AddWorkingDays(at(6, 0, 0), 1))
+	assert.Equal(t, at(12, 0, 0), calendar.AddWorkingDays(at(6, 0, 0), 3))
+	assert.Equal(t, at(6, 0, 0), calendar.AddWorkingDays(at(6, 0, 0), 0))
+}
+
+func TestIsWorkingTime(t *testing.T) {
+	calendar := New(DefaultWorkingHours(), []time.Time{at(9, 0, 0)})
+
+	assert.True(t, calendar.IsWorkingTime(at(6, 9, 0)))
+	assert.False(t, calendar.IsWorkingTime(at(6, 18, 0)))
+	assert.False(t, calendar.IsWorkingTime(at(6, 8, 59)))
+	assert.False(t, calendar.IsWorkingTime(at(7, 10, 0)))
+	assert.False(t, calendar.IsWorkingTime(at(9, 10, 0)))
+	assert.True(t, calendar.IsWorkingTime(at(10, 10, 0)))
+}
+
+func TestNilCalendar_UsesCalendarTime(t *testing.T) {
+	var calendar *Calendar
+
+	assert.True(t, calendar.IsWorkingTime(at(7, 3, 0)))
+	assert.Equal(t, at(7, 17, 0), calendar.AddWorkingDuration(at(6, 17, 0), 24*time.Hour))
+	assert.Equal(t, at(16, 0, 0), calendar.AddWorkingDays(at(6, 0, 0), 10))
+}
+
+func TestParseWorkingHours(t *testing.T) {
+	hours, err := ParseWorkingHours([]int{1, 2, 3, 4, 5, 6}, "08:30", "17:30")
+	require.NoError(t, err)
+	assert.Len(t, hours.Days, 6)
+	assert.Equal(t, 8*time.Hour+30*time.Minute, hours.Start)
+	assert.Equal(t, 17*time.Hour+30*time.Minute, hours.End)
+
+	// 单休时周六下午的2小时在当天结束
+	calendar := New(hours, nil)
+	assert.Equal(t, at(7, 17, 0), calendar.AddWorkingDuration(at(7, 15, 0), 2*time.Hour))
+
+	_, err = ParseWorkingHours(nil, "18:00", "09:00")
+	assert.Error(t, err)
+	_, err = ParseWorkingHours([]int{7}, "", "")
+	assert.Error(t, err)
+	_, err = ParseWorkingHours(nil, "9am", "")
+	assert.Error(t, err)
+}